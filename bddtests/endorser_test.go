@@ -26,6 +26,7 @@ import (
 	"github.com/DATA-DOG/godog"
 	"github.com/DATA-DOG/godog/gherkin"
 	"github.com/hyperledger/fabric/core/util"
+	"github.com/hyperledger/fabric/peer/common"
 	pb "github.com/hyperledger/fabric/protos"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
@@ -93,12 +94,19 @@ func (b *BDDContext) userCreatesADeploymentProposalUsingChaincodeDeploymentSpec(
 	if ccDeploymentSpec, err = userRegistration.GetChaincodeDeploymentSpec(ccDeploymentSpecAlias); err != nil {
 		return errRetFunc()
 	}
+	var identity *common.Identity
+	if identity, err = common.GetIdentity(); err != nil {
+		return errRetFunc()
+	}
 	var proposal *pb.Proposal
-	// TODO: how should we get a cert from the command line?
-	if proposal, err = createProposalForChaincode(ccDeploymentSpec, []byte("cert")); err != nil {
-
+	if proposal, err = createProposalForChaincode(ccDeploymentSpec, identity.Cert); err != nil {
+		return errRetFunc()
 	}
-	if _, err = userRegistration.SetTagValue(proposalAlias, proposal); err != nil {
+	var signedProposal *pb.SignedProposal
+	if signedProposal, err = identity.Sign(proposal); err != nil {
+		return errRetFunc()
+	}
+	if _, err = userRegistration.SetTagValue(proposalAlias, signedProposal); err != nil {
 		return errRetFunc()
 	}
 	return nil
@@ -204,7 +212,7 @@ func (b *BDDContext) getGrpcClientForComposeService(composeService string) (grpc
 }
 
 func (b *BDDContext) userSendsProposalToEndorsersWithTimeoutOfSeconds(enrollID, proposalAlias, timeoutInSecs string, endorsersTable *gherkin.DataTable) (err error) {
-	var proposal *pb.Proposal
+	var proposal *pb.SignedProposal
 	var keyedProposalResponsesMap KeyedProposalResponseMap
 	keyedProposalResponsesMap = make(KeyedProposalResponseMap)
 	errRetFunc := func() error {
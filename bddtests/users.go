@@ -69,8 +69,8 @@ func (u *UserRegistration) GetChaincodeSpec(tagName string) (ccSpec *pb.Chaincod
 	return ccSpec, err
 }
 
-// GetProposal returns a Proposal for the supplied tag name (uses type assertion).  Fails if not of expected type.
-func (u *UserRegistration) GetProposal(tagName string) (result *pb.Proposal, err error) {
+// GetProposal returns a SignedProposal for the supplied tag name (uses type assertion).  Fails if not of expected type.
+func (u *UserRegistration) GetProposal(tagName string) (result *pb.SignedProposal, err error) {
 	errRetFunc := func() error {
 		return fmt.Errorf("Error getting Proposal '%s' for User '%s': '%s'", tagName, u.enrollID, err)
 	}
@@ -79,8 +79,8 @@ func (u *UserRegistration) GetProposal(tagName string) (result *pb.Proposal, err
 	if value, err = u.GetTagValue(tagName); err != nil {
 		return nil, errRetFunc()
 	}
-	// Now assert value is pointer to Proposal
-	if result, ok = value.(*pb.Proposal); ok != true {
+	// Now assert value is pointer to SignedProposal
+	if result, ok = value.(*pb.SignedProposal); ok != true {
 		err = fmt.Errorf("Unexpected type found for tag '%s', found '%v'", tagName, value)
 		return nil, errRetFunc()
 	}
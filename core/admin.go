@@ -17,14 +17,31 @@ limitations under the License.
 package core
 
 import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
 	"os"
 	"runtime"
+	"strings"
+	"syscall"
 
 	"github.com/op/go-logging"
 	"github.com/spf13/viper"
 	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/hyperledger/fabric/core/audit"
+	"github.com/hyperledger/fabric/core/chaincode"
+	"github.com/hyperledger/fabric/core/comm"
+	"github.com/hyperledger/fabric/core/ledger/kvledger"
+	"github.com/hyperledger/fabric/core/system_chaincode/cscc"
 	"github.com/hyperledger/fabric/flogging"
 	pb "github.com/hyperledger/fabric/protos"
 )
@@ -41,6 +58,68 @@ func NewAdminServer() *ServerAdmin {
 type ServerAdmin struct {
 }
 
+// isAdminIdentity tells whether the identity read from a file of
+// "peer.adminClients" is the certificate cert, PEM encoded or not
+func isAdminIdentity(identity []byte, cert *x509.Certificate) bool {
+	if block, _ := pem.Decode(identity); block != nil {
+		identity = block.Bytes
+	}
+	return bytes.Equal(bytes.TrimSpace(identity), cert.Raw)
+}
+
+// checkAdmin checks that the client of a request is an administrator of the
+// peer, authenticated by a TLS client certificate listed in
+// "peer.adminClients". No one is permitted when the list is empty
+func checkAdmin(ctx context.Context) error {
+	admins := viper.GetStringSlice("peer.adminClients")
+	if len(admins) == 0 {
+		return grpc.Errorf(codes.PermissionDenied, "no administrator listed in peer.adminClients")
+	}
+
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return grpc.Errorf(codes.Unauthenticated, "the admin service requires a client certificate")
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.PeerCertificates) == 0 {
+		return grpc.Errorf(codes.Unauthenticated, "the admin service requires a client certificate")
+	}
+	cert := tlsInfo.State.PeerCertificates[0]
+	for _, file := range admins {
+		identity, err := ioutil.ReadFile(file)
+		if err != nil {
+			log.Warningf("Could not read identity %s listed in peer.adminClients: %s", file, err)
+			continue
+		}
+		if isAdminIdentity(identity, cert) {
+			return nil
+		}
+	}
+	log.Warningf("Rejected client %s of the admin service, %s is not an administrator", p.Addr, cert.Subject.CommonName)
+	return grpc.Errorf(codes.PermissionDenied, "client is not an administrator of the peer")
+}
+
+// AdminServerInterceptor is the interceptor of a gRPC server permitting the
+// requests of the Admin service to the administrators of the peer only, see
+// checkAdmin, and recording them to the audit log, rejected or not. The
+// requests of the other services are not affected
+func AdminServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if !strings.HasPrefix(info.FullMethod, "/protos.Admin/") {
+		return handler(ctx, req)
+	}
+	var resp interface{}
+	err := checkAdmin(ctx)
+	if err == nil {
+		resp, err = handler(ctx, req)
+	}
+	details := ""
+	if msg, ok := req.(proto.Message); ok {
+		details = proto.CompactTextString(msg)
+	}
+	audit.Record(audit.ClientIdentity(ctx), "admin."+strings.TrimPrefix(info.FullMethod, "/protos.Admin/"), details, err)
+	return resp, err
+}
+
 func worker(id int, die chan struct{}) {
 	for {
 		select {
@@ -68,7 +147,8 @@ func (*ServerAdmin) StartServer(context.Context, *empty.Empty) (*pb.ServerStatus
 	return status, nil
 }
 
-// StopServer stops the server
+// StopServer stops the server gracefully, as on SIGTERM: the peer stops accepting proposals, completes
+// those in flight and the block being committed, stops the chaincodes and exits
 func (*ServerAdmin) StopServer(context.Context, *empty.Empty) (*pb.ServerStatus, error) {
 	status := &pb.ServerStatus{Status: pb.ServerStatus_STOPPED}
 	log.Debugf("returning status: %s", status)
@@ -76,7 +156,9 @@ func (*ServerAdmin) StopServer(context.Context, *empty.Empty) (*pb.ServerStatus,
 	pidFile := viper.GetString("peer.fileSystemPath") + "/peer.pid"
 	log.Debugf("Remove pid file  %s", pidFile)
 	os.Remove(pidFile)
-	defer os.Exit(0)
+	if err := syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+		return nil, fmt.Errorf("Error stopping the server: %s", err)
+	}
 	return status, nil
 }
 
@@ -95,3 +177,78 @@ func (*ServerAdmin) SetModuleLogLevel(ctx context.Context, request *pb.LogLevelR
 
 	return logResponse, err
 }
+
+// SetChaincodeLogLevel sets the logging level of a module of a running chaincode, propagated over the
+// stream of the chaincode so that the chaincode does not need to be redeployed
+func (*ServerAdmin) SetChaincodeLogLevel(ctx context.Context, request *pb.ChaincodeLogLevelRequest) (*pb.LogLevelResponse, error) {
+	chain := chaincode.GetChain(chaincode.DefaultChain)
+	if chain == nil {
+		return nil, fmt.Errorf("Chaincode support is not initialized")
+	}
+	if err := chain.SetLogLevel(request.ChaincodeName, request.LogModule, request.LogLevel); err != nil {
+		return nil, err
+	}
+	log.Infof("Set the log level of module [%s] of chaincode %s to %s", request.LogModule, request.ChaincodeName, request.LogLevel)
+	return &pb.LogLevelResponse{LogModule: request.LogModule, LogLevel: strings.ToUpper(request.LogLevel)}, nil
+}
+
+// ReloadTLS reloads the TLS certificates, keys and root CAs of the peer from their files, so that the
+// certificates are rotated without restarting the peer. The connections already established are kept
+func (*ServerAdmin) ReloadTLS(ctx context.Context, _ *empty.Empty) (*empty.Empty, error) {
+	if err := comm.ReloadTLS(); err != nil {
+		return nil, err
+	}
+	return &empty.Empty{}, nil
+}
+
+// ListChannels returns the chains the peer has joined
+func (*ServerAdmin) ListChannels(context.Context, *empty.Empty) (*pb.ChannelQueryResponse, error) {
+	return cscc.Channels()
+}
+
+// CompactLedger compacts the state and index databases of the ledger of a chain, or of all the ledgers
+// if no chain is given, and reports the sizes of the databases before and after the compaction
+func (*ServerAdmin) CompactLedger(ctx context.Context, request *pb.CompactLedgerRequest) (*pb.CompactLedgerResponse, error) {
+	names := []string{request.ChainID}
+	if request.ChainID == "" {
+		var err error
+		if names, err = kvledger.GetLedgerNames(); err != nil {
+			return nil, err
+		}
+	} else if err := checkLedger(request.ChainID); err != nil {
+		return nil, err
+	}
+	response := &pb.CompactLedgerResponse{}
+	for _, name := range names {
+		compaction, err := kvledger.GetLedger(name).Compact()
+		if err != nil {
+			return nil, fmt.Errorf("Error compacting the ledger of chain %s: %s", name, err)
+		}
+		compaction.ChainID = name
+		log.Infof("Compacted the ledger of chain %s, state database from %d to %d bytes, index database from %d to %d bytes",
+			name, compaction.StateSizeBefore, compaction.StateSizeAfter, compaction.IndexSizeBefore, compaction.IndexSizeAfter)
+		response.Compactions = append(response.Compactions, compaction)
+	}
+	return response, nil
+}
+
+// GetBlockLocalMetadata returns the local metadata of a block of a chain, which holds the state hash
+// used to compare the state of the ledger across peers
+func (*ServerAdmin) GetBlockLocalMetadata(ctx context.Context, request *pb.BlockLocalMetadataRequest) (*pb.BlockLocalMetadata, error) {
+	if err := checkLedger(request.ChainID); err != nil {
+		return nil, err
+	}
+	return kvledger.GetLedger(request.ChainID).GetBlockLocalMetadata(request.BlockNumber)
+}
+
+// checkLedger checks that the peer has a ledger for the chain
+func checkLedger(chainID string) error {
+	exists, err := kvledger.LedgerExists(chainID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("Chain %s not found", chainID)
+	}
+	return nil
+}
@@ -16,9 +16,101 @@ limitations under the License.
 
 package core
 
-import "testing"
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
 
 func TestServer_Status(t *testing.T) {
 	t.Skip("TBD")
 	//performHandshake(t, peerClientConn)
 }
+
+func selfSignedCert(t *testing.T, name string) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %s", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %s", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %s", err)
+	}
+	return cert
+}
+
+func clientContext(cert *x509.Certificate) context.Context {
+	state := tls.ConnectionState{}
+	if cert != nil {
+		state.PeerCertificates = []*x509.Certificate{cert}
+	}
+	return peer.NewContext(context.Background(), &peer.Peer{
+		Addr:     &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 7051},
+		AuthInfo: credentials.TLSInfo{State: state},
+	})
+}
+
+func TestAdminServerInterceptor(t *testing.T) {
+	dir, err := ioutil.TempDir("", "admins")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	admin := selfSignedCert(t, "admin")
+	file := filepath.Join(dir, "admin.pem")
+	if err = ioutil.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: admin.Raw}), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %s", file, err)
+	}
+
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+	call := func(ctx context.Context, method string) error {
+		_, err := AdminServerInterceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		return err
+	}
+
+	// No one is permitted without administrators
+	assert.Equal(t, codes.PermissionDenied, grpc.Code(call(clientContext(admin), "/protos.Admin/GetStatus")))
+
+	// The identities of the proposals are not administrators of the service
+	viper.Set("peer.admins", []string{file})
+	defer viper.Set("peer.admins", []string{})
+	assert.Equal(t, codes.PermissionDenied, grpc.Code(call(clientContext(admin), "/protos.Admin/GetStatus")))
+
+	viper.Set("peer.adminClients", []string{file})
+	defer viper.Set("peer.adminClients", []string{})
+	assert.NoError(t, call(clientContext(admin), "/protos.Admin/ListChannels"))
+	assert.Equal(t, codes.PermissionDenied, grpc.Code(call(clientContext(selfSignedCert(t, "other")), "/protos.Admin/SetModuleLogLevel")))
+	assert.Equal(t, codes.Unauthenticated, grpc.Code(call(clientContext(nil), "/protos.Admin/GetStatus")))
+	assert.Equal(t, codes.Unauthenticated, grpc.Code(call(context.Background(), "/protos.Admin/GetStatus")))
+	// The other services are not affected
+	assert.NoError(t, call(context.Background(), "/protos.Endorser/ProcessProposal"))
+}
@@ -0,0 +1,287 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/op/go-logging"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+var logger = logging.MustGetLogger("audit")
+
+// Entry is a record of the audit log: an administrative or lifecycle
+// operation, the identity that requested it and its outcome. The entries
+// are chained: Hash covers the fields of the entry and Prev, the Hash of
+// the previous entry, so that a modified, inserted or removed entry breaks
+// the chain. Hash is an HMAC-SHA256 when the log has a key, so that only the
+// holders of the key can rebuild the chain, and a plain SHA-256, which anyone
+// able to write the file can recompute, otherwise
+type Entry struct {
+	Seq       uint64    `json:"seq"`
+	Time      time.Time `json:"time"`
+	Identity  string    `json:"identity"`
+	Operation string    `json:"operation"`
+	Details   string    `json:"details,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	Prev      string    `json:"prev"`
+	Hash      string    `json:"hash"`
+}
+
+// computeHash returns the hash of the entry, of its fields but Hash, keyed
+// with key when not empty
+func (e Entry) computeHash(key []byte) string {
+	e.Hash = ""
+	b, _ := json.Marshal(e)
+	if len(key) == 0 {
+		sum := sha256.Sum256(b)
+		return hex.EncodeToString(sum[:])
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(b)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Log is an audit log appended to a file, as JSON lines
+type Log struct {
+	lock sync.Mutex
+	file *os.File
+	key  []byte
+	seq  uint64
+	last string
+}
+
+// Open opens the audit log of path, created if it does not exist, whose
+// entries are hashed with key, if not empty. The entries already in the
+// file are verified, an error is returned if their chain is broken. An
+// incomplete last entry, left by a crash while it was appended, is removed
+func Open(path string, key []byte) (*Log, error) {
+	entries, last, size, err := verify(path, key)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if info, err := os.Stat(path); err == nil && info.Size() > size {
+		logger.Warningf("Removing the incomplete last entry of audit log %s", path)
+		if err = os.Truncate(path, size); err != nil {
+			return nil, fmt.Errorf("Failed to truncate audit log %s: %s", path, err)
+		}
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open audit log %s: %s", path, err)
+	}
+	return &Log{file: f, key: key, seq: entries, last: last}, nil
+}
+
+// Append records an operation requested by identity, failed with err when
+// not nil
+func (l *Log) Append(identity, operation, details string, err error) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	e := Entry{
+		Seq:       l.seq + 1,
+		Time:      time.Now().UTC(),
+		Identity:  identity,
+		Operation: operation,
+		Details:   details,
+		Prev:      l.last,
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	e.Hash = e.computeHash(l.key)
+	b, _ := json.Marshal(e)
+	if _, err := l.file.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf("Failed to write audit log: %s", err)
+	}
+	if err := l.file.Sync(); err != nil {
+		return fmt.Errorf("Failed to sync audit log: %s", err)
+	}
+	l.seq, l.last = e.Seq, e.Hash
+	return nil
+}
+
+// Close closes the file of the log
+func (l *Log) Close() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.file.Close()
+}
+
+// verify checks the chain of the entries of the audit log of path, and
+// returns their number, the hash of the last one and the size of the file
+// they take. A last line without end of line that isn't a valid entry is an
+// entry whose append was interrupted, which is logged and not counted
+func verify(path string, key []byte) (uint64, string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, "", 0, err
+	}
+	defer f.Close()
+
+	var seq uint64
+	var size int64
+	last := ""
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF && len(line) == 0 {
+			return seq, last, size, nil
+		} else if err != nil && err != io.EOF {
+			return 0, "", 0, fmt.Errorf("Failed to read audit log %s: %s", path, err)
+		}
+		torn := err == io.EOF
+		e := Entry{}
+		if err := json.Unmarshal(line, &e); err != nil {
+			if torn {
+				logger.Warningf("Entry %d of audit log %s is incomplete", seq+1, path)
+				return seq, last, size, nil
+			}
+			return 0, "", 0, fmt.Errorf("Invalid entry %d of audit log %s: %s", seq+1, path, err)
+		}
+		if e.Seq != seq+1 || e.Prev != last || e.Hash != e.computeHash(key) {
+			return 0, "", 0, fmt.Errorf("Audit log %s is broken at entry %d", path, seq+1)
+		}
+		seq, last = e.Seq, e.Hash
+		size += int64(len(line))
+	}
+}
+
+// Verify checks that the entries of the audit log of path, hashed with key
+// if not empty, were not modified, inserted or removed, and returns their
+// number. The removal of the last entries is detected only by comparing
+// their number with a previous one
+func Verify(path string, key []byte) (uint64, error) {
+	entries, _, _, err := verify(path, key)
+	return entries, err
+}
+
+// ReadKey returns the key of the audit log read from keyFile, or nil if
+// keyFile is empty
+func ReadKey(keyFile string) ([]byte, error) {
+	if keyFile == "" {
+		return nil, nil
+	}
+	key, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the key of the audit log: %s", err)
+	}
+	if len(key) == 0 {
+		return nil, fmt.Errorf("The key of the audit log %s is empty", keyFile)
+	}
+	return key, nil
+}
+
+var (
+	auditLog     *Log
+	auditLogLock sync.RWMutex
+)
+
+// Init records the audit log to the file of path, its entries hashed with
+// the key read from keyFile if not empty, or disables the file when path is
+// empty. The operations are logged by the audit module either way
+func Init(path string, keyFile string) error {
+	auditLogLock.Lock()
+	defer auditLogLock.Unlock()
+	if auditLog != nil {
+		auditLog.Close()
+		auditLog = nil
+	}
+	if path == "" {
+		return nil
+	}
+	key, err := ReadKey(keyFile)
+	if err != nil {
+		return err
+	}
+	if key == nil {
+		logger.Warning("The audit log has no key, its hashes can be recomputed by anyone able to write it")
+	}
+	l, err := Open(path, key)
+	if err != nil {
+		return err
+	}
+	auditLog = l
+	logger.Infof("Recording the audit log to %s", path)
+	return nil
+}
+
+// Record records an operation requested by identity, failed with err when
+// not nil, to the audit log
+func Record(identity, operation, details string, err error) {
+	if err != nil {
+		logger.Warningf("%s %s by %s failed: %s", operation, details, identity, err)
+	} else {
+		logger.Infof("%s %s by %s", operation, details, identity)
+	}
+
+	auditLogLock.RLock()
+	defer auditLogLock.RUnlock()
+	if auditLog == nil {
+		return
+	}
+	if err := auditLog.Append(identity, operation, details, err); err != nil {
+		logger.Errorf("Failed to record %s by %s: %s", operation, identity, err)
+	}
+}
+
+// Identity returns the identity recorded for a creator or a certificate, PEM
+// encoded or not: the common name of the certificate and the SHA-256 hash of
+// the identity
+func Identity(creator []byte) string {
+	if len(creator) == 0 {
+		return "anonymous"
+	}
+	der := creator
+	if block, _ := pem.Decode(creator); block != nil {
+		der = block.Bytes
+	}
+	sum := sha256.Sum256(der)
+	if cert, err := x509.ParseCertificate(der); err == nil {
+		return fmt.Sprintf("%s [%x]", cert.Subject.CommonName, sum)
+	}
+	return fmt.Sprintf("[%x]", sum)
+}
+
+// ClientIdentity returns the identity recorded for the client of a gRPC
+// request, authenticated by its TLS client certificate or else known by its
+// address
+func ClientIdentity(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "anonymous"
+	}
+	if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(tlsInfo.State.PeerCertificates) > 0 {
+		return Identity(tlsInfo.State.PeerCertificates[0].Raw)
+	}
+	return fmt.Sprintf("anonymous@%s", p.Addr)
+}
@@ -0,0 +1,171 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func tempLog(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %s", err)
+	}
+	return filepath.Join(dir, "audit.log"), func() { os.RemoveAll(dir) }
+}
+
+func TestLog(t *testing.T) {
+	path, cleanup := tempLog(t)
+	defer cleanup()
+
+	l, err := Open(path, nil)
+	if err != nil {
+		t.Fatalf("Failed to open the audit log: %s", err)
+	}
+	assert.NoError(t, l.Append("admin", "channel.join", "mychain", nil))
+	assert.NoError(t, l.Append("admin", "chaincode.install", "mycc:1.0", errors.New("already installed")))
+	assert.NoError(t, l.Close())
+
+	// The chain goes on when the log is reopened
+	l, err = Open(path, nil)
+	if err != nil {
+		t.Fatalf("Failed to reopen the audit log: %s", err)
+	}
+	assert.NoError(t, l.Append("admin", "chaincode.instantiate", "mychain/mycc:1.0", nil))
+	assert.NoError(t, l.Close())
+
+	entries, err := Verify(path, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), entries)
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read the audit log: %s", err)
+	}
+	lines := bytes.SplitAfter(content, []byte("\n"))
+
+	// A modified entry breaks the chain
+	modified := bytes.Replace(content, []byte("mycc:1.0"), []byte("mycc:2.0"), 1)
+	assert.NoError(t, ioutil.WriteFile(path, modified, 0600))
+	_, err = Verify(path, nil)
+	assert.Error(t, err)
+	_, err = Open(path, nil)
+	assert.Error(t, err)
+
+	// So does a removed one
+	assert.NoError(t, ioutil.WriteFile(path, bytes.Join([][]byte{lines[0], lines[2]}, nil), 0600))
+	_, err = Verify(path, nil)
+	assert.Error(t, err)
+
+	// An incomplete last entry is removed when the log is opened
+	torn := append(bytes.Join(lines[:2], nil), lines[2][:len(lines[2])/2]...)
+	assert.NoError(t, ioutil.WriteFile(path, torn, 0600))
+	entries, err = Verify(path, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), entries)
+	l, err = Open(path, nil)
+	if err != nil {
+		t.Fatalf("Failed to reopen the audit log: %s", err)
+	}
+	assert.NoError(t, l.Append("admin", "chaincode.instantiate", "mychain/mycc:1.0", nil))
+	assert.NoError(t, l.Close())
+	entries, err = Verify(path, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), entries)
+}
+
+func TestKeyedLog(t *testing.T) {
+	path, cleanup := tempLog(t)
+	defer cleanup()
+
+	l, err := Open(path, []byte("key"))
+	if err != nil {
+		t.Fatalf("Failed to open the audit log: %s", err)
+	}
+	assert.NoError(t, l.Append("admin", "channel.join", "mychain", nil))
+	assert.NoError(t, l.Close())
+
+	entries, err := Verify(path, []byte("key"))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), entries)
+
+	// The chain can't be verified, nor rebuilt, without the key
+	_, err = Verify(path, nil)
+	assert.Error(t, err)
+	_, err = Verify(path, []byte("other"))
+	assert.Error(t, err)
+	_, err = Open(path, nil)
+	assert.Error(t, err)
+}
+
+func TestRecord(t *testing.T) {
+	path, cleanup := tempLog(t)
+	defer cleanup()
+
+	// Without a file the operations are only logged
+	Record("admin", "admin.GetStatus", "", nil)
+
+	assert.NoError(t, Init(path, ""))
+	defer Init("", "")
+	Record("admin", "admin.SetModuleLogLevel", "logModule:\"peer\" logLevel:\"debug\" ", nil)
+	Record("other", "admin.StopServer", "", errors.New("permission denied"))
+
+	entries, err := Verify(path, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), entries)
+	content, _ := ioutil.ReadFile(path)
+	assert.True(t, strings.Contains(string(content), `"error":"permission denied"`))
+}
+
+func TestIdentity(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %s", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "admin"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %s", err)
+	}
+
+	identity := Identity(der)
+	assert.True(t, strings.HasPrefix(identity, "admin ["))
+	assert.Equal(t, identity, Identity(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})))
+	assert.True(t, strings.HasPrefix(Identity([]byte("creator")), "["))
+	assert.Equal(t, "anonymous", Identity(nil))
+}
@@ -0,0 +1,46 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package acl
+
+import (
+	"bytes"
+	"io/ioutil"
+
+	"github.com/op/go-logging"
+)
+
+var logger = logging.MustGetLogger("acl")
+
+// IsListed checks whether one of the files holds the creator, key naming the
+// configuration listing the files. Files that cannot be read are skipped and
+// an empty creator is never listed
+func IsListed(files []string, key string, creator []byte) bool {
+	if len(creator) == 0 {
+		return false
+	}
+	for _, file := range files {
+		identity, err := ioutil.ReadFile(file)
+		if err != nil {
+			logger.Warningf("Could not read identity %s listed in %s: %s", file, key, err)
+			continue
+		}
+		if bytes.Equal(bytes.TrimSpace(identity), creator) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package acl
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsListed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "acltest")
+	if err != nil {
+		t.Fatalf("Error creating temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	admin := filepath.Join(dir, "admin.pem")
+	empty := filepath.Join(dir, "empty.pem")
+	ioutil.WriteFile(admin, []byte("admin\n"), 0644)
+	ioutil.WriteFile(empty, nil, 0644)
+	files := []string{filepath.Join(dir, "missing.pem"), empty, admin}
+
+	if !IsListed(files, "peer.admins", []byte("admin")) {
+		t.Fatalf("Expected the admin to be listed")
+	}
+	if IsListed(files, "peer.admins", []byte("other")) {
+		t.Fatalf("Expected another identity not to be listed")
+	}
+	if IsListed(files, "peer.admins", nil) {
+		t.Fatalf("Expected an empty creator not to be listed")
+	}
+}
@@ -0,0 +1,260 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ccpackage
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+
+	"github.com/golang/protobuf/proto"
+	pb "github.com/hyperledger/fabric/protos"
+)
+
+// A package archive is a gzipped tar holding the metadata describing the
+// chaincode, its code package and the signatures of its owners. The
+// deployment spec signed by the owners is rebuilt from the metadata and the
+// code, so an archive can be inspected and distributed out of band before
+// it is installed.
+const (
+	// MetadataFile is the JSON encoded Metadata of the archive
+	MetadataFile = "metadata.json"
+
+	// CodeFile is the code package of the chaincode
+	CodeFile = "code.tar.gz"
+
+	// OwnersFile is the JSON encoded list of the signatures of the owners
+	OwnersFile = "owners.json"
+)
+
+var labelRegexp = regexp.MustCompile(`^[[:alnum:]][[:alnum:]_.+-]*$`)
+
+// Metadata describes the chaincode of a package archive
+type Metadata struct {
+	Type    string `json:"type"`
+	Label   string `json:"label"`
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Path    string `json:"path"`
+
+	// identities permitted to instantiate or upgrade the chaincode,
+	// everyone if there is none
+	InstantiationPolicy [][]byte `json:"instantiationPolicy,omitempty"`
+
+	ResourceLimits *pb.ChaincodeResourceLimits `json:"resourceLimits,omitempty"`
+}
+
+type ownerEndorsement struct {
+	Endorser  string `json:"endorser"`
+	Signature []byte `json:"signature"`
+}
+
+// NewPackageSpec returns the deployment spec of a package, keeping only
+// what the metadata of an archive describes
+func NewPackageSpec(cds *pb.ChaincodeDeploymentSpec) *pb.ChaincodeDeploymentSpec {
+	spec := &pb.ChaincodeSpec{Type: cds.ChaincodeSpec.Type, ResourceLimits: cds.ChaincodeSpec.ResourceLimits}
+	if id := cds.ChaincodeSpec.ChaincodeID; id != nil {
+		spec.ChaincodeID = &pb.ChaincodeID{Path: id.Path, Name: id.Name, Version: id.Version}
+	}
+	return &pb.ChaincodeDeploymentSpec{ChaincodeSpec: spec, CodePackage: cds.CodePackage}
+}
+
+// IsArchive tells a package archive from a marshalled package
+func IsArchive(b []byte) bool {
+	return len(b) > 1 && b[0] == 0x1f && b[1] == 0x8b
+}
+
+// GetMetadata returns the metadata describing the package with the given
+// label, which defaults to <name>_<version>
+func GetMetadata(spkg *pb.SignedChaincodeDeploymentSpec, label string) (*Metadata, error) {
+	cds, err := GetDeploymentSpec(spkg)
+	if err != nil {
+		return nil, err
+	}
+	if cds.ChaincodeSpec == nil || cds.ChaincodeSpec.ChaincodeID == nil {
+		return nil, fmt.Errorf("chaincode ID not specified in the package")
+	}
+
+	id := cds.ChaincodeSpec.ChaincodeID
+	if label == "" {
+		label = id.Name + "_" + id.Version
+	}
+	if !labelRegexp.MatchString(label) {
+		return nil, fmt.Errorf("invalid package label %s", label)
+	}
+
+	md := &Metadata{
+		Type:           cds.ChaincodeSpec.Type.String(),
+		Label:          label,
+		Name:           id.Name,
+		Version:        id.Version,
+		Path:           id.Path,
+		ResourceLimits: cds.ChaincodeSpec.ResourceLimits,
+	}
+
+	if len(spkg.InstantiationPolicy) > 0 {
+		policy := &pb.ChaincodeInstantiationPolicy{}
+		if err = proto.Unmarshal(spkg.InstantiationPolicy, policy); err != nil {
+			return nil, fmt.Errorf("invalid instantiation policy: %s", err)
+		}
+		md.InstantiationPolicy = policy.Identities
+	}
+
+	return md, nil
+}
+
+// newArchivedPackage rebuilds the package from the metadata and the code of
+// an archive
+func newArchivedPackage(md *Metadata, code []byte) (*pb.SignedChaincodeDeploymentSpec, error) {
+	typ, ok := pb.ChaincodeSpec_Type_value[md.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown chaincode type %s", md.Type)
+	}
+
+	cds := &pb.ChaincodeDeploymentSpec{
+		ChaincodeSpec: &pb.ChaincodeSpec{
+			Type:           pb.ChaincodeSpec_Type(typ),
+			ChaincodeID:    &pb.ChaincodeID{Path: md.Path, Name: md.Name, Version: md.Version},
+			ResourceLimits: md.ResourceLimits,
+		},
+		CodePackage: code,
+	}
+
+	policy, err := NewInstantiationPolicy(md.InstantiationPolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSignedPackage(cds, policy)
+}
+
+// WriteArchive writes the package as an archive with the given label. The
+// package must hold what the metadata describes only, as created from a spec
+// returned by NewPackageSpec, for the signatures of the owners to hold once
+// the package is rebuilt from the archive
+func WriteArchive(w io.Writer, spkg *pb.SignedChaincodeDeploymentSpec, label string) error {
+	md, err := GetMetadata(spkg, label)
+	if err != nil {
+		return err
+	}
+	cds, err := GetDeploymentSpec(spkg)
+	if err != nil {
+		return err
+	}
+
+	rebuilt, err := newArchivedPackage(md, cds.CodePackage)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(rebuilt.ChaincodeDeploymentSpec, spkg.ChaincodeDeploymentSpec) || !bytes.Equal(rebuilt.InstantiationPolicy, spkg.InstantiationPolicy) {
+		return fmt.Errorf("the package holds more than the metadata of an archive describes")
+	}
+
+	mdBytes, err := json.MarshalIndent(md, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	owners := []*ownerEndorsement{}
+	for _, e := range spkg.OwnerEndorsements {
+		owners = append(owners, &ownerEndorsement{Endorser: string(e.Endorser), Signature: e.Signature})
+	}
+	ownersBytes, err := json.MarshalIndent(owners, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	for _, f := range []struct {
+		name string
+		body []byte
+	}{{MetadataFile, mdBytes}, {CodeFile, cds.CodePackage}, {OwnersFile, ownersBytes}} {
+		//no modification time, archiving the same package gives the same bytes
+		if err = tw.WriteHeader(&tar.Header{Name: f.name, Mode: 0644, Size: int64(len(f.body))}); err != nil {
+			return err
+		}
+		if _, err = tw.Write(f.body); err != nil {
+			return err
+		}
+	}
+	if err = tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+// ReadArchive reads a package archive, returning the package rebuilt from it
+// and its metadata
+func ReadArchive(r io.Reader) (*pb.SignedChaincodeDeploymentSpec, *Metadata, error) {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid package archive: %s", err)
+	}
+	defer gr.Close()
+
+	files := make(map[string][]byte)
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid package archive: %s", err)
+		}
+		if files[hdr.Name], err = ioutil.ReadAll(tr); err != nil {
+			return nil, nil, fmt.Errorf("invalid package archive: %s", err)
+		}
+	}
+
+	for _, name := range []string{MetadataFile, CodeFile} {
+		if _, ok := files[name]; !ok {
+			return nil, nil, fmt.Errorf("invalid package archive: no %s", name)
+		}
+	}
+
+	md := &Metadata{}
+	if err = json.Unmarshal(files[MetadataFile], md); err != nil {
+		return nil, nil, fmt.Errorf("invalid %s: %s", MetadataFile, err)
+	}
+	if !labelRegexp.MatchString(md.Label) {
+		return nil, nil, fmt.Errorf("invalid package label %s", md.Label)
+	}
+
+	spkg, err := newArchivedPackage(md, files[CodeFile])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if b, ok := files[OwnersFile]; ok {
+		var owners []*ownerEndorsement
+		if err = json.Unmarshal(b, &owners); err != nil {
+			return nil, nil, fmt.Errorf("invalid %s: %s", OwnersFile, err)
+		}
+		for _, o := range owners {
+			spkg.OwnerEndorsements = append(spkg.OwnerEndorsements, &pb.ChaincodeOwnerEndorsement{Endorser: []byte(o.Endorser), Signature: o.Signature})
+		}
+	}
+
+	return spkg, md, nil
+}
@@ -0,0 +1,167 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ccpackage
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/core/crypto/primitives"
+	pb "github.com/hyperledger/fabric/protos"
+)
+
+// NewSignedPackage wraps the deployment spec in a package for its owners to
+// sign. The package carries no signature yet
+func NewSignedPackage(cds *pb.ChaincodeDeploymentSpec, instantiationPolicy []byte) (*pb.SignedChaincodeDeploymentSpec, error) {
+	b, err := proto.Marshal(cds)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.SignedChaincodeDeploymentSpec{ChaincodeDeploymentSpec: b, InstantiationPolicy: instantiationPolicy}, nil
+}
+
+// GetDeploymentSpec returns the deployment spec in the package
+func GetDeploymentSpec(spkg *pb.SignedChaincodeDeploymentSpec) (*pb.ChaincodeDeploymentSpec, error) {
+	cds := &pb.ChaincodeDeploymentSpec{}
+	if err := proto.Unmarshal(spkg.ChaincodeDeploymentSpec, cds); err != nil {
+		return nil, fmt.Errorf("invalid deployment spec in package: %s", err)
+	}
+	return cds, nil
+}
+
+// GetPackageHash returns the SHA-256 hash of the code package of the spec.
+// Packaging identical sources gives identical code packages, organizations
+// compare the hash to verify they installed the same code
+func GetPackageHash(cds *pb.ChaincodeDeploymentSpec) []byte {
+	hash := sha256.Sum256(cds.CodePackage)
+	return hash[:]
+}
+
+// signedBytes returns what an owner signs, the deployment spec, the
+// instantiation policy and the certificate of the owner, each prefixed by its
+// length so that moving bytes from one field to the next changes the message
+func signedBytes(spkg *pb.SignedChaincodeDeploymentSpec, endorser []byte) []byte {
+	var msg []byte
+	for _, field := range [][]byte{spkg.ChaincodeDeploymentSpec, spkg.InstantiationPolicy, endorser} {
+		var length [8]byte
+		binary.BigEndian.PutUint64(length[:], uint64(len(field)))
+		msg = append(msg, length[:]...)
+		msg = append(msg, field...)
+	}
+	return msg
+}
+
+// Sign adds the signature of an owner, given its PEM encoded certificate and
+// its private key, to the package
+func Sign(spkg *pb.SignedChaincodeDeploymentSpec, cert []byte, key interface{}) error {
+	x509Cert, err := primitives.PEMtoCertificate(cert)
+	if err != nil {
+		return fmt.Errorf("invalid owner certificate: %s", err)
+	}
+	if err = primitives.CheckCertPKAgainstSK(x509Cert, key); err != nil {
+		return fmt.Errorf("the key does not match the owner certificate: %s", err)
+	}
+
+	signature, err := primitives.ECDSASign(key, signedBytes(spkg, cert))
+	if err != nil {
+		return err
+	}
+
+	spkg.OwnerEndorsements = append(spkg.OwnerEndorsements, &pb.ChaincodeOwnerEndorsement{Endorser: cert, Signature: signature})
+	return nil
+}
+
+// VerifyOwnerEndorsements checks the signatures of the owners on the package
+// and returns the DER encoded certificates of the owners
+func VerifyOwnerEndorsements(spkg *pb.SignedChaincodeDeploymentSpec) ([][]byte, error) {
+	var owners [][]byte
+	for i, e := range spkg.OwnerEndorsements {
+		x509Cert, der, err := primitives.PEMtoCertificateAndDER(e.Endorser)
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate of owner %d: %s", i, err)
+		}
+
+		if _, ok := x509Cert.PublicKey.(*ecdsa.PublicKey); !ok {
+			return nil, fmt.Errorf("certificate of owner %s has no ECDSA key", x509Cert.Subject.CommonName)
+		}
+
+		ok, err := primitives.ECDSAVerify(x509Cert.PublicKey, signedBytes(spkg, e.Endorser), e.Signature)
+		if err != nil || !ok {
+			return nil, fmt.Errorf("invalid signature of owner %s", x509Cert.Subject.CommonName)
+		}
+
+		owners = append(owners, der)
+	}
+	return owners, nil
+}
+
+// CheckOwners checks that at least required of the trusted owners, given as
+// DER encoded certificates, are among the signers of a package
+func CheckOwners(signers [][]byte, trusted [][]byte, required int) error {
+	signed := 0
+	for _, owner := range trusted {
+		for _, signer := range signers {
+			if bytes.Equal(owner, signer) {
+				signed++
+				break
+			}
+		}
+	}
+
+	if signed < required {
+		return fmt.Errorf("package signed by %d of the trusted owners, %d required", signed, required)
+	}
+	return nil
+}
+
+// NewInstantiationPolicy returns the marshalled instantiation policy
+// permitting the given identities
+func NewInstantiationPolicy(identities [][]byte) ([]byte, error) {
+	if len(identities) == 0 {
+		return nil, nil
+	}
+	return proto.Marshal(&pb.ChaincodeInstantiationPolicy{Identities: identities})
+}
+
+// CheckInstantiationPolicy checks that the creator, as sent in the proposal
+// header, may instantiate or upgrade the chaincode of the package
+func CheckInstantiationPolicy(spkg *pb.SignedChaincodeDeploymentSpec, creator []byte) error {
+	if len(spkg.InstantiationPolicy) == 0 {
+		return nil
+	}
+
+	policy := &pb.ChaincodeInstantiationPolicy{}
+	if err := proto.Unmarshal(spkg.InstantiationPolicy, policy); err != nil {
+		return fmt.Errorf("invalid instantiation policy: %s", err)
+	}
+
+	if len(policy.Identities) == 0 {
+		return nil
+	}
+
+	for _, identity := range policy.Identities {
+		if bytes.Equal(identity, creator) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("creator is not permitted by the instantiation policy of the package")
+}
@@ -0,0 +1,204 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ccpackage
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/hyperledger/fabric/core/crypto/primitives"
+	pb "github.com/hyperledger/fabric/protos"
+)
+
+func newOwner(t *testing.T) ([]byte, []byte, interface{}) {
+	der, key, err := primitives.NewSelfSignedCert()
+	if err != nil {
+		t.Fatalf("Failed creating owner certificate: %s", err)
+	}
+	return primitives.DERCertToPEM(der), der, key
+}
+
+func newPackage(t *testing.T) *pb.SignedChaincodeDeploymentSpec {
+	cds := &pb.ChaincodeDeploymentSpec{ChaincodeSpec: &pb.ChaincodeSpec{ChaincodeID: &pb.ChaincodeID{Name: "mycc", Version: "1.0"}}, CodePackage: []byte("code")}
+	spkg, err := NewSignedPackage(cds, []byte("policy"))
+	if err != nil {
+		t.Fatalf("Failed creating package: %s", err)
+	}
+	return spkg
+}
+
+func TestSignAndVerify(t *testing.T) {
+	primitives.InitSecurityLevel("SHA2", 256)
+
+	spkg := newPackage(t)
+	cert1, der1, key1 := newOwner(t)
+	cert2, der2, key2 := newOwner(t)
+
+	if err := Sign(spkg, cert1, key1); err != nil {
+		t.Fatalf("Failed signing package: %s", err)
+	}
+	if err := Sign(spkg, cert2, key2); err != nil {
+		t.Fatalf("Failed signing package: %s", err)
+	}
+
+	signers, err := VerifyOwnerEndorsements(spkg)
+	if err != nil {
+		t.Fatalf("Failed verifying package: %s", err)
+	}
+	if len(signers) != 2 {
+		t.Fatalf("Expected 2 signers, got %d", len(signers))
+	}
+
+	if err = CheckOwners(signers, [][]byte{der1, der2}, 2); err != nil {
+		t.Fatalf("Expected owners to be satisfied: %s", err)
+	}
+
+	cds, err := GetDeploymentSpec(spkg)
+	if err != nil || cds.ChaincodeSpec.ChaincodeID.Name != "mycc" {
+		t.Fatalf("Failed getting the deployment spec: %s", err)
+	}
+}
+
+func TestSignWithWrongKey(t *testing.T) {
+	primitives.InitSecurityLevel("SHA2", 256)
+
+	cert, _, _ := newOwner(t)
+	_, _, otherKey := newOwner(t)
+
+	if err := Sign(newPackage(t), cert, otherKey); err == nil {
+		t.Fatalf("Expected signing with a key not matching the certificate to fail")
+	}
+}
+
+func TestVerifyTampered(t *testing.T) {
+	primitives.InitSecurityLevel("SHA2", 256)
+
+	spkg := newPackage(t)
+	cert, _, key := newOwner(t)
+	if err := Sign(spkg, cert, key); err != nil {
+		t.Fatalf("Failed signing package: %s", err)
+	}
+
+	spkg.InstantiationPolicy = []byte("other policy")
+	if _, err := VerifyOwnerEndorsements(spkg); err == nil {
+		t.Fatalf("Expected verification of a tampered package to fail")
+	}
+}
+
+func TestVerifyFieldsShifted(t *testing.T) {
+	primitives.InitSecurityLevel("SHA2", 256)
+
+	spkg := newPackage(t)
+	cert, _, key := newOwner(t)
+	if err := Sign(spkg, cert, key); err != nil {
+		t.Fatalf("Failed signing package: %s", err)
+	}
+
+	//the same bytes split differently between the spec and the policy
+	spkg.ChaincodeDeploymentSpec = append(spkg.ChaincodeDeploymentSpec, spkg.InstantiationPolicy[0])
+	spkg.InstantiationPolicy = spkg.InstantiationPolicy[1:]
+	if _, err := VerifyOwnerEndorsements(spkg); err == nil {
+		t.Fatalf("Expected verification of a package with shifted fields to fail")
+	}
+}
+
+func TestCheckOwners(t *testing.T) {
+	signers := [][]byte{[]byte("owner1")}
+	trusted := [][]byte{[]byte("owner1"), []byte("owner2")}
+
+	if err := CheckOwners(signers, trusted, 1); err != nil {
+		t.Fatalf("Expected one trusted owner to be enough: %s", err)
+	}
+	if err := CheckOwners(signers, trusted, 2); err == nil {
+		t.Fatalf("Expected two trusted owners to be required")
+	}
+	if err := CheckOwners([][]byte{[]byte("other")}, trusted, 1); err == nil {
+		t.Fatalf("Expected an untrusted signer not to count")
+	}
+}
+
+func TestInstantiationPolicy(t *testing.T) {
+	cds := &pb.ChaincodeDeploymentSpec{ChaincodeSpec: &pb.ChaincodeSpec{ChaincodeID: &pb.ChaincodeID{Name: "mycc", Version: "1.0"}}}
+
+	policy, err := NewInstantiationPolicy([][]byte{[]byte("admin")})
+	if err != nil {
+		t.Fatalf("Failed creating instantiation policy: %s", err)
+	}
+
+	spkg, err := NewSignedPackage(cds, policy)
+	if err != nil {
+		t.Fatalf("Failed creating package: %s", err)
+	}
+
+	if err = CheckInstantiationPolicy(spkg, []byte("admin")); err != nil {
+		t.Fatalf("Expected admin to be permitted: %s", err)
+	}
+	if err = CheckInstantiationPolicy(spkg, []byte("other")); err == nil {
+		t.Fatalf("Expected other creator to be denied")
+	}
+
+	//a package without a policy permits everyone
+	spkg, _ = NewSignedPackage(cds, nil)
+	if err = CheckInstantiationPolicy(spkg, []byte("other")); err != nil {
+		t.Fatalf("Expected everyone to be permitted: %s", err)
+	}
+}
+
+func TestArchive(t *testing.T) {
+	primitives.InitSecurityLevel("SHA2", 256)
+
+	cds := &pb.ChaincodeDeploymentSpec{ChaincodeSpec: &pb.ChaincodeSpec{Type: pb.ChaincodeSpec_GOLANG, ChaincodeID: &pb.ChaincodeID{Name: "mycc", Version: "1.0", Path: "github.com/mycc"}, CtorMsg: &pb.ChaincodeInput{Args: [][]byte{[]byte("init")}}}, CodePackage: []byte("code")}
+	policy, _ := NewInstantiationPolicy([][]byte{[]byte("admin")})
+
+	// the constructor message is not part of a package archive
+	spkg, _ := NewSignedPackage(cds, policy)
+	if err := WriteArchive(&bytes.Buffer{}, spkg, ""); err == nil {
+		t.Fatalf("Expected a package holding more than the metadata to be refused")
+	}
+
+	spkg, _ = NewSignedPackage(NewPackageSpec(cds), policy)
+	cert, _, key := newOwner(t)
+	if err := Sign(spkg, cert, key); err != nil {
+		t.Fatalf("Failed signing package: %s", err)
+	}
+
+	if err := WriteArchive(&bytes.Buffer{}, spkg, "my label"); err == nil {
+		t.Fatalf("Expected an invalid label to be refused")
+	}
+
+	buf := &bytes.Buffer{}
+	if err := WriteArchive(buf, spkg, ""); err != nil {
+		t.Fatalf("Failed writing archive: %s", err)
+	}
+	if !IsArchive(buf.Bytes()) || IsArchive(spkg.ChaincodeDeploymentSpec) {
+		t.Fatalf("Archive not told from a marshalled package")
+	}
+
+	read, md, err := ReadArchive(buf)
+	if err != nil {
+		t.Fatalf("Failed reading archive: %s", err)
+	}
+	if md.Label != "mycc_1.0" || md.Type != "GOLANG" || md.Path != "github.com/mycc" || len(md.InstantiationPolicy) != 1 {
+		t.Fatalf("Unexpected metadata %v", md)
+	}
+	if !bytes.Equal(read.ChaincodeDeploymentSpec, spkg.ChaincodeDeploymentSpec) || !bytes.Equal(read.InstantiationPolicy, spkg.InstantiationPolicy) {
+		t.Fatalf("Package not rebuilt from the archive")
+	}
+	if signers, err := VerifyOwnerEndorsements(read); err != nil || len(signers) != 1 {
+		t.Fatalf("Expected the signature of the owner to hold: %s", err)
+	}
+}
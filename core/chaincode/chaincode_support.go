@@ -31,12 +31,21 @@ import (
 
 	"strings"
 
+	"github.com/hyperledger/fabric/core/chaincode/platforms"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
 	"github.com/hyperledger/fabric/core/container"
 	"github.com/hyperledger/fabric/core/container/ccintf"
+	"github.com/hyperledger/fabric/core/container/dockercontroller"
 	"github.com/hyperledger/fabric/core/crypto"
 	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/kvledgerconfig"
+	"github.com/hyperledger/fabric/core/metrics"
+	"github.com/hyperledger/fabric/core/operations"
+	"github.com/hyperledger/fabric/core/tracing"
 	"github.com/hyperledger/fabric/flogging"
 	pb "github.com/hyperledger/fabric/protos"
+	"google.golang.org/grpc/metadata"
 )
 
 // ChainName is the name of the chain to which this chaincode support belongs to.
@@ -48,17 +57,27 @@ const (
 	// DevModeUserRunsChaincode property allows user to run chaincode in development environment
 	DevModeUserRunsChaincode       string = "dev"
 	chaincodeStartupTimeoutDefault int    = 5000
+	chaincodeExecuteTimeoutDefault int    = 30000
 	chaincodeInstallPathDefault    string = "/opt/gopath/bin/"
 	peerAddressDefault             string = "0.0.0.0:7051"
 
 	//TXSimulatorKey is used to attach ledger simulation context
 	TXSimulatorKey string = "txsimulatorkey"
+
+	//ProposalKey is used to attach the proposal being executed
+	ProposalKey string = "proposalkey"
+
+	//SignedProposalKey is used to attach the signed proposal being executed
+	SignedProposalKey string = "signedproposalkey"
 )
 
 // chains is a map between different blockchains and their ChaincodeSupport.
 //this needs to be a first class, top-level object... for now, lets just have a placeholder
 var chains map[ChainName]*ChaincodeSupport
 
+// chainsLock guards chains, to which the chains joined by the peer are added
+var chainsLock sync.RWMutex
+
 func init() {
 	chains = make(map[ChainName]*ChaincodeSupport)
 }
@@ -71,11 +90,32 @@ func getTxSimulator(context context.Context) ledger.TxSimulator {
 	panic("!!!---Not Using ledgernext---!!!")
 }
 
+//getProposal returns the proposal being executed, nil if the execution is not
+//for a proposal (e.g. system chaincodes invoked by the peer itself)
+func getProposal(context context.Context) *pb.Proposal {
+	if prop, ok := context.Value(ProposalKey).(*pb.Proposal); ok {
+		return prop
+	}
+	return nil
+}
+
+//getSignedProposal returns the signed proposal being executed, nil if the
+//execution is not for a proposal
+func getSignedProposal(context context.Context) *pb.SignedProposal {
+	if signedProp, ok := context.Value(SignedProposalKey).(*pb.SignedProposal); ok {
+		return signedProp
+	}
+	return nil
+}
+
 //
 //chaincode runtime environment encapsulates handler and container environment
 //This is where the VM that's running the chaincode would hook in
 type chaincodeRTEnv struct {
 	handler *Handler
+	// the deployment spec of a chaincode launched by the peer, used to relaunch
+	// the chaincode when its container goes down
+	cds *pb.ChaincodeDeploymentSpec
 }
 
 // runningChaincodes contains maps of chaincodeIDs to their chaincodeRTEs
@@ -83,13 +123,80 @@ type runningChaincodes struct {
 	sync.RWMutex
 	// chaincode environment for each chaincode
 	chaincodeMap map[string]*chaincodeRTEnv
+	// number of relaunches of each chaincode since a transaction last succeeded on it
+	relaunches map[string]int
 }
 
 // GetChain returns the chaincode support for a given chain
 func GetChain(name ChainName) *ChaincodeSupport {
+	chainsLock.RLock()
+	defer chainsLock.RUnlock()
 	return chains[name]
 }
 
+// GetChaincodeSupport returns the chaincode support running the chaincode on
+// the chain. The system chaincodes of all the chains run in the chaincode
+// support of the default chain
+func GetChaincodeSupport(chainname string, ccname string) (*ChaincodeSupport, error) {
+	if chainname == "" || IsSysCC(ccname) {
+		chainname = string(DefaultChain)
+	}
+	if s := GetChain(ChainName(chainname)); s != nil {
+		return s, nil
+	}
+	return nil, fmt.Errorf("chain %s has no chaincode support", chainname)
+}
+
+// AddChain creates the chaincode support of a chain joined by the peer, with
+// the settings of the default chain. Its chaincodes run in containers of their
+// own and register with the chain named in the metadata of their stream. The
+// chaincode support of the chain is returned if it exists already
+func AddChain(name ChainName) (*ChaincodeSupport, error) {
+	chainsLock.Lock()
+	defer chainsLock.Unlock()
+	if s, ok := chains[name]; ok {
+		return s, nil
+	}
+	def, ok := chains[DefaultChain]
+	if !ok {
+		return nil, fmt.Errorf("chaincode support of chain %s not created", DefaultChain)
+	}
+	s := *def
+	s.name = name
+	s.runningChaincodes = &runningChaincodes{chaincodeMap: make(map[string]*chaincodeRTEnv), relaunches: make(map[string]int)}
+	chains[name] = &s
+	chaincodeLogger.Infof("Created chaincode support of chain %s", name)
+	return &s, nil
+}
+
+// StopAllChains stops the chaincodes launched by the peer on all the chains
+func StopAllChains(context context.Context) error {
+	chainsLock.RLock()
+	supports := make([]*ChaincodeSupport, 0, len(chains))
+	for _, s := range chains {
+		supports = append(supports, s)
+	}
+	chainsLock.RUnlock()
+
+	var firstErr error
+	for _, s := range supports {
+		if err := s.StopAll(context); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// getCCID returns the ID of the container of the chaincode. The chaincodes of
+// the chains joined by the peer run in containers named after their chain
+func (chaincodeSupport *ChaincodeSupport) getCCID(spec *pb.ChaincodeSpec) ccintf.CCID {
+	ccid := ccintf.CCID{ChaincodeSpec: spec, NetworkID: chaincodeSupport.peerNetworkID, PeerID: chaincodeSupport.peerID}
+	if chaincodeSupport.name != DefaultChain {
+		ccid.ChainID = string(chaincodeSupport.name)
+	}
+	return ccid
+}
+
 //call this under lock
 func (chaincodeSupport *ChaincodeSupport) preLaunchSetup(chaincode string) chan bool {
 	//register placeholder Handler. This will be transferred in registerHandler
@@ -111,10 +218,13 @@ func NewChaincodeSupport(chainname ChainName, getPeerEndpoint func() (*pb.PeerEn
 	pnid := viper.GetString("peer.networkId")
 	pid := viper.GetString("peer.id")
 
-	s := &ChaincodeSupport{name: chainname, runningChaincodes: &runningChaincodes{chaincodeMap: make(map[string]*chaincodeRTEnv)}, secHelper: secHelper, peerNetworkID: pnid, peerID: pid}
+	s := &ChaincodeSupport{name: chainname, runningChaincodes: &runningChaincodes{chaincodeMap: make(map[string]*chaincodeRTEnv), relaunches: make(map[string]int)}, secHelper: secHelper, peerNetworkID: pnid, peerID: pid}
+	s.metrics = newExecuteMetrics(metrics.GetProvider())
 
 	//initialize global chain
+	chainsLock.Lock()
 	chains[chainname] = s
+	chainsLock.Unlock()
 
 	peerEndpoint, err := getPeerEndpoint()
 	if err != nil {
@@ -130,15 +240,28 @@ func NewChaincodeSupport(chainname ChainName, getPeerEndpoint func() (*pb.PeerEn
 	}
 
 	s.userRunsCC = userrunsCC
+	if !userrunsCC {
+		//the chaincodes launched by the peer run on docker
+		operations.RegisterChecker("docker", dockercontroller.HealthCheck)
+	}
 
 	s.ccStartupTimeout = ccstartuptimeout
 
+	s.executeTimeout = time.Duration(chaincodeExecuteTimeoutDefault) * time.Millisecond
+	if t := viper.GetInt("chaincode.executetimeout"); t > 0 {
+		s.executeTimeout = time.Duration(t) * time.Millisecond
+	}
+	s.executeTimeouts = getExecuteTimeouts()
+
 	//TODO I'm not sure if this needs to be on a per chain basis... too lowel and just needs to be a global default ?
 	s.chaincodeInstallPath = viper.GetString("chaincode.installpath")
 	if s.chaincodeInstallPath == "" {
 		s.chaincodeInstallPath = chaincodeInstallPathDefault
 	}
 
+	s.totalQueryLimit = kvledgerconfig.GetTotalQueryLimit()
+	s.maxOpenQueryIterators = kvledgerconfig.GetMaxOpenQueryIterators()
+
 	s.peerTLS = viper.GetBool("peer.tls.enabled")
 	if s.peerTLS {
 		s.peerTLSCertFile = viper.GetString("peer.tls.cert.file")
@@ -149,6 +272,13 @@ func NewChaincodeSupport(chainname ChainName, getPeerEndpoint func() (*pb.PeerEn
 	kadef := 0
 	if ka := viper.GetString("chaincode.keepalive"); ka == "" {
 		s.keepalive = time.Duration(kadef) * time.Second
+	} else if d, derr := time.ParseDuration(ka); derr == nil {
+		//the interval may also be given with a unit, as in 500ms or 1m
+		if d <= 0 {
+			chaincodeLogger.Debugf("Turn off keepalive(value %s)", ka)
+			d = time.Duration(kadef) * time.Second
+		}
+		s.keepalive = d
 	} else {
 		t, terr := strconv.Atoi(ka)
 		if terr != nil {
@@ -160,6 +290,19 @@ func NewChaincodeSupport(chainname ChainName, getPeerEndpoint func() (*pb.PeerEn
 		}
 		s.keepalive = time.Duration(t) * time.Second
 	}
+	s.keepaliveMisses = viper.GetInt("chaincode.keepaliveMisses")
+	s.streamSendTimeout = time.Duration(viper.GetInt("chaincode.stream.sendTimeout")) * time.Millisecond
+	s.streamRecvTimeout = time.Duration(viper.GetInt("chaincode.stream.recvTimeout")) * time.Millisecond
+	if s.keepalive > 0 && s.streamRecvTimeout > 0 && s.streamRecvTimeout <= s.keepalive {
+		chaincodeLogger.Warningf("chaincode.stream.recvTimeout %s does not exceed the keepalive interval %s, idle chaincode streams will be ended", s.streamRecvTimeout, s.keepalive)
+	}
+
+	s.relaunch = viper.GetBool("chaincode.relaunch.enabled")
+	s.relaunchMaxAttempts = viper.GetInt("chaincode.relaunch.maxAttempts")
+
+	if viper.GetBool("chaincode.debug.enabled") {
+		s.setDebug(viper.GetInt("chaincode.debug.port"), time.Duration(viper.GetInt("chaincode.debug.timeout"))*time.Millisecond)
+	}
 
 	viper.SetEnvPrefix("CORE")
 	viper.AutomaticEnv()
@@ -191,6 +334,8 @@ type ChaincodeSupport struct {
 	runningChaincodes    *runningChaincodes
 	peerAddress          string
 	ccStartupTimeout     time.Duration
+	executeTimeout       time.Duration
+	executeTimeouts      map[string]time.Duration
 	chaincodeInstallPath string
 	userRunsCC           bool
 	secHelper            crypto.Peer
@@ -201,7 +346,20 @@ type ChaincodeSupport struct {
 	peerTLSKeyFile       string
 	peerTLSSvrHostOrd    string
 	keepalive            time.Duration
-	chaincodeLogLevel    string
+	// number of unanswered keepalives after which the chaincode is considered dead, 0 to never
+	keepaliveMisses     int
+	relaunch            bool
+	relaunchMaxAttempts int
+	chaincodeLogLevel   string
+	// limits on the query iterators of a transaction, 0 for no limit
+	totalQueryLimit       int
+	maxOpenQueryIterators int
+	// the chaincode stream ends when a send takes or nothing is received for longer, 0 to never
+	streamSendTimeout time.Duration
+	streamRecvTimeout time.Duration
+	// port the debugger of chaincodes launched by the peer listens on, 0 when not debugging
+	debugPort int
+	metrics   *executeMetrics
 }
 
 // DuplicateChaincodeHandlerError returned if attempt to register same chaincodeID while a stream already exists.
@@ -217,6 +375,83 @@ func newDuplicateChaincodeHandlerError(chaincodeHandler *Handler) error {
 	return &DuplicateChaincodeHandlerError{ChaincodeID: chaincodeHandler.ChaincodeID}
 }
 
+// ChaincodeTimeoutError returned if the chaincode did not complete a transaction within its execute timeout
+type ChaincodeTimeoutError struct {
+	Chaincode string
+	Timeout   time.Duration
+}
+
+func (c *ChaincodeTimeoutError) Error() string {
+	return fmt.Sprintf("Timeout expired while executing transaction: chaincode %s did not complete within %s", c.Chaincode, c.Timeout)
+}
+
+// IsChaincodeTimeout returns true if err is a ChaincodeTimeoutError
+func IsChaincodeTimeout(err error) bool {
+	_, ok := err.(*ChaincodeTimeoutError)
+	return ok
+}
+
+// ChaincodeUnavailableError returned if a transaction fails because the chaincode is
+// down or being (re)launched. The failure is transient, the transaction can be retried.
+type ChaincodeUnavailableError struct {
+	Chaincode string
+	Reason    string
+}
+
+func (c *ChaincodeUnavailableError) Error() string {
+	return fmt.Sprintf("chaincode %s unavailable: %s", c.Chaincode, c.Reason)
+}
+
+// IsChaincodeUnavailable returns true if err is a ChaincodeUnavailableError
+func IsChaincodeUnavailable(err error) bool {
+	_, ok := err.(*ChaincodeUnavailableError)
+	return ok
+}
+
+// ChaincodeError returned if the chaincode completed a transaction with a failure. It carries
+// the status and the message of the chaincode, so that the client can tell an application-level
+// failure from a failure of the peer.
+type ChaincodeError struct {
+	Chaincode string
+	Status    int32
+	Message   string
+}
+
+func (c *ChaincodeError) Error() string {
+	return fmt.Sprintf("Transaction or query returned with failure: %s", c.Message)
+}
+
+// IsChaincodeError returns true if err is a ChaincodeError
+func IsChaincodeError(err error) bool {
+	_, ok := err.(*ChaincodeError)
+	return ok
+}
+
+// CheckMigrateNotInvoked checks that the arguments do not invoke Migrate of
+// the chaincode, which only the peer invokes when it upgrades the chaincode.
+// Neither clients nor other chaincodes may invoke it
+func CheckMigrateNotInvoked(ccname string, args [][]byte) error {
+	if len(args) > 0 && string(args[0]) == shim.MigrateFunction {
+		return fmt.Errorf("%s of chaincode %s can only be invoked by an upgrade", shim.MigrateFunction, ccname)
+	}
+	return nil
+}
+
+// newChaincodeError returns the ChaincodeError of the ERROR or QUERY_ERROR message of the
+// chaincode. Without a valid status in the response of the chaincode, the status is 500.
+func newChaincodeError(chaincode string, msg *pb.ChaincodeMessage) *ChaincodeError {
+	ccErr := &ChaincodeError{Chaincode: chaincode, Status: shim.ERROR, Message: string(msg.Payload)}
+	if msg.Response != nil {
+		if msg.Response.Status >= shim.ERRORTHRESHOLD && msg.Response.Status < 600 {
+			ccErr.Status = msg.Response.Status
+		}
+		if msg.Response.Message != "" {
+			ccErr.Message = msg.Response.Message
+		}
+	}
+	return ccErr
+}
+
 func (chaincodeSupport *ChaincodeSupport) registerHandler(chaincodehandler *Handler) error {
 	key := chaincodehandler.ChaincodeID.Name
 
@@ -225,9 +460,15 @@ func (chaincodeSupport *ChaincodeSupport) registerHandler(chaincodehandler *Hand
 
 	chrte2, ok := chaincodeSupport.chaincodeHasBeenLaunched(key)
 	if ok && chrte2.handler.registered == true {
-		chaincodeLogger.Debugf("duplicate registered handler(key:%s) return error", key)
-		// Duplicate, return error
-		return newDuplicateChaincodeHandlerError(chaincodehandler)
+		//in development mode the chaincode restarted by the user replaces the
+		//running one, so a rebuilt chaincode is picked up without redeploying it
+		if !chaincodeSupport.userRunsCC || chrte2.handler.ChaincodeID.Version != chaincodehandler.ChaincodeID.Version {
+			chaincodeLogger.Debugf("duplicate registered handler(key:%s) return error", key)
+			// Duplicate, return error
+			return newDuplicateChaincodeHandlerError(chaincodehandler)
+		}
+		chaincodeLogger.Infof("chaincode %s registered again, replacing the running one", key)
+		chrte2.handler.endStream(fmt.Errorf("chaincode %s registered again, ending the stream of the replaced one", key))
 	}
 	//a placeholder, unregistered handler will be setup by query or transaction processing that comes
 	//through via consensus. In this case we swap the handler and give it the notify channel
@@ -263,15 +504,116 @@ func (chaincodeSupport *ChaincodeSupport) deregisterHandler(chaincodehandler *Ha
 	chaincodeLogger.Debugf("Deregister handler: %s", key)
 	chaincodeSupport.runningChaincodes.Lock()
 	defer chaincodeSupport.runningChaincodes.Unlock()
-	if _, ok := chaincodeSupport.chaincodeHasBeenLaunched(key); !ok {
+	chrte, ok := chaincodeSupport.chaincodeHasBeenLaunched(key)
+	if !ok {
 		// Handler NOT found
 		return fmt.Errorf("Error deregistering handler, could not find handler with key: %s", key)
 	}
+	if chrte.handler != chaincodehandler {
+		// the chaincode has been relaunched since, leave the new handler alone
+		return fmt.Errorf("Error deregistering handler, handler with key %s was replaced", key)
+	}
 	delete(chaincodeSupport.runningChaincodes.chaincodeMap, key)
 	chaincodeLogger.Debugf("Deregistered handler with key: %s", key)
+
+	//a chaincode stopped by the peer is removed from the map before its stream
+	//ends, so the container of a chaincode still in the map went down by itself
+	if chrte.cds != nil && chaincodeSupport.relaunch {
+		attempts := chaincodeSupport.runningChaincodes.relaunches[key]
+		if attempts >= chaincodeSupport.relaunchMaxAttempts {
+			chaincodeLogger.Errorf("chaincode %s went down, not relaunching it after %d attempts", key, attempts)
+		} else {
+			chaincodeSupport.runningChaincodes.relaunches[key] = attempts + 1
+			go chaincodeSupport.relaunchChaincode(chrte.cds)
+		}
+	}
 	return nil
 }
 
+//relaunchChaincode tears down the container of a chaincode that went down and
+//launches it again. The chaincode is made ready by the next transaction for it
+func (chaincodeSupport *ChaincodeSupport) relaunchChaincode(cds *pb.ChaincodeDeploymentSpec) {
+	cID := cds.ChaincodeSpec.ChaincodeID
+	chaincodeLogger.Warningf("chaincode %s went down, relaunching it", cID.Name)
+
+	chaincodeSupport.runningChaincodes.Lock()
+	_, launched := chaincodeSupport.chaincodeHasBeenLaunched(cID.Name)
+	chaincodeSupport.runningChaincodes.Unlock()
+	if launched {
+		chaincodeLogger.Debugf("chaincode %s has already been launched again", cID.Name)
+		return
+	}
+
+	ctxt := context.Background()
+	if err := chaincodeSupport.Stop(ctxt, cds); err != nil {
+		chaincodeLogger.Debugf("error tearing down chaincode %s: %s", cID.Name, err)
+	}
+
+	var targz io.Reader = bytes.NewBuffer(cds.CodePackage)
+	if _, err := chaincodeSupport.launchAndWaitForRegister(ctxt, cds, cID, "", cds.ChaincodeSpec.Type, targz); err != nil {
+		chaincodeLogger.Errorf("relaunching chaincode %s failed: %s", cID.Name, err)
+		return
+	}
+	chaincodeLogger.Infof("relaunched chaincode %s", cID.Name)
+}
+
+//getExecuteTimeouts returns the execute timeouts configured for single chaincodes
+//under chaincode.executetimeouts. It is a list rather than a map as viper lower
+//cases map keys while chaincode names are case sensitive
+func getExecuteTimeouts() map[string]time.Duration {
+	var configured []struct {
+		Name    string
+		Timeout int
+	}
+	if err := viper.UnmarshalKey("chaincode.executetimeouts", &configured); err != nil {
+		chaincodeLogger.Errorf("Invalid chaincode.executetimeouts, using chaincode.executetimeout for all chaincodes: %s", err)
+	}
+
+	timeouts := make(map[string]time.Duration)
+	for _, t := range configured {
+		if t.Name == "" || t.Timeout <= 0 {
+			chaincodeLogger.Errorf("Invalid execute timeout %d of chaincode %q ignored", t.Timeout, t.Name)
+			continue
+		}
+		timeouts[t.Name] = time.Duration(t.Timeout) * time.Millisecond
+	}
+	return timeouts
+}
+
+//setDebug prepares the peer for chaincodes stepped through in a debugger. A
+//chaincode stopped at a breakpoint answers neither keepalives nor transactions,
+//so its stream is kept, it is not relaunched and the startup and execute
+//timeouts are extended
+func (chaincodeSupport *ChaincodeSupport) setDebug(port int, timeout time.Duration) {
+	chaincodeLogger.Warningf("Chaincode debugging enabled, chaincodes that stop responding are not detected")
+
+	chaincodeSupport.debugPort = port
+	chaincodeSupport.keepaliveMisses = 0
+	chaincodeSupport.streamSendTimeout = 0
+	chaincodeSupport.streamRecvTimeout = 0
+	chaincodeSupport.relaunch = false
+
+	if chaincodeSupport.ccStartupTimeout < timeout {
+		chaincodeSupport.ccStartupTimeout = timeout
+	}
+	if chaincodeSupport.executeTimeout < timeout {
+		chaincodeSupport.executeTimeout = timeout
+	}
+	for chaincode, t := range chaincodeSupport.executeTimeouts {
+		if t < timeout {
+			chaincodeSupport.executeTimeouts[chaincode] = timeout
+		}
+	}
+}
+
+//getExecuteTimeout returns how long a transaction of the chaincode may run
+func (chaincodeSupport *ChaincodeSupport) getExecuteTimeout(chaincode string) time.Duration {
+	if timeout, ok := chaincodeSupport.executeTimeouts[chaincode]; ok {
+		return timeout
+	}
+	return chaincodeSupport.executeTimeout
+}
+
 // Based on state of chaincode send either init or ready to move to ready state
 func (chaincodeSupport *ChaincodeSupport) sendInitOrReady(context context.Context, txid string, chaincode string, initArgs [][]byte, timeout time.Duration, tx *pb.Transaction, depTx *pb.Transaction) error {
 	chaincodeSupport.runningChaincodes.Lock()
@@ -309,7 +651,11 @@ func (chaincodeSupport *ChaincodeSupport) sendInitOrReady(context context.Contex
 
 //get args and env given chaincodeID
 func (chaincodeSupport *ChaincodeSupport) getArgsAndEnv(cID *pb.ChaincodeID, cLang pb.ChaincodeSpec_Type) (args []string, envs []string, err error) {
-	envs = []string{"CORE_CHAINCODE_ID_NAME=" + cID.Name}
+	envs = []string{"CORE_CHAINCODE_ID_NAME=" + cID.Name, "CORE_PEER_ADDRESS=" + chaincodeSupport.peerAddress}
+	//the chaincode of a joined chain names its chain when it registers
+	if chaincodeSupport.name != DefaultChain {
+		envs = append(envs, "CORE_CHAINCODE_CHAINID="+string(chaincodeSupport.name))
+	}
 	//if TLS is enabled, pass TLS material to chaincode
 	if chaincodeSupport.peerTLS {
 		envs = append(envs, "CORE_PEER_TLS_ENABLED=true")
@@ -329,6 +675,11 @@ func (chaincodeSupport *ChaincodeSupport) getArgsAndEnv(cID *pb.ChaincodeID, cLa
 	case pb.ChaincodeSpec_GOLANG, pb.ChaincodeSpec_CAR:
 		//chaincode executable will be same as the name of the chaincode
 		args = []string{chaincodeSupport.chaincodeInstallPath + cID.Name, fmt.Sprintf("-peer.address=%s", chaincodeSupport.peerAddress)}
+		if cLang == pb.ChaincodeSpec_GOLANG && chaincodeSupport.debugPort > 0 {
+			//run the chaincode in a headless delve server for the debugger to attach to
+			args = append([]string{"dlv", "exec", args[0], "--headless", fmt.Sprintf("--listen=:%d", chaincodeSupport.debugPort),
+				"--api-version=2", "--accept-multiclient", "--continue", "--"}, args[1:]...)
+		}
 		chaincodeLogger.Debugf("Executable is %s", args[0])
 	case pb.ChaincodeSpec_JAVA:
 		//TODO add security args
@@ -339,6 +690,20 @@ func (chaincodeSupport *ChaincodeSupport) getArgsAndEnv(cID *pb.ChaincodeID, cLa
 		if chaincodeSupport.peerTLS {
 			args = append(args, " -s")
 		}
+		if chaincodeSupport.debugPort > 0 {
+			envs = append(envs, fmt.Sprintf("JAVA_OPTS=-agentlib:jdwp=transport=dt_socket,server=y,suspend=n,address=%d", chaincodeSupport.debugPort))
+		}
+		chaincodeLogger.Debugf("Executable is %s", args[0])
+	case pb.ChaincodeSpec_NODE:
+		//the package's start script runs the chaincode, the peer address is
+		//passed through to it. The chaincode name is in the environment
+		args = strings.Split(
+			fmt.Sprintf("npm start --prefix /usr/local/src -- --peer.address %s",
+				chaincodeSupport.peerAddress),
+			" ")
+		if chaincodeSupport.debugPort > 0 {
+			chaincodeLogger.Warningf("Node chaincode %s is launched without a debugger, debug it in development mode", cID.Name)
+		}
 		chaincodeLogger.Debugf("Executable is %s", args[0])
 	default:
 		return nil, nil, fmt.Errorf("Unknown chaincodeType: %s", cLang)
@@ -364,6 +729,7 @@ func (chaincodeSupport *ChaincodeSupport) launchAndWaitForRegister(ctxt context.
 	alreadyRunning := false
 
 	notfy := chaincodeSupport.preLaunchSetup(chaincode)
+	chaincodeSupport.runningChaincodes.chaincodeMap[chaincode].cds = cds
 	chaincodeSupport.runningChaincodes.Unlock()
 
 	//launch the chaincode
@@ -377,9 +743,12 @@ func (chaincodeSupport *ChaincodeSupport) launchAndWaitForRegister(ctxt context.
 
 	vmtype, _ := chaincodeSupport.getVMType(cds)
 
-	sir := container.StartImageReq{CCID: ccintf.CCID{ChaincodeSpec: cds.ChaincodeSpec, NetworkID: chaincodeSupport.peerNetworkID, PeerID: chaincodeSupport.peerID}, Reader: targz, Args: args, Env: env}
+	sir := container.StartImageReq{CCID: chaincodeSupport.getCCID(cds.ChaincodeSpec), Reader: targz, Args: args, Env: env}
 
 	ipcCtxt := context.WithValue(ctxt, ccintf.GetCCHandlerKey(), chaincodeSupport)
+	if cds.ExecEnv == pb.ChaincodeDeploymentSpec_EXTERNAL {
+		ipcCtxt = context.WithValue(ipcCtxt, ccintf.GetCCServerKey(), cds.ChaincodeServer)
+	}
 
 	resp, err := container.VMCProcess(ipcCtxt, vmtype, sir)
 	if err != nil || (resp != nil && resp.(container.VMCResp).Err != nil) {
@@ -419,29 +788,71 @@ func (chaincodeSupport *ChaincodeSupport) Stop(context context.Context, cds *pb.
 		return fmt.Errorf("chaincode name not set")
 	}
 
+	//forget the chaincode before stopping it, the stream ending with the
+	//chaincode still in the map would relaunch it
+	chaincodeSupport.runningChaincodes.Lock()
+	delete(chaincodeSupport.runningChaincodes.chaincodeMap, chaincode)
+	chaincodeSupport.runningChaincodes.Unlock()
+
 	//stop the chaincode
-	sir := container.StopImageReq{CCID: ccintf.CCID{ChaincodeSpec: cds.ChaincodeSpec, NetworkID: chaincodeSupport.peerNetworkID, PeerID: chaincodeSupport.peerID}, Timeout: 0}
+	sir := container.StopImageReq{CCID: chaincodeSupport.getCCID(cds.ChaincodeSpec), Timeout: 0}
 
 	vmtype, _ := chaincodeSupport.getVMType(cds)
 
 	_, err := container.VMCProcess(context, vmtype, sir)
 	if err != nil {
-		err = fmt.Errorf("Error stopping container: %s", err)
-		//but proceed to cleanup
+		return fmt.Errorf("Error stopping container: %s", err)
 	}
 
+	return nil
+}
+
+// StopAll stops the chaincodes launched by the peer, for the peer to shut
+// down. The chaincodes are forgotten first so that the end of their streams
+// does not relaunch them. The chaincodes the users run are left running,
+// their streams end with the peer
+func (chaincodeSupport *ChaincodeSupport) StopAll(context context.Context) error {
+	var launched []*pb.ChaincodeDeploymentSpec
 	chaincodeSupport.runningChaincodes.Lock()
-	if _, ok := chaincodeSupport.chaincodeHasBeenLaunched(chaincode); !ok {
-		//nothing to do
-		chaincodeSupport.runningChaincodes.Unlock()
-		return nil
+	for _, chrte := range chaincodeSupport.runningChaincodes.chaincodeMap {
+		if chrte.cds != nil {
+			launched = append(launched, chrte.cds)
+		}
 	}
+	chaincodeSupport.runningChaincodes.Unlock()
 
-	delete(chaincodeSupport.runningChaincodes.chaincodeMap, chaincode)
+	var firstErr error
+	for _, cds := range launched {
+		chaincodeLogger.Infof("Stopping chaincode %s", cds.ChaincodeSpec.ChaincodeID.Name)
+		if err := chaincodeSupport.Stop(context, cds); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("Error stopping chaincode %s: %s", cds.ChaincodeSpec.ChaincodeID.Name, err)
+		}
+	}
+	return firstErr
+}
+
+// SetLogLevel changes the logging level of a module of the running chaincode
+// without restarting it. The level is sent over the stream of the chaincode,
+// an empty module sets the level of the shim and of the chaincode loggers
+func (chaincodeSupport *ChaincodeSupport) SetLogLevel(chaincode string, module string, level string) error {
+	if _, err := logging.LogLevel(level); err != nil {
+		return fmt.Errorf("Invalid log level %s: %s", level, err)
+	}
 
+	chaincodeSupport.runningChaincodes.Lock()
+	chrte, ok := chaincodeSupport.chaincodeHasBeenLaunched(chaincode)
 	chaincodeSupport.runningChaincodes.Unlock()
+	if !ok || !chrte.handler.registered {
+		return fmt.Errorf("chaincode %s is not running", chaincode)
+	}
 
-	return err
+	payload, err := proto.Marshal(&pb.SetLogLevel{Module: module, Level: level})
+	if err != nil {
+		return err
+	}
+
+	chaincodeLogger.Debugf("Setting log level of module [%s] of chaincode %s to %s", module, chaincode, level)
+	return chrte.handler.serialSend(&pb.ChaincodeMessage{Type: pb.ChaincodeMessage_SET_LOG_LEVEL, Payload: payload})
 }
 
 // Launch will launch the chaincode if not running (if running return nil) and will wait for handler of the chaincode to get into FSM ready state.
@@ -484,7 +895,7 @@ func (chaincodeSupport *ChaincodeSupport) Launch(context context.Context, t *pb.
 		if !chrte.handler.registered {
 			chaincodeSupport.runningChaincodes.Unlock()
 			chaincodeLogger.Debugf("premature execution - chaincode (%s) is being launched", chaincode)
-			err = fmt.Errorf("premature execution - chaincode (%s) is being launched", chaincode)
+			err = &ChaincodeUnavailableError{Chaincode: chaincode, Reason: "premature execution - chaincode is being launched"}
 			return cID, cMsg, err
 		}
 		if chrte.handler.isRunning() {
@@ -511,7 +922,7 @@ func (chaincodeSupport *ChaincodeSupport) Launch(context context.Context, t *pb.
 	// See issue #710
 
 	if t.Type != pb.Transaction_CHAINCODE_DEPLOY {
-		if chaincodeSupport.userRunsCC {
+		if chaincodeSupport.userRunsCC && chrte == nil {
 			chaincodeLogger.Error("You are attempting to perform an action other than Deploy on Chaincode that is not ready and you are in developer mode. Did you forget to Deploy your chaincode?")
 		}
 
@@ -521,7 +932,7 @@ func (chaincodeSupport *ChaincodeSupport) Launch(context context.Context, t *pb.
 		var depPayload []byte
 
 		//hopefully we are restarting from existing image and the deployed transaction exists
-		depPayload, err = GetCDSFromLCCC(context, string(DefaultChain), chaincode)
+		depPayload, err = GetCDSFromLCCC(context, string(chaincodeSupport.name), chaincode)
 		if err != nil {
 			return cID, cMsg, fmt.Errorf("Could not get deployment transaction from LCCC for %s - %s", chaincode, err)
 		}
@@ -539,8 +950,9 @@ func (chaincodeSupport *ChaincodeSupport) Launch(context context.Context, t *pb.
 
 	//from here on : if we launch the container and get an error, we need to stop the container
 
-	//launch container if it is a System container or not in dev mode
-	if (!chaincodeSupport.userRunsCC || cds.ExecEnv == pb.ChaincodeDeploymentSpec_SYSTEM) && (chrte == nil || chrte.handler == nil) {
+	//launch container if it is a System container or not in dev mode. A chaincode
+	//run as an external service is connected to in either mode
+	if (!chaincodeSupport.userRunsCC || cds.ExecEnv != pb.ChaincodeDeploymentSpec_DOCKER) && (chrte == nil || chrte.handler == nil) {
 		var targz io.Reader = bytes.NewBuffer(cds.CodePackage)
 		_, err = chaincodeSupport.launchAndWaitForRegister(context, cds, cID, t.Txid, cLang, targz)
 		if err != nil {
@@ -555,9 +967,13 @@ func (chaincodeSupport *ChaincodeSupport) Launch(context context.Context, t *pb.
 		if err != nil {
 			chaincodeLogger.Errorf("sending init failed(%s)", err)
 			err = fmt.Errorf("Failed to init chaincode(%s)", err)
-			errIgnore := chaincodeSupport.Stop(context, cds)
-			if errIgnore != nil {
-				chaincodeLogger.Errorf("stop failed %s(%s)", errIgnore, err)
+			//there is no container to stop in development mode, the user
+			//fixes the chaincode and restarts it
+			if !chaincodeSupport.userRunsCC || cds.ExecEnv != pb.ChaincodeDeploymentSpec_DOCKER {
+				errIgnore := chaincodeSupport.Stop(context, cds)
+				if errIgnore != nil {
+					chaincodeLogger.Errorf("stop failed %s(%s)", errIgnore, err)
+				}
 			}
 		}
 		chaincodeLogger.Debug("sending init completed")
@@ -576,8 +992,11 @@ func (chaincodeSupport *ChaincodeSupport) getSecHelper() crypto.Peer {
 //getVMType - just returns a string for now. Another possibility is to use a factory method to
 //return a VM executor
 func (chaincodeSupport *ChaincodeSupport) getVMType(cds *pb.ChaincodeDeploymentSpec) (string, error) {
-	if cds.ExecEnv == pb.ChaincodeDeploymentSpec_SYSTEM {
+	switch cds.ExecEnv {
+	case pb.ChaincodeDeploymentSpec_SYSTEM:
 		return container.SYSTEM, nil
+	case pb.ChaincodeDeploymentSpec_EXTERNAL:
+		return container.EXTERNAL, nil
 	}
 	return container.DOCKER, nil
 }
@@ -597,6 +1016,10 @@ func (chaincodeSupport *ChaincodeSupport) Deploy(context context.Context, t *pb.
 		return cds, err
 	}
 
+	if cds.ExecEnv == pb.ChaincodeDeploymentSpec_EXTERNAL && (cds.ChaincodeServer == nil || cds.ChaincodeServer.Address == "") {
+		return cds, fmt.Errorf("chaincode server address not set for external chaincode %s", chaincode)
+	}
+
 	if chaincodeSupport.userRunsCC {
 		chaincodeLogger.Debug("user runs chaincode, not deploying chaincode")
 		return nil, nil
@@ -617,7 +1040,7 @@ func (chaincodeSupport *ChaincodeSupport) Deploy(context context.Context, t *pb.
 	}
 
 	var targz io.Reader = bytes.NewBuffer(cds.CodePackage)
-	cir := &container.CreateImageReq{CCID: ccintf.CCID{ChaincodeSpec: cds.ChaincodeSpec, NetworkID: chaincodeSupport.peerNetworkID, PeerID: chaincodeSupport.peerID}, Args: args, Reader: targz, Env: envs}
+	cir := &container.CreateImageReq{CCID: chaincodeSupport.getCCID(cds.ChaincodeSpec), Args: args, Reader: targz, Env: envs}
 
 	vmtype, _ := chaincodeSupport.getVMType(cds)
 
@@ -627,19 +1050,61 @@ func (chaincodeSupport *ChaincodeSupport) Deploy(context context.Context, t *pb.
 	resp, err2 := container.VMCProcess(context, vmtype, cir)
 	if err2 != nil || (resp != nil && resp.(container.VMCResp).Err != nil) {
 		err = fmt.Errorf("Error creating image: %s", err2)
+		return cds, err
 	}
 
+	//create the state database indexes packaged with the chaincode
+	err = chaincodeSupport.createStateDBIndexes(cds)
+
 	return cds, err
 }
 
+//createStateDBIndexes creates the indexes packaged under META-INF/statedb in the
+//chaincode's code package so rich queries against its state are indexed from the start
+func (chaincodeSupport *ChaincodeSupport) createStateDBIndexes(cds *pb.ChaincodeDeploymentSpec) error {
+	cID := cds.ChaincodeSpec.ChaincodeID
+	indexes, err := platforms.GetCouchDBIndexes(cID.Path, cds.CodePackage)
+	if err != nil {
+		return fmt.Errorf("error getting state database indexes for chaincode %s: %s", cID.Name, err)
+	}
+	if len(indexes) == 0 {
+		return nil
+	}
+
+	chaincodeLogger.Debugf("creating %d state database indexes for chaincode %s", len(indexes), cID.Name)
+	lgr := kvledger.GetLedger(string(chaincodeSupport.name))
+	if err = lgr.CreateStateDBIndexes(cID.Name, indexes); err != nil {
+		return fmt.Errorf("error creating state database indexes for chaincode %s: %s", cID.Name, err)
+	}
+
+	// build the new indexes in the background rather than on the first query that uses them
+	if kvledgerconfig.IsIndexWarmupEnabled() {
+		go func() {
+			if err := lgr.WarmUpStateDBIndexes(); err != nil {
+				chaincodeLogger.Warningf("error warming up state database indexes for chaincode %s: %s", cID.Name, err)
+			}
+		}()
+	}
+	return nil
+}
+
 // HandleChaincodeStream implements ccintf.HandleChaincodeStream for all vms to call with appropriate stream
 func (chaincodeSupport *ChaincodeSupport) HandleChaincodeStream(ctxt context.Context, stream ccintf.ChaincodeStream) error {
 	return HandleChaincodeStream(chaincodeSupport, ctxt, stream)
 }
 
 // Register the bidi stream entry point called by chaincode to register with the Peer.
+// The chaincodes of the chains joined by the peer name their chain in the
+// metadata of the stream, which is handled by the chaincode support of that chain
 func (chaincodeSupport *ChaincodeSupport) Register(stream pb.ChaincodeSupport_RegisterServer) error {
-	return chaincodeSupport.HandleChaincodeStream(stream.Context(), stream)
+	support := chaincodeSupport
+	if md, ok := metadata.FromContext(stream.Context()); ok && len(md[shim.ChainIDKey]) > 0 {
+		chainname := md[shim.ChainIDKey][0]
+		if support = GetChain(ChainName(chainname)); support == nil {
+			return fmt.Errorf("chain %s has no chaincode support", chainname)
+		}
+	}
+	return support.HandleChaincodeStream(stream.Context(), stream)
 }
 
 // createTransactionMessage creates a transaction message.
@@ -673,22 +1138,41 @@ func (chaincodeSupport *ChaincodeSupport) Execute(ctxt context.Context, chaincod
 	}
 	chaincodeSupport.runningChaincodes.Unlock()
 
+	start := time.Now()
+	span, ctxt := tracing.StartSpan(ctxt, "chaincode.Execute")
+	span.SetTag("chaincode", chaincode)
+	span.SetTag("type", msg.Type.String())
+	span.SetTag("txID", msg.Txid)
 	var notfy chan *pb.ChaincodeMessage
 	var err error
 	if notfy, err = chrte.handler.sendExecuteMessage(ctxt, msg, tx); err != nil {
-		return nil, fmt.Errorf("Error sending %s: %s", msg.Type.String(), err)
+		err = fmt.Errorf("Error sending %s: %s", msg.Type.String(), err)
+		span.Finish(err)
+		return nil, err
 	}
 	var ccresp *pb.ChaincodeMessage
 	select {
 	case ccresp = <-notfy:
 		//response is sent to user or calling chaincode. ChaincodeMessage_ERROR and ChaincodeMessage_QUERY_ERROR
 		//are typically treated as error
+		if ccresp.Type == pb.ChaincodeMessage_COMPLETED || ccresp.Type == pb.ChaincodeMessage_QUERY_COMPLETED {
+			chaincodeSupport.runningChaincodes.Lock()
+			delete(chaincodeSupport.runningChaincodes.relaunches, chaincode)
+			chaincodeSupport.runningChaincodes.Unlock()
+		}
+	case <-chrte.handler.terminated:
+		err = &ChaincodeUnavailableError{Chaincode: chaincode, Reason: "the chaincode stream ended during the transaction"}
 	case <-time.After(timeout):
-		err = fmt.Errorf("Timeout expired while executing transaction")
+		err = &ChaincodeTimeoutError{Chaincode: chaincode, Timeout: timeout}
 	}
 
 	//our responsibility to delete transaction context if sendExecuteMessage succeeded
 	chrte.handler.deleteTxContext(msg.Txid)
+	chaincodeSupport.metrics.observe(chaincode, msg, start, ccresp, err)
+	if ccresp != nil {
+		span.SetTag("response", ccresp.Type.String())
+	}
+	span.Finish(err)
 
 	return ccresp, err
 }
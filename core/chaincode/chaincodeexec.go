@@ -21,16 +21,17 @@ import (
 
 	"fmt"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric/core/util"
 	pb "github.com/hyperledger/fabric/protos"
 )
 
 //create a Transactions - this has to change to Proposal when we move chaincode to use Proposals
-func createTx(typ pb.Transaction_Type, ccname string, args [][]byte) (*pb.Transaction, error) {
+func createTx(typ pb.Transaction_Type, ccname string, input *pb.ChaincodeInput) (*pb.Transaction, error) {
 	var tx *pb.Transaction
 	var err error
 	uuid := util.GenerateUUID()
-	spec := &pb.ChaincodeInvocationSpec{ChaincodeSpec: &pb.ChaincodeSpec{Type: 1, ChaincodeID: &pb.ChaincodeID{Name: ccname}, CtorMsg: &pb.ChaincodeInput{Args: args}}}
+	spec := &pb.ChaincodeInvocationSpec{ChaincodeSpec: &pb.ChaincodeSpec{Type: 1, ChaincodeID: &pb.ChaincodeID{Name: ccname}, CtorMsg: input}}
 	tx, err = pb.NewChaincodeExecute(spec, uuid, typ)
 	if nil != err {
 		return nil, err
@@ -43,16 +44,44 @@ func GetCDSFromLCCC(ctxt context.Context, chainID string, chaincodeID string) ([
 	return payload, err
 }
 
+//GetChaincodeDataFromLCCC returns what lccc records on the chain of an
+//instantiated chaincode, including the system chaincodes endorsing and
+//validating its transactions
+func GetChaincodeDataFromLCCC(ctxt context.Context, chainID string, chaincodeID string) (*pb.ChaincodeInfo, error) {
+	payload, _, err := ExecuteChaincode(ctxt, pb.Transaction_CHAINCODE_INVOKE, string(DefaultChain), "lccc", [][]byte{[]byte(GETCCDATA), []byte(chainID), []byte(chaincodeID)})
+	if err != nil {
+		return nil, err
+	}
+
+	info := &pb.ChaincodeInfo{}
+	if err = proto.Unmarshal(payload, info); err != nil {
+		return nil, fmt.Errorf("Error unmarshalling the data of chaincode %s: %s", chaincodeID, err)
+	}
+	return info, nil
+}
+
 // ExecuteChaincode executes a given chaincode given chaincode name and arguments
 func ExecuteChaincode(ctxt context.Context, typ pb.Transaction_Type, chainname string, ccname string, args [][]byte) ([]byte, *pb.ChaincodeEvent, error) {
+	return ExecuteChaincodeInput(ctxt, typ, chainname, ccname, &pb.ChaincodeInput{Args: args})
+}
+
+// ExecuteChaincodeInput executes a given chaincode given chaincode name and
+// input, which carries the decorations of the peer along with the arguments
+func ExecuteChaincodeInput(ctxt context.Context, typ pb.Transaction_Type, chainname string, ccname string, input *pb.ChaincodeInput) ([]byte, *pb.ChaincodeEvent, error) {
 	var tx *pb.Transaction
 	var err error
 	var b []byte
 	var ccevent *pb.ChaincodeEvent
 
-	tx, err = createTx(typ, ccname, args)
-	b, ccevent, err = Execute(ctxt, GetChain(ChainName(chainname)), tx)
+	chaincodeSupport, err := GetChaincodeSupport(chainname, ccname)
 	if err != nil {
+		return nil, nil, err
+	}
+	tx, err = createTx(typ, ccname, input)
+	b, ccevent, err = Execute(ctxt, chaincodeSupport, tx)
+	if IsChaincodeUnavailable(err) || IsChaincodeTimeout(err) || IsChaincodeError(err) {
+		return nil, nil, err
+	} else if err != nil {
 		return nil, nil, fmt.Errorf("Error deploying chaincode: %s", err)
 	}
 	return b, ccevent, err
@@ -0,0 +1,95 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chaincode
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger"
+)
+
+// getChaincodeInstance splits the name of a called chaincode into the chaincode
+// name and the channel. The chaincode of another channel is called as "name/channel",
+// an empty channel means the channel of the calling chaincode
+func getChaincodeInstance(ccName string) (string, string) {
+	if i := strings.LastIndex(ccName, "/"); i >= 0 {
+		return ccName[:i], ccName[i+1:]
+	}
+	return ccName, ""
+}
+
+// readOnlyTxSimulator is the simulator of a chaincode called from another channel.
+// The called chaincode can read the state of its own channel but cannot write to
+// it, the call contributes nothing to the write set of the calling transaction
+type readOnlyTxSimulator struct {
+	ledger.TxSimulator
+	channel string
+}
+
+func (s *readOnlyTxSimulator) writeErr() error {
+	return fmt.Errorf("Cannot write the state of channel %s from a chaincode called from another channel", s.channel)
+}
+
+// SetState is not allowed on the called channel
+func (s *readOnlyTxSimulator) SetState(namespace string, key string, value []byte) error {
+	return s.writeErr()
+}
+
+// DeleteState is not allowed on the called channel
+func (s *readOnlyTxSimulator) DeleteState(namespace string, key string) error {
+	return s.writeErr()
+}
+
+// SetStateMultipleKeys is not allowed on the called channel
+func (s *readOnlyTxSimulator) SetStateMultipleKeys(namespace string, kvs map[string][]byte) error {
+	return s.writeErr()
+}
+
+// ExecuteUpdate is not allowed on the called channel
+func (s *readOnlyTxSimulator) ExecuteUpdate(query string) error {
+	return s.writeErr()
+}
+
+// CopyState is not allowed on the called channel
+func (s *readOnlyTxSimulator) CopyState(sourceNamespace string, targetNamespace string) error {
+	return s.writeErr()
+}
+
+// GetLedgerHeight is not allowed on the called channel, the read of the height
+// would not be validated with the calling transaction
+func (s *readOnlyTxSimulator) GetLedgerHeight() (uint64, error) {
+	return 0, fmt.Errorf("Cannot read the ledger height of channel %s from a chaincode called from another channel", s.channel)
+}
+
+// getCrossChannelTxSimulator returns a read only simulator on the ledger of the
+// given channel. The caller releases it with Done and discards its results
+func getCrossChannelTxSimulator(channel string) (ledger.TxSimulator, error) {
+	exists, err := kvledger.LedgerExists(channel)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("Channel %s not found", channel)
+	}
+	txsim, err := kvledger.GetLedger(channel).NewTxSimulator()
+	if err != nil {
+		return nil, err
+	}
+	return &readOnlyTxSimulator{TxSimulator: txsim, channel: channel}, nil
+}
@@ -0,0 +1,85 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chaincode
+
+import (
+	"testing"
+)
+
+func TestGetChaincodeInstance(t *testing.T) {
+	for _, test := range []struct{ ccName, name, channel string }{
+		{"mycc", "mycc", ""},
+		{"mycc/mychannel", "mycc", "mychannel"},
+		{"github.com/mycc/othercc", "github.com/mycc", "othercc"},
+	} {
+		name, channel := getChaincodeInstance(test.ccName)
+		if name != test.name || channel != test.channel {
+			t.Fatalf("Unexpected chaincode instance %s %s for %s", name, channel, test.ccName)
+		}
+	}
+}
+
+func TestReadOnlyTxSimulator(t *testing.T) {
+	txsim := &readOnlyTxSimulator{channel: "mychannel"}
+	if err := txsim.SetState("mycc", "key", []byte("value")); err == nil {
+		t.Fatalf("Expected an error setting the state from another channel")
+	}
+	if err := txsim.DeleteState("mycc", "key"); err == nil {
+		t.Fatalf("Expected an error deleting the state from another channel")
+	}
+	if err := txsim.SetStateMultipleKeys("mycc", map[string][]byte{"key": []byte("value")}); err == nil {
+		t.Fatalf("Expected an error setting the state from another channel")
+	}
+}
+
+func TestAddChain(t *testing.T) {
+	def := &ChaincodeSupport{name: DefaultChain, peerID: "peer0", runningChaincodes: &runningChaincodes{chaincodeMap: make(map[string]*chaincodeRTEnv)}}
+	chainsLock.Lock()
+	saved := chains[DefaultChain]
+	chains[DefaultChain] = def
+	chainsLock.Unlock()
+	defer func() {
+		chainsLock.Lock()
+		chains[DefaultChain] = saved
+		delete(chains, "mychannel")
+		chainsLock.Unlock()
+	}()
+
+	s, err := AddChain("mychannel")
+	if err != nil {
+		t.Fatalf("Failed to add the chain: %s", err)
+	}
+	if s == def || s.runningChaincodes == def.runningChaincodes || s.peerID != def.peerID {
+		t.Fatalf("Expected the settings of the default chain and chaincodes of its own")
+	}
+	if ccid := s.getCCID(nil); ccid.ChainID != "mychannel" {
+		t.Fatalf("Unexpected chain %s of the chaincode containers", ccid.ChainID)
+	}
+	if again, _ := AddChain("mychannel"); again != s {
+		t.Fatalf("Expected the chaincode support of the chain added already")
+	}
+
+	if got, _ := GetChaincodeSupport("mychannel", "mycc"); got != s {
+		t.Fatalf("Expected the chaincode support of the chain for a user chaincode")
+	}
+	if got, _ := GetChaincodeSupport("mychannel", "lccc"); got != def {
+		t.Fatalf("Expected the chaincode support of the default chain for a system chaincode")
+	}
+	if _, err = GetChaincodeSupport("otherchannel", "mycc"); err == nil {
+		t.Fatalf("Expected an error for a chain not joined")
+	}
+}
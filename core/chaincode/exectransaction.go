@@ -19,7 +19,6 @@ package chaincode
 import (
 	"errors"
 	"fmt"
-	"time"
 
 	"golang.org/x/net/context"
 
@@ -53,7 +52,9 @@ func Execute(ctxt context.Context, chain *ChaincodeSupport, t *pb.Transaction) (
 	} else if t.Type == pb.Transaction_CHAINCODE_INVOKE || t.Type == pb.Transaction_CHAINCODE_QUERY {
 		//will launch if necessary (and wait for ready)
 		cID, cMsg, err := chain.Launch(ctxt, t)
-		if err != nil {
+		if IsChaincodeUnavailable(err) {
+			return nil, nil, err
+		} else if err != nil {
 			return nil, nil, fmt.Errorf("Failed to launch chaincode spec(%s)", err)
 		}
 
@@ -64,8 +65,7 @@ func Execute(ctxt context.Context, chain *ChaincodeSupport, t *pb.Transaction) (
 			return nil, nil, fmt.Errorf("Failed to stablish stream to container %s", chaincode)
 		}
 
-		// TODO: Need to comment next line and uncomment call to getTimeout, when transaction blocks are being created
-		timeout := time.Duration(30000) * time.Millisecond
+		timeout := chain.getExecuteTimeout(chaincode)
 
 		if err != nil {
 			return nil, nil, fmt.Errorf("Failed to retrieve chaincode spec(%s)", err)
@@ -85,7 +85,11 @@ func Execute(ctxt context.Context, chain *ChaincodeSupport, t *pb.Transaction) (
 		}
 
 		resp, err := chain.Execute(ctxt, chaincode, ccMsg, timeout, t)
-		if err != nil {
+		if IsChaincodeUnavailable(err) || IsChaincodeTimeout(err) {
+			// the client may retry once the chaincode is relaunched, or
+			// learns that the chaincode ran out of time
+			return nil, nil, err
+		} else if err != nil {
 			// Rollback transaction
 			return nil, nil, fmt.Errorf("Failed to execute transaction or query(%s)", err)
 		} else if resp == nil {
@@ -102,7 +106,7 @@ func Execute(ctxt context.Context, chain *ChaincodeSupport, t *pb.Transaction) (
 				return resp.Payload, resp.ChaincodeEvent, nil
 			} else if resp.Type == pb.ChaincodeMessage_ERROR || resp.Type == pb.ChaincodeMessage_QUERY_ERROR {
 				// Rollback transaction
-				return nil, resp.ChaincodeEvent, fmt.Errorf("Transaction or query returned with failure: %s", string(resp.Payload))
+				return nil, resp.ChaincodeEvent, newChaincodeError(chaincode, resp)
 			}
 			return resp.Payload, nil, fmt.Errorf("receive a response for (%s) but in invalid state(%d)", t.Txid, resp.Type)
 		}
@@ -37,10 +37,8 @@ const (
 	createdstate     = "created"     //start state
 	establishedstate = "established" //in: CREATED, rcv:  REGISTER, send: REGISTERED, INIT
 	initstate        = "init"        //in:ESTABLISHED, rcv:-, send: INIT
-	readystate       = "ready"       //in:ESTABLISHED,TRANSACTION, rcv:COMPLETED
-	transactionstate = "transaction" //in:READY, rcv: xact from consensus, send: TRANSACTION
+	readystate       = "ready"       //in:ESTABLISHED,INIT, send: TRANSACTION, QUERY, executed concurrently by txid
 	busyinitstate    = "busyinit"    //in:INIT, rcv: PUT_STATE, DEL_STATE, INVOKE_CHAINCODE
-	busyxactstate    = "busyxact"    //in:TRANSACION, rcv: PUT_STATE, DEL_STATE, INVOKE_CHAINCODE
 	endstate         = "end"         //in:INIT,ESTABLISHED, rcv: error, terminate container
 
 )
@@ -59,6 +57,8 @@ type transactionContext struct {
 
 	// tracks open iterators used for range queries
 	rangeQueryIteratorMap map[string]ledger.ResultsIterator
+	// number of results returned by the iterators of the transaction, checked against the total query limit
+	queryResultCount int
 
 	txsimulator ledger.TxSimulator
 }
@@ -94,6 +94,13 @@ type Handler struct {
 
 	// used to do Send after making sure the state transition is complete
 	nextState chan *nextStateInfo
+
+	// closed when the stream ends, failing the transactions still waiting for the chaincode
+	terminated chan struct{}
+	// receives the error of a send that timed out, which ends the stream
+	streamFailed chan error
+	// keepalives sent since the chaincode last sent a message
+	missedKeepalives int
 }
 
 func shorttxid(txid string) string {
@@ -105,12 +112,36 @@ func shorttxid(txid string) string {
 
 func (handler *Handler) serialSend(msg *pb.ChaincodeMessage) error {
 	handler.serialLock.Lock()
-	defer handler.serialLock.Unlock()
-	if err := handler.ChatStream.Send(msg); err != nil {
-		chaincodeLogger.Errorf("Error sending %s: %s", msg.Type.String(), err)
-		return fmt.Errorf("Error sending %s: %s", msg.Type.String(), err)
+	timeout := handler.chaincodeSupport.streamSendTimeout
+	if timeout <= 0 {
+		defer handler.serialLock.Unlock()
+		if err := handler.ChatStream.Send(msg); err != nil {
+			chaincodeLogger.Errorf("Error sending %s: %s", msg.Type.String(), err)
+			return fmt.Errorf("Error sending %s: %s", msg.Type.String(), err)
+		}
+		return nil
+	}
+
+	//the send keeps the lock until it returns, the stream is never sent on
+	//concurrently. Once it timed out it returns when the stream has ended
+	sent := make(chan error, 1)
+	go func() {
+		defer handler.serialLock.Unlock()
+		sent <- handler.ChatStream.Send(msg)
+	}()
+	select {
+	case err := <-sent:
+		if err != nil {
+			chaincodeLogger.Errorf("Error sending %s: %s", msg.Type.String(), err)
+			return fmt.Errorf("Error sending %s: %s", msg.Type.String(), err)
+		}
+		return nil
+	case <-time.After(timeout):
+		err := fmt.Errorf("Timeout expired sending %s after %s, ending chaincode support stream", msg.Type.String(), timeout)
+		chaincodeLogger.Errorf("%s", err)
+		handler.endStream(err)
+		return err
 	}
-	return nil
 }
 
 func (handler *Handler) createTxContext(ctxt context.Context, txid string, tx *pb.Transaction) (*transactionContext, error) {
@@ -140,15 +171,53 @@ func (handler *Handler) deleteTxContext(txid string) {
 	handler.Lock()
 	defer handler.Unlock()
 	if handler.txCtxs != nil {
+		if txContext := handler.txCtxs[txid]; txContext != nil {
+			handler.closeQueryIterators(txContext, txid)
+		}
 		delete(handler.txCtxs, txid)
 	}
 }
 
+// closeQueryIterators closes the iterators that the chaincode left open at the end of the
+// transaction. The caller must hold the handler lock
+func (handler *Handler) closeQueryIterators(txContext *transactionContext, txid string) {
+	if len(txContext.rangeQueryIteratorMap) == 0 {
+		return
+	}
+	handler.chaincodeSupport.metrics.leakedIterators.With("chaincode", handler.ChaincodeID.Name).Add(float64(len(txContext.rangeQueryIteratorMap)))
+	chaincodeLogger.Warningf("[%s]Closing %d query iterators left open by chaincode %s",
+		shorttxid(txid), len(txContext.rangeQueryIteratorMap), handler.ChaincodeID.Name)
+	for id, iter := range txContext.rangeQueryIteratorMap {
+		iter.Close()
+		delete(txContext.rangeQueryIteratorMap, id)
+	}
+}
+
 func (handler *Handler) putRangeQueryIterator(txContext *transactionContext, txid string,
-	rangeScanIterator ledger.ResultsIterator) {
+	rangeScanIterator ledger.ResultsIterator) error {
 	handler.Lock()
 	defer handler.Unlock()
+	if maxOpen := handler.maxOpenQueryIterators(); maxOpen > 0 && len(txContext.rangeQueryIteratorMap) >= maxOpen {
+		return fmt.Errorf("Too many open query iterators, at most %d iterators can be open in a transaction", maxOpen)
+	}
 	txContext.rangeQueryIteratorMap[txid] = rangeScanIterator
+	return nil
+}
+
+// maxOpenQueryIterators returns the maximum number of iterators a transaction can have open, 0 for no limit
+func (handler *Handler) maxOpenQueryIterators() int {
+	if handler.chaincodeSupport == nil {
+		return 0
+	}
+	return handler.chaincodeSupport.maxOpenQueryIterators
+}
+
+// totalQueryLimit returns the maximum number of results returned by the iterators of a transaction, 0 for no limit
+func (handler *Handler) totalQueryLimit() int {
+	if handler.chaincodeSupport == nil {
+		return 0
+	}
+	return handler.chaincodeSupport.totalQueryLimit
 }
 
 func (handler *Handler) getRangeQueryIterator(txContext *transactionContext, txid string) ledger.ResultsIterator {
@@ -259,6 +328,14 @@ func (handler *Handler) getSecurityBinding(tx *pb.Transaction) ([]byte, error) {
 	return secHelper.GetTransactionBinding(tx)
 }
 
+//endStream ends the stream of the chaincode with err, unless it is already ending
+func (handler *Handler) endStream(err error) {
+	select {
+	case handler.streamFailed <- err:
+	default:
+	}
+}
+
 func (handler *Handler) deregister() error {
 	if handler.registered {
 		handler.chaincodeSupport.deregisterHandler(handler)
@@ -280,8 +357,20 @@ func (handler *Handler) waitForKeepaliveTimer() <-chan time.Time {
 	return c
 }
 
+//waitForRecvTimer fires once nothing was received from the chaincode for the
+//receive timeout since lastRecv
+func (handler *Handler) waitForRecvTimer(lastRecv time.Time) <-chan time.Time {
+	if handler.chaincodeSupport.streamRecvTimeout > 0 {
+		return time.After(handler.chaincodeSupport.streamRecvTimeout - time.Since(lastRecv))
+	}
+	//no one will signal this channel, listner blocks forever
+	c := make(chan time.Time, 1)
+	return c
+}
+
 func (handler *Handler) processStream() error {
 	defer handler.deregister()
+	defer close(handler.terminated)
 	msgAvail := make(chan *pb.ChaincodeMessage)
 	var nsInfo *nextStateInfo
 	var in *pb.ChaincodeMessage
@@ -290,6 +379,7 @@ func (handler *Handler) processStream() error {
 	//recv is used to spin Recv routine after previous received msg
 	//has been processed
 	recv := true
+	lastRecv := time.Now()
 	for {
 		in = nil
 		err = nil
@@ -323,6 +413,8 @@ func (handler *Handler) processStream() error {
 
 			// we can spin off another Recv again
 			recv = true
+			lastRecv = time.Now()
+			handler.missedKeepalives = 0
 
 			if in.Type == pb.ChaincodeMessage_KEEPALIVE {
 				chaincodeLogger.Debug("Received KEEPALIVE Response")
@@ -344,7 +436,13 @@ func (handler *Handler) processStream() error {
 				continue
 			}
 
-			//TODO we could use this to hook into container lifecycle (kill the chaincode if not in use, etc)
+			//the chaincode answers keepalives, one that stopped doing so is hung
+			if misses := handler.chaincodeSupport.keepaliveMisses; misses > 0 && handler.missedKeepalives >= misses {
+				err = fmt.Errorf("chaincode did not answer %d keepalives, ending chaincode support stream", handler.missedKeepalives)
+				chaincodeLogger.Errorf("%s", err)
+				return err
+			}
+			handler.missedKeepalives++
 			kaerr := handler.serialSend(&pb.ChaincodeMessage{Type: pb.ChaincodeMessage_KEEPALIVE})
 			if kaerr != nil {
 				chaincodeLogger.Errorf("Error sending keepalive, err=%s", kaerr)
@@ -353,6 +451,12 @@ func (handler *Handler) processStream() error {
 			}
 			//keepalive message kicked in. just continue
 			continue
+		case <-handler.waitForRecvTimer(lastRecv):
+			err = fmt.Errorf("received nothing from the chaincode for %s, ending chaincode support stream", handler.chaincodeSupport.streamRecvTimeout)
+			chaincodeLogger.Errorf("%s", err)
+			return err
+		case err = <-handler.streamFailed:
+			return err
 		}
 
 		err = handler.HandleMessage(in)
@@ -386,6 +490,8 @@ func newChaincodeSupportHandler(chaincodeSupport *ChaincodeSupport, peerChatStre
 	v.chaincodeSupport = chaincodeSupport
 	//we want this to block
 	v.nextState = make(chan *nextStateInfo)
+	v.terminated = make(chan struct{})
+	v.streamFailed = make(chan error, 1)
 
 	v.FSM = fsm.NewFSM(
 		createdstate,
@@ -394,40 +500,34 @@ func newChaincodeSupportHandler(chaincodeSupport *ChaincodeSupport, peerChatStre
 			{Name: pb.ChaincodeMessage_REGISTER.String(), Src: []string{createdstate}, Dst: establishedstate},
 			{Name: pb.ChaincodeMessage_INIT.String(), Src: []string{establishedstate}, Dst: initstate},
 			{Name: pb.ChaincodeMessage_READY.String(), Src: []string{establishedstate}, Dst: readystate},
-			{Name: pb.ChaincodeMessage_TRANSACTION.String(), Src: []string{readystate}, Dst: transactionstate},
-			{Name: pb.ChaincodeMessage_PUT_STATE.String(), Src: []string{transactionstate}, Dst: busyxactstate},
-			{Name: pb.ChaincodeMessage_DEL_STATE.String(), Src: []string{transactionstate}, Dst: busyxactstate},
-			{Name: pb.ChaincodeMessage_INVOKE_CHAINCODE.String(), Src: []string{transactionstate}, Dst: busyxactstate},
 			{Name: pb.ChaincodeMessage_PUT_STATE.String(), Src: []string{initstate}, Dst: busyinitstate},
 			{Name: pb.ChaincodeMessage_DEL_STATE.String(), Src: []string{initstate}, Dst: busyinitstate},
 			{Name: pb.ChaincodeMessage_INVOKE_CHAINCODE.String(), Src: []string{initstate}, Dst: busyinitstate},
-			{Name: pb.ChaincodeMessage_COMPLETED.String(), Src: []string{initstate, readystate, transactionstate}, Dst: readystate},
+			{Name: pb.ChaincodeMessage_COMPLETED.String(), Src: []string{initstate}, Dst: readystate},
 			{Name: pb.ChaincodeMessage_GET_STATE.String(), Src: []string{readystate}, Dst: readystate},
 			{Name: pb.ChaincodeMessage_GET_STATE.String(), Src: []string{initstate}, Dst: initstate},
 			{Name: pb.ChaincodeMessage_GET_STATE.String(), Src: []string{busyinitstate}, Dst: busyinitstate},
-			{Name: pb.ChaincodeMessage_GET_STATE.String(), Src: []string{transactionstate}, Dst: transactionstate},
-			{Name: pb.ChaincodeMessage_GET_STATE.String(), Src: []string{busyxactstate}, Dst: busyxactstate},
 			{Name: pb.ChaincodeMessage_RANGE_QUERY_STATE.String(), Src: []string{readystate}, Dst: readystate},
 			{Name: pb.ChaincodeMessage_RANGE_QUERY_STATE.String(), Src: []string{initstate}, Dst: initstate},
 			{Name: pb.ChaincodeMessage_RANGE_QUERY_STATE.String(), Src: []string{busyinitstate}, Dst: busyinitstate},
-			{Name: pb.ChaincodeMessage_RANGE_QUERY_STATE.String(), Src: []string{transactionstate}, Dst: transactionstate},
-			{Name: pb.ChaincodeMessage_RANGE_QUERY_STATE.String(), Src: []string{busyxactstate}, Dst: busyxactstate},
 			{Name: pb.ChaincodeMessage_RANGE_QUERY_STATE_NEXT.String(), Src: []string{readystate}, Dst: readystate},
 			{Name: pb.ChaincodeMessage_RANGE_QUERY_STATE_NEXT.String(), Src: []string{initstate}, Dst: initstate},
 			{Name: pb.ChaincodeMessage_RANGE_QUERY_STATE_NEXT.String(), Src: []string{busyinitstate}, Dst: busyinitstate},
-			{Name: pb.ChaincodeMessage_RANGE_QUERY_STATE_NEXT.String(), Src: []string{transactionstate}, Dst: transactionstate},
-			{Name: pb.ChaincodeMessage_RANGE_QUERY_STATE_NEXT.String(), Src: []string{busyxactstate}, Dst: busyxactstate},
 			{Name: pb.ChaincodeMessage_RANGE_QUERY_STATE_CLOSE.String(), Src: []string{readystate}, Dst: readystate},
 			{Name: pb.ChaincodeMessage_RANGE_QUERY_STATE_CLOSE.String(), Src: []string{initstate}, Dst: initstate},
 			{Name: pb.ChaincodeMessage_RANGE_QUERY_STATE_CLOSE.String(), Src: []string{busyinitstate}, Dst: busyinitstate},
-			{Name: pb.ChaincodeMessage_RANGE_QUERY_STATE_CLOSE.String(), Src: []string{transactionstate}, Dst: transactionstate},
-			{Name: pb.ChaincodeMessage_RANGE_QUERY_STATE_CLOSE.String(), Src: []string{busyxactstate}, Dst: busyxactstate},
+			{Name: pb.ChaincodeMessage_GET_QUERY_RESULT.String(), Src: []string{readystate}, Dst: readystate},
+			{Name: pb.ChaincodeMessage_GET_QUERY_RESULT.String(), Src: []string{initstate}, Dst: initstate},
+			{Name: pb.ChaincodeMessage_GET_QUERY_RESULT.String(), Src: []string{busyinitstate}, Dst: busyinitstate},
+			{Name: pb.ChaincodeMessage_GET_STATE_MULTIPLE.String(), Src: []string{readystate}, Dst: readystate},
+			{Name: pb.ChaincodeMessage_GET_STATE_MULTIPLE.String(), Src: []string{initstate}, Dst: initstate},
+			{Name: pb.ChaincodeMessage_GET_STATE_MULTIPLE.String(), Src: []string{busyinitstate}, Dst: busyinitstate},
+			{Name: pb.ChaincodeMessage_GET_LEDGER_HEIGHT.String(), Src: []string{readystate}, Dst: readystate},
+			{Name: pb.ChaincodeMessage_GET_LEDGER_HEIGHT.String(), Src: []string{initstate}, Dst: initstate},
+			{Name: pb.ChaincodeMessage_GET_LEDGER_HEIGHT.String(), Src: []string{busyinitstate}, Dst: busyinitstate},
 			{Name: pb.ChaincodeMessage_ERROR.String(), Src: []string{initstate}, Dst: endstate},
-			{Name: pb.ChaincodeMessage_ERROR.String(), Src: []string{transactionstate}, Dst: readystate},
 			{Name: pb.ChaincodeMessage_ERROR.String(), Src: []string{busyinitstate}, Dst: initstate},
-			{Name: pb.ChaincodeMessage_ERROR.String(), Src: []string{busyxactstate}, Dst: transactionstate},
 			{Name: pb.ChaincodeMessage_RESPONSE.String(), Src: []string{busyinitstate}, Dst: initstate},
-			{Name: pb.ChaincodeMessage_RESPONSE.String(), Src: []string{busyxactstate}, Dst: transactionstate},
 		},
 		fsm.Callbacks{
 			"before_" + pb.ChaincodeMessage_REGISTER.String():               func(e *fsm.Event) { v.beforeRegisterEvent(e, v.FSM.Current()) },
@@ -437,6 +537,9 @@ func newChaincodeSupportHandler(chaincodeSupport *ChaincodeSupport, peerChatStre
 			"after_" + pb.ChaincodeMessage_RANGE_QUERY_STATE.String():       func(e *fsm.Event) { v.afterRangeQueryState(e, v.FSM.Current()) },
 			"after_" + pb.ChaincodeMessage_RANGE_QUERY_STATE_NEXT.String():  func(e *fsm.Event) { v.afterRangeQueryStateNext(e, v.FSM.Current()) },
 			"after_" + pb.ChaincodeMessage_RANGE_QUERY_STATE_CLOSE.String(): func(e *fsm.Event) { v.afterRangeQueryStateClose(e, v.FSM.Current()) },
+			"after_" + pb.ChaincodeMessage_GET_QUERY_RESULT.String():        func(e *fsm.Event) { v.afterGetQueryResult(e, v.FSM.Current()) },
+			"after_" + pb.ChaincodeMessage_GET_STATE_MULTIPLE.String():      func(e *fsm.Event) { v.afterGetStateMultiple(e, v.FSM.Current()) },
+			"after_" + pb.ChaincodeMessage_GET_LEDGER_HEIGHT.String():       func(e *fsm.Event) { v.afterGetLedgerHeight(e, v.FSM.Current()) },
 			"after_" + pb.ChaincodeMessage_PUT_STATE.String():               func(e *fsm.Event) { v.afterPutState(e, v.FSM.Current()) },
 			"after_" + pb.ChaincodeMessage_DEL_STATE.String():               func(e *fsm.Event) { v.afterDelState(e, v.FSM.Current()) },
 			"after_" + pb.ChaincodeMessage_INVOKE_CHAINCODE.String():        func(e *fsm.Event) { v.afterInvokeChaincode(e, v.FSM.Current()) },
@@ -444,7 +547,6 @@ func newChaincodeSupportHandler(chaincodeSupport *ChaincodeSupport, peerChatStre
 			"enter_" + initstate:                                            func(e *fsm.Event) { v.enterInitState(e, v.FSM.Current()) },
 			"enter_" + readystate:                                           func(e *fsm.Event) { v.enterReadyState(e, v.FSM.Current()) },
 			"enter_" + busyinitstate:                                        func(e *fsm.Event) { v.enterBusyState(e, v.FSM.Current()) },
-			"enter_" + busyxactstate:                                        func(e *fsm.Event) { v.enterBusyState(e, v.FSM.Current()) },
 			"enter_" + endstate:                                             func(e *fsm.Event) { v.enterEndState(e, v.FSM.Current()) },
 		},
 	)
@@ -556,9 +658,7 @@ func (handler *Handler) notify(msg *pb.ChaincodeMessage) {
 		tctx.responseNotifier <- msg
 
 		// clean up rangeQueryIteratorMap
-		for _, v := range tctx.rangeQueryIteratorMap {
-			v.Close()
-		}
+		handler.closeQueryIterators(tctx, msg.Txid)
 	}
 }
 
@@ -700,6 +800,11 @@ func (handler *Handler) handleRangeQueryState(msg *pb.ChaincodeMessage) {
 			return
 		}
 
+		if rangeQueryState.PageSize != 0 {
+			serialSendMsg = handler.getPaginatedRangeQueryResponse(msg, rangeQueryState)
+			return
+		}
+
 		iterID := util.GenerateUUID()
 		txContext := handler.getTxContext(msg.Txid)
 
@@ -714,60 +819,145 @@ func (handler *Handler) handleRangeQueryState(msg *pb.ChaincodeMessage) {
 			return
 		}
 
-		handler.putRangeQueryIterator(txContext, iterID, rangeIter)
+		if err = handler.putRangeQueryIterator(txContext, iterID, rangeIter); err != nil {
+			rangeIter.Close()
+			chaincodeLogger.Errorf("[%s]%s. Sending %s", shorttxid(msg.Txid), err, pb.ChaincodeMessage_ERROR)
+			serialSendMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: []byte(err.Error()), Txid: msg.Txid}
+			return
+		}
 
-		var keysAndValues []*pb.RangeQueryStateKeyValue
-		var i = uint32(0)
-		var qresult ledger.QueryResult
-		for ; i < maxRangeQueryStateLimit; i++ {
-			qresult, err := rangeIter.Next()
-			if err != nil {
-				chaincodeLogger.Errorf("Failed to get query result from iterator. Sending %s", pb.ChaincodeMessage_ERROR)
-				return
-			}
-			if qresult == nil {
-				break
-			}
-			//PDMP - let it panic if not KV
-			kv := qresult.(ledger.KV)
-			// Decrypt the data if the confidential is enabled
-			decryptedValue, decryptErr := handler.decrypt(msg.Txid, kv.Value)
-			if decryptErr != nil {
-				payload := []byte(decryptErr.Error())
-				chaincodeLogger.Errorf("Failed decrypt value. Sending %s", pb.ChaincodeMessage_ERROR)
-				serialSendMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: payload, Txid: msg.Txid}
+		serialSendMsg = handler.getQueryResultBatch(txContext, iterID, rangeIter, msg.Txid)
+	}()
+}
 
-				rangeIter.Close()
-				handler.deleteRangeQueryIterator(txContext, iterID)
+// getPaginatedRangeQueryResponse executes a paginated range query and returns the message to be sent to the chaincode
+func (handler *Handler) getPaginatedRangeQueryResponse(msg *pb.ChaincodeMessage, rangeQueryState *pb.RangeQueryState) *pb.ChaincodeMessage {
+	if err := handler.validatePaginatedQuery(msg.Txid); err != nil {
+		chaincodeLogger.Errorf("[%s]Invalid paginated range query: %s. Sending %s", shorttxid(msg.Txid), err, pb.ChaincodeMessage_ERROR)
+		return &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: []byte(err.Error()), Txid: msg.Txid}
+	}
 
-				return
-			}
-			keyAndValue := pb.RangeQueryStateKeyValue{Key: kv.Key, Value: decryptedValue}
-			keysAndValues = append(keysAndValues, &keyAndValue)
-		}
+	txContext := handler.getTxContext(msg.Txid)
+	chaincodeID := handler.ChaincodeID.Name
 
-		if qresult != nil {
-			rangeIter.Close()
-			handler.deleteRangeQueryIterator(txContext, iterID)
+	pageIter, err := txContext.txsimulator.GetStateRangeScanIteratorWithPagination(chaincodeID,
+		rangeQueryState.StartKey, rangeQueryState.EndKey, rangeQueryState.PageSize, rangeQueryState.Bookmark)
+	if err != nil {
+		chaincodeLogger.Errorf("[%s]Failed to get ledger scan iterator. Sending %s", shorttxid(msg.Txid), pb.ChaincodeMessage_ERROR)
+		return &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: []byte(err.Error()), Txid: msg.Txid}
+	}
+
+	return handler.getPageResponse(msg.Txid, pageIter)
+}
+
+// validatePaginatedQuery checks that a paginated query is not issued in transaction context. Paginated queries
+// are executed outside of the read set, so they cannot be validated at commit time. The page size is checked
+// by the ledger against the configured default and maximum page sizes
+func (handler *Handler) validatePaginatedQuery(txid string) error {
+	if handler.getIsTransaction(txid) {
+		return fmt.Errorf("Paginated queries are not allowed in transaction context")
+	}
+	return nil
+}
+
+// getPageResponse reads a whole page from the iterator and builds the response message for the chaincode.
+// The iterator is closed before returning, so no iterator is retained for subsequent requests
+func (handler *Handler) getPageResponse(txid string, pageIter ledger.PaginatedResultsIterator) *pb.ChaincodeMessage {
+	defer pageIter.Close()
+
+	var keysAndValues []*pb.RangeQueryStateKeyValue
+	for {
+		qresult, err := pageIter.Next()
+		if err != nil {
+			chaincodeLogger.Errorf("[%s]Failed to get query result from iterator. Sending %s", shorttxid(txid), pb.ChaincodeMessage_ERROR)
+			return &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: []byte(err.Error()), Txid: txid}
 		}
+		if qresult == nil {
+			break
+		}
+		//PDMP - let it panic if not KV
+		kv := qresult.(ledger.KV)
+		// Decrypt the data if the confidential is enabled
+		decryptedValue, decryptErr := handler.decrypt(txid, kv.Value)
+		if decryptErr != nil {
+			chaincodeLogger.Errorf("[%s]Failed decrypt value. Sending %s", shorttxid(txid), pb.ChaincodeMessage_ERROR)
+			return &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: []byte(decryptErr.Error()), Txid: txid}
+		}
+		keysAndValues = append(keysAndValues, &pb.RangeQueryStateKeyValue{Key: kv.Key, Value: decryptedValue})
+	}
 
-		payload := &pb.RangeQueryStateResponse{KeysAndValues: keysAndValues, HasMore: qresult != nil, ID: iterID}
-		payloadBytes, err := proto.Marshal(payload)
+	metadata := &pb.QueryResponseMetadata{FetchedRecordsCount: int32(len(keysAndValues)), Bookmark: pageIter.GetBookmark()}
+	payload := &pb.RangeQueryStateResponse{KeysAndValues: keysAndValues, HasMore: false, Metadata: metadata}
+	payloadBytes, err := proto.Marshal(payload)
+	if err != nil {
+		chaincodeLogger.Errorf("[%s]Failed marshall response. Sending %s", shorttxid(txid), pb.ChaincodeMessage_ERROR)
+		return &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: []byte(err.Error()), Txid: txid}
+	}
+
+	chaincodeLogger.Debugf("[%s]Got page of %d keys and values. Sending %s", shorttxid(txid), len(keysAndValues), pb.ChaincodeMessage_RESPONSE)
+	return &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_RESPONSE, Payload: payloadBytes, Txid: txid}
+}
+
+// getQueryResultBatch reads up to maxRangeQueryStateLimit results from the iterator and builds the response
+// message for the chaincode. The iterator is closed and removed from the transaction context once it is
+// exhausted or fails, otherwise the chaincode fetches the following batch with RANGE_QUERY_STATE_NEXT.
+// Once the iterators of the transaction have returned the total query limit of results, the iterator
+// is treated as exhausted
+func (handler *Handler) getQueryResultBatch(txContext *transactionContext, iterID string, iter ledger.ResultsIterator, txid string) *pb.ChaincodeMessage {
+	var keysAndValues []*pb.RangeQueryStateKeyValue
+	var qresult ledger.QueryResult
+	var err error
+	totalQueryLimit := handler.totalQueryLimit()
+	for i := 0; i < maxRangeQueryStateLimit; i++ {
+		if totalQueryLimit > 0 && txContext.queryResultCount >= totalQueryLimit {
+			chaincodeLogger.Warningf("[%s]Total query limit of %d results reached, the query results are truncated", shorttxid(txid), totalQueryLimit)
+			qresult = nil
+			break
+		}
+		qresult, err = iter.Next()
 		if err != nil {
-			rangeIter.Close()
+			iter.Close()
 			handler.deleteRangeQueryIterator(txContext, iterID)
-
-			// Send error msg back to chaincode. GetState will not trigger event
-			payload := []byte(err.Error())
-			chaincodeLogger.Errorf("Failed marshall resopnse. Sending %s", pb.ChaincodeMessage_ERROR)
-			serialSendMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: payload, Txid: msg.Txid}
-			return
+			chaincodeLogger.Errorf("Failed to get query result from iterator. Sending %s", pb.ChaincodeMessage_ERROR)
+			return &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: []byte(err.Error()), Txid: txid}
+		}
+		if qresult == nil {
+			break
+		}
+		//PDMP - let it panic if not KV
+		kv := qresult.(ledger.KV)
+		// Decrypt the data if the confidential is enabled
+		decryptedValue, decryptErr := handler.decrypt(txid, kv.Value)
+		if decryptErr != nil {
+			iter.Close()
+			handler.deleteRangeQueryIterator(txContext, iterID)
+			chaincodeLogger.Errorf("Failed decrypt value. Sending %s", pb.ChaincodeMessage_ERROR)
+			return &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: []byte(decryptErr.Error()), Txid: txid}
 		}
+		keysAndValues = append(keysAndValues, &pb.RangeQueryStateKeyValue{Key: kv.Key, Value: decryptedValue})
+		txContext.queryResultCount++
+	}
 
-		chaincodeLogger.Debugf("Got keys and values. Sending %s", pb.ChaincodeMessage_RESPONSE)
-		serialSendMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_RESPONSE, Payload: payloadBytes, Txid: msg.Txid}
+	// a nil result means the iterator is exhausted
+	hasMore := qresult != nil
+	if !hasMore {
+		iter.Close()
+		handler.deleteRangeQueryIterator(txContext, iterID)
+	}
 
-	}()
+	payload := &pb.RangeQueryStateResponse{KeysAndValues: keysAndValues, HasMore: hasMore, ID: iterID}
+	payloadBytes, err := proto.Marshal(payload)
+	if err != nil {
+		iter.Close()
+		handler.deleteRangeQueryIterator(txContext, iterID)
+
+		// Send error msg back to chaincode. GetState will not trigger event
+		chaincodeLogger.Errorf("Failed marshall resopnse. Sending %s", pb.ChaincodeMessage_ERROR)
+		return &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: []byte(err.Error()), Txid: txid}
+	}
+
+	chaincodeLogger.Debugf("Got keys and values. Sending %s", pb.ChaincodeMessage_RESPONSE)
+	return &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_RESPONSE, Payload: payloadBytes, Txid: txid}
 }
 
 // afterRangeQueryState handles a RANGE_QUERY_STATE_NEXT request from the chaincode.
@@ -825,59 +1015,7 @@ func (handler *Handler) handleRangeQueryStateNext(msg *pb.ChaincodeMessage) {
 			return
 		}
 
-		var keysAndValues []*pb.RangeQueryStateKeyValue
-		var i = uint32(0)
-
-		var qresult ledger.QueryResult
-		var err error
-		for ; i < maxRangeQueryStateLimit; i++ {
-			qresult, err = rangeIter.Next()
-			if err != nil {
-				chaincodeLogger.Errorf("Failed to get query result from iterator. Sending %s", pb.ChaincodeMessage_ERROR)
-				return
-			}
-			if qresult != nil {
-				break
-			}
-			//PDMP - let it panic if not KV
-			kv := qresult.(ledger.KV)
-			// Decrypt the data if the confidential is enabled
-			decryptedValue, decryptErr := handler.decrypt(msg.Txid, kv.Value)
-			if decryptErr != nil {
-				payload := []byte(decryptErr.Error())
-				chaincodeLogger.Errorf("Failed decrypt value. Sending %s", pb.ChaincodeMessage_ERROR)
-				serialSendMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: payload, Txid: msg.Txid}
-
-				rangeIter.Close()
-				handler.deleteRangeQueryIterator(txContext, rangeQueryStateNext.ID)
-
-				return
-			}
-			keyAndValue := pb.RangeQueryStateKeyValue{Key: kv.Key, Value: decryptedValue}
-			keysAndValues = append(keysAndValues, &keyAndValue)
-		}
-
-		if qresult != nil {
-			rangeIter.Close()
-			handler.deleteRangeQueryIterator(txContext, rangeQueryStateNext.ID)
-		}
-
-		payload := &pb.RangeQueryStateResponse{KeysAndValues: keysAndValues, HasMore: qresult != nil, ID: rangeQueryStateNext.ID}
-		payloadBytes, err := proto.Marshal(payload)
-		if err != nil {
-			rangeIter.Close()
-			handler.deleteRangeQueryIterator(txContext, rangeQueryStateNext.ID)
-
-			// Send error msg back to chaincode. GetState will not trigger event
-			payload := []byte(err.Error())
-			chaincodeLogger.Errorf("Failed marshall resopnse. Sending %s", pb.ChaincodeMessage_ERROR)
-			serialSendMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: payload, Txid: msg.Txid}
-			return
-		}
-
-		chaincodeLogger.Debugf("Got keys and values. Sending %s", pb.ChaincodeMessage_RESPONSE)
-		serialSendMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_RESPONSE, Payload: payloadBytes, Txid: msg.Txid}
-
+		serialSendMsg = handler.getQueryResultBatch(txContext, rangeQueryStateNext.ID, rangeIter, msg.Txid)
 	}()
 }
 
@@ -950,6 +1088,236 @@ func (handler *Handler) handleRangeQueryStateClose(msg *pb.ChaincodeMessage) {
 	}()
 }
 
+// afterGetQueryResult handles a GET_QUERY_RESULT request from the chaincode.
+func (handler *Handler) afterGetQueryResult(e *fsm.Event, state string) {
+	msg, ok := e.Args[0].(*pb.ChaincodeMessage)
+	if !ok {
+		e.Cancel(fmt.Errorf("Received unexpected message type"))
+		return
+	}
+	chaincodeLogger.Debugf("Received %s, invoking rich query on ledger", pb.ChaincodeMessage_GET_QUERY_RESULT)
+
+	// Query ledger for a page of results
+	handler.handleGetQueryResult(msg)
+	chaincodeLogger.Debug("Exiting GET_QUERY_RESULT")
+}
+
+// Handles a rich query against the ledger. A non-zero page size requests a single page of results
+func (handler *Handler) handleGetQueryResult(msg *pb.ChaincodeMessage) {
+	// The defer followed by triggering a go routine dance is needed to ensure that the previous state transition
+	// is completed before the next one is triggered. The previous state transition is deemed complete only when
+	// the afterGetQueryResult function is exited. Interesting bug fix!!
+	go func() {
+		// Check if this is the unique state request from this chaincode txid
+		uniqueReq := handler.createTXIDEntry(msg.Txid)
+		if !uniqueReq {
+			// Drop this request
+			chaincodeLogger.Error("Another state request pending for this Txid. Cannot process.")
+			return
+		}
+
+		var serialSendMsg *pb.ChaincodeMessage
+
+		defer func() {
+			handler.deleteTXIDEntry(msg.Txid)
+			chaincodeLogger.Debugf("[%s]handleGetQueryResult serial send %s", shorttxid(serialSendMsg.Txid), serialSendMsg.Type)
+			handler.serialSend(serialSendMsg)
+		}()
+
+		getQueryResult := &pb.GetQueryResult{}
+		unmarshalErr := proto.Unmarshal(msg.Payload, getQueryResult)
+		if unmarshalErr != nil {
+			payload := []byte(unmarshalErr.Error())
+			chaincodeLogger.Errorf("Failed to unmarshall query request. Sending %s", pb.ChaincodeMessage_ERROR)
+			serialSendMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: payload, Txid: msg.Txid}
+			return
+		}
+
+		txContext := handler.getTxContext(msg.Txid)
+		chaincodeID := handler.ChaincodeID.Name
+
+		if getQueryResult.PageSize == 0 {
+			iterID := util.GenerateUUID()
+			queryIter, err := txContext.txsimulator.ExecuteQuery(chaincodeID, getQueryResult.Query)
+			if err != nil {
+				// Send error msg back to chaincode. GetQueryResult will not trigger event
+				payload := []byte(err.Error())
+				chaincodeLogger.Errorf("Failed to execute query. Sending %s", pb.ChaincodeMessage_ERROR)
+				serialSendMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: payload, Txid: msg.Txid}
+				return
+			}
+
+			// the remaining results are fetched with RANGE_QUERY_STATE_NEXT, as for range queries
+			if err = handler.putRangeQueryIterator(txContext, iterID, queryIter); err != nil {
+				queryIter.Close()
+				chaincodeLogger.Errorf("[%s]%s. Sending %s", shorttxid(msg.Txid), err, pb.ChaincodeMessage_ERROR)
+				serialSendMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: []byte(err.Error()), Txid: msg.Txid}
+				return
+			}
+			serialSendMsg = handler.getQueryResultBatch(txContext, iterID, queryIter, msg.Txid)
+			return
+		}
+
+		if err := handler.validatePaginatedQuery(msg.Txid); err != nil {
+			chaincodeLogger.Errorf("[%s]Invalid paginated query: %s. Sending %s", shorttxid(msg.Txid), err, pb.ChaincodeMessage_ERROR)
+			serialSendMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: []byte(err.Error()), Txid: msg.Txid}
+			return
+		}
+
+		pageIter, err := txContext.txsimulator.ExecuteQueryWithPagination(chaincodeID,
+			getQueryResult.Query, getQueryResult.PageSize, getQueryResult.Bookmark)
+		if err != nil {
+			// Send error msg back to chaincode. GetQueryResult will not trigger event
+			payload := []byte(err.Error())
+			chaincodeLogger.Errorf("Failed to execute query. Sending %s", pb.ChaincodeMessage_ERROR)
+			serialSendMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: payload, Txid: msg.Txid}
+			return
+		}
+
+		serialSendMsg = handler.getPageResponse(msg.Txid, pageIter)
+	}()
+}
+
+// afterGetStateMultiple handles a GET_STATE_MULTIPLE request from the chaincode.
+func (handler *Handler) afterGetStateMultiple(e *fsm.Event, state string) {
+	msg, ok := e.Args[0].(*pb.ChaincodeMessage)
+	if !ok {
+		e.Cancel(fmt.Errorf("Received unexpected message type"))
+		return
+	}
+	chaincodeLogger.Debugf("[%s]Received %s, invoking get state from ledger", shorttxid(msg.Txid), pb.ChaincodeMessage_GET_STATE_MULTIPLE)
+
+	// Query ledger for state
+	handler.handleGetStateMultiple(msg)
+}
+
+// Handles query to ledger to get the state of multiple keys
+func (handler *Handler) handleGetStateMultiple(msg *pb.ChaincodeMessage) {
+	// The defer followed by triggering a go routine dance is needed to ensure that the previous state transition
+	// is completed before the next one is triggered. The previous state transition is deemed complete only when
+	// the afterGetStateMultiple function is exited. Interesting bug fix!!
+	go func() {
+		// Check if this is the unique state request from this chaincode txid
+		uniqueReq := handler.createTXIDEntry(msg.Txid)
+		if !uniqueReq {
+			// Drop this request
+			chaincodeLogger.Error("Another state request pending for this Txid. Cannot process.")
+			return
+		}
+
+		var serialSendMsg *pb.ChaincodeMessage
+
+		defer func() {
+			handler.deleteTXIDEntry(msg.Txid)
+			chaincodeLogger.Debugf("[%s]handleGetStateMultiple serial send %s", shorttxid(serialSendMsg.Txid), serialSendMsg.Type)
+			handler.serialSend(serialSendMsg)
+		}()
+
+		getStateMultiple := &pb.GetStateMultiple{}
+		unmarshalErr := proto.Unmarshal(msg.Payload, getStateMultiple)
+		if unmarshalErr != nil {
+			payload := []byte(unmarshalErr.Error())
+			chaincodeLogger.Errorf("Failed to unmarshall get state request. Sending %s", pb.ChaincodeMessage_ERROR)
+			serialSendMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: payload, Txid: msg.Txid}
+			return
+		}
+
+		txContext := handler.getTxContext(msg.Txid)
+		chaincodeID := handler.ChaincodeID.Name
+
+		values, err := txContext.txsimulator.GetStateMultipleKeys(chaincodeID, getStateMultiple.Keys)
+		if err != nil {
+			// Send error msg back to chaincode. GetStateMultiple will not trigger event
+			payload := []byte(err.Error())
+			chaincodeLogger.Errorf("[%s]Failed to get chaincode state(%s). Sending %s", shorttxid(msg.Txid), err, pb.ChaincodeMessage_ERROR)
+			serialSendMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: payload, Txid: msg.Txid}
+			return
+		}
+
+		response := &pb.GetStateMultipleResponse{Values: make([][]byte, len(values))}
+		for i, value := range values {
+			if value == nil {
+				//The state object being requested does not exist, so don't attempt to decrypt it
+				continue
+			}
+			// Decrypt the data if the confidential is enabled
+			if response.Values[i], err = handler.decrypt(msg.Txid, value); err != nil {
+				chaincodeLogger.Errorf("[%s]Got error (%s) while decrypting. Sending %s", shorttxid(msg.Txid), err, pb.ChaincodeMessage_ERROR)
+				serialSendMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: []byte(err.Error()), Txid: msg.Txid}
+				return
+			}
+		}
+
+		payload, err := proto.Marshal(response)
+		if err != nil {
+			chaincodeLogger.Errorf("Failed to marshall response. Sending %s", pb.ChaincodeMessage_ERROR)
+			serialSendMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: []byte(err.Error()), Txid: msg.Txid}
+			return
+		}
+
+		chaincodeLogger.Debugf("[%s]Got state for %d keys. Sending %s", shorttxid(msg.Txid), len(values), pb.ChaincodeMessage_RESPONSE)
+		serialSendMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_RESPONSE, Payload: payload, Txid: msg.Txid}
+	}()
+}
+
+// afterGetLedgerHeight handles a GET_LEDGER_HEIGHT request from the chaincode.
+func (handler *Handler) afterGetLedgerHeight(e *fsm.Event, state string) {
+	msg, ok := e.Args[0].(*pb.ChaincodeMessage)
+	if !ok {
+		e.Cancel(fmt.Errorf("Received unexpected message type"))
+		return
+	}
+	chaincodeLogger.Debugf("[%s]Received %s, invoking get ledger height", shorttxid(msg.Txid), pb.ChaincodeMessage_GET_LEDGER_HEIGHT)
+
+	handler.handleGetLedgerHeight(msg)
+}
+
+// Handles query to ledger to get the height it is simulated on. The height is
+// recorded in the read set of the transaction by the simulator
+func (handler *Handler) handleGetLedgerHeight(msg *pb.ChaincodeMessage) {
+	go func() {
+		// Check if this is the unique state request from this chaincode txid
+		uniqueReq := handler.createTXIDEntry(msg.Txid)
+		if !uniqueReq {
+			// Drop this request
+			chaincodeLogger.Error("Another state request pending for this Txid. Cannot process.")
+			return
+		}
+
+		var serialSendMsg *pb.ChaincodeMessage
+
+		defer func() {
+			handler.deleteTXIDEntry(msg.Txid)
+			chaincodeLogger.Debugf("[%s]handleGetLedgerHeight serial send %s", shorttxid(serialSendMsg.Txid), serialSendMsg.Type)
+			handler.serialSend(serialSendMsg)
+		}()
+
+		txContext := handler.getTxContext(msg.Txid)
+		if txContext == nil || txContext.txsimulator == nil {
+			chaincodeLogger.Errorf("[%s]No ledger context for %s. Sending %s", shorttxid(msg.Txid), pb.ChaincodeMessage_GET_LEDGER_HEIGHT, pb.ChaincodeMessage_ERROR)
+			serialSendMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: []byte("No ledger context for GetLedgerHeight"), Txid: msg.Txid}
+			return
+		}
+
+		height, err := txContext.txsimulator.GetLedgerHeight()
+		if err != nil {
+			chaincodeLogger.Errorf("[%s]Failed to get ledger height(%s). Sending %s", shorttxid(msg.Txid), err, pb.ChaincodeMessage_ERROR)
+			serialSendMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: []byte(err.Error()), Txid: msg.Txid}
+			return
+		}
+
+		payload, err := proto.Marshal(&pb.LedgerHeight{Height: height})
+		if err != nil {
+			chaincodeLogger.Errorf("Failed to marshall response. Sending %s", pb.ChaincodeMessage_ERROR)
+			serialSendMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: []byte(err.Error()), Txid: msg.Txid}
+			return
+		}
+
+		chaincodeLogger.Debugf("[%s]Got ledger height %d. Sending %s", shorttxid(msg.Txid), height, pb.ChaincodeMessage_RESPONSE)
+		serialSendMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_RESPONSE, Payload: payload, Txid: msg.Txid}
+	}()
+}
+
 // afterPutState handles a PUT_STATE request from the chaincode.
 func (handler *Handler) afterPutState(e *fsm.Event, state string) {
 	_, ok := e.Args[0].(*pb.ChaincodeMessage)
@@ -959,7 +1327,7 @@ func (handler *Handler) afterPutState(e *fsm.Event, state string) {
 	}
 	chaincodeLogger.Debugf("Received %s in state %s, invoking put state to ledger", pb.ChaincodeMessage_PUT_STATE, state)
 
-	// Put state into ledger handled within enterBusyState
+	// Put state into ledger handled within handleTxRequest
 }
 
 // afterDelState handles a DEL_STATE request from the chaincode.
@@ -971,7 +1339,7 @@ func (handler *Handler) afterDelState(e *fsm.Event, state string) {
 	}
 	chaincodeLogger.Debugf("Received %s, invoking delete state from ledger", pb.ChaincodeMessage_DEL_STATE)
 
-	// Delete state from ledger handled within enterBusyState
+	// Delete state from ledger handled within handleTxRequest
 }
 
 // afterInvokeChaincode handles an INVOKE_CHAINCODE request from the chaincode.
@@ -983,134 +1351,180 @@ func (handler *Handler) afterInvokeChaincode(e *fsm.Event, state string) {
 	}
 	chaincodeLogger.Debugf("Received %s in state %s, invoking another chaincode", pb.ChaincodeMessage_INVOKE_CHAINCODE, state)
 
-	// Invoke another chaincode handled within enterBusyState
+	// Invoke another chaincode handled within handleTxRequest
 }
 
-// Handles request to ledger to put state
+// enterBusyState handles a request of the chaincode's init to the ledger or another
+// chaincode, the response moves the handler back to the init state
 func (handler *Handler) enterBusyState(e *fsm.Event, state string) {
-	go func() {
-		msg, _ := e.Args[0].(*pb.ChaincodeMessage)
-		// First check if this TXID is a transaction; error otherwise
-		if !handler.getIsTransaction(msg.Txid) {
-			payload := []byte(fmt.Sprintf("Cannot handle %s in query context", msg.Type.String()))
-			chaincodeLogger.Debugf("[%s]Cannot handle %s in query context. Sending %s", shorttxid(msg.Txid), msg.Type.String(), pb.ChaincodeMessage_ERROR)
-			errMsg := &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: payload, Txid: msg.Txid}
-			handler.triggerNextState(errMsg, true)
-			return
-		}
+	msg, _ := e.Args[0].(*pb.ChaincodeMessage)
+	go handler.handleTxRequest(msg, state, func(respMsg *pb.ChaincodeMessage) {
+		handler.triggerNextState(respMsg, true)
+	})
+}
 
-		chaincodeLogger.Debugf("[%s]state is %s", shorttxid(msg.Txid), state)
-		// Check if this is the unique request from this chaincode txid
-		uniqueReq := handler.createTXIDEntry(msg.Txid)
-		if !uniqueReq {
-			// Drop this request
-			chaincodeLogger.Debug("Another request pending for this Txid. Cannot process.")
-			return
-		}
+// handleTxRequest handles a PUT_STATE, DEL_STATE or INVOKE_CHAINCODE request of a
+// transaction and passes the RESPONSE or ERROR to respond
+func (handler *Handler) handleTxRequest(msg *pb.ChaincodeMessage, state string, respond func(*pb.ChaincodeMessage)) {
+	// First check if this TXID is a transaction; error otherwise
+	if !handler.getIsTransaction(msg.Txid) {
+		payload := []byte(fmt.Sprintf("Cannot handle %s in query context", msg.Type.String()))
+		chaincodeLogger.Debugf("[%s]Cannot handle %s in query context. Sending %s", shorttxid(msg.Txid), msg.Type.String(), pb.ChaincodeMessage_ERROR)
+		errMsg := &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: payload, Txid: msg.Txid}
+		respond(errMsg)
+		return
+	}
 
-		var triggerNextStateMsg *pb.ChaincodeMessage
+	chaincodeLogger.Debugf("[%s]state is %s", shorttxid(msg.Txid), state)
+	// Check if this is the unique request from this chaincode txid
+	uniqueReq := handler.createTXIDEntry(msg.Txid)
+	if !uniqueReq {
+		// Drop this request
+		chaincodeLogger.Debug("Another request pending for this Txid. Cannot process.")
+		return
+	}
 
-		defer func() {
-			handler.deleteTXIDEntry(msg.Txid)
-			chaincodeLogger.Debugf("[%s]enterBusyState trigger event %s", shorttxid(triggerNextStateMsg.Txid), triggerNextStateMsg.Type)
-			handler.triggerNextState(triggerNextStateMsg, true)
-		}()
+	var respMsg *pb.ChaincodeMessage
 
-		chaincodeID := handler.ChaincodeID.Name
-		var err error
-		var res []byte
+	defer func() {
+		handler.deleteTXIDEntry(msg.Txid)
+		chaincodeLogger.Debugf("[%s]handleTxRequest respond %s", shorttxid(respMsg.Txid), respMsg.Type)
+		respond(respMsg)
+	}()
 
-		if msg.Type.String() == pb.ChaincodeMessage_PUT_STATE.String() {
-			putStateInfo := &pb.PutStateInfo{}
-			unmarshalErr := proto.Unmarshal(msg.Payload, putStateInfo)
-			if unmarshalErr != nil {
-				payload := []byte(unmarshalErr.Error())
-				chaincodeLogger.Debugf("[%s]Unable to decipher payload. Sending %s", shorttxid(msg.Txid), pb.ChaincodeMessage_ERROR)
-				triggerNextStateMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: payload, Txid: msg.Txid}
-				return
-			}
+	chaincodeID := handler.ChaincodeID.Name
+	var err error
+	var res []byte
 
-			var pVal []byte
-			// Encrypt the data if the confidential is enabled
-			if pVal, err = handler.encrypt(msg.Txid, putStateInfo.Value); err == nil {
-				// Invoke ledger to put state
-				txContext := handler.getTxContext(msg.Txid)
-				err = txContext.txsimulator.SetState(chaincodeID, putStateInfo.Key, pVal)
+	if msg.Type.String() == pb.ChaincodeMessage_PUT_STATE.String() {
+		putStateInfo := &pb.PutStateInfo{}
+		unmarshalErr := proto.Unmarshal(msg.Payload, putStateInfo)
+		if unmarshalErr != nil {
+			payload := []byte(unmarshalErr.Error())
+			chaincodeLogger.Debugf("[%s]Unable to decipher payload. Sending %s", shorttxid(msg.Txid), pb.ChaincodeMessage_ERROR)
+			respMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: payload, Txid: msg.Txid}
+			return
+		}
 
-			}
-		} else if msg.Type.String() == pb.ChaincodeMessage_DEL_STATE.String() {
-			// Invoke ledger to delete state
-			key := string(msg.Payload)
+		var pVal []byte
+		// Encrypt the data if the confidential is enabled
+		if pVal, err = handler.encrypt(msg.Txid, putStateInfo.Value); err == nil {
+			// Invoke ledger to put state
 			txContext := handler.getTxContext(msg.Txid)
-			err = txContext.txsimulator.DeleteState(chaincodeID, key)
-		} else if msg.Type.String() == pb.ChaincodeMessage_INVOKE_CHAINCODE.String() {
-			//check and prohibit C-call-C for CONFIDENTIAL txs
-			chaincodeLogger.Debugf("[%s] C-call-C", shorttxid(msg.Txid))
+			err = txContext.txsimulator.SetState(chaincodeID, putStateInfo.Key, pVal)
 
-			if triggerNextStateMsg = handler.canCallChaincode(msg.Txid, false); triggerNextStateMsg != nil {
-				return
-			}
-			chaincodeSpec := &pb.ChaincodeSpec{}
-			unmarshalErr := proto.Unmarshal(msg.Payload, chaincodeSpec)
-			if unmarshalErr != nil {
-				payload := []byte(unmarshalErr.Error())
-				chaincodeLogger.Debugf("[%s]Unable to decipher payload. Sending %s", shorttxid(msg.Txid), pb.ChaincodeMessage_ERROR)
-				triggerNextStateMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: payload, Txid: msg.Txid}
+		}
+	} else if msg.Type.String() == pb.ChaincodeMessage_DEL_STATE.String() {
+		// Invoke ledger to delete state
+		key := string(msg.Payload)
+		txContext := handler.getTxContext(msg.Txid)
+		err = txContext.txsimulator.DeleteState(chaincodeID, key)
+	} else if msg.Type.String() == pb.ChaincodeMessage_INVOKE_CHAINCODE.String() {
+		//check and prohibit C-call-C for CONFIDENTIAL txs
+		chaincodeLogger.Debugf("[%s] C-call-C", shorttxid(msg.Txid))
+
+		if respMsg = handler.canCallChaincode(msg.Txid, false); respMsg != nil {
+			return
+		}
+		chaincodeSpec := &pb.ChaincodeSpec{}
+		unmarshalErr := proto.Unmarshal(msg.Payload, chaincodeSpec)
+		if unmarshalErr != nil {
+			payload := []byte(unmarshalErr.Error())
+			chaincodeLogger.Debugf("[%s]Unable to decipher payload. Sending %s", shorttxid(msg.Txid), pb.ChaincodeMessage_ERROR)
+			respMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: payload, Txid: msg.Txid}
+			return
+		}
+
+		// Only the peer migrates the state of an upgraded chaincode
+		if chaincodeSpec.CtorMsg != nil {
+			if migrateErr := CheckMigrateNotInvoked(chaincodeSpec.ChaincodeID.Name, chaincodeSpec.CtorMsg.Args); migrateErr != nil {
+				payload := []byte(migrateErr.Error())
+				chaincodeLogger.Debugf("[%s]Chaincode invoked Migrate of %s. Sending %s", shorttxid(msg.Txid), chaincodeSpec.ChaincodeID.Name, pb.ChaincodeMessage_ERROR)
+				respMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: payload, Txid: msg.Txid}
 				return
 			}
+		}
 
-			// Get the chaincodeID to invoke
-			newChaincodeID := chaincodeSpec.ChaincodeID.Name
-			chaincodeLogger.Debugf("[%s] C-call-C %s", shorttxid(msg.Txid), newChaincodeID)
+		// Get the chaincodeID and the channel to invoke
+		newChaincodeID, calledChannel := getChaincodeInstance(chaincodeSpec.ChaincodeID.Name)
+		chaincodeSpec.ChaincodeID.Name = newChaincodeID
+		chaincodeLogger.Debugf("[%s] C-call-C %s on channel %s", shorttxid(msg.Txid), newChaincodeID, calledChannel)
 
-			txContext := handler.getTxContext(msg.Txid)
-			ctxt := context.Background()
+		txContext := handler.getTxContext(msg.Txid)
+		ctxt := context.Background()
+		if calledChannel == "" || calledChannel == string(handler.chaincodeSupport.name) {
+			// the read-write set of the called chaincode is merged with the caller's
 			ctxt = context.WithValue(ctxt, TXSimulatorKey, txContext.txsimulator)
-
-			// Create the transaction object
-			chaincodeInvocationSpec := &pb.ChaincodeInvocationSpec{ChaincodeSpec: chaincodeSpec}
-			transaction, _ := pb.NewChaincodeExecute(chaincodeInvocationSpec, msg.Txid, pb.Transaction_CHAINCODE_INVOKE)
-
-			// Launch the new chaincode if not already running
-			_, chaincodeInput, launchErr := handler.chaincodeSupport.Launch(ctxt, transaction)
-			if launchErr != nil {
-				payload := []byte(launchErr.Error())
-				chaincodeLogger.Debugf("[%s]Failed to launch invoked chaincode. Sending %s", shorttxid(msg.Txid), pb.ChaincodeMessage_ERROR)
-				triggerNextStateMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: payload, Txid: msg.Txid}
+		} else {
+			// the chaincode of another channel is called read only, its
+			// simulation contributes nothing to the caller's transaction
+			txsim, simErr := getCrossChannelTxSimulator(calledChannel)
+			if simErr != nil {
+				payload := []byte(simErr.Error())
+				chaincodeLogger.Debugf("[%s]Failed to access channel %s. Sending %s", shorttxid(msg.Txid), calledChannel, pb.ChaincodeMessage_ERROR)
+				respMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: payload, Txid: msg.Txid}
 				return
 			}
+			defer txsim.Done()
+			ctxt = context.WithValue(ctxt, TXSimulatorKey, txsim)
+		}
 
-			// TODO: Need to handle timeout correctly
-			timeout := time.Duration(30000) * time.Millisecond
-
-			ccMsg, _ := createTransactionMessage(transaction.Txid, chaincodeInput)
-
-			// Execute the chaincode
-			//NOTE: when confidential C-call-C is understood, transaction should have the correct sec context for enc/dec
-			response, execErr := handler.chaincodeSupport.Execute(ctxt, newChaincodeID, ccMsg, timeout, transaction)
+		// Create the transaction object
+		chaincodeInvocationSpec := &pb.ChaincodeInvocationSpec{ChaincodeSpec: chaincodeSpec}
+		transaction, _ := pb.NewChaincodeExecute(chaincodeInvocationSpec, msg.Txid, pb.Transaction_CHAINCODE_INVOKE)
 
-			//payload is marshalled and send to the calling chaincode's shim which unmarshals and
-			//sends it to chaincode
-			res = nil
-			if execErr != nil {
-				err = execErr
-			} else {
-				res, err = proto.Marshal(response)
-			}
+		// The called chaincode runs in the chaincode support of its channel
+		channel := calledChannel
+		if channel == "" {
+			channel = string(handler.chaincodeSupport.name)
+		}
+		calledSupport, supportErr := GetChaincodeSupport(channel, newChaincodeID)
+		if supportErr != nil {
+			payload := []byte(supportErr.Error())
+			chaincodeLogger.Debugf("[%s]No chaincode support for channel %s. Sending %s", shorttxid(msg.Txid), channel, pb.ChaincodeMessage_ERROR)
+			respMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: payload, Txid: msg.Txid}
+			return
 		}
 
-		if err != nil {
-			// Send error msg back to chaincode and trigger event
-			payload := []byte(err.Error())
-			chaincodeLogger.Errorf("[%s]Failed to handle %s. Sending %s", shorttxid(msg.Txid), msg.Type.String(), pb.ChaincodeMessage_ERROR)
-			triggerNextStateMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: payload, Txid: msg.Txid}
+		// Launch the new chaincode if not already running
+		_, chaincodeInput, launchErr := calledSupport.Launch(ctxt, transaction)
+		if launchErr != nil {
+			payload := []byte(launchErr.Error())
+			chaincodeLogger.Debugf("[%s]Failed to launch invoked chaincode. Sending %s", shorttxid(msg.Txid), pb.ChaincodeMessage_ERROR)
+			respMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: payload, Txid: msg.Txid}
 			return
 		}
 
-		// Send response msg back to chaincode.
-		chaincodeLogger.Debugf("[%s]Completed %s. Sending %s", shorttxid(msg.Txid), msg.Type.String(), pb.ChaincodeMessage_RESPONSE)
-		triggerNextStateMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_RESPONSE, Payload: res, Txid: msg.Txid}
-	}()
+		// the called chaincode runs within its own execute timeout
+		timeout := calledSupport.getExecuteTimeout(newChaincodeID)
+
+		ccMsg, _ := createTransactionMessage(transaction.Txid, chaincodeInput)
+
+		// Execute the chaincode
+		//NOTE: when confidential C-call-C is understood, transaction should have the correct sec context for enc/dec
+		response, execErr := calledSupport.Execute(ctxt, newChaincodeID, ccMsg, timeout, transaction)
+
+		//payload is marshalled and send to the calling chaincode's shim which unmarshals and
+		//sends it to chaincode
+		res = nil
+		if execErr != nil {
+			err = execErr
+		} else {
+			res, err = proto.Marshal(response)
+		}
+	}
+
+	if err != nil {
+		// Send error msg back to chaincode and trigger event
+		payload := []byte(err.Error())
+		chaincodeLogger.Errorf("[%s]Failed to handle %s. Sending %s", shorttxid(msg.Txid), msg.Type.String(), pb.ChaincodeMessage_ERROR)
+		respMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: payload, Txid: msg.Txid}
+		return
+	}
+
+	// Send response msg back to chaincode.
+	chaincodeLogger.Debugf("[%s]Completed %s. Sending %s", shorttxid(msg.Txid), msg.Type.String(), pb.ChaincodeMessage_RESPONSE)
+	respMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_RESPONSE, Payload: res, Txid: msg.Txid}
 }
 
 func (handler *Handler) enterEstablishedState(e *fsm.Event, state string) {
@@ -1138,9 +1552,19 @@ func (handler *Handler) enterInitState(e *fsm.Event, state string) {
 func (handler *Handler) enterReadyState(e *fsm.Event, state string) {
 	// Now notify
 	msg, ok := e.Args[0].(*pb.ChaincodeMessage)
+	if !ok {
+		e.Cancel(fmt.Errorf("Received unexpected message type"))
+		return
+	}
+	chaincodeLogger.Debugf("[%s]Entered state %s", shorttxid(msg.Txid), state)
+	handler.notifyCompleted(msg)
+}
+
+// notifyCompleted notifies the waiter of an init or transaction of its COMPLETED or ERROR
+func (handler *Handler) notifyCompleted(msg *pb.ChaincodeMessage) {
 	//we have to encrypt chaincode event payload. We cannot encrypt event type as
 	//it is needed by the event system to filter clients by
-	if ok && msg.ChaincodeEvent != nil && msg.ChaincodeEvent.Payload != nil {
+	if msg.ChaincodeEvent != nil && msg.ChaincodeEvent.Payload != nil {
 		var err error
 		if msg.Payload, err = handler.encrypt(msg.Txid, msg.Payload); nil != err {
 			chaincodeLogger.Errorf("[%s]Failed to encrypt chaincode event payload", msg.Txid)
@@ -1149,11 +1573,6 @@ func (handler *Handler) enterReadyState(e *fsm.Event, state string) {
 		}
 	}
 	handler.deleteIsTransaction(msg.Txid)
-	if !ok {
-		e.Cancel(fmt.Errorf("Received unexpected message type"))
-		return
-	}
-	chaincodeLogger.Debugf("[%s]Entered state %s", shorttxid(msg.Txid), state)
 	handler.notify(msg)
 }
 
@@ -1288,7 +1707,7 @@ func (handler *Handler) initOrReady(ctxt context.Context, txid string, initArgs
 			handler.deleteTxContext(txid)
 			return nil, fmt.Errorf("Failed to marshall %s : %s\n", ccMsg.Type.String(), funcErr)
 		}
-		ccMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_INIT, Payload: payload, Txid: txid}
+		ccMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_INIT, Payload: payload, Txid: txid, Proposal: getProposal(ctxt), SignedProposal: getSignedProposal(ctxt)}
 		send = false
 	} else {
 		chaincodeLogger.Debug("sending READY")
@@ -1354,8 +1773,17 @@ func (handler *Handler) handleQueryChaincode(msg *pb.ChaincodeMessage) {
 		ctxt := context.Background()
 		ctxt = context.WithValue(ctxt, TXSimulatorKey, txContext.txsimulator)
 
+		// The called system chaincodes run in the chaincode support of the default chain
+		calledSupport, supportErr := GetChaincodeSupport(string(handler.chaincodeSupport.name), newChaincodeID)
+		if supportErr != nil {
+			payload := []byte(supportErr.Error())
+			chaincodeLogger.Debugf("[%s]No chaincode support for %s. Sending %s", shorttxid(msg.Txid), newChaincodeID, pb.ChaincodeMessage_ERROR)
+			serialSendMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: payload, Txid: msg.Txid}
+			return
+		}
+
 		// Launch the new chaincode if not already running
-		_, chaincodeInput, launchErr := handler.chaincodeSupport.Launch(ctxt, transaction)
+		_, chaincodeInput, launchErr := calledSupport.Launch(ctxt, transaction)
 		if launchErr != nil {
 			payload := []byte(launchErr.Error())
 			chaincodeLogger.Debugf("[%s]Failed to launch invoked chaincode. Sending %s", shorttxid(msg.Txid), pb.ChaincodeMessage_ERROR)
@@ -1363,14 +1791,14 @@ func (handler *Handler) handleQueryChaincode(msg *pb.ChaincodeMessage) {
 			return
 		}
 
-		// TODO: Need to handle timeout correctly
-		timeout := time.Duration(30000) * time.Millisecond
+		// the called chaincode runs within its own execute timeout
+		timeout := calledSupport.getExecuteTimeout(newChaincodeID)
 
 		ccMsg, _ := createQueryMessage(transaction.Txid, chaincodeInput)
 
 		// Query the chaincode
 		//NOTE: when confidential C-call-C is understood, transaction should have the correct sec context for enc/dec
-		response, execErr := handler.chaincodeSupport.Execute(ctxt, newChaincodeID, ccMsg, timeout, transaction)
+		response, execErr := calledSupport.Execute(ctxt, newChaincodeID, ccMsg, timeout, transaction)
 
 		if execErr != nil {
 			// Send error msg back to chaincode and trigger event
@@ -1422,6 +1850,21 @@ func (handler *Handler) HandleMessage(msg *pb.ChaincodeMessage) error {
 		handler.handleQueryChaincode(msg)
 		return nil
 	}
+	if handler.FSM.Current() == readystate {
+		// Once the chaincode is ready transactions execute concurrently. They
+		// are told apart by their txid and do not transition state
+		switch msg.Type {
+		case pb.ChaincodeMessage_COMPLETED, pb.ChaincodeMessage_ERROR:
+			chaincodeLogger.Debugf("[%s]HandleMessage- %s. Notify", msg.Txid, msg.Type)
+			handler.notifyCompleted(msg)
+			return nil
+		case pb.ChaincodeMessage_PUT_STATE, pb.ChaincodeMessage_DEL_STATE, pb.ChaincodeMessage_INVOKE_CHAINCODE:
+			go handler.handleTxRequest(msg, readystate, func(respMsg *pb.ChaincodeMessage) {
+				handler.serialSend(respMsg)
+			})
+			return nil
+		}
+	}
 	if handler.FSM.Cannot(msg.Type.String()) {
 		// Check if this is a request from validator in query context
 		if msg.Type.String() == pb.ChaincodeMessage_PUT_STATE.String() || msg.Type.String() == pb.ChaincodeMessage_DEL_STATE.String() || msg.Type.String() == pb.ChaincodeMessage_INVOKE_CHAINCODE.String() {
@@ -1487,17 +1930,17 @@ func (handler *Handler) sendExecuteMessage(ctxt context.Context, msg *pb.Chainco
 		return nil, err
 	}
 
-	// Trigger FSM event if it is a transaction
-	if msg.Type.String() == pb.ChaincodeMessage_TRANSACTION.String() {
-		chaincodeLogger.Debugf("[%s]sendExecuteMsg trigger event %s", shorttxid(msg.Txid), msg.Type)
-		handler.triggerNextState(msg, true)
-	} else {
-		// Send the message to shim
-		chaincodeLogger.Debugf("[%s]sending query", shorttxid(msg.Txid))
-		if err = handler.serialSend(msg); err != nil {
-			handler.deleteTxContext(msg.Txid)
-			return nil, fmt.Errorf("[%s]SendMessage error sending (%s)", shorttxid(msg.Txid), err)
-		}
+	// the chaincode reads the transient data of the proposal from it, and
+	// verifies the signature of the signed proposal
+	msg.Proposal = getProposal(ctxt)
+	msg.SignedProposal = getSignedProposal(ctxt)
+
+	// Send the message to shim, transactions and queries do not transition
+	// state so that several of them execute concurrently in the chaincode
+	chaincodeLogger.Debugf("[%s]sending %s", shorttxid(msg.Txid), msg.Type)
+	if err = handler.serialSend(msg); err != nil {
+		handler.deleteTxContext(msg.Txid)
+		return nil, fmt.Errorf("[%s]SendMessage error sending (%s)", shorttxid(msg.Txid), err)
 	}
 
 	return txctx.responseNotifier, nil
@@ -0,0 +1,114 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chaincode
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/core/ledger"
+	pb "github.com/hyperledger/fabric/protos"
+)
+
+// testResultsIterator returns the given number of results
+type testResultsIterator struct {
+	results int
+	next    int
+	closed  bool
+}
+
+func (itr *testResultsIterator) Next() (ledger.QueryResult, error) {
+	if itr.next >= itr.results {
+		return nil, nil
+	}
+	itr.next++
+	return ledger.KV{Key: fmt.Sprintf("key%d", itr.next), Value: []byte("value")}, nil
+}
+
+func (itr *testResultsIterator) Close() {
+	itr.closed = true
+}
+
+func getTestQueryResultBatch(t *testing.T, handler *Handler, txContext *transactionContext, iterID string, iter ledger.ResultsIterator) *pb.RangeQueryStateResponse {
+	txContext.rangeQueryIteratorMap[iterID] = iter
+	msg := handler.getQueryResultBatch(txContext, iterID, iter, "txid")
+	if msg.Type != pb.ChaincodeMessage_RESPONSE {
+		t.Fatalf("Expected a response, got %s: %s", msg.Type, msg.Payload)
+	}
+	response := &pb.RangeQueryStateResponse{}
+	if err := proto.Unmarshal(msg.Payload, response); err != nil {
+		t.Fatalf("Error unmarshalling the response: %s", err)
+	}
+	return response
+}
+
+func TestGetQueryResultBatchTotalQueryLimit(t *testing.T) {
+	handler := &Handler{chaincodeSupport: &ChaincodeSupport{totalQueryLimit: 5}}
+	txContext := &transactionContext{rangeQueryIteratorMap: make(map[string]ledger.ResultsIterator)}
+
+	// the first iterator returns all its results
+	iter1 := &testResultsIterator{results: 4}
+	response := getTestQueryResultBatch(t, handler, txContext, "iter1", iter1)
+	if len(response.KeysAndValues) != 4 || response.HasMore || !iter1.closed {
+		t.Fatalf("Expected all 4 results of the first iterator, got %d (hasMore %t, closed %t)",
+			len(response.KeysAndValues), response.HasMore, iter1.closed)
+	}
+
+	// the second iterator is cut off once the transaction has received the total query limit of results
+	iter2 := &testResultsIterator{results: 4}
+	response = getTestQueryResultBatch(t, handler, txContext, "iter2", iter2)
+	if len(response.KeysAndValues) != 1 || response.HasMore || !iter2.closed {
+		t.Fatalf("Expected 1 result of the second iterator, got %d (hasMore %t, closed %t)",
+			len(response.KeysAndValues), response.HasMore, iter2.closed)
+	}
+	if _, ok := txContext.rangeQueryIteratorMap["iter2"]; ok {
+		t.Fatalf("Expected the exhausted iterator to be removed from the transaction context")
+	}
+
+	// without a limit all the results are returned
+	handler = &Handler{chaincodeSupport: &ChaincodeSupport{}}
+	txContext = &transactionContext{rangeQueryIteratorMap: make(map[string]ledger.ResultsIterator)}
+	for i := 0; i < 3; i++ {
+		response = getTestQueryResultBatch(t, handler, txContext, "iter", &testResultsIterator{results: 4})
+		if len(response.KeysAndValues) != 4 {
+			t.Fatalf("Expected all 4 results without a limit, got %d", len(response.KeysAndValues))
+		}
+	}
+}
+
+func TestHandleTxRequestInvokeMigrate(t *testing.T) {
+	handler := &Handler{
+		chaincodeSupport: &ChaincodeSupport{},
+		ChaincodeID:      &pb.ChaincodeID{Name: "caller"},
+		txidMap:          map[string]bool{},
+		isTransaction:    map[string]bool{"txid": true},
+	}
+
+	spec := &pb.ChaincodeSpec{ChaincodeID: &pb.ChaincodeID{Name: "callee"}, CtorMsg: &pb.ChaincodeInput{Args: [][]byte{[]byte("Migrate"), []byte("1.0"), []byte("2.0")}}}
+	payload, err := proto.Marshal(spec)
+	if err != nil {
+		t.Fatalf("Error marshalling the chaincode spec: %s", err)
+	}
+
+	var resp *pb.ChaincodeMessage
+	msg := &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_INVOKE_CHAINCODE, Payload: payload, Txid: "txid"}
+	handler.handleTxRequest(msg, readystate, func(m *pb.ChaincodeMessage) { resp = m })
+	if resp == nil || resp.Type != pb.ChaincodeMessage_ERROR {
+		t.Fatalf("Expected a chaincode invoking Migrate of another chaincode to get an error, got %v", resp)
+	}
+}
@@ -17,8 +17,15 @@ limitations under the License.
 package chaincode
 
 import (
+	"fmt"
+
 	//import system chain codes here
+	"github.com/hyperledger/fabric/core/system_chaincode/cscc"
 	"github.com/hyperledger/fabric/core/system_chaincode/escc"
+	"github.com/hyperledger/fabric/core/system_chaincode/iscc"
+	"github.com/hyperledger/fabric/core/system_chaincode/pscc"
+	"github.com/hyperledger/fabric/core/system_chaincode/qscc"
+	"github.com/hyperledger/fabric/core/system_chaincode/tscc"
 	"github.com/hyperledger/fabric/core/system_chaincode/vscc"
 )
 
@@ -44,8 +51,92 @@ var systemChaincodes = []*SystemChaincode{
 		Path:      "github.com/hyperledger/fabric/core/system_chaincode/vscc",
 		InitArgs:  [][]byte{[]byte("")},
 		Chaincode: &vscc.ValidatorOneValidSignature{},
+	},
+	{
+		Enabled:   true,
+		Name:      "qscc",
+		Path:      "github.com/hyperledger/fabric/core/system_chaincode/qscc",
+		InitArgs:  [][]byte{[]byte("")},
+		Chaincode: &qscc.LedgerQuerier{},
+	},
+	{
+		Enabled:   true,
+		Name:      "cscc",
+		Path:      "github.com/hyperledger/fabric/core/system_chaincode/cscc",
+		InitArgs:  [][]byte{[]byte("")},
+		Chaincode: &cscc.PeerConfiger{},
+	},
+	{
+		Enabled:   true,
+		Name:      "tscc",
+		Path:      "github.com/hyperledger/fabric/core/system_chaincode/tscc",
+		InitArgs:  [][]byte{[]byte("")},
+		Chaincode: &tscc.TokenManager{},
+	},
+	{
+		Enabled:   true,
+		Name:      "iscc",
+		Path:      "github.com/hyperledger/fabric/core/system_chaincode/iscc",
+		InitArgs:  [][]byte{[]byte("")},
+		Chaincode: &iscc.IdentityRegistry{},
+	},
+	{
+		Enabled:   true,
+		Name:      "pscc",
+		Path:      "github.com/hyperledger/fabric/core/system_chaincode/pscc",
+		InitArgs:  [][]byte{[]byte("")},
+		Chaincode: &pscc.PolicyManager{},
 	}}
 
+//AddSysCC adds a system chaincode to the ones registered with the fabric by
+//RegisterSysCCs. System chaincodes built outside this file call it from their
+//init function and are linked into the peer with a blank import; they are
+//still enabled only when whitelisted under "chaincode.system" in core.yaml
+func AddSysCC(syscc *SystemChaincode) error {
+	if syscc.Name == "" {
+		return fmt.Errorf("system chaincode has no name")
+	}
+	if IsSysCC(syscc.Name) {
+		return fmt.Errorf("system chaincode %s already added", syscc.Name)
+	}
+	systemChaincodes = append(systemChaincodes, syscc)
+	return nil
+}
+
+//IsSysCC returns true if the name matches a system chaincode
+func IsSysCC(name string) bool {
+	for _, sysCC := range systemChaincodes {
+		if sysCC.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+//IsSysCCRegistered returns true if the name matches a system chaincode
+//registered with the peer, that is enabled and whitelisted
+func IsSysCCRegistered(name string) bool {
+	for _, sysCC := range systemChaincodes {
+		if sysCC.Name == name {
+			return sysCC.Enabled && isWhitelisted(sysCC)
+		}
+	}
+	return false
+}
+
+//IsSysCCInvokableExternal returns true if the name matches an enabled system
+//chaincode that clients may invoke with proposals. System chaincodes
+//whitelisted as "internal" can only be called by the peer and by other
+//chaincodes
+func IsSysCCInvokableExternal(name string) bool {
+	for _, sysCC := range systemChaincodes {
+		if sysCC.Name == name {
+			return sysCC.Enabled && isWhitelisted(sysCC) && !isInternal(sysCC)
+		}
+	}
+	return false
+}
+
 //RegisterSysCCs is the hook for system chaincodes where system chaincodes are registered with the fabric
 //note the chaincode must still be deployed and launched like a user chaincode will be
 func RegisterSysCCs() {
@@ -0,0 +1,171 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chaincode
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/core/chaincode/ccpackage"
+	pb "github.com/hyperledger/fabric/protos"
+	"github.com/spf13/viper"
+)
+
+//Installed chaincode packages are kept on the peer's file system, outside
+//of any ledger. Installing a package only makes it available to this peer,
+//a chaincode is activated on a chain by instantiating (or upgrading to) an
+//installed package through LCCC.
+
+//InstalledChaincodeNotFoundErr chaincode package not installed error
+type InstalledChaincodeNotFoundErr string
+
+func (f InstalledChaincodeNotFoundErr) Error() string {
+	return fmt.Sprintf("chaincode %s is not installed", string(f))
+}
+
+//InstalledChaincodeExistsErr chaincode package already installed error
+type InstalledChaincodeExistsErr string
+
+func (f InstalledChaincodeExistsErr) Error() string {
+	return fmt.Sprintf("chaincode %s is already installed", string(f))
+}
+
+//installedChaincodeDir returns the directory holding installed packages
+func installedChaincodeDir() string {
+	return filepath.Join(viper.GetString("peer.fileSystemPath"), "chaincodes")
+}
+
+//installedChaincodePath returns the file for the given chaincode name and version
+func installedChaincodePath(name string, version string) string {
+	return filepath.Join(installedChaincodeDir(), name+"."+version)
+}
+
+//PutInstalledChaincode saves the package of the chaincode on the peer. A
+//name and version can be installed only once
+func PutInstalledChaincode(spkg *pb.SignedChaincodeDeploymentSpec) error {
+	cds, err := ccpackage.GetDeploymentSpec(spkg)
+	if err != nil {
+		return err
+	}
+	if cds.ChaincodeSpec == nil || cds.ChaincodeSpec.ChaincodeID == nil {
+		return fmt.Errorf("chaincode ID not specified in the deployment spec")
+	}
+	if cds.CodePackage == nil {
+		return fmt.Errorf("code package not specified in the deployment spec")
+	}
+
+	id := cds.ChaincodeSpec.ChaincodeID
+	path := installedChaincodePath(id.Name, id.Version)
+	if _, err := os.Stat(path); err == nil {
+		return InstalledChaincodeExistsErr(id.Name + ":" + id.Version)
+	}
+
+	b, err := proto.Marshal(spkg)
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(installedChaincodeDir(), 0755); err != nil {
+		return fmt.Errorf("could not create chaincode install directory: %s", err)
+	}
+
+	return ioutil.WriteFile(path, b, 0644)
+}
+
+//GetInstalledPackage returns the package, with the signatures of its
+//owners, installed on the peer for the given chaincode name and version
+func GetInstalledPackage(name string, version string) (*pb.SignedChaincodeDeploymentSpec, error) {
+	b, err := ioutil.ReadFile(installedChaincodePath(name, version))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, InstalledChaincodeNotFoundErr(name + ":" + version)
+		}
+		return nil, err
+	}
+
+	spkg := &pb.SignedChaincodeDeploymentSpec{}
+	if err = proto.Unmarshal(b, spkg); err != nil {
+		return nil, fmt.Errorf("installed package for %s:%s is corrupt: %s", name, version, err)
+	}
+
+	return spkg, nil
+}
+
+//GetInstalledChaincodes returns the deployment specs of all the packages
+//installed on the peer. Corrupt packages are skipped
+func GetInstalledChaincodes() ([]*pb.ChaincodeDeploymentSpec, error) {
+	files, err := ioutil.ReadDir(installedChaincodeDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var installed []*pb.ChaincodeDeploymentSpec
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		b, err := ioutil.ReadFile(filepath.Join(installedChaincodeDir(), file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		spkg := &pb.SignedChaincodeDeploymentSpec{}
+		if err = proto.Unmarshal(b, spkg); err != nil {
+			chaincodeLogger.Warningf("Skipping corrupt installed package %s: %s", file.Name(), err)
+			continue
+		}
+		cds, err := ccpackage.GetDeploymentSpec(spkg)
+		if err != nil || cds.ChaincodeSpec == nil || cds.ChaincodeSpec.ChaincodeID == nil {
+			chaincodeLogger.Warningf("Skipping corrupt installed package %s", file.Name())
+			continue
+		}
+		installed = append(installed, cds)
+	}
+
+	return installed, nil
+}
+
+//GetInstalledChaincodeNames returns the chaincodes installed on the peer as
+//name:version
+func GetInstalledChaincodeNames() ([]string, error) {
+	installed, err := GetInstalledChaincodes()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, cds := range installed {
+		id := cds.ChaincodeSpec.ChaincodeID
+		names = append(names, id.Name+":"+id.Version)
+	}
+	return names, nil
+}
+
+//GetInstalledChaincode returns the deployment spec installed on the peer for
+//the given chaincode name and version
+func GetInstalledChaincode(name string, version string) (*pb.ChaincodeDeploymentSpec, error) {
+	spkg, err := GetInstalledPackage(name, version)
+	if err != nil {
+		return nil, err
+	}
+
+	return ccpackage.GetDeploymentSpec(spkg)
+}
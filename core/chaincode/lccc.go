@@ -17,10 +17,13 @@ limitations under the License.
 package chaincode
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 
 	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/core/audit"
+	"github.com/hyperledger/fabric/core/chaincode/ccpackage"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 	"github.com/hyperledger/fabric/core/ledger"
 	"github.com/hyperledger/fabric/core/ledger/kvledger"
@@ -32,7 +35,11 @@ import (
 //The life cycle system chaincode manages chaincodes deployed
 //on this peer. It manages chaincodes via Invoke proposals.
 //     "Args":["deploy",<ChaincodeDeploymentSpec>]
-//     "Args":["upgrade",<ChaincodeDeploymentSpec>]
+//     "Args":["install",<SignedChaincodeDeploymentSpec>]
+//     "Args":["instantiate",<chainname>,<ChaincodeDeploymentSpec>,<policy>]
+//     "Args":["upgrade",<chainname>,<ChaincodeDeploymentSpec>,<policy>]
+//     "Args":["approve",<chainname>,<organization>,<ChaincodeDefinition>]
+//     "Args":["commit",<chainname>,<ChaincodeDefinition>]
 //     "Args":["stop",<ChaincodeInvocationSpec>]
 //     "Args":["start",<ChaincodeInvocationSpec>]
 
@@ -47,6 +54,22 @@ const (
 	//DEPLOY deploy command
 	DEPLOY = "deploy"
 
+	//INSTALL install command, saves the package on the peer
+	INSTALL = "install"
+
+	//INSTANTIATE instantiate command, activates an installed package on a chain
+	INSTANTIATE = "instantiate"
+
+	//UPGRADE upgrade command, replaces the package of an instantiated chaincode
+	UPGRADE = "upgrade"
+
+	//APPROVE approve command, records the approval of a chaincode definition
+	//by an organization
+	APPROVE = "approve"
+
+	//COMMIT commit command, activates an approved chaincode definition
+	COMMIT = "commit"
+
 	//chaincode query commands
 
 	//GETCCINFO get chaincode
@@ -55,10 +78,54 @@ const (
 	//GETDEPSPEC get ChaincodeDeploymentSpec
 	GETDEPSPEC = "getdepspec"
 
+	//GETPOLICY get the policy the chaincode was instantiated with
+	GETPOLICY = "getpolicy"
+
+	//GETCCHASH get the hash of the code package of the chaincode
+	GETCCHASH = "gethash"
+
+	//GETCCDATA get the ChaincodeInfo of an instantiated chaincode
+	GETCCDATA = "getccdata"
+
+	//GETAPPROVALS get the organizations that approved a chaincode definition
+	GETAPPROVALS = "getapprovals"
+
+	//GETDEFINITION get the committed ChaincodeDefinition
+	GETDEFINITION = "getdefinition"
+
+	//GETINSTALLED list the chaincodes installed on the peer
+	GETINSTALLED = "getinstalledchaincodes"
+
+	//GETINSTANTIATED list the chaincodes instantiated on a chain
+	GETINSTANTIATED = "getchaincodes"
+
+	//GETINTERESTS get the interests declared by the committed definition
+	GETINTERESTS = "getinterests"
+
+	//UPGRADEEVENT name of the event recording the versions of an upgrade
+	UPGRADEEVENT = "upgrade"
+
 	//characters used in chaincodenamespace
 	specialChars = "/:[]${}"
 )
 
+//UpgradeEvent is the payload of the event set by the upgrade transaction,
+//the peer migrates the state of the chaincode from the old to the new version
+type UpgradeEvent struct {
+	Name        string `json:"name"`
+	FromVersion string `json:"fromVersion"`
+	ToVersion   string `json:"toVersion"`
+}
+
+//chaincodeData is what the chain records of an instantiated chaincode
+//besides its code: its endorsement policy and the system chaincodes
+//endorsing and validating its transactions
+type chaincodeData struct {
+	policy []byte
+	escc   string
+	vscc   string
+}
+
 //---------- the LCCC -----------------
 
 // LifeCycleSysCC implements chaincode lifecycle and policies aroud it
@@ -130,6 +197,20 @@ func (f InvalidChainNameErr) Error() string {
 	return fmt.Sprintf("invalid chain name %s", string(f))
 }
 
+//InvalidChaincodeVersionErr invalid chaincode version error
+type InvalidChaincodeVersionErr string
+
+func (f InvalidChaincodeVersionErr) Error() string {
+	return fmt.Sprintf("invalid chain code version %s", string(f))
+}
+
+//IdenticalVersionErr upgrade to the instantiated version error
+type IdenticalVersionErr string
+
+func (f IdenticalVersionErr) Error() string {
+	return fmt.Sprintf("chaincode %s is already at this version", string(f))
+}
+
 //InvalidChaincodeNameErr invalid chaincode name error
 type InvalidChaincodeNameErr string
 
@@ -137,6 +218,13 @@ func (f InvalidChaincodeNameErr) Error() string {
 	return fmt.Sprintf("invalid chain code name %s", string(f))
 }
 
+//UnknownSysCCErr system chaincode not registered error
+type UnknownSysCCErr string
+
+func (f UnknownSysCCErr) Error() string {
+	return fmt.Sprintf("%s is not a registered system chaincode", string(f))
+}
+
 //-------------- helper functions ------------------
 //create the table to maintain list of chaincodes maintained in this
 //blockchain.
@@ -151,9 +239,21 @@ func (lccc *LifeCycleSysCC) createChaincodeTable(stub shim.ChaincodeStubInterfac
 	//QUESTION - Should code be separately maintained ?
 	codeDef := shim.ColumnDefinition{Name: "code",
 		Type: shim.ColumnDefinition_BYTES, Key: false}
+	policyDef := shim.ColumnDefinition{Name: "policy",
+		Type: shim.ColumnDefinition_BYTES, Key: false}
+	hashDef := shim.ColumnDefinition{Name: "hash",
+		Type: shim.ColumnDefinition_BYTES, Key: false}
+	esccDef := shim.ColumnDefinition{Name: "escc",
+		Type: shim.ColumnDefinition_STRING, Key: false}
+	vsccDef := shim.ColumnDefinition{Name: "vscc",
+		Type: shim.ColumnDefinition_STRING, Key: false}
 	colDefs = append(colDefs, &nameColDef)
 	colDefs = append(colDefs, &versColDef)
 	colDefs = append(colDefs, &codeDef)
+	colDefs = append(colDefs, &policyDef)
+	colDefs = append(colDefs, &hashDef)
+	colDefs = append(colDefs, &esccDef)
+	colDefs = append(colDefs, &vsccDef)
 	return stub.CreateTable(cctable, colDefs)
 }
 
@@ -176,23 +276,74 @@ func (lccc *LifeCycleSysCC) register(stub shim.ChaincodeStubInterface, name stri
 }
 
 //create the chaincode on the given chain
-func (lccc *LifeCycleSysCC) createChaincode(stub shim.ChaincodeStubInterface, chainname string, ccname string, cccode []byte) (*shim.Row, error) {
+func (lccc *LifeCycleSysCC) createChaincode(stub shim.ChaincodeStubInterface, chainname string, ccname string, cccode []byte, data *chaincodeData) (*shim.Row, error) {
+	row, err := lccc.newChaincodeRow(ccname, 0, cccode, data)
+	if err != nil {
+		return nil, err
+	}
+	_, err = stub.InsertRow(CHAINCODETABLE+"-"+chainname, *row)
+	if err != nil {
+		return nil, fmt.Errorf("insertion of chaincode failed. %s", err)
+	}
+	return row, nil
+}
+
+//replace the code and data of an existing chaincode on the given chain
+func (lccc *LifeCycleSysCC) upgradeChaincode(stub shim.ChaincodeStubInterface, chainname string, ccname string, version int32, cccode []byte, data *chaincodeData) (*shim.Row, error) {
+	row, err := lccc.newChaincodeRow(ccname, version, cccode, data)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := stub.ReplaceRow(CHAINCODETABLE+"-"+chainname, *row)
+	if err != nil {
+		return nil, fmt.Errorf("upgrade of chaincode failed. %s", err)
+	}
+	if !ok {
+		return nil, TXNotFoundErr(chainname + "/" + ccname)
+	}
+	return row, nil
+}
+
+//newChaincodeRow returns the row of the chaincode, recording the hash of its
+//code package so organizations can verify they installed the same code. The
+//transactions of the chaincode are endorsed by "escc" and validated by
+//"vscc" unless the data names other system chaincodes
+func (lccc *LifeCycleSysCC) newChaincodeRow(ccname string, version int32, cccode []byte, data *chaincodeData) (*shim.Row, error) {
+	cds, err := lccc.getChaincodeDeploymentSpec(cccode)
+	if err != nil {
+		return nil, err
+	}
+
+	if data == nil {
+		data = &chaincodeData{}
+	}
+	escc, vscc := data.escc, data.vscc
+	if escc == "" {
+		escc = "escc"
+	}
+	if vscc == "" {
+		vscc = "vscc"
+	}
+
 	var columns []*shim.Column
 
 	nameCol := shim.Column{Value: &shim.Column_String_{String_: ccname}}
-	versCol := shim.Column{Value: &shim.Column_Int32{Int32: 0}}
+	versCol := shim.Column{Value: &shim.Column_Int32{Int32: version}}
 	codeCol := shim.Column{Value: &shim.Column_Bytes{Bytes: cccode}}
+	policyCol := shim.Column{Value: &shim.Column_Bytes{Bytes: data.policy}}
+	hashCol := shim.Column{Value: &shim.Column_Bytes{Bytes: ccpackage.GetPackageHash(cds)}}
+	esccCol := shim.Column{Value: &shim.Column_String_{String_: escc}}
+	vsccCol := shim.Column{Value: &shim.Column_String_{String_: vscc}}
 
 	columns = append(columns, &nameCol)
 	columns = append(columns, &versCol)
 	columns = append(columns, &codeCol)
+	columns = append(columns, &policyCol)
+	columns = append(columns, &hashCol)
+	columns = append(columns, &esccCol)
+	columns = append(columns, &vsccCol)
 
-	row := &shim.Row{Columns: columns}
-	_, err := stub.InsertRow(CHAINCODETABLE+"-"+chainname, *row)
-	if err != nil {
-		return nil, fmt.Errorf("insertion of chaincode failed. %s", err)
-	}
-	return row, nil
+	return &shim.Row{Columns: columns}, nil
 }
 
 //checks for existence of chaincode on the given chain
@@ -238,6 +389,20 @@ func (lccc *LifeCycleSysCC) isValidChainName(chainname string) bool {
 	return true
 }
 
+//check validity of chaincode version
+func (lccc *LifeCycleSysCC) isValidChaincodeVersion(version string) bool {
+	if version == "" {
+		return false
+	}
+
+	//the version is part of the name of the installed package
+	if strings.ContainsAny(version, specialChars) {
+		return false
+	}
+
+	return true
+}
+
 //check validity of chaincode name
 func (lccc *LifeCycleSysCC) isValidChaincodeName(chaincodename string) bool {
 	//TODO we probably need more checks
@@ -253,6 +418,18 @@ func (lccc *LifeCycleSysCC) isValidChaincodeName(chaincodename string) bool {
 	return true
 }
 
+//validateSystemChaincodes checks that the system chaincodes endorsing and
+//validating the transactions of a chaincode, empty for the default ones, are
+//registered with the peer
+func (lccc *LifeCycleSysCC) validateSystemChaincodes(escc string, vscc string) error {
+	for _, name := range []string{escc, vscc} {
+		if name != "" && !IsSysCCRegistered(name) {
+			return UnknownSysCCErr(name)
+		}
+	}
+	return nil
+}
+
 //deploy the chaincode on to the chain
 func (lccc *LifeCycleSysCC) deploy(stub shim.ChaincodeStubInterface, chainname string, cds *pb.ChaincodeDeploymentSpec) error {
 	//TODO : this needs to be converted to another data structure to be handled
@@ -287,8 +464,10 @@ func (lccc *LifeCycleSysCC) deploy(stub shim.ChaincodeStubInterface, chainname s
 
 	ctxt = context.WithValue(ctxt, TXSimulatorKey, dummytxsim)
 
-	//TODO - create chaincode support for chainname, for now use DefaultChain
 	chaincodeSupport := GetChain(ChainName(chainname))
+	if chaincodeSupport == nil {
+		return fmt.Errorf("chain %s has no chaincode support", chainname)
+	}
 
 	_, err = chaincodeSupport.Deploy(ctxt, t)
 	if err != nil {
@@ -332,6 +511,11 @@ func (lccc *LifeCycleSysCC) executeDeploy(stub shim.ChaincodeStubInterface, chai
 		return err
 	}
 
+	//a deployment spec carries no signatures of its owners
+	if owners, err := getTrustedOwners(); err != nil || len(owners) > 0 {
+		return UnsignedPackageErr(cds.ChaincodeSpec.ChaincodeID.Name)
+	}
+
 	_, exists, err := lccc.getChaincode(stub, chainname, cds.ChaincodeSpec.ChaincodeID.Name)
 	if exists {
 		return ChaincodeExistsErr(cds.ChaincodeSpec.ChaincodeID.Name)
@@ -345,16 +529,261 @@ func (lccc *LifeCycleSysCC) executeDeploy(stub shim.ChaincodeStubInterface, chai
 		 *}
 		 **/
 
-	_, err = lccc.createChaincode(stub, chainname, cds.ChaincodeSpec.ChaincodeID.Name, code)
+	_, err = lccc.createChaincode(stub, chainname, cds.ChaincodeSpec.ChaincodeID.Name, code, nil)
 
 	return err
 }
 
+//validate the name and version of the chaincode in a deployment spec
+func (lccc *LifeCycleSysCC) getValidatedDeploymentSpec(code []byte) (*pb.ChaincodeDeploymentSpec, error) {
+	cds, err := lccc.getChaincodeDeploymentSpec(code)
+	if err != nil {
+		return nil, err
+	}
+
+	if cds.ChaincodeSpec == nil || cds.ChaincodeSpec.ChaincodeID == nil {
+		return nil, InvalidDeploymentSpecErr("chaincode ID not specified")
+	}
+
+	if !lccc.isValidChaincodeName(cds.ChaincodeSpec.ChaincodeID.Name) {
+		return nil, InvalidChaincodeNameErr(cds.ChaincodeSpec.ChaincodeID.Name)
+	}
+
+	if !lccc.isValidChaincodeVersion(cds.ChaincodeSpec.ChaincodeID.Version) {
+		return nil, InvalidChaincodeVersionErr(cds.ChaincodeSpec.ChaincodeID.Version)
+	}
+
+	return cds, nil
+}
+
+//this implements "install". The package is saved on the peer only, nothing
+//is written to the chain
+func (lccc *LifeCycleSysCC) executeInstall(stub shim.ChaincodeStubInterface, pkg []byte) error {
+	spkg := &pb.SignedChaincodeDeploymentSpec{}
+	if err := proto.Unmarshal(pkg, spkg); err != nil {
+		return InvalidDeploymentSpecErr(err.Error())
+	}
+
+	cds, err := lccc.getValidatedDeploymentSpec(spkg.ChaincodeDeploymentSpec)
+	if err != nil {
+		return err
+	}
+
+	if cds.CodePackage == nil {
+		return InvalidDeploymentSpecErr("code package not specified")
+	}
+
+	if err = CheckPackageOwners(cds.ChaincodeSpec.ChaincodeID.Name, spkg); err != nil {
+		return err
+	}
+
+	return PutInstalledChaincode(spkg)
+}
+
+//getInstalledDeploymentSpec returns the installed package of the chaincode
+//in the given spec, carrying the init arguments of that spec
+func (lccc *LifeCycleSysCC) getInstalledDeploymentSpec(cds *pb.ChaincodeDeploymentSpec) ([]byte, error) {
+	id := cds.ChaincodeSpec.ChaincodeID
+	installed, err := GetInstalledChaincode(id.Name, id.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	installed.ChaincodeSpec.CtorMsg = cds.ChaincodeSpec.CtorMsg
+
+	return proto.Marshal(installed)
+}
+
+//this implements "instantiate" Invoke transaction. It returns the stored
+//deployment spec so the caller can launch the chaincode
+func (lccc *LifeCycleSysCC) executeInstantiate(stub shim.ChaincodeStubInterface, chainname string, code []byte, data *chaincodeData) ([]byte, error) {
+	if err := lccc.register(stub, chainname); err != nil {
+		if _, ok := err.(AlreadyRegisteredErr); !ok {
+			return nil, err
+		}
+	}
+
+	cds, err := lccc.getValidatedDeploymentSpec(code)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = lccc.checkDeploymentApprovals(stub, chainname, cds, data); err != nil {
+		return nil, err
+	}
+
+	return lccc.instantiate(stub, chainname, cds, data)
+}
+
+//instantiate the installed package named by the spec on the chain
+func (lccc *LifeCycleSysCC) instantiate(stub shim.ChaincodeStubInterface, chainname string, cds *pb.ChaincodeDeploymentSpec, data *chaincodeData) ([]byte, error) {
+	if err := lccc.acl(stub, ChainName(chainname), cds); err != nil {
+		return nil, err
+	}
+
+	_, exists, _ := lccc.getChaincode(stub, chainname, cds.ChaincodeSpec.ChaincodeID.Name)
+	if exists {
+		return nil, ChaincodeExistsErr(cds.ChaincodeSpec.ChaincodeID.Name)
+	}
+
+	depspec, err := lccc.getInstalledDeploymentSpec(cds)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = lccc.createChaincode(stub, chainname, cds.ChaincodeSpec.ChaincodeID.Name, depspec, data); err != nil {
+		return nil, err
+	}
+
+	return depspec, nil
+}
+
+//this implements "upgrade" Invoke transaction. It returns the stored
+//deployment spec of the new version so the caller can launch it
+func (lccc *LifeCycleSysCC) executeUpgrade(stub shim.ChaincodeStubInterface, chainname string, code []byte, data *chaincodeData) ([]byte, error) {
+	cds, err := lccc.getValidatedDeploymentSpec(code)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = lccc.checkDeploymentApprovals(stub, chainname, cds, data); err != nil {
+		return nil, err
+	}
+
+	return lccc.upgrade(stub, chainname, cds, data)
+}
+
+//upgrade the chaincode on the chain to the installed package named by the spec
+func (lccc *LifeCycleSysCC) upgrade(stub shim.ChaincodeStubInterface, chainname string, cds *pb.ChaincodeDeploymentSpec, data *chaincodeData) ([]byte, error) {
+	if err := lccc.acl(stub, ChainName(chainname), cds); err != nil {
+		return nil, err
+	}
+
+	ccname := cds.ChaincodeSpec.ChaincodeID.Name
+	ccrow, exists, _ := lccc.getChaincode(stub, chainname, ccname)
+	if !exists {
+		return nil, TXNotFoundErr(chainname + "/" + ccname)
+	}
+
+	current, err := lccc.getChaincodeDeploymentSpec(ccrow.Columns[2].GetBytes())
+	if err != nil {
+		return nil, err
+	}
+
+	if current.ChaincodeSpec.ChaincodeID.Version == cds.ChaincodeSpec.ChaincodeID.Version {
+		return nil, IdenticalVersionErr(ccname + ":" + cds.ChaincodeSpec.ChaincodeID.Version)
+	}
+
+	depspec, err := lccc.getInstalledDeploymentSpec(cds)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = lccc.upgradeChaincode(stub, chainname, ccname, ccrow.Columns[1].GetInt32()+1, depspec, data); err != nil {
+		return nil, err
+	}
+
+	event, err := json.Marshal(&UpgradeEvent{Name: ccname, FromVersion: current.ChaincodeSpec.ChaincodeID.Version, ToVersion: cds.ChaincodeSpec.ChaincodeID.Version})
+	if err != nil {
+		return nil, err
+	}
+	if err = stub.SetEvent(UPGRADEEVENT, event); err != nil {
+		return nil, err
+	}
+
+	return depspec, nil
+}
+
+//audit records the lifecycle operation of the creator of the proposal on
+//the chaincode of the deployment spec code
+func (lccc *LifeCycleSysCC) audit(stub shim.ChaincodeStubInterface, operation string, chainname string, code []byte, err error) {
+	details := chainname
+	if cds, cerr := lccc.getChaincodeDeploymentSpec(code); cerr == nil && cds.ChaincodeSpec != nil && cds.ChaincodeSpec.ChaincodeID != nil {
+		id := cds.ChaincodeSpec.ChaincodeID
+		details = strings.TrimPrefix(chainname+"/"+id.Name+":"+id.Version, "/")
+	}
+	creator, _ := stub.GetCreator()
+	audit.Record(audit.Identity(creator), operation, details, err)
+}
+
 //TODO - this is temporary till we use Transaction in chaincode code
 func (lccc *LifeCycleSysCC) toTransaction(cds *pb.ChaincodeDeploymentSpec) (*pb.Transaction, error) {
 	return pb.NewChaincodeDeployTransaction(cds, cds.ChaincodeSpec.ChaincodeID.Name)
 }
 
+//getInstalledChaincodes lists the packages installed on the peer
+func (lccc *LifeCycleSysCC) getInstalledChaincodes() ([]byte, error) {
+	installed, err := GetInstalledChaincodes()
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.ChaincodeQueryResponse{}
+	for _, cds := range installed {
+		resp.Chaincodes = append(resp.Chaincodes, &pb.ChaincodeInfo{
+			Name:    cds.ChaincodeSpec.ChaincodeID.Name,
+			Version: cds.ChaincodeSpec.ChaincodeID.Version,
+			Path:    cds.ChaincodeSpec.ChaincodeID.Path,
+			Hash:    ccpackage.GetPackageHash(cds),
+		})
+	}
+
+	return proto.Marshal(resp)
+}
+
+//getInstantiatedChaincodes lists the chaincodes of the chaincode table of
+//the chain
+func (lccc *LifeCycleSysCC) getInstantiatedChaincodes(stub shim.ChaincodeStubInterface, chainname string) ([]byte, error) {
+	resp := &pb.ChaincodeQueryResponse{}
+
+	//no chaincode was ever instantiated on the chain
+	if tbl, err := stub.GetTable(CHAINCODETABLE + "-" + chainname); err != nil || tbl == nil {
+		return proto.Marshal(resp)
+	}
+
+	rows, err := stub.GetRows(CHAINCODETABLE+"-"+chainname, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for row := range rows {
+		if len(row.Columns) < 3 {
+			continue
+		}
+		info, err := lccc.getChaincodeInfo(row)
+		if err != nil {
+			return nil, err
+		}
+		resp.Chaincodes = append(resp.Chaincodes, info)
+	}
+
+	return proto.Marshal(resp)
+}
+
+//getChaincodeInfo returns what the row of an instantiated chaincode records
+func (lccc *LifeCycleSysCC) getChaincodeInfo(row shim.Row) (*pb.ChaincodeInfo, error) {
+	cds, err := lccc.getChaincodeDeploymentSpec(row.Columns[2].GetBytes())
+	if err != nil {
+		return nil, err
+	}
+	info := &pb.ChaincodeInfo{Name: row.Columns[0].GetString_(), Escc: "escc", Vscc: "vscc"}
+	if cds.ChaincodeSpec != nil && cds.ChaincodeSpec.ChaincodeID != nil {
+		info.Version = cds.ChaincodeSpec.ChaincodeID.Version
+		info.Path = cds.ChaincodeSpec.ChaincodeID.Path
+	}
+	if len(row.Columns) > 3 {
+		info.EndorsementPolicy = row.Columns[3].GetBytes()
+	}
+	if len(row.Columns) > 4 {
+		info.Hash = row.Columns[4].GetBytes()
+	}
+	if len(row.Columns) > 6 {
+		info.Escc = row.Columns[5].GetString_()
+		info.Vscc = row.Columns[6].GetString_()
+	}
+	return info, nil
+}
+
 //-------------- the chaincode stub interface implementation ----------
 
 //Init does nothing
@@ -362,11 +791,25 @@ func (lccc *LifeCycleSysCC) Init(stub shim.ChaincodeStubInterface) ([]byte, erro
 	return nil, nil
 }
 
-// Invoke implements lifecycle functions "deploy", "start", "stop", "upgrade".
+// Invoke implements lifecycle functions "deploy", "install", "instantiate", "upgrade", "approve", "commit".
 // Deploy's arguments -  {[]byte("deploy"), []byte(<chainname>), <unmarshalled pb.ChaincodeDeploymentSpec>}
+// Install's arguments -  {[]byte("install"), <unmarshalled pb.SignedChaincodeDeploymentSpec>}
+// Instantiate's and upgrade's arguments -  {[]byte("instantiate"|"upgrade"), []byte(<chainname>), <unmarshalled pb.ChaincodeDeploymentSpec>, <policy>, []byte(<escc>), []byte(<vscc>)}
+// where the spec carries the name, version and init args of an installed
+// chaincode and the policy, escc and vscc are optional
+// Approve's arguments -  {[]byte("approve"), []byte(<chainname>), []byte(<organization>), <unmarshalled pb.ChaincodeDefinition>}
+// Commit's arguments -  {[]byte("commit"), []byte(<chainname>), <unmarshalled pb.ChaincodeDefinition>}
 //
 // Invoke also implements some query-like functions
 // Get chaincode arguments -  {[]byte("getid"), []byte(<chainname>), []byte(<chaincodename>)}
+// Get the hash of the code package -  {[]byte("gethash"), []byte(<chainname>), []byte(<chaincodename>)}
+// Get the chaincode data -  {[]byte("getccdata"), []byte(<chainname>), []byte(<chaincodename>)}
+// returning a marshalled pb.ChaincodeInfo
+// List the installed chaincodes -  {[]byte("getinstalledchaincodes")}
+// List the instantiated chaincodes -  {[]byte("getchaincodes"), []byte(<chainname>)}
+// both returning a marshalled pb.ChaincodeQueryResponse
+// Get the interests of the chaincode -  {[]byte("getinterests"), []byte(<chainname>), []byte(<chaincodename>), []byte(<function>)}
+// where the function is optional, returning a marshalled pb.ChaincodeInterests
 func (lccc *LifeCycleSysCC) Invoke(stub shim.ChaincodeStubInterface) ([]byte, error) {
 	args := stub.GetArgs()
 	if len(args) < 1 {
@@ -395,7 +838,116 @@ func (lccc *LifeCycleSysCC) Invoke(stub shim.ChaincodeStubInterface) ([]byte, er
 		err := lccc.executeDeploy(stub, chainname, code)
 
 		return nil, err
-	case GETCCINFO, GETDEPSPEC:
+	case INSTALL:
+		if len(args) != 2 {
+			return nil, InvalidArgsLenErr(len(args))
+		}
+
+		err := lccc.executeInstall(stub, args[1])
+		spkg := &pb.SignedChaincodeDeploymentSpec{}
+		proto.Unmarshal(args[1], spkg)
+		lccc.audit(stub, "chaincode.install", "", spkg.ChaincodeDeploymentSpec, err)
+
+		return nil, err
+	case INSTANTIATE, UPGRADE:
+		if len(args) < 3 || len(args) > 6 {
+			return nil, InvalidArgsLenErr(len(args))
+		}
+
+		chainname := string(args[1])
+
+		if !lccc.isValidChainName(chainname) {
+			return nil, InvalidChainNameErr(chainname)
+		}
+
+		data := &chaincodeData{}
+		if len(args) > 3 {
+			data.policy = args[3]
+		}
+		if len(args) > 4 {
+			data.escc = string(args[4])
+		}
+		if len(args) > 5 {
+			data.vscc = string(args[5])
+		}
+		if err := lccc.validateSystemChaincodes(data.escc, data.vscc); err != nil {
+			return nil, err
+		}
+
+		var depspec []byte
+		var err error
+		if function == INSTANTIATE {
+			depspec, err = lccc.executeInstantiate(stub, chainname, args[2], data)
+		} else {
+			depspec, err = lccc.executeUpgrade(stub, chainname, args[2], data)
+		}
+		lccc.audit(stub, "chaincode."+function, chainname, args[2], err)
+		return depspec, err
+	case APPROVE:
+		if len(args) != 4 {
+			return nil, InvalidArgsLenErr(len(args))
+		}
+
+		chainname := string(args[1])
+
+		if !lccc.isValidChainName(chainname) {
+			return nil, InvalidChainNameErr(chainname)
+		}
+
+		err := lccc.executeApprove(stub, chainname, string(args[2]), args[3])
+
+		return nil, err
+	case COMMIT, GETAPPROVALS:
+		if len(args) != 3 {
+			return nil, InvalidArgsLenErr(len(args))
+		}
+
+		chainname := string(args[1])
+
+		if !lccc.isValidChainName(chainname) {
+			return nil, InvalidChainNameErr(chainname)
+		}
+
+		if function == COMMIT {
+			return lccc.executeCommit(stub, chainname, args[2])
+		}
+		return lccc.executeGetApprovals(stub, chainname, args[2])
+	case GETINSTALLED:
+		if len(args) != 1 {
+			return nil, InvalidArgsLenErr(len(args))
+		}
+
+		return lccc.getInstalledChaincodes()
+	case GETINSTANTIATED:
+		if len(args) != 2 {
+			return nil, InvalidArgsLenErr(len(args))
+		}
+
+		chainname := string(args[1])
+
+		if !lccc.isValidChainName(chainname) {
+			return nil, InvalidChainNameErr(chainname)
+		}
+
+		return lccc.getInstantiatedChaincodes(stub, chainname)
+	case GETINTERESTS:
+		if len(args) < 3 || len(args) > 4 {
+			return nil, InvalidArgsLenErr(len(args))
+		}
+
+		var function string
+		if len(args) == 4 {
+			function = string(args[3])
+		}
+
+		return lccc.getInterests(stub, string(args[1]), string(args[2]), function)
+	case GETDEFINITION:
+		if len(args) != 3 {
+			return nil, InvalidArgsLenErr(len(args))
+		}
+
+		return lccc.getDefinition(stub, string(args[1]), string(args[2]))
+	case GETCCINFO, GETDEPSPEC, GETPOLICY, GETCCHASH, GETCCDATA:
 		if len(args) != 3 {
 			return nil, InvalidArgsLenErr(len(args))
 		}
@@ -410,8 +962,25 @@ func (lccc *LifeCycleSysCC) Invoke(stub shim.ChaincodeStubInterface) ([]byte, er
 			return nil, TXNotFoundErr(chain + "/" + ccname)
 		}
 
-		if function == GETCCINFO {
+		switch function {
+		case GETCCINFO:
 			return []byte(ccrow.Columns[1].GetString_()), nil
+		case GETPOLICY:
+			if len(ccrow.Columns) < 4 {
+				return nil, nil
+			}
+			return ccrow.Columns[3].GetBytes(), nil
+		case GETCCHASH:
+			if len(ccrow.Columns) < 5 {
+				return nil, nil
+			}
+			return ccrow.Columns[4].GetBytes(), nil
+		case GETCCDATA:
+			info, err := lccc.getChaincodeInfo(ccrow)
+			if err != nil {
+				return nil, err
+			}
+			return proto.Marshal(info)
 		}
 		return ccrow.Columns[2].GetBytes(), nil
 	}
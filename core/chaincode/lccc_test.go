@@ -16,13 +16,20 @@ limitations under the License.
 package chaincode
 
 import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/core/chaincode/ccpackage"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 	"github.com/hyperledger/fabric/core/container"
+	"github.com/hyperledger/fabric/core/crypto/primitives"
 	pb "github.com/hyperledger/fabric/protos"
+	"github.com/spf13/viper"
 	"google.golang.org/grpc"
 )
 
@@ -169,6 +176,39 @@ func TestCheckCC(t *testing.T) {
 	}
 }
 
+//TestGetHash checks that the hash of the code package is recorded on deploy
+func TestGetHash(t *testing.T) {
+	initialize()
+
+	scc := new(LifeCycleSysCC)
+	stub := shim.NewMockStub("lccc", scc)
+
+	cds, err := constructDeploymentSpec("example02", "github.com/hyperledger/fabric/examples/chaincode/go/chaincode_example02", [][]byte{[]byte("init"), []byte("a"), []byte("100"), []byte("b"), []byte("200")})
+	if err != nil {
+		t.FailNow()
+	}
+
+	//packaging the same sources again gives the same package
+	again, err := constructDeploymentSpec("example02", "github.com/hyperledger/fabric/examples/chaincode/go/chaincode_example02", [][]byte{[]byte("init"), []byte("a"), []byte("100"), []byte("b"), []byte("200")})
+	if err != nil || !bytes.Equal(cds.CodePackage, again.CodePackage) {
+		t.Fatalf("packaging identical sources gave different packages")
+	}
+
+	args := [][]byte{[]byte(DEPLOY), []byte("test"), mustMarshal(t, cds)}
+	if _, err := stub.MockInvoke("1", args); err != nil {
+		t.FailNow()
+	}
+
+	args = [][]byte{[]byte(GETCCHASH), []byte("test"), []byte(cds.ChaincodeSpec.ChaincodeID.Name)}
+	hash, err := stub.MockInvoke("1", args)
+	if err != nil {
+		t.Fatalf("gethash failed: %s", err)
+	}
+	if !bytes.Equal(hash, ccpackage.GetPackageHash(cds)) {
+		t.Fatalf("expected the hash of the code package, got %x", hash)
+	}
+}
+
 //TestMultipleDeploy tests deploying multiple chaincodes
 func TestMultipleDeploy(t *testing.T) {
 	initialize()
@@ -248,3 +288,704 @@ func TestRetryFailedDeploy(t *testing.T) {
 		t.FailNow()
 	}
 }
+
+//installForTest installs example02 at the given version under a temporary peer file system path
+func installForTest(t *testing.T, stub *shim.MockStub, version string) *pb.ChaincodeDeploymentSpec {
+	cds, err := constructDeploymentSpec("example02", "github.com/hyperledger/fabric/examples/chaincode/go/chaincode_example02", [][]byte{[]byte("init"), []byte("a"), []byte("100"), []byte("b"), []byte("200")})
+	if err != nil {
+		t.FailNow()
+	}
+	cds.ChaincodeSpec.ChaincodeID.Version = version
+
+	args := [][]byte{[]byte(INSTALL), packageForTest(t, cds)}
+	if _, err := stub.MockInvoke("1", args); err != nil {
+		t.Fatalf("install failed: %s", err)
+	}
+
+	return cds
+}
+
+//packageForTest returns the package of the deployment spec signed by the given owners
+func packageForTest(t *testing.T, cds *pb.ChaincodeDeploymentSpec, owners ...*owner) []byte {
+	spkg, err := ccpackage.NewSignedPackage(cds, nil)
+	if err != nil {
+		t.FailNow()
+	}
+	for _, o := range owners {
+		if err = ccpackage.Sign(spkg, o.cert, o.key); err != nil {
+			t.Fatalf("signing failed: %s", err)
+		}
+	}
+	return mustMarshal(t, spkg)
+}
+
+//withoutCode returns the spec sent to instantiate or upgrade an installed chaincode
+func withoutCode(t *testing.T, cds *pb.ChaincodeDeploymentSpec) []byte {
+	b, err := proto.Marshal(&pb.ChaincodeDeploymentSpec{ChaincodeSpec: cds.ChaincodeSpec})
+	if err != nil {
+		t.FailNow()
+	}
+	return b
+}
+
+func setupInstallDir(t *testing.T) func() {
+	dir, err := ioutil.TempDir("", "lccc")
+	if err != nil {
+		t.FailNow()
+	}
+	viper.Set("peer.fileSystemPath", dir)
+	return func() { os.RemoveAll(dir) }
+}
+
+//TestInstall tests installing a package twice fails and a package needs a version
+func TestInstall(t *testing.T) {
+	initialize()
+	defer setupInstallDir(t)()
+
+	scc := new(LifeCycleSysCC)
+	stub := shim.NewMockStub("lccc", scc)
+
+	cds := installForTest(t, stub, "1.0")
+
+	if installed, err := GetInstalledChaincode("example02", "1.0"); err != nil || installed.CodePackage == nil {
+		t.Fatalf("package not installed: %s", err)
+	}
+
+	if _, err := stub.MockInvoke("1", [][]byte{[]byte(INSTALL), packageForTest(t, cds)}); err == nil {
+		t.Fatalf("expected install of an installed package to fail")
+	}
+
+	cds.ChaincodeSpec.ChaincodeID.Version = ""
+	_, err := stub.MockInvoke("1", [][]byte{[]byte(INSTALL), packageForTest(t, cds)})
+	if _, ok := err.(InvalidChaincodeVersionErr); !ok {
+		t.Fatalf("expected invalid version error, got %v", err)
+	}
+}
+
+//TestInstantiate tests instantiating an installed package and the policy it is stored with
+func TestInstantiate(t *testing.T) {
+	initialize()
+	defer setupInstallDir(t)()
+
+	scc := new(LifeCycleSysCC)
+	stub := shim.NewMockStub("lccc", scc)
+	mockChannel(stub)
+
+	cds := installForTest(t, stub, "1.0")
+
+	args := [][]byte{[]byte(INSTANTIATE), []byte("test"), withoutCode(t, cds), []byte("policy")}
+	depspec, err := stub.MockInvoke("1", args)
+	if err != nil {
+		t.Fatalf("instantiate failed: %s", err)
+	}
+
+	stored := &pb.ChaincodeDeploymentSpec{}
+	if err = proto.Unmarshal(depspec, stored); err != nil || stored.CodePackage == nil {
+		t.Fatalf("expected the installed package to be returned")
+	}
+
+	args = [][]byte{[]byte(GETPOLICY), []byte("test"), []byte("example02")}
+	if policy, err := stub.MockInvoke("1", args); err != nil || string(policy) != "policy" {
+		t.Fatalf("expected the policy to be stored, got %s (%v)", policy, err)
+	}
+
+	args = [][]byte{[]byte(INSTANTIATE), []byte("test"), withoutCode(t, cds)}
+	_, err = stub.MockInvoke("1", args)
+	if _, ok := err.(ChaincodeExistsErr); !ok {
+		t.Fatalf("expected exists error, got %v", err)
+	}
+}
+
+//TestChaincodeData tests the system chaincodes recorded for an instantiated chaincode
+func TestChaincodeData(t *testing.T) {
+	initialize()
+	defer setupInstallDir(t)()
+
+	scc := new(LifeCycleSysCC)
+	stub := shim.NewMockStub("lccc", scc)
+	mockChannel(stub)
+
+	cds := installForTest(t, stub, "1.0")
+
+	viper.Set("chaincode.system", map[string]string{"escc": "true", "vscc": "true"})
+	defer viper.Set("chaincode.system", map[string]string{})
+
+	args := [][]byte{[]byte(INSTANTIATE), []byte("test"), withoutCode(t, cds), []byte("policy"), []byte("escc"), []byte("myvscc")}
+	if _, err := stub.MockInvoke("1", args); err == nil {
+		t.Fatalf("expected an unregistered vscc to fail")
+	}
+
+	args[5] = []byte("vscc")
+	if _, err := stub.MockInvoke("1", args); err != nil {
+		t.Fatalf("instantiate failed: %s", err)
+	}
+
+	b, err := stub.MockInvoke("1", [][]byte{[]byte(GETCCDATA), []byte("test"), []byte("example02")})
+	info := &pb.ChaincodeInfo{}
+	if err != nil || proto.Unmarshal(b, info) != nil {
+		t.Fatalf("getccdata failed: %v", err)
+	}
+	if info.Version != "1.0" || string(info.EndorsementPolicy) != "policy" || info.Escc != "escc" || info.Vscc != "vscc" || len(info.Hash) == 0 {
+		t.Fatalf("unexpected chaincode data %v", info)
+	}
+
+	//the default system chaincodes are recorded when none is given
+	cds = installForTest(t, stub, "2.0")
+	args = [][]byte{[]byte(UPGRADE), []byte("test"), withoutCode(t, cds)}
+	if _, err = stub.MockInvoke("1", args); err != nil {
+		t.Fatalf("upgrade failed: %s", err)
+	}
+	b, err = stub.MockInvoke("1", [][]byte{[]byte(GETCCDATA), []byte("test"), []byte("example02")})
+	info = &pb.ChaincodeInfo{}
+	if err != nil || proto.Unmarshal(b, info) != nil || info.Version != "2.0" || info.Escc != "escc" || info.Vscc != "vscc" {
+		t.Fatalf("unexpected chaincode data %v (%v)", info, err)
+	}
+}
+
+//TestInstantiateNotInstalled tests instantiating a package that is not installed fails
+func TestInstantiateNotInstalled(t *testing.T) {
+	initialize()
+	defer setupInstallDir(t)()
+
+	scc := new(LifeCycleSysCC)
+	stub := shim.NewMockStub("lccc", scc)
+	mockChannel(stub)
+
+	cds, err := constructDeploymentSpec("example02", "github.com/hyperledger/fabric/examples/chaincode/go/chaincode_example02", [][]byte{[]byte("init")})
+	if err != nil {
+		t.FailNow()
+	}
+	cds.ChaincodeSpec.ChaincodeID.Version = "1.0"
+
+	args := [][]byte{[]byte(INSTANTIATE), []byte("test"), withoutCode(t, cds)}
+	_, err = stub.MockInvoke("1", args)
+	if _, ok := err.(InstalledChaincodeNotFoundErr); !ok {
+		t.Fatalf("expected not installed error, got %v", err)
+	}
+}
+
+//TestUpgrade tests upgrading to another installed version
+func TestUpgrade(t *testing.T) {
+	initialize()
+	defer setupInstallDir(t)()
+
+	scc := new(LifeCycleSysCC)
+	stub := shim.NewMockStub("lccc", scc)
+	mockChannel(stub)
+
+	cds := installForTest(t, stub, "1.0")
+
+	args := [][]byte{[]byte(UPGRADE), []byte("test"), withoutCode(t, cds)}
+	if _, err := stub.MockInvoke("1", args); err == nil {
+		t.Fatalf("expected upgrade of a chaincode not instantiated to fail")
+	}
+
+	args = [][]byte{[]byte(INSTANTIATE), []byte("test"), withoutCode(t, cds)}
+	if _, err := stub.MockInvoke("1", args); err != nil {
+		t.Fatalf("instantiate failed: %s", err)
+	}
+
+	args = [][]byte{[]byte(UPGRADE), []byte("test"), withoutCode(t, cds)}
+	_, err := stub.MockInvoke("1", args)
+	if _, ok := err.(IdenticalVersionErr); !ok {
+		t.Fatalf("expected identical version error, got %v", err)
+	}
+
+	cds = installForTest(t, stub, "2.0")
+
+	args = [][]byte{[]byte(UPGRADE), []byte("test"), withoutCode(t, cds)}
+	if _, err = stub.MockInvoke("1", args); err != nil {
+		t.Fatalf("upgrade failed: %s", err)
+	}
+
+	args = [][]byte{[]byte(GETDEPSPEC), []byte("test"), []byte("example02")}
+	depspec, err := stub.MockInvoke("1", args)
+	if err != nil {
+		t.FailNow()
+	}
+	stored := &pb.ChaincodeDeploymentSpec{}
+	if err = proto.Unmarshal(depspec, stored); err != nil || stored.ChaincodeSpec.ChaincodeID.Version != "2.0" {
+		t.Fatalf("expected version 2.0 after upgrade")
+	}
+}
+
+//listForTest returns the chaincodes listed by the given lccc function
+func listForTest(t *testing.T, stub *shim.MockStub, args ...[]byte) []*pb.ChaincodeInfo {
+	b, err := stub.MockInvoke("1", args)
+	if err != nil {
+		t.Fatalf("%s failed: %s", args[0], err)
+	}
+	resp := &pb.ChaincodeQueryResponse{}
+	if err = proto.Unmarshal(b, resp); err != nil {
+		t.Fatalf("invalid response of %s: %s", args[0], err)
+	}
+	return resp.Chaincodes
+}
+
+//TestListChaincodes tests listing the installed and the instantiated chaincodes
+func TestListChaincodes(t *testing.T) {
+	initialize()
+	defer setupInstallDir(t)()
+
+	scc := new(LifeCycleSysCC)
+	stub := shim.NewMockStub("lccc", scc)
+	mockChannel(stub)
+
+	if ccs := listForTest(t, stub, []byte(GETINSTALLED)); len(ccs) != 0 {
+		t.Fatalf("expected no installed chaincodes, got %v", ccs)
+	}
+	if ccs := listForTest(t, stub, []byte(GETINSTANTIATED), []byte("test")); len(ccs) != 0 {
+		t.Fatalf("expected no instantiated chaincodes, got %v", ccs)
+	}
+
+	cds := installForTest(t, stub, "1.0")
+	installForTest(t, stub, "2.0")
+
+	ccs := listForTest(t, stub, []byte(GETINSTALLED))
+	if len(ccs) != 2 || ccs[0].Name != "example02" || ccs[0].Version != "1.0" || ccs[1].Version != "2.0" {
+		t.Fatalf("expected versions 1.0 and 2.0 to be installed, got %v", ccs)
+	}
+	if !bytes.Equal(ccs[0].Hash, ccpackage.GetPackageHash(cds)) {
+		t.Fatalf("unexpected hash of the installed package")
+	}
+
+	args := [][]byte{[]byte(INSTANTIATE), []byte("test"), withoutCode(t, cds), []byte("policy")}
+	if _, err := stub.MockInvoke("1", args); err != nil {
+		t.Fatalf("instantiate failed: %s", err)
+	}
+
+	ccs = listForTest(t, stub, []byte(GETINSTANTIATED), []byte("test"))
+	if len(ccs) != 1 || ccs[0].Name != "example02" || ccs[0].Version != "1.0" || string(ccs[0].EndorsementPolicy) != "policy" {
+		t.Fatalf("expected example02:1.0 to be instantiated, got %v", ccs)
+	}
+	if !bytes.Equal(ccs[0].Hash, ccpackage.GetPackageHash(cds)) {
+		t.Fatalf("unexpected hash of the instantiated package")
+	}
+
+	if _, err := stub.MockInvoke("1", [][]byte{[]byte(GETINSTANTIATED)}); err == nil {
+		t.Fatalf("expected listing without a chain name to fail")
+	}
+}
+
+//TestLifecycleACL tests installers and instantiators are checked separately
+func TestLifecycleACL(t *testing.T) {
+	f, err := ioutil.TempFile("", "installer")
+	if err != nil {
+		t.FailNow()
+	}
+	defer os.Remove(f.Name())
+	f.WriteString("installer\n")
+	f.Close()
+
+	viper.Set("chaincode.lifecycle.installers", []string{f.Name()})
+	defer viper.Set("chaincode.lifecycle.installers", []string{})
+
+	if err = CheckLifecycleACL([][]byte{[]byte(INSTALL)}, []byte("installer")); err != nil {
+		t.Fatalf("expected installer to be permitted: %s", err)
+	}
+	if err = CheckLifecycleACL([][]byte{[]byte(INSTALL)}, []byte("other")); err == nil {
+		t.Fatalf("expected other creator to be denied")
+	}
+	if err = CheckLifecycleACL([][]byte{[]byte(INSTANTIATE)}, []byte("other")); err != nil {
+		t.Fatalf("expected instantiate to be permitted to everyone: %s", err)
+	}
+	if err = CheckLifecycleACL([][]byte{[]byte(GETINSTALLED)}, []byte("other")); err == nil {
+		t.Fatalf("expected other creator to be denied the installed chaincodes")
+	}
+}
+
+//TestListACL tests the instantiated chaincodes are listed to organization members only
+func TestListACL(t *testing.T) {
+	args := [][]byte{[]byte(GETINSTANTIATED), []byte("test")}
+	if err := CheckLifecycleACL(args, []byte("other")); err != nil {
+		t.Fatalf("expected listing to be permitted without organizations: %s", err)
+	}
+
+	defer setupOrganizations(t, "org1", "org2")()
+
+	if err := CheckLifecycleACL(args, []byte("org2-admin")); err != nil {
+		t.Fatalf("expected member to be permitted: %s", err)
+	}
+	if err := CheckLifecycleACL(args, []byte("other")); err == nil {
+		t.Fatalf("expected non member to be denied")
+	}
+}
+
+//setupOrganizations configures the given organizations, each with one member identity file
+func setupOrganizations(t *testing.T, names ...string) func() {
+	dir, err := ioutil.TempDir("", "orgs")
+	if err != nil {
+		t.FailNow()
+	}
+	var orgs []map[string]interface{}
+	for _, name := range names {
+		member := filepath.Join(dir, name)
+		if err = ioutil.WriteFile(member, []byte(name+"-admin"), 0644); err != nil {
+			t.FailNow()
+		}
+		orgs = append(orgs, map[string]interface{}{"name": name, "members": []string{member}})
+	}
+	viper.Set("chaincode.lifecycle.organizations", orgs)
+	return func() {
+		viper.Set("chaincode.lifecycle.organizations", nil)
+		os.RemoveAll(dir)
+	}
+}
+
+//mockChannel answers the channel configuration requests of the stub with a
+//chain declaring the given organizations
+func mockChannel(stub *shim.MockStub, orgs ...string) {
+	stub.MockInvokedChaincode("qscc", func(args [][]byte) ([]byte, error) {
+		return proto.Marshal(&pb.ChannelConfig{ChainID: string(args[1]), Organizations: orgs})
+	})
+}
+
+func TestInterests(t *testing.T) {
+	initialize()
+	defer setupInstallDir(t)()
+	defer setupOrganizations(t, "org1", "org2")()
+
+	scc := new(LifeCycleSysCC)
+	stub := shim.NewMockStub("lccc", scc)
+	mockChannel(stub, "org1", "org2")
+
+	installForTest(t, stub, "1.0")
+
+	def := &pb.ChaincodeDefinition{Name: "example02", Version: "1.0", EndorsementPolicy: []byte("policy"), CtorMsg: &pb.ChaincodeInput{Args: [][]byte{[]byte("init")}}}
+
+	def.Interests = []*pb.ChaincodeInterest{{Function: "invoke", Organizations: []string{"org3"}}}
+	if err := approve(t, stub, "org1", def); err == nil {
+		t.Fatalf("expected an interest in an unknown organization to fail")
+	}
+
+	def.Interests = []*pb.ChaincodeInterest{{Function: "invoke"}, {Function: "invoke"}}
+	if err := approve(t, stub, "org1", def); err == nil {
+		t.Fatalf("expected a function declared twice to fail")
+	}
+
+	def.Interests = []*pb.ChaincodeInterest{{Function: "invoke", Collections: []string{"marbles"}, Organizations: []string{"org1"}}, {Function: "query"}}
+	for _, org := range []string{"org1", "org2"} {
+		if err := approve(t, stub, org, def); err != nil {
+			t.Fatalf("approve failed: %s", err)
+		}
+	}
+	if err := commit(t, stub, def); err != nil {
+		t.Fatalf("commit failed: %s", err)
+	}
+
+	b, err := stub.MockInvoke("1", [][]byte{[]byte(GETINTERESTS), []byte("test"), []byte("example02")})
+	interests := &pb.ChaincodeInterests{}
+	if err != nil || proto.Unmarshal(b, interests) != nil || len(interests.Interests) != 2 {
+		t.Fatalf("expected the interests of both functions, got %v (%v)", interests, err)
+	}
+
+	b, err = stub.MockInvoke("1", [][]byte{[]byte(GETINTERESTS), []byte("test"), []byte("example02"), []byte("invoke")})
+	interests = &pb.ChaincodeInterests{}
+	if err != nil || proto.Unmarshal(b, interests) != nil || len(interests.Interests) != 1 || interests.Interests[0].Collections[0] != "marbles" {
+		t.Fatalf("expected the interests of invoke, got %v (%v)", interests, err)
+	}
+
+	b, err = stub.MockInvoke("1", [][]byte{[]byte(GETINTERESTS), []byte("test"), []byte("example02"), []byte("delete")})
+	if err != nil || len(b) != 0 {
+		t.Fatalf("expected no interests for delete, got %v (%v)", b, err)
+	}
+
+	if _, err = stub.MockInvoke("1", [][]byte{[]byte(GETINTERESTS), []byte("test"), []byte("unknown")}); err == nil {
+		t.Fatalf("expected interests of an uncommitted chaincode to fail")
+	}
+
+	//a chaincode deployed without a definition declares no interest
+	cds, err := constructDeploymentSpec("example03", "github.com/hyperledger/fabric/examples/chaincode/go/chaincode_example02", [][]byte{[]byte("init")})
+	if err != nil {
+		t.FailNow()
+	}
+	if _, err = stub.MockInvoke("1", [][]byte{[]byte(DEPLOY), []byte("test"), mustMarshal(t, cds)}); err != nil {
+		t.Fatalf("deploy failed: %s", err)
+	}
+	b, err = stub.MockInvoke("1", [][]byte{[]byte(GETINTERESTS), []byte("test"), []byte("example03")})
+	if err != nil || len(b) != 0 {
+		t.Fatalf("expected no interests for example03, got %v (%v)", b, err)
+	}
+}
+
+func approve(t *testing.T, stub *shim.MockStub, org string, def *pb.ChaincodeDefinition) error {
+	b, err := proto.Marshal(def)
+	if err != nil {
+		t.FailNow()
+	}
+	_, err = stub.MockInvoke("1", [][]byte{[]byte(APPROVE), []byte("test"), []byte(org), b})
+	return err
+}
+
+func commit(t *testing.T, stub *shim.MockStub, def *pb.ChaincodeDefinition) error {
+	b, err := proto.Marshal(def)
+	if err != nil {
+		t.FailNow()
+	}
+	_, err = stub.MockInvoke("1", [][]byte{[]byte(COMMIT), []byte("test"), b})
+	return err
+}
+
+//TestApproveAndCommit tests a definition is only committed once a majority of organizations approved it
+func TestApproveAndCommit(t *testing.T) {
+	initialize()
+	defer setupInstallDir(t)()
+	defer setupOrganizations(t, "org1", "org2", "org3")()
+
+	scc := new(LifeCycleSysCC)
+	stub := shim.NewMockStub("lccc", scc)
+	mockChannel(stub, "org1", "org2", "org3")
+
+	installForTest(t, stub, "1.0")
+
+	def := &pb.ChaincodeDefinition{Name: "example02", Version: "1.0", EndorsementPolicy: []byte("policy"), CtorMsg: &pb.ChaincodeInput{Args: [][]byte{[]byte("init")}}}
+
+	if err := approve(t, stub, "org4", def); err == nil {
+		t.Fatalf("expected approval by an unknown organization to fail")
+	}
+
+	if err := approve(t, stub, "org1", def); err != nil {
+		t.Fatalf("approve failed: %s", err)
+	}
+
+	_, err := stub.MockInvoke("1", [][]byte{[]byte(COMMIT), []byte("test"), mustMarshal(t, def)})
+	if _, ok := err.(ApprovalPolicyErr); !ok {
+		t.Fatalf("expected approval policy error, got %v", err)
+	}
+
+	//an approval of another definition does not count
+	other := proto.Clone(def).(*pb.ChaincodeDefinition)
+	other.EndorsementPolicy = []byte("other")
+	if err = approve(t, stub, "org2", other); err != nil {
+		t.Fatalf("approve failed: %s", err)
+	}
+	if err = commit(t, stub, def); err == nil {
+		t.Fatalf("expected commit to fail")
+	}
+
+	//org2 approves the definition instead
+	if err = approve(t, stub, "org2", def); err != nil {
+		t.Fatalf("approve failed: %s", err)
+	}
+
+	approvals, err := stub.MockInvoke("1", [][]byte{[]byte(GETAPPROVALS), []byte("test"), mustMarshal(t, def)})
+	if err != nil || string(approvals) != `{"org1":true,"org2":true,"org3":false}` {
+		t.Fatalf("unexpected approvals %s (%v)", approvals, err)
+	}
+
+	if err = commit(t, stub, def); err != nil {
+		t.Fatalf("commit failed: %s", err)
+	}
+
+	policy, err := stub.MockInvoke("1", [][]byte{[]byte(GETPOLICY), []byte("test"), []byte("example02")})
+	if err != nil || string(policy) != "policy" {
+		t.Fatalf("expected the endorsement policy of the definition, got %s (%v)", policy, err)
+	}
+
+	committed, err := stub.MockInvoke("1", [][]byte{[]byte(GETDEFINITION), []byte("test"), []byte("example02")})
+	if err != nil || string(committed) != string(mustMarshal(t, def)) {
+		t.Fatalf("expected the committed definition (%v)", err)
+	}
+}
+
+//TestCommitUpgrade tests committing a definition of another version upgrades the chaincode
+func TestCommitUpgrade(t *testing.T) {
+	initialize()
+	defer setupInstallDir(t)()
+	defer setupOrganizations(t, "org1")()
+
+	scc := new(LifeCycleSysCC)
+	stub := shim.NewMockStub("lccc", scc)
+	mockChannel(stub, "org1")
+
+	installForTest(t, stub, "1.0")
+	installForTest(t, stub, "2.0")
+
+	for _, version := range []string{"1.0", "2.0"} {
+		def := &pb.ChaincodeDefinition{Name: "example02", Version: version}
+		if err := approve(t, stub, "org1", def); err != nil {
+			t.Fatalf("approve failed: %s", err)
+		}
+		if err := commit(t, stub, def); err != nil {
+			t.Fatalf("commit of %s failed: %s", version, err)
+		}
+	}
+
+	depspec, err := stub.MockInvoke("1", [][]byte{[]byte(GETDEPSPEC), []byte("test"), []byte("example02")})
+	if err != nil {
+		t.FailNow()
+	}
+	stored := &pb.ChaincodeDeploymentSpec{}
+	if err = proto.Unmarshal(depspec, stored); err != nil || stored.ChaincodeSpec.ChaincodeID.Version != "2.0" {
+		t.Fatalf("expected version 2.0 after commit")
+	}
+}
+
+//TestInstantiateApprovals tests instantiating and upgrading require the approvals of the organizations of the chain
+func TestInstantiateApprovals(t *testing.T) {
+	initialize()
+	defer setupInstallDir(t)()
+	defer setupOrganizations(t, "org1", "org2")()
+
+	scc := new(LifeCycleSysCC)
+	stub := shim.NewMockStub("lccc", scc)
+	mockChannel(stub, "org1", "org2")
+
+	for _, version := range []string{"1.0", "2.0"} {
+		cds := installForTest(t, stub, version)
+		function := INSTANTIATE
+		if version != "1.0" {
+			function = UPGRADE
+		}
+
+		args := [][]byte{[]byte(function), []byte("test"), withoutCode(t, cds), []byte("policy")}
+		if _, err := stub.MockInvoke("1", args); err == nil {
+			t.Fatalf("expected %s of %s without approvals to fail", function, version)
+		} else if _, ok := err.(ApprovalPolicyErr); !ok {
+			t.Fatalf("expected approval policy error, got %v", err)
+		}
+
+		def := &pb.ChaincodeDefinition{Name: "example02", Version: version, EndorsementPolicy: []byte("policy"), CtorMsg: cds.ChaincodeSpec.CtorMsg}
+		for _, org := range []string{"org1", "org2"} {
+			if err := approve(t, stub, org, def); err != nil {
+				t.Fatalf("approve failed: %s", err)
+			}
+		}
+
+		if _, err := stub.MockInvoke("1", args); err != nil {
+			t.Fatalf("%s of %s failed: %s", function, version, err)
+		}
+	}
+}
+
+//TestApprovalACL tests approvals are only accepted from members of the organization
+func TestApprovalACL(t *testing.T) {
+	defer setupOrganizations(t, "org1", "org2")()
+
+	args := [][]byte{[]byte(APPROVE), []byte("test"), []byte("org1"), nil}
+	if err := CheckLifecycleACL(args, []byte("org1-admin")); err != nil {
+		t.Fatalf("expected org1 member to be permitted: %s", err)
+	}
+	if err := CheckLifecycleACL(args, []byte("org2-admin")); err == nil {
+		t.Fatalf("expected org2 member to be denied")
+	}
+}
+
+func mustMarshal(t *testing.T, msg proto.Message) []byte {
+	b, err := proto.Marshal(msg)
+	if err != nil {
+		t.FailNow()
+	}
+	return b
+}
+
+type owner struct {
+	cert []byte
+	key  interface{}
+}
+
+//setupOwners trusts a new owner and returns it together with an owner not trusted
+func setupOwners(t *testing.T) (*owner, *owner, func()) {
+	primitives.InitSecurityLevel("SHA2", 256)
+
+	var owners []*owner
+	for i := 0; i < 2; i++ {
+		der, key, err := primitives.NewSelfSignedCert()
+		if err != nil {
+			t.FailNow()
+		}
+		owners = append(owners, &owner{cert: primitives.DERCertToPEM(der), key: key})
+	}
+
+	f, err := ioutil.TempFile("", "owner")
+	if err != nil {
+		t.FailNow()
+	}
+	f.Write(owners[0].cert)
+	f.Close()
+
+	viper.Set("chaincode.lifecycle.owners", []string{f.Name()})
+	return owners[0], owners[1], func() {
+		viper.Set("chaincode.lifecycle.owners", []string{})
+		os.Remove(f.Name())
+	}
+}
+
+//TestInstallSignedPackage tests only packages signed by a trusted owner are installed
+func TestInstallSignedPackage(t *testing.T) {
+	initialize()
+	defer setupInstallDir(t)()
+	trusted, untrusted, cleanup := setupOwners(t)
+	defer cleanup()
+
+	scc := new(LifeCycleSysCC)
+	stub := shim.NewMockStub("lccc", scc)
+
+	cds, err := constructDeploymentSpec("example02", "github.com/hyperledger/fabric/examples/chaincode/go/chaincode_example02", [][]byte{[]byte("init")})
+	if err != nil {
+		t.FailNow()
+	}
+	cds.ChaincodeSpec.ChaincodeID.Version = "1.0"
+
+	_, err = stub.MockInvoke("1", [][]byte{[]byte(INSTALL), packageForTest(t, cds)})
+	if _, ok := err.(UnsignedPackageErr); !ok {
+		t.Fatalf("expected unsigned package error, got %v", err)
+	}
+
+	_, err = stub.MockInvoke("1", [][]byte{[]byte(INSTALL), packageForTest(t, cds, untrusted)})
+	if _, ok := err.(UnsignedPackageErr); !ok {
+		t.Fatalf("expected unsigned package error, got %v", err)
+	}
+
+	if _, err = stub.MockInvoke("1", [][]byte{[]byte(INSTALL), packageForTest(t, cds, untrusted, trusted)}); err != nil {
+		t.Fatalf("install of a package signed by a trusted owner failed: %s", err)
+	}
+
+	//deployment specs carry no signatures
+	_, err = stub.MockInvoke("1", [][]byte{[]byte(DEPLOY), []byte("test"), mustMarshal(t, cds)})
+	if _, ok := err.(UnsignedPackageErr); !ok {
+		t.Fatalf("expected unsigned package error on deploy, got %v", err)
+	}
+}
+
+//TestInstantiationPolicy tests only the identities of the instantiation policy of the package may instantiate it
+func TestInstantiationPolicy(t *testing.T) {
+	initialize()
+	defer setupInstallDir(t)()
+
+	scc := new(LifeCycleSysCC)
+	stub := shim.NewMockStub("lccc", scc)
+	mockChannel(stub)
+
+	cds, err := constructDeploymentSpec("example02", "github.com/hyperledger/fabric/examples/chaincode/go/chaincode_example02", [][]byte{[]byte("init")})
+	if err != nil {
+		t.FailNow()
+	}
+	cds.ChaincodeSpec.ChaincodeID.Version = "1.0"
+
+	policy, err := ccpackage.NewInstantiationPolicy([][]byte{[]byte("admin")})
+	if err != nil {
+		t.FailNow()
+	}
+	spkg, err := ccpackage.NewSignedPackage(cds, policy)
+	if err != nil {
+		t.FailNow()
+	}
+	if _, err = stub.MockInvoke("1", [][]byte{[]byte(INSTALL), mustMarshal(t, spkg)}); err != nil {
+		t.Fatalf("install failed: %s", err)
+	}
+
+	args := [][]byte{[]byte(INSTANTIATE), []byte("test"), withoutCode(t, cds)}
+	if err = CheckLifecycleACL(args, []byte("admin")); err != nil {
+		t.Fatalf("expected admin to be permitted: %s", err)
+	}
+	if _, ok := CheckLifecycleACL(args, []byte("other")).(InstantiationPolicyErr); !ok {
+		t.Fatalf("expected instantiation policy error")
+	}
+
+	def := &pb.ChaincodeDefinition{Name: "example02", Version: "1.0"}
+	args = [][]byte{[]byte(COMMIT), []byte("test"), mustMarshal(t, def)}
+	if _, ok := CheckLifecycleACL(args, []byte("other")).(InstantiationPolicyErr); !ok {
+		t.Fatalf("expected instantiation policy error on commit")
+	}
+}
@@ -0,0 +1,406 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos"
+	"github.com/spf13/viper"
+)
+
+//Instead of a single instantiator dictating the definition of a chaincode
+//on a chain, each organization of the chain approves a definition (name,
+//version, endorsement policy, collections and init args) and the definition
+//becomes active once the approvals satisfy "chaincode.lifecycle.approvalPolicy".
+//The organizations are those declared in the configuration of the chain
+
+const (
+	//APPROVALSTABLE prefix for the tables of approvals
+	APPROVALSTABLE = "approvals"
+
+	//DEFINITIONSTABLE prefix for the tables of committed definitions
+	DEFINITIONSTABLE = "definitions"
+)
+
+//InvalidInterestErr invalid interest in a chaincode definition error
+type InvalidInterestErr string
+
+func (f InvalidInterestErr) Error() string {
+	return fmt.Sprintf("invalid chaincode interest: %s", string(f))
+}
+
+//ApprovalPolicyErr the approvals do not satisfy the approval policy error
+type ApprovalPolicyErr string
+
+func (f ApprovalPolicyErr) Error() string {
+	return fmt.Sprintf("approvals of %s do not satisfy the approval policy", string(f))
+}
+
+//approvalPolicySatisfied evaluates the approval policy, "majority" (the
+//default), "all" or "any", for the given number of approving organizations
+func approvalPolicySatisfied(approved int, total int) (bool, error) {
+	if total == 0 {
+		return false, fmt.Errorf("no organizations declared in the configuration of the chain")
+	}
+
+	switch policy := viper.GetString("chaincode.lifecycle.approvalPolicy"); policy {
+	case "", "majority":
+		return approved > total/2, nil
+	case "all":
+		return approved == total, nil
+	case "any":
+		return approved > 0, nil
+	default:
+		return false, fmt.Errorf("unknown approval policy %s", policy)
+	}
+}
+
+//create the tables of approvals and committed definitions of the chain if
+//they do not exist
+func (lccc *LifeCycleSysCC) createApprovalTables(stub shim.ChaincodeStubInterface, chainname string) error {
+	if tbl, err := stub.GetTable(APPROVALSTABLE + "-" + chainname); err != nil || tbl == nil {
+		err = stub.CreateTable(APPROVALSTABLE+"-"+chainname, []*shim.ColumnDefinition{
+			{Name: "name", Type: shim.ColumnDefinition_STRING, Key: true},
+			{Name: "organization", Type: shim.ColumnDefinition_STRING, Key: true},
+			{Name: "definition", Type: shim.ColumnDefinition_BYTES, Key: false},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if tbl, err := stub.GetTable(DEFINITIONSTABLE + "-" + chainname); err != nil || tbl == nil {
+		err = stub.CreateTable(DEFINITIONSTABLE+"-"+chainname, []*shim.ColumnDefinition{
+			{Name: "name", Type: shim.ColumnDefinition_STRING, Key: true},
+			{Name: "definition", Type: shim.ColumnDefinition_BYTES, Key: false},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//getChannelOrganizations returns the organizations declared in the
+//configuration of the chain, as read by QSCC
+func (lccc *LifeCycleSysCC) getChannelOrganizations(stub shim.ChaincodeStubInterface, chainname string) ([]string, error) {
+	config, err := stub.GetChannelConfig(chainname)
+	if err != nil {
+		return nil, err
+	}
+	return config.Organizations, nil
+}
+
+//checkChannelOrganization checks the organization is declared in the
+//configuration of the chain
+func (lccc *LifeCycleSysCC) checkChannelOrganization(stub shim.ChaincodeStubInterface, chainname string, org string) error {
+	orgs, err := lccc.getChannelOrganizations(stub, chainname)
+	if err != nil {
+		return err
+	}
+	for _, name := range orgs {
+		if name == org {
+			return nil
+		}
+	}
+	return UnknownOrganizationErr(org)
+}
+
+//getValidatedDefinition returns the ChaincodeDefinition given args
+func (lccc *LifeCycleSysCC) getValidatedDefinition(stub shim.ChaincodeStubInterface, chainname string, b []byte) (*pb.ChaincodeDefinition, error) {
+	def := &pb.ChaincodeDefinition{}
+	if err := proto.Unmarshal(b, def); err != nil {
+		return nil, InvalidDeploymentSpecErr(err.Error())
+	}
+
+	if !lccc.isValidChaincodeName(def.Name) {
+		return nil, InvalidChaincodeNameErr(def.Name)
+	}
+
+	if !lccc.isValidChaincodeVersion(def.Version) {
+		return nil, InvalidChaincodeVersionErr(def.Version)
+	}
+
+	if err := lccc.validateInterests(stub, chainname, def.Interests); err != nil {
+		return nil, err
+	}
+
+	if err := lccc.validateSystemChaincodes(def.Escc, def.Vscc); err != nil {
+		return nil, err
+	}
+
+	return def, nil
+}
+
+//validateInterests checks each function declares its interests once and
+//only names organizations of the chain
+func (lccc *LifeCycleSysCC) validateInterests(stub shim.ChaincodeStubInterface, chainname string, interests []*pb.ChaincodeInterest) error {
+	functions := make(map[string]bool)
+	for _, interest := range interests {
+		if interest.Function == "" {
+			return InvalidInterestErr("no function")
+		}
+		if functions[interest.Function] {
+			return InvalidInterestErr("function " + interest.Function + " declared twice")
+		}
+		functions[interest.Function] = true
+
+		for _, collection := range interest.Collections {
+			if collection == "" {
+				return InvalidInterestErr("empty collection name for function " + interest.Function)
+			}
+		}
+		for _, org := range interest.Organizations {
+			if err := lccc.checkChannelOrganization(stub, chainname, org); err != nil {
+				return InvalidInterestErr(err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+//insert the row or replace it if it exists
+func (lccc *LifeCycleSysCC) putRow(stub shim.ChaincodeStubInterface, table string, row shim.Row) error {
+	ok, err := stub.InsertRow(table, row)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		_, err = stub.ReplaceRow(table, row)
+	}
+	return err
+}
+
+//this implements "approve" Invoke transaction. An organization approving
+//another definition of the chaincode replaces its previous approval
+func (lccc *LifeCycleSysCC) executeApprove(stub shim.ChaincodeStubInterface, chainname string, org string, b []byte) error {
+	if err := lccc.checkChannelOrganization(stub, chainname, org); err != nil {
+		return err
+	}
+
+	def, err := lccc.getValidatedDefinition(stub, chainname, b)
+	if err != nil {
+		return err
+	}
+
+	if err = lccc.createApprovalTables(stub, chainname); err != nil {
+		return err
+	}
+
+	row := shim.Row{Columns: []*shim.Column{
+		{Value: &shim.Column_String_{String_: def.Name}},
+		{Value: &shim.Column_String_{String_: org}},
+		{Value: &shim.Column_Bytes{Bytes: b}},
+	}}
+	if err = lccc.putRow(stub, APPROVALSTABLE+"-"+chainname, row); err != nil {
+		return fmt.Errorf("recording approval of %s by %s failed. %s", def.Name, org, err)
+	}
+
+	logger.Infof("Organization %s approved chaincode %s:%s on %s", org, def.Name, def.Version, chainname)
+
+	return nil
+}
+
+//getApprovals returns for each organization of the chain whether it
+//approved exactly the given definition
+func (lccc *LifeCycleSysCC) getApprovals(stub shim.ChaincodeStubInterface, chainname string, def *pb.ChaincodeDefinition) (map[string]bool, error) {
+	orgs, err := lccc.getChannelOrganizations(stub, chainname)
+	if err != nil {
+		return nil, err
+	}
+
+	approvals := make(map[string]bool)
+	for _, org := range orgs {
+		approvals[org] = false
+
+		key := []shim.Column{
+			{Value: &shim.Column_String_{String_: def.Name}},
+			{Value: &shim.Column_String_{String_: org}},
+		}
+		row, err := stub.GetRow(APPROVALSTABLE+"-"+chainname, key)
+		if err != nil || len(row.Columns) == 0 {
+			continue
+		}
+
+		approved := &pb.ChaincodeDefinition{}
+		if err = proto.Unmarshal(row.Columns[2].GetBytes(), approved); err != nil {
+			continue
+		}
+		approvals[org] = proto.Equal(approved, def)
+	}
+
+	return approvals, nil
+}
+
+//checkApprovals checks the approvals of the definition satisfy the approval
+//policy
+func (lccc *LifeCycleSysCC) checkApprovals(stub shim.ChaincodeStubInterface, chainname string, def *pb.ChaincodeDefinition) error {
+	approvals, err := lccc.getApprovals(stub, chainname, def)
+	if err != nil {
+		return err
+	}
+
+	approved := 0
+	for _, ok := range approvals {
+		if ok {
+			approved++
+		}
+	}
+
+	satisfied, err := approvalPolicySatisfied(approved, len(approvals))
+	if err != nil {
+		return err
+	}
+	if !satisfied {
+		return ApprovalPolicyErr(def.Name + ":" + def.Version)
+	}
+	return nil
+}
+
+//checkDeploymentApprovals checks the organizations of the chain approved the
+//definition an "instantiate" or "upgrade" activates, made of the deployment
+//spec and the chaincode data. A chain declaring no organizations has none to
+//approve it
+func (lccc *LifeCycleSysCC) checkDeploymentApprovals(stub shim.ChaincodeStubInterface, chainname string, cds *pb.ChaincodeDeploymentSpec, data *chaincodeData) error {
+	orgs, err := lccc.getChannelOrganizations(stub, chainname)
+	if err != nil {
+		return err
+	}
+	if len(orgs) == 0 {
+		return nil
+	}
+
+	def := &pb.ChaincodeDefinition{
+		Name:              cds.ChaincodeSpec.ChaincodeID.Name,
+		Version:           cds.ChaincodeSpec.ChaincodeID.Version,
+		EndorsementPolicy: data.policy,
+		CtorMsg:           cds.ChaincodeSpec.CtorMsg,
+		Escc:              data.escc,
+		Vscc:              data.vscc,
+	}
+	return lccc.checkApprovals(stub, chainname, def)
+}
+
+//this implements "getapprovals", the approvals are returned as a JSON
+//object of organization names to booleans
+func (lccc *LifeCycleSysCC) executeGetApprovals(stub shim.ChaincodeStubInterface, chainname string, b []byte) ([]byte, error) {
+	def, err := lccc.getValidatedDefinition(stub, chainname, b)
+	if err != nil {
+		return nil, err
+	}
+
+	approvals, err := lccc.getApprovals(stub, chainname, def)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(approvals)
+}
+
+//this implements "commit" Invoke transaction. Once the approval policy is
+//satisfied the definition instantiates the chaincode, or upgrades it if it
+//is already instantiated. The stored deployment spec is returned so the
+//caller can launch the chaincode
+func (lccc *LifeCycleSysCC) executeCommit(stub shim.ChaincodeStubInterface, chainname string, b []byte) ([]byte, error) {
+	def, err := lccc.getValidatedDefinition(stub, chainname, b)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = lccc.createApprovalTables(stub, chainname); err != nil {
+		return nil, err
+	}
+
+	if err = lccc.checkApprovals(stub, chainname, def); err != nil {
+		return nil, err
+	}
+
+	if err = lccc.register(stub, chainname); err != nil {
+		if _, ok := err.(AlreadyRegisteredErr); !ok {
+			return nil, err
+		}
+	}
+
+	cds := &pb.ChaincodeDeploymentSpec{ChaincodeSpec: &pb.ChaincodeSpec{ChaincodeID: &pb.ChaincodeID{Name: def.Name, Version: def.Version}, CtorMsg: def.CtorMsg}}
+
+	data := &chaincodeData{policy: def.EndorsementPolicy, escc: def.Escc, vscc: def.Vscc}
+
+	var depspec []byte
+	if _, exists, _ := lccc.getChaincode(stub, chainname, def.Name); exists {
+		depspec, err = lccc.upgrade(stub, chainname, cds, data)
+	} else {
+		depspec, err = lccc.instantiate(stub, chainname, cds, data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	row := shim.Row{Columns: []*shim.Column{
+		{Value: &shim.Column_String_{String_: def.Name}},
+		{Value: &shim.Column_Bytes{Bytes: b}},
+	}}
+	if err = lccc.putRow(stub, DEFINITIONSTABLE+"-"+chainname, row); err != nil {
+		return nil, fmt.Errorf("recording definition of %s failed. %s", def.Name, err)
+	}
+
+	return depspec, nil
+}
+
+//getDefinition returns the committed definition of the chaincode
+func (lccc *LifeCycleSysCC) getDefinition(stub shim.ChaincodeStubInterface, chainname string, ccname string) ([]byte, error) {
+	key := []shim.Column{{Value: &shim.Column_String_{String_: ccname}}}
+	row, err := stub.GetRow(DEFINITIONSTABLE+"-"+chainname, key)
+	if err != nil || len(row.Columns) == 0 {
+		return nil, TXNotFoundErr(chainname + "/" + ccname)
+	}
+
+	return row.Columns[1].GetBytes(), nil
+}
+
+//getInterests returns the interests declared by the committed definition of
+//the chaincode, only those of the given function if it is not empty. A
+//function declaring no interest is endorsed according to the endorsement
+//policy of the chaincode alone, as are the chaincodes instantiated without
+//a definition
+func (lccc *LifeCycleSysCC) getInterests(stub shim.ChaincodeStubInterface, chainname string, ccname string, function string) ([]byte, error) {
+	b, err := lccc.getDefinition(stub, chainname, ccname)
+	if err != nil {
+		if _, exists, _ := lccc.getChaincode(stub, chainname, ccname); exists {
+			return proto.Marshal(&pb.ChaincodeInterests{})
+		}
+		return nil, err
+	}
+
+	def := &pb.ChaincodeDefinition{}
+	if err = proto.Unmarshal(b, def); err != nil {
+		return nil, InvalidDeploymentSpecErr(err.Error())
+	}
+
+	interests := &pb.ChaincodeInterests{}
+	for _, interest := range def.Interests {
+		if function == "" || interest.Function == function {
+			interests.Interests = append(interests.Interests, interest)
+		}
+	}
+
+	return proto.Marshal(interests)
+}
@@ -0,0 +1,302 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chaincode
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/core/chaincode/acl"
+	"github.com/hyperledger/fabric/core/chaincode/ccpackage"
+	"github.com/hyperledger/fabric/core/crypto/primitives"
+	"github.com/hyperledger/fabric/core/system_chaincode/cscc"
+	pb "github.com/hyperledger/fabric/protos"
+	"github.com/spf13/viper"
+)
+
+//LifecycleACLErr lifecycle function not permitted to the creator error
+type LifecycleACLErr string
+
+func (f LifecycleACLErr) Error() string {
+	return fmt.Sprintf("creator is not permitted to %s chaincodes", string(f))
+}
+
+//InstantiationPolicyErr creator not permitted by the instantiation policy error
+type InstantiationPolicyErr string
+
+func (f InstantiationPolicyErr) Error() string {
+	return fmt.Sprintf("creator is not permitted by the instantiation policy of %s", string(f))
+}
+
+//NotOrganizationMemberErr creator is not a member of the organization error
+type NotOrganizationMemberErr string
+
+func (f NotOrganizationMemberErr) Error() string {
+	return fmt.Sprintf("creator is not a member of organization %s", string(f))
+}
+
+//UnknownOrganizationErr organization not configured error
+type UnknownOrganizationErr string
+
+func (f UnknownOrganizationErr) Error() string {
+	return fmt.Sprintf("unknown organization %s", string(f))
+}
+
+//Organization an organization that approves chaincode definitions. Members
+//lists the files holding the identities of its members and Peers the
+//addresses of its endorsing peers
+type Organization struct {
+	Name    string
+	Members []string
+	Peers   []string
+}
+
+//GetOrganizations returns the organizations in "chaincode.lifecycle.organizations"
+func GetOrganizations() ([]*Organization, error) {
+	var orgs []*Organization
+	if err := viper.UnmarshalKey("chaincode.lifecycle.organizations", &orgs); err != nil {
+		return nil, fmt.Errorf("error reading chaincode.lifecycle.organizations: %s", err)
+	}
+	for _, org := range orgs {
+		if org.Name == "" {
+			return nil, fmt.Errorf("organization with no name in chaincode.lifecycle.organizations")
+		}
+	}
+	return orgs, nil
+}
+
+//GetChainOrganizations returns the organizations of the chain, those in
+//"chaincode.lifecycle.organizations" declared in the configuration recorded
+//for the chain. All the configured organizations are members of a chain
+//without recorded configuration, such as the default chain
+func GetChainOrganizations(chainID string) ([]*Organization, error) {
+	orgs, err := GetOrganizations()
+	if err != nil {
+		return nil, err
+	}
+	chainOrgs, err := cscc.ChainOrganizations(chainID)
+	if err != nil {
+		return nil, err
+	}
+	if chainOrgs == nil {
+		return orgs, nil
+	}
+	var members []*Organization
+	for _, org := range orgs {
+		if chainOrgs[org.Name] {
+			members = append(members, org)
+		}
+	}
+	return members, nil
+}
+
+//getOrganization returns the organization of the chain with the given name
+func getOrganization(chainID string, name string) (*Organization, error) {
+	orgs, err := GetChainOrganizations(chainID)
+	if err != nil {
+		return nil, err
+	}
+	for _, org := range orgs {
+		if org.Name == name {
+			return org, nil
+		}
+	}
+	return nil, UnknownOrganizationErr(name)
+}
+
+//lifecycleACLKey returns the configuration key listing the identities
+//permitted to call the given lccc function. Functions without a key are
+//permitted to everyone
+func lifecycleACLKey(function string) string {
+	switch function {
+	case INSTALL, GETINSTALLED:
+		return "chaincode.lifecycle.installers"
+	case INSTANTIATE, UPGRADE, COMMIT:
+		return "chaincode.lifecycle.instantiators"
+	}
+	return ""
+}
+
+//CheckLifecycleACL checks that the creator of a proposal may call lccc with
+//the given arguments. Installing a package on the peer and activating it on a
+//chain are controlled separately by "chaincode.lifecycle.installers" and
+//"chaincode.lifecycle.instantiators", each a list of files holding the
+//identities (as sent in the proposal header) permitted. An empty list
+//permits everyone. The installers, administering the peer, are also the
+//ones permitted to list the installed chaincodes. Approvals are only
+//accepted from the members of the approving organization, and the
+//chaincodes instantiated on a chain are listed to the members of any of the
+//organizations of the chain
+func CheckLifecycleACL(args [][]byte, creator []byte) error {
+	if len(args) == 0 {
+		return nil
+	}
+	function := string(args[0])
+
+	if function == APPROVE {
+		if len(args) < 3 {
+			return InvalidArgsLenErr(len(args))
+		}
+		org, err := getOrganization(string(args[1]), string(args[2]))
+		if err != nil {
+			return err
+		}
+		if !acl.IsListed(org.Members, "chaincode.lifecycle.organizations", creator) {
+			return NotOrganizationMemberErr(org.Name)
+		}
+		return nil
+	}
+
+	if function == GETINSTANTIATED {
+		if len(args) < 2 {
+			return InvalidArgsLenErr(len(args))
+		}
+		return checkMember(string(args[1]), creator)
+	}
+
+	key := lifecycleACLKey(function)
+	if key == "" {
+		return nil
+	}
+
+	files := viper.GetStringSlice(key)
+	if len(files) > 0 && !acl.IsListed(files, key, creator) {
+		return LifecycleACLErr(function)
+	}
+
+	if function == INSTALL || function == GETINSTALLED {
+		return nil
+	}
+
+	return checkInstantiationPolicy(args, creator)
+}
+
+//checkMember checks that the creator is a member of one of the
+//organizations of the chain, if any are configured
+func checkMember(chainID string, creator []byte) error {
+	orgs, err := GetChainOrganizations(chainID)
+	if err != nil {
+		return err
+	}
+	if len(orgs) == 0 {
+		return nil
+	}
+	for _, org := range orgs {
+		if acl.IsListed(org.Members, "chaincode.lifecycle.organizations", creator) {
+			return nil
+		}
+	}
+	return LifecycleACLErr("list")
+}
+
+//checkInstantiationPolicy checks that the creator may instantiate or upgrade
+//the chaincode, or commit its definition, according to the instantiation
+//policy of the installed package
+func checkInstantiationPolicy(args [][]byte, creator []byte) error {
+	//lccc reports invalid arguments
+	if len(args) < 3 {
+		return nil
+	}
+
+	var name, version string
+	if string(args[0]) == COMMIT {
+		def := &pb.ChaincodeDefinition{}
+		if err := proto.Unmarshal(args[2], def); err != nil {
+			return InvalidDeploymentSpecErr(err.Error())
+		}
+		name, version = def.Name, def.Version
+	} else {
+		cds := &pb.ChaincodeDeploymentSpec{}
+		if err := proto.Unmarshal(args[2], cds); err != nil {
+			return InvalidDeploymentSpecErr(err.Error())
+		}
+		if cds.ChaincodeSpec == nil || cds.ChaincodeSpec.ChaincodeID == nil {
+			return InvalidDeploymentSpecErr("chaincode ID not specified")
+		}
+		name, version = cds.ChaincodeSpec.ChaincodeID.Name, cds.ChaincodeSpec.ChaincodeID.Version
+	}
+
+	spkg, err := GetInstalledPackage(name, version)
+	if err != nil {
+		//lccc reports the package is not installed
+		return nil
+	}
+
+	if err = ccpackage.CheckInstantiationPolicy(spkg, creator); err != nil {
+		chaincodeLogger.Warningf("%s of %s:%s refused: %s", args[0], name, version, err)
+		return InstantiationPolicyErr(name + ":" + version)
+	}
+
+	return nil
+}
+
+//UnsignedPackageErr package not signed by the trusted owners error
+type UnsignedPackageErr string
+
+func (f UnsignedPackageErr) Error() string {
+	return fmt.Sprintf("package %s is not signed by the trusted owners", string(f))
+}
+
+//getTrustedOwners returns the DER encoded certificates of the owners listed in
+//"chaincode.lifecycle.owners"
+func getTrustedOwners() ([][]byte, error) {
+	var owners [][]byte
+	for _, file := range viper.GetStringSlice("chaincode.lifecycle.owners") {
+		raw, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("could not read owner certificate %s: %s", file, err)
+		}
+		der, err := primitives.PEMtoDER(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid owner certificate %s: %s", file, err)
+		}
+		owners = append(owners, der)
+	}
+	return owners, nil
+}
+
+//CheckPackageOwners verifies the signatures of the owners of a package. When
+//"chaincode.lifecycle.owners" lists trusted owners (files holding PEM
+//certificates), at least "chaincode.lifecycle.requiredOwners" of them, one
+//by default, must have signed it
+func CheckPackageOwners(name string, spkg *pb.SignedChaincodeDeploymentSpec) error {
+	signers, err := ccpackage.VerifyOwnerEndorsements(spkg)
+	if err != nil {
+		return err
+	}
+
+	trusted, err := getTrustedOwners()
+	if err != nil {
+		return err
+	}
+	if len(trusted) == 0 {
+		return nil
+	}
+
+	required := viper.GetInt("chaincode.lifecycle.requiredOwners")
+	if required <= 0 {
+		required = 1
+	}
+
+	if err = ccpackage.CheckOwners(signers, trusted, required); err != nil {
+		chaincodeLogger.Warningf("Package %s rejected: %s", name, err)
+		return UnsignedPackageErr(name)
+	}
+
+	return nil
+}
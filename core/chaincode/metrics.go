@@ -0,0 +1,67 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chaincode
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/hyperledger/fabric/core/metrics"
+	pb "github.com/hyperledger/fabric/protos"
+)
+
+// executeMetrics are the metrics of the transactions and queries executed
+// by the chaincodes
+type executeMetrics struct {
+	duration        metrics.Histogram
+	timeouts        metrics.Counter
+	leakedIterators metrics.Counter
+}
+
+func newExecuteMetrics(p metrics.Provider) *executeMetrics {
+	return &executeMetrics{
+		duration: p.NewHistogram(metrics.HistogramOpts{
+			Namespace:  "chaincode",
+			Name:       "execute_duration",
+			Help:       "The time to execute a transaction or query on a chaincode, in seconds.",
+			LabelNames: []string{"chaincode", "type", "success"},
+		}),
+		timeouts: p.NewCounter(metrics.CounterOpts{
+			Namespace:  "chaincode",
+			Name:       "execute_timeouts",
+			Help:       "The number of transactions and queries the chaincode didn't complete in time.",
+			LabelNames: []string{"chaincode"},
+		}),
+		leakedIterators: p.NewCounter(metrics.CounterOpts{
+			Namespace:  "chaincode",
+			Name:       "leaked_query_iterators",
+			Help:       "The number of query iterators the chaincode left open when its transaction or query ended.",
+			LabelNames: []string{"chaincode"},
+		}),
+	}
+}
+
+// observe records the execution of msg on a chaincode since start, which
+// ended with resp or err
+func (m *executeMetrics) observe(chaincode string, msg *pb.ChaincodeMessage, start time.Time, resp *pb.ChaincodeMessage, err error) {
+	success := err == nil && resp != nil &&
+		(resp.Type == pb.ChaincodeMessage_COMPLETED || resp.Type == pb.ChaincodeMessage_QUERY_COMPLETED)
+	m.duration.With("chaincode", chaincode, "type", msg.Type.String(), "success", strconv.FormatBool(success)).Observe(time.Since(start).Seconds())
+	if IsChaincodeTimeout(err) {
+		m.timeouts.With("chaincode", chaincode).Add(1)
+	}
+}
@@ -19,6 +19,7 @@ package golang
 import (
 	"archive/tar"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -28,6 +29,27 @@ import (
 	pb "github.com/hyperledger/fabric/protos"
 )
 
+//goInstallCommand returns the go install command building the chaincode at
+//path, with the build flags and environment of chaincode.golang
+func goInstallCommand(path string) string {
+	env := viper.GetStringMapString("chaincode.golang.buildEnv")
+	var names []string
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var cmd []string
+	for _, name := range names {
+		cmd = append(cmd, fmt.Sprintf("%s=%q", strings.ToUpper(name), env[name]))
+	}
+	cmd = append(cmd, "go install")
+	if flags := viper.GetString("chaincode.golang.buildFlags"); flags != "" {
+		cmd = append(cmd, flags)
+	}
+	return strings.Join(append(cmd, path), " ")
+}
+
 //tw is expected to have the chaincode in it from GenerateHashcode. This method
 //will just package rest of the bytes
 func writeChaincodePackage(spec *pb.ChaincodeSpec, tw *tar.Writer) error {
@@ -59,7 +81,7 @@ func writeChaincodePackage(spec *pb.ChaincodeSpec, tw *tar.Writer) error {
 	}
 
 	//let the executable's name be chaincode ID's name
-	newRunLine := fmt.Sprintf("RUN go install %s && cp src/github.com/hyperledger/fabric/peer/core.yaml $GOPATH/bin && mv $GOPATH/bin/%s $GOPATH/bin/%s", urlLocation, chaincodeGoName, spec.ChaincodeID.Name)
+	newRunLine := fmt.Sprintf("RUN %s && cp src/github.com/hyperledger/fabric/peer/core.yaml $GOPATH/bin && mv $GOPATH/bin/%s $GOPATH/bin/%s", goInstallCommand(urlLocation), chaincodeGoName, spec.ChaincodeID.Name)
 
 	//NOTE-this could have been abstracted away so we could use it for all platforms in a common manner
 	//However, it would still be docker specific. Hence any such abstraction has to be done in a manner that
@@ -0,0 +1,58 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package node
+
+import (
+	"archive/tar"
+	"fmt"
+	"strings"
+	"time"
+
+	cutil "github.com/hyperledger/fabric/core/container/util"
+	pb "github.com/hyperledger/fabric/protos"
+)
+
+// chaincodeDir is where the package is installed in the chaincode image
+const chaincodeDir = "/usr/local/src"
+
+// writeChaincodePackage writes the Dockerfile followed by the sources of the
+// package. The dependencies are installed with npm when the image is built
+func writeChaincodePackage(spec *pb.ChaincodeSpec, tw *tar.Writer) error {
+	codepath, err := getCodePath(spec)
+	if err != nil {
+		return err
+	}
+
+	var buf []string
+	buf = append(buf, cutil.GetDockerfileFromConfig("chaincode.node.Dockerfile"))
+	buf = append(buf, "COPY src "+chaincodeDir)
+	buf = append(buf, "WORKDIR "+chaincodeDir)
+	buf = append(buf, "RUN npm install --production")
+	dockerFileContents := strings.Join(buf, "\n")
+
+	dockerFileSize := int64(len([]byte(dockerFileContents)))
+
+	//Make headers identical by using zero time
+	var zeroTime time.Time
+	tw.WriteHeader(&tar.Header{Name: "Dockerfile", Size: dockerFileSize, ModTime: zeroTime, AccessTime: zeroTime, ChangeTime: zeroTime})
+	tw.Write([]byte(dockerFileContents))
+
+	if err = cutil.WriteNodeProjectToPackage(tw, codepath); err != nil {
+		return fmt.Errorf("Error writing Chaincode package contents: %s", err)
+	}
+	return nil
+}
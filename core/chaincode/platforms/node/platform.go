@@ -0,0 +1,76 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package node
+
+import (
+	"archive/tar"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	pb "github.com/hyperledger/fabric/protos"
+)
+
+// Platform for chaincodes written in Node.js
+type Platform struct {
+}
+
+// getCodePath returns the local directory of the chaincode package
+func getCodePath(spec *pb.ChaincodeSpec) (string, error) {
+	if spec.ChaincodeID == nil || spec.ChaincodeID.Path == "" {
+		return "", fmt.Errorf("empty chaincode path")
+	}
+
+	codepath := spec.ChaincodeID.Path
+	if strings.HasPrefix(codepath, "http://") || strings.HasPrefix(codepath, "https://") {
+		return "", fmt.Errorf("remote Node.js chaincode packages are not supported: %s", codepath)
+	}
+
+	codepath, err := filepath.Abs(codepath)
+	if err != nil {
+		return "", fmt.Errorf("invalid path: %s", err)
+	}
+	return codepath, nil
+}
+
+// ValidateSpec validates the Node.js chaincode specs. The path must be a local
+// directory holding a package.json, whose start script runs the chaincode
+func (nodePlatform *Platform) ValidateSpec(spec *pb.ChaincodeSpec) error {
+	codepath, err := getCodePath(spec)
+	if err != nil {
+		return err
+	}
+
+	fi, err := os.Stat(codepath)
+	if err != nil {
+		return fmt.Errorf("Error validating chaincode path: %s", err)
+	}
+	if !fi.IsDir() {
+		return fmt.Errorf("Path to chaincode is not a directory: %s", spec.ChaincodeID.Path)
+	}
+
+	if _, err = os.Stat(filepath.Join(codepath, "package.json")); err != nil {
+		return fmt.Errorf("Node.js chaincode %s has no package.json: %s", spec.ChaincodeID.Path, err)
+	}
+	return nil
+}
+
+// WritePackage writes the Node.js chaincode package
+func (nodePlatform *Platform) WritePackage(spec *pb.ChaincodeSpec, tw *tar.Writer) error {
+	return writeChaincodePackage(spec, tw)
+}
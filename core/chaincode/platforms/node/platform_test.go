@@ -0,0 +1,123 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package node
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric/core/config"
+	pb "github.com/hyperledger/fabric/protos"
+)
+
+const examplePath = "../../../../examples/chaincode/node/chaincode_example02"
+
+func TestMain(m *testing.M) {
+	config.SetupTestConfig("../../../../peer")
+	os.Exit(m.Run())
+}
+
+func TestValidateSpec(t *testing.T) {
+	platform := &Platform{}
+
+	spec := &pb.ChaincodeSpec{Type: pb.ChaincodeSpec_NODE, ChaincodeID: &pb.ChaincodeID{Name: "ex02", Path: examplePath}}
+	if err := platform.ValidateSpec(spec); err != nil {
+		t.Fatalf("Expected the example to be valid, got %s", err)
+	}
+
+	dir, err := ioutil.TempDir("", "nodecc")
+	if err != nil {
+		t.Fatalf("Error creating temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	spec.ChaincodeID.Path = dir
+	if err := platform.ValidateSpec(spec); err == nil {
+		t.Fatalf("Expected an error for a package without package.json")
+	}
+
+	spec.ChaincodeID.Path = "https://example.com/chaincode"
+	if err := platform.ValidateSpec(spec); err == nil {
+		t.Fatalf("Expected an error for a remote package")
+	}
+}
+
+func TestWritePackage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nodecc")
+	if err != nil {
+		t.Fatalf("Error creating temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"package.json":                  `{"name": "cc"}`,
+		"chaincode.js":                  "// chaincode",
+		"README.md":                     "not packaged",
+		"node_modules/dep/index.js":     "// installed dependency",
+		"node_modules/dep/package.json": `{"name": "dep"}`,
+	}
+	for name, contents := range files {
+		path := dir + "/" + name
+		if err = os.MkdirAll(path[:strings.LastIndex(path, "/")], 0755); err != nil {
+			t.Fatalf("Error creating dir: %s", err)
+		}
+		if err = ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatalf("Error writing %s: %s", name, err)
+		}
+	}
+
+	spec := &pb.ChaincodeSpec{Type: pb.ChaincodeSpec_NODE, ChaincodeID: &pb.ChaincodeID{Name: "cc", Path: dir}}
+	buf := &bytes.Buffer{}
+	if err = (&Platform{}).WritePackage(spec, tar.NewWriter(buf)); err != nil {
+		t.Fatalf("Error writing package: %s", err)
+	}
+
+	entries := make(map[string]string)
+	tr := tar.NewReader(buf)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Error reading package: %s", err)
+		}
+		contents, err := ioutil.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("Error reading %s: %s", hdr.Name, err)
+		}
+		entries[hdr.Name] = string(contents)
+	}
+
+	if len(entries) != 3 {
+		t.Fatalf("Expected the Dockerfile and 2 source files, got %v", entries)
+	}
+	if _, ok := entries["src/chaincode.js"]; !ok {
+		t.Fatalf("Expected src/chaincode.js in the package, got %v", entries)
+	}
+	if _, ok := entries["src/package.json"]; !ok {
+		t.Fatalf("Expected src/package.json in the package, got %v", entries)
+	}
+	if !strings.Contains(entries["Dockerfile"], "RUN npm install --production") {
+		t.Fatalf("Expected the Dockerfile to install the dependencies, got %s", entries["Dockerfile"])
+	}
+}
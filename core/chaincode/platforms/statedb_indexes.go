@@ -0,0 +1,92 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platforms
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+)
+
+// CouchDBIndexesDir is the directory, relative to the chaincode path, under which
+// a chaincode package carries the CouchDB index definitions for its state
+const CouchDBIndexesDir = "META-INF/statedb/couchdb/indexes"
+
+// GetCouchDBIndexes returns the CouchDB index definitions found in the code
+// package (a gzipped tar) of the chaincode at chaincodePath. Every ".json" file
+// directly under <chaincodePath>/META-INF/statedb/couchdb/indexes is an index
+// definition and must contain an "index" object
+func GetCouchDBIndexes(chaincodePath string, codePackage []byte) ([][]byte, error) {
+	if len(codePackage) == 0 {
+		return nil, nil
+	}
+
+	if strings.HasPrefix(chaincodePath, "http://") {
+		chaincodePath = chaincodePath[7:]
+	} else if strings.HasPrefix(chaincodePath, "https://") {
+		chaincodePath = chaincodePath[8:]
+	}
+	indexesDir := path.Join(chaincodePath, CouchDBIndexesDir)
+
+	gr, err := gzip.NewReader(bytes.NewReader(codePackage))
+	if err != nil {
+		return nil, fmt.Errorf("Error reading code package: %s", err)
+	}
+	defer gr.Close()
+
+	var indexes [][]byte
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Error reading code package: %s", err)
+		}
+
+		if !hdr.FileInfo().Mode().IsRegular() {
+			continue
+		}
+		if path.Ext(hdr.Name) != ".json" || !strings.HasSuffix(path.Dir(hdr.Name), indexesDir) {
+			continue
+		}
+
+		index, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading index definition %s: %s", hdr.Name, err)
+		}
+
+		var definition map[string]interface{}
+		if err = json.Unmarshal(index, &definition); err != nil {
+			return nil, fmt.Errorf("Invalid index definition %s: %s", hdr.Name, err)
+		}
+		if _, ok := definition["index"].(map[string]interface{}); !ok {
+			return nil, fmt.Errorf("Invalid index definition %s: missing \"index\" object", hdr.Name)
+		}
+
+		indexes = append(indexes, index)
+	}
+
+	return indexes, nil
+}
@@ -0,0 +1,66 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platforms
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"testing"
+)
+
+func writeTestPackage(t *testing.T, files map[string]string) []byte {
+	buf := bytes.NewBuffer(nil)
+	gw := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gw)
+	for name, contents := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(contents))}); err != nil {
+			t.Fatalf("Error writing header: %s", err)
+		}
+		if _, err := tw.Write([]byte(contents)); err != nil {
+			t.Fatalf("Error writing contents: %s", err)
+		}
+	}
+	tw.Close()
+	gw.Close()
+	return buf.Bytes()
+}
+
+func TestGetCouchDBIndexes(t *testing.T) {
+	index := `{"index":{"fields":["owner"]},"name":"indexOwner","type":"json"}`
+	codePackage := writeTestPackage(t, map[string]string{
+		"src/github.com/example/cc/cc.go":                                               "package main",
+		"src/github.com/example/cc/META-INF/statedb/couchdb/indexes/indexOwner.json":    index,
+		"src/github.com/example/cc/META-INF/statedb/couchdb/indexes/README.md":          "not an index",
+		"src/github.com/example/other/META-INF/statedb/couchdb/indexes/indexOther.json": index,
+	})
+
+	indexes, err := GetCouchDBIndexes("http://github.com/example/cc", codePackage)
+	if err != nil {
+		t.Fatalf("Error getting indexes: %s", err)
+	}
+	if len(indexes) != 1 || string(indexes[0]) != index {
+		t.Fatalf("Expected only the chaincode's index, got %q", indexes)
+	}
+
+	codePackage = writeTestPackage(t, map[string]string{
+		"src/github.com/example/cc/META-INF/statedb/couchdb/indexes/bad.json": `{"fields":["owner"]}`,
+	})
+	if _, err = GetCouchDBIndexes("github.com/example/cc", codePackage); err == nil {
+		t.Fatalf("Expected error for index definition without \"index\" object")
+	}
+}
@@ -24,9 +24,11 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"math/rand"
 	"os"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/ptypes/timestamp"
@@ -35,12 +37,18 @@ import (
 	"github.com/hyperledger/fabric/core/comm"
 	"github.com/hyperledger/fabric/core/util"
 	pb "github.com/hyperledger/fabric/protos"
+	"github.com/hyperledger/fabric/protos/utils"
 	"github.com/op/go-logging"
 	"github.com/spf13/viper"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 )
 
+// ChainIDKey is the key of the stream metadata in which a chaincode names the
+// chain it runs on when it registers with the peer
+const ChainIDKey = "chainid"
+
 // Logger for the shim package.
 var chaincodeLogger = logging.MustGetLogger("shim")
 
@@ -50,8 +58,12 @@ type ChaincodeStub struct {
 	TxID            string
 	securityContext *pb.ChaincodeSecurityContext
 	chaincodeEvent  *pb.ChaincodeEvent
+	proposal        *pb.Proposal
+	signedProposal  *pb.SignedProposal
 	args            [][]byte
+	decorations     map[string][]byte
 	handler         *Handler
+	random          *rand.Rand
 }
 
 // Peer address derived from command line or env var
@@ -62,12 +74,7 @@ var peerAddress string
 func Start(cc Chaincode) error {
 	// If Start() is called, we assume this is a standalone chaincode and set
 	// up formatted logging.
-	format := logging.MustStringFormatter("%{time:15:04:05.000} [%{module}] %{level:.4s} : %{message}")
-	backend := logging.NewLogBackend(os.Stderr, "", 0)
-	backendFormatter := logging.NewBackendFormatter(backend, format)
-	logging.SetBackend(backendFormatter).SetLevel(logging.Level(shimLoggingLevel), "shim")
-
-	SetChaincodeLoggingLevel()
+	setupStandaloneLogging()
 
 	flag.StringVar(&peerAddress, "peer.address", "", "peer address")
 
@@ -86,8 +93,12 @@ func Start(cc Chaincode) error {
 
 	chaincodeSupportClient := pb.NewChaincodeSupportClient(clientConn)
 
-	// Establish stream with validating peer
-	stream, err := chaincodeSupportClient.Register(context.Background())
+	// Establish stream with validating peer, on the chain the chaincode runs on
+	ctxt := context.Background()
+	if chainID := viper.GetString("chaincode.chainid"); chainID != "" {
+		ctxt = metadata.NewContext(ctxt, metadata.Pairs(ChainIDKey, chainID))
+	}
+	stream, err := chaincodeSupportClient.Register(ctxt)
 	if err != nil {
 		return fmt.Errorf("Error chatting with leader at address=%s:  %s", getPeerAddress(), err)
 	}
@@ -101,6 +112,17 @@ func Start(cc Chaincode) error {
 	return err
 }
 
+// setupStandaloneLogging sets up formatted logging for a chaincode that runs
+// in its own process
+func setupStandaloneLogging() {
+	format := logging.MustStringFormatter("%{time:15:04:05.000} [%{module}] %{level:.4s} : %{message}")
+	backend := logging.NewLogBackend(os.Stderr, "", 0)
+	backendFormatter := logging.NewBackendFormatter(backend, format)
+	logging.SetBackend(backendFormatter).SetLevel(logging.Level(shimLoggingLevel), "shim")
+
+	SetChaincodeLoggingLevel()
+}
+
 // IsEnabledForLogLevel checks to see if the chaincodeLogger is enabled for a specific logging level
 // used primarily for testing
 func IsEnabledForLogLevel(logLevel string) bool {
@@ -254,14 +276,17 @@ func chatWithPeer(chaincodename string, stream PeerChaincodeStream, cc Chaincode
 // -- init stub ---
 // ChaincodeInvocation functionality
 
-func (stub *ChaincodeStub) init(handler *Handler, txid string, secContext *pb.ChaincodeSecurityContext) {
+func (stub *ChaincodeStub) init(handler *Handler, txid string, secContext *pb.ChaincodeSecurityContext, proposal *pb.Proposal, signedProposal *pb.SignedProposal) {
 	stub.TxID = txid
 	stub.securityContext = secContext
+	stub.proposal = proposal
+	stub.signedProposal = signedProposal
 	stub.args = [][]byte{}
 	newCI := pb.ChaincodeInput{}
 	err := proto.Unmarshal(secContext.Payload, &newCI)
 	if err == nil {
 		stub.args = newCI.Args
+		stub.decorations = newCI.Decorations
 	} else {
 		panic("Arguments cannot be unmarshalled.")
 	}
@@ -273,7 +298,7 @@ func InitTestStub(funargs ...string) *ChaincodeStub {
 	allargs := util.ToChaincodeArgs(funargs...)
 	newCI := pb.ChaincodeInput{Args: allargs}
 	pl, _ := proto.Marshal(&newCI)
-	stub.init(&Handler{}, "TEST-txid", &pb.ChaincodeSecurityContext{Payload: pl})
+	stub.init(&Handler{}, "TEST-txid", &pb.ChaincodeSecurityContext{Payload: pl}, nil, nil)
 	return &stub
 }
 
@@ -281,6 +306,58 @@ func (stub *ChaincodeStub) GetTxID() string {
 	return stub.TxID
 }
 
+// GetTransient returns the transient data of the proposal being executed. The
+// transient data is available to the chaincode during the simulation, but is
+// never written to the ledger. nil is returned if there is no transient data.
+func (stub *ChaincodeStub) GetTransient() (map[string][]byte, error) {
+	if stub.proposal == nil {
+		return nil, nil
+	}
+	ccPropPayload := &pb.ChaincodeProposalPayload{}
+	if err := proto.Unmarshal(stub.proposal.Payload, ccPropPayload); err != nil {
+		return nil, fmt.Errorf("Error unmarshalling the proposal payload: %s", err)
+	}
+	return ccPropPayload.TransientMap, nil
+}
+
+// GetCreator returns the serialized identity of the creator of the proposal
+// being executed, taken from the header of the proposal. nil is returned if
+// there is no proposal.
+func (stub *ChaincodeStub) GetCreator() ([]byte, error) {
+	if stub.proposal == nil {
+		return nil, nil
+	}
+	hdr := &pb.Header{}
+	if err := proto.Unmarshal(stub.proposal.Header, hdr); err != nil {
+		return nil, fmt.Errorf("Error unmarshalling the proposal header: %s", err)
+	}
+	return hdr.Creator, nil
+}
+
+// GetDecorations returns the decorations of the peer passed along with the
+// arguments of the chaincode. nil is returned if there are none.
+func (stub *ChaincodeStub) GetDecorations() map[string][]byte {
+	return stub.decorations
+}
+
+// ErrProposalNotSigned is returned by GetSignedProposal when the proposal
+// being executed carries no signature
+var ErrProposalNotSigned = errors.New("The proposal was not signed")
+
+// GetSignedProposal returns the signed proposal being executed, as verified
+// by the endorser. ErrProposalNotSigned is returned, rather than a signed
+// proposal with an empty signature, when the peer sent the proposal without
+// its signature. nil is returned if there is no proposal.
+func (stub *ChaincodeStub) GetSignedProposal() (*pb.SignedProposal, error) {
+	if stub.signedProposal != nil {
+		return stub.signedProposal, nil
+	}
+	if stub.proposal == nil {
+		return nil, nil
+	}
+	return nil, ErrProposalNotSigned
+}
+
 // --------- Security functions ----------
 //CHAINCODE SEC INTERFACE FUNCS TOBE IMPLEMENTED BY ANGELO
 
@@ -288,8 +365,13 @@ func (stub *ChaincodeStub) GetTxID() string {
 
 // InvokeChaincode locally calls the specified chaincode `Invoke` using the
 // same transaction context; that is, chaincode calling chaincode doesn't
-// create a new transaction message.
-func (stub *ChaincodeStub) InvokeChaincode(chaincodeName string, args [][]byte) ([]byte, error) {
+// create a new transaction message. The chaincode of another channel is
+// called read only.
+func (stub *ChaincodeStub) InvokeChaincode(chaincodeName string, args [][]byte, channel string) ([]byte, error) {
+	// the chaincode of another channel is addressed as "name/channel"
+	if channel != "" {
+		chaincodeName = chaincodeName + "/" + channel
+	}
 	return stub.handler.handleInvokeChaincode(chaincodeName, args, stub.TxID)
 }
 
@@ -300,6 +382,40 @@ func (stub *ChaincodeStub) QueryChaincode(chaincodeName string, args [][]byte) (
 	return stub.handler.handleQueryChaincode(chaincodeName, args, stub.TxID)
 }
 
+// GetChannelConfig returns the configuration values of the channel, as read by
+// QSCC. An empty channel is the channel of the proposal being executed.
+func (stub *ChaincodeStub) GetChannelConfig(channel string) (*pb.ChannelConfig, error) {
+	return getChannelConfig(stub, stub.proposal, channel)
+}
+
+// getChannelConfig invokes QSCC for the configuration of the channel, the
+// channel of the proposal being executed by the stub when empty
+func getChannelConfig(stub ChaincodeStubInterface, prop *pb.Proposal, channel string) (*pb.ChannelConfig, error) {
+	if channel == "" {
+		if prop == nil {
+			return nil, errors.New("No channel given and no proposal to take it from")
+		}
+		hdr := &pb.Header{}
+		if err := proto.Unmarshal(prop.Header, hdr); err != nil {
+			return nil, fmt.Errorf("Error unmarshalling the proposal header: %s", err)
+		}
+		if len(hdr.ChainID) == 0 {
+			return nil, errors.New("No channel given and none in the proposal header")
+		}
+		channel = string(hdr.ChainID)
+	}
+
+	res, err := stub.InvokeChaincode("qscc", [][]byte{[]byte("GetChannelConfig"), []byte(channel)}, "")
+	if err != nil {
+		return nil, fmt.Errorf("Error getting the configuration of channel %s: %s", channel, err)
+	}
+	config := &pb.ChannelConfig{}
+	if err = proto.Unmarshal(res, config); err != nil {
+		return nil, fmt.Errorf("Error unmarshalling the configuration of channel %s: %s", channel, err)
+	}
+	return config, nil
+}
+
 // --------- State functions ----------
 
 // GetState returns the byte array value specified by the `key`.
@@ -307,6 +423,18 @@ func (stub *ChaincodeStub) GetState(key string) ([]byte, error) {
 	return stub.handler.handleGetState(key, stub.TxID)
 }
 
+// GetStateMultipleKeys returns the values of the specified `keys` in a single
+// round trip to the peer. The value of a key that does not exist is nil.
+func (stub *ChaincodeStub) GetStateMultipleKeys(keys []string) ([][]byte, error) {
+	return stub.handler.handleGetStateMultiple(keys, stub.TxID)
+}
+
+// GetLedgerHeight returns the height of the ledger the transaction is
+// simulated on, recorded in the read set of the transaction.
+func (stub *ChaincodeStub) GetLedgerHeight() (uint64, error) {
+	return stub.handler.handleGetLedgerHeight(stub.TxID)
+}
+
 // PutState writes the specified `value` and `key` into the ledger.
 func (stub *ChaincodeStub) PutState(key string, value []byte) error {
 	return stub.handler.handlePutState(key, value, stub.TxID)
@@ -357,6 +485,8 @@ type StateRangeQueryIterator struct {
 	uuid       string
 	response   *pb.RangeQueryStateResponse
 	currentLoc int
+	// err is the error fetching the next batch, returned by Next
+	err error
 }
 
 // RangeQueryState function can be invoked by a chaincode to query of a range
@@ -369,49 +499,181 @@ func (stub *ChaincodeStub) RangeQueryState(startKey, endKey string) (StateRangeQ
 	if err != nil {
 		return nil, err
 	}
-	return &StateRangeQueryIterator{stub.handler, stub.TxID, response, 0}, nil
+	return &StateRangeQueryIterator{handler: stub.handler, uuid: stub.TxID, response: response}, nil
+}
+
+// GetStateByRangeWithPagination returns a single page of at most pageSize
+// keys between the startKey and endKey, inclusive, starting from the given
+// bookmark. The returned metadata carries the bookmark for the next page.
+// Paginated queries are not recorded in the read set, so they are rejected
+// in transaction context.
+func (stub *ChaincodeStub) GetStateByRangeWithPagination(startKey, endKey string, pageSize int32,
+	bookmark string) (StateRangeQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	response, err := stub.handler.handleRangeQueryStateWithPagination(startKey, endKey, pageSize, bookmark, stub.TxID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &StateRangeQueryIterator{handler: stub.handler, uuid: stub.TxID, response: response}, response.Metadata, nil
+}
+
+// GetQueryResult performs a rich query against the state, for state databases
+// that support it, and returns an iterator over the results. The query string
+// is in the native syntax of the state database, e.g. a CouchDB query with a
+// "selector". The results are not recorded in the read set and are not
+// re-validated at commit time, so a transaction that acts on them is exposed
+// to phantom reads: keys that are added or changed by another transaction
+// before this one commits go unnoticed. Use GetQueryResult in query context,
+// or only for data that is otherwise protected against concurrent updates.
+func (stub *ChaincodeStub) GetQueryResult(query string) (StateRangeQueryIteratorInterface, error) {
+	response, err := stub.handler.handleGetQueryResult(query, stub.TxID)
+	if err != nil {
+		return nil, err
+	}
+	return &StateRangeQueryIterator{handler: stub.handler, uuid: stub.TxID, response: response}, nil
+}
+
+// GetQueryResultWithPagination performs a rich query against the state and
+// returns a single page of at most pageSize results starting from the given
+// bookmark. The returned metadata carries the bookmark for the next page.
+// Like GetStateByRangeWithPagination, it is rejected in transaction context.
+func (stub *ChaincodeStub) GetQueryResultWithPagination(query string, pageSize int32,
+	bookmark string) (StateRangeQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	response, err := stub.handler.handleGetQueryResultWithPagination(query, pageSize, bookmark, stub.TxID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &StateRangeQueryIterator{handler: stub.handler, uuid: stub.TxID, response: response}, response.Metadata, nil
 }
 
 // HasNext returns true if the range query iterator contains additional keys
-// and values.
+// and values. Unlike a check of the current batch alone, HasNext fetches the
+// next batch from the peer when the current one is used up, as the peer
+// cannot tell that a batch was the last one until it tries to read past it:
+// a batch flagged with more results may be followed by an empty one, in which
+// case HasNext returns false. If fetching the next batch fails, HasNext
+// returns true and the following call to Next returns the error, after which
+// the iterator is exhausted.
 func (iter *StateRangeQueryIterator) HasNext() bool {
-	if iter.currentLoc < len(iter.response.KeysAndValues) || iter.response.HasMore {
+	if iter.err != nil {
 		return true
 	}
-	return false
-}
-
-// Next returns the next key and value in the range query iterator.
-func (iter *StateRangeQueryIterator) Next() (string, []byte, error) {
-	if iter.currentLoc < len(iter.response.KeysAndValues) {
-		keyValue := iter.response.KeysAndValues[iter.currentLoc]
-		iter.currentLoc++
-		return keyValue.Key, keyValue.Value, nil
-	} else if !iter.response.HasMore {
-		return "", nil, errors.New("No such key")
-	} else {
+	for iter.currentLoc >= len(iter.response.KeysAndValues) {
+		if !iter.response.HasMore {
+			return false
+		}
 		response, err := iter.handler.handleRangeQueryStateNext(iter.response.ID, iter.uuid)
-
 		if err != nil {
-			return "", nil, err
+			chaincodeLogger.Errorf("Failed to fetch the next batch of results: %s", err)
+			iter.err = err
+			return true
 		}
-
 		iter.currentLoc = 0
 		iter.response = response
-		keyValue := iter.response.KeysAndValues[iter.currentLoc]
-		iter.currentLoc++
-		return keyValue.Key, keyValue.Value, nil
+	}
+	return true
+}
 
+// Next returns the next key and value in the range query iterator, or the
+// error that occurred fetching the next batch of results from the peer.
+func (iter *StateRangeQueryIterator) Next() (string, []byte, error) {
+	if !iter.HasNext() {
+		return "", nil, errors.New("No such key")
+	}
+	if err := iter.err; err != nil {
+		iter.err = nil
+		iter.currentLoc = len(iter.response.KeysAndValues)
+		iter.response.HasMore = false
+		return "", nil, err
 	}
+	keyValue := iter.response.KeysAndValues[iter.currentLoc]
+	iter.currentLoc++
+	return keyValue.Key, keyValue.Value, nil
 }
 
 // Close closes the range query iterator. This should be called when done
 // reading from the iterator to free up resources.
 func (iter *StateRangeQueryIterator) Close() error {
+	if iter.response.ID == "" {
+		// paginated queries return the whole page at once and hold no iterator on the peer
+		return nil
+	}
 	_, err := iter.handler.handleRangeQueryStateClose(iter.response.ID, iter.uuid)
 	return err
 }
 
+// --------- Composite key functions ----------
+
+const (
+	// compositeKeyNamespace prefixes all composite keys to keep them apart
+	// from simple keys
+	compositeKeyNamespace = "\x00"
+	// compositeKeyDelimiter (U+0000) terminates every component of a composite key
+	compositeKeyDelimiter = "\x00"
+	// maxUnicodeRune (U+10FFFF) is the largest code point and is not allowed
+	// in composite key components, so it can mark the end of a partial key range
+	maxUnicodeRune = utf8.MaxRune
+)
+
+// CreateCompositeKey combines the given objectType and attributes into a
+// single key that can be used with PutState and GetState. The components
+// must be valid utf8 strings and must not contain U+0000 or U+10FFFF.
+func (stub *ChaincodeStub) CreateCompositeKey(objectType string, attributes []string) (string, error) {
+	return createCompositeKey(objectType, attributes)
+}
+
+// SplitCompositeKey splits the given composite key into the objectType and
+// attributes it was formed from.
+func (stub *ChaincodeStub) SplitCompositeKey(compositeKey string) (string, []string, error) {
+	return splitCompositeKey(compositeKey)
+}
+
+// GetStateByPartialCompositeKey queries the state for the keys that match the
+// given objectType and the leading attributes. The returned iterator walks all
+// composite keys that start with the partial key formed by objectType and
+// attributes.
+func (stub *ChaincodeStub) GetStateByPartialCompositeKey(objectType string, attributes []string) (StateRangeQueryIteratorInterface, error) {
+	partialCompositeKey, err := createCompositeKey(objectType, attributes)
+	if err != nil {
+		return nil, err
+	}
+	return stub.RangeQueryState(partialCompositeKey, partialCompositeKey+string(maxUnicodeRune))
+}
+
+func createCompositeKey(objectType string, attributes []string) (string, error) {
+	if err := validateCompositeKeyComponent(objectType); err != nil {
+		return "", err
+	}
+	compositeKey := compositeKeyNamespace + objectType + compositeKeyDelimiter
+	for _, attribute := range attributes {
+		if err := validateCompositeKeyComponent(attribute); err != nil {
+			return "", err
+		}
+		compositeKey += attribute + compositeKeyDelimiter
+	}
+	return compositeKey, nil
+}
+
+func splitCompositeKey(compositeKey string) (string, []string, error) {
+	if !strings.HasPrefix(compositeKey, compositeKeyNamespace) || !strings.HasSuffix(compositeKey, compositeKeyDelimiter) ||
+		len(compositeKey) < len(compositeKeyNamespace)+len(compositeKeyDelimiter) {
+		return "", nil, fmt.Errorf("Not a composite key: %q", compositeKey)
+	}
+	components := strings.Split(compositeKey[len(compositeKeyNamespace):len(compositeKey)-len(compositeKeyDelimiter)], compositeKeyDelimiter)
+	return components[0], components[1:], nil
+}
+
+func validateCompositeKeyComponent(component string) error {
+	if !utf8.ValidString(component) {
+		return fmt.Errorf("Composite key component is not a valid utf8 string: %q", component)
+	}
+	for _, r := range component {
+		if r == 0 || r == maxUnicodeRune {
+			return fmt.Errorf("Composite key component %q must not contain U+0000 or U+10FFFF", component)
+		}
+	}
+	return nil
+}
+
 func (stub *ChaincodeStub) GetArgs() [][]byte {
 	return stub.args
 }
@@ -643,27 +905,28 @@ func getRowsInternal(stub ChaincodeStubInterface, tableName string, key []Column
 	if err != nil {
 		return nil, fmt.Errorf("Error fetching rows: %s", err)
 	}
-	defer iter.Close()
 
 	rows := make(chan Row)
 
+	//the iterator is consumed, and closed, once the rows are read
 	go func() {
+		defer close(rows)
+		defer iter.Close()
 		for iter.HasNext() {
 			_, rowBytes, err := iter.Next()
 			if err != nil {
-				close(rows)
+				return
 			}
 
 			var row Row
 			err = proto.Unmarshal(rowBytes, &row)
 			if err != nil {
-				close(rows)
+				return
 			}
 
 			rows <- row
 
 		}
-		close(rows)
 	}()
 
 	return rows, nil
@@ -710,9 +973,13 @@ func (stub *ChaincodeStub) GetCallerMetadata() ([]byte, error) {
 	return stub.securityContext.Metadata, nil
 }
 
-// GetBinding returns the transaction binding
+// GetBinding returns the binding of the proposal being executed. Without a
+// proposal the binding of the transaction computed by the peer is returned.
 func (stub *ChaincodeStub) GetBinding() ([]byte, error) {
-	return stub.securityContext.Binding, nil
+	if stub.proposal == nil {
+		return stub.securityContext.Binding, nil
+	}
+	return utils.ComputeProposalBinding(stub.proposal)
 }
 
 // GetPayload returns transaction payload, which is a `ChaincodeSpec` defined
@@ -721,11 +988,31 @@ func (stub *ChaincodeStub) GetPayload() ([]byte, error) {
 	return stub.securityContext.Payload, nil
 }
 
-// GetTxTimestamp returns transaction created timestamp, which is currently
-// taken from the peer receiving the transaction. Note that this timestamp
-// may not be the same with the other peers' time.
+// GetTxTimestamp returns the timestamp in the header of the proposal, so that
+// all the endorsers see the same value. Without a proposal the timestamp of
+// the transaction taken by the peer is returned.
 func (stub *ChaincodeStub) GetTxTimestamp() (*timestamp.Timestamp, error) {
-	return stub.securityContext.TxTimestamp, nil
+	if stub.proposal == nil {
+		return stub.securityContext.TxTimestamp, nil
+	}
+	hdr := &pb.Header{}
+	if err := proto.Unmarshal(stub.proposal.Header, hdr); err != nil {
+		return nil, fmt.Errorf("Error unmarshalling the proposal header: %s", err)
+	}
+	return hdr.Timestamp, nil
+}
+
+// GetRandom returns the pseudo-random generator of the transaction, seeded
+// with the nonce of the proposal and the transaction ID.
+func (stub *ChaincodeStub) GetRandom() (*rand.Rand, error) {
+	if stub.random == nil {
+		nonce, err := getProposalNonce(stub.proposal)
+		if err != nil {
+			return nil, fmt.Errorf("Error unmarshalling the proposal header: %s", err)
+		}
+		stub.random = newTxRand(stub.TxID, nonce)
+	}
+	return stub.random, nil
 }
 
 func getTable(stub ChaincodeStubInterface, tableName string) (*Table, error) {
@@ -0,0 +1,283 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package contract routes the invocations of a Go chaincode by function name
+// to the methods of a contract, sparing the chaincode the switch on the
+// function every chaincode otherwise implements.
+//
+// The routed methods are the exported methods of the contract taking a
+// shim.ChaincodeStubInterface as their first parameter:
+//
+//	type Assets struct{}
+//
+//	func (a *Assets) Transfer(stub shim.ChaincodeStubInterface, id string, owner Owner) (*Asset, error)
+//
+//	func main() {
+//		router, err := contract.NewRouter(&Assets{})
+//		...
+//		err = shim.Start(router)
+//	}
+//
+// "transfer" and "Transfer" invoke the method. The arguments following the
+// function name are passed as the remaining parameters, string and []byte
+// parameters as they are and other types decoded from JSON. The method may
+// return a result, an error or both, the result is returned to the client as
+// is when it is a []byte or string and encoded to JSON otherwise.
+//
+// The Init method of the contract, if any, is called with the parameters of
+// the deploy transaction whatever its function name. It cannot be invoked
+// afterwards. A Migrate method implementing shim.Migrator is not routed to
+// either, the shim calls it when the chaincode is upgraded.
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+var (
+	stubType  = reflect.TypeOf((*shim.ChaincodeStubInterface)(nil)).Elem()
+	errorType = reflect.TypeOf((*error)(nil)).Elem()
+	bytesType = reflect.TypeOf([]byte(nil))
+)
+
+// BeforeFunc is called before the method routed to, an error fails the
+// invocation without calling the method
+type BeforeFunc func(stub shim.ChaincodeStubInterface, function string) error
+
+// AfterFunc is called with the result and error of the method routed to,
+// what it returns is returned to the client instead
+type AfterFunc func(stub shim.ChaincodeStubInterface, function string, result []byte, err error) ([]byte, error)
+
+// Router is a shim.Chaincode routing the invocations and queries by function
+// name to the methods of a contract
+type Router struct {
+	contract reflect.Value
+	init     *method
+	methods  map[string]*method
+
+	// Before is called before every routed invocation or query when set
+	Before BeforeFunc
+	// After is called after every routed invocation or query when set
+	After AfterFunc
+}
+
+// method is a routed method of the contract
+type method struct {
+	name   string
+	fn     reflect.Value
+	params []reflect.Type
+	// index of the result and error among the returns, -1 if not returned
+	result int
+	err    int
+}
+
+// NewRouter returns a Router to the methods of contract. It fails if the
+// contract has no method to route to or one of them returns something else
+// than a result and an error
+func NewRouter(contract interface{}) (*Router, error) {
+	r := &Router{contract: reflect.ValueOf(contract), methods: make(map[string]*method)}
+	t := r.contract.Type()
+	for i := 0; i < t.NumMethod(); i++ {
+		m := t.Method(i)
+		// Method includes the receiver as the first parameter
+		if m.PkgPath != "" || m.Type.NumIn() < 2 || m.Type.In(1) != stubType {
+			continue
+		}
+		routed, err := newMethod(m, r.contract.Method(i))
+		if err != nil {
+			return nil, err
+		}
+		switch m.Name {
+		case "Init":
+			r.init = routed
+		case "Migrate":
+			if _, ok := contract.(shim.Migrator); !ok {
+				r.methods[m.Name] = routed
+				r.methods[lowerFirst(m.Name)] = routed
+			}
+		default:
+			r.methods[m.Name] = routed
+			r.methods[lowerFirst(m.Name)] = routed
+		}
+	}
+	if r.init == nil && len(r.methods) == 0 {
+		return nil, fmt.Errorf("%s has no method taking a shim.ChaincodeStubInterface to route to", t)
+	}
+	return r, nil
+}
+
+func newMethod(m reflect.Method, fn reflect.Value) (*method, error) {
+	routed := &method{name: m.Name, fn: fn, result: -1, err: -1}
+	for i := 2; i < m.Type.NumIn(); i++ {
+		routed.params = append(routed.params, m.Type.In(i))
+	}
+	if m.Type.IsVariadic() {
+		return nil, fmt.Errorf("Method %s is variadic", m.Name)
+	}
+
+	switch m.Type.NumOut() {
+	case 0:
+	case 1:
+		if m.Type.Out(0) == errorType {
+			routed.err = 0
+		} else {
+			routed.result = 0
+		}
+	case 2:
+		if m.Type.Out(1) != errorType {
+			return nil, fmt.Errorf("Method %s returns %s rather than an error last", m.Name, m.Type.Out(1))
+		}
+		routed.result, routed.err = 0, 1
+	default:
+		return nil, fmt.Errorf("Method %s returns %d values, at most a result and an error are supported", m.Name, m.Type.NumOut())
+	}
+	return routed, nil
+}
+
+// Functions returns the sorted function names routed to the methods of the contract
+func (r *Router) Functions() []string {
+	var functions []string
+	for name, m := range r.methods {
+		if name == m.name {
+			functions = append(functions, lowerFirst(name))
+		}
+	}
+	sort.Strings(functions)
+	return functions
+}
+
+// Init calls the Init method of the contract, if any, with the parameters of
+// the deploy transaction
+func (r *Router) Init(stub shim.ChaincodeStubInterface) ([]byte, error) {
+	if r.init == nil {
+		return nil, nil
+	}
+	_, params := stub.GetFunctionAndParameters()
+	return r.call(stub, r.init, "init", params)
+}
+
+// Invoke calls the method of the contract the function of the invocation is routed to
+func (r *Router) Invoke(stub shim.ChaincodeStubInterface) ([]byte, error) {
+	return r.route(stub)
+}
+
+// Query calls the method of the contract the function of the query is routed to
+func (r *Router) Query(stub shim.ChaincodeStubInterface) ([]byte, error) {
+	return r.route(stub)
+}
+
+// Migrate migrates the state of the contract if it implements shim.Migrator
+func (r *Router) Migrate(stub shim.ChaincodeStubInterface, fromVersion string, toVersion string) ([]byte, error) {
+	if migrator, ok := r.contract.Interface().(shim.Migrator); ok {
+		return migrator.Migrate(stub, fromVersion, toVersion)
+	}
+	return nil, nil
+}
+
+func (r *Router) route(stub shim.ChaincodeStubInterface) ([]byte, error) {
+	function, params := stub.GetFunctionAndParameters()
+	m, ok := r.methods[function]
+	if !ok {
+		return nil, fmt.Errorf("Unknown function %s", function)
+	}
+	return r.call(stub, m, function, params)
+}
+
+func (r *Router) call(stub shim.ChaincodeStubInterface, m *method, function string, params []string) ([]byte, error) {
+	if r.Before != nil {
+		if err := r.Before(stub, function); err != nil {
+			return nil, err
+		}
+	}
+	result, err := m.call(stub, params)
+	if r.After != nil {
+		return r.After(stub, function, result, err)
+	}
+	return result, err
+}
+
+func (m *method) call(stub shim.ChaincodeStubInterface, params []string) ([]byte, error) {
+	if len(params) != len(m.params) {
+		return nil, fmt.Errorf("Incorrect number of arguments for %s: expecting %d, got %d", m.name, len(m.params), len(params))
+	}
+	in := []reflect.Value{reflect.ValueOf(stub)}
+	for i, param := range params {
+		arg, err := decodeArg(param, m.params[i])
+		if err != nil {
+			return nil, fmt.Errorf("Invalid argument %d of %s: %s", i+1, m.name, err)
+		}
+		in = append(in, arg)
+	}
+
+	out := m.fn.Call(in)
+	if m.err >= 0 && !out[m.err].IsNil() {
+		return nil, out[m.err].Interface().(error)
+	}
+	if m.result < 0 {
+		return nil, nil
+	}
+	return encodeResult(out[m.result])
+}
+
+// decodeArg returns the argument as the type of the parameter, string and
+// []byte as is and other types decoded from JSON
+func decodeArg(param string, t reflect.Type) (reflect.Value, error) {
+	switch {
+	case t.Kind() == reflect.String:
+		return reflect.ValueOf(param).Convert(t), nil
+	case t == bytesType:
+		return reflect.ValueOf([]byte(param)), nil
+	}
+	arg := reflect.New(t)
+	if err := json.Unmarshal([]byte(param), arg.Interface()); err != nil {
+		return reflect.Value{}, err
+	}
+	return arg.Elem(), nil
+}
+
+// encodeResult returns the result as is if it is a []byte or string and its
+// JSON encoding otherwise
+func encodeResult(result reflect.Value) ([]byte, error) {
+	switch {
+	case result.Kind() == reflect.String:
+		return []byte(result.String()), nil
+	case result.Type() == bytesType:
+		return result.Bytes(), nil
+	}
+	switch result.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		if result.IsNil() {
+			return nil, nil
+		}
+	}
+	res, err := json.Marshal(result.Interface())
+	if err != nil {
+		return nil, fmt.Errorf("Error marshalling the result: %s", err)
+	}
+	return res, nil
+}
+
+func lowerFirst(name string) string {
+	r, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToLower(r)) + name[size:]
+}
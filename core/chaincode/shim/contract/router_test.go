@@ -0,0 +1,175 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package contract
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+type asset struct {
+	ID    string `json:"id"`
+	Value int    `json:"value"`
+}
+
+// assets is a contract storing assets by id
+type assets struct {
+	initialized string
+	calls       []string
+}
+
+func (a *assets) Init(stub shim.ChaincodeStubInterface, name string) error {
+	a.initialized = name
+	return nil
+}
+
+func (a *assets) Create(stub shim.ChaincodeStubInterface, created asset) error {
+	if created.ID == "" {
+		return errors.New("missing id")
+	}
+	return stub.PutState(created.ID, []byte(fmt.Sprint(created.Value)))
+}
+
+func (a *assets) Read(stub shim.ChaincodeStubInterface, id string) (*asset, error) {
+	value, err := stub.GetState(id)
+	if err != nil || value == nil {
+		return nil, err
+	}
+	read := &asset{ID: id}
+	_, err = fmt.Sscan(string(value), &read.Value)
+	return read, err
+}
+
+func (a *assets) Count(stub shim.ChaincodeStubInterface, ids []string) int {
+	return len(ids)
+}
+
+func (a *assets) Name(stub shim.ChaincodeStubInterface) string {
+	return a.initialized
+}
+
+// not routed to as it does not take a stub
+func (a *assets) Helper() {}
+
+func args(function string, params ...string) [][]byte {
+	bytes := [][]byte{[]byte(function)}
+	for _, param := range params {
+		bytes = append(bytes, []byte(param))
+	}
+	return bytes
+}
+
+func TestRouter(t *testing.T) {
+	contract := &assets{}
+	router, err := NewRouter(contract)
+	if err != nil {
+		t.Fatalf("Error creating the router: %s", err)
+	}
+	if functions := fmt.Sprint(router.Functions()); functions != "[count create name read]" {
+		t.Fatalf("Unexpected functions %s", functions)
+	}
+	stub := shim.NewMockStub("assets", router)
+
+	if _, err = stub.MockInit("1", args("init", "my assets")); err != nil || contract.initialized != "my assets" {
+		t.Fatalf("Init failed: %v, initialized %q", err, contract.initialized)
+	}
+	if _, err = stub.MockInvoke("2", args("init", "again")); err == nil {
+		t.Fatalf("Expected Init not to be invocable")
+	}
+
+	if _, err = stub.MockInvoke("3", args("create", `{"id":"a1","value":42}`)); err != nil {
+		t.Fatalf("Error creating the asset: %s", err)
+	}
+	if _, err = stub.MockInvoke("4", args("Create", `{"value":1}`)); err == nil || err.Error() != "missing id" {
+		t.Fatalf("Expected the error of the method, got %v", err)
+	}
+
+	for _, test := range []struct {
+		args     [][]byte
+		expected string
+	}{
+		{args("read", "a1"), `{"id":"a1","value":42}`},
+		{args("read", "a2"), ""},
+		{args("count", `["a1","a2","a3"]`), "3"},
+		{args("name"), "my assets"},
+	} {
+		res, err := stub.MockQuery(test.args)
+		if err != nil || string(res) != test.expected {
+			t.Fatalf("Unexpected result %s of %s, error %v", res, test.args[0], err)
+		}
+	}
+
+	for _, invalid := range [][][]byte{
+		args("unknown"),
+		args("helper"),
+		args("read"),
+		args("count", "not json"),
+	} {
+		if _, err = stub.MockQuery(invalid); err == nil {
+			t.Fatalf("Expected an error for %s", invalid)
+		}
+	}
+}
+
+func TestRouterHooks(t *testing.T) {
+	contract := &assets{}
+	router, err := NewRouter(contract)
+	if err != nil {
+		t.Fatalf("Error creating the router: %s", err)
+	}
+	router.Before = func(stub shim.ChaincodeStubInterface, function string) error {
+		contract.calls = append(contract.calls, "before "+function)
+		if function == "count" {
+			return errors.New("access denied")
+		}
+		return nil
+	}
+	router.After = func(stub shim.ChaincodeStubInterface, function string, result []byte, err error) ([]byte, error) {
+		contract.calls = append(contract.calls, "after "+function)
+		return append(result, '!'), err
+	}
+	stub := shim.NewMockStub("assets", router)
+
+	res, err := stub.MockQuery(args("name"))
+	if err != nil || string(res) != "!" {
+		t.Fatalf("Unexpected result %s, error %v", res, err)
+	}
+	if _, err = stub.MockQuery(args("count", "[]")); err == nil || err.Error() != "access denied" {
+		t.Fatalf("Expected the error of the before hook, got %v", err)
+	}
+	if calls := fmt.Sprint(contract.calls); calls != "[before name after name before count]" {
+		t.Fatalf("Unexpected calls %s", calls)
+	}
+}
+
+type invalidContract struct{}
+
+func (c *invalidContract) Pair(stub shim.ChaincodeStubInterface) (string, string) {
+	return "", ""
+}
+
+func TestNewRouterInvalid(t *testing.T) {
+	if _, err := NewRouter(&invalidContract{}); err == nil {
+		t.Fatalf("Expected an error for a method not returning an error last")
+	}
+	if _, err := NewRouter(&struct{}{}); err == nil {
+		t.Fatalf("Expected an error for a contract without methods")
+	}
+}
@@ -0,0 +1,241 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package entities lets chaincode encrypt, decrypt, sign and verify values
+// with keys passed by the client, typically in the transient field of the
+// proposal, so that values can be kept confidential on top of public state.
+// The cryptographic operations are performed by a BCCSP.
+package entities
+
+import (
+	"bytes"
+	"crypto/aes"
+	"errors"
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/crypto/bccsp"
+	"github.com/hyperledger/fabric/core/crypto/bccsp/factory"
+	"github.com/hyperledger/fabric/core/crypto/primitives"
+)
+
+// Entity is a party in the encryption or signature of values, identified
+// by an ID
+type Entity interface {
+	// ID returns the identifier of the entity
+	ID() string
+
+	// Equals returns true if the entity has the same ID and keys as e
+	Equals(e Entity) bool
+}
+
+// Encrypter encrypts and decrypts values
+type Encrypter interface {
+	// Encrypt returns the ciphertext of plaintext
+	Encrypt(plaintext []byte) ([]byte, error)
+
+	// Decrypt returns the plaintext of ciphertext
+	Decrypt(ciphertext []byte) ([]byte, error)
+}
+
+// Signer signs values and verifies their signatures
+type Signer interface {
+	// Sign returns the signature of msg
+	Sign(msg []byte) ([]byte, error)
+
+	// Verify returns true if signature is a valid signature of msg
+	Verify(signature, msg []byte) (bool, error)
+}
+
+// EncrypterEntity is an entity able to encrypt and decrypt
+type EncrypterEntity interface {
+	Entity
+	Encrypter
+}
+
+// SignerEntity is an entity able to sign and verify
+type SignerEntity interface {
+	Entity
+	Signer
+}
+
+// EncrypterSignerEntity is an entity able to encrypt, decrypt, sign and verify
+type EncrypterSignerEntity interface {
+	Entity
+	Encrypter
+	Signer
+}
+
+// GetBCCSP returns an ephemeral software BCCSP suitable for chaincode, where
+// the keys are imported from the transaction rather than from a key store.
+// The security level is the default one (SHA2, 256) unless it was set before
+func GetBCCSP() (bccsp.BCCSP, error) {
+	if err := primitives.InitSecurityLevel("SHA2", 256); err != nil {
+		return nil, fmt.Errorf("Failed initializing the security level [%s]", err)
+	}
+	return factory.GetBCCSP(&factory.SwOpts{EphemeralFlag: true})
+}
+
+// baseEntity is the base of the entities, holding the ID and the BCCSP
+type baseEntity struct {
+	id    string
+	bccsp bccsp.BCCSP
+}
+
+// ID returns the identifier of the entity
+func (e *baseEntity) ID() string {
+	return e.id
+}
+
+// Equals returns true if the entity has the same ID as e
+func (e *baseEntity) Equals(ent Entity) bool {
+	return ent != nil && e.id == ent.ID()
+}
+
+// aes256EncrypterEntity encrypts with AES-256 in CBC mode with PKCS7 padding
+type aes256EncrypterEntity struct {
+	baseEntity
+	key bccsp.Key
+	raw []byte
+	iv  []byte
+}
+
+// NewAES256EncrypterEntity returns an entity encrypting with the 32 bytes
+// AES key and the 16 bytes IV. Chaincode must give an IV: the endorsers of a
+// proposal only produce the same write set if they encrypt with the same IV.
+// A random IV is generated for each encryption when IV is nil
+func NewAES256EncrypterEntity(ID string, b bccsp.BCCSP, key, IV []byte) (EncrypterEntity, error) {
+	if b == nil {
+		return nil, errors.New("Invalid BCCSP. Nil.")
+	}
+	if IV != nil && len(IV) != aes.BlockSize {
+		return nil, fmt.Errorf("Invalid IV length %d, must be %d", len(IV), aes.BlockSize)
+	}
+	k, err := b.KeyImport(key, &bccsp.AES256ImportKeyOpts{Temporary: true})
+	if err != nil {
+		return nil, fmt.Errorf("Failed importing the AES key [%s]", err)
+	}
+	return &aes256EncrypterEntity{baseEntity{ID, b}, k, append([]byte(nil), key...), append([]byte(nil), IV...)}, nil
+}
+
+func (e *aes256EncrypterEntity) Encrypt(plaintext []byte) ([]byte, error) {
+	opts := &bccsp.AESCBCPKCS7ModeOpts{}
+	if len(e.iv) > 0 {
+		opts.IV = e.iv
+	}
+	return e.bccsp.Encrypt(e.key, plaintext, opts)
+}
+
+func (e *aes256EncrypterEntity) Decrypt(ciphertext []byte) ([]byte, error) {
+	return e.bccsp.Decrypt(e.key, ciphertext, &bccsp.AESCBCPKCS7ModeOpts{})
+}
+
+func (e *aes256EncrypterEntity) Equals(ent Entity) bool {
+	other, ok := ent.(*aes256EncrypterEntity)
+	return ok && e.id == other.id && bytes.Equal(e.raw, other.raw) && bytes.Equal(e.iv, other.iv)
+}
+
+// ecdsaSignerEntity signs the hash of a message with an ECDSA private key, or
+// only verifies signatures if it holds the public key
+type ecdsaSignerEntity struct {
+	baseEntity
+	key bccsp.Key
+	raw []byte
+}
+
+// NewECDSASignerEntity returns an entity signing with the DER encoded ECDSA
+// private key, and verifying with the corresponding public key
+func NewECDSASignerEntity(ID string, b bccsp.BCCSP, signKey []byte) (SignerEntity, error) {
+	if b == nil {
+		return nil, errors.New("Invalid BCCSP. Nil.")
+	}
+	k, err := b.KeyImport(signKey, &bccsp.ECDSAPrivateKeyImportOpts{Temporary: true})
+	if err != nil {
+		return nil, fmt.Errorf("Failed importing the ECDSA private key [%s]", err)
+	}
+	return &ecdsaSignerEntity{baseEntity{ID, b}, k, append([]byte(nil), signKey...)}, nil
+}
+
+// NewECDSAVerifierEntity returns an entity verifying signatures with the DER
+// encoded PKIX ECDSA public key. It cannot sign
+func NewECDSAVerifierEntity(ID string, b bccsp.BCCSP, verifyKey []byte) (SignerEntity, error) {
+	if b == nil {
+		return nil, errors.New("Invalid BCCSP. Nil.")
+	}
+	k, err := b.KeyImport(verifyKey, &bccsp.ECDSAPKIXPublicKeyImportOpts{Temporary: true})
+	if err != nil {
+		return nil, fmt.Errorf("Failed importing the ECDSA public key [%s]", err)
+	}
+	return &ecdsaSignerEntity{baseEntity{ID, b}, k, append([]byte(nil), verifyKey...)}, nil
+}
+
+func (e *ecdsaSignerEntity) Sign(msg []byte) ([]byte, error) {
+	if !e.key.Private() {
+		return nil, fmt.Errorf("Entity %s holds no private key and cannot sign", e.id)
+	}
+	digest, err := e.bccsp.Hash(msg, &bccsp.SHAOpts{})
+	if err != nil {
+		return nil, fmt.Errorf("Failed computing the hash [%s]", err)
+	}
+	return e.bccsp.Sign(e.key, digest, nil)
+}
+
+func (e *ecdsaSignerEntity) Verify(signature, msg []byte) (bool, error) {
+	digest, err := e.bccsp.Hash(msg, &bccsp.SHAOpts{})
+	if err != nil {
+		return false, fmt.Errorf("Failed computing the hash [%s]", err)
+	}
+	return e.bccsp.Verify(e.key, signature, digest, nil)
+}
+
+func (e *ecdsaSignerEntity) Equals(ent Entity) bool {
+	other, ok := ent.(*ecdsaSignerEntity)
+	return ok && e.id == other.id && bytes.Equal(e.raw, other.raw)
+}
+
+// aes256EncrypterECDSASignerEntity combines an AES-256 encrypter and an ECDSA
+// signer
+type aes256EncrypterECDSASignerEntity struct {
+	*aes256EncrypterEntity
+	signer *ecdsaSignerEntity
+}
+
+// NewAES256EncrypterECDSASignerEntity returns an entity encrypting with the
+// 32 bytes AES key and the IV, as NewAES256EncrypterEntity, and signing with
+// the DER encoded ECDSA private key
+func NewAES256EncrypterECDSASignerEntity(ID string, b bccsp.BCCSP, encKey, IV, signKey []byte) (EncrypterSignerEntity, error) {
+	enc, err := NewAES256EncrypterEntity(ID, b, encKey, IV)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := NewECDSASignerEntity(ID, b, signKey)
+	if err != nil {
+		return nil, err
+	}
+	return &aes256EncrypterECDSASignerEntity{enc.(*aes256EncrypterEntity), signer.(*ecdsaSignerEntity)}, nil
+}
+
+func (e *aes256EncrypterECDSASignerEntity) Sign(msg []byte) ([]byte, error) {
+	return e.signer.Sign(msg)
+}
+
+func (e *aes256EncrypterECDSASignerEntity) Verify(signature, msg []byte) (bool, error) {
+	return e.signer.Verify(signature, msg)
+}
+
+func (e *aes256EncrypterECDSASignerEntity) Equals(ent Entity) bool {
+	other, ok := ent.(*aes256EncrypterECDSASignerEntity)
+	return ok && e.aes256EncrypterEntity.Equals(other.aes256EncrypterEntity) && e.signer.Equals(other.signer)
+}
@@ -0,0 +1,240 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package entities
+
+import (
+	"bytes"
+	"crypto/x509"
+	"testing"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/core/crypto/bccsp"
+	"github.com/hyperledger/fabric/core/crypto/primitives"
+)
+
+func getTestBCCSP(t *testing.T) bccsp.BCCSP {
+	b, err := GetBCCSP()
+	if err != nil {
+		t.Fatalf("Failed getting the BCCSP [%s]", err)
+	}
+	return b
+}
+
+func getTestKeys(t *testing.T) (encKey, signKey, verifyKey []byte) {
+	encKey, err := primitives.GenAESKey()
+	if err != nil {
+		t.Fatalf("Failed generating AES key [%s]", err)
+	}
+	sk, err := primitives.NewECDSAKey()
+	if err != nil {
+		t.Fatalf("Failed generating ECDSA key [%s]", err)
+	}
+	if signKey, err = primitives.PrivateKeyToDER(sk); err != nil {
+		t.Fatalf("Failed marshalling ECDSA private key [%s]", err)
+	}
+	if verifyKey, err = x509.MarshalPKIXPublicKey(&sk.PublicKey); err != nil {
+		t.Fatalf("Failed marshalling ECDSA public key [%s]", err)
+	}
+	return
+}
+
+func TestEncrypterSignerEntity(t *testing.T) {
+	b := getTestBCCSP(t)
+	encKey, signKey, verifyKey := getTestKeys(t)
+
+	ent, err := NewAES256EncrypterECDSASignerEntity("alice", b, encKey, nil, signKey)
+	if err != nil {
+		t.Fatalf("Failed creating the entity [%s]", err)
+	}
+	if ent.ID() != "alice" {
+		t.Fatalf("Unexpected ID %s", ent.ID())
+	}
+
+	ct, err := ent.Encrypt([]byte("secret"))
+	if err != nil {
+		t.Fatalf("Failed encrypting [%s]", err)
+	}
+	if bytes.Contains(ct, []byte("secret")) {
+		t.Fatal("The ciphertext contains the plaintext")
+	}
+	pt, err := ent.Decrypt(ct)
+	if err != nil || string(pt) != "secret" {
+		t.Fatalf("Unexpected plaintext %s, error %v", pt, err)
+	}
+
+	// the signature verifies with the public key only
+	sig, err := ent.Sign([]byte("msg"))
+	if err != nil {
+		t.Fatalf("Failed signing [%s]", err)
+	}
+	verifier, err := NewECDSAVerifierEntity("alice", b, verifyKey)
+	if err != nil {
+		t.Fatalf("Failed creating the verifier [%s]", err)
+	}
+	if valid, err := verifier.Verify(sig, []byte("msg")); err != nil || !valid {
+		t.Fatalf("Signature not valid, error %v", err)
+	}
+	if valid, _ := verifier.Verify(sig, []byte("other msg")); valid {
+		t.Fatal("Signature of another message valid")
+	}
+	if _, err = verifier.Sign([]byte("msg")); err == nil {
+		t.Fatal("A verifier must not sign")
+	}
+
+	// entities are equal with the same ID and keys
+	other, _ := NewAES256EncrypterECDSASignerEntity("alice", b, encKey, nil, signKey)
+	if !ent.Equals(other) {
+		t.Fatal("Entities with the same ID and keys must be equal")
+	}
+	other, _ = NewAES256EncrypterECDSASignerEntity("bob", b, encKey, nil, signKey)
+	if ent.Equals(other) {
+		t.Fatal("Entities with different IDs must not be equal")
+	}
+
+	if _, err = NewAES256EncrypterEntity("alice", b, []byte("short"), nil); err == nil {
+		t.Fatal("Expected an error with an invalid AES key")
+	}
+	if _, err = NewAES256EncrypterEntity("alice", b, encKey, []byte("short")); err == nil {
+		t.Fatal("Expected an error with an invalid IV")
+	}
+	if _, err = NewECDSASignerEntity("alice", nil, signKey); err == nil {
+		t.Fatal("Expected an error without a BCCSP")
+	}
+}
+
+func TestEncryptWithIV(t *testing.T) {
+	b := getTestBCCSP(t)
+	encKey, _, _ := getTestKeys(t)
+	iv := bytes.Repeat([]byte{1}, 16)
+
+	// two endorsers encrypting with the same key and IV get the same ciphertext
+	ent1, err := NewAES256EncrypterEntity("alice", b, encKey, iv)
+	if err != nil {
+		t.Fatalf("Failed creating the entity [%s]", err)
+	}
+	ent2, _ := NewAES256EncrypterEntity("alice", b, encKey, iv)
+	ct1, err := ent1.Encrypt([]byte("secret"))
+	if err != nil {
+		t.Fatalf("Failed encrypting [%s]", err)
+	}
+	ct2, _ := ent2.Encrypt([]byte("secret"))
+	if !bytes.Equal(ct1, ct2) {
+		t.Fatal("Expected the same ciphertext with the same key and IV")
+	}
+	if pt, err := ent2.Decrypt(ct1); err != nil || string(pt) != "secret" {
+		t.Fatalf("Unexpected plaintext %s, error %v", pt, err)
+	}
+
+	// without an IV, each encryption draws a random one
+	random, _ := NewAES256EncrypterEntity("alice", b, encKey, nil)
+	ct3, _ := random.Encrypt([]byte("secret"))
+	ct4, _ := random.Encrypt([]byte("secret"))
+	if bytes.Equal(ct3, ct4) {
+		t.Fatal("Expected different ciphertexts with random IVs")
+	}
+}
+
+func TestSignedMessage(t *testing.T) {
+	b := getTestBCCSP(t)
+	_, signKey, verifyKey := getTestKeys(t)
+	signer, _ := NewECDSASignerEntity("alice", b, signKey)
+	verifier, _ := NewECDSAVerifierEntity("alice", b, verifyKey)
+
+	m := &SignedMessage{Payload: []byte("payload")}
+	if err := m.Sign(signer); err != nil {
+		t.Fatalf("Failed signing the message [%s]", err)
+	}
+	raw, err := m.ToBytes()
+	if err != nil {
+		t.Fatalf("Failed marshalling the message [%s]", err)
+	}
+
+	m2 := &SignedMessage{}
+	if err = m2.FromBytes(raw); err != nil {
+		t.Fatalf("Failed unmarshalling the message [%s]", err)
+	}
+	if valid, err := m2.Verify(verifier); err != nil || !valid {
+		t.Fatalf("Message not valid, error %v", err)
+	}
+
+	m2.Payload = []byte("tampered")
+	if valid, _ := m2.Verify(verifier); valid {
+		t.Fatal("Tampered message valid")
+	}
+	other, _ := NewECDSAVerifierEntity("bob", b, verifyKey)
+	if valid, _ := m.Verify(other); valid {
+		t.Fatal("Message valid for another ID")
+	}
+}
+
+func TestEntitiesFromTransient(t *testing.T) {
+	b := getTestBCCSP(t)
+	encKey, signKey, verifyKey := getTestKeys(t)
+
+	stub := shim.NewMockStub("entities", nil)
+	stub.MockTransactionStart("tx1")
+	defer stub.MockTransactionEnd("tx1")
+
+	if _, err := NewEncrypterFromTransient(stub, b, "alice"); err == nil {
+		t.Fatal("Expected an error without a key in the transient data")
+	}
+
+	stub.Transient = map[string][]byte{EncryptionKeyField: encKey, VerificationKeyField: verifyKey}
+	if _, err := NewEncrypterFromTransient(stub, b, "alice"); err == nil {
+		t.Fatal("Expected an error without an IV in the transient data")
+	}
+
+	stub.Transient[IVField] = bytes.Repeat([]byte{1}, 16)
+	enc, err := NewEncrypterFromTransient(stub, b, "alice")
+	if err != nil {
+		t.Fatalf("Failed creating the encrypter [%s]", err)
+	}
+	if err = PutStateEncrypted(stub, enc, "key", []byte("secret")); err != nil {
+		t.Fatalf("Failed putting the state [%s]", err)
+	}
+	if bytes.Equal(stub.State["key"], []byte("secret")) {
+		t.Fatal("The state is not encrypted")
+	}
+	value, err := GetStateDecrypted(stub, enc, "key")
+	if err != nil || string(value) != "secret" {
+		t.Fatalf("Unexpected value %s, error %v", value, err)
+	}
+	if value, err = GetStateDecrypted(stub, enc, "missing"); err != nil || value != nil {
+		t.Fatalf("Unexpected value %s, error %v", value, err)
+	}
+
+	// without a signing key, the entity only verifies
+	verifier, err := NewSignerFromTransient(stub, b, "alice")
+	if err != nil {
+		t.Fatalf("Failed creating the verifier [%s]", err)
+	}
+	if _, err = verifier.Sign([]byte("msg")); err == nil {
+		t.Fatal("A verifier must not sign")
+	}
+	stub.Transient[SigningKeyField] = signKey
+	signer, err := NewSignerFromTransient(stub, b, "alice")
+	if err != nil {
+		t.Fatalf("Failed creating the signer [%s]", err)
+	}
+	sig, err := signer.Sign([]byte("msg"))
+	if err != nil {
+		t.Fatalf("Failed signing [%s]", err)
+	}
+	if valid, err := verifier.Verify(sig, []byte("msg")); err != nil || !valid {
+		t.Fatalf("Signature not valid, error %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package entities
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// SignedMessage is a payload signed by the entity ID, marshaled in JSON to
+// be stored or passed as argument
+type SignedMessage struct {
+	ID      []byte `json:"id"`
+	Payload []byte `json:"payload"`
+	Sig     []byte `json:"sig"`
+}
+
+// Sign sets the ID and the signature of the message with the signer
+func (m *SignedMessage) Sign(signer SignerEntity) error {
+	if signer == nil {
+		return errors.New("Invalid signer. Nil.")
+	}
+	m.ID = []byte(signer.ID())
+	sig, err := signer.Sign(m.signedBytes())
+	if err != nil {
+		return err
+	}
+	m.Sig = sig
+	return nil
+}
+
+// Verify returns true if the message was signed by the verifier
+func (m *SignedMessage) Verify(verifier SignerEntity) (bool, error) {
+	if verifier == nil {
+		return false, errors.New("Invalid verifier. Nil.")
+	}
+	if string(m.ID) != verifier.ID() {
+		return false, nil
+	}
+	return verifier.Verify(m.Sig, m.signedBytes())
+}
+
+// signedBytes returns the bytes covered by the signature, the ID and the
+// payload
+func (m *SignedMessage) signedBytes() []byte {
+	raw, _ := json.Marshal(&SignedMessage{ID: m.ID, Payload: m.Payload})
+	return raw
+}
+
+// ToBytes marshals the message
+func (m *SignedMessage) ToBytes() ([]byte, error) {
+	return json.Marshal(m)
+}
+
+// FromBytes unmarshals the message
+func (m *SignedMessage) FromBytes(raw []byte) error {
+	return json.Unmarshal(raw, m)
+}
@@ -0,0 +1,107 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package entities
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/core/crypto/bccsp"
+)
+
+const (
+	// EncryptionKeyField is the transient field carrying the AES-256 key
+	EncryptionKeyField = "ENCKEY"
+
+	// IVField is the transient field carrying the 16 bytes IV the values are
+	// encrypted with
+	IVField = "IV"
+
+	// SigningKeyField is the transient field carrying the DER encoded ECDSA
+	// private key
+	SigningKeyField = "SIGNKEY"
+
+	// VerificationKeyField is the transient field carrying the DER encoded
+	// PKIX ECDSA public key
+	VerificationKeyField = "VERKEY"
+)
+
+// NewEncrypterFromTransient returns the entity ID encrypting with the key in
+// the EncryptionKeyField and the IV in the IVField of the transient data of
+// the transaction. The IV is required so that all the endorsers of the
+// proposal encrypt the values alike
+func NewEncrypterFromTransient(stub shim.ChaincodeStubInterface, b bccsp.BCCSP, ID string) (EncrypterEntity, error) {
+	key, err := getTransientField(stub, EncryptionKeyField)
+	if err != nil {
+		return nil, err
+	}
+	iv, err := getTransientField(stub, IVField)
+	if err != nil {
+		return nil, err
+	}
+	return NewAES256EncrypterEntity(ID, b, key, iv)
+}
+
+// NewSignerFromTransient returns the entity ID signing with the key in the
+// SigningKeyField of the transient data of the transaction or, without it,
+// only verifying with the key in the VerificationKeyField
+func NewSignerFromTransient(stub shim.ChaincodeStubInterface, b bccsp.BCCSP, ID string) (SignerEntity, error) {
+	if key, err := getTransientField(stub, SigningKeyField); err == nil {
+		return NewECDSASignerEntity(ID, b, key)
+	}
+	key, err := getTransientField(stub, VerificationKeyField)
+	if err != nil {
+		return nil, fmt.Errorf("Neither %s nor %s in the transient data", SigningKeyField, VerificationKeyField)
+	}
+	return NewECDSAVerifierEntity(ID, b, key)
+}
+
+func getTransientField(stub shim.ChaincodeStubInterface, field string) ([]byte, error) {
+	transient, err := stub.GetTransient()
+	if err != nil {
+		return nil, fmt.Errorf("Failed getting the transient data [%s]", err)
+	}
+	value, ok := transient[field]
+	if !ok || len(value) == 0 {
+		return nil, fmt.Errorf("No %s in the transient data", field)
+	}
+	return value, nil
+}
+
+// PutStateEncrypted encrypts value with the encrypter and writes it into the
+// ledger under key
+func PutStateEncrypted(stub shim.ChaincodeStubInterface, enc Encrypter, key string, value []byte) error {
+	ciphertext, err := enc.Encrypt(value)
+	if err != nil {
+		return fmt.Errorf("Failed encrypting the value of %s [%s]", key, err)
+	}
+	return stub.PutState(key, ciphertext)
+}
+
+// GetStateDecrypted reads the value of key from the ledger and decrypts it
+// with the encrypter. The value of a key that does not exist is nil
+func GetStateDecrypted(stub shim.ChaincodeStubInterface, enc Encrypter, key string) ([]byte, error) {
+	ciphertext, err := stub.GetState(key)
+	if err != nil || ciphertext == nil {
+		return nil, err
+	}
+	value, err := enc.Decrypt(ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("Failed decrypting the value of %s [%s]", key, err)
+	}
+	return value, nil
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/golang/protobuf/proto"
 	pb "github.com/hyperledger/fabric/protos"
 	"github.com/looplab/fsm"
+	"github.com/op/go-logging"
 )
 
 // PeerChaincodeStream interface for stream between Peer and chaincode instance.
@@ -131,6 +132,12 @@ func (handler *Handler) markIsTransaction(txid string, isTrans bool) bool {
 	return true
 }
 
+func (handler *Handler) getIsTransaction(txid string) bool {
+	handler.RLock()
+	defer handler.RUnlock()
+	return handler.isTransaction[txid]
+}
+
 func (handler *Handler) deleteIsTransaction(txid string) {
 	handler.Lock()
 	if handler.isTransaction != nil {
@@ -159,13 +166,10 @@ func newChaincodeHandler(peerChatStream PeerChaincodeStream, chaincode Chaincode
 			{Name: pb.ChaincodeMessage_ERROR.String(), Src: []string{"init"}, Dst: "established"},
 			{Name: pb.ChaincodeMessage_RESPONSE.String(), Src: []string{"init"}, Dst: "init"},
 			{Name: pb.ChaincodeMessage_COMPLETED.String(), Src: []string{"init"}, Dst: "ready"},
-			{Name: pb.ChaincodeMessage_TRANSACTION.String(), Src: []string{"ready"}, Dst: "transaction"},
-			{Name: pb.ChaincodeMessage_COMPLETED.String(), Src: []string{"transaction"}, Dst: "ready"},
-			{Name: pb.ChaincodeMessage_ERROR.String(), Src: []string{"transaction"}, Dst: "ready"},
-			{Name: pb.ChaincodeMessage_RESPONSE.String(), Src: []string{"transaction"}, Dst: "transaction"},
-			{Name: pb.ChaincodeMessage_QUERY.String(), Src: []string{"transaction"}, Dst: "transaction"},
+			{Name: pb.ChaincodeMessage_TRANSACTION.String(), Src: []string{"ready"}, Dst: "ready"},
 			{Name: pb.ChaincodeMessage_QUERY.String(), Src: []string{"ready"}, Dst: "ready"},
 			{Name: pb.ChaincodeMessage_RESPONSE.String(), Src: []string{"ready"}, Dst: "ready"},
+			{Name: pb.ChaincodeMessage_ERROR.String(), Src: []string{"ready"}, Dst: "ready"},
 		},
 		fsm.Callbacks{
 			"before_" + pb.ChaincodeMessage_REGISTERED.String(): func(e *fsm.Event) { v.beforeRegistered(e) },
@@ -173,10 +177,10 @@ func newChaincodeHandler(peerChatStream PeerChaincodeStream, chaincode Chaincode
 			//"after_" + pb.ChaincodeMessage_TRANSACTION.String(): func(e *fsm.Event) { v.beforeTransaction(e) },
 			"after_" + pb.ChaincodeMessage_RESPONSE.String(): func(e *fsm.Event) { v.afterResponse(e) },
 			"after_" + pb.ChaincodeMessage_ERROR.String():    func(e *fsm.Event) { v.afterError(e) },
-			"enter_init":                                     func(e *fsm.Event) { v.enterInitState(e) },
-			"enter_transaction":                              func(e *fsm.Event) { v.enterTransactionState(e) },
+			"enter_init": func(e *fsm.Event) { v.enterInitState(e) },
 			//"enter_ready":                                     func(e *fsm.Event) { v.enterReadyState(e) },
-			"before_" + pb.ChaincodeMessage_QUERY.String(): func(e *fsm.Event) { v.beforeQuery(e) }, //only checks for QUERY
+			"before_" + pb.ChaincodeMessage_QUERY.String():       func(e *fsm.Event) { v.beforeQuery(e) }, //only checks for QUERY
+			"before_" + pb.ChaincodeMessage_TRANSACTION.String(): func(e *fsm.Event) { v.beforeTransaction(e) },
 		},
 	)
 	return v
@@ -222,7 +226,7 @@ func (handler *Handler) handleInit(msg *pb.ChaincodeMessage) {
 		// Call chaincode's Run
 		// Create the ChaincodeStub which the chaincode can use to callback
 		stub := new(ChaincodeStub)
-		stub.init(handler, msg.Txid, msg.SecurityContext)
+		stub.init(handler, msg.Txid, msg.SecurityContext, msg.Proposal, msg.SignedProposal)
 		res, err := handler.cc.Init(stub)
 
 		// delete isTransaction entry
@@ -232,7 +236,7 @@ func (handler *Handler) handleInit(msg *pb.ChaincodeMessage) {
 			payload := []byte(err.Error())
 			// Send ERROR message to chaincode support and change state
 			chaincodeLogger.Errorf("[%s]Init failed. Sending %s", shorttxid(msg.Txid), pb.ChaincodeMessage_ERROR)
-			nextStateMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: payload, Txid: msg.Txid, ChaincodeEvent: stub.chaincodeEvent}
+			nextStateMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: payload, Txid: msg.Txid, ChaincodeEvent: stub.chaincodeEvent, Response: errorResponse(err)}
 			return
 		}
 
@@ -259,17 +263,13 @@ func (handler *Handler) enterInitState(e *fsm.Event) {
 
 // handleTransaction Handles request to execute a transaction.
 func (handler *Handler) handleTransaction(msg *pb.ChaincodeMessage) {
-	// The defer followed by triggering a go routine dance is needed to ensure that the previous state transition
-	// is completed before the next one is triggered. The previous state transition is deemed complete only when
-	// the beforeInit function is exited. Interesting bug fix!!
+	// Transactions do not transition state, several of them execute
+	// concurrently, each with its own stub and told apart by their txid
 	go func() {
-		//better not be nil
-		var nextStateMsg *pb.ChaincodeMessage
-
-		send := true
+		var serialSendMsg *pb.ChaincodeMessage
 
 		defer func() {
-			handler.triggerNextState(nextStateMsg, send)
+			handler.serialSend(serialSendMsg)
 		}()
 
 		// Get the function and args from Payload
@@ -277,9 +277,9 @@ func (handler *Handler) handleTransaction(msg *pb.ChaincodeMessage) {
 		unmarshalErr := proto.Unmarshal(msg.Payload, input)
 		if unmarshalErr != nil {
 			payload := []byte(unmarshalErr.Error())
-			// Send ERROR message to chaincode support and change state
+			// Send ERROR message to chaincode support
 			chaincodeLogger.Debugf("[%s]Incorrect payload format. Sending %s", shorttxid(msg.Txid), pb.ChaincodeMessage_ERROR)
-			nextStateMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: payload, Txid: msg.Txid}
+			serialSendMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: payload, Txid: msg.Txid}
 			return
 		}
 
@@ -289,23 +289,23 @@ func (handler *Handler) handleTransaction(msg *pb.ChaincodeMessage) {
 		// Call chaincode's Run
 		// Create the ChaincodeStub which the chaincode can use to callback
 		stub := new(ChaincodeStub)
-		stub.init(handler, msg.Txid, msg.SecurityContext)
-		res, err := handler.cc.Invoke(stub)
+		stub.init(handler, msg.Txid, msg.SecurityContext, msg.Proposal, msg.SignedProposal)
+		res, err := invoke(handler.cc, stub)
 
 		// delete isTransaction entry
 		handler.deleteIsTransaction(msg.Txid)
 
 		if err != nil {
 			payload := []byte(err.Error())
-			// Send ERROR message to chaincode support and change state
+			// Send ERROR message to chaincode support
 			chaincodeLogger.Errorf("[%s]Transaction execution failed. Sending %s", shorttxid(msg.Txid), pb.ChaincodeMessage_ERROR)
-			nextStateMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: payload, Txid: msg.Txid, ChaincodeEvent: stub.chaincodeEvent}
+			serialSendMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: payload, Txid: msg.Txid, ChaincodeEvent: stub.chaincodeEvent, Response: errorResponse(err)}
 			return
 		}
 
-		// Send COMPLETED message to chaincode support and change state
+		// Send COMPLETED message to chaincode support
 		chaincodeLogger.Debugf("[%s]Transaction completed. Sending %s", shorttxid(msg.Txid), pb.ChaincodeMessage_COMPLETED)
-		nextStateMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_COMPLETED, Payload: res, Txid: msg.Txid, ChaincodeEvent: stub.chaincodeEvent}
+		serialSendMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_COMPLETED, Payload: res, Txid: msg.Txid, ChaincodeEvent: stub.chaincodeEvent}
 	}()
 }
 
@@ -336,7 +336,7 @@ func (handler *Handler) handleQuery(msg *pb.ChaincodeMessage) {
 		// Call chaincode's Query
 		// Create the ChaincodeStub which the chaincode can use to callback
 		stub := new(ChaincodeStub)
-		stub.init(handler, msg.Txid, msg.SecurityContext)
+		stub.init(handler, msg.Txid, msg.SecurityContext, msg.Proposal, msg.SignedProposal)
 		res, err := handler.cc.Query(stub)
 
 		// delete isTransaction entry
@@ -346,7 +346,7 @@ func (handler *Handler) handleQuery(msg *pb.ChaincodeMessage) {
 			payload := []byte(err.Error())
 			// Send ERROR message to chaincode support and change state
 			chaincodeLogger.Errorf("[%s]Query execution failed. Sending %s", shorttxid(msg.Txid), pb.ChaincodeMessage_QUERY_ERROR)
-			serialSendMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_QUERY_ERROR, Payload: payload, Txid: msg.Txid}
+			serialSendMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_QUERY_ERROR, Payload: payload, Txid: msg.Txid, Response: errorResponse(err)}
 			return
 		}
 
@@ -356,18 +356,15 @@ func (handler *Handler) handleQuery(msg *pb.ChaincodeMessage) {
 	}()
 }
 
-// enterTransactionState will execute chaincode's Run if coming from a TRANSACTION event.
-func (handler *Handler) enterTransactionState(e *fsm.Event) {
+// beforeTransaction will execute chaincode's Run when a transaction message is received from the validator
+func (handler *Handler) beforeTransaction(e *fsm.Event) {
 	msg, ok := e.Args[0].(*pb.ChaincodeMessage)
 	if !ok {
 		e.Cancel(fmt.Errorf("Received unexpected message type"))
 		return
 	}
-	chaincodeLogger.Debugf("[%s]Received %s, invoking transaction on chaincode(Src:%s, Dst:%s)", shorttxid(msg.Txid), msg.Type.String(), e.Src, e.Dst)
-	if msg.Type.String() == pb.ChaincodeMessage_TRANSACTION.String() {
-		// Call the chaincode's Run function to invoke transaction
-		handler.handleTransaction(msg)
-	}
+	chaincodeLogger.Debugf("[%s]Received %s, invoking transaction on chaincode", shorttxid(msg.Txid), msg.Type.String())
+	handler.handleTransaction(msg)
 }
 
 // enterReadyState will need to handle COMPLETED event by sending message to the peer
@@ -474,11 +471,122 @@ func (handler *Handler) handleGetState(key string, txid string) ([]byte, error)
 	return nil, errors.New("Incorrect chaincode message received")
 }
 
+// handleGetLedgerHeight communicates with the validator to fetch the height of the ledger the transaction
+// is simulated on.
+func (handler *Handler) handleGetLedgerHeight(txid string) (uint64, error) {
+	// Create the channel on which to communicate the response from validating peer
+	respChan, uniqueReqErr := handler.createChannel(txid)
+	if uniqueReqErr != nil {
+		chaincodeLogger.Debug("Another state request pending for this Txid. Cannot process.")
+		return 0, uniqueReqErr
+	}
+
+	defer handler.deleteChannel(txid)
+
+	// Send GET_LEDGER_HEIGHT message to validator chaincode support
+	msg := &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_GET_LEDGER_HEIGHT, Txid: txid}
+	chaincodeLogger.Debugf("[%s]Sending %s", shorttxid(msg.Txid), pb.ChaincodeMessage_GET_LEDGER_HEIGHT)
+	if err := handler.serialSend(msg); err != nil {
+		chaincodeLogger.Errorf("[%s]error sending GET_LEDGER_HEIGHT %s", shorttxid(txid), err)
+		return 0, errors.New("could not send msg")
+	}
+
+	// Wait on responseChannel for response
+	responseMsg, ok := handler.receiveChannel(respChan)
+	if !ok {
+		chaincodeLogger.Errorf("[%s]Received unexpected message type", shorttxid(responseMsg.Txid))
+		return 0, errors.New("Received unexpected message type")
+	}
+
+	if responseMsg.Type.String() == pb.ChaincodeMessage_RESPONSE.String() {
+		// Success response
+		chaincodeLogger.Debugf("[%s]GetLedgerHeight received payload %s", shorttxid(responseMsg.Txid), pb.ChaincodeMessage_RESPONSE)
+		response := &pb.LedgerHeight{}
+		if err := proto.Unmarshal(responseMsg.Payload, response); err != nil {
+			chaincodeLogger.Errorf("[%s]unmarshall error", shorttxid(responseMsg.Txid))
+			return 0, errors.New("Error unmarshalling LedgerHeight.")
+		}
+		return response.Height, nil
+	}
+	if responseMsg.Type.String() == pb.ChaincodeMessage_ERROR.String() {
+		// Error response
+		chaincodeLogger.Errorf("[%s]GetLedgerHeight received error %s", shorttxid(responseMsg.Txid), pb.ChaincodeMessage_ERROR)
+		return 0, errors.New(string(responseMsg.Payload[:]))
+	}
+
+	// Incorrect chaincode message received
+	chaincodeLogger.Errorf("[%s]Incorrect chaincode message %s received. Expecting %s or %s", shorttxid(responseMsg.Txid), responseMsg.Type, pb.ChaincodeMessage_RESPONSE, pb.ChaincodeMessage_ERROR)
+	return 0, errors.New("Incorrect chaincode message received")
+}
+
+// handleGetStateMultiple communicates with the validator to fetch the state of multiple keys from the ledger
+// with a single message.
+func (handler *Handler) handleGetStateMultiple(keys []string, txid string) ([][]byte, error) {
+	payload := &pb.GetStateMultiple{Keys: keys}
+	payloadBytes, err := proto.Marshal(payload)
+	if err != nil {
+		return nil, errors.New("Failed to process get state request")
+	}
+
+	// Create the channel on which to communicate the response from validating peer
+	respChan, uniqueReqErr := handler.createChannel(txid)
+	if uniqueReqErr != nil {
+		chaincodeLogger.Debug("Another state request pending for this Txid. Cannot process.")
+		return nil, uniqueReqErr
+	}
+
+	defer handler.deleteChannel(txid)
+
+	// Send GET_STATE_MULTIPLE message to validator chaincode support
+	msg := &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_GET_STATE_MULTIPLE, Payload: payloadBytes, Txid: txid}
+	chaincodeLogger.Debugf("[%s]Sending %s", shorttxid(msg.Txid), pb.ChaincodeMessage_GET_STATE_MULTIPLE)
+	if err = handler.serialSend(msg); err != nil {
+		chaincodeLogger.Errorf("[%s]error sending GET_STATE_MULTIPLE %s", shorttxid(txid), err)
+		return nil, errors.New("could not send msg")
+	}
+
+	// Wait on responseChannel for response
+	responseMsg, ok := handler.receiveChannel(respChan)
+	if !ok {
+		chaincodeLogger.Errorf("[%s]Received unexpected message type", shorttxid(responseMsg.Txid))
+		return nil, errors.New("Received unexpected message type")
+	}
+
+	if responseMsg.Type.String() == pb.ChaincodeMessage_RESPONSE.String() {
+		// Success response
+		chaincodeLogger.Debugf("[%s]GetStateMultiple received payload %s", shorttxid(responseMsg.Txid), pb.ChaincodeMessage_RESPONSE)
+		response := &pb.GetStateMultipleResponse{}
+		if err = proto.Unmarshal(responseMsg.Payload, response); err != nil {
+			chaincodeLogger.Errorf("[%s]unmarshall error", shorttxid(responseMsg.Txid))
+			return nil, errors.New("Error unmarshalling GetStateMultipleResponse.")
+		}
+		if len(response.Values) != len(keys) {
+			return nil, fmt.Errorf("Received %d values for %d keys", len(response.Values), len(keys))
+		}
+		// an empty value means that the key does not exist, as with GetState
+		for i, value := range response.Values {
+			if len(value) == 0 {
+				response.Values[i] = nil
+			}
+		}
+		return response.Values, nil
+	}
+	if responseMsg.Type.String() == pb.ChaincodeMessage_ERROR.String() {
+		// Error response
+		chaincodeLogger.Errorf("[%s]GetStateMultiple received error %s", shorttxid(responseMsg.Txid), pb.ChaincodeMessage_ERROR)
+		return nil, errors.New(string(responseMsg.Payload[:]))
+	}
+
+	// Incorrect chaincode message received
+	chaincodeLogger.Errorf("[%s]Incorrect chaincode message %s received. Expecting %s or %s", shorttxid(responseMsg.Txid), responseMsg.Type, pb.ChaincodeMessage_RESPONSE, pb.ChaincodeMessage_ERROR)
+	return nil, errors.New("Incorrect chaincode message received")
+}
+
 // handlePutState communicates with the validator to put state information into the ledger.
 func (handler *Handler) handlePutState(key string, value []byte, txid string) error {
 	// Check if this is a transaction
-	chaincodeLogger.Debugf("[%s]Inside putstate, isTransaction = %t", shorttxid(txid), handler.isTransaction[txid])
-	if !handler.isTransaction[txid] {
+	chaincodeLogger.Debugf("[%s]Inside putstate, isTransaction = %t", shorttxid(txid), handler.getIsTransaction(txid))
+	if !handler.getIsTransaction(txid) {
 		return errors.New("Cannot put state in query context")
 	}
 
@@ -532,7 +640,7 @@ func (handler *Handler) handlePutState(key string, value []byte, txid string) er
 // handleDelState communicates with the validator to delete a key from the state in the ledger.
 func (handler *Handler) handleDelState(key string, txid string) error {
 	// Check if this is a transaction
-	if !handler.isTransaction[txid] {
+	if !handler.getIsTransaction(txid) {
 		return errors.New("Cannot del state in query context")
 	}
 
@@ -739,10 +847,99 @@ func (handler *Handler) handleRangeQueryStateClose(id, txid string) (*pb.RangeQu
 	return nil, errors.New("Incorrect chaincode message received")
 }
 
+// handleRangeQueryStateWithPagination communicates with the validator to fetch a single page of a range query.
+func (handler *Handler) handleRangeQueryStateWithPagination(startKey, endKey string, pageSize int32, bookmark string, txid string) (*pb.RangeQueryStateResponse, error) {
+	// Paginated queries are not recorded in the read set
+	if handler.getIsTransaction(txid) {
+		return nil, errors.New("Paginated queries are not allowed in transaction context")
+	}
+
+	payload := &pb.RangeQueryState{StartKey: startKey, EndKey: endKey, PageSize: pageSize, Bookmark: bookmark}
+	payloadBytes, err := proto.Marshal(payload)
+	if err != nil {
+		return nil, errors.New("Failed to process range query state request")
+	}
+	return handler.handleQueryRequest(pb.ChaincodeMessage_RANGE_QUERY_STATE, payloadBytes, txid)
+}
+
+// handleGetQueryResult communicates with the validator to execute a rich query.
+func (handler *Handler) handleGetQueryResult(query string, txid string) (*pb.RangeQueryStateResponse, error) {
+	payload := &pb.GetQueryResult{Query: query}
+	payloadBytes, err := proto.Marshal(payload)
+	if err != nil {
+		return nil, errors.New("Failed to process get query result request")
+	}
+	return handler.handleQueryRequest(pb.ChaincodeMessage_GET_QUERY_RESULT, payloadBytes, txid)
+}
+
+// handleGetQueryResultWithPagination communicates with the validator to fetch a single page of a rich query.
+func (handler *Handler) handleGetQueryResultWithPagination(query string, pageSize int32, bookmark string, txid string) (*pb.RangeQueryStateResponse, error) {
+	// Paginated queries are not recorded in the read set
+	if handler.getIsTransaction(txid) {
+		return nil, errors.New("Paginated queries are not allowed in transaction context")
+	}
+
+	payload := &pb.GetQueryResult{Query: query, PageSize: pageSize, Bookmark: bookmark}
+	payloadBytes, err := proto.Marshal(payload)
+	if err != nil {
+		return nil, errors.New("Failed to process get query result request")
+	}
+	return handler.handleQueryRequest(pb.ChaincodeMessage_GET_QUERY_RESULT, payloadBytes, txid)
+}
+
+// handleQueryRequest sends a query request to the validator and waits for the first batch of results.
+func (handler *Handler) handleQueryRequest(msgType pb.ChaincodeMessage_Type, payloadBytes []byte, txid string) (*pb.RangeQueryStateResponse, error) {
+	// Create the channel on which to communicate the response from validating peer
+	respChan, uniqueReqErr := handler.createChannel(txid)
+	if uniqueReqErr != nil {
+		chaincodeLogger.Debugf("[%s]Another state request pending for this Txid. Cannot process.", shorttxid(txid))
+		return nil, uniqueReqErr
+	}
+
+	defer handler.deleteChannel(txid)
+
+	msg := &pb.ChaincodeMessage{Type: msgType, Payload: payloadBytes, Txid: txid}
+	chaincodeLogger.Debugf("[%s]Sending %s", shorttxid(msg.Txid), msgType)
+	if err := handler.serialSend(msg); err != nil {
+		chaincodeLogger.Errorf("[%s]error sending %s", shorttxid(msg.Txid), msgType)
+		return nil, errors.New("could not send msg")
+	}
+
+	// Wait on responseChannel for response
+	responseMsg, ok := handler.receiveChannel(respChan)
+	if !ok {
+		chaincodeLogger.Errorf("[%s]Received unexpected message type", txid)
+		return nil, errors.New("Received unexpected message type")
+	}
+
+	if responseMsg.Type.String() == pb.ChaincodeMessage_RESPONSE.String() {
+		// Success response
+		chaincodeLogger.Debugf("[%s]Received %s. Successfully got results", shorttxid(responseMsg.Txid), pb.ChaincodeMessage_RESPONSE)
+
+		queryResponse := &pb.RangeQueryStateResponse{}
+		unmarshalErr := proto.Unmarshal(responseMsg.Payload, queryResponse)
+		if unmarshalErr != nil {
+			chaincodeLogger.Errorf("[%s]unmarshall error", shorttxid(responseMsg.Txid))
+			return nil, errors.New("Error unmarshalling RangeQueryStateResponse.")
+		}
+
+		return queryResponse, nil
+	}
+	if responseMsg.Type.String() == pb.ChaincodeMessage_ERROR.String() {
+		// Error response
+		chaincodeLogger.Errorf("[%s]Received %s", shorttxid(responseMsg.Txid), pb.ChaincodeMessage_ERROR)
+		return nil, errors.New(string(responseMsg.Payload[:]))
+	}
+
+	// Incorrect chaincode message received
+	chaincodeLogger.Errorf("Incorrect chaincode message %s recieved. Expecting %s or %s", responseMsg.Type, pb.ChaincodeMessage_RESPONSE, pb.ChaincodeMessage_ERROR)
+	return nil, errors.New("Incorrect chaincode message received")
+}
+
 // handleInvokeChaincode communicates with the validator to invoke another chaincode.
 func (handler *Handler) handleInvokeChaincode(chaincodeName string, args [][]byte, txid string) ([]byte, error) {
 	// Check if this is a transaction
-	if !handler.isTransaction[txid] {
+	if !handler.getIsTransaction(txid) {
 		return nil, errors.New("Cannot invoke chaincode in query context")
 	}
 
@@ -867,9 +1064,14 @@ func (handler *Handler) handleQueryChaincode(chaincodeName string, args [][]byte
 // handleMessage message handles loop for shim side of chaincode/validator stream.
 func (handler *Handler) handleMessage(msg *pb.ChaincodeMessage) error {
 	if msg.Type == pb.ChaincodeMessage_KEEPALIVE {
-		// Received a keep alive message, we don't do anything with it for now
-		// and it does not touch the state machine
-		return nil
+		// Received a keep alive message, answer it so the peer knows we are
+		// alive. It does not touch the state machine
+		return handler.serialSend(msg)
+	}
+	if msg.Type == pb.ChaincodeMessage_SET_LOG_LEVEL {
+		// The log level is changed outside of any transaction, it does not
+		// touch the state machine either
+		return handleSetLogLevel(msg)
 	}
 	chaincodeLogger.Debugf("[%s]Handling ChaincodeMessage of type: %s(state:%s)", shorttxid(msg.Txid), msg.Type, handler.FSM.Current())
 	if handler.FSM.Cannot(msg.Type.String()) {
@@ -884,6 +1086,32 @@ func (handler *Handler) handleMessage(msg *pb.ChaincodeMessage) error {
 	return filterError(err)
 }
 
+// handleSetLogLevel changes the logging level of a module of the chaincode as
+// requested by the peer, the level of the shim and the default level of the
+// chaincode loggers if no module is given.
+func handleSetLogLevel(msg *pb.ChaincodeMessage) error {
+	req := &pb.SetLogLevel{}
+	if err := proto.Unmarshal(msg.Payload, req); err != nil {
+		chaincodeLogger.Errorf("Incorrect payload format of %s: %s", msg.Type, err)
+		return nil
+	}
+	level, err := LogLevel(req.Level)
+	if err != nil {
+		chaincodeLogger.Errorf("Invalid log level %s: %s", req.Level, err)
+		return nil
+	}
+	if req.Module == "" {
+		logging.SetLevel(logging.Level(level), "")
+		SetLoggingLevel(level)
+	} else if req.Module == "shim" {
+		SetLoggingLevel(level)
+	} else {
+		logging.SetLevel(logging.Level(level), req.Module)
+	}
+	chaincodeLogger.Infof("Log level of module [%s] set to %s", req.Module, req.Level)
+	return nil
+}
+
 // filterError filters the errors to allow NoTransitionError and CanceledError to not propagate for cases where embedded Err == nil.
 func filterError(errFromFSMEvent error) error {
 	if errFromFSMEvent != nil {
@@ -18,8 +18,11 @@ limitations under the License.
 package shim
 
 import (
+	"math/rand"
+
 	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/hyperledger/fabric/core/chaincode/shim/crypto/attr"
+	pb "github.com/hyperledger/fabric/protos"
 )
 
 // Chaincode interface must be implemented by all chaincodes. The fabric runs
@@ -38,6 +41,13 @@ type Chaincode interface {
 	Query(stub ChaincodeStubInterface) ([]byte, error)
 }
 
+// Migrator may be implemented by a chaincode to migrate its state when it is
+// upgraded. The peer calls Migrate of the new version in the upgrade
+// transaction, after Init, with the versions upgraded from and to
+type Migrator interface {
+	Migrate(stub ChaincodeStubInterface, fromVersion string, toVersion string) ([]byte, error)
+}
+
 // ChaincodeStubInterface is used by deployable chaincode apps to access and modify their ledgers
 type ChaincodeStubInterface interface {
 	// Get the arguments to the stub call as a 2D byte array
@@ -53,19 +63,60 @@ type ChaincodeStubInterface interface {
 	// Get the transaction ID
 	GetTxID() string
 
+	// GetTransient returns the transient data of the proposal (e.g. keys or
+	// other secrets supplied by the client). It is available to the chaincode
+	// during the simulation, but is never written to the ledger
+	GetTransient() (map[string][]byte, error)
+
+	// GetCreator returns the serialized identity (e.g. the certificate) of the
+	// creator of the proposal, the client which submitted the transaction. It
+	// lets the chaincode control access based on who invoked it
+	GetCreator() ([]byte, error)
+
+	// GetSignedProposal returns the signed proposal being executed, with its
+	// header, payload and signature, so that the chaincode can verify, bind
+	// or log the exact request it is acting on. ErrProposalNotSigned is
+	// returned when the peer sent the proposal without its signature
+	GetSignedProposal() (*pb.SignedProposal, error)
+
+	// GetDecorations returns the decorations added to the input of the
+	// chaincode by the decorators of the endorsing peer, e.g. a classification
+	// of the client or metadata of the deployment
+	GetDecorations() map[string][]byte
+
 	// InvokeChaincode locally calls the specified chaincode `Invoke` using the
 	// same transaction context; that is, chaincode calling chaincode doesn't
-	// create a new transaction message.
-	InvokeChaincode(chaincodeName string, args [][]byte) ([]byte, error)
+	// create a new transaction message. If channel is empty or the channel of
+	// the caller, the read-write set of the called chaincode is merged with the
+	// caller's. Otherwise the chaincode of the other channel is called read only:
+	// it can read the state of its channel, but cannot write it, and the call
+	// contributes nothing to the caller's transaction.
+	InvokeChaincode(chaincodeName string, args [][]byte, channel string) ([]byte, error)
 
 	// QueryChaincode locally calls the specified chaincode `Query` using the
 	// same transaction context; that is, chaincode calling chaincode doesn't
 	// create a new transaction message.
 	QueryChaincode(chaincodeName string, args [][]byte) ([]byte, error)
 
+	// GetChannelConfig returns the configuration values of the channel, its
+	// organizations and the names of its access control policies, as read by
+	// QSCC. An empty channel is the channel of the proposal being executed
+	GetChannelConfig(channel string) (*pb.ChannelConfig, error)
+
 	// GetState returns the byte array value specified by the `key`.
 	GetState(key string) ([]byte, error)
 
+	// GetStateMultipleKeys returns the values of the specified `keys` in a
+	// single round trip to the peer. The values are returned in the order of
+	// the keys, with a nil value for a key that does not exist.
+	GetStateMultipleKeys(keys []string) ([][]byte, error)
+
+	// GetLedgerHeight returns the height of the ledger the transaction is
+	// simulated on. The height is recorded in the read set, so that the
+	// transaction is invalidated if a block is committed before it, which
+	// makes it safe to measure time (e.g. vesting or expiry) in blocks.
+	GetLedgerHeight() (uint64, error)
+
 	// PutState writes the specified `value` and `key` into the ledger.
 	PutState(key string, value []byte) error
 
@@ -79,6 +130,54 @@ type ChaincodeStubInterface interface {
 	// returned by the iterator is random.
 	RangeQueryState(startKey, endKey string) (StateRangeQueryIteratorInterface, error)
 
+	// GetStateByRangeWithPagination returns a single page of at most pageSize
+	// keys between the startKey and endKey, inclusive, starting from the given
+	// bookmark. An empty bookmark starts from the startKey. The returned
+	// metadata contains the number of fetched records and the bookmark to pass
+	// in for the next page; an empty bookmark indicates there are no more keys.
+	// A pageSize of 0 selects the default page size of the peer, and a
+	// pageSize above the maximum page size of the peer is rejected.
+	// Paginated queries are not recorded in the read set and therefore can
+	// only be invoked in query context.
+	GetStateByRangeWithPagination(startKey, endKey string, pageSize int32,
+		bookmark string) (StateRangeQueryIteratorInterface, *pb.QueryResponseMetadata, error)
+
+	// GetQueryResult performs a rich query against the state, for state
+	// databases that support it, and returns an iterator over the results. The
+	// query string is in the native syntax of the state database, e.g. a
+	// CouchDB query with a "selector". The results are not recorded in the read
+	// set and are not re-validated at commit time, so a transaction acting on
+	// them is exposed to phantom reads. Use it in query context, or only for
+	// data that is otherwise protected against concurrent updates.
+	GetQueryResult(query string) (StateRangeQueryIteratorInterface, error)
+
+	// GetQueryResultWithPagination performs a rich query against the state,
+	// for state databases that support it, and returns a single page of at
+	// most pageSize results starting from the given bookmark. The query string
+	// is in the native syntax of the state database. As with
+	// GetStateByRangeWithPagination, the page size is subject to the default
+	// and maximum page sizes of the peer, and it can only be invoked in query
+	// context.
+	GetQueryResultWithPagination(query string, pageSize int32,
+		bookmark string) (StateRangeQueryIteratorInterface, *pb.QueryResponseMetadata, error)
+
+	// CreateCompositeKey combines the given objectType and attributes into a
+	// single key that can be used as the key in PutState and GetState. The
+	// objectType and attributes must be valid utf8 strings and must not
+	// contain U+0000 or U+10FFFF. Composite keys are prefixed with U+0000 to
+	// keep them apart from simple keys.
+	CreateCompositeKey(objectType string, attributes []string) (string, error)
+
+	// SplitCompositeKey splits a key created by CreateCompositeKey back into
+	// the objectType and attributes it was formed from.
+	SplitCompositeKey(compositeKey string) (string, []string, error)
+
+	// GetStateByPartialCompositeKey queries the state for the composite keys
+	// that match the given objectType and leading attributes. For example,
+	// with keys of objectType "owner~asset" formed from [owner, asset], the
+	// assets of one owner can be fetched by passing only [owner].
+	GetStateByPartialCompositeKey(objectType string, attributes []string) (StateRangeQueryIteratorInterface, error)
+
 	// CreateTable creates a new table given the table name and column definitions
 	CreateTable(name string, columnDefinitions []*ColumnDefinition) error
 
@@ -148,18 +247,27 @@ type ChaincodeStubInterface interface {
 	// GetCallerMetadata returns caller metadata
 	GetCallerMetadata() ([]byte, error)
 
-	// GetBinding returns the transaction binding
+	// GetBinding returns the transaction binding, the hash over the nonce,
+	// the creator and the epoch of the proposal. Application data signed over
+	// it is bound to the transaction and cannot be replayed in another one
 	GetBinding() ([]byte, error)
 
 	// GetPayload returns transaction payload, which is a `ChaincodeSpec` defined
 	// in fabric/protos/chaincode.proto
 	GetPayload() ([]byte, error)
 
-	// GetTxTimestamp returns transaction created timestamp, which is currently
-	// taken from the peer receiving the transaction. Note that this timestamp
-	// may not be the same with the other peers' time.
+	// GetTxTimestamp returns the timestamp asserted by the client in the header
+	// of the proposal, which is the same for all the endorsers of the
+	// transaction. Chaincodes should use it instead of the local time
 	GetTxTimestamp() (*timestamp.Timestamp, error)
 
+	// GetRandom returns a pseudo-random generator seeded with the nonce of the
+	// proposal and the transaction ID, so that all the endorsers draw the same
+	// values. It is created on the first call and shared by the following
+	// calls of the transaction. The values are predictable by the client and
+	// must not be used where secrecy or fairness matters
+	GetRandom() (*rand.Rand, error)
+
 	// SetEvent saves the event to be sent when a transaction is made part of a block
 	SetEvent(name string, payload []byte) error
 }
@@ -169,10 +277,12 @@ type ChaincodeStubInterface interface {
 type StateRangeQueryIteratorInterface interface {
 
 	// HasNext returns true if the range query iterator contains additional keys
-	// and values.
+	// and values. It may fetch the next batch of results from the peer, and
+	// returns true when that fails so that Next reports the error.
 	HasNext() bool
 
-	// Next returns the next key and value in the range query iterator.
+	// Next returns the next key and value in the range query iterator, or the
+	// error fetching them.
 	Next() (string, []byte, error)
 
 	// Close closes the range query iterator. This should be called when done
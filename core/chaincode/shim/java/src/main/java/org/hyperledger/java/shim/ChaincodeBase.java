@@ -49,6 +49,12 @@ public abstract class ChaincodeBase {
 	public abstract String query(ChaincodeStub stub, String function, String[] args);
 	public abstract String getChaincodeID();
 
+	/**
+	 * The function the peer invokes on an upgraded chaincode, with the versions
+	 * upgraded from and to as arguments
+	 */
+	public static final String MIGRATE_FUNCTION = "Migrate";
+
 	public static final String DEFAULT_HOST = "127.0.0.1";
 	public static final int DEFAULT_PORT = 7051;
 
@@ -205,6 +211,14 @@ public abstract class ChaincodeBase {
 		return null;
 	}
 
+	/**
+	 * Migrates the state of the chaincode when it is upgraded. Chaincodes which
+	 * need to migrate their state override it, by default nothing is done
+	 */
+	public String migrate(ChaincodeStub stub, String fromVersion, String toVersion) {
+		return null;
+	}
+
 	protected ByteString runHelper(ChaincodeStub stub, String function, String[] args) {
 		ByteString ret = runRaw(stub, function, args);
 		if (ret == null) {
@@ -214,6 +228,15 @@ public abstract class ChaincodeBase {
 		return ret;
 	}
 
+	protected ByteString migrateHelper(ChaincodeStub stub, String[] args) {
+		if (args.length != 2) {
+			throw new IllegalArgumentException(String.format("%s expects the versions upgraded from and to, got %d arguments",
+					MIGRATE_FUNCTION, args.length));
+		}
+		String tmp = migrate(stub, args[0], args[1]);
+		return ByteString.copyFromUtf8(tmp == null ? "" : tmp);
+	}
+
 	protected ByteString queryHelper(ChaincodeStub stub, String function, String[] args) {
 		ByteString ret = queryRaw(stub, function, args);
 		if (ret == null) {
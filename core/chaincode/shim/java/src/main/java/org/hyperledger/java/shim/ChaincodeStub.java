@@ -1,456 +1,825 @@
-/*
-Copyright DTCC 2016 All Rights Reserved.
-
-Licensed under the Apache License, Version 2.0 (the "License");
-you may not use this file except in compliance with the License.
-You may obtain a copy of the License at
-
-         http://www.apache.org/licenses/LICENSE-2.0
-
-Unless required by applicable law or agreed to in writing, software
-distributed under the License is distributed on an "AS IS" BASIS,
-WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
-See the License for the specific language governing permissions and
-limitations under the License.
-*/
-
-package org.hyperledger.java.shim;
-
-import com.google.protobuf.ByteString;
-import com.google.protobuf.InvalidProtocolBufferException;
-import org.apache.commons.logging.Log;
-import org.apache.commons.logging.LogFactory;
-import org.hyperledger.protos.Chaincode;
-import org.hyperledger.protos.TableProto;
-
-import java.util.ArrayList;
-import java.util.HashMap;
-import java.util.List;
-import java.util.Map;
-
-import static org.hyperledger.protos.TableProto.ColumnDefinition.Type.STRING;
-
-public class ChaincodeStub {
-    private static Log logger = LogFactory.getLog(ChaincodeStub.class);
-    private final String uuid;
-    private final Handler handler;
-
-    public ChaincodeStub(String uuid, Handler handler) {
-        this.uuid = uuid;
-        this.handler = handler;
-    }
-
-    /**
-     * Gets the UUID of this stub
-     *
-     * @return the id used to identify this communication channel
-     */
-    public String getUuid() {
-        return uuid;
-    }
-
-    /**
-     * Get the state of the provided key from the ledger, and returns is as a string
-     *
-     * @param key the key of the desired state
-     * @return the String value of the requested state
-     */
-    public String getState(String key) {
-        return handler.handleGetState(key, uuid).toStringUtf8();
-    }
-
-    /**
-     * Puts the given state into a ledger, automatically wrapping it in a ByteString
-     *
-     * @param key   reference key
-     * @param value value to be put
-     */
-    public void putState(String key, String value) {
-        handler.handlePutState(key, ByteString.copyFromUtf8(value), uuid);
-    }
-
-    /**
-     * Deletes the state of the given key from the ledger
-     *
-     * @param key key of the state to be deleted
-     */
-    public void delState(String key) {
-        handler.handleDeleteState(key, uuid);
-    }
-
-    /**
-     * Given a start key and end key, this method returns a map of items with value converted to UTF-8 string.
-     *
-     * @param startKey
-     * @param endKey
-     * @return
-     */
-    public Map<String, String> rangeQueryState(String startKey, String endKey) {
-        Map<String, String> retMap = new HashMap<>();
-        for (Map.Entry<String, ByteString> item : rangeQueryRawState(startKey, endKey).entrySet()) {
-            retMap.put(item.getKey(), item.getValue().toStringUtf8());
-        }
-        return retMap;
-    }
-
-    /**
-     * This method is same as rangeQueryState, except it returns value in ByteString, useful in cases where
-     * serialized object can be retrieved.
-     *
-     * @param startKey
-     * @param endKey
-     * @return
-     */
-    public Map<String, ByteString> rangeQueryRawState(String startKey, String endKey) {
-        Map<String, ByteString> map = new HashMap<>();
-        for (Chaincode.RangeQueryStateKeyValue mapping : handler.handleRangeQueryState(
-                startKey, endKey, uuid).getKeysAndValuesList()) {
-            map.put(mapping.getKey(), mapping.getValue());
-        }
-        return map;
-    }
-
-    /**
-     * @param chaincodeName
-     * @param function
-     * @param args
-     * @return
-     */
-    public String invokeChaincode(String chaincodeName, String function, List<ByteString> args) {
-        return handler.handleInvokeChaincode(chaincodeName, function, args, uuid).toStringUtf8();
-    }
-
-    /**
-     * @param chaincodeName
-     * @param function
-     * @param args
-     * @return
-     */
-    public String queryChaincode(String chaincodeName, String function, List<ByteString> args) {
-        return handler.handleQueryChaincode(chaincodeName, function, args, uuid).toStringUtf8();
-    }
-
-    //------RAW CALLS------
-
-    /**
-     * @param key
-     * @return
-     */
-    public ByteString getRawState(String key) {
-        return handler.handleGetState(key, uuid);
-    }
-
-    /**
-     * @param key
-     * @param value
-     */
-    public void putRawState(String key, ByteString value) {
-        handler.handlePutState(key, value, uuid);
-    }
-
-    /**
-     *
-     * @param startKey
-     * @param endKey
-     * @param limit
-     * @return
-     */
-//	public RangeQueryStateResponse rangeQueryRawState(String startKey, String endKey, int limit) {
-//		return handler.handleRangeQueryState(startKey, endKey, limit, uuid);
-//	}
-
-    /**
-     * @param chaincodeName
-     * @param function
-     * @param args
-     * @return
-     */
-    public ByteString queryRawChaincode(String chaincodeName, String function, List<ByteString> args) {
-        return handler.handleQueryChaincode(chaincodeName, function, args, uuid);
-    }
-
-    /**
-     * Invokes the provided chaincode with the given function and arguments, and returns the
-     * raw ByteString value that invocation generated.
-     *
-     * @param chaincodeName The name of the chaincode to invoke
-     * @param function      the function parameter to pass to the chaincode
-     * @param args          the arguments to be provided in the chaincode call
-     * @return the value returned by the chaincode call
-     */
-    public ByteString invokeRawChaincode(String chaincodeName, String function, List<ByteString> args) {
-        return handler.handleInvokeChaincode(chaincodeName, function, args, uuid);
-    }
-
-    public boolean createTable(String tableName, List<TableProto.ColumnDefinition> columnDefinitions)
-            throws Exception    {
-        if (validateTableName(tableName)) {
-            logger.debug("Table name %s is valid, continue table creation");
-
-            if (tableExist(tableName)) {
-                logger.error("Table with tableName already exist, Create table operation failed");
-                return false;//table exist
-            } else {
-                if (columnDefinitions != null && columnDefinitions.size() == 0) {
-                    logger.error("Invalid column definitions. Table must contain at least one column");
-                    return false;
-                }
-                Map<String, Boolean> nameMap = new HashMap<>();
-                int idx = 0;
-                boolean hasKey = false;
-                logger.debug("Number of columns " + columnDefinitions.size());
-                for (TableProto.ColumnDefinition colDef : columnDefinitions) {
-                    logger.debug("Col information - " + colDef.getName()+ "=" + colDef.getType()+ "=" +colDef.isInitialized());
-
-                    if (!colDef.isInitialized() || colDef.getName().length() == 0) {
-                        logger.error("Column definition is invalid for index " + idx);
-                    return false;
-                    }
-
-                    if (!nameMap.isEmpty() && nameMap.containsKey(colDef.getName())){
-                        logger.error("Column already exist for colIdx " + idx + " with name " + colDef.getName());
-                        return false;
-                    }
-                    nameMap.put(colDef.getName(), true);
-                    switch (colDef.getType()) {
-                        case STRING:
-                            break;
-                        case INT32:
-                            break;
-                        case INT64:
-                            break;
-                        case UINT32:
-                            break;
-                        case UINT64:
-                            break;
-                        case BYTES:
-                            break;
-                        case BOOL:
-                            break;
-                        default:
-                            logger.error("Invalid column type for index " + idx + " given type " + colDef.getType());
-//                            return false;
-
-                    }
-
-                    if (colDef.getKey()) hasKey = true;
-
-                    idx++;
-                }
-                if (!hasKey) {
-                    logger.error("Invalid table. One or more columns must be a key.");
-                    return false;
-                }
-                TableProto.Table table = TableProto.Table.newBuilder()
-                        .setName(tableName)
-                        .addAllColumnDefinitions(columnDefinitions)
-                        .build();
-                String tableNameKey = getTableNameKey(tableName);
-                putRawState(tableNameKey, table.toByteString());
-                return true;
-            }
-        }
-        return false;
-    }
-    public boolean deleteTable(String tableName) {
-        String tableNameKey = getTableNameKey(tableName);
-        rangeQueryState(tableNameKey + "1", tableNameKey + ":")
-                .keySet().forEach(key -> delState(key));
-        delState(tableNameKey);
-        return true;
-    }
-    public boolean insertRow(String tableName, TableProto.Row row) throws Exception {
-        try {
-            return insertRowInternal(tableName, row, false);
-         } catch (Exception e) {
-            logger.error("Error while inserting row on table - " + tableName);
-            logger.error(e.getMessage());
-
-            throw e;
-        }
-    }
-
-    public boolean replaceRow(String tableName, TableProto.Row row) throws Exception {
-        try {
-            return insertRowInternal(tableName, row, true);
-        } catch (Exception e) {
-            logger.error("Error while updating row on table - " + tableName);
-            logger.error(e.getMessage());
-
-            throw e;
-        }
-    }
-    private List<TableProto.Column> getKeyAndVerifyRow(TableProto.Table table, TableProto.Row row) throws Exception {
-        List keys  = new ArrayList();
-        //logger.debug("Entering getKeyAndVerifyRow with tableName -" + table.getName() );
-        //logger.debug("Entering getKeyAndVerifyRow with rowcount -" + row.getColumnsCount() );
-        if ( !row.isInitialized() || row.getColumnsCount() != table.getColumnDefinitionsCount()){
-            logger.error("Table " + table.getName() + " define "
-            + table.getColumnDefinitionsCount() + " columns but row has "
-            + row.getColumnsCount() + " columns");
-            return keys;
-        }
-        int colIdx = 0;
-        for (TableProto.Column col: row.getColumnsList()) {
-            boolean expectedType;
-            switch (col.getValueCase()){
-                case STRING:
-                    expectedType = table.getColumnDefinitions(colIdx).getType()
-                            == STRING;
-                    break;
-                case INT32:
-                    expectedType = table.getColumnDefinitions(colIdx).getType()
-                            == TableProto.ColumnDefinition.Type.INT32;
-                    break;
-                case INT64:
-                    expectedType = table.getColumnDefinitions(colIdx).getType()
-                            == TableProto.ColumnDefinition.Type.INT64;
-                    break;
-                case UINT32:
-                    expectedType = table.getColumnDefinitions(colIdx).getType()
-                            == TableProto.ColumnDefinition.Type.UINT32;
-                    break;
-                case UINT64:
-                    expectedType = table.getColumnDefinitions(colIdx).getType()
-                            == TableProto.ColumnDefinition.Type.UINT64;
-                    break;
-                case BYTES:
-                    expectedType = table.getColumnDefinitions(colIdx).getType()
-                            == TableProto.ColumnDefinition.Type.BYTES;
-                    break;
-                case BOOL:
-                    expectedType = table.getColumnDefinitions(colIdx).getType()
-                            == TableProto.ColumnDefinition.Type.BOOL;
-                    break;
-                default:
-                    expectedType = false;
-            }
-            if (!expectedType){
-                logger.error("The type for table " + table.getName()
-                        + " column " + table.getColumnDefinitions(colIdx).getName() + " is "
-                        + table.getColumnDefinitions(colIdx).getType() +  " but the column in the row does not match" );
-                throw new Exception();
-            }
-            if (table.getColumnDefinitions(colIdx).getKey()){
-                keys.add(col);
-            }
-
-        colIdx++;
-        }
-        return keys;
-    }
-    private boolean isRowPresent(String tableName, List<TableProto.Column> keys){
-        String keyString = buildKeyString(tableName, keys);
-        ByteString rowBytes =   getRawState(keyString);
-        return  !rowBytes.isEmpty();
-    }
-
-    private String buildKeyString(String tableName, List<TableProto.Column> keys){
-
-        StringBuffer sb = new StringBuffer();
-        String tableNameKey = getTableNameKey(tableName);
-
-        sb.append(tableNameKey);
-        String keyString="";
-        for (TableProto.Column col: keys) {
-
-            switch (col.getValueCase()){
-                case STRING:
-                    keyString = col.getString();
-                    break;
-                case INT32:
-                    keyString = ""+col.getInt32();
-                    break;
-                case INT64:
-                    keyString = ""+col.getInt64();
-                    break;
-                case UINT32:
-                    keyString = ""+col.getUint32();
-                    break;
-                case UINT64:
-                    keyString = ""+col.getUint64();
-                    break;
-                case BYTES:
-                    keyString = col.getBytes().toString();
-                    break;
-                case BOOL:
-                    keyString = ""+col.getBool();
-                    break;
-            }
-
-            sb.append(keyString.length());
-            sb.append(keyString);
-
-        }
-        return sb.toString();
-    }
-    public TableProto.Row getRow(String tableName, List<TableProto.Column> key) throws InvalidProtocolBufferException {
-
-        String keyString = buildKeyString(tableName, key);
-        try {
-            return TableProto.Row.parseFrom(getRawState(keyString));
-        } catch (InvalidProtocolBufferException e) {
-
-            logger.error("Error while retrieving row on table -" + tableName);
-            throw e;
-        }
-    }
-
-    public boolean deleteRow(String tableName, List<TableProto.Column> key){
-        String keyString = buildKeyString(tableName, key);
-        delState(keyString);
-        return true;
-    }
-
-    private boolean insertRowInternal(String tableName, TableProto.Row row, boolean update)
-    throws  Exception{
-        try {
-            //logger.debug("inside insertRowInternal with tname " + tableName);
-            TableProto.Table table = getTable(tableName);
-            //logger.debug("inside insertRowInternal with tableName " + table.getName());
-            List<TableProto.Column> keys = getKeyAndVerifyRow(table, row);
-            Boolean present = isRowPresent(tableName, keys);
-            if((present && !update) || (!present && update)){
-                return false;
-            }
-            String keyString = buildKeyString(tableName, keys);
-            putRawState(keyString, row.toByteString());
-        } catch (Exception e) {
-            logger.error("Unable to insert/update table -" + tableName);
-            logger.error(e.getMessage());
-            throw e;
-        }
-
-        return true;
-    }
-
-    private TableProto.Table getTable(String tableName) throws Exception {
-logger.info("Inside get tbale");
-        String tName = getTableNameKey(tableName);
-        logger.debug("Table name key for getRawState - " + tName);
-        ByteString tableBytes = getRawState(tName);
-        logger.debug("Table after getrawState -" + tableBytes);
-        return TableProto.Table.parseFrom(tableBytes);
-    }
-
-    private boolean tableExist(String tableName) throws Exception {
-        boolean tableExist = false;
-        //TODO Better way to check table existence ?
-        if (getTable(tableName).getName().equals(tableName)) {
-            tableExist = true;
-        }
-        return tableExist;
-    }
-
-    private String getTableNameKey(String name) {
-        return name.length() + name;
-    }
-
-    public boolean validateTableName(String name) throws Exception {
-        boolean validTableName = true;
-        if (name.length() == 0) {
-            validTableName = false;
-        }
-        return validTableName;
-    }
-}
+/*
+Copyright DTCC 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+         http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package org.hyperledger.java.shim;
+
+import com.google.protobuf.ByteString;
+import com.google.protobuf.InvalidProtocolBufferException;
+import com.google.protobuf.Timestamp;
+import org.apache.commons.logging.Log;
+import org.apache.commons.logging.LogFactory;
+import org.hyperledger.protos.Chaincode;
+import org.hyperledger.protos.Chaincodeevent.ChaincodeEvent;
+import org.hyperledger.protos.TableProto;
+import protos.ChaincodeProposal.ChaincodeProposalPayload;
+import protos.FabricTransactionHeader.Header;
+import protos.FabricProposal.Proposal;
+import protos.FabricProposal.SignedProposal;
+
+import java.nio.ByteBuffer;
+import java.nio.ByteOrder;
+import java.security.MessageDigest;
+import java.security.NoSuchAlgorithmException;
+import java.util.ArrayList;
+import java.util.Arrays;
+import java.util.HashMap;
+import java.util.LinkedHashMap;
+import java.util.List;
+import java.util.Map;
+import java.util.Random;
+
+import static org.hyperledger.protos.TableProto.ColumnDefinition.Type.STRING;
+
+public class ChaincodeStub {
+    private static Log logger = LogFactory.getLog(ChaincodeStub.class);
+    // compositeKeyNamespace prefixes all composite keys to keep them apart from simple keys
+    private static final String compositeKeyNamespace = "\u0000";
+    // compositeKeyDelimiter (U+0000) terminates every component of a composite key
+    private static final String compositeKeyDelimiter = "\u0000";
+    // maxUnicodeCodePoint (U+10FFFF) is the largest code point and is not allowed in composite key components
+    private static final String maxUnicodeCodePoint = new String(Character.toChars(Character.MAX_CODE_POINT));
+    private final String uuid;
+    private final Handler handler;
+    private final Proposal proposal;
+    private final SignedProposal signedProposal;
+    private final Map<String, ByteString> decorations;
+    private ChaincodeEvent event;
+    private TxRandom random;
+
+    public ChaincodeStub(String uuid, Handler handler) {
+        this(uuid, handler, null);
+    }
+
+    public ChaincodeStub(String uuid, Handler handler, Proposal proposal) {
+        this(uuid, handler, proposal, null, new HashMap<>());
+    }
+
+    public ChaincodeStub(String uuid, Handler handler, Proposal proposal, SignedProposal signedProposal, Map<String, ByteString> decorations) {
+        this.uuid = uuid;
+        this.handler = handler;
+        this.proposal = proposal;
+        this.signedProposal = signedProposal;
+        this.decorations = decorations;
+    }
+
+    /**
+     * Gets the UUID of this stub
+     *
+     * @return the id used to identify this communication channel
+     */
+    public String getUuid() {
+        return uuid;
+    }
+
+    /**
+     * Get the state of the provided key from the ledger, and returns is as a string
+     *
+     * @param key the key of the desired state
+     * @return the String value of the requested state
+     */
+    public String getState(String key) {
+        return handler.handleGetState(key, uuid).toStringUtf8();
+    }
+
+    /**
+     * Puts the given state into a ledger, automatically wrapping it in a ByteString
+     *
+     * @param key   reference key
+     * @param value value to be put
+     */
+    public void putState(String key, String value) {
+        handler.handlePutState(key, ByteString.copyFromUtf8(value), uuid);
+    }
+
+    /**
+     * Deletes the state of the given key from the ledger
+     *
+     * @param key key of the state to be deleted
+     */
+    public void delState(String key) {
+        handler.handleDeleteState(key, uuid);
+    }
+
+    /**
+     * Given a start key and end key, this method returns a map of items with value converted to UTF-8 string.
+     *
+     * @param startKey
+     * @param endKey
+     * @return
+     */
+    public Map<String, String> rangeQueryState(String startKey, String endKey) {
+        Map<String, String> retMap = new HashMap<>();
+        for (Map.Entry<String, ByteString> item : rangeQueryRawState(startKey, endKey).entrySet()) {
+            retMap.put(item.getKey(), item.getValue().toStringUtf8());
+        }
+        return retMap;
+    }
+
+    /**
+     * This method is same as rangeQueryState, except it returns value in ByteString, useful in cases where
+     * serialized object can be retrieved.
+     *
+     * @param startKey
+     * @param endKey
+     * @return
+     */
+    public Map<String, ByteString> rangeQueryRawState(String startKey, String endKey) {
+        return collectResults(handler.handleRangeQueryState(startKey, endKey, uuid));
+    }
+
+    /**
+     * Returns a single page of at most pageSize keys between the start key and end key, starting from the
+     * given bookmark. The metadata of the returned response carries the bookmark for the next page.
+     * Paginated queries are not recorded in the read set, so they are rejected in transaction context.
+     *
+     * @param startKey
+     * @param endKey
+     * @param pageSize
+     * @param bookmark the bookmark returned with the previous page, empty for the first page
+     * @return
+     */
+    public Chaincode.RangeQueryStateResponse rangeQueryRawStateWithPagination(String startKey, String endKey,
+                                                                              int pageSize, String bookmark) {
+        return handler.handleRangeQueryStateWithPagination(startKey, endKey, pageSize, bookmark, uuid);
+    }
+
+    /**
+     * Returns the values of the given keys, read in a single round trip, in the order of the keys.
+     * The value of a key that does not exist is empty.
+     *
+     * @param keys
+     * @return
+     */
+    public List<ByteString> getRawStateMultipleKeys(List<String> keys) {
+        return handler.handleGetStateMultiple(keys, uuid);
+    }
+
+    /**
+     * Returns the height of the ledger the transaction is simulated on. The height is recorded in
+     * the read set, so the transaction is invalidated if a block is committed before it.
+     *
+     * @return
+     */
+    public long getLedgerHeight() {
+        return handler.handleGetLedgerHeight(uuid);
+    }
+
+    /**
+     * Performs a rich query against the state, for state databases that support it. The query string is
+     * in the native syntax of the state database, e.g. a CouchDB query with a "selector".
+     *
+     * @param query
+     * @return
+     */
+    public Map<String, ByteString> getRawQueryResult(String query) {
+        return collectResults(handler.handleGetQueryResult(query, 0, "", uuid));
+    }
+
+    /**
+     * Performs a rich query against the state and returns a single page of at most pageSize results,
+     * starting from the given bookmark. Like rangeQueryRawStateWithPagination, it is rejected in
+     * transaction context.
+     *
+     * @param query
+     * @param pageSize
+     * @param bookmark the bookmark returned with the previous page, empty for the first page
+     * @return
+     */
+    public Chaincode.RangeQueryStateResponse getRawQueryResultWithPagination(String query, int pageSize, String bookmark) {
+        return handler.handleGetQueryResult(query, pageSize, bookmark, uuid);
+    }
+
+    // collectResults reads all the batches of results of a range or rich query
+    private Map<String, ByteString> collectResults(Chaincode.RangeQueryStateResponse response) {
+        Map<String, ByteString> map = new LinkedHashMap<>();
+        while (true) {
+            for (Chaincode.RangeQueryStateKeyValue mapping : response.getKeysAndValuesList()) {
+                map.put(mapping.getKey(), mapping.getValue());
+            }
+            // the validator releases the iterator once the last batch has been read
+            if (!response.getHasMore()) {
+                return map;
+            }
+            response = handler.handleRangeQueryStateNext(response.getID(), uuid);
+        }
+    }
+
+    /**
+     * Combines the given object type and attributes into a composite key, which can be used as the key
+     * of putState. The components must not contain U+0000 or U+10FFFF.
+     *
+     * @param objectType
+     * @param attributes
+     * @return
+     */
+    public String createCompositeKey(String objectType, List<String> attributes) {
+        validateCompositeKeyComponent(objectType);
+        StringBuilder compositeKey = new StringBuilder(compositeKeyNamespace).append(objectType).append(compositeKeyDelimiter);
+        for (String attribute : attributes) {
+            validateCompositeKeyComponent(attribute);
+            compositeKey.append(attribute).append(compositeKeyDelimiter);
+        }
+        return compositeKey.toString();
+    }
+
+    /**
+     * Splits the given composite key into the object type, the first element of the returned list, and the
+     * attributes it was formed from.
+     *
+     * @param compositeKey
+     * @return
+     */
+    public List<String> splitCompositeKey(String compositeKey) {
+        if (!compositeKey.startsWith(compositeKeyNamespace) || !compositeKey.endsWith(compositeKeyDelimiter)
+                || compositeKey.length() < compositeKeyNamespace.length() + compositeKeyDelimiter.length()) {
+            throw new IllegalArgumentException("Not a composite key: " + compositeKey);
+        }
+        String components = compositeKey.substring(compositeKeyNamespace.length(),
+                compositeKey.length() - compositeKeyDelimiter.length());
+        return new ArrayList<>(Arrays.asList(components.split(compositeKeyDelimiter, -1)));
+    }
+
+    /**
+     * Queries the state for the composite keys that start with the partial key formed by the given object
+     * type and leading attributes.
+     *
+     * @param objectType
+     * @param attributes
+     * @return
+     */
+    public Map<String, ByteString> getRawStateByPartialCompositeKey(String objectType, List<String> attributes) {
+        String partialCompositeKey = createCompositeKey(objectType, attributes);
+        return rangeQueryRawState(partialCompositeKey, partialCompositeKey + maxUnicodeCodePoint);
+    }
+
+    private void validateCompositeKeyComponent(String component) {
+        if (component.contains(compositeKeyDelimiter) || component.contains(maxUnicodeCodePoint)) {
+            throw new IllegalArgumentException("Composite key component " + component
+                    + " must not contain U+0000 or U+10FFFF");
+        }
+    }
+
+    /**
+     * Sets the event to be delivered to the registered event consumers when the transaction is committed.
+     *
+     * @param name    the name of the event, used by the consumers to filter the events
+     * @param payload
+     */
+    public void setEvent(String name, ByteString payload) {
+        event = ChaincodeEvent.newBuilder()
+                .setEventName(name)
+                .setPayload(payload)
+                .build();
+    }
+
+    /**
+     * @return the event set by the chaincode, null if none
+     */
+    ChaincodeEvent getEvent() {
+        return event;
+    }
+
+    /**
+     * Returns the transient data of the proposal (e.g. keys or other secrets supplied by the client). It is
+     * available to the chaincode during the simulation, but is never written to the ledger.
+     *
+     * @return the transient data, empty if there is none
+     */
+    public Map<String, ByteString> getTransient() {
+        if (proposal == null) {
+            return new HashMap<>();
+        }
+        try {
+            return ChaincodeProposalPayload.parseFrom(proposal.getPayload()).getTransientMap();
+        } catch (InvalidProtocolBufferException e) {
+            throw new RuntimeException("Error unmarshalling the proposal payload: " + e.getMessage());
+        }
+    }
+
+    /**
+     * Returns the serialized identity (e.g. the certificate) of the creator of the proposal, the client which
+     * submitted the transaction. It lets the chaincode control access based on who invoked it.
+     *
+     * @return the identity of the creator, null if there is no proposal
+     */
+    public ByteString getCreator() {
+        if (proposal == null) {
+            return null;
+        }
+        try {
+            return Header.parseFrom(proposal.getHeader()).getCreator();
+        } catch (InvalidProtocolBufferException e) {
+            throw new RuntimeException("Error unmarshalling the proposal header: " + e.getMessage());
+        }
+    }
+
+    /**
+     * Returns the decorations added to the input of the chaincode by the decorators of the endorsing peer, e.g. a
+     * classification of the client or metadata of the deployment.
+     *
+     * @return the decorations, empty if there are none
+     */
+    public Map<String, ByteString> getDecorations() {
+        return decorations;
+    }
+
+    /**
+     * Returns the timestamp asserted by the client in the header of the proposal. It is the same for all the
+     * endorsers of the transaction, chaincodes should use it instead of the local time.
+     *
+     * @return the timestamp of the transaction, null if there is no proposal
+     */
+    public Timestamp getTxTimestamp() {
+        if (proposal == null) {
+            return null;
+        }
+        try {
+            return Header.parseFrom(proposal.getHeader()).getTimestamp();
+        } catch (InvalidProtocolBufferException e) {
+            throw new RuntimeException("Error unmarshalling the proposal header: " + e.getMessage());
+        }
+    }
+
+    /**
+     * Returns the pseudo-random generator of the transaction, seeded with the nonce of the proposal and the
+     * transaction ID so that all the endorsers draw the same values. It is shared by the calls of the transaction.
+     *
+     * @return the generator of the transaction
+     */
+    public Random getRandom() {
+        if (random == null) {
+            byte[] nonce = new byte[0];
+            if (proposal != null) {
+                try {
+                    nonce = Header.parseFrom(proposal.getHeader()).getNonce().toByteArray();
+                } catch (InvalidProtocolBufferException e) {
+                    throw new RuntimeException("Error unmarshalling the proposal header: " + e.getMessage());
+                }
+            }
+            random = new TxRandom(uuid, nonce);
+        }
+        return random;
+    }
+
+    /**
+     * Returns the binding of the proposal, the SHA-256 hash over the nonce, the creator and the epoch of its
+     * header. Application data signed over the binding is bound to the transaction and cannot be replayed.
+     *
+     * @return the binding of the transaction, null if there is no proposal
+     */
+    public ByteString getBinding() {
+        if (proposal == null) {
+            return null;
+        }
+        try {
+            Header header = Header.parseFrom(proposal.getHeader());
+            byte[] epoch = ByteBuffer.allocate(8).order(ByteOrder.LITTLE_ENDIAN).putLong(header.getEpoch()).array();
+            MessageDigest digest = MessageDigest.getInstance("SHA-256");
+            digest.update(header.getNonce().toByteArray());
+            digest.update(header.getCreator().toByteArray());
+            digest.update(epoch);
+            return ByteString.copyFrom(digest.digest());
+        } catch (InvalidProtocolBufferException e) {
+            throw new RuntimeException("Error unmarshalling the proposal header: " + e.getMessage());
+        } catch (NoSuchAlgorithmException e) {
+            throw new RuntimeException("Error computing the binding: " + e.getMessage());
+        }
+    }
+
+    /**
+     * Returns the proposal being executed, with its header, payload and signature, so that the chaincode can verify,
+     * bind or log the exact request it is acting on. Rather than a signed proposal with an empty signature, an
+     * exception is thrown when the peer sent the proposal without its signature.
+     *
+     * @return the signed proposal, null if there is no proposal
+     * @throws IllegalStateException if the proposal was not signed
+     */
+    public SignedProposal getSignedProposal() {
+        if (signedProposal != null) {
+            return signedProposal;
+        }
+        if (proposal == null) {
+            return null;
+        }
+        throw new IllegalStateException("The proposal was not signed");
+    }
+
+    /**
+     * @param chaincodeName
+     * @param function
+     * @param args
+     * @return
+     */
+    public String invokeChaincode(String chaincodeName, String function, List<ByteString> args) {
+        return handler.handleInvokeChaincode(chaincodeName, function, args, uuid).toStringUtf8();
+    }
+
+    /**
+     * Invokes the chaincode of the given channel. If channel is empty or the channel of the caller, the
+     * read-write set of the called chaincode is merged with the caller's. Otherwise the chaincode of the
+     * other channel is called read only and contributes nothing to the caller's transaction.
+     *
+     * @param chaincodeName
+     * @param function
+     * @param args
+     * @param channel
+     * @return
+     */
+    public String invokeChaincode(String chaincodeName, String function, List<ByteString> args, String channel) {
+        return invokeRawChaincode(chaincodeName, function, args, channel).toStringUtf8();
+    }
+
+    /**
+     * @param chaincodeName
+     * @param function
+     * @param args
+     * @return
+     */
+    public String queryChaincode(String chaincodeName, String function, List<ByteString> args) {
+        return handler.handleQueryChaincode(chaincodeName, function, args, uuid).toStringUtf8();
+    }
+
+    //------RAW CALLS------
+
+    /**
+     * @param key
+     * @return
+     */
+    public ByteString getRawState(String key) {
+        return handler.handleGetState(key, uuid);
+    }
+
+    /**
+     * @param key
+     * @param value
+     */
+    public void putRawState(String key, ByteString value) {
+        handler.handlePutState(key, value, uuid);
+    }
+
+    /**
+     *
+     * @param startKey
+     * @param endKey
+     * @param limit
+     * @return
+     */
+//	public RangeQueryStateResponse rangeQueryRawState(String startKey, String endKey, int limit) {
+//		return handler.handleRangeQueryState(startKey, endKey, limit, uuid);
+//	}
+
+    /**
+     * @param chaincodeName
+     * @param function
+     * @param args
+     * @return
+     */
+    public ByteString queryRawChaincode(String chaincodeName, String function, List<ByteString> args) {
+        return handler.handleQueryChaincode(chaincodeName, function, args, uuid);
+    }
+
+    /**
+     * Invokes the provided chaincode with the given function and arguments, and returns the
+     * raw ByteString value that invocation generated.
+     *
+     * @param chaincodeName The name of the chaincode to invoke
+     * @param function      the function parameter to pass to the chaincode
+     * @param args          the arguments to be provided in the chaincode call
+     * @return the value returned by the chaincode call
+     */
+    public ByteString invokeRawChaincode(String chaincodeName, String function, List<ByteString> args) {
+        return handler.handleInvokeChaincode(chaincodeName, function, args, uuid);
+    }
+
+    /**
+     * Same as invokeChaincode with a channel, except it returns the raw ByteString value.
+     *
+     * @param chaincodeName
+     * @param function
+     * @param args
+     * @param channel
+     * @return
+     */
+    public ByteString invokeRawChaincode(String chaincodeName, String function, List<ByteString> args, String channel) {
+        // the chaincode of another channel is addressed as "name/channel"
+        if (channel != null && !channel.isEmpty()) {
+            chaincodeName = chaincodeName + "/" + channel;
+        }
+        return handler.handleInvokeChaincode(chaincodeName, function, args, uuid);
+    }
+
+    /**
+     * Returns the configuration values of the channel as read by QSCC: its organizations and the names of its
+     * access control policies by the resource they control.
+     *
+     * @param channel the channel, the channel of the proposal if null or empty
+     * @return the configuration of the channel
+     */
+    public Chaincode.ChannelConfig getChannelConfig(String channel) {
+        if (channel == null || channel.isEmpty()) {
+            if (proposal == null) {
+                throw new RuntimeException("No channel given and no proposal to take it from");
+            }
+            try {
+                channel = Header.parseFrom(proposal.getHeader()).getChainID().toStringUtf8();
+            } catch (InvalidProtocolBufferException e) {
+                throw new RuntimeException("Error unmarshalling the proposal header: " + e.getMessage());
+            }
+            if (channel.isEmpty()) {
+                throw new RuntimeException("No channel given and none in the proposal header");
+            }
+        }
+        ByteString response = invokeRawChaincode("qscc", "GetChannelConfig",
+                Arrays.asList(ByteString.copyFromUtf8(channel)));
+        try {
+            return Chaincode.ChannelConfig.parseFrom(response);
+        } catch (InvalidProtocolBufferException e) {
+            throw new RuntimeException("Error unmarshalling the configuration of channel " + channel + ": " + e.getMessage());
+        }
+    }
+
+    public boolean createTable(String tableName, List<TableProto.ColumnDefinition> columnDefinitions)
+            throws Exception    {
+        if (validateTableName(tableName)) {
+            logger.debug("Table name %s is valid, continue table creation");
+
+            if (tableExist(tableName)) {
+                logger.error("Table with tableName already exist, Create table operation failed");
+                return false;//table exist
+            } else {
+                if (columnDefinitions != null && columnDefinitions.size() == 0) {
+                    logger.error("Invalid column definitions. Table must contain at least one column");
+                    return false;
+                }
+                Map<String, Boolean> nameMap = new HashMap<>();
+                int idx = 0;
+                boolean hasKey = false;
+                logger.debug("Number of columns " + columnDefinitions.size());
+                for (TableProto.ColumnDefinition colDef : columnDefinitions) {
+                    logger.debug("Col information - " + colDef.getName()+ "=" + colDef.getType()+ "=" +colDef.isInitialized());
+
+                    if (!colDef.isInitialized() || colDef.getName().length() == 0) {
+                        logger.error("Column definition is invalid for index " + idx);
+                    return false;
+                    }
+
+                    if (!nameMap.isEmpty() && nameMap.containsKey(colDef.getName())){
+                        logger.error("Column already exist for colIdx " + idx + " with name " + colDef.getName());
+                        return false;
+                    }
+                    nameMap.put(colDef.getName(), true);
+                    switch (colDef.getType()) {
+                        case STRING:
+                            break;
+                        case INT32:
+                            break;
+                        case INT64:
+                            break;
+                        case UINT32:
+                            break;
+                        case UINT64:
+                            break;
+                        case BYTES:
+                            break;
+                        case BOOL:
+                            break;
+                        default:
+                            logger.error("Invalid column type for index " + idx + " given type " + colDef.getType());
+//                            return false;
+
+                    }
+
+                    if (colDef.getKey()) hasKey = true;
+
+                    idx++;
+                }
+                if (!hasKey) {
+                    logger.error("Invalid table. One or more columns must be a key.");
+                    return false;
+                }
+                TableProto.Table table = TableProto.Table.newBuilder()
+                        .setName(tableName)
+                        .addAllColumnDefinitions(columnDefinitions)
+                        .build();
+                String tableNameKey = getTableNameKey(tableName);
+                putRawState(tableNameKey, table.toByteString());
+                return true;
+            }
+        }
+        return false;
+    }
+    public boolean deleteTable(String tableName) {
+        String tableNameKey = getTableNameKey(tableName);
+        rangeQueryState(tableNameKey + "1", tableNameKey + ":")
+                .keySet().forEach(key -> delState(key));
+        delState(tableNameKey);
+        return true;
+    }
+    public boolean insertRow(String tableName, TableProto.Row row) throws Exception {
+        try {
+            return insertRowInternal(tableName, row, false);
+         } catch (Exception e) {
+            logger.error("Error while inserting row on table - " + tableName);
+            logger.error(e.getMessage());
+
+            throw e;
+        }
+    }
+
+    public boolean replaceRow(String tableName, TableProto.Row row) throws Exception {
+        try {
+            return insertRowInternal(tableName, row, true);
+        } catch (Exception e) {
+            logger.error("Error while updating row on table - " + tableName);
+            logger.error(e.getMessage());
+
+            throw e;
+        }
+    }
+    private List<TableProto.Column> getKeyAndVerifyRow(TableProto.Table table, TableProto.Row row) throws Exception {
+        List keys  = new ArrayList();
+        //logger.debug("Entering getKeyAndVerifyRow with tableName -" + table.getName() );
+        //logger.debug("Entering getKeyAndVerifyRow with rowcount -" + row.getColumnsCount() );
+        if ( !row.isInitialized() || row.getColumnsCount() != table.getColumnDefinitionsCount()){
+            logger.error("Table " + table.getName() + " define "
+            + table.getColumnDefinitionsCount() + " columns but row has "
+            + row.getColumnsCount() + " columns");
+            return keys;
+        }
+        int colIdx = 0;
+        for (TableProto.Column col: row.getColumnsList()) {
+            boolean expectedType;
+            switch (col.getValueCase()){
+                case STRING:
+                    expectedType = table.getColumnDefinitions(colIdx).getType()
+                            == STRING;
+                    break;
+                case INT32:
+                    expectedType = table.getColumnDefinitions(colIdx).getType()
+                            == TableProto.ColumnDefinition.Type.INT32;
+                    break;
+                case INT64:
+                    expectedType = table.getColumnDefinitions(colIdx).getType()
+                            == TableProto.ColumnDefinition.Type.INT64;
+                    break;
+                case UINT32:
+                    expectedType = table.getColumnDefinitions(colIdx).getType()
+                            == TableProto.ColumnDefinition.Type.UINT32;
+                    break;
+                case UINT64:
+                    expectedType = table.getColumnDefinitions(colIdx).getType()
+                            == TableProto.ColumnDefinition.Type.UINT64;
+                    break;
+                case BYTES:
+                    expectedType = table.getColumnDefinitions(colIdx).getType()
+                            == TableProto.ColumnDefinition.Type.BYTES;
+                    break;
+                case BOOL:
+                    expectedType = table.getColumnDefinitions(colIdx).getType()
+                            == TableProto.ColumnDefinition.Type.BOOL;
+                    break;
+                default:
+                    expectedType = false;
+            }
+            if (!expectedType){
+                logger.error("The type for table " + table.getName()
+                        + " column " + table.getColumnDefinitions(colIdx).getName() + " is "
+                        + table.getColumnDefinitions(colIdx).getType() +  " but the column in the row does not match" );
+                throw new Exception();
+            }
+            if (table.getColumnDefinitions(colIdx).getKey()){
+                keys.add(col);
+            }
+
+        colIdx++;
+        }
+        return keys;
+    }
+    private boolean isRowPresent(String tableName, List<TableProto.Column> keys){
+        String keyString = buildKeyString(tableName, keys);
+        ByteString rowBytes =   getRawState(keyString);
+        return  !rowBytes.isEmpty();
+    }
+
+    private String buildKeyString(String tableName, List<TableProto.Column> keys){
+
+        StringBuffer sb = new StringBuffer();
+        String tableNameKey = getTableNameKey(tableName);
+
+        sb.append(tableNameKey);
+        String keyString="";
+        for (TableProto.Column col: keys) {
+
+            switch (col.getValueCase()){
+                case STRING:
+                    keyString = col.getString();
+                    break;
+                case INT32:
+                    keyString = ""+col.getInt32();
+                    break;
+                case INT64:
+                    keyString = ""+col.getInt64();
+                    break;
+                case UINT32:
+                    keyString = ""+col.getUint32();
+                    break;
+                case UINT64:
+                    keyString = ""+col.getUint64();
+                    break;
+                case BYTES:
+                    keyString = col.getBytes().toString();
+                    break;
+                case BOOL:
+                    keyString = ""+col.getBool();
+                    break;
+            }
+
+            sb.append(keyString.length());
+            sb.append(keyString);
+
+        }
+        return sb.toString();
+    }
+    public TableProto.Row getRow(String tableName, List<TableProto.Column> key) throws InvalidProtocolBufferException {
+
+        String keyString = buildKeyString(tableName, key);
+        try {
+            return TableProto.Row.parseFrom(getRawState(keyString));
+        } catch (InvalidProtocolBufferException e) {
+
+            logger.error("Error while retrieving row on table -" + tableName);
+            throw e;
+        }
+    }
+
+    public boolean deleteRow(String tableName, List<TableProto.Column> key){
+        String keyString = buildKeyString(tableName, key);
+        delState(keyString);
+        return true;
+    }
+
+    private boolean insertRowInternal(String tableName, TableProto.Row row, boolean update)
+    throws  Exception{
+        try {
+            //logger.debug("inside insertRowInternal with tname " + tableName);
+            TableProto.Table table = getTable(tableName);
+            //logger.debug("inside insertRowInternal with tableName " + table.getName());
+            List<TableProto.Column> keys = getKeyAndVerifyRow(table, row);
+            Boolean present = isRowPresent(tableName, keys);
+            if((present && !update) || (!present && update)){
+                return false;
+            }
+            String keyString = buildKeyString(tableName, keys);
+            putRawState(keyString, row.toByteString());
+        } catch (Exception e) {
+            logger.error("Unable to insert/update table -" + tableName);
+            logger.error(e.getMessage());
+            throw e;
+        }
+
+        return true;
+    }
+
+    private TableProto.Table getTable(String tableName) throws Exception {
+logger.info("Inside get tbale");
+        String tName = getTableNameKey(tableName);
+        logger.debug("Table name key for getRawState - " + tName);
+        ByteString tableBytes = getRawState(tName);
+        logger.debug("Table after getrawState -" + tableBytes);
+        return TableProto.Table.parseFrom(tableBytes);
+    }
+
+    private boolean tableExist(String tableName) throws Exception {
+        boolean tableExist = false;
+        //TODO Better way to check table existence ?
+        if (getTable(tableName).getName().equals(tableName)) {
+            tableExist = true;
+        }
+        return tableExist;
+    }
+
+    private String getTableNameKey(String name) {
+        return name.length() + name;
+    }
+
+    public boolean validateTableName(String name) throws Exception {
+        boolean validTableName = true;
+        if (name.length() == 0) {
+            validTableName = false;
+        }
+        return validTableName;
+    }
+}
@@ -0,0 +1,60 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+         http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+
+package org.hyperledger.java.shim;
+
+import protos.FabricProposalResponse.Response2;
+
+/**
+ * Thrown by a chaincode to fail a proposal with a status code, the status and the message being returned to the
+ * client in the proposal response. Any other exception fails the proposal with the status ERROR.
+ */
+public class ResponseException extends RuntimeException {
+
+    public static final int OK = 200;
+    public static final int ERRORTHRESHOLD = 400;
+    public static final int ERROR = 500;
+
+    private final int status;
+
+    public ResponseException(int status, String message) {
+        super(message);
+        this.status = status;
+    }
+
+    public int getStatus() {
+        return status;
+    }
+
+    /**
+     * Converts an exception of the chaincode into the response sent to the peer, exceptions without a valid failure
+     * status being internal errors.
+     */
+    static Response2 toResponse(Throwable e) {
+        int status = ERROR;
+        if (e instanceof ResponseException) {
+            int s = ((ResponseException) e).getStatus();
+            if (s >= ERRORTHRESHOLD && s < 600) {
+                status = s;
+            }
+        }
+        return Response2.newBuilder()
+                .setStatus(status)
+                .setMessage(e.getMessage() == null ? e.toString() : e.getMessage())
+                .build();
+    }
+}
@@ -0,0 +1,77 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+         http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+
+package org.hyperledger.java.shim;
+
+import java.nio.ByteBuffer;
+import java.nio.charset.StandardCharsets;
+import java.security.MessageDigest;
+import java.security.NoSuchAlgorithmException;
+import java.util.Random;
+
+/**
+ * Deterministic pseudo-random generator for a transaction, seeded with SHA-256(nonce || txid) so that all the
+ * endorsers draw the same values. Block i of the stream is SHA-256(seed || i), i being a big endian uint64, as in
+ * the Go shim. The values are predictable by the client and must not be used where secrecy or fairness matters.
+ */
+public class TxRandom extends Random {
+
+    private final byte[] seed;
+    private long counter;
+    private ByteBuffer block = ByteBuffer.allocate(0);
+
+    public TxRandom(String txid, byte[] nonce) {
+        MessageDigest digest = sha256();
+        digest.update(nonce == null ? new byte[0] : nonce);
+        digest.update((txid == null ? "" : txid).getBytes(StandardCharsets.UTF_8));
+        this.seed = digest.digest();
+    }
+
+    private static MessageDigest sha256() {
+        try {
+            return MessageDigest.getInstance("SHA-256");
+        } catch (NoSuchAlgorithmException e) {
+            throw new RuntimeException("Error creating the random generator: " + e.getMessage());
+        }
+    }
+
+    @Override
+    public synchronized void nextBytes(byte[] bytes) {
+        for (int i = 0; i < bytes.length; i++) {
+            bytes[i] = nextByte();
+        }
+    }
+
+    @Override
+    protected synchronized int next(int bits) {
+        int v = 0;
+        for (int i = 0; i < 4; i++) {
+            v = (v << 8) | (nextByte() & 0xff);
+        }
+        return v >>> (32 - bits);
+    }
+
+    private byte nextByte() {
+        if (!block.hasRemaining()) {
+            MessageDigest digest = sha256();
+            digest.update(seed);
+            digest.update(ByteBuffer.allocate(8).putLong(counter++).array());
+            block = ByteBuffer.wrap(digest.digest());
+        }
+        return block.get();
+    }
+}
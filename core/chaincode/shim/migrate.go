@@ -0,0 +1,46 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shim
+
+import "fmt"
+
+// MigrateFunction is the function the peer invokes on an upgraded chaincode,
+// with the versions upgraded from and to as arguments. It is reserved, the
+// peer refuses proposals invoking it
+const MigrateFunction = "Migrate"
+
+// invoke calls Invoke of the chaincode, or Migrate when the peer migrates the
+// state of the upgraded chaincode. Migrating a chaincode that is not a
+// Migrator does nothing
+func invoke(cc Chaincode, stub ChaincodeStubInterface) ([]byte, error) {
+	args := stub.GetArgs()
+	if len(args) == 0 || string(args[0]) != MigrateFunction {
+		return cc.Invoke(stub)
+	}
+
+	migrator, ok := cc.(Migrator)
+	if !ok {
+		chaincodeLogger.Debugf("[%s]Chaincode does not migrate its state", shorttxid(stub.GetTxID()))
+		return nil, nil
+	}
+
+	if len(args) != 3 {
+		return nil, fmt.Errorf("%s expects the versions upgraded from and to, got %d arguments", MigrateFunction, len(args)-1)
+	}
+
+	return migrator.Migrate(stub, string(args[1]), string(args[2]))
+}
@@ -20,11 +20,17 @@ package shim
 
 import (
 	"container/list"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
 	"strings"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/hyperledger/fabric/core/chaincode/shim/crypto/attr"
+	pb "github.com/hyperledger/fabric/protos"
 	"github.com/op/go-logging"
 )
 
@@ -53,6 +59,33 @@ type MockStub struct {
 	// registered list of other MockStub chaincodes that can be called from this MockStub
 	Invokables map[string]*MockStub
 
+	// Transient is the transient data returned by GetTransient, set by the test
+	Transient map[string][]byte
+
+	// Creator is the identity returned by GetCreator, set by the test
+	Creator []byte
+
+	// SignedProposal is the proposal returned by GetSignedProposal, set by the test
+	SignedProposal *pb.SignedProposal
+
+	// TxTimestamp is the timestamp returned by GetTxTimestamp, set by the test
+	TxTimestamp *timestamp.Timestamp
+
+	// Binding is the binding returned by GetBinding, set by the test
+	Binding []byte
+
+	// Decorations are the decorations returned by GetDecorations, set by the test
+	Decorations map[string][]byte
+
+	// ChaincodeEvent is the event set by the chaincode in the current or last transaction
+	ChaincodeEvent *pb.ChaincodeEvent
+
+	// LedgerHeight is the height returned by GetLedgerHeight, set by the test
+	LedgerHeight uint64
+
+	// the generator returned by GetRandom in the current transaction
+	random *rand.Rand
+
 	// stores a transaction uuid while being Invoked / Deployed
 	// TODO if a chaincode uses recursion this may need to be a stack of TxIDs or possibly a reference counting map
 	TxID string
@@ -62,6 +95,22 @@ func (stub *MockStub) GetTxID() string {
 	return stub.TxID
 }
 
+func (stub *MockStub) GetTransient() (map[string][]byte, error) {
+	return stub.Transient, nil
+}
+
+func (stub *MockStub) GetCreator() ([]byte, error) {
+	return stub.Creator, nil
+}
+
+func (stub *MockStub) GetSignedProposal() (*pb.SignedProposal, error) {
+	return stub.SignedProposal, nil
+}
+
+func (stub *MockStub) GetDecorations() map[string][]byte {
+	return stub.Decorations
+}
+
 func (stub *MockStub) GetArgs() [][]byte {
 	return stub.args
 }
@@ -91,6 +140,8 @@ func (stub *MockStub) GetFunctionAndParameters() (function string, params []stri
 // MockStub doesn't support concurrent transactions at present.
 func (stub *MockStub) MockTransactionStart(txid string) {
 	stub.TxID = txid
+	stub.ChaincodeEvent = nil
+	stub.random = nil
 }
 
 // End a mocked transaction, clearing the UUID.
@@ -105,6 +156,34 @@ func (stub *MockStub) MockPeerChaincode(invokableChaincodeName string, otherStub
 	stub.Invokables[invokableChaincodeName] = otherStub
 }
 
+// MockChaincodeFunc answers the invocations and queries of a chaincode mocked
+// with MockInvokedChaincode
+type MockChaincodeFunc func(args [][]byte) ([]byte, error)
+
+// funcChaincode is a Chaincode answering its invocations and queries with a MockChaincodeFunc
+type funcChaincode struct {
+	f MockChaincodeFunc
+}
+
+func (cc *funcChaincode) Init(stub ChaincodeStubInterface) ([]byte, error) {
+	return nil, nil
+}
+
+func (cc *funcChaincode) Invoke(stub ChaincodeStubInterface) ([]byte, error) {
+	return cc.f(stub.GetArgs())
+}
+
+func (cc *funcChaincode) Query(stub ChaincodeStubInterface) ([]byte, error) {
+	return cc.f(stub.GetArgs())
+}
+
+// Mock a chaincode invoked or queried by this chaincode without implementing it,
+// f answers with the response or error the test expects of it.
+// invokableChaincodeName is registered as with MockPeerChaincode
+func (stub *MockStub) MockInvokedChaincode(invokableChaincodeName string, f MockChaincodeFunc) {
+	stub.MockPeerChaincode(invokableChaincodeName, NewMockStub(invokableChaincodeName, &funcChaincode{f: f}))
+}
+
 // Initialise this chaincode,  also starts and ends a transaction.
 func (stub *MockStub) MockInit(uuid string, args [][]byte) ([]byte, error) {
 	stub.args = args
@@ -118,11 +197,37 @@ func (stub *MockStub) MockInit(uuid string, args [][]byte) ([]byte, error) {
 func (stub *MockStub) MockInvoke(uuid string, args [][]byte) ([]byte, error) {
 	stub.args = args
 	stub.MockTransactionStart(uuid)
-	bytes, err := stub.cc.Invoke(stub)
+	bytes, err := invoke(stub.cc, stub)
 	stub.MockTransactionEnd(uuid)
 	return bytes, err
 }
 
+// Invoke this chaincode with a proposal, also starts and ends a transaction.
+// The creator, transient data, timestamp and binding the chaincode gets are
+// taken from the proposal as the peer does.
+func (stub *MockStub) MockInvokeWithSignedProposal(uuid string, args [][]byte, sp *pb.SignedProposal) ([]byte, error) {
+	prop := &pb.Proposal{}
+	if err := proto.Unmarshal(sp.ProposalBytes, prop); err != nil {
+		return nil, fmt.Errorf("Error unmarshalling the proposal: %s", err)
+	}
+	propStub := &ChaincodeStub{proposal: prop}
+	var err error
+	if stub.Creator, err = propStub.GetCreator(); err != nil {
+		return nil, err
+	}
+	if stub.Transient, err = propStub.GetTransient(); err != nil {
+		return nil, err
+	}
+	if stub.TxTimestamp, err = propStub.GetTxTimestamp(); err != nil {
+		return nil, err
+	}
+	if stub.Binding, err = propStub.GetBinding(); err != nil {
+		return nil, err
+	}
+	stub.SignedProposal = sp
+	return stub.MockInvoke(uuid, args)
+}
+
 // Query this chaincode
 func (stub *MockStub) MockQuery(args [][]byte) ([]byte, error) {
 	stub.args = args
@@ -138,6 +243,40 @@ func (stub *MockStub) GetState(key string) ([]byte, error) {
 	return value, nil
 }
 
+// GetStateMultipleKeys retrieves the values for the given keys from the ledger
+func (stub *MockStub) GetStateMultipleKeys(keys []string) ([][]byte, error) {
+	values := make([][]byte, len(keys))
+	for i, key := range keys {
+		values[i] = stub.State[key]
+	}
+	mockLogger.Debug("MockStub", stub.Name, "Getting", keys)
+	return values, nil
+}
+
+// GetRandom returns the pseudo-random generator of the transaction, seeded
+// as by the peer with the nonce of the SignedProposal and the TxID
+func (stub *MockStub) GetRandom() (*rand.Rand, error) {
+	if stub.random == nil {
+		prop := &pb.Proposal{}
+		if stub.SignedProposal != nil {
+			if err := proto.Unmarshal(stub.SignedProposal.ProposalBytes, prop); err != nil {
+				return nil, err
+			}
+		}
+		nonce, err := getProposalNonce(prop)
+		if err != nil {
+			return nil, err
+		}
+		stub.random = newTxRand(stub.TxID, nonce)
+	}
+	return stub.random, nil
+}
+
+// GetLedgerHeight returns the LedgerHeight set by the test
+func (stub *MockStub) GetLedgerHeight() (uint64, error) {
+	return stub.LedgerHeight, nil
+}
+
 // PutState writes the specified `value` and `key` into the ledger.
 func (stub *MockStub) PutState(key string, value []byte) error {
 	if stub.TxID == "" {
@@ -195,10 +334,155 @@ func (stub *MockStub) DelState(key string) error {
 	return nil
 }
 
+// RangeQueryState returns the keys and values between startKey and endKey,
+// inclusive. An empty endKey does not bound the range.
 func (stub *MockStub) RangeQueryState(startKey, endKey string) (StateRangeQueryIteratorInterface, error) {
 	return NewMockStateRangeQueryIterator(stub, startKey, endKey), nil
 }
 
+// GetStateByRangeWithPagination returns a page of at most pageSize keys
+// between startKey and endKey, inclusive, starting from the bookmark.
+func (stub *MockStub) GetStateByRangeWithPagination(startKey, endKey string, pageSize int32,
+	bookmark string) (StateRangeQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	if pageSize <= 0 {
+		return nil, nil, errors.New("Invalid page size")
+	}
+	if bookmark != "" {
+		startKey = bookmark
+	}
+
+	var keysAndValues []*pb.RangeQueryStateKeyValue
+	nextBookmark := ""
+	for elem := stub.Keys.Front(); elem != nil; elem = elem.Next() {
+		key := elem.Value.(string)
+		if key < startKey || (endKey != "" && key > endKey) {
+			continue
+		}
+		if int32(len(keysAndValues)) == pageSize {
+			nextBookmark = key
+			break
+		}
+		keysAndValues = append(keysAndValues, &pb.RangeQueryStateKeyValue{Key: key, Value: stub.State[key]})
+	}
+
+	iter := &StateRangeQueryIterator{response: &pb.RangeQueryStateResponse{KeysAndValues: keysAndValues}}
+	metadata := &pb.QueryResponseMetadata{FetchedRecordsCount: int32(len(keysAndValues)), Bookmark: nextBookmark}
+	return iter, metadata, nil
+}
+
+// GetQueryResult returns the keys and values whose JSON value matches the
+// "selector" of a CouchDB query. The mock only supports selectors on the
+// equality of fields, nested fields being given as nested objects or dotted
+// names; operators such as "$gt" are not supported.
+func (stub *MockStub) GetQueryResult(query string) (StateRangeQueryIteratorInterface, error) {
+	iter, _, err := stub.queryPage(query, 0, "")
+	return iter, err
+}
+
+// GetQueryResultWithPagination returns a page of at most pageSize keys and
+// values matching the selector of the query, as GetQueryResult, starting from
+// the bookmark.
+func (stub *MockStub) GetQueryResultWithPagination(query string, pageSize int32,
+	bookmark string) (StateRangeQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	if pageSize <= 0 {
+		return nil, nil, errors.New("Invalid page size")
+	}
+	return stub.queryPage(query, pageSize, bookmark)
+}
+
+// queryPage returns the keys and values matching the selector of the query
+// from the bookmark on, at most pageSize of them unless pageSize is 0
+func (stub *MockStub) queryPage(query string, pageSize int32,
+	bookmark string) (StateRangeQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	parsed := struct {
+		Selector map[string]interface{} `json:"selector"`
+	}{}
+	if err := json.Unmarshal([]byte(query), &parsed); err != nil {
+		return nil, nil, fmt.Errorf("Query is not a valid JSON: %s", err)
+	}
+	if parsed.Selector == nil {
+		return nil, nil, errors.New("Query does not contain a selector")
+	}
+
+	var keysAndValues []*pb.RangeQueryStateKeyValue
+	nextBookmark := ""
+	for elem := stub.Keys.Front(); elem != nil; elem = elem.Next() {
+		key := elem.Value.(string)
+		if key < bookmark {
+			continue
+		}
+		doc := make(map[string]interface{})
+		if err := json.Unmarshal(stub.State[key], &doc); err != nil || !matchesSelector(doc, parsed.Selector) {
+			continue
+		}
+		if pageSize > 0 && int32(len(keysAndValues)) == pageSize {
+			nextBookmark = key
+			break
+		}
+		keysAndValues = append(keysAndValues, &pb.RangeQueryStateKeyValue{Key: key, Value: stub.State[key]})
+	}
+
+	iter := &StateRangeQueryIterator{response: &pb.RangeQueryStateResponse{KeysAndValues: keysAndValues}}
+	metadata := &pb.QueryResponseMetadata{FetchedRecordsCount: int32(len(keysAndValues)), Bookmark: nextBookmark}
+	return iter, metadata, nil
+}
+
+// matchesSelector tells whether the fields of a JSON document equal those of
+// a selector
+func matchesSelector(doc map[string]interface{}, selector map[string]interface{}) bool {
+	for field, expected := range selector {
+		var value interface{} = doc
+		for _, name := range strings.Split(field, ".") {
+			object, ok := value.(map[string]interface{})
+			if !ok {
+				return false
+			}
+			if value, ok = object[name]; !ok {
+				return false
+			}
+		}
+		if subSelector, ok := expected.(map[string]interface{}); ok {
+			object, ok := value.(map[string]interface{})
+			if !ok || !matchesSelector(object, subSelector) {
+				return false
+			}
+			continue
+		}
+		if !reflect.DeepEqual(value, expected) {
+			return false
+		}
+	}
+	return true
+}
+
+// CreateCompositeKey combines the objectType and attributes into a composite key.
+func (stub *MockStub) CreateCompositeKey(objectType string, attributes []string) (string, error) {
+	return createCompositeKey(objectType, attributes)
+}
+
+// SplitCompositeKey splits a composite key into its objectType and attributes.
+func (stub *MockStub) SplitCompositeKey(compositeKey string) (string, []string, error) {
+	return splitCompositeKey(compositeKey)
+}
+
+// GetStateByPartialCompositeKey returns the keys and values of all composite
+// keys that start with the partial key formed by objectType and attributes.
+func (stub *MockStub) GetStateByPartialCompositeKey(objectType string, attributes []string) (StateRangeQueryIteratorInterface, error) {
+	partialCompositeKey, err := createCompositeKey(objectType, attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	var keysAndValues []*pb.RangeQueryStateKeyValue
+	for elem := stub.Keys.Front(); elem != nil; elem = elem.Next() {
+		key := elem.Value.(string)
+		if strings.HasPrefix(key, partialCompositeKey) {
+			keysAndValues = append(keysAndValues, &pb.RangeQueryStateKeyValue{Key: key, Value: stub.State[key]})
+		}
+	}
+	return &StateRangeQueryIterator{response: &pb.RangeQueryStateResponse{KeysAndValues: keysAndValues}}, nil
+}
+
 // CreateTable creates a new table given the table name and column definitions
 func (stub *MockStub) CreateTable(name string, columnDefinitions []*ColumnDefinition) error {
 	return createTableInternal(stub, name, columnDefinitions)
@@ -256,12 +540,20 @@ func (stub *MockStub) DeleteRow(tableName string, key []Column) error {
 }
 
 // Invokes a peered chaincode.
-// E.g. stub1.InvokeChaincode("stub2Hash", funcArgs)
+// E.g. stub1.InvokeChaincode("stub2Hash", funcArgs, "")
 // Before calling this make sure to create another MockStub stub2, call stub2.MockInit(uuid, func, args)
 // and register it with stub1 by calling stub1.MockPeerChaincode("stub2Hash", stub2)
-func (stub *MockStub) InvokeChaincode(chaincodeName string, args [][]byte) ([]byte, error) {
+// The chaincode of another channel is registered as "stub2Hash/channel".
+func (stub *MockStub) InvokeChaincode(chaincodeName string, args [][]byte, channel string) ([]byte, error) {
 	// TODO "args" here should possibly be a serialized pb.ChaincodeInput
+	if channel != "" {
+		chaincodeName = chaincodeName + "/" + channel
+	}
 	otherStub := stub.Invokables[chaincodeName]
+	if otherStub == nil {
+		mockLogger.Error("Could not find peer chaincode to invoke", chaincodeName)
+		return nil, errors.New("Could not find peer chaincode to invoke")
+	}
 	mockLogger.Debug("MockStub", stub.Name, "Invoking peer chaincode", otherStub.Name, args)
 	//	function, strings := getFuncArgs(args)
 	bytes, err := otherStub.MockInvoke(stub.TxID, args)
@@ -269,6 +561,19 @@ func (stub *MockStub) InvokeChaincode(chaincodeName string, args [][]byte) ([]by
 	return bytes, err
 }
 
+// GetChannelConfig invokes the chaincode registered as "qscc" with
+// MockPeerChaincode, which can be QSCC or a mock of it
+func (stub *MockStub) GetChannelConfig(channel string) (*pb.ChannelConfig, error) {
+	var prop *pb.Proposal
+	if stub.SignedProposal != nil {
+		prop = &pb.Proposal{}
+		if err := proto.Unmarshal(stub.SignedProposal.ProposalBytes, prop); err != nil {
+			return nil, fmt.Errorf("Error unmarshalling the proposal: %s", err)
+		}
+	}
+	return getChannelConfig(stub, prop, channel)
+}
+
 func (stub *MockStub) QueryChaincode(chaincodeName string, args [][]byte) ([]byte, error) {
 	// TODO "args" here should possibly be a serialized pb.ChaincodeInput
 	mockLogger.Debug("MockStub", stub.Name, "Looking for peer chaincode", chaincodeName)
@@ -313,9 +618,8 @@ func (stub *MockStub) GetCallerMetadata() ([]byte, error) {
 	return nil, nil
 }
 
-// Not implemented
 func (stub *MockStub) GetBinding() ([]byte, error) {
-	return nil, nil
+	return stub.Binding, nil
 }
 
 // Not implemented
@@ -323,13 +627,13 @@ func (stub *MockStub) GetPayload() ([]byte, error) {
 	return nil, nil
 }
 
-// Not implemented
 func (stub *MockStub) GetTxTimestamp() (*timestamp.Timestamp, error) {
-	return nil, nil
+	return stub.TxTimestamp, nil
 }
 
-// Not implemented
+// SetEvent saves the event in ChaincodeEvent for the test to check
 func (stub *MockStub) SetEvent(name string, payload []byte) error {
+	stub.ChaincodeEvent = &pb.ChaincodeEvent{EventName: name, Payload: payload}
 	return nil
 }
 
@@ -355,7 +659,26 @@ type MockStateRangeQueryIterator struct {
 	Stub     *MockStub
 	StartKey string
 	EndKey   string
-	Current  *list.Element
+	// Current is the element of the key last returned, nil before the first
+	Current *list.Element
+}
+
+// nextElement returns the element of the next key in the range, nil at its end
+func (iter *MockStateRangeQueryIterator) nextElement() *list.Element {
+	elem := iter.Stub.Keys.Front()
+	if iter.Current != nil {
+		elem = iter.Current.Next()
+	}
+	for ; elem != nil; elem = elem.Next() {
+		key := elem.Value.(string)
+		if iter.EndKey != "" && key > iter.EndKey {
+			return nil
+		}
+		if key >= iter.StartKey {
+			return elem
+		}
+	}
+	return nil
 }
 
 // HasNext returns true if the range query iterator contains additional keys
@@ -367,18 +690,7 @@ func (iter *MockStateRangeQueryIterator) HasNext() bool {
 		return false
 	}
 
-	if iter.Current == nil {
-		mockLogger.Error("HasNext() couldn't get Current")
-		return false
-	}
-
-	if iter.Current.Next() == nil {
-		// we've reached the end of the underlying values
-		mockLogger.Debug("HasNext() but no next")
-		return false
-	}
-
-	if iter.EndKey == iter.Current.Value {
+	if iter.nextElement() == nil {
 		// we've reached the end of the specified range
 		mockLogger.Debug("HasNext() at end of specified range")
 		return false
@@ -400,12 +712,7 @@ func (iter *MockStateRangeQueryIterator) Next() (string, []byte, error) {
 		return "", nil, errors.New("MockStateRangeQueryIterator.Next() called when it does not HaveNext()")
 	}
 
-	iter.Current = iter.Current.Next()
-
-	if iter.Current == nil {
-		mockLogger.Error("MockStateRangeQueryIterator.Next() went past end of range")
-		return "", nil, errors.New("MockStateRangeQueryIterator.Next() went past end of range")
-	}
+	iter.Current = iter.nextElement()
 	key := iter.Current.Value.(string)
 	value, err := iter.Stub.GetState(key)
 	return key, value, err
@@ -441,7 +748,7 @@ func NewMockStateRangeQueryIterator(stub *MockStub, startKey string, endKey stri
 	iter.Stub = stub
 	iter.StartKey = startKey
 	iter.EndKey = endKey
-	iter.Current = stub.Keys.Front()
+	iter.Current = nil
 
 	iter.Print()
 
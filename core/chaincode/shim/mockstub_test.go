@@ -19,8 +19,12 @@ package shim
 import (
 	"errors"
 	"fmt"
+	"reflect"
 	"testing"
 
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	pb "github.com/hyperledger/fabric/protos"
 	"github.com/spf13/viper"
 )
 
@@ -131,6 +135,393 @@ func TestMockStateRangeQueryIterator(t *testing.T) {
 	}
 }
 
+func TestMockRangeQueryState(t *testing.T) {
+	stub := NewMockStub("rangeQueryTest", nil)
+	stub.MockTransactionStart("init")
+	for _, key := range []string{"b", "d", "a", "c"} {
+		stub.PutState(key, []byte(key))
+	}
+	stub.MockTransactionEnd("init")
+
+	for _, test := range []struct {
+		startKey, endKey string
+		expectKeys       []string
+	}{
+		{"a", "c", []string{"a", "b", "c"}},
+		{"b", "", []string{"b", "c", "d"}},
+		{"bb", "cc", []string{"c"}},
+		{"e", "", nil},
+	} {
+		iter, err := stub.RangeQueryState(test.startKey, test.endKey)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		var keys []string
+		for iter.HasNext() {
+			key, value, err := iter.Next()
+			if err != nil || string(value) != key {
+				t.Fatalf("Unexpected value %s of key %s, error %v", value, key, err)
+			}
+			keys = append(keys, key)
+		}
+		iter.Close()
+		if fmt.Sprint(keys) != fmt.Sprint(test.expectKeys) {
+			t.Fatalf("Expected keys %v between %s and %s, got %v", test.expectKeys, test.startKey, test.endKey, keys)
+		}
+	}
+}
+
+func TestMockGetStateByRangeWithPagination(t *testing.T) {
+	stub := NewMockStub("paginationTest", nil)
+	stub.MockTransactionStart("init")
+	for _, key := range []string{"1", "2", "3", "4", "5", "6"} {
+		stub.PutState(key, []byte(key))
+	}
+	stub.MockTransactionEnd("init")
+
+	expectPages := [][]string{{"2", "3"}, {"4", "5"}}
+	bookmark := ""
+	for _, expectKeys := range expectPages {
+		iter, metadata, err := stub.GetStateByRangeWithPagination("2", "5", 2, bookmark)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		var keys []string
+		for iter.HasNext() {
+			key, _, _ := iter.Next()
+			keys = append(keys, key)
+		}
+		iter.Close()
+		if fmt.Sprint(keys) != fmt.Sprint(expectKeys) || metadata.FetchedRecordsCount != int32(len(expectKeys)) {
+			t.Fatalf("Expected keys %v, got %v", expectKeys, keys)
+		}
+		bookmark = metadata.Bookmark
+	}
+	if bookmark != "" {
+		t.Fatalf("Expected empty bookmark after the last page, got %s", bookmark)
+	}
+}
+
+func TestMockGetQueryResultWithPagination(t *testing.T) {
+	stub := NewMockStub("queryTest", nil)
+	stub.MockTransactionStart("init")
+	stub.PutState("1", []byte(`{"color":"blue","owner":{"name":"tom"}}`))
+	stub.PutState("2", []byte(`{"color":"red","owner":{"name":"tom"}}`))
+	stub.PutState("3", []byte(`{"color":"blue","owner":{"name":"bob"}}`))
+	stub.PutState("4", []byte(`{"color":"blue","owner":{"name":"tom"}}`))
+	stub.PutState("5", []byte("not json"))
+	stub.PutState("6", []byte(`{"color":"blue","owner":{"name":"tom"}}`))
+	stub.MockTransactionEnd("init")
+
+	iter, err := stub.GetQueryResult(`{"selector":{"color":"blue","owner.name":"tom"}}`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	var keys []string
+	for iter.HasNext() {
+		key, _, _ := iter.Next()
+		keys = append(keys, key)
+	}
+	if fmt.Sprint(keys) != fmt.Sprint([]string{"1", "4", "6"}) {
+		t.Fatalf("Expected keys [1 4 6], got %v", keys)
+	}
+
+	expectPages := [][]string{{"1", "4"}, {"6"}}
+	bookmark := ""
+	for _, expectKeys := range expectPages {
+		iter, metadata, err := stub.GetQueryResultWithPagination(`{"selector":{"owner":{"name":"tom"},"color":"blue"}}`, 2, bookmark)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		keys = nil
+		for iter.HasNext() {
+			key, _, _ := iter.Next()
+			keys = append(keys, key)
+		}
+		if fmt.Sprint(keys) != fmt.Sprint(expectKeys) || metadata.FetchedRecordsCount != int32(len(expectKeys)) {
+			t.Fatalf("Expected keys %v, got %v", expectKeys, keys)
+		}
+		bookmark = metadata.Bookmark
+	}
+	if bookmark != "" {
+		t.Fatalf("Expected empty bookmark after the last page, got %s", bookmark)
+	}
+
+	if _, err = stub.GetQueryResult(`{"color":"blue"}`); err == nil {
+		t.Fatalf("Expected an error for a query without selector")
+	}
+}
+
+func TestMockGetStateMultipleKeys(t *testing.T) {
+	stub := NewMockStub("multipleKeysTest", nil)
+	stub.MockTransactionStart("init")
+	stub.PutState("1", []byte{61})
+	stub.PutState("2", []byte{62})
+	stub.MockTransactionEnd("init")
+
+	values, err := stub.GetStateMultipleKeys([]string{"2", "3", "1"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if fmt.Sprint(values) != fmt.Sprint([][]byte{{62}, nil, {61}}) {
+		t.Fatalf("Unexpected values %v", values)
+	}
+}
+
+// echoChaincode returns the arguments of the invocation
+type echoChaincode struct{}
+
+func (e *echoChaincode) Init(stub ChaincodeStubInterface) ([]byte, error) {
+	return nil, nil
+}
+
+func (e *echoChaincode) Invoke(stub ChaincodeStubInterface) ([]byte, error) {
+	return []byte(fmt.Sprint(stub.GetStringArgs())), nil
+}
+
+func (e *echoChaincode) Query(stub ChaincodeStubInterface) ([]byte, error) {
+	return nil, nil
+}
+
+func TestMockInvokeChaincode(t *testing.T) {
+	stub := NewMockStub("caller", nil)
+	stub.MockPeerChaincode("echo", NewMockStub("echo", &echoChaincode{}))
+	stub.MockPeerChaincode("echo/other", NewMockStub("echoOther", &echoChaincode{}))
+
+	response, err := stub.InvokeChaincode("echo", [][]byte{[]byte("a")}, "")
+	if err != nil || string(response) != "[a]" {
+		t.Fatalf("Unexpected response %s, error %v", response, err)
+	}
+	response, err = stub.InvokeChaincode("echo", [][]byte{[]byte("b")}, "other")
+	if err != nil || string(response) != "[b]" {
+		t.Fatalf("Unexpected response %s, error %v", response, err)
+	}
+	if _, err = stub.InvokeChaincode("echo", nil, "missing"); err == nil {
+		t.Fatalf("Expected an error invoking a chaincode of an unknown channel")
+	}
+}
+
+func TestMockInvokedChaincode(t *testing.T) {
+	stub := NewMockStub("caller", nil)
+	stub.MockInvokedChaincode("mocked", func(args [][]byte) ([]byte, error) {
+		if len(args) == 0 {
+			return nil, errors.New("no arguments")
+		}
+		return args[0], nil
+	})
+
+	response, err := stub.InvokeChaincode("mocked", [][]byte{[]byte("a")}, "")
+	if err != nil || string(response) != "a" {
+		t.Fatalf("Unexpected response %s, error %v", response, err)
+	}
+	response, err = stub.QueryChaincode("mocked", [][]byte{[]byte("b")})
+	if err != nil || string(response) != "b" {
+		t.Fatalf("Unexpected response %s, error %v", response, err)
+	}
+	if _, err = stub.InvokeChaincode("mocked", nil, ""); err == nil {
+		t.Fatalf("Expected the error of the mocked chaincode")
+	}
+}
+
+func TestMockGetChannelConfig(t *testing.T) {
+	stub := NewMockStub("caller", nil)
+	stub.MockInvokedChaincode("qscc", func(args [][]byte) ([]byte, error) {
+		if len(args) != 2 || string(args[0]) != "GetChannelConfig" {
+			return nil, errors.New("unexpected arguments")
+		}
+		return proto.Marshal(&pb.ChannelConfig{ChainID: string(args[1]), Organizations: []string{"org1", "org2"}})
+	})
+
+	config, err := stub.GetChannelConfig("mychannel")
+	if err != nil || config.ChainID != "mychannel" || len(config.Organizations) != 2 {
+		t.Fatalf("Unexpected config %v, error %v", config, err)
+	}
+
+	// without a channel, the one of the proposal
+	if _, err = stub.GetChannelConfig(""); err == nil {
+		t.Fatalf("Expected an error without a channel nor a proposal")
+	}
+	hdr, _ := proto.Marshal(&pb.Header{ChainID: []byte("proposalchannel")})
+	propBytes, _ := proto.Marshal(&pb.Proposal{Header: hdr})
+	stub.SignedProposal = &pb.SignedProposal{ProposalBytes: propBytes}
+	config, err = stub.GetChannelConfig("")
+	if err != nil || config.ChainID != "proposalchannel" {
+		t.Fatalf("Unexpected config %v, error %v", config, err)
+	}
+}
+
+func TestMockGetRandom(t *testing.T) {
+	draw := func(stub *MockStub) []int {
+		r, err := stub.GetRandom()
+		if err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+		return []int{r.Intn(1000), r.Intn(1000), r.Intn(1000)}
+	}
+
+	// the same transaction draws the same values on every endorser
+	stub1 := NewMockStub("endorser1", nil)
+	stub2 := NewMockStub("endorser2", nil)
+	hdr, _ := proto.Marshal(&pb.Header{Nonce: []byte("nonce")})
+	propBytes, _ := proto.Marshal(&pb.Proposal{Header: hdr})
+	stub1.SignedProposal = &pb.SignedProposal{ProposalBytes: propBytes}
+	stub2.SignedProposal = &pb.SignedProposal{ProposalBytes: propBytes}
+	stub1.MockTransactionStart("tx1")
+	stub2.MockTransactionStart("tx1")
+	values := draw(stub1)
+	if !reflect.DeepEqual(values, draw(stub2)) {
+		t.Fatalf("Endorsers drew different values")
+	}
+
+	// the generator continues within the transaction
+	if reflect.DeepEqual(values, draw(stub1)) {
+		t.Fatalf("The generator restarted within the transaction")
+	}
+
+	// another transaction draws other values
+	stub1.MockTransactionStart("tx2")
+	if reflect.DeepEqual(values, draw(stub1)) {
+		t.Fatalf("Another transaction drew the same values")
+	}
+}
+
+func TestMockGetLedgerHeight(t *testing.T) {
+	stub := NewMockStub("height", nil)
+	stub.LedgerHeight = 42
+	height, err := stub.GetLedgerHeight()
+	if err != nil || height != 42 {
+		t.Fatalf("Unexpected height %d, error %v", height, err)
+	}
+}
+
+// proposalChaincode returns the creator and the transient data of the proposal
+// and sets an event
+type proposalChaincode struct {
+	echoChaincode
+}
+
+func (p *proposalChaincode) Invoke(stub ChaincodeStubInterface) ([]byte, error) {
+	creator, err := stub.GetCreator()
+	if err != nil {
+		return nil, err
+	}
+	transient, err := stub.GetTransient()
+	if err != nil {
+		return nil, err
+	}
+	stub.SetEvent("invoked", creator)
+	return append(creator, transient["secret"]...), nil
+}
+
+func TestMockInvokeWithSignedProposal(t *testing.T) {
+	ts := &timestamp.Timestamp{Seconds: 1481000000}
+	hdr, err := proto.Marshal(&pb.Header{Creator: []byte("cert"), Timestamp: ts})
+	if err != nil {
+		t.Fatalf("Error marshalling the header: %s", err)
+	}
+	payload, err := proto.Marshal(&pb.ChaincodeProposalPayload{TransientMap: map[string][]byte{"secret": []byte("-key")}})
+	if err != nil {
+		t.Fatalf("Error marshalling the payload: %s", err)
+	}
+	propBytes, err := proto.Marshal(&pb.Proposal{Header: hdr, Payload: payload})
+	if err != nil {
+		t.Fatalf("Error marshalling the proposal: %s", err)
+	}
+
+	stub := NewMockStub("proposal", &proposalChaincode{})
+	response, err := stub.MockInvokeWithSignedProposal("1", [][]byte{[]byte("invoke")}, &pb.SignedProposal{ProposalBytes: propBytes})
+	if err != nil || string(response) != "cert-key" {
+		t.Fatalf("Unexpected response %s, error %v", response, err)
+	}
+	if txts, _ := stub.GetTxTimestamp(); !proto.Equal(txts, ts) {
+		t.Fatalf("Unexpected timestamp %v", txts)
+	}
+	if stub.ChaincodeEvent == nil || stub.ChaincodeEvent.EventName != "invoked" || string(stub.ChaincodeEvent.Payload) != "cert" {
+		t.Fatalf("Unexpected event %v", stub.ChaincodeEvent)
+	}
+
+	// the event is the one of the transaction
+	stub.MockTransactionStart("2")
+	if stub.ChaincodeEvent != nil {
+		t.Fatalf("Unexpected event %v in a new transaction", stub.ChaincodeEvent)
+	}
+	stub.MockTransactionEnd("2")
+
+	if _, err = stub.MockInvokeWithSignedProposal("3", nil, &pb.SignedProposal{ProposalBytes: []byte("garbage")}); err == nil {
+		t.Fatalf("Expected an error for a malformed proposal")
+	}
+}
+
+type migratingChaincode struct {
+	echoChaincode
+}
+
+func (m *migratingChaincode) Migrate(stub ChaincodeStubInterface, fromVersion string, toVersion string) ([]byte, error) {
+	return []byte(fromVersion + "->" + toVersion), nil
+}
+
+func TestMockMigrate(t *testing.T) {
+	stub := NewMockStub("migrate", &migratingChaincode{})
+	args := [][]byte{[]byte(MigrateFunction), []byte("1.0"), []byte("2.0")}
+	response, err := stub.MockInvoke("1", args)
+	if err != nil || string(response) != "1.0->2.0" {
+		t.Fatalf("Unexpected response %s, error %v", response, err)
+	}
+	if _, err = stub.MockInvoke("2", args[:2]); err == nil {
+		t.Fatalf("Expected an error migrating without the version upgraded to")
+	}
+	response, err = stub.MockInvoke("3", [][]byte{[]byte("a")})
+	if err != nil || string(response) != "[a]" {
+		t.Fatalf("Unexpected response %s, error %v", response, err)
+	}
+
+	// a chaincode which does not migrate its state ignores the migration
+	stub = NewMockStub("echo", &echoChaincode{})
+	response, err = stub.MockInvoke("1", args)
+	if err != nil || response != nil {
+		t.Fatalf("Unexpected response %s, error %v", response, err)
+	}
+}
+
+func TestMockCompositeKeys(t *testing.T) {
+	stub := NewMockStub("compositeKeyTest", nil)
+	stub.MockTransactionStart("init")
+	for _, ownerAndAsset := range [][]string{{"alice", "car"}, {"alice", "house"}, {"bob", "boat"}, {"alicia", "bike"}} {
+		key, err := stub.CreateCompositeKey("owner~asset", ownerAndAsset)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		stub.PutState(key, []byte(ownerAndAsset[1]))
+	}
+	stub.PutState("simplekey", []byte("simplevalue"))
+	stub.MockTransactionEnd("init")
+
+	iter, err := stub.GetStateByPartialCompositeKey("owner~asset", []string{"alice"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	var assets []string
+	for iter.HasNext() {
+		key, _, _ := iter.Next()
+		objectType, attributes, err := stub.SplitCompositeKey(key)
+		if err != nil || objectType != "owner~asset" || len(attributes) != 2 || attributes[0] != "alice" {
+			t.Fatalf("Unexpected composite key split: %s %v %v", objectType, attributes, err)
+		}
+		assets = append(assets, attributes[1])
+	}
+	iter.Close()
+	if fmt.Sprint(assets) != fmt.Sprint([]string{"car", "house"}) {
+		t.Fatalf("Expected assets [car house], got %v", assets)
+	}
+
+	if _, err := stub.CreateCompositeKey("owner~asset", []string{"a\x00b"}); err == nil {
+		t.Fatalf("Expected error for attribute containing U+0000")
+	}
+	if _, _, err := stub.SplitCompositeKey("simplekey"); err == nil {
+		t.Fatalf("Expected error splitting a simple key")
+	}
+}
+
 func TestMockTable(t *testing.T) {
 	stub := NewMockStub("CreateTable", nil)
 	stub.MockTransactionStart("init")
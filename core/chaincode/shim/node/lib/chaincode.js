@@ -0,0 +1,98 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+'use strict';
+
+const fs = require('fs');
+const grpc = require('grpc');
+const protos = require('./protos.js');
+const ChaincodeHandler = require('./handler.js');
+const response = require('./response.js');
+
+// getPeerAddress reads the address from the --peer.address argument, the
+// peer passes it when it launches the chaincode container
+function getPeerAddress(argv) {
+	for (let i = 0; i < argv.length; i++) {
+		if (argv[i] === '--peer.address' && i + 1 < argv.length) {
+			return argv[i + 1];
+		}
+		if (argv[i].startsWith('--peer.address=')) {
+			return argv[i].substring('--peer.address='.length);
+		}
+	}
+	return process.env.CORE_PEER_ADDRESS;
+}
+
+function getCredentials() {
+	if (process.env.CORE_PEER_TLS_ENABLED !== 'true') {
+		return {credentials: grpc.credentials.createInsecure(), options: {}};
+	}
+	const cert = fs.readFileSync(process.env.CORE_PEER_TLS_CERT_FILE);
+	const options = {};
+	if (process.env.CORE_PEER_TLS_SERVERHOSTOVERRIDE) {
+		options['grpc.ssl_target_name_override'] = process.env.CORE_PEER_TLS_SERVERHOSTOVERRIDE;
+	}
+	return {credentials: grpc.credentials.createSsl(cert), options: options};
+}
+
+// start connects the chaincode to the peer and serves its requests until the
+// stream is closed. The chaincode is an object with Init, Invoke and Query
+// functions that take a stub and return the result or a promise of it. It may
+// define Migrate(stub, fromVersion, toVersion) to migrate its state when the
+// chaincode is upgraded, and SetLogLevel(module, level) to apply the log levels
+// set by the peer administrator.
+function start(chaincode) {
+	for (let fcn of ['Init', 'Invoke', 'Query']) {
+		if (typeof chaincode[fcn] !== 'function') {
+			throw new Error('The chaincode does not implement ' + fcn);
+		}
+	}
+
+	const chaincodeName = process.env.CORE_CHAINCODE_ID_NAME;
+	if (!chaincodeName) {
+		throw new Error('Error chaincode id not provided');
+	}
+	const peerAddress = getPeerAddress(process.argv);
+	if (!peerAddress) {
+		throw new Error('peer.address not configured, can\'t connect to peer');
+	}
+
+	const creds = getCredentials();
+	const client = new protos.chaincode.ChaincodeSupport(peerAddress, creds.credentials, creds.options);
+	const stream = client.register();
+	const handler = new ChaincodeHandler(chaincode, stream);
+
+	stream.on('data', (msg) => {
+		handler.handleMessage(msg);
+	});
+	stream.on('error', (err) => {
+		console.error('Error chatting with the peer at address=%s: %s', peerAddress, err);
+		process.exit(1);
+	});
+	stream.on('end', () => {
+		stream.end();
+	});
+
+	handler.register(chaincodeName);
+	return handler;
+}
+
+module.exports.start = start;
+module.exports.ChaincodeStub = require('./stub.js');
+module.exports.error = response.error;
+module.exports.OK = response.OK;
+module.exports.ERRORTHRESHOLD = response.ERRORTHRESHOLD;
+module.exports.ERROR = response.ERROR;
@@ -0,0 +1,256 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+'use strict';
+
+const protos = require('./protos.js');
+const ChaincodeStub = require('./stub.js');
+const response = require('./response.js');
+
+const _pb = protos.chaincode;
+const MSG_TYPE = _pb.ChaincodeMessage.Type;
+
+// the function the peer invokes on an upgraded chaincode to migrate its state
+const MIGRATE_FUNCTION = 'Migrate';
+
+// messages received through grpc carry the enum either as its name or its
+// value depending on the deserializer, compare against both
+function isType(msg, name) {
+	return msg.type === name || msg.type === MSG_TYPE[name];
+}
+
+function typeName(msg) {
+	if (typeof msg.type === 'string') {
+		return msg.type;
+	}
+	for (let name in MSG_TYPE) {
+		if (MSG_TYPE[name] === msg.type) {
+			return name;
+		}
+	}
+	return String(msg.type);
+}
+
+function shorttxid(txid) {
+	return txid && txid.length > 8 ? txid.substring(0, 8) : txid;
+}
+
+// ChaincodeHandler drives the chat stream with the peer. Init, Invoke and
+// Query requests are dispatched to the chaincode, the responses to the state
+// requests of a running transaction are matched to it by the txid.
+class ChaincodeHandler {
+	constructor(chaincode, stream) {
+		this.chaincode = chaincode;
+		this.stream = stream;
+		this.state = 'created';
+		this.pending = {};
+	}
+
+	send(msg) {
+		this.stream.write(msg);
+	}
+
+	register(chaincodeName) {
+		const chaincodeID = new _pb.ChaincodeID({name: chaincodeName});
+		this.send({type: MSG_TYPE.REGISTER, payload: chaincodeID.toBuffer()});
+	}
+
+	handleMessage(msg) {
+		if (isType(msg, 'KEEPALIVE')) {
+			this.send(msg);
+			return;
+		}
+
+		const pending = this.pending[msg.txid];
+		if (pending && (isType(msg, 'RESPONSE') || isType(msg, 'ERROR'))) {
+			delete this.pending[msg.txid];
+			if (isType(msg, 'RESPONSE')) {
+				pending.resolve(msg.payload);
+			} else {
+				pending.reject(new Error(Buffer.from(msg.payload || []).toString('utf8')));
+			}
+			return;
+		}
+
+		switch (typeName(msg)) {
+		case 'REGISTERED':
+			this.state = 'established';
+			break;
+		case 'READY':
+			this.state = 'ready';
+			break;
+		case 'INIT':
+			this.state = 'ready';
+			this.handleTransaction(msg, 'Init', MSG_TYPE.COMPLETED, MSG_TYPE.ERROR);
+			break;
+		case 'TRANSACTION':
+			this.handleTransaction(msg, 'Invoke', MSG_TYPE.COMPLETED, MSG_TYPE.ERROR);
+			break;
+		case 'QUERY':
+			this.handleTransaction(msg, 'Query', MSG_TYPE.QUERY_COMPLETED, MSG_TYPE.QUERY_ERROR);
+			break;
+		case 'SET_LOG_LEVEL':
+			this.handleSetLogLevel(msg);
+			break;
+		default:
+			console.error('[%s]Unexpected message %s in state %s', shorttxid(msg.txid), typeName(msg), this.state);
+			this.send({type: MSG_TYPE.ERROR, payload: Buffer.from('Unexpected message ' + typeName(msg)), txid: msg.txid});
+		}
+	}
+
+	handleTransaction(msg, fcn, completedType, errorType) {
+		let input;
+		try {
+			input = _pb.ChaincodeInput.decode(msg.payload);
+		} catch (err) {
+			this.send({type: MSG_TYPE.ERROR, payload: Buffer.from(err.toString()), txid: msg.txid});
+			return;
+		}
+		const stub = new ChaincodeStub(this, msg.txid, input, msg.securityContext, msg.proposal);
+
+		Promise.resolve().then(() => {
+			const args = stub.getStringArgs();
+			if (fcn === 'Invoke' && args[0] === MIGRATE_FUNCTION) {
+				return this.migrate(stub, args);
+			}
+			return this.chaincode[fcn](stub);
+		}).then((result) => {
+			const reply = {type: completedType, payload: result ? Buffer.from(result) : Buffer.alloc(0), txid: msg.txid};
+			if (stub.chaincodeEvent && completedType === MSG_TYPE.COMPLETED) {
+				reply.chaincodeEvent = stub.chaincodeEvent;
+			}
+			this.send(reply);
+		}, (err) => {
+			console.error('[%s]%s failed: %s', shorttxid(msg.txid), fcn, err);
+			this.send({type: errorType, payload: Buffer.from(err.message || String(err)), response: response.toResponse(err), txid: msg.txid});
+		});
+	}
+
+	// handleSetLogLevel passes the log level requested by the peer to the
+	// SetLogLevel function of the chaincode, if it defines one, as the shim
+	// itself only logs errors
+	handleSetLogLevel(msg) {
+		let request;
+		try {
+			request = _pb.SetLogLevel.decode(msg.payload);
+		} catch (err) {
+			console.error('Incorrect payload format of SET_LOG_LEVEL: %s', err);
+			return;
+		}
+		if (typeof this.chaincode.SetLogLevel === 'function') {
+			this.chaincode.SetLogLevel(request.module, request.level);
+		}
+	}
+
+	// migrate calls Migrate of the chaincode with the versions it is upgraded
+	// from and to, a chaincode which does not define it ignores the migration
+	migrate(stub, args) {
+		if (typeof this.chaincode.Migrate !== 'function') {
+			return null;
+		}
+		if (args.length !== 3) {
+			throw new Error(MIGRATE_FUNCTION + ' expects the versions upgraded from and to, got ' + (args.length - 1) + ' arguments');
+		}
+		return this.chaincode.Migrate(stub, args[1], args[2]);
+	}
+
+	// request sends a state request of a transaction to the peer and resolves
+	// to the payload of the response
+	request(type, payload, txid) {
+		if (this.pending[txid]) {
+			return Promise.reject(new Error('Another state request is in progress for transaction ' + txid));
+		}
+		return new Promise((resolve, reject) => {
+			this.pending[txid] = {resolve: resolve, reject: reject};
+			this.send({type: type, payload: payload, txid: txid});
+		});
+	}
+
+	handleGetState(key, txid) {
+		return this.request(MSG_TYPE.GET_STATE, Buffer.from(key), txid);
+	}
+
+	handleGetStateMultiple(keys, txid) {
+		const payload = new _pb.GetStateMultiple({keys: keys});
+		return this.request(MSG_TYPE.GET_STATE_MULTIPLE, payload.toBuffer(), txid).then((response) => {
+			return _pb.GetStateMultipleResponse.decode(response).values.map((value) => value.toBuffer());
+		});
+	}
+
+	handleGetLedgerHeight(txid) {
+		return this.request(MSG_TYPE.GET_LEDGER_HEIGHT, Buffer.alloc(0), txid).then((response) => {
+			// the height is a uint64 decoded as a Long
+			return _pb.LedgerHeight.decode(response).height.toNumber();
+		});
+	}
+
+	handlePutState(key, value, txid) {
+		const payload = new _pb.PutStateInfo({key: key, value: Buffer.from(value)});
+		return this.request(MSG_TYPE.PUT_STATE, payload.toBuffer(), txid).then(() => {});
+	}
+
+	handleDelState(key, txid) {
+		return this.request(MSG_TYPE.DEL_STATE, Buffer.from(key), txid).then(() => {});
+	}
+
+	handleRangeQueryState(startKey, endKey, pageSize, bookmark, txid) {
+		const payload = new _pb.RangeQueryState({startKey: startKey, endKey: endKey, pageSize: pageSize, bookmark: bookmark});
+		return this.request(MSG_TYPE.RANGE_QUERY_STATE, payload.toBuffer(), txid).then(decodeQueryResponse);
+	}
+
+	handleRangeQueryStateNext(id, txid) {
+		const payload = new _pb.RangeQueryStateNext({ID: id});
+		return this.request(MSG_TYPE.RANGE_QUERY_STATE_NEXT, payload.toBuffer(), txid).then(decodeQueryResponse);
+	}
+
+	handleRangeQueryStateClose(id, txid) {
+		const payload = new _pb.RangeQueryStateClose({ID: id});
+		return this.request(MSG_TYPE.RANGE_QUERY_STATE_CLOSE, payload.toBuffer(), txid).then(decodeQueryResponse);
+	}
+
+	handleGetQueryResult(query, pageSize, bookmark, txid) {
+		const payload = new _pb.GetQueryResult({query: query, pageSize: pageSize, bookmark: bookmark});
+		return this.request(MSG_TYPE.GET_QUERY_RESULT, payload.toBuffer(), txid).then(decodeQueryResponse);
+	}
+
+	handleInvokeChaincode(chaincodeName, args, txid) {
+		const spec = new _pb.ChaincodeSpec({
+			chaincodeID: {name: chaincodeName},
+			ctorMsg: {args: args.map((arg) => Buffer.from(arg))}
+		});
+		return this.request(MSG_TYPE.INVOKE_CHAINCODE, spec.toBuffer(), txid).then((response) => {
+			const respMsg = _pb.ChaincodeMessage.decode(response);
+			if (respMsg.type === MSG_TYPE.COMPLETED) {
+				return respMsg.payload.toBuffer();
+			}
+			throw new Error(respMsg.payload.toString('utf8'));
+		});
+	}
+}
+
+function decodeQueryResponse(response) {
+	const decoded = _pb.RangeQueryStateResponse.decode(response);
+	return {
+		keysAndValues: decoded.keysAndValues.map((kv) => {
+			return {key: kv.key, value: kv.value.toBuffer()};
+		}),
+		hasMore: decoded.hasMore,
+		ID: decoded.ID,
+		metadata: decoded.metadata
+	};
+}
+
+module.exports = ChaincodeHandler;
@@ -0,0 +1,65 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+'use strict';
+
+// StateQueryIterator walks the results of a range or rich query. The results
+// are fetched from the peer in batches, next() resolves to the next key/value
+// pair or to null when the iterator is exhausted.
+class StateQueryIterator {
+	constructor(handler, txid, response) {
+		this.handler = handler;
+		this.txid = txid;
+		this.response = response;
+		this.currentLoc = 0;
+		this.closed = false;
+	}
+
+	hasNext() {
+		return this.currentLoc < this.response.keysAndValues.length || this.response.hasMore;
+	}
+
+	next() {
+		if (this.currentLoc < this.response.keysAndValues.length) {
+			const kv = this.response.keysAndValues[this.currentLoc++];
+			return Promise.resolve({key: kv.key, value: kv.value});
+		}
+		if (!this.response.hasMore) {
+			return this.close().then(() => null);
+		}
+		return this.handler.handleRangeQueryStateNext(this.response.ID, this.txid).then((response) => {
+			this.response = response;
+			this.currentLoc = 0;
+			return this.next();
+		});
+	}
+
+	close() {
+		if (this.closed) {
+			return Promise.resolve();
+		}
+		this.closed = true;
+		return this.handler.handleRangeQueryStateClose(this.response.ID, this.txid).then(() => {});
+	}
+
+	// metadata is set for the pages of a paginated query and holds the
+	// bookmark to continue from
+	getMetadata() {
+		return this.response.metadata;
+	}
+}
+
+module.exports = StateQueryIterator;
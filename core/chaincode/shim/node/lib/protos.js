@@ -0,0 +1,42 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+'use strict';
+
+const fs = require('fs');
+const path = require('path');
+const grpc = require('grpc');
+
+// The proto files are copied into the package when the nodeenv image is
+// built. Within the fabric tree they are loaded from the top level protos.
+const protoDirs = [
+	path.join(__dirname, '..', 'protos'),
+	path.join(__dirname, '..', '..', '..', '..', '..', 'protos')
+];
+
+function loadProtos() {
+	for (let dir of protoDirs) {
+		if (fs.existsSync(path.join(dir, 'chaincode.proto'))) {
+			const chaincode = grpc.load({root: dir, file: 'chaincode.proto'}).protos;
+			const proposal = grpc.load({root: dir, file: 'chaincode_proposal.proto'}).protos;
+			const header = grpc.load({root: dir, file: 'fabric_transaction_header.proto'}).protos;
+			return {chaincode: chaincode, proposal: proposal, header: header};
+		}
+	}
+	throw new Error('Cannot find the fabric proto files in ' + protoDirs.join(', '));
+}
+
+module.exports = loadProtos();
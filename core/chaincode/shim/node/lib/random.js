@@ -0,0 +1,82 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+
+'use strict';
+
+const crypto = require('crypto');
+
+// TxRandom is a deterministic pseudo-random generator for a transaction,
+// seeded with SHA-256(nonce || txid) so that all the endorsers draw the same
+// values. Block i of the stream is SHA-256(seed || i), i being a big endian
+// uint64, as in the Go shim. The values are predictable by the client and
+// must not be used where secrecy or fairness matters.
+class TxRandom {
+	constructor(txid, nonce) {
+		this.seed = crypto.createHash('sha256')
+			.update(nonce || Buffer.alloc(0))
+			.update(Buffer.from(txid || ''))
+			.digest();
+		this.counter = 0;
+		this.block = Buffer.alloc(0);
+	}
+
+	// bytes returns the next n bytes of the stream
+	bytes(n) {
+		const out = Buffer.alloc(n);
+		let offset = 0;
+		while (offset < n) {
+			if (this.block.length === 0) {
+				const counter = Buffer.alloc(8);
+				counter.writeUInt32BE(Math.floor(this.counter / 0x100000000), 0);
+				counter.writeUInt32BE(this.counter % 0x100000000, 4);
+				this.counter++;
+				this.block = crypto.createHash('sha256').update(this.seed).update(counter).digest();
+			}
+			const len = Math.min(n - offset, this.block.length);
+			this.block.copy(out, offset, 0, len);
+			this.block = this.block.slice(len);
+			offset += len;
+		}
+		return out;
+	}
+
+	// uint32 returns the next 32 bits of the stream as an unsigned integer
+	uint32() {
+		return this.bytes(4).readUInt32BE(0);
+	}
+
+	// int returns a uniform integer in [0, max), max being at most 2^32
+	int(max) {
+		if (!(max > 0 && max <= 0x100000000)) {
+			throw new Error('Invalid bound ' + max);
+		}
+		// reject the values of the last incomplete range to stay uniform
+		const limit = 0x100000000 - (0x100000000 % max);
+		let v = this.uint32();
+		while (v >= limit) {
+			v = this.uint32();
+		}
+		return v % max;
+	}
+
+	// float returns a uniform number in [0, 1)
+	float() {
+		return this.uint32() / 0x100000000;
+	}
+}
+
+module.exports = TxRandom;
@@ -0,0 +1,47 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+'use strict';
+
+// status codes of a chaincode response, a status of ERRORTHRESHOLD or above
+// fails the proposal
+const OK = 200;
+const ERRORTHRESHOLD = 400;
+const ERROR = 500;
+
+// error returns an Error failing the proposal with the given status, the
+// message being returned to the client
+function error(status, message) {
+	const err = new Error(message);
+	err.status = status;
+	return err;
+}
+
+// toResponse converts an error of the chaincode into the response sent to the
+// peer, errors without a valid failure status being internal errors
+function toResponse(err) {
+	let status = ERROR;
+	if (err && typeof err.status === 'number' && err.status >= ERRORTHRESHOLD && err.status < 600) {
+		status = err.status;
+	}
+	return {status: status, message: (err && err.message) || String(err)};
+}
+
+module.exports.OK = OK;
+module.exports.ERRORTHRESHOLD = ERRORTHRESHOLD;
+module.exports.ERROR = ERROR;
+module.exports.error = error;
+module.exports.toResponse = toResponse;
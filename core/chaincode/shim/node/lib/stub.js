@@ -0,0 +1,304 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+'use strict';
+
+const crypto = require('crypto');
+const protos = require('./protos.js');
+const StateQueryIterator = require('./iterator.js');
+const TxRandom = require('./random.js');
+
+const _pb = protos.chaincode;
+
+const COMPOSITEKEY_NS = '\u0000';
+const COMPOSITEKEY_DELIMITER = '\u0000';
+const MAX_UNICODE_RUNE = '\u{10FFFF}';
+
+function validateCompositeKeyComponent(component) {
+	if (typeof component !== 'string') {
+		throw new Error('Composite key component must be a string: ' + component);
+	}
+	if (component.indexOf('\u0000') >= 0 || component.indexOf(MAX_UNICODE_RUNE) >= 0) {
+		throw new Error('Composite key component ' + JSON.stringify(component) + ' must not contain U+0000 or U+10FFFF');
+	}
+}
+
+// ChaincodeStub is passed to the Init, Invoke and Query functions of a
+// chaincode and gives access to the arguments and the state of the
+// transaction. All state functions return promises.
+class ChaincodeStub {
+	constructor(handler, txid, input, securityContext, proposal) {
+		this.handler = handler;
+		this.txid = txid;
+		this.args = (input.args || []).map((arg) => Buffer.from(arg));
+		this.decorations = {};
+		if (input.decorations) {
+			input.decorations.forEach((value, key) => {
+				this.decorations[key] = value.toBuffer();
+			});
+		}
+		this.securityContext = securityContext;
+		this.proposal = proposal;
+		this.chaincodeEvent = null;
+		this.random = null;
+	}
+
+	getArgs() {
+		return this.args;
+	}
+
+	getStringArgs() {
+		return this.args.map((arg) => arg.toString('utf8'));
+	}
+
+	// getFunctionAndParameters returns the first argument as the function
+	// name and the rest as its parameters
+	getFunctionAndParameters() {
+		const args = this.getStringArgs();
+		return {fcn: args.length > 0 ? args[0] : '', params: args.slice(1)};
+	}
+
+	getTxID() {
+		return this.txid;
+	}
+
+	getState(key) {
+		return this.handler.handleGetState(key, this.txid);
+	}
+
+	// getStateMultipleKeys resolves to the values of the keys in order, the
+	// value of a missing key is empty
+	getStateMultipleKeys(keys) {
+		return this.handler.handleGetStateMultiple(keys, this.txid);
+	}
+
+	// getLedgerHeight resolves to the height of the ledger the transaction is
+	// simulated on. The height is recorded in the read set, so a block
+	// committed in between invalidates the transaction
+	getLedgerHeight() {
+		return this.handler.handleGetLedgerHeight(this.txid);
+	}
+
+	putState(key, value) {
+		return this.handler.handlePutState(key, value, this.txid);
+	}
+
+	delState(key) {
+		return this.handler.handleDelState(key, this.txid);
+	}
+
+	// rangeQueryState resolves to an iterator over the keys from startKey
+	// inclusive to endKey exclusive
+	rangeQueryState(startKey, endKey) {
+		return this.handler.handleRangeQueryState(startKey, endKey, 0, '', this.txid).then((response) => {
+			return new StateQueryIterator(this.handler, this.txid, response);
+		});
+	}
+
+	// rangeQueryStateWithPagination resolves to an iterator over a single
+	// page of at most pageSize results starting from the bookmark
+	rangeQueryStateWithPagination(startKey, endKey, pageSize, bookmark) {
+		if (!(pageSize > 0)) {
+			return Promise.reject(new Error('The page size must be greater than zero'));
+		}
+		return this.handler.handleRangeQueryState(startKey, endKey, pageSize, bookmark || '', this.txid).then((response) => {
+			return new StateQueryIterator(this.handler, this.txid, response);
+		});
+	}
+
+	// getQueryResult runs a rich query against the state database
+	getQueryResult(query) {
+		return this.handler.handleGetQueryResult(query, 0, '', this.txid).then((response) => {
+			return new StateQueryIterator(this.handler, this.txid, response);
+		});
+	}
+
+	getQueryResultWithPagination(query, pageSize, bookmark) {
+		if (!(pageSize > 0)) {
+			return Promise.reject(new Error('The page size must be greater than zero'));
+		}
+		return this.handler.handleGetQueryResult(query, pageSize, bookmark || '', this.txid).then((response) => {
+			return new StateQueryIterator(this.handler, this.txid, response);
+		});
+	}
+
+	createCompositeKey(objectType, attributes) {
+		validateCompositeKeyComponent(objectType);
+		let compositeKey = COMPOSITEKEY_NS + objectType + COMPOSITEKEY_DELIMITER;
+		for (let attribute of attributes) {
+			validateCompositeKeyComponent(attribute);
+			compositeKey += attribute + COMPOSITEKEY_DELIMITER;
+		}
+		return compositeKey;
+	}
+
+	splitCompositeKey(compositeKey) {
+		if (!compositeKey.startsWith(COMPOSITEKEY_NS) || !compositeKey.endsWith(COMPOSITEKEY_DELIMITER) ||
+			compositeKey.length < COMPOSITEKEY_NS.length + COMPOSITEKEY_DELIMITER.length) {
+			throw new Error('Not a composite key: ' + JSON.stringify(compositeKey));
+		}
+		const components = compositeKey.substring(COMPOSITEKEY_NS.length, compositeKey.length - COMPOSITEKEY_DELIMITER.length).split(COMPOSITEKEY_DELIMITER);
+		return {objectType: components[0], attributes: components.slice(1)};
+	}
+
+	getStateByPartialCompositeKey(objectType, attributes) {
+		let partialCompositeKey;
+		try {
+			partialCompositeKey = this.createCompositeKey(objectType, attributes);
+		} catch (err) {
+			return Promise.reject(err);
+		}
+		return this.rangeQueryState(partialCompositeKey, partialCompositeKey + MAX_UNICODE_RUNE);
+	}
+
+	// invokeChaincode calls another chaincode. An empty channel runs it on
+	// the channel of this transaction, a chaincode on another channel is run
+	// read only.
+	invokeChaincode(chaincodeName, args, channel) {
+		if (channel) {
+			chaincodeName = chaincodeName + '/' + channel;
+		}
+		return this.handler.handleInvokeChaincode(chaincodeName, args, this.txid);
+	}
+
+	// getChannelConfig resolves to the configuration values of the channel as
+	// read by QSCC: chainID, organizations and the names of the access control
+	// policies by resource. An empty channel is the channel of the proposal
+	getChannelConfig(channel) {
+		if (!channel) {
+			if (!this.proposal || !this.proposal.header || this.proposal.header.length === 0) {
+				return Promise.reject(new Error('No channel given and no proposal to take it from'));
+			}
+			const header = protos.header.Header.decode(this.proposal.header);
+			if (!header.chainID || header.chainID.remaining() === 0) {
+				return Promise.reject(new Error('No channel given and none in the proposal header'));
+			}
+			channel = header.chainID.toString('utf8');
+		}
+		return this.invokeChaincode('qscc', ['GetChannelConfig', channel]).then((response) => {
+			const decoded = _pb.ChannelConfig.decode(response);
+			const policies = {};
+			decoded.policies.forEach((policy, resource) => {
+				policies[resource] = policy;
+			});
+			return {chainID: decoded.chainID, organizations: decoded.organizations, policies: policies};
+		});
+	}
+
+	// setEvent sets the event of the transaction, it is delivered to the
+	// registered event consumers once the transaction is committed
+	setEvent(name, payload) {
+		if (!name) {
+			throw new Error('Event name can not be empty');
+		}
+		this.chaincodeEvent = new _pb.ChaincodeEvent({eventName: name, payload: payload});
+	}
+
+	// getTransient returns the transient map of the proposal, data that is
+	// passed to the chaincode but never written to the ledger
+	getTransient() {
+		const transient = {};
+		if (!this.proposal || !this.proposal.payload || this.proposal.payload.length === 0) {
+			return transient;
+		}
+		const payload = protos.proposal.ChaincodeProposalPayload.decode(this.proposal.payload);
+		if (payload.TransientMap) {
+			payload.TransientMap.forEach((value, key) => {
+				transient[key] = value.toBuffer();
+			});
+		}
+		return transient;
+	}
+
+	// getCreator returns the serialized identity of the creator of the
+	// proposal, null if there is no proposal
+	getCreator() {
+		if (!this.proposal || !this.proposal.header || this.proposal.header.length === 0) {
+			return null;
+		}
+		const header = protos.header.Header.decode(this.proposal.header);
+		return header.creator ? header.creator.toBuffer() : Buffer.alloc(0);
+	}
+
+	// getDecorations returns the decorations added to the input of the
+	// chaincode by the decorators of the endorsing peer
+	getDecorations() {
+		return this.decorations;
+	}
+
+	// getTxTimestamp returns the timestamp asserted by the client in the
+	// header of the proposal, the same for all the endorsers, as an object
+	// with seconds and nanos. null is returned if there is no proposal
+	getTxTimestamp() {
+		if (!this.proposal || !this.proposal.header || this.proposal.header.length === 0) {
+			return null;
+		}
+		const header = protos.header.Header.decode(this.proposal.header);
+		return header.timestamp;
+	}
+
+	// getRandom returns the pseudo-random generator of the transaction, seeded
+	// with the nonce of the proposal and the transaction ID so that all the
+	// endorsers draw the same values. It is shared by the calls of the
+	// transaction
+	getRandom() {
+		if (!this.random) {
+			let nonce = Buffer.alloc(0);
+			if (this.proposal && this.proposal.header && this.proposal.header.length !== 0) {
+				const header = protos.header.Header.decode(this.proposal.header);
+				if (header.nonce) {
+					nonce = header.nonce.toBuffer();
+				}
+			}
+			this.random = new TxRandom(this.txid, nonce);
+		}
+		return this.random;
+	}
+
+	// getBinding returns the binding of the proposal, the SHA-256 hash over
+	// the nonce, the creator and the epoch of its header. null is returned if
+	// there is no proposal
+	getBinding() {
+		if (!this.proposal || !this.proposal.header || this.proposal.header.length === 0) {
+			return null;
+		}
+		const header = protos.header.Header.decode(this.proposal.header);
+		// the epoch is a uint64 decoded as a Long, written little endian
+		const epoch = Buffer.alloc(8);
+		if (header.epoch) {
+			epoch.writeUInt32LE(header.epoch.low >>> 0, 0);
+			epoch.writeUInt32LE(header.epoch.high >>> 0, 4);
+		}
+		return crypto.createHash('sha256')
+			.update(header.nonce ? header.nonce.toBuffer() : Buffer.alloc(0))
+			.update(header.creator ? header.creator.toBuffer() : Buffer.alloc(0))
+			.update(epoch)
+			.digest();
+	}
+
+	// getSignedProposal returns the signed proposal being executed, null if
+	// there is no proposal. The endorser receives the proposals unsigned, so
+	// rather than a signed proposal with an empty signature it throws until
+	// signed proposals are submitted
+	getSignedProposal() {
+		if (!this.proposal) {
+			return null;
+		}
+		throw new Error('The proposal was not signed');
+	}
+}
+
+module.exports = ChaincodeStub;
@@ -0,0 +1,37 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shim
+
+import "fmt"
+
+// CheckPolicy checks that the creator of the proposal being executed holds
+// the role of the named application policy, as defined with the policy system
+// chaincode (PSCC) of the channel
+func CheckPolicy(stub ChaincodeStubInterface, policy string, role string) error {
+	creator, err := stub.GetCreator()
+	if err != nil {
+		return err
+	}
+	if len(creator) == 0 {
+		return fmt.Errorf("No creator to check against policy %s", policy)
+	}
+
+	if _, err = stub.InvokeChaincode("pscc", [][]byte{[]byte("CheckPolicy"), []byte(policy), []byte(role), creator}, ""); err != nil {
+		return fmt.Errorf("Creator does not satisfy policy %s: %s", policy, err)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shim
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math/rand"
+
+	"github.com/golang/protobuf/proto"
+	pb "github.com/hyperledger/fabric/protos"
+)
+
+// txRandSource is a deterministic source of pseudo-random numbers for a
+// transaction. Block i of the stream is SHA-256(seed || i), i being a big
+// endian uint64, so the values only depend on the seed and not on the
+// implementation of math/rand
+type txRandSource struct {
+	seed    []byte
+	counter uint64
+	block   []byte
+}
+
+// newTxRand returns a generator seeded with SHA-256(nonce || txid)
+func newTxRand(txid string, nonce []byte) *rand.Rand {
+	h := sha256.New()
+	h.Write(nonce)
+	h.Write([]byte(txid))
+	return rand.New(&txRandSource{seed: h.Sum(nil)})
+}
+
+// Int63 returns the next 63 bits of the stream
+func (s *txRandSource) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+// Uint64 returns the next 64 bits of the stream
+func (s *txRandSource) Uint64() uint64 {
+	if len(s.block) < 8 {
+		var counter [8]byte
+		binary.BigEndian.PutUint64(counter[:], s.counter)
+		s.counter++
+		block := sha256.Sum256(append(append([]byte(nil), s.seed...), counter[:]...))
+		s.block = block[:]
+	}
+	v := binary.BigEndian.Uint64(s.block[:8])
+	s.block = s.block[8:]
+	return v
+}
+
+// Seed restarts the stream from the given seed
+func (s *txRandSource) Seed(seed int64) {
+	s.seed = make([]byte, 8)
+	binary.BigEndian.PutUint64(s.seed, uint64(seed))
+	s.counter = 0
+	s.block = nil
+}
+
+// getProposalNonce returns the nonce in the header of the proposal, nil
+// without a proposal
+func getProposalNonce(prop *pb.Proposal) ([]byte, error) {
+	if prop == nil || len(prop.Header) == 0 {
+		return nil, nil
+	}
+	hdr := &pb.Header{}
+	if err := proto.Unmarshal(prop.Header, hdr); err != nil {
+		return nil, err
+	}
+	return hdr.Nonce, nil
+}
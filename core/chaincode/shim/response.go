@@ -0,0 +1,73 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shim
+
+import (
+	"fmt"
+
+	pb "github.com/hyperledger/fabric/protos"
+)
+
+// Status codes of the response of a chaincode to the client, following the
+// HTTP status codes
+const (
+	// OK is the status of a successful invocation
+	OK = 200
+
+	// ERRORTHRESHOLD is the lowest status of a failed invocation. The
+	// statuses from ERRORTHRESHOLD are application-level failures, the
+	// proposal is not endorsed
+	ERRORTHRESHOLD = 400
+
+	// ERROR is the status of an invocation failing with an error not
+	// carrying a status
+	ERROR = 500
+)
+
+// ResponseError is an error returned by a chaincode with the status and the
+// message of the response to the client
+type ResponseError struct {
+	Status  int32
+	Message string
+}
+
+func (e *ResponseError) Error() string {
+	return e.Message
+}
+
+// Error returns an error failing the invocation with the given status, which
+// must be at least ERRORTHRESHOLD, and message. For example
+//
+//	return nil, shim.Error(404, "asset not found")
+func Error(status int32, message string) error {
+	return &ResponseError{Status: status, Message: message}
+}
+
+// Errorf is Error with a formatted message
+func Errorf(status int32, format string, args ...interface{}) error {
+	return Error(status, fmt.Sprintf(format, args...))
+}
+
+// errorResponse returns the response of an invocation failing with err. The
+// status of an error not carrying a valid one is ERROR
+func errorResponse(err error) *pb.Response2 {
+	status := int32(ERROR)
+	if respErr, ok := err.(*ResponseError); ok && respErr.Status >= ERRORTHRESHOLD && respErr.Status < 600 {
+		status = respErr.Status
+	}
+	return &pb.Response2{Status: status, Message: err.Error()}
+}
@@ -0,0 +1,92 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shim
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/hyperledger/fabric/core/comm"
+	pb "github.com/hyperledger/fabric/protos"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// chaincodeServer serves the streams the peer opens to a chaincode that is run
+// as an external service.
+type chaincodeServer struct {
+	chaincodename string
+	cc            Chaincode
+}
+
+// serverStream adapts the server side of the Connect stream to
+// PeerChaincodeStream. The stream is closed when Connect returns.
+type serverStream struct {
+	pb.Chaincode_ConnectServer
+}
+
+func (s *serverStream) CloseSend() error {
+	return nil
+}
+
+// Connect registers the chaincode on the stream opened by the peer and
+// handles the peer's requests until the stream ends.
+func (s *chaincodeServer) Connect(stream pb.Chaincode_ConnectServer) error {
+	chaincodeLogger.Debugf("Peer connected, starting chat using name=%s", s.chaincodename)
+	return chatWithPeer(s.chaincodename, &serverStream{stream}, s.cc)
+}
+
+// StartServer is the entry point for chaincodes run as an external service,
+// e.g. in a Kubernetes pod rather than a container launched by the peer. It is
+// not an API for chaincodes. Instead of connecting to the peer, the chaincode
+// listens on chaincode.server.address and the peer connects to it. TLS is
+// enabled by setting chaincode.server.tls.cert.file and key.file.
+func StartServer(cc Chaincode) error {
+	setupStandaloneLogging()
+
+	chaincodename := viper.GetString("chaincode.id.name")
+	if chaincodename == "" {
+		return fmt.Errorf("Error chaincode id not provided")
+	}
+
+	address := viper.GetString("chaincode.server.address")
+	if address == "" {
+		return fmt.Errorf("Error chaincode server address not provided")
+	}
+
+	opts := []grpc.ServerOption{comm.MessageSizeServerOption(comm.GetMessageSizeOptions())}
+	certFile := viper.GetString("chaincode.server.tls.cert.file")
+	if certFile != "" {
+		creds, err := credentials.NewServerTLSFromFile(certFile, viper.GetString("chaincode.server.tls.key.file"))
+		if err != nil {
+			return fmt.Errorf("Error creating TLS credentials for the chaincode server: %s", err)
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+
+	lis, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("Error listening on %s: %s", address, err)
+	}
+
+	grpcServer := grpc.NewServer(opts...)
+	pb.RegisterChaincodeServer(grpcServer, &chaincodeServer{chaincodename: chaincodename, cc: cc})
+
+	chaincodeLogger.Infof("Chaincode %s serving on %s", chaincodename, address)
+	return grpcServer.Serve(lis)
+}
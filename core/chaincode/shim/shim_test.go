@@ -17,9 +17,17 @@ limitations under the License.
 package shim
 
 import (
+	"bytes"
+	"errors"
 	"os"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	pb "github.com/hyperledger/fabric/protos"
+	"github.com/hyperledger/fabric/protos/utils"
 	"github.com/op/go-logging"
 )
 
@@ -134,3 +142,378 @@ func TestChaincodeLogging(t *testing.T) {
 		t.Errorf("'bar' should be enabled for LogCritical")
 	}
 }
+
+// TestGetCreator tests that the creator is taken from the proposal header
+func TestGetCreator(t *testing.T) {
+	stub := InitTestStub("invoke")
+	if creator, err := stub.GetCreator(); err != nil || creator != nil {
+		t.Fatalf("Expected no creator without a proposal, got %v, error %v", creator, err)
+	}
+
+	hdr, err := proto.Marshal(&pb.Header{Creator: []byte("cert")})
+	if err != nil {
+		t.Fatalf("Error marshalling the header: %s", err)
+	}
+	stub.proposal = &pb.Proposal{Header: hdr}
+	creator, err := stub.GetCreator()
+	if err != nil || !bytes.Equal(creator, []byte("cert")) {
+		t.Fatalf("Unexpected creator %s, error %v", creator, err)
+	}
+
+	stub.proposal = &pb.Proposal{Header: []byte("garbage")}
+	if _, err = stub.GetCreator(); err == nil {
+		t.Fatalf("Expected an error for a malformed proposal header")
+	}
+}
+
+// TestGetSignedProposal tests that a proposal received unsigned is not
+// returned as a signed proposal with an empty signature
+func TestGetSignedProposal(t *testing.T) {
+	stub := InitTestStub("invoke")
+	if sp, err := stub.GetSignedProposal(); err != nil || sp != nil {
+		t.Fatalf("Expected no proposal, got %v, error %v", sp, err)
+	}
+
+	stub.proposal = &pb.Proposal{Header: []byte("header"), Payload: []byte("payload")}
+	if sp, err := stub.GetSignedProposal(); err != ErrProposalNotSigned || sp != nil {
+		t.Fatalf("Expected ErrProposalNotSigned, got %v, error %v", sp, err)
+	}
+}
+
+// signedProposalChaincode returns the signature of the signed proposal it
+// executes
+type signedProposalChaincode struct {
+}
+
+func (cc *signedProposalChaincode) Init(stub ChaincodeStubInterface) ([]byte, error) {
+	return cc.Invoke(stub)
+}
+
+func (cc *signedProposalChaincode) Invoke(stub ChaincodeStubInterface) ([]byte, error) {
+	sp, err := stub.GetSignedProposal()
+	if err != nil {
+		return nil, err
+	}
+	return sp.Signature, nil
+}
+
+func (cc *signedProposalChaincode) Query(stub ChaincodeStubInterface) ([]byte, error) {
+	return nil, nil
+}
+
+// TestHandlerSignedProposal tests that the signed proposal sent by the peer
+// along with a transaction is returned to the chaincode by the stub
+func TestHandlerSignedProposal(t *testing.T) {
+	stream := &chanStream{sent: make(chan *pb.ChaincodeMessage, 1)}
+	handler := newChaincodeHandler(stream, &signedProposalChaincode{})
+	for _, msg := range []*pb.ChaincodeMessage{{Type: pb.ChaincodeMessage_REGISTERED}, {Type: pb.ChaincodeMessage_READY}} {
+		if err := handler.handleMessage(msg); err != nil {
+			t.Fatalf("Error handling %s: %s", msg.Type, err)
+		}
+	}
+
+	input, err := proto.Marshal(&pb.ChaincodeInput{Args: [][]byte{[]byte("invoke")}})
+	if err != nil {
+		t.Fatalf("Error marshalling the input: %s", err)
+	}
+	prop := &pb.Proposal{Header: []byte("header"), Payload: []byte("payload")}
+	propBytes, err := proto.Marshal(prop)
+	if err != nil {
+		t.Fatalf("Error marshalling the proposal: %s", err)
+	}
+	signedProp := &pb.SignedProposal{ProposalBytes: propBytes, Signature: []byte("signature")}
+	msg := &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_TRANSACTION, Payload: input, Txid: "tx1", SecurityContext: &pb.ChaincodeSecurityContext{Payload: input}, Proposal: prop, SignedProposal: signedProp}
+	if err = handler.handleMessage(msg); err != nil {
+		t.Fatalf("Error handling the transaction: %s", err)
+	}
+
+	select {
+	case msg = <-stream.sent:
+		if msg.Type != pb.ChaincodeMessage_COMPLETED || string(msg.Payload) != "signature" {
+			t.Fatalf("Expected the signature of the proposal, got %v", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("The transaction did not complete")
+	}
+}
+
+// TestGetTxTimestamp tests that the timestamp is taken from the proposal header
+func TestGetTxTimestamp(t *testing.T) {
+	stub := InitTestStub("invoke")
+	ts := &timestamp.Timestamp{Seconds: 1481000000, Nanos: 42}
+	hdr, err := proto.Marshal(&pb.Header{Timestamp: ts})
+	if err != nil {
+		t.Fatalf("Error marshalling the header: %s", err)
+	}
+	stub.proposal = &pb.Proposal{Header: hdr}
+	txts, err := stub.GetTxTimestamp()
+	if err != nil || !proto.Equal(txts, ts) {
+		t.Fatalf("Unexpected timestamp %v, error %v", txts, err)
+	}
+}
+
+// TestGetBinding tests that the binding is computed over the proposal header
+func TestGetBinding(t *testing.T) {
+	stub := InitTestStub("invoke")
+	hdr, err := proto.Marshal(&pb.Header{Nonce: []byte("nonce"), Creator: []byte("cert"), Epoch: 1})
+	if err != nil {
+		t.Fatalf("Error marshalling the header: %s", err)
+	}
+	stub.proposal = &pb.Proposal{Header: hdr}
+	binding, err := stub.GetBinding()
+	if err != nil {
+		t.Fatalf("Error getting the binding: %s", err)
+	}
+	expected, err := utils.ComputeProposalBinding(stub.proposal)
+	if err != nil || !bytes.Equal(binding, expected) {
+		t.Fatalf("Unexpected binding %x, expected %x, error %v", binding, expected, err)
+	}
+}
+
+// TestGetDecorations tests that the decorations are taken from the input
+func TestGetDecorations(t *testing.T) {
+	input, err := proto.Marshal(&pb.ChaincodeInput{Args: [][]byte{[]byte("invoke")}, Decorations: map[string][]byte{"key": []byte("value")}})
+	if err != nil {
+		t.Fatalf("Error marshalling the input: %s", err)
+	}
+	stub := &ChaincodeStub{}
+	stub.init(&Handler{}, "TEST-txid", &pb.ChaincodeSecurityContext{Payload: input}, nil, nil)
+	if decorations := stub.GetDecorations(); len(decorations) != 1 || string(decorations["key"]) != "value" {
+		t.Fatalf("Unexpected decorations %v", decorations)
+	}
+	if decorations := InitTestStub("invoke").GetDecorations(); len(decorations) != 0 {
+		t.Fatalf("Unexpected decorations %v", decorations)
+	}
+}
+
+// TestSetLogLevel tests that the peer can change the log levels of a running
+// chaincode
+func TestSetLogLevel(t *testing.T) {
+	setLevel := func(module, level string) {
+		payload, err := proto.Marshal(&pb.SetLogLevel{Module: module, Level: level})
+		if err != nil {
+			t.Fatalf("Error marshalling the request: %s", err)
+		}
+		if err = handleSetLogLevel(&pb.ChaincodeMessage{Type: pb.ChaincodeMessage_SET_LOG_LEVEL, Payload: payload}); err != nil {
+			t.Fatalf("Error setting the log level: %s", err)
+		}
+	}
+	defer SetLoggingLevel(LogInfo)
+
+	setLevel("shim", "debug")
+	if logging.GetLevel("shim") != logging.DEBUG {
+		t.Fatalf("Unexpected level of the shim %s", logging.GetLevel("shim"))
+	}
+
+	NewLogger("setloglevel")
+	setLevel("setloglevel", "critical")
+	if logging.GetLevel("setloglevel") != logging.CRITICAL {
+		t.Fatalf("Unexpected level of the chaincode logger %s", logging.GetLevel("setloglevel"))
+	}
+
+	// an invalid level is ignored
+	setLevel("setloglevel", "invalid")
+	if logging.GetLevel("setloglevel") != logging.CRITICAL {
+		t.Fatalf("Unexpected level of the chaincode logger %s", logging.GetLevel("setloglevel"))
+	}
+
+	setLevel("", "warning")
+	if logging.GetLevel("shim") != logging.WARNING {
+		t.Fatalf("Unexpected level of the shim %s", logging.GetLevel("shim"))
+	}
+}
+
+// sendRecorder is a PeerChaincodeStream recording the messages sent to the peer
+type sendRecorder struct {
+	sent []*pb.ChaincodeMessage
+}
+
+func (s *sendRecorder) Send(msg *pb.ChaincodeMessage) error {
+	s.sent = append(s.sent, msg)
+	return nil
+}
+
+func (s *sendRecorder) Recv() (*pb.ChaincodeMessage, error) {
+	return nil, nil
+}
+
+func (s *sendRecorder) CloseSend() error {
+	return nil
+}
+
+// TestKeepaliveAnswered tests that the shim answers the keepalives of the peer,
+// which considers a chaincode that stops answering them hung
+func TestKeepaliveAnswered(t *testing.T) {
+	stream := &sendRecorder{}
+	handler := &Handler{ChatStream: stream}
+	if err := handler.handleMessage(&pb.ChaincodeMessage{Type: pb.ChaincodeMessage_KEEPALIVE}); err != nil {
+		t.Fatalf("Error handling the keepalive: %s", err)
+	}
+	if len(stream.sent) != 1 || stream.sent[0].Type != pb.ChaincodeMessage_KEEPALIVE {
+		t.Fatalf("Expected the keepalive to be answered, sent %v", stream.sent)
+	}
+}
+
+// chanStream is a PeerChaincodeStream passing the messages sent to the peer on
+// a channel, they are sent concurrently by the transactions
+type chanStream struct {
+	sent chan *pb.ChaincodeMessage
+}
+
+func (s *chanStream) Send(msg *pb.ChaincodeMessage) error {
+	s.sent <- msg
+	return nil
+}
+
+func (s *chanStream) Recv() (*pb.ChaincodeMessage, error) {
+	return nil, nil
+}
+
+func (s *chanStream) CloseSend() error {
+	return nil
+}
+
+// barrierChaincode completes its invocations only once all of them are running
+type barrierChaincode struct {
+	running sync.WaitGroup
+}
+
+func (cc *barrierChaincode) Init(stub ChaincodeStubInterface) ([]byte, error) {
+	return nil, nil
+}
+
+func (cc *barrierChaincode) Invoke(stub ChaincodeStubInterface) ([]byte, error) {
+	cc.running.Done()
+	cc.running.Wait()
+	return []byte(stub.GetTxID()), nil
+}
+
+func (cc *barrierChaincode) Query(stub ChaincodeStubInterface) ([]byte, error) {
+	return nil, nil
+}
+
+// TestConcurrentTransactions tests that the transactions sent to a chaincode
+// execute concurrently rather than one after the other
+func TestConcurrentTransactions(t *testing.T) {
+	stream := &chanStream{sent: make(chan *pb.ChaincodeMessage, 2)}
+	cc := &barrierChaincode{}
+	cc.running.Add(2)
+	handler := newChaincodeHandler(stream, cc)
+	for _, msg := range []*pb.ChaincodeMessage{{Type: pb.ChaincodeMessage_REGISTERED}, {Type: pb.ChaincodeMessage_READY}} {
+		if err := handler.handleMessage(msg); err != nil {
+			t.Fatalf("Error handling %s: %s", msg.Type, err)
+		}
+	}
+
+	input, err := proto.Marshal(&pb.ChaincodeInput{Args: [][]byte{[]byte("invoke")}})
+	if err != nil {
+		t.Fatalf("Error marshalling the input: %s", err)
+	}
+	for _, txid := range []string{"tx1", "tx2"} {
+		if err = handler.handleMessage(&pb.ChaincodeMessage{Type: pb.ChaincodeMessage_TRANSACTION, Payload: input, Txid: txid, SecurityContext: &pb.ChaincodeSecurityContext{Payload: input}}); err != nil {
+			t.Fatalf("Error handling transaction %s: %s", txid, err)
+		}
+	}
+
+	completed := make(map[string]bool)
+	for len(completed) < 2 {
+		select {
+		case msg := <-stream.sent:
+			if msg.Type != pb.ChaincodeMessage_COMPLETED || string(msg.Payload) != msg.Txid {
+				t.Fatalf("Unexpected message %v", msg)
+			}
+			completed[msg.Txid] = true
+		case <-time.After(5 * time.Second):
+			t.Fatalf("The transactions did not execute concurrently, completed %v", completed)
+		}
+	}
+	if handler.FSM.Current() != "ready" {
+		t.Fatalf("Unexpected state %s after the transactions", handler.FSM.Current())
+	}
+}
+
+// TestErrorResponse checks the status of the response of a failed invocation
+func TestErrorResponse(t *testing.T) {
+	resp := errorResponse(Errorf(404, "asset %s not found", "a"))
+	if resp.Status != 404 || resp.Message != "asset a not found" {
+		t.Fatalf("Unexpected response %v", resp)
+	}
+
+	// errors without a valid failure status are internal errors
+	for _, err := range []error{errors.New("failed"), Error(OK, "failed"), Error(700, "failed")} {
+		resp = errorResponse(err)
+		if resp.Status != ERROR || resp.Message != "failed" {
+			t.Fatalf("Unexpected response %v for %v", resp, err)
+		}
+	}
+}
+
+// batchStream is a PeerChaincodeStream answering the requests of the next
+// batch of a range query with the given batches, then with errors
+type batchStream struct {
+	handler *Handler
+	batches []*pb.RangeQueryStateResponse
+}
+
+func (s *batchStream) Send(msg *pb.ChaincodeMessage) error {
+	resp := &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: []byte("no more batches"), Txid: msg.Txid}
+	if len(s.batches) > 0 {
+		payload, _ := proto.Marshal(s.batches[0])
+		s.batches = s.batches[1:]
+		resp = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_RESPONSE, Payload: payload, Txid: msg.Txid}
+	}
+	go s.handler.sendChannel(resp)
+	return nil
+}
+
+func (s *batchStream) Recv() (*pb.ChaincodeMessage, error) {
+	return nil, nil
+}
+
+func (s *batchStream) CloseSend() error {
+	return nil
+}
+
+func collectKeys(iter StateRangeQueryIteratorInterface) ([]string, error) {
+	var keys []string
+	for iter.HasNext() {
+		key, _, err := iter.Next()
+		if err != nil {
+			return keys, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// TestRangeQueryIteratorHasNext tests that HasNext fetches the next batches,
+// an empty last batch ending the iteration, and that Next reports the failure
+// to fetch a batch
+func TestRangeQueryIteratorHasNext(t *testing.T) {
+	stream := &batchStream{batches: []*pb.RangeQueryStateResponse{
+		{KeysAndValues: []*pb.RangeQueryStateKeyValue{{Key: "b"}}, HasMore: true, ID: "iter"},
+		{HasMore: false, ID: "iter"},
+	}}
+	stream.handler = &Handler{ChatStream: stream, responseChannel: make(map[string]chan pb.ChaincodeMessage)}
+	first := &pb.RangeQueryStateResponse{KeysAndValues: []*pb.RangeQueryStateKeyValue{{Key: "a"}}, HasMore: true, ID: "iter"}
+
+	iter := &StateRangeQueryIterator{handler: stream.handler, uuid: "tx1", response: first}
+	keys, err := collectKeys(iter)
+	if err != nil || len(keys) != 2 || keys[0] != "a" || keys[1] != "b" {
+		t.Fatalf("Expected keys [a b], got %v, err %v", keys, err)
+	}
+	if _, _, err = iter.Next(); err == nil {
+		t.Fatalf("Expected an error reading past the end of the iterator")
+	}
+
+	// the peer fails to return the next batch
+	first.HasMore = true
+	iter = &StateRangeQueryIterator{handler: stream.handler, uuid: "tx2", response: first}
+	keys, err = collectKeys(iter)
+	if err == nil || len(keys) != 1 {
+		t.Fatalf("Expected the error fetching the next batch after key a, got %v, err %v", keys, err)
+	}
+	if iter.HasNext() {
+		t.Fatalf("Expected the iterator to be exhausted after the error")
+	}
+}
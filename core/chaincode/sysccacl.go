@@ -0,0 +1,96 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chaincode
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric/core/chaincode/acl"
+	"github.com/spf13/viper"
+)
+
+//SysCCACLErr system chaincode function not permitted to the creator error
+type SysCCACLErr string
+
+func (f SysCCACLErr) Error() string {
+	return fmt.Sprintf("creator is not permitted to invoke %s", string(f))
+}
+
+//UnknownSysCCACLPolicyErr policy neither predefined nor a configuration key
+//error
+type UnknownSysCCACLPolicyErr string
+
+func (f UnknownSysCCACLPolicyErr) Error() string {
+	return fmt.Sprintf("unknown system chaincode policy %s", string(f))
+}
+
+//sysCCACL returns the policy controlling the invocation of the function of
+//the system chaincode, configured for the function or else for the whole
+//system chaincode. System chaincodes without a policy are permitted to
+//everyone
+func sysCCACL(name string, function string) string {
+	acls := viper.GetStringMapString("chaincode.acls")
+	//viper keys are case insensitive
+	if policy, ok := acls[strings.ToLower(name+"/"+function)]; ok {
+		return policy
+	}
+	return acls[strings.ToLower(name)]
+}
+
+//CheckSysCCACL checks that the creator of a proposal may invoke the function
+//of the system chaincode according to the policies in "chaincode.acls". The
+//policies are
+//  any      everyone
+//  admins   the identities in "peer.admins"
+//  readers  the identities in "ledger.query.readers" and the members of the
+//           organizations of the chain in "chaincode.lifecycle.organizations"
+//  members  the members of the organizations of the chain
+//or else the configuration key, which must be set, of a list of files holding
+//the identities permitted. A policy listing no identity permits no one
+func CheckSysCCACL(chainID string, name string, function string, creator []byte) error {
+	policy := strings.TrimSpace(sysCCACL(name, function))
+
+	var files []string
+	switch policy {
+	case "", "any":
+		return nil
+	case "admins":
+		files = viper.GetStringSlice("peer.admins")
+	case "readers", "members":
+		if policy == "readers" {
+			files = viper.GetStringSlice("ledger.query.readers")
+		}
+		orgs, err := GetChainOrganizations(chainID)
+		if err != nil {
+			return err
+		}
+		for _, org := range orgs {
+			files = append(files, org.Members...)
+		}
+	default:
+		if !viper.IsSet(policy) {
+			return UnknownSysCCACLPolicyErr(policy)
+		}
+		files = viper.GetStringSlice(policy)
+	}
+
+	if !acl.IsListed(files, policy, creator) {
+		return SysCCACLErr(name + "/" + function)
+	}
+	return nil
+}
@@ -34,7 +34,7 @@ var sysccLogger = logging.MustGetLogger("sysccapi")
 
 // SystemChaincode defines the metadata needed to initialize system chaincode
 // when the fabric comes up. SystemChaincodes are installed by adding an
-// entry in importsysccs.go
+// entry in importsysccs.go or by calling AddSysCC before the peer starts
 type SystemChaincode struct {
 	// Enabled a convenient switch to enable/disable system chaincode without
 	// having to remove entry from importsysccs.go
@@ -147,9 +147,17 @@ func DeploySysCC(ctx context.Context, spec *protos.ChaincodeSpec) error {
 	return err
 }
 
+//isWhitelisted returns true if the system chaincode is enabled in the
+//"chaincode.system" whitelist, either for everyone ("enable", "true", "yes")
+//or only for the peer and other chaincodes ("internal")
 func isWhitelisted(syscc *SystemChaincode) bool {
 	chaincodes := viper.GetStringMapString("chaincode.system")
 	val, ok := chaincodes[syscc.Name]
-	enabled := val == "enable" || val == "true" || val == "yes"
+	enabled := val == "enable" || val == "true" || val == "yes" || val == "internal"
 	return ok && enabled
 }
+
+//isInternal returns true if clients may not invoke the system chaincode
+func isInternal(syscc *SystemChaincode) bool {
+	return viper.GetStringMapString("chaincode.system")[syscc.Name] == "internal"
+}
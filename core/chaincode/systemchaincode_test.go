@@ -17,7 +17,10 @@ limitations under the License.
 package chaincode
 
 import (
+	"io/ioutil"
 	"net"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -104,3 +107,99 @@ func TestExecuteDeploySysChaincode(t *testing.T) {
 
 	closeListenerAndSleep(lis)
 }
+
+func TestAddSysCC(t *testing.T) {
+	saved := systemChaincodes
+	defer func() { systemChaincodes = saved }()
+	systemChaincodes = []*SystemChaincode{}
+
+	syscc := &SystemChaincode{Enabled: true, Name: "sample_syscc", Path: "github.com/hyperledger/fabric/core/system_chaincode/samplesyscc", Chaincode: &samplesyscc.SampleSysCC{}}
+	if err := AddSysCC(syscc); err != nil {
+		t.Fatalf("Error adding sample_syscc: %s", err)
+	}
+	if !IsSysCC("sample_syscc") {
+		t.Fatalf("sample_syscc expected to be a system chaincode")
+	}
+	if err := AddSysCC(syscc); err == nil {
+		t.Fatalf("sample_syscc expected to be rejected when added twice")
+	}
+	if err := AddSysCC(&SystemChaincode{Enabled: true}); err == nil {
+		t.Fatalf("system chaincode without a name expected to be rejected")
+	}
+}
+
+func TestSysCCInvokableExternal(t *testing.T) {
+	saved := systemChaincodes
+	defer func() { systemChaincodes = saved }()
+	systemChaincodes = []*SystemChaincode{
+		{Enabled: true, Name: "external_syscc"},
+		{Enabled: true, Name: "internal_syscc"},
+		{Enabled: true, Name: "unlisted_syscc"},
+		{Enabled: false, Name: "disabled_syscc"},
+	}
+
+	viper.Set("chaincode.system", map[string]string{"external_syscc": "enable", "internal_syscc": "internal", "disabled_syscc": "enable"})
+	defer viper.Set("chaincode.system", map[string]string{})
+
+	expected := map[string]bool{"external_syscc": true, "internal_syscc": false, "unlisted_syscc": false, "disabled_syscc": false, "mycc": false}
+	for name, invokable := range expected {
+		if IsSysCCInvokableExternal(name) != invokable {
+			t.Fatalf("%s expected invokable by clients to be %t", name, invokable)
+		}
+	}
+	if !isWhitelisted(systemChaincodes[1]) {
+		t.Fatalf("internal_syscc expected to be whitelisted")
+	}
+}
+
+func TestCheckSysCCACL(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sysccacl")
+	if err != nil {
+		t.Fatalf("Error creating temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	admin := filepath.Join(dir, "admin.pem")
+	member := filepath.Join(dir, "member.pem")
+	ioutil.WriteFile(admin, []byte("admin\n"), 0644)
+	ioutil.WriteFile(member, []byte("member"), 0644)
+
+	viper.Set("peer.admins", []string{admin})
+	defer viper.Set("peer.admins", nil)
+	viper.Set("chaincode.lifecycle.organizations", []map[string]interface{}{{"name": "org1", "members": []string{member}}})
+	defer viper.Set("chaincode.lifecycle.organizations", nil)
+	viper.Set("chaincode.acls", map[string]string{"qscc": "readers", "cscc": "admins", "cscc/getchannels": "members", "tscc/issue": "peer.admins", "iscc": "peer.admin", "pscc": "ledger.query.readers"})
+	defer viper.Set("chaincode.acls", nil)
+
+	for _, c := range []struct {
+		name     string
+		function string
+		creator  string
+		expected bool
+	}{
+		{"qscc", "GetChainInfo", "member", true},
+		{"qscc", "GetChainInfo", "other", false},
+		{"cscc", "JoinChain", "admin", true},
+		{"cscc", "JoinChain", "member", false},
+		{"cscc", "GetChannels", "member", true},
+		{"cscc", "GetChannels", "admin", false},
+		{"tscc", "Issue", "admin", true},
+		{"tscc", "Issue", "", false},
+		{"tscc", "Transfer", "other", true},
+		{"iscc", "Register", "admin", false},
+		{"pscc", "SetPolicy", "admin", false},
+	} {
+		err = CheckSysCCACL(string(DefaultChain), c.name, c.function, []byte(c.creator))
+		if c.expected && err != nil {
+			t.Fatalf("%s/%s should be permitted to %q: %s", c.name, c.function, c.creator, err)
+		}
+		if !c.expected && err == nil {
+			t.Fatalf("%s/%s should not be permitted to %q", c.name, c.function, c.creator)
+		}
+	}
+
+	//a policy listing no identity permits no one
+	viper.Set("peer.admins", []string{})
+	if err = CheckSysCCACL(string(DefaultChain), "cscc", "JoinChain", []byte("admin")); err == nil {
+		t.Fatalf("cscc/JoinChain should not be permitted when no admin is listed")
+	}
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package comm
 
 import (
+	"crypto/tls"
+	"fmt"
 	"os"
 	"time"
 
@@ -53,7 +55,8 @@ func NewClientConnectionWithAddress(peerAddress string, block bool, tslEnabled b
 	} else {
 		opts = append(opts, grpc.WithInsecure())
 	}
-	opts = append(opts, grpc.WithTimeout(defaultTimeout))
+	opts = append(opts, grpc.WithTimeout(defaultTimeout), ClientKeepalive().DialOption(),
+		MessageSizeDialOption(GetMessageSizeOptions()))
 	if block {
 		opts = append(opts, grpc.WithBlock())
 	}
@@ -66,19 +69,62 @@ func NewClientConnectionWithAddress(peerAddress string, block bool, tslEnabled b
 
 // InitTLSForPeer returns TLS credentials for peer
 func InitTLSForPeer() credentials.TransportCredentials {
-	var sn string
-	if viper.GetString("peer.tls.serverhostoverride") != "" {
-		sn = viper.GetString("peer.tls.serverhostoverride")
+	creds, err := PeerClientTLS()
+	if err != nil {
+		grpclog.Fatalf("Failed to create TLS credentials %v", err)
 	}
-	var creds credentials.TransportCredentials
+	return creds
+}
+
+// PeerClientTLS returns the TLS credentials of the clients of the peer, with
+// the TLS versions and cipher suites of GetTLSProtocolConfig, presenting the
+// client certificate of "peer.tls.clientCert.file" if set, or an error if
+// the root CA of "peer.tls.cert.file" or the client certificate cannot be
+// loaded
+func PeerClientTLS() (credentials.TransportCredentials, error) {
+	var rootCAs []string
 	if viper.GetString("peer.tls.cert.file") != "" {
-		var err error
-		creds, err = credentials.NewClientTLSFromFile(viper.GetString("peer.tls.cert.file"), sn)
+		rootCAs = []string{viper.GetString("peer.tls.cert.file")}
+	}
+	return clientTLS(viper.GetString("peer.tls.serverhostoverride"), rootCAs)
+}
+
+// OrdererTLSEnabled returns whether the peer connects to the orderers over
+// TLS, "peer.committer.ledger.tls.enabled"
+func OrdererTLSEnabled() bool {
+	return viper.GetBool("peer.committer.ledger.tls.enabled")
+}
+
+// OrdererClientTLS returns the TLS credentials of the connections of the peer
+// to the orderers, trusting the root CAs of
+// "peer.committer.ledger.tls.rootCAs.files", or those of the host if none is
+// listed. The client certificate, TLS versions and cipher suites are those of
+// PeerClientTLS
+func OrdererClientTLS() (credentials.TransportCredentials, error) {
+	return clientTLS(viper.GetString("peer.committer.ledger.tls.serverhostoverride"),
+		viper.GetStringSlice("peer.committer.ledger.tls.rootCAs.files"))
+}
+
+// clientTLS returns the TLS credentials of a client of the peer trusting the
+// root CAs of the files listed, those of the host if none is
+func clientTLS(serverName string, rootCAFiles []string) (credentials.TransportCredentials, error) {
+	protocol, err := GetTLSProtocolConfig()
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{ServerName: serverName}
+	if len(rootCAFiles) > 0 {
+		if config.RootCAs, err = loadCertPool(rootCAFiles...); err != nil {
+			return nil, err
+		}
+	}
+	if certFile := viper.GetString("peer.tls.clientCert.file"); certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, viper.GetString("peer.tls.clientKey.file"))
 		if err != nil {
-			grpclog.Fatalf("Failed to create TLS credentials %v", err)
+			return nil, fmt.Errorf("Failed to load the client certificate %s: %s", certFile, err)
 		}
-	} else {
-		creds = credentials.NewClientTLSFromCert(nil, sn)
+		config.Certificates = []tls.Certificate{cert}
 	}
-	return creds
+	protocol.Apply(config)
+	return credentials.NewTLS(config), nil
 }
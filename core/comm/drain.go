@@ -0,0 +1,101 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package comm
+
+import (
+	"sync"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// Drainer tracks the requests of services in flight and, once draining,
+// rejects their new requests with an Unavailable error, for the clients to
+// turn to another peer
+type Drainer struct {
+	services map[string]bool
+
+	lock     sync.Mutex
+	draining bool
+	inFlight sync.WaitGroup
+}
+
+// NewDrainer returns the Drainer of the services, by gRPC service name
+func NewDrainer(services ...string) *Drainer {
+	d := &Drainer{services: make(map[string]bool)}
+	for _, service := range services {
+		d.services[service] = true
+	}
+	return d
+}
+
+// enter returns the function ending the request of fullMethod, or an
+// Unavailable error if the service is drained
+func (d *Drainer) enter(fullMethod string) (func(), error) {
+	service, _ := splitMethodName(fullMethod)
+	if !d.services[service] {
+		return func() {}, nil
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.draining {
+		return nil, grpc.Errorf(codes.Unavailable, "%s is shutting down", service)
+	}
+	d.inFlight.Add(1)
+	return d.inFlight.Done, nil
+}
+
+// UnaryServerInterceptor rejects the requests of the drained services
+func (d *Drainer) UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	done, err := d.enter(info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	return handler(ctx, req)
+}
+
+// StreamServerInterceptor rejects the streams of the drained services
+func (d *Drainer) StreamServerInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	done, err := d.enter(info.FullMethod)
+	if err != nil {
+		return err
+	}
+	defer done()
+	return handler(srv, ss)
+}
+
+// Drain rejects the new requests of the services and waits for those in
+// flight to end, or for ctx to be done
+func (d *Drainer) Drain(ctx context.Context) error {
+	d.lock.Lock()
+	d.draining = true
+	d.lock.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		d.inFlight.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,64 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package comm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func TestDrainer(t *testing.T) {
+	drainer := NewDrainer("protos.Endorser")
+	call := func(method string, handler grpc.UnaryHandler) error {
+		_, err := drainer.UnaryServerInterceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		return err
+	}
+	immediate := func(ctx context.Context, req interface{}) (interface{}, error) { return nil, nil }
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- call("/protos.Endorser/ProcessProposal", func(ctx context.Context, req interface{}) (interface{}, error) {
+			close(entered)
+			<-release
+			return nil, nil
+		})
+	}()
+	<-entered
+
+	// The proposal in flight outlasts the grace period
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, drainer.Drain(ctx))
+
+	// The new proposals are rejected, the other services are not drained
+	assert.Equal(t, codes.Unavailable, grpc.Code(call("/protos.Endorser/ProcessProposal", immediate)))
+	assert.NoError(t, call("/protos.Admin/GetStatus", immediate))
+	err := drainer.StreamServerInterceptor(nil, nil, &grpc.StreamServerInfo{FullMethod: "/protos.Endorser/ProcessProposalStream"},
+		func(srv interface{}, ss grpc.ServerStream) error { return nil })
+	assert.Equal(t, codes.Unavailable, grpc.Code(err))
+
+	close(release)
+	assert.NoError(t, <-done)
+	assert.NoError(t, drainer.Drain(context.Background()))
+}
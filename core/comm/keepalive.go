@@ -0,0 +1,181 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package comm
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric/core/config"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+)
+
+// KeepaliveOptions configures the keepalive of the TCP connections of the
+// gRPC servers and clients of the peer. An idle connection is probed after
+// Time and closed if the probe is not answered within Timeout. The probes
+// are sent whether or not the connection carries streams. Zero values keep
+// the defaults of the net package
+type KeepaliveOptions struct {
+	Time    time.Duration
+	Timeout time.Duration
+}
+
+// GetServerKeepaliveOptions returns the KeepaliveOptions of the connections
+// accepted by the peer, from the "peer.keepalive.server" settings
+func GetServerKeepaliveOptions() KeepaliveOptions {
+	return keepaliveOptions(viper.GetDuration, "peer.keepalive.server")
+}
+
+// GetClientKeepaliveOptions returns the KeepaliveOptions of the connections
+// of the clients of the peer, to peers and from chaincodes, from the
+// "peer.keepalive.client" settings
+func GetClientKeepaliveOptions() KeepaliveOptions {
+	return keepaliveOptions(viper.GetDuration, "peer.keepalive.client")
+}
+
+func keepaliveOptions(getDuration func(key string) time.Duration, prefix string) KeepaliveOptions {
+	return KeepaliveOptions{
+		Time:    getDuration(prefix + ".time"),
+		Timeout: getDuration(prefix + ".timeout"),
+	}
+}
+
+func (o KeepaliveOptions) validate() error {
+	if o.Time < 0 || o.Timeout < 0 {
+		return fmt.Errorf("Invalid keepalive time %s or timeout %s", o.Time, o.Timeout)
+	}
+	return nil
+}
+
+func (o KeepaliveOptions) config() net.KeepAliveConfig {
+	config := net.KeepAliveConfig{Enable: true, Idle: o.Time, Interval: o.Timeout}
+	if o.Timeout > 0 {
+		// A single probe, unanswered for Timeout, closes the connection
+		config.Count = 1
+	}
+	return config
+}
+
+func (o KeepaliveOptions) apply(conn net.Conn) error {
+	if tcpConn, ok := conn.(*net.TCPConn); ok {
+		return tcpConn.SetKeepAliveConfig(o.config())
+	}
+	return nil
+}
+
+// Keepalive holds KeepaliveOptions that may be replaced at runtime. The
+// options in force apply to the connections accepted or dialed afterwards
+type Keepalive struct {
+	lock    sync.RWMutex
+	options KeepaliveOptions
+}
+
+// NewKeepalive returns a Keepalive holding options
+func NewKeepalive(options KeepaliveOptions) *Keepalive {
+	return &Keepalive{options: options}
+}
+
+// Options returns the KeepaliveOptions in force
+func (k *Keepalive) Options() KeepaliveOptions {
+	k.lock.RLock()
+	defer k.lock.RUnlock()
+	return k.options
+}
+
+// SetOptions replaces the KeepaliveOptions in force
+func (k *Keepalive) SetOptions(options KeepaliveOptions) {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	k.options = options
+}
+
+// WatchConfig lets the changes of the "time" and "timeout" settings under
+// prefix in the config file replace the options of k, see
+// config.ConfigWatcher
+func (k *Keepalive) WatchConfig(prefix string) {
+	config.RegisterDynamicSetting(prefix, config.DynamicSetting{
+		Keys: []string{prefix + ".time", prefix + ".timeout"},
+		Apply: func(c *viper.Viper) error {
+			options := keepaliveOptions(c.GetDuration, prefix)
+			if err := options.validate(); err != nil {
+				return err
+			}
+			k.SetOptions(options)
+			return nil
+		},
+	})
+}
+
+type keepaliveListener struct {
+	net.Listener
+	keepalive *Keepalive
+}
+
+func (l *keepaliveListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	if err = l.keepalive.Options().apply(conn); err != nil {
+		commLogger.Warningf("Failed to set the keepalive of connection from %s: %s", conn.RemoteAddr(), err)
+	}
+	return conn, nil
+}
+
+// Listener returns a listener setting the KeepaliveOptions in force of the
+// connections accepted by lis
+func (k *Keepalive) Listener(lis net.Listener) net.Listener {
+	return &keepaliveListener{Listener: lis, keepalive: k}
+}
+
+// DialOption returns the DialOption of a gRPC client setting the
+// KeepaliveOptions in force of its connections
+func (k *Keepalive) DialOption() grpc.DialOption {
+	return grpc.WithDialer(func(addr string, timeout time.Duration) (net.Conn, error) {
+		dialer := &net.Dialer{Timeout: timeout, KeepAliveConfig: k.Options().config()}
+		return dialer.Dial("tcp", addr)
+	})
+}
+
+// KeepaliveListener returns a listener setting the KeepaliveOptions of the
+// connections accepted by lis
+func KeepaliveListener(lis net.Listener, options KeepaliveOptions) net.Listener {
+	return NewKeepalive(options).Listener(lis)
+}
+
+// KeepaliveDialOption returns the DialOption of a gRPC client setting the
+// KeepaliveOptions of its connections
+func KeepaliveDialOption(options KeepaliveOptions) grpc.DialOption {
+	return NewKeepalive(options).DialOption()
+}
+
+var clientKeepalive struct {
+	sync.Once
+	keepalive *Keepalive
+}
+
+// ClientKeepalive returns the Keepalive of the clients of the peer, holding
+// the GetClientKeepaliveOptions of the time of its first use
+func ClientKeepalive() *Keepalive {
+	clientKeepalive.Do(func() {
+		clientKeepalive.keepalive = NewKeepalive(GetClientKeepaliveOptions())
+	})
+	return clientKeepalive.keepalive
+}
@@ -0,0 +1,80 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package comm
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeepaliveOptions(t *testing.T) {
+	viper.Set("peer.keepalive.server.time", "2m")
+	viper.Set("peer.keepalive.server.timeout", "20s")
+	defer viper.Set("peer.keepalive.server.time", "")
+	defer viper.Set("peer.keepalive.server.timeout", "")
+
+	options := GetServerKeepaliveOptions()
+	assert.Equal(t, KeepaliveOptions{Time: 2 * time.Minute, Timeout: 20 * time.Second}, options)
+	assert.Equal(t, net.KeepAliveConfig{Enable: true, Idle: 2 * time.Minute, Interval: 20 * time.Second, Count: 1}, options.config())
+	assert.Equal(t, net.KeepAliveConfig{Enable: true}, GetClientKeepaliveOptions().config())
+}
+
+func TestKeepaliveListener(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %s", err)
+	}
+	options := KeepaliveOptions{Time: time.Minute, Timeout: 10 * time.Second}
+	lis = KeepaliveListener(lis, options)
+	defer lis.Close()
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, err := lis.Accept()
+		assert.NoError(t, err)
+		accepted <- conn
+	}()
+
+	conn, err := net.DialTimeout("tcp", lis.Addr().String(), time.Second)
+	if err != nil {
+		t.Fatalf("Failed to dial: %s", err)
+	}
+	defer conn.Close()
+	serverConn := <-accepted
+	defer serverConn.Close()
+
+	// The accepted connection is usable
+	_, err = conn.Write([]byte("ping"))
+	assert.NoError(t, err)
+	buf := make([]byte, 4)
+	_, err = serverConn.Read(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "ping", string(buf))
+}
+
+func TestKeepaliveSetOptions(t *testing.T) {
+	k := NewKeepalive(KeepaliveOptions{Time: time.Minute})
+	k.SetOptions(KeepaliveOptions{Time: 2 * time.Minute, Timeout: 10 * time.Second})
+	assert.Equal(t, KeepaliveOptions{Time: 2 * time.Minute, Timeout: 10 * time.Second}, k.Options())
+
+	assert.Error(t, KeepaliveOptions{Time: -time.Second}.validate())
+	assert.NoError(t, k.Options().validate())
+}
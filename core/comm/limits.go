@@ -0,0 +1,164 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package comm
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/hyperledger/fabric/core/config"
+	"github.com/spf13/viper"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// concurrencyLimitServices maps the services of the peer whose concurrency
+// may be bounded, as named in the "peer.limits.concurrency" section, to
+// their gRPC service names
+var concurrencyLimitServices = map[string]string{
+	"endorser": "protos.Endorser",
+	"deliver":  "protos.Events",
+}
+
+// GetConcurrencyLimits returns the maximum numbers of requests, and of open
+// streams, of the services of the peer, by gRPC service name, from the
+// "peer.limits.concurrency" settings. The services are not bounded when 0
+func GetConcurrencyLimits() map[string]int {
+	limits, err := concurrencyLimits(viper.GetInt)
+	if err != nil {
+		commLogger.Warningf("Ignoring the negative concurrency limits: %s", err)
+	}
+	return limits
+}
+
+func concurrencyLimits(getInt func(key string) int) (map[string]int, error) {
+	limits := make(map[string]int)
+	var err error
+	for name, service := range concurrencyLimitServices {
+		limit := getInt("peer.limits.concurrency." + name)
+		if limit < 0 && err == nil {
+			err = fmt.Errorf("Invalid concurrency limit %d of %s", limit, name)
+		}
+		if limit > 0 {
+			limits[service] = limit
+		}
+	}
+	return limits, err
+}
+
+// ConcurrencyLimiter bounds the number of requests, and of open streams, of
+// the services of gRPC servers. The requests in excess are rejected with a
+// ResourceExhausted error rather than queued
+type ConcurrencyLimiter struct {
+	lock   sync.Mutex
+	limits map[string]int
+	active map[string]int
+}
+
+// NewConcurrencyLimiter returns a ConcurrencyLimiter bounding the services
+// by limits, by gRPC service name
+func NewConcurrencyLimiter(limits map[string]int) *ConcurrencyLimiter {
+	l := &ConcurrencyLimiter{active: make(map[string]int)}
+	l.SetLimits(limits)
+	return l
+}
+
+// SetLimits replaces the limits of the services. The requests already
+// executing are not interrupted when a limit is lowered, new ones are
+// rejected until there are fewer than the limit
+func (l *ConcurrencyLimiter) SetLimits(limits map[string]int) {
+	copied := make(map[string]int, len(limits))
+	for service, limit := range limits {
+		copied[service] = limit
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.limits = copied
+}
+
+// acquire returns the function releasing the slot of the service of
+// fullMethod taken, or a ResourceExhausted error if none is left
+func (l *ConcurrencyLimiter) acquire(fullMethod string) (func(), error) {
+	service, _ := splitMethodName(fullMethod)
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	limit, bounded := l.limits[service]
+	if !bounded {
+		return func() {}, nil
+	}
+	if l.active[service] >= limit {
+		commLogger.Warningf("Rejected %s, %d requests of %s executing already", fullMethod, l.active[service], service)
+		return nil, grpc.Errorf(codes.ResourceExhausted, "too many requests of %s, try again later", service)
+	}
+	l.active[service]++
+	return func() {
+		l.lock.Lock()
+		defer l.lock.Unlock()
+		l.active[service]--
+	}, nil
+}
+
+// UnaryServerInterceptor rejects the requests in excess of the limit of
+// their service
+func (l *ConcurrencyLimiter) UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	release, err := l.acquire(info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return handler(ctx, req)
+}
+
+// StreamServerInterceptor rejects the streams in excess of the limit of
+// their service
+func (l *ConcurrencyLimiter) StreamServerInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	release, err := l.acquire(info.FullMethod)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return handler(srv, ss)
+}
+
+// WatchConfig lets the changes of the "peer.limits.concurrency" settings in
+// the config file replace the limits of l, see config.ConfigWatcher
+func (l *ConcurrencyLimiter) WatchConfig() {
+	var keys []string
+	for name := range concurrencyLimitServices {
+		keys = append(keys, "peer.limits.concurrency."+name)
+	}
+	config.RegisterDynamicSetting("peer.limits.concurrency", config.DynamicSetting{
+		Keys: keys,
+		Apply: func(c *viper.Viper) error {
+			limits, err := concurrencyLimits(c.GetInt)
+			if err != nil {
+				return err
+			}
+			l.SetLimits(limits)
+			return nil
+		},
+	})
+}
+
+// ConcurrencyLimitInterceptors returns the interceptors of a gRPC server
+// bounding the number of requests, and of open streams, of its services by
+// limits, by gRPC service name, as those of a ConcurrencyLimiter
+func ConcurrencyLimitInterceptors(limits map[string]int) (grpc.UnaryServerInterceptor, grpc.StreamServerInterceptor) {
+	l := NewConcurrencyLimiter(limits)
+	return l.UnaryServerInterceptor, l.StreamServerInterceptor
+}
@@ -0,0 +1,94 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package comm
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func TestGetConcurrencyLimits(t *testing.T) {
+	viper.Set("peer.limits.concurrency.endorser", 10)
+	viper.Set("peer.limits.concurrency.deliver", 0)
+	defer viper.Set("peer.limits.concurrency.endorser", 0)
+
+	assert.Equal(t, map[string]int{"protos.Endorser": 10}, GetConcurrencyLimits())
+}
+
+func TestConcurrencyLimitInterceptors(t *testing.T) {
+	unary, stream := ConcurrencyLimitInterceptors(map[string]int{"protos.Endorser": 2, "protos.Events": 1})
+
+	// The handlers block until released, holding their slot
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	blocking := func(ctx context.Context, req interface{}) (interface{}, error) {
+		entered <- struct{}{}
+		<-release
+		return nil, nil
+	}
+	call := func(method string, handler grpc.UnaryHandler) error {
+		_, err := unary(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		return err
+	}
+	done := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() { done <- call("/protos.Endorser/ProcessProposal", blocking) }()
+		<-entered
+	}
+
+	immediate := func(ctx context.Context, req interface{}) (interface{}, error) { return nil, nil }
+	assert.Equal(t, codes.ResourceExhausted, grpc.Code(call("/protos.Endorser/ProcessProposal", immediate)))
+	// The other services are not affected
+	assert.NoError(t, call("/protos.Admin/GetStatus", immediate))
+
+	close(release)
+	assert.NoError(t, <-done)
+	assert.NoError(t, <-done)
+	assert.NoError(t, call("/protos.Endorser/ProcessProposal", immediate))
+
+	// A stream holds its slot until it ends
+	info := &grpc.StreamServerInfo{FullMethod: "/protos.Events/Chat"}
+	err := stream(nil, nil, info, func(srv interface{}, ss grpc.ServerStream) error {
+		return stream(nil, nil, info, func(srv interface{}, ss grpc.ServerStream) error { return nil })
+	})
+	assert.Equal(t, codes.ResourceExhausted, grpc.Code(err))
+	assert.NoError(t, stream(nil, nil, info, func(srv interface{}, ss grpc.ServerStream) error { return nil }))
+}
+
+func TestConcurrencyLimiterSetLimits(t *testing.T) {
+	l := NewConcurrencyLimiter(map[string]int{"protos.Events": 1})
+	info := &grpc.StreamServerInfo{FullMethod: "/protos.Events/Chat"}
+	nested := func(srv interface{}, ss grpc.ServerStream) error {
+		return l.StreamServerInterceptor(nil, nil, info, func(srv interface{}, ss grpc.ServerStream) error { return nil })
+	}
+	assert.Equal(t, codes.ResourceExhausted, grpc.Code(l.StreamServerInterceptor(nil, nil, info, nested)))
+
+	l.SetLimits(map[string]int{"protos.Events": 2})
+	assert.NoError(t, l.StreamServerInterceptor(nil, nil, info, nested))
+
+	// Unbounded once the limit is removed
+	l.SetLimits(nil)
+	assert.NoError(t, l.StreamServerInterceptor(nil, nil, info, nested))
+
+	_, err := concurrencyLimits(func(key string) int { return -1 })
+	assert.Error(t, err)
+}
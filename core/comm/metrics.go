@@ -0,0 +1,102 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package comm
+
+import (
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric/core/metrics"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+// serverMetrics are the metrics of the requests served by a gRPC server
+type serverMetrics struct {
+	received  metrics.Counter
+	completed metrics.Counter
+	duration  metrics.Histogram
+}
+
+func newServerMetrics(p metrics.Provider) *serverMetrics {
+	return &serverMetrics{
+		received: p.NewCounter(metrics.CounterOpts{
+			Namespace:  "grpc",
+			Subsystem:  "server",
+			Name:       "requests_received",
+			Help:       "The number of requests received.",
+			LabelNames: []string{"service", "method"},
+		}),
+		completed: p.NewCounter(metrics.CounterOpts{
+			Namespace:  "grpc",
+			Subsystem:  "server",
+			Name:       "requests_completed",
+			Help:       "The number of requests completed, by status code.",
+			LabelNames: []string{"service", "method", "code"},
+		}),
+		duration: p.NewHistogram(metrics.HistogramOpts{
+			Namespace:  "grpc",
+			Subsystem:  "server",
+			Name:       "request_duration",
+			Help:       "The time to complete a request, in seconds.",
+			LabelNames: []string{"service", "method", "code"},
+		}),
+	}
+}
+
+// observe records a request on fullMethod, of the form /service/method,
+// which started at start and ended with err
+func (m *serverMetrics) observe(fullMethod string, start time.Time, err error) {
+	service, method := splitMethodName(fullMethod)
+	code := grpc.Code(err).String()
+	m.completed.With("service", service, "method", method, "code", code).Add(1)
+	m.duration.With("service", service, "method", method, "code", code).Observe(time.Since(start).Seconds())
+}
+
+func splitMethodName(fullMethod string) (string, string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	if i := strings.Index(fullMethod, "/"); i >= 0 {
+		return fullMethod[:i], fullMethod[i+1:]
+	}
+	return "unknown", fullMethod
+}
+
+func (m *serverMetrics) unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	service, method := splitMethodName(info.FullMethod)
+	m.received.With("service", service, "method", method).Add(1)
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	m.observe(info.FullMethod, start, err)
+	return resp, err
+}
+
+func (m *serverMetrics) stream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	service, method := splitMethodName(info.FullMethod)
+	m.received.With("service", service, "method", method).Add(1)
+	start := time.Now()
+	err := handler(srv, ss)
+	m.observe(info.FullMethod, start, err)
+	return err
+}
+
+// MetricsServerInterceptors returns the interceptors of a gRPC server
+// recording the number, status codes and durations of the requests it
+// serves with the metrics of the provider
+func MetricsServerInterceptors(p metrics.Provider) (grpc.UnaryServerInterceptor, grpc.StreamServerInterceptor) {
+	m := newServerMetrics(p)
+	return m.unary, m.stream
+}
@@ -0,0 +1,52 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package comm
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/hyperledger/fabric/core/metrics"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func TestServerMetrics(t *testing.T) {
+	p := metrics.NewPrometheusProvider()
+	m := newServerMetrics(p)
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/protos.Endorser/ProcessProposal"}
+	ok := func(context.Context, interface{}) (interface{}, error) { return "resp", nil }
+	resp, err := m.unary(context.Background(), "req", info, ok)
+	assert.NoError(t, err)
+	assert.Equal(t, "resp", resp)
+
+	failed := errors.New("failed")
+	streamInfo := &grpc.StreamServerInfo{FullMethod: "/protos.Peer/Chat"}
+	err = m.stream(nil, nil, streamInfo, func(interface{}, grpc.ServerStream) error { return failed })
+	assert.Equal(t, failed, err)
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, p.WriteMetrics(buf))
+	assert.Contains(t, buf.String(), `grpc_server_requests_received{service="protos.Endorser",method="ProcessProposal"} 1`)
+	assert.Contains(t, buf.String(), `grpc_server_requests_completed{service="protos.Endorser",method="ProcessProposal",code="OK"} 1`)
+	assert.Contains(t, buf.String(), `grpc_server_requests_completed{service="protos.Peer",method="Chat",code="`+codes.Unknown.String()+`"} 1`)
+	assert.Contains(t, buf.String(), `grpc_server_request_duration_count{service="protos.Peer",method="Chat",code="Unknown"} 1`)
+}
@@ -0,0 +1,81 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package comm
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// MessageSizeOptions bounds the size of the messages sent and received by
+// the gRPC servers and clients of the peer, 0 leaving it unbounded
+type MessageSizeOptions struct {
+	MaxSend int
+	MaxRecv int
+}
+
+// GetMessageSizeOptions returns the MessageSizeOptions of the peer, from
+// the "peer.maxSendMsgSize" and "peer.maxRecvMsgSize" settings
+func GetMessageSizeOptions() MessageSizeOptions {
+	return MessageSizeOptions{
+		MaxSend: viper.GetInt("peer.maxSendMsgSize"),
+		MaxRecv: viper.GetInt("peer.maxRecvMsgSize"),
+	}
+}
+
+// sizeLimitCodec is the protobuf codec of gRPC, rejecting the messages
+// exceeding the MessageSizeOptions. The requests too large fail with
+// ResourceExhausted, the other messages with the Internal error of gRPC
+type sizeLimitCodec struct {
+	options MessageSizeOptions
+}
+
+func (c sizeLimitCodec) Marshal(v interface{}) ([]byte, error) {
+	data, err := proto.Marshal(v.(proto.Message))
+	if err != nil {
+		return nil, err
+	}
+	if c.options.MaxSend > 0 && len(data) > c.options.MaxSend {
+		return nil, grpc.Errorf(codes.ResourceExhausted, "message of %d bytes exceeds the maximum size of %d bytes to send", len(data), c.options.MaxSend)
+	}
+	return data, nil
+}
+
+func (c sizeLimitCodec) Unmarshal(data []byte, v interface{}) error {
+	if c.options.MaxRecv > 0 && len(data) > c.options.MaxRecv {
+		return grpc.Errorf(codes.ResourceExhausted, "message of %d bytes exceeds the maximum size of %d bytes to receive", len(data), c.options.MaxRecv)
+	}
+	return proto.Unmarshal(data, v.(proto.Message))
+}
+
+func (sizeLimitCodec) String() string {
+	return "proto"
+}
+
+// MessageSizeServerOption returns the ServerOption of a gRPC server bounding
+// the size of its messages
+func MessageSizeServerOption(options MessageSizeOptions) grpc.ServerOption {
+	return grpc.CustomCodec(sizeLimitCodec{options: options})
+}
+
+// MessageSizeDialOption returns the DialOption of a gRPC client bounding the
+// size of its messages
+func MessageSizeDialOption(options MessageSizeOptions) grpc.DialOption {
+	return grpc.WithCodec(sizeLimitCodec{options: options})
+}
@@ -0,0 +1,68 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package comm
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestMessageSizeOptions(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %s", err)
+	}
+	server := grpc.NewServer(MessageSizeServerOption(MessageSizeOptions{MaxRecv: 100}))
+	healthServer := health.NewHealthServer()
+	healthServer.SetServingStatus(strings.Repeat("a", 200), healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+	go server.Serve(lis)
+	defer server.Stop()
+
+	dial := func(options MessageSizeOptions) healthpb.HealthClient {
+		conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure(), grpc.WithBlock(),
+			grpc.WithTimeout(time.Second), MessageSizeDialOption(options))
+		if err != nil {
+			t.Fatalf("Failed to dial: %s", err)
+		}
+		return healthpb.NewHealthClient(conn)
+	}
+	check := func(client healthpb.HealthClient, service string) error {
+		_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+		return err
+	}
+
+	client := dial(MessageSizeOptions{})
+	assert.NoError(t, check(client, ""))
+	// The request is rejected by the server
+	assert.Equal(t, codes.ResourceExhausted, grpc.Code(check(client, strings.Repeat("a", 200))))
+	// and by the client, before being sent
+	client = dial(MessageSizeOptions{MaxSend: 100})
+	err = check(client, strings.Repeat("a", 200))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "exceeds the maximum size of 100 bytes to send")
+	assert.NoError(t, check(client, ""))
+}
@@ -0,0 +1,265 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package comm
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// TLSReloader is TLS material loaded again from its files by Reload
+type TLSReloader interface {
+	// Reload loads the TLS material again from its files. The material in use
+	// is kept if it fails
+	Reload() error
+	// Files returns the files the TLS material is loaded from
+	Files() []string
+}
+
+// ReloadableCredentials are TransportCredentials swapped by Reload for the
+// ones returned by their load function. The connections already established
+// keep the credentials of their handshake
+type ReloadableCredentials struct {
+	load  func() (credentials.TransportCredentials, error)
+	files []string
+	mutex sync.RWMutex
+	creds credentials.TransportCredentials
+}
+
+// NewReloadableCredentials returns the ReloadableCredentials returned by load,
+// loaded from files
+func NewReloadableCredentials(load func() (credentials.TransportCredentials, error), files ...string) (*ReloadableCredentials, error) {
+	creds, err := load()
+	if err != nil {
+		return nil, err
+	}
+	return &ReloadableCredentials{load: load, files: files, creds: creds}, nil
+}
+
+func (c *ReloadableCredentials) current() credentials.TransportCredentials {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.creds
+}
+
+// ClientHandshake does the client handshake with the current credentials
+func (c *ReloadableCredentials) ClientHandshake(addr string, rawConn net.Conn, timeout time.Duration) (net.Conn, credentials.AuthInfo, error) {
+	return c.current().ClientHandshake(addr, rawConn, timeout)
+}
+
+// ServerHandshake does the server handshake with the current credentials
+func (c *ReloadableCredentials) ServerHandshake(rawConn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return c.current().ServerHandshake(rawConn)
+}
+
+// Info returns the ProtocolInfo of the current credentials
+func (c *ReloadableCredentials) Info() credentials.ProtocolInfo {
+	return c.current().Info()
+}
+
+// Reload replaces the credentials by the ones returned by the load function
+func (c *ReloadableCredentials) Reload() error {
+	creds, err := c.load()
+	if err != nil {
+		return err
+	}
+	c.mutex.Lock()
+	c.creds = creds
+	c.mutex.Unlock()
+	return nil
+}
+
+// Files returns the files the credentials are loaded from
+func (c *ReloadableCredentials) Files() []string {
+	return c.files
+}
+
+// ServerTLS holds the TLS credentials of a gRPC server and the root CAs of
+// the clients of its services, reloaded from their files by Reload
+type ServerTLS struct {
+	*ReloadableCredentials
+	auth          ClientAuthConfig
+	mutex         sync.RWMutex
+	authenticator *clientAuthenticator
+}
+
+// NewServerTLS returns the ServerTLS of a server with the key pair of
+// certFile and keyFile, authenticating its clients as configured by auth
+func NewServerTLS(certFile, keyFile string, auth ClientAuthConfig) (*ServerTLS, error) {
+	files := append([]string{certFile, keyFile}, auth.RootCAFiles...)
+	for _, serviceFiles := range auth.ServiceRootCAFiles {
+		files = append(files, serviceFiles...)
+	}
+	creds, err := NewReloadableCredentials(func() (credentials.TransportCredentials, error) {
+		return ServerTLSCredentials(certFile, keyFile, auth)
+	}, files...)
+	if err != nil {
+		return nil, err
+	}
+	authenticator, err := newClientAuthenticator(auth)
+	if err != nil {
+		return nil, err
+	}
+	return &ServerTLS{ReloadableCredentials: creds, auth: auth, authenticator: authenticator}, nil
+}
+
+// Reload loads the key pair of the server and the root CAs of its clients
+// again
+func (s *ServerTLS) Reload() error {
+	authenticator, err := newClientAuthenticator(s.auth)
+	if err != nil {
+		return err
+	}
+	if err = s.ReloadableCredentials.Reload(); err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	s.authenticator = authenticator
+	s.mutex.Unlock()
+	return nil
+}
+
+func (s *ServerTLS) authenticate(ctx context.Context, fullMethod string) error {
+	s.mutex.RLock()
+	authenticator := s.authenticator
+	s.mutex.RUnlock()
+	return authenticator.authenticate(ctx, fullMethod)
+}
+
+// UnaryServerInterceptor rejects the requests from the clients not accepted
+// by the root CAs of the service, as the interceptor of ClientAuthInterceptors
+func (s *ServerTLS) UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := s.authenticate(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// StreamServerInterceptor rejects the streams from the clients not accepted
+// by the root CAs of the service, as the interceptor of ClientAuthInterceptors
+func (s *ServerTLS) StreamServerInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := s.authenticate(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+var tlsReloaders = struct {
+	sync.Mutex
+	reloaders map[string]TLSReloader
+}{reloaders: make(map[string]TLSReloader)}
+
+// RegisterTLSReloader registers the TLSReloader reloaded by ReloadTLS and
+// WatchTLSFiles under name
+func RegisterTLSReloader(name string, reloader TLSReloader) {
+	tlsReloaders.Lock()
+	defer tlsReloaders.Unlock()
+	tlsReloaders.reloaders[name] = reloader
+}
+
+func registeredTLSReloaders() map[string]TLSReloader {
+	tlsReloaders.Lock()
+	defer tlsReloaders.Unlock()
+	reloaders := make(map[string]TLSReloader, len(tlsReloaders.reloaders))
+	for name, reloader := range tlsReloaders.reloaders {
+		reloaders[name] = reloader
+	}
+	return reloaders
+}
+
+func reloadTLS(name string, reloader TLSReloader) error {
+	if err := reloader.Reload(); err != nil {
+		commLogger.Errorf("Failed to reload the TLS material of %s: %s", name, err)
+		return fmt.Errorf("Failed to reload the TLS material of %s: %s", name, err)
+	}
+	commLogger.Infof("Reloaded the TLS material of %s", name)
+	return nil
+}
+
+// ReloadTLS reloads the registered TLS material. It returns the first error,
+// after trying to reload all of it
+func ReloadTLS() error {
+	var firstErr error
+	for name, reloader := range registeredTLSReloaders() {
+		if err := reloadTLS(name, reloader); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+type fileVersion struct {
+	modTime time.Time
+	size    int64
+}
+
+func fileVersions(files []string) map[string]fileVersion {
+	versions := make(map[string]fileVersion, len(files))
+	for _, file := range files {
+		if info, err := os.Stat(file); err == nil {
+			versions[file] = fileVersion{modTime: info.ModTime(), size: info.Size()}
+		}
+	}
+	return versions
+}
+
+func changed(before, after map[string]fileVersion) bool {
+	if len(before) != len(after) {
+		return true
+	}
+	for file, version := range after {
+		if before[file] != version {
+			return true
+		}
+	}
+	return false
+}
+
+// WatchTLSFiles reloads the registered TLS material whose files changed,
+// checking them every interval until stop is closed
+func WatchTLSFiles(interval time.Duration, stop <-chan struct{}) {
+	versions := make(map[string]map[string]fileVersion)
+	for name, reloader := range registeredTLSReloaders() {
+		versions[name] = fileVersions(reloader.Files())
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		for name, reloader := range registeredTLSReloaders() {
+			current := fileVersions(reloader.Files())
+			previous, known := versions[name]
+			versions[name] = current
+			if !known || !changed(previous, current) {
+				continue
+			}
+			// A failed reload is tried again on the next change of the files
+			reloadTLS(name, reloader)
+		}
+	}
+}
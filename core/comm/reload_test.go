@@ -0,0 +1,138 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package comm
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// serverCommonName returns the common name of the certificate presented by
+// the server in a handshake with tlsServer
+func serverCommonName(t *testing.T, tlsServer *ServerTLS) string {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+	go tlsServer.ServerHandshake(serverConn)
+	client := tls.Client(clientConn, &tls.Config{InsecureSkipVerify: true})
+	if err := client.Handshake(); err != nil {
+		t.Fatalf("Handshake failed: %s", err)
+	}
+	return client.ConnectionState().PeerCertificates[0].Subject.CommonName
+}
+
+func copyCert(t *testing.T, from, to *testCert) {
+	for src, dst := range map[string]string{from.certFile: to.certFile, from.keyFile: to.keyFile} {
+		data, err := ioutil.ReadFile(src)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %s", src, err)
+		}
+		if err = ioutil.WriteFile(dst, data, 0600); err != nil {
+			t.Fatalf("Failed to write %s: %s", dst, err)
+		}
+	}
+}
+
+func TestServerTLSReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "comm")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ca := issueCert(t, dir, "ca", nil)
+	server := issueCert(t, dir, "server", ca)
+	rotated := issueCert(t, dir, "rotated", ca)
+	clientCA := issueCert(t, dir, "clientca", nil)
+	otherCA := issueCert(t, dir, "otherca", nil)
+	client := issueCert(t, dir, "client", otherCA)
+
+	tlsServer, err := NewServerTLS(server.certFile, server.keyFile, ClientAuthConfig{
+		ServiceRootCAFiles: map[string][]string{"protos.Endorser": {clientCA.certFile}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "server", serverCommonName(t, tlsServer))
+
+	call := func() error {
+		_, err := tlsServer.UnaryServerInterceptor(clientContext(client), nil,
+			&grpc.UnaryServerInfo{FullMethod: "/protos.Endorser/ProcessProposal"}, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return nil, nil
+			})
+		return err
+	}
+	assert.Equal(t, codes.Unauthenticated, grpc.Code(call()))
+
+	// The key pair and the root CAs are rotated
+	copyCert(t, rotated, server)
+	copyCert(t, otherCA, clientCA)
+	assert.NoError(t, tlsServer.Reload())
+	assert.Equal(t, "rotated", serverCommonName(t, tlsServer))
+	assert.NoError(t, call())
+
+	// A failed reload keeps the key pair in use
+	assert.NoError(t, ioutil.WriteFile(server.keyFile, []byte("garbage"), 0600))
+	assert.Error(t, tlsServer.Reload())
+	assert.Equal(t, "rotated", serverCommonName(t, tlsServer))
+}
+
+func TestWatchTLSFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "comm")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ca := issueCert(t, dir, "ca", nil)
+	server := issueCert(t, dir, "server", ca)
+	rotated := issueCert(t, dir, "rotated", ca)
+
+	tlsServer, err := NewServerTLS(server.certFile, server.keyFile, ClientAuthConfig{})
+	assert.NoError(t, err)
+	RegisterTLSReloader("TestWatchTLSFiles", tlsServer)
+	defer func() {
+		tlsReloaders.Lock()
+		delete(tlsReloaders.reloaders, "TestWatchTLSFiles")
+		tlsReloaders.Unlock()
+	}()
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go WatchTLSFiles(10*time.Millisecond, stop)
+	time.Sleep(50 * time.Millisecond)
+
+	copyCert(t, rotated, server)
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(server.certFile, later, later)
+	os.Chtimes(server.keyFile, later, later)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for serverCommonName(t, tlsServer) != "rotated" {
+		if time.Now().After(deadline) {
+			t.Fatal("The rotated certificate was not reloaded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
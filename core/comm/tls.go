@@ -0,0 +1,266 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package comm
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/spf13/viper"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// clientAuthServices maps the services of the peer whose clients may be
+// restricted, as named in the "peer.tls.clientRootCAs" section, to their
+// gRPC service names. Blocks are delivered to the clients by the event hub
+var clientAuthServices = map[string]string{
+	"endorser": "protos.Endorser",
+	"admin":    "protos.Admin",
+	"deliver":  "protos.Events",
+}
+
+// tlsVersions are the TLS versions of "peer.tls.minVersion"
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// cipherSuites are the cipher suites of "peer.tls.cipherSuites"
+var cipherSuites = map[string]uint16{
+	"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256": tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	"TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384": tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256":   tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384":   tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	"TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA":    tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA,
+	"TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA":    tls.TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA,
+	"TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA":      tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA,
+	"TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA":      tls.TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA,
+	"TLS_RSA_WITH_AES_128_GCM_SHA256":         tls.TLS_RSA_WITH_AES_128_GCM_SHA256,
+	"TLS_RSA_WITH_AES_256_GCM_SHA384":         tls.TLS_RSA_WITH_AES_256_GCM_SHA384,
+	"TLS_RSA_WITH_AES_128_CBC_SHA":            tls.TLS_RSA_WITH_AES_128_CBC_SHA,
+	"TLS_RSA_WITH_AES_256_CBC_SHA":            tls.TLS_RSA_WITH_AES_256_CBC_SHA,
+}
+
+// TLSProtocolConfig restricts the TLS versions and cipher suites negotiated
+// by the peer, as a server and as a client
+type TLSProtocolConfig struct {
+	// MinVersion is the minimum TLS version, the default one of crypto/tls
+	// if 0
+	MinVersion uint16
+	// CipherSuites are the cipher suites allowed up to TLS 1.2, the default
+	// ones of crypto/tls if empty. The cipher suites of TLS 1.3 are not
+	// configurable
+	CipherSuites []uint16
+}
+
+// ParseTLSProtocolConfig returns the TLSProtocolConfig of a TLS version,
+// "1.2" or "1.3", and of the names of the cipher suites, as in
+// crypto/tls. Both are optional
+func ParseTLSProtocolConfig(minVersion string, suites []string) (TLSProtocolConfig, error) {
+	var config TLSProtocolConfig
+	if minVersion != "" {
+		version, ok := tlsVersions[minVersion]
+		if !ok {
+			return config, fmt.Errorf("Unsupported TLS version %s", minVersion)
+		}
+		config.MinVersion = version
+	}
+	for _, name := range suites {
+		suite, ok := cipherSuites[name]
+		if !ok {
+			return config, fmt.Errorf("Unsupported TLS cipher suite %s", name)
+		}
+		config.CipherSuites = append(config.CipherSuites, suite)
+	}
+	return config, nil
+}
+
+// GetTLSProtocolConfig returns the TLSProtocolConfig of the peer, from the
+// "peer.tls.minVersion" and "peer.tls.cipherSuites" settings
+func GetTLSProtocolConfig() (TLSProtocolConfig, error) {
+	return ParseTLSProtocolConfig(viper.GetString("peer.tls.minVersion"), viper.GetStringSlice("peer.tls.cipherSuites"))
+}
+
+// Apply restricts the TLS versions and cipher suites of config
+func (c TLSProtocolConfig) Apply(config *tls.Config) {
+	config.MinVersion = c.MinVersion
+	config.CipherSuites = c.CipherSuites
+}
+
+// ClientAuthConfig configures the authentication of the clients of a gRPC
+// server with their TLS certificates
+type ClientAuthConfig struct {
+	// Required makes the TLS handshake fail for the clients without a
+	// certificate issued by one of the root CAs
+	Required bool
+	// RootCAFiles are the files of the root CAs of the clients of all the
+	// services
+	RootCAFiles []string
+	// ServiceRootCAFiles are the files of the root CAs of the clients of
+	// the services, by gRPC service name. Only the clients with a
+	// certificate issued by one of them may call a service listed
+	ServiceRootCAFiles map[string][]string
+}
+
+// GetClientAuthConfig returns the ClientAuthConfig of the peer services,
+// from the "peer.tls.clientAuthRequired" and "peer.tls.clientRootCAs"
+// settings
+func GetClientAuthConfig() ClientAuthConfig {
+	config := ClientAuthConfig{
+		Required:           viper.GetBool("peer.tls.clientAuthRequired"),
+		RootCAFiles:        viper.GetStringSlice("peer.tls.clientRootCAs.files"),
+		ServiceRootCAFiles: make(map[string][]string),
+	}
+	for name, service := range clientAuthServices {
+		if files := viper.GetStringSlice("peer.tls.clientRootCAs." + name + ".files"); len(files) > 0 {
+			config.ServiceRootCAFiles[service] = files
+		}
+	}
+	return config
+}
+
+// enabled returns whether the certificates of the clients are verified
+func (c ClientAuthConfig) enabled() bool {
+	return c.Required || len(c.RootCAFiles) > 0 || len(c.ServiceRootCAFiles) > 0
+}
+
+func loadCertPool(files ...string) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	for _, file := range files {
+		pem, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read root CA %s: %s", file, err)
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("No certificate found in root CA %s", file)
+		}
+	}
+	return pool, nil
+}
+
+// ServerTLSCredentials returns the TLS credentials of a gRPC server with
+// the key pair of certFile and keyFile. The clients presenting a certificate
+// must present one issued by a root CA of the ClientAuthConfig, of any of
+// its services. Whether a client may call a service is left to the
+// interceptors of ClientAuthInterceptors. The TLS versions and cipher
+// suites are those of GetTLSProtocolConfig
+func ServerTLSCredentials(certFile, keyFile string, auth ClientAuthConfig) (credentials.TransportCredentials, error) {
+	protocol, err := GetTLSProtocolConfig()
+	if err != nil {
+		return nil, err
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load the TLS key pair: %s", err)
+	}
+	config := &tls.Config{Certificates: []tls.Certificate{cert}}
+	protocol.Apply(config)
+	if auth.enabled() {
+		files := auth.RootCAFiles
+		for _, serviceFiles := range auth.ServiceRootCAFiles {
+			files = append(files, serviceFiles...)
+		}
+		if config.ClientCAs, err = loadCertPool(files...); err != nil {
+			return nil, err
+		}
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+		if auth.Required {
+			config.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+	return credentials.NewTLS(config), nil
+}
+
+// clientAuthenticator verifies that the clients of the services with root
+// CAs of their own present a certificate issued by one of them
+type clientAuthenticator struct {
+	pools map[string]*x509.CertPool
+}
+
+func newClientAuthenticator(auth ClientAuthConfig) (*clientAuthenticator, error) {
+	a := &clientAuthenticator{pools: make(map[string]*x509.CertPool)}
+	for service, files := range auth.ServiceRootCAFiles {
+		pool, err := loadCertPool(files...)
+		if err != nil {
+			return nil, err
+		}
+		a.pools[service] = pool
+	}
+	return a, nil
+}
+
+// authenticate returns an Unauthenticated error if the client of ctx may
+// not call the service of fullMethod
+func (a *clientAuthenticator) authenticate(ctx context.Context, fullMethod string) error {
+	service, _ := splitMethodName(fullMethod)
+	pool, restricted := a.pools[service]
+	if !restricted {
+		return nil
+	}
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return grpc.Errorf(codes.Unauthenticated, "%s requires a client certificate", service)
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.PeerCertificates) == 0 {
+		return grpc.Errorf(codes.Unauthenticated, "%s requires a client certificate", service)
+	}
+	certs := tlsInfo.State.PeerCertificates
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         pool,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if err != nil {
+		commLogger.Warningf("Rejected client %s of %s: %s", p.Addr, service, err)
+		return grpc.Errorf(codes.Unauthenticated, "client certificate not accepted by %s", service)
+	}
+	return nil
+}
+
+// ClientAuthInterceptors returns the interceptors of a gRPC server
+// rejecting the requests to the services of ServiceRootCAFiles from the
+// clients without a certificate issued by one of the root CAs of the service
+func ClientAuthInterceptors(auth ClientAuthConfig) (grpc.UnaryServerInterceptor, grpc.StreamServerInterceptor, error) {
+	a, err := newClientAuthenticator(auth)
+	if err != nil {
+		return nil, nil, err
+	}
+	unary := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := a.authenticate(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+	stream := func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := a.authenticate(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+	return unary, stream, nil
+}
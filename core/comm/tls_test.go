@@ -0,0 +1,245 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package comm
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+type testCert struct {
+	cert     *x509.Certificate
+	key      *ecdsa.PrivateKey
+	certFile string
+	keyFile  string
+}
+
+func issueCert(t *testing.T, dir string, name string, issuer *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %s", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	parent, signer := template, key
+	if issuer == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		parent, signer = issuer.cert, issuer.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %s", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+
+	tc := &testCert{cert: cert, key: key,
+		certFile: filepath.Join(dir, name+".pem"), keyFile: filepath.Join(dir, name+".key")}
+	ioutil.WriteFile(tc.certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	ioutil.WriteFile(tc.keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+	return tc
+}
+
+func clientContext(cert *testCert) context.Context {
+	p := &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 50000}}
+	if cert != nil {
+		p.AuthInfo = credentials.TLSInfo{State: tls.ConnectionState{
+			PeerCertificates: []*x509.Certificate{cert.cert},
+		}}
+	}
+	return peer.NewContext(context.Background(), p)
+}
+
+func TestClientAuthInterceptors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "comm")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	commonCA := issueCert(t, dir, "commonca", nil)
+	endorserCA := issueCert(t, dir, "endorserca", nil)
+	commonClient := issueCert(t, dir, "commonclient", commonCA)
+	endorserClient := issueCert(t, dir, "endorserclient", endorserCA)
+
+	unary, stream, err := ClientAuthInterceptors(ClientAuthConfig{
+		RootCAFiles:        []string{commonCA.certFile},
+		ServiceRootCAFiles: map[string][]string{"protos.Endorser": {endorserCA.certFile}},
+	})
+	assert.NoError(t, err)
+
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+	call := func(ctx context.Context, method string) error {
+		_, err := unary(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		return err
+	}
+
+	assert.NoError(t, call(clientContext(endorserClient), "/protos.Endorser/ProcessProposal"))
+	assert.Equal(t, codes.Unauthenticated, grpc.Code(call(clientContext(commonClient), "/protos.Endorser/ProcessProposal")))
+	assert.Equal(t, codes.Unauthenticated, grpc.Code(call(clientContext(nil), "/protos.Endorser/ProcessProposal")))
+	assert.Equal(t, codes.Unauthenticated, grpc.Code(call(context.Background(), "/protos.Endorser/ProcessProposal")))
+
+	// The services without root CAs of their own are left to the handshake
+	assert.NoError(t, call(clientContext(commonClient), "/protos.Admin/GetStatus"))
+	assert.NoError(t, call(clientContext(nil), "/protos.Admin/GetStatus"))
+
+	err = stream(nil, &testServerStream{ctx: clientContext(commonClient)},
+		&grpc.StreamServerInfo{FullMethod: "/protos.Endorser/Stream"},
+		func(srv interface{}, ss grpc.ServerStream) error { return nil })
+	assert.Equal(t, codes.Unauthenticated, grpc.Code(err))
+}
+
+type testServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestClientAuthInvalidRootCA(t *testing.T) {
+	dir, err := ioutil.TempDir("", "comm")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	server := issueCert(t, dir, "server", nil)
+	_, _, err = ClientAuthInterceptors(ClientAuthConfig{
+		ServiceRootCAFiles: map[string][]string{"protos.Admin": {filepath.Join(dir, "missing.pem")}},
+	})
+	assert.Error(t, err)
+
+	_, err = ServerTLSCredentials(server.certFile, server.keyFile, ClientAuthConfig{
+		Required:    true,
+		RootCAFiles: []string{server.keyFile},
+	})
+	assert.Error(t, err)
+
+	_, err = ServerTLSCredentials(server.certFile, server.keyFile, ClientAuthConfig{
+		Required:    true,
+		RootCAFiles: []string{server.certFile},
+	})
+	assert.NoError(t, err)
+}
+
+func TestParseTLSProtocolConfig(t *testing.T) {
+	config, err := ParseTLSProtocolConfig("", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, TLSProtocolConfig{}, config)
+
+	config, err = ParseTLSProtocolConfig("1.2", []string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256", "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"})
+	assert.NoError(t, err)
+	assert.Equal(t, TLSProtocolConfig{
+		MinVersion:   tls.VersionTLS12,
+		CipherSuites: []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256, tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384},
+	}, config)
+
+	_, err = ParseTLSProtocolConfig("1.0", nil)
+	assert.Error(t, err)
+	_, err = ParseTLSProtocolConfig("1.3", []string{"TLS_RSA_WITH_RC4_128_SHA"})
+	assert.Error(t, err)
+}
+
+func TestServerTLSMinVersion(t *testing.T) {
+	dir, err := ioutil.TempDir("", "comm")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	server := issueCert(t, dir, "server", nil)
+
+	viper.Set("peer.tls.minVersion", "1.3")
+	defer viper.Set("peer.tls.minVersion", "")
+	creds, err := ServerTLSCredentials(server.certFile, server.keyFile, ClientAuthConfig{})
+	assert.NoError(t, err)
+
+	handshake := func(maxVersion uint16) error {
+		serverConn, clientConn := net.Pipe()
+		defer serverConn.Close()
+		defer clientConn.Close()
+		go creds.ServerHandshake(serverConn)
+		return tls.Client(clientConn, &tls.Config{InsecureSkipVerify: true, MaxVersion: maxVersion}).Handshake()
+	}
+	assert.Error(t, handshake(tls.VersionTLS12))
+	assert.NoError(t, handshake(tls.VersionTLS13))
+
+	viper.Set("peer.tls.minVersion", "1.1")
+	_, err = ServerTLSCredentials(server.certFile, server.keyFile, ClientAuthConfig{})
+	assert.Error(t, err)
+}
+
+func TestOrdererClientTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "comm")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	orderer := issueCert(t, dir, "orderer", nil)
+	other := issueCert(t, dir, "other", nil)
+	defer viper.Set("peer.committer.ledger.tls.rootCAs.files", []string{})
+
+	handshake := func(rootCAs ...string) error {
+		viper.Set("peer.committer.ledger.tls.rootCAs.files", rootCAs)
+		creds, err := OrdererClientTLS()
+		if err != nil {
+			return err
+		}
+		serverConn, clientConn := net.Pipe()
+		defer serverConn.Close()
+		defer clientConn.Close()
+		cert, _ := tls.LoadX509KeyPair(orderer.certFile, orderer.keyFile)
+		go tls.Server(serverConn, &tls.Config{Certificates: []tls.Certificate{cert}}).Handshake()
+		_, _, err = creds.ClientHandshake("orderer:7050", clientConn, time.Second)
+		return err
+	}
+	assert.NoError(t, handshake(orderer.certFile))
+	assert.Error(t, handshake(other.certFile))
+	assert.Error(t, handshake(filepath.Join(dir, "missing.pem")))
+}
@@ -0,0 +1,135 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package noopssinglechain
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/golang/protobuf/jsonpb"
+	cb "github.com/hyperledger/fabric/protos/common"
+)
+
+const blockFileFormatString string = "block_%020d.json"
+
+// blockArchive keeps the committed blocks as the orderer created them, one file
+// per block, so the hash chain of the blocks can be verified and the blocks can
+// be sent to the peers that miss them. Besides the genesis block, which declares
+// the chain, only the last retention blocks are kept, all of them if retention
+// is 0
+type blockArchive struct {
+	directory string
+	marshaler *jsonpb.Marshaler
+	retention uint64
+}
+
+func newBlockArchive(directory string, retention uint64) (*blockArchive, error) {
+	if err := os.MkdirAll(directory, 0700); err != nil {
+		return nil, err
+	}
+	a := &blockArchive{directory: directory, marshaler: &jsonpb.Marshaler{}, retention: retention}
+	// the retention may have been lowered since the blocks were archived
+	if err := a.pruneAll(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (a *blockArchive) blockFilename(number uint64) string {
+	return fmt.Sprintf(a.directory+"/"+blockFileFormatString, number)
+}
+
+// put archives a block, written to a temporary file renamed once complete so
+// that a crash never leaves a partial block in the archive
+func (a *blockArchive) put(block *cb.Block) error {
+	file, err := ioutil.TempFile(a.directory, "block")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if err = a.marshaler.Marshal(file, block); err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err = os.Rename(file.Name(), a.blockFilename(block.Header.Number)); err != nil {
+		return err
+	}
+	if a.retention != 0 && block.Header.Number > a.retention {
+		a.remove(block.Header.Number - a.retention)
+	}
+	return nil
+}
+
+// pruneAll removes the archived blocks older than the last retention ones,
+// except the genesis block
+func (a *blockArchive) pruneAll() error {
+	if a.retention == 0 {
+		return nil
+	}
+	fileInfos, err := ioutil.ReadDir(a.directory)
+	if err != nil {
+		return err
+	}
+	var numbers []uint64
+	var last uint64
+	for _, fileInfo := range fileInfos {
+		var number uint64
+		if _, err := fmt.Sscanf(fileInfo.Name(), blockFileFormatString, &number); err != nil {
+			continue
+		}
+		numbers = append(numbers, number)
+		if number > last {
+			last = number
+		}
+	}
+	for _, number := range numbers {
+		if number != 0 && number+a.retention <= last {
+			a.remove(number)
+		}
+	}
+	return nil
+}
+
+// remove removes an archived block. The block is left, and the next pruning
+// retries, if it can't be removed
+func (a *blockArchive) remove(number uint64) {
+	if err := os.Remove(a.blockFilename(number)); err != nil && !os.IsNotExist(err) {
+		logger.Warningf("Error removing archived block %d(%s)", number, err)
+	}
+}
+
+// get returns an archived block, or nil if the block wasn't archived or was
+// pruned
+func (a *blockArchive) get(number uint64) *cb.Block {
+	file, err := os.Open(a.blockFilename(number))
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+	block := &cb.Block{}
+	if err = jsonpb.Unmarshal(file, block); err != nil {
+		logger.Errorf("Error reading archived block %d(%s)", number, err)
+		return nil
+	}
+	return block
+}
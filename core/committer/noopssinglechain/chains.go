@@ -0,0 +1,125 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package noopssinglechain
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/hyperledger/fabric/core/chaincode"
+	"github.com/hyperledger/fabric/core/committer"
+	"github.com/hyperledger/fabric/core/operations"
+	"github.com/hyperledger/fabric/core/system_chaincode/cscc"
+	"github.com/hyperledger/fabric/gossip/service"
+)
+
+// commitMode is the way the committers of the chains get their blocks
+type commitMode int
+
+const (
+	//the blocks are pulled from the orderer
+	soloMode commitMode = iota
+	//the blocks are pulled from the orderer while the peer is the leader of
+	//its organization, and received over gossip otherwise
+	electedMode
+	//the blocks are received over gossip
+	gossipMode
+)
+
+// chainCommitters runs a committer for the default chain and one for every
+// chain the peer joined, each committing the blocks of its chain to the
+// ledger of the chain. The committers of the chains joined while the peer
+// runs are started as they are joined
+type chainCommitters struct {
+	mode commitMode
+	//address of the peer
+	address string
+	//fallback address of the ordering service
+	orderer string
+	//election of the leader of the organization in electedMode
+	leadership *leadership
+
+	lock    sync.Mutex
+	started map[string]bool
+}
+
+// Start starts the committers of the default chain and of the chains the peer
+// joined, and makes the chains joined from then on start theirs
+func (c *chainCommitters) Start() error {
+	if c.mode == electedMode {
+		g := service.GetGossipService()
+		if g == nil {
+			return fmt.Errorf("gossip is not enabled")
+		}
+		orgPeers, err := getOrganizationPeers(c.address)
+		if err != nil {
+			return err
+		}
+		c.leadership = &leadership{}
+		service.NewLeaderElectionService(g, c.address, orgPeers, c.leadership.onLeadershipChange)
+	}
+
+	// registered first so that no chain joined in between is missed, the
+	// chains already started are skipped
+	cscc.OnJoin(c.startChain)
+	chains, err := cscc.JoinedChains()
+	if err != nil {
+		return err
+	}
+	c.startChain(string(chaincode.DefaultChain))
+	for chainID := range chains {
+		c.startChain(chainID)
+	}
+	return nil
+}
+
+// startChain starts the committer of the ledger of a chain, unless started
+// already
+func (c *chainCommitters) startChain(ledger string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.started[ledger] {
+		return
+	}
+	c.started[ledger] = true
+
+	// the checker of the orderer of the default chain keeps its name
+	checkerName := "orderer"
+	if ledger != string(chaincode.DefaultChain) {
+		checkerName = "orderer_" + ledger
+	}
+	endpoints := newOrdererEndpoints(ledger, c.orderer)
+	var lc committer.Committer
+	switch c.mode {
+	case electedMode:
+		ec := &electedCommitter{ledger: ledger, endpoints: endpoints, leadership: c.leadership}
+		operations.RegisterChecker(checkerName, ec.checkOrderer)
+		lc = ec
+	case soloMode:
+		operations.RegisterChecker(checkerName, endpoints.check)
+		lc = &solo{ledger: ledger, endpoints: endpoints}
+	default:
+		lc = &gossipCommitter{ledger: ledger, endpoints: endpoints}
+	}
+
+	logger.Infof("Starting the committer of %s", ledger)
+	go func() {
+		if err := lc.Start(); err != nil {
+			logger.Errorf("Could not start the committer of %s, continuing without it(%s)", ledger, err)
+		}
+	}()
+}
@@ -27,6 +27,9 @@ import (
 	"github.com/hyperledger/fabric/core/chaincode"
 	"github.com/hyperledger/fabric/core/committer"
 	"github.com/hyperledger/fabric/core/ledger/kvledger"
+	"github.com/hyperledger/fabric/events/producer"
+	"github.com/hyperledger/fabric/gossip/service"
+	cb "github.com/hyperledger/fabric/protos/common"
 	ab "github.com/hyperledger/fabric/protos/orderer"
 	putils "github.com/hyperledger/fabric/protos/utils"
 	"golang.org/x/net/context"
@@ -51,11 +54,22 @@ type deliverClient struct {
 	client         ab.AtomicBroadcast_DeliverClient
 	windowSize     uint64
 	unAcknowledged uint64
-	solo           *solo
+	ledger         string
+	chainID        []byte
+	commitBlock    func(*cb.Block) error
 }
 
-func newDeliverClient(client ab.AtomicBroadcast_DeliverClient, windowSize uint64, solo *solo) *deliverClient {
-	return &deliverClient{client: client, windowSize: windowSize, solo: solo}
+func newDeliverClient(client ab.AtomicBroadcast_DeliverClient, windowSize uint64, ledger string, commitBlock func(*cb.Block) error) *deliverClient {
+	return &deliverClient{client: client, windowSize: windowSize, ledger: ledger, chainID: orderedChainID(ledger), commitBlock: commitBlock}
+}
+
+// orderedChainID returns the ID the orderer knows the chain of a ledger by, the default chain being the system chain of
+// the orderer which is sought without naming a chain
+func orderedChainID(ledger string) []byte {
+	if ledger == string(chaincode.DefaultChain) {
+		return nil
+	}
+	return []byte(ledger)
 }
 
 func (r *deliverClient) seekOldest() error {
@@ -64,6 +78,7 @@ func (r *deliverClient) seekOldest() error {
 			Seek: &ab.SeekInfo{
 				Start:      ab.SeekInfo_OLDEST,
 				WindowSize: r.windowSize,
+				ChainID:    r.chainID,
 			},
 		},
 	})
@@ -75,6 +90,7 @@ func (r *deliverClient) seekNewest() error {
 			Seek: &ab.SeekInfo{
 				Start:      ab.SeekInfo_NEWEST,
 				WindowSize: r.windowSize,
+				ChainID:    r.chainID,
 			},
 		},
 	})
@@ -87,13 +103,14 @@ func (r *deliverClient) seek(blockNumber uint64) error {
 				Start:           ab.SeekInfo_SPECIFIED,
 				SpecifiedNumber: blockNumber,
 				WindowSize:      r.windowSize,
+				ChainID:         r.chainID,
 			},
 		},
 	})
 }
 
 // constructBlock constructs a block from a list of transactions
-func (r *deliverClient) constructBlock(transactions []*pb.Transaction2) *pb.Block2 {
+func constructBlock(transactions []*pb.Transaction2) *pb.Block2 {
 	block := &pb.Block2{}
 	for _, tx := range transactions {
 		txBytes, _ := proto.Marshal(tx)
@@ -103,21 +120,66 @@ func (r *deliverClient) constructBlock(transactions []*pb.Transaction2) *pb.Bloc
 }
 
 // commit the received transaction
-func (r *deliverClient) commit(txs []*pb.Transaction2) error {
-	rawblock := r.constructBlock(txs)
+func commit(ledger string, notifier *committer.StateListenerNotifier, txs []*pb.Transaction2) error {
+	txs, err := validateTransactions(ledger, txs)
+	if err != nil {
+		return err
+	}
+	rawblock := constructBlock(txs)
 
-	lgr := kvledger.GetLedger(r.solo.ledger)
+	lgr := kvledger.GetLedger(ledger)
 
-	var err error
-	if _, _, err = lgr.RemoveInvalidTransactionsAndPrepare(rawblock); err != nil {
+	validBlock, _, err := lgr.RemoveInvalidTransactionsAndPrepare(rawblock)
+	if err != nil {
 		return err
 	}
 	if err = lgr.Commit(); err != nil {
 		return err
 	}
+	notifier.NotifyCommitted()
+	sendChaincodeEvents(validBlock)
 	return err
 }
 
+// getTransactions returns the endorser transactions of an orderer block
+func getTransactions(block *cb.Block) []*pb.Transaction2 {
+	txs := []*pb.Transaction2{}
+	for _, d := range block.Data.Data {
+		if d != nil {
+			if tx, err := putils.GetEndorserTxFromBlock(d); err != nil {
+				fmt.Printf("Error getting tx from block(%s)\n", err)
+			} else if tx != nil {
+				txs = append(txs, tx)
+			} else {
+				fmt.Printf("Nil tx from block\n")
+			}
+		}
+	}
+	return txs
+}
+
+// sendChaincodeEvents delivers the events set by the chaincodes in the committed
+// transactions to the registered event consumers
+func sendChaincodeEvents(block *pb.Block2) {
+	for _, txBytes := range block.Transactions {
+		tx := &pb.Transaction2{}
+		if err := proto.Unmarshal(txBytes, tx); err != nil {
+			logger.Errorf("Error unmarshalling committed transaction(%s)", err)
+			continue
+		}
+		ccEvents, err := putils.GetChaincodeEvents(tx)
+		if err != nil {
+			logger.Errorf("Error getting chaincode events of committed transaction(%s)", err)
+			continue
+		}
+		for _, ccEvent := range ccEvents {
+			if err = producer.Send(producer.CreateChaincodeEvent(ccEvent)); err != nil {
+				logger.Errorf("Error sending chaincode event %s of chaincode %s(%s)", ccEvent.EventName, ccEvent.ChaincodeID, err)
+			}
+		}
+	}
+}
+
 func (r *deliverClient) readUntilClose() {
 	for {
 		msg, err := r.client.Recv()
@@ -133,22 +195,16 @@ func (r *deliverClient) readUntilClose() {
 			}
 			fmt.Println("Got error ", t)
 		case *ab.DeliverResponse_Block:
-			txs := []*pb.Transaction2{}
-			for _, d := range t.Block.Data.Data {
-				if d != nil {
-					if tx, err := putils.GetEndorserTxFromBlock(d); err != nil {
-						fmt.Printf("Error getting tx from block(%s)\n", err)
-					} else if tx != nil {
-						txs = append(txs, tx)
-					} else {
-						fmt.Printf("Nil tx from block\n")
-					}
-				}
-			}
-			if err = r.commit(txs); err != nil {
+			if err = r.commitBlock(t.Block); err != nil {
 				fmt.Printf("Got error while committing(%s)\n", err)
 			} else {
 				fmt.Printf("Commit success, created a block!\n")
+				// disseminate the block to the peers without a connection to the orderer
+				if g := service.GetGossipService(); g != nil {
+					if err = service.GossipBlock(g, r.ledger, t.Block); err != nil {
+						logger.Errorf("Error gossiping block(%s)", err)
+					}
+				}
 			}
 
 			r.unAcknowledged++
@@ -171,54 +227,113 @@ type solo struct {
 	//ledger to commit to
 	ledger string
 
-	//orderer to connect to
-	orderer string
+	//endpoints of the ordering service to connect to
+	endpoints *ordererEndpoints
 
 	//client of the orderer
 	client *deliverClient
+
+	//committer of the blocks in order
+	blocks *inOrderCommitter
 }
 
 const defaultTimeout = time.Second * 3
 
-//Start establishes communication with an orderer
+// checkOrderer checks that the orderer at address accepts connections
+func checkOrderer(address string) error {
+	conn, err := dialOrderer(address)
+	if err != nil {
+		return fmt.Errorf("orderer %s is unreachable: %s", address, err)
+	}
+	return conn.Close()
+}
+
+//Start establishes communication with an orderer and commits the blocks it
+//delivers. When the connection closes, it reconnects to the next address of
+//the ordering service, until the committer is stopped
 func (s *solo) Start() error {
 	if s.client != nil {
-		return fmt.Errorf("Client to (%s) exists", s.orderer)
+		return fmt.Errorf("Client of (%s) exists", s.ledger)
 	}
 
-	var opts []grpc.DialOption
-	opts = append(opts, grpc.WithInsecure())
-	opts = append(opts, grpc.WithTimeout(defaultTimeout))
-	opts = append(opts, grpc.WithBlock())
-	conn, err := grpc.Dial(s.orderer, opts...)
-	if err != nil {
+	var err error
+	if s.blocks, err = newInOrderCommitter(s.ledger); err != nil {
 		return err
 	}
-	var abc ab.AtomicBroadcast_DeliverClient
-	abc, err = ab.NewAtomicBroadcastClient(conn).Deliver(context.TODO())
+	// the addresses of the configuration of the chain are known once the
+	// committer has read the genesis block of the ledger
+	s.blocks.setEndpoints(s.endpoints)
+	conn, err := s.endpoints.connect(false)
 	if err != nil {
 		return err
 	}
+	if stateProvider := startStateTransfer(s.blocks); stateProvider != nil {
+		defer stateProvider.Stop()
+	}
 
-	s.client = newDeliverClient(abc, 10, s)
-	if err = s.client.seekOldest(); err != nil {
-		return err
+	seek := (*deliverClient).seekOldest
+	for {
+		if err = s.deliverFrom(conn, seek); err != nil {
+			logger.Errorf("Error pulling the blocks of %s(%s)", s.ledger, err)
+		}
+		conn.Close()
+		if s.blocks.isStopped() {
+			return nil
+		}
+
+		seek = func(client *deliverClient) error {
+			return client.seek(s.blocks.height())
+		}
+		for conn, err = s.endpoints.connect(true); err != nil; conn, err = s.endpoints.connect(true) {
+			logger.Errorf("Error reconnecting to the ordering service of %s(%s)", s.ledger, err)
+			time.Sleep(defaultTimeout)
+			if s.blocks.isStopped() {
+				return nil
+			}
+		}
 	}
+}
 
+// deliverFrom commits the blocks delivered over a connection to an orderer
+// starting from the block requested by seek, until the connection closes
+func (s *solo) deliverFrom(conn *grpc.ClientConn, seek func(*deliverClient) error) error {
+	abc, err := ab.NewAtomicBroadcastClient(conn).Deliver(context.TODO())
+	if err != nil {
+		return err
+	}
+	s.client = newDeliverClient(abc, 10, s.ledger, s.blocks.add)
+	if err = seek(s.client); err != nil {
+		return err
+	}
 	s.client.readUntilClose()
-
-	return err
+	return nil
 }
 
-// NewCommitter constructs a committer object if not already present
-func NewCommitter() committer.Committer {
-	if viper.GetBool("peer.committer.enabled") {
-		//TODO ledger needs to be configured, for now just the default
-		ledger := string(chaincode.DefaultChain)
-		orderer := viper.GetString("peer.committer.ledger.orderer")
+// NewCommitter constructs the committer of the peer, which starts a commit
+// loop for the default chain and for every chain the peer joined or joins.
+// With leader election, the peer at peerAddress pulls the blocks from the
+// orderer only while it is the leader of its organization. The orderer
+// addresses are those of the configuration of the chain,
+// "peer.committer.ledger.orderer" while it declares none
+func NewCommitter(peerAddress string) committer.Committer {
+	c := &chainCommitters{
+		address: peerAddress,
+		orderer: viper.GetString("peer.committer.ledger.orderer"),
+		started: make(map[string]bool),
+	}
+	switch {
+	case viper.GetBool("peer.gossip.enabled") && viper.GetBool("peer.gossip.useLeaderElection"):
+		logger.Infof("Creating committer pulling the blocks from the orderer when elected leader")
+		c.mode = electedMode
+	case viper.GetBool("peer.committer.enabled"):
 		logger.Infof("Creating committer for single noops endorser")
-		return &solo{ledger: ledger, orderer: orderer}
+		c.mode = soloMode
+	case viper.GetBool("peer.gossip.enabled"):
+		logger.Infof("Creating committer of the blocks received over gossip")
+		c.mode = gossipMode
+	default:
+		logger.Infof("Committer disabled")
+		return nil
 	}
-	logger.Infof("Committer disabled")
-	return nil
+	return c
 }
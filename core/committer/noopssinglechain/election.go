@@ -0,0 +1,202 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package noopssinglechain
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric/core/chaincode"
+	"github.com/hyperledger/fabric/gossip/service"
+	ab "github.com/hyperledger/fabric/protos/orderer"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+// leadership notifies the committers of the chains of the changes of the
+// leadership of the peer, elected once for all the chains
+type leadership struct {
+	lock       sync.Mutex
+	isLeader   bool
+	committers []*electedCommitter
+}
+
+// add makes the leadership changes notified to the committer, told at once
+// if the peer is the leader already
+func (l *leadership) add(c *electedCommitter) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.committers = append(l.committers, c)
+	if l.isLeader {
+		c.onLeadershipChange(true)
+	}
+}
+
+func (l *leadership) onLeadershipChange(isLeader bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.isLeader = isLeader
+	for _, c := range l.committers {
+		c.onLeadershipChange(isLeader)
+	}
+}
+
+// electedCommitter commits the blocks received over gossip and, while the peer
+// is the elected leader of its organization, pulls the blocks from the orderer
+// and disseminates them to the other peers
+type electedCommitter struct {
+	//ledger to commit to
+	ledger string
+
+	//endpoints of the ordering service to connect to while leader
+	endpoints *ordererEndpoints
+
+	//leadership of the peer, notifying its changes
+	leadership *leadership
+
+	blocks *inOrderCommitter
+
+	lock sync.Mutex
+	//term is incremented on each leadership change
+	term     int
+	isLeader bool
+	conn     *grpc.ClientConn
+}
+
+//Start commits the blocks of the ledger in order, pulling them from the
+//orderer while the peer is the leader of its organization
+func (c *electedCommitter) Start() error {
+	g := service.GetGossipService()
+	if g == nil {
+		return fmt.Errorf("gossip is not enabled")
+	}
+
+	var err error
+	if c.blocks, err = newInOrderCommitter(c.ledger); err != nil {
+		return err
+	}
+	c.blocks.setEndpoints(c.endpoints)
+	if stateProvider := startStateTransfer(c.blocks); stateProvider != nil {
+		defer stateProvider.Stop()
+	}
+
+	c.leadership.add(c)
+
+	for block := range service.AcceptBlocks(g, c.ledger) {
+		if err = c.blocks.add(block); err != nil {
+			logger.Warningf("Dropping block received over gossip(%s)", err)
+		}
+	}
+	return nil
+}
+
+// onLeadershipChange connects to the orderer when the peer becomes the leader,
+// and disconnects when it steps down
+func (c *electedCommitter) onLeadershipChange(isLeader bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.term++
+	c.isLeader = isLeader
+	if isLeader {
+		logger.Infof("Elected leader, pulling the blocks of %s from the orderer", c.ledger)
+		go c.deliver(c.term)
+		return
+	}
+	logger.Infof("Stepped down as leader, stop pulling the blocks of %s", c.ledger)
+	if c.conn != nil {
+		c.conn.Close()
+		c.conn = nil
+	}
+}
+
+// deliver pulls the blocks from the orderer for as long as the leadership term
+// lasts, reconnecting to the next address of the ordering service if the
+// connection fails
+func (c *electedCommitter) deliver(term int) {
+	for rotate := false; c.inTerm(term); rotate = true {
+		if err := c.deliverFrom(term, rotate); err != nil {
+			logger.Errorf("Error pulling the blocks of %s(%s)", c.ledger, err)
+		}
+		time.Sleep(defaultTimeout)
+	}
+}
+
+func (c *electedCommitter) deliverFrom(term int, rotate bool) error {
+	conn, err := c.endpoints.connect(rotate)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	c.lock.Lock()
+	if c.term != term {
+		c.lock.Unlock()
+		return nil
+	}
+	c.conn = conn
+	c.lock.Unlock()
+
+	abc, err := ab.NewAtomicBroadcastClient(conn).Deliver(context.TODO())
+	if err != nil {
+		return err
+	}
+	client := newDeliverClient(abc, 10, c.ledger, c.blocks.add)
+	if err = client.seek(c.blocks.height()); err != nil {
+		return err
+	}
+	client.readUntilClose()
+	return nil
+}
+
+// checkOrderer checks that the orderer accepts connections while the peer is
+// the leader, the other peers not connecting to it
+func (c *electedCommitter) checkOrderer() error {
+	c.lock.Lock()
+	isLeader := c.isLeader
+	c.lock.Unlock()
+	if !isLeader {
+		return nil
+	}
+	return c.endpoints.check()
+}
+
+func (c *electedCommitter) inTerm(term int) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.term == term && c.isLeader
+}
+
+// getOrganizationPeers returns the peers of the organization the peer at
+// address belongs to in "chaincode.lifecycle.organizations", or nil if it
+// belongs to none
+func getOrganizationPeers(address string) ([]string, error) {
+	orgs, err := chaincode.GetOrganizations()
+	if err != nil {
+		return nil, err
+	}
+	for _, org := range orgs {
+		for _, p := range org.Peers {
+			if p == address {
+				return org.Peers, nil
+			}
+		}
+	}
+	logger.Warningf("Peer %s belongs to no organization, electing the leader among all the peers", address)
+	return nil, nil
+}
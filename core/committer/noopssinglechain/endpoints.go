@@ -0,0 +1,198 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package noopssinglechain
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/hyperledger/fabric/core/comm"
+	"github.com/hyperledger/fabric/core/system_chaincode/cscc"
+	cb "github.com/hyperledger/fabric/protos/common"
+	ab "github.com/hyperledger/fabric/protos/orderer"
+	putils "github.com/hyperledger/fabric/protos/utils"
+	"google.golang.org/grpc"
+)
+
+// ordererEndpoints tracks the addresses of the ordering service of a ledger:
+// those declared in the configuration of the chain the ledger follows, updated
+// as its configuration blocks are committed, or the fallback address of
+// "peer.committer.ledger.orderer" while the configuration declares none
+type ordererEndpoints struct {
+	ledger   string
+	fallback string
+
+	lock      sync.Mutex
+	addresses []string
+	//address connected to last
+	address string
+	conn    *grpc.ClientConn
+}
+
+// endpoints are the ordererEndpoints of the ledgers of the peer
+var endpoints = struct {
+	sync.Mutex
+	ledgers map[string]*ordererEndpoints
+}{ledgers: make(map[string]*ordererEndpoints)}
+
+// newOrdererEndpoints returns the endpoints of the ordering service of a
+// ledger, those of the configuration of its chain once known from its genesis
+// block, see follow
+func newOrdererEndpoints(ledger, fallback string) *ordererEndpoints {
+	e := &ordererEndpoints{ledger: ledger, fallback: fallback}
+	endpoints.Lock()
+	defer endpoints.Unlock()
+	endpoints.ledgers[ledger] = e
+	return e
+}
+
+// OrdererAddresses returns the addresses of the ordering service of a ledger,
+// or nil if the peer commits no blocks to the ledger
+func OrdererAddresses(ledger string) []string {
+	endpoints.Lock()
+	e := endpoints.ledgers[ledger]
+	endpoints.Unlock()
+	if e == nil {
+		return nil
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return append([]string(nil), e.list()...)
+}
+
+// follow applies the addresses of the ordering service declared in the
+// configuration recorded for the chain the ledger follows, if the peer
+// joined it
+func (e *ordererEndpoints) follow(chainID string) {
+	config, err := cscc.GetChainConfig(chainID)
+	if err != nil {
+		logger.Warningf("Could not read the configuration of %s, pulling the blocks of %s from %s(%s)", chainID, e.ledger, e.fallback, err)
+	} else if config != nil {
+		e.update(config)
+	}
+}
+
+// list returns the addresses of the ordering service
+func (e *ordererEndpoints) list() []string {
+	if len(e.addresses) == 0 {
+		return []string{e.fallback}
+	}
+	return e.addresses
+}
+
+func indexOf(addresses []string, address string) int {
+	for i, a := range addresses {
+		if a == address {
+			return i
+		}
+	}
+	return -1
+}
+
+// update applies the addresses of the ordering service declared in a
+// configuration of the chain. The connection to an address no longer declared
+// is closed, so that the deliver client reconnects to one of the new addresses
+func (e *ordererEndpoints) update(config *ab.ConfigurationEnvelope) {
+	addresses, err := putils.GetOrdererAddresses(config)
+	if err != nil {
+		logger.Errorf("Ignoring the orderer addresses of configuration %d of %s(%s)", config.Sequence, e.ledger, err)
+		return
+	}
+	if len(addresses) == 0 {
+		return
+	}
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.addresses = addresses
+	logger.Infof("Ordering service of %s at %v", e.ledger, addresses)
+	if e.conn != nil && indexOf(addresses, e.address) < 0 {
+		logger.Infof("Orderer %s was removed from the configuration of %s, reconnecting", e.address, e.ledger)
+		e.conn.Close()
+		e.conn = nil
+	}
+}
+
+// connect connects to the ordering service, trying each address in turn from
+// the one connected to last, or from the following one when rotate is set
+func (e *ordererEndpoints) connect(rotate bool) (*grpc.ClientConn, error) {
+	e.lock.Lock()
+	addresses := e.list()
+	start := indexOf(addresses, e.address)
+	e.lock.Unlock()
+	if start < 0 || rotate {
+		start++
+	}
+
+	for i := range addresses {
+		address := addresses[(start+i)%len(addresses)]
+		conn, err := dialOrderer(address)
+		if err != nil {
+			logger.Warningf("Could not connect to orderer %s of %s(%s)", address, e.ledger, err)
+			continue
+		}
+
+		e.lock.Lock()
+		e.address = address
+		e.conn = conn
+		e.lock.Unlock()
+		logger.Infof("Pulling the blocks of %s from %s", e.ledger, address)
+		return conn, nil
+	}
+	return nil, fmt.Errorf("no orderer of %s is reachable at %v", e.ledger, addresses)
+}
+
+// check checks that the orderer connected to last, or the first one to
+// connect to, accepts connections
+func (e *ordererEndpoints) check() error {
+	e.lock.Lock()
+	address := e.address
+	if indexOf(e.list(), address) < 0 {
+		address = e.list()[0]
+	}
+	e.lock.Unlock()
+	return checkOrderer(address)
+}
+
+// dialOrderer connects to the orderer at address, over TLS if
+// "peer.committer.ledger.tls.enabled" is set
+func dialOrderer(address string) (*grpc.ClientConn, error) {
+	if !comm.OrdererTLSEnabled() {
+		return comm.NewClientConnectionWithAddress(address, true, false, nil)
+	}
+	creds, err := comm.OrdererClientTLS()
+	if err != nil {
+		return nil, err
+	}
+	return comm.NewClientConnectionWithAddress(address, true, true, creds)
+}
+
+// configCommitted records a configuration block of the chain the ledger
+// follows, committed on the ledger, as the configuration of the chain, and
+// applies the addresses of the ordering service it declares
+func configCommitted(ledger, chainID string, endpoints *ordererEndpoints, block *cb.Block) {
+	config := putils.GetConfigurationEnvelope(block)
+	if config == nil || string(config.ChainID) != chainID {
+		return
+	}
+	if err := cscc.RecordConfigBlock(block); err != nil {
+		logger.Warningf("Could not record configuration block %d of %s(%s)", block.Header.Number, ledger, err)
+	}
+	if endpoints != nil {
+		endpoints.update(config)
+	}
+}
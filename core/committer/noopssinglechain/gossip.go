@@ -0,0 +1,363 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package noopssinglechain
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric/core/chaincode"
+	"github.com/hyperledger/fabric/core/committer"
+	"github.com/hyperledger/fabric/core/ledger/kvledger"
+	"github.com/hyperledger/fabric/core/metrics"
+	"github.com/hyperledger/fabric/core/system_chaincode/cscc"
+	"github.com/hyperledger/fabric/core/tracing"
+	"github.com/hyperledger/fabric/gossip/service"
+	"github.com/hyperledger/fabric/gossip/state"
+	cb "github.com/hyperledger/fabric/protos/common"
+	putils "github.com/hyperledger/fabric/protos/utils"
+	"github.com/spf13/viper"
+	"golang.org/x/net/context"
+)
+
+// gossipCommitter commits the blocks that the peers connected to the orderer
+// disseminate over gossip, for peers without a connection to the orderer
+type gossipCommitter struct {
+	//ledger to commit to
+	ledger string
+
+	//endpoints of the ordering service, for the discovery of the clients
+	endpoints *ordererEndpoints
+}
+
+//Start commits the blocks of the ledger received over gossip in order,
+//starting from the height of the ledger
+func (c *gossipCommitter) Start() error {
+	g := service.GetGossipService()
+	if g == nil {
+		return fmt.Errorf("gossip is not enabled")
+	}
+
+	blocks, err := newInOrderCommitter(c.ledger)
+	if err != nil {
+		return err
+	}
+	blocks.setEndpoints(c.endpoints)
+	if stateProvider := startStateTransfer(blocks); stateProvider != nil {
+		defer stateProvider.Stop()
+	}
+	for block := range service.AcceptBlocks(g, c.ledger) {
+		if err = blocks.add(block); err != nil {
+			logger.Warningf("Dropping block received over gossip(%s)", err)
+		}
+	}
+	return nil
+}
+
+// startStateTransfer makes the peer fetch the blocks it misses from the other
+// peers over gossip, and send them the blocks they miss. Returns nil if gossip
+// or the state transfer is disabled
+func startStateTransfer(blocks *inOrderCommitter) state.GossipStateProvider {
+	g := service.GetGossipService()
+	if g == nil || !viper.GetBool("peer.gossip.state.enabled") {
+		return nil
+	}
+	return service.NewStateProvider(g, blocks.ledger, blocks, installedChaincodes)
+}
+
+// installedChaincodes returns the chaincodes installed on the peer, published
+// to the other peers for their discovery
+func installedChaincodes() []string {
+	names, err := chaincode.GetInstalledChaincodeNames()
+	if err != nil {
+		logger.Warningf("Error reading the installed chaincodes(%s)", err)
+	}
+	return names
+}
+
+// maxPendingBlocks is the number of blocks ahead of the ledger that wait for
+// the missing ones, the blocks further ahead are dropped
+const maxPendingBlocks = 100
+
+// inOrderCommitter commits the blocks of a ledger in order, once verified that
+// they are signed by the orderer and extend the hash chain of the blocks
+// committed before. The blocks arrive out of order, up to maxPendingBlocks
+// ahead of the ledger wait for the missing ones
+type inOrderCommitter struct {
+	ledger   string
+	notifier *committer.StateListenerNotifier
+	archive  *blockArchive
+	metrics  *commitMetrics
+	//endpoints of the ordering service updated by the configuration blocks
+	endpoints *ordererEndpoints
+	//chain the ledger follows, declared by the configuration of its genesis
+	//block, empty until the genesis block is known
+	chainID string
+
+	lock     sync.Mutex
+	stopped  bool
+	next     uint64
+	lastHash []byte
+	pending  map[uint64]*cb.Block
+	//genesis block the chain was joined with, anchoring the hash chain of
+	//an empty ledger, nil for the chains not joined
+	genesis *cb.Block
+}
+
+// committers are the inOrderCommitters of the peer, stopped by Stop
+var committers = struct {
+	sync.Mutex
+	stopped bool
+	list    []*inOrderCommitter
+}{}
+
+// Stop waits for the blocks being committed and makes the committers of the
+// peer drop the following ones, so that the peer stops between two blocks
+func Stop() {
+	committers.Lock()
+	defer committers.Unlock()
+	committers.stopped = true
+	for _, c := range committers.list {
+		c.stop()
+	}
+}
+
+// stop waits for the block being committed and drops the following ones
+func (c *inOrderCommitter) stop() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if !c.stopped {
+		c.notifier.Stop()
+	}
+	c.stopped = true
+}
+
+func (c *inOrderCommitter) isStopped() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.stopped
+}
+
+func newInOrderCommitter(ledger string) (*inOrderCommitter, error) {
+	lgr := kvledger.GetLedger(ledger)
+	info, err := lgr.GetBlockchainInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	// the listeners catch up with the blocks committed before the start
+	notifier := committer.NewStateListenerNotifier(ledger, lgr, committer.GetStateListenerCursorDir(ledger))
+	notifier.NotifyCommitted()
+
+	retention := viper.GetInt("peer.committer.archivedBlocks")
+	if retention < 0 {
+		return nil, fmt.Errorf("Invalid peer.committer.archivedBlocks %d", retention)
+	}
+	archive, err := newBlockArchive(filepath.Join(viper.GetString("peer.fileSystemPath"), "committer", "blocks", ledger), uint64(retention))
+	if err != nil {
+		return nil, err
+	}
+	c := &inOrderCommitter{
+		ledger:   ledger,
+		notifier: notifier,
+		archive:  archive,
+		metrics:  newCommitMetrics(metrics.GetProvider(), ledger),
+		next:     info.Height,
+		pending:  make(map[uint64]*cb.Block),
+	}
+	if info.Height > 0 {
+		last := archive.get(info.Height - 1)
+		if last == nil {
+			return nil, fmt.Errorf("block %d of %s wasn't archived, the hash chain of the blocks that follow can't be verified", info.Height-1, ledger)
+		}
+		c.lastHash = last.Header.Hash()
+		genesis := archive.get(0)
+		if genesis == nil {
+			return nil, fmt.Errorf("genesis block of %s wasn't archived, the chain it follows is unknown", ledger)
+		}
+		c.chainID = genesisChainID(genesis)
+	} else if c.genesis, err = cscc.GetGenesisBlock(ledger); err != nil {
+		return nil, err
+	} else if c.genesis != nil {
+		c.chainID = genesisChainID(c.genesis)
+	}
+	c.metrics.height.Set(float64(info.Height))
+
+	committers.Lock()
+	defer committers.Unlock()
+	c.stopped = committers.stopped
+	if !c.stopped {
+		notifier.Start()
+	}
+	committers.list = append(committers.list, c)
+	return c, nil
+}
+
+// genesisChainID returns the chain ID declared by the configuration of a
+// genesis block, empty if it declares none
+func genesisChainID(block *cb.Block) string {
+	config := putils.GetConfigurationEnvelope(block)
+	if config == nil {
+		return ""
+	}
+	return string(config.ChainID)
+}
+
+// setEndpoints makes the configuration blocks committed update the endpoints
+// of the ordering service, applying the configuration recorded for the chain
+// the ledger follows if known
+func (c *inOrderCommitter) setEndpoints(endpoints *ordererEndpoints) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.endpoints = endpoints
+	if c.chainID != "" {
+		endpoints.follow(c.chainID)
+	}
+}
+
+// height returns the number of the next block to commit
+func (c *inOrderCommitter) height() uint64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.next
+}
+
+// add commits the block if it is the next one of the ledger, along with the
+// pending blocks that follow it. Blocks already committed are ignored
+func (c *inOrderCommitter) add(block *cb.Block) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.stopped {
+		return fmt.Errorf("committer of %s stopped", c.ledger)
+	}
+	if block.Header == nil || block.Data == nil {
+		return fmt.Errorf("block without header or data")
+	}
+	if block.Header.Number < c.next {
+		return nil
+	}
+	if block.Header.Number >= c.next+maxPendingBlocks {
+		return fmt.Errorf("block %d is more than %d blocks ahead of block %d", block.Header.Number, maxPendingBlocks, c.next)
+	}
+	c.pending[block.Header.Number] = block
+	return c.commitPending()
+}
+
+// commitPending commits the pending blocks that follow the ledger
+func (c *inOrderCommitter) commitPending() error {
+	for block, exists := c.pending[c.next]; exists; block, exists = c.pending[c.next] {
+		delete(c.pending, c.next)
+		start := time.Now()
+		// the blocks carry no trace context, so the commit can't be part of
+		// the traces of the endorsements of its transactions
+		span, _ := tracing.StartSpan(context.Background(), "committer.CommitBlock")
+		span.SetTag("ledger", c.ledger)
+		span.SetTag("block", strconv.FormatUint(c.next, 10))
+		span.SetTag("transactions", strconv.Itoa(len(block.Data.Data)))
+		err := c.commitNext(block)
+		span.Finish(err)
+		if err != nil {
+			c.metrics.rejected.Add(1)
+			return err
+		}
+		c.metrics.duration.Observe(time.Since(start).Seconds())
+		c.metrics.height.Set(float64(c.next))
+	}
+	return nil
+}
+
+// commitNext verifies and commits the next block of the ledger
+func (c *inOrderCommitter) commitNext(block *cb.Block) error {
+	if err := cscc.VerifyBlock(block); err != nil {
+		return fmt.Errorf("block %d isn't signed by the orderer: %s", c.next, err)
+	}
+	if err := c.verifyHashChain(block); err != nil {
+		return err
+	}
+	// the block is archived first so that the archive holds every committed
+	// block, anchoring the hash chain of the next one
+	if err := c.archive.put(block); err != nil {
+		return fmt.Errorf("could not archive block %d: %s", c.next, err)
+	}
+	if err := commit(c.ledger, c.notifier, getTransactions(block)); err != nil {
+		return fmt.Errorf("could not commit block %d: %s", c.next, err)
+	}
+	if c.next == 0 {
+		c.chainID = genesisChainID(block)
+	}
+	configCommitted(c.ledger, c.chainID, c.endpoints, block)
+	logger.Debugf("Committed block %d", c.next)
+	c.lastHash = block.Header.Hash()
+	c.next++
+	return nil
+}
+
+// verifyHashChain verifies that the block extends the hash chain of the
+// ledger. The first block of a ledger must be a genesis block and, for a chain
+// joined, the genesis block it was joined with
+func (c *inOrderCommitter) verifyHashChain(block *cb.Block) error {
+	if c.lastHash != nil {
+		if !bytes.Equal(block.Header.PreviousHash, c.lastHash) {
+			return fmt.Errorf("block %d doesn't extend the hash chain", c.next)
+		}
+		return nil
+	}
+	if block.Header.Number != 0 || len(block.Header.PreviousHash) != 0 {
+		return fmt.Errorf("block %d isn't a genesis block", c.next)
+	}
+	if c.genesis != nil && !bytes.Equal(block.Header.Hash(), c.genesis.Header.Hash()) {
+		return fmt.Errorf("block %d isn't the genesis block %s was joined with", c.next, c.ledger)
+	}
+	return nil
+}
+
+// Height returns the number of blocks of the ledger
+func (c *inOrderCommitter) Height() uint64 {
+	return c.height()
+}
+
+// GetBlock returns an archived block of the ledger, nil if it was pruned
+func (c *inOrderCommitter) GetBlock(number uint64) *cb.Block {
+	if number >= c.height() {
+		return nil
+	}
+	return c.archive.get(number)
+}
+
+// CommitBlock verifies that the block is the next one of the ledger and
+// extends its hash chain, and commits it
+func (c *inOrderCommitter) CommitBlock(block *cb.Block) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.stopped {
+		return fmt.Errorf("committer of %s stopped", c.ledger)
+	}
+	if block.Header.Number != c.next {
+		return fmt.Errorf("expected block %d, got block %d", c.next, block.Header.Number)
+	}
+	delete(c.pending, c.next)
+	if err := c.commitNext(block); err != nil {
+		return err
+	}
+	return c.commitPending()
+}
@@ -0,0 +1,51 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package noopssinglechain
+
+import (
+	"github.com/hyperledger/fabric/core/metrics"
+)
+
+// commitMetrics are the metrics of the blocks committed to a ledger
+type commitMetrics struct {
+	height   metrics.Gauge
+	duration metrics.Histogram
+	rejected metrics.Counter
+}
+
+func newCommitMetrics(p metrics.Provider, ledger string) *commitMetrics {
+	return &commitMetrics{
+		height: p.NewGauge(metrics.GaugeOpts{
+			Namespace:  "ledger",
+			Name:       "blockchain_height",
+			Help:       "The height of the ledger.",
+			LabelNames: []string{"ledger"},
+		}).With("ledger", ledger),
+		duration: p.NewHistogram(metrics.HistogramOpts{
+			Namespace:  "ledger",
+			Name:       "block_commit_duration",
+			Help:       "The time to commit a block to the ledger, in seconds.",
+			LabelNames: []string{"ledger"},
+		}).With("ledger", ledger),
+		rejected: p.NewCounter(metrics.CounterOpts{
+			Namespace:  "ledger",
+			Name:       "blocks_rejected",
+			Help:       "The number of blocks that failed verification or commit.",
+			LabelNames: []string{"ledger"},
+		}).With("ledger", ledger),
+	}
+}
@@ -0,0 +1,124 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package noopssinglechain
+
+import (
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/core/chaincode"
+	"github.com/hyperledger/fabric/core/ledger/kvledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt"
+	pb "github.com/hyperledger/fabric/protos"
+	putils "github.com/hyperledger/fabric/protos/utils"
+	"golang.org/x/net/context"
+)
+
+// defaultVSCC validates the transactions writing the state of system
+// chaincodes and of chaincodes lccc has no record of yet, such as the
+// chaincode instantiated by the transaction
+const defaultVSCC = "vscc"
+
+// validateTransactions returns the transactions accepted by the validation
+// system chaincode lccc records for each chaincode whose state they write
+func validateTransactions(ledger string, txs []*pb.Transaction2) ([]*pb.Transaction2, error) {
+	txsim, err := kvledger.GetLedger(ledger).NewTxSimulator()
+	if err != nil {
+		return nil, err
+	}
+	defer txsim.Done()
+	ctxt := context.WithValue(context.Background(), chaincode.TXSimulatorKey, txsim)
+
+	vsccs := make(map[string]string)
+	var valid []*pb.Transaction2
+	for _, tx := range txs {
+		if err = validateTransaction(ctxt, ledger, tx, vsccs); err != nil {
+			logger.Warningf("Invalid transaction: %s", err)
+			continue
+		}
+		valid = append(valid, tx)
+	}
+	return valid, nil
+}
+
+// validateTransaction invokes the validation system chaincode of each
+// chaincode whose state the transaction writes with a block of the
+// transaction. vsccs caches the validation system chaincodes of the chaincodes
+func validateTransaction(ctxt context.Context, ledger string, tx *pb.Transaction2, vsccs map[string]string) error {
+	namespaces, err := getWrittenNamespaces(tx)
+	if err != nil {
+		return err
+	}
+	if len(namespaces) == 0 {
+		return nil
+	}
+	block, err := proto.Marshal(constructBlock([]*pb.Transaction2{tx}))
+	if err != nil {
+		return err
+	}
+
+	for _, ns := range namespaces {
+		vscc, ok := vsccs[ns]
+		if !ok {
+			vscc = getVSCC(ctxt, ledger, ns)
+			vsccs[ns] = vscc
+		}
+		if _, _, err = chaincode.ExecuteChaincode(ctxt, pb.Transaction_CHAINCODE_INVOKE, string(chaincode.DefaultChain), vscc, [][]byte{[]byte(ledger), block}); err != nil {
+			return fmt.Errorf("rejected by %s validating chaincode %s: %s", vscc, ns, err)
+		}
+	}
+	return nil
+}
+
+// getVSCC returns the validation system chaincode lccc records for the
+// chaincode
+func getVSCC(ctxt context.Context, ledger string, ccname string) string {
+	if chaincode.IsSysCC(ccname) {
+		return defaultVSCC
+	}
+	info, err := chaincode.GetChaincodeDataFromLCCC(ctxt, ledger, ccname)
+	if err != nil || info.Vscc == "" {
+		logger.Debugf("No validation system chaincode recorded for chaincode %s, validating with %s", ccname, defaultVSCC)
+		return defaultVSCC
+	}
+	return info.Vscc
+}
+
+// getWrittenNamespaces returns the namespaces, named after the chaincodes,
+// whose state the transaction writes
+func getWrittenNamespaces(tx *pb.Transaction2) ([]string, error) {
+	var namespaces []string
+	for _, action := range tx.Actions {
+		_, respPayload, err := putils.GetPayloads(action)
+		if err != nil {
+			return nil, err
+		}
+		if respPayload == nil {
+			continue
+		}
+		txRWSet := &txmgmt.TxReadWriteSet{}
+		if err = txRWSet.Unmarshal(respPayload.Results); err != nil {
+			return nil, fmt.Errorf("invalid read-write set: %s", err)
+		}
+		for _, nsRWSet := range txRWSet.NsRWs {
+			if len(nsRWSet.Writes) > 0 {
+				namespaces = append(namespaces, nsRWSet.NameSpace)
+			}
+		}
+	}
+	return namespaces, nil
+}
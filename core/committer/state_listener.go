@@ -0,0 +1,258 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package committer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hyperledger/fabric/core/ledger/kvledger/cdc"
+	"github.com/hyperledger/fabric/protos"
+	"github.com/op/go-logging"
+	"github.com/spf13/viper"
+)
+
+var logger = logging.MustGetLogger("committer")
+
+// cursorFileSuffix is the suffix of the names of the files holding the cursors of the listeners
+const cursorFileSuffix = ".cursor"
+
+// StateUpdates are the writes of the valid transactions of a committed block, by namespace
+type StateUpdates map[string][]*cdc.Write
+
+// StateListener - an interface for plugins that are notified of the state updates of the committed blocks,
+// such as a plugin that keeps an off-chain index of the state in a database
+type StateListener interface {
+	// StateUpdatesCommitted is invoked for every committed block of the ledger, in the order of the blocks.
+	// The notification is delivered at least once: a block is delivered again if the listener returns an error
+	// or if the peer stops before the cursor of the listener is persisted, so the updates should be applied idempotently
+	StateUpdatesCommitted(ledgerName string, blockNum uint64, updates StateUpdates) error
+}
+
+var (
+	stateListenersLock sync.RWMutex
+	stateListeners     = make(map[string]StateListener)
+)
+
+// RegisterStateListener registers a listener by the given name. The name identifies the cursor of the
+// listener, i.e. the last block delivered to it, so a listener should keep its name across restarts
+func RegisterStateListener(name string, listener StateListener) {
+	stateListenersLock.Lock()
+	defer stateListenersLock.Unlock()
+	stateListeners[name] = listener
+}
+
+// UnregisterStateListener removes the listener registered by the given name. Its cursor is kept
+func UnregisterStateListener(name string) {
+	stateListenersLock.Lock()
+	defer stateListenersLock.Unlock()
+	delete(stateListeners, name)
+}
+
+func getStateListeners() ([]string, map[string]StateListener) {
+	stateListenersLock.RLock()
+	defer stateListenersLock.RUnlock()
+	names := []string{}
+	listeners := make(map[string]StateListener)
+	for name, listener := range stateListeners {
+		names = append(names, name)
+		listeners[name] = listener
+	}
+	sort.Strings(names)
+	return names, listeners
+}
+
+// CommittedLedger - the ledger queries needed to notify the listeners
+type CommittedLedger interface {
+	GetBlockchainInfo() (*protos.BlockchainInfo, error)
+	GetBlockWrites(blockNum uint64) ([]*cdc.Write, error)
+}
+
+// GetStateListenerCursorDir returns the directory under which the cursors of the listeners of a ledger are persisted
+func GetStateListenerCursorDir(ledgerName string) string {
+	return filepath.Join(viper.GetString("peer.fileSystemPath"), "committer", "listeners", ledgerName)
+}
+
+// StateListenerNotifier delivers the state updates of the committed blocks of a ledger to the registered listeners
+type StateListenerNotifier struct {
+	lock       sync.Mutex
+	ledgerName string
+	ledger     CommittedLedger
+	cursorDir  string
+
+	notifyChan chan struct{}
+	stopChan   chan struct{}
+	done       sync.WaitGroup
+}
+
+// NewStateListenerNotifier constructs a `StateListenerNotifier` that persists the cursors of the listeners under `cursorDir`
+func NewStateListenerNotifier(ledgerName string, ledger CommittedLedger, cursorDir string) *StateListenerNotifier {
+	return &StateListenerNotifier{ledgerName: ledgerName, ledger: ledger, cursorDir: cursorDir,
+		notifyChan: make(chan struct{}, 1), stopChan: make(chan struct{})}
+}
+
+// Start starts delivering the state updates in the background, whenever NotifyCommitted is invoked
+func (n *StateListenerNotifier) Start() {
+	n.done.Add(1)
+	go func() {
+		defer n.done.Done()
+		for {
+			select {
+			case <-n.notifyChan:
+				n.notifyAll()
+			case <-n.stopChan:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the background delivery once the delivery in progress, if any, is done. It is invoked once
+func (n *StateListenerNotifier) Stop() {
+	close(n.stopChan)
+	n.done.Wait()
+}
+
+// NotifyCommitted makes the background delivery catch up with the last committed block, so that the commits are
+// not held back by the listeners. It is invoked after every commit, and on start to catch up with the blocks
+// committed while a listener was not registered. The notifications made while a delivery is in progress are
+// served by a single delivery
+func (n *StateListenerNotifier) NotifyCommitted() {
+	select {
+	case n.notifyChan <- struct{}{}:
+	default:
+	}
+}
+
+// notifyAll delivers to each registered listener the state updates of the blocks after its cursor, up to
+// the last committed block. A listener that returns an error is left behind until the next notification,
+// without holding back the other listeners
+func (n *StateListenerNotifier) notifyAll() {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	names, listeners := getStateListeners()
+	if len(names) == 0 {
+		return
+	}
+	bcInfo, err := n.ledger.GetBlockchainInfo()
+	if err != nil {
+		logger.Errorf("Error getting the height of ledger %s to notify the state listeners: %s", n.ledgerName, err)
+		return
+	}
+	for _, name := range names {
+		if err := n.notify(name, listeners[name], bcInfo.Height); err != nil {
+			logger.Errorf("Error notifying the state listener [%s] of ledger %s: %s", name, n.ledgerName, err)
+		}
+	}
+}
+
+func (n *StateListenerNotifier) notify(name string, listener StateListener, height uint64) error {
+	cursor, err := n.getCursor(name)
+	if err != nil {
+		return err
+	}
+	for blockNum := cursor + 1; blockNum <= height; blockNum++ {
+		writes, err := n.ledger.GetBlockWrites(blockNum)
+		if err != nil {
+			return err
+		}
+		updates := make(StateUpdates)
+		for _, write := range writes {
+			updates[write.Namespace] = append(updates[write.Namespace], write)
+		}
+		if err = listener.StateUpdatesCommitted(n.ledgerName, blockNum, updates); err != nil {
+			return fmt.Errorf("Error delivering block [%d]: %s", blockNum, err)
+		}
+		if err = n.setCursor(name, blockNum); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ResetCursors moves back to blockNum the cursors ahead of it, of the registered listeners or not, so that
+// the blocks committed again after a rollback of the ledger to blockNum are delivered again
+func (n *StateListenerNotifier) ResetCursors(blockNum uint64) error {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	files, err := ioutil.ReadDir(n.cursorDir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if !strings.HasSuffix(file.Name(), cursorFileSuffix) {
+			continue
+		}
+		name := strings.TrimSuffix(file.Name(), cursorFileSuffix)
+		cursor, err := n.getCursor(name)
+		if err != nil {
+			return err
+		}
+		if cursor <= blockNum {
+			continue
+		}
+		if err = n.setCursor(name, blockNum); err != nil {
+			return err
+		}
+		logger.Infof("Reset the cursor of the state listener [%s] of ledger %s from block [%d] to block [%d]",
+			name, n.ledgerName, cursor, blockNum)
+	}
+	return nil
+}
+
+// getCursor returns the last block delivered to the listener, 0 if none
+func (n *StateListenerNotifier) getCursor(name string) (uint64, error) {
+	cursorBytes, err := ioutil.ReadFile(n.cursorPath(name))
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	cursor, err := strconv.ParseUint(strings.TrimSpace(string(cursorBytes)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid cursor of state listener [%s]: %s", name, err)
+	}
+	return cursor, nil
+}
+
+// setCursor persists the last block delivered to the listener. The cursor is written to a temporary file
+// that is renamed over the cursor file, so that a failure leaves either the old or the new cursor
+func (n *StateListenerNotifier) setCursor(name string, blockNum uint64) error {
+	if err := os.MkdirAll(n.cursorDir, 0755); err != nil {
+		return err
+	}
+	cursorPath := n.cursorPath(name)
+	tmpPath := cursorPath + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, []byte(strconv.FormatUint(blockNum, 10)), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, cursorPath)
+}
+
+func (n *StateListenerNotifier) cursorPath(name string) string {
+	return filepath.Join(n.cursorDir, name+cursorFileSuffix)
+}
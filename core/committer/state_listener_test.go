@@ -0,0 +1,164 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package committer
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/core/ledger/kvledger/cdc"
+	"github.com/hyperledger/fabric/core/ledger/testutil"
+	"github.com/hyperledger/fabric/protos"
+)
+
+type testLedger struct {
+	blocks [][]*cdc.Write
+}
+
+func (l *testLedger) GetBlockchainInfo() (*protos.BlockchainInfo, error) {
+	return &protos.BlockchainInfo{Height: uint64(len(l.blocks))}, nil
+}
+
+func (l *testLedger) GetBlockWrites(blockNum uint64) ([]*cdc.Write, error) {
+	return l.blocks[blockNum-1], nil
+}
+
+type testStateListener struct {
+	delivered []uint64
+	updates   map[uint64]StateUpdates
+	failAt    uint64
+}
+
+func (l *testStateListener) StateUpdatesCommitted(ledgerName string, blockNum uint64, updates StateUpdates) error {
+	if blockNum == l.failAt {
+		return errors.New("listener failure")
+	}
+	l.delivered = append(l.delivered, blockNum)
+	l.updates[blockNum] = updates
+	return nil
+}
+
+func TestStateListenerNotifier(t *testing.T) {
+	cursorDir, err := ioutil.TempDir("", "statelistener")
+	testutil.AssertNoError(t, err, "")
+	defer os.RemoveAll(cursorDir)
+
+	write1 := &cdc.Write{BlockNumber: 1, Namespace: "ns1", Key: "key1", Value: []byte("value1")}
+	write2 := &cdc.Write{BlockNumber: 1, TxNumber: 1, Namespace: "ns2", Key: "key2", Value: []byte("value2")}
+	write3 := &cdc.Write{BlockNumber: 2, Namespace: "ns1", Key: "key1", IsDelete: true}
+	ledger := &testLedger{blocks: [][]*cdc.Write{{write1, write2}, {write3}}}
+	notifier := NewStateListenerNotifier("testLedger", ledger, cursorDir)
+
+	listener1 := &testStateListener{updates: make(map[uint64]StateUpdates)}
+	listener2 := &testStateListener{updates: make(map[uint64]StateUpdates), failAt: 2}
+	RegisterStateListener("listener1", listener1)
+	RegisterStateListener("listener2", listener2)
+	defer UnregisterStateListener("listener1")
+	defer UnregisterStateListener("listener2")
+
+	// a failing listener is left behind without holding back the other listeners
+	notifier.notifyAll()
+	testutil.AssertEquals(t, listener1.delivered, []uint64{1, 2})
+	testutil.AssertEquals(t, listener1.updates[1], StateUpdates{"ns1": {write1}, "ns2": {write2}})
+	testutil.AssertEquals(t, listener1.updates[2], StateUpdates{"ns1": {write3}})
+	testutil.AssertEquals(t, listener2.delivered, []uint64{1})
+
+	// the failed block is delivered again on the next notification, along with the new blocks
+	write4 := &cdc.Write{BlockNumber: 3, Namespace: "ns2", Key: "key2", Value: []byte("value3")}
+	ledger.blocks = append(ledger.blocks, []*cdc.Write{write4})
+	listener2.failAt = 0
+	notifier.notifyAll()
+	testutil.AssertEquals(t, listener1.delivered, []uint64{1, 2, 3})
+	testutil.AssertEquals(t, listener2.delivered, []uint64{1, 2, 3})
+
+	// the cursors are persisted, so a new notifier delivers only the blocks after them
+	listener3 := &testStateListener{updates: make(map[uint64]StateUpdates)}
+	RegisterStateListener("listener1", listener3)
+	notifier = NewStateListenerNotifier("testLedger", ledger, cursorDir)
+	notifier.notifyAll()
+	testutil.AssertNil(t, listener3.delivered)
+	ledger.blocks = append(ledger.blocks, []*cdc.Write{})
+	notifier.notifyAll()
+	testutil.AssertEquals(t, listener3.delivered, []uint64{4})
+	testutil.AssertEquals(t, listener3.updates[4], StateUpdates{})
+}
+
+type testChanStateListener struct {
+	delivered chan uint64
+}
+
+func (l *testChanStateListener) StateUpdatesCommitted(ledgerName string, blockNum uint64, updates StateUpdates) error {
+	l.delivered <- blockNum
+	return nil
+}
+
+func TestStateListenerNotifierAsync(t *testing.T) {
+	cursorDir, err := ioutil.TempDir("", "statelistener")
+	testutil.AssertNoError(t, err, "")
+	defer os.RemoveAll(cursorDir)
+
+	ledger := &testLedger{blocks: [][]*cdc.Write{{}, {}}}
+	notifier := NewStateListenerNotifier("testLedger", ledger, cursorDir)
+	listener := &testChanStateListener{delivered: make(chan uint64)}
+	RegisterStateListener("listener", listener)
+	defer UnregisterStateListener("listener")
+
+	// the notification returns while the listener has not received the blocks yet
+	notifier.NotifyCommitted()
+	notifier.Start()
+	defer notifier.Stop()
+	for _, expectedBlockNum := range []uint64{1, 2} {
+		select {
+		case blockNum := <-listener.delivered:
+			testutil.AssertEquals(t, blockNum, expectedBlockNum)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Block [%d] was not delivered", expectedBlockNum)
+		}
+	}
+}
+
+func TestStateListenerNotifierResetCursors(t *testing.T) {
+	cursorDir, err := ioutil.TempDir("", "statelistener")
+	testutil.AssertNoError(t, err, "")
+	defer os.RemoveAll(cursorDir)
+
+	ledger := &testLedger{blocks: [][]*cdc.Write{{}, {}, {}}}
+	notifier := NewStateListenerNotifier("testLedger", ledger, cursorDir)
+	testutil.AssertNoError(t, notifier.ResetCursors(1), "")
+	listener := &testStateListener{updates: make(map[uint64]StateUpdates)}
+	RegisterStateListener("listener", listener)
+	defer UnregisterStateListener("listener")
+	notifier.notifyAll()
+	testutil.AssertEquals(t, listener.delivered, []uint64{1, 2, 3})
+
+	// after a rollback to block 1, the blocks committed again are delivered again
+	testutil.AssertNoError(t, notifier.ResetCursors(1), "")
+	cursor, err := notifier.getCursor("listener")
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, cursor, uint64(1))
+	ledger.blocks = ledger.blocks[:2]
+	notifier.notifyAll()
+	testutil.AssertEquals(t, listener.delivered, []uint64{1, 2, 3, 2})
+
+	// the cursors behind the rollback block are kept
+	testutil.AssertNoError(t, notifier.ResetCursors(3), "")
+	cursor, _ = notifier.getCursor("listener")
+	testutil.AssertEquals(t, cursor, uint64(2))
+}
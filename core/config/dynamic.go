@@ -0,0 +1,186 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric/core/audit"
+	"github.com/spf13/viper"
+)
+
+// DynamicSetting is a group of settings of the config file applied to the
+// running process, without a restart, when the file changes
+type DynamicSetting struct {
+	// Keys are the keys of the settings of the group
+	Keys []string
+	// Apply validates the values of the settings read from config and
+	// applies them. The settings keep their values when it returns an error
+	Apply func(config *viper.Viper) error
+}
+
+// The changes of the dynamic settings, applied or rejected, are recorded to
+// the audit log as made by the local operator of the config file
+const auditIdentity = "local"
+
+var dynamicSettings = struct {
+	sync.Mutex
+	settings map[string]DynamicSetting
+}{settings: make(map[string]DynamicSetting)}
+
+// RegisterDynamicSetting registers the DynamicSetting applied by the
+// ConfigWatcher under name
+func RegisterDynamicSetting(name string, setting DynamicSetting) {
+	dynamicSettings.Lock()
+	defer dynamicSettings.Unlock()
+	dynamicSettings.settings[name] = setting
+}
+
+func registeredDynamicSettings() map[string]DynamicSetting {
+	dynamicSettings.Lock()
+	defer dynamicSettings.Unlock()
+	settings := make(map[string]DynamicSetting, len(dynamicSettings.settings))
+	for name, setting := range dynamicSettings.settings {
+		settings[name] = setting
+	}
+	return settings
+}
+
+// ConfigWatcher applies the changes of the registered dynamic settings in a
+// config file. The settings overridden by environment variables keep their
+// values
+type ConfigWatcher struct {
+	file      string
+	envPrefix string
+
+	lock     sync.Mutex
+	modTime  time.Time
+	size     int64
+	baseline *viper.Viper
+	applied  map[string]string
+}
+
+// NewConfigWatcher returns the ConfigWatcher of file, whose settings are
+// overridden by the environment variables of envPrefix as in viper. The
+// settings in force are those of the file at the time of the call
+func NewConfigWatcher(file, envPrefix string) (*ConfigWatcher, error) {
+	w := &ConfigWatcher{file: file, envPrefix: envPrefix, applied: make(map[string]string)}
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to watch config file %s: %s", file, err)
+	}
+	if w.baseline, err = w.read(); err != nil {
+		return nil, err
+	}
+	w.modTime, w.size = info.ModTime(), info.Size()
+	return w, nil
+}
+
+func (w *ConfigWatcher) read() (*viper.Viper, error) {
+	config := viper.New()
+	config.SetEnvPrefix(w.envPrefix)
+	config.AutomaticEnv()
+	config.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	config.SetConfigFile(w.file)
+	if err := config.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("Failed to read config file %s: %s", w.file, err)
+	}
+	return config, nil
+}
+
+// value returns the value in force of key, the last one applied or else
+// the one of the file when the watcher was created
+func (w *ConfigWatcher) value(key string) string {
+	if value, ok := w.applied[key]; ok {
+		return value
+	}
+	return fmt.Sprint(w.baseline.Get(key))
+}
+
+// Apply reads the config file and applies the dynamic settings whose values
+// changed. It returns the first error, after trying to apply all of them
+func (w *ConfigWatcher) Apply() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	config, err := w.read()
+	if err != nil {
+		audit.Record(auditIdentity, "config.change", w.file, err)
+		return err
+	}
+
+	settings := registeredDynamicSettings()
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var firstErr error
+	for _, name := range names {
+		setting := settings[name]
+		var changes []string
+		values := make(map[string]string, len(setting.Keys))
+		for _, key := range setting.Keys {
+			values[key] = fmt.Sprint(config.Get(key))
+			if previous := w.value(key); previous != values[key] {
+				changes = append(changes, fmt.Sprintf("%s: %s -> %s", key, previous, values[key]))
+			}
+		}
+		if len(changes) == 0 {
+			continue
+		}
+		if err := setting.Apply(config); err != nil {
+			audit.Record(auditIdentity, "config.change", fmt.Sprintf("%s in %s (%s)", name, w.file, strings.Join(changes, ", ")), err)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("Invalid %s: %s", name, err)
+			}
+			continue
+		}
+		for key, value := range values {
+			w.applied[key] = value
+		}
+		audit.Record(auditIdentity, "config.change", fmt.Sprintf("%s in %s (%s)", name, w.file, strings.Join(changes, ", ")), nil)
+	}
+	return firstErr
+}
+
+// Watch applies the changes of the dynamic settings, checking the config
+// file every interval until stop is closed
+func (w *ConfigWatcher) Watch(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		info, err := os.Stat(w.file)
+		if err != nil || (info.ModTime().Equal(w.modTime) && info.Size() == w.size) {
+			continue
+		}
+		w.modTime, w.size = info.ModTime(), info.Size()
+		// An invalid change is logged, and tried again on the next change
+		// of the file
+		w.Apply()
+	}
+}
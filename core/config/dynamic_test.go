@@ -0,0 +1,97 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeConfig(t *testing.T, file string, limit int) {
+	content := fmt.Sprintf("peer:\n    limits:\n        concurrency:\n            endorser: %d\n", limit)
+	if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %s", file, err)
+	}
+}
+
+func TestConfigWatcher(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dynamic")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "core.yaml")
+	writeConfig(t, file, 10)
+
+	var applied []int
+	RegisterDynamicSetting("test", DynamicSetting{
+		Keys: []string{"peer.limits.concurrency.endorser"},
+		Apply: func(c *viper.Viper) error {
+			limit := c.GetInt("peer.limits.concurrency.endorser")
+			if limit < 0 {
+				return fmt.Errorf("negative limit %d", limit)
+			}
+			applied = append(applied, limit)
+			return nil
+		},
+	})
+	defer func() {
+		dynamicSettings.Lock()
+		defer dynamicSettings.Unlock()
+		delete(dynamicSettings.settings, "test")
+	}()
+
+	w, err := NewConfigWatcher(file, "dynamictest")
+	if err != nil {
+		t.Fatalf("Failed to create the watcher: %s", err)
+	}
+
+	// Unchanged settings are not applied again
+	assert.NoError(t, w.Apply())
+	assert.Empty(t, applied)
+
+	writeConfig(t, file, 20)
+	assert.NoError(t, w.Apply())
+	assert.Equal(t, []int{20}, applied)
+
+	// An invalid value is rejected, the setting keeps its value
+	writeConfig(t, file, -1)
+	assert.Error(t, w.Apply())
+	assert.Equal(t, "20", w.value("peer.limits.concurrency.endorser"))
+	writeConfig(t, file, 20)
+	assert.NoError(t, w.Apply())
+	assert.Equal(t, []int{20}, applied)
+
+	// The environment variables override the config file
+	os.Setenv("DYNAMICTEST_PEER_LIMITS_CONCURRENCY_ENDORSER", "30")
+	defer os.Unsetenv("DYNAMICTEST_PEER_LIMITS_CONCURRENCY_ENDORSER")
+	assert.NoError(t, w.Apply())
+	writeConfig(t, file, 40)
+	assert.NoError(t, w.Apply())
+	assert.Equal(t, []int{20, 30}, applied)
+}
+
+func TestConfigWatcherMissingFile(t *testing.T) {
+	_, err := NewConfigWatcher(filepath.Join(os.TempDir(), "missing", "core.yaml"), "core")
+	assert.Error(t, err)
+}
@@ -42,9 +42,17 @@ func GetCCHandlerKey() string {
 	return "CCHANDLER"
 }
 
+// GetCCServerKey is used to pass the ChaincodeServerInfo of a chaincode run as
+// an external service via context
+func GetCCServerKey() string {
+	return "CCSERVER"
+}
+
 //CCID encapsulates chaincode ID
 type CCID struct {
 	ChaincodeSpec *pb.ChaincodeSpec
 	NetworkID     string
 	PeerID        string
+	// the chain the chaincode runs on, empty for the default chain
+	ChainID string
 }
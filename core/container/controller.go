@@ -25,6 +25,8 @@ import (
 
 	"github.com/hyperledger/fabric/core/container/ccintf"
 	"github.com/hyperledger/fabric/core/container/dockercontroller"
+	"github.com/hyperledger/fabric/core/container/externalbuilder"
+	"github.com/hyperledger/fabric/core/container/externalcontroller"
 	"github.com/hyperledger/fabric/core/container/inproccontroller"
 )
 
@@ -57,8 +59,9 @@ var vmcontroller *VMController
 
 //constants for supported containers
 const (
-	DOCKER = "Docker"
-	SYSTEM = "System"
+	DOCKER   = "Docker"
+	SYSTEM   = "System"
+	EXTERNAL = "External"
 )
 
 //NewVMController - creates/returns singleton
@@ -75,8 +78,14 @@ func (vmc *VMController) newVM(typ string) vm {
 	switch typ {
 	case DOCKER:
 		v = &dockercontroller.DockerVM{}
+		//the external builders take over the chaincodes they detect
+		if externalbuilder.Enabled() {
+			v = externalbuilder.NewExternalBuilderVM(v)
+		}
 	case SYSTEM:
 		v = &inproccontroller.InprocVM{}
+	case EXTERNAL:
+		v = &externalcontroller.ExternalVM{}
 	default:
 		v = &dockercontroller.DockerVM{}
 	}
@@ -25,6 +25,7 @@ import (
 	"github.com/fsouza/go-dockerclient"
 	"github.com/hyperledger/fabric/core/container/ccintf"
 	cutil "github.com/hyperledger/fabric/core/container/util"
+	pb "github.com/hyperledger/fabric/protos"
 	"github.com/op/go-logging"
 	"github.com/spf13/viper"
 	"golang.org/x/net/context"
@@ -40,6 +41,16 @@ type DockerVM struct {
 	id string
 }
 
+// HealthCheck checks that the docker daemon the chaincode containers run on
+// responds
+func HealthCheck() error {
+	client, err := cutil.NewDockerClient()
+	if err != nil {
+		return fmt.Errorf("Error creating docker client: %s", err)
+	}
+	return client.Ping()
+}
+
 func getDockerHostConfig() *docker.HostConfig {
 	if hostConfig != nil {
 		return hostConfig
@@ -95,14 +106,65 @@ func getDockerHostConfig() *docker.HostConfig {
 		CPUQuota:         getInt64("CpuQuota"),
 		CPUPeriod:        getInt64("CpuPeriod"),
 		BlkioWeight:      getInt64("BlkioWeight"),
+		PidsLimit:        getInt64("PidsLimit"),
 	}
 
 	return hostConfig
 }
 
-func (vm *DockerVM) createContainer(ctxt context.Context, client *docker.Client, imageID string, containerID string, args []string, env []string, attachstdin bool, attachstdout bool) error {
+//getHostConfig returns the host config for the container of the given
+//chaincode. Resource limits requested in the chaincode spec replace the
+//ones configured for the peer but can never exceed them
+func getHostConfig(spec *pb.ChaincodeSpec) *docker.HostConfig {
+	peerConfig := getDockerHostConfig()
+	limits := spec.GetResourceLimits()
+	if limits == nil {
+		return peerConfig
+	}
+
+	config := *peerConfig
+	config.CPUShares = resourceLimit("CpuShares", peerConfig.CPUShares, limits.CpuShares)
+	config.Memory = resourceLimit("Memory", peerConfig.Memory, limits.Memory)
+	config.PidsLimit = resourceLimit("PidsLimit", peerConfig.PidsLimit, limits.PidsLimit)
+	return &config
+}
+
+//getDebugHostConfig publishes the port the debugger of the chaincode listens
+//on when chaincode debugging is enabled. A container in the network of the
+//host listens on the host already
+func getDebugHostConfig(config *docker.HostConfig) *docker.HostConfig {
+	port := viper.GetInt("chaincode.debug.port")
+	if !viper.GetBool("chaincode.debug.enabled") || port <= 0 || config.NetworkMode == "host" {
+		return config
+	}
+
+	debugConfig := *config
+	debugConfig.PortBindings = map[docker.Port][]docker.PortBinding{
+		docker.Port(fmt.Sprintf("%d/tcp", port)): {{HostIP: "127.0.0.1"}},
+	}
+	return &debugConfig
+}
+
+func resourceLimit(key string, peerLimit int64, requested int64) int64 {
+	if requested <= 0 {
+		return peerLimit
+	}
+	if peerLimit > 0 && requested > peerLimit {
+		dockerLogger.Warningf("requested %s %d exceeds vm.docker.hostConfig.%s, using %d", key, requested, key, peerLimit)
+		return peerLimit
+	}
+	return requested
+}
+
+func (vm *DockerVM) createContainer(ctxt context.Context, client *docker.Client, imageID string, containerID string, hostConfig *docker.HostConfig, args []string, env []string, attachstdin bool, attachstdout bool) error {
 	config := docker.Config{Cmd: args, Image: imageID, Env: env, AttachStdin: attachstdin, AttachStdout: attachstdout}
-	copts := docker.CreateContainerOptions{Name: containerID, Config: &config, HostConfig: getDockerHostConfig()}
+	if len(hostConfig.PortBindings) > 0 {
+		config.ExposedPorts = make(map[docker.Port]struct{})
+		for port := range hostConfig.PortBindings {
+			config.ExposedPorts[port] = struct{}{}
+		}
+	}
+	copts := docker.CreateContainerOptions{Name: containerID, Config: &config, HostConfig: hostConfig}
 	dockerLogger.Debugf("Create container: %s", containerID)
 	_, err := client.CreateContainer(copts)
 	if err != nil {
@@ -120,6 +182,7 @@ func (vm *DockerVM) deployImage(client *docker.Client, ccid ccintf.CCID, args []
 		Pull:         false,
 		InputStream:  reader,
 		OutputStream: outputbuf,
+		BuildArgs:    cutil.GetBuildArgs(),
 	}
 
 	if err := client.BuildImage(opts); err != nil {
@@ -159,14 +222,15 @@ func (vm *DockerVM) Start(ctxt context.Context, ccid ccintf.CCID, args []string,
 		return err
 	}
 
-	containerID := strings.Replace(imageID, ":", "_", -1)
+	containerID := getContainerName(ccid, imageID)
 
 	//stop,force remove if necessary
 	dockerLogger.Debugf("Cleanup container %s", containerID)
 	vm.stopInternal(ctxt, client, containerID, 0, false, false)
 
 	dockerLogger.Debugf("Start container %s", containerID)
-	err = vm.createContainer(ctxt, client, imageID, containerID, args, env, attachstdin, attachstdout)
+	hostConfig := getDebugHostConfig(getHostConfig(ccid.ChaincodeSpec))
+	err = vm.createContainer(ctxt, client, imageID, containerID, hostConfig, args, env, attachstdin, attachstdout)
 	if err != nil {
 		//if image not found try to create image and retry
 		if err == docker.ErrNoSuchImage {
@@ -177,7 +241,7 @@ func (vm *DockerVM) Start(ctxt context.Context, ccid ccintf.CCID, args []string,
 				}
 
 				dockerLogger.Debug("start-recreated image successfully")
-				if err = vm.createContainer(ctxt, client, imageID, containerID, args, env, attachstdin, attachstdout); err != nil {
+				if err = vm.createContainer(ctxt, client, imageID, containerID, hostConfig, args, env, attachstdin, attachstdout); err != nil {
 					dockerLogger.Errorf("start-could not recreate container post recreate image: %s", err)
 					return err
 				}
@@ -199,9 +263,42 @@ func (vm *DockerVM) Start(ctxt context.Context, ccid ccintf.CCID, args []string,
 	}
 
 	dockerLogger.Debugf("Started container %s", containerID)
+	if len(hostConfig.PortBindings) > 0 {
+		logDebugPorts(client, containerID)
+	}
+	go watchContainer(client, containerID)
 	return nil
 }
 
+//logDebugPorts reports the host ports the debugger of the chaincode is reachable on
+func logDebugPorts(client *docker.Client, containerID string) {
+	container, err := client.InspectContainer(containerID)
+	if err != nil || container.NetworkSettings == nil {
+		dockerLogger.Warningf("Could not find the debug ports of container %s: %v", containerID, err)
+		return
+	}
+	for port, bindings := range container.NetworkSettings.Ports {
+		for _, binding := range bindings {
+			dockerLogger.Infof("Debugger of container %s listening on port %s is reachable on %s:%s", containerID, port, binding.HostIP, binding.HostPort)
+		}
+	}
+}
+
+//watchContainer reports how the container exited. The peer notices the exit
+//through the chaincode stream ending and relaunches the chaincode
+func watchContainer(client *docker.Client, containerID string) {
+	code, err := client.WaitContainer(containerID)
+	if err != nil {
+		dockerLogger.Debugf("stopped watching container %s: %s", containerID, err)
+		return
+	}
+	if container, err := client.InspectContainer(containerID); err == nil && container.State.OOMKilled {
+		dockerLogger.Warningf("Container %s was killed for exceeding its memory limit", containerID)
+		return
+	}
+	dockerLogger.Infof("Container %s exited with code %d", containerID, code)
+}
+
 //Stop stops a running chaincode
 func (vm *DockerVM) Stop(ctxt context.Context, ccid ccintf.CCID, timeout uint, dontkill bool, dontremove bool) error {
 	id, _ := vm.GetVMName(ccid)
@@ -210,7 +307,7 @@ func (vm *DockerVM) Stop(ctxt context.Context, ccid ccintf.CCID, timeout uint, d
 		dockerLogger.Debugf("stop - cannot create client %s", err)
 		return err
 	}
-	id = strings.Replace(id, ":", "_", -1)
+	id = getContainerName(ccid, id)
 
 	err = vm.stopInternal(ctxt, client, id, timeout, dontkill, dontremove)
 
@@ -264,14 +361,29 @@ func (vm *DockerVM) Destroy(ctxt context.Context, ccid ccintf.CCID, force bool,
 	return err
 }
 
+//getContainerName returns the name of the container of the image. The image of
+//a chaincode is shared by the chains, each chain joined by the peer runs its
+//own container
+func getContainerName(ccid ccintf.CCID, imageID string) string {
+	if ccid.ChainID != "" {
+		imageID = fmt.Sprintf("%s-%s", imageID, ccid.ChainID)
+	}
+	return strings.Replace(imageID, ":", "_", -1)
+}
+
 //GetVMName generates the docker image from peer information given the hashcode. This is needed to
 //keep image name's unique in a single host, multi-peer environment (such as a development environment)
+//The version, when set, keeps the images of an upgraded chaincode apart
 func (vm *DockerVM) GetVMName(ccid ccintf.CCID) (string, error) {
+	name := ccid.ChaincodeSpec.ChaincodeID.Name
+	if ccid.ChaincodeSpec.ChaincodeID.Version != "" {
+		name = fmt.Sprintf("%s-%s", name, ccid.ChaincodeSpec.ChaincodeID.Version)
+	}
 	if ccid.NetworkID != "" {
-		return fmt.Sprintf("%s-%s-%s", ccid.NetworkID, ccid.PeerID, ccid.ChaincodeSpec.ChaincodeID.Name), nil
+		return fmt.Sprintf("%s-%s-%s", ccid.NetworkID, ccid.PeerID, name), nil
 	} else if ccid.PeerID != "" {
-		return fmt.Sprintf("%s-%s", ccid.PeerID, ccid.ChaincodeSpec.ChaincodeID.Name), nil
+		return fmt.Sprintf("%s-%s", ccid.PeerID, name), nil
 	} else {
-		return ccid.ChaincodeSpec.ChaincodeID.Name, nil
+		return name, nil
 	}
 }
@@ -25,7 +25,9 @@ import (
 	"github.com/spf13/viper"
 
 	"github.com/hyperledger/fabric/core/config"
+	"github.com/hyperledger/fabric/core/container/ccintf"
 	"github.com/hyperledger/fabric/core/ledger/testutil"
+	pb "github.com/hyperledger/fabric/protos"
 )
 
 func TestHostConfig(t *testing.T) {
@@ -54,3 +56,50 @@ func TestGetDockerHostConfig(t *testing.T) {
 	testutil.AssertEquals(t, hostConfig.Memory, int64(1024*1024*1024*2))
 	testutil.AssertEquals(t, hostConfig.CPUShares, int64(1024*1024*1024*2))
 }
+
+func TestGetHostConfigResourceLimits(t *testing.T) {
+	saved := hostConfig
+	defer func() { hostConfig = saved }()
+	hostConfig = &docker.HostConfig{NetworkMode: "host", Memory: 1024, CPUShares: 512}
+
+	spec := &pb.ChaincodeSpec{ChaincodeID: &pb.ChaincodeID{Name: "mycc"}}
+	testutil.AssertSame(t, getHostConfig(spec), hostConfig)
+
+	spec.ResourceLimits = &pb.ChaincodeResourceLimits{CpuShares: 256, Memory: 4096, PidsLimit: 100}
+	hc := getHostConfig(spec)
+	testutil.AssertEquals(t, hc.NetworkMode, "host")
+	testutil.AssertEquals(t, hc.CPUShares, int64(256))
+	testutil.AssertEquals(t, hc.Memory, int64(1024))
+	testutil.AssertEquals(t, hc.PidsLimit, int64(100))
+
+	// the peer config is shared by all containers and must be left untouched
+	testutil.AssertEquals(t, hostConfig.CPUShares, int64(512))
+	testutil.AssertEquals(t, hostConfig.PidsLimit, int64(0))
+}
+
+func TestGetDebugHostConfig(t *testing.T) {
+	defer viper.Set("chaincode.debug.enabled", false)
+	viper.Set("chaincode.debug.port", 2345)
+	bridge := &docker.HostConfig{NetworkMode: "bridge"}
+
+	viper.Set("chaincode.debug.enabled", false)
+	testutil.AssertSame(t, getDebugHostConfig(bridge), bridge)
+
+	viper.Set("chaincode.debug.enabled", true)
+	host := &docker.HostConfig{NetworkMode: "host"}
+	testutil.AssertSame(t, getDebugHostConfig(host), host)
+
+	hc := getDebugHostConfig(bridge)
+	testutil.AssertEquals(t, len(hc.PortBindings[docker.Port("2345/tcp")]), 1)
+	testutil.AssertEquals(t, len(bridge.PortBindings), 0)
+}
+
+func TestGetContainerName(t *testing.T) {
+	spec := &pb.ChaincodeSpec{ChaincodeID: &pb.ChaincodeID{Name: "mycc", Version: "1.0"}}
+	vm := &DockerVM{}
+	imageID, _ := vm.GetVMName(ccintf.CCID{ChaincodeSpec: spec, PeerID: "peer0"})
+	chainImageID, _ := vm.GetVMName(ccintf.CCID{ChaincodeSpec: spec, PeerID: "peer0", ChainID: "mychannel"})
+	testutil.AssertEquals(t, chainImageID, imageID)
+	testutil.AssertEquals(t, getContainerName(ccintf.CCID{ChaincodeSpec: spec, PeerID: "peer0"}, imageID), "peer0-mycc-1.0")
+	testutil.AssertEquals(t, getContainerName(ccintf.CCID{ChaincodeSpec: spec, PeerID: "peer0", ChainID: "mychannel"}, imageID), "peer0-mycc-1.0-mychannel")
+}
@@ -0,0 +1,170 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalbuilder
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Builder is an external builder configured under chaincode.externalBuilders.
+// The path holds the bin/detect, bin/build and bin/run executables that the
+// peer invokes instead of building a docker image and running a container.
+type Builder struct {
+	Name                 string
+	Path                 string
+	EnvironmentWhitelist []string
+}
+
+// environment variables passed to the builders in addition to the whitelist
+var defaultEnvironmentWhitelist = []string{"LD_LIBRARY_PATH", "LIBPATH", "PATH", "TMPDIR"}
+
+// GetBuilders returns the external builders in the configured order
+func GetBuilders() ([]*Builder, error) {
+	var builders []*Builder
+	if err := viper.UnmarshalKey("chaincode.externalBuilders", &builders); err != nil {
+		return nil, fmt.Errorf("error reading chaincode.externalBuilders: %s", err)
+	}
+	for _, b := range builders {
+		if b.Path == "" {
+			return nil, fmt.Errorf("external builder %q has no path", b.Name)
+		}
+		if b.Name == "" {
+			b.Name = filepath.Base(b.Path)
+		}
+	}
+	return builders, nil
+}
+
+// environment returns the environment the builder's executables are run with,
+// the whitelisted variables of the peer followed by extra
+func (b *Builder) environment(extra []string) []string {
+	var env []string
+	for _, name := range append(defaultEnvironmentWhitelist, b.EnvironmentWhitelist...) {
+		if value, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+value)
+		}
+	}
+	return append(env, extra...)
+}
+
+func (b *Builder) command(name string, env []string, args ...string) *exec.Cmd {
+	cmd := exec.Command(filepath.Join(b.Path, "bin", name), args...)
+	cmd.Env = b.environment(env)
+	return cmd
+}
+
+// run runs a builder executable to completion, its output is logged
+func (b *Builder) run(name string, args ...string) error {
+	cmd := b.command(name, nil, args...)
+	output, err := cmd.CombinedOutput()
+	if len(output) > 0 {
+		externalBuilderLogger.Debugf("[%s] %s output:\n%s", b.Name, name, output)
+	}
+	return err
+}
+
+// Detect reports whether the builder builds the chaincode in the source dir
+func (b *Builder) Detect(sourceDir, metadataDir string) bool {
+	if err := b.run("detect", sourceDir, metadataDir); err != nil {
+		externalBuilderLogger.Debugf("[%s] detect did not match: %s", b.Name, err)
+		return false
+	}
+	return true
+}
+
+// Build builds the chaincode in the source dir into the output dir
+func (b *Builder) Build(sourceDir, metadataDir, outputDir string) error {
+	if err := b.run("build", sourceDir, metadataDir, outputDir); err != nil {
+		return fmt.Errorf("external builder %s failed to build chaincode: %s", b.Name, err)
+	}
+	return nil
+}
+
+// Run starts the chaincode built into the output dir. The run metadata dir
+// holds chaincode.json with what the chaincode needs to connect to the peer.
+// The chaincode env is passed to the run executable as well.
+func (b *Builder) Run(outputDir, runMetadataDir string, env []string) (*exec.Cmd, error) {
+	cmd := b.command("run", env, outputDir, runMetadataDir)
+	cmd.Stdout = &logWriter{prefix: b.Name}
+	cmd.Stderr = cmd.Stdout
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("external builder %s failed to run chaincode: %s", b.Name, err)
+	}
+	return cmd, nil
+}
+
+// logWriter logs the output of a running chaincode
+type logWriter struct {
+	prefix string
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	externalBuilderLogger.Infof("[%s] %s", w.prefix, strings.TrimRight(string(p), "\n"))
+	return len(p), nil
+}
+
+// extractPackage extracts the regular files of the gzipped tar code package
+// into dir
+func extractPackage(codePackage []byte, dir string) error {
+	gr, err := gzip.NewReader(bytes.NewReader(codePackage))
+	if err != nil {
+		return fmt.Errorf("error reading code package: %s", err)
+	}
+	defer gr.Close()
+
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error reading code package: %s", err)
+		}
+		if header.Typeflag != tar.TypeReg && header.Typeflag != tar.TypeRegA {
+			continue
+		}
+
+		name := filepath.Clean(header.Name)
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return fmt.Errorf("illegal file %s in code package", header.Name)
+		}
+		path := filepath.Join(dir, name)
+		if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.FileMode(header.Mode)&0755|0600)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(f, tr)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("error extracting %s from code package: %s", header.Name, err)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalbuilder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/hyperledger/fabric/core/container/ccintf"
+	"github.com/op/go-logging"
+	"github.com/spf13/viper"
+	"golang.org/x/net/context"
+)
+
+var externalBuilderLogger = logging.MustGetLogger("externalbuilder")
+
+// fallbackVM is the vm used for the chaincodes no external builder detects
+type fallbackVM interface {
+	Deploy(ctxt context.Context, ccid ccintf.CCID, args []string, env []string, attachstdin bool, attachstdout bool, reader io.Reader) error
+	Start(ctxt context.Context, ccid ccintf.CCID, args []string, env []string, attachstdin bool, attachstdout bool, reader io.Reader) error
+	Stop(ctxt context.Context, ccid ccintf.CCID, timeout uint, dontkill bool, dontremove bool) error
+	Destroy(ctxt context.Context, ccid ccintf.CCID, force bool, noprune bool) error
+	GetVMName(ccID ccintf.CCID) (string, error)
+}
+
+// the chaincodes launched by the run executable of a builder
+var running = struct {
+	sync.Mutex
+	m map[string]*exec.Cmd
+}{m: make(map[string]*exec.Cmd)}
+
+// Enabled returns whether any external builders are configured
+func Enabled() bool {
+	builders, err := GetBuilders()
+	if err != nil {
+		externalBuilderLogger.Errorf("%s", err)
+		return false
+	}
+	return len(builders) > 0
+}
+
+// ExternalBuilderVM builds and runs chaincode with the first configured external
+// builder whose detect accepts the code package. The chaincodes no builder
+// detects are built and run by the fallback vm, i.e. in docker
+type ExternalBuilderVM struct {
+	fallback fallbackVM
+}
+
+// NewExternalBuilderVM returns an ExternalBuilderVM falling back to the given vm
+func NewExternalBuilderVM(fallback fallbackVM) *ExternalBuilderVM {
+	return &ExternalBuilderVM{fallback: fallback}
+}
+
+// buildDir returns where the chaincode is extracted and built, the builder
+// that built it is recorded in the builder file
+func buildDir(name string) string {
+	return filepath.Join(viper.GetString("peer.fileSystemPath"), "externalbuilds", name)
+}
+
+// metadata of the chaincode passed to detect and build
+type buildMetadata struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	Type string `json:"type"`
+}
+
+// runMetadata is written to chaincode.json in the run metadata dir
+type runMetadata struct {
+	ChaincodeID string   `json:"chaincode_id"`
+	PeerAddress string   `json:"peer_address"`
+	Args        []string `json:"args"`
+	Env         []string `json:"env"`
+}
+
+func writeJSON(path string, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, 0644)
+}
+
+// build extracts the code package and builds it with the first builder that
+// detects it. nil is returned if no builder detects the chaincode
+func (vm *ExternalBuilderVM) build(ccid ccintf.CCID, name string, reader io.Reader) (*Builder, error) {
+	builders, err := GetBuilders()
+	if err != nil {
+		return nil, err
+	}
+
+	codePackage, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("error reading code package: %s", err)
+	}
+
+	dir := buildDir(name)
+	if err = os.RemoveAll(dir); err != nil {
+		return nil, err
+	}
+	sourceDir := filepath.Join(dir, "src")
+	metadataDir := filepath.Join(dir, "metadata")
+	outputDir := filepath.Join(dir, "bld")
+	for _, d := range []string{sourceDir, metadataDir, outputDir} {
+		if err = os.MkdirAll(d, 0755); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = extractPackage(codePackage, sourceDir); err != nil {
+		return nil, err
+	}
+	spec := ccid.ChaincodeSpec
+	md := &buildMetadata{Name: spec.ChaincodeID.Name, Path: spec.ChaincodeID.Path, Type: spec.Type.String()}
+	if err = writeJSON(filepath.Join(metadataDir, "metadata.json"), md); err != nil {
+		return nil, err
+	}
+
+	for _, b := range builders {
+		if !b.Detect(sourceDir, metadataDir) {
+			continue
+		}
+		externalBuilderLogger.Debugf("building %s with external builder %s", name, b.Name)
+		if err = b.Build(sourceDir, metadataDir, outputDir); err != nil {
+			return nil, err
+		}
+		if err = ioutil.WriteFile(filepath.Join(dir, "builder"), []byte(b.Name), 0644); err != nil {
+			return nil, err
+		}
+		return b, nil
+	}
+
+	externalBuilderLogger.Debugf("no external builder detected %s", name)
+	os.RemoveAll(dir)
+	return nil, nil
+}
+
+// builtBy returns the builder that built the chaincode, nil if it was not
+// built by an external builder
+func builtBy(name string) (*Builder, error) {
+	builderName, err := ioutil.ReadFile(filepath.Join(buildDir(name), "builder"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	builders, err := GetBuilders()
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range builders {
+		if b.Name == string(builderName) {
+			return b, nil
+		}
+	}
+	return nil, fmt.Errorf("external builder %s of chaincode %s is no longer configured", builderName, name)
+}
+
+// Deploy builds the chaincode with an external builder, or in the fallback vm
+// if no builder detects it
+func (vm *ExternalBuilderVM) Deploy(ctxt context.Context, ccid ccintf.CCID, args []string, env []string, attachstdin bool, attachstdout bool, reader io.Reader) error {
+	name, _ := vm.GetVMName(ccid)
+	codePackage, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("error reading code package: %s", err)
+	}
+
+	b, err := vm.build(ccid, name, bytes.NewReader(codePackage))
+	if err != nil || b != nil {
+		return err
+	}
+	return vm.fallback.Deploy(ctxt, ccid, args, env, attachstdin, attachstdout, bytes.NewReader(codePackage))
+}
+
+// Start runs the chaincode with the run executable of the builder that built it.
+// The chaincode is built first if the build is missing, e.g. on a new peer
+func (vm *ExternalBuilderVM) Start(ctxt context.Context, ccid ccintf.CCID, args []string, env []string, attachstdin bool, attachstdout bool, reader io.Reader) error {
+	name, _ := vm.GetVMName(ccid)
+
+	b, err := builtBy(name)
+	if err != nil {
+		return err
+	}
+
+	var codePackage []byte
+	if b == nil && reader != nil {
+		if codePackage, err = ioutil.ReadAll(reader); err != nil {
+			return fmt.Errorf("error reading code package: %s", err)
+		}
+		if b, err = vm.build(ccid, name, bytes.NewReader(codePackage)); err != nil {
+			return err
+		}
+		reader = bytes.NewReader(codePackage)
+	}
+	if b == nil {
+		return vm.fallback.Start(ctxt, ccid, args, env, attachstdin, attachstdout, reader)
+	}
+
+	//stop the chaincode if it is still running
+	vm.stopInternal(name)
+
+	runMetadataDir := filepath.Join(buildDir(name), "run")
+	if err = os.MkdirAll(runMetadataDir, 0755); err != nil {
+		return err
+	}
+	md := &runMetadata{ChaincodeID: ccid.ChaincodeSpec.ChaincodeID.Name, Args: args, Env: env}
+	for _, e := range env {
+		if strings.HasPrefix(e, "CORE_PEER_ADDRESS=") {
+			md.PeerAddress = strings.TrimPrefix(e, "CORE_PEER_ADDRESS=")
+		}
+	}
+	if err = writeJSON(filepath.Join(runMetadataDir, "chaincode.json"), md); err != nil {
+		return err
+	}
+
+	cmd, err := b.Run(filepath.Join(buildDir(name), "bld"), runMetadataDir, env)
+	if err != nil {
+		return err
+	}
+
+	running.Lock()
+	running.m[name] = cmd
+	running.Unlock()
+
+	go func() {
+		err := cmd.Wait()
+		externalBuilderLogger.Debugf("chaincode %s run by external builder %s exited: %v", name, b.Name, err)
+		running.Lock()
+		if running.m[name] == cmd {
+			delete(running.m, name)
+		}
+		running.Unlock()
+	}()
+
+	externalBuilderLogger.Debugf("started chaincode %s with external builder %s", name, b.Name)
+	return nil
+}
+
+// stopInternal kills the chaincode process if it is running
+func (vm *ExternalBuilderVM) stopInternal(name string) bool {
+	running.Lock()
+	cmd, ok := running.m[name]
+	delete(running.m, name)
+	running.Unlock()
+
+	if !ok {
+		return false
+	}
+	if err := cmd.Process.Kill(); err != nil {
+		externalBuilderLogger.Debugf("Kill chaincode %s (%s)", name, err)
+	}
+	return true
+}
+
+// Stop stops a running chaincode
+func (vm *ExternalBuilderVM) Stop(ctxt context.Context, ccid ccintf.CCID, timeout uint, dontkill bool, dontremove bool) error {
+	name, _ := vm.GetVMName(ccid)
+	if vm.stopInternal(name) {
+		return nil
+	}
+	if b, err := builtBy(name); err != nil || b != nil {
+		return err
+	}
+	return vm.fallback.Stop(ctxt, ccid, timeout, dontkill, dontremove)
+}
+
+// Destroy removes the build of the chaincode
+func (vm *ExternalBuilderVM) Destroy(ctxt context.Context, ccid ccintf.CCID, force bool, noprune bool) error {
+	name, _ := vm.GetVMName(ccid)
+	if b, err := builtBy(name); err != nil || b == nil {
+		return vm.fallback.Destroy(ctxt, ccid, force, noprune)
+	}
+	return os.RemoveAll(buildDir(name))
+}
+
+// GetVMName returns the name of the fallback vm, so that a chaincode has the
+// same name whichever way it is built
+func (vm *ExternalBuilderVM) GetVMName(ccid ccintf.CCID) (string, error) {
+	return vm.fallback.GetVMName(ccid)
+}
@@ -0,0 +1,206 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalbuilder
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/core/container/ccintf"
+	pb "github.com/hyperledger/fabric/protos"
+	"github.com/spf13/viper"
+	"golang.org/x/net/context"
+)
+
+// recordingVM records the calls that fall back to it
+type recordingVM struct {
+	calls []string
+}
+
+func (vm *recordingVM) Deploy(ctxt context.Context, ccid ccintf.CCID, args []string, env []string, attachstdin bool, attachstdout bool, reader io.Reader) error {
+	vm.calls = append(vm.calls, "deploy")
+	return nil
+}
+
+func (vm *recordingVM) Start(ctxt context.Context, ccid ccintf.CCID, args []string, env []string, attachstdin bool, attachstdout bool, reader io.Reader) error {
+	vm.calls = append(vm.calls, "start")
+	return nil
+}
+
+func (vm *recordingVM) Stop(ctxt context.Context, ccid ccintf.CCID, timeout uint, dontkill bool, dontremove bool) error {
+	vm.calls = append(vm.calls, "stop")
+	return nil
+}
+
+func (vm *recordingVM) Destroy(ctxt context.Context, ccid ccintf.CCID, force bool, noprune bool) error {
+	vm.calls = append(vm.calls, "destroy")
+	return nil
+}
+
+func (vm *recordingVM) GetVMName(ccid ccintf.CCID) (string, error) {
+	return ccid.ChaincodeSpec.ChaincodeID.Name, nil
+}
+
+var builderScripts = map[string]string{
+	"detect": "#!/bin/sh\ntest -f \"$1/src/marker\"\n",
+	"build":  "#!/bin/sh\ncp \"$1/src/marker\" \"$3/built\"\n",
+	"run":    "#!/bin/sh\ncp \"$2/chaincode.json\" \"$1/ran\"\nexec sleep 30\n",
+}
+
+func setupBuilder(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "externalbuilder")
+	if err != nil {
+		t.Fatalf("Error creating temp dir: %s", err)
+	}
+	builderPath := filepath.Join(dir, "builder")
+	if err = os.MkdirAll(filepath.Join(builderPath, "bin"), 0755); err != nil {
+		t.Fatalf("Error creating builder: %s", err)
+	}
+	for name, script := range builderScripts {
+		if err = ioutil.WriteFile(filepath.Join(builderPath, "bin", name), []byte(script), 0755); err != nil {
+			t.Fatalf("Error writing %s: %s", name, err)
+		}
+	}
+
+	viper.Set("peer.fileSystemPath", filepath.Join(dir, "peer"))
+	viper.Set("chaincode.externalBuilders", []map[string]interface{}{{"name": "test-builder", "path": builderPath}})
+	return dir
+}
+
+func codePackage(t *testing.T, files map[string]string) []byte {
+	buf := &bytes.Buffer{}
+	gw := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gw)
+	for name, contents := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Size: int64(len(contents)), Mode: 0644}); err != nil {
+			t.Fatalf("Error writing package: %s", err)
+		}
+		tw.Write([]byte(contents))
+	}
+	tw.Close()
+	gw.Close()
+	return buf.Bytes()
+}
+
+func TestGetBuilders(t *testing.T) {
+	dir := setupBuilder(t)
+	defer os.RemoveAll(dir)
+	defer viper.Set("chaincode.externalBuilders", nil)
+
+	builders, err := GetBuilders()
+	if err != nil {
+		t.Fatalf("Error getting builders: %s", err)
+	}
+	if len(builders) != 1 || builders[0].Name != "test-builder" || builders[0].Path != filepath.Join(dir, "builder") {
+		t.Fatalf("Unexpected builders %v", builders)
+	}
+	if !Enabled() {
+		t.Fatalf("Expected the external builders to be enabled")
+	}
+
+	viper.Set("chaincode.externalBuilders", nil)
+	if Enabled() {
+		t.Fatalf("Expected the external builders to be disabled")
+	}
+}
+
+func TestBuildAndRun(t *testing.T) {
+	dir := setupBuilder(t)
+	defer os.RemoveAll(dir)
+	defer viper.Set("chaincode.externalBuilders", nil)
+
+	fallback := &recordingVM{}
+	vm := NewExternalBuilderVM(fallback)
+	ccid := ccintf.CCID{ChaincodeSpec: &pb.ChaincodeSpec{Type: pb.ChaincodeSpec_GOLANG, ChaincodeID: &pb.ChaincodeID{Name: "extcc", Path: "example/extcc"}}}
+	ctxt := context.Background()
+
+	pkg := codePackage(t, map[string]string{"src/marker": "extcc", "Dockerfile": "FROM scratch"})
+	if err := vm.Deploy(ctxt, ccid, nil, nil, false, false, bytes.NewReader(pkg)); err != nil {
+		t.Fatalf("Error deploying: %s", err)
+	}
+	if built, err := ioutil.ReadFile(filepath.Join(buildDir("extcc"), "bld", "built")); err != nil || string(built) != "extcc" {
+		t.Fatalf("Expected the chaincode to be built by the external builder, got %q, %v", built, err)
+	}
+
+	env := []string{"CORE_CHAINCODE_ID_NAME=extcc", "CORE_PEER_ADDRESS=peer0:7051"}
+	if err := vm.Start(ctxt, ccid, []string{"extcc"}, env, false, false, nil); err != nil {
+		t.Fatalf("Error starting: %s", err)
+	}
+
+	ran := filepath.Join(buildDir("extcc"), "bld", "ran")
+	var md runMetadata
+	for i := 0; ; i++ {
+		b, err := ioutil.ReadFile(ran)
+		if err == nil && json.Unmarshal(b, &md) == nil {
+			break
+		}
+		if i == 50 {
+			t.Fatalf("Timed out waiting for the chaincode to run")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if md.ChaincodeID != "extcc" || md.PeerAddress != "peer0:7051" {
+		t.Fatalf("Unexpected run metadata %+v", md)
+	}
+
+	if err := vm.Stop(ctxt, ccid, 0, false, false); err != nil {
+		t.Fatalf("Error stopping: %s", err)
+	}
+	if err := vm.Destroy(ctxt, ccid, false, false); err != nil {
+		t.Fatalf("Error destroying: %s", err)
+	}
+	if _, err := os.Stat(buildDir("extcc")); !os.IsNotExist(err) {
+		t.Fatalf("Expected the build to be removed, got %v", err)
+	}
+	if len(fallback.calls) != 0 {
+		t.Fatalf("Expected no calls to fall back, got %v", fallback.calls)
+	}
+}
+
+func TestFallback(t *testing.T) {
+	dir := setupBuilder(t)
+	defer os.RemoveAll(dir)
+	defer viper.Set("chaincode.externalBuilders", nil)
+
+	fallback := &recordingVM{}
+	vm := NewExternalBuilderVM(fallback)
+	ccid := ccintf.CCID{ChaincodeSpec: &pb.ChaincodeSpec{Type: pb.ChaincodeSpec_GOLANG, ChaincodeID: &pb.ChaincodeID{Name: "dockercc"}}}
+	ctxt := context.Background()
+
+	pkg := codePackage(t, map[string]string{"src/main.go": "package main", "Dockerfile": "FROM scratch"})
+	if err := vm.Deploy(ctxt, ccid, nil, nil, false, false, bytes.NewReader(pkg)); err != nil {
+		t.Fatalf("Error deploying: %s", err)
+	}
+	if err := vm.Start(ctxt, ccid, nil, nil, false, false, bytes.NewReader(pkg)); err != nil {
+		t.Fatalf("Error starting: %s", err)
+	}
+	if err := vm.Stop(ctxt, ccid, 0, false, false); err != nil {
+		t.Fatalf("Error stopping: %s", err)
+	}
+
+	if len(fallback.calls) != 3 || fallback.calls[0] != "deploy" || fallback.calls[1] != "start" || fallback.calls[2] != "stop" {
+		t.Fatalf("Expected deploy, start and stop to fall back, got %v", fallback.calls)
+	}
+}
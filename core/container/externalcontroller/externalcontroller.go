@@ -0,0 +1,169 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalcontroller
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/hyperledger/fabric/core/comm"
+	"github.com/hyperledger/fabric/core/container/ccintf"
+	pb "github.com/hyperledger/fabric/protos"
+	"github.com/op/go-logging"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+var externalLogger = logging.MustGetLogger("externalcontroller")
+
+// externalConnection is the connection of the peer to a chaincode server
+type externalConnection struct {
+	conn   *grpc.ClientConn
+	cancel context.CancelFunc
+}
+
+var connections = struct {
+	sync.Mutex
+	m map[string]*externalConnection
+}{m: make(map[string]*externalConnection)}
+
+// ExternalVM is a vm for chaincodes that are run as an external service, e.g.
+// in their own pod in Kubernetes. Nothing is built or launched, the peer
+// connects to the chaincode server and the chaincode registers on the stream
+type ExternalVM struct {
+}
+
+// Deploy does nothing, the chaincode server is built and run by its operator
+func (vm *ExternalVM) Deploy(ctxt context.Context, ccid ccintf.CCID, args []string, env []string, attachstdin bool, attachstdout bool, reader io.Reader) error {
+	return nil
+}
+
+func getCredentials(server *pb.ChaincodeServerInfo) (credentials.TransportCredentials, error) {
+	if !server.TlsEnabled {
+		return nil, nil
+	}
+	protocol, err := comm.GetTLSProtocolConfig()
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{ServerName: server.ServerHostOverride}
+	//verify the server with the system roots if no root certificate is given
+	if len(server.RootCert) > 0 {
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(server.RootCert) {
+			return nil, fmt.Errorf("invalid root certificate for chaincode server %s", server.Address)
+		}
+	}
+	protocol.Apply(config)
+	return credentials.NewTLS(config), nil
+}
+
+// Start connects to the chaincode server and hands the stream to the chaincode
+// support of the peer, just like the stream of a chaincode that registered
+func (vm *ExternalVM) Start(ctxt context.Context, ccid ccintf.CCID, args []string, env []string, attachstdin bool, attachstdout bool, reader io.Reader) error {
+	name := ccid.ChaincodeSpec.ChaincodeID.Name
+
+	server, ok := ctxt.Value(ccintf.GetCCServerKey()).(*pb.ChaincodeServerInfo)
+	if !ok || server == nil || server.Address == "" {
+		return fmt.Errorf("chaincode server address not supplied for %s", name)
+	}
+
+	ccSupport, ok := ctxt.Value(ccintf.GetCCHandlerKey()).(ccintf.CCSupport)
+	if !ok || ccSupport == nil {
+		return fmt.Errorf("chaincode support not supplied")
+	}
+
+	key, _ := vm.GetVMName(ccid)
+	connections.Lock()
+	defer connections.Unlock()
+	if _, ok = connections.m[key]; ok {
+		return fmt.Errorf("chaincode %s is already connected", key)
+	}
+
+	creds, err := getCredentials(server)
+	if err != nil {
+		return err
+	}
+
+	conn, err := comm.NewClientConnectionWithAddress(server.Address, true, server.TlsEnabled, creds)
+	if err != nil {
+		return fmt.Errorf("error connecting to chaincode server %s for %s: %s", server.Address, name, err)
+	}
+
+	streamCtxt, cancel := context.WithCancel(context.Background())
+	stream, err := pb.NewChaincodeClient(conn).Connect(streamCtxt)
+	if err != nil {
+		cancel()
+		conn.Close()
+		return fmt.Errorf("error opening stream to chaincode server %s for %s: %s", server.Address, name, err)
+	}
+
+	ec := &externalConnection{conn: conn, cancel: cancel}
+	connections.m[key] = ec
+	externalLogger.Debugf("connected to chaincode server %s for %s", server.Address, name)
+
+	go func() {
+		err := ccSupport.HandleChaincodeStream(streamCtxt, stream)
+		if err != nil {
+			externalLogger.Errorf("chaincode server %s for %s ended with err: %s", server.Address, name, err)
+		}
+
+		connections.Lock()
+		if connections.m[key] == ec {
+			delete(connections.m, key)
+		}
+		connections.Unlock()
+		cancel()
+		conn.Close()
+	}()
+
+	return nil
+}
+
+// Stop closes the connection to the chaincode server, the server keeps running
+func (vm *ExternalVM) Stop(ctxt context.Context, ccid ccintf.CCID, timeout uint, dontkill bool, dontremove bool) error {
+	name, _ := vm.GetVMName(ccid)
+
+	connections.Lock()
+	ec, ok := connections.m[name]
+	delete(connections.m, name)
+	connections.Unlock()
+
+	if !ok {
+		return fmt.Errorf("%s not connected", name)
+	}
+	ec.cancel()
+	return ec.conn.Close()
+}
+
+// Destroy does nothing, there is no image for the chaincode
+func (vm *ExternalVM) Destroy(ctxt context.Context, ccid ccintf.CCID, force bool, noprune bool) error {
+	return nil
+}
+
+// GetVMName returns the chaincode name, there is a single connection per
+// chaincode and chain
+func (vm *ExternalVM) GetVMName(ccid ccintf.CCID) (string, error) {
+	if ccid.ChainID != "" {
+		return fmt.Sprintf("%s-%s", ccid.ChaincodeSpec.ChaincodeID.Name, ccid.ChainID), nil
+	}
+	return ccid.ChaincodeSpec.ChaincodeID.Name, nil
+}
@@ -0,0 +1,120 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalcontroller
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/core/container/ccintf"
+	pb "github.com/hyperledger/fabric/protos"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+// registeringServer mimics a chaincode server, it registers on every stream the
+// peer opens and waits for the peer to close it
+type registeringServer struct{}
+
+func (s *registeringServer) Connect(stream pb.Chaincode_ConnectServer) error {
+	if err := stream.Send(&pb.ChaincodeMessage{Type: pb.ChaincodeMessage_REGISTER}); err != nil {
+		return err
+	}
+	<-stream.Context().Done()
+	return nil
+}
+
+// recordingSupport records the first message received on the stream
+type recordingSupport struct {
+	received chan *pb.ChaincodeMessage
+}
+
+func (s *recordingSupport) HandleChaincodeStream(ctxt context.Context, stream ccintf.ChaincodeStream) error {
+	msg, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	s.received <- msg
+	<-ctxt.Done()
+	return nil
+}
+
+func TestStartAndStop(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error listening: %s", err)
+	}
+	grpcServer := grpc.NewServer()
+	pb.RegisterChaincodeServer(grpcServer, &registeringServer{})
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+
+	ccid := ccintf.CCID{ChaincodeSpec: &pb.ChaincodeSpec{ChaincodeID: &pb.ChaincodeID{Name: "extcc"}}}
+	support := &recordingSupport{received: make(chan *pb.ChaincodeMessage, 1)}
+	ctxt := context.WithValue(context.Background(), ccintf.GetCCHandlerKey(), support)
+	ctxt = context.WithValue(ctxt, ccintf.GetCCServerKey(), &pb.ChaincodeServerInfo{Address: lis.Addr().String()})
+
+	vm := &ExternalVM{}
+	if err = vm.Start(ctxt, ccid, nil, nil, false, false, nil); err != nil {
+		t.Fatalf("Error starting: %s", err)
+	}
+
+	select {
+	case msg := <-support.received:
+		if msg.Type != pb.ChaincodeMessage_REGISTER {
+			t.Fatalf("Expected REGISTER from the chaincode server, got %s", msg.Type)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for the chaincode server to register")
+	}
+
+	if err = vm.Start(ctxt, ccid, nil, nil, false, false, nil); err == nil {
+		t.Fatalf("Expected an error connecting a chaincode that is already connected")
+	}
+
+	if err = vm.Stop(ctxt, ccid, 0, false, false); err != nil {
+		t.Fatalf("Error stopping: %s", err)
+	}
+	if err = vm.Stop(ctxt, ccid, 0, false, false); err == nil {
+		t.Fatalf("Expected an error stopping a chaincode that is not connected")
+	}
+}
+
+func TestStartWithoutServer(t *testing.T) {
+	ccid := ccintf.CCID{ChaincodeSpec: &pb.ChaincodeSpec{ChaincodeID: &pb.ChaincodeID{Name: "extcc"}}}
+	ctxt := context.WithValue(context.Background(), ccintf.GetCCHandlerKey(), &recordingSupport{})
+	if err := (&ExternalVM{}).Start(ctxt, ccid, nil, nil, false, false, nil); err == nil {
+		t.Fatalf("Expected an error starting without a chaincode server address")
+	}
+}
+
+func TestGetCredentials(t *testing.T) {
+	creds, err := getCredentials(&pb.ChaincodeServerInfo{Address: "cc:7052"})
+	if err != nil || creds != nil {
+		t.Fatalf("Expected no credentials without TLS, got %v, %v", creds, err)
+	}
+
+	if _, err = getCredentials(&pb.ChaincodeServerInfo{Address: "cc:7052", TlsEnabled: true, RootCert: []byte("not a cert")}); err == nil {
+		t.Fatalf("Expected an error for an invalid root certificate")
+	}
+
+	creds, err = getCredentials(&pb.ChaincodeServerInfo{Address: "cc:7052", TlsEnabled: true})
+	if err != nil || creds == nil {
+		t.Fatalf("Expected TLS credentials with the system roots, got %v, %v", creds, err)
+	}
+}
@@ -18,6 +18,7 @@ package util
 
 import (
 	"runtime"
+	"sort"
 	"strings"
 
 	"github.com/fsouza/go-dockerclient"
@@ -63,6 +64,39 @@ func parseDockerfileTemplate(template string) string {
 	return r.Replace(template)
 }
 
+//GetDockerfileFromConfig returns the Dockerfile at path. $(BASE_IMAGE) in it is
+//replaced by the baseImage configured next to the Dockerfile
 func GetDockerfileFromConfig(path string) string {
-	return parseDockerfileTemplate(viper.GetString(path))
+	baseImage := viper.GetString(strings.TrimSuffix(path, "Dockerfile") + "baseImage")
+	return parseDockerfileTemplate(strings.Replace(viper.GetString(path), "$(BASE_IMAGE)", baseImage, -1))
+}
+
+//GetBuildArgs returns the build args of chaincode images from vm.docker.build.
+//The proxy settings are passed as the HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+//args docker predefines, the RUN instructions see them without them being
+//written to the image
+func GetBuildArgs() []docker.BuildArg {
+	args := viper.GetStringMapString("vm.docker.build.args")
+	proxies := map[string]string{
+		"HTTP_PROXY":  viper.GetString("vm.docker.build.httpProxy"),
+		"HTTPS_PROXY": viper.GetString("vm.docker.build.httpsProxy"),
+		"NO_PROXY":    viper.GetString("vm.docker.build.noProxy"),
+	}
+	for name, value := range proxies {
+		if value != "" {
+			args[name] = value
+		}
+	}
+
+	var names []string
+	for name := range args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buildArgs []docker.BuildArg
+	for _, name := range names {
+		buildArgs = append(buildArgs, docker.BuildArg{Name: name, Value: args[name]})
+	}
+	return buildArgs
 }
@@ -17,9 +17,12 @@ limitations under the License.
 package util
 
 import (
+	"reflect"
 	"testing"
 
+	"github.com/fsouza/go-dockerclient"
 	"github.com/hyperledger/fabric/metadata"
+	"github.com/spf13/viper"
 )
 
 func TestUtil_DockerfileTemplateParser(t *testing.T) {
@@ -29,3 +32,29 @@ func TestUtil_DockerfileTemplateParser(t *testing.T) {
 		t.Errorf("Error parsing Dockerfile Template.  Expected \"%s\", got \"%s\"", expected, actual)
 	}
 }
+
+func TestUtil_GetDockerfileFromConfig(t *testing.T) {
+	viper.Set("chaincode.test.baseImage", "registry.local/ccenv:$(ARCH)")
+	viper.Set("chaincode.test.Dockerfile", "FROM $(BASE_IMAGE)")
+	defer viper.Reset()
+	expected := "FROM registry.local/ccenv:" + getArch()
+	if actual := GetDockerfileFromConfig("chaincode.test.Dockerfile"); actual != expected {
+		t.Errorf("Error getting Dockerfile.  Expected \"%s\", got \"%s\"", expected, actual)
+	}
+}
+
+func TestUtil_GetBuildArgs(t *testing.T) {
+	viper.Set("vm.docker.build.httpProxy", "http://proxy:3128")
+	viper.Set("vm.docker.build.noProxy", "localhost")
+	viper.Set("vm.docker.build.args", map[string]string{"GOPROXY": "off"})
+	defer viper.Reset()
+
+	expected := []docker.BuildArg{
+		{Name: "GOPROXY", Value: "off"},
+		{Name: "HTTP_PROXY", Value: "http://proxy:3128"},
+		{Name: "NO_PROXY", Value: "localhost"},
+	}
+	if actual := GetBuildArgs(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Error getting build args.  Expected %v, got %v", expected, actual)
+	}
+}
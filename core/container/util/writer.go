@@ -45,6 +45,11 @@ var javaFileTypes = map[string]bool{
 	".gradle":     true,
 }
 
+var nodeFileTypes = map[string]bool{
+	".js":   true,
+	".json": true,
+}
+
 func WriteFolderToTarPackage(tw *tar.Writer, srcPath string, excludeDir string, includeFileTypeMap map[string]bool) error {
 	rootDirectory := srcPath
 	vmLogger.Infof("rootDirectory = %s", rootDirectory)
@@ -145,6 +150,24 @@ func WriteJavaProjectToPackage(tw *tar.Writer, srcPath string) error {
 
 }
 
+//WriteNodeProjectToPackage writes the sources of a Node.js project to the
+//tarball. Installed dependencies under node_modules are left out, they are
+//installed again with npm when the image is built
+func WriteNodeProjectToPackage(tw *tar.Writer, srcPath string) error {
+
+	if err := WriteFolderToTarPackage(tw, srcPath, "node_modules", nodeFileTypes); err != nil {
+
+		vmLogger.Errorf("Error writing folder to tar package %s", err)
+		return err
+	}
+	// Write the tar file out
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return nil
+
+}
+
 //WriteFileToPackage writes a file to the tarball
 func WriteFileToPackage(localpath string, packagepath string, tw *tar.Writer) error {
 	fd, err := os.Open(localpath)
@@ -170,12 +193,20 @@ func WriteStreamToPackage(is io.Reader, localpath string, packagepath string, tw
 	}
 
 	//Let's take the variance out of the tar, make headers identical by using zero time
+	//and leaving out the owner and the permissions of the local file, so identical
+	//sources give byte identical packages on any machine
 	oldname := header.Name
 	var zeroTime time.Time
 	header.AccessTime = zeroTime
 	header.ModTime = zeroTime
 	header.ChangeTime = zeroTime
 	header.Name = packagepath
+	header.Uid, header.Gid = 0, 0
+	header.Uname, header.Gname = "", ""
+	header.Mode = 0644
+	if info.Mode()&0111 != 0 {
+		header.Mode = 0755
+	}
 
 	if err = tw.WriteHeader(header); err != nil {
 		return fmt.Errorf("Error write header for (path: %s, oldname:%s,newname:%s,sz:%d) : %s", localpath, oldname, packagepath, header.Size, err)
@@ -0,0 +1,65 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeTestFile(t *testing.T, dir string, mode os.FileMode, mtime time.Time) string {
+	path := filepath.Join(dir, "chaincode.go")
+	if err := ioutil.WriteFile(path, []byte("package main\n"), mode); err != nil {
+		t.Fatalf("Error writing test file: %s", err)
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		t.Fatalf("Error changing the mode of the test file: %s", err)
+	}
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatalf("Error changing the times of the test file: %s", err)
+	}
+	return path
+}
+
+func packageTestFile(t *testing.T, path string) []byte {
+	buf := bytes.NewBuffer(nil)
+	tw := tar.NewWriter(buf)
+	if err := WriteFileToPackage(path, "src/chaincode.go", tw); err != nil {
+		t.Fatalf("Error writing the package: %s", err)
+	}
+	tw.Close()
+	return buf.Bytes()
+}
+
+func TestWriteFileToPackageIsReproducible(t *testing.T) {
+	dir, err := ioutil.TempDir("", "writer")
+	if err != nil {
+		t.Fatalf("Error creating temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	first := packageTestFile(t, writeTestFile(t, dir, 0600, time.Now()))
+	second := packageTestFile(t, writeTestFile(t, dir, 0664, time.Now().Add(-time.Hour)))
+	if !bytes.Equal(first, second) {
+		t.Fatalf("Packaging identical sources with different modes and times gave different packages")
+	}
+}
@@ -120,6 +120,7 @@ func (vm *VM) buildChaincodeContainerUsingDockerfilePackageBytes(spec *pb.Chainc
 		Name:         vmName,
 		InputStream:  inputbuf,
 		OutputStream: outputbuf,
+		BuildArgs:    cutil.GetBuildArgs(),
 	}
 	if err := vm.Client.BuildImage(opts); err != nil {
 		vmLogger.Errorf("Failed Chaincode docker build:\n%s\n", outputbuf.String())
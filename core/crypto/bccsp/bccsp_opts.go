@@ -97,7 +97,12 @@ func (opts *AESKeyGenOpts) Ephemeral() bool {
 
 // AESCBCPKCS7ModeOpts contains options for AES encryption in CBC mode
 // with PKCS7 padding.
-type AESCBCPKCS7ModeOpts struct{}
+type AESCBCPKCS7ModeOpts struct {
+	// IV is the initialization vector of the encryption, a random one is
+	// generated when it is nil. It is ignored by the decryption, the IV
+	// being at the beginning of the ciphertext
+	IV []byte
+}
 
 // HMACTruncated256AESDeriveKeyOpts contains options for HMAC truncated
 // at 256 bits key derivation.
@@ -180,6 +185,42 @@ func (opts *HMACImportKeyOpts) Ephemeral() bool {
 	return opts.Temporary
 }
 
+// ECDSAPKIXPublicKeyImportOpts contains options for importing ECDSA public keys
+// in PKIX format (DER encoded).
+type ECDSAPKIXPublicKeyImportOpts struct {
+	Temporary bool
+}
+
+// Algorithm returns an identifier for the algorithm to be used
+// to import the raw material of a key.
+func (opts *ECDSAPKIXPublicKeyImportOpts) Algorithm() string {
+	return ECDSA
+}
+
+// Ephemeral returns true if the key generated has to be ephemeral,
+// false otherwise.
+func (opts *ECDSAPKIXPublicKeyImportOpts) Ephemeral() bool {
+	return opts.Temporary
+}
+
+// ECDSAPrivateKeyImportOpts contains options for importing ECDSA private keys
+// (DER encoded).
+type ECDSAPrivateKeyImportOpts struct {
+	Temporary bool
+}
+
+// Algorithm returns an identifier for the algorithm to be used
+// to import the raw material of a key.
+func (opts *ECDSAPrivateKeyImportOpts) Algorithm() string {
+	return ECDSA
+}
+
+// Ephemeral returns true if the key generated has to be ephemeral,
+// false otherwise.
+func (opts *ECDSAPrivateKeyImportOpts) Ephemeral() bool {
+	return opts.Temporary
+}
+
 // SHAOpts contains options for computing SHA.
 type SHAOpts struct {
 }
@@ -18,6 +18,7 @@ package sw
 import (
 	"crypto/ecdsa"
 	"crypto/rand"
+	"crypto/x509"
 	"encoding/asn1"
 	"encoding/hex"
 	"errors"
@@ -296,6 +297,54 @@ func (csp *impl) KeyImport(raw []byte, opts bccsp.KeyImportOpts) (k bccsp.Key, e
 		}
 
 		return aesK, nil
+	case *bccsp.ECDSAPKIXPublicKeyImportOpts:
+
+		lowLevelKey, err := x509.ParsePKIXPublicKey(raw)
+		if err != nil {
+			return nil, fmt.Errorf("Failed converting PKIX to ECDSA public key [%s]", err)
+		}
+
+		ecdsaPK, ok := lowLevelKey.(*ecdsa.PublicKey)
+		if !ok {
+			return nil, errors.New("Failed casting to ECDSA public key. Invalid raw material.")
+		}
+
+		k = &ecdsaPublicKey{ecdsaPK}
+
+		// If the key is not Ephemeral, store it.
+		if !opts.Ephemeral() {
+			// Store the key
+			err = csp.ks.storePublicKey(hex.EncodeToString(k.SKI()), lowLevelKey)
+			if err != nil {
+				return nil, fmt.Errorf("Failed storing ECDSA key [%s]", err)
+			}
+		}
+
+		return k, nil
+	case *bccsp.ECDSAPrivateKeyImportOpts:
+
+		lowLevelKey, err := primitives.DERToPrivateKey(raw)
+		if err != nil {
+			return nil, fmt.Errorf("Failed converting DER to ECDSA private key [%s]", err)
+		}
+
+		ecdsaSK, ok := lowLevelKey.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, errors.New("Failed casting to ECDSA private key. Invalid raw material.")
+		}
+
+		k = &ecdsaPrivateKey{ecdsaSK}
+
+		// If the key is not Ephemeral, store it.
+		if !opts.Ephemeral() {
+			// Store the key
+			err = csp.ks.storePrivateKey(hex.EncodeToString(k.SKI()), lowLevelKey)
+			if err != nil {
+				return nil, fmt.Errorf("Failed storing ECDSA key [%s]", err)
+			}
+		}
+
+		return k, nil
 	default:
 		return nil, errors.New("Import Key Options not recognized")
 	}
@@ -407,6 +456,14 @@ func (csp *impl) Verify(k bccsp.Key, signature, digest []byte, opts bccsp.Signer
 		}
 
 		return ecdsa.Verify(&(k.(*ecdsaPrivateKey).k.PublicKey), digest, ecdsaSignature.R, ecdsaSignature.S), nil
+	case *ecdsaPublicKey:
+		ecdsaSignature := new(primitives.ECDSASignature)
+		_, err := asn1.Unmarshal(signature, ecdsaSignature)
+		if err != nil {
+			return false, fmt.Errorf("Failed unmashalling signature [%s]", err)
+		}
+
+		return ecdsa.Verify(k.(*ecdsaPublicKey).k, digest, ecdsaSignature.R, ecdsaSignature.S), nil
 	case *rsaPrivateKey:
 		if opts == nil {
 			return false, errors.New("Invalid options. Nil.")
@@ -438,13 +495,21 @@ func (csp *impl) Encrypt(k bccsp.Key, plaintext []byte, opts bccsp.EncrypterOpts
 	switch k.(type) {
 	case *aesPrivateKey:
 		// check for mode
-		switch opts.(type) {
-		case *bccsp.AESCBCPKCS7ModeOpts, bccsp.AESCBCPKCS7ModeOpts:
-			// AES in CBC mode with PKCS7 padding
-			return primitives.CBCPKCS7Encrypt(k.(*aesPrivateKey).k, plaintext)
+		var iv []byte
+		switch o := opts.(type) {
+		case *bccsp.AESCBCPKCS7ModeOpts:
+			iv = o.IV
+		case bccsp.AESCBCPKCS7ModeOpts:
+			iv = o.IV
 		default:
 			return nil, fmt.Errorf("Mode not recognized [%s]", opts)
 		}
+
+		// AES in CBC mode with PKCS7 padding
+		if iv != nil {
+			return primitives.CBCPKCS7EncryptWithIV(k.(*aesPrivateKey).k, iv, plaintext)
+		}
+		return primitives.CBCPKCS7Encrypt(k.(*aesPrivateKey).k, plaintext)
 	default:
 		return nil, fmt.Errorf("Key type not recognized [%s]", k)
 	}
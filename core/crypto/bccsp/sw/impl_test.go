@@ -22,6 +22,7 @@ import (
 
 	"crypto"
 	"crypto/rsa"
+	"crypto/x509"
 
 	"github.com/hyperledger/fabric/core/crypto/bccsp"
 	"github.com/hyperledger/fabric/core/crypto/primitives"
@@ -265,6 +266,64 @@ func TestECDSAVerify(t *testing.T) {
 	}
 }
 
+func TestECDSAKeyImport(t *testing.T) {
+	csp := getBCCSP(t)
+
+	lowLevelKey, err := primitives.NewECDSAKey()
+	if err != nil {
+		t.Fatalf("Failed generating ECDSA key [%s]", err)
+	}
+	skRaw, err := primitives.PrivateKeyToDER(lowLevelKey)
+	if err != nil {
+		t.Fatalf("Failed marshalling ECDSA private key [%s]", err)
+	}
+	pkRaw, err := x509.MarshalPKIXPublicKey(&lowLevelKey.PublicKey)
+	if err != nil {
+		t.Fatalf("Failed marshalling ECDSA public key [%s]", err)
+	}
+
+	sk, err := csp.KeyImport(skRaw, &bccsp.ECDSAPrivateKeyImportOpts{Temporary: true})
+	if err != nil {
+		t.Fatalf("Failed importing ECDSA private key [%s]", err)
+	}
+	if !sk.Private() || sk.Symmetric() {
+		t.Fatal("Failed importing ECDSA private key. Imported Key should be private and asymmetric")
+	}
+	pk, err := csp.KeyImport(pkRaw, &bccsp.ECDSAPKIXPublicKeyImportOpts{Temporary: true})
+	if err != nil {
+		t.Fatalf("Failed importing ECDSA public key [%s]", err)
+	}
+	if pk.Private() || pk.Symmetric() {
+		t.Fatal("Failed importing ECDSA public key. Imported Key should be public and asymmetric")
+	}
+
+	// a signature of the imported private key verifies with the imported public key
+	digest, err := csp.Hash([]byte("Hello World"), &bccsp.SHAOpts{})
+	if err != nil {
+		t.Fatalf("Failed computing HASH [%s]", err)
+	}
+	signature, err := csp.Sign(sk, digest, nil)
+	if err != nil {
+		t.Fatalf("Failed generating ECDSA signature [%s]", err)
+	}
+	valid, err := csp.Verify(pk, signature, digest, nil)
+	if err != nil {
+		t.Fatalf("Failed verifying ECDSA signature [%s]", err)
+	}
+	if !valid {
+		t.Fatal("Failed verifying ECDSA signature. Signature not valid.")
+	}
+
+	_, err = csp.KeyImport(skRaw, &bccsp.ECDSAPKIXPublicKeyImportOpts{Temporary: true})
+	if err == nil {
+		t.Fatal("Failed importing key. Must fail on importing a private key as a public key")
+	}
+	_, err = csp.KeyImport(pkRaw, &bccsp.ECDSAPrivateKeyImportOpts{Temporary: true})
+	if err == nil {
+		t.Fatal("Failed importing key. Must fail on importing a public key as a private key")
+	}
+}
+
 func TestECDSAKeyDeriv(t *testing.T) {
 	csp := getBCCSP(t)
 
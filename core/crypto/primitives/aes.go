@@ -75,18 +75,34 @@ func CBCEncrypt(key, s []byte) ([]byte, error) {
 		return nil, errors.New("plaintext is not a multiple of the block size")
 	}
 
+	// The IV needs to be unique, but not secure. Therefore it's common to
+	// include it at the beginning of the ciphertext.
+	iv := make([]byte, aes.BlockSize)
+	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+		return nil, err
+	}
+
+	return CBCEncryptWithIV(key, iv, s)
+}
+
+// CBCEncryptWithIV encrypts using CBC mode with the given IV, which is
+// included at the beginning of the ciphertext. Encrypting the same plaintext
+// with the same key and IV gives the same ciphertext
+func CBCEncryptWithIV(key, iv, s []byte) ([]byte, error) {
+	if len(s)%aes.BlockSize != 0 {
+		return nil, errors.New("plaintext is not a multiple of the block size")
+	}
+	if len(iv) != aes.BlockSize {
+		return nil, fmt.Errorf("invalid IV length %d, must be %d", len(iv), aes.BlockSize)
+	}
+
 	block, err := aes.NewCipher(key)
 	if err != nil {
 		return nil, err
 	}
 
-	// The IV needs to be unique, but not secure. Therefore it's common to
-	// include it at the beginning of the ciphertext.
 	ciphertext := make([]byte, aes.BlockSize+len(s))
-	iv := ciphertext[:aes.BlockSize]
-	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
-		return nil, err
-	}
+	copy(ciphertext, iv)
 
 	mode := cipher.NewCBCEncrypter(block, iv)
 	mode.CryptBlocks(ciphertext[aes.BlockSize:], s)
@@ -138,6 +154,12 @@ func CBCPKCS7Encrypt(key, src []byte) ([]byte, error) {
 	return CBCEncrypt(key, PKCS7Padding(src))
 }
 
+// CBCPKCS7EncryptWithIV combines CBC encryption with the given IV and PKCS7
+// padding
+func CBCPKCS7EncryptWithIV(key, iv, src []byte) ([]byte, error) {
+	return CBCEncryptWithIV(key, iv, PKCS7Padding(src))
+}
+
 // CBCPKCS7Decrypt combines CBC decryption and PKCS7 unpadding
 func CBCPKCS7Decrypt(key, src []byte) ([]byte, error) {
 	pt, err := CBCDecrypt(key, src)
@@ -479,3 +479,28 @@ func TestVariousAESKeyEncoding(t *testing.T) {
 		t.Fatalf("Failed converting encrypted PEM to AES key. Keys are different [%x][%x]", key, keyFromPEM)
 	}
 }
+
+func TestCBCPKCS7EncryptWithIV(t *testing.T) {
+	key := make([]byte, 32)
+	rand.Reader.Read(key)
+	iv := make([]byte, aes.BlockSize)
+	rand.Reader.Read(iv)
+
+	ct1, err := primitives.CBCPKCS7EncryptWithIV(key, iv, []byte("a message"))
+	if err != nil {
+		t.Fatalf("Error encrypting: %s", err)
+	}
+	ct2, _ := primitives.CBCPKCS7EncryptWithIV(key, iv, []byte("a message"))
+	if !bytes.Equal(ct1, ct2) || !bytes.Equal(ct1[:aes.BlockSize], iv) {
+		t.Fatalf("Expected the same ciphertext, starting with the IV, with the same key and IV")
+	}
+
+	pt, err := primitives.CBCPKCS7Decrypt(key, ct1)
+	if err != nil || string(pt) != "a message" {
+		t.Fatalf("Unexpected plaintext %s (%v)", pt, err)
+	}
+
+	if _, err = primitives.CBCPKCS7EncryptWithIV(key, iv[1:], []byte("a message")); err == nil {
+		t.Fatalf("Expected an error with an IV of invalid length")
+	}
+}
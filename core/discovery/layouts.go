@@ -0,0 +1,247 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package discovery
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/golang/protobuf/proto"
+	pb "github.com/hyperledger/fabric/protos"
+	ab "github.com/hyperledger/fabric/protos/orderer"
+)
+
+// MaxLayoutOrganizations is the largest number of organizations endorsement
+// layouts are computed for, the search being exponential in that number
+const MaxLayoutOrganizations = 16
+
+// EndorsementPolicy is the endorsement policy of a chaincode as far as
+// layouts are concerned: the cauthdsl signature policy recorded by lccc,
+// each of its identities standing for the organization it is a member of
+type EndorsementPolicy struct {
+	policy *ab.SignaturePolicy
+	orgs   []string
+}
+
+// NewEndorsementPolicy returns the endorsement policy of the marshaled
+// signature policy envelope, members giving the identities of the members of
+// each organization. An empty policy is met by any valid endorsement, as
+// vscc checks
+func NewEndorsementPolicy(policy []byte, members map[string][][]byte) (*EndorsementPolicy, error) {
+	if len(policy) == 0 {
+		return &EndorsementPolicy{}, nil
+	}
+
+	env := &ab.SignaturePolicyEnvelope{}
+	if err := proto.Unmarshal(policy, env); err != nil {
+		return nil, fmt.Errorf("invalid endorsement policy: %s", err)
+	}
+	if env.Version != 0 {
+		return nil, fmt.Errorf("unsupported endorsement policy version %d", env.Version)
+	}
+	if env.Policy == nil {
+		return nil, fmt.Errorf("endorsement policy with no signature policy")
+	}
+
+	p := &EndorsementPolicy{policy: env.Policy, orgs: make([]string, len(env.Identities))}
+	for i, id := range env.Identities {
+		for org, ids := range members {
+			for _, member := range ids {
+				if bytes.Equal(id, member) {
+					p.orgs[i] = org
+				}
+			}
+		}
+	}
+	if err := p.check(env.Policy); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// check checks that the signature policy only refers to identities of the
+// envelope
+func (p *EndorsementPolicy) check(policy *ab.SignaturePolicy) error {
+	switch t := policy.Type.(type) {
+	case *ab.SignaturePolicy_From:
+		for _, sub := range t.From.Policies {
+			if err := p.check(sub); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *ab.SignaturePolicy_SignedBy:
+		if t.SignedBy < 0 || t.SignedBy >= int32(len(p.orgs)) {
+			return fmt.Errorf("identity index %d of the endorsement policy out of range, the policy has %d identities", t.SignedBy, len(p.orgs))
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown endorsement policy type %T", t)
+	}
+}
+
+// satisfied checks whether endorsements from the organizations meet the
+// policy, an identity being endorsed by the organization it is a member of
+func (p *EndorsementPolicy) satisfied(orgs map[string]bool) bool {
+	if p.policy == nil {
+		return len(orgs) > 0
+	}
+	return p.evaluate(p.policy, orgs)
+}
+
+func (p *EndorsementPolicy) evaluate(policy *ab.SignaturePolicy, orgs map[string]bool) bool {
+	switch t := policy.Type.(type) {
+	case *ab.SignaturePolicy_From:
+		verified := int32(0)
+		for _, sub := range t.From.Policies {
+			if p.evaluate(sub, orgs) {
+				verified++
+			}
+		}
+		return verified >= t.From.N
+	case *ab.SignaturePolicy_SignedBy:
+		org := p.orgs[t.SignedBy]
+		return org != "" && orgs[org]
+	}
+	return false
+}
+
+// Collection is the configuration of a private data collection, shared by
+// the member Organizations. The endorsers send the private data of a
+// transaction to up to MaxPeerCount peers of these organizations and fail
+// the endorsement unless RequiredPeerCount of them acknowledged it
+type Collection struct {
+	Name              string   `json:"name"`
+	Organizations     []string `json:"organizations"`
+	RequiredPeerCount int      `json:"requiredPeerCount"`
+	MaxPeerCount      int      `json:"maxPeerCount"`
+}
+
+// ParseCollections parses the collections configuration of a chaincode
+// definition, a JSON array of collections
+func ParseCollections(config []byte) (map[string]*Collection, error) {
+	collections := make(map[string]*Collection)
+	if len(config) == 0 {
+		return collections, nil
+	}
+
+	var list []*Collection
+	if err := json.Unmarshal(config, &list); err != nil {
+		return nil, fmt.Errorf("invalid collections configuration: %s", err)
+	}
+	for _, c := range list {
+		if c.RequiredPeerCount < 0 || c.RequiredPeerCount > c.MaxPeerCount {
+			return nil, fmt.Errorf("invalid dissemination policy of collection %s: requiredPeerCount %d, maxPeerCount %d", c.Name, c.RequiredPeerCount, c.MaxPeerCount)
+		}
+		collections[c.Name] = c
+	}
+	return collections, nil
+}
+
+// Requirement is what a chaincode call requires of the endorsers of a
+// transaction: endorsements satisfying the Policy of the chaincode and
+// from each of the Organizations whose data the call touches
+type Requirement struct {
+	Policy        *EndorsementPolicy
+	Organizations []string
+}
+
+// satisfied checks whether endorsements from the organizations meet the
+// requirement
+func (r *Requirement) satisfied(orgs map[string]bool) bool {
+	for _, org := range r.Organizations {
+		if !orgs[org] {
+			return false
+		}
+	}
+	return r.Policy.satisfied(orgs)
+}
+
+// ComputeLayouts returns the minimal sets of organizations among the
+// eligible ones that meet all the requirements, each organization with its
+// endorsing peers. The smallest layouts come first, no layout is returned
+// when the requirements cannot be met
+func ComputeLayouts(reqs []*Requirement, eligible []string, peers map[string][]string) ([]*pb.EndorsementLayout, error) {
+	var orgs []string
+	for _, org := range eligible {
+		if len(peers[org]) > 0 && !inArray(org, orgs) {
+			orgs = append(orgs, org)
+		}
+	}
+	if len(orgs) > MaxLayoutOrganizations {
+		return nil, fmt.Errorf("too many organizations (%d) to compute endorsement layouts, the maximum is %d", len(orgs), MaxLayoutOrganizations)
+	}
+	sort.Strings(orgs)
+
+	var minimal []uint32
+	var layouts []*pb.EndorsementLayout
+	for size := 1; size <= len(orgs); size++ {
+		for set := uint32(1); set < 1<<uint(len(orgs)); set++ {
+			if bitCount(set) != size || hasSubset(set, minimal) {
+				continue
+			}
+
+			members := make(map[string]bool)
+			for i, org := range orgs {
+				if set&(1<<uint(i)) != 0 {
+					members[org] = true
+				}
+			}
+			if !satisfiesAll(reqs, members) {
+				continue
+			}
+
+			minimal = append(minimal, set)
+			layout := &pb.EndorsementLayout{}
+			for _, org := range orgs {
+				if members[org] {
+					layout.Organizations = append(layout.Organizations, &pb.OrganizationEndorsers{Organization: org, Peers: peers[org]})
+				}
+			}
+			layouts = append(layouts, layout)
+		}
+	}
+	return layouts, nil
+}
+
+func satisfiesAll(reqs []*Requirement, orgs map[string]bool) bool {
+	for _, req := range reqs {
+		if !req.satisfied(orgs) {
+			return false
+		}
+	}
+	return true
+}
+
+func hasSubset(set uint32, sets []uint32) bool {
+	for _, s := range sets {
+		if set&s == s {
+			return true
+		}
+	}
+	return false
+}
+
+func bitCount(set uint32) int {
+	n := 0
+	for ; set != 0; set &= set - 1 {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,155 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package discovery
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/orderer/common/cauthdsl"
+	pb "github.com/hyperledger/fabric/protos"
+	ab "github.com/hyperledger/fabric/protos/orderer"
+)
+
+var layoutPeers = map[string][]string{
+	"org1": {"peer0.org1:7051", "peer1.org1:7051"},
+	"org2": {"peer0.org2:7051"},
+	"org3": {"peer0.org3:7051"},
+}
+
+func layoutOrgs(layouts []*pb.EndorsementLayout) [][]string {
+	var res [][]string
+	for _, layout := range layouts {
+		var orgs []string
+		for _, org := range layout.Organizations {
+			orgs = append(orgs, org.Organization)
+		}
+		res = append(res, orgs)
+	}
+	return res
+}
+
+func checkLayouts(t *testing.T, reqs []*Requirement, eligible []string, expected [][]string) {
+	layouts, err := ComputeLayouts(reqs, eligible, layoutPeers)
+	if err != nil {
+		t.Fatalf("ComputeLayouts failed: %s", err)
+	}
+	actual := layoutOrgs(layouts)
+	if len(actual) != len(expected) {
+		t.Fatalf("Expected layouts %v, got %v", expected, actual)
+	}
+	for i := range expected {
+		if len(actual[i]) != len(expected[i]) {
+			t.Fatalf("Expected layouts %v, got %v", expected, actual)
+		}
+		for j := range expected[i] {
+			if actual[i][j] != expected[i][j] {
+				t.Fatalf("Expected layouts %v, got %v", expected, actual)
+			}
+		}
+	}
+}
+
+var layoutMembers = map[string][][]byte{
+	"org1": {[]byte("member.org1")},
+	"org2": {[]byte("member.org2")},
+	"org3": {[]byte("member.org3")},
+}
+
+func newPolicy(t *testing.T, policy *ab.SignaturePolicy, identities ...string) *EndorsementPolicy {
+	var ids [][]byte
+	for _, id := range identities {
+		ids = append(ids, []byte(id))
+	}
+	b, err := proto.Marshal(cauthdsl.Envelope(policy, ids))
+	if err != nil {
+		t.Fatalf("Marshal failed: %s", err)
+	}
+	p, err := NewEndorsementPolicy(b, layoutMembers)
+	if err != nil {
+		t.Fatalf("NewEndorsementPolicy failed: %s", err)
+	}
+	return p
+}
+
+func TestNewEndorsementPolicy(t *testing.T) {
+	p, err := NewEndorsementPolicy(nil, layoutMembers)
+	if err != nil || !p.satisfied(map[string]bool{"org1": true}) {
+		t.Fatalf("Expected an empty policy to be met by any endorsement, got %v (%v)", p, err)
+	}
+
+	p = newPolicy(t, cauthdsl.And(cauthdsl.SignedBy(0), cauthdsl.SignedBy(1)), "member.org1", "member.org2")
+	if !p.satisfied(map[string]bool{"org1": true, "org2": true}) || p.satisfied(map[string]bool{"org1": true, "org3": true}) {
+		t.Fatalf("Expected the policy to require org1 and org2")
+	}
+
+	// an identity that is no member of an organization cannot be endorsed
+	p = newPolicy(t, cauthdsl.SignedBy(0), "unknown")
+	if p.satisfied(map[string]bool{"org1": true, "org2": true, "org3": true}) {
+		t.Fatalf("Expected the policy of an unknown identity not to be met")
+	}
+
+	if _, err = NewEndorsementPolicy([]byte("majority"), layoutMembers); err == nil {
+		t.Fatalf("Expected a policy that is no signature policy envelope to fail")
+	}
+	b, _ := proto.Marshal(cauthdsl.Envelope(cauthdsl.SignedBy(1), [][]byte{[]byte("member.org1")}))
+	if _, err = NewEndorsementPolicy(b, layoutMembers); err == nil {
+		t.Fatalf("Expected a policy referring to an unknown identity index to fail")
+	}
+}
+
+func TestComputeLayouts(t *testing.T) {
+	orgs := []string{"org1", "org2", "org3"}
+	identities := []string{"member.org1", "member.org2", "member.org3"}
+	majority := newPolicy(t, cauthdsl.NOutOf(2, []*ab.SignaturePolicy{cauthdsl.SignedBy(0), cauthdsl.SignedBy(1), cauthdsl.SignedBy(2)}), identities...)
+	anyOrg := newPolicy(t, cauthdsl.Or(cauthdsl.SignedBy(1), cauthdsl.SignedBy(2)), identities...)
+
+	checkLayouts(t, []*Requirement{{Policy: majority}}, orgs, [][]string{{"org1", "org2"}, {"org1", "org3"}, {"org2", "org3"}})
+
+	// the call touching the data of org1 needs its endorsement
+	checkLayouts(t, []*Requirement{{Policy: anyOrg}, {Policy: anyOrg, Organizations: []string{"org1"}}}, orgs, [][]string{{"org1", "org2"}, {"org1", "org3"}})
+
+	// a collection restricts the eligible organizations
+	checkLayouts(t, []*Requirement{{Policy: majority}}, []string{"org1", "org3"}, [][]string{{"org1", "org3"}})
+	checkLayouts(t, []*Requirement{{Policy: majority}}, []string{"org1"}, nil)
+
+	// organizations without peers cannot endorse
+	checkLayouts(t, []*Requirement{{Policy: majority}}, []string{"org1", "org4", "org2"}, [][]string{{"org1", "org2"}})
+
+	layouts, _ := ComputeLayouts([]*Requirement{{Policy: anyOrg}}, orgs, layoutPeers)
+	if len(layouts[0].Organizations[0].Peers) != 1 || layouts[0].Organizations[0].Peers[0] != "peer0.org2:7051" {
+		t.Fatalf("Expected the peers of org2, got %v", layouts[0])
+	}
+}
+
+func TestParseCollections(t *testing.T) {
+	collections, err := ParseCollections([]byte(`[{"name":"marbles","organizations":["org1","org2"]}]`))
+	if err != nil || len(collections["marbles"].Organizations) != 2 {
+		t.Fatalf("Expected the marbles collection, got %v (%v)", collections, err)
+	}
+	if _, err = ParseCollections([]byte("marbles")); err == nil {
+		t.Fatalf("Expected an invalid configuration to fail")
+	}
+
+	collections, err = ParseCollections([]byte(`[{"name":"marbles","organizations":["org1"],"requiredPeerCount":1,"maxPeerCount":3}]`))
+	if err != nil || collections["marbles"].RequiredPeerCount != 1 || collections["marbles"].MaxPeerCount != 3 {
+		t.Fatalf("Expected the dissemination policy of the marbles collection, got %v (%v)", collections, err)
+	}
+	if _, err = ParseCollections([]byte(`[{"name":"marbles","organizations":["org1"],"requiredPeerCount":2,"maxPeerCount":1}]`)); err == nil {
+		t.Fatalf("Expected a collection requiring more peers than it is sent to to fail")
+	}
+}
@@ -0,0 +1,55 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+
+package discovery
+
+import (
+	"sort"
+
+	gdiscovery "github.com/hyperledger/fabric/gossip/discovery"
+	"github.com/hyperledger/fabric/gossip/state"
+	pb "github.com/hyperledger/fabric/protos"
+)
+
+// Membership returns the peers of a chain among the alive members of the gossip
+// network, with the height of their ledger and their chaincodes as published in
+// their gossip metadata, and their organization in orgs, which maps the address
+// of the peers to their organization. Peers that didn't publish the state of the
+// chain are left out. The peers are sorted by endpoint
+func Membership(chainID string, members []gdiscovery.NetworkMember, orgs map[string]string) []*pb.PeerInfo {
+	var peers []*pb.PeerInfo
+	for _, member := range members {
+		metastate, err := state.FromBytes(member.Metadata)
+		if err != nil || metastate.ChainID != chainID {
+			continue
+		}
+		peers = append(peers, &pb.PeerInfo{
+			Endpoint:     member.Endpoint,
+			Organization: orgs[member.Endpoint],
+			LedgerHeight: metastate.Height(),
+			Chaincodes:   metastate.Chaincodes,
+		})
+	}
+	sort.Sort(peersByEndpoint(peers))
+	return peers
+}
+
+type peersByEndpoint []*pb.PeerInfo
+
+func (p peersByEndpoint) Len() int           { return len(p) }
+func (p peersByEndpoint) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+func (p peersByEndpoint) Less(i, j int) bool { return p[i].Endpoint < p[j].Endpoint }
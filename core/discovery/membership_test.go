@@ -0,0 +1,55 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+
+package discovery
+
+import (
+	"testing"
+
+	gdiscovery "github.com/hyperledger/fabric/gossip/discovery"
+	"github.com/hyperledger/fabric/gossip/state"
+	pb "github.com/hyperledger/fabric/protos"
+	"github.com/stretchr/testify/assert"
+)
+
+func metadata(t *testing.T, chainID string, height uint64, chaincodes ...string) []byte {
+	metastate := state.NewNodeMetastate(height)
+	metastate.ChainID = chainID
+	metastate.Chaincodes = chaincodes
+	md, err := metastate.Bytes()
+	if err != nil {
+		t.Fatalf("Error serializing metadata: %s", err)
+	}
+	return md
+}
+
+func TestMembership(t *testing.T) {
+	members := []gdiscovery.NetworkMember{
+		{Endpoint: "peer1.org2:7051", Metadata: metadata(t, "A", 5)},
+		{Endpoint: "peer0.org1:7051", Metadata: metadata(t, "A", 7, "mycc:1.0")},
+		{Endpoint: "peer0.org2:7051", Metadata: metadata(t, "B", 3)},
+		{Endpoint: "peer0.org3:7051", Metadata: []byte{}},
+	}
+	orgs := map[string]string{"peer0.org1:7051": "org1", "peer1.org2:7051": "org2"}
+
+	expected := []*pb.PeerInfo{
+		{Endpoint: "peer0.org1:7051", Organization: "org1", LedgerHeight: 7, Chaincodes: []string{"mycc:1.0"}},
+		{Endpoint: "peer1.org2:7051", Organization: "org2", LedgerHeight: 5},
+	}
+	assert.Equal(t, expected, Membership("A", members, orgs))
+	assert.Empty(t, Membership("C", members, orgs))
+}
@@ -0,0 +1,99 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package decoration
+
+import (
+	"fmt"
+	"sync"
+
+	pb "github.com/hyperledger/fabric/protos"
+	"github.com/op/go-logging"
+	"github.com/spf13/viper"
+	"golang.org/x/net/context"
+)
+
+var logger = logging.MustGetLogger("decoration")
+
+// Decorator - an interface for plugins that add data to the input of the chaincode before a proposal is
+// executed, such as a classification of the client address or metadata of the deployment. The chaincode
+// reads the decorations through GetDecorations
+type Decorator interface {
+	// Decorate returns the input to pass to the chaincode for the given proposal. The decorator adds its
+	// data to the Decorations of the input, the arguments should be left untouched
+	Decorate(ctx context.Context, prop *pb.Proposal, input *pb.ChaincodeInput) (*pb.ChaincodeInput, error)
+}
+
+var (
+	decoratorsLock sync.RWMutex
+	decorators     = make(map[string]Decorator)
+)
+
+// RegisterDecorator registers a decorator by the given name. The decorator is applied only if its name
+// is listed in `peer.decorators`
+func RegisterDecorator(name string, decorator Decorator) {
+	decoratorsLock.Lock()
+	defer decoratorsLock.Unlock()
+	decorators[name] = decorator
+}
+
+// UnregisterDecorator removes the decorator registered by the given name
+func UnregisterDecorator(name string) {
+	decoratorsLock.Lock()
+	defer decoratorsLock.Unlock()
+	delete(decorators, name)
+}
+
+// getDecorators returns the decorators listed in `peer.decorators`, in the order of the list
+func getDecorators() ([]string, []Decorator, error) {
+	decoratorsLock.RLock()
+	defer decoratorsLock.RUnlock()
+	names := viper.GetStringSlice("peer.decorators")
+	enabled := make([]Decorator, 0, len(names))
+	for _, name := range names {
+		decorator, ok := decorators[name]
+		if !ok {
+			return nil, nil, fmt.Errorf("Decorator [%s] is not registered", name)
+		}
+		enabled = append(enabled, decorator)
+	}
+	return names, enabled, nil
+}
+
+// Apply passes the input of the chaincode through the configured decorators, in order, and returns the
+// decorations to hand to the chaincode. Decorations supplied by the client are dropped, only the peer
+// decorates the input
+func Apply(ctx context.Context, prop *pb.Proposal, input *pb.ChaincodeInput) (map[string][]byte, error) {
+	names, enabled, err := getDecorators()
+	if err != nil {
+		return nil, err
+	}
+	if len(enabled) == 0 {
+		return nil, nil
+	}
+
+	decorated := &pb.ChaincodeInput{Args: input.Args, Decorations: make(map[string][]byte)}
+	for i, decorator := range enabled {
+		if decorated, err = decorator.Decorate(ctx, prop, decorated); err != nil {
+			return nil, fmt.Errorf("Decorator [%s] failed: %s", names[i], err)
+		}
+		if decorated == nil {
+			return nil, fmt.Errorf("Decorator [%s] returned no input", names[i])
+		}
+		logger.Debugf("Input decorated by [%s]", names[i])
+	}
+	return decorated.Decorations, nil
+}
@@ -0,0 +1,80 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package decoration
+
+import (
+	"errors"
+	"testing"
+
+	pb "github.com/hyperledger/fabric/protos"
+	"github.com/spf13/viper"
+	"golang.org/x/net/context"
+)
+
+type staticDecorator struct {
+	key   string
+	value []byte
+	err   error
+}
+
+func (d *staticDecorator) Decorate(ctx context.Context, prop *pb.Proposal, input *pb.ChaincodeInput) (*pb.ChaincodeInput, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	input.Decorations[d.key] = d.value
+	return input, nil
+}
+
+func TestApply(t *testing.T) {
+	RegisterDecorator("first", &staticDecorator{key: "k", value: []byte("first")})
+	RegisterDecorator("second", &staticDecorator{key: "k", value: []byte("second")})
+	RegisterDecorator("other", &staticDecorator{key: "other", value: []byte("v")})
+	defer UnregisterDecorator("first")
+	defer UnregisterDecorator("second")
+	defer UnregisterDecorator("other")
+	defer viper.Set("peer.decorators", nil)
+
+	input := &pb.ChaincodeInput{Args: [][]byte{[]byte("a")}, Decorations: map[string][]byte{"k": []byte("client")}}
+
+	// no decorator configured
+	decorations, err := Apply(context.Background(), nil, input)
+	if err != nil || decorations != nil {
+		t.Fatalf("Unexpected decorations %v, error %v", decorations, err)
+	}
+
+	// the decorators are applied in the configured order and the client decorations are dropped
+	viper.Set("peer.decorators", []string{"first", "second"})
+	decorations, err = Apply(context.Background(), nil, input)
+	if err != nil {
+		t.Fatalf("Error applying the decorators: %s", err)
+	}
+	if len(decorations) != 1 || string(decorations["k"]) != "second" {
+		t.Fatalf("Unexpected decorations %v", decorations)
+	}
+
+	viper.Set("peer.decorators", []string{"first", "missing"})
+	if _, err = Apply(context.Background(), nil, input); err == nil {
+		t.Fatalf("Expected an error for a decorator which is not registered")
+	}
+
+	RegisterDecorator("failing", &staticDecorator{err: errors.New("failure")})
+	defer UnregisterDecorator("failing")
+	viper.Set("peer.decorators", []string{"failing"})
+	if _, err = Apply(context.Background(), nil, input); err == nil {
+		t.Fatalf("Expected the error of the decorator")
+	}
+}
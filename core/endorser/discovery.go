@@ -0,0 +1,108 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endorser
+
+import (
+	"golang.org/x/net/context"
+
+	"github.com/hyperledger/fabric/core/chaincode"
+	"github.com/hyperledger/fabric/core/committer/noopssinglechain"
+	"github.com/hyperledger/fabric/core/discovery"
+	"github.com/hyperledger/fabric/core/ledger/kvledger"
+	"github.com/hyperledger/fabric/core/peer"
+	"github.com/hyperledger/fabric/gossip/service"
+	pb "github.com/hyperledger/fabric/protos"
+)
+
+// DiscoveryServer answers the discovery requests of the clients of the peer
+type DiscoveryServer struct {
+	endorser pb.EndorserServer
+}
+
+// NewDiscoveryServer creates a discovery server computing the endorsement
+// layouts with the given endorser
+func NewDiscoveryServer(endorser pb.EndorserServer) pb.DiscoveryServer {
+	return &DiscoveryServer{endorser: endorser}
+}
+
+// Discover returns the peers of a chain the peer knows of over gossip, the
+// peer included, the orderers of the chain and, if the request has chaincode
+// calls of interest, their endorsement layouts
+func (s *DiscoveryServer) Discover(ctx context.Context, req *pb.DiscoveryRequest) (*pb.DiscoveryResponse, error) {
+	chainID := req.ChainID
+	if chainID == "" {
+		chainID = string(chaincode.DefaultChain)
+	}
+	if err := checkChain(chainID); err != nil {
+		return nil, err
+	}
+
+	orgs, err := chaincode.GetChainOrganizations(chainID)
+	if err != nil {
+		return nil, err
+	}
+	peerOrgs := make(map[string]string)
+	for _, org := range orgs {
+		for _, p := range org.Peers {
+			peerOrgs[p] = org.Name
+		}
+	}
+
+	self, err := getSelfInfo(chainID, peerOrgs)
+	if err != nil {
+		return nil, err
+	}
+	resp := &pb.DiscoveryResponse{Peers: []*pb.PeerInfo{self}}
+	if g := service.GetGossipService(); g != nil {
+		resp.Peers = append(resp.Peers, discovery.Membership(chainID, g.GetPeers(), peerOrgs)...)
+	}
+
+	resp.Orderers = noopssinglechain.OrdererAddresses(chainID)
+
+	if len(req.Interest) > 0 {
+		layouts, err := s.endorser.GetEndorsementLayouts(ctx, &pb.EndorsementLayoutsRequest{ChainID: chainID, Calls: req.Interest})
+		if err != nil {
+			return nil, err
+		}
+		resp.Layouts = layouts.Layouts
+	}
+
+	return resp, nil
+}
+
+// getSelfInfo returns the endpoint, organization, ledger height of the chain
+// and installed chaincodes of the peer
+func getSelfInfo(chainID string, peerOrgs map[string]string) (*pb.PeerInfo, error) {
+	endpoint, err := peer.GetPeerEndpoint()
+	if err != nil {
+		return nil, err
+	}
+	info, err := kvledger.GetLedger(chainID).GetBlockchainInfo()
+	if err != nil {
+		return nil, err
+	}
+	chaincodes, err := chaincode.GetInstalledChaincodeNames()
+	if err != nil {
+		return nil, err
+	}
+	return &pb.PeerInfo{
+		Endpoint:     endpoint.Address,
+		Organization: peerOrgs[endpoint.Address],
+		LedgerHeight: info.Height,
+		Chaincodes:   chaincodes,
+	}, nil
+}
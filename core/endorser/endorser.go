@@ -18,17 +18,24 @@ package endorser
 
 import (
 	"fmt"
+	"io"
+	"time"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/op/go-logging"
 	"golang.org/x/net/context"
 
 	"github.com/hyperledger/fabric/core/chaincode"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/core/endorser/decoration"
 	"github.com/hyperledger/fabric/core/ledger"
 	"github.com/hyperledger/fabric/core/ledger/kvledger"
+	"github.com/hyperledger/fabric/core/metrics"
 	"github.com/hyperledger/fabric/core/peer"
+	"github.com/hyperledger/fabric/core/tracing"
 	pb "github.com/hyperledger/fabric/protos"
 	putils "github.com/hyperledger/fabric/protos/utils"
+	"github.com/spf13/viper"
 )
 
 var devopsLogger = logging.MustGetLogger("endorser")
@@ -38,19 +45,62 @@ var devopsLogger = logging.MustGetLogger("endorser")
 
 // Endorser provides the Endorser service ProcessProposal
 type Endorser struct {
-	coord peer.MessageHandlerCoordinator
+	coord   peer.MessageHandlerCoordinator
+	metrics *endorserMetrics
 }
 
 // NewEndorserServer creates and returns a new Endorser server instance.
 func NewEndorserServer(coord peer.MessageHandlerCoordinator) pb.EndorserServer {
 	e := new(Endorser)
 	e.coord = coord
+	e.metrics = newEndorserMetrics(metrics.GetProvider())
 	return e
 }
 
-//TODO - what would Endorser's ACL be ?
-func (*Endorser) checkACL(prop *pb.Proposal) error {
-	return nil
+//TODO - what would Endorser's ACL be ? For now only the system chaincodes
+//are controlled: the ones clients may invoke, the policies of their
+//functions and the chaincode lifecycle functions of lccc
+func (*Endorser) checkACL(prop *pb.Proposal, chainID string, cis *pb.ChaincodeInvocationSpec) error {
+	ccname := cis.ChaincodeSpec.ChaincodeID.Name
+	if chaincode.IsSysCC(ccname) && !chaincode.IsSysCCInvokableExternal(ccname) {
+		return fmt.Errorf("system chaincode %s cannot be invoked by clients", ccname)
+	}
+
+	if len(cis.ChaincodeSpec.CtorMsg.Args) == 0 {
+		return nil
+	}
+
+	//only the peer migrates the state of an upgraded chaincode
+	if err := chaincode.CheckMigrateNotInvoked(ccname, cis.ChaincodeSpec.CtorMsg.Args); err != nil {
+		return err
+	}
+
+	if !chaincode.IsSysCC(ccname) {
+		return nil
+	}
+
+	hdr, err := putils.GetHeader(prop)
+	if err != nil {
+		return err
+	}
+
+	if err = chaincode.CheckSysCCACL(chainID, ccname, string(cis.ChaincodeSpec.CtorMsg.Args[0]), hdr.Creator); err != nil {
+		return err
+	}
+
+	if ccname != "lccc" {
+		return nil
+	}
+
+	//lccc only operates on the chain of the proposal, except for the
+	//functions managing the packages installed on the peer
+	args := cis.ChaincodeSpec.CtorMsg.Args
+	function := string(args[0])
+	if function != chaincode.INSTALL && function != chaincode.GETINSTALLED && len(args) >= 2 && string(args[1]) != chainID {
+		return fmt.Errorf("lccc %s on chain %s cannot be proposed on chain %s", function, string(args[1]), chainID)
+	}
+
+	return chaincode.CheckLifecycleACL(cis.ChaincodeSpec.CtorMsg.Args, hdr.Creator)
 }
 
 //TODO - check for escc and vscc
@@ -58,13 +108,44 @@ func (*Endorser) checkEsccAndVscc(prop *pb.Proposal) error {
 	return nil
 }
 
+//getChainID returns the chain targeted by the proposal, the default chain
+//when the header names none. The chain must be one of the ledgers of the
+//peer, the default chain or one it joined
+func getChainID(hdr *pb.Header) (string, error) {
+	chainID := string(hdr.ChainID)
+	if chainID == "" {
+		return string(chaincode.DefaultChain), nil
+	}
+	if err := checkChain(chainID); err != nil {
+		return "", err
+	}
+	return chainID, nil
+}
+
+//checkChain checks that the peer has the ledger of the chain
+func checkChain(chainID string) error {
+	if chainID == string(chaincode.DefaultChain) {
+		return nil
+	}
+	exists, err := kvledger.LedgerExists(chainID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("chain %s not joined by the peer", chainID)
+	}
+	return nil
+}
+
 func (*Endorser) getTxSimulator(ledgername string) (ledger.TxSimulator, error) {
 	lgr := kvledger.GetLedger(ledgername)
 	return lgr.NewTxSimulator()
 }
 
-//deploy the chaincode after call to the system chaincode is successful
-func (e *Endorser) deploy(ctxt context.Context, chainname string, cds *pb.ChaincodeDeploymentSpec, cid *pb.ChaincodeID) error {
+//deploy the chaincode after call to the system chaincode is successful. When
+//the chaincode is upgraded from upgradedCDS its state is migrated by calling
+//Migrate of the new version in the same transaction
+func (e *Endorser) deploy(ctxt context.Context, chainname string, cds *pb.ChaincodeDeploymentSpec, cid *pb.ChaincodeID, upgradedCDS *pb.ChaincodeDeploymentSpec) error {
 	//TODO : this needs to be converted to another data structure to be handled
 	//       by the chaincode framework (which currently handles "Transaction")
 	t, err := pb.NewChaincodeDeployTransaction(cds, cid.Name)
@@ -72,8 +153,10 @@ func (e *Endorser) deploy(ctxt context.Context, chainname string, cds *pb.Chainc
 		return err
 	}
 
-	//TODO - create chaincode support for chainname, for now use DefaultChain
-	chaincodeSupport := chaincode.GetChain(chaincode.ChainName(chainname))
+	chaincodeSupport, err := chaincode.GetChaincodeSupport(chainname, cid.Name)
+	if err != nil {
+		return err
+	}
 
 	_, err = chaincodeSupport.Deploy(ctxt, t)
 	if err != nil {
@@ -86,23 +169,55 @@ func (e *Endorser) deploy(ctxt context.Context, chainname string, cds *pb.Chainc
 		return fmt.Errorf("%s", err)
 	}
 
+	if upgradedCDS != nil {
+		args := [][]byte{[]byte(shim.MigrateFunction), []byte(upgradedCDS.ChaincodeSpec.ChaincodeID.Version), []byte(cds.ChaincodeSpec.ChaincodeID.Version)}
+		if _, _, err = chaincode.ExecuteChaincode(ctxt, pb.Transaction_CHAINCODE_INVOKE, chainname, cid.Name, args); err != nil {
+			chaincodeSupport.Stop(ctxt, cds)
+			return fmt.Errorf("Failed to migrate chaincode %s from version %s to %s(%s)", cid.Name, upgradedCDS.ChaincodeSpec.ChaincodeID.Version, cds.ChaincodeSpec.ChaincodeID.Version, err)
+		}
+	}
+
 	//stop now that we are done
 	chaincodeSupport.Stop(ctxt, cds)
 
 	return nil
 }
 
-//call specified chaincode (system or user)
-func (e *Endorser) callChaincode(ctxt context.Context, cis *pb.ChaincodeInvocationSpec, cid *pb.ChaincodeID, txsim ledger.TxSimulator) ([]byte, *pb.ChaincodeEvent, error) {
+//call specified chaincode (system or user) on the chain of the transaction
+//simulator
+func (e *Endorser) callChaincode(ctxt context.Context, chainID string, cis *pb.ChaincodeInvocationSpec, cid *pb.ChaincodeID, txsim ledger.TxSimulator) ([]byte, *pb.ChaincodeEvent, error) {
 	var err error
 	var b []byte
 	var ccevent *pb.ChaincodeEvent
 
-	//TODO - get chainname from cis when defined
-	chainName := string(chaincode.DefaultChain)
-
 	ctxt = context.WithValue(ctxt, chaincode.TXSimulatorKey, txsim)
-	b, ccevent, err = chaincode.ExecuteChaincode(ctxt, pb.Transaction_CHAINCODE_INVOKE, chainName, cid.Name, cis.ChaincodeSpec.CtorMsg.Args)
+
+	//the running version is stopped once an upgrade succeeds
+	var upgradedCDS *pb.ChaincodeDeploymentSpec
+	if cid.Name == "lccc" && len(cis.ChaincodeSpec.CtorMsg.Args) >= 3 {
+		switch string(cis.ChaincodeSpec.CtorMsg.Args[0]) {
+		case "upgrade":
+			var cds *pb.ChaincodeDeploymentSpec
+			if cds, err = putils.GetChaincodeDeploymentSpec(cis.ChaincodeSpec.CtorMsg.Args[2]); err != nil {
+				return nil, nil, err
+			}
+			if err = checkChaincodeSpec(cds.ChaincodeSpec); err != nil {
+				return nil, nil, err
+			}
+			if upgradedCDS, err = e.getCurrentCDS(ctxt, chainID, cds.ChaincodeSpec.ChaincodeID.Name); err != nil {
+				return nil, nil, err
+			}
+		case "commit":
+			//a committed definition upgrades the chaincode if it is instantiated
+			def := &pb.ChaincodeDefinition{}
+			if err = proto.Unmarshal(cis.ChaincodeSpec.CtorMsg.Args[2], def); err != nil {
+				return nil, nil, err
+			}
+			upgradedCDS, _ = e.getCurrentCDS(ctxt, chainID, def.Name)
+		}
+	}
+
+	b, ccevent, err = chaincode.ExecuteChaincodeInput(ctxt, pb.Transaction_CHAINCODE_INVOKE, chainID, cid.Name, cis.ChaincodeSpec.CtorMsg)
 
 	if err != nil {
 		return nil, nil, err
@@ -122,18 +237,42 @@ func (e *Endorser) callChaincode(ctxt context.Context, cis *pb.ChaincodeInvocati
 		if err != nil {
 			return nil, nil, err
 		}
-		err = e.deploy(ctxt, chainName, cds, cid)
+		if err = checkChaincodeSpec(cds.ChaincodeSpec); err != nil {
+			return nil, nil, err
+		}
+		err = e.deploy(ctxt, chainID, cds, cid, nil)
 		if err != nil {
 			return nil, nil, err
 		}
 	}
+
+	//instantiate, upgrade and commit return the installed package to launch
+	if cid.Name == "lccc" && len(cis.ChaincodeSpec.CtorMsg.Args) >= 3 {
+		function := string(cis.ChaincodeSpec.CtorMsg.Args[0])
+		if function == "instantiate" || function == "upgrade" || function == "commit" {
+			var cds *pb.ChaincodeDeploymentSpec
+			cds, err = putils.GetChaincodeDeploymentSpec(b)
+			if err != nil {
+				return nil, nil, err
+			}
+			if upgradedCDS != nil {
+				if chaincodeSupport := chaincode.GetChain(chaincode.ChainName(chainID)); chaincodeSupport != nil {
+					chaincodeSupport.Stop(ctxt, upgradedCDS)
+				}
+			}
+			err = e.deploy(ctxt, chainID, cds, cds.ChaincodeSpec.ChaincodeID, upgradedCDS)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+	}
 	//----- END -------
 
 	return b, ccevent, err
 }
 
 //simulate the proposal by calling the chaincode
-func (e *Endorser) simulateProposal(ctx context.Context, prop *pb.Proposal, cid *pb.ChaincodeID, txsim ledger.TxSimulator) ([]byte, []byte, *pb.ChaincodeEvent, error) {
+func (e *Endorser) simulateProposal(ctx context.Context, chainID string, prop *pb.Proposal, cid *pb.ChaincodeID, txsim ledger.TxSimulator) ([]byte, []byte, *pb.ChaincodeEvent, error) {
 	//we do expect the payload to be a ChaincodeInvocationSpec
 	//if we are supporting other payloads in future, this be glaringly point
 	//as something that should change
@@ -142,7 +281,7 @@ func (e *Endorser) simulateProposal(ctx context.Context, prop *pb.Proposal, cid
 		return nil, nil, nil, err
 	}
 	//---1. check ACL
-	if err = e.checkACL(prop); err != nil {
+	if err = e.checkACL(prop, chainID, cis); err != nil {
 		return nil, nil, nil, err
 	}
 
@@ -151,11 +290,18 @@ func (e *Endorser) simulateProposal(ctx context.Context, prop *pb.Proposal, cid
 		return nil, nil, nil, err
 	}
 
-	//---3. execute the proposal and get simulation results
+	//---3. decorate the input of the chaincode, replacing any decorations of the client
+	if cis.ChaincodeSpec.CtorMsg.Decorations, err = decoration.Apply(ctx, prop, cis.ChaincodeSpec.CtorMsg); err != nil {
+		return nil, nil, nil, err
+	}
+
+	//---4. execute the proposal and get simulation results
 	var simResult []byte
 	var resp []byte
 	var ccevent *pb.ChaincodeEvent
-	resp, ccevent, err = e.callChaincode(ctx, cis, cid, txsim)
+	//the proposal is passed along so that the chaincode can access its transient data
+	ctx = context.WithValue(ctx, chaincode.ProposalKey, prop)
+	resp, ccevent, err = e.callChaincode(ctx, chainID, cis, cid, txsim)
 	if err != nil {
 		return nil, nil, nil, err
 	}
@@ -167,33 +313,35 @@ func (e *Endorser) simulateProposal(ctx context.Context, prop *pb.Proposal, cid
 	return resp, simResult, ccevent, nil
 }
 
-func (e *Endorser) getCDSFromLCCC(ctx context.Context, chaincodeID string, txsim ledger.TxSimulator) ([]byte, error) {
+func (e *Endorser) getChaincodeDataFromLCCC(ctx context.Context, chainID string, chaincodeID string, txsim ledger.TxSimulator) (*pb.ChaincodeInfo, error) {
 	ctxt := context.WithValue(ctx, chaincode.TXSimulatorKey, txsim)
-	return chaincode.GetCDSFromLCCC(ctxt, string(chaincode.DefaultChain), chaincodeID)
+	return chaincode.GetChaincodeDataFromLCCC(ctxt, chainID, chaincodeID)
+}
+
+//getCurrentCDS returns the deployment spec of the version of the given
+//chaincode instantiated on the chain
+func (e *Endorser) getCurrentCDS(ctxt context.Context, chainID string, ccname string) (*pb.ChaincodeDeploymentSpec, error) {
+	b, err := chaincode.GetCDSFromLCCC(ctxt, chainID, ccname)
+	if err != nil {
+		return nil, err
+	}
+
+	return putils.GetChaincodeDeploymentSpec(b)
 }
 
 //endorse the proposal by calling the ESCC
-func (e *Endorser) endorseProposal(ctx context.Context, proposal *pb.Proposal, simRes []byte, event *pb.ChaincodeEvent, visibility []byte, ccid *pb.ChaincodeID, txsim ledger.TxSimulator) ([]byte, error) {
+func (e *Endorser) endorseProposal(ctx context.Context, chainID string, proposal *pb.Proposal, response *pb.Response2, simRes []byte, event *pb.ChaincodeEvent, visibility []byte, ccid *pb.ChaincodeID, txsim ledger.TxSimulator, privateData *pb.PrivateDataHashes) ([]byte, error) {
 	devopsLogger.Infof("endorseProposal starts for proposal %p, simRes %p event %p, visibility %p, ccid %s", proposal, simRes, event, visibility, ccid)
 
-	// 1) extract the chaincodeDeploymentSpec for the chaincode we are invoking; we need it to get the escc
-	var escc string
-	if ccid.Name != "lccc" {
-		depPayload, err := e.getCDSFromLCCC(ctx, ccid.Name, txsim)
-		if err != nil {
-			return nil, fmt.Errorf("failed to obtain cds for %s - %s", ccid, err)
-		}
-
-		_, err = putils.GetChaincodeDeploymentSpec(depPayload)
+	// 1) look up the escc of the chaincode we are invoking in the data lccc
+	// records on the chain, system chaincodes are endorsed by the default one
+	escc := "escc"
+	if !chaincode.IsSysCC(ccid.Name) {
+		ccdata, err := e.getChaincodeDataFromLCCC(ctx, chainID, ccid.Name, txsim)
 		if err != nil {
-			return nil, fmt.Errorf("failed to unmarshal cds for %s - %s", ccid, err)
+			return nil, fmt.Errorf("failed to obtain the chaincode data of %s - %s", ccid, err)
 		}
-
-		// FIXME: pick the right escc from cds - currently cds doesn't have this info
-		escc = "escc"
-	} else {
-		// FIXME: getCDSFromLCCC seems to fail for lccc - not sure this is expected?
-		escc = "escc"
+		escc = ccdata.Escc
 	}
 
 	devopsLogger.Infof("endorseProposal info: escc for cid %s is %s", ccid, escc)
@@ -208,6 +356,12 @@ func (e *Endorser) endorseProposal(ctx context.Context, proposal *pb.Proposal, s
 		}
 	}
 
+	// marshalling the response of the chaincode
+	resBytes, err := proto.Marshal(response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the chaincode response - %s", err)
+	}
+
 	// 3) call the ESCC we've identified
 	// arguments:
 	// args[0] - function name (not used now)
@@ -216,9 +370,18 @@ func (e *Endorser) endorseProposal(ctx context.Context, proposal *pb.Proposal, s
 	// args[3] - binary blob of simulation results
 	// args[4] - serialized events
 	// args[5] - payloadVisibility
-	args := [][]byte{[]byte(""), proposal.Header, proposal.Payload, simRes, eventBytes, visibility}
+	// args[6] - serialized Response2 of the chaincode
+	// args[7] - serialized PrivateDataHashes of the private data disseminated
+	args := [][]byte{[]byte(""), proposal.Header, proposal.Payload, simRes, eventBytes, visibility, resBytes}
+	if privateData != nil {
+		privateDataBytes, err := proto.Marshal(privateData)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal the private data hashes - %s", err)
+		}
+		args = append(args, privateDataBytes)
+	}
 	ecccis := &pb.ChaincodeInvocationSpec{ChaincodeSpec: &pb.ChaincodeSpec{Type: pb.ChaincodeSpec_GOLANG, ChaincodeID: &pb.ChaincodeID{Name: escc}, CtorMsg: &pb.ChaincodeInput{Args: args}}}
-	prBytes, _, err := e.callChaincode(ctx, ecccis, &pb.ChaincodeID{Name: escc}, txsim)
+	prBytes, _, err := e.callChaincode(ctx, chainID, ecccis, &pb.ChaincodeID{Name: escc}, txsim)
 	if err != nil {
 		return nil, err
 	}
@@ -250,41 +413,71 @@ func (e *Endorser) validateChaincodeProposalMessage(prop *pb.Proposal, hdr *pb.H
 
 	//    - ensure that the chaincodeID is correct (?)
 	// TODO: should we even do this? If so, using which interface?
+	if chaincodeHdrExt.ChaincodeID == nil {
+		return nil, fmt.Errorf("Invalid header extension, no chaincode ID specified")
+	}
 
 	//    - ensure that the visibility field has some value we understand
 	// TODO: we need to define visibility fields first
 
-	// TODO: should we check the payload as well?
+	//    - ensure that the payload names the chaincode and carries its input,
+	//      which the ACL checks and the simulation dereference
+	cis, err := putils.GetChaincodeInvocationSpec(prop)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkChaincodeSpec(cis.ChaincodeSpec); err != nil {
+		return nil, err
+	}
+	if cis.ChaincodeSpec.CtorMsg == nil {
+		return nil, fmt.Errorf("Invalid chaincode spec, no input specified")
+	}
 
 	return chaincodeHdrExt, nil
 }
 
+//checkChaincodeSpec checks that a chaincode spec of a proposal, invoked or
+//deployed, names its chaincode
+func checkChaincodeSpec(spec *pb.ChaincodeSpec) error {
+	if spec == nil {
+		return fmt.Errorf("Invalid proposal payload, no chaincode spec specified")
+	}
+	if spec.ChaincodeID == nil {
+		return fmt.Errorf("Invalid chaincode spec, no chaincode ID specified")
+	}
+	return nil
+}
+
 // FIXME: this method might be of general interest, should we package it somewhere else?
 // validateProposalMessage checks the validity of a generic Proposal message
-// this function returns Header and ChaincodeHeaderExtension messages since they
-// have been unmarshalled and validated
-func (e *Endorser) validateProposalMessage(prop *pb.Proposal) (*pb.Header, *pb.ChaincodeHeaderExtension, error) {
+// this function returns Proposal, Header and ChaincodeHeaderExtension messages
+// since they have been unmarshalled and validated
+func (e *Endorser) validateProposalMessage(signedProp *pb.SignedProposal) (*pb.Proposal, *pb.Header, *pb.ChaincodeHeaderExtension, error) {
+	prop, err := putils.GetProposal(signedProp)
+	if err != nil {
+		return nil, nil, nil, err
+	}
 	devopsLogger.Infof("validateProposalMessage starts for proposal %p", prop)
 
 	// 1) look at the ProposalHeader
 	hdr, err := putils.GetHeader(prop)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	//    - validate the type
 	if hdr.Type != pb.Header_CHAINCODE {
-		return nil, nil, fmt.Errorf("Invalid proposal type %d", hdr.Type)
+		return nil, nil, nil, fmt.Errorf("Invalid proposal type %d", hdr.Type)
 	}
 
 	devopsLogger.Infof("validateProposalMessage info: proposal type %d", hdr.Type)
 
 	//    - ensure that there is a nonce and a creator
 	if hdr.Nonce == nil || len(hdr.Nonce) == 0 {
-		return nil, nil, fmt.Errorf("Invalid nonce specified in the header")
+		return nil, nil, nil, fmt.Errorf("Invalid nonce specified in the header")
 	}
 	if hdr.Creator == nil || len(hdr.Creator) == 0 {
-		return nil, nil, fmt.Errorf("Invalid creator specified in the header")
+		return nil, nil, nil, fmt.Errorf("Invalid creator specified in the header")
 	}
 
 	//    - ensure that creator is a valid certificate (depends on membership svc)
@@ -305,32 +498,74 @@ func (e *Endorser) validateProposalMessage(prop *pb.Proposal) (*pb.Header, *pb.C
 	// 2) perform a check against replay attacks
 	// TODO
 
-	// 3) validate the signature of creator on header and payload
-	// TODO: We need MSP APIs for this
+	// 3) validate the signature of creator on header and payload: the ACLs
+	//    compare the creator with the identities they list, which only
+	//    identifies the client once it proved it holds the creator key
+	if err = putils.VerifyProposalSignature(signedProp, hdr.Creator); err != nil {
+		return nil, nil, nil, fmt.Errorf("Invalid signature of the proposal: %s", err)
+	}
 
 	// validation of the proposal message knowing it's of type CHAINCODE
 	chaincodeHdrExt, err := e.validateChaincodeProposalMessage(prop, hdr)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
+	}
+
+	return prop, hdr, chaincodeHdrExt, err
+}
+
+// simulationErrorResponse returns the response to a proposal whose simulation
+// failed. The statuses the client acts upon come with no error, as gRPC drops
+// the response of a call returning an error
+func simulationErrorResponse(err error) (*pb.ProposalResponse, error) {
+	if ccErr, ok := err.(*chaincode.ChaincodeError); ok {
+		// the chaincode itself failed the proposal: this is not an error of
+		// the endorser, the client gets the status set by the chaincode
+		return &pb.ProposalResponse{Response: &pb.Response2{Status: ccErr.Status, Message: ccErr.Message}}, nil
+	} else if chaincode.IsChaincodeUnavailable(err) {
+		// the chaincode is down or being relaunched, the client may retry the proposal
+		return &pb.ProposalResponse{Response: &pb.Response2{Status: 503, Message: err.Error()}}, nil
+	} else if chaincode.IsChaincodeTimeout(err) {
+		// the chaincode did not complete within its execute timeout
+		return &pb.ProposalResponse{Response: &pb.Response2{Status: 504, Message: err.Error()}}, nil
 	}
+	return &pb.ProposalResponse{Response: &pb.Response2{Status: 500, Message: err.Error()}}, err
+}
 
-	return hdr, chaincodeHdrExt, err
+// ProcessProposal process the Proposal, once its signature is verified
+// against the certificate of its creator
+func (e *Endorser) ProcessProposal(ctx context.Context, signedProp *pb.SignedProposal) (*pb.ProposalResponse, error) {
+	e.metrics.received.Add(1)
+	start := time.Now()
+	span, ctx := tracing.StartSpan(ctx, "endorser.ProcessProposal")
+	resp, err := e.processProposal(ctx, signedProp)
+	if prop, propErr := putils.GetProposal(signedProp); span != nil && propErr == nil {
+		if hdr, hdrErr := putils.GetHeader(prop); hdrErr == nil {
+			span.SetTag("chainID", string(hdr.ChainID))
+		}
+	}
+	span.Finish(err)
+	e.metrics.observe(start, resp)
+	return resp, err
 }
 
-// ProcessProposal process the Proposal
-func (e *Endorser) ProcessProposal(ctx context.Context, prop *pb.Proposal) (*pb.ProposalResponse, error) {
+func (e *Endorser) processProposal(ctx context.Context, signedProp *pb.SignedProposal) (*pb.ProposalResponse, error) {
 	// at first, we check whether the message is valid
 	// TODO: Do the checks performed by this function belong here or in the ESCC? From a security standpoint they should be performed as early as possible so here seems to be a good place
-	_, hdrExt, err := e.validateProposalMessage(prop)
+	prop, hdr, hdrExt, err := e.validateProposalMessage(signedProp)
+	if err != nil {
+		return &pb.ProposalResponse{Response: &pb.Response2{Status: 500, Message: err.Error()}}, err
+	}
+
+	// the proposal is simulated and endorsed on the ledger of its chain
+	chainID, err := getChainID(hdr)
 	if err != nil {
 		return &pb.ProposalResponse{Response: &pb.Response2{Status: 500, Message: err.Error()}}, err
 	}
 
 	// obtaining once the tx simulator for this proposal
 	var txsim ledger.TxSimulator
-	//TODO - get chainname from the proposal when defined
-	chainName := string(chaincode.DefaultChain)
-	if txsim, err = e.getTxSimulator(chainName); err != nil {
+	if txsim, err = e.getTxSimulator(chainID); err != nil {
 		return &pb.ProposalResponse{Response: &pb.Response2{Status: 500, Message: err.Error()}}, err
 	}
 	defer txsim.Done()
@@ -340,22 +575,27 @@ func (e *Endorser) ProcessProposal(ctx context.Context, prop *pb.Proposal) (*pb.
 	//       we're trying to emulate a submitting peer. On the other hand, we need
 	//       to validate the supplied action before endorsing it
 
-	//1 -- simulate
-	//TODO what do we do with response ? We need it for Invoke responses for sure
-	//Which field in PayloadResponse will carry return value ?
-	_, simulationResult, ccevent, err := e.simulateProposal(ctx, prop, hdrExt.ChaincodeID, txsim)
+	//1 -- simulate, passing the signed proposal along so that the chaincode can verify it
+	ctx = context.WithValue(ctx, chaincode.SignedProposalKey, signedProp)
+	res, simulationResult, ccevent, err := e.simulateProposal(ctx, chainID, prop, hdrExt.ChaincodeID, txsim)
+	if err != nil {
+		return simulationErrorResponse(err)
+	}
+
+	//2 -- disseminate the private data, only its hashes are endorsed
+	privateData, err := e.disseminatePrivateData(ctx, chainID, prop, hdrExt.ChaincodeID, txsim)
 	if err != nil {
 		return &pb.ProposalResponse{Response: &pb.Response2{Status: 500, Message: err.Error()}}, err
 	}
 
-	//2 -- endorse and get a marshalled ProposalResponse message
-	//TODO what do we do with response ? We need it for Invoke responses for sure
-	prBytes, err := e.endorseProposal(ctx, prop, simulationResult, ccevent, hdrExt.PayloadVisibility, hdrExt.ChaincodeID, txsim)
+	//3 -- endorse and get a marshalled ProposalResponse message
+	response := &pb.Response2{Status: shim.OK, Message: "OK", Payload: res}
+	prBytes, err := e.endorseProposal(ctx, chainID, prop, response, simulationResult, ccevent, hdrExt.PayloadVisibility, hdrExt.ChaincodeID, txsim, privateData)
 	if err != nil {
 		return &pb.ProposalResponse{Response: &pb.Response2{Status: 500, Message: err.Error()}}, err
 	}
 
-	//3 -- respond
+	//4 -- respond
 	pResp, err := putils.GetProposalResponse(prBytes)
 	if err != nil {
 		return nil, err
@@ -364,11 +604,50 @@ func (e *Endorser) ProcessProposal(ctx context.Context, prop *pb.Proposal) (*pb.
 	return pResp, nil
 }
 
+// defaultMaxProposalSize is the size of the largest proposal reassembled from
+// chunks when "peer.maxProposalSize" is not set
+const defaultMaxProposalSize = 100 * 1024 * 1024
+
+// ProcessProposalStream reassembles a signed proposal sent as a stream of chunks,
+// checking its size and hash, and processes it
+func (e *Endorser) ProcessProposalStream(stream pb.Endorser_ProcessProposalStreamServer) error {
+	maxSize := viper.GetInt("peer.maxProposalSize")
+	if maxSize <= 0 {
+		maxSize = defaultMaxProposalSize
+	}
+
+	assembler := putils.NewProposalAssembler(uint64(maxSize))
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err = assembler.Add(chunk); err != nil {
+			return err
+		}
+	}
+
+	signedProp, err := assembler.GetSignedProposal()
+	if err != nil {
+		return err
+	}
+
+	pResp, err := e.ProcessProposal(stream.Context(), signedProp)
+	if err != nil {
+		return err
+	}
+
+	return stream.SendAndClose(pResp)
+}
+
 // Only exposed for testing purposes - commit the tx simulation so that
 // a deploy transaction is persisted and that chaincode can be invoked.
 // This makes the endorser test self-sufficient
-func (e *Endorser) commitTxSimulation(pResp *pb.ProposalResponse) error {
-	tx, err := putils.CreateTxFromProposalResponse(pResp)
+func (e *Endorser) commitTxSimulation(prop *pb.Proposal, pResp *pb.ProposalResponse) error {
+	tx, err := putils.CreateTxFromProposalResponse(prop.Header, pResp)
 	if err != nil {
 		return err
 	}
@@ -17,6 +17,7 @@ limitations under the License.
 package endorser
 
 import (
+	"crypto/ecdsa"
 	"fmt"
 	"net"
 	"os"
@@ -27,6 +28,7 @@ import (
 
 	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric/core/chaincode"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
 	"github.com/hyperledger/fabric/core/container"
 	"github.com/hyperledger/fabric/core/crypto"
 	"github.com/hyperledger/fabric/core/crypto/primitives"
@@ -45,6 +47,10 @@ import (
 var testDBWrapper = db.NewTestDBWrapper()
 var endorserServer pb.EndorserServer
 
+//the identity the proposals of the tests are created and signed by
+var testCreator []byte
+var testCreatorKey *ecdsa.PrivateKey
+
 //initialize peer and start up. If security==enabled, login as vp
 func initPeer() (net.Listener, error) {
 	//start clean
@@ -121,7 +127,21 @@ func closeListenerAndSleep(l net.Listener) {
 //getProposal gets the proposal for the chaincode invocation
 //Currently supported only for Invokes (Queries still go through devops client)
 func getProposal(cis *pb.ChaincodeInvocationSpec) (*pb.Proposal, error) {
-	return pbutils.CreateChaincodeProposal(cis, []byte("cert"))
+	return pbutils.CreateChaincodeProposal(cis, testCreator)
+}
+
+//signProposal signs the proposal with the key of the creator of the tests
+func signProposal(prop *pb.Proposal) (*pb.SignedProposal, error) {
+	return pbutils.SignProposal(prop, testCreatorKey)
+}
+
+//newTestIdentity returns a PEM encoded self-signed certificate and its key
+func newTestIdentity() ([]byte, *ecdsa.PrivateKey, error) {
+	cert, key, err := primitives.NewSelfSignedCert()
+	if err != nil {
+		return nil, nil, err
+	}
+	return primitives.DERCertToPEM(cert), key.(*ecdsa.PrivateKey), nil
 }
 
 //getDeployProposal gets the proposal for the chaincode deployment
@@ -148,14 +168,14 @@ func getDeploymentSpec(context context.Context, spec *pb.ChaincodeSpec) (*pb.Cha
 	return chaincodeDeploymentSpec, nil
 }
 
-func deploy(endorserServer pb.EndorserServer, spec *pb.ChaincodeSpec, f func(*pb.ChaincodeDeploymentSpec)) (*pb.ProposalResponse, error) {
+func deploy(endorserServer pb.EndorserServer, spec *pb.ChaincodeSpec, f func(*pb.ChaincodeDeploymentSpec)) (*pb.Proposal, *pb.ProposalResponse, error) {
 	var err error
 	var depSpec *pb.ChaincodeDeploymentSpec
 
 	ctxt := context.Background()
 	depSpec, err = getDeploymentSpec(ctxt, spec)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	if f != nil {
@@ -165,13 +185,21 @@ func deploy(endorserServer pb.EndorserServer, spec *pb.ChaincodeSpec, f func(*pb
 	var prop *pb.Proposal
 	prop, err = getDeployProposal(depSpec)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	signedProp, err := signProposal(prop)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	var resp *pb.ProposalResponse
-	resp, err = endorserServer.ProcessProposal(context.Background(), prop)
+	resp, err = endorserServer.ProcessProposal(context.Background(), signedProp)
+	if err == nil && resp.Response.Status >= shim.ERRORTHRESHOLD {
+		err = fmt.Errorf("deploy failed with status %d: %s", resp.Response.Status, resp.Response.Message)
+	}
 
-	return resp, err
+	return prop, resp, err
 }
 
 func invoke(spec *pb.ChaincodeSpec) (*pb.ProposalResponse, error) {
@@ -183,10 +211,18 @@ func invoke(spec *pb.ChaincodeSpec) (*pb.ProposalResponse, error) {
 		return nil, fmt.Errorf("Error creating proposal  %s: %s\n", spec.ChaincodeID, err)
 	}
 
-	resp, err := endorserServer.ProcessProposal(context.Background(), prop)
+	signedProp, err := signProposal(prop)
+	if err != nil {
+		return nil, fmt.Errorf("Error signing proposal %s: %s\n", spec.ChaincodeID, err)
+	}
+
+	resp, err := endorserServer.ProcessProposal(context.Background(), signedProp)
 	if err != nil {
 		return nil, fmt.Errorf("Error endorsing %s: %s\n", spec.ChaincodeID, err)
 	}
+	if resp.Response.Status >= shim.ERRORTHRESHOLD {
+		return nil, fmt.Errorf("Error invoking %s: status %d, %s\n", spec.ChaincodeID, resp.Response.Status, resp.Response.Message)
+	}
 
 	return resp, err
 }
@@ -200,7 +236,7 @@ func invoke(spec *pb.ChaincodeSpec) (*pb.ProposalResponse, error) {
 func TestDeploy(t *testing.T) {
 	spec := &pb.ChaincodeSpec{Type: 1, ChaincodeID: &pb.ChaincodeID{Name: "ex01", Path: "github.com/hyperledger/fabric/examples/chaincode/go/chaincode_example01"}, CtorMsg: &pb.ChaincodeInput{Args: [][]byte{[]byte("init"), []byte("a"), []byte("100"), []byte("b"), []byte("200")}}}
 
-	_, err := deploy(endorserServer, spec, nil)
+	_, _, err := deploy(endorserServer, spec, nil)
 	if err != nil {
 		t.Fail()
 		t.Logf("Deploy-error in deploy %s", err)
@@ -215,7 +251,7 @@ func TestDeployBadArgs(t *testing.T) {
 	//invalid arguments
 	spec := &pb.ChaincodeSpec{Type: 1, ChaincodeID: &pb.ChaincodeID{Name: "ex02", Path: "github.com/hyperledger/fabric/examples/chaincode/go/chaincode_example02"}, CtorMsg: &pb.ChaincodeInput{Args: [][]byte{[]byte("init"), []byte("a"), []byte("100"), []byte("b")}}}
 
-	_, err := deploy(endorserServer, spec, nil)
+	_, _, err := deploy(endorserServer, spec, nil)
 	if err == nil {
 		t.Fail()
 		t.Log("DeployBadArgs-expected error in deploy but succeeded")
@@ -233,7 +269,7 @@ func TestDeployBadPayload(t *testing.T) {
 	f := func(cds *pb.ChaincodeDeploymentSpec) {
 		cds.CodePackage = nil
 	}
-	_, err := deploy(endorserServer, spec, f)
+	_, _, err := deploy(endorserServer, spec, f)
 	if err == nil {
 		t.Fail()
 		t.Log("DeployBadPayload-expected error in deploy but succeeded")
@@ -249,7 +285,7 @@ func TestRedeploy(t *testing.T) {
 	//invalid arguments
 	spec := &pb.ChaincodeSpec{Type: 1, ChaincodeID: &pb.ChaincodeID{Name: "ex02", Path: "github.com/hyperledger/fabric/examples/chaincode/go/chaincode_example02"}, CtorMsg: &pb.ChaincodeInput{Args: [][]byte{[]byte("init"), []byte("a"), []byte("100"), []byte("b"), []byte("200")}}}
 
-	_, err := deploy(endorserServer, spec, nil)
+	_, _, err := deploy(endorserServer, spec, nil)
 	if err != nil {
 		t.Fail()
 		t.Logf("error in endorserServer.ProcessProposal %s", err)
@@ -258,7 +294,7 @@ func TestRedeploy(t *testing.T) {
 	}
 
 	//second time should not fail as we are just simulating
-	_, err = deploy(endorserServer, spec, nil)
+	_, _, err = deploy(endorserServer, spec, nil)
 	if err != nil {
 		t.Fail()
 		t.Logf("error in endorserServer.ProcessProposal %s", err)
@@ -280,7 +316,7 @@ func TestDeployAndInvoke(t *testing.T) {
 	f := "init"
 	argsDeploy := util.ToChaincodeArgs(f, "a", "100", "b", "200")
 	spec := &pb.ChaincodeSpec{Type: 1, ChaincodeID: chaincodeID, CtorMsg: &pb.ChaincodeInput{Args: argsDeploy}}
-	resp, err := deploy(endorserServer, spec, nil)
+	prop, resp, err := deploy(endorserServer, spec, nil)
 	chaincodeID1 := spec.ChaincodeID.Name
 	if err != nil {
 		t.Fail()
@@ -288,7 +324,7 @@ func TestDeployAndInvoke(t *testing.T) {
 		return
 	}
 
-	err = endorserServer.(*Endorser).commitTxSimulation(resp)
+	err = endorserServer.(*Endorser).commitTxSimulation(prop, resp)
 	if err != nil {
 		t.Fail()
 		t.Logf("Error committing <%s>: %s", chaincodeID1, err)
@@ -311,6 +347,105 @@ func TestDeployAndInvoke(t *testing.T) {
 	chaincode.GetChain(chaincode.DefaultChain).Stop(ctxt, &pb.ChaincodeDeploymentSpec{ChaincodeSpec: &pb.ChaincodeSpec{ChaincodeID: chaincodeID}})
 }
 
+//TestGetChainID checks that proposals target the default chain unless they
+//name a chain the peer has a ledger of
+func TestGetChainID(t *testing.T) {
+	chainID, err := getChainID(&pb.Header{})
+	if err != nil || chainID != string(chaincode.DefaultChain) {
+		t.Fatalf("Expected the default chain for a header with no chain, got %s, %v", chainID, err)
+	}
+
+	if _, err = getChainID(&pb.Header{ChainID: []byte("notjoined")}); err == nil {
+		t.Fatalf("Expected an error for a chain the peer has not joined")
+	}
+
+	kvledger.GetLedger("joinedchain")
+	chainID, err = getChainID(&pb.Header{ChainID: []byte("joinedchain")})
+	if err != nil || chainID != "joinedchain" {
+		t.Fatalf("Expected chain joinedchain, got %s, %v", chainID, err)
+	}
+}
+
+//TestProcessProposalMalformedSpec checks that proposals whose invocation spec
+//lacks the chaincode spec, its chaincode ID or its input are rejected
+func TestProcessProposalMalformedSpec(t *testing.T) {
+	specs := []*pb.ChaincodeInvocationSpec{
+		{},
+		{ChaincodeSpec: &pb.ChaincodeSpec{Type: pb.ChaincodeSpec_GOLANG, CtorMsg: &pb.ChaincodeInput{Args: [][]byte{[]byte("invoke")}}}},
+		{ChaincodeSpec: &pb.ChaincodeSpec{Type: pb.ChaincodeSpec_GOLANG, ChaincodeID: &pb.ChaincodeID{Name: "lccc"}}},
+	}
+	validSpec := &pb.ChaincodeInvocationSpec{ChaincodeSpec: &pb.ChaincodeSpec{Type: pb.ChaincodeSpec_GOLANG, ChaincodeID: &pb.ChaincodeID{Name: "ex02"}, CtorMsg: &pb.ChaincodeInput{}}}
+	for i, cis := range specs {
+		//the proposal is created for a valid spec, then given the malformed one
+		prop, err := getProposal(validSpec)
+		if err != nil {
+			t.Fatalf("Error creating proposal %d: %s", i, err)
+		}
+		cisBytes, _ := proto.Marshal(cis)
+		if prop.Payload, err = proto.Marshal(&pb.ChaincodeProposalPayload{Input: cisBytes}); err != nil {
+			t.Fatalf("Error marshalling the payload of proposal %d: %s", i, err)
+		}
+		signedProp, err := signProposal(prop)
+		if err != nil {
+			t.Fatalf("Error signing proposal %d: %s", i, err)
+		}
+		if _, err = endorserServer.ProcessProposal(context.Background(), signedProp); err == nil {
+			t.Fatalf("Expected an error for the malformed proposal %d", i)
+		}
+	}
+}
+
+//TestProcessProposalSignature checks that proposals are only processed when
+//signed by their creator, before any ACL compares the creator
+func TestProcessProposalSignature(t *testing.T) {
+	cis := &pb.ChaincodeInvocationSpec{ChaincodeSpec: &pb.ChaincodeSpec{Type: pb.ChaincodeSpec_GOLANG, ChaincodeID: &pb.ChaincodeID{Name: "lccc"}, CtorMsg: &pb.ChaincodeInput{Args: [][]byte{[]byte("getinstalledchaincodes")}}}}
+	prop, err := getProposal(cis)
+	if err != nil {
+		t.Fatalf("Error creating proposal: %s", err)
+	}
+	propBytes, _ := proto.Marshal(prop)
+
+	//unsigned
+	if _, err = endorserServer.ProcessProposal(context.Background(), &pb.SignedProposal{ProposalBytes: propBytes}); err == nil {
+		t.Fatalf("Expected an unsigned proposal to be refused")
+	}
+
+	//the certificate of the creator pasted by another identity
+	_, otherKey, err := newTestIdentity()
+	if err != nil {
+		t.Fatalf("Error creating identity: %s", err)
+	}
+	forged, err := pbutils.SignProposal(prop, otherKey)
+	if err != nil {
+		t.Fatalf("Error signing proposal: %s", err)
+	}
+	if _, err = endorserServer.ProcessProposal(context.Background(), forged); err == nil {
+		t.Fatalf("Expected a proposal signed by another identity than its creator to be refused")
+	}
+}
+
+func TestSimulationErrorResponse(t *testing.T) {
+	resp, err := simulationErrorResponse(&chaincode.ChaincodeError{Chaincode: "mycc", Status: 404, Message: "not found"})
+	if err != nil || resp.Response.Status != 404 {
+		t.Fatalf("Expected the status of the chaincode with no error, got %v (%v)", resp, err)
+	}
+
+	resp, err = simulationErrorResponse(&chaincode.ChaincodeUnavailableError{Chaincode: "mycc", Reason: "relaunching"})
+	if err != nil || resp.Response.Status != 503 {
+		t.Fatalf("Expected status 503 with no error for an unavailable chaincode, got %v (%v)", resp, err)
+	}
+
+	resp, err = simulationErrorResponse(&chaincode.ChaincodeTimeoutError{Chaincode: "mycc", Timeout: time.Second})
+	if err != nil || resp.Response.Status != 504 {
+		t.Fatalf("Expected status 504 with no error for a chaincode timing out, got %v (%v)", resp, err)
+	}
+
+	resp, err = simulationErrorResponse(fmt.Errorf("failure"))
+	if err == nil || resp.Response.Status != 500 {
+		t.Fatalf("Expected status 500 with the error, got %v (%v)", resp, err)
+	}
+}
+
 func TestMain(m *testing.M) {
 	SetupTestConfig()
 	testDBWrapper.CleanDB(nil)
@@ -323,6 +458,12 @@ func TestMain(m *testing.M) {
 		return
 	}
 
+	if testCreator, testCreatorKey, err = newTestIdentity(); err != nil {
+		fmt.Printf("Could not create the identity of the tests: %s", err)
+		finitPeer(lis)
+		os.Exit(-1)
+	}
+
 	endorserServer = NewEndorserServer(nil)
 	retVal := m.Run()
 
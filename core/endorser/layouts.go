@@ -0,0 +1,180 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endorser
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+
+	"github.com/hyperledger/fabric/core/chaincode"
+	"github.com/hyperledger/fabric/core/discovery"
+	pb "github.com/hyperledger/fabric/protos"
+)
+
+// GetEndorsementLayouts returns the minimal sets of organizations, with their
+// peers, whose endorsements satisfy the endorsement policies of all the
+// chaincodes called and include the organizations whose data the calls touch.
+// Only the organizations sharing every private data collection involved are
+// eligible
+func (e *Endorser) GetEndorsementLayouts(ctx context.Context, req *pb.EndorsementLayoutsRequest) (*pb.EndorsementLayoutsResponse, error) {
+	if len(req.Calls) == 0 {
+		return nil, fmt.Errorf("no chaincode call to compute endorsement layouts for")
+	}
+
+	chainID := req.ChainID
+	if chainID == "" {
+		chainID = string(chaincode.DefaultChain)
+	}
+	if err := checkChain(chainID); err != nil {
+		return nil, err
+	}
+
+	orgs, err := chaincode.GetChainOrganizations(chainID)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	peers := make(map[string][]string)
+	members := make(map[string][][]byte)
+	for _, org := range orgs {
+		names = append(names, org.Name)
+		peers[org.Name] = org.Peers
+		for _, file := range org.Members {
+			identity, err := ioutil.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("error reading member identity of organization %s: %s", org.Name, err)
+			}
+			members[org.Name] = append(members[org.Name], identity)
+		}
+	}
+
+	txsim, err := e.getTxSimulator(chainID)
+	if err != nil {
+		return nil, err
+	}
+	defer txsim.Done()
+	ctxt := context.WithValue(ctx, chaincode.TXSimulatorKey, txsim)
+
+	eligible := names
+	var reqs []*discovery.Requirement
+	for _, call := range req.Calls {
+		r, collections, err := getRequirement(ctxt, chainID, members, call)
+		if err != nil {
+			return nil, err
+		}
+		reqs = append(reqs, r)
+		for _, collection := range collections {
+			eligible = intersect(eligible, collection.Organizations)
+		}
+	}
+
+	layouts, err := discovery.ComputeLayouts(reqs, eligible, peers)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.EndorsementLayoutsResponse{Layouts: layouts}, nil
+}
+
+// getRequirement returns what a call requires of the endorsers according to
+// the endorsement policy and the interests of the chaincode, along with the
+// collections the call involves. The interests of every function of the
+// chaincode apply to a call not naming its function
+func getRequirement(ctxt context.Context, chainID string, members map[string][][]byte, call *pb.ChaincodeCall) (*discovery.Requirement, []*discovery.Collection, error) {
+	policyBytes, err := queryLCCC(ctxt, chainID, chaincode.GETPOLICY, chainID, call.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+	policy, err := discovery.NewEndorsementPolicy(policyBytes, members)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot compute endorsement layouts of %s: %s", call.Name, err)
+	}
+
+	args := []string{chaincode.GETINTERESTS, chainID, call.Name}
+	if call.Function != "" {
+		args = append(args, call.Function)
+	}
+	b, err := queryLCCC(ctxt, chainID, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	interests := &pb.ChaincodeInterests{}
+	if err = proto.Unmarshal(b, interests); err != nil {
+		return nil, nil, err
+	}
+
+	r := &discovery.Requirement{Policy: policy}
+	names := append([]string(nil), call.Collections...)
+	for _, interest := range interests.Interests {
+		r.Organizations = append(r.Organizations, interest.Organizations...)
+		names = append(names, interest.Collections...)
+	}
+	if len(names) == 0 {
+		return r, nil, nil
+	}
+
+	b, err = queryLCCC(ctxt, chainID, chaincode.GETDEFINITION, chainID, call.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+	def := &pb.ChaincodeDefinition{}
+	if err = proto.Unmarshal(b, def); err != nil {
+		return nil, nil, err
+	}
+	configured, err := discovery.ParseCollections(def.Collections)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var collections []*discovery.Collection
+	for _, name := range names {
+		collection, ok := configured[name]
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown collection %s of chaincode %s", name, call.Name)
+		}
+		collections = append(collections, collection)
+	}
+
+	return r, collections, nil
+}
+
+// queryLCCC calls lccc of the chain with the given arguments
+func queryLCCC(ctxt context.Context, chainID string, args ...string) ([]byte, error) {
+	var input [][]byte
+	for _, arg := range args {
+		input = append(input, []byte(arg))
+	}
+	payload, _, err := chaincode.ExecuteChaincode(ctxt, pb.Transaction_CHAINCODE_INVOKE, chainID, "lccc", input)
+	return payload, err
+}
+
+// intersect returns the organizations of orgs also in others
+func intersect(orgs []string, others []string) []string {
+	var res []string
+	for _, org := range orgs {
+		for _, other := range others {
+			if org == other {
+				res = append(res, org)
+				break
+			}
+		}
+	}
+	return res
+}
@@ -0,0 +1,56 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endorser
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/hyperledger/fabric/core/metrics"
+	pb "github.com/hyperledger/fabric/protos"
+)
+
+// endorserMetrics are the metrics of the proposals processed by the endorser
+type endorserMetrics struct {
+	received metrics.Counter
+	duration metrics.Histogram
+}
+
+func newEndorserMetrics(p metrics.Provider) *endorserMetrics {
+	return &endorserMetrics{
+		received: p.NewCounter(metrics.CounterOpts{
+			Namespace: "endorser",
+			Name:      "proposals_received",
+			Help:      "The number of proposals received.",
+		}),
+		duration: p.NewHistogram(metrics.HistogramOpts{
+			Namespace:  "endorser",
+			Name:       "proposal_duration",
+			Help:       "The time to process a proposal, in seconds, by response status.",
+			LabelNames: []string{"status"},
+		}),
+	}
+}
+
+// observe records a proposal processed since start, answered with resp
+func (m *endorserMetrics) observe(start time.Time, resp *pb.ProposalResponse) {
+	status := "none"
+	if resp != nil && resp.Response != nil {
+		status = strconv.Itoa(int(resp.Response.Status))
+	}
+	m.duration.With("status", status).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,144 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endorser
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+
+	"github.com/hyperledger/fabric/core/chaincode"
+	"github.com/hyperledger/fabric/core/discovery"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/peer"
+	gproto "github.com/hyperledger/fabric/gossip/proto"
+	"github.com/hyperledger/fabric/gossip/service"
+	pb "github.com/hyperledger/fabric/protos"
+	putils "github.com/hyperledger/fabric/protos/utils"
+)
+
+// getPrivateData returns the private data of a proposal, held in its transient
+// map, keyed by collection
+func getPrivateData(prop *pb.Proposal) (map[string][]byte, error) {
+	cpp := &pb.ChaincodeProposalPayload{}
+	if err := proto.Unmarshal(prop.Payload, cpp); err != nil {
+		return nil, err
+	}
+
+	privateData := make(map[string][]byte)
+	for key, value := range cpp.TransientMap {
+		if strings.HasPrefix(key, putils.PrivateDataTransientKeyPrefix) {
+			privateData[strings.TrimPrefix(key, putils.PrivateDataTransientKeyPrefix)] = value
+		}
+	}
+	return privateData, nil
+}
+
+// getPrivateDataTxID returns the identifier of the private data of a proposal,
+// the identifier of its transaction
+func getPrivateDataTxID(prop *pb.Proposal) string {
+	return putils.ComputeTxID(prop.Header)
+}
+
+// disseminatePrivateData sends the private data of a proposal to the peers of
+// the organizations of each collection, according to the dissemination policy of
+// the collection, and returns the hashes of the private data which are endorsed
+// in its place. The endorser keeps the private data of the collections of its
+// organization
+func (e *Endorser) disseminatePrivateData(ctx context.Context, chainID string, prop *pb.Proposal, ccid *pb.ChaincodeID, txsim ledger.TxSimulator) (*pb.PrivateDataHashes, error) {
+	privateData, err := getPrivateData(prop)
+	if err != nil {
+		return nil, err
+	}
+	if len(privateData) == 0 {
+		return nil, nil
+	}
+
+	g := service.GetGossipService()
+	if g == nil {
+		return nil, fmt.Errorf("private data cannot be disseminated, gossip is disabled")
+	}
+
+	ctxt := context.WithValue(ctx, chaincode.TXSimulatorKey, txsim)
+	b, err := queryLCCC(ctxt, chainID, chaincode.GETDEFINITION, chainID, ccid.Name)
+	if err != nil {
+		return nil, fmt.Errorf("chaincode %s has no definition declaring private data collections", ccid.Name)
+	}
+	def := &pb.ChaincodeDefinition{}
+	if err = proto.Unmarshal(b, def); err != nil {
+		return nil, err
+	}
+	collections, err := discovery.ParseCollections(def.Collections)
+	if err != nil {
+		return nil, err
+	}
+
+	orgs, err := chaincode.GetChainOrganizations(chainID)
+	if err != nil {
+		return nil, err
+	}
+	self, err := peer.GetPeerEndpoint()
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for name := range privateData {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	txID := getPrivateDataTxID(prop)
+	hashes := &pb.PrivateDataHashes{}
+	for _, name := range names {
+		collection, ok := collections[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown collection %s of chaincode %s", name, ccid.Name)
+		}
+
+		var peers []string
+		member := false
+		for _, org := range orgs {
+			if len(intersect([]string{org.Name}, collection.Organizations)) == 0 {
+				continue
+			}
+			for _, p := range org.Peers {
+				if p == self.Address {
+					member = true
+				} else {
+					peers = append(peers, p)
+				}
+			}
+		}
+
+		data := privateData[name]
+		if member {
+			g.StorePrivateData(&gproto.PrivateDataMessage{ChainID: []byte(chainID), TxID: txID, Collection: name, Data: data})
+		}
+		if err = service.DisseminatePrivateData(g, chainID, txID, name, data, peers, collection.RequiredPeerCount, collection.MaxPeerCount); err != nil {
+			return nil, err
+		}
+
+		hash := sha256.Sum256(data)
+		hashes.Hashes = append(hashes.Hashes, &pb.PrivateDataHash{Collection: name, Hash: hash[:]})
+	}
+	return hashes, nil
+}
@@ -18,6 +18,7 @@ package blkstorage
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/hyperledger/fabric/core/ledger"
 	"github.com/hyperledger/fabric/protos"
@@ -31,6 +32,7 @@ const (
 	IndexableAttrBlockNum  = IndexableAttr("BlockNum")
 	IndexableAttrBlockHash = IndexableAttr("BlockHash")
 	IndexableAttrTxID      = IndexableAttr("TxID")
+	IndexableAttrBlockTxID = IndexableAttr("BlockTxID")
 )
 
 // IndexConfig - a configuration that includes a list of attributes that should be indexed
@@ -55,5 +57,60 @@ type BlockStore interface {
 	RetrieveBlockByHash(blockHash []byte) (*protos.Block2, error)
 	RetrieveBlockByNumber(blockNum uint64) (*protos.Block2, error)
 	RetrieveTxByID(txID string) (*protos.Transaction2, error)
+	RetrieveBlockByTxID(txID string) (*protos.Block2, error)
 	Shutdown()
 }
+
+// EncryptionKeyRotator - an optional interface that a block store implements if it stores the blocks encrypted.
+// RotateEncryptionKey switches to a new key for the blocks added from then on; existing blocks remain readable
+type EncryptionKeyRotator interface {
+	RotateEncryptionKey() error
+}
+
+// Verifier - an optional interface that a block store implements if it can verify the integrity of the stored blocks.
+// VerifyBlocks returns the number of blocks verified and a `*CorruptBlockError` for the first corrupt block
+type Verifier interface {
+	VerifyBlocks() (uint64, error)
+}
+
+// Truncater - an optional interface that a block store implements if it can remove the blocks
+// after a given block so that the given block becomes the last block in the block store
+type Truncater interface {
+	TruncateToBlock(blockNum uint64) error
+}
+
+// LocalMetadataStore - an optional interface that a block store implements if it can keep, next to a block,
+// metadata that the peer computes locally for the block. The local metadata is not part of the block
+type LocalMetadataStore interface {
+	SetLocalMetadata(blockNum uint64, metadata []byte) error
+	// GetLocalMetadata returns the local metadata of the block, nil if none has been set
+	GetLocalMetadata(blockNum uint64) ([]byte, error)
+}
+
+// TxValidationCodeStore - an optional interface that a block store implements if it can keep the validation codes
+// of the transactions of a block, by transaction id, including those of the invalid transactions that were removed
+// from the block before it was added
+type TxValidationCodeStore interface {
+	// SetTxValidationCodes records the validation code codes[i] of the transaction txIDs[i] of block `blockNum`.
+	// The code recorded first for a transaction id is kept, e.g. for a transaction resubmitted with the same id
+	SetTxValidationCodes(blockNum uint64, txIDs []string, codes []protos.TxValidationCode) error
+	// GetTxValidationCode returns the validation code of the transaction, `ErrNotFoundInIndex` if none has been set
+	GetTxValidationCode(txID string) (protos.TxValidationCode, error)
+}
+
+// IndexCompacter - an optional interface that a block store implements if it can compact its index database,
+// so that the space held by deleted and overwritten entries is reclaimed
+type IndexCompacter interface {
+	// CompactIndex returns the size in bytes of the index database before and after the compaction
+	CompactIndex() (int64, int64, error)
+}
+
+// CorruptBlockError is used to indicate the first block that failed the verification of a block store
+type CorruptBlockError struct {
+	BlockNum uint64
+	Reason   string
+}
+
+func (e *CorruptBlockError) Error() string {
+	return fmt.Sprintf("Block [%d] is corrupt: %s", e.BlockNum, e.Reason)
+}
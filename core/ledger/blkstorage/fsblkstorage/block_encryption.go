@@ -0,0 +1,177 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fsblkstorage
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/core/ledger/util/db"
+	"github.com/hyperledger/fabric/core/ledger/util/encryption"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+const (
+	blockKeyPrefix = 'k'
+	blockKeySize   = 32
+)
+
+var (
+	currentBlockKeyIDKey = []byte("blkKeyInfo")
+)
+
+// blockEncrypter encrypts the blocks with AES-GCM before they are appended to the block files.
+// Each block is encrypted with a data-encryption key (DEK). The DEKs are wrapped with the
+// key-encryption key held by the peer (`Conf.Encrypter`) and kept in the block store db, so the
+// DEK used for new blocks can be rotated without re-encrypting the blocks written earlier.
+// An encrypted block is stored as - varint(id of the DEK) + nonce + ciphertext
+type blockEncrypter struct {
+	db           *db.DB
+	kek          encryption.Encrypter
+	lock         sync.RWMutex
+	currentKeyID uint64
+	aeads        map[uint64]cipher.AEAD
+}
+
+func newBlockEncrypter(db *db.DB, kek encryption.Encrypter) (*blockEncrypter, error) {
+	e := &blockEncrypter{db: db, kek: kek, aeads: make(map[uint64]cipher.AEAD)}
+	keyIDBytes, err := db.Get(currentBlockKeyIDKey)
+	if err != nil {
+		return nil, err
+	}
+	if keyIDBytes == nil {
+		// first use of encryption for this block store
+		if _, err = e.rotateKey(); err != nil {
+			return nil, err
+		}
+		return e, nil
+	}
+	e.currentKeyID, _ = proto.DecodeVarint(keyIDBytes)
+	if _, err = e.getAEAD(e.currentKeyID); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// rotateKey generates a new DEK that is used for encrypting the blocks added from now on
+// and returns its id. Blocks encrypted with the earlier DEKs remain readable
+func (e *blockEncrypter) rotateKey() (uint64, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	key := make([]byte, blockKeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return 0, fmt.Errorf("Error generating block encryption key: %s", err)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return 0, err
+	}
+	wrappedKey, err := e.kek.Encrypt(key)
+	if err != nil {
+		return 0, fmt.Errorf("Error wrapping block encryption key: %s", err)
+	}
+	keyID := e.currentKeyID + 1
+	batch := &leveldb.Batch{}
+	batch.Put(constructBlockKeyKey(keyID), wrappedKey)
+	batch.Put(currentBlockKeyIDKey, proto.EncodeVarint(keyID))
+	if err = e.db.WriteBatch(batch, true); err != nil {
+		return 0, err
+	}
+	e.aeads[keyID] = aead
+	e.currentKeyID = keyID
+	logger.Debugf("Rotated block encryption key. Current key id=[%d]", keyID)
+	return keyID, nil
+}
+
+func (e *blockEncrypter) encrypt(blockBytes []byte) ([]byte, error) {
+	e.lock.RLock()
+	keyID := e.currentKeyID
+	aead := e.aeads[keyID]
+	e.lock.RUnlock()
+	keyIDBytes := proto.EncodeVarint(keyID)
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	record := append(keyIDBytes, nonce...)
+	// the key id is authenticated along with the block bytes
+	return aead.Seal(record, nonce, blockBytes, keyIDBytes), nil
+}
+
+func (e *blockEncrypter) decrypt(record []byte) ([]byte, error) {
+	keyID, n := proto.DecodeVarint(record)
+	if n == 0 {
+		return nil, fmt.Errorf("Error in decoding block encryption key id")
+	}
+	aead, err := e.getAEAD(keyID)
+	if err != nil {
+		return nil, err
+	}
+	if len(record) < n+aead.NonceSize() {
+		return nil, fmt.Errorf("Encrypted block is too short")
+	}
+	nonce := record[n : n+aead.NonceSize()]
+	blockBytes, err := aead.Open(nil, nonce, record[n+aead.NonceSize():], record[:n])
+	if err != nil {
+		return nil, fmt.Errorf("Error decrypting block: %s", err)
+	}
+	return blockBytes, nil
+}
+
+func (e *blockEncrypter) getAEAD(keyID uint64) (cipher.AEAD, error) {
+	e.lock.RLock()
+	aead, ok := e.aeads[keyID]
+	e.lock.RUnlock()
+	if ok {
+		return aead, nil
+	}
+	wrappedKey, err := e.db.Get(constructBlockKeyKey(keyID))
+	if err != nil {
+		return nil, err
+	}
+	if wrappedKey == nil {
+		return nil, fmt.Errorf("Block encryption key [%d] not found", keyID)
+	}
+	key, err := e.kek.Decrypt(wrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("Error unwrapping block encryption key [%d]: %s", keyID, err)
+	}
+	if aead, err = newAEAD(key); err != nil {
+		return nil, err
+	}
+	e.lock.Lock()
+	e.aeads[keyID] = aead
+	e.lock.Unlock()
+	return aead, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func constructBlockKeyKey(keyID uint64) []byte {
+	return append([]byte{blockKeyPrefix}, proto.EncodeVarint(keyID)...)
+}
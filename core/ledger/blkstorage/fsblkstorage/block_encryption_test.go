@@ -0,0 +1,97 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fsblkstorage
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/core/ledger/testutil"
+	"github.com/hyperledger/fabric/protos"
+	putils "github.com/hyperledger/fabric/protos/utils"
+)
+
+// xorEncrypter is a trivial key-encryption key for the tests
+type xorEncrypter struct{}
+
+func (e *xorEncrypter) Encrypt(plaintext []byte) ([]byte, error) {
+	ciphertext := make([]byte, len(plaintext))
+	for i, b := range plaintext {
+		ciphertext[i] = b ^ 0xff
+	}
+	return ciphertext, nil
+}
+
+func (e *xorEncrypter) Decrypt(ciphertext []byte) ([]byte, error) {
+	return e.Encrypt(ciphertext)
+}
+
+func TestBlockfileMgrEncryption(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.Cleanup()
+	env.conf.Encrypter = &xorEncrypter{}
+	blkfileMgrWrapper := newTestBlockfileWrapper(t, env)
+	blocks := testutil.ConstructTestBlocks(t, 10)
+	blkfileMgrWrapper.addBlocks(blocks[:5])
+	err := blkfileMgrWrapper.blockfileMgr.rotateEncryptionKey()
+	testutil.AssertNoError(t, err, "Error while rotating block encryption key")
+	testutil.AssertEquals(t, blkfileMgrWrapper.blockfileMgr.encrypter.currentKeyID, uint64(2))
+	blkfileMgrWrapper.addBlocks(blocks[5:])
+
+	blockfileBytes, err := ioutil.ReadFile(deriveBlockfilePath(env.conf.blockfilesDir, 0))
+	testutil.AssertNoError(t, err, "Error while reading block file")
+	testutil.AssertSame(t, bytes.Contains(blockfileBytes, blocks[0].Transactions[0]), false)
+
+	blkfileMgrWrapper.testGetBlockByHash(blocks)
+	blkfileMgrWrapper.testGetBlockByNumber(blocks, 1)
+	testBlockfileMgrBlockIterator(t, blkfileMgrWrapper.blockfileMgr, 1, 10, blocks)
+	testEncryptedBlocksTxByID(t, blkfileMgrWrapper.blockfileMgr, blocks)
+	blkfileMgrWrapper.close()
+
+	// blocks written with both keys remain readable after a restart
+	blkfileMgrWrapper = newTestBlockfileWrapper(t, env)
+	defer blkfileMgrWrapper.close()
+	testutil.AssertEquals(t, blkfileMgrWrapper.blockfileMgr.encrypter.currentKeyID, uint64(2))
+	blkfileMgrWrapper.testGetBlockByHash(blocks)
+	testEncryptedBlocksTxByID(t, blkfileMgrWrapper.blockfileMgr, blocks)
+}
+
+func testEncryptedBlocksTxByID(t *testing.T, blockfileMgr *blockfileMgr, blocks []*protos.Block2) {
+	for _, blk := range blocks {
+		for _, txBytes := range blk.Transactions {
+			tx := &protos.Transaction2{}
+			err := proto.Unmarshal(txBytes, tx)
+			testutil.AssertNoError(t, err, "Error while unmarshalling tx")
+			txID, err := putils.GetTxID(tx)
+			testutil.AssertNoError(t, err, "Error while getting tx id")
+			txFromFileMgr, err := blockfileMgr.retrieveTransactionByID(txID)
+			testutil.AssertNoError(t, err, "Error while retrieving tx from blkfileMgr")
+			testutil.AssertEquals(t, txFromFileMgr, tx)
+		}
+	}
+}
+
+func TestBlockfileMgrRotateKeyWithoutEncryption(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.Cleanup()
+	blkfileMgrWrapper := newTestBlockfileWrapper(t, env)
+	defer blkfileMgrWrapper.close()
+	err := blkfileMgrWrapper.blockfileMgr.rotateEncryptionKey()
+	testutil.AssertError(t, err, "Expected error when block encryption is not enabled")
+}
@@ -0,0 +1,117 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fsblkstorage
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/hyperledger/fabric/protos"
+)
+
+// truncateToBlock removes all the blocks after the block `blockNum` from the block files and
+// the index so that `blockNum` becomes the last block in the block store. This is expected to
+// be invoked only when no other goroutine is reading or adding blocks
+func (mgr *blockfileMgr) truncateToBlock(blockNum uint64) error {
+	if blockNum >= mgr.cpInfo.lastBlockNumber {
+		return fmt.Errorf("Cannot truncate to block [%d], the last block in the block store is [%d]",
+			blockNum, mgr.cpInfo.lastBlockNumber)
+	}
+	stream, err := newBlockStream(mgr.rootDir, 0, 0, mgr.cpInfo.latestFileChunkSuffixNum)
+	if err != nil {
+		return err
+	}
+	defer stream.close()
+
+	// the placement of the first block to be removed decides where the block files are truncated
+	var truncatePlacementInfo *blockPlacementInfo
+	var removedBlocks []*blockIdxInfo
+	for currentBlockNum := uint64(1); ; currentBlockNum++ {
+		blockBytes, placementInfo, err := stream.nextBlockBytesAndPlacementInfo()
+		if err != nil {
+			return err
+		}
+		if blockBytes == nil {
+			break
+		}
+		if currentBlockNum <= blockNum {
+			continue
+		}
+		if truncatePlacementInfo == nil {
+			truncatePlacementInfo = placementInfo
+		}
+		if blockBytes, err = mgr.decryptBlockBytes(blockBytes); err != nil {
+			return err
+		}
+		serBlock := protos.NewSerBlock2(blockBytes)
+		txOffsets, err := serBlock.GetTxOffsets()
+		if err != nil {
+			return err
+		}
+		block, err := serBlock.ToBlock2()
+		if err != nil {
+			return err
+		}
+		txIDs, err := extractTxIDs(block)
+		if err != nil {
+			return err
+		}
+		removedBlocks = append(removedBlocks, &blockIdxInfo{blockNum: currentBlockNum,
+			blockHash: serBlock.ComputeHash(), txOffsets: txOffsets, txIDs: txIDs})
+	}
+	if truncatePlacementInfo == nil {
+		return fmt.Errorf("Block [%d] not found in the block files", blockNum+1)
+	}
+	logger.Debugf("Truncating block files at file [%d] and offset [%d] removing [%d] blocks",
+		truncatePlacementInfo.fileNum, truncatePlacementInfo.blockStartOffset, len(removedBlocks))
+
+	// remove the index entries first so that the index never points past the end of the block files
+	if err = mgr.index.removeBlocks(removedBlocks, blockNum); err != nil {
+		return err
+	}
+	if err = mgr.currentFileWriter.close(); err != nil {
+		return err
+	}
+	for fileNum := truncatePlacementInfo.fileNum + 1; fileNum <= mgr.cpInfo.latestFileChunkSuffixNum; fileNum++ {
+		if err = os.Remove(deriveBlockfilePath(mgr.rootDir, fileNum)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	currentFileWriter, err := newBlockfileWriter(deriveBlockfilePath(mgr.rootDir, truncatePlacementInfo.fileNum))
+	if err != nil {
+		return err
+	}
+	if err = currentFileWriter.truncateFile(int(truncatePlacementInfo.blockStartOffset)); err != nil {
+		return err
+	}
+	cpInfo := &checkpointInfo{
+		latestFileChunkSuffixNum: truncatePlacementInfo.fileNum,
+		latestFileChunksize:      int(truncatePlacementInfo.blockStartOffset),
+		lastBlockNumber:          blockNum}
+	if err = mgr.saveCurrentInfo(cpInfo, true); err != nil {
+		return err
+	}
+	mgr.currentFileWriter = currentFileWriter
+	mgr.updateCheckpoint(cpInfo)
+
+	bcInfo, err := mgr.computeBlockchainInfo()
+	if err != nil {
+		return err
+	}
+	mgr.bcInfo.Store(bcInfo)
+	return nil
+}
@@ -0,0 +1,74 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fsblkstorage
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/core/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger/util"
+	"github.com/hyperledger/fabric/protos"
+)
+
+func TestBlockfileMgrTruncateToBlock(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.Cleanup()
+	blocks := testutil.ConstructTestBlocks(t, 10)
+	size := 0
+	for _, block := range blocks {
+		serBlock, err := protos.ConstructSerBlock2(block)
+		testutil.AssertNoError(t, err, "Error while getting bytes from block")
+		blockBytesSize := len(serBlock.GetBytes())
+		size += blockBytesSize + len(proto.EncodeVarint(uint64(blockBytesSize)))
+	}
+	env.conf.maxBlockfileSize = int(0.40 * float64(size))
+	blkfileMgrWrapper := newTestBlockfileWrapper(t, env)
+	defer blkfileMgrWrapper.close()
+	blockfileMgr := blkfileMgrWrapper.blockfileMgr
+	blkfileMgrWrapper.addBlocks(blocks)
+	testutil.AssertEquals(t, blockfileMgr.cpInfo.latestFileChunkSuffixNum, 2)
+
+	err := blockfileMgr.truncateToBlock(10)
+	testutil.AssertError(t, err, "Expected an error while truncating to the last block")
+
+	err = blockfileMgr.truncateToBlock(3)
+	testutil.AssertNoError(t, err, "Error while truncating blocks")
+	testutil.AssertEquals(t, blockfileMgr.cpInfo.latestFileChunkSuffixNum, 0)
+	testutil.AssertEquals(t, blockfileMgr.cpInfo.lastBlockNumber, uint64(3))
+	testutil.AssertEquals(t, blockfileMgr.getBlockchainInfo().Height, uint64(3))
+	exists, _, err := util.FileExists(deriveBlockfilePath(env.conf.blockfilesDir, 1))
+	testutil.AssertNoError(t, err, "Error while checking block file")
+	testutil.AssertEquals(t, exists, false)
+	blkfileMgrWrapper.testGetBlockByHash(blocks[:3])
+	_, err = blockfileMgr.retrieveBlockByNumber(4)
+	testutil.AssertError(t, err, "Expected an error while retrieving a removed block")
+	removedTxIDs, err := extractTxIDs(blocks[3])
+	testutil.AssertNoError(t, err, "Error while extracting the transaction IDs")
+	_, err = blockfileMgr.retrieveTransactionByID(removedTxIDs[0])
+	testutil.AssertError(t, err, "Expected an error while retrieving a transaction of a removed block")
+	numBlocks, err := blockfileMgr.verifyBlocks()
+	testutil.AssertNoError(t, err, "Error while verifying truncated blocks")
+	testutil.AssertEquals(t, numBlocks, uint64(3))
+
+	// blocks can be added again after the truncation
+	blkfileMgrWrapper.addBlocks(blocks[3:])
+	blkfileMgrWrapper.testGetBlockByHash(blocks)
+	numBlocks, err = blockfileMgr.verifyBlocks()
+	testutil.AssertNoError(t, err, "Error while verifying blocks")
+	testutil.AssertEquals(t, numBlocks, uint64(10))
+}
@@ -0,0 +1,125 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fsblkstorage
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/ledger/blkstorage"
+	"github.com/hyperledger/fabric/protos"
+)
+
+// verifyBlocks walks the blocks in the block files, recomputes the hash of each block and checks the
+// hash chain and the index entries of the block. It returns the number of blocks verified and for the
+// first block that fails the verification a `*blkstorage.CorruptBlockError`
+func (mgr *blockfileMgr) verifyBlocks() (uint64, error) {
+	stream, err := newBlockStream(mgr.rootDir, 0, 0, mgr.cpInfo.latestFileChunkSuffixNum)
+	if err != nil {
+		return 0, err
+	}
+	defer stream.close()
+
+	var previousBlockHash []byte
+	blockNum := uint64(1)
+	for ; ; blockNum++ {
+		blockBytes, placementInfo, err := stream.nextBlockBytesAndPlacementInfo()
+		if err != nil {
+			return blockNum - 1, &blkstorage.CorruptBlockError{BlockNum: blockNum, Reason: err.Error()}
+		}
+		if blockBytes == nil {
+			break
+		}
+		if blockBytes, err = mgr.decryptBlockBytes(blockBytes); err != nil {
+			return blockNum - 1, &blkstorage.CorruptBlockError{BlockNum: blockNum, Reason: err.Error()}
+		}
+		serBlock := protos.NewSerBlock2(blockBytes)
+		block, err := serBlock.ToBlock2()
+		if err != nil {
+			return blockNum - 1, &blkstorage.CorruptBlockError{BlockNum: blockNum, Reason: err.Error()}
+		}
+		blockHash := serBlock.ComputeHash()
+
+		// blocks committed without the hash of the previous block are not part of the hash chain
+		if len(block.PreviousBlockHash) != 0 && previousBlockHash != nil &&
+			!bytes.Equal(block.PreviousBlockHash, previousBlockHash) {
+			return blockNum - 1, &blkstorage.CorruptBlockError{BlockNum: blockNum,
+				Reason: "previous block hash does not match the hash of the previous block"}
+		}
+		blockLoc := &fileLocPointer{fileSuffixNum: placementInfo.fileNum,
+			locPointer: locPointer{offset: int(placementInfo.blockStartOffset)}}
+		if reason := mgr.verifyBlockIndexes(blockNum, blockHash, blockLoc, block); reason != "" {
+			return blockNum - 1, &blkstorage.CorruptBlockError{BlockNum: blockNum, Reason: reason}
+		}
+		previousBlockHash = blockHash
+	}
+
+	numBlocks := blockNum - 1
+	if numBlocks != mgr.cpInfo.lastBlockNumber {
+		return numBlocks, &blkstorage.CorruptBlockError{BlockNum: numBlocks + 1,
+			Reason: fmt.Sprintf("block files end after block [%d] but the checkpoint is at block [%d]",
+				numBlocks, mgr.cpInfo.lastBlockNumber)}
+	}
+	return numBlocks, nil
+}
+
+// verifyBlockIndexes checks that the entries of the configured indexes point to the block and its
+// transactions. It returns the reason of the first mismatch, or an empty string
+func (mgr *blockfileMgr) verifyBlockIndexes(blockNum uint64, blockHash []byte, blockLoc *fileLocPointer, block *protos.Block2) string {
+	if loc, err := mgr.index.getBlockLocByBlockNum(blockNum); err != blkstorage.ErrAttrNotIndexed {
+		if err != nil || !sameBlockLoc(loc, blockLoc) {
+			return fmt.Sprintf("block number index does not point to the block: %v", err)
+		}
+	}
+	if loc, err := mgr.index.getBlockLocByHash(blockHash); err != blkstorage.ErrAttrNotIndexed {
+		if err != nil || !sameBlockLoc(loc, blockLoc) {
+			return fmt.Sprintf("block hash index does not point to the block: %v", err)
+		}
+	}
+	txIDs, err := extractTxIDs(block)
+	if err != nil {
+		return err.Error()
+	}
+	for i, txBytes := range block.Transactions {
+		txID := txIDs[i]
+		if loc, err := mgr.index.getBlockLocByTxID(txID); err != blkstorage.ErrAttrNotIndexed {
+			if err != nil || !sameBlockLoc(loc, blockLoc) {
+				return fmt.Sprintf("block index of transaction [%s] does not point to the block: %v", txID, err)
+			}
+		}
+		loc, err := mgr.index.getTxLoc(txID)
+		if err == blkstorage.ErrAttrNotIndexed {
+			continue
+		}
+		var indexedTxBytes []byte
+		if err == nil {
+			if loc.inEncryptedBlock {
+				indexedTxBytes, err = mgr.fetchTxBytesFromEncryptedBlock(loc)
+			} else {
+				indexedTxBytes, err = mgr.fetchRawBytes(loc)
+			}
+		}
+		if err != nil || !bytes.Equal(indexedTxBytes, txBytes) {
+			return fmt.Sprintf("transaction index does not point to transaction [%s]: %v", txID, err)
+		}
+	}
+	return ""
+}
+
+func sameBlockLoc(loc1 *fileLocPointer, loc2 *fileLocPointer) bool {
+	return loc1.fileSuffixNum == loc2.fileSuffixNum && loc1.offset == loc2.offset
+}
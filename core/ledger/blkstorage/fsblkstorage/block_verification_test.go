@@ -0,0 +1,93 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fsblkstorage
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/hyperledger/fabric/core/ledger/blkstorage"
+	"github.com/hyperledger/fabric/core/ledger/testutil"
+)
+
+func TestBlockfileMgrVerifyBlocks(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.Cleanup()
+	blkfileMgrWrapper := newTestBlockfileWrapper(t, env)
+	defer blkfileMgrWrapper.close()
+	blockfileMgr := blkfileMgrWrapper.blockfileMgr
+
+	numBlocks, err := blockfileMgr.verifyBlocks()
+	testutil.AssertNoError(t, err, "Error while verifying empty block store")
+	testutil.AssertEquals(t, numBlocks, uint64(0))
+
+	blocks := testutil.ConstructTestBlocks(t, 5)
+	blkfileMgrWrapper.addBlocks(blocks)
+	numBlocks, err = blockfileMgr.verifyBlocks()
+	testutil.AssertNoError(t, err, "Error while verifying blocks")
+	testutil.AssertEquals(t, numBlocks, uint64(5))
+
+	// corrupt a byte of the third block
+	loc, err := blockfileMgr.index.getBlockLocByBlockNum(3)
+	testutil.AssertNoError(t, err, "Error while retrieving block location")
+	blockfilePath := deriveBlockfilePath(env.conf.blockfilesDir, loc.fileSuffixNum)
+	blockfileBytes, err := ioutil.ReadFile(blockfilePath)
+	testutil.AssertNoError(t, err, "Error while reading block file")
+	blockfileBytes[loc.offset+10] ^= 0xff
+	err = ioutil.WriteFile(blockfilePath, blockfileBytes, 0644)
+	testutil.AssertNoError(t, err, "Error while writing block file")
+
+	numBlocks, err = blockfileMgr.verifyBlocks()
+	testutil.AssertEquals(t, numBlocks, uint64(2))
+	corruptBlockErr, ok := err.(*blkstorage.CorruptBlockError)
+	testutil.AssertSame(t, ok, true)
+	testutil.AssertEquals(t, corruptBlockErr.BlockNum, uint64(3))
+}
+
+func TestBlockfileMgrVerifyBlocksReadOnly(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.Cleanup()
+	blkfileMgrWrapper := newTestBlockfileWrapper(t, env)
+	blkfileMgrWrapper.addBlocks(testutil.ConstructTestBlocks(t, 3))
+	blkfileMgrWrapper.close()
+
+	// append an incomplete block, as left by a crash while adding a block
+	blockfilePath := deriveBlockfilePath(env.conf.blockfilesDir, 0)
+	file, err := os.OpenFile(blockfilePath, os.O_WRONLY|os.O_APPEND, 0644)
+	testutil.AssertNoError(t, err, "Error while opening block file")
+	_, err = file.Write([]byte{0x20, 0x01, 0x02})
+	testutil.AssertNoError(t, err, "Error while writing block file")
+	file.Close()
+	fileInfo, _ := os.Stat(blockfilePath)
+	sizeBefore := fileInfo.Size()
+
+	readOnlyConf := *env.conf
+	readOnlyConf.ReadOnly = true
+	blockfileMgr := newBlockfileMgr(&readOnlyConf, env.indexConfig)
+	numBlocks, err := blockfileMgr.verifyBlocks()
+	testutil.AssertEquals(t, numBlocks, uint64(3))
+	_, ok := err.(*blkstorage.CorruptBlockError)
+	testutil.AssertSame(t, ok, true)
+	testutil.AssertError(t, blockfileMgr.addBlock(testutil.ConstructTestBlocks(t, 1)[0]),
+		"Expected an error when adding a block to a read-only block store")
+	blockfileMgr.close()
+
+	// the incomplete block is left in place
+	fileInfo, _ = os.Stat(blockfilePath)
+	testutil.AssertEquals(t, fileInfo.Size(), sizeBefore)
+}
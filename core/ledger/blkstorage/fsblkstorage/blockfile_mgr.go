@@ -27,6 +27,7 @@ import (
 	"github.com/hyperledger/fabric/core/ledger/util/db"
 	"github.com/hyperledger/fabric/protos"
 	"github.com/op/go-logging"
+	"github.com/syndtr/goleveldb/leveldb"
 )
 
 var logger = logging.MustGetLogger("kvledger")
@@ -49,71 +50,96 @@ type blockfileMgr struct {
 	cpInfoCond        *sync.Cond
 	currentFileWriter *blockfileWriter
 	bcInfo            atomic.Value
+	encrypter         *blockEncrypter
 }
 
 func newBlockfileMgr(conf *Conf, indexConfig *blkstorage.IndexConfig) *blockfileMgr {
 	rootDir := conf.blockfilesDir
-	_, err := util.CreateDirIfMissing(rootDir)
-	if err != nil {
-		panic(fmt.Sprintf("Error: %s", err))
+	if !conf.ReadOnly {
+		if _, err := util.CreateDirIfMissing(rootDir); err != nil {
+			panic(fmt.Sprintf("Error: %s", err))
+		}
 	}
+	var err error
 	db := initDB(conf)
 	mgr := &blockfileMgr{rootDir: rootDir, conf: conf, db: db}
+	if conf.Encrypter != nil {
+		if mgr.encrypter, err = newBlockEncrypter(db, conf.Encrypter); err != nil {
+			panic(fmt.Sprintf("Could not initialize block encryption: %s", err))
+		}
+	}
 	cpInfo, err := mgr.loadCurrentInfo()
 	if err != nil {
 		panic(fmt.Sprintf("Could not get block file info for current block file from db: %s", err))
 	}
 	if cpInfo == nil {
 		cpInfo = &checkpointInfo{latestFileChunkSuffixNum: 0, latestFileChunksize: 0}
-		err = mgr.saveCurrentInfo(cpInfo, true)
-		if err != nil {
-			panic(fmt.Sprintf("Could not save next block file info to db: %s", err))
+		if !conf.ReadOnly {
+			err = mgr.saveCurrentInfo(cpInfo, true)
+			if err != nil {
+				panic(fmt.Sprintf("Could not save next block file info to db: %s", err))
+			}
 		}
 	}
 	syncCPInfoFromFS(conf, cpInfo)
-	currentFileWriter, err := newBlockfileWriter(deriveBlockfilePath(rootDir, cpInfo.latestFileChunkSuffixNum))
-	if err != nil {
-		panic(fmt.Sprintf("Could not open writer to current file: %s", err))
-	}
-	err = currentFileWriter.truncateFile(cpInfo.latestFileChunksize)
-	if err != nil {
-		panic(fmt.Sprintf("Could not truncate current file to known size in db: %s", err))
-	}
-
 	mgr.index = newBlockIndex(indexConfig, db)
 	mgr.cpInfo = cpInfo
-	mgr.currentFileWriter = currentFileWriter
 	mgr.cpInfoCond = sync.NewCond(&sync.Mutex{})
-	mgr.syncIndex()
+
+	// a read-only block store has no writer and is not brought in sync with the block files
+	if !conf.ReadOnly {
+		currentFileWriter, err := newBlockfileWriter(deriveBlockfilePath(rootDir, cpInfo.latestFileChunkSuffixNum))
+		if err != nil {
+			panic(fmt.Sprintf("Could not open writer to current file: %s", err))
+		}
+		err = currentFileWriter.truncateFile(cpInfo.latestFileChunksize)
+		if err != nil {
+			panic(fmt.Sprintf("Could not truncate current file to known size in db: %s", err))
+		}
+		mgr.currentFileWriter = currentFileWriter
+		mgr.syncIndex()
+	}
 
 	// init BlockchainInfo
+	bcInfo, err := mgr.computeBlockchainInfo()
+	if err != nil {
+		panic(err.Error())
+	}
+	mgr.bcInfo.Store(bcInfo)
+	return mgr
+}
+
+// computeBlockchainInfo derives the BlockchainInfo from the last block as per the checkpoint info
+func (mgr *blockfileMgr) computeBlockchainInfo() (*protos.BlockchainInfo, error) {
 	bcInfo := &protos.BlockchainInfo{
 		Height:            0,
 		CurrentBlockHash:  nil,
 		PreviousBlockHash: nil}
 
-	if cpInfo.lastBlockNumber > 0 {
-		lastBlock, err := mgr.retrieveSerBlockByNumber(cpInfo.lastBlockNumber)
+	if mgr.cpInfo.lastBlockNumber > 0 {
+		lastBlock, err := mgr.retrieveSerBlockByNumber(mgr.cpInfo.lastBlockNumber)
 		if err != nil {
-			panic(fmt.Sprintf("Could not retrieve last block form file: %s", err))
+			return nil, fmt.Errorf("Could not retrieve last block form file: %s", err)
 		}
 		lastBlockHash := lastBlock.ComputeHash()
 		previousBlockHash, err := lastBlock.GetPreviousBlockHash()
 		if err != nil {
-			panic(fmt.Sprintf("Error in decoding block: %s", err))
+			return nil, fmt.Errorf("Error in decoding block: %s", err)
 		}
 		bcInfo = &protos.BlockchainInfo{
-			Height:            cpInfo.lastBlockNumber,
+			Height:            mgr.cpInfo.lastBlockNumber,
 			CurrentBlockHash:  lastBlockHash,
 			PreviousBlockHash: previousBlockHash}
 	}
-	mgr.bcInfo.Store(bcInfo)
-	return mgr
+	return bcInfo, nil
 }
 
 func initDB(conf *Conf) *db.DB {
+	options := conf.IndexDBOptions
+	options.ReadOnly = conf.ReadOnly
 	dbInst := db.CreateDB(&db.Conf{
-		DBPath: conf.dbPath})
+		DBPath:  conf.dbPath,
+		Options: options})
 	dbInst.Open()
 	return dbInst
 }
@@ -150,7 +176,9 @@ func (mgr *blockfileMgr) open() error {
 }
 
 func (mgr *blockfileMgr) close() {
-	mgr.currentFileWriter.close()
+	if mgr.currentFileWriter != nil {
+		mgr.currentFileWriter.close()
+	}
 	mgr.db.Close()
 }
 
@@ -176,6 +204,9 @@ func (mgr *blockfileMgr) moveToNextFile() {
 }
 
 func (mgr *blockfileMgr) addBlock(block *protos.Block2) error {
+	if mgr.conf.ReadOnly {
+		return fmt.Errorf("Cannot add a block to a read-only block store")
+	}
 	serBlock, err := protos.ConstructSerBlock2(block)
 	if err != nil {
 		return fmt.Errorf("Error while serializing block: %s", err)
@@ -187,6 +218,15 @@ func (mgr *blockfileMgr) addBlock(block *protos.Block2) error {
 	if err != nil {
 		return fmt.Errorf("Error while serializing block: %s", err)
 	}
+	txIDs, err := extractTxIDs(block)
+	if err != nil {
+		return fmt.Errorf("Error while extracting the transaction IDs of block: %s", err)
+	}
+	if mgr.encrypter != nil {
+		if blockBytes, err = mgr.encrypter.encrypt(blockBytes); err != nil {
+			return fmt.Errorf("Error while encrypting block: %s", err)
+		}
+	}
 	blockBytesLen := len(blockBytes)
 	blockBytesEncodedLen := proto.EncodeVarint(uint64(blockBytesLen))
 	totalBytesToAppend := blockBytesLen + len(blockBytesEncodedLen)
@@ -222,13 +262,16 @@ func (mgr *blockfileMgr) addBlock(block *protos.Block2) error {
 
 	blockFLP := &fileLocPointer{fileSuffixNum: newCPInfo.latestFileChunkSuffixNum}
 	blockFLP.offset = currentOffset
-	// shift the txoffset because we prepend length of bytes before block bytes
-	for i := 0; i < len(txOffsets); i++ {
-		txOffsets[i] += len(blockBytesEncodedLen)
+	// shift the txoffset because we prepend length of bytes before block bytes.
+	// The txoffsets of an encrypted block remain relative to the decrypted block bytes
+	if mgr.encrypter == nil {
+		for i := 0; i < len(txOffsets); i++ {
+			txOffsets[i] += len(blockBytesEncodedLen)
+		}
 	}
 	mgr.index.indexBlock(&blockIdxInfo{
 		blockNum: newCPInfo.lastBlockNumber, blockHash: blockHash,
-		flp: blockFLP, txOffsets: txOffsets})
+		flp: blockFLP, txOffsets: txOffsets, txIDs: txIDs, encrypted: mgr.encrypter != nil})
 
 	mgr.updateCheckpoint(newCPInfo)
 	mgr.updateBlockchainInfo(blockHash, block)
@@ -269,13 +312,23 @@ func (mgr *blockfileMgr) syncIndex() error {
 		if blockBytes == nil {
 			break
 		}
+		if blockBytes, err = mgr.decryptBlockBytes(blockBytes); err != nil {
+			return err
+		}
 		serBlock2 := protos.NewSerBlock2(blockBytes)
 		var txOffsets []int
 		if txOffsets, err = serBlock2.GetTxOffsets(); err != nil {
 			return err
 		}
-		for i := 0; i < len(txOffsets); i++ {
-			txOffsets[i] += int(blockPlacementInfo.blockBytesOffset)
+		var block *protos.Block2
+		if block, err = serBlock2.ToBlock2(); err != nil {
+			return err
+		}
+		// the txoffsets of an encrypted block remain relative to the decrypted block bytes
+		if mgr.encrypter == nil {
+			for i := 0; i < len(txOffsets); i++ {
+				txOffsets[i] += int(blockPlacementInfo.blockBytesOffset)
+			}
 		}
 		blockIdxInfo := &blockIdxInfo{}
 		blockIdxInfo.blockHash = serBlock2.ComputeHash()
@@ -283,6 +336,10 @@ func (mgr *blockfileMgr) syncIndex() error {
 		blockIdxInfo.flp = &fileLocPointer{fileSuffixNum: blockPlacementInfo.fileNum,
 			locPointer: locPointer{offset: int(blockPlacementInfo.blockStartOffset)}}
 		blockIdxInfo.txOffsets = txOffsets
+		if blockIdxInfo.txIDs, err = extractTxIDs(block); err != nil {
+			return err
+		}
+		blockIdxInfo.encrypted = mgr.encrypter != nil
 		if err = mgr.index.indexBlock(blockIdxInfo); err != nil {
 			return err
 		}
@@ -353,6 +410,73 @@ func (mgr *blockfileMgr) retrieveTransactionByID(txID string) (*protos.Transacti
 	return mgr.fetchTransaction(loc)
 }
 
+func (mgr *blockfileMgr) retrieveBlockByTxID(txID string) (*protos.Block2, error) {
+	logger.Debugf("retrieveBlockByTxID() - txID = [%s]", txID)
+	loc, err := mgr.index.getBlockLocByTxID(txID)
+	if err != nil {
+		return nil, err
+	}
+	return mgr.fetchBlock(loc)
+}
+
+// setLocalMetadata stores the local metadata of a block in the index database
+func (mgr *blockfileMgr) setLocalMetadata(blockNum uint64, metadata []byte) error {
+	if blockNum == 0 || blockNum > mgr.getBlockchainInfo().Height {
+		return fmt.Errorf("Cannot set the local metadata of block [%d], the block is not in the block store", blockNum)
+	}
+	return mgr.db.Put(constructLocalMetadataKey(blockNum), metadata, false)
+}
+
+func (mgr *blockfileMgr) getLocalMetadata(blockNum uint64) ([]byte, error) {
+	return mgr.db.Get(constructLocalMetadataKey(blockNum))
+}
+
+// setTxValidationCodes stores the validation codes of the transactions of a block in the index database, along
+// with the list of the transaction ids recorded for the block so that they are removed with the block
+func (mgr *blockfileMgr) setTxValidationCodes(blockNum uint64, txIDs []string, codes []protos.TxValidationCode) error {
+	if blockNum == 0 || blockNum > mgr.getBlockchainInfo().Height {
+		return fmt.Errorf("Cannot set the validation codes of block [%d], the block is not in the block store", blockNum)
+	}
+	if len(txIDs) != len(codes) {
+		return fmt.Errorf("Got %d validation codes for %d transactions", len(codes), len(txIDs))
+	}
+	batch := &leveldb.Batch{}
+	var recorded []string
+	recordedSet := make(map[string]bool)
+	for i, txID := range txIDs {
+		if recordedSet[txID] {
+			continue
+		}
+		existing, err := mgr.db.Get(constructTxValidationCodeKey(txID))
+		if err != nil {
+			return err
+		}
+		// the code of an earlier block is kept, the one of this block is recorded again
+		if existing != nil {
+			if existingBlockNum, _ := decodeTxValidationCode(existing); existingBlockNum != blockNum {
+				continue
+			}
+		}
+		batch.Put(constructTxValidationCodeKey(txID), encodeTxValidationCode(blockNum, codes[i]))
+		recorded = append(recorded, txID)
+		recordedSet[txID] = true
+	}
+	batch.Put(constructBlockTxIDsKey(blockNum), encodeTxIDs(recorded))
+	return mgr.db.WriteBatch(batch, false)
+}
+
+func (mgr *blockfileMgr) getTxValidationCode(txID string) (protos.TxValidationCode, error) {
+	b, err := mgr.db.Get(constructTxValidationCodeKey(txID))
+	if err != nil {
+		return -1, err
+	}
+	if b == nil {
+		return -1, blkstorage.ErrNotFoundInIndex
+	}
+	_, code := decodeTxValidationCode(b)
+	return code, nil
+}
+
 func (mgr *blockfileMgr) fetchBlock(lp *fileLocPointer) (*protos.Block2, error) {
 	serBlock, err := mgr.fetchSerBlock(lp)
 	if err != nil {
@@ -374,7 +498,13 @@ func (mgr *blockfileMgr) fetchSerBlock(lp *fileLocPointer) (*protos.SerBlock2, e
 }
 
 func (mgr *blockfileMgr) fetchTransaction(lp *fileLocPointer) (*protos.Transaction2, error) {
-	txBytes, err := mgr.fetchRawBytes(lp)
+	var txBytes []byte
+	var err error
+	if lp.inEncryptedBlock {
+		txBytes, err = mgr.fetchTxBytesFromEncryptedBlock(lp)
+	} else {
+		txBytes, err = mgr.fetchRawBytes(lp)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -396,7 +526,37 @@ func (mgr *blockfileMgr) fetchBlockBytes(lp *fileLocPointer) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	return b, nil
+	return mgr.decryptBlockBytes(b)
+}
+
+// fetchTxBytesFromEncryptedBlock decrypts the block that contains the transaction
+// and returns the transaction bytes from the decrypted block bytes
+func (mgr *blockfileMgr) fetchTxBytesFromEncryptedBlock(lp *fileLocPointer) ([]byte, error) {
+	blockBytes, err := mgr.fetchBlockBytes(&fileLocPointer{fileSuffixNum: lp.fileSuffixNum,
+		locPointer: locPointer{offset: lp.encryptedBlockOffset}})
+	if err != nil {
+		return nil, err
+	}
+	if lp.offset+lp.bytesLength > len(blockBytes) {
+		return nil, fmt.Errorf("Transaction location [%s] is outside of the block", lp)
+	}
+	return blockBytes[lp.offset : lp.offset+lp.bytesLength], nil
+}
+
+// decryptBlockBytes decrypts the block bytes read from a block file, if block encryption is enabled
+func (mgr *blockfileMgr) decryptBlockBytes(blockBytes []byte) ([]byte, error) {
+	if mgr.encrypter == nil || blockBytes == nil {
+		return blockBytes, nil
+	}
+	return mgr.encrypter.decrypt(blockBytes)
+}
+
+func (mgr *blockfileMgr) rotateEncryptionKey() error {
+	if mgr.encrypter == nil {
+		return fmt.Errorf("Block encryption is not enabled")
+	}
+	_, err := mgr.encrypter.rotateKey()
+	return err
 }
 
 func (mgr *blockfileMgr) fetchRawBytes(lp *fileLocPointer) ([]byte, error) {
@@ -23,6 +23,7 @@ import (
 	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric/core/ledger/testutil"
 	"github.com/hyperledger/fabric/protos"
+	putils "github.com/hyperledger/fabric/protos/utils"
 )
 
 func TestBlockfileMgrBlockReadWrite(t *testing.T) {
@@ -141,15 +142,16 @@ func TestBlockfileMgrGetTxById(t *testing.T) {
 	defer blkfileMgrWrapper.close()
 	blocks := testutil.ConstructTestBlocks(t, 10)
 	blkfileMgrWrapper.addBlocks(blocks)
-	for i, blk := range blocks {
-		for j, txBytes := range blk.Transactions {
-			// blockNum starts with 1
-			txID := constructTxID(uint64(i+1), j)
-			txFromFileMgr, err := blkfileMgrWrapper.blockfileMgr.retrieveTransactionByID(txID)
-			testutil.AssertNoError(t, err, "Error while retrieving tx from blkfileMgr")
+	for _, blk := range blocks {
+		for _, txBytes := range blk.Transactions {
 			tx := &protos.Transaction2{}
-			err = proto.Unmarshal(txBytes, tx)
+			err := proto.Unmarshal(txBytes, tx)
 			testutil.AssertNoError(t, err, "Error while unmarshalling tx")
+			// transactions are indexed by the identifier computed from their header
+			txID, err := putils.GetTxID(tx)
+			testutil.AssertNoError(t, err, "Error while getting tx id")
+			txFromFileMgr, err := blkfileMgrWrapper.blockfileMgr.retrieveTransactionByID(txID)
+			testutil.AssertNoError(t, err, "Error while retrieving tx from blkfileMgr")
 			testutil.AssertEquals(t, txFromFileMgr, tx)
 		}
 	}
@@ -199,3 +201,31 @@ func TestBlockfileMgrFileRolling(t *testing.T) {
 	testutil.AssertEquals(t, blkfileMgrWrapper.blockfileMgr.cpInfo.latestFileChunkSuffixNum, 2)
 	blkfileMgrWrapper.testGetBlockByHash(blocks)
 }
+
+func TestBlockfileMgrLocalMetadata(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.Cleanup()
+	blkfileMgrWrapper := newTestBlockfileWrapper(t, env)
+	defer blkfileMgrWrapper.close()
+	blockfileMgr := blkfileMgrWrapper.blockfileMgr
+	blkfileMgrWrapper.addBlocks(testutil.ConstructTestBlocks(t, 3))
+
+	for blockNum := uint64(1); blockNum <= 3; blockNum++ {
+		err := blockfileMgr.setLocalMetadata(blockNum, []byte(fmt.Sprintf("metadata%d", blockNum)))
+		testutil.AssertNoError(t, err, "Error while setting local metadata")
+	}
+	testutil.AssertError(t, blockfileMgr.setLocalMetadata(4, []byte("metadata4")),
+		"Expected an error while setting the local metadata of a missing block")
+	metadata, err := blockfileMgr.getLocalMetadata(2)
+	testutil.AssertNoError(t, err, "Error while getting local metadata")
+	testutil.AssertEquals(t, metadata, []byte("metadata2"))
+
+	// the local metadata of the removed blocks is removed along with the blocks
+	testutil.AssertNoError(t, blockfileMgr.truncateToBlock(1), "Error while truncating blocks")
+	metadata, err = blockfileMgr.getLocalMetadata(2)
+	testutil.AssertNoError(t, err, "Error while getting local metadata")
+	testutil.AssertNil(t, metadata)
+	metadata, err = blockfileMgr.getLocalMetadata(1)
+	testutil.AssertNoError(t, err, "Error while getting local metadata")
+	testutil.AssertEquals(t, metadata, []byte("metadata1"))
+}
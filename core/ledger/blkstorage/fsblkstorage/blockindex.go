@@ -18,19 +18,26 @@ package fsblkstorage
 
 import (
 	"fmt"
+	"io"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric/core/ledger/blkstorage"
 	"github.com/hyperledger/fabric/core/ledger/util"
 	"github.com/hyperledger/fabric/core/ledger/util/db"
+	"github.com/hyperledger/fabric/protos"
+	putils "github.com/hyperledger/fabric/protos/utils"
 	"github.com/syndtr/goleveldb/leveldb"
 )
 
 const (
-	blockNumIdxKeyPrefix  = 'n'
-	blockHashIdxKeyPrefix = 'h'
-	txIDIdxKeyPrefix      = 't'
-	indexCheckpointKeyStr = "indexCheckpointKey"
+	blockNumIdxKeyPrefix      = 'n'
+	blockHashIdxKeyPrefix     = 'h'
+	txIDIdxKeyPrefix          = 't'
+	blockTxIDIdxKeyPrefix     = 'b'
+	localMetadataKeyPrefix    = 'm'
+	txValidationCodeKeyPrefix = 'v'
+	blockTxIDsKeyPrefix       = 'c'
+	indexCheckpointKeyStr     = "indexCheckpointKey"
 )
 
 var indexCheckpointKey = []byte(indexCheckpointKeyStr)
@@ -41,6 +48,8 @@ type index interface {
 	getBlockLocByHash(blockHash []byte) (*fileLocPointer, error)
 	getBlockLocByBlockNum(blockNum uint64) (*fileLocPointer, error)
 	getTxLoc(txID string) (*fileLocPointer, error)
+	getBlockLocByTxID(txID string) (*fileLocPointer, error)
+	removeBlocks(blockIdxInfos []*blockIdxInfo, lastBlockNum uint64) error
 }
 
 type blockIdxInfo struct {
@@ -48,6 +57,8 @@ type blockIdxInfo struct {
 	blockHash []byte
 	flp       *fileLocPointer
 	txOffsets []int
+	txIDs     []string
+	encrypted bool
 }
 
 type blockIndex struct {
@@ -98,10 +109,16 @@ func (index *blockIndex) indexBlock(blockIdxInfo *blockIdxInfo) error {
 	}
 
 	if _, ok := index.indexItemsMap[blkstorage.IndexableAttrTxID]; ok {
-		for i := 0; i < len(txOffsets)-1; i++ {
-			txID := constructTxID(blockIdxInfo.blockNum, i)
+		for i, txID := range blockIdxInfo.txIDs {
 			txBytesLength := txOffsets[i+1] - txOffsets[i]
-			txFlp := newFileLocationPointer(flp.fileSuffixNum, flp.offset, &locPointer{txOffsets[i], txBytesLength})
+			var txFlp *fileLocPointer
+			if blockIdxInfo.encrypted {
+				txFlp = newFileLocationPointer(flp.fileSuffixNum, 0, &locPointer{txOffsets[i], txBytesLength})
+				txFlp.inEncryptedBlock = true
+				txFlp.encryptedBlockOffset = flp.offset
+			} else {
+				txFlp = newFileLocationPointer(flp.fileSuffixNum, flp.offset, &locPointer{txOffsets[i], txBytesLength})
+			}
 			logger.Debugf("Adding txLoc [%s] for tx [%s] to index", txFlp, txID)
 			txFlpBytes, marshalErr := txFlp.marshal()
 			if marshalErr != nil {
@@ -111,6 +128,12 @@ func (index *blockIndex) indexBlock(blockIdxInfo *blockIdxInfo) error {
 		}
 	}
 
+	if _, ok := index.indexItemsMap[blkstorage.IndexableAttrBlockTxID]; ok {
+		for _, txID := range blockIdxInfo.txIDs {
+			batch.Put(constructBlockTxIDKey(txID), flpBytes)
+		}
+	}
+
 	batch.Put(indexCheckpointKey, encodeBlockNum(blockIdxInfo.blockNum))
 	if err := index.db.WriteBatch(batch, false); err != nil {
 		return err
@@ -118,6 +141,30 @@ func (index *blockIndex) indexBlock(blockIdxInfo *blockIdxInfo) error {
 	return nil
 }
 
+// removeBlocks removes the index entries of the given blocks and moves the index checkpoint back to lastBlockNum
+func (index *blockIndex) removeBlocks(blockIdxInfos []*blockIdxInfo, lastBlockNum uint64) error {
+	batch := &leveldb.Batch{}
+	for _, blockIdxInfo := range blockIdxInfos {
+		batch.Delete(constructBlockHashKey(blockIdxInfo.blockHash))
+		batch.Delete(constructBlockNumKey(blockIdxInfo.blockNum))
+		batch.Delete(constructLocalMetadataKey(blockIdxInfo.blockNum))
+		recordedTxIDs, err := index.db.Get(constructBlockTxIDsKey(blockIdxInfo.blockNum))
+		if err != nil {
+			return err
+		}
+		for _, txID := range decodeTxIDs(recordedTxIDs) {
+			batch.Delete(constructTxValidationCodeKey(txID))
+		}
+		batch.Delete(constructBlockTxIDsKey(blockIdxInfo.blockNum))
+		for _, txID := range blockIdxInfo.txIDs {
+			batch.Delete(constructTxIDKey(txID))
+			batch.Delete(constructBlockTxIDKey(txID))
+		}
+	}
+	batch.Put(indexCheckpointKey, encodeBlockNum(lastBlockNum))
+	return index.db.WriteBatch(batch, true)
+}
+
 func (index *blockIndex) getBlockLocByHash(blockHash []byte) (*fileLocPointer, error) {
 	if _, ok := index.indexItemsMap[blkstorage.IndexableAttrBlockHash]; !ok {
 		return nil, blkstorage.ErrAttrNotIndexed
@@ -166,11 +213,39 @@ func (index *blockIndex) getTxLoc(txID string) (*fileLocPointer, error) {
 	return txFLP, nil
 }
 
+func (index *blockIndex) getBlockLocByTxID(txID string) (*fileLocPointer, error) {
+	if _, ok := index.indexItemsMap[blkstorage.IndexableAttrBlockTxID]; !ok {
+		return nil, blkstorage.ErrAttrNotIndexed
+	}
+	b, err := index.db.Get(constructBlockTxIDKey(txID))
+	if err != nil {
+		return nil, err
+	}
+	if b == nil {
+		return nil, blkstorage.ErrNotFoundInIndex
+	}
+	blkLoc := &fileLocPointer{}
+	blkLoc.unmarshal(b)
+	return blkLoc, nil
+}
+
 func constructBlockNumKey(blockNum uint64) []byte {
 	blkNumBytes := util.EncodeOrderPreservingVarUint64(blockNum)
 	return append([]byte{blockNumIdxKeyPrefix}, blkNumBytes...)
 }
 
+func constructLocalMetadataKey(blockNum uint64) []byte {
+	return append([]byte{localMetadataKeyPrefix}, encodeBlockNum(blockNum)...)
+}
+
+func constructTxValidationCodeKey(txID string) []byte {
+	return append([]byte{txValidationCodeKeyPrefix}, []byte(txID)...)
+}
+
+func constructBlockTxIDsKey(blockNum uint64) []byte {
+	return append([]byte{blockTxIDsKeyPrefix}, encodeBlockNum(blockNum)...)
+}
+
 func constructBlockHashKey(blockHash []byte) []byte {
 	return append([]byte{blockHashIdxKeyPrefix}, blockHash...)
 }
@@ -179,8 +254,25 @@ func constructTxIDKey(txID string) []byte {
 	return append([]byte{txIDIdxKeyPrefix}, []byte(txID)...)
 }
 
-func constructTxID(blockNum uint64, txNum int) string {
-	return fmt.Sprintf("%d:%d", blockNum, txNum)
+func constructBlockTxIDKey(txID string) []byte {
+	return append([]byte{blockTxIDIdxKeyPrefix}, []byte(txID)...)
+}
+
+// extractTxIDs returns the identifiers of the transactions of a block, in the order of the block
+func extractTxIDs(block *protos.Block2) ([]string, error) {
+	txIDs := make([]string, len(block.Transactions))
+	for i, txBytes := range block.Transactions {
+		tx := &protos.Transaction2{}
+		if err := proto.Unmarshal(txBytes, tx); err != nil {
+			return nil, fmt.Errorf("Error unmarshalling transaction [%d]: %s", i, err)
+		}
+		txID, err := putils.GetTxID(tx)
+		if err != nil {
+			return nil, fmt.Errorf("Error getting the identifier of transaction [%d]: %s", i, err)
+		}
+		txIDs[i] = txID
+	}
+	return txIDs, nil
 }
 
 func encodeBlockNum(blockNum uint64) []byte {
@@ -192,6 +284,37 @@ func decodeBlockNum(blockNumBytes []byte) uint64 {
 	return blockNum
 }
 
+func encodeTxValidationCode(blockNum uint64, code protos.TxValidationCode) []byte {
+	return append(proto.EncodeVarint(blockNum), proto.EncodeVarint(uint64(code))...)
+}
+
+func decodeTxValidationCode(b []byte) (uint64, protos.TxValidationCode) {
+	buffer := proto.NewBuffer(b)
+	blockNum, _ := buffer.DecodeVarint()
+	code, _ := buffer.DecodeVarint()
+	return blockNum, protos.TxValidationCode(code)
+}
+
+func encodeTxIDs(txIDs []string) []byte {
+	buffer := proto.NewBuffer([]byte{})
+	for _, txID := range txIDs {
+		buffer.EncodeStringBytes(txID)
+	}
+	return buffer.Bytes()
+}
+
+func decodeTxIDs(b []byte) []string {
+	var txIDs []string
+	buffer := proto.NewBuffer(b)
+	for {
+		txID, err := buffer.DecodeStringBytes()
+		if err != nil {
+			return txIDs
+		}
+		txIDs = append(txIDs, txID)
+	}
+}
+
 type locPointer struct {
 	offset      int
 	bytesLength int
@@ -206,6 +329,11 @@ func (lp *locPointer) String() string {
 type fileLocPointer struct {
 	fileSuffixNum int
 	locPointer
+	// inEncryptedBlock is set for a transaction stored in an encrypted block. Such a block has to be
+	// decrypted as a whole, so locPointer is relative to the decrypted block bytes and
+	// encryptedBlockOffset is the offset of the block in the file
+	inEncryptedBlock     bool
+	encryptedBlockOffset int
 }
 
 func newFileLocationPointer(fileSuffixNum int, begginingOffset int, relativeLP *locPointer) *fileLocPointer {
@@ -229,6 +357,12 @@ func (flp *fileLocPointer) marshal() ([]byte, error) {
 	if e != nil {
 		return nil, e
 	}
+	if flp.inEncryptedBlock {
+		e = buffer.EncodeVarint(uint64(flp.encryptedBlockOffset))
+		if e != nil {
+			return nil, e
+		}
+	}
 	return buffer.Bytes(), nil
 }
 
@@ -250,6 +384,16 @@ func (flp *fileLocPointer) unmarshal(b []byte) error {
 		return e
 	}
 	flp.bytesLength = int(i)
+	i, e = buffer.DecodeVarint()
+	if e == io.ErrUnexpectedEOF {
+		// not a location within an encrypted block
+		return nil
+	}
+	if e != nil {
+		return e
+	}
+	flp.inEncryptedBlock = true
+	flp.encryptedBlockOffset = int(i)
 	return nil
 }
 
@@ -24,6 +24,7 @@ import (
 	"github.com/hyperledger/fabric/core/ledger/blkstorage"
 	"github.com/hyperledger/fabric/core/ledger/testutil"
 	"github.com/hyperledger/fabric/protos"
+	putils "github.com/hyperledger/fabric/protos/utils"
 )
 
 type noopIndex struct {
@@ -44,6 +45,12 @@ func (i *noopIndex) getBlockLocByBlockNum(blockNum uint64) (*fileLocPointer, err
 func (i *noopIndex) getTxLoc(txID string) (*fileLocPointer, error) {
 	return nil, nil
 }
+func (i *noopIndex) getBlockLocByTxID(txID string) (*fileLocPointer, error) {
+	return nil, nil
+}
+func (i *noopIndex) removeBlocks(blockIdxInfos []*blockIdxInfo, lastBlockNum uint64) error {
+	return nil
+}
 
 func TestBlockIndexSync(t *testing.T) {
 	testBlockIndexSync(t, 10, 5, false)
@@ -105,6 +112,7 @@ func TestBlockIndexSelectiveIndexing(t *testing.T) {
 	testBlockIndexSelectiveIndexing(t, []blkstorage.IndexableAttr{blkstorage.IndexableAttrBlockHash})
 	testBlockIndexSelectiveIndexing(t, []blkstorage.IndexableAttr{blkstorage.IndexableAttrBlockNum})
 	testBlockIndexSelectiveIndexing(t, []blkstorage.IndexableAttr{blkstorage.IndexableAttrTxID})
+	testBlockIndexSelectiveIndexing(t, []blkstorage.IndexableAttr{blkstorage.IndexableAttrBlockTxID})
 	testBlockIndexSelectiveIndexing(t, []blkstorage.IndexableAttr{blkstorage.IndexableAttrBlockHash, blkstorage.IndexableAttrBlockNum})
 }
 
@@ -140,13 +148,26 @@ func testBlockIndexSelectiveIndexing(t *testing.T, indexItems []blkstorage.Index
 	}
 
 	// test 'retrieveTransactionByID'
-	tx, err := blockfileMgr.retrieveTransactionByID(constructTxID(1, 0))
+	txOrig := &protos.Transaction2{}
+	proto.Unmarshal(blocks[0].Transactions[0], txOrig)
+	txID, _ := putils.GetTxID(txOrig)
+	tx, err := blockfileMgr.retrieveTransactionByID(txID)
 	if testutil.Contains(indexItems, blkstorage.IndexableAttrTxID) {
 		testutil.AssertNoError(t, err, "Error while retrieving tx by id")
-		txOrig := &protos.Transaction2{}
-		proto.Unmarshal(blocks[0].Transactions[0], txOrig)
 		testutil.AssertEquals(t, tx, txOrig)
 	} else {
 		testutil.AssertSame(t, err, blkstorage.ErrAttrNotIndexed)
 	}
+
+	// test 'retrieveBlockByTxID'
+	txOrig = &protos.Transaction2{}
+	proto.Unmarshal(blocks[1].Transactions[0], txOrig)
+	txID, _ = putils.GetTxID(txOrig)
+	block, err = blockfileMgr.retrieveBlockByTxID(txID)
+	if testutil.Contains(indexItems, blkstorage.IndexableAttrBlockTxID) {
+		testutil.AssertNoError(t, err, "Error while retrieving block by tx id")
+		testutil.AssertEquals(t, block, blocks[1])
+	} else {
+		testutil.AssertSame(t, err, blkstorage.ErrAttrNotIndexed)
+	}
 }
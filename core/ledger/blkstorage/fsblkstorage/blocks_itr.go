@@ -107,6 +107,9 @@ func (itr *BlocksItr) Next() (ledger.QueryResult, error) {
 	if err != nil {
 		return nil, err
 	}
+	if nextBlockBytes, err = itr.mgr.decryptBlockBytes(nextBlockBytes); err != nil {
+		return nil, err
+	}
 	itr.blockNumToRetrieve++
 	return &BlockHolder{nextBlockBytes}, nil
 }
@@ -16,7 +16,12 @@ limitations under the License.
 
 package fsblkstorage
 
-import "strings"
+import (
+	"strings"
+
+	"github.com/hyperledger/fabric/core/ledger/util/db"
+	"github.com/hyperledger/fabric/core/ledger/util/encryption"
+)
 
 const (
 	defaultMaxBlockfileSize = 64 * 1024 * 1024
@@ -27,6 +32,13 @@ type Conf struct {
 	blockfilesDir    string
 	dbPath           string
 	maxBlockfileSize int
+	// Encrypter, if set, is the key-encryption key that protects the keys used to encrypt the blocks
+	Encrypter encryption.Encrypter
+	// IndexDBOptions are the goleveldb options of the db that holds the block index
+	IndexDBOptions db.Options
+	// ReadOnly opens an existing block store for reading only. The block files and the index are
+	// left as found, i.e. an incomplete last block is not truncated and missing index entries are not added
+	ReadOnly bool
 }
 
 // NewConf constructs new `Conf`.
@@ -38,5 +50,5 @@ func NewConf(filesystemPath string, maxBlockfileSize int) *Conf {
 	if maxBlockfileSize <= 0 {
 		maxBlockfileSize = defaultMaxBlockfileSize
 	}
-	return &Conf{filesystemPath + "blocks", filesystemPath + "db", maxBlockfileSize}
+	return &Conf{blockfilesDir: filesystemPath + "blocks", dbPath: filesystemPath + "db", maxBlockfileSize: maxBlockfileSize}
 }
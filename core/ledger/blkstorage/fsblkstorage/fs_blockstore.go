@@ -67,6 +67,51 @@ func (store *FsBlockStore) RetrieveTxByID(txID string) (*protos.Transaction2, er
 	return store.fileMgr.retrieveTransactionByID(txID)
 }
 
+// RetrieveBlockByTxID returns the block that contains the transaction with the given id
+func (store *FsBlockStore) RetrieveBlockByTxID(txID string) (*protos.Block2, error) {
+	return store.fileMgr.retrieveBlockByTxID(txID)
+}
+
+// VerifyBlocks implements method in interface `blkstorage.Verifier`
+func (store *FsBlockStore) VerifyBlocks() (uint64, error) {
+	return store.fileMgr.verifyBlocks()
+}
+
+// TruncateToBlock implements method in interface `blkstorage.Truncater`
+func (store *FsBlockStore) TruncateToBlock(blockNum uint64) error {
+	return store.fileMgr.truncateToBlock(blockNum)
+}
+
+// RotateEncryptionKey implements method in interface `blkstorage.EncryptionKeyRotator`
+func (store *FsBlockStore) RotateEncryptionKey() error {
+	return store.fileMgr.rotateEncryptionKey()
+}
+
+// SetLocalMetadata implements method in interface `blkstorage.LocalMetadataStore`
+func (store *FsBlockStore) SetLocalMetadata(blockNum uint64, metadata []byte) error {
+	return store.fileMgr.setLocalMetadata(blockNum, metadata)
+}
+
+// GetLocalMetadata implements method in interface `blkstorage.LocalMetadataStore`
+func (store *FsBlockStore) GetLocalMetadata(blockNum uint64) ([]byte, error) {
+	return store.fileMgr.getLocalMetadata(blockNum)
+}
+
+// SetTxValidationCodes implements method in interface `blkstorage.TxValidationCodeStore`
+func (store *FsBlockStore) SetTxValidationCodes(blockNum uint64, txIDs []string, codes []protos.TxValidationCode) error {
+	return store.fileMgr.setTxValidationCodes(blockNum, txIDs, codes)
+}
+
+// GetTxValidationCode implements method in interface `blkstorage.TxValidationCodeStore`
+func (store *FsBlockStore) GetTxValidationCode(txID string) (protos.TxValidationCode, error) {
+	return store.fileMgr.getTxValidationCode(txID)
+}
+
+// CompactIndex implements method in interface `blkstorage.IndexCompacter`
+func (store *FsBlockStore) CompactIndex() (int64, int64, error) {
+	return store.fileMgr.db.Compact()
+}
+
 // Shutdown shuts down the block store
 func (store *FsBlockStore) Shutdown() {
 	store.fileMgr.close()
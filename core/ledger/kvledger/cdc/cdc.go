@@ -0,0 +1,81 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cdc
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Write is a key-value write of a valid transaction, as committed to the state of a ledger
+type Write struct {
+	BlockNumber uint64 `json:"blockNumber"`
+	// TxNumber is the position of the transaction in the block
+	TxNumber  int    `json:"txNumber"`
+	Namespace string `json:"namespace"`
+	Key       string `json:"key"`
+	IsDelete  bool   `json:"isDelete"`
+	Value     []byte `json:"value,omitempty"`
+}
+
+// Sink - an interface for a destination of the writes committed to the state of a ledger, such as
+// an off-chain database that mirrors the state
+type Sink interface {
+	// Emit is invoked with the writes of each committed block, in the order of the blocks and, within a block,
+	// in the order of the transactions. It is invoked once the block is committed, so an error does not undo the commit.
+	// The block is emitted again, before the following blocks, with the next commit
+	Emit(blockNum uint64, writes []*Write) error
+	Close()
+}
+
+// SinkFactory creates the sink for a ledger. ledgerDir is the directory of the ledger, under which a sink may keep files
+type SinkFactory func(ledgerName string, ledgerDir string) (Sink, error)
+
+var (
+	sinkFactoriesLock sync.RWMutex
+	sinkFactories     = make(map[string]SinkFactory)
+)
+
+// RegisterSinkFactory makes a kind of sink available by the given name
+func RegisterSinkFactory(name string, factory SinkFactory) {
+	sinkFactoriesLock.Lock()
+	defer sinkFactoriesLock.Unlock()
+	sinkFactories[name] = factory
+}
+
+// NewSink creates a sink of the kind registered with the given name for a ledger
+func NewSink(name string, ledgerName string, ledgerDir string) (Sink, error) {
+	sinkFactoriesLock.RLock()
+	factory, ok := sinkFactories[name]
+	sinkFactoriesLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("Unknown change data capture sink [%s]. Available sinks are %s", name, sinkNames())
+	}
+	return factory(ledgerName, ledgerDir)
+}
+
+func sinkNames() []string {
+	sinkFactoriesLock.RLock()
+	defer sinkFactoriesLock.RUnlock()
+	names := []string{}
+	for name := range sinkFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,70 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cdc
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperledger/fabric/core/ledger/testutil"
+)
+
+const testLedgerDir = "/tmp/tests/ledger/kvledger/cdc"
+
+func TestNewSink(t *testing.T) {
+	defer os.RemoveAll(testLedgerDir)
+	_, err := NewSink("unknown", "ledger1", testLedgerDir)
+	testutil.AssertError(t, err, "Expected an error for an unknown sink")
+	sink, err := NewSink(File, "ledger1", testLedgerDir)
+	testutil.AssertNoError(t, err, "")
+	sink.Close()
+}
+
+func TestFileSink(t *testing.T) {
+	os.RemoveAll(testLedgerDir)
+	defer os.RemoveAll(testLedgerDir)
+	writes := []*Write{
+		{BlockNumber: 1, TxNumber: 0, Namespace: "ns1", Key: "key1", Value: []byte("value1")},
+		{BlockNumber: 1, TxNumber: 1, Namespace: "ns1", Key: "key2", IsDelete: true},
+		{BlockNumber: 2, TxNumber: 0, Namespace: "ns2", Key: "key3", Value: []byte("value3")},
+	}
+	sink, err := newFileSink("ledger1", testLedgerDir)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertNoError(t, sink.Emit(1, writes[:2]), "")
+	sink.Close()
+
+	// the file is appended to when the sink is opened again
+	sink, err = newFileSink("ledger1", testLedgerDir)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertNoError(t, sink.Emit(2, writes[2:]), "")
+	sink.Close()
+
+	file, err := os.Open(filepath.Join(testLedgerDir, fileSinkName))
+	testutil.AssertNoError(t, err, "")
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	var readWrites []*Write
+	for scanner.Scan() {
+		write := &Write{}
+		testutil.AssertNoError(t, json.Unmarshal(scanner.Bytes(), write), "")
+		readWrites = append(readWrites, write)
+	}
+	testutil.AssertEquals(t, readWrites, writes)
+}
@@ -0,0 +1,70 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cdc
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// File is the name of the sink that appends the writes to a file of the ledger
+const File = "file"
+
+// fileSinkName is the name of the file, under the directory of the ledger, to which the file sink appends
+const fileSinkName = "cdc.json"
+
+func init() {
+	RegisterSinkFactory(File, newFileSink)
+}
+
+// fileSink appends the writes to a file, one JSON object per line, so that a pipeline can follow the file
+type fileSink struct {
+	file *os.File
+}
+
+func newFileSink(ledgerName string, ledgerDir string) (Sink, error) {
+	if err := os.MkdirAll(ledgerDir, 0755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(filepath.Join(ledgerDir, fileSinkName), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &fileSink{file}, nil
+}
+
+// Emit implements method in interface `Sink`. The writes of a block are synced to the file before returning
+func (s *fileSink) Emit(blockNum uint64, writes []*Write) error {
+	w := bufio.NewWriter(s.file)
+	encoder := json.NewEncoder(w)
+	for _, write := range writes {
+		if err := encoder.Encode(write); err != nil {
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return s.file.Sync()
+}
+
+// Close implements method in interface `Sink`
+func (s *fileSink) Close() {
+	s.file.Close()
+}
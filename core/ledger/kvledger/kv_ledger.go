@@ -17,18 +17,30 @@ limitations under the License.
 package kvledger
 
 import (
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/core/crypto/bccsp/factory"
 	"github.com/hyperledger/fabric/core/ledger"
 	"github.com/hyperledger/fabric/core/ledger/blkstorage"
 	"github.com/hyperledger/fabric/core/ledger/blkstorage/fsblkstorage"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/cdc"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/kvledgerconfig"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/couchdbtxmgmt"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/couchdbtxmgmt/couchdb"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/lockbasedtxmgmt"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
+	"github.com/hyperledger/fabric/core/ledger/util/encryption"
+	"github.com/hyperledger/fabric/core/metrics"
 	"github.com/hyperledger/fabric/protos"
+	putils "github.com/hyperledger/fabric/protos/utils"
 	logging "github.com/op/go-logging"
 )
 
@@ -39,6 +51,9 @@ type Conf struct {
 	blockStorageDir  string
 	maxBlockfileSize int
 	txMgrDBPath      string
+	// readOnly opens the block store and the key-value state database of an existing ledger without
+	// writing to them. A state held in CouchDB is not opened
+	readOnly bool
 }
 
 // NewConf constructs new `Conf`.
@@ -49,7 +64,7 @@ func NewConf(filesystemPath string, maxBlockfileSize int) *Conf {
 	}
 	blocksStorageDir := filesystemPath + "blocks"
 	txMgrDBPath := filesystemPath + "txMgmgt/db"
-	return &Conf{blocksStorageDir, maxBlockfileSize, txMgrDBPath}
+	return &Conf{blockStorageDir: blocksStorageDir, maxBlockfileSize: maxBlockfileSize, txMgrDBPath: txMgrDBPath}
 }
 
 // KVLedger provides an implementation of `ledger.ValidatedLedger`.
@@ -58,6 +73,15 @@ type KVLedger struct {
 	blockStore           blkstorage.BlockStore
 	txtmgmt              txmgmt.TxMgr
 	pendingBlockToCommit *protos.Block2
+	writeSinks           []*writeSink
+
+	// pendingInvalidTxs are the transactions removed from pendingBlockToCommit, whose validation codes are
+	// recorded at commit
+	pendingInvalidTxs []*protos.InvalidTransaction
+
+	// stopWarmUp is closed by Close to stop the warm up started by startWarmUp, warmUpDone waits for it
+	stopWarmUp chan struct{}
+	warmUpDone sync.WaitGroup
 }
 
 // NewKVLedger constructs new `KVLedger`
@@ -69,28 +93,108 @@ func NewKVLedger(conf *Conf) (*KVLedger, error) {
 		blkstorage.IndexableAttrBlockHash,
 		blkstorage.IndexableAttrBlockNum,
 		blkstorage.IndexableAttrTxID,
+		blkstorage.IndexableAttrBlockTxID,
 	}
 	indexConfig := &blkstorage.IndexConfig{AttrsToIndex: attrsToIndex}
 	blockStorageConf := fsblkstorage.NewConf(conf.blockStorageDir, conf.maxBlockfileSize)
+	blockStorageConf.IndexDBOptions = kvledgerconfig.GetLevelDBOptions("index")
+	blockStorageConf.ReadOnly = conf.readOnly
+	var err error
+	if kvledgerconfig.IsBlockEncryptionEnabled() {
+		if blockStorageConf.Encrypter, err = newEncrypter(kvledgerconfig.GetBlockEncryptionKeySKI()); err != nil {
+			return nil, err
+		}
+	}
 	blockStore := fsblkstorage.NewFsBlockStore(blockStorageConf, indexConfig)
 
+	var encrypter encryption.Encrypter
+	if kvledgerconfig.IsStateEncryptionEnabled() {
+		if encrypter, err = newEncrypter(kvledgerconfig.GetStateEncryptionKeySKI()); err != nil {
+			return nil, err
+		}
+	}
+
+	if kvledgerconfig.IsCouchDBEnabled() == true && conf.readOnly {
+		return &KVLedger{blockStore: blockStore}, nil
+	}
+
 	if kvledgerconfig.IsCouchDBEnabled() == true {
 		//By default we can talk to CouchDB with empty id and pw (""), or you can add your own id and password to talk to a secured CouchDB
 		logger.Debugf("===COUCHDB=== NewKVLedger() Using CouchDB instead of RocksDB...hardcoding and passing connection config for now")
 		//TODO Hardcoding and passing connection config for now, eventually this will be passed from external config
-		txmgmt := couchdbtxmgmt.NewCouchDBTxMgr(&couchdbtxmgmt.Conf{DBPath: conf.txMgrDBPath},
+		couchDBOptions := couchdb.ConnectionOptions{
+			MaxConnections: kvledgerconfig.GetCouchDBMaxConnections(),
+			RequestTimeout: kvledgerconfig.GetCouchDBRequestTimeout(),
+			MaxRetries:     kvledgerconfig.GetCouchDBMaxRetries(),
+			RetryBackoff:   kvledgerconfig.GetCouchDBRetryBackoff()}
+		txmgmt := couchdbtxmgmt.NewCouchDBTxMgr(&couchdbtxmgmt.Conf{DBPath: conf.txMgrDBPath, Encrypter: encrypter,
+			CouchDBOptions:      couchDBOptions,
+			MaxBatchUpdateSize:  kvledgerconfig.GetCouchDBMaxBatchUpdateSize(),
+			MaxBatchUpdateBytes: kvledgerconfig.GetCouchDBMaxBatchUpdateBytes(),
+			QueryLimits:         getQueryLimits()},
 			"127.0.0.1", //couchDB host
 			5984,        //couchDB port
 			"system",    //couchDB db name matches ledger name, TODO for now use system ledger, eventually allow passing in subledger name
 			"",          //enter couchDB id here
 			"")          //enter couchDB pw here
-		return &KVLedger{blockStore, txmgmt, nil}, nil
+		return &KVLedger{blockStore: blockStore, txtmgmt: txmgmt}, nil
+	}
+
+	// Fall back to using the lockbased transaction manager on a key-value state database
+	stateDBProvider, err := getStateDBProvider(kvledgerconfig.GetStateDatabase())
+	if err != nil {
+		return nil, err
 	}
+	if conf.readOnly {
+		if kvledgerconfig.GetStateDatabase() != statedb.GoLevelDB {
+			blockStore.Shutdown()
+			return nil, fmt.Errorf("State database %s cannot be opened read-only", kvledgerconfig.GetStateDatabase())
+		}
+		options := kvledgerconfig.GetLevelDBOptions("state")
+		options.ReadOnly = true
+		stateDBProvider = statedb.NewLevelDBProvider(options)
+	}
+	txmgmt := lockbasedtxmgmt.NewLockBasedTxMgr(&lockbasedtxmgmt.Conf{DBPath: conf.txMgrDBPath,
+		Encrypter: encrypter, StateDBProvider: stateDBProvider, StateTreeDepth: kvledgerconfig.GetStateTreeDepth(),
+		QueryLimits: getQueryLimits()})
+	return &KVLedger{blockStore: blockStore, txtmgmt: txmgmt}, nil
+
+}
+
+// getStateDBProvider returns the provider of the state database with the given name. The goleveldb
+// stores are opened with the configured options
+func getStateDBProvider(stateDatabase string) (statedb.Provider, error) {
+	if stateDatabase == statedb.GoLevelDB {
+		return statedb.NewLevelDBProvider(kvledgerconfig.GetLevelDBOptions("state")), nil
+	}
+	return statedb.GetProvider(stateDatabase)
+}
 
-	// Fall back to using RocksDB lockbased transaction manager
-	txmgmt := lockbasedtxmgmt.NewLockBasedTxMgr(&lockbasedtxmgmt.Conf{DBPath: conf.txMgrDBPath})
-	return &KVLedger{blockStore, txmgmt, nil}, nil
+// getQueryLimits returns the limits of the queries executed against the state database, as configured
+func getQueryLimits() txmgmt.QueryLimits {
+	return txmgmt.QueryLimits{
+		QueryResultLimit:   kvledgerconfig.GetQueryResultLimit(),
+		InternalQueryLimit: kvledgerconfig.GetInternalQueryLimit(),
+		DefaultPageSize:    kvledgerconfig.GetDefaultPageSize(),
+		MaxPageSize:        kvledgerconfig.GetMaxPageSize()}
+}
 
+// newEncrypter returns an `encryption.Encrypter` that uses the AES key with the given
+// hex encoded SKI from the default BCCSP
+func newEncrypter(keySKI string) (encryption.Encrypter, error) {
+	ski, err := hex.DecodeString(keySKI)
+	if err != nil || len(ski) == 0 {
+		return nil, fmt.Errorf("Invalid encryption key SKI [%s]", keySKI)
+	}
+	csp, err := factory.GetDefault()
+	if err != nil {
+		return nil, fmt.Errorf("Error getting BCCSP for encryption: %s", err)
+	}
+	key, err := csp.GetKey(ski)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting encryption key: %s", err)
+	}
+	return encryption.NewBCCSPEncrypter(csp, key)
 }
 
 // GetTransactionByID retrieves a transaction by id
@@ -98,6 +202,21 @@ func (l *KVLedger) GetTransactionByID(txID string) (*protos.Transaction2, error)
 	return l.blockStore.RetrieveTxByID(txID)
 }
 
+// GetBlockByTxID returns the block that contains the transaction with the given id
+func (l *KVLedger) GetBlockByTxID(txID string) (*protos.Block2, error) {
+	return l.blockStore.RetrieveBlockByTxID(txID)
+}
+
+// GetTxValidationCodeByTxID returns the validation code recorded at commit for the transaction with the given id,
+// which is not in the ledger if it was invalid
+func (l *KVLedger) GetTxValidationCodeByTxID(txID string) (protos.TxValidationCode, error) {
+	store, ok := l.blockStore.(blkstorage.TxValidationCodeStore)
+	if !ok {
+		return -1, errors.New("Block store does not support validation codes")
+	}
+	return store.GetTxValidationCode(txID)
+}
+
 // GetBlockchainInfo returns basic info about blockchain
 func (l *KVLedger) GetBlockchainInfo() (*protos.BlockchainInfo, error) {
 	return l.blockStore.GetBlockchainInfo()
@@ -139,6 +258,256 @@ func (l *KVLedger) NewQueryExecutor() (ledger.QueryExecutor, error) {
 	return l.txtmgmt.NewQueryExecutor()
 }
 
+// CreateStateDBIndexes creates the given index definitions for the chaincode `namespace`
+// in the state database. This is a no-op if the state database does not support indexes
+func (l *KVLedger) CreateStateDBIndexes(namespace string, indexDefinitions [][]byte) error {
+	if len(indexDefinitions) == 0 {
+		return nil
+	}
+	indexCapable, ok := l.txtmgmt.(txmgmt.IndexCapable)
+	if !ok {
+		logger.Debugf("State database does not support indexes, ignoring %d index definitions for namespace %s", len(indexDefinitions), namespace)
+		return nil
+	}
+	return indexCapable.CreateIndexes(namespace, indexDefinitions)
+}
+
+// WarmUpStateDBIndexes brings the indexes of the state database up to date, so that the first
+// queries after a restart or a chaincode deploy do not wait for the indexes to be built.
+// This is a no-op if the state database does not support indexes
+func (l *KVLedger) WarmUpStateDBIndexes() error {
+	indexCapable, ok := l.txtmgmt.(txmgmt.IndexCapable)
+	if !ok {
+		return nil
+	}
+	return indexCapable.WarmUpIndexes()
+}
+
+// WarmUpState reads the keys written in the last `numBlocks` blocks, so that the state database caches
+// hold the recently used state before the first transactions are simulated. It returns the number of keys read
+func (l *KVLedger) WarmUpState(numBlocks uint64) (int, error) {
+	return l.warmUpState(numBlocks, nil)
+}
+
+// errWarmUpStopped is returned by warmUpState when the warm up is stopped as the ledger is closed
+var errWarmUpStopped = errors.New("Warm up stopped")
+
+// warmUpState implements WarmUpState. It returns errWarmUpStopped once the stop channel is closed
+func (l *KVLedger) warmUpState(numBlocks uint64, stop <-chan struct{}) (int, error) {
+	bcInfo, err := l.blockStore.GetBlockchainInfo()
+	if err != nil {
+		return 0, err
+	}
+	var startBlockNumber uint64 = 1
+	if bcInfo.Height > numBlocks {
+		startBlockNumber = bcInfo.Height - numBlocks + 1
+	}
+
+	writtenKeys := make(map[string]map[string]bool)
+	for blockNumber := startBlockNumber; blockNumber <= bcInfo.Height; blockNumber++ {
+		if isStopped(stop) {
+			return 0, errWarmUpStopped
+		}
+		block, err := l.blockStore.RetrieveBlockByNumber(blockNumber)
+		if err != nil {
+			return 0, err
+		}
+		if err = addWrittenKeys(block, writtenKeys); err != nil {
+			return 0, err
+		}
+	}
+
+	queryExecutor, err := l.txtmgmt.NewQueryExecutor()
+	if err != nil {
+		return 0, err
+	}
+	numKeys := 0
+	for ns, keySet := range writtenKeys {
+		if isStopped(stop) {
+			return numKeys, errWarmUpStopped
+		}
+		keys := make([]string, 0, len(keySet))
+		for key := range keySet {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		if _, err = queryExecutor.GetStateMultipleKeys(ns, keys); err != nil {
+			return numKeys, err
+		}
+		numKeys += len(keys)
+	}
+	logger.Debugf("Warmed up the state with %d keys written in blocks [%d-%d]", numKeys, startBlockNumber, bcInfo.Height)
+	return numKeys, nil
+}
+
+// startWarmUp warms up the ledger in the background. The warm up is stopped and waited for by Close
+func (l *KVLedger) startWarmUp() {
+	stop := make(chan struct{})
+	l.stopWarmUp = stop
+	l.warmUpDone.Add(1)
+	go func() {
+		defer l.warmUpDone.Done()
+		l.warmUp(stop)
+	}()
+}
+
+// isStopped returns whether the stop channel is closed. A nil channel is never closed
+func isStopped(stop <-chan struct{}) bool {
+	select {
+	case <-stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// warmUp warms up the state and the indexes of the state database as configured, until the stop channel
+// is closed. Failures are only logged as the ledger remains usable, just slower at first
+func (l *KVLedger) warmUp(stop <-chan struct{}) {
+	if numBlocks := kvledgerconfig.GetStateWarmupBlocks(); numBlocks > 0 {
+		if numKeys, err := l.warmUpState(numBlocks, stop); err == errWarmUpStopped {
+			logger.Debugf("Warm up of the state stopped as the ledger is closed")
+			return
+		} else if err != nil {
+			logger.Warningf("Error warming up the state: %s", err)
+		} else {
+			logger.Infof("Warmed up the state with %d keys from the last %d blocks", numKeys, numBlocks)
+		}
+	}
+	if kvledgerconfig.IsIndexWarmupEnabled() && !isStopped(stop) {
+		if err := l.WarmUpStateDBIndexes(); err != nil {
+			logger.Warningf("Error warming up the state database indexes: %s", err)
+		}
+	}
+}
+
+// addWrittenKeys adds the keys written by the transactions in the block to the keys by namespace
+func addWrittenKeys(block *protos.Block2, writtenKeys map[string]map[string]bool) error {
+	return visitWrites(block, func(txNum int, ns string, kvWrite *txmgmt.KVWrite) {
+		if writtenKeys[ns] == nil {
+			writtenKeys[ns] = make(map[string]bool)
+		}
+		writtenKeys[ns][kvWrite.Key] = true
+	})
+}
+
+// visitWrites calls the function for each write of the transactions in the block, in the order of the transactions
+func visitWrites(block *protos.Block2, f func(txNum int, ns string, kvWrite *txmgmt.KVWrite)) error {
+	for txNum, txBytes := range block.Transactions {
+		tx := &protos.Transaction2{}
+		if err := proto.Unmarshal(txBytes, tx); err != nil {
+			return err
+		}
+		for _, action := range tx.Actions {
+			_, respPayload, err := putils.GetPayloads(action)
+			if err != nil {
+				return err
+			}
+			txRWSet := &txmgmt.TxReadWriteSet{}
+			if err = txRWSet.Unmarshal(respPayload.Results); err != nil {
+				return err
+			}
+			for _, nsRWSet := range txRWSet.NsRWs {
+				for _, kvWrite := range nsRWSet.Writes {
+					f(txNum, nsRWSet.NameSpace, kvWrite)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Verify checks the integrity of the ledger. The block store is walked to recompute the block hashes and to check
+// the hash chain and the indexes, and the savepoint of the state database is checked against the height of the
+// block store. It returns the number of blocks verified; the first corrupt block is reported as a `*blkstorage.CorruptBlockError`
+func (l *KVLedger) Verify() (uint64, error) {
+	verifier, ok := l.blockStore.(blkstorage.Verifier)
+	if !ok {
+		return 0, errors.New("Block store does not support verification")
+	}
+	numBlocks, err := verifier.VerifyBlocks()
+	if err != nil {
+		return numBlocks, err
+	}
+
+	savepointCapable, ok := l.txtmgmt.(txmgmt.SavepointCapable)
+	if !ok {
+		logger.Warning("State database does not record a savepoint, its height is not verified")
+		return numBlocks, nil
+	}
+	savepoint, err := savepointCapable.GetLastSavepoint()
+	if err != nil {
+		return numBlocks, err
+	}
+	if savepoint == 0 && numBlocks != 0 {
+		logger.Warning("State database has no savepoint, its height is not verified")
+		return numBlocks, nil
+	}
+	if savepoint != numBlocks {
+		return numBlocks, fmt.Errorf("State database is at block [%d] but the block store is at block [%d]", savepoint, numBlocks)
+	}
+	return numBlocks, nil
+}
+
+// RotateBlockStoreKey switches the block store to a new key for encrypting the blocks added from now on.
+// An error is returned if the block store does not store the blocks encrypted
+func (l *KVLedger) RotateBlockStoreKey() error {
+	keyRotator, ok := l.blockStore.(blkstorage.EncryptionKeyRotator)
+	if !ok {
+		return errors.New("Block store does not support encryption")
+	}
+	return keyRotator.RotateEncryptionKey()
+}
+
+// Compact compacts the state database and the index database of the block store, so that the space held by
+// deleted and overwritten entries is reclaimed, e.g. after large deletes. The sizes of the databases before and
+// after the compaction are returned. The ledger remains in use during the compaction
+func (l *KVLedger) Compact() (*protos.LedgerCompaction, error) {
+	compactable, ok := l.txtmgmt.(txmgmt.Compactable)
+	if !ok {
+		return nil, errors.New("State database does not support compaction")
+	}
+	indexCompacter, ok := l.blockStore.(blkstorage.IndexCompacter)
+	if !ok {
+		return nil, errors.New("Block store does not support compacting the index")
+	}
+	compaction := &protos.LedgerCompaction{}
+	var err error
+	if compaction.StateSizeBefore, compaction.StateSizeAfter, err = compactable.CompactState(); err != nil {
+		return nil, fmt.Errorf("Error compacting the state database: %s", err)
+	}
+	if compaction.IndexSizeBefore, compaction.IndexSizeAfter, err = indexCompacter.CompactIndex(); err != nil {
+		return nil, fmt.Errorf("Error compacting the index database: %s", err)
+	}
+	return compaction, nil
+}
+
+// GetStateWithProof returns the committed value of the key in the namespace along with a proof against
+// the state root recorded for the last committed block. The proof can be checked with `buckettree.VerifyStateProof`
+func (l *KVLedger) GetStateWithProof(namespace string, key string) (*protos.StateProof, error) {
+	proofCapable, ok := l.txtmgmt.(txmgmt.StateProofCapable)
+	if !ok {
+		return nil, errors.New("State database does not support state proofs")
+	}
+	return proofCapable.GetStateWithProof(namespace, key)
+}
+
+// GetStateRoot returns the root of the hash tree over the state after the commit of the block `blockNum`
+func (l *KVLedger) GetStateRoot(blockNum uint64) (*protos.StateRoot, error) {
+	proofCapable, ok := l.txtmgmt.(txmgmt.StateProofCapable)
+	if !ok {
+		return nil, errors.New("State database does not support state proofs")
+	}
+	root, err := proofCapable.GetStateRoot(blockNum)
+	if err != nil {
+		return nil, err
+	}
+	if root == nil {
+		return nil, fmt.Errorf("No state root recorded for block [%d]", blockNum)
+	}
+	return &protos.StateRoot{BlockNumber: blockNum, Root: root}, nil
+}
+
 // RemoveInvalidTransactionsAndPrepare validates all the transactions in the given block
 // and returns a block that contains only valid transactions and a list of transactions that are invalid
 func (l *KVLedger) RemoveInvalidTransactionsAndPrepare(block *protos.Block2) (*protos.Block2, []*protos.InvalidTransaction, error) {
@@ -148,6 +517,7 @@ func (l *KVLedger) RemoveInvalidTransactionsAndPrepare(block *protos.Block2) (*p
 	validBlock, invalidTxs, err = l.txtmgmt.ValidateAndPrepare(block)
 	if err == nil {
 		l.pendingBlockToCommit = validBlock
+		l.pendingInvalidTxs = invalidTxs
 	}
 	return validBlock, invalidTxs, err
 }
@@ -164,21 +534,283 @@ func (l *KVLedger) Commit() error {
 	}
 
 	logger.Debugf("Committing block to state database")
-	if err := l.txtmgmt.Commit(); err != nil {
+	bcInfo, err := l.blockStore.GetBlockchainInfo()
+	if err != nil {
+		return err
+	}
+	if err := l.commitState(bcInfo.Height); err != nil {
 		panic(fmt.Errorf(`Error during commit to txmgr:%s`, err))
 	}
+	// the local metadata missing after a failure is recorded along with the next block
+	if err := l.recordLocalMetadata(bcInfo.Height, l.pendingBlockToCommit); err != nil {
+		logger.Warningf("Error recording the local metadata of block [%d]: %s", bcInfo.Height, err)
+	}
+	if err := l.recordTxValidationCodes(bcInfo.Height, l.pendingBlockToCommit, l.pendingInvalidTxs); err != nil {
+		logger.Warningf("Error recording the validation codes of the transactions of block [%d]: %s", bcInfo.Height, err)
+	}
+	l.emitWrites(bcInfo.Height, l.pendingBlockToCommit)
 	l.pendingBlockToCommit = nil
+	l.pendingInvalidTxs = nil
+	return nil
+}
+
+// txValidationCodes maps the causes of the invalid transactions to their validation codes
+var txValidationCodes = map[protos.InvalidTransaction_Cause]protos.TxValidationCode{
+	protos.InvalidTransaction_TxIdAlreadyExists:      protos.TxValidationCode_TxIdAlreadyExists,
+	protos.InvalidTransaction_RWConflictDuringCommit: protos.TxValidationCode_RWConflictDuringCommit,
+}
+
+// recordTxValidationCodes records the validation codes of the valid transactions of the block `blockNum` and of
+// the invalid transactions removed from it, by transaction id
+func (l *KVLedger) recordTxValidationCodes(blockNum uint64, block *protos.Block2, invalidTxs []*protos.InvalidTransaction) error {
+	store, ok := l.blockStore.(blkstorage.TxValidationCodeStore)
+	if !ok {
+		return nil
+	}
+	var txIDs []string
+	var codes []protos.TxValidationCode
+	for _, txBytes := range block.Transactions {
+		tx := &protos.Transaction2{}
+		if err := proto.Unmarshal(txBytes, tx); err != nil {
+			return err
+		}
+		txID, err := putils.GetTxID(tx)
+		if err != nil {
+			return err
+		}
+		txIDs = append(txIDs, txID)
+		codes = append(codes, protos.TxValidationCode_Valid)
+	}
+	for _, invalidTx := range invalidTxs {
+		txID, err := putils.GetTxID(invalidTx.Transaction)
+		if err != nil {
+			return err
+		}
+		txIDs = append(txIDs, txID)
+		codes = append(codes, txValidationCodes[invalidTx.Cause])
+	}
+	return store.SetTxValidationCodes(blockNum, txIDs, codes)
+}
+
+// commitState commits the state changes of the block `blockNum`, along with the
+// block number as the savepoint if the state database supports savepoints
+func (l *KVLedger) commitState(blockNum uint64) error {
+	savepointCapable, ok := l.txtmgmt.(txmgmt.SavepointCapable)
+	if !ok {
+		return l.txtmgmt.Commit()
+	}
+	return savepointCapable.CommitWithSavepoint(blockNum)
+}
+
+// RebuildState drops the state database and rebuilds it by validating and committing the state
+// changes of all the blocks in the block store. The ledger should not be in use during the rebuild
+func (l *KVLedger) RebuildState() error {
+	resettable, ok := l.txtmgmt.(txmgmt.Resettable)
+	if !ok {
+		return errors.New("State database does not support resetting the state")
+	}
+	if err := resettable.ResetState(); err != nil {
+		return err
+	}
+	bcInfo, err := l.blockStore.GetBlockchainInfo()
+	if err != nil {
+		return err
+	}
+	if err = l.replayBlocks(1, bcInfo.Height, false); err != nil {
+		return err
+	}
+	logger.Debugf("Rebuilt the state from %d blocks", bcInfo.Height)
+	return nil
+}
+
+// replayBlocksProgressInterval is the interval of the progress logs of replayBlocks
+var replayBlocksProgressInterval = 10 * time.Second
+
+// replayBlocks validates and commits the state changes of the blocks `from` to `to` of the block store,
+// logging its progress every replayBlocksProgressInterval. With `emit`, the writes of every block are
+// emitted to the write sinks once it is committed
+func (l *KVLedger) replayBlocks(from uint64, to uint64, emit bool) error {
+	start := time.Now()
+	lastProgress := start
+	for blockNum := from; blockNum <= to; blockNum++ {
+		block, err := l.blockStore.RetrieveBlockByNumber(blockNum)
+		if err != nil {
+			return err
+		}
+		if _, _, err = l.txtmgmt.ValidateAndPrepare(block); err != nil {
+			return fmt.Errorf("Error validating block [%d]: %s", blockNum, err)
+		}
+		if err = l.commitState(blockNum); err != nil {
+			return fmt.Errorf("Error committing the state of block [%d]: %s", blockNum, err)
+		}
+		if emit {
+			l.emitWrites(blockNum, block)
+		}
+		if time.Since(lastProgress) >= replayBlocksProgressInterval {
+			lastProgress = time.Now()
+			logger.Infof("Replaying blocks into the state database: block [%d] of [%d]", blockNum, to)
+		}
+	}
+	if to >= from {
+		logger.Infof("Replayed blocks [%d] to [%d] into the state database in %s", from, to, time.Since(start))
+	}
 	return nil
 }
 
+// RecoverState brings the state database to the height of the block store, which it may not be at after
+// a failure between the commits of a block to the block store and to the state database or after the
+// restore of a backup. A state database behind the block store is caught up by committing the state
+// changes of the missing blocks, whose writes are emitted to the write sinks as they were not before the
+// failure; a missing state, or one ahead of the block store, is rebuilt from all the blocks. It returns the number of blocks replayed. The ledger should not be in use during the recovery
+func (l *KVLedger) RecoverState() (uint64, error) {
+	savepointCapable, ok := l.txtmgmt.(txmgmt.SavepointCapable)
+	if !ok {
+		logger.Info("State database does not record a savepoint, its height is not checked")
+		return 0, nil
+	}
+	savepoint, err := savepointCapable.GetLastSavepoint()
+	if err != nil {
+		return 0, err
+	}
+	bcInfo, err := l.blockStore.GetBlockchainInfo()
+	if err != nil {
+		return 0, err
+	}
+
+	switch {
+	case savepoint == bcInfo.Height:
+		return 0, nil
+	case savepoint == 0 || savepoint > bcInfo.Height:
+		logger.Warningf("State database is at block [%d] but the block store is at block [%d], rebuilding the state",
+			savepoint, bcInfo.Height)
+		return bcInfo.Height, l.RebuildState()
+	default:
+		logger.Warningf("State database is at block [%d] but the block store is at block [%d], catching up the state",
+			savepoint, bcInfo.Height)
+		return bcInfo.Height - savepoint, l.replayBlocks(savepoint+1, bcInfo.Height, true)
+	}
+}
+
+// RollbackTo removes all the blocks after the block `blockNum` and rebuilds the state as of that block.
+// The ledger should not be in use during the rollback
+func (l *KVLedger) RollbackTo(blockNum uint64) error {
+	truncater, ok := l.blockStore.(blkstorage.Truncater)
+	if !ok {
+		return errors.New("Block store does not support removing blocks")
+	}
+	if _, ok = l.txtmgmt.(txmgmt.Resettable); !ok {
+		return errors.New("State database does not support resetting the state")
+	}
+	if err := truncater.TruncateToBlock(blockNum); err != nil {
+		return err
+	}
+	return l.RebuildState()
+}
+
+// writeSink is a sink of the ledger along with the first block that it failed to take, 0 if none
+type writeSink struct {
+	name        string
+	sink        cdc.Sink
+	failedBlock uint64
+	errors      metrics.Counter
+}
+
+// AddWriteSink adds a sink to which the writes of the valid transactions are emitted after the commit of every
+// block, for change data capture. The sink is closed when the ledger is closed
+func (l *KVLedger) AddWriteSink(name string, sink cdc.Sink) {
+	emitErrors := metrics.GetProvider().NewCounter(metrics.CounterOpts{
+		Namespace:  "ledger",
+		Name:       "cdc_emit_errors",
+		Help:       "The number of failures to emit the writes of a block to a change data capture sink.",
+		LabelNames: []string{"sink"},
+	}).With("sink", name)
+	l.writeSinks = append(l.writeSinks, &writeSink{name: name, sink: sink, errors: emitErrors})
+}
+
+// emitWrites emits the writes of the committed block to the sinks. As the block is already committed, a sink
+// that fails is not emitted the following blocks until it takes the block it failed on, which is emitted again
+// with the next commit. The failures are logged and counted
+func (l *KVLedger) emitWrites(blockNum uint64, block *protos.Block2) {
+	if len(l.writeSinks) == 0 {
+		return
+	}
+	writes, err := blockWrites(blockNum, block)
+	if err != nil {
+		logger.Errorf("Error reading the writes of block [%d] for change data capture: %s", blockNum, err)
+		return
+	}
+	for _, s := range l.writeSinks {
+		if err = l.emitWritesToSink(s, blockNum, writes); err != nil {
+			s.errors.Add(1)
+			logger.Errorf("Error emitting the writes of block [%d] to the change data capture sink [%s], "+
+				"the block is emitted again with the next commit: %s", s.failedBlock, s.name, err)
+		}
+	}
+}
+
+// emitWritesToSink emits to the sink the writes of the blocks from the block it failed on, if any, then the
+// writes of the block `blockNum`
+func (l *KVLedger) emitWritesToSink(s *writeSink, blockNum uint64, writes []*cdc.Write) error {
+	if s.failedBlock == 0 {
+		s.failedBlock = blockNum
+	}
+	for ; s.failedBlock < blockNum; s.failedBlock++ {
+		failedWrites, err := l.GetBlockWrites(s.failedBlock)
+		if err == nil {
+			err = s.sink.Emit(s.failedBlock, failedWrites)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err := s.sink.Emit(blockNum, writes); err != nil {
+		return err
+	}
+	s.failedBlock = 0
+	return nil
+}
+
+// GetBlockWrites returns the writes of the valid transactions of the committed block `blockNum`,
+// in the order of the transactions
+func (l *KVLedger) GetBlockWrites(blockNum uint64) ([]*cdc.Write, error) {
+	block, err := l.blockStore.RetrieveBlockByNumber(blockNum)
+	if err != nil {
+		return nil, err
+	}
+	return blockWrites(blockNum, block)
+}
+
+func blockWrites(blockNum uint64, block *protos.Block2) ([]*cdc.Write, error) {
+	writes := []*cdc.Write{}
+	err := visitWrites(block, func(txNum int, ns string, kvWrite *txmgmt.KVWrite) {
+		writes = append(writes, &cdc.Write{BlockNumber: blockNum, TxNumber: txNum, Namespace: ns,
+			Key: kvWrite.Key, IsDelete: kvWrite.IsDelete, Value: kvWrite.Value})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return writes, nil
+}
+
 // Rollback rollbacks the changes caused by the last invocation to method `RemoveInvalidTransactionsAndPrepare`
 func (l *KVLedger) Rollback() {
 	l.txtmgmt.Rollback()
 	l.pendingBlockToCommit = nil
+	l.pendingInvalidTxs = nil
 }
 
 // Close closes `KVLedger`
 func (l *KVLedger) Close() {
+	if l.stopWarmUp != nil {
+		close(l.stopWarmUp)
+		l.stopWarmUp = nil
+		l.warmUpDone.Wait()
+	}
 	l.blockStore.Shutdown()
-	l.txtmgmt.Shutdown()
+	if l.txtmgmt != nil {
+		l.txtmgmt.Shutdown()
+	}
+	for _, s := range l.writeSinks {
+		s.sink.Close()
+	}
 }
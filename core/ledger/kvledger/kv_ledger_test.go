@@ -17,10 +17,18 @@ limitations under the License.
 package kvledger
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
 	"testing"
 
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/cdc"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt"
 	"github.com/hyperledger/fabric/core/ledger/testutil"
 	"github.com/hyperledger/fabric/protos"
+	putils "github.com/hyperledger/fabric/protos/utils"
 )
 
 func TestKVLedgerBlockStorage(t *testing.T) {
@@ -76,4 +84,469 @@ func TestKVLedgerBlockStorage(t *testing.T) {
 
 	b2, _ = ledger.GetBlockByNumber(2)
 	testutil.AssertEquals(t, b2, block2)
+
+	tx2 := &protos.Transaction2{}
+	proto.Unmarshal(block2.Transactions[0], tx2)
+	txID2, _ := putils.GetTxID(tx2)
+	b2, _ = ledger.GetBlockByTxID(txID2)
+	testutil.AssertEquals(t, b2, block2)
+}
+
+func TestKVLedgerTxValidationCodes(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	simulator, _ := ledger.NewTxSimulator()
+	simulator.SetState("ns1", "key1", []byte("value1"))
+	simulator.Done()
+	simRes, _ := simulator.GetTxSimulationResults()
+	ledger.RemoveInvalidTransactionsAndPrepare(testutil.ConstructBlockForSimulationResults(t, [][]byte{simRes}))
+	ledger.Commit()
+
+	// the second transaction conflicts with the first one and is removed from the block
+	simulator1, _ := ledger.NewTxSimulator()
+	simulator1.GetState("ns1", "key1")
+	simulator1.SetState("ns1", "key1", []byte("value2"))
+	simulator1.Done()
+	simRes1, _ := simulator1.GetTxSimulationResults()
+	simulator2, _ := ledger.NewTxSimulator()
+	simulator2.GetState("ns1", "key1")
+	simulator2.SetState("ns1", "key1", []byte("value3"))
+	simulator2.Done()
+	simRes2, _ := simulator2.GetTxSimulationResults()
+	block2 := testutil.ConstructBlockForSimulationResults(t, [][]byte{simRes1, simRes2})
+	validBlock, invalidTxs, err := ledger.RemoveInvalidTransactionsAndPrepare(block2)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, len(validBlock.Transactions), 1)
+	testutil.AssertEquals(t, len(invalidTxs), 1)
+	testutil.AssertNoError(t, ledger.Commit(), "")
+
+	validTx := &protos.Transaction2{}
+	proto.Unmarshal(block2.Transactions[0], validTx)
+	validTxID, _ := putils.GetTxID(validTx)
+	invalidTxID, _ := putils.GetTxID(invalidTxs[0].Transaction)
+
+	code, err := ledger.GetTxValidationCodeByTxID(validTxID)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, code, protos.TxValidationCode_Valid)
+	code, err = ledger.GetTxValidationCodeByTxID(invalidTxID)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, code, protos.TxValidationCode_RWConflictDuringCommit)
+	_, err = ledger.GetTxValidationCodeByTxID("unknown")
+	testutil.AssertError(t, err, "The validation code of an unknown transaction should not be returned")
+
+	// the codes are removed along with the block
+	testutil.AssertNoError(t, ledger.RollbackTo(1), "")
+	_, err = ledger.GetTxValidationCodeByTxID(invalidTxID)
+	testutil.AssertError(t, err, "The validation code of a transaction of a removed block should not be returned")
+}
+
+func TestKVLedgerWarmUpState(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	numKeys, err := ledger.WarmUpState(10)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, numKeys, 0)
+
+	for _, keys := range [][]string{{"key1", "key2"}, {"key2", "key3"}, {"key4"}} {
+		simulator, _ := ledger.NewTxSimulator()
+		for _, key := range keys {
+			simulator.SetState("ns1", key, []byte("value"))
+		}
+		simulator.Done()
+		simRes, _ := simulator.GetTxSimulationResults()
+		block := testutil.ConstructBlockForSimulationResults(t, [][]byte{simRes})
+		ledger.RemoveInvalidTransactionsAndPrepare(block)
+		ledger.Commit()
+	}
+
+	numKeys, err = ledger.WarmUpState(2)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, numKeys, 3)
+
+	numKeys, err = ledger.WarmUpState(10)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, numKeys, 4)
+
+	testutil.AssertNoError(t, ledger.WarmUpStateDBIndexes(), "")
+
+	stop := make(chan struct{})
+	close(stop)
+	_, err = ledger.warmUpState(10, stop)
+	testutil.AssertEquals(t, err, errWarmUpStopped)
+}
+
+func TestKVLedgerCloseStopsWarmUp(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	ledger.startWarmUp()
+	ledger.Close()
+	testutil.AssertNil(t, ledger.stopWarmUp)
+	// closing again does not wait for the warm up once more
+	ledger.Close()
+}
+
+func TestKVLedgerVerify(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	for _, value := range []string{"value1", "value2", "value3"} {
+		simulator, _ := ledger.NewTxSimulator()
+		simulator.SetState("ns1", "key1", []byte(value))
+		simulator.Done()
+		simRes, _ := simulator.GetTxSimulationResults()
+		block := testutil.ConstructBlockForSimulationResults(t, [][]byte{simRes})
+		ledger.RemoveInvalidTransactionsAndPrepare(block)
+		ledger.Commit()
+	}
+
+	numBlocks, err := ledger.Verify()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, numBlocks, uint64(3))
+
+	// the state database is behind the block store
+	simulator, _ := ledger.NewTxSimulator()
+	simulator.SetState("ns1", "key1", []byte("value4"))
+	simulator.Done()
+	simRes, _ := simulator.GetTxSimulationResults()
+	block := testutil.ConstructBlockForSimulationResults(t, [][]byte{simRes})
+	ledger.blockStore.AddBlock(block)
+	_, err = ledger.Verify()
+	testutil.AssertError(t, err, "Expected an error for the state database behind the block store")
+}
+
+func TestKVLedgerRecoverState(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+
+	newBlock := func(value string) *protos.Block2 {
+		simulator, _ := ledger.NewTxSimulator()
+		simulator.SetState("ns1", "key1", []byte(value))
+		simulator.Done()
+		simRes, _ := simulator.GetTxSimulationResults()
+		return testutil.ConstructBlockForSimulationResults(t, [][]byte{simRes})
+	}
+	for _, value := range []string{"value1", "value2", "value3"} {
+		ledger.RemoveInvalidTransactionsAndPrepare(newBlock(value))
+		ledger.Commit()
+	}
+	numBlocks, err := ledger.RecoverState()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, numBlocks, uint64(0))
+
+	// the state database is behind the block store, the writes of the replayed block are emitted
+	sink := &testWriteSink{}
+	ledger.AddWriteSink("test", sink)
+	ledger.blockStore.AddBlock(newBlock("value4"))
+	numBlocks, err = ledger.RecoverState()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, numBlocks, uint64(1))
+	testutil.AssertEquals(t, sink.writes, []*cdc.Write{
+		{BlockNumber: 4, TxNumber: 0, Namespace: "ns1", Key: "key1", Value: []byte("value4")},
+	})
+	_, err = ledger.Verify()
+	testutil.AssertNoError(t, err, "")
+	queryExecutor, _ := ledger.NewQueryExecutor()
+	value, _ := queryExecutor.GetState("ns1", "key1")
+	testutil.AssertEquals(t, value, []byte("value4"))
+	ledger.Close()
+
+	// the state database is missing
+	os.RemoveAll(env.conf.txMgrDBPath)
+	ledger, _ = NewKVLedger(env.conf)
+	defer ledger.Close()
+	numBlocks, err = ledger.RecoverState()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, numBlocks, uint64(4))
+	queryExecutor, _ = ledger.NewQueryExecutor()
+	value, _ = queryExecutor.GetState("ns1", "key1")
+	testutil.AssertEquals(t, value, []byte("value4"))
+}
+
+func TestKVLedgerRollbackTo(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	for i, value := range []string{"value1", "value2", "value3", "value4"} {
+		simulator, _ := ledger.NewTxSimulator()
+		simulator.SetState("ns1", "key1", []byte(value))
+		simulator.SetState("ns1", fmt.Sprintf("key%d", i+2), []byte(value))
+		simulator.Done()
+		simRes, _ := simulator.GetTxSimulationResults()
+		block := testutil.ConstructBlockForSimulationResults(t, [][]byte{simRes})
+		ledger.RemoveInvalidTransactionsAndPrepare(block)
+		ledger.Commit()
+	}
+
+	err := ledger.RollbackTo(4)
+	testutil.AssertError(t, err, "Expected an error while rolling back to the last block")
+
+	err = ledger.RollbackTo(2)
+	testutil.AssertNoError(t, err, "")
+	bcInfo, _ := ledger.GetBlockchainInfo()
+	testutil.AssertEquals(t, bcInfo.Height, uint64(2))
+	numBlocks, err := ledger.Verify()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, numBlocks, uint64(2))
+
+	queryExecutor, _ := ledger.NewQueryExecutor()
+	value, _ := queryExecutor.GetState("ns1", "key1")
+	testutil.AssertEquals(t, value, []byte("value2"))
+	value, _ = queryExecutor.GetState("ns1", "key3")
+	testutil.AssertEquals(t, value, []byte("value2"))
+	value, _ = queryExecutor.GetState("ns1", "key4")
+	testutil.AssertNil(t, value)
+
+	// the ledger accepts new blocks after the rollback
+	simulator, _ := ledger.NewTxSimulator()
+	simulator.SetState("ns1", "key1", []byte("value5"))
+	simulator.Done()
+	simRes, _ := simulator.GetTxSimulationResults()
+	block := testutil.ConstructBlockForSimulationResults(t, [][]byte{simRes})
+	_, invalidTxs, err := ledger.RemoveInvalidTransactionsAndPrepare(block)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, len(invalidTxs), 0)
+	testutil.AssertNoError(t, ledger.Commit(), "")
+	numBlocks, err = ledger.Verify()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, numBlocks, uint64(3))
+}
+
+func TestKVLedgerCompact(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	value := make([]byte, 1024)
+	simulator, _ := ledger.NewTxSimulator()
+	for i := 0; i < 500; i++ {
+		simulator.SetState("ns1", fmt.Sprintf("key%d", i), value)
+	}
+	simulator.Done()
+	simRes, _ := simulator.GetTxSimulationResults()
+	ledger.RemoveInvalidTransactionsAndPrepare(testutil.ConstructBlockForSimulationResults(t, [][]byte{simRes}))
+	ledger.Commit()
+
+	simulator, _ = ledger.NewTxSimulator()
+	for i := 1; i < 500; i++ {
+		simulator.DeleteState("ns1", fmt.Sprintf("key%d", i))
+	}
+	simulator.Done()
+	simRes, _ = simulator.GetTxSimulationResults()
+	block2 := testutil.ConstructBlockForSimulationResults(t, [][]byte{simRes})
+	ledger.RemoveInvalidTransactionsAndPrepare(block2)
+	ledger.Commit()
+
+	compaction, err := ledger.Compact()
+	testutil.AssertNoError(t, err, "")
+	if compaction.StateSizeAfter >= compaction.StateSizeBefore {
+		t.Fatalf("Expected the compaction to reclaim space of the state database, the size went from %d to %d bytes",
+			compaction.StateSizeBefore, compaction.StateSizeAfter)
+	}
+	testutil.AssertNotEquals(t, compaction.IndexSizeAfter, int64(0))
+
+	queryExecutor, _ := ledger.NewQueryExecutor()
+	retrievedValue, _ := queryExecutor.GetState("ns1", "key0")
+	testutil.AssertSame(t, bytes.Equal(retrievedValue, value), true)
+	retrievedValue, _ = queryExecutor.GetState("ns1", "key1")
+	testutil.AssertNil(t, retrievedValue)
+	tx2 := &protos.Transaction2{}
+	proto.Unmarshal(block2.Transactions[0], tx2)
+	txID2, _ := putils.GetTxID(tx2)
+	b2, _ := ledger.GetBlockByTxID(txID2)
+	testutil.AssertNotNil(t, b2)
+}
+
+func TestKVLedgerBlockLocalMetadata(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	_, err := ledger.GetBlockLocalMetadata(0)
+	testutil.AssertError(t, err, "Expected an error for a ledger without blocks")
+
+	simulator, _ := ledger.NewTxSimulator()
+	simulator.SetState("ns1", "key1", []byte("value1"))
+	simulator.SetState("ns1", "key2", []byte("value2"))
+	simulator.Done()
+	simRes, _ := simulator.GetTxSimulationResults()
+	block1 := testutil.ConstructBlockForSimulationResults(t, [][]byte{simRes})
+	ledger.RemoveInvalidTransactionsAndPrepare(block1)
+	ledger.Commit()
+
+	simulator, _ = ledger.NewTxSimulator()
+	simulator.SetState("ns1", "key1", []byte("value3"))
+	simulator.DeleteState("ns1", "key2")
+	simulator.Done()
+	simRes, _ = simulator.GetTxSimulationResults()
+	block2 := testutil.ConstructBlockForSimulationResults(t, [][]byte{simRes})
+	ledger.RemoveInvalidTransactionsAndPrepare(block2)
+	ledger.Commit()
+
+	metadata1, err := ledger.GetBlockLocalMetadata(1)
+	testutil.AssertNoError(t, err, "")
+	metadata2, err := ledger.GetBlockLocalMetadata(0)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, metadata2.BlockNumber, uint64(2))
+	testutil.AssertNotEquals(t, metadata2.UpdatesHash, metadata1.UpdatesHash)
+	testutil.AssertNotEquals(t, metadata2.StateHash, metadata1.StateHash)
+	expectedStateHash := txmgmt.NewStateHash(nil)
+	expectedStateHash.Add("ns1", "key1", []byte("value3"))
+	testutil.AssertEquals(t, metadata2.StateHash, expectedStateHash.Bytes())
+	_, err = ledger.GetBlockLocalMetadata(3)
+	testutil.AssertError(t, err, "Expected an error for a block that is not in the ledger")
+
+	// the updates hash depends only on the resulting updates, not on how they are spread over the transactions
+	simulator1, _ := ledger.NewTxSimulator()
+	simulator1.SetState("ns1", "key1", []byte("value1"))
+	simulator1.Done()
+	simRes1, _ := simulator1.GetTxSimulationResults()
+	simulator2, _ := ledger.NewTxSimulator()
+	simulator2.SetState("ns1", "key2", []byte("value2"))
+	simulator2.Done()
+	simRes2, _ := simulator2.GetTxSimulationResults()
+	updatesHash, err := computeStateUpdatesHash(testutil.ConstructBlockForSimulationResults(t, [][]byte{simRes2, simRes1}))
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, updatesHash, metadata1.UpdatesHash)
+
+	// the local metadata is removed with the blocks and recorded again for the blocks committed again
+	testutil.AssertNoError(t, ledger.RollbackTo(1), "")
+	_, err = ledger.GetBlockLocalMetadata(2)
+	testutil.AssertError(t, err, "Expected an error for a removed block")
+	ledger.RemoveInvalidTransactionsAndPrepare(block2)
+	ledger.Commit()
+	metadata, err := ledger.GetBlockLocalMetadata(2)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, metadata, metadata2)
+}
+
+type testWriteSink struct {
+	writes []*cdc.Write
+	closed bool
+	failAt uint64
+}
+
+func (s *testWriteSink) Emit(blockNum uint64, writes []*cdc.Write) error {
+	if blockNum == s.failAt {
+		return errors.New("sink failure")
+	}
+	s.writes = append(s.writes, writes...)
+	return nil
+}
+
+func (s *testWriteSink) Close() {
+	s.closed = true
+}
+
+func TestKVLedgerWriteSinks(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	sink := &testWriteSink{}
+	ledger.AddWriteSink("test", sink)
+
+	simulator, _ := ledger.NewTxSimulator()
+	simulator.SetState("ns1", "key1", []byte("value1"))
+	simulator.SetState("ns2", "key2", []byte("value2"))
+	simulator.Done()
+	simRes, _ := simulator.GetTxSimulationResults()
+	block1 := testutil.ConstructBlockForSimulationResults(t, [][]byte{simRes})
+	ledger.RemoveInvalidTransactionsAndPrepare(block1)
+	ledger.Commit()
+
+	// the second transaction conflicts with the first one and its writes are not emitted
+	simulator1, _ := ledger.NewTxSimulator()
+	simulator1.GetState("ns1", "key1")
+	simulator1.DeleteState("ns1", "key1")
+	simulator1.Done()
+	simRes1, _ := simulator1.GetTxSimulationResults()
+	simulator2, _ := ledger.NewTxSimulator()
+	simulator2.GetState("ns1", "key1")
+	simulator2.SetState("ns2", "key2", []byte("value3"))
+	simulator2.Done()
+	simRes2, _ := simulator2.GetTxSimulationResults()
+	block2 := testutil.ConstructBlockForSimulationResults(t, [][]byte{simRes1, simRes2})
+	_, invalidTxs, _ := ledger.RemoveInvalidTransactionsAndPrepare(block2)
+	testutil.AssertEquals(t, len(invalidTxs), 1)
+	ledger.Commit()
+
+	testutil.AssertEquals(t, sink.writes, []*cdc.Write{
+		{BlockNumber: 1, TxNumber: 0, Namespace: "ns1", Key: "key1", Value: []byte("value1")},
+		{BlockNumber: 1, TxNumber: 0, Namespace: "ns2", Key: "key2", Value: []byte("value2")},
+		{BlockNumber: 2, TxNumber: 0, Namespace: "ns1", Key: "key1", IsDelete: true},
+	})
+	ledger.Close()
+	testutil.AssertSame(t, sink.closed, true)
+}
+
+func TestKVLedgerWriteSinkFailure(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+	sink := &testWriteSink{failAt: 2}
+	ledger.AddWriteSink("test", sink)
+
+	commitWrite := func(value string) {
+		simulator, _ := ledger.NewTxSimulator()
+		simulator.SetState("ns1", "key1", []byte(value))
+		simulator.Done()
+		simRes, _ := simulator.GetTxSimulationResults()
+		ledger.RemoveInvalidTransactionsAndPrepare(testutil.ConstructBlockForSimulationResults(t, [][]byte{simRes}))
+		ledger.Commit()
+	}
+	commitWrite("value1")
+	commitWrite("value2")
+	commitWrite("value3")
+	testutil.AssertEquals(t, len(sink.writes), 1)
+
+	// the block the sink failed on is emitted again with the next commit, before the following blocks
+	sink.failAt = 0
+	commitWrite("value4")
+	testutil.AssertEquals(t, len(sink.writes), 4)
+	for i, write := range sink.writes {
+		testutil.AssertEquals(t, write.BlockNumber, uint64(i+1))
+		testutil.AssertEquals(t, write.Value, []byte(fmt.Sprintf("value%d", i+1)))
+	}
+}
+
+func TestKVLedgerGetBlockWrites(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	ledger, _ := NewKVLedger(env.conf)
+	defer ledger.Close()
+
+	var simResults [][]byte
+	for i, ns := range []string{"ns1", "ns2"} {
+		simulator, _ := ledger.NewTxSimulator()
+		simulator.SetState(ns, fmt.Sprintf("key%d", i+1), []byte(fmt.Sprintf("value%d", i+1)))
+		simulator.Done()
+		simRes, _ := simulator.GetTxSimulationResults()
+		simResults = append(simResults, simRes)
+	}
+	block1 := testutil.ConstructBlockForSimulationResults(t, simResults)
+	ledger.RemoveInvalidTransactionsAndPrepare(block1)
+	ledger.Commit()
+
+	writes, err := ledger.GetBlockWrites(1)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, writes, []*cdc.Write{
+		{BlockNumber: 1, TxNumber: 0, Namespace: "ns1", Key: "key1", Value: []byte("value1")},
+		{BlockNumber: 1, TxNumber: 1, Namespace: "ns2", Key: "key2", Value: []byte("value2")},
+	})
+	_, err = ledger.GetBlockWrites(2)
+	testutil.AssertError(t, err, "Expected an error for a block that is not in the ledger")
 }
@@ -18,8 +18,15 @@ package kvledger
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
+
+	"github.com/hyperledger/fabric/core/ledger/kvledger/cdc"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/kvledgerconfig"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
 )
 
 //--!!!!IMPORTANT!!!--!!!IMPORTANT!!!---!!!IMPORTANT!!!-----------
@@ -69,6 +76,13 @@ func (l LedgerExistsErr) Error() string {
 	return fmt.Sprintf("ledger exists %s", string(l))
 }
 
+//LedgerNotFoundErr not found error
+type LedgerNotFoundErr string
+
+func (l LedgerNotFoundErr) Error() string {
+	return fmt.Sprintf("ledger not found %s", string(l))
+}
+
 //LedgerCreateErr exists error
 type LedgerCreateErr string
 
@@ -102,8 +116,33 @@ func (lMgr *ledgerManager) create(name string) (*KVLedger, error) {
 		return nil, LedgerCreateErr(name)
 	}
 
+	for _, sinkName := range kvledgerconfig.GetCDCSinks() {
+		sink, err := cdc.NewSink(sinkName, name, lPath)
+		if err != nil {
+			logger.Errorf("Error creating the change data capture sink [%s] of ledger %s: %s", sinkName, name, err)
+			lgr.Close()
+			return nil, LedgerCreateErr(name)
+		}
+		lgr.AddWriteSink(sinkName, sink)
+	}
+
+	//the state database is brought to the height of the block store before the ledger is used
+	if kvledgerconfig.IsStateRecoveryEnabled() {
+		logger.Infof("Checking the state database of ledger %s", name)
+		if numBlocks, err := lgr.RecoverState(); err != nil {
+			logger.Errorf("Error recovering the state database of ledger %s: %s", name, err)
+			lgr.Close()
+			return nil, LedgerCreateErr(name)
+		} else if numBlocks > 0 {
+			logger.Infof("Recovered the state database of ledger %s from %d blocks", name, numBlocks)
+		}
+	}
+
 	lMgr.ledgers[lPath] = lgr
 
+	//warm up in the background so that opening the ledger is not delayed
+	lgr.startWarmUp()
+
 	return lgr, nil
 }
 
@@ -117,3 +156,141 @@ func GetLedger(name string) *KVLedger {
 	}
 	return lgr
 }
+
+//GetLedgerNames returns the names of the ledgers found under the ledger path,
+//including those that have not been opened yet
+func GetLedgerNames() ([]string, error) {
+	if lManager == nil {
+		return nil, LedgerNotInitializedErr("")
+	}
+	fileInfos, err := ioutil.ReadDir(lManager.ledgerPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, fileInfo := range fileInfos {
+		if fileInfo.IsDir() {
+			names = append(names, fileInfo.Name())
+		}
+	}
+	return names, nil
+}
+
+//LedgerExists tells whether the ledger `name` is found under the ledger path, whether or not it
+//has been opened. Unlike GetLedger, it never creates the ledger
+func LedgerExists(name string) (bool, error) {
+	if lManager == nil {
+		return false, LedgerNotInitializedErr("")
+	}
+	if name == "" || name == "." || name == ".." || filepath.Base(name) != name {
+		return false, nil
+	}
+	fileInfo, err := os.Stat(lManager.ledgerPath + name)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return fileInfo.IsDir(), nil
+}
+
+//MigrateState copies the state database of the ledger `name` from the configured state database
+//(ledger.state.stateDatabase) into a store of `targetDatabase` and puts the copy in place of the
+//existing store. The existing store is kept next to it with the name of its state database as suffix.
+//The ledger must not be in use, i.e. the peer is stopped, and ledger.state.stateDatabase is set to
+//`targetDatabase` before the peer is started again. The number of keys copied is returned
+func MigrateState(name string, targetDatabase string) (int, error) {
+	if lManager == nil {
+		return 0, LedgerNotInitializedErr("")
+	}
+	lManager.Lock()
+	defer lManager.Unlock()
+
+	lPath := lManager.ledgerPath + name
+	if lManager.ledgers[lPath] != nil {
+		return 0, fmt.Errorf("Ledger %s is in use", name)
+	}
+	conf := NewConf(lPath, 0)
+	if _, err := os.Stat(conf.txMgrDBPath); err != nil {
+		return 0, fmt.Errorf("Ledger %s has no state database: %s", name, err)
+	}
+	sourceDatabase := kvledgerconfig.GetStateDatabase()
+	backupPath := conf.txMgrDBPath + "." + sourceDatabase
+	if _, err := os.Stat(backupPath); err == nil {
+		return 0, fmt.Errorf("A previous state database of ledger %s exists at %s, remove it first", name, backupPath)
+	}
+	sourceProvider, err := getStateDBProvider(sourceDatabase)
+	if err != nil {
+		return 0, err
+	}
+	targetProvider, err := getStateDBProvider(targetDatabase)
+	if err != nil {
+		return 0, err
+	}
+
+	//the copy is made next to the existing store and only swapped in once complete
+	migratingPath := conf.txMgrDBPath + ".migrating"
+	if err := os.RemoveAll(migratingPath); err != nil {
+		return 0, err
+	}
+	numKeys, err := migrateState(sourceProvider, conf.txMgrDBPath, targetProvider, migratingPath)
+	if err != nil {
+		os.RemoveAll(migratingPath)
+		return numKeys, err
+	}
+	if err := os.Rename(conf.txMgrDBPath, backupPath); err != nil {
+		return numKeys, err
+	}
+	if err := os.Rename(migratingPath, conf.txMgrDBPath); err != nil {
+		return numKeys, err
+	}
+	logger.Infof("Migrated %d keys of the state of ledger %s from %s to %s, the previous state is kept at %s",
+		numKeys, name, sourceDatabase, targetDatabase, backupPath)
+	return numKeys, nil
+}
+
+func migrateState(sourceProvider statedb.Provider, sourcePath string, targetProvider statedb.Provider, targetPath string) (int, error) {
+	source, err := sourceProvider.Open(sourcePath)
+	if err != nil {
+		return 0, err
+	}
+	defer source.Close()
+	target, err := targetProvider.Open(targetPath)
+	if err != nil {
+		return 0, err
+	}
+	defer target.Close()
+	return statedb.Migrate(source, target)
+}
+
+//VerifyLedger verifies the integrity of the existing ledger `name` as KVLedger.Verify does. The ledger is
+//opened read-only, so that it is left as found, and is not recovered, warmed up or kept open. The ledger
+//must not be in use, i.e. the peer is stopped. The number of valid blocks is returned
+func VerifyLedger(name string) (uint64, error) {
+	if lManager == nil {
+		return 0, LedgerNotInitializedErr("")
+	}
+	lPath := lManager.ledgerPath + name
+	conf := NewConf(lPath, 0)
+	conf.readOnly = true
+	for _, path := range []string{conf.blockStorageDir, conf.txMgrDBPath} {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			if path == conf.txMgrDBPath && kvledgerconfig.IsCouchDBEnabled() {
+				continue
+			}
+			return 0, LedgerNotFoundErr(name)
+		} else if err != nil {
+			return 0, err
+		}
+	}
+	lgr, err := NewKVLedger(conf)
+	if err != nil {
+		return 0, err
+	}
+	defer lgr.Close()
+	return lgr.Verify()
+}
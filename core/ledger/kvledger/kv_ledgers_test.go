@@ -18,7 +18,11 @@ package kvledger
 
 import (
 	"os"
+	"reflect"
 	"testing"
+
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
+	"github.com/hyperledger/fabric/core/ledger/testutil"
 )
 
 func TestCreate(t *testing.T) {
@@ -38,3 +42,119 @@ func TestCreate(t *testing.T) {
 		t.FailNow()
 	}
 }
+
+func TestGetLedgerNames(t *testing.T) {
+	lpath := "/tmp/ledgerstest"
+	os.RemoveAll(lpath)
+
+	Initialize(lpath)
+	names, err := GetLedgerNames()
+	if err != nil || len(names) != 0 {
+		t.Fatalf("Expected no ledgers, got %v (%v)", names, err)
+	}
+
+	GetLedger("test1")
+	GetLedger("test2")
+	names, err = GetLedgerNames()
+	if err != nil || !reflect.DeepEqual(names, []string{"test1", "test2"}) {
+		t.Fatalf("Expected ledgers [test1 test2], got %v (%v)", names, err)
+	}
+}
+
+func TestLedgerExists(t *testing.T) {
+	lpath := "/tmp/ledgerstest"
+	os.RemoveAll(lpath)
+
+	Initialize(lpath)
+	exists, err := LedgerExists("test1")
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, exists, false)
+
+	GetLedger("test1")
+	exists, err = LedgerExists("test1")
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, exists, true)
+
+	//checking a ledger does not create it
+	exists, _ = LedgerExists("test2")
+	testutil.AssertEquals(t, exists, false)
+	names, _ := GetLedgerNames()
+	testutil.AssertEquals(t, names, []string{"test1"})
+
+	//names that are not a ledger directory under the ledger path
+	for _, name := range []string{"", ".", "..", "../ledger/test1", "test1/blocks"} {
+		exists, err = LedgerExists(name)
+		testutil.AssertNoError(t, err, "")
+		testutil.AssertEquals(t, exists, false)
+	}
+}
+
+func TestMigrateState(t *testing.T) {
+	lpath := "/tmp/ledgerstest"
+	os.RemoveAll(lpath)
+
+	Initialize(lpath)
+	_, err := MigrateState("test", statedb.GoLevelDB)
+	testutil.AssertError(t, err, "Expected an error for a ledger that does not exist")
+
+	//the ledger is written outside the ledger manager, as the ledgers it holds are in use
+	lgr, err := NewKVLedger(NewConf(lManager.ledgerPath+"test", 0))
+	testutil.AssertNoError(t, err, "")
+	simulator, _ := lgr.NewTxSimulator()
+	simulator.SetState("ns1", "key1", []byte("value1"))
+	simulator.SetState("ns1", "key2", []byte("value2"))
+	simulator.Done()
+	simRes, _ := simulator.GetTxSimulationResults()
+	lgr.RemoveInvalidTransactionsAndPrepare(testutil.ConstructBlockForSimulationResults(t, [][]byte{simRes}))
+	lgr.Commit()
+	lgr.Close()
+
+	_, err = MigrateState("test", "unknown")
+	testutil.AssertError(t, err, "Expected an error for an unknown state database")
+
+	numKeys, err := MigrateState("test", statedb.GoLevelDB)
+	testutil.AssertNoError(t, err, "")
+	if numKeys == 0 {
+		t.Fatalf("Expected the keys of the state to be migrated")
+	}
+	_, err = os.Stat(lManager.ledgerPath + "test/txMgmgt/db." + statedb.GoLevelDB)
+	testutil.AssertNoError(t, err, "Expected the previous state database to be kept")
+
+	//a second migration would overwrite the kept state database
+	_, err = MigrateState("test", statedb.GoLevelDB)
+	testutil.AssertError(t, err, "Expected an error while the previous state database exists")
+
+	lgr = GetLedger("test")
+	queryExecutor, _ := lgr.NewQueryExecutor()
+	value, err := queryExecutor.GetState("ns1", "key2")
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, value, []byte("value2"))
+
+	_, err = MigrateState("test", statedb.GoLevelDB)
+	testutil.AssertError(t, err, "Expected an error for a ledger in use")
+}
+
+func TestVerifyLedger(t *testing.T) {
+	lpath := "/tmp/ledgerstest"
+	os.RemoveAll(lpath)
+
+	Initialize(lpath)
+	_, err := VerifyLedger("test")
+	testutil.AssertEquals(t, err, LedgerNotFoundErr("test"))
+	names, _ := GetLedgerNames()
+	testutil.AssertEquals(t, len(names), 0)
+
+	lgr, err := NewKVLedger(NewConf(lManager.ledgerPath+"test", 0))
+	testutil.AssertNoError(t, err, "")
+	simulator, _ := lgr.NewTxSimulator()
+	simulator.SetState("ns1", "key1", []byte("value1"))
+	simulator.Done()
+	simRes, _ := simulator.GetTxSimulationResults()
+	lgr.RemoveInvalidTransactionsAndPrepare(testutil.ConstructBlockForSimulationResults(t, [][]byte{simRes}))
+	lgr.Commit()
+	lgr.Close()
+
+	numBlocks, err := VerifyLedger("test")
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, numBlocks, uint64(1))
+}
@@ -16,6 +16,13 @@ limitations under the License.
 
 package kvledgerconfig
 
+import (
+	"time"
+
+	"github.com/hyperledger/fabric/core/ledger/util/db"
+	"github.com/spf13/viper"
+)
+
 // Change this feature toggle to true to use CouchDB for state database
 // TODO Eventually this feature toggle will be externalized via a real
 //      config option on the peer
@@ -25,3 +32,149 @@ var useCouchDB = false
 func IsCouchDBEnabled() bool {
 	return useCouchDB
 }
+
+//GetStateDatabase exposes the ledger.state.stateDatabase config option, the name of
+//the key-value store used for the state when CouchDB is not enabled
+func GetStateDatabase() string {
+	stateDatabase := viper.GetString("ledger.state.stateDatabase")
+	if stateDatabase == "" {
+		stateDatabase = "goleveldb"
+	}
+	return stateDatabase
+}
+
+//GetCouchDBMaxConnections exposes the ledger.state.couchDBConfig.maxConnections config option,
+//the maximum number of concurrent requests to CouchDB (0 for no limit)
+func GetCouchDBMaxConnections() int {
+	return viper.GetInt("ledger.state.couchDBConfig.maxConnections")
+}
+
+//GetCouchDBRequestTimeout exposes the ledger.state.couchDBConfig.requestTimeout config option (0 for no timeout)
+func GetCouchDBRequestTimeout() time.Duration {
+	return viper.GetDuration("ledger.state.couchDBConfig.requestTimeout")
+}
+
+//GetCouchDBMaxRetries exposes the ledger.state.couchDBConfig.maxRetries config option, the number of
+//times a CouchDB request is retried after a connection error or a server error
+func GetCouchDBMaxRetries() int {
+	return viper.GetInt("ledger.state.couchDBConfig.maxRetries")
+}
+
+//GetCouchDBRetryBackoff exposes the ledger.state.couchDBConfig.retryBackoff config option,
+//the wait before the first retry of a CouchDB request, doubled for every further retry
+func GetCouchDBRetryBackoff() time.Duration {
+	return viper.GetDuration("ledger.state.couchDBConfig.retryBackoff")
+}
+
+//GetCouchDBMaxBatchUpdateSize exposes the ledger.state.couchDBConfig.maxBatchUpdateSize config option,
+//the maximum number of documents written with a single bulk update at commit
+func GetCouchDBMaxBatchUpdateSize() int {
+	return viper.GetInt("ledger.state.couchDBConfig.maxBatchUpdateSize")
+}
+
+//GetCouchDBMaxBatchUpdateBytes exposes the ledger.state.couchDBConfig.maxBatchUpdateBytes config option,
+//the maximum size of the values written with a single bulk update at commit
+func GetCouchDBMaxBatchUpdateBytes() int {
+	return viper.GetInt("ledger.state.couchDBConfig.maxBatchUpdateBytes")
+}
+
+//IsStateEncryptionEnabled exposes the ledger.state.encryption.enabled config option
+func IsStateEncryptionEnabled() bool {
+	return viper.GetBool("ledger.state.encryption.enabled")
+}
+
+//GetStateEncryptionKeySKI exposes the ledger.state.encryption.keySKI config option,
+//the hex encoded subject key identifier of the AES key in the BCCSP used to encrypt the state
+func GetStateEncryptionKeySKI() string {
+	return viper.GetString("ledger.state.encryption.keySKI")
+}
+
+//IsBlockEncryptionEnabled exposes the ledger.blockchain.encryption.enabled config option
+func IsBlockEncryptionEnabled() bool {
+	return viper.GetBool("ledger.blockchain.encryption.enabled")
+}
+
+//GetBlockEncryptionKeySKI exposes the ledger.blockchain.encryption.keySKI config option,
+//the hex encoded subject key identifier of the AES key in the BCCSP that protects the block encryption keys
+func GetBlockEncryptionKeySKI() string {
+	return viper.GetString("ledger.blockchain.encryption.keySKI")
+}
+
+//GetStateWarmupBlocks exposes the ledger.state.warmup.blocks config option, the number of most recent
+//blocks whose written keys are read into the state database caches when a ledger is opened
+func GetStateWarmupBlocks() uint64 {
+	blocks := viper.GetInt("ledger.state.warmup.blocks")
+	if blocks < 0 {
+		return 0
+	}
+	return uint64(blocks)
+}
+
+//IsStateRecoveryEnabled exposes the ledger.state.recovery.enabled config option, whether the state
+//database is brought to the height of the block store when a ledger is opened
+func IsStateRecoveryEnabled() bool {
+	return viper.GetBool("ledger.state.recovery.enabled")
+}
+
+//IsIndexWarmupEnabled exposes the ledger.state.warmup.indexes config option
+func IsIndexWarmupEnabled() bool {
+	return viper.GetBool("ledger.state.warmup.indexes")
+}
+
+//GetStateTreeDepth exposes the ledger.state.stateTree.depth config option, the depth of the hash tree
+//maintained over the state for proving values to clients, 0 if no tree is maintained
+func GetStateTreeDepth() int {
+	return viper.GetInt("ledger.state.stateTree.depth")
+}
+
+//GetTotalQueryLimit exposes the ledger.state.totalQueryLimit config option, the maximum number
+//of results returned by all the query iterators of a transaction together, 0 for no limit
+func GetTotalQueryLimit() int {
+	return viper.GetInt("ledger.state.totalQueryLimit")
+}
+
+//GetQueryResultLimit exposes the ledger.state.queryResultLimit config option, the maximum number
+//of results returned by a single iterator over the results of a query, 0 for no limit
+func GetQueryResultLimit() int {
+	return viper.GetInt("ledger.state.queryResultLimit")
+}
+
+//GetInternalQueryLimit exposes the ledger.state.internalQueryLimit config option, the maximum number
+//of records fetched from the state database in a single request while iterating over query results
+func GetInternalQueryLimit() int {
+	return viper.GetInt("ledger.state.internalQueryLimit")
+}
+
+//GetDefaultPageSize exposes the ledger.state.defaultPageSize config option, the page size of the
+//paginated queries issued with a page size of 0
+func GetDefaultPageSize() int32 {
+	return int32(viper.GetInt("ledger.state.defaultPageSize"))
+}
+
+//GetMaxPageSize exposes the ledger.state.maxPageSize config option, the maximum page size of the
+//paginated queries, 0 for no limit
+func GetMaxPageSize() int32 {
+	return int32(viper.GetInt("ledger.state.maxPageSize"))
+}
+
+//GetMaxOpenQueryIterators exposes the ledger.state.maxOpenQueryIterators config option, the maximum
+//number of query iterators a transaction can have open, 0 for no limit
+func GetMaxOpenQueryIterators() int {
+	return viper.GetInt("ledger.state.maxOpenQueryIterators")
+}
+
+//GetCDCSinks exposes the ledger.cdc.sinks config option, the names of the sinks to which the writes
+//committed to the state are emitted for change data capture
+func GetCDCSinks() []string {
+	return viper.GetStringSlice("ledger.cdc.sinks")
+}
+
+//GetLevelDBOptions exposes the ledger.leveldb.<dbName> config options, the goleveldb tuning options of
+//the database `dbName` ('state' or 'index'). The sizes are configured in MiB
+func GetLevelDBOptions(dbName string) db.Options {
+	prefix := "ledger.leveldb." + dbName + "."
+	return db.Options{
+		BlockCacheSize:  viper.GetInt(prefix+"blockCacheSize") * 1024 * 1024,
+		WriteBufferSize: viper.GetInt(prefix+"writeBufferSize") * 1024 * 1024,
+		BloomFilterBits: viper.GetInt(prefix + "bloomFilterBits")}
+}
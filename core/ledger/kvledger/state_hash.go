@@ -0,0 +1,175 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/core/ledger/blkstorage"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt"
+	"github.com/hyperledger/fabric/protos"
+)
+
+// GetBlockLocalMetadata returns the local metadata recorded for the block `blockNum`, or for the last block if
+// `blockNum` is 0. The state hash in the local metadata allows to compare the state of the ledger across peers.
+// The local metadata is only read, it is recorded on the commit path
+func (l *KVLedger) GetBlockLocalMetadata(blockNum uint64) (*protos.BlockLocalMetadata, error) {
+	store, ok := l.blockStore.(blkstorage.LocalMetadataStore)
+	if !ok {
+		return nil, errors.New("Block store does not support local metadata")
+	}
+	bcInfo, err := l.blockStore.GetBlockchainInfo()
+	if err != nil {
+		return nil, err
+	}
+	if blockNum == 0 {
+		blockNum = bcInfo.Height
+	}
+	if blockNum == 0 || blockNum > bcInfo.Height {
+		return nil, fmt.Errorf("Block [%d] is not in the ledger, the last block is [%d]", blockNum, bcInfo.Height)
+	}
+	metadata, err := getLocalMetadata(store, blockNum)
+	if err != nil {
+		return nil, err
+	}
+	if metadata == nil || metadata.StateHash == nil {
+		return nil, fmt.Errorf("No state hash recorded for block [%d]", blockNum)
+	}
+	return metadata, nil
+}
+
+// recordLocalMetadata computes and records the local metadata of the block `blockNum`, and of the blocks before it
+// that are missing it, e.g. after a failure between the commit of a block and the record of its local metadata.
+// `block` is the block `blockNum` if at hand, it is retrieved from the block store otherwise. The state hash is
+// the one recorded by the state database for the block, so it is missing for the blocks committed without it
+func (l *KVLedger) recordLocalMetadata(blockNum uint64, block *protos.Block2) error {
+	store, ok := l.blockStore.(blkstorage.LocalMetadataStore)
+	if !ok {
+		return nil
+	}
+	lastRecorded := blockNum
+	for ; lastRecorded > 0; lastRecorded-- {
+		metadata, err := getLocalMetadata(store, lastRecorded)
+		if err != nil {
+			return err
+		}
+		if metadata != nil {
+			break
+		}
+	}
+	if blockNum-lastRecorded > 1 {
+		logger.Infof("Recording the missing local metadata of blocks [%d-%d]", lastRecorded+1, blockNum-1)
+	}
+	for currentBlockNum := lastRecorded + 1; currentBlockNum <= blockNum; currentBlockNum++ {
+		currentBlock := block
+		if currentBlockNum != blockNum || currentBlock == nil {
+			var err error
+			if currentBlock, err = l.blockStore.RetrieveBlockByNumber(currentBlockNum); err != nil {
+				return err
+			}
+		}
+		updatesHash, err := computeStateUpdatesHash(currentBlock)
+		if err != nil {
+			return fmt.Errorf("Error computing the state updates hash of block [%d]: %s", currentBlockNum, err)
+		}
+		stateHash, err := l.getStateHash(currentBlockNum)
+		if err != nil {
+			return err
+		}
+		metadata := &protos.BlockLocalMetadata{BlockNumber: currentBlockNum, UpdatesHash: updatesHash,
+			StateHash: stateHash}
+		metadataBytes, err := proto.Marshal(metadata)
+		if err != nil {
+			return err
+		}
+		if err = store.SetLocalMetadata(currentBlockNum, metadataBytes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getStateHash returns the state hash recorded by the state database for the block, nil if there is none
+func (l *KVLedger) getStateHash(blockNum uint64) ([]byte, error) {
+	stateHashCapable, ok := l.txtmgmt.(txmgmt.StateHashCapable)
+	if !ok {
+		return nil, nil
+	}
+	return stateHashCapable.GetStateHash(blockNum)
+}
+
+func getLocalMetadata(store blkstorage.LocalMetadataStore, blockNum uint64) (*protos.BlockLocalMetadata, error) {
+	metadataBytes, err := store.GetLocalMetadata(blockNum)
+	if err != nil || metadataBytes == nil {
+		return nil, err
+	}
+	metadata := &protos.BlockLocalMetadata{}
+	if err = proto.Unmarshal(metadataBytes, metadata); err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
+
+// computeStateUpdatesHash returns the hash of the state updates of a block that contains only valid transactions.
+// The last write of each key is hashed in the sorted order of namespaces and keys, so that the hash depends
+// only on the resulting state updates
+func computeStateUpdatesHash(block *protos.Block2) ([]byte, error) {
+	updates := make(map[string]map[string]*txmgmt.KVWrite)
+	err := visitWrites(block, func(txNum int, ns string, kvWrite *txmgmt.KVWrite) {
+		if updates[ns] == nil {
+			updates[ns] = make(map[string]*txmgmt.KVWrite)
+		}
+		updates[ns][kvWrite.Key] = kvWrite
+	})
+	if err != nil {
+		return nil, err
+	}
+	namespaces := make([]string, 0, len(updates))
+	for ns := range updates {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+
+	h := sha256.New()
+	for _, ns := range namespaces {
+		keys := make([]string, 0, len(updates[ns]))
+		for key := range updates[ns] {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			kvWrite := updates[ns][key]
+			h.Write(encodeLengthPrefixed([]byte(ns)))
+			h.Write(encodeLengthPrefixed([]byte(key)))
+			if kvWrite.IsDelete {
+				h.Write([]byte{1})
+			} else {
+				h.Write([]byte{0})
+				h.Write(encodeLengthPrefixed(kvWrite.Value))
+			}
+		}
+	}
+	return h.Sum(nil), nil
+}
+
+func encodeLengthPrefixed(b []byte) []byte {
+	return append(proto.EncodeVarint(uint64(len(b))), b...)
+}
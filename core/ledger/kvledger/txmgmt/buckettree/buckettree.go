@@ -0,0 +1,331 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package buckettree
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
+	"github.com/hyperledger/fabric/protos"
+)
+
+// MaxDepth is the maximum depth of a `Tree`, i.e. at most 2^24 buckets
+const MaxDepth = 24
+
+// The tree is stored in the state database under keys starting with a 0x00 byte, which do not
+// collide with the composite keys of the state as namespaces are never empty
+var (
+	entryKeyPrefix = []byte{0x00, 'b', 't', 'e'}
+	nodeKeyPrefix  = []byte{0x00, 'b', 't', 'n'}
+	depthKey       = []byte{0x00, 'b', 't', 'd'}
+)
+
+// Tree is a binary hash tree over a fixed number of buckets (2^depth). A key of the state is placed
+// in the bucket given by the hash of the key, and the hash of a bucket is computed over the hashes
+// of the keys and values of its entries. Every internal node hashes its two children, up to the root.
+// An update touches only the buckets of the written keys and the nodes on their paths to the root
+type Tree struct {
+	depth int
+	// emptyHashes[level] is the hash of a node at the level whose buckets are all empty
+	emptyHashes [][]byte
+}
+
+// New constructs a `Tree` with 2^depth buckets
+func New(depth int) *Tree {
+	if depth < 1 || depth > MaxDepth {
+		panic(fmt.Sprintf("Invalid bucket tree depth %d, the depth should be between 1 and %d", depth, MaxDepth))
+	}
+	emptyHashes := make([][]byte, depth+1)
+	emptyHashes[0] = computeHash()
+	for level := 1; level <= depth; level++ {
+		emptyHashes[level] = computeHash(emptyHashes[level-1], emptyHashes[level-1])
+	}
+	return &Tree{depth, emptyHashes}
+}
+
+// Depth returns the depth of the tree
+func (t *Tree) Depth() int {
+	return t.depth
+}
+
+// GetStoredDepth returns the depth of the tree stored in the state database, 0 if there is none
+func GetStoredDepth(store statedb.KVStore) (int, error) {
+	depthBytes, err := store.Get(depthKey)
+	if err != nil || depthBytes == nil {
+		return 0, err
+	}
+	return int(binary.BigEndian.Uint32(depthBytes)), nil
+}
+
+// CompositeKeyHash returns the hash of the key in the namespace under which the key is placed in the tree
+func CompositeKeyHash(namespace string, key string) []byte {
+	return computeHash([]byte(namespace), []byte{0x00}, []byte(key))
+}
+
+// ValueHash returns the hash of a value as recorded in the tree
+func ValueHash(value []byte) []byte {
+	return computeHash(value)
+}
+
+// PrepareUpdates adds to the batch the updates of the tree for the given writes of the state, keyed
+// by the key hash of the written key, with a nil value for a deleted key. The state database is read
+// for the current content of the tree. It returns the root of the tree after the updates
+func (t *Tree) PrepareUpdates(store statedb.KVStore, writes map[string][]byte, batch *statedb.UpdateBatch) ([]byte, error) {
+	// the changed entries by bucket, with a nil value hash for a removed entry
+	changedBuckets := make(map[uint64]map[string][]byte)
+	for keyHash, value := range writes {
+		bucket := t.bucketOf([]byte(keyHash))
+		if changedBuckets[bucket] == nil {
+			changedBuckets[bucket] = make(map[string][]byte)
+		}
+		if value == nil {
+			changedBuckets[bucket][keyHash] = nil
+			batch.Delete(entryKey(bucket, []byte(keyHash)))
+		} else {
+			valueHash := ValueHash(value)
+			changedBuckets[bucket][keyHash] = valueHash
+			batch.Put(entryKey(bucket, []byte(keyHash)), valueHash)
+		}
+	}
+
+	changedNodes := make(map[uint64][]byte)
+	for bucket, changedEntries := range changedBuckets {
+		entries, err := t.getBucketEntries(store, bucket)
+		if err != nil {
+			return nil, err
+		}
+		entries = mergeEntries(entries, changedEntries)
+		changedNodes[bucket] = computeBucketHash(entries)
+	}
+
+	for level := 0; ; level++ {
+		for index, hash := range changedNodes {
+			if bytes.Equal(hash, t.emptyHashes[level]) {
+				batch.Delete(nodeKey(level, index))
+			} else {
+				batch.Put(nodeKey(level, index), hash)
+			}
+		}
+		if level == t.depth {
+			break
+		}
+		parentNodes := make(map[uint64][]byte)
+		for index := range changedNodes {
+			parent := index / 2
+			if _, ok := parentNodes[parent]; ok {
+				continue
+			}
+			left, err := t.getNodeHash(store, changedNodes, level, parent*2)
+			if err != nil {
+				return nil, err
+			}
+			right, err := t.getNodeHash(store, changedNodes, level, parent*2+1)
+			if err != nil {
+				return nil, err
+			}
+			parentNodes[parent] = computeHash(left, right)
+		}
+		changedNodes = parentNodes
+	}
+
+	depthBytes := make([]byte, 4)
+	binary.BigEndian.PutUint32(depthBytes, uint32(t.depth))
+	batch.Put(depthKey, depthBytes)
+
+	if root, ok := changedNodes[0]; ok {
+		return root, nil
+	}
+	return t.GetRoot(store)
+}
+
+// GetRoot returns the root of the tree as committed in the state database
+func (t *Tree) GetRoot(store statedb.KVStore) ([]byte, error) {
+	return t.getNodeHash(store, nil, t.depth, 0)
+}
+
+// GetProof returns the number of the bucket of the key, the entries of the bucket and the hashes
+// of the siblings of the nodes on the path from the bucket to the root, as committed in the state database
+func (t *Tree) GetProof(store statedb.KVStore, keyHash []byte) (uint64, []*protos.StateProofEntry, [][]byte, error) {
+	bucket := t.bucketOf(keyHash)
+	entries, err := t.getBucketEntries(store, bucket)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	siblingHashes := make([][]byte, t.depth)
+	index := bucket
+	for level := 0; level < t.depth; level++ {
+		if siblingHashes[level], err = t.getNodeHash(store, nil, level, index^1); err != nil {
+			return 0, nil, nil, err
+		}
+		index /= 2
+	}
+	return bucket, entries, siblingHashes, nil
+}
+
+// VerifyStateProof checks that the proof is consistent, i.e. that the bucket entries prove the value
+// (or the absence) of the key and that they hash up to the state root in the proof. The caller is
+// responsible for checking that the state root is the one recorded for the block by a trusted set of peers
+func VerifyStateProof(proof *protos.StateProof) error {
+	depth := len(proof.SiblingHashes)
+	if depth < 1 || depth > MaxDepth {
+		return fmt.Errorf("Invalid number of sibling hashes %d in the proof", depth)
+	}
+	keyHash := CompositeKeyHash(proof.Namespace, proof.Key)
+	if bucketOf(keyHash, depth) != proof.BucketNumber {
+		return fmt.Errorf("Key [%s:%s] is not in bucket %d", proof.Namespace, proof.Key, proof.BucketNumber)
+	}
+
+	found := false
+	for i, entry := range proof.BucketEntries {
+		if i > 0 && bytes.Compare(proof.BucketEntries[i-1].KeyHash, entry.KeyHash) >= 0 {
+			return fmt.Errorf("Bucket entries are not sorted by key hash")
+		}
+		if bucketOf(entry.KeyHash, depth) != proof.BucketNumber {
+			return fmt.Errorf("Bucket entry %d does not belong to bucket %d", i, proof.BucketNumber)
+		}
+		if bytes.Equal(entry.KeyHash, keyHash) {
+			found = true
+			if !proof.Exists || !bytes.Equal(entry.ValueHash, ValueHash(proof.Value)) {
+				return fmt.Errorf("Bucket entry for key [%s:%s] does not match the value in the proof", proof.Namespace, proof.Key)
+			}
+		}
+	}
+	if proof.Exists && !found {
+		return fmt.Errorf("Key [%s:%s] is not in the bucket entries", proof.Namespace, proof.Key)
+	}
+
+	hash := computeBucketHash(proof.BucketEntries)
+	index := proof.BucketNumber
+	for _, siblingHash := range proof.SiblingHashes {
+		if index%2 == 0 {
+			hash = computeHash(hash, siblingHash)
+		} else {
+			hash = computeHash(siblingHash, hash)
+		}
+		index /= 2
+	}
+	if !bytes.Equal(hash, proof.StateRoot) {
+		return fmt.Errorf("Proof does not hash up to the state root")
+	}
+	return nil
+}
+
+func (t *Tree) bucketOf(keyHash []byte) uint64 {
+	return bucketOf(keyHash, t.depth)
+}
+
+// bucketOf returns the bucket of a key, given by the first `depth` bits of the key hash
+func bucketOf(keyHash []byte, depth int) uint64 {
+	if len(keyHash) < 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(keyHash) >> uint(64-depth)
+}
+
+func (t *Tree) getBucketEntries(store statedb.KVStore, bucket uint64) ([]*protos.StateProofEntry, error) {
+	startKey := entryKey(bucket, nil)
+	endKey := entryKey(bucket+1, nil)
+	itr := store.GetIterator(startKey, endKey)
+	defer itr.Release()
+	var entries []*protos.StateProofEntry
+	for itr.Next() {
+		// the iterator may reuse its buffers, so the key hash and value hash are copied
+		keyHash := append([]byte{}, itr.Key()[len(startKey):]...)
+		valueHash := append([]byte{}, itr.Value()...)
+		entries = append(entries, &protos.StateProofEntry{KeyHash: keyHash, ValueHash: valueHash})
+	}
+	if err := itr.Error(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// getNodeHash returns the hash of a node from the pending changes, if present, or from the state database
+func (t *Tree) getNodeHash(store statedb.KVStore, changedNodes map[uint64][]byte, level int, index uint64) ([]byte, error) {
+	if hash, ok := changedNodes[index]; ok {
+		return hash, nil
+	}
+	hash, err := store.Get(nodeKey(level, index))
+	if err != nil {
+		return nil, err
+	}
+	if hash == nil {
+		return t.emptyHashes[level], nil
+	}
+	return hash, nil
+}
+
+// mergeEntries applies the changed entries to the sorted entries of a bucket
+func mergeEntries(entries []*protos.StateProofEntry, changedEntries map[string][]byte) []*protos.StateProofEntry {
+	merged := make([]*protos.StateProofEntry, 0, len(entries)+len(changedEntries))
+	for _, entry := range entries {
+		if _, ok := changedEntries[string(entry.KeyHash)]; !ok {
+			merged = append(merged, entry)
+		}
+	}
+	for keyHash, valueHash := range changedEntries {
+		if valueHash != nil {
+			merged = append(merged, &protos.StateProofEntry{KeyHash: []byte(keyHash), ValueHash: valueHash})
+		}
+	}
+	sort.Sort(entriesByKeyHash(merged))
+	return merged
+}
+
+type entriesByKeyHash []*protos.StateProofEntry
+
+func (e entriesByKeyHash) Len() int           { return len(e) }
+func (e entriesByKeyHash) Swap(i, j int)      { e[i], e[j] = e[j], e[i] }
+func (e entriesByKeyHash) Less(i, j int) bool { return bytes.Compare(e[i].KeyHash, e[j].KeyHash) < 0 }
+
+func computeBucketHash(entries []*protos.StateProofEntry) []byte {
+	parts := make([][]byte, 0, 2*len(entries))
+	for _, entry := range entries {
+		parts = append(parts, entry.KeyHash, entry.ValueHash)
+	}
+	return computeHash(parts...)
+}
+
+func computeHash(parts ...[]byte) []byte {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write(part)
+	}
+	return h.Sum(nil)
+}
+
+func entryKey(bucket uint64, keyHash []byte) []byte {
+	key := append([]byte{}, entryKeyPrefix...)
+	key = append(key, encodeUint64(bucket)...)
+	return append(key, keyHash...)
+}
+
+func nodeKey(level int, index uint64) []byte {
+	key := append([]byte{}, nodeKeyPrefix...)
+	key = append(key, byte(level))
+	return append(key, encodeUint64(index)...)
+}
+
+func encodeUint64(n uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, n)
+	return b
+}
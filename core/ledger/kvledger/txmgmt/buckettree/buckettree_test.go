@@ -0,0 +1,147 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package buckettree
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
+	"github.com/hyperledger/fabric/core/ledger/testutil"
+	"github.com/hyperledger/fabric/protos"
+)
+
+const testDBPath = "/tmp/tests/ledger/kvledger/txmgmt/buckettree"
+
+func openTestStore(t testing.TB, name string) statedb.KVStore {
+	dbPath := testDBPath + "/" + name
+	os.RemoveAll(dbPath)
+	provider, err := statedb.GetProvider(statedb.GoLevelDB)
+	testutil.AssertNoError(t, err, "")
+	store, err := provider.Open(dbPath)
+	testutil.AssertNoError(t, err, "")
+	return store
+}
+
+// applyWrites commits the tree updates for the given values by key, a nil value deletes the key
+func applyWrites(t testing.TB, tree *Tree, store statedb.KVStore, values map[string][]byte) []byte {
+	writes := make(map[string][]byte)
+	for key, value := range values {
+		writes[string(CompositeKeyHash("ns", key))] = value
+	}
+	batch := statedb.NewUpdateBatch()
+	root, err := tree.PrepareUpdates(store, writes, batch)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertNoError(t, store.WriteBatch(batch, true), "")
+	committedRoot, err := tree.GetRoot(store)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, committedRoot, root)
+	return root
+}
+
+func getProof(t testing.TB, tree *Tree, store statedb.KVStore, key string, value []byte) *protos.StateProof {
+	root, err := tree.GetRoot(store)
+	testutil.AssertNoError(t, err, "")
+	bucket, entries, siblingHashes, err := tree.GetProof(store, CompositeKeyHash("ns", key))
+	testutil.AssertNoError(t, err, "")
+	return &protos.StateProof{Namespace: "ns", Key: key, Exists: value != nil, Value: value, StateRoot: root,
+		BucketNumber: bucket, BucketEntries: entries, SiblingHashes: siblingHashes}
+}
+
+func TestTreeRoot(t *testing.T) {
+	defer os.RemoveAll(testDBPath)
+	store1 := openTestStore(t, "store1")
+	defer store1.Close()
+	store2 := openTestStore(t, "store2")
+	defer store2.Close()
+	tree := New(3)
+
+	emptyRoot, err := tree.GetRoot(store1)
+	testutil.AssertNoError(t, err, "")
+
+	// the root depends on the state only, not on the order of the updates
+	values := make(map[string][]byte)
+	for i := 0; i < 20; i++ {
+		values[fmt.Sprintf("key%d", i)] = []byte(fmt.Sprintf("value%d", i))
+	}
+	root1 := applyWrites(t, tree, store1, values)
+	for i := 19; i >= 0; i-- {
+		key := fmt.Sprintf("key%d", i)
+		applyWrites(t, tree, store2, map[string][]byte{key: values[key]})
+	}
+	root2, err := tree.GetRoot(store2)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, root2, root1)
+
+	root := applyWrites(t, tree, store1, map[string][]byte{"key1": []byte("value1_1")})
+	testutil.AssertNotEquals(t, root, root1)
+	root = applyWrites(t, tree, store1, map[string][]byte{"key1": []byte("value1")})
+	testutil.AssertEquals(t, root, root1)
+
+	deletes := make(map[string][]byte)
+	for key := range values {
+		deletes[key] = nil
+	}
+	root = applyWrites(t, tree, store1, deletes)
+	testutil.AssertEquals(t, root, emptyRoot)
+
+	depth, err := GetStoredDepth(store1)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, depth, 3)
+}
+
+func TestVerifyStateProof(t *testing.T) {
+	defer os.RemoveAll(testDBPath)
+	store := openTestStore(t, "store")
+	defer store.Close()
+	tree := New(2)
+	values := make(map[string][]byte)
+	for i := 0; i < 10; i++ {
+		values[fmt.Sprintf("key%d", i)] = []byte(fmt.Sprintf("value%d", i))
+	}
+	applyWrites(t, tree, store, values)
+
+	for key, value := range values {
+		testutil.AssertNoError(t, VerifyStateProof(getProof(t, tree, store, key, value)), key)
+	}
+	testutil.AssertNoError(t, VerifyStateProof(getProof(t, tree, store, "key10", nil)), "")
+
+	proof := getProof(t, tree, store, "key1", []byte("value1_1"))
+	testutil.AssertError(t, VerifyStateProof(proof), "Expected an error for a wrong value")
+
+	proof = getProof(t, tree, store, "key1", nil)
+	testutil.AssertError(t, VerifyStateProof(proof), "Expected an error for an existing key proven absent")
+
+	proof = getProof(t, tree, store, "key10", []byte("value10"))
+	testutil.AssertError(t, VerifyStateProof(proof), "Expected an error for a missing key proven present")
+
+	proof = getProof(t, tree, store, "key1", []byte("value1"))
+	proof.StateRoot = ValueHash([]byte("root"))
+	testutil.AssertError(t, VerifyStateProof(proof), "Expected an error for a wrong state root")
+
+	proof = getProof(t, tree, store, "key1", []byte("value1"))
+	proof.SiblingHashes[0] = ValueHash([]byte("sibling"))
+	testutil.AssertError(t, VerifyStateProof(proof), "Expected an error for a wrong sibling hash")
+
+	// an entry of another key of the bucket cannot be left out to prove the absence of that key
+	proof = getProof(t, tree, store, "key1", []byte("value1"))
+	if len(proof.BucketEntries) > 1 {
+		proof.BucketEntries = proof.BucketEntries[:1]
+		testutil.AssertError(t, VerifyStateProof(proof), "Expected an error for a missing bucket entry")
+	}
+}
@@ -19,6 +19,7 @@ package couchdb
 import (
 	"bytes"
 	"compress/gzip"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -32,7 +33,11 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/hyperledger/fabric/core/metrics"
 	logging "github.com/op/go-logging"
 )
 
@@ -73,6 +78,81 @@ type CouchDBConnectionDef struct {
 	Username string
 	Password string
 	Database string
+	options  ConnectionOptions
+	client   *http.Client
+	// connSlots limits the number of requests in flight when MaxConnections is set
+	connSlots chan struct{}
+	stats     RequestStats
+	metrics   *requestMetrics
+}
+
+// ConnectionOptions tune the http client of a connection. A zero value disables the corresponding limit
+type ConnectionOptions struct {
+	// MaxConnections is the maximum number of requests in flight, and of idle connections kept open
+	MaxConnections int
+	// RequestTimeout is the time limit for a request, including reading the response body
+	RequestTimeout time.Duration
+	// MaxRetries is the number of times a request that only reads is retried after a transient failure,
+	// see IsTransient. Requests that write are not retried, as they may have been applied before failing
+	MaxRetries int
+	// RetryBackoff is the wait before the first retry, doubled for every further retry
+	RetryBackoff time.Duration
+}
+
+// RequestStats are the counters of the requests made through a connection
+type RequestStats struct {
+	Requests uint64
+	Retries  uint64
+	Failures uint64
+	// TotalLatency is the total time in nanoseconds spent in requests, including the retries
+	TotalLatency int64
+}
+
+// defaultRetryBackoff is used as RetryBackoff if retries are enabled without a backoff
+const defaultRetryBackoff = 100 * time.Millisecond
+
+//RequestError is the error of a request that CouchDB answered with an error status code
+type RequestError struct {
+	StatusCode int
+	Reason     string
+}
+
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("Couch DB Error: %s", e.Reason)
+}
+
+//IsConflict returns whether the error is a revision conflict reported by CouchDB
+func IsConflict(err error) bool {
+	requestErr, ok := err.(*RequestError)
+	return ok && requestErr.StatusCode == http.StatusConflict
+}
+
+//IsTransient returns whether a request that failed with the error may succeed when it is sent again,
+//i.e. CouchDB could not be reached, timed out, was overloaded or failed internally. Other errors, like
+//bad requests, unauthorized requests or conflicts, are permanent
+func IsTransient(err error) bool {
+	requestErr, ok := err.(*RequestError)
+	if !ok {
+		return err != nil
+	}
+	return requestErr.StatusCode == http.StatusRequestTimeout || requestErr.StatusCode == http.StatusTooManyRequests ||
+		requestErr.StatusCode >= 500
+}
+
+//isReadOnlyRequest returns whether the request only reads, so that it can be sent again safely.
+//Besides GET and HEAD, the POST requests of _all_docs and _find only read
+func isReadOnlyRequest(method, requestURL string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead:
+		return true
+	case http.MethodPost:
+		path := requestURL
+		if i := strings.Index(path, "?"); i >= 0 {
+			path = path[:i]
+		}
+		return strings.HasSuffix(path, "/_all_docs") || strings.HasSuffix(path, "/_find")
+	}
+	return false
 }
 
 //DBReturn contains an error reported by CouchDB
@@ -96,6 +176,84 @@ type DocRev struct {
 	Rev string `json:"_rev"`
 }
 
+//QueryResult is used for returning query results from CouchDB
+type QueryResult struct {
+	ID    string
+	Value []byte
+}
+
+//queryResponse is the body returned by CouchDB for a _find request
+type queryResponse struct {
+	Docs     []json.RawMessage `json:"docs"`
+	Bookmark string            `json:"bookmark"`
+}
+
+//createIndexResponse is the body returned by CouchDB for an _index request
+type createIndexResponse struct {
+	Result string `json:"result"`
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+}
+
+//IndexDefinition defines an index as listed by CouchDB
+type IndexDefinition struct {
+	DesignDocument string `json:"ddoc"`
+	Name           string `json:"name"`
+	Type           string `json:"type"`
+	Definition     struct {
+		Fields []map[string]string `json:"fields"`
+	} `json:"def"`
+}
+
+//listIndexResponse is the body returned by CouchDB when listing the indexes of a database
+type listIndexResponse struct {
+	Indexes []IndexDefinition `json:"indexes"`
+}
+
+//CouchDoc defines a document for a batch update. The document is saved either as the
+//JSONValue or, if JSONValue is nil, with the Attachments. A document is deleted if Deleted is set
+type CouchDoc struct {
+	ID          string
+	Rev         string
+	JSONValue   []byte
+	Attachments []Attachment
+	Deleted     bool
+}
+
+//BatchUpdateResponse defines the outcome of updating a single document in a batch
+type BatchUpdateResponse struct {
+	ID     string `json:"id"`
+	Rev    string `json:"rev"`
+	Ok     bool   `json:"ok"`
+	Error  string `json:"error"`
+	Reason string `json:"reason"`
+}
+
+//inlineAttachment is the representation of an attachment within a document of a _bulk_docs request
+type inlineAttachment struct {
+	ContentType string `json:"content_type"`
+	Data        string `json:"data"`
+}
+
+//allDocsResponse is the body returned by CouchDB for an _all_docs request
+type allDocsResponse struct {
+	Rows []struct {
+		ID    string `json:"id"`
+		Key   string `json:"key"`
+		Error string `json:"error"`
+		Value struct {
+			Rev     string `json:"rev"`
+			Deleted bool   `json:"deleted"`
+		} `json:"value"`
+		Doc json.RawMessage `json:"doc"`
+	} `json:"rows"`
+}
+
+//docAttachments is the part of a document read with attachments=true that holds the attachments
+type docAttachments struct {
+	Attachments map[string]inlineAttachment `json:"_attachments"`
+}
+
 //FileDetails defines the structure needed to send an attachment to couchdb
 type FileDetails struct {
 	Follows     bool   `json:"follows"`
@@ -105,6 +263,12 @@ type FileDetails struct {
 
 //CreateConnectionDefinition for a new client connection
 func CreateConnectionDefinition(host string, port int, databaseName, username, password string) (*CouchDBConnectionDef, error) {
+	return CreateConnectionDefinitionWithOptions(host, port, databaseName, username, password, ConnectionOptions{})
+}
+
+//CreateConnectionDefinitionWithOptions for a new client connection that uses the given connection options
+func CreateConnectionDefinitionWithOptions(host string, port int, databaseName, username, password string,
+	options ConnectionOptions) (*CouchDBConnectionDef, error) {
 
 	logger.Debugf("===COUCHDB=== Entering CreateConnectionDefinition()")
 
@@ -128,8 +292,56 @@ func CreateConnectionDefinition(host string, port int, databaseName, username, p
 
 	logger.Debugf("===COUCHDB=== Exiting CreateConnectionDefinition()")
 
+	if options.MaxRetries > 0 && options.RetryBackoff <= 0 {
+		options.RetryBackoff = defaultRetryBackoff
+	}
+
+	//the client is shared by all requests so that connections are reused
+	transport := &http.Transport{Proxy: http.ProxyFromEnvironment}
+	transport.DisableCompression = false
+	if options.MaxConnections > 0 {
+		transport.MaxIdleConnsPerHost = options.MaxConnections
+	}
+	client := &http.Client{Transport: transport, Timeout: options.RequestTimeout}
+
+	var connSlots chan struct{}
+	if options.MaxConnections > 0 {
+		connSlots = make(chan struct{}, options.MaxConnections)
+	}
+
 	//return an object containing the connection information
-	return &CouchDBConnectionDef{finalURL.String(), username, password, databaseName}, nil
+	return &CouchDBConnectionDef{URL: finalURL.String(), Username: username, Password: password,
+		Database: databaseName, options: options, client: client, connSlots: connSlots,
+		metrics: newRequestMetrics(metrics.GetProvider(), databaseName)}, nil
+}
+
+//HealthCheck method checks that the CouchDB server responds. As the connection
+//used for the check is kept open, it also warms up the connection pool
+func (dbclient *CouchDBConnectionDef) HealthCheck() error {
+
+	logger.Debugf("===COUCHDB=== Entering HealthCheck()")
+
+	resp, _, err := dbclient.handleRequest(http.MethodGet, dbclient.URL, nil, "", "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	//the body is drained so that the connection can be reused
+	io.Copy(ioutil.Discard, resp.Body)
+
+	logger.Debugf("===COUCHDB=== Exiting HealthCheck()")
+
+	return nil
+}
+
+//GetRequestStats returns a snapshot of the counters of the requests made through the connection
+func (dbclient *CouchDBConnectionDef) GetRequestStats() RequestStats {
+	return RequestStats{
+		Requests:     atomic.LoadUint64(&dbclient.stats.Requests),
+		Retries:      atomic.LoadUint64(&dbclient.stats.Retries),
+		Failures:     atomic.LoadUint64(&dbclient.stats.Failures),
+		TotalLatency: atomic.LoadInt64(&dbclient.stats.TotalLatency),
+	}
 }
 
 //CreateDatabaseIfNotExist method provides function to create database
@@ -381,6 +593,25 @@ func getRevisionHeader(resp *http.Response) (string, error) {
 
 }
 
+//DeleteDoc method provides function to delete the revision rev of a document
+func (dbclient *CouchDBConnectionDef) DeleteDoc(id string, rev string) error {
+
+	logger.Debugf("===COUCHDB=== Entering DeleteDoc()  id=%s", id)
+
+	url := fmt.Sprintf("%s/%s/%s?rev=%s", dbclient.URL, dbclient.Database, id, rev)
+
+	resp, _, err := dbclient.handleRequest(http.MethodDelete, url, nil, "", "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	logger.Debugf("===COUCHDB=== Exiting DeleteDoc()")
+
+	return nil
+
+}
+
 //ReadDoc method provides function to retrieve a document from the database by id
 func (dbclient *CouchDBConnectionDef) ReadDoc(id string) ([]byte, string, error) {
 
@@ -493,12 +724,358 @@ func (dbclient *CouchDBConnectionDef) ReadDoc(id string) ([]byte, string, error)
 
 }
 
-//handleRequest method is a generic http request handler
+//QueryDocuments method provides function for processing a query using the CouchDB _find API.
+//Returns the matching documents along with the bookmark that can be used to fetch the next set of results
+func (dbclient *CouchDBConnectionDef) QueryDocuments(query string) (*[]QueryResult, string, error) {
+
+	logger.Debugf("===COUCHDB=== Entering QueryDocuments()  query=%s", query)
+
+	//Test to see if this is a valid JSON
+	if IsJSON(query) != true {
+		return nil, "", fmt.Errorf("JSON format is not valid")
+	}
+
+	url := fmt.Sprintf("%s/%s/_find", dbclient.URL, dbclient.Database)
+
+	resp, _, err := dbclient.handleRequest(http.MethodPost, url, bytes.NewBufferString(query), "", "")
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	jsonResponse := &queryResponse{}
+	if err = json.NewDecoder(resp.Body).Decode(jsonResponse); err != nil {
+		return nil, "", err
+	}
+
+	var results []QueryResult
+	for _, doc := range jsonResponse.Docs {
+
+		docRev := &DocRev{}
+		if err = json.Unmarshal(doc, docRev); err != nil {
+			return nil, "", err
+		}
+
+		logger.Debugf("===COUCHDB=== Adding JSON document for id: %s", docRev.Id)
+
+		results = append(results, QueryResult{ID: docRev.Id, Value: doc})
+	}
+
+	logger.Debugf("===COUCHDB=== Exiting QueryDocuments()")
+
+	return &results, jsonResponse.Bookmark, nil
+
+}
+
+//CreateIndex method provides a function for creating an index using the CouchDB _index API.
+//Creating an index that already exists is not an error, CouchDB reports it as "exists"
+func (dbclient *CouchDBConnectionDef) CreateIndex(indexdefinition string) error {
+
+	logger.Debugf("===COUCHDB=== Entering CreateIndex()  indexdefinition=%s", indexdefinition)
+
+	//Test to see if this is a valid JSON
+	if IsJSON(indexdefinition) != true {
+		return fmt.Errorf("JSON format is not valid")
+	}
+
+	url := fmt.Sprintf("%s/%s/_index", dbclient.URL, dbclient.Database)
+
+	resp, _, err := dbclient.handleRequest(http.MethodPost, url, bytes.NewBufferString(indexdefinition), "", "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	indexResponse := &createIndexResponse{}
+	if err = json.NewDecoder(resp.Body).Decode(indexResponse); err != nil {
+		return err
+	}
+
+	logger.Debugf("===COUCHDB=== Index %s %s", indexResponse.Name, indexResponse.Result)
+
+	logger.Debugf("===COUCHDB=== Exiting CreateIndex()")
+
+	return nil
+
+}
+
+//ListIndexes method provides a function for listing the indexes of the database
+func (dbclient *CouchDBConnectionDef) ListIndexes() ([]IndexDefinition, error) {
+
+	logger.Debugf("===COUCHDB=== Entering ListIndexes()")
+
+	url := fmt.Sprintf("%s/%s/_index", dbclient.URL, dbclient.Database)
+
+	resp, _, err := dbclient.handleRequest(http.MethodGet, url, nil, "", "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	jsonResponse := &listIndexResponse{}
+	if err = json.NewDecoder(resp.Body).Decode(jsonResponse); err != nil {
+		return nil, err
+	}
+
+	logger.Debugf("===COUCHDB=== Exiting ListIndexes()")
+
+	return jsonResponse.Indexes, nil
+
+}
+
+//WarmIndex method provides a function for bringing a JSON index up to date with the database.
+//CouchDB updates an index only when it is queried, so a query for a single document is run with the index
+func (dbclient *CouchDBConnectionDef) WarmIndex(index IndexDefinition) error {
+
+	logger.Debugf("===COUCHDB=== Entering WarmIndex()  index=%s", index.Name)
+
+	if len(index.Definition.Fields) == 0 {
+		return fmt.Errorf("Index %s has no fields", index.Name)
+	}
+
+	//Select on the first field of the index so that CouchDB accepts the index for the query
+	var field string
+	for name := range index.Definition.Fields[0] {
+		field = name
+		break
+	}
+
+	query, err := json.Marshal(map[string]interface{}{
+		"selector":  map[string]interface{}{field: map[string]interface{}{"$gt": nil}},
+		"use_index": []string{index.DesignDocument, index.Name},
+		"fields":    []string{"_id"},
+		"limit":     1,
+	})
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/%s/_find", dbclient.URL, dbclient.Database)
+
+	resp, _, err := dbclient.handleRequest(http.MethodPost, url, bytes.NewReader(query), "", "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	logger.Debugf("===COUCHDB=== Exiting WarmIndex()")
+
+	return nil
+
+}
+
+//BatchRetrieveDocumentRevisions method provides a function for retrieving the current revisions of
+//a set of documents in a single request using the CouchDB _all_docs API.
+//Documents that do not exist or have been deleted are not included in the returned map
+func (dbclient *CouchDBConnectionDef) BatchRetrieveDocumentRevisions(ids []string) (map[string]string, error) {
+
+	logger.Debugf("===COUCHDB=== Entering BatchRetrieveDocumentRevisions()  number of ids=%d", len(ids))
+
+	revisions := make(map[string]string)
+	if len(ids) == 0 {
+		return revisions, nil
+	}
+
+	keys, err := json.Marshal(map[string]interface{}{"keys": ids})
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/%s/_all_docs", dbclient.URL, dbclient.Database)
+
+	resp, _, err := dbclient.handleRequest(http.MethodPost, url, bytes.NewReader(keys), "", "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	jsonResponse := &allDocsResponse{}
+	if err = json.NewDecoder(resp.Body).Decode(jsonResponse); err != nil {
+		return nil, err
+	}
+
+	for _, row := range jsonResponse.Rows {
+		if row.Error != "" || row.Value.Deleted {
+			continue
+		}
+		revisions[row.ID] = row.Value.Rev
+	}
+
+	logger.Debugf("===COUCHDB=== Exiting BatchRetrieveDocumentRevisions()")
+
+	return revisions, nil
+
+}
+
+//BatchReadDocuments method provides a function for reading a set of documents in a single request
+//using the CouchDB _all_docs API. The value of each document is returned in the order of the given ids
+//as ReadDoc returns it, i.e. the JSON document or the data of its valueBytes attachment.
+//The value is nil for documents that do not exist or have been deleted
+func (dbclient *CouchDBConnectionDef) BatchReadDocuments(ids []string) ([][]byte, error) {
+
+	logger.Debugf("===COUCHDB=== Entering BatchReadDocuments()  number of ids=%d", len(ids))
+
+	values := make([][]byte, len(ids))
+	if len(ids) == 0 {
+		return values, nil
+	}
+
+	keys, err := json.Marshal(map[string]interface{}{"keys": ids})
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/%s/_all_docs?include_docs=true&attachments=true", dbclient.URL, dbclient.Database)
+
+	resp, _, err := dbclient.handleRequest(http.MethodPost, url, bytes.NewReader(keys), "", "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	jsonResponse := &allDocsResponse{}
+	if err = json.NewDecoder(resp.Body).Decode(jsonResponse); err != nil {
+		return nil, err
+	}
+	if len(jsonResponse.Rows) != len(ids) {
+		return nil, fmt.Errorf("Expected %d rows from CouchDB, received %d", len(ids), len(jsonResponse.Rows))
+	}
+
+	for i, row := range jsonResponse.Rows {
+		if row.Error != "" || row.Value.Deleted || len(row.Doc) == 0 || string(row.Doc) == "null" {
+			continue
+		}
+		attachments := &docAttachments{}
+		if err = json.Unmarshal(row.Doc, attachments); err != nil {
+			return nil, err
+		}
+		if attachment, ok := attachments.Attachments["valueBytes"]; ok {
+			if values[i], err = base64.StdEncoding.DecodeString(attachment.Data); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		values[i] = []byte(row.Doc)
+	}
+
+	logger.Debugf("===COUCHDB=== Exiting BatchReadDocuments()")
+
+	return values, nil
+
+}
+
+//BatchUpdateDocuments method provides a function for saving or deleting a set of documents in a
+//single request using the CouchDB _bulk_docs API. The update of each document succeeds or fails
+//individually, the outcome for each document is returned in the order of the given documents
+func (dbclient *CouchDBConnectionDef) BatchUpdateDocuments(docs []*CouchDoc) ([]*BatchUpdateResponse, error) {
+
+	logger.Debugf("===COUCHDB=== Entering BatchUpdateDocuments()  number of docs=%d", len(docs))
+
+	jsonDocs := []map[string]interface{}{}
+	for _, doc := range docs {
+
+		jsonDoc := make(map[string]interface{})
+		if doc.JSONValue != nil && !doc.Deleted {
+			if err := json.Unmarshal(doc.JSONValue, &jsonDoc); err != nil {
+				return nil, fmt.Errorf("JSON format is not valid for document %s", doc.ID)
+			}
+		}
+
+		jsonDoc["_id"] = doc.ID
+		if doc.Rev != "" {
+			jsonDoc["_rev"] = doc.Rev
+		}
+		if doc.Deleted {
+			jsonDoc["_deleted"] = true
+		} else if doc.JSONValue == nil {
+			attachments := make(map[string]inlineAttachment)
+			for _, attachment := range doc.Attachments {
+				attachments[attachment.Name] = inlineAttachment{attachment.ContentType,
+					base64.StdEncoding.EncodeToString(attachment.AttachmentBytes)}
+			}
+			jsonDoc["_attachments"] = attachments
+		}
+
+		jsonDocs = append(jsonDocs, jsonDoc)
+	}
+
+	bulkDocs, err := json.Marshal(map[string]interface{}{"docs": jsonDocs})
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/%s/_bulk_docs", dbclient.URL, dbclient.Database)
+
+	resp, _, err := dbclient.handleRequest(http.MethodPost, url, bytes.NewReader(bulkDocs), "", "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var jsonResponse []*BatchUpdateResponse
+	if err = json.NewDecoder(resp.Body).Decode(&jsonResponse); err != nil {
+		return nil, err
+	}
+
+	logger.Debugf("===COUCHDB=== Exiting BatchUpdateDocuments()")
+
+	return jsonResponse, nil
+
+}
+
+//handleRequest method is a generic http request handler. Connection errors and 5xx status codes
+//are retried as per the connection options
 func (dbclient *CouchDBConnectionDef) handleRequest(method, url string, data io.Reader, rev string, multipartBoundary string) (*http.Response, *DBReturn, error) {
 
 	logger.Debugf("===COUCHDB=== Entering handleRequest()  method=%s  url=%s", method, url)
 
+	//the body is buffered so that it can be sent again on a retry
+	var body []byte
+	if data != nil {
+		var err error
+		if body, err = ioutil.ReadAll(data); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	startTime := time.Now()
+	atomic.AddUint64(&dbclient.stats.Requests, 1)
+	defer func() {
+		atomic.AddInt64(&dbclient.stats.TotalLatency, int64(time.Since(startTime)))
+	}()
+
+	//a request that writes is not sent again, it may have been applied although it failed
+	maxRetries := 0
+	if isReadOnlyRequest(method, url) {
+		maxRetries = dbclient.options.MaxRetries
+	}
+	backoff := dbclient.options.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		resp, couchDBReturn, err := dbclient.doRequest(method, url, body, rev, multipartBoundary)
+		if !IsTransient(err) || attempt >= maxRetries {
+			if err != nil {
+				atomic.AddUint64(&dbclient.stats.Failures, 1)
+			}
+			dbclient.metrics.observe(method, startTime, err)
+			logger.Debugf("===COUCHDB=== Exiting handleRequest()  attempts=%d  latency=%s", attempt+1, time.Since(startTime))
+			return resp, couchDBReturn, err
+		}
+		logger.Warningf("===COUCHDB=== Retrying %s %s in %s after error: %s", method, url, backoff, err)
+		atomic.AddUint64(&dbclient.stats.Retries, 1)
+		dbclient.metrics.retries.With("method", method).Add(1)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+//doRequest sends a single http request to CouchDB
+func (dbclient *CouchDBConnectionDef) doRequest(method, url string, body []byte, rev string, multipartBoundary string) (*http.Response, *DBReturn, error) {
+
 	//Create request based on URL for couchdb operation
+	var data io.Reader
+	if body != nil {
+		data = bytes.NewReader(body)
+	}
 	req, err := http.NewRequest(method, url, data)
 	if err != nil {
 		return nil, nil, err
@@ -526,6 +1103,12 @@ func (dbclient *CouchDBConnectionDef) handleRequest(method, url string, data io.
 		req.Header.Set("Accept", "application/json")
 	}
 
+	//add content headers for POST, used for queries
+	if method == http.MethodPost {
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+	}
+
 	//add content header for GET
 	if method == http.MethodGet {
 		req.Header.Set("Accept", "multipart/related")
@@ -545,18 +1128,23 @@ func (dbclient *CouchDBConnectionDef) handleRequest(method, url string, data io.
 		fmt.Printf("%s", dump)
 	*/
 
-	//Create the http client
-	client := &http.Client{}
-
-	transport := &http.Transport{Proxy: http.ProxyFromEnvironment}
-	transport.DisableCompression = false
-	client.Transport = transport
+	//wait for a free connection slot, released when the response body is closed
+	var release func()
+	if dbclient.connSlots != nil {
+		dbclient.connSlots <- struct{}{}
+		var once sync.Once
+		release = func() { once.Do(func() { <-dbclient.connSlots }) }
+	} else {
+		release = func() {}
+	}
 
 	//Execute http request
-	resp, err := client.Do(req)
+	resp, err := dbclient.client.Do(req)
 	if err != nil {
+		release()
 		return nil, nil, err
 	}
+	resp.Body = &releasingBody{resp.Body, release}
 
 	//create the return object for couchDB
 	couchDBReturn := &DBReturn{}
@@ -569,6 +1157,7 @@ func (dbclient *CouchDBConnectionDef) handleRequest(method, url string, data io.
 	if resp.StatusCode >= 400 {
 
 		jsonError, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
 		if err != nil {
 			return nil, nil, err
 		}
@@ -579,16 +1168,25 @@ func (dbclient *CouchDBConnectionDef) handleRequest(method, url string, data io.
 
 		json.Unmarshal(errorBytes, &couchDBReturn)
 
-		return nil, couchDBReturn, fmt.Errorf("Couch DB Error: %s", couchDBReturn.Reason)
+		return nil, couchDBReturn, &RequestError{StatusCode: resp.StatusCode, Reason: couchDBReturn.Reason}
 
 	}
 
-	logger.Debugf("===COUCHDB=== Exiting handleRequest()")
-
 	//If no errors, then return the results
 	return resp, couchDBReturn, nil
 }
 
+//releasingBody releases the connection slot of a request when the response body is closed
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	defer b.release()
+	return b.ReadCloser.Close()
+}
+
 //IsJSON tests a string to determine if a valid JSON
 func IsJSON(s string) bool {
 	var js map[string]interface{}
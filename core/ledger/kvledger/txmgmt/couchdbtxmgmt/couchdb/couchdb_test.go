@@ -17,12 +17,20 @@ limitations under the License.
 package couchdb
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	kvledgerconfig "github.com/hyperledger/fabric/core/ledger/kvledger/kvledgerconfig"
 	"github.com/hyperledger/fabric/core/ledger/testutil"
+	"github.com/hyperledger/fabric/core/metrics"
 )
 
 type Asset struct {
@@ -323,3 +331,283 @@ func cleanup() {
 	db.DropDatabase()
 
 }
+
+func TestDBBatchUpdate(t *testing.T) {
+
+	//without CouchDB the batch requests are sent to a fake server
+	db, server := createBatchTestServerConnection(t)
+	defer server.Close()
+	testDBBatchUpdate(t, db)
+
+	if kvledgerconfig.IsCouchDBEnabled() == true {
+
+		cleanup()
+		defer cleanup()
+
+		//create a new connection
+		db, err := CreateConnectionDefinition(hostname, port, database, username, password)
+		testutil.AssertNoError(t, err, fmt.Sprintf("Error when trying to create database connection definition"))
+
+		//create a new database
+		_, errdb := db.CreateDatabaseIfNotExist()
+		testutil.AssertNoError(t, errdb, fmt.Sprintf("Error when trying to create database"))
+
+		testDBBatchUpdate(t, db)
+
+		//The attachment is read back from the second document
+		value, _, err := db.ReadDoc("2")
+		testutil.AssertNoError(t, err, fmt.Sprintf("Error when trying to read a document"))
+		testutil.AssertEquals(t, value, []byte("binary value"))
+
+	}
+}
+
+func testDBBatchUpdate(t *testing.T, db *CouchDBConnectionDef) {
+
+	attachment := Attachment{Name: "valueBytes", ContentType: "application/octet-stream", AttachmentBytes: []byte("binary value")}
+	docs := []*CouchDoc{
+		&CouchDoc{ID: "1", JSONValue: assetJSON},
+		&CouchDoc{ID: "2", Attachments: []Attachment{attachment}},
+	}
+
+	//Save the documents in a single batch
+	resps, err := db.BatchUpdateDocuments(docs)
+	testutil.AssertNoError(t, err, fmt.Sprintf("Error when trying to batch update documents"))
+	testutil.AssertEquals(t, len(resps), 2)
+	testutil.AssertEquals(t, resps[0].Ok, true)
+	testutil.AssertEquals(t, resps[1].Ok, true)
+
+	//Read the documents back in a single batch
+	values, err := db.BatchReadDocuments([]string{"1", "2", "3"})
+	testutil.AssertNoError(t, err, fmt.Sprintf("Error when trying to batch read documents"))
+	testutil.AssertEquals(t, len(values), 3)
+	asset := &Asset{}
+	testutil.AssertNoError(t, json.Unmarshal(values[0], asset), fmt.Sprintf("Error when trying to unmarshal a document"))
+	testutil.AssertEquals(t, asset.ID, "1")
+	testutil.AssertEquals(t, asset.AssetName, "marble1")
+	testutil.AssertEquals(t, values[1], []byte("binary value"))
+	testutil.AssertNil(t, values[2])
+
+	//Saving again without the revision results in a conflict
+	resps, err = db.BatchUpdateDocuments(docs[:1])
+	testutil.AssertNoError(t, err, fmt.Sprintf("Error when trying to batch update documents"))
+	testutil.AssertEquals(t, resps[0].Error, "conflict")
+
+	//Retrieve the revisions and delete the first document
+	revs, err := db.BatchRetrieveDocumentRevisions([]string{"1", "2", "3"})
+	testutil.AssertNoError(t, err, fmt.Sprintf("Error when trying to retrieve document revisions"))
+	testutil.AssertEquals(t, len(revs), 2)
+	resps, err = db.BatchUpdateDocuments([]*CouchDoc{&CouchDoc{ID: "1", Rev: revs["1"], Deleted: true}})
+	testutil.AssertNoError(t, err, fmt.Sprintf("Error when trying to batch delete documents"))
+	testutil.AssertEquals(t, resps[0].Ok, true)
+
+	//The deleted document has no revision
+	revs, err = db.BatchRetrieveDocumentRevisions([]string{"1", "2"})
+	testutil.AssertNoError(t, err, fmt.Sprintf("Error when trying to retrieve document revisions"))
+	testutil.AssertEquals(t, len(revs), 1)
+	_, ok := revs["2"]
+	testutil.AssertEquals(t, ok, true)
+	values, err = db.BatchReadDocuments([]string{"1", "2"})
+	testutil.AssertNoError(t, err, fmt.Sprintf("Error when trying to batch read documents"))
+	testutil.AssertNil(t, values[0])
+	testutil.AssertEquals(t, values[1], []byte("binary value"))
+}
+
+//createBatchTestServerConnection returns a connection to a test server that implements the
+//_bulk_docs and _all_docs requests of CouchDB on documents held in memory. The attachments are
+//kept inline, as CouchDB returns them with attachments=true
+func createBatchTestServerConnection(t *testing.T) (*CouchDBConnectionDef, *httptest.Server) {
+	type fakeDoc struct {
+		rev     int
+		deleted bool
+		body    map[string]interface{}
+	}
+	docs := make(map[string]*fakeDoc)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/" + database + "/_bulk_docs":
+			request := struct {
+				Docs []map[string]interface{} `json:"docs"`
+			}{}
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			resps := []*BatchUpdateResponse{}
+			for _, jsonDoc := range request.Docs {
+				id, _ := jsonDoc["_id"].(string)
+				rev, _ := jsonDoc["_rev"].(string)
+				doc, exists := docs[id]
+				if exists && !doc.deleted && rev != strconv.Itoa(doc.rev) {
+					resps = append(resps, &BatchUpdateResponse{ID: id, Error: "conflict", Reason: "Document update conflict."})
+					continue
+				}
+				if !exists {
+					doc = &fakeDoc{}
+					docs[id] = doc
+				}
+				doc.rev++
+				doc.deleted = jsonDoc["_deleted"] == true
+				doc.body = jsonDoc
+				resps = append(resps, &BatchUpdateResponse{ID: id, Rev: strconv.Itoa(doc.rev), Ok: true})
+			}
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(resps)
+		case "/" + database + "/_all_docs":
+			request := struct {
+				Keys []string `json:"keys"`
+			}{}
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			rows := []map[string]interface{}{}
+			for _, id := range request.Keys {
+				doc, exists := docs[id]
+				if !exists {
+					rows = append(rows, map[string]interface{}{"key": id, "error": "not_found"})
+					continue
+				}
+				row := map[string]interface{}{"id": id, "key": id,
+					"value": map[string]interface{}{"rev": strconv.Itoa(doc.rev), "deleted": doc.deleted}}
+				if r.URL.Query().Get("include_docs") == "true" {
+					row["doc"] = nil
+					if !doc.deleted {
+						doc.body["_rev"] = strconv.Itoa(doc.rev)
+						row["doc"] = doc.body
+					}
+				}
+				rows = append(rows, row)
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"rows": rows})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	host, portStr, _ := net.SplitHostPort(server.Listener.Addr().String())
+	serverPort, _ := strconv.Atoi(portStr)
+	db, err := CreateConnectionDefinition(host, serverPort, database, username, password)
+	testutil.AssertNoError(t, err, fmt.Sprintf("Error when trying to create database connection definition"))
+	return db, server
+}
+
+//createTestServerConnection returns a connection to a test server that fails with
+//the given status code for the first `failures` requests
+func createTestServerConnection(t *testing.T, failures int, statusCode int, options ConnectionOptions) (*CouchDBConnectionDef, *httptest.Server) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests <= failures {
+			w.WriteHeader(statusCode)
+			w.Write([]byte(`{"error":"unavailable","reason":"test failure"}`))
+			return
+		}
+		w.Write([]byte(`{"couchdb":"Welcome"}`))
+	}))
+	host, portStr, _ := net.SplitHostPort(server.Listener.Addr().String())
+	serverPort, _ := strconv.Atoi(portStr)
+	db, err := CreateConnectionDefinitionWithOptions(host, serverPort, database, username, password, options)
+	testutil.AssertNoError(t, err, fmt.Sprintf("Error when trying to create database connection definition"))
+	return db, server
+}
+
+func TestDBRequestRetry(t *testing.T) {
+
+	options := ConnectionOptions{MaxConnections: 2, MaxRetries: 3, RetryBackoff: time.Millisecond}
+
+	//server errors are retried
+	db, server := createTestServerConnection(t, 2, http.StatusServiceUnavailable, options)
+	defer server.Close()
+	testutil.AssertNoError(t, db.HealthCheck(), fmt.Sprintf("Error when checking the health of the server"))
+	stats := db.GetRequestStats()
+	testutil.AssertEquals(t, stats.Requests, uint64(1))
+	testutil.AssertEquals(t, stats.Retries, uint64(2))
+	testutil.AssertEquals(t, stats.Failures, uint64(0))
+
+	//the request fails once the retries are exhausted
+	db, server = createTestServerConnection(t, 10, http.StatusServiceUnavailable, options)
+	defer server.Close()
+	testutil.AssertError(t, db.HealthCheck(), fmt.Sprintf("Did not receive error when the retries are exhausted"))
+	stats = db.GetRequestStats()
+	testutil.AssertEquals(t, stats.Retries, uint64(3))
+	testutil.AssertEquals(t, stats.Failures, uint64(1))
+
+	//client errors are not retried
+	db, server = createTestServerConnection(t, 1, http.StatusBadRequest, options)
+	defer server.Close()
+	testutil.AssertError(t, db.HealthCheck(), fmt.Sprintf("Did not receive error for a bad request"))
+	testutil.AssertEquals(t, db.GetRequestStats().Retries, uint64(0))
+
+	//requests that write are not retried
+	db, server = createTestServerConnection(t, 1, http.StatusServiceUnavailable, options)
+	defer server.Close()
+	_, err := db.BatchUpdateDocuments([]*CouchDoc{&CouchDoc{ID: "1", JSONValue: assetJSON}})
+	testutil.AssertError(t, err, fmt.Sprintf("Did not receive error for a failed bulk update"))
+	testutil.AssertEquals(t, IsTransient(err), true)
+	testutil.AssertEquals(t, db.GetRequestStats().Retries, uint64(0))
+
+	//requests that only read are retried, also when they are sent with POST
+	db, server = createTestServerConnection(t, 1, http.StatusServiceUnavailable, options)
+	defer server.Close()
+	db.BatchRetrieveDocumentRevisions([]string{"1"})
+	testutil.AssertEquals(t, db.GetRequestStats().Retries, uint64(1))
+
+	//the connection slots are released, also after failed requests
+	for i := 0; i < 5; i++ {
+		testutil.AssertNoError(t, db.HealthCheck(), fmt.Sprintf("Error when checking the health of the server"))
+	}
+}
+
+func TestDBRequestTimeout(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+	host, portStr, _ := net.SplitHostPort(server.Listener.Addr().String())
+	serverPort, _ := strconv.Atoi(portStr)
+	db, err := CreateConnectionDefinitionWithOptions(host, serverPort, database, username, password,
+		ConnectionOptions{RequestTimeout: 50 * time.Millisecond})
+	testutil.AssertNoError(t, err, fmt.Sprintf("Error when trying to create database connection definition"))
+	testutil.AssertError(t, db.HealthCheck(), fmt.Sprintf("Did not receive error for a request that timed out"))
+}
+
+func TestDBErrorClassification(t *testing.T) {
+	testutil.AssertEquals(t, IsConflict(&RequestError{StatusCode: http.StatusConflict}), true)
+	testutil.AssertEquals(t, IsConflict(&RequestError{StatusCode: http.StatusBadRequest}), false)
+	testutil.AssertEquals(t, IsConflict(fmt.Errorf("connection refused")), false)
+
+	testutil.AssertEquals(t, IsTransient(fmt.Errorf("connection refused")), true)
+	testutil.AssertEquals(t, IsTransient(&RequestError{StatusCode: http.StatusServiceUnavailable}), true)
+	testutil.AssertEquals(t, IsTransient(&RequestError{StatusCode: http.StatusTooManyRequests}), true)
+	testutil.AssertEquals(t, IsTransient(&RequestError{StatusCode: http.StatusBadRequest}), false)
+	testutil.AssertEquals(t, IsTransient(&RequestError{StatusCode: http.StatusConflict}), false)
+	testutil.AssertEquals(t, IsTransient(nil), false)
+
+	testutil.AssertEquals(t, isReadOnlyRequest(http.MethodGet, "http://localhost/db/doc"), true)
+	testutil.AssertEquals(t, isReadOnlyRequest(http.MethodPost, "http://localhost/db/_all_docs?include_docs=true"), true)
+	testutil.AssertEquals(t, isReadOnlyRequest(http.MethodPost, "http://localhost/db/_find"), true)
+	testutil.AssertEquals(t, isReadOnlyRequest(http.MethodPost, "http://localhost/db/_bulk_docs"), false)
+	testutil.AssertEquals(t, isReadOnlyRequest(http.MethodPut, "http://localhost/db/doc"), false)
+}
+
+func TestDBRequestMetrics(t *testing.T) {
+	provider := metrics.NewPrometheusProvider()
+	db, server := createTestServerConnection(t, 1, http.StatusServiceUnavailable,
+		ConnectionOptions{MaxRetries: 1, RetryBackoff: time.Millisecond})
+	defer server.Close()
+	db.metrics = newRequestMetrics(provider, database)
+	testutil.AssertNoError(t, db.HealthCheck(), fmt.Sprintf("Error when checking the health of the server"))
+
+	buf := &bytes.Buffer{}
+	provider.WriteMetrics(buf)
+	output := buf.String()
+	for _, expected := range []string{
+		`couchdb_request_retries{database="testdb1",method="GET"} 1`,
+		`couchdb_request_duration_count{database="testdb1",method="GET",success="true"} 1`,
+	} {
+		if !strings.Contains(output, expected) {
+			t.Fatalf("Expected %s in the metrics:\n%s", expected, output)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package couchdb
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/hyperledger/fabric/core/metrics"
+)
+
+// requestMetrics are the metrics of the requests made to a CouchDB database
+type requestMetrics struct {
+	duration metrics.Histogram
+	retries  metrics.Counter
+}
+
+func newRequestMetrics(p metrics.Provider, database string) *requestMetrics {
+	return &requestMetrics{
+		duration: p.NewHistogram(metrics.HistogramOpts{
+			Namespace:  "couchdb",
+			Name:       "request_duration",
+			Help:       "The time to complete a request to CouchDB, including its retries, in seconds.",
+			LabelNames: []string{"database", "method", "success"},
+		}).With("database", database),
+		retries: p.NewCounter(metrics.CounterOpts{
+			Namespace:  "couchdb",
+			Name:       "request_retries",
+			Help:       "The number of requests to CouchDB retried after a transient failure.",
+			LabelNames: []string{"database", "method"},
+		}).With("database", database),
+	}
+}
+
+// observe records a request with the given method since start, which ended with err
+func (m *requestMetrics) observe(method string, start time.Time, err error) {
+	m.duration.With("method", method, "success", strconv.FormatBool(err == nil)).Observe(time.Since(start).Seconds())
+}
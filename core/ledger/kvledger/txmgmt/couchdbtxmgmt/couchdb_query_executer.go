@@ -17,9 +17,13 @@ limitations under the License.
 package couchdbtxmgmt
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
+	"fmt"
 
 	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/couchdbtxmgmt/couchdb"
 )
 
 // CouchDBQueryExecutor is a query executor used in `CouchDBTxMgr`
@@ -38,8 +42,13 @@ func (q *CouchDBQueryExecutor) GetState(ns string, key string) ([]byte, error) {
 }
 
 // GetStateMultipleKeys implements method in interface `ledger.QueryExecutor`
+// The values are read with a single request to CouchDB
 func (q *CouchDBQueryExecutor) GetStateMultipleKeys(namespace string, keys []string) ([][]byte, error) {
-	return nil, errors.New("Not yet implemented")
+	values, _, err := q.txmgr.getCommittedValuesAndVersions(namespace, keys)
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
 }
 
 // GetStateRangeScanIterator implements method in interface `ledger.QueryExecutor`
@@ -52,7 +61,232 @@ func (q *CouchDBQueryExecutor) GetTransactionsForKey(namespace string, key strin
 	return nil, errors.New("Not yet implemented")
 }
 
-// ExecuteQuery implements method in interface `ledger.QueryExecutor`
-func (q *CouchDBQueryExecutor) ExecuteQuery(query string) (ledger.ResultsIterator, error) {
-	return nil, errors.New("Not supported by KV data model")
+// defaultInternalQueryLimit is the number of documents fetched from CouchDB in a single request by the iterator
+// returned from ExecuteQuery, if the internal query limit is not set
+const defaultInternalQueryLimit = 1000
+
+// ExecuteQuery implements method in interface `ledger.QueryExecutor`.
+// The query is expected to be a CouchDB query containing a "selector". The returned iterator fetches
+// the results from CouchDB in batches of the internal query limit as it advances, and returns at most
+// the query result limit of results
+func (q *CouchDBQueryExecutor) ExecuteQuery(namespace string, query string) (ledger.ResultsIterator, error) {
+	page, err := q.executeQueryPage(namespace, query, int32(q.txmgr.queryLimits.InternalQueryLimit), "")
+	if err != nil {
+		return nil, err
+	}
+	return &queryResultsItr{queryExecutor: q, namespace: namespace, query: query, page: page,
+		queryResultLimit: q.txmgr.queryLimits.QueryResultLimit}, nil
+}
+
+// GetStateRangeScanIteratorWithPagination implements method in interface `ledger.QueryExecutor`.
+// The keys are selected by document id, sorted, and the results are not recorded in the read set. One
+// document more than the page size is fetched, whose key is the bookmark of the next page. A page size
+// of 0 selects the default page size of the query limits
+func (q *CouchDBQueryExecutor) GetStateRangeScanIteratorWithPagination(namespace string, startKey string, endKey string, pageSize int32, bookmark string) (ledger.PaginatedResultsIterator, error) {
+	pageSize, err := q.txmgr.queryLimits.GetPageSize(pageSize)
+	if err != nil {
+		return nil, err
+	}
+	if q.txmgr.encrypter != nil {
+		return nil, errors.New("Paginated range queries are not supported when state encryption is enabled")
+	}
+	if bookmark != "" {
+		if bookmark < startKey || (endKey != "" && bookmark > endKey) {
+			return nil, fmt.Errorf("Bookmark [%s] is outside of the range [%s, %s]", bookmark, startKey, endKey)
+		}
+		startKey = bookmark
+	}
+
+	rangeQuery, err := constructRangeQuery(namespace, startKey, endKey, pageSize+1)
+	if err != nil {
+		return nil, err
+	}
+	results, _, err := q.txmgr.couchDB.QueryDocuments(rangeQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &queryScanner{results: *results}
+	if int32(len(page.results)) > pageSize {
+		next, err := page.resultKey(page.results[pageSize])
+		if err != nil {
+			return nil, err
+		}
+		page.results = page.results[:pageSize]
+		page.bookmark = next
+	}
+	return page, nil
+}
+
+// ExecuteQueryWithPagination implements method in interface `ledger.QueryExecutor`.
+// The query is expected to be a CouchDB query containing a "selector". The selector is restricted to
+// the documents of the given namespace and the results are not recorded in the read set. A page size of 0
+// selects the default page size of the query limits
+func (q *CouchDBQueryExecutor) ExecuteQueryWithPagination(namespace string, query string, pageSize int32, bookmark string) (ledger.PaginatedResultsIterator, error) {
+	pageSize, err := q.txmgr.queryLimits.GetPageSize(pageSize)
+	if err != nil {
+		return nil, err
+	}
+	page, err := q.executeQueryPage(namespace, query, pageSize, bookmark)
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// executeQueryPage fetches a page of at most `pageSize` results of the query in a single request
+func (q *CouchDBQueryExecutor) executeQueryPage(namespace string, query string, pageSize int32, bookmark string) (*queryScanner, error) {
+	if q.txmgr.encrypter != nil {
+		return nil, errors.New("Rich queries are not supported when state encryption is enabled")
+	}
+	namespaceQuery, err := constructNamespaceQuery(namespace, query, pageSize, bookmark)
+	if err != nil {
+		return nil, err
+	}
+	results, nextBookmark, err := q.txmgr.couchDB.QueryDocuments(namespaceQuery)
+	if err != nil {
+		return nil, err
+	}
+	if int32(len(*results)) < pageSize {
+		// a partial page indicates that the result set is exhausted
+		nextBookmark = ""
+	}
+	return &queryScanner{results: *results, bookmark: nextBookmark}, nil
+}
+
+// constructRangeQuery returns the query of at most `limit` documents of the namespace whose keys are
+// between startKey and endKey, inclusive, sorted by key. An empty endKey does not bound the range
+func constructRangeQuery(namespace string, startKey string, endKey string, limit int32) (string, error) {
+	idRange := map[string]interface{}{"$gte": string(constructCompositeKey(namespace, startKey))}
+	if endKey == "" {
+		// scan till the end of the namespace
+		idRange["$lt"] = namespace + string(byte(1))
+	} else {
+		idRange["$lte"] = string(constructCompositeKey(namespace, endKey))
+	}
+	rangeQuery, err := json.Marshal(map[string]interface{}{
+		"selector": map[string]interface{}{"_id": idRange},
+		"sort":     []interface{}{map[string]string{"_id": "asc"}},
+		"limit":    limit,
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(rangeQuery), nil
+}
+
+// constructNamespaceQuery adds the namespace restriction and the pagination options to the given query
+func constructNamespaceQuery(namespace string, query string, pageSize int32, bookmark string) (string, error) {
+	jsonQuery := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(query), &jsonQuery); err != nil {
+		return "", fmt.Errorf("Query is not a valid JSON: %s", err)
+	}
+	selector, ok := jsonQuery["selector"]
+	if !ok {
+		return "", errors.New("Query does not contain a selector")
+	}
+	namespaceSelector := map[string]interface{}{
+		"_id": map[string]interface{}{
+			"$gt": string(constructCompositeKey(namespace, "")),
+			"$lt": namespace + string(byte(1)),
+		},
+	}
+	jsonQuery["selector"] = map[string]interface{}{"$and": []interface{}{namespaceSelector, selector}}
+	jsonQuery["limit"] = pageSize
+	delete(jsonQuery, "skip")
+	if bookmark != "" {
+		jsonQuery["bookmark"] = bookmark
+	}
+	namespaceQuery, err := json.Marshal(jsonQuery)
+	if err != nil {
+		return "", err
+	}
+	return string(namespaceQuery), nil
+}
+
+// queryScanner implements interface `ledger.PaginatedResultsIterator` over a page of CouchDB query results
+type queryScanner struct {
+	results  []couchdb.QueryResult
+	cursor   int
+	bookmark string
+}
+
+// Next implements method in interface `ledger.ResultsIterator`
+func (scanner *queryScanner) Next() (ledger.QueryResult, error) {
+	if scanner.cursor >= len(scanner.results) {
+		return nil, nil
+	}
+	result := scanner.results[scanner.cursor]
+	scanner.cursor++
+	key, err := scanner.resultKey(result)
+	if err != nil {
+		return nil, err
+	}
+	return ledger.KV{Key: key, Value: result.Value}, nil
+}
+
+// resultKey returns the key of the state a query result is the document of
+func (scanner *queryScanner) resultKey(result couchdb.QueryResult) (string, error) {
+	split := bytes.SplitN([]byte(result.ID), []byte{0x00}, 2)
+	if len(split) != 2 {
+		return "", fmt.Errorf("Unexpected document id [%s] in query results", result.ID)
+	}
+	return string(split[1]), nil
+}
+
+// GetBookmark implements method in interface `ledger.PaginatedResultsIterator`
+func (scanner *queryScanner) GetBookmark() string {
+	return scanner.bookmark
+}
+
+// Close implements method in interface `ledger.ResultsIterator`
+func (scanner *queryScanner) Close() {
+	scanner.results = nil
+}
+
+// queryResultsItr implements interface `ledger.ResultsIterator` over all the results of a CouchDB query,
+// fetching the next batch of results using the bookmark of the current one
+type queryResultsItr struct {
+	queryExecutor    *CouchDBQueryExecutor
+	namespace        string
+	query            string
+	page             *queryScanner
+	queryResultLimit int
+	returned         int
+	truncated        bool
+}
+
+// Next implements method in interface `ledger.ResultsIterator`
+func (itr *queryResultsItr) Next() (ledger.QueryResult, error) {
+	if itr.queryResultLimit > 0 && itr.returned >= itr.queryResultLimit {
+		if !itr.truncated {
+			logger.Warningf("Query result limit of %d results reached, the results of query [%s] are truncated", itr.queryResultLimit, itr.query)
+			itr.truncated = true
+		}
+		return nil, nil
+	}
+	for {
+		queryResult, err := itr.page.Next()
+		if err != nil || queryResult != nil {
+			if queryResult != nil {
+				itr.returned++
+			}
+			return queryResult, err
+		}
+		bookmark := itr.page.GetBookmark()
+		if bookmark == "" {
+			return nil, nil
+		}
+		nextPage, err := itr.queryExecutor.executeQueryPage(itr.namespace, itr.query, int32(itr.queryExecutor.txmgr.queryLimits.InternalQueryLimit), bookmark)
+		if err != nil {
+			return nil, err
+		}
+		itr.page.Close()
+		itr.page = nextPage
+	}
+}
+
+// Close implements method in interface `ledger.ResultsIterator`
+func (itr *queryResultsItr) Close() {
+	itr.page.Close()
 }
@@ -112,6 +112,39 @@ func (s *CouchDBTxSimulator) GetState(ns string, key string) ([]byte, error) {
 	return value, nil
 }
 
+// GetStateMultipleKeys implements method in interface `ledger.TxSimulator`.
+// The keys that are neither in the write set nor in the read set are read from CouchDB with a single request
+// and added to the read set
+func (s *CouchDBTxSimulator) GetStateMultipleKeys(ns string, keys []string) ([][]byte, error) {
+	nsRWs := s.getOrCreateNsRWHolder(ns)
+	values := make([][]byte, len(keys))
+	var pendingKeys []string
+	var pendingIndexes []int
+	for i, key := range keys {
+		if kvWrite, ok := nsRWs.writeMap[key]; ok {
+			values[i] = kvWrite.Value
+		} else if readCache, ok := nsRWs.readMap[key]; ok {
+			values[i] = readCache.cachedValue
+		} else {
+			pendingKeys = append(pendingKeys, key)
+			pendingIndexes = append(pendingIndexes, i)
+		}
+	}
+	if len(pendingKeys) == 0 {
+		return values, nil
+	}
+
+	committedValues, versions, err := s.txmgr.getCommittedValuesAndVersions(ns, pendingKeys)
+	if err != nil {
+		return nil, err
+	}
+	for j, key := range pendingKeys {
+		values[pendingIndexes[j]] = committedValues[j]
+		nsRWs.readMap[key] = &kvReadCache{txmgmt.NewKVRead(key, versions[j]), committedValues[j]}
+	}
+	return values, nil
+}
+
 // SetState implements method in interface `ledger.TxSimulator`
 func (s *CouchDBTxSimulator) SetState(ns string, key string, value []byte) error {
 	logger.Debugf("===COUCHDB=== Entering CouchDBTxSimulator.SetState()")
@@ -197,6 +230,11 @@ func (s *CouchDBTxSimulator) SetStateMultipleKeys(namespace string, kvs map[stri
 	return errors.New("Not yet implemented")
 }
 
+// GetLedgerHeight implements method in interface `ledger.TxSimulator`
+func (s *CouchDBTxSimulator) GetLedgerHeight() (uint64, error) {
+	return 0, errors.New("Not yet implemented")
+}
+
 // CopyState implements method in interface `ledger.TxSimulator`
 func (s *CouchDBTxSimulator) CopyState(sourceNamespace string, targetNamespace string) error {
 	return errors.New("Not yet implemented")
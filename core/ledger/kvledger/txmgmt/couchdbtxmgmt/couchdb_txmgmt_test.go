@@ -17,11 +17,19 @@ limitations under the License.
 package couchdbtxmgmt
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strconv"
 	"testing"
 
+	"github.com/hyperledger/fabric/core/ledger"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/kvledgerconfig"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/couchdbtxmgmt/couchdb"
 	"github.com/hyperledger/fabric/core/ledger/testutil"
 )
@@ -36,7 +44,7 @@ type testEnv struct {
 }
 
 func newTestEnv(t testing.TB) *testEnv {
-	conf := &Conf{"/tmp/tests/ledger/kvledger/txmgmt/couchdbtxmgmt"}
+	conf := &Conf{DBPath: "/tmp/tests/ledger/kvledger/txmgmt/couchdbtxmgmt"}
 	os.RemoveAll(conf.DBPath)
 	return &testEnv{
 		conf:              conf,
@@ -104,3 +112,151 @@ func TestDatabaseAutoCreate(t *testing.T) {
 	}
 
 }
+
+func TestSplitIntoChunks(t *testing.T) {
+	txMgr := &CouchDBTxMgr{updateSet: newUpdateSet(), maxBatchUpdateSize: 3}
+	keys := []string{}
+	for i := 0; i < 7; i++ {
+		key := fmt.Sprintf("key%d", i)
+		keys = append(keys, key)
+		txMgr.updateSet.m[key] = &versionedValue{value: make([]byte, 10)}
+	}
+	txMgr.updateSet.m["key4"].value = make([]byte, 100)
+
+	chunks := txMgr.splitIntoChunks(keys)
+	testutil.AssertEquals(t, chunks, [][]string{keys[0:3], keys[3:6], keys[6:7]})
+
+	// the byte threshold closes a chunk early and a larger value gets a chunk of its own
+	txMgr.maxBatchUpdateBytes = 25
+	chunks = txMgr.splitIntoChunks(keys)
+	testutil.AssertEquals(t, chunks, [][]string{keys[0:2], keys[2:4], keys[4:5], keys[5:7]})
+}
+
+func TestConstructRangeQuery(t *testing.T) {
+	query, err := constructRangeQuery("ns1", "key1", "key5", 3)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, query,
+		`{"limit":3,"selector":{"_id":{"$gte":"ns1\u0000key1","$lte":"ns1\u0000key5"}},"sort":[{"_id":"asc"}]}`)
+
+	// an open ended range stops at the end of the namespace
+	query, err = constructRangeQuery("ns1", "key1", "", 3)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, query,
+		`{"limit":3,"selector":{"_id":{"$gte":"ns1\u0000key1","$lt":"ns1\u0001"}},"sort":[{"_id":"asc"}]}`)
+}
+
+func TestQueryScannerBookmark(t *testing.T) {
+	scanner := &queryScanner{results: []couchdb.QueryResult{{ID: "ns1\x00key1"}, {ID: "ns1\x00key2"}}, bookmark: "key3"}
+	kv, err := scanner.Next()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, kv.(ledger.KV).Key, "key1")
+	scanner.Next()
+	kv, _ = scanner.Next()
+	testutil.AssertNil(t, kv)
+	testutil.AssertEquals(t, scanner.GetBookmark(), "key3")
+}
+
+// newQueryTestTxMgr returns a transaction manager on a test server that answers the _find requests with
+// pages of the documents of the keys key0 to key<numDocs-1> of namespace ns1, the bookmark being the index
+// of the first document of the next page
+func newQueryTestTxMgr(t *testing.T, numDocs int, queryLimits txmgmt.QueryLimits) (*CouchDBTxMgr, *httptest.Server) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := struct {
+			Limit    int    `json:"limit"`
+			Bookmark string `json:"bookmark"`
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		start, _ := strconv.Atoi(request.Bookmark)
+		docs := []map[string]string{}
+		for i := start; i < numDocs && i < start+request.Limit; i++ {
+			docs = append(docs, map[string]string{"_id": string(constructCompositeKey("ns1", fmt.Sprintf("key%d", i)))})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"docs": docs, "bookmark": strconv.Itoa(start + len(docs))})
+	}))
+	host, portStr, _ := net.SplitHostPort(server.Listener.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+	couchDB, err := couchdb.CreateConnectionDefinition(host, port, "system_test", "", "")
+	testutil.AssertNoError(t, err, "")
+	return &CouchDBTxMgr{couchDB: couchDB, queryLimits: queryLimits}, server
+}
+
+func countQueryResults(t *testing.T, itr ledger.ResultsIterator) int {
+	defer itr.Close()
+	count := 0
+	for {
+		result, err := itr.Next()
+		testutil.AssertNoError(t, err, "")
+		if result == nil {
+			return count
+		}
+		count++
+	}
+}
+
+func TestExecuteQueryResultLimit(t *testing.T) {
+	query := `{"selector":{"owner":"jerry"}}`
+
+	// the results are fetched in pages of the internal query limit
+	txMgr, server := newQueryTestTxMgr(t, 10, txmgmt.QueryLimits{InternalQueryLimit: 3})
+	defer server.Close()
+	itr, err := (&CouchDBQueryExecutor{txMgr}).ExecuteQuery("ns1", query)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, countQueryResults(t, itr), 10)
+
+	// a single iterator returns at most the query result limit of results
+	txMgr, server = newQueryTestTxMgr(t, 10, txmgmt.QueryLimits{InternalQueryLimit: 3, QueryResultLimit: 4})
+	defer server.Close()
+	itr, err = (&CouchDBQueryExecutor{txMgr}).ExecuteQuery("ns1", query)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, countQueryResults(t, itr), 4)
+
+	// the limit applies to every iterator on its own
+	itr, err = (&CouchDBQueryExecutor{txMgr}).ExecuteQuery("ns1", query)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, countQueryResults(t, itr), 4)
+}
+
+func TestSavepoint(t *testing.T) {
+	// the test server stores the documents without their revisions
+	docs := map[string][]byte{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			doc, ok := docs[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"error":"not_found","reason":"missing"}`))
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Etag", `"1-rev"`)
+			w.Write(doc)
+		case http.MethodPut:
+			docs[r.URL.Path], _ = ioutil.ReadAll(r.Body)
+			w.Header().Set("Etag", `"1-rev"`)
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	defer server.Close()
+	host, portStr, _ := net.SplitHostPort(server.Listener.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+	couchDB, err := couchdb.CreateConnectionDefinition(host, port, "system_test", "", "")
+	testutil.AssertNoError(t, err, "")
+	txMgr := &CouchDBTxMgr{couchDB: couchDB}
+
+	// no savepoint is recorded before the first commit
+	savepoint, err := txMgr.GetLastSavepoint()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, savepoint, uint64(0))
+
+	for _, blockNum := range []uint64{1, 2} {
+		txMgr.updateSet = newUpdateSet()
+		testutil.AssertNoError(t, txMgr.CommitWithSavepoint(blockNum), "")
+		savepoint, err = txMgr.GetLastSavepoint()
+		testutil.AssertNoError(t, err, "")
+		testutil.AssertEquals(t, savepoint, blockNum)
+	}
+}
@@ -17,14 +17,20 @@ limitations under the License.
 package couchdbtxmgmt
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric/core/ledger"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/couchdbtxmgmt/couchdb"
 	"github.com/hyperledger/fabric/core/ledger/util/db"
+	"github.com/hyperledger/fabric/core/ledger/util/encryption"
+	"github.com/hyperledger/fabric/core/operations"
 	"github.com/hyperledger/fabric/protos"
 	putils "github.com/hyperledger/fabric/protos/utils"
 	"github.com/op/go-logging"
@@ -32,9 +38,40 @@ import (
 
 var logger = logging.MustGetLogger("couchdbtxmgmt")
 
+// defaultMaxBatchUpdateSize is the maximum number of documents written in a single bulk update
+// at commit, if not configured
+const defaultMaxBatchUpdateSize = 500
+
+// maxCommitRetries is the number of times an individual document update that failed because of
+// a revision conflict is retried at commit
+const maxCommitRetries = 3
+
+// savepointDocID is the ID of the document recording the savepoint. Without the
+// separator of the composite keys, it is outside of the key range of any namespace
+const savepointDocID = "savepoint"
+
+// savepointDoc is the document recording the savepoint
+type savepointDoc struct {
+	BlockNum uint64 `json:"blockNum"`
+}
+
 // Conf - configuration for `CouchDBTxMgr`
 type Conf struct {
 	DBPath string
+	// Encrypter, if set, is used to encrypt the values written to the state database.
+	// Encrypted values are stored as attachments and hence cannot be used in rich queries
+	Encrypter encryption.Encrypter
+	// CouchDBOptions tune the connection to CouchDB
+	CouchDBOptions couchdb.ConnectionOptions
+	// MaxBatchUpdateSize is the maximum number of documents in a bulk update at commit.
+	// `defaultMaxBatchUpdateSize` is used if not set
+	MaxBatchUpdateSize int
+	// MaxBatchUpdateBytes is the maximum size in bytes of the values in a bulk update at commit.
+	// A single value larger than this is still written. The size is not limited if not set
+	MaxBatchUpdateBytes int
+	// QueryLimits bound the queries of the query executors. `defaultInternalQueryLimit` is used
+	// if the internal query limit is not set
+	QueryLimits txmgmt.QueryLimits
 }
 
 type versionedValue struct {
@@ -66,10 +103,14 @@ func (u *updateSet) get(compositeKey []byte) *versionedValue {
 // CouchDBTxMgr a simple implementation of interface `txmgmt.TxMgr`.
 // This implementation uses a read-write lock to prevent conflicts between transaction simulation and committing
 type CouchDBTxMgr struct {
-	db           *db.DB
-	updateSet    *updateSet
-	commitRWLock sync.RWMutex
-	couchDB      *couchdb.CouchDBConnectionDef // COUCHDB new properties for CouchDB
+	db                  *db.DB
+	encrypter           encryption.Encrypter
+	updateSet           *updateSet
+	commitRWLock        sync.RWMutex
+	couchDB             *couchdb.CouchDBConnectionDef // COUCHDB new properties for CouchDB
+	maxBatchUpdateSize  int
+	maxBatchUpdateBytes int
+	queryLimits         txmgmt.QueryLimits
 }
 
 // CouchConnection provides connection info for CouchDB
@@ -88,23 +129,40 @@ func NewCouchDBTxMgr(conf *Conf, host string, port int, dbName string, id string
 	db := db.CreateDB(&db.Conf{DBPath: conf.DBPath})
 	db.Open()
 
-	couchDB, err := couchdb.CreateConnectionDefinition(host,
+	couchDB, err := couchdb.CreateConnectionDefinitionWithOptions(host,
 		port,
 		dbName,
 		id,
-		pw)
+		pw,
+		conf.CouchDBOptions)
 	if err != nil {
 		logger.Errorf("===COUCHDB=== Error during CreateConnectionDefinition(): %s\n", err.Error())
 	}
 
+	// Check that CouchDB is reachable, which also opens the first pooled connection
+	if err = couchDB.HealthCheck(); err != nil {
+		logger.Errorf("===COUCHDB=== CouchDB is not reachable at startup: %s\n", err.Error())
+	}
+	operations.RegisterChecker("couchdb."+dbName, couchDB.HealthCheck)
+
 	// Create CouchDB database upon ledger startup, if it doesn't already exist
 	_, err = couchDB.CreateDatabaseIfNotExist()
 	if err != nil {
 		logger.Errorf("===COUCHDB=== Error during CreateDatabaseIfNotExist(): %s\n", err.Error())
 	}
 
+	maxBatchUpdateSize := conf.MaxBatchUpdateSize
+	if maxBatchUpdateSize <= 0 {
+		maxBatchUpdateSize = defaultMaxBatchUpdateSize
+	}
+
 	// db and stateIndexCF will not be used for CouchDB. TODO to cleanup
-	return &CouchDBTxMgr{db: db, couchDB: couchDB}
+	queryLimits := conf.QueryLimits
+	if queryLimits.InternalQueryLimit <= 0 {
+		queryLimits.InternalQueryLimit = defaultInternalQueryLimit
+	}
+	return &CouchDBTxMgr{db: db, encrypter: conf.Encrypter, couchDB: couchDB,
+		maxBatchUpdateSize: maxBatchUpdateSize, maxBatchUpdateBytes: conf.MaxBatchUpdateBytes, queryLimits: queryLimits}
 }
 
 // NewQueryExecutor implements method in interface `txmgmt.TxMgr`
@@ -266,54 +324,257 @@ func (txmgr *CouchDBTxMgr) Commit() error {
 	defer txmgr.commitRWLock.Unlock()
 	defer func() { txmgr.updateSet = nil }()
 
-	for k, v := range txmgr.updateSet.m {
-
-		if couchdb.IsJSON(string(v.value)) {
-
-			// SaveDoc using couchdb client and use JSON format
-			rev, err := txmgr.couchDB.SaveDoc(k, "", v.value, nil)
-			if err != nil {
-				logger.Errorf("===COUCHDB=== Error during Commit(): %s\n", err.Error())
-				return err
-			}
-			if rev != "" {
-				logger.Debugf("===COUCHDB=== Saved document revision number: %s\n", rev)
+	// Split the sorted keys of the update set into chunks that are written in parallel,
+	// each chunk using a single bulk update request
+	keys := make([]string, 0, len(txmgr.updateSet.m))
+	for k := range txmgr.updateSet.m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	chunks := txmgr.splitIntoChunks(keys)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(chunks))
+	for _, chunk := range chunks {
+		wg.Add(1)
+		go func(chunk []string) {
+			defer wg.Done()
+			if err := txmgr.commitChunk(chunk); err != nil {
+				errs <- err
 			}
+		}(chunk)
+	}
+	wg.Wait()
+	close(errs)
 
-		} else {
+	if err := <-errs; err != nil {
+		logger.Errorf("===COUCHDB=== Error during Commit(): %s\n", err.Error())
+		return err
+	}
 
-			//Create an attachment structure and load the bytes
-			attachment := &couchdb.Attachment{}
-			attachment.AttachmentBytes = v.value
-			attachment.ContentType = "application/octet-stream"
-			attachment.Name = "valueBytes"
+	if logger.IsEnabledFor(logging.DEBUG) {
+		stats := txmgr.couchDB.GetRequestStats()
+		logger.Debugf("===COUCHDB=== CouchDB requests=%d retries=%d failures=%d totalLatency=%s",
+			stats.Requests, stats.Retries, stats.Failures, time.Duration(stats.TotalLatency))
+	}
 
-			attachments := []couchdb.Attachment{}
-			attachments = append(attachments, *attachment)
+	logger.Debugf("===COUCHDB=== Exiting CouchDBTxMgr.Commit()")
+	return nil
+}
 
-			// SaveDoc using couchdb client and use attachment
-			rev, err := txmgr.couchDB.SaveDoc(k, "", nil, attachments)
-			if err != nil {
-				logger.Errorf("===COUCHDB=== Error during Commit(): %s\n", err.Error())
-				return err
-			}
-			if rev != "" {
-				logger.Debugf("===COUCHDB=== Saved document revision number: %s\n", rev)
-			}
+// splitIntoChunks splits the keys into chunks of at most `maxBatchUpdateSize` keys whose
+// values have at most `maxBatchUpdateBytes` bytes in total
+func (txmgr *CouchDBTxMgr) splitIntoChunks(keys []string) [][]string {
+	var chunks [][]string
+	start := 0
+	chunkBytes := 0
+	for i, k := range keys {
+		valueBytes := len(txmgr.updateSet.m[k].value)
+		full := i-start == txmgr.maxBatchUpdateSize ||
+			(txmgr.maxBatchUpdateBytes > 0 && i > start && chunkBytes+valueBytes > txmgr.maxBatchUpdateBytes)
+		if full {
+			chunks = append(chunks, keys[start:i])
+			start = i
+			chunkBytes = 0
+		}
+		chunkBytes += valueBytes
+	}
+	if start < len(keys) {
+		chunks = append(chunks, keys[start:])
+	}
+	return chunks
+}
 
+// commitChunk writes the updates for the given keys with a bulk update request. The documents
+// that fail because of a revision conflict are then written one at a time
+func (txmgr *CouchDBTxMgr) commitChunk(keys []string) error {
+	docs := make(map[string]*couchdb.CouchDoc)
+	for _, k := range keys {
+		doc, err := txmgr.createCouchDoc(k, txmgr.updateSet.m[k].value)
+		if err != nil {
+			return err
 		}
+		docs[k] = doc
+	}
 
+	revisions, err := txmgr.couchDB.BatchRetrieveDocumentRevisions(keys)
+	if err != nil {
+		return err
+	}
+	batch := make([]*couchdb.CouchDoc, 0, len(keys))
+	for _, k := range keys {
+		doc := docs[k]
+		doc.Rev = revisions[k]
+		if doc.Deleted && doc.Rev == "" {
+			// nothing to delete
+			continue
+		}
+		batch = append(batch, doc)
+	}
+	if len(batch) == 0 {
+		return nil
 	}
 
-	logger.Debugf("===COUCHDB=== Exiting CouchDBTxMgr.Commit()")
+	responses, err := txmgr.couchDB.BatchUpdateDocuments(batch)
+	if err != nil {
+		return err
+	}
+	for _, resp := range responses {
+		if resp.Ok {
+			logger.Debugf("===COUCHDB=== Saved document %s revision number: %s\n", resp.ID, resp.Rev)
+			continue
+		}
+		if resp.Error != "conflict" {
+			return fmt.Errorf("Error saving document %s: %s %s", resp.ID, resp.Error, resp.Reason)
+		}
+		logger.Debugf("===COUCHDB=== Revision conflict for document %s, saving it individually\n", resp.ID)
+		if err = txmgr.commitDoc(docs[resp.ID]); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// commitDoc writes a single document with its latest revision. A revision conflict, caused by a
+// concurrent update of the document, and a transient failure are retried. As the latest revision is
+// read again before every attempt, a write that was applied although it failed is not duplicated
+func (txmgr *CouchDBTxMgr) commitDoc(doc *couchdb.CouchDoc) error {
+	var err error
+	for attempt := 0; attempt <= maxCommitRetries; attempt++ {
+		var revisions map[string]string
+		if revisions, err = txmgr.couchDB.BatchRetrieveDocumentRevisions([]string{doc.ID}); err != nil {
+			return err
+		}
+		rev := revisions[doc.ID]
+		switch {
+		case doc.Deleted && rev == "":
+			return nil
+		case doc.Deleted:
+			err = txmgr.couchDB.DeleteDoc(doc.ID, rev)
+		case doc.JSONValue != nil:
+			_, err = txmgr.couchDB.SaveDoc(doc.ID, rev, doc.JSONValue, nil)
+		default:
+			_, err = txmgr.couchDB.SaveDoc(doc.ID, rev, nil, doc.Attachments)
+		}
+		if err == nil {
+			return nil
+		}
+		if !couchdb.IsConflict(err) && !couchdb.IsTransient(err) {
+			break
+		}
+		logger.Debugf("===COUCHDB=== Error saving document %s individually, retrying: %s\n", doc.ID, err)
+	}
+	return fmt.Errorf("Error saving document %s: %s", doc.ID, err)
+}
+
+// createCouchDoc builds the document for a key of the update set. JSON values are
+// stored as the document itself, any other (or encrypted) value as an attachment
+func (txmgr *CouchDBTxMgr) createCouchDoc(key string, value []byte) (*couchdb.CouchDoc, error) {
+	if value == nil {
+		return &couchdb.CouchDoc{ID: key, Deleted: true}, nil
+	}
+
+	if txmgr.encrypter == nil && couchdb.IsJSON(string(value)) {
+		return &couchdb.CouchDoc{ID: key, JSONValue: value}, nil
+	}
+
+	if txmgr.encrypter != nil {
+		var err error
+		if value, err = txmgr.encrypter.Encrypt(value); err != nil {
+			return nil, err
+		}
+	}
+
+	//Create an attachment structure and load the bytes
+	attachment := couchdb.Attachment{}
+	attachment.AttachmentBytes = value
+	attachment.ContentType = "application/octet-stream"
+	attachment.Name = "valueBytes"
+
+	return &couchdb.CouchDoc{ID: key, Attachments: []couchdb.Attachment{attachment}}, nil
+}
+
 // Rollback implements method in interface `txmgmt.TxMgr`
 func (txmgr *CouchDBTxMgr) Rollback() {
 	txmgr.updateSet = nil
 }
 
+// CreateIndexes implements method in interface `txmgmt.IndexCapable`
+func (txmgr *CouchDBTxMgr) CreateIndexes(namespace string, indexDefinitions [][]byte) error {
+	logger.Debugf("===COUCHDB=== Entering CouchDBTxMgr.CreateIndexes()  namespace=%s", namespace)
+	for _, indexDefinition := range indexDefinitions {
+		if err := txmgr.couchDB.CreateIndex(string(indexDefinition)); err != nil {
+			return fmt.Errorf("Error creating index for namespace %s: %s", namespace, err)
+		}
+	}
+	logger.Debugf("===COUCHDB=== Exiting CouchDBTxMgr.CreateIndexes()")
+	return nil
+}
+
+// WarmUpIndexes implements method in interface `txmgmt.IndexCapable`
+func (txmgr *CouchDBTxMgr) WarmUpIndexes() error {
+	logger.Debugf("===COUCHDB=== Entering CouchDBTxMgr.WarmUpIndexes()")
+	indexes, err := txmgr.couchDB.ListIndexes()
+	if err != nil {
+		return err
+	}
+	for _, index := range indexes {
+		// the primary index on _id is always up to date
+		if index.Type != "json" {
+			continue
+		}
+		if err := txmgr.couchDB.WarmIndex(index); err != nil {
+			return fmt.Errorf("Error warming up index %s: %s", index.Name, err)
+		}
+	}
+	logger.Debugf("===COUCHDB=== Exiting CouchDBTxMgr.WarmUpIndexes()")
+	return nil
+}
+
+// ResetState implements method in interface `txmgmt.Resettable`.
+// Dropping the database also drops the indexes created for the chaincodes
+func (txmgr *CouchDBTxMgr) ResetState() error {
+	txmgr.commitRWLock.Lock()
+	defer txmgr.commitRWLock.Unlock()
+	if _, err := txmgr.couchDB.DropDatabase(); err != nil {
+		return err
+	}
+	_, err := txmgr.couchDB.CreateDatabaseIfNotExist()
+	return err
+}
+
+// CommitWithSavepoint implements method in interface `txmgmt.SavepointCapable`. CouchDB does not write
+// several documents atomically, the savepoint is written once the state changes are so that, after a
+// failure in between, it is behind the state and the blocks that follow it are committed again
+func (txmgr *CouchDBTxMgr) CommitWithSavepoint(blockNum uint64) error {
+	if err := txmgr.Commit(); err != nil {
+		return err
+	}
+	doc, err := json.Marshal(&savepointDoc{BlockNum: blockNum})
+	if err != nil {
+		return err
+	}
+	if _, err = txmgr.couchDB.SaveDoc(savepointDocID, "", doc, nil); err != nil {
+		return fmt.Errorf("Error saving the savepoint: %s", err)
+	}
+	return nil
+}
+
+// GetLastSavepoint implements method in interface `txmgmt.SavepointCapable`
+func (txmgr *CouchDBTxMgr) GetLastSavepoint() (uint64, error) {
+	b, _, err := txmgr.couchDB.ReadDoc(savepointDocID)
+	if requestErr, ok := err.(*couchdb.RequestError); ok && requestErr.StatusCode == http.StatusNotFound {
+		return 0, nil
+	} else if err != nil {
+		return 0, fmt.Errorf("Error reading the savepoint: %s", err)
+	}
+	doc := &savepointDoc{}
+	if err = json.Unmarshal(b, doc); err != nil {
+		return 0, fmt.Errorf("Invalid savepoint: %s", err)
+	}
+	return doc.BlockNum, nil
+}
+
 func (txmgr *CouchDBTxMgr) getCommitedVersion(ns string, key string) (uint64, error) {
 	var err error
 	var version uint64
@@ -345,10 +606,39 @@ func (txmgr *CouchDBTxMgr) getCommittedValueAndVersion(ns string, key string) ([
 		logger.Debugf("===COUCHDB=== getCommittedValueAndVersion() Read jsonString:\n   %s", jsonString)
 	}
 	value := jsonBytes
+	if txmgr.encrypter != nil && value != nil {
+		var err error
+		if value, err = txmgr.encrypter.Decrypt(value); err != nil {
+			return nil, 0, err
+		}
+	}
 	var version uint64 = 1 //TODO - version hardcoded to 1 is a temporary value for the prototype
 	return value, version, nil
 }
 
+// getCommittedValuesAndVersions reads the values and versions of multiple keys of a namespace with a single
+// request to CouchDB
+func (txmgr *CouchDBTxMgr) getCommittedValuesAndVersions(ns string, keys []string) ([][]byte, []uint64, error) {
+	ids := make([]string, len(keys))
+	for i, key := range keys {
+		ids[i] = string(constructCompositeKey(ns, key))
+	}
+	values, err := txmgr.couchDB.BatchReadDocuments(ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	versions := make([]uint64, len(keys))
+	for i, value := range values {
+		if txmgr.encrypter != nil && value != nil {
+			if values[i], err = txmgr.encrypter.Decrypt(value); err != nil {
+				return nil, nil, err
+			}
+		}
+		versions[i] = 1 //TODO - version hardcoded to 1 as in getCommittedValueAndVersion
+	}
+	return values, versions, nil
+}
+
 func encodeValue(value []byte, version uint64) []byte {
 	versionBytes := proto.EncodeVarint(version)
 	deleteMarker := 0
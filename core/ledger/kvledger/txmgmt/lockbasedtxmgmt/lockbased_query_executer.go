@@ -18,6 +18,7 @@ package lockbasedtxmgmt
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/hyperledger/fabric/core/ledger"
 )
@@ -39,7 +40,11 @@ func (q *RWLockQueryExecutor) GetState(ns string, key string) ([]byte, error) {
 
 // GetStateMultipleKeys implements method in interface `ledger.QueryExecutor`
 func (q *RWLockQueryExecutor) GetStateMultipleKeys(namespace string, keys []string) ([][]byte, error) {
-	return nil, errors.New("Not yet implemented")
+	values, _, err := q.txmgr.getCommittedValuesAndVersions(namespace, keys)
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
 }
 
 // GetStateRangeScanIterator implements method in interface `ledger.QueryExecutor`
@@ -53,6 +58,28 @@ func (q *RWLockQueryExecutor) GetTransactionsForKey(namespace string, key string
 }
 
 // ExecuteQuery implements method in interface `ledger.QueryExecutor`
-func (q *RWLockQueryExecutor) ExecuteQuery(query string) (ledger.ResultsIterator, error) {
+func (q *RWLockQueryExecutor) ExecuteQuery(namespace string, query string) (ledger.ResultsIterator, error) {
+	return nil, errors.New("Not supported by KV data model")
+}
+
+// GetStateRangeScanIteratorWithPagination implements method in interface `ledger.QueryExecutor`.
+// The results are read directly from the committed state and are not recorded in the read set.
+// A page size of 0 selects the default page size of the query limits
+func (q *RWLockQueryExecutor) GetStateRangeScanIteratorWithPagination(namespace string, startKey string, endKey string, pageSize int32, bookmark string) (ledger.PaginatedResultsIterator, error) {
+	pageSize, err := q.txmgr.queryLimits.GetPageSize(pageSize)
+	if err != nil {
+		return nil, err
+	}
+	if bookmark != "" {
+		if bookmark < startKey || (endKey != "" && bookmark > endKey) {
+			return nil, fmt.Errorf("Bookmark [%s] is outside of the range [%s, %s]", bookmark, startKey, endKey)
+		}
+		startKey = bookmark
+	}
+	return newKVScanner(q.txmgr, namespace, startKey, endKey, pageSize), nil
+}
+
+// ExecuteQueryWithPagination implements method in interface `ledger.QueryExecutor`
+func (q *RWLockQueryExecutor) ExecuteQueryWithPagination(namespace string, query string, pageSize int32, bookmark string) (ledger.PaginatedResultsIterator, error) {
 	return nil, errors.New("Not supported by KV data model")
 }
@@ -0,0 +1,110 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lockbasedtxmgmt
+
+import (
+	"bytes"
+
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
+)
+
+// kvScanner implements interface `ledger.PaginatedResultsIterator` for a range of keys within a namespace.
+// The scanner returns at most `pageSize` non-deleted key-values and, if more keys remain in the range,
+// captures the next key as the bookmark from where the subsequent page can be fetched
+type kvScanner struct {
+	txmgr     *LockBasedTxMgr
+	namespace string
+	dbItr     statedb.Iterator
+	pageSize  int32
+	fetched   int32
+	done      bool
+	bookmark  string
+}
+
+func newKVScanner(txmgr *LockBasedTxMgr, namespace string, startKey string, endKey string, pageSize int32) *kvScanner {
+	compositeStartKey := constructCompositeKey(namespace, startKey)
+	var compositeEndKey []byte
+	if endKey == "" {
+		// scan till the end of the namespace
+		compositeEndKey = append([]byte(namespace), byte(1))
+	} else {
+		// endKey is inclusive
+		compositeEndKey = append(constructCompositeKey(namespace, endKey), byte(0))
+	}
+	dbItr := txmgr.db.GetIterator(compositeStartKey, compositeEndKey)
+	return &kvScanner{txmgr: txmgr, namespace: namespace, dbItr: dbItr, pageSize: pageSize}
+}
+
+// Next implements method in interface `ledger.ResultsIterator`. Once the page is read, the bookmark is
+// captured and further calls return no result, leaving the bookmark untouched
+func (scanner *kvScanner) Next() (ledger.QueryResult, error) {
+	if scanner.done {
+		return nil, nil
+	}
+	if scanner.fetched == scanner.pageSize {
+		// page is full - remember where the next page should start from
+		scanner.done = true
+		key, _, ok, err := scanner.nextLiveKV()
+		if ok {
+			scanner.bookmark = key
+		}
+		return nil, err
+	}
+	key, value, ok, err := scanner.nextLiveKV()
+	if !ok {
+		scanner.done = true
+		return nil, err
+	}
+	scanner.fetched++
+	return ledger.KV{Key: key, Value: value}, nil
+}
+
+// nextLiveKV moves the underlying iterator to the next key that is not marked as deleted
+func (scanner *kvScanner) nextLiveKV() (string, []byte, bool, error) {
+	for scanner.dbItr.Next() {
+		value, _ := decodeValue(scanner.dbItr.Value())
+		if value == nil {
+			continue
+		}
+		// the db iterator reuses its buffers, so the value is copied
+		valueCopy := make([]byte, len(value))
+		copy(valueCopy, value)
+		valueCopy, err := scanner.txmgr.decryptValue(valueCopy)
+		if err != nil {
+			return "", nil, false, err
+		}
+		return splitCompositeKey(scanner.dbItr.Key()), valueCopy, true, nil
+	}
+	return "", nil, false, scanner.dbItr.Error()
+}
+
+// GetBookmark implements method in interface `ledger.PaginatedResultsIterator`.
+// The bookmark is available once the iterator is exhausted
+func (scanner *kvScanner) GetBookmark() string {
+	return scanner.bookmark
+}
+
+// Close implements method in interface `ledger.ResultsIterator`
+func (scanner *kvScanner) Close() {
+	scanner.dbItr.Release()
+}
+
+func splitCompositeKey(compositeKey []byte) string {
+	split := bytes.SplitN(compositeKey, []byte{0x00}, 2)
+	return string(split[1])
+}
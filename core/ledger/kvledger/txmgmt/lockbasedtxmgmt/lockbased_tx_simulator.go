@@ -19,6 +19,7 @@ package lockbasedtxmgmt
 import (
 	"errors"
 	"reflect"
+	"sort"
 
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt"
 	logging "github.com/op/go-logging"
@@ -105,6 +106,41 @@ func (s *LockBasedTxSimulator) GetState(ns string, key string) ([]byte, error) {
 	return value, nil
 }
 
+// GetStateMultipleKeys implements method in interface `ledger.TxSimulator`.
+// The keys that are neither in the write set nor in the read set are read from storage with a single call
+// and added to the read set
+func (s *LockBasedTxSimulator) GetStateMultipleKeys(ns string, keys []string) ([][]byte, error) {
+	logger.Debugf("Get state for %d keys of namespace [%s]", len(keys), ns)
+	nsRWs := s.getOrCreateNsRWHolder(ns)
+	values := make([][]byte, len(keys))
+	var pendingKeys []string
+	var pendingIndexes []int
+	for i, key := range keys {
+		if kvWrite, ok := nsRWs.writeMap[key]; ok {
+			values[i] = kvWrite.Value
+		} else if readCache, ok := nsRWs.readMap[key]; ok {
+			values[i] = readCache.cachedValue
+		} else {
+			pendingKeys = append(pendingKeys, key)
+			pendingIndexes = append(pendingIndexes, i)
+		}
+	}
+	if len(pendingKeys) == 0 {
+		return values, nil
+	}
+
+	// read from storage
+	committedValues, versions, err := s.txmgr.getCommittedValuesAndVersions(ns, pendingKeys)
+	if err != nil {
+		return nil, err
+	}
+	for j, key := range pendingKeys {
+		values[pendingIndexes[j]] = committedValues[j]
+		nsRWs.readMap[key] = &kvReadCache{txmgmt.NewKVRead(key, versions[j]), committedValues[j]}
+	}
+	return values, nil
+}
+
 // SetState implements method in interface `ledger.TxSimulator`
 func (s *LockBasedTxSimulator) SetState(ns string, key string, value []byte) error {
 	if s.done {
@@ -174,6 +210,7 @@ func getSortedKeys(m interface{}) []string {
 	for _, keyVal := range keyVals {
 		keys = append(keys, keyVal.String())
 	}
+	sort.Strings(keys)
 	return keys
 }
 
@@ -188,6 +225,22 @@ func (s *LockBasedTxSimulator) SetStateMultipleKeys(namespace string, kvs map[st
 	return errors.New("Not yet implemented")
 }
 
+// GetLedgerHeight implements method in interface `ledger.TxSimulator`. The height is the savepoint of the state,
+// which cannot change during the simulation
+func (s *LockBasedTxSimulator) GetLedgerHeight() (uint64, error) {
+	nsRWs := s.getOrCreateNsRWHolder(txmgmt.LedgerHeightNamespace)
+	if readCache, ok := nsRWs.readMap[txmgmt.LedgerHeightKey]; ok {
+		return readCache.kvRead.Version, nil
+	}
+	height, err := s.txmgr.GetLastSavepoint()
+	if err != nil {
+		return 0, err
+	}
+	logger.Debugf("Read ledger height [%d]", height)
+	nsRWs.readMap[txmgmt.LedgerHeightKey] = &kvReadCache{txmgmt.NewKVRead(txmgmt.LedgerHeightKey, height), nil}
+	return height, nil
+}
+
 // CopyState implements method in interface `ledger.TxSimulator`
 func (s *LockBasedTxSimulator) CopyState(sourceNamespace string, targetNamespace string) error {
 	return errors.New("Not yet implemented")
@@ -17,9 +17,14 @@ limitations under the License.
 package lockbasedtxmgmt
 
 import (
+	"bytes"
 	"fmt"
 	"testing"
 
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/buckettree"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
 	"github.com/hyperledger/fabric/core/ledger/testutil"
 )
 
@@ -181,6 +186,351 @@ func TestTxValidation(t *testing.T) {
 	testutil.AssertSame(t, isValid, true)
 }
 
+func TestLedgerHeightValidation(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.Cleanup()
+	txMgr := NewLockBasedTxMgr(env.conf)
+	defer txMgr.Shutdown()
+
+	s1, _ := txMgr.NewTxSimulator()
+	s1.SetState("ns1", "key1", []byte("value1"))
+	s1.Done()
+	txMgr.addWriteSetToBatch(s1.(*LockBasedTxSimulator).getTxReadWriteSet())
+	testutil.AssertNoError(t, txMgr.CommitWithSavepoint(1), "")
+
+	// simulate tx2 and tx3 at height 1, the height is read once per transaction
+	s2, _ := txMgr.NewTxSimulator()
+	height, err := s2.GetLedgerHeight()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, height, uint64(1))
+	height, _ = s2.GetLedgerHeight()
+	testutil.AssertEquals(t, height, uint64(1))
+	s2.SetState("ns1", "key2", []byte("value2"))
+	s2.Done()
+	s3, _ := txMgr.NewTxSimulator()
+	s3.GetLedgerHeight()
+	s3.Done()
+
+	// tx2 is valid in the next block, after which tx3 read a stale height
+	txRWSet := s2.(*LockBasedTxSimulator).getTxReadWriteSet()
+	testutil.AssertEquals(t, txRWSet.NsRWs[0].NameSpace, txmgmt.LedgerHeightNamespace)
+	isValid, err := txMgr.validateTx(txRWSet)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertSame(t, isValid, true)
+	txMgr.addWriteSetToBatch(txRWSet)
+	testutil.AssertNoError(t, txMgr.CommitWithSavepoint(2), "")
+
+	isValid, err = txMgr.validateTx(s3.(*LockBasedTxSimulator).getTxReadWriteSet())
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertSame(t, isValid, false)
+}
+
+func TestRangeScanWithPagination(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.Cleanup()
+	txMgr := NewLockBasedTxMgr(env.conf)
+	defer txMgr.Shutdown()
+
+	// simulate and commit tx1
+	s1, _ := txMgr.NewTxSimulator()
+	for i := 1; i <= 5; i++ {
+		s1.SetState("ns1", fmt.Sprintf("key%d", i), []byte(fmt.Sprintf("value%d", i)))
+	}
+	s1.SetState("ns2", "key6", []byte("value6"))
+	s1.Done()
+	txRWSet := s1.(*LockBasedTxSimulator).getTxReadWriteSet()
+	txMgr.addWriteSetToBatch(txRWSet)
+	err := txMgr.Commit()
+	testutil.AssertNoError(t, err, fmt.Sprintf("Error while calling commit(): %s", err))
+
+	// simulate and commit tx2 that deletes a key
+	s2, _ := txMgr.NewTxSimulator()
+	s2.DeleteState("ns1", "key2")
+	s2.Done()
+	txMgr.addWriteSetToBatch(s2.(*LockBasedTxSimulator).getTxReadWriteSet())
+	txMgr.Commit()
+
+	queryExecuter, _ := txMgr.NewQueryExecutor()
+	keys, bookmark := collectPage(t, queryExecuter, "key1", "key5", 2, "")
+	testutil.AssertEquals(t, keys, []string{"key1", "key3"})
+	testutil.AssertEquals(t, bookmark, "key4")
+
+	keys, bookmark = collectPage(t, queryExecuter, "key1", "key5", 2, bookmark)
+	testutil.AssertEquals(t, keys, []string{"key4", "key5"})
+	testutil.AssertEquals(t, bookmark, "")
+
+	// reading past the end of a full page keeps the bookmark of the next page
+	itr, err := queryExecuter.GetStateRangeScanIteratorWithPagination("ns1", "key1", "", 1, "")
+	testutil.AssertNoError(t, err, "")
+	for i := 0; i < 4; i++ {
+		itr.Next()
+	}
+	testutil.AssertEquals(t, itr.GetBookmark(), "key3")
+	itr.Close()
+
+	// open ended range should not cross into other namespaces
+	keys, bookmark = collectPage(t, queryExecuter, "key4", "", 10, "")
+	testutil.AssertEquals(t, keys, []string{"key4", "key5"})
+	testutil.AssertEquals(t, bookmark, "")
+
+	_, err = queryExecuter.GetStateRangeScanIteratorWithPagination("ns1", "key1", "key5", 0, "")
+	testutil.AssertError(t, err, "Expected error for invalid page size")
+	_, err = queryExecuter.GetStateRangeScanIteratorWithPagination("ns1", "key1", "key3", 2, "key4")
+	testutil.AssertError(t, err, "Expected error for bookmark outside of the range")
+}
+
+func TestRangeScanWithPaginationQueryLimits(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.Cleanup()
+	env.conf.QueryLimits = txmgmt.QueryLimits{DefaultPageSize: 2, MaxPageSize: 3}
+	txMgr := NewLockBasedTxMgr(env.conf)
+	defer txMgr.Shutdown()
+
+	s, _ := txMgr.NewTxSimulator()
+	for i := 1; i <= 5; i++ {
+		s.SetState("ns1", fmt.Sprintf("key%d", i), []byte(fmt.Sprintf("value%d", i)))
+	}
+	s.Done()
+	txMgr.addWriteSetToBatch(s.(*LockBasedTxSimulator).getTxReadWriteSet())
+	err := txMgr.Commit()
+	testutil.AssertNoError(t, err, fmt.Sprintf("Error while calling commit(): %s", err))
+
+	queryExecuter, _ := txMgr.NewQueryExecutor()
+	// a page size of 0 selects the default page size
+	keys, bookmark := collectPage(t, queryExecuter, "key1", "", 0, "")
+	testutil.AssertEquals(t, keys, []string{"key1", "key2"})
+	testutil.AssertEquals(t, bookmark, "key3")
+
+	keys, bookmark = collectPage(t, queryExecuter, "key1", "", 3, bookmark)
+	testutil.AssertEquals(t, keys, []string{"key3", "key4", "key5"})
+	testutil.AssertEquals(t, bookmark, "")
+
+	_, err = queryExecuter.GetStateRangeScanIteratorWithPagination("ns1", "key1", "", 4, "")
+	testutil.AssertError(t, err, "Expected error for a page size above the maximum page size")
+}
+
+func collectPage(t *testing.T, queryExecuter ledger.QueryExecutor, startKey string, endKey string, pageSize int32, bookmark string) ([]string, string) {
+	itr, err := queryExecuter.GetStateRangeScanIteratorWithPagination("ns1", startKey, endKey, pageSize, bookmark)
+	testutil.AssertNoError(t, err, "")
+	defer itr.Close()
+	keys := []string{}
+	for {
+		queryResult, err := itr.Next()
+		testutil.AssertNoError(t, err, "")
+		if queryResult == nil {
+			break
+		}
+		keys = append(keys, queryResult.(ledger.KV).Key)
+	}
+	return keys, itr.GetBookmark()
+}
+
+// xorEncrypter is a trivial `encryption.Encrypter` that lets the tests check that values are not stored in clear
+type xorEncrypter struct{}
+
+func (e *xorEncrypter) Encrypt(plaintext []byte) ([]byte, error) {
+	ciphertext := make([]byte, len(plaintext))
+	for i, b := range plaintext {
+		ciphertext[i] = b ^ 0xff
+	}
+	return ciphertext, nil
+}
+
+func (e *xorEncrypter) Decrypt(ciphertext []byte) ([]byte, error) {
+	return e.Encrypt(ciphertext)
+}
+
+func TestStateEncryption(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.Cleanup()
+	env.conf.Encrypter = &xorEncrypter{}
+	txMgr := NewLockBasedTxMgr(env.conf)
+	defer txMgr.Shutdown()
+
+	s, _ := txMgr.NewTxSimulator()
+	s.SetState("ns1", "key1", []byte("value1"))
+	s.SetState("ns1", "key2", []byte("value2"))
+	s.Done()
+	txMgr.addWriteSetToBatch(s.(*LockBasedTxSimulator).getTxReadWriteSet())
+	err := txMgr.Commit()
+	testutil.AssertNoError(t, err, fmt.Sprintf("Error while calling commit(): %s", err))
+
+	encodedValue, _ := txMgr.db.Get(constructCompositeKey("ns1", "key1"))
+	testutil.AssertSame(t, bytes.Contains(encodedValue, []byte("value1")), false)
+
+	queryExecuter, _ := txMgr.NewQueryExecutor()
+	value, err := queryExecuter.GetState("ns1", "key1")
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, value, []byte("value1"))
+
+	itr, err := queryExecuter.GetStateRangeScanIteratorWithPagination("ns1", "key2", "key2", 1, "")
+	testutil.AssertNoError(t, err, "")
+	defer itr.Close()
+	queryResult, err := itr.Next()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, queryResult.(ledger.KV).Value, []byte("value2"))
+}
+
+func TestGetStateMultipleKeys(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.Cleanup()
+	txMgr := NewLockBasedTxMgr(env.conf)
+	defer txMgr.Shutdown()
+
+	s1, _ := txMgr.NewTxSimulator()
+	s1.SetState("ns1", "key1", []byte("value1"))
+	s1.SetState("ns1", "key2", []byte("value2"))
+	s1.Done()
+	txMgr.addWriteSetToBatch(s1.(*LockBasedTxSimulator).getTxReadWriteSet())
+	err := txMgr.Commit()
+	testutil.AssertNoError(t, err, fmt.Sprintf("Error while calling commit(): %s", err))
+
+	queryExecuter, _ := txMgr.NewQueryExecutor()
+	values, err := queryExecuter.GetStateMultipleKeys("ns1", []string{"key2", "key3", "key1"})
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, values, [][]byte{[]byte("value2"), nil, []byte("value1")})
+
+	// values written in the simulation take precedence and the keys read from storage are added to the read set
+	s2, _ := txMgr.NewTxSimulator()
+	s2.SetState("ns1", "key1", []byte("value1_1"))
+	values, err = s2.GetStateMultipleKeys("ns1", []string{"key1", "key2", "key3"})
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, values, [][]byte{[]byte("value1_1"), []byte("value2"), nil})
+	s2.Done()
+	txRWSet := s2.(*LockBasedTxSimulator).getTxReadWriteSet()
+	testutil.AssertEquals(t, len(txRWSet.NsRWs[0].Reads), 2)
+}
+
+func TestStateProof(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.Cleanup()
+	env.conf.StateTreeDepth = 4
+	txMgr := NewLockBasedTxMgr(env.conf)
+	defer txMgr.Shutdown()
+
+	s1, _ := txMgr.NewTxSimulator()
+	s1.SetState("ns1", "key1", []byte("value1"))
+	s1.SetState("ns1", "key2", []byte("value2"))
+	s1.SetState("ns2", "key3", []byte("value3"))
+	s1.Done()
+	txMgr.addWriteSetToBatch(s1.(*LockBasedTxSimulator).getTxReadWriteSet())
+	testutil.AssertNoError(t, txMgr.CommitWithSavepoint(1), "")
+
+	s2, _ := txMgr.NewTxSimulator()
+	s2.DeleteState("ns1", "key2")
+	s2.Done()
+	txMgr.addWriteSetToBatch(s2.(*LockBasedTxSimulator).getTxReadWriteSet())
+	testutil.AssertNoError(t, txMgr.CommitWithSavepoint(2), "")
+
+	root1, err := txMgr.GetStateRoot(1)
+	testutil.AssertNoError(t, err, "")
+	root2, err := txMgr.GetStateRoot(2)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertNotEquals(t, root1, root2)
+
+	proof, err := txMgr.GetStateWithProof("ns1", "key1")
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertSame(t, proof.Exists, true)
+	testutil.AssertEquals(t, proof.Value, []byte("value1"))
+	testutil.AssertEquals(t, proof.BlockNumber, uint64(2))
+	testutil.AssertEquals(t, proof.StateRoot, root2)
+	testutil.AssertNoError(t, buckettree.VerifyStateProof(proof), "")
+
+	// the deleted key is proven absent
+	proof, err = txMgr.GetStateWithProof("ns1", "key2")
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertSame(t, proof.Exists, false)
+	testutil.AssertNoError(t, buckettree.VerifyStateProof(proof), "")
+
+	// the state root is rebuilt along with the state
+	testutil.AssertNoError(t, txMgr.ResetState(), "")
+	s3, _ := txMgr.NewTxSimulator()
+	s3.SetState("ns1", "key1", []byte("value1"))
+	s3.SetState("ns2", "key3", []byte("value3"))
+	s3.Done()
+	txMgr.addWriteSetToBatch(s3.(*LockBasedTxSimulator).getTxReadWriteSet())
+	testutil.AssertNoError(t, txMgr.CommitWithSavepoint(1), "")
+	root, err := txMgr.GetStateRoot(1)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, root, root2)
+}
+
+func TestStateProofWithoutTree(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.Cleanup()
+	txMgr := NewLockBasedTxMgr(env.conf)
+	s, _ := txMgr.NewTxSimulator()
+	s.SetState("ns1", "key1", []byte("value1"))
+	s.Done()
+	txMgr.addWriteSetToBatch(s.(*LockBasedTxSimulator).getTxReadWriteSet())
+	testutil.AssertNoError(t, txMgr.CommitWithSavepoint(1), "")
+	_, err := txMgr.GetStateWithProof("ns1", "key1")
+	testutil.AssertError(t, err, "Expected an error when no state hash tree is maintained")
+	txMgr.Shutdown()
+
+	// a tree is not started on a state committed without one
+	env.conf.StateTreeDepth = 4
+	txMgr = NewLockBasedTxMgr(env.conf)
+	defer txMgr.Shutdown()
+	_, err = txMgr.GetStateWithProof("ns1", "key1")
+	testutil.AssertError(t, err, "Expected an error when the state was committed without a hash tree")
+}
+
+func TestStateHash(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.Cleanup()
+	txMgr := NewLockBasedTxMgr(env.conf)
+
+	s1, _ := txMgr.NewTxSimulator()
+	s1.SetState("ns1", "key1", []byte("value1"))
+	s1.SetState("ns1", "key2", []byte("value2"))
+	s1.SetState("ns2", "key3", []byte("value3"))
+	s1.Done()
+	txMgr.addWriteSetToBatch(s1.(*LockBasedTxSimulator).getTxReadWriteSet())
+	testutil.AssertNoError(t, txMgr.CommitWithSavepoint(1), "")
+
+	s2, _ := txMgr.NewTxSimulator()
+	s2.SetState("ns1", "key1", []byte("value1_1"))
+	s2.DeleteState("ns1", "key2")
+	s2.Done()
+	txMgr.addWriteSetToBatch(s2.(*LockBasedTxSimulator).getTxReadWriteSet())
+	testutil.AssertNoError(t, txMgr.CommitWithSavepoint(2), "")
+
+	hash1, err := txMgr.GetStateHash(1)
+	testutil.AssertNoError(t, err, "")
+	hash2, err := txMgr.GetStateHash(2)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertNotEquals(t, hash1, hash2)
+	expectedHash := txmgmt.NewStateHash(nil)
+	expectedHash.Add("ns2", "key3", []byte("value3"))
+	expectedHash.Add("ns1", "key1", []byte("value1_1"))
+	testutil.AssertEquals(t, hash2, expectedHash.Bytes())
+
+	// a state diverged from the blocks gives a different state hash for the same updates
+	batch := statedb.NewUpdateBatch()
+	batch.Put(constructCompositeKey("ns1", "key1"), encodeValue([]byte("diverged"), 2))
+	testutil.AssertNoError(t, txMgr.db.WriteBatch(batch, false), "")
+	s3, _ := txMgr.NewTxSimulator()
+	s3.SetState("ns1", "key1", []byte("value1_2"))
+	s3.Done()
+	txMgr.addWriteSetToBatch(s3.(*LockBasedTxSimulator).getTxReadWriteSet())
+	testutil.AssertNoError(t, txMgr.CommitWithSavepoint(3), "")
+	hash3, err := txMgr.GetStateHash(3)
+	testutil.AssertNoError(t, err, "")
+	expectedHash.Remove("ns1", "key1", []byte("value1_1"))
+	expectedHash.Add("ns1", "key1", []byte("value1_2"))
+	testutil.AssertNotEquals(t, hash3, expectedHash.Bytes())
+
+	// the state hash of a state committed without one is computed from all the keys of the state
+	batch = statedb.NewUpdateBatch()
+	batch.Delete(stateHashKey)
+	testutil.AssertNoError(t, txMgr.db.WriteBatch(batch, false), "")
+	txMgr.Shutdown()
+	txMgr = NewLockBasedTxMgr(env.conf)
+	defer txMgr.Shutdown()
+	testutil.AssertEquals(t, txMgr.stateHash.Bytes(), expectedHash.Bytes())
+}
+
 func TestEncodeDecodeValueAndVersion(t *testing.T) {
 	testValueAndVersionEncodeing(t, []byte("value1"), uint64(1))
 	testValueAndVersionEncodeing(t, nil, uint64(2))
@@ -17,17 +17,20 @@ limitations under the License.
 package lockbasedtxmgmt
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 	"sync"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric/core/ledger"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt"
-	"github.com/hyperledger/fabric/core/ledger/util/db"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/buckettree"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
+	"github.com/hyperledger/fabric/core/ledger/util/encryption"
 	"github.com/hyperledger/fabric/protos"
 	putils "github.com/hyperledger/fabric/protos/utils"
 	"github.com/op/go-logging"
-	"github.com/syndtr/goleveldb/leveldb"
 )
 
 var logger = logging.MustGetLogger("lockbasedtxmgmt")
@@ -35,6 +38,15 @@ var logger = logging.MustGetLogger("lockbasedtxmgmt")
 // Conf - configuration for `LockBasedTxMgr`
 type Conf struct {
 	DBPath string
+	// Encrypter, if set, is used to encrypt the values written to the state database
+	Encrypter encryption.Encrypter
+	// StateDBProvider opens the state database. The goleveldb based state database is used if not set
+	StateDBProvider statedb.Provider
+	// StateTreeDepth, if not 0, is the depth of the hash tree maintained over the state for proving
+	// values to clients. The state root is recorded for every block committed with a savepoint
+	StateTreeDepth int
+	// QueryLimits bound the queries of the query executors
+	QueryLimits txmgmt.QueryLimits
 }
 
 type versionedValue struct {
@@ -63,19 +75,102 @@ func (u *updateSet) get(compositeKey []byte) *versionedValue {
 	return u.m[string(compositeKey)]
 }
 
+// savepointKey is the key under which the number of the last committed block is stored.
+// It does not collide with the composite keys of the state as namespaces are never empty
+var savepointKey = []byte{0x00, 's', 'a', 'v', 'e', 'p', 'o', 'i', 'n', 't'}
+
+// stateRootKeyPrefix is the prefix of the keys under which the state root after the commit of a block is stored
+var stateRootKeyPrefix = []byte{0x00, 'r', 'o', 'o', 't'}
+
+// stateHashKey is the key under which the state hash of the committed state is stored, and
+// stateHashKeyPrefix the prefix of the keys under which the state hash after the commit of a block is stored
+var stateHashKey = []byte{0x00, 'h', 'a', 's', 'h'}
+var stateHashKeyPrefix = []byte{0x00, 'h', 'a', 's', 'h', 'b'}
+
 // LockBasedTxMgr a simple implementation of interface `txmgmt.TxMgr`.
 // This implementation uses a read-write lock to prevent conflicts between transaction simulation and committing
 type LockBasedTxMgr struct {
-	db           *db.DB
-	updateSet    *updateSet
-	commitRWLock sync.RWMutex
+	db             statedb.KVStore
+	encrypter      encryption.Encrypter
+	stateTreeDepth int
+	stateTree      *buckettree.Tree
+	stateHash      *txmgmt.StateHash
+	queryLimits    txmgmt.QueryLimits
+	updateSet      *updateSet
+	commitRWLock   sync.RWMutex
 }
 
 // NewLockBasedTxMgr constructs a `LockBasedTxMgr`
 func NewLockBasedTxMgr(conf *Conf) *LockBasedTxMgr {
-	db := db.CreateDB(&db.Conf{DBPath: conf.DBPath})
-	db.Open()
-	return &LockBasedTxMgr{db: db}
+	provider := conf.StateDBProvider
+	if provider == nil {
+		var err error
+		if provider, err = statedb.GetProvider(statedb.GoLevelDB); err != nil {
+			panic(err.Error())
+		}
+	}
+	db, err := provider.Open(conf.DBPath)
+	if err != nil {
+		panic(fmt.Sprintf("Error while trying to open state DB: %s", err))
+	}
+	txmgr := &LockBasedTxMgr{db: db, encrypter: conf.Encrypter, stateTreeDepth: conf.StateTreeDepth,
+		queryLimits: conf.QueryLimits}
+	if conf.StateTreeDepth != 0 {
+		if txmgr.stateTree, err = openStateTree(db, conf.StateTreeDepth); err != nil {
+			panic(fmt.Sprintf("Error while trying to open the state hash tree: %s", err))
+		}
+	}
+	if txmgr.stateHash, err = txmgr.openStateHash(); err != nil {
+		panic(fmt.Sprintf("Error while trying to open the state hash: %s", err))
+	}
+	return txmgr
+}
+
+// openStateHash returns the stored state hash, or computes it from all the keys of the state if the state
+// was committed without one
+func (txmgr *LockBasedTxMgr) openStateHash() (*txmgmt.StateHash, error) {
+	hashBytes, err := txmgr.db.Get(stateHashKey)
+	if err != nil || hashBytes != nil {
+		return txmgmt.NewStateHash(hashBytes), err
+	}
+	stateHash := txmgmt.NewStateHash(nil)
+	// the keys of the state start with a namespace, which is never empty, the other keys with 0x00
+	itr := txmgr.db.GetIterator([]byte{0x01}, nil)
+	defer itr.Release()
+	for itr.Next() {
+		value, _ := decodeValue(itr.Value())
+		if value == nil {
+			continue
+		}
+		if value, err = txmgr.decryptValue(value); err != nil {
+			return nil, err
+		}
+		split := strings.SplitN(string(itr.Key()), string(byte(0)), 2)
+		stateHash.Add(split[0], split[1], value)
+	}
+	return stateHash, itr.Error()
+}
+
+// openStateTree returns the hash tree over the state. The depth of an existing tree is kept, as a change of
+// depth requires building the tree anew. No tree is returned if the state was committed without one
+func openStateTree(db statedb.KVStore, depth int) (*buckettree.Tree, error) {
+	storedDepth, err := buckettree.GetStoredDepth(db)
+	if err != nil {
+		return nil, err
+	}
+	if storedDepth != 0 {
+		if storedDepth != depth {
+			logger.Warningf("Keeping the state hash tree depth %d, the configured depth %d applies once the state is rebuilt", storedDepth, depth)
+		}
+		return buckettree.New(storedDepth), nil
+	}
+	itr := db.GetIterator(nil, nil)
+	defer itr.Release()
+	if itr.Next() {
+		logger.Warning("The state was committed without a hash tree, state proofs are not available until the state is rebuilt")
+		return nil, nil
+	}
+	return buckettree.New(depth), itr.Error()
 }
 
 // NewQueryExecutor implements method in interface `txmgmt.TxMgr`
@@ -180,6 +275,18 @@ func (txmgr *LockBasedTxMgr) validateTx(txRWSet *txmgmt.TxReadWriteSet) (bool, e
 	for _, nsRWSet := range txRWSet.NsRWs {
 		ns := nsRWSet.NameSpace
 		for _, kvRead := range nsRWSet.Reads {
+			if ns == txmgmt.LedgerHeightNamespace && kvRead.Key == txmgmt.LedgerHeightKey {
+				// the height read is current as long as no block was committed since the simulation
+				if currentVersion, err = txmgr.GetLastSavepoint(); err != nil {
+					return false, err
+				}
+				if currentVersion != kvRead.Version {
+					logger.Debugf("Ledger height mismatch. Current height = [%d], height in readSet [%d]",
+						currentVersion, kvRead.Version)
+					return false, nil
+				}
+				continue
+			}
 			compositeKey := constructCompositeKey(ns, kvRead.Key)
 			if txmgr.updateSet != nil && txmgr.updateSet.exists(compositeKey) {
 				return false, nil
@@ -225,12 +332,119 @@ func (txmgr *LockBasedTxMgr) addWriteSetToBatch(txRWSet *txmgmt.TxReadWriteSet)
 
 // Commit implements method in interface `txmgmt.TxMgr`
 func (txmgr *LockBasedTxMgr) Commit() error {
-	batch := &leveldb.Batch{}
+	return txmgr.commit(0)
+}
+
+// CommitWithSavepoint implements method in interface `txmgmt.SavepointCapable`
+func (txmgr *LockBasedTxMgr) CommitWithSavepoint(blockNum uint64) error {
+	return txmgr.commit(blockNum)
+}
+
+// GetLastSavepoint implements method in interface `txmgmt.SavepointCapable`
+func (txmgr *LockBasedTxMgr) GetLastSavepoint() (uint64, error) {
+	savepoint, err := txmgr.db.Get(savepointKey)
+	if err != nil || savepoint == nil {
+		return 0, err
+	}
+	blockNum, _ := proto.DecodeVarint(savepoint)
+	return blockNum, nil
+}
+
+// ResetState implements method in interface `txmgmt.Resettable`
+func (txmgr *LockBasedTxMgr) ResetState() error {
+	txmgr.commitRWLock.Lock()
+	defer txmgr.commitRWLock.Unlock()
+	numKeys, err := statedb.DeleteAll(txmgr.db)
+	if err != nil {
+		return err
+	}
+	logger.Debugf("Deleted %d keys from the state database", numKeys)
+	// the state is rebuilt from the first block, so the hash tree can be built along with it
+	if txmgr.stateTreeDepth != 0 {
+		txmgr.stateTree = buckettree.New(txmgr.stateTreeDepth)
+	}
+	txmgr.stateHash = txmgmt.NewStateHash(nil)
+	return nil
+}
+
+// CompactState implements method in interface `txmgmt.Compactable`
+func (txmgr *LockBasedTxMgr) CompactState() (int64, int64, error) {
+	compacter, ok := txmgr.db.(statedb.Compacter)
+	if !ok {
+		return 0, 0, errors.New("State database does not support compaction")
+	}
+	return compacter.Compact()
+}
+
+// GetStateWithProof implements method in interface `txmgmt.StateProofCapable`
+func (txmgr *LockBasedTxMgr) GetStateWithProof(namespace string, key string) (*protos.StateProof, error) {
+	if txmgr.stateTree == nil {
+		return nil, errors.New("State hash tree is not maintained")
+	}
+	txmgr.commitRWLock.RLock()
+	defer txmgr.commitRWLock.RUnlock()
+	blockNum, err := txmgr.GetLastSavepoint()
+	if err != nil {
+		return nil, err
+	}
+	if blockNum == 0 {
+		return nil, errors.New("No block has been committed to the state")
+	}
+	value, _, err := txmgr.getCommittedValueAndVersion(namespace, key)
+	if err != nil {
+		return nil, err
+	}
+	stateRoot, err := txmgr.stateTree.GetRoot(txmgr.db)
+	if err != nil {
+		return nil, err
+	}
+	bucket, entries, siblingHashes, err := txmgr.stateTree.GetProof(txmgr.db, buckettree.CompositeKeyHash(namespace, key))
+	if err != nil {
+		return nil, err
+	}
+	return &protos.StateProof{Namespace: namespace, Key: key, Exists: value != nil, Value: value,
+		BlockNumber: blockNum, StateRoot: stateRoot, BucketNumber: bucket,
+		BucketEntries: entries, SiblingHashes: siblingHashes}, nil
+}
+
+// GetStateRoot implements method in interface `txmgmt.StateProofCapable`
+func (txmgr *LockBasedTxMgr) GetStateRoot(blockNum uint64) ([]byte, error) {
+	if txmgr.stateTree == nil {
+		return nil, errors.New("State hash tree is not maintained")
+	}
+	return txmgr.db.Get(constructStateRootKey(blockNum))
+}
+
+// GetStateHash implements method in interface `txmgmt.StateHashCapable`
+func (txmgr *LockBasedTxMgr) GetStateHash(blockNum uint64) ([]byte, error) {
+	return txmgr.db.Get(constructStateHashKey(blockNum))
+}
+
+// commit writes the prepared updates. If blockNum is not 0, it is recorded as the savepoint
+// and the state root and state hash after the updates are recorded for the block
+func (txmgr *LockBasedTxMgr) commit(blockNum uint64) error {
+	batch := statedb.NewUpdateBatch()
 	if txmgr.updateSet == nil {
 		panic("validateAndPrepare() method should have been called before calling commit()")
 	}
 	for k, v := range txmgr.updateSet.m {
-		batch.Put([]byte(k), encodeValue(v.value, v.version))
+		value, err := txmgr.encryptValue(v.value)
+		if err != nil {
+			return fmt.Errorf("Error encrypting value for key [%s]: %s", k, err)
+		}
+		batch.Put([]byte(k), encodeValue(value, v.version))
+	}
+	if txmgr.stateTree != nil {
+		if err := txmgr.prepareStateTreeUpdates(batch, blockNum); err != nil {
+			return err
+		}
+	}
+	stateHash, err := txmgr.prepareStateHashUpdates(batch, blockNum)
+	if err != nil {
+		return err
+	}
+	if blockNum != 0 {
+		batch.Put(savepointKey, proto.EncodeVarint(blockNum))
 	}
 	txmgr.commitRWLock.Lock()
 	defer txmgr.commitRWLock.Unlock()
@@ -238,6 +452,52 @@ func (txmgr *LockBasedTxMgr) Commit() error {
 	if err := txmgr.db.WriteBatch(batch, false); err != nil {
 		return err
 	}
+	txmgr.stateHash = stateHash
+	return nil
+}
+
+// prepareStateHashUpdates returns the state hash after the prepared updates and adds it to the batch, also for
+// the block `blockNum` if not 0. The previous values are read from the state database, so that the state hash
+// reflects the committed state rather than the updates alone
+func (txmgr *LockBasedTxMgr) prepareStateHashUpdates(batch *statedb.UpdateBatch, blockNum uint64) (*txmgmt.StateHash, error) {
+	stateHash := txmgr.stateHash.Copy()
+	for k, v := range txmgr.updateSet.m {
+		split := strings.SplitN(k, string(byte(0)), 2)
+		previousValue, _, err := txmgr.getCommittedValueAndVersion(split[0], split[1])
+		if err != nil {
+			return nil, err
+		}
+		if previousValue != nil {
+			stateHash.Remove(split[0], split[1], previousValue)
+		}
+		if v.value != nil {
+			stateHash.Add(split[0], split[1], v.value)
+		}
+	}
+	hashBytes := stateHash.Bytes()
+	batch.Put(stateHashKey, hashBytes)
+	if blockNum != 0 {
+		batch.Put(constructStateHashKey(blockNum), hashBytes)
+	}
+	return stateHash, nil
+}
+
+// prepareStateTreeUpdates adds the updates of the hash tree for the prepared updates to the batch,
+// along with the resulting state root for the block `blockNum`, if not 0. The plain values are hashed
+// so that a client can verify a value against the tree
+func (txmgr *LockBasedTxMgr) prepareStateTreeUpdates(batch *statedb.UpdateBatch, blockNum uint64) error {
+	writes := make(map[string][]byte, len(txmgr.updateSet.m))
+	for k, v := range txmgr.updateSet.m {
+		split := strings.SplitN(k, string(byte(0)), 2)
+		writes[string(buckettree.CompositeKeyHash(split[0], split[1]))] = v.value
+	}
+	stateRoot, err := txmgr.stateTree.PrepareUpdates(txmgr.db, writes, batch)
+	if err != nil {
+		return fmt.Errorf("Error updating the state hash tree: %s", err)
+	}
+	if blockNum != 0 {
+		batch.Put(constructStateRootKey(blockNum), stateRoot)
+	}
 	return nil
 }
 
@@ -266,9 +526,54 @@ func (txmgr *LockBasedTxMgr) getCommittedValueAndVersion(ns string, key string)
 		return nil, 0, nil
 	}
 	value, version := decodeValue(encodedValue)
+	if value, err = txmgr.decryptValue(value); err != nil {
+		return nil, 0, err
+	}
 	return value, version, nil
 }
 
+// getCommittedValuesAndVersions reads the values and versions of multiple keys of a namespace with a single
+// call to the state database
+func (txmgr *LockBasedTxMgr) getCommittedValuesAndVersions(ns string, keys []string) ([][]byte, []uint64, error) {
+	compositeKeys := make([][]byte, len(keys))
+	for i, key := range keys {
+		compositeKeys[i] = constructCompositeKey(ns, key)
+	}
+	encodedValues, err := txmgr.db.MultiGet(compositeKeys)
+	if err != nil {
+		return nil, nil, err
+	}
+	values := make([][]byte, len(keys))
+	versions := make([]uint64, len(keys))
+	for i, encodedValue := range encodedValues {
+		if encodedValue == nil {
+			continue
+		}
+		value, version := decodeValue(encodedValue)
+		if values[i], err = txmgr.decryptValue(value); err != nil {
+			return nil, nil, err
+		}
+		versions[i] = version
+	}
+	return values, versions, nil
+}
+
+// encryptValue encrypts the value if state encryption is enabled. A nil value (delete marker) is kept as is
+func (txmgr *LockBasedTxMgr) encryptValue(value []byte) ([]byte, error) {
+	if txmgr.encrypter == nil || value == nil {
+		return value, nil
+	}
+	return txmgr.encrypter.Encrypt(value)
+}
+
+// decryptValue decrypts a value that was encrypted by `encryptValue`
+func (txmgr *LockBasedTxMgr) decryptValue(value []byte) ([]byte, error) {
+	if txmgr.encrypter == nil || value == nil {
+		return value, nil
+	}
+	return txmgr.encrypter.Decrypt(value)
+}
+
 func encodeValue(value []byte, version uint64) []byte {
 	versionBytes := proto.EncodeVarint(version)
 	deleteMarker := 0
@@ -293,6 +598,14 @@ func decodeValue(encodedValue []byte) ([]byte, uint64) {
 	return value, version
 }
 
+func constructStateRootKey(blockNum uint64) []byte {
+	return append(append([]byte{}, stateRootKeyPrefix...), proto.EncodeVarint(blockNum)...)
+}
+
+func constructStateHashKey(blockNum uint64) []byte {
+	return append(append([]byte{}, stateHashKeyPrefix...), proto.EncodeVarint(blockNum)...)
+}
+
 func constructCompositeKey(ns string, key string) []byte {
 	compositeKey := []byte(ns)
 	compositeKey = append(compositeKey, byte(0))
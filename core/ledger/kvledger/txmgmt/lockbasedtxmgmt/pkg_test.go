@@ -26,7 +26,7 @@ type testEnv struct {
 }
 
 func newTestEnv(t testing.TB) *testEnv {
-	conf := &Conf{"/tmp/tests/ledger/kvledger/txmgmt/lockbasedtxmgmt"}
+	conf := &Conf{DBPath: "/tmp/tests/ledger/kvledger/txmgmt/lockbasedtxmgmt"}
 	os.RemoveAll(conf.DBPath)
 	return &testEnv{conf}
 }
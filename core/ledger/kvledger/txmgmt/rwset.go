@@ -23,6 +23,13 @@ import (
 	"github.com/golang/protobuf/proto"
 )
 
+// LedgerHeightNamespace and LedgerHeightKey identify the read recording the height of the ledger at the time of
+// transaction simulation, the version of the read being the height. No chaincode has the empty namespace
+const (
+	LedgerHeightNamespace = ""
+	LedgerHeightKey       = "height"
+)
+
 // KVRead - a tuple of key and its version at the time of transaction simulation
 type KVRead struct {
 	Key     string
@@ -0,0 +1,230 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statedb
+
+import (
+	"bytes"
+	"os"
+
+	"github.com/dgraph-io/badger"
+	"github.com/hyperledger/fabric/core/ledger/util"
+	"github.com/op/go-logging"
+)
+
+var logger = logging.MustGetLogger("statedb")
+
+// Badger is the name of the badger based state database
+const Badger = "badger"
+
+// badgerValueLogGCDiscardRatio is the fraction of a value log file that must be stale
+// for the file to be rewritten by `Compact`
+const badgerValueLogGCDiscardRatio = 0.5
+
+func init() {
+	RegisterProvider(Badger, &badgerProvider{})
+}
+
+// badgerProvider implements interface `Provider` using badger, a pure Go LSM store that keeps
+// the values apart from the keys in a value log, so that the compactions only rewrite the keys
+type badgerProvider struct {
+}
+
+// Open implements method in interface `Provider`. Every write is synced to disk, the badger default
+func (p *badgerProvider) Open(dbPath string) (KVStore, error) {
+	if err := os.MkdirAll(dbPath, 0755); err != nil {
+		return nil, err
+	}
+	opts := badger.DefaultOptions
+	opts.Dir = dbPath
+	opts.ValueDir = dbPath
+	db, err := badger.Open(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &badgerStore{db, dbPath}, nil
+}
+
+// badgerStore implements interface `KVStore` on top of a badger `DB`
+type badgerStore struct {
+	db     *badger.DB
+	dbPath string
+}
+
+// Get implements method in interface `KVStore`
+func (s *badgerStore) Get(key []byte) ([]byte, error) {
+	var value []byte
+	err := s.db.View(func(txn *badger.Txn) error {
+		var err error
+		value, err = badgerGet(txn, key)
+		return err
+	})
+	return value, err
+}
+
+// MultiGet implements method in interface `KVStore`. All the keys are read from the same snapshot
+func (s *badgerStore) MultiGet(keys [][]byte) ([][]byte, error) {
+	values := make([][]byte, len(keys))
+	err := s.db.View(func(txn *badger.Txn) error {
+		for i, key := range keys {
+			value, err := badgerGet(txn, key)
+			if err != nil {
+				return err
+			}
+			values[i] = value
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// badgerGet returns a copy of the value of the key, or nil if the key does not exist
+func badgerGet(txn *badger.Txn, key []byte) ([]byte, error) {
+	item, err := txn.Get(key)
+	if err == badger.ErrKeyNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	value, err := item.ValueCopy(nil)
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		// an empty value is not a deleted key
+		value = []byte{}
+	}
+	return value, nil
+}
+
+// WriteBatch implements method in interface `KVStore`. The batch is committed in a single badger
+// transaction, so a batch larger than a badger transaction admits fails with `badger.ErrTxnTooBig`
+func (s *badgerStore) WriteBatch(batch *UpdateBatch, sync bool) error {
+	txn := s.db.NewTransaction(true)
+	defer txn.Discard()
+	var err error
+	batch.Visit(func(key []byte, value []byte) {
+		if err != nil {
+			return
+		}
+		if value == nil {
+			err = txn.Delete(key)
+		} else {
+			err = txn.Set(key, value)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return txn.Commit(nil)
+}
+
+// Compact implements method in interface `Compacter`. badger compacts the keys in the background, so
+// only the value log files are rewritten to drop the values of the deleted and overwritten keys
+func (s *badgerStore) Compact() (int64, int64, error) {
+	sizeBefore, err := util.DirSize(s.dbPath)
+	if err != nil {
+		return 0, 0, err
+	}
+	for {
+		err = s.db.RunValueLogGC(badgerValueLogGCDiscardRatio)
+		if err == badger.ErrNoRewrite {
+			break
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	sizeAfter, err := util.DirSize(s.dbPath)
+	if err != nil {
+		return 0, 0, err
+	}
+	return sizeBefore, sizeAfter, nil
+}
+
+// GetIterator implements method in interface `KVStore`
+func (s *badgerStore) GetIterator(startKey []byte, endKey []byte) Iterator {
+	txn := s.db.NewTransaction(false)
+	return &badgerIterator{txn: txn, itr: txn.NewIterator(badger.DefaultIteratorOptions),
+		startKey: startKey, endKey: endKey}
+}
+
+// Close implements method in interface `KVStore`
+func (s *badgerStore) Close() {
+	if err := s.db.Close(); err != nil {
+		logger.Errorf("Error while closing state database [%s]: %s", s.dbPath, err)
+	}
+}
+
+// badgerIterator implements interface `Iterator` on a read-only badger transaction
+type badgerIterator struct {
+	txn      *badger.Txn
+	itr      *badger.Iterator
+	startKey []byte
+	endKey   []byte
+	started  bool
+	value    []byte
+	err      error
+}
+
+// Next implements method in interface `Iterator`
+func (i *badgerIterator) Next() bool {
+	if i.err != nil {
+		return false
+	}
+	if !i.started {
+		i.started = true
+		i.itr.Seek(i.startKey)
+	} else {
+		i.itr.Next()
+	}
+	if !i.itr.Valid() {
+		return false
+	}
+	item := i.itr.Item()
+	if i.endKey != nil && bytes.Compare(item.Key(), i.endKey) >= 0 {
+		return false
+	}
+	if i.value, i.err = item.Value(); i.err != nil {
+		return false
+	}
+	return true
+}
+
+// Key implements method in interface `Iterator`
+func (i *badgerIterator) Key() []byte {
+	return i.itr.Item().Key()
+}
+
+// Value implements method in interface `Iterator`
+func (i *badgerIterator) Value() []byte {
+	return i.value
+}
+
+// Error implements method in interface `Iterator`
+func (i *badgerIterator) Error() error {
+	return i.err
+}
+
+// Release implements method in interface `Iterator`
+func (i *badgerIterator) Release() {
+	i.itr.Close()
+	i.txn.Discard()
+}
@@ -0,0 +1,98 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statedb
+
+import (
+	"github.com/hyperledger/fabric/core/ledger/util/db"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// GoLevelDB is the name of the goleveldb based state database
+const GoLevelDB = "goleveldb"
+
+func init() {
+	RegisterProvider(GoLevelDB, &levelDBProvider{})
+}
+
+// levelDBProvider implements interface `Provider` using goleveldb
+type levelDBProvider struct {
+	options db.Options
+}
+
+// NewLevelDBProvider returns a goleveldb `Provider` that opens the stores with the given options.
+// The provider registered as `GoLevelDB` uses the goleveldb defaults
+func NewLevelDBProvider(options db.Options) Provider {
+	return &levelDBProvider{options}
+}
+
+// Open implements method in interface `Provider`
+func (p *levelDBProvider) Open(dbPath string) (KVStore, error) {
+	dbInst := db.CreateDB(&db.Conf{DBPath: dbPath, Options: p.options})
+	dbInst.Open()
+	return &levelDBStore{dbInst}, nil
+}
+
+// levelDBStore implements interface `KVStore` on top of a goleveldb `db.DB`
+type levelDBStore struct {
+	db *db.DB
+}
+
+// Get implements method in interface `KVStore`
+func (s *levelDBStore) Get(key []byte) ([]byte, error) {
+	return s.db.Get(key)
+}
+
+// MultiGet implements method in interface `KVStore`
+func (s *levelDBStore) MultiGet(keys [][]byte) ([][]byte, error) {
+	values := make([][]byte, len(keys))
+	for i, key := range keys {
+		value, err := s.db.Get(key)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// WriteBatch implements method in interface `KVStore`
+func (s *levelDBStore) WriteBatch(batch *UpdateBatch, sync bool) error {
+	levelBatch := &leveldb.Batch{}
+	batch.Visit(func(key []byte, value []byte) {
+		if value == nil {
+			levelBatch.Delete(key)
+		} else {
+			levelBatch.Put(key, value)
+		}
+	})
+	return s.db.WriteBatch(levelBatch, sync)
+}
+
+// Compact implements method in interface `Compacter`
+func (s *levelDBStore) Compact() (int64, int64, error) {
+	return s.db.Compact()
+}
+
+// GetIterator implements method in interface `KVStore`
+func (s *levelDBStore) GetIterator(startKey []byte, endKey []byte) Iterator {
+	return s.db.GetIterator(startKey, endKey)
+}
+
+// Close implements method in interface `KVStore`
+func (s *levelDBStore) Close() {
+	s.db.Close()
+}
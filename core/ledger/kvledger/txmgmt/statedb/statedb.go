@@ -0,0 +1,188 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statedb
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// KVStore - an interface that a key-value store for the state should implement
+type KVStore interface {
+	Get(key []byte) ([]byte, error)
+	// MultiGet returns the values for the given keys in a single call, in the order of the keys.
+	// The value is nil for a key that does not exist
+	MultiGet(keys [][]byte) ([][]byte, error)
+	WriteBatch(batch *UpdateBatch, sync bool) error
+	// GetIterator returns an iterator over key-range [startKey, endKey) in the sorted order of keys.
+	// A nil endKey means the iterator runs till the last key in the store
+	GetIterator(startKey []byte, endKey []byte) Iterator
+	Close()
+}
+
+// Compacter - an optional interface that a `KVStore` implements if it can compact its storage, so that
+// the space held by deleted and overwritten keys is reclaimed
+type Compacter interface {
+	// Compact returns the size in bytes of the store before and after the compaction
+	Compact() (int64, int64, error)
+}
+
+// Iterator - an interface for iterating over the keys of a `KVStore`.
+// The key and value returned may be reused by the iterator once it moves to the next key
+type Iterator interface {
+	Next() bool
+	Key() []byte
+	Value() []byte
+	Error() error
+	Release()
+}
+
+// Provider - an interface for opening a `KVStore` at a given path
+type Provider interface {
+	Open(dbPath string) (KVStore, error)
+}
+
+// UpdateBatch captures the updates that are applied to a `KVStore` atomically
+type UpdateBatch struct {
+	updates map[string][]byte
+}
+
+// NewUpdateBatch constructs an empty `UpdateBatch`
+func NewUpdateBatch() *UpdateBatch {
+	return &UpdateBatch{make(map[string][]byte)}
+}
+
+// Put adds a key-value to the batch
+func (batch *UpdateBatch) Put(key []byte, value []byte) {
+	if value == nil {
+		panic("Nil value not allowed. Use Delete() for deleting a key")
+	}
+	batch.updates[string(key)] = value
+}
+
+// Delete adds the deletion of a key to the batch
+func (batch *UpdateBatch) Delete(key []byte) {
+	batch.updates[string(key)] = nil
+}
+
+// Len returns the number of updates in the batch
+func (batch *UpdateBatch) Len() int {
+	return len(batch.updates)
+}
+
+// Visit calls the function for each update in the sorted order of keys. A nil value means delete
+func (batch *UpdateBatch) Visit(f func(key []byte, value []byte)) {
+	keys := make([]string, 0, len(batch.updates))
+	for k := range batch.updates {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		f([]byte(k), batch.updates[k])
+	}
+}
+
+var (
+	providersLock sync.RWMutex
+	providers     = make(map[string]Provider)
+)
+
+// RegisterProvider makes a `Provider` available by the given name
+func RegisterProvider(name string, provider Provider) {
+	providersLock.Lock()
+	defer providersLock.Unlock()
+	providers[name] = provider
+}
+
+// GetProvider returns the `Provider` registered with the given name
+func GetProvider(name string) (Provider, error) {
+	providersLock.RLock()
+	defer providersLock.RUnlock()
+	provider, ok := providers[name]
+	if !ok {
+		return nil, fmt.Errorf("Unknown state database [%s]. Available state databases are %s", name, providerNames())
+	}
+	return provider, nil
+}
+
+func providerNames() []string {
+	names := []string{}
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// migrateBatchSize is the number of keys copied in a single batch by `Migrate`
+const migrateBatchSize = 1000
+
+// Migrate copies all the keys from the source store to the target store. This allows moving
+// an existing state to a different state database while the peer is stopped
+func Migrate(source KVStore, target KVStore) (int, error) {
+	itr := source.GetIterator(nil, nil)
+	defer itr.Release()
+	numKeys := 0
+	batch := NewUpdateBatch()
+	for itr.Next() {
+		// the iterator may reuse its buffers, so the key and value are copied
+		key := append([]byte{}, itr.Key()...)
+		value := append([]byte{}, itr.Value()...)
+		batch.Put(key, value)
+		numKeys++
+		if batch.Len() == migrateBatchSize {
+			if err := target.WriteBatch(batch, false); err != nil {
+				return numKeys, err
+			}
+			batch = NewUpdateBatch()
+		}
+	}
+	if err := itr.Error(); err != nil {
+		return numKeys, err
+	}
+	if err := target.WriteBatch(batch, true); err != nil {
+		return numKeys, err
+	}
+	return numKeys, nil
+}
+
+// DeleteAll deletes all the keys from the store and returns the number of keys deleted.
+// The keys are deleted in batches of `migrateBatchSize` keys
+func DeleteAll(store KVStore) (int, error) {
+	itr := store.GetIterator(nil, nil)
+	defer itr.Release()
+	numKeys := 0
+	batch := NewUpdateBatch()
+	for itr.Next() {
+		batch.Delete(append([]byte{}, itr.Key()...))
+		numKeys++
+		if batch.Len() == migrateBatchSize {
+			if err := store.WriteBatch(batch, false); err != nil {
+				return numKeys, err
+			}
+			batch = NewUpdateBatch()
+		}
+	}
+	if err := itr.Error(); err != nil {
+		return numKeys, err
+	}
+	if err := store.WriteBatch(batch, true); err != nil {
+		return numKeys, err
+	}
+	return numKeys, nil
+}
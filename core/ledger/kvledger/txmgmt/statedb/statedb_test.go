@@ -0,0 +1,217 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statedb
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/hyperledger/fabric/core/ledger/testutil"
+)
+
+const testDBPath = "/tmp/tests/ledger/kvledger/txmgmt/statedb"
+
+func openTestStore(t testing.TB, name string) KVStore {
+	return openTestStoreOf(t, GoLevelDB, name)
+}
+
+func openTestStoreOf(t testing.TB, database string, name string) KVStore {
+	dbPath := testDBPath + "/" + name
+	os.RemoveAll(dbPath)
+	provider, err := GetProvider(database)
+	testutil.AssertNoError(t, err, "")
+	store, err := provider.Open(dbPath)
+	testutil.AssertNoError(t, err, "")
+	return store
+}
+
+func TestGetProvider(t *testing.T) {
+	_, err := GetProvider(GoLevelDB)
+	testutil.AssertNoError(t, err, "")
+	_, err = GetProvider(Badger)
+	testutil.AssertNoError(t, err, "")
+	_, err = GetProvider("unknown")
+	testutil.AssertError(t, err, "Expected error for an unknown state database")
+}
+
+func TestLevelDBStore(t *testing.T) {
+	testStore(t, GoLevelDB)
+}
+
+func TestBadgerStore(t *testing.T) {
+	testStore(t, Badger)
+}
+
+func testStore(t *testing.T, database string) {
+	defer os.RemoveAll(testDBPath)
+	store := openTestStoreOf(t, database, "store")
+	defer store.Close()
+
+	batch := NewUpdateBatch()
+	batch.Put([]byte("key1"), []byte("value1"))
+	batch.Put([]byte("key2"), []byte("value2"))
+	batch.Put([]byte("key3"), []byte("value3"))
+	testutil.AssertNoError(t, store.WriteBatch(batch, true), "")
+
+	batch = NewUpdateBatch()
+	batch.Delete([]byte("key2"))
+	testutil.AssertNoError(t, store.WriteBatch(batch, true), "")
+
+	value, err := store.Get([]byte("key1"))
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, value, []byte("value1"))
+	value, err = store.Get([]byte("key2"))
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertNil(t, value)
+
+	itr := store.GetIterator([]byte("key1"), nil)
+	defer itr.Release()
+	keys := []string{}
+	for itr.Next() {
+		keys = append(keys, string(itr.Key()))
+	}
+	testutil.AssertNoError(t, itr.Error(), "")
+	testutil.AssertEquals(t, keys, []string{"key1", "key3"})
+
+	itr = store.GetIterator([]byte("key1"), []byte("key3"))
+	defer itr.Release()
+	keys = []string{}
+	for itr.Next() {
+		keys = append(keys, string(itr.Key()))
+	}
+	testutil.AssertNoError(t, itr.Error(), "")
+	testutil.AssertEquals(t, keys, []string{"key1"})
+
+	values, err := store.MultiGet([][]byte{[]byte("key3"), []byte("key2"), []byte("key1")})
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, values, [][]byte{[]byte("value3"), nil, []byte("value1")})
+}
+
+func TestMigrate(t *testing.T) {
+	defer os.RemoveAll(testDBPath)
+	source := openTestStore(t, "source")
+	defer source.Close()
+	target := openTestStoreOf(t, Badger, "target")
+	defer target.Close()
+
+	batch := NewUpdateBatch()
+	numKeys := migrateBatchSize + 10
+	for i := 0; i < numKeys; i++ {
+		batch.Put([]byte(fmt.Sprintf("key%d", i)), []byte(fmt.Sprintf("value%d", i)))
+	}
+	testutil.AssertNoError(t, source.WriteBatch(batch, true), "")
+
+	migrated, err := Migrate(source, target)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, migrated, numKeys)
+	for i := 0; i < numKeys; i++ {
+		value, err := target.Get([]byte(fmt.Sprintf("key%d", i)))
+		testutil.AssertNoError(t, err, "")
+		testutil.AssertEquals(t, value, []byte(fmt.Sprintf("value%d", i)))
+	}
+}
+
+func TestDeleteAll(t *testing.T) {
+	defer os.RemoveAll(testDBPath)
+	store := openTestStore(t, "store")
+	defer store.Close()
+
+	batch := NewUpdateBatch()
+	numKeys := migrateBatchSize + 10
+	for i := 0; i < numKeys; i++ {
+		batch.Put([]byte(fmt.Sprintf("key%d", i)), []byte(fmt.Sprintf("value%d", i)))
+	}
+	testutil.AssertNoError(t, store.WriteBatch(batch, true), "")
+
+	deleted, err := DeleteAll(store)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, deleted, numKeys)
+	itr := store.GetIterator(nil, nil)
+	defer itr.Release()
+	testutil.AssertEquals(t, itr.Next(), false)
+}
+
+func BenchmarkLevelDBWriteBatch(b *testing.B) {
+	benchmarkWriteBatch(b, GoLevelDB)
+}
+
+func BenchmarkLevelDBGet(b *testing.B) {
+	benchmarkGet(b, GoLevelDB)
+}
+
+func BenchmarkBadgerWriteBatch(b *testing.B) {
+	benchmarkWriteBatch(b, Badger)
+}
+
+func BenchmarkBadgerGet(b *testing.B) {
+	benchmarkGet(b, Badger)
+}
+
+// benchmarkWriteBatch measures committing blocks of 100 writes to the state database registered by the given name
+func benchmarkWriteBatch(b *testing.B, name string) {
+	defer os.RemoveAll(testDBPath)
+	store := openBenchmarkStore(b, name)
+	defer store.Close()
+	value := testutil.ConstructRandomBytes(b, 100)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		batch := NewUpdateBatch()
+		for j := 0; j < 100; j++ {
+			batch.Put([]byte(fmt.Sprintf("key%d_%d", i, j)), value)
+		}
+		if err := store.WriteBatch(batch, false); err != nil {
+			b.Fatalf("Error writing batch: %s", err)
+		}
+	}
+}
+
+// benchmarkGet measures random reads from the state database registered by the given name
+func benchmarkGet(b *testing.B, name string) {
+	defer os.RemoveAll(testDBPath)
+	store := openBenchmarkStore(b, name)
+	defer store.Close()
+	numKeys := 10000
+	value := testutil.ConstructRandomBytes(b, 100)
+	batch := NewUpdateBatch()
+	for i := 0; i < numKeys; i++ {
+		batch.Put([]byte(fmt.Sprintf("key%d", i)), value)
+	}
+	if err := store.WriteBatch(batch, true); err != nil {
+		b.Fatalf("Error writing batch: %s", err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := store.Get([]byte(fmt.Sprintf("key%d", i%numKeys))); err != nil {
+			b.Fatalf("Error reading key: %s", err)
+		}
+	}
+}
+
+func openBenchmarkStore(b *testing.B, name string) KVStore {
+	dbPath := testDBPath + "/" + name
+	os.RemoveAll(dbPath)
+	provider, err := GetProvider(name)
+	if err != nil {
+		b.Fatalf("%s", err)
+	}
+	store, err := provider.Open(dbPath)
+	if err != nil {
+		b.Fatalf("Error opening state database: %s", err)
+	}
+	return store
+}
@@ -0,0 +1,77 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package txmgmt
+
+import (
+	"crypto/sha256"
+	"math/big"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// stateHashModulus is the modulus of the sums of entry hashes kept by `StateHash`
+var stateHashModulus = new(big.Int).Lsh(big.NewInt(1), 256)
+
+// StateHash is a hash over all the keys and values of a state. It is the sum modulo 2^256 of the hashes of the
+// entries, so it depends only on the set of entries and is kept up to date by removing the previous value of
+// each written key and adding the new one, without hashing the whole state for every block
+type StateHash struct {
+	sum *big.Int
+}
+
+// NewStateHash returns the state hash encoded in hashBytes, as returned by `Bytes`, or the hash of an
+// empty state if hashBytes is nil
+func NewStateHash(hashBytes []byte) *StateHash {
+	return &StateHash{new(big.Int).SetBytes(hashBytes)}
+}
+
+// Copy returns a copy of the state hash
+func (h *StateHash) Copy() *StateHash {
+	return &StateHash{new(big.Int).Set(h.sum)}
+}
+
+// Add adds the entry of the key with the value to the state hash
+func (h *StateHash) Add(namespace string, key string, value []byte) {
+	h.sum.Add(h.sum, entryHash(namespace, key, value))
+	h.sum.Mod(h.sum, stateHashModulus)
+}
+
+// Remove removes the entry of the key with the value from the state hash
+func (h *StateHash) Remove(namespace string, key string, value []byte) {
+	h.sum.Sub(h.sum, entryHash(namespace, key, value))
+	h.sum.Mod(h.sum, stateHashModulus)
+}
+
+// Bytes returns the 32 bytes of the state hash
+func (h *StateHash) Bytes() []byte {
+	hashBytes := make([]byte, 32)
+	sumBytes := h.sum.Bytes()
+	copy(hashBytes[len(hashBytes)-len(sumBytes):], sumBytes)
+	return hashBytes
+}
+
+func entryHash(namespace string, key string, value []byte) *big.Int {
+	h := sha256.New()
+	h.Write(encodeLengthPrefixed([]byte(namespace)))
+	h.Write(encodeLengthPrefixed([]byte(key)))
+	h.Write(encodeLengthPrefixed(value))
+	return new(big.Int).SetBytes(h.Sum(nil))
+}
+
+func encodeLengthPrefixed(b []byte) []byte {
+	return append(proto.EncodeVarint(uint64(len(b))), b...)
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package txmgmt
 
 import (
+	"fmt"
+
 	"github.com/hyperledger/fabric/core/ledger"
 	"github.com/hyperledger/fabric/protos"
 )
@@ -30,3 +32,89 @@ type TxMgr interface {
 	Rollback()
 	Shutdown()
 }
+
+// IndexCapable - an optional interface that a transaction manager implements
+// if its state database supports indexes for rich queries
+type IndexCapable interface {
+	CreateIndexes(namespace string, indexDefinitions [][]byte) error
+	// WarmUpIndexes brings all the indexes up to date with the state, so that
+	// the first queries that use them are not delayed by building the index
+	WarmUpIndexes() error
+}
+
+// SavepointCapable - an optional interface that a transaction manager implements if it records
+// in the state database the number of the last block whose changes have been committed
+type SavepointCapable interface {
+	// CommitWithSavepoint commits the changes prepared by ValidateAndPrepare and records
+	// blockNum as the savepoint with the same atomic write
+	CommitWithSavepoint(blockNum uint64) error
+	// GetLastSavepoint returns the last recorded savepoint, 0 if there is none
+	GetLastSavepoint() (uint64, error)
+}
+
+// Resettable - an optional interface that a transaction manager implements if it can drop all
+// the state, including the savepoint, so that the state can be rebuilt from the blocks
+type Resettable interface {
+	ResetState() error
+}
+
+// StateProofCapable - an optional interface that a transaction manager implements if it maintains a hash
+// tree over the state and records its root for every block, so that values can be proven to clients
+type StateProofCapable interface {
+	// GetStateWithProof returns the committed value of the key with a proof against the state root
+	// of the last block committed with a savepoint
+	GetStateWithProof(namespace string, key string) (*protos.StateProof, error)
+	// GetStateRoot returns the state root recorded for the block, nil if there is none
+	GetStateRoot(blockNum uint64) ([]byte, error)
+}
+
+// StateHashCapable - an optional interface that a transaction manager implements if it maintains a `StateHash`
+// over the committed keys and values and records it for every block, so that the state can be compared across peers
+type StateHashCapable interface {
+	// GetStateHash returns the state hash recorded for the block, nil if there is none
+	GetStateHash(blockNum uint64) ([]byte, error)
+}
+
+// Compactable - an optional interface that a transaction manager implements if its state database
+// can be compacted to reclaim the space held by deleted and overwritten keys
+type Compactable interface {
+	// CompactState returns the size in bytes of the state database before and after the compaction
+	CompactState() (int64, int64, error)
+}
+
+// QueryLimits bound the queries executed by the query executors of a transaction manager.
+// A zero value disables the corresponding limit, except for the maximum page size
+type QueryLimits struct {
+	// QueryResultLimit is the maximum number of results returned by an iterator over all the results of a query.
+	// The results returned to a transaction over all its iterators are limited by the chaincode support
+	QueryResultLimit int
+	// InternalQueryLimit is the maximum number of records fetched from the state database in a single
+	// request while iterating over the results of a query
+	InternalQueryLimit int
+	// DefaultPageSize is the page size of the paginated queries issued with a page size of 0
+	DefaultPageSize int32
+	// MaxPageSize is the maximum page size of the paginated queries, DefaultMaxPageSize if 0
+	MaxPageSize int32
+}
+
+// DefaultMaxPageSize is the maximum page size of the paginated queries when the query limits set none.
+// A page is read in a single request and returned in a single response, so its size is always capped
+const DefaultMaxPageSize int32 = 1000
+
+// GetPageSize returns the page size of a paginated query issued with the given page size
+func (l *QueryLimits) GetPageSize(pageSize int32) (int32, error) {
+	if pageSize == 0 {
+		pageSize = l.DefaultPageSize
+	}
+	if pageSize <= 0 {
+		return 0, fmt.Errorf("Invalid page size [%d]", pageSize)
+	}
+	maxPageSize := l.MaxPageSize
+	if maxPageSize <= 0 {
+		maxPageSize = DefaultMaxPageSize
+	}
+	if pageSize > maxPageSize {
+		return 0, fmt.Errorf("Page size [%d] exceeds the maximum page size [%d]", pageSize, maxPageSize)
+	}
+	return pageSize, nil
+}
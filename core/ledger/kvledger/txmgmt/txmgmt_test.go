@@ -0,0 +1,48 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package txmgmt
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/core/ledger/testutil"
+)
+
+func TestQueryLimitsGetPageSize(t *testing.T) {
+	limits := &QueryLimits{DefaultPageSize: 10, MaxPageSize: 100}
+	pageSize, err := limits.GetPageSize(0)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, pageSize, int32(10))
+	pageSize, err = limits.GetPageSize(100)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, pageSize, int32(100))
+	_, err = limits.GetPageSize(101)
+	testutil.AssertError(t, err, "A page size above the maximum should be rejected")
+	_, err = limits.GetPageSize(-1)
+	testutil.AssertError(t, err, "A negative page size should be rejected")
+
+	// without a default page size, the page size has to be given
+	limits = &QueryLimits{}
+	_, err = limits.GetPageSize(0)
+	testutil.AssertError(t, err, "A page size of 0 should be rejected without a default page size")
+	// without a maximum page size, the page size is still capped
+	pageSize, err = limits.GetPageSize(DefaultMaxPageSize)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, pageSize, DefaultMaxPageSize)
+	_, err = limits.GetPageSize(DefaultMaxPageSize + 1)
+	testutil.AssertError(t, err, "A page size above the default maximum should be rejected")
+}
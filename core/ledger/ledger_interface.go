@@ -51,6 +51,11 @@ type ValidatedLedger interface {
 	GetTransactionByID(txID string) (*protos.Transaction2, error)
 	// GetBlockByHash returns a block given it's hash
 	GetBlockByHash(blockHash []byte) (*protos.Block2, error)
+	// GetBlockByTxID returns the block that contains the transaction with the given id
+	GetBlockByTxID(txID string) (*protos.Block2, error)
+	// GetTxValidationCodeByTxID returns the validation code recorded at commit for the transaction with the given
+	// id, including the invalid transactions that are not in the ledger
+	GetTxValidationCodeByTxID(txID string) (protos.TxValidationCode, error)
 	// NewTxSimulator gives handle to a transaction simulator.
 	// A client can obtain more than one 'TxSimulator's for parallel execution.
 	// Any snapshoting/synchronization should be performed at the implementation level if required
@@ -85,8 +90,19 @@ type QueryExecutor interface {
 	// GetTransactionsForKey returns an iterator that contains all the transactions that modified the given key.
 	// The returned ResultsIterator contains results of type *msgs.Transaction
 	GetTransactionsForKey(namespace string, key string) (ResultsIterator, error)
-	// ExecuteQuery executes the given query and returns an iterator that contains results of type specific to the underlying data store.
-	ExecuteQuery(query string) (ResultsIterator, error)
+	// ExecuteQuery executes the given query in the given namespace and returns an iterator that contains results
+	// of type specific to the underlying data store.
+	// The results are not recorded in the read set and hence are not re-validated at commit time
+	ExecuteQuery(namespace string, query string) (ResultsIterator, error)
+	// GetStateRangeScanIteratorWithPagination returns an iterator that contains at most `pageSize` key-values
+	// between the given key ranges, starting from the position captured in `bookmark` (an empty bookmark starts
+	// from `startKey`). The bookmark for the next page can be obtained from the returned iterator.
+	// The returned PaginatedResultsIterator contains results of type *KV
+	GetStateRangeScanIteratorWithPagination(namespace string, startKey string, endKey string, pageSize int32, bookmark string) (PaginatedResultsIterator, error)
+	// ExecuteQueryWithPagination executes the given rich query in the given namespace and returns an iterator that contains
+	// at most `pageSize` results, starting from the position captured in `bookmark`.
+	// The returned PaginatedResultsIterator contains results of type *KV
+	ExecuteQueryWithPagination(namespace string, query string, pageSize int32, bookmark string) (PaginatedResultsIterator, error)
 }
 
 // TxSimulator simulates a transaction on a consistent snapshot of the 'as recent state as possible'
@@ -103,6 +119,10 @@ type TxSimulator interface {
 	ExecuteUpdate(query string) error
 	// CopyState copies the entire state in the sourceNamespace to the targetNamespace. This can be a large payload
 	CopyState(sourceNamespace string, targetNamespace string) error
+	// GetLedgerHeight returns the height of the ledger whose state the simulation reads. The height is recorded in
+	// the read set, so that the transaction is invalidated if a block is committed between the simulation and the
+	// transaction, as it is for a key updated in the meantime
+	GetLedgerHeight() (uint64, error)
 	// Done releases resources occupied by the TxSimulator
 	Done()
 	// GetTxSimulationResults encapsulates the results of the transaction simulation.
@@ -125,6 +145,14 @@ type ResultsIterator interface {
 	Close()
 }
 
+// PaginatedResultsIterator - an iterator for a single page of a query result set
+type PaginatedResultsIterator interface {
+	ResultsIterator
+	// GetBookmark returns the bookmark to be passed in for retrieving the next page.
+	// An empty bookmark indicates that there are no more results
+	GetBookmark() string
+}
+
 // QueryResult - a general interface for supporting different types of query results. Actual types differ for different queries
 type QueryResult interface{}
 
@@ -23,7 +23,10 @@ import (
 	"github.com/hyperledger/fabric/core/ledger/util"
 	"github.com/op/go-logging"
 	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/filter"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
 	"github.com/syndtr/goleveldb/leveldb/opt"
+	goleveldbutil "github.com/syndtr/goleveldb/leveldb/util"
 )
 
 var logger = logging.MustGetLogger("kvledger.db")
@@ -37,7 +40,21 @@ const (
 
 // Conf configuration for `DB`
 type Conf struct {
-	DBPath string
+	DBPath  string
+	Options Options
+}
+
+// Options are the goleveldb tuning options of a `DB`. A zero value leaves the goleveldb default in place
+type Options struct {
+	// BlockCacheSize is the size in bytes of the cache of uncompressed table blocks
+	BlockCacheSize int
+	// WriteBufferSize is the size in bytes of the memtable that is written to a table file when full
+	WriteBufferSize int
+	// BloomFilterBits is the number of bits per key of the bloom filter of the table files.
+	// The tables have no filter if zero
+	BloomFilterBits int
+	// ReadOnly opens an existing db without writing to it. Writes to the db fail
+	ReadOnly bool
 }
 
 // DB - a wrapper on an actual store
@@ -74,14 +91,24 @@ func (dbInst *DB) Open() {
 	if dbInst.dbState == opened {
 		return
 	}
-	dbOpts := &opt.Options{}
+	dbOpts := &opt.Options{
+		BlockCacheCapacity: dbInst.conf.Options.BlockCacheSize,
+		WriteBuffer:        dbInst.conf.Options.WriteBufferSize}
+	if dbInst.conf.Options.BloomFilterBits > 0 {
+		dbOpts.Filter = filter.NewBloomFilter(dbInst.conf.Options.BloomFilterBits)
+	}
 	dbPath := dbInst.conf.DBPath
 	var err error
-	var dirEmpty bool
-	if dirEmpty, err = util.CreateDirIfMissing(dbPath); err != nil {
-		panic(fmt.Sprintf("Error while trying to open DB: %s", err))
+	if dbInst.conf.Options.ReadOnly {
+		dbOpts.ReadOnly = true
+		dbOpts.ErrorIfMissing = true
+	} else {
+		var dirEmpty bool
+		if dirEmpty, err = util.CreateDirIfMissing(dbPath); err != nil {
+			panic(fmt.Sprintf("Error while trying to open DB: %s", err))
+		}
+		dbOpts.ErrorIfMissing = !dirEmpty
 	}
-	dbOpts.ErrorIfMissing = !dirEmpty
 	if dbInst.db, err = leveldb.OpenFile(dbPath, dbOpts); err != nil {
 		panic(fmt.Sprintf("Error while trying to open DB: %s", err))
 	}
@@ -105,10 +132,31 @@ func (dbInst *DB) isOpen() bool {
 	return dbInst.dbState == opened
 }
 
+// Compact compacts the whole key space of the db, so that the space held by deleted and overwritten entries
+// is reclaimed. It returns the size in bytes of the db files before and after the compaction
+func (dbInst *DB) Compact() (int64, int64, error) {
+	sizeBefore, err := util.DirSize(dbInst.conf.DBPath)
+	if err != nil {
+		return 0, 0, err
+	}
+	if err = dbInst.db.CompactRange(goleveldbutil.Range{}); err != nil {
+		logger.Errorf("Error while trying to compact DB: %s", err)
+		return 0, 0, err
+	}
+	sizeAfter, err := util.DirSize(dbInst.conf.DBPath)
+	if err != nil {
+		return 0, 0, err
+	}
+	logger.Debugf("Compacted DB [%s] from %d to %d bytes", dbInst.conf.DBPath, sizeBefore, sizeAfter)
+	return sizeBefore, sizeAfter, nil
+}
+
 // Get returns the value for the given key
 func (dbInst *DB) Get(key []byte) ([]byte, error) {
 	value, err := dbInst.db.Get(key, dbInst.readOpts)
 	if err == leveldb.ErrNotFound {
+		// goleveldb may return an empty, non-nil value for a key that has been deleted
+		value = nil
 		err = nil
 	}
 	if err != nil {
@@ -146,6 +194,12 @@ func (dbInst *DB) Delete(key []byte, sync bool) error {
 	return nil
 }
 
+// GetIterator returns an iterator over key-range [startKey, endKey). A nil endKey means the iterator
+// runs till the last key in the db. The caller should release the iterator after use
+func (dbInst *DB) GetIterator(startKey []byte, endKey []byte) iterator.Iterator {
+	return dbInst.db.NewIterator(&goleveldbutil.Range{Start: startKey, Limit: endKey}, dbInst.readOpts)
+}
+
 // WriteBatch writes a batch
 func (dbInst *DB) WriteBatch(batch *leveldb.Batch, sync bool) error {
 	wo := dbInst.writeOptsNoSync
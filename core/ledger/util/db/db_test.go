@@ -17,6 +17,7 @@ limitations under the License.
 package db
 
 import (
+	"fmt"
 	"os"
 	"testing"
 
@@ -28,7 +29,7 @@ func TestDBBasicWriteAndReads(t *testing.T) {
 	if err := os.RemoveAll(testDBPath); err != nil {
 		t.Fatalf("Error:%s", err)
 	}
-	dbConf := &Conf{testDBPath}
+	dbConf := &Conf{DBPath: testDBPath}
 	defer func() { os.RemoveAll(testDBPath) }()
 	db := CreateDB(dbConf)
 	db.Open()
@@ -48,3 +49,58 @@ func TestDBBasicWriteAndReads(t *testing.T) {
 	testutil.AssertNoError(t, err, "")
 	testutil.AssertEquals(t, val, []byte("value3"))
 }
+
+func TestDBWithOptions(t *testing.T) {
+	testDBPath := "/tmp/test/hyperledger/fabric/core/ledger/util/db"
+	if err := os.RemoveAll(testDBPath); err != nil {
+		t.Fatalf("Error:%s", err)
+	}
+	defer func() { os.RemoveAll(testDBPath) }()
+	options := Options{BlockCacheSize: 16 * 1024 * 1024, WriteBufferSize: 8 * 1024 * 1024, BloomFilterBits: 10}
+	db := CreateDB(&Conf{DBPath: testDBPath, Options: options})
+	db.Open()
+	db.Put([]byte("key1"), []byte("value1"), true)
+	db.Close()
+
+	// a db written with a bloom filter remains readable without the filter
+	db = CreateDB(&Conf{DBPath: testDBPath})
+	db.Open()
+	defer db.Close()
+	val, err := db.Get([]byte("key1"))
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, val, []byte("value1"))
+	val, err = db.Get([]byte("key2"))
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertNil(t, val)
+}
+
+func TestDBCompact(t *testing.T) {
+	testDBPath := "/tmp/test/hyperledger/fabric/core/ledger/util/db"
+	if err := os.RemoveAll(testDBPath); err != nil {
+		t.Fatalf("Error:%s", err)
+	}
+	defer func() { os.RemoveAll(testDBPath) }()
+	db := CreateDB(&Conf{DBPath: testDBPath})
+	db.Open()
+	defer db.Close()
+	value := make([]byte, 1024)
+	for i := 0; i < 1000; i++ {
+		db.Put([]byte(fmt.Sprintf("key%d", i)), value, false)
+	}
+	for i := 0; i < 1000; i++ {
+		db.Delete([]byte(fmt.Sprintf("key%d", i)), false)
+	}
+	db.Put([]byte("key1"), []byte("value1"), true)
+
+	sizeBefore, sizeAfter, err := db.Compact()
+	testutil.AssertNoError(t, err, "")
+	if sizeAfter >= sizeBefore {
+		t.Fatalf("Expected the compaction to reclaim space, the size went from %d to %d bytes", sizeBefore, sizeAfter)
+	}
+	val, err := db.Get([]byte("key1"))
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, val, []byte("value1"))
+	val, err = db.Get([]byte("key2"))
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertNil(t, val)
+}
@@ -0,0 +1,56 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package encryption
+
+import (
+	"errors"
+
+	"github.com/hyperledger/fabric/core/crypto/bccsp"
+)
+
+// Encrypter encrypts data before it is written to disk and decrypts it after it is read back
+type Encrypter interface {
+	Encrypt(plaintext []byte) ([]byte, error)
+	Decrypt(ciphertext []byte) ([]byte, error)
+}
+
+// bccspEncrypter implements interface `Encrypter` using a symmetric key held by a BCCSP
+type bccspEncrypter struct {
+	csp bccsp.BCCSP
+	key bccsp.Key
+}
+
+// NewBCCSPEncrypter constructs an `Encrypter` that uses the given BCCSP and its symmetric (AES) key
+func NewBCCSPEncrypter(csp bccsp.BCCSP, key bccsp.Key) (Encrypter, error) {
+	if csp == nil || key == nil {
+		return nil, errors.New("A BCCSP and a key are required for encryption")
+	}
+	if !key.Symmetric() {
+		return nil, errors.New("Encryption requires a symmetric key")
+	}
+	return &bccspEncrypter{csp, key}, nil
+}
+
+// Encrypt implements method in interface `Encrypter`
+func (e *bccspEncrypter) Encrypt(plaintext []byte) ([]byte, error) {
+	return e.csp.Encrypt(e.key, plaintext, &bccsp.AESCBCPKCS7ModeOpts{})
+}
+
+// Decrypt implements method in interface `Encrypter`
+func (e *bccspEncrypter) Decrypt(ciphertext []byte) ([]byte, error) {
+	return e.csp.Decrypt(e.key, ciphertext, &bccsp.AESCBCPKCS7ModeOpts{})
+}
@@ -0,0 +1,70 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package encryption
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/hyperledger/fabric/core/crypto/bccsp"
+	"github.com/hyperledger/fabric/core/crypto/bccsp/factory"
+	"github.com/hyperledger/fabric/core/crypto/primitives"
+	"github.com/spf13/viper"
+)
+
+func TestBCCSPEncrypter(t *testing.T) {
+	primitives.InitSecurityLevel("SHA2", 256)
+	viper.Set("security.bccsp.default.keyStorePath", os.TempDir())
+	csp, err := factory.GetBCCSP(&factory.SwOpts{EphemeralFlag: true})
+	if err != nil {
+		t.Fatalf("Error getting BCCSP: %s", err)
+	}
+
+	key, err := csp.KeyGen(&bccsp.AESKeyGenOpts{Temporary: true})
+	if err != nil {
+		t.Fatalf("Error generating AES key: %s", err)
+	}
+	encrypter, err := NewBCCSPEncrypter(csp, key)
+	if err != nil {
+		t.Fatalf("Error creating encrypter: %s", err)
+	}
+
+	plaintext := []byte("value1")
+	ciphertext, err := encrypter.Encrypt(plaintext)
+	if err != nil {
+		t.Fatalf("Error encrypting: %s", err)
+	}
+	if bytes.Contains(ciphertext, plaintext) {
+		t.Fatalf("Ciphertext should not contain the plaintext")
+	}
+	decrypted, err := encrypter.Decrypt(ciphertext)
+	if err != nil {
+		t.Fatalf("Error decrypting: %s", err)
+	}
+	if !bytes.Equal(decrypted, plaintext) {
+		t.Fatalf("Expected [%s], got [%s]", plaintext, decrypted)
+	}
+
+	ecdsaKey, err := csp.KeyGen(&bccsp.ECDSAKeyGenOpts{Temporary: true})
+	if err != nil {
+		t.Fatalf("Error generating ECDSA key: %s", err)
+	}
+	if _, err = NewBCCSPEncrypter(csp, ecdsaKey); err == nil {
+		t.Fatalf("Expected error for an asymmetric key")
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"io"
 	"os"
 	"path"
+	"path/filepath"
 	"strings"
 
 	"github.com/op/go-logging"
@@ -67,3 +68,18 @@ func FileExists(filePath string) (bool, int64, error) {
 	}
 	return true, fileInfo.Size(), err
 }
+
+// DirSize returns the total size of the files under the given dir
+func DirSize(dirPath string) (int64, error) {
+	var size int64
+	err := filepath.Walk(dirPath, func(filePath string, fileInfo os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fileInfo.Mode().IsRegular() {
+			size += fileInfo.Size()
+		}
+		return nil
+	})
+	return size, err
+}
@@ -90,6 +90,30 @@ func TestDirNotEmptyAndFileExists(t *testing.T) {
 	testutil.AssertEquals(t, dirEmpty5, false) //test directory is empty is returning false
 }
 
+func TestDirSize(t *testing.T) {
+	cleanup(DbPathTest)
+	defer cleanup(DbPathTest)
+
+	_, err := DirSize(DbPathTest)
+	testutil.AssertError(t, err, "Expected error for a missing directory")
+
+	_, err = CreateDirIfMissing(DbPathTest + "/subdir")
+	testutil.AssertNoError(t, err, "")
+	size, err := DirSize(DbPathTest)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, size, int64(0))
+
+	sizeOfFileCreated, err := createAndWriteAFile("This is some test data in a file")
+	testutil.AssertNoError(t, err, "")
+	f, err := os.Create(DbPathTest + "/subdir/testUtilFileDat2")
+	testutil.AssertNoError(t, err, "")
+	f.WriteString("More test data")
+	f.Close()
+	size, err = DirSize(DbPathTest)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, size, int64(sizeOfFileCreated+len("More test data")))
+}
+
 func createAndWriteAFile(sentence string) (int, error) {
 	//create a file in the direcotry
 	f, err2 := os.Create(DbFileTest)
@@ -0,0 +1,45 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+// DisabledProvider creates metrics that discard their values
+type DisabledProvider struct{}
+
+// NewCounter returns a counter discarding its values
+func (*DisabledProvider) NewCounter(CounterOpts) Counter { return disabledCounter{} }
+
+// NewGauge returns a gauge discarding its values
+func (*DisabledProvider) NewGauge(GaugeOpts) Gauge { return disabledGauge{} }
+
+// NewHistogram returns a histogram discarding its values
+func (*DisabledProvider) NewHistogram(HistogramOpts) Histogram { return disabledHistogram{} }
+
+type disabledCounter struct{}
+
+func (c disabledCounter) With(...string) Counter { return c }
+func (disabledCounter) Add(float64)              {}
+
+type disabledGauge struct{}
+
+func (g disabledGauge) With(...string) Gauge { return g }
+func (disabledGauge) Add(float64)            {}
+func (disabledGauge) Set(float64)            {}
+
+type disabledHistogram struct{}
+
+func (h disabledHistogram) With(...string) Histogram { return h }
+func (disabledHistogram) Observe(float64)            {}
@@ -0,0 +1,162 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/op/go-logging"
+	"github.com/spf13/viper"
+)
+
+var logger = logging.MustGetLogger("metrics")
+
+// Provider creates the metrics of the peer subsystems. The label values of
+// a metric are given to With as alternating label names and values, the
+// label names must be among the LabelNames of the options of the metric
+type Provider interface {
+	NewCounter(opts CounterOpts) Counter
+	NewGauge(opts GaugeOpts) Gauge
+	NewHistogram(opts HistogramOpts) Histogram
+}
+
+// Counter is a metric that only goes up, like the number of requests served
+type Counter interface {
+	With(labelValues ...string) Counter
+	Add(delta float64)
+}
+
+// Gauge is a metric that goes up and down, like the height of a ledger
+type Gauge interface {
+	With(labelValues ...string) Gauge
+	Add(delta float64)
+	Set(value float64)
+}
+
+// Histogram samples observations, like the durations of requests, into
+// buckets
+type Histogram interface {
+	With(labelValues ...string) Histogram
+	Observe(value float64)
+}
+
+// CounterOpts describes a counter. Its name is made of the non empty
+// Namespace, Subsystem and Name joined by underscores
+type CounterOpts struct {
+	Namespace  string
+	Subsystem  string
+	Name       string
+	Help       string
+	LabelNames []string
+}
+
+// GaugeOpts describes a gauge
+type GaugeOpts struct {
+	Namespace  string
+	Subsystem  string
+	Name       string
+	Help       string
+	LabelNames []string
+}
+
+// HistogramOpts describes a histogram. Buckets are the upper bounds of the
+// buckets in increasing order, DefaultBuckets when empty
+type HistogramOpts struct {
+	Namespace  string
+	Subsystem  string
+	Name       string
+	Help       string
+	LabelNames []string
+	Buckets    []float64
+}
+
+// DefaultBuckets suit the durations, in seconds, of network requests
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// withLabelValues returns the values of the label names of a metric, given
+// in the order of labelNames, updated with alternating label names and
+// values. It panics on the labels the metric doesn't have
+func withLabelValues(metric string, labelNames []string, values []string, labelValues []string) []string {
+	if len(labelValues)%2 != 0 {
+		panic(fmt.Sprintf("metric %s: label names and values are not paired: %v", metric, labelValues))
+	}
+	updated := make([]string, len(labelNames))
+	copy(updated, values)
+	for i := 0; i < len(labelValues); i += 2 {
+		index := -1
+		for j, name := range labelNames {
+			if name == labelValues[i] {
+				index = j
+			}
+		}
+		if index < 0 {
+			panic(fmt.Sprintf("metric %s has no label %s", metric, labelValues[i]))
+		}
+		updated[index] = labelValues[i+1]
+	}
+	return updated
+}
+
+var (
+	provider     Provider = &DisabledProvider{}
+	providerLock sync.RWMutex
+)
+
+// GetProvider returns the provider of the peer, which discards the metrics
+// until InitProvider selected another one
+func GetProvider() Provider {
+	providerLock.RLock()
+	defer providerLock.RUnlock()
+	return provider
+}
+
+// InitProvider selects the provider of the peer with "metrics.provider",
+// either "disabled", "prometheus" or "statsd". The subsystems create their
+// metrics when they are created, so it must be called before them
+func InitProvider() (Provider, error) {
+	var p Provider
+	switch name := viper.GetString("metrics.provider"); name {
+	case "", "disabled":
+		p = &DisabledProvider{}
+	case "prometheus":
+		p = NewPrometheusProvider()
+	case "statsd":
+		viper.SetDefault("metrics.statsd.network", "udp")
+		viper.SetDefault("metrics.statsd.flushInterval", 10*time.Second)
+		statsd, err := NewStatsdProvider(viper.GetString("metrics.statsd.network"),
+			viper.GetString("metrics.statsd.address"),
+			viper.GetString("metrics.statsd.prefix"),
+			viper.GetDuration("metrics.statsd.flushInterval"))
+		if err != nil {
+			return nil, err
+		}
+		p = statsd
+	default:
+		return nil, fmt.Errorf("Unknown metrics provider %s", name)
+	}
+	logger.Infof("Using metrics provider %T", p)
+
+	providerLock.Lock()
+	defer providerLock.Unlock()
+	if statsd, ok := provider.(*StatsdProvider); ok {
+		statsd.Stop()
+	}
+	provider = p
+	return p, nil
+}
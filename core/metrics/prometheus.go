@@ -0,0 +1,274 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// PrometheusProvider keeps the metrics it creates and writes them in the
+// Prometheus text exposition format, for the operations server to serve
+// them on /metrics
+type PrometheusProvider struct {
+	lock     sync.Mutex
+	families map[string]*family
+}
+
+// NewPrometheusProvider creates a PrometheusProvider without metrics
+func NewPrometheusProvider() *PrometheusProvider {
+	return &PrometheusProvider{families: make(map[string]*family)}
+}
+
+// NewCounter creates the counter, or returns the counter of the same name
+// created before
+func (p *PrometheusProvider) NewCounter(opts CounterOpts) Counter {
+	f := p.family("counter", fqName(opts.Namespace, opts.Subsystem, opts.Name), opts.Help, opts.LabelNames, nil)
+	return &promCounter{promMetric{family: f}}
+}
+
+// NewGauge creates the gauge, or returns the gauge of the same name created
+// before
+func (p *PrometheusProvider) NewGauge(opts GaugeOpts) Gauge {
+	f := p.family("gauge", fqName(opts.Namespace, opts.Subsystem, opts.Name), opts.Help, opts.LabelNames, nil)
+	return &promGauge{promMetric{family: f}}
+}
+
+// NewHistogram creates the histogram, or returns the histogram of the same
+// name created before
+func (p *PrometheusProvider) NewHistogram(opts HistogramOpts) Histogram {
+	buckets := opts.Buckets
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	f := p.family("histogram", fqName(opts.Namespace, opts.Subsystem, opts.Name), opts.Help, opts.LabelNames, buckets)
+	return &promHistogram{promMetric{family: f}}
+}
+
+func (p *PrometheusProvider) family(kind, name, help string, labelNames []string, buckets []float64) *family {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if f, ok := p.families[name]; ok {
+		if f.kind != kind {
+			panic(fmt.Sprintf("metric %s was created as a %s", name, f.kind))
+		}
+		return f
+	}
+	f := &family{
+		kind:       kind,
+		name:       name,
+		help:       help,
+		labelNames: labelNames,
+		buckets:    buckets,
+		series:     make(map[string]*series),
+	}
+	p.families[name] = f
+	return f
+}
+
+// WriteMetrics writes the current values of the metrics in the Prometheus
+// text exposition format
+func (p *PrometheusProvider) WriteMetrics(w io.Writer) error {
+	p.lock.Lock()
+	families := make([]*family, 0, len(p.families))
+	for _, f := range p.families {
+		families = append(families, f)
+	}
+	p.lock.Unlock()
+	sort.Sort(byName(families))
+
+	bw := bufio.NewWriter(w)
+	for _, f := range families {
+		f.write(bw)
+	}
+	return bw.Flush()
+}
+
+func fqName(namespace, subsystem, name string) string {
+	var parts []string
+	for _, part := range []string{namespace, subsystem, name} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "_")
+}
+
+// family is a metric along with the series of its label values
+type family struct {
+	lock       sync.Mutex
+	kind       string
+	name       string
+	help       string
+	labelNames []string
+	buckets    []float64
+	series     map[string]*series
+}
+
+type series struct {
+	labelValues []string
+	value       float64
+	counts      []uint64
+	count       uint64
+}
+
+// get returns the series of the label values, in the order of the label
+// names of the family. It must be called with the lock held
+func (f *family) get(labelValues []string) *series {
+	key := strings.Join(labelValues, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labelValues: labelValues}
+		if f.kind == "histogram" {
+			s.counts = make([]uint64, len(f.buckets))
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+func (f *family) write(w io.Writer) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, f.kind)
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		s := f.series[key]
+		if f.kind != "histogram" {
+			fmt.Fprintf(w, "%s%s %s\n", f.name, f.labels(s.labelValues, ""), formatFloat(s.value))
+			continue
+		}
+		for i, bound := range f.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, f.labels(s.labelValues, formatFloat(bound)), s.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, f.labels(s.labelValues, "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", f.name, f.labels(s.labelValues, ""), formatFloat(s.value))
+		fmt.Fprintf(w, "%s_count%s %d\n", f.name, f.labels(s.labelValues, ""), s.count)
+	}
+}
+
+// labels formats the label values of a series, with the le label of a
+// histogram bucket when le is set
+func (f *family) labels(labelValues []string, le string) string {
+	var pairs []string
+	for i, name := range f.labelNames {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, name, labelValueEscaper.Replace(labelValues[i])))
+	}
+	if le != "" {
+		pairs = append(pairs, fmt.Sprintf("le=%q", le))
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+type byName []*family
+
+func (a byName) Len() int           { return len(a) }
+func (a byName) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a byName) Less(i, j int) bool { return a[i].name < a[j].name }
+
+// promMetric is a metric of a family with some of its label values set
+type promMetric struct {
+	family      *family
+	labelValues []string
+}
+
+// with returns the metric with its label values updated with the label names
+// and values
+func (m promMetric) with(labelValues []string) promMetric {
+	return promMetric{family: m.family, labelValues: withLabelValues(m.family.name, m.family.labelNames, m.labelValues, labelValues)}
+}
+
+func (m promMetric) update(update func(s *series)) {
+	values := m.labelValues
+	if values == nil {
+		values = make([]string, len(m.family.labelNames))
+	}
+	m.family.lock.Lock()
+	defer m.family.lock.Unlock()
+	update(m.family.get(values))
+}
+
+type promCounter struct{ promMetric }
+
+func (c *promCounter) With(labelValues ...string) Counter {
+	return &promCounter{c.with(labelValues)}
+}
+
+func (c *promCounter) Add(delta float64) {
+	if delta < 0 {
+		panic(fmt.Sprintf("counter %s cannot decrease", c.family.name))
+	}
+	c.update(func(s *series) { s.value += delta })
+}
+
+type promGauge struct{ promMetric }
+
+func (g *promGauge) With(labelValues ...string) Gauge {
+	return &promGauge{g.with(labelValues)}
+}
+
+func (g *promGauge) Add(delta float64) {
+	g.update(func(s *series) { s.value += delta })
+}
+
+func (g *promGauge) Set(value float64) {
+	g.update(func(s *series) { s.value = value })
+}
+
+type promHistogram struct{ promMetric }
+
+func (h *promHistogram) With(labelValues ...string) Histogram {
+	return &promHistogram{h.with(labelValues)}
+}
+
+func (h *promHistogram) Observe(value float64) {
+	h.update(func(s *series) {
+		for i, bound := range h.family.buckets {
+			if value <= bound {
+				s.counts[i]++
+			}
+		}
+		s.count++
+		s.value += value
+	})
+}
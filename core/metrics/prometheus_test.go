@@ -0,0 +1,99 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrometheusProvider(t *testing.T) {
+	p := NewPrometheusProvider()
+
+	requests := p.NewCounter(CounterOpts{Namespace: "endorser", Name: "proposals_received", Help: "Proposals received", LabelNames: []string{"chain", "status"}})
+	requests.With("chain", "A", "status", "200").Add(1)
+	requests.With("chain", "A").With("status", "200").Add(2)
+	requests.With("chain", "B", "status", "500").Add(1)
+
+	height := p.NewGauge(GaugeOpts{Namespace: "ledger", Name: "height", Help: "Ledger height"})
+	height.Set(10)
+	height.Add(-2)
+
+	duration := p.NewHistogram(HistogramOpts{Name: "duration", Help: "Durations", Buckets: []float64{0.1, 1}})
+	duration.Observe(0.05)
+	duration.Observe(0.5)
+	duration.Observe(5)
+
+	// creating a metric again returns the existing one
+	p.NewGauge(GaugeOpts{Namespace: "ledger", Name: "height", Help: "Ledger height"}).Add(1)
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, p.WriteMetrics(buf))
+	assert.Equal(t, `# HELP duration Durations
+# TYPE duration histogram
+duration_bucket{le="0.1"} 1
+duration_bucket{le="1"} 2
+duration_bucket{le="+Inf"} 3
+duration_sum 5.55
+duration_count 3
+# HELP endorser_proposals_received Proposals received
+# TYPE endorser_proposals_received counter
+endorser_proposals_received{chain="A",status="200"} 3
+endorser_proposals_received{chain="B",status="500"} 1
+# HELP ledger_height Ledger height
+# TYPE ledger_height gauge
+ledger_height 9
+`, buf.String())
+}
+
+func TestPrometheusLabels(t *testing.T) {
+	p := NewPrometheusProvider()
+	c := p.NewCounter(CounterOpts{Name: "c", Help: "c", LabelNames: []string{"l"}})
+
+	c.With("l", "a\"b\\c\nd").Add(1)
+	buf := &bytes.Buffer{}
+	assert.NoError(t, p.WriteMetrics(buf))
+	assert.Contains(t, buf.String(), `c{l="a\"b\\c\nd"} 1`)
+
+	assert.Panics(t, func() { c.With("l") })
+	assert.Panics(t, func() { c.With("other", "a") })
+	assert.Panics(t, func() { c.Add(-1) })
+	assert.Panics(t, func() { p.NewGauge(GaugeOpts{Name: "c"}) })
+}
+
+func TestInitProvider(t *testing.T) {
+	defer viper.Reset()
+
+	viper.Set("metrics.provider", "prometheus")
+	p, err := InitProvider()
+	assert.NoError(t, err)
+	assert.IsType(t, &PrometheusProvider{}, p)
+	assert.Equal(t, p, GetProvider())
+
+	viper.Set("metrics.provider", "disabled")
+	p, err = InitProvider()
+	assert.NoError(t, err)
+	assert.IsType(t, &DisabledProvider{}, p)
+
+	viper.Set("metrics.provider", "unknown")
+	_, err = InitProvider()
+	assert.Error(t, err)
+	assert.IsType(t, &DisabledProvider{}, GetProvider())
+}
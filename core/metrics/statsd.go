@@ -0,0 +1,246 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxStatsdPacketSize keeps the packets sent to the StatsD server within
+// the MTU of common networks
+const maxStatsdPacketSize = 1432
+
+// StatsdProvider aggregates the metrics it creates and sends them to a
+// StatsD server every flush interval. The label values of a metric are
+// appended to its name, separated by dots. Histograms are sent as timings:
+// their observations, in seconds, are sent in milliseconds
+type StatsdProvider struct {
+	prefix string
+	writer io.Writer
+
+	lock     sync.Mutex
+	counters map[string]float64
+	gauges   map[string]float64
+	changed  map[string]bool
+	timings  map[string][]float64
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewStatsdProvider creates a StatsdProvider sending the metrics, with
+// their names prefixed by prefix, to the StatsD server at address over
+// network, usually "udp", every flushInterval
+func NewStatsdProvider(network, address, prefix string, flushInterval time.Duration) (*StatsdProvider, error) {
+	if flushInterval <= 0 {
+		return nil, fmt.Errorf("Invalid StatsD flush interval %s", flushInterval)
+	}
+	conn, err := net.Dial(network, address)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to connect to StatsD server %s: %s", address, err)
+	}
+	p := newStatsdProvider(conn, prefix)
+	go p.run(flushInterval, conn)
+	return p, nil
+}
+
+func newStatsdProvider(writer io.Writer, prefix string) *StatsdProvider {
+	return &StatsdProvider{
+		prefix:   prefix,
+		writer:   writer,
+		counters: make(map[string]float64),
+		gauges:   make(map[string]float64),
+		changed:  make(map[string]bool),
+		timings:  make(map[string][]float64),
+		stop:     make(chan struct{}),
+	}
+}
+
+func (p *StatsdProvider) run(flushInterval time.Duration, conn io.Closer) {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.flush()
+		case <-p.stop:
+			p.flush()
+			conn.Close()
+			return
+		}
+	}
+}
+
+// Stop sends the metrics aggregated since the last flush and closes the
+// connection to the StatsD server
+func (p *StatsdProvider) Stop() {
+	p.stopOnce.Do(func() { close(p.stop) })
+}
+
+// NewCounter creates a counter, sent as the sum of its increments since
+// the last flush
+func (p *StatsdProvider) NewCounter(opts CounterOpts) Counter {
+	return &statsdCounter{p.metric(opts.Namespace, opts.Subsystem, opts.Name, opts.LabelNames)}
+}
+
+// NewGauge creates a gauge, sent when its value changed since the last flush
+func (p *StatsdProvider) NewGauge(opts GaugeOpts) Gauge {
+	return &statsdGauge{p.metric(opts.Namespace, opts.Subsystem, opts.Name, opts.LabelNames)}
+}
+
+// NewHistogram creates a histogram, whose observations are sent as timings
+func (p *StatsdProvider) NewHistogram(opts HistogramOpts) Histogram {
+	return &statsdHistogram{p.metric(opts.Namespace, opts.Subsystem, opts.Name, opts.LabelNames)}
+}
+
+func (p *StatsdProvider) metric(namespace, subsystem, name string, labelNames []string) statsdMetric {
+	var parts []string
+	for _, part := range []string{p.prefix, namespace, subsystem, name} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return statsdMetric{provider: p, name: strings.Join(parts, "."), labelNames: labelNames}
+}
+
+// flush sends the metrics aggregated since the last flush, in as few
+// packets as possible
+func (p *StatsdProvider) flush() {
+	p.lock.Lock()
+	var lines []string
+	for name, value := range p.counters {
+		lines = append(lines, fmt.Sprintf("%s:%s|c", name, formatFloat(value)))
+	}
+	for name := range p.changed {
+		lines = append(lines, fmt.Sprintf("%s:%s|g", name, formatFloat(p.gauges[name])))
+	}
+	for name, values := range p.timings {
+		for _, value := range values {
+			lines = append(lines, fmt.Sprintf("%s:%s|ms", name, strconv.FormatFloat(value*1000, 'f', -1, 64)))
+		}
+	}
+	p.counters = make(map[string]float64)
+	p.changed = make(map[string]bool)
+	p.timings = make(map[string][]float64)
+	p.lock.Unlock()
+	sort.Strings(lines)
+
+	packet := &bytes.Buffer{}
+	send := func() {
+		if packet.Len() == 0 {
+			return
+		}
+		if _, err := p.writer.Write(packet.Bytes()); err != nil {
+			logger.Warningf("Failed to send metrics to StatsD: %s", err)
+		}
+		packet.Reset()
+	}
+	for _, line := range lines {
+		if packet.Len() > 0 && packet.Len()+1+len(line) > maxStatsdPacketSize {
+			send()
+		}
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+		packet.WriteString(line)
+	}
+	send()
+}
+
+var statsdNameEscaper = strings.NewReplacer(":", "_", "|", "_", "@", "_", "\n", "_", " ", "_")
+
+// statsdMetric is a metric with some of its label values set
+type statsdMetric struct {
+	provider    *StatsdProvider
+	name        string
+	labelNames  []string
+	labelValues []string
+}
+
+func (m statsdMetric) with(labelValues []string) statsdMetric {
+	m.labelValues = withLabelValues(m.name, m.labelNames, m.labelValues, labelValues)
+	return m
+}
+
+// fullName returns the name of the metric followed by its non empty label
+// values
+func (m statsdMetric) fullName() string {
+	name := m.name
+	for _, value := range m.labelValues {
+		if value != "" {
+			name += "." + value
+		}
+	}
+	return statsdNameEscaper.Replace(name)
+}
+
+type statsdCounter struct{ statsdMetric }
+
+func (c *statsdCounter) With(labelValues ...string) Counter {
+	return &statsdCounter{c.with(labelValues)}
+}
+
+func (c *statsdCounter) Add(delta float64) {
+	if delta < 0 {
+		panic(fmt.Sprintf("counter %s cannot decrease", c.name))
+	}
+	c.provider.lock.Lock()
+	defer c.provider.lock.Unlock()
+	c.provider.counters[c.fullName()] += delta
+}
+
+type statsdGauge struct{ statsdMetric }
+
+func (g *statsdGauge) With(labelValues ...string) Gauge {
+	return &statsdGauge{g.with(labelValues)}
+}
+
+func (g *statsdGauge) Add(delta float64) {
+	g.provider.lock.Lock()
+	defer g.provider.lock.Unlock()
+	name := g.fullName()
+	g.provider.gauges[name] += delta
+	g.provider.changed[name] = true
+}
+
+func (g *statsdGauge) Set(value float64) {
+	g.provider.lock.Lock()
+	defer g.provider.lock.Unlock()
+	name := g.fullName()
+	g.provider.gauges[name] = value
+	g.provider.changed[name] = true
+}
+
+type statsdHistogram struct{ statsdMetric }
+
+func (h *statsdHistogram) With(labelValues ...string) Histogram {
+	return &statsdHistogram{h.with(labelValues)}
+}
+
+func (h *statsdHistogram) Observe(value float64) {
+	h.provider.lock.Lock()
+	defer h.provider.lock.Unlock()
+	name := h.fullName()
+	h.provider.timings[name] = append(h.provider.timings[name], value)
+}
@@ -0,0 +1,113 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+type packetRecorder struct {
+	packets []string
+}
+
+func (r *packetRecorder) Write(b []byte) (int, error) {
+	r.packets = append(r.packets, string(b))
+	return len(b), nil
+}
+
+func TestStatsdProvider(t *testing.T) {
+	r := &packetRecorder{}
+	p := newStatsdProvider(r, "peer0")
+
+	proposals := p.NewCounter(CounterOpts{Namespace: "endorser", Name: "proposals_received", LabelNames: []string{"chain"}})
+	proposals.With("chain", "A").Add(1)
+	proposals.With("chain", "A").Add(2)
+	proposals.With("chain", "B:1").Add(1)
+
+	height := p.NewGauge(GaugeOpts{Namespace: "ledger", Name: "height"})
+	height.Set(10)
+	height.Add(-2)
+
+	duration := p.NewHistogram(HistogramOpts{Name: "duration", LabelNames: []string{"chaincode", "success"}})
+	duration.With("success", "true").Observe(0.25)
+
+	p.flush()
+	assert.Equal(t, []string{strings.Join([]string{
+		"peer0.duration.true:250|ms",
+		"peer0.endorser.proposals_received.A:3|c",
+		"peer0.endorser.proposals_received.B_1:1|c",
+		"peer0.ledger.height:8|g",
+	}, "\n")}, r.packets)
+
+	// only the metrics updated since the last flush are sent
+	r.packets = nil
+	p.flush()
+	assert.Empty(t, r.packets)
+	height.Add(1)
+	p.flush()
+	assert.Equal(t, []string{"peer0.ledger.height:9|g"}, r.packets)
+}
+
+func TestStatsdPacketSize(t *testing.T) {
+	r := &packetRecorder{}
+	p := newStatsdProvider(r, "")
+
+	c := p.NewCounter(CounterOpts{Name: strings.Repeat("c", 100), LabelNames: []string{"n"}})
+	for i := 0; i < 100; i++ {
+		c.With("n", string(rune('a'+i%26))+string(rune('a'+i/26))).Add(1)
+	}
+	p.flush()
+
+	lines := 0
+	for _, packet := range r.packets {
+		assert.True(t, len(packet) <= maxStatsdPacketSize)
+		lines += len(strings.Split(packet, "\n"))
+	}
+	assert.True(t, len(r.packets) > 1)
+	assert.Equal(t, 100, lines)
+}
+
+func TestStatsdServer(t *testing.T) {
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %s", err)
+	}
+	defer server.Close()
+
+	defer viper.Reset()
+	viper.Set("metrics.provider", "statsd")
+	viper.Set("metrics.statsd.address", server.LocalAddr().String())
+	viper.Set("metrics.statsd.prefix", "peer0")
+	viper.Set("metrics.statsd.flushInterval", 10*time.Millisecond)
+	p, err := InitProvider()
+	assert.NoError(t, err)
+	defer p.(*StatsdProvider).Stop()
+
+	p.NewCounter(CounterOpts{Namespace: "gossip", Name: "blocks_sent"}).Add(1)
+
+	buf := make([]byte, maxStatsdPacketSize)
+	server.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := server.ReadFrom(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "peer0.gossip.blocks_sent:1|c", string(buf[:n]))
+}
@@ -0,0 +1,432 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operations
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric/core/audit"
+	"github.com/hyperledger/fabric/flogging"
+	"github.com/op/go-logging"
+	"github.com/spf13/viper"
+)
+
+var logger = logging.MustGetLogger("operations")
+
+// Checker reports whether a component the peer depends on is able to serve,
+// by returning an error describing the failure when it is not
+type Checker func() error
+
+// TLSOptions configures TLS on the operations listener. When
+// ClientAuthRequired is set only clients presenting a certificate issued by
+// one of ClientRootCAFiles are served
+type TLSOptions struct {
+	Enabled            bool
+	CertFile           string
+	KeyFile            string
+	ClientAuthRequired bool
+	ClientRootCAFiles  []string
+	// MinVersion and CipherSuites restrict the TLS versions and cipher
+	// suites negotiated, as in tls.Config
+	MinVersion   uint16
+	CipherSuites []uint16
+}
+
+// Exporter writes the metrics of a metrics provider in the Prometheus text
+// exposition format
+type Exporter interface {
+	WriteMetrics(w io.Writer) error
+}
+
+// Options configures the operations System. The metrics of Exporter, when
+// set, are served on /metrics after the registered gauges
+type Options struct {
+	ListenAddress string
+	TLS           TLSOptions
+	Exporter      Exporter
+	// CheckTimeout bounds the duration of each check of /healthz, a check
+	// still running at its end failing. The checks are not bounded when 0
+	CheckTimeout time.Duration
+	// Profile serves the profiles of net/http/pprof on /debug/pprof/. It
+	// requires TLS with client authentication
+	Profile bool
+}
+
+// checkers are the checkers of the subsystems of the peer, run by the
+// /healthz endpoint of every System along with its own
+var checkers = struct {
+	sync.RWMutex
+	m map[string]Checker
+}{m: make(map[string]Checker)}
+
+// RegisterChecker adds the checker of a subsystem of the peer, such as a
+// database or a service it connects to, to the checks run by /healthz. It
+// replaces a checker already registered for the component. Subsystems may
+// register their checkers before the System is created
+func RegisterChecker(component string, checker Checker) {
+	checkers.Lock()
+	defer checkers.Unlock()
+	checkers.m[component] = checker
+}
+
+// OptionsFromConfig reads the Options from the peer.operations section of
+// the configuration
+func OptionsFromConfig() Options {
+	return Options{
+		ListenAddress: viper.GetString("peer.operations.listenAddress"),
+		CheckTimeout:  viper.GetDuration("peer.operations.checkTimeout"),
+		Profile:       viper.GetBool("peer.operations.profile.enabled"),
+		TLS: TLSOptions{
+			Enabled:            viper.GetBool("peer.operations.tls.enabled"),
+			CertFile:           viper.GetString("peer.operations.tls.cert.file"),
+			KeyFile:            viper.GetString("peer.operations.tls.key.file"),
+			ClientAuthRequired: viper.GetBool("peer.operations.tls.clientAuthRequired"),
+			ClientRootCAFiles:  viper.GetStringSlice("peer.operations.tls.clientRootCAs.files"),
+		},
+	}
+}
+
+type gauge struct {
+	help  string
+	value func() float64
+}
+
+// System is the operations HTTP server of the peer. It serves, on a
+// listener of its own, the health of the registered components on
+// /healthz, the registered metrics on /metrics, the logging specification
+// on /logspec and, when enabled, the profiles of the peer on /debug/pprof/
+type System struct {
+	sync.RWMutex
+	options  Options
+	checkers map[string]Checker
+	gauges   map[string]*gauge
+	listener net.Listener
+}
+
+// NewSystem creates an operations System, which serves nothing until
+// started
+func NewSystem(options Options) *System {
+	return &System{
+		options:  options,
+		checkers: make(map[string]Checker),
+		gauges:   make(map[string]*gauge),
+	}
+}
+
+// RegisterChecker adds the checker of a component to the checks run by
+// /healthz, replacing a checker already registered for the component
+func (s *System) RegisterChecker(component string, checker Checker) {
+	s.Lock()
+	defer s.Unlock()
+	s.checkers[component] = checker
+}
+
+// RegisterGauge adds a gauge, whose value is read from value on every
+// scrape, to the metrics served by /metrics
+func (s *System) RegisterGauge(name string, help string, value func() float64) {
+	s.Lock()
+	defer s.Unlock()
+	s.gauges[name] = &gauge{help: help, value: value}
+}
+
+// Start listens on the configured address and serves the operations
+// endpoints in the background
+func (s *System) Start() error {
+	if s.options.Profile && !(s.options.TLS.Enabled && s.options.TLS.ClientAuthRequired) {
+		return fmt.Errorf("Profiling requires TLS with client authentication on the operations server")
+	}
+	listener, err := net.Listen("tcp", s.options.ListenAddress)
+	if err != nil {
+		return fmt.Errorf("Failed to listen on %s: %s", s.options.ListenAddress, err)
+	}
+	if s.options.TLS.Enabled {
+		config, err := s.tlsConfig()
+		if err != nil {
+			listener.Close()
+			return err
+		}
+		listener = tls.NewListener(listener, config)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", s.handleHealth)
+	mux.HandleFunc("/metrics", s.handleMetrics)
+	mux.HandleFunc("/logspec", s.handleLogSpec)
+	if s.options.Profile {
+		mux.HandleFunc("/debug/pprof/", profileHandler(pprof.Index))
+		mux.HandleFunc("/debug/pprof/cmdline", profileHandler(pprof.Cmdline))
+		mux.HandleFunc("/debug/pprof/profile", profileHandler(pprof.Profile))
+		mux.HandleFunc("/debug/pprof/symbol", profileHandler(pprof.Symbol))
+		mux.HandleFunc("/debug/pprof/trace", profileHandler(pprof.Trace))
+	}
+
+	s.Lock()
+	s.listener = listener
+	s.Unlock()
+
+	logger.Infof("Starting operations server on %s, TLS enabled: %t", listener.Addr(), s.options.TLS.Enabled)
+	go func() {
+		err := (&http.Server{Handler: mux}).Serve(listener)
+		if s.Addr() != "" {
+			logger.Errorf("Operations server exited with error: %s", err)
+		}
+	}()
+	return nil
+}
+
+// Stop closes the listener of the System
+func (s *System) Stop() error {
+	s.Lock()
+	defer s.Unlock()
+	if s.listener == nil {
+		return nil
+	}
+	err := s.listener.Close()
+	s.listener = nil
+	return err
+}
+
+// Addr returns the address the System listens on, or an empty string if it
+// isn't started
+func (s *System) Addr() string {
+	s.RLock()
+	defer s.RUnlock()
+	if s.listener == nil {
+		return ""
+	}
+	return s.listener.Addr().String()
+}
+
+func (s *System) tlsConfig() (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(s.options.TLS.CertFile, s.options.TLS.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load operations TLS key pair: %s", err)
+	}
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   s.options.TLS.MinVersion,
+		CipherSuites: s.options.TLS.CipherSuites,
+	}
+	if len(s.options.TLS.ClientRootCAFiles) > 0 {
+		pool := x509.NewCertPool()
+		for _, file := range s.options.TLS.ClientRootCAFiles {
+			pem, err := ioutil.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("Failed to read client root CA %s: %s", file, err)
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("Invalid client root CA %s", file)
+			}
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	if s.options.TLS.ClientAuthRequired {
+		if config.ClientCAs == nil {
+			return nil, fmt.Errorf("Client authentication requires client root CAs")
+		}
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return config, nil
+}
+
+// HealthStatus is the body of the /healthz response. FailedChecks holds
+// the error of every failing component
+type HealthStatus struct {
+	Status       string            `json:"status"`
+	FailedChecks map[string]string `json:"failed_checks,omitempty"`
+}
+
+func (s *System) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("Invalid request method: %s", r.Method))
+		return
+	}
+
+	status := &HealthStatus{Status: "OK"}
+	for component, err := range s.runChecks() {
+		if status.FailedChecks == nil {
+			status.FailedChecks = make(map[string]string)
+		}
+		status.FailedChecks[component] = err.Error()
+	}
+	code := http.StatusOK
+	if len(status.FailedChecks) > 0 {
+		status.Status = "Service Unavailable"
+		code = http.StatusServiceUnavailable
+	}
+	writeJSON(w, code, status)
+}
+
+// runChecks runs the checkers of the subsystems and of the System
+// concurrently and returns the errors of the failed ones, by component
+func (s *System) runChecks() map[string]error {
+	all := make(map[string]Checker)
+	checkers.RLock()
+	for component, checker := range checkers.m {
+		all[component] = checker
+	}
+	checkers.RUnlock()
+	s.RLock()
+	for component, checker := range s.checkers {
+		all[component] = checker
+	}
+	s.RUnlock()
+
+	type result struct {
+		component string
+		err       error
+	}
+	results := make(chan result, len(all))
+	for component, checker := range all {
+		go func(component string, checker Checker) {
+			results <- result{component: component, err: checker()}
+		}(component, checker)
+	}
+
+	var timeout <-chan time.Time
+	if s.options.CheckTimeout > 0 {
+		timeout = time.After(s.options.CheckTimeout)
+	}
+	failed := make(map[string]error)
+	pending := all
+	for len(pending) > 0 {
+		select {
+		case r := <-results:
+			delete(pending, r.component)
+			if r.err != nil {
+				failed[r.component] = r.err
+			}
+		case <-timeout:
+			for component := range pending {
+				failed[component] = fmt.Errorf("check timed out after %s", s.options.CheckTimeout)
+			}
+			return failed
+		}
+	}
+	return failed
+}
+
+func (s *System) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("Invalid request method: %s", r.Method))
+		return
+	}
+
+	s.RLock()
+	names := make([]string, 0, len(s.gauges))
+	for name := range s.gauges {
+		names = append(names, name)
+	}
+	gauges := make(map[string]*gauge, len(s.gauges))
+	for name, g := range s.gauges {
+		gauges[name] = g
+	}
+	s.RUnlock()
+	sort.Strings(names)
+
+	// The metrics are exposed in the Prometheus text format
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	for _, name := range names {
+		g := gauges[name]
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %g\n", name, g.help, name, name, g.value())
+	}
+	if s.options.Exporter != nil {
+		if err := s.options.Exporter.WriteMetrics(w); err != nil {
+			logger.Warningf("Failed to write metrics: %s", err)
+		}
+	}
+}
+
+// LogSpec is the body of the /logspec requests and responses
+type LogSpec struct {
+	Spec string `json:"spec"`
+}
+
+// handleLogSpec serves the logging specification, only changed by the clients
+// authenticated with TLS client certificates
+func (s *System) handleLogSpec(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, &LogSpec{Spec: flogging.GetLoggingSpec()})
+	case http.MethodPut:
+		if !(s.options.TLS.Enabled && s.options.TLS.ClientAuthRequired) {
+			writeError(w, http.StatusForbidden, fmt.Errorf("Changing the logging specification requires TLS with client authentication"))
+			return
+		}
+		spec := &LogSpec{}
+		if err := json.NewDecoder(r.Body).Decode(spec); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("Invalid request body: %s", err))
+			return
+		}
+		err := flogging.SetLoggingSpec(spec.Spec)
+		audit.Record(requestIdentity(r), "operations.logspec", spec.Spec, err)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("Invalid request method: %s", r.Method))
+	}
+}
+
+// profileHandler records the requests of a profile of the peer to the audit
+// log before serving them with handler
+func profileHandler(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		audit.Record(requestIdentity(r), "operations.profile", r.URL.RequestURI(), nil)
+		handler(w, r)
+	}
+}
+
+// requestIdentity returns the identity recorded to the audit log for the
+// client of an operations request, authenticated by its TLS client
+// certificate or else known by its address
+func requestIdentity(r *http.Request) string {
+	if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+		return audit.Identity(r.TLS.PeerCertificates[0].Raw)
+	}
+	return "anonymous@" + r.RemoteAddr
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, &errorResponse{Error: err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.Warningf("Failed to write operations response: %s", err)
+	}
+}
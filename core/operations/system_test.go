@@ -0,0 +1,341 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operations
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/flogging"
+	"github.com/op/go-logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func startSystem(t *testing.T, options Options) *System {
+	options.ListenAddress = "127.0.0.1:0"
+	system := NewSystem(options)
+	if err := system.Start(); err != nil {
+		t.Fatalf("Failed to start operations system: %s", err)
+	}
+	return system
+}
+
+func TestHealth(t *testing.T) {
+	system := startSystem(t, Options{})
+	defer system.Stop()
+
+	ledgerErr := error(nil)
+	system.RegisterChecker("ledger", func() error { return ledgerErr })
+	system.RegisterChecker("gossip", func() error { return nil })
+
+	resp, err := http.Get("http://" + system.Addr() + "/healthz")
+	assert.NoError(t, err)
+	status := &HealthStatus{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(status))
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, &HealthStatus{Status: "OK"}, status)
+
+	ledgerErr = errors.New("ledger unavailable")
+	resp, err = http.Get("http://" + system.Addr() + "/healthz")
+	assert.NoError(t, err)
+	status = &HealthStatus{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(status))
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, map[string]string{"ledger": "ledger unavailable"}, status.FailedChecks)
+}
+
+func TestSubsystemHealth(t *testing.T) {
+	system := startSystem(t, Options{CheckTimeout: 50 * time.Millisecond})
+	defer system.Stop()
+
+	release := make(chan struct{})
+	defer close(release)
+	RegisterChecker("couchdb", func() error { return errors.New("couchdb unreachable") })
+	RegisterChecker("orderer", func() error {
+		<-release
+		return nil
+	})
+	defer func() {
+		checkers.Lock()
+		delete(checkers.m, "couchdb")
+		delete(checkers.m, "orderer")
+		checkers.Unlock()
+	}()
+	system.RegisterChecker("ledger", func() error { return nil })
+
+	resp, err := http.Get("http://" + system.Addr() + "/healthz")
+	assert.NoError(t, err)
+	status := &HealthStatus{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(status))
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, map[string]string{
+		"couchdb": "couchdb unreachable",
+		"orderer": "check timed out after 50ms",
+	}, status.FailedChecks)
+}
+
+func TestMetrics(t *testing.T) {
+	system := startSystem(t, Options{})
+	defer system.Stop()
+
+	system.RegisterGauge("ledger_height", "Height of the ledger", func() float64 { return 12 })
+
+	resp, err := http.Get("http://" + system.Addr() + "/metrics")
+	assert.NoError(t, err)
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, "# HELP ledger_height Height of the ledger\n# TYPE ledger_height gauge\nledger_height 12\n", string(body))
+}
+
+type testExporter struct{}
+
+func (testExporter) WriteMetrics(w io.Writer) error {
+	_, err := io.WriteString(w, "# HELP proposals Proposals\n# TYPE proposals counter\nproposals 3\n")
+	return err
+}
+
+func TestMetricsExporter(t *testing.T) {
+	system := startSystem(t, Options{Exporter: testExporter{}})
+	defer system.Stop()
+
+	system.RegisterGauge("ledger_height", "Height of the ledger", func() float64 { return 12 })
+
+	resp, err := http.Get("http://" + system.Addr() + "/metrics")
+	assert.NoError(t, err)
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, "# HELP ledger_height Height of the ledger\n# TYPE ledger_height gauge\nledger_height 12\n"+
+		"# HELP proposals Proposals\n# TYPE proposals counter\nproposals 3\n", string(body))
+}
+
+func TestLogSpec(t *testing.T) {
+	dir, err := ioutil.TempDir("", "operations")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	ca := issueCert(t, dir, "ca", nil)
+	server := issueCert(t, dir, "server", ca)
+	client := issueCert(t, dir, "client", ca)
+
+	system := startSystem(t, Options{TLS: TLSOptions{
+		Enabled:            true,
+		CertFile:           server.certFile,
+		KeyFile:            server.keyFile,
+		ClientAuthRequired: true,
+		ClientRootCAFiles:  []string{ca.certFile},
+	}})
+	defer system.Stop()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	pair, err := tls.LoadX509KeyPair(client.certFile, client.keyFile)
+	assert.NoError(t, err)
+	c := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: []tls.Certificate{pair}}}}
+
+	put := func(c *http.Client, url string, body string) int {
+		req, err := http.NewRequest(http.MethodPut, url, bytes.NewBufferString(body))
+		assert.NoError(t, err)
+		resp, err := c.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	url := "https://" + system.Addr() + "/logspec"
+
+	assert.Equal(t, http.StatusNoContent, put(c, url, `{"spec": "operations=debug:info"}`))
+	assert.Equal(t, logging.DEBUG, logging.GetLevel("operations"))
+	assert.Equal(t, http.StatusBadRequest, put(c, url, `{"spec": "operations=loud"}`))
+	assert.Equal(t, http.StatusBadRequest, put(c, url, `not json`))
+
+	// Not changed without client authentication
+	plain := startSystem(t, Options{})
+	defer plain.Stop()
+	assert.Equal(t, http.StatusForbidden, put(http.DefaultClient, "http://"+plain.Addr()+"/logspec", `{"spec": "operations=info"}`))
+
+	resp, err := http.Get("http://" + plain.Addr() + "/logspec")
+	assert.NoError(t, err)
+	spec := &LogSpec{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(spec))
+	resp.Body.Close()
+	assert.Equal(t, "operations=debug:info", spec.Spec)
+	assert.Equal(t, flogging.GetLoggingSpec(), spec.Spec)
+}
+
+type testCert struct {
+	cert     *x509.Certificate
+	key      *ecdsa.PrivateKey
+	certFile string
+	keyFile  string
+}
+
+func issueCert(t *testing.T, dir string, name string, issuer *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %s", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	parent, signer := template, key
+	if issuer == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		parent, signer = issuer.cert, issuer.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %s", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+
+	tc := &testCert{cert: cert, key: key,
+		certFile: filepath.Join(dir, name+".pem"), keyFile: filepath.Join(dir, name+".key")}
+	ioutil.WriteFile(tc.certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	ioutil.WriteFile(tc.keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+	return tc
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "operations")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ca := issueCert(t, dir, "ca", nil)
+	server := issueCert(t, dir, "server", ca)
+	client := issueCert(t, dir, "client", ca)
+	otherCA := issueCert(t, dir, "otherca", nil)
+	stranger := issueCert(t, dir, "stranger", otherCA)
+
+	system := startSystem(t, Options{TLS: TLSOptions{
+		Enabled:            true,
+		CertFile:           server.certFile,
+		KeyFile:            server.keyFile,
+		ClientAuthRequired: true,
+		ClientRootCAFiles:  []string{ca.certFile},
+	}})
+	defer system.Stop()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	get := func(clientCert *testCert) (*http.Response, error) {
+		config := &tls.Config{RootCAs: roots}
+		if clientCert != nil {
+			pair, err := tls.LoadX509KeyPair(clientCert.certFile, clientCert.keyFile)
+			assert.NoError(t, err)
+			config.Certificates = []tls.Certificate{pair}
+		}
+		c := &http.Client{Transport: &http.Transport{TLSClientConfig: config}}
+		return c.Get("https://" + system.Addr() + "/healthz")
+	}
+
+	resp, err := get(client)
+	assert.NoError(t, err)
+	if resp != nil {
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		resp.Body.Close()
+	}
+
+	_, err = get(nil)
+	assert.Error(t, err, "Clients without a certificate should be rejected")
+	_, err = get(stranger)
+	assert.Error(t, err, "Clients with a certificate of another CA should be rejected")
+}
+
+func TestInvalidTLSOptions(t *testing.T) {
+	system := NewSystem(Options{ListenAddress: "127.0.0.1:0", TLS: TLSOptions{Enabled: true, ClientAuthRequired: true}})
+	assert.Error(t, system.Start())
+	assert.Equal(t, "", system.Addr())
+}
+
+func TestProfile(t *testing.T) {
+	// Profiling is refused without TLS client authentication
+	system := NewSystem(Options{ListenAddress: "127.0.0.1:0", Profile: true})
+	assert.Error(t, system.Start())
+
+	dir, err := ioutil.TempDir("", "operations")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	ca := issueCert(t, dir, "ca", nil)
+	server := issueCert(t, dir, "server", ca)
+	client := issueCert(t, dir, "client", ca)
+
+	system = startSystem(t, Options{Profile: true, TLS: TLSOptions{
+		Enabled:            true,
+		CertFile:           server.certFile,
+		KeyFile:            server.keyFile,
+		ClientAuthRequired: true,
+		ClientRootCAFiles:  []string{ca.certFile},
+	}})
+	defer system.Stop()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	pair, err := tls.LoadX509KeyPair(client.certFile, client.keyFile)
+	assert.NoError(t, err)
+	c := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: []tls.Certificate{pair}}}}
+	for _, path := range []string{"/debug/pprof/", "/debug/pprof/goroutine?debug=1", "/debug/pprof/cmdline"} {
+		resp, err := c.Get("https://" + system.Addr() + path)
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusOK, resp.StatusCode, path)
+			resp.Body.Close()
+		}
+	}
+
+	// Not served unless enabled
+	plain := startSystem(t, Options{})
+	defer plain.Stop()
+	resp, err := http.Get("http://" + plain.Addr() + "/debug/pprof/")
+	if assert.NoError(t, err) {
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		resp.Body.Close()
+	}
+}
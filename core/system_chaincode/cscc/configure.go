@@ -0,0 +1,439 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cscc
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/core/audit"
+	"github.com/hyperledger/fabric/core/chaincode/acl"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/core/ledger/kvledger"
+	"github.com/hyperledger/fabric/gossip/service"
+	"github.com/hyperledger/fabric/orderer/common/blocksig"
+	pb "github.com/hyperledger/fabric/protos"
+	cb "github.com/hyperledger/fabric/protos/common"
+	ab "github.com/hyperledger/fabric/protos/orderer"
+	putils "github.com/hyperledger/fabric/protos/utils"
+	"github.com/op/go-logging"
+	"github.com/spf13/viper"
+)
+
+// PeerConfiger implements the configuration functions of the peer:
+// - JoinChain joins the peer to the chain of a genesis block
+// - GetChannels returns the chains the peer has joined, as a
+//   ChannelQueryResponse
+// - GetConfigBlock returns the latest configuration block recorded for a
+//   chain, the genesis block the peer joined the chain with until the
+//   committer records a later one
+// The functions are permitted to the identities listed in "peer.admins", to
+// no one when the list is empty. The genesis blocks must be signed by one of
+// the orderers listed in "peer.committer.ledger.ordererCerts". The
+// configuration blocks are kept under "peer.fileSystemPath"/chains.
+type PeerConfiger struct {
+}
+
+var cscclogger = logging.MustGetLogger("cscc")
+
+// These are function names from Invoke first parameter
+const (
+	JoinChain      string = "JoinChain"
+	GetChannels    string = "GetChannels"
+	GetConfigBlock string = "GetConfigBlock"
+)
+
+// configBlockFile is the name of the file holding the configuration block of
+// a chain in the directory of the chain
+const configBlockFile = "config.block"
+
+// genesisBlockFile is the name of the file holding the genesis block a chain
+// was joined with in the directory of the chain
+const genesisBlockFile = "genesis.block"
+
+// validChainName matches the chain names usable as ledger names
+var validChainName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+// joinListeners are called with the name of every chain the peer joins
+var joinListeners struct {
+	sync.Mutex
+	list []func(chainID string)
+}
+
+// OnJoin registers a function called with the name of every chain the peer
+// joins from then on, once its ledger is created and its genesis block
+// recorded
+func OnJoin(listener func(chainID string)) {
+	joinListeners.Lock()
+	defer joinListeners.Unlock()
+	joinListeners.list = append(joinListeners.list, listener)
+}
+
+func notifyJoined(chainID string) {
+	joinListeners.Lock()
+	listeners := append([]func(string){}, joinListeners.list...)
+	joinListeners.Unlock()
+	for _, listener := range listeners {
+		listener(chainID)
+	}
+}
+
+// Init is called once per chain when the chain is created.
+// This allows the chaincode to initialize any variables on the ledger prior
+// to any transaction execution on the chain.
+func (e *PeerConfiger) Init(stub shim.ChaincodeStubInterface) ([]byte, error) {
+	cscclogger.Info("Init CSCC")
+
+	return nil, nil
+}
+
+// Invoke is called with args[0] contains the function name. JoinChain takes
+// the marshalled genesis block in args[1] and GetConfigBlock the chain name
+func (e *PeerConfiger) Invoke(stub shim.ChaincodeStubInterface) ([]byte, error) {
+	args := stub.GetArgs()
+
+	if len(args) < 1 {
+		return nil, fmt.Errorf("Incorrect number of arguments, %d", len(args))
+	}
+	fname := string(args[0])
+
+	if fname != GetChannels && len(args) < 2 {
+		return nil, fmt.Errorf("missing 2nd argument for %s", fname)
+	}
+
+	cscclogger.Debugf("Invoke function: %s", fname)
+
+	if err := checkAdmin(stub, fname); err != nil {
+		return nil, err
+	}
+
+	switch fname {
+	case JoinChain:
+		chainID, err := joinChain(args[1])
+		creator, _ := stub.GetCreator()
+		audit.Record(audit.Identity(creator), "channel.join", chainID, err)
+		return nil, err
+	case GetChannels:
+		return getChannels()
+	case GetConfigBlock:
+		return getConfigBlock(string(args[1]))
+	}
+
+	return nil, fmt.Errorf("Requested function %s not found.", fname)
+}
+
+// checkAdmin checks that the creator of the proposal is an administrator of
+// the peer
+func checkAdmin(stub shim.ChaincodeStubInterface, fname string) error {
+	admins := viper.GetStringSlice("peer.admins")
+	if len(admins) == 0 {
+		return fmt.Errorf("no administrator listed in peer.admins, %s is not permitted", fname)
+	}
+
+	creator, err := stub.GetCreator()
+	if err != nil {
+		return err
+	}
+	if acl.IsListed(admins, "peer.admins", creator) {
+		return nil
+	}
+	return fmt.Errorf("creator is not permitted to call %s", fname)
+}
+
+// VerifyBlock checks that a block of the ordering service is signed by one
+// of the orderers whose certificates are listed in
+// "peer.committer.ledger.ordererCerts", refusing every block when none is
+func VerifyBlock(block *cb.Block) error {
+	files := viper.GetStringSlice("peer.committer.ledger.ordererCerts")
+	if len(files) == 0 {
+		return fmt.Errorf("no orderer certificate listed in peer.committer.ledger.ordererCerts")
+	}
+	var certs [][]byte
+	for _, file := range files {
+		cert, err := ioutil.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("could not read orderer certificate %s: %s", file, err)
+		}
+		certs = append(certs, cert)
+	}
+	return blocksig.Verify(block, certs)
+}
+
+// chainsDir returns the directory holding the configuration of the chains
+func chainsDir() string {
+	return filepath.Join(viper.GetString("peer.fileSystemPath"), "chains")
+}
+
+// getChainID returns the name of the chain of a genesis block, whose only
+// data is the configuration envelope of the chain
+func getChainID(block *cb.Block) (string, error) {
+	config, err := getGenesisConfig(block)
+	if err != nil {
+		return "", err
+	}
+	return string(config.ChainID), nil
+}
+
+// getGenesisConfig returns the configuration envelope of a genesis block
+func getGenesisConfig(block *cb.Block) (*ab.ConfigurationEnvelope, error) {
+	if block.Header == nil || block.Header.Number != 0 {
+		return nil, fmt.Errorf("not a genesis block")
+	}
+	if block.Data == nil || len(block.Data.Data) != 1 {
+		return nil, fmt.Errorf("genesis block without a single configuration envelope")
+	}
+
+	config := &ab.ConfigurationEnvelope{}
+	if err := proto.Unmarshal(block.Data.Data[0], config); err != nil {
+		return nil, fmt.Errorf("invalid configuration envelope in genesis block: %s", err)
+	}
+	if len(config.Items) == 0 {
+		return nil, fmt.Errorf("no configuration item in genesis block")
+	}
+	if !validChainName.Match(config.ChainID) {
+		return nil, fmt.Errorf("invalid chain name %q in genesis block", string(config.ChainID))
+	}
+	return config, nil
+}
+
+// joinChain creates the ledger of the chain of the genesis block and records
+// the block as the configuration block of the chain. It returns the name of
+// the chain, once known
+func joinChain(b []byte) (string, error) {
+	block := &cb.Block{}
+	if err := proto.Unmarshal(b, block); err != nil {
+		return "", fmt.Errorf("invalid genesis block: %s", err)
+	}
+	if err := VerifyBlock(block); err != nil {
+		return "", fmt.Errorf("invalid genesis block: %s", err)
+	}
+	chainID, err := getChainID(block)
+	if err != nil {
+		return "", err
+	}
+
+	dir := filepath.Join(chainsDir(), chainID)
+	if _, err = os.Stat(filepath.Join(dir, configBlockFile)); err == nil {
+		return chainID, fmt.Errorf("peer already joined chain %s", chainID)
+	}
+
+	kvledger.GetLedger(chainID)
+
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return chainID, fmt.Errorf("could not create the directory of chain %s: %s", chainID, err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, genesisBlockFile), b, 0644); err != nil {
+		return chainID, fmt.Errorf("could not record the genesis block of chain %s: %s", chainID, err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, configBlockFile), b, 0644); err != nil {
+		return chainID, fmt.Errorf("could not record the configuration block of chain %s: %s", chainID, err)
+	}
+
+	if g := service.GetGossipService(); g != nil {
+		g.JoinChain([]byte(chainID))
+	}
+	notifyJoined(chainID)
+
+	cscclogger.Infof("Joined chain %s", chainID)
+	return chainID, nil
+}
+
+// RecordConfigBlock records a configuration block committed on a chain the
+// peer joined as the configuration block of the chain, unless the recorded
+// configuration is as recent
+func RecordConfigBlock(block *cb.Block) error {
+	config := putils.GetConfigurationEnvelope(block)
+	if config == nil {
+		return fmt.Errorf("not a configuration block")
+	}
+	chainID := string(config.ChainID)
+	if !validChainName.MatchString(chainID) {
+		return fmt.Errorf("invalid chain name %q in configuration block", chainID)
+	}
+
+	recorded, err := readConfig(chainID)
+	if err != nil {
+		return err
+	}
+	if recorded == nil {
+		return fmt.Errorf("peer has not joined chain %s", chainID)
+	}
+	if block.Header.Number == 0 || config.Sequence <= recorded.Sequence {
+		return nil
+	}
+
+	b, err := proto.Marshal(block)
+	if err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(filepath.Join(chainsDir(), chainID, configBlockFile), b, 0644); err != nil {
+		return fmt.Errorf("could not record the configuration block of chain %s: %s", chainID, err)
+	}
+	cscclogger.Infof("Recorded configuration %d of chain %s", config.Sequence, chainID)
+	return nil
+}
+
+// readConfig returns the configuration recorded for a chain, or nil if the
+// peer has not joined the chain
+func readConfig(chainID string) (*ab.ConfigurationEnvelope, error) {
+	b, err := ioutil.ReadFile(filepath.Join(chainsDir(), chainID, configBlockFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	block := &cb.Block{}
+	if err = proto.Unmarshal(b, block); err != nil {
+		return nil, fmt.Errorf("invalid configuration block of chain %s: %s", chainID, err)
+	}
+	if block.Header != nil && block.Header.Number == 0 {
+		config, err := getGenesisConfig(block)
+		if err != nil {
+			return nil, fmt.Errorf("invalid configuration block of chain %s: %s", chainID, err)
+		}
+		return config, nil
+	}
+	config := putils.GetConfigurationEnvelope(block)
+	if config == nil {
+		return nil, fmt.Errorf("invalid configuration block of chain %s", chainID)
+	}
+	return config, nil
+}
+
+// GetGenesisBlock returns the genesis block a chain was joined with, or nil if
+// the peer has not joined the chain
+func GetGenesisBlock(chainID string) (*cb.Block, error) {
+	if !validChainName.MatchString(chainID) {
+		return nil, fmt.Errorf("invalid chain name %q", chainID)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(chainsDir(), chainID, genesisBlockFile))
+	if os.IsNotExist(err) {
+		//chains joined before the genesis block was recorded apart
+		b, err = ioutil.ReadFile(filepath.Join(chainsDir(), chainID, configBlockFile))
+	}
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	block := &cb.Block{}
+	if err = proto.Unmarshal(b, block); err != nil {
+		return nil, fmt.Errorf("invalid genesis block of chain %s: %s", chainID, err)
+	}
+	if block.Header == nil || block.Header.Number != 0 {
+		return nil, fmt.Errorf("genesis block of chain %s not recorded", chainID)
+	}
+	return block, nil
+}
+
+// GetChainConfig returns the latest configuration recorded for a chain, or nil
+// if the peer has not joined the chain
+func GetChainConfig(chainID string) (*ab.ConfigurationEnvelope, error) {
+	if !validChainName.MatchString(chainID) {
+		return nil, fmt.Errorf("invalid chain name %q", chainID)
+	}
+	return readConfig(chainID)
+}
+
+// ChainOrganizations returns the names of the organizations declared in the
+// latest configuration recorded for the chain, those declaring anchor peers.
+// It returns nil if the peer has no configuration of the chain, as for the
+// default chain, of which all the configured organizations are members
+func ChainOrganizations(chainID string) (map[string]bool, error) {
+	config, err := GetChainConfig(chainID)
+	if err != nil || config == nil {
+		return nil, err
+	}
+	anchorPeers, err := putils.GetAnchorPeers(config)
+	if err != nil {
+		return nil, err
+	}
+	orgs := make(map[string]bool)
+	for org := range anchorPeers {
+		orgs[org] = true
+	}
+	return orgs, nil
+}
+
+// JoinedChains returns the configuration recorded for the chains the peer
+// joined, keyed by chain name
+func JoinedChains() (map[string]*ab.ConfigurationEnvelope, error) {
+	fileInfos, err := ioutil.ReadDir(chainsDir())
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	chains := make(map[string]*ab.ConfigurationEnvelope)
+	for _, fileInfo := range fileInfos {
+		config, err := readConfig(fileInfo.Name())
+		if err != nil {
+			return nil, err
+		}
+		if config != nil {
+			chains[fileInfo.Name()] = config
+		}
+	}
+	return chains, nil
+}
+
+// Channels returns the chains the peer has joined, those with a recorded
+// configuration block
+func Channels() (*pb.ChannelQueryResponse, error) {
+	fileInfos, err := ioutil.ReadDir(chainsDir())
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	resp := &pb.ChannelQueryResponse{}
+	for _, fileInfo := range fileInfos {
+		if _, err = os.Stat(filepath.Join(chainsDir(), fileInfo.Name(), configBlockFile)); err == nil {
+			resp.Channels = append(resp.Channels, &pb.ChannelInfo{ChainID: fileInfo.Name()})
+		}
+	}
+	return resp, nil
+}
+
+// getChannels returns the marshalled Channels
+func getChannels() ([]byte, error) {
+	resp, err := Channels()
+	if err != nil {
+		return nil, err
+	}
+	return proto.Marshal(resp)
+}
+
+// getConfigBlock returns the configuration block recorded for the chain
+func getConfigBlock(chainID string) ([]byte, error) {
+	if !validChainName.MatchString(chainID) {
+		return nil, fmt.Errorf("invalid chain name %q", chainID)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(chainsDir(), chainID, configBlockFile))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("peer has not joined chain %s", chainID)
+	}
+	return b, err
+}
+
+// Query is no longer implemented. Will be removed
+func (e *PeerConfiger) Query(stub shim.ChaincodeStubInterface) ([]byte, error) {
+	return nil, nil
+}
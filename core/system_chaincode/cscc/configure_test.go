@@ -0,0 +1,277 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cscc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/core/ledger/kvledger"
+	"github.com/hyperledger/fabric/core/ledger/testutil"
+	"github.com/hyperledger/fabric/orderer/common/blocksig"
+	pb "github.com/hyperledger/fabric/protos"
+	cb "github.com/hyperledger/fabric/protos/common"
+	ab "github.com/hyperledger/fabric/protos/orderer"
+	"github.com/spf13/viper"
+)
+
+// ordererSigner signs the genesis blocks of the tests, as the orderer whose
+// certificate setupPeerFileSystem lists
+var ordererSigner *blocksig.Signer
+
+// setupPeerFileSystem sets up the file system of the peer, with "admin" as
+// administrator and the certificate of ordererSigner as orderer certificate
+func setupPeerFileSystem(t *testing.T) func() {
+	path, err := ioutil.TempDir("", "cscctest")
+	if err != nil {
+		t.Fatalf("Error creating temp dir: %s", err)
+	}
+	kvledger.Initialize(path)
+	viper.Set("peer.fileSystemPath", path)
+
+	admin := filepath.Join(path, "admin.pem")
+	ioutil.WriteFile(admin, []byte("admin\n"), 0644)
+	viper.Set("peer.admins", []string{admin})
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Error generating orderer key: %s", err)
+	}
+	template := &x509.Certificate{SerialNumber: big.NewInt(1), NotBefore: time.Now(), NotAfter: time.Now().Add(time.Hour)}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Error creating orderer certificate: %s", err)
+	}
+	raw, _ := x509.MarshalECPrivateKey(key)
+	keyFile := filepath.Join(path, "orderer.key")
+	certFile := filepath.Join(path, "orderer.pem")
+	ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: raw}), 0600)
+	ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644)
+	if ordererSigner, err = blocksig.NewSigner(keyFile); err != nil {
+		t.Fatalf("Error loading orderer key: %s", err)
+	}
+	viper.Set("peer.committer.ledger.ordererCerts", []string{certFile})
+
+	return func() {
+		viper.Set("peer.fileSystemPath", nil)
+		viper.Set("peer.admins", nil)
+		viper.Set("peer.committer.ledger.ordererCerts", nil)
+		os.RemoveAll(path)
+	}
+}
+
+// newAdminStub returns a mock stub invoking cscc as the administrator
+func newAdminStub() *shim.MockStub {
+	stub := shim.NewMockStub("PeerConfiger", new(PeerConfiger))
+	stub.Creator = []byte("admin")
+	return stub
+}
+
+func genesisBlock(t *testing.T, chainID string) []byte {
+	config, err := proto.Marshal(&ab.ConfigurationEnvelope{
+		ChainID: []byte(chainID),
+		Items:   []*ab.SignedConfigurationItem{{ConfigurationItem: []byte("item")}},
+	})
+	if err != nil {
+		t.Fatalf("Error marshalling configuration envelope: %s", err)
+	}
+	data := &cb.BlockData{Data: [][]byte{config}}
+	block, err := ordererSigner.Sign(&cb.Block{Header: &cb.BlockHeader{Number: 0, DataHash: data.Hash()}, Data: data})
+	if err != nil {
+		t.Fatalf("Error signing genesis block: %s", err)
+	}
+	b, err := proto.Marshal(block)
+	if err != nil {
+		t.Fatalf("Error marshalling genesis block: %s", err)
+	}
+	return b
+}
+
+func TestJoinChain(t *testing.T) {
+	defer setupPeerFileSystem(t)()
+
+	stub := newAdminStub()
+	var joined []string
+	OnJoin(func(chainID string) { joined = append(joined, chainID) })
+
+	block := genesisBlock(t, "mychain")
+	if _, err := stub.MockInvoke("1", [][]byte{[]byte(JoinChain), block}); err != nil {
+		t.Fatalf("cscc JoinChain failed: %s", err)
+	}
+	if _, err := stub.MockInvoke("1", [][]byte{[]byte(JoinChain), block}); err == nil {
+		t.Fatalf("cscc JoinChain should have failed for a chain already joined")
+	}
+	testutil.AssertEquals(t, joined, []string{"mychain"})
+
+	res, err := stub.MockInvoke("1", [][]byte{[]byte(GetChannels)})
+	if err != nil {
+		t.Fatalf("cscc GetChannels failed: %s", err)
+	}
+	channels := &pb.ChannelQueryResponse{}
+	proto.Unmarshal(res, channels)
+	testutil.AssertEquals(t, len(channels.Channels), 1)
+	testutil.AssertEquals(t, channels.Channels[0].ChainID, "mychain")
+
+	res, err = stub.MockInvoke("1", [][]byte{[]byte(GetConfigBlock), []byte("mychain")})
+	if err != nil {
+		t.Fatalf("cscc GetConfigBlock failed: %s", err)
+	}
+	testutil.AssertEquals(t, res, block)
+
+	chains, err := JoinedChains()
+	if err != nil {
+		t.Fatalf("JoinedChains failed: %s", err)
+	}
+	testutil.AssertEquals(t, len(chains), 1)
+	testutil.AssertEquals(t, string(chains["mychain"].ChainID), "mychain")
+
+	if _, err = stub.MockInvoke("1", [][]byte{[]byte(GetConfigBlock), []byte("otherchain")}); err == nil {
+		t.Fatalf("cscc GetConfigBlock should have failed for a chain not joined")
+	}
+	if _, err = stub.MockInvoke("1", [][]byte{[]byte(GetConfigBlock), []byte("../mychain")}); err == nil {
+		t.Fatalf("cscc GetConfigBlock should have failed for an invalid chain name")
+	}
+}
+
+func TestJoinChainInvalidBlock(t *testing.T) {
+	defer setupPeerFileSystem(t)()
+
+	stub := newAdminStub()
+
+	notGenesis := &cb.Block{}
+	proto.Unmarshal(genesisBlock(t, "mychain"), notGenesis)
+	notGenesis.Header.Number = 1
+	b, _ := proto.Marshal(notGenesis)
+
+	unsigned := &cb.Block{}
+	proto.Unmarshal(genesisBlock(t, "mychain"), unsigned)
+	unsigned.Metadata = nil
+	u, _ := proto.Marshal(unsigned)
+
+	for _, block := range [][]byte{[]byte("block"), genesisBlock(t, "../mychain"), b, u} {
+		if _, err := stub.MockInvoke("1", [][]byte{[]byte(JoinChain), block}); err == nil {
+			t.Fatalf("cscc JoinChain should have failed with an invalid genesis block")
+		}
+	}
+	if _, err := stub.MockInvoke("1", [][]byte{[]byte(JoinChain)}); err == nil {
+		t.Fatalf("cscc JoinChain should have failed with a missing argument")
+	}
+}
+
+func TestConfigAdminACL(t *testing.T) {
+	defer setupPeerFileSystem(t)()
+
+	stub := newAdminStub()
+	stub.Creator = []byte("other")
+	if _, err := stub.MockInvoke("1", [][]byte{[]byte(JoinChain), genesisBlock(t, "mychain")}); err == nil {
+		t.Fatalf("cscc JoinChain should have failed for a creator that is not an admin")
+	}
+
+	stub.Creator = []byte("admin")
+	if _, err := stub.MockInvoke("1", [][]byte{[]byte(JoinChain), genesisBlock(t, "mychain")}); err != nil {
+		t.Fatalf("cscc JoinChain failed for an admin: %s", err)
+	}
+
+	// no one is permitted when no administrator is listed
+	viper.Set("peer.admins", nil)
+	if _, err := stub.MockInvoke("1", [][]byte{[]byte(GetChannels)}); err == nil {
+		t.Fatalf("cscc GetChannels should have failed without administrators")
+	}
+}
+
+func configBlock(t *testing.T, chainID string, number, sequence uint64) *cb.Block {
+	config, err := proto.Marshal(&ab.ConfigurationEnvelope{
+		ChainID:  []byte(chainID),
+		Sequence: sequence,
+		Items:    []*ab.SignedConfigurationItem{{ConfigurationItem: []byte("item")}},
+	})
+	if err != nil {
+		t.Fatalf("Error marshalling configuration envelope: %s", err)
+	}
+	payload, err := proto.Marshal(&cb.Payload{
+		Header: &cb.Header{ChainHeader: &cb.ChainHeader{Type: int32(cb.HeaderType_CONFIGURATION_TRANSACTION), ChainID: []byte(chainID)}},
+		Data:   config,
+	})
+	if err != nil {
+		t.Fatalf("Error marshalling configuration transaction: %s", err)
+	}
+	env, err := proto.Marshal(&cb.Envelope{Payload: payload})
+	if err != nil {
+		t.Fatalf("Error marshalling configuration transaction: %s", err)
+	}
+	return &cb.Block{Header: &cb.BlockHeader{Number: number}, Data: &cb.BlockData{Data: [][]byte{env}}}
+}
+
+func TestRecordConfigBlock(t *testing.T) {
+	defer setupPeerFileSystem(t)()
+
+	if err := RecordConfigBlock(configBlock(t, "mychain", 3, 1)); err == nil {
+		t.Fatalf("RecordConfigBlock should have failed for a chain not joined")
+	}
+
+	stub := newAdminStub()
+	if _, err := stub.MockInvoke("1", [][]byte{[]byte(JoinChain), genesisBlock(t, "mychain")}); err != nil {
+		t.Fatalf("cscc JoinChain failed: %s", err)
+	}
+	if err := RecordConfigBlock(&cb.Block{Header: &cb.BlockHeader{Number: 3}, Data: &cb.BlockData{Data: [][]byte{[]byte("tx")}}}); err == nil {
+		t.Fatalf("RecordConfigBlock should have failed for a block without configuration")
+	}
+
+	block := configBlock(t, "mychain", 3, 1)
+	if err := RecordConfigBlock(block); err != nil {
+		t.Fatalf("RecordConfigBlock failed: %s", err)
+	}
+	// an older configuration doesn't replace the recorded one
+	if err := RecordConfigBlock(configBlock(t, "mychain", 2, 1)); err != nil {
+		t.Fatalf("RecordConfigBlock failed: %s", err)
+	}
+
+	res, err := stub.MockInvoke("1", [][]byte{[]byte(GetConfigBlock), []byte("mychain")})
+	if err != nil {
+		t.Fatalf("cscc GetConfigBlock failed: %s", err)
+	}
+	recorded := &cb.Block{}
+	proto.Unmarshal(res, recorded)
+	testutil.AssertEquals(t, recorded.Header.Number, uint64(3))
+
+	chains, err := JoinedChains()
+	if err != nil {
+		t.Fatalf("JoinedChains failed: %s", err)
+	}
+	testutil.AssertEquals(t, chains["mychain"].Sequence, uint64(1))
+
+	// the genesis block is kept once a later configuration is recorded
+	genesis, err := GetGenesisBlock("mychain")
+	if err != nil || genesis == nil {
+		t.Fatalf("GetGenesisBlock failed: %v", err)
+	}
+	testutil.AssertEquals(t, genesis.Header.Number, uint64(0))
+	if genesis, err = GetGenesisBlock("otherchain"); err != nil || genesis != nil {
+		t.Fatalf("GetGenesisBlock should have returned no block for a chain not joined, got %v (%v)", genesis, err)
+	}
+}
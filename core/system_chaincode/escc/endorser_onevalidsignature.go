@@ -21,6 +21,7 @@ import (
 
 	"fmt"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 	"github.com/hyperledger/fabric/protos"
 	"github.com/hyperledger/fabric/protos/utils"
@@ -46,13 +47,15 @@ func (e *EndorserOneValidSignature) Init(stub shim.ChaincodeStubInterface) ([]by
 // policy specification to be coded as a transaction of the chaincode and Client
 // could select which policy to use for endorsement using parameter
 // @return a marshalled proposal response
-// Note that Peer calls this function with 4 mandatory arguments (and 2 optional ones):
+// Note that Peer calls this function with 4 mandatory arguments (and 4 optional ones):
 // args[0] - function name (not used now)
 // args[1] - serialized Header object
 // args[2] - serialized ChaincodeProposalPayload object
 // args[3] - binary blob of simulation results
 // args[4] - serialized events (optional)
 // args[5] - payloadVisibility (optional)
+// args[6] - serialized Response2 of the chaincode (optional)
+// args[7] - serialized PrivateDataHashes of the private data disseminated (optional)
 //
 // NOTE: this chaincode is meant to sign another chaincode's simulation
 // results. It should not manipulate state as any state change will be
@@ -63,8 +66,8 @@ func (e *EndorserOneValidSignature) Invoke(stub shim.ChaincodeStubInterface) ([]
 	args := stub.GetArgs()
 	if len(args) < 4 {
 		return nil, fmt.Errorf("Incorrect number of arguments (expected a minimum of 4, provided %d)", len(args))
-	} else if len(args) > 6 {
-		return nil, fmt.Errorf("Incorrect number of arguments (expected a maximum of 6, provided %d)", len(args))
+	} else if len(args) > 8 {
+		return nil, fmt.Errorf("Incorrect number of arguments (expected a maximum of 8, provided %d)", len(args))
 	}
 
 	logger.Infof("ESCC starts: %d args", len(args))
@@ -111,6 +114,29 @@ func (e *EndorserOneValidSignature) Invoke(stub shim.ChaincodeStubInterface) ([]
 		}
 	}
 
+	// Handle the response of the chaincode (it's an optional argument), a
+	// successful one being endorsed along with the simulation results
+	response := &protos.Response2{Status: shim.OK, Message: "OK"}
+	if len(args) > 6 && args[6] != nil {
+		response = &protos.Response2{}
+		if err := proto.Unmarshal(args[6], response); err != nil {
+			return nil, fmt.Errorf("Could not unmarshal the chaincode response: err %s", err)
+		}
+		if response.Status >= shim.ERRORTHRESHOLD {
+			return nil, fmt.Errorf("Cannot endorse a failed chaincode response with status %d", response.Status)
+		}
+	}
+
+	// Handle the hashes of the private data (it's an optional argument), the
+	// private data itself being disseminated by the peer
+	var privateData *protos.PrivateDataHashes
+	if len(args) > 7 && args[7] != nil {
+		privateData = &protos.PrivateDataHashes{}
+		if err := proto.Unmarshal(args[7], privateData); err != nil {
+			return nil, fmt.Errorf("Could not unmarshal the private data hashes: err %s", err)
+		}
+	}
+
 	// obtain the proposal hash given proposal header, payload and the requested visibility
 	pHashBytes, err := utils.GetProposalHash(hdr, payl, visibility)
 	if err != nil {
@@ -122,7 +148,7 @@ func (e *EndorserOneValidSignature) Invoke(stub shim.ChaincodeStubInterface) ([]
 	logger.Infof("using epoch %s", string(epoch))
 
 	// get the bytes of the proposal response payload - we need to sign them
-	prpBytes, err := utils.GetBytesProposalResponsePayload(pHashBytes, epoch, results, events)
+	prpBytes, err := utils.GetBytesProposalResponsePayload(pHashBytes, epoch, response, results, events, privateData)
 	if err != nil {
 		return nil, errors.New("Failure while unmarshalling the ProposalResponsePayload")
 	}
@@ -134,7 +160,7 @@ func (e *EndorserOneValidSignature) Invoke(stub shim.ChaincodeStubInterface) ([]
 	signature := []byte("here_goes_the_signature_of_prpBytes_under_the_endorsers_key")
 
 	// marshall the proposal response so that we return its bytes
-	prBytes, err := utils.GetBytesProposalResponse(prpBytes, &protos.Endorsement{Signature: signature, Endorser: endorser})
+	prBytes, err := utils.GetBytesProposalResponse(prpBytes, response, &protos.Endorsement{Signature: signature, Endorser: endorser})
 	if err != nil {
 		return nil, fmt.Errorf("Could not marshall ProposalResponse: err %s", err)
 	}
@@ -21,6 +21,8 @@ import (
 
 	"bytes"
 
+	"github.com/golang/protobuf/proto"
+
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 	"github.com/hyperledger/fabric/core/crypto/primitives"
 	pb "github.com/hyperledger/fabric/protos"
@@ -149,6 +151,50 @@ func TestInvoke(t *testing.T) {
 		t.Fatalf("%s", err)
 		return
 	}
+
+	// success test 4: invocation with mandatory args + events + visibility + response
+	response := &pb.Response2{Status: 200, Message: "OK", Payload: []byte("payload")}
+	responseBytes, _ := proto.Marshal(response)
+
+	args = [][]byte{[]byte(""), proposal.Header, proposal.Payload, simRes, events, visibility, responseBytes}
+	prBytes, err = stub.MockInvoke("1", args)
+	if err != nil {
+		t.Fatalf("escc invoke failed with: %v", err)
+	}
+
+	err = validateProposalResponse(prBytes, proposal, visibility, simRes, events)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	pResp, _ := putils.GetProposalResponse(prBytes)
+	prp, _ := putils.GetProposalResponsePayload(pResp.Payload)
+	cact, _ := putils.GetChaincodeAction(prp.Extension)
+	if string(pResp.Response.Payload) != "payload" || string(cact.Response.Payload) != "payload" {
+		t.Fatalf("the response of the chaincode is not endorsed: %v, %v", pResp.Response, cact.Response)
+	}
+
+	// success test 5: invocation with mandatory args + events + visibility + response + private data hashes
+	privateData := &pb.PrivateDataHashes{Hashes: []*pb.PrivateDataHash{{Collection: "c1", Hash: []byte("hash")}}}
+	privateDataBytes, _ := proto.Marshal(privateData)
+
+	args = [][]byte{[]byte(""), proposal.Header, proposal.Payload, simRes, events, visibility, responseBytes, privateDataBytes}
+	prBytes, err = stub.MockInvoke("1", args)
+	if err != nil {
+		t.Fatalf("escc invoke failed with: %v", err)
+	}
+	pResp, _ = putils.GetProposalResponse(prBytes)
+	prp, _ = putils.GetProposalResponsePayload(pResp.Payload)
+	cact, _ = putils.GetChaincodeAction(prp.Extension)
+	if !proto.Equal(cact.PrivateData, privateData) {
+		t.Fatalf("the private data hashes are not endorsed: %v", cact.PrivateData)
+	}
+
+	// Failed path: a failed response is not endorsed
+	responseBytes, _ = proto.Marshal(&pb.Response2{Status: 404, Message: "not found"})
+	args = [][]byte{[]byte(""), proposal.Header, proposal.Payload, simRes, events, visibility, responseBytes}
+	if _, err = stub.MockInvoke("1", args); err == nil {
+		t.Fatalf("escc invoke should have failed with a failed chaincode response")
+	}
 }
 
 func validateProposalResponse(prBytes []byte, proposal *pb.Proposal, visibility []byte, simRes []byte, events []byte) error {
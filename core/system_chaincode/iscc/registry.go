@@ -0,0 +1,290 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package iscc
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/core/chaincode/acl"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos"
+	"github.com/op/go-logging"
+	"github.com/spf13/viper"
+)
+
+// IdentityRegistry implements the registry of application identities shared
+// by the organizations of the chain:
+// - Register registers an identity, given as a marshalled RegisteredIdentity
+// - Update replaces the affiliation and the attributes of an identity
+// - Revoke removes an identity from the registry
+// - GetIdentity returns a RegisteredIdentity
+// - GetAttribute returns the value of an attribute of an identity
+// - GetIdentities returns the RegisteredIdentities of an organization
+// The identities of an organization are registered, updated and revoked by
+// the admins of the organization in "chaincode.lifecycle.organizations".
+// Chaincodes query the registry with InvokeChaincode.
+type IdentityRegistry struct {
+}
+
+var isccLogger = logging.MustGetLogger("iscc")
+
+// These are function names from Invoke first parameter
+const (
+	Register      string = "Register"
+	Update        string = "Update"
+	Revoke        string = "Revoke"
+	GetIdentity   string = "GetIdentity"
+	GetAttribute  string = "GetAttribute"
+	GetIdentities string = "GetIdentities"
+)
+
+// These are the object types of the composite keys of the state, the
+// identities by id and the ids by organization
+const (
+	identityKey    string = "identity"
+	affiliationKey string = "affiliation"
+)
+
+// validAffiliation matches an organization name optionally followed by dot
+// separated units
+var validAffiliation = regexp.MustCompile(`^[a-zA-Z0-9_-]+(\.[a-zA-Z0-9_-]+)*$`)
+
+// Init is called once per chain when the chain is created.
+// This allows the chaincode to initialize any variables on the ledger prior
+// to any transaction execution on the chain.
+func (r *IdentityRegistry) Init(stub shim.ChaincodeStubInterface) ([]byte, error) {
+	isccLogger.Info("Init ISCC")
+
+	return nil, nil
+}
+
+// Invoke is called with args[0] contains the function name. Register and
+// Update take the marshalled RegisteredIdentity in args[1], GetIdentities
+// the organization and the other functions the id of the identity, followed
+// by the name of the attribute for GetAttribute
+func (r *IdentityRegistry) Invoke(stub shim.ChaincodeStubInterface) ([]byte, error) {
+	args := stub.GetArgs()
+
+	if len(args) < 2 {
+		return nil, fmt.Errorf("Incorrect number of arguments, %d", len(args))
+	}
+	fname := string(args[0])
+
+	isccLogger.Debugf("Invoke function: %s", fname)
+
+	switch fname {
+	case Register, Update:
+		identity := &pb.RegisteredIdentity{}
+		if err := proto.Unmarshal(args[1], identity); err != nil {
+			return nil, fmt.Errorf("invalid identity: %s", err)
+		}
+		return nil, register(stub, identity, fname == Update)
+	case Revoke:
+		return nil, revoke(stub, string(args[1]))
+	case GetIdentity:
+		identity, err := getIdentity(stub, string(args[1]))
+		if err != nil {
+			return nil, err
+		}
+		return proto.Marshal(identity)
+	case GetAttribute:
+		if len(args) < 3 {
+			return nil, fmt.Errorf("missing the attribute name for %s", fname)
+		}
+		return getAttribute(stub, string(args[1]), string(args[2]))
+	case GetIdentities:
+		return getIdentities(stub, string(args[1]))
+	}
+
+	return nil, fmt.Errorf("Requested function %s not found.", fname)
+}
+
+// Query is no longer implemented. Will be removed
+func (r *IdentityRegistry) Query(stub shim.ChaincodeStubInterface) ([]byte, error) {
+	return nil, nil
+}
+
+// register registers a new identity or, when update is set, replaces a
+// registered one
+func register(stub shim.ChaincodeStubInterface, identity *pb.RegisteredIdentity, update bool) error {
+	if identity.Id == "" {
+		return fmt.Errorf("identity has no id")
+	}
+	if !validAffiliation.MatchString(identity.Affiliation) {
+		return fmt.Errorf("invalid affiliation %q of identity %s", identity.Affiliation, identity.Id)
+	}
+	names := make(map[string]bool)
+	for _, attr := range identity.Attributes {
+		if attr.Name == "" || names[attr.Name] {
+			return fmt.Errorf("invalid or duplicate attribute name %q of identity %s", attr.Name, identity.Id)
+		}
+		names[attr.Name] = true
+	}
+	if err := checkAdmin(stub, organizationOf(identity.Affiliation)); err != nil {
+		return err
+	}
+
+	existing, err := getIdentity(stub, identity.Id)
+	if update && err != nil {
+		return err
+	}
+	if !update && err == nil {
+		return fmt.Errorf("identity %s already registered", identity.Id)
+	}
+	if update {
+		if err = removeIdentity(stub, existing); err != nil {
+			return err
+		}
+	}
+
+	b, err := proto.Marshal(identity)
+	if err != nil {
+		return err
+	}
+	key, err := stub.CreateCompositeKey(identityKey, []string{identity.Id})
+	if err != nil {
+		return err
+	}
+	if err = stub.PutState(key, b); err != nil {
+		return err
+	}
+	index, err := stub.CreateCompositeKey(affiliationKey, []string{organizationOf(identity.Affiliation), identity.Id})
+	if err != nil {
+		return err
+	}
+	return stub.PutState(index, []byte(identity.Id))
+}
+
+// revoke removes a registered identity
+func revoke(stub shim.ChaincodeStubInterface, id string) error {
+	identity, err := getIdentity(stub, id)
+	if err != nil {
+		return err
+	}
+	return removeIdentity(stub, identity)
+}
+
+// removeIdentity removes an identity and its index entry once the creator is
+// checked to be an admin of its organization
+func removeIdentity(stub shim.ChaincodeStubInterface, identity *pb.RegisteredIdentity) error {
+	org := organizationOf(identity.Affiliation)
+	if err := checkAdmin(stub, org); err != nil {
+		return err
+	}
+
+	key, err := stub.CreateCompositeKey(identityKey, []string{identity.Id})
+	if err != nil {
+		return err
+	}
+	if err = stub.DelState(key); err != nil {
+		return err
+	}
+	index, err := stub.CreateCompositeKey(affiliationKey, []string{org, identity.Id})
+	if err != nil {
+		return err
+	}
+	return stub.DelState(index)
+}
+
+// getIdentity returns a registered identity
+func getIdentity(stub shim.ChaincodeStubInterface, id string) (*pb.RegisteredIdentity, error) {
+	key, err := stub.CreateCompositeKey(identityKey, []string{id})
+	if err != nil {
+		return nil, err
+	}
+	b, err := stub.GetState(key)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil {
+		return nil, fmt.Errorf("identity %s not registered", id)
+	}
+	identity := &pb.RegisteredIdentity{}
+	if err = proto.Unmarshal(b, identity); err != nil {
+		return nil, fmt.Errorf("invalid identity %s: %s", id, err)
+	}
+	return identity, nil
+}
+
+// getAttribute returns the value of an attribute of a registered identity
+func getAttribute(stub shim.ChaincodeStubInterface, id string, name string) ([]byte, error) {
+	identity, err := getIdentity(stub, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, attr := range identity.Attributes {
+		if attr.Name == name {
+			return []byte(attr.Value), nil
+		}
+	}
+	return nil, fmt.Errorf("identity %s has no attribute %s", id, name)
+}
+
+// getIdentities returns the marshalled identities registered in an
+// organization
+func getIdentities(stub shim.ChaincodeStubInterface, org string) ([]byte, error) {
+	iter, err := stub.GetStateByPartialCompositeKey(affiliationKey, []string{org})
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	resp := &pb.RegisteredIdentities{}
+	for iter.HasNext() {
+		_, id, err := iter.Next()
+		if err != nil {
+			return nil, err
+		}
+		identity, err := getIdentity(stub, string(id))
+		if err != nil {
+			return nil, err
+		}
+		resp.Identities = append(resp.Identities, identity)
+	}
+	return proto.Marshal(resp)
+}
+
+// organizationOf returns the organization of an affiliation
+func organizationOf(affiliation string) string {
+	return strings.SplitN(affiliation, ".", 2)[0]
+}
+
+// checkAdmin checks that the creator of the proposal is an admin of the
+// organization
+func checkAdmin(stub shim.ChaincodeStubInterface, org string) error {
+	var orgs []struct {
+		Name   string
+		Admins []string
+	}
+	if err := viper.UnmarshalKey("chaincode.lifecycle.organizations", &orgs); err != nil {
+		return fmt.Errorf("error reading chaincode.lifecycle.organizations: %s", err)
+	}
+
+	creator, err := stub.GetCreator()
+	if err != nil {
+		return err
+	}
+	for _, o := range orgs {
+		if o.Name == org && acl.IsListed(o.Admins, "chaincode.lifecycle.organizations", creator) {
+			return nil
+		}
+	}
+	return fmt.Errorf("creator is not an admin of organization %s", org)
+}
@@ -0,0 +1,127 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package iscc
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos"
+	"github.com/spf13/viper"
+)
+
+func marshalIdentity(t *testing.T, id string, affiliation string, attrs ...string) []byte {
+	identity := &pb.RegisteredIdentity{Id: id, Affiliation: affiliation}
+	for i := 0; i+1 < len(attrs); i += 2 {
+		identity.Attributes = append(identity.Attributes, &pb.IdentityAttribute{Name: attrs[i], Value: attrs[i+1]})
+	}
+	b, err := proto.Marshal(identity)
+	if err != nil {
+		t.Fatalf("Error marshalling identity: %s", err)
+	}
+	return b
+}
+
+func TestRegistry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "iscctest")
+	if err != nil {
+		t.Fatalf("Error creating temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	admin1 := filepath.Join(dir, "admin1.pem")
+	admin2 := filepath.Join(dir, "admin2.pem")
+	member1 := filepath.Join(dir, "member1.pem")
+	ioutil.WriteFile(admin1, []byte("admin1\n"), 0644)
+	ioutil.WriteFile(admin2, []byte("admin2"), 0644)
+	ioutil.WriteFile(member1, []byte("member1"), 0644)
+	viper.Set("chaincode.lifecycle.organizations", []map[string]interface{}{
+		{"name": "org1", "members": []string{admin1, member1}, "admins": []string{admin1}},
+		{"name": "org2", "members": []string{admin2}, "admins": []string{admin2}},
+	})
+	defer viper.Set("chaincode.lifecycle.organizations", nil)
+
+	stub := shim.NewMockStub("IdentityRegistry", new(IdentityRegistry))
+
+	stub.Creator = []byte("admin2")
+	if _, err = stub.MockInvoke("1", [][]byte{[]byte(Register), marshalIdentity(t, "alice", "org1.sales", "role", "buyer")}); err == nil {
+		t.Fatalf("iscc Register should have failed for an admin of another organization")
+	}
+	stub.Creator = []byte("member1")
+	if _, err = stub.MockInvoke("1", [][]byte{[]byte(Register), marshalIdentity(t, "alice", "org1.sales", "role", "buyer")}); err == nil {
+		t.Fatalf("iscc Register should have failed for a member not an admin of the organization")
+	}
+	stub.Creator = []byte("admin1")
+	if _, err = stub.MockInvoke("1", [][]byte{[]byte(Register), marshalIdentity(t, "alice", "org1..sales")}); err == nil {
+		t.Fatalf("iscc Register should have failed for an invalid affiliation")
+	}
+	if _, err = stub.MockInvoke("1", [][]byte{[]byte(Register), marshalIdentity(t, "alice", "org1.sales", "role", "buyer", "role", "seller")}); err == nil {
+		t.Fatalf("iscc Register should have failed for duplicate attributes")
+	}
+	if _, err = stub.MockInvoke("1", [][]byte{[]byte(Register), marshalIdentity(t, "alice", "org1.sales", "role", "buyer")}); err != nil {
+		t.Fatalf("iscc Register failed: %s", err)
+	}
+	if _, err = stub.MockInvoke("1", [][]byte{[]byte(Register), marshalIdentity(t, "alice", "org1")}); err == nil {
+		t.Fatalf("iscc Register should have failed for an identity already registered")
+	}
+	if _, err = stub.MockInvoke("1", [][]byte{[]byte(Register), marshalIdentity(t, "carol", "org1")}); err != nil {
+		t.Fatalf("iscc Register failed: %s", err)
+	}
+
+	res, err := stub.MockInvoke("1", [][]byte{[]byte(GetAttribute), []byte("alice"), []byte("role")})
+	if err != nil || string(res) != "buyer" {
+		t.Fatalf("iscc GetAttribute returned %s, %v", res, err)
+	}
+
+	if _, err = stub.MockInvoke("1", [][]byte{[]byte(Update), marshalIdentity(t, "alice", "org1", "role", "seller")}); err != nil {
+		t.Fatalf("iscc Update failed: %s", err)
+	}
+	res, err = stub.MockInvoke("1", [][]byte{[]byte(GetIdentity), []byte("alice")})
+	if err != nil {
+		t.Fatalf("iscc GetIdentity failed: %s", err)
+	}
+	identity := &pb.RegisteredIdentity{}
+	proto.Unmarshal(res, identity)
+	if identity.Affiliation != "org1" || len(identity.Attributes) != 1 || identity.Attributes[0].Value != "seller" {
+		t.Fatalf("iscc GetIdentity returned %v", identity)
+	}
+
+	res, err = stub.MockInvoke("1", [][]byte{[]byte(GetIdentities), []byte("org1")})
+	if err != nil {
+		t.Fatalf("iscc GetIdentities failed: %s", err)
+	}
+	identities := &pb.RegisteredIdentities{}
+	proto.Unmarshal(res, identities)
+	if len(identities.Identities) != 2 {
+		t.Fatalf("iscc GetIdentities returned %d identities, expected 2", len(identities.Identities))
+	}
+
+	stub.Creator = []byte("admin2")
+	if _, err = stub.MockInvoke("1", [][]byte{[]byte(Revoke), []byte("alice")}); err == nil {
+		t.Fatalf("iscc Revoke should have failed for an admin of another organization")
+	}
+	stub.Creator = []byte("admin1")
+	if _, err = stub.MockInvoke("1", [][]byte{[]byte(Revoke), []byte("alice")}); err != nil {
+		t.Fatalf("iscc Revoke failed: %s", err)
+	}
+	if _, err = stub.MockInvoke("1", [][]byte{[]byte(GetIdentity), []byte("alice")}); err == nil {
+		t.Fatalf("iscc GetIdentity should have failed for a revoked identity")
+	}
+}
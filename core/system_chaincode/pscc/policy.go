@@ -0,0 +1,217 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pscc
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/core/chaincode/acl"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos"
+	"github.com/op/go-logging"
+	"github.com/spf13/viper"
+)
+
+// PolicyManager implements the application policies of the chain, named
+// sets of roles each held by a set of principals:
+// - SetPolicy defines or replaces a policy, given as a marshalled
+//   ApplicationPolicy
+// - DeletePolicy removes a policy
+// - GetPolicy returns an ApplicationPolicy
+// - CheckPolicy checks that an identity holds a role of a policy
+// The policies are defined by the channel admins, the admins of the
+// organizations in "chaincode.lifecycle.organizations", and by no one when no
+// admin is configured. Chaincodes check their policies with
+// shim.CheckPolicy.
+type PolicyManager struct {
+}
+
+var psccLogger = logging.MustGetLogger("pscc")
+
+// These are function names from Invoke first parameter
+const (
+	SetPolicy    string = "SetPolicy"
+	DeletePolicy string = "DeletePolicy"
+	GetPolicy    string = "GetPolicy"
+	CheckPolicy  string = "CheckPolicy"
+)
+
+// policyKey is the object type of the composite keys of the policies
+const policyKey string = "policy"
+
+// validPolicyName matches the names of policies and roles
+var validPolicyName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+// Init is called once per chain when the chain is created.
+// This allows the chaincode to initialize any variables on the ledger prior
+// to any transaction execution on the chain.
+func (p *PolicyManager) Init(stub shim.ChaincodeStubInterface) ([]byte, error) {
+	psccLogger.Info("Init PSCC")
+
+	return nil, nil
+}
+
+// Invoke is called with args[0] contains the function name. SetPolicy takes
+// the marshalled ApplicationPolicy in args[1] and the other functions the
+// name of the policy, followed by the role and the identity for CheckPolicy
+func (p *PolicyManager) Invoke(stub shim.ChaincodeStubInterface) ([]byte, error) {
+	args := stub.GetArgs()
+
+	if len(args) < 2 {
+		return nil, fmt.Errorf("Incorrect number of arguments, %d", len(args))
+	}
+	fname := string(args[0])
+
+	psccLogger.Debugf("Invoke function: %s", fname)
+
+	switch fname {
+	case SetPolicy:
+		policy := &pb.ApplicationPolicy{}
+		if err := proto.Unmarshal(args[1], policy); err != nil {
+			return nil, fmt.Errorf("invalid policy: %s", err)
+		}
+		return nil, setPolicy(stub, policy)
+	case DeletePolicy:
+		return nil, deletePolicy(stub, string(args[1]))
+	case GetPolicy:
+		policy, err := getPolicy(stub, string(args[1]))
+		if err != nil {
+			return nil, err
+		}
+		return proto.Marshal(policy)
+	case CheckPolicy:
+		if len(args) < 4 {
+			return nil, fmt.Errorf("%s takes the policy, the role and the identity", fname)
+		}
+		return nil, checkPolicy(stub, string(args[1]), string(args[2]), args[3])
+	}
+
+	return nil, fmt.Errorf("Requested function %s not found.", fname)
+}
+
+// Query is no longer implemented. Will be removed
+func (p *PolicyManager) Query(stub shim.ChaincodeStubInterface) ([]byte, error) {
+	return nil, nil
+}
+
+// setPolicy defines or replaces a policy
+func setPolicy(stub shim.ChaincodeStubInterface, policy *pb.ApplicationPolicy) error {
+	if err := checkAdmin(stub); err != nil {
+		return err
+	}
+	if !validPolicyName.MatchString(policy.Name) {
+		return fmt.Errorf("invalid policy name %q", policy.Name)
+	}
+	roles := make(map[string]bool)
+	for _, role := range policy.Roles {
+		if !validPolicyName.MatchString(role.Role) || roles[role.Role] {
+			return fmt.Errorf("invalid or duplicate role %q of policy %s", role.Role, policy.Name)
+		}
+		roles[role.Role] = true
+	}
+
+	b, err := proto.Marshal(policy)
+	if err != nil {
+		return err
+	}
+	key, err := stub.CreateCompositeKey(policyKey, []string{policy.Name})
+	if err != nil {
+		return err
+	}
+	return stub.PutState(key, b)
+}
+
+// deletePolicy removes a policy
+func deletePolicy(stub shim.ChaincodeStubInterface, name string) error {
+	if err := checkAdmin(stub); err != nil {
+		return err
+	}
+	if _, err := getPolicy(stub, name); err != nil {
+		return err
+	}
+	key, err := stub.CreateCompositeKey(policyKey, []string{name})
+	if err != nil {
+		return err
+	}
+	return stub.DelState(key)
+}
+
+// getPolicy returns a policy
+func getPolicy(stub shim.ChaincodeStubInterface, name string) (*pb.ApplicationPolicy, error) {
+	key, err := stub.CreateCompositeKey(policyKey, []string{name})
+	if err != nil {
+		return nil, err
+	}
+	b, err := stub.GetState(key)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil {
+		return nil, fmt.Errorf("policy %s not defined", name)
+	}
+	policy := &pb.ApplicationPolicy{}
+	if err = proto.Unmarshal(b, policy); err != nil {
+		return nil, fmt.Errorf("invalid policy %s: %s", name, err)
+	}
+	return policy, nil
+}
+
+// checkPolicy checks that the identity is a principal of the role of the
+// policy
+func checkPolicy(stub shim.ChaincodeStubInterface, name string, role string, identity []byte) error {
+	policy, err := getPolicy(stub, name)
+	if err != nil {
+		return err
+	}
+	for _, r := range policy.Roles {
+		if r.Role != role {
+			continue
+		}
+		for _, principal := range r.Principals {
+			if len(identity) > 0 && bytes.Equal(principal, identity) {
+				return nil
+			}
+		}
+		return fmt.Errorf("identity does not hold role %s of policy %s", role, name)
+	}
+	return fmt.Errorf("policy %s has no role %s", name, role)
+}
+
+// checkAdmin checks that the creator of the proposal is an admin of one of
+// the organizations of the chain
+func checkAdmin(stub shim.ChaincodeStubInterface) error {
+	var orgs []struct {
+		Admins []string
+	}
+	if err := viper.UnmarshalKey("chaincode.lifecycle.organizations", &orgs); err != nil {
+		return fmt.Errorf("error reading chaincode.lifecycle.organizations: %s", err)
+	}
+
+	creator, err := stub.GetCreator()
+	if err != nil {
+		return err
+	}
+	for _, org := range orgs {
+		if acl.IsListed(org.Admins, "chaincode.lifecycle.organizations", creator) {
+			return nil
+		}
+	}
+	return fmt.Errorf("creator is not permitted to define application policies")
+}
@@ -0,0 +1,124 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pscc
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos"
+	"github.com/spf13/viper"
+)
+
+// appChaincode checks the policy and role of its arguments with
+// shim.CheckPolicy
+type appChaincode struct {
+}
+
+func (cc *appChaincode) Init(stub shim.ChaincodeStubInterface) ([]byte, error) {
+	return nil, nil
+}
+
+func (cc *appChaincode) Invoke(stub shim.ChaincodeStubInterface) ([]byte, error) {
+	args := stub.GetStringArgs()
+	return nil, shim.CheckPolicy(stub, args[0], args[1])
+}
+
+func (cc *appChaincode) Query(stub shim.ChaincodeStubInterface) ([]byte, error) {
+	return nil, nil
+}
+
+func TestPolicies(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pscctest")
+	if err != nil {
+		t.Fatalf("Error creating temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	admin := filepath.Join(dir, "admin.pem")
+	member := filepath.Join(dir, "member.pem")
+	ioutil.WriteFile(admin, []byte("admin\n"), 0644)
+	ioutil.WriteFile(member, []byte("member"), 0644)
+	viper.Set("chaincode.lifecycle.organizations", []map[string]interface{}{{"name": "org1", "members": []string{admin, member}, "admins": []string{admin}}})
+	defer viper.Set("chaincode.lifecycle.organizations", nil)
+
+	stub := shim.NewMockStub("PolicyManager", new(PolicyManager))
+
+	policy, err := proto.Marshal(&pb.ApplicationPolicy{Name: "trade", Roles: []*pb.PolicyRole{
+		{Role: "buyer", Principals: [][]byte{[]byte("alice"), []byte("bob")}},
+		{Role: "seller", Principals: [][]byte{[]byte("carol")}},
+	}})
+	if err != nil {
+		t.Fatalf("Error marshalling policy: %s", err)
+	}
+	stub.Creator = []byte("alice")
+	if _, err = stub.MockInvoke("1", [][]byte{[]byte(SetPolicy), policy}); err == nil {
+		t.Fatalf("pscc SetPolicy should have failed for a creator not a channel admin")
+	}
+	stub.Creator = []byte("member")
+	if _, err = stub.MockInvoke("1", [][]byte{[]byte(SetPolicy), policy}); err == nil {
+		t.Fatalf("pscc SetPolicy should have failed for a member not an admin of the organization")
+	}
+	stub.Creator = []byte("admin")
+	if _, err = stub.MockInvoke("1", [][]byte{[]byte(SetPolicy), policy}); err != nil {
+		t.Fatalf("pscc SetPolicy failed: %s", err)
+	}
+
+	res, err := stub.MockInvoke("1", [][]byte{[]byte(GetPolicy), []byte("trade")})
+	if err != nil {
+		t.Fatalf("pscc GetPolicy failed: %s", err)
+	}
+	got := &pb.ApplicationPolicy{}
+	proto.Unmarshal(res, got)
+	if len(got.Roles) != 2 || got.Roles[1].Role != "seller" {
+		t.Fatalf("pscc GetPolicy returned %v", got)
+	}
+
+	app := shim.NewMockStub("app", new(appChaincode))
+	app.MockPeerChaincode("pscc", stub)
+	for _, c := range []struct {
+		creator  string
+		role     string
+		expected bool
+	}{
+		{"alice", "buyer", true},
+		{"carol", "seller", true},
+		{"carol", "buyer", false},
+		{"alice", "broker", false},
+		{"", "buyer", false},
+	} {
+		app.Creator = []byte(c.creator)
+		_, err = app.MockInvoke("1", [][]byte{[]byte("trade"), []byte(c.role)})
+		if c.expected && err != nil {
+			t.Fatalf("shim.CheckPolicy failed for %q as %s: %s", c.creator, c.role, err)
+		}
+		if !c.expected && err == nil {
+			t.Fatalf("shim.CheckPolicy should have failed for %q as %s", c.creator, c.role)
+		}
+	}
+
+	if _, err = stub.MockInvoke("1", [][]byte{[]byte(DeletePolicy), []byte("trade")}); err != nil {
+		t.Fatalf("pscc DeletePolicy failed: %s", err)
+	}
+	app.Creator = []byte("alice")
+	if _, err = app.MockInvoke("1", [][]byte{[]byte("trade"), []byte("buyer")}); err == nil {
+		t.Fatalf("shim.CheckPolicy should have failed for a deleted policy")
+	}
+}
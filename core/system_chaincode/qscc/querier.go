@@ -0,0 +1,233 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package qscc
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/core/chaincode/acl"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/core/ledger/kvledger"
+	"github.com/hyperledger/fabric/core/system_chaincode/cscc"
+	pb "github.com/hyperledger/fabric/protos"
+	"github.com/op/go-logging"
+	"github.com/spf13/viper"
+)
+
+// LedgerQuerier implements the ledger query functions:
+// - GetChainInfo returns BlockchainInfo
+// - GetBlockByNumber returns a block
+// - GetBlockByHash returns a block
+// - GetBlockByTxID returns the block containing the transaction
+// - GetTransactionByID returns a transaction
+// - GetTxValidationCodeByTxID returns the validation code of a transaction
+// - GetStateWithProof returns the value of a key with a proof against the state root
+// - GetStateRoot returns the state root recorded for a block
+// - GetChannelConfig returns the configuration values of the chain
+// All functions take the chain name as the first argument, and the values
+// are returned marshalled as protobufs, except for the validation code that
+// is returned by name. The lookups use the block store indexes and do not
+// scan the blocks. The ledgers are read by the identities listed in
+// "ledger.query.readers" and the members of the organizations in
+// "chaincode.lifecycle.organizations", by everyone when neither is set.
+type LedgerQuerier struct {
+}
+
+var qscclogger = logging.MustGetLogger("qscc")
+
+// These are function names from Invoke first parameter
+const (
+	GetChainInfo              string = "GetChainInfo"
+	GetBlockByNumber          string = "GetBlockByNumber"
+	GetBlockByHash            string = "GetBlockByHash"
+	GetBlockByTxID            string = "GetBlockByTxID"
+	GetTransactionByID        string = "GetTransactionByID"
+	GetTxValidationCodeByTxID string = "GetTxValidationCodeByTxID"
+	GetStateWithProof         string = "GetStateWithProof"
+	GetStateRoot              string = "GetStateRoot"
+	GetChannelConfig          string = "GetChannelConfig"
+)
+
+// channelPolicies names the access control policies of a chain, the keys of
+// the peer configuration listing the identities permitted, by the resource
+// they control
+var channelPolicies = map[string]string{
+	"lccc/install":     "chaincode.lifecycle.installers",
+	"lccc/instantiate": "chaincode.lifecycle.instantiators",
+	"lccc/upgrade":     "chaincode.lifecycle.instantiators",
+	"lccc/commit":      "chaincode.lifecycle.instantiators",
+	"lccc/approve":     "chaincode.lifecycle.organizations",
+	"qscc/read":        "ledger.query.readers",
+	"cscc/join":        "peer.admins",
+	"tscc/issue":       "chaincode.token.issuers",
+	"iscc/register":    "chaincode.lifecycle.organizations",
+	"pscc/define":      "chaincode.lifecycle.organizations",
+}
+
+// Init is called once per chain when the chain is created.
+// This allows the chaincode to initialize any variables on the ledger prior
+// to any transaction execution on the chain.
+func (e *LedgerQuerier) Init(stub shim.ChaincodeStubInterface) ([]byte, error) {
+	qscclogger.Info("Init QSCC")
+
+	return nil, nil
+}
+
+// Invoke is called with args[0] contains the query function name, args[1]
+// contains the chain name and args[2] the argument of the query function,
+// which is absent for GetChainInfo. GetStateWithProof takes the namespace
+// in args[2] and the key in args[3]
+func (e *LedgerQuerier) Invoke(stub shim.ChaincodeStubInterface) ([]byte, error) {
+	args := stub.GetArgs()
+
+	if len(args) < 2 {
+		return nil, fmt.Errorf("Incorrect number of arguments, %d", len(args))
+	}
+	fname := string(args[0])
+	chainName := string(args[1])
+
+	if fname != GetChainInfo && fname != GetChannelConfig && len(args) < 3 {
+		return nil, fmt.Errorf("missing 3rd argument for %s", fname)
+	}
+	if fname == GetStateWithProof && len(args) < 4 {
+		return nil, fmt.Errorf("missing 4th argument for %s", fname)
+	}
+
+	qscclogger.Debugf("Invoke function: %s on chain: %s", fname, chainName)
+
+	if err := checkReadACL(stub, chainName); err != nil {
+		return nil, err
+	}
+	// kvledger.GetLedger creates the ledgers it does not find
+	if err := checkChain(chainName); err != nil {
+		return nil, err
+	}
+
+	lgr := kvledger.GetLedger(chainName)
+
+	var res proto.Message
+	var err error
+	switch fname {
+	case GetChainInfo:
+		res, err = lgr.GetBlockchainInfo()
+	case GetBlockByNumber:
+		var blockNumber uint64
+		if blockNumber, err = strconv.ParseUint(string(args[2]), 10, 64); err != nil {
+			return nil, fmt.Errorf("Invalid block number %s", string(args[2]))
+		}
+		res, err = lgr.GetBlockByNumber(blockNumber)
+	case GetBlockByHash:
+		res, err = lgr.GetBlockByHash(args[2])
+	case GetBlockByTxID:
+		res, err = lgr.GetBlockByTxID(string(args[2]))
+	case GetTransactionByID:
+		res, err = lgr.GetTransactionByID(string(args[2]))
+	case GetTxValidationCodeByTxID:
+		var code pb.TxValidationCode
+		if code, err = lgr.GetTxValidationCodeByTxID(string(args[2])); err != nil {
+			return nil, fmt.Errorf("Failed to execute %s on chain %s: %s", fname, chainName, err)
+		}
+		return []byte(code.String()), nil
+	case GetStateWithProof:
+		res, err = lgr.GetStateWithProof(string(args[2]), string(args[3]))
+	case GetStateRoot:
+		var blockNumber uint64
+		if blockNumber, err = strconv.ParseUint(string(args[2]), 10, 64); err != nil {
+			return nil, fmt.Errorf("Invalid block number %s", string(args[2]))
+		}
+		res, err = lgr.GetStateRoot(blockNumber)
+	case GetChannelConfig:
+		res, err = getChannelConfig(chainName)
+	default:
+		return nil, fmt.Errorf("Requested function %s not found.", fname)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to execute %s on chain %s: %s", fname, chainName, err)
+	}
+	return proto.Marshal(res)
+}
+
+// checkChain checks that the ledger of the chain exists
+func checkChain(chainName string) error {
+	exists, err := kvledger.LedgerExists(chainName)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("chain %s not found", chainName)
+	}
+	return nil
+}
+
+// checkReadACL checks that the creator of the proposal may read the ledger
+// of the chain
+func checkReadACL(stub shim.ChaincodeStubInterface, chainName string) error {
+	var orgs []struct {
+		Members []string
+	}
+	if err := viper.UnmarshalKey("chaincode.lifecycle.organizations", &orgs); err != nil {
+		return fmt.Errorf("error reading chaincode.lifecycle.organizations: %s", err)
+	}
+
+	readers := viper.GetStringSlice("ledger.query.readers")
+	for _, org := range orgs {
+		readers = append(readers, org.Members...)
+	}
+	if len(readers) == 0 {
+		return nil
+	}
+
+	creator, err := stub.GetCreator()
+	if err != nil {
+		return err
+	}
+	if acl.IsListed(readers, "ledger.query.readers", creator) {
+		return nil
+	}
+	return fmt.Errorf("creator is not permitted to read the ledger of chain %s", chainName)
+}
+
+// getChannelConfig returns the organizations declared in the latest
+// configuration recorded for the chain, those declaring anchor peers, and the
+// access control policies of the chain. A chain without recorded
+// configuration, one the peer did not join such as the default chain,
+// declares no organization
+func getChannelConfig(chainName string) (*pb.ChannelConfig, error) {
+	config := &pb.ChannelConfig{ChainID: chainName, Policies: make(map[string]string)}
+	for resource, policy := range channelPolicies {
+		config.Policies[resource] = policy
+	}
+
+	orgs, err := cscc.ChainOrganizations(chainName)
+	if err != nil {
+		return nil, err
+	}
+	for org := range orgs {
+		config.Organizations = append(config.Organizations, org)
+	}
+	sort.Strings(config.Organizations)
+	return config, nil
+}
+
+// Query is no longer implemented. Will be removed
+func (e *LedgerQuerier) Query(stub shim.ChaincodeStubInterface) ([]byte, error) {
+	return nil, nil
+}
@@ -0,0 +1,292 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package qscc
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/core/ledger/kvledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/buckettree"
+	"github.com/hyperledger/fabric/core/ledger/testutil"
+	pb "github.com/hyperledger/fabric/protos"
+	cb "github.com/hyperledger/fabric/protos/common"
+	ab "github.com/hyperledger/fabric/protos/orderer"
+	putils "github.com/hyperledger/fabric/protos/utils"
+	"github.com/spf13/viper"
+)
+
+func TestQueryLedger(t *testing.T) {
+	ledgerPath, err := ioutil.TempDir("", "qscctest")
+	if err != nil {
+		t.Fatalf("Error creating temp dir: %s", err)
+	}
+	defer os.RemoveAll(ledgerPath)
+	kvledger.Initialize(ledgerPath)
+
+	lgr := kvledger.GetLedger("mytestchain")
+	simulator, _ := lgr.NewTxSimulator()
+	simulator.SetState("ns1", "key1", []byte("value1"))
+	simulator.Done()
+	simRes, _ := simulator.GetTxSimulationResults()
+	block := testutil.ConstructBlockForSimulationResults(t, [][]byte{simRes})
+	lgr.RemoveInvalidTransactionsAndPrepare(block)
+	lgr.Commit()
+	txOrig := &pb.Transaction2{}
+	proto.Unmarshal(block.Transactions[0], txOrig)
+	txID, _ := putils.GetTxID(txOrig)
+
+	stub := shim.NewMockStub("LedgerQuerier", new(LedgerQuerier))
+
+	res, err := stub.MockInvoke("1", [][]byte{[]byte(GetChainInfo), []byte("mytestchain")})
+	if err != nil {
+		t.Fatalf("qscc GetChainInfo failed: %s", err)
+	}
+	bcInfo := &pb.BlockchainInfo{}
+	proto.Unmarshal(res, bcInfo)
+	testutil.AssertEquals(t, bcInfo.Height, uint64(1))
+
+	for _, args := range [][][]byte{
+		{[]byte(GetBlockByNumber), []byte("mytestchain"), []byte("1")},
+		{[]byte(GetBlockByHash), []byte("mytestchain"), bcInfo.CurrentBlockHash},
+		{[]byte(GetBlockByTxID), []byte("mytestchain"), []byte(txID)},
+	} {
+		res, err = stub.MockInvoke("1", args)
+		if err != nil {
+			t.Fatalf("qscc %s failed: %s", args[0], err)
+		}
+		b := &pb.Block2{}
+		proto.Unmarshal(res, b)
+		testutil.AssertEquals(t, b.Transactions, block.Transactions)
+	}
+
+	res, err = stub.MockInvoke("1", [][]byte{[]byte(GetTransactionByID), []byte("mytestchain"), []byte(txID)})
+	if err != nil {
+		t.Fatalf("qscc GetTransactionByID failed: %s", err)
+	}
+	tx := &pb.Transaction2{}
+	proto.Unmarshal(res, tx)
+	testutil.AssertEquals(t, tx, txOrig)
+
+	if _, err = stub.MockInvoke("1", [][]byte{[]byte(GetBlockByNumber), []byte("mytestchain")}); err == nil {
+		t.Fatalf("qscc GetBlockByNumber should have failed with a missing argument")
+	}
+	if _, err = stub.MockInvoke("1", [][]byte{[]byte(GetBlockByNumber), []byte("mytestchain"), []byte("x")}); err == nil {
+		t.Fatalf("qscc GetBlockByNumber should have failed with an invalid block number")
+	}
+	if _, err = stub.MockInvoke("1", [][]byte{[]byte("GetState"), []byte("mytestchain"), []byte("x")}); err == nil {
+		t.Fatalf("qscc invoke should have failed with an unknown function")
+	}
+
+	//querying a chain does not create its ledger
+	if _, err = stub.MockInvoke("1", [][]byte{[]byte(GetChainInfo), []byte("myunknownchain")}); err == nil {
+		t.Fatalf("qscc GetChainInfo should have failed with an unknown chain")
+	}
+	names, _ := kvledger.GetLedgerNames()
+	testutil.AssertEquals(t, names, []string{"mytestchain"})
+}
+
+func TestQueryStateProof(t *testing.T) {
+	ledgerPath, err := ioutil.TempDir("", "qscctest")
+	if err != nil {
+		t.Fatalf("Error creating temp dir: %s", err)
+	}
+	defer os.RemoveAll(ledgerPath)
+	viper.Set("ledger.state.stateTree.depth", 4)
+	defer viper.Set("ledger.state.stateTree.depth", 0)
+	kvledger.Initialize(ledgerPath)
+
+	lgr := kvledger.GetLedger("myproofchain")
+	simulator, _ := lgr.NewTxSimulator()
+	simulator.SetState("ns1", "key1", []byte("value1"))
+	simulator.Done()
+	simRes, _ := simulator.GetTxSimulationResults()
+	block := testutil.ConstructBlockForSimulationResults(t, [][]byte{simRes})
+	lgr.RemoveInvalidTransactionsAndPrepare(block)
+	lgr.Commit()
+
+	stub := shim.NewMockStub("LedgerQuerier", new(LedgerQuerier))
+
+	res, err := stub.MockInvoke("1", [][]byte{[]byte(GetStateWithProof), []byte("myproofchain"), []byte("ns1"), []byte("key1")})
+	if err != nil {
+		t.Fatalf("qscc GetStateWithProof failed: %s", err)
+	}
+	proof := &pb.StateProof{}
+	proto.Unmarshal(res, proof)
+	testutil.AssertEquals(t, proof.Value, []byte("value1"))
+	testutil.AssertNoError(t, buckettree.VerifyStateProof(proof), "")
+
+	res, err = stub.MockInvoke("1", [][]byte{[]byte(GetStateRoot), []byte("myproofchain"), []byte("1")})
+	if err != nil {
+		t.Fatalf("qscc GetStateRoot failed: %s", err)
+	}
+	stateRoot := &pb.StateRoot{}
+	proto.Unmarshal(res, stateRoot)
+	testutil.AssertEquals(t, stateRoot.Root, proof.StateRoot)
+
+	if _, err = stub.MockInvoke("1", [][]byte{[]byte(GetStateWithProof), []byte("myproofchain"), []byte("ns1")}); err == nil {
+		t.Fatalf("qscc GetStateWithProof should have failed with a missing argument")
+	}
+	if _, err = stub.MockInvoke("1", [][]byte{[]byte(GetStateRoot), []byte("myproofchain"), []byte("2")}); err == nil {
+		t.Fatalf("qscc GetStateRoot should have failed for a block that is not committed")
+	}
+}
+
+func TestQueryTxValidationCode(t *testing.T) {
+	ledgerPath, err := ioutil.TempDir("", "qscctest")
+	if err != nil {
+		t.Fatalf("Error creating temp dir: %s", err)
+	}
+	defer os.RemoveAll(ledgerPath)
+	kvledger.Initialize(ledgerPath)
+
+	// the second transaction conflicts with the first one and is removed from the block
+	lgr := kvledger.GetLedger("mycodechain")
+	var simRes [][]byte
+	for _, value := range []string{"value1", "value2"} {
+		simulator, _ := lgr.NewTxSimulator()
+		simulator.GetState("ns1", "key1")
+		simulator.SetState("ns1", "key1", []byte(value))
+		simulator.Done()
+		res, _ := simulator.GetTxSimulationResults()
+		simRes = append(simRes, res)
+	}
+	block := testutil.ConstructBlockForSimulationResults(t, simRes)
+	_, invalidTxs, _ := lgr.RemoveInvalidTransactionsAndPrepare(block)
+	testutil.AssertEquals(t, len(invalidTxs), 1)
+	lgr.Commit()
+
+	stub := shim.NewMockStub("LedgerQuerier", new(LedgerQuerier))
+
+	for i, code := range []pb.TxValidationCode{pb.TxValidationCode_Valid, pb.TxValidationCode_RWConflictDuringCommit} {
+		tx := &pb.Transaction2{}
+		proto.Unmarshal(block.Transactions[i], tx)
+		txID, _ := putils.GetTxID(tx)
+		res, err := stub.MockInvoke("1", [][]byte{[]byte(GetTxValidationCodeByTxID), []byte("mycodechain"), []byte(txID)})
+		if err != nil {
+			t.Fatalf("qscc GetTxValidationCodeByTxID failed: %s", err)
+		}
+		testutil.AssertEquals(t, string(res), code.String())
+	}
+	if _, err = stub.MockInvoke("1", [][]byte{[]byte(GetTxValidationCodeByTxID), []byte("mycodechain"), []byte("unknown")}); err == nil {
+		t.Fatalf("qscc GetTxValidationCodeByTxID should have failed with an unknown transaction")
+	}
+}
+
+// writeConfigBlock records a genesis block declaring the anchor peers of the
+// given organizations as the configuration block of the chain
+func writeConfigBlock(t *testing.T, chainID string, orgs ...string) {
+	config := &ab.ConfigurationEnvelope{ChainID: []byte(chainID)}
+	for _, org := range orgs {
+		item, err := putils.CreateAnchorPeersItem([]byte(chainID), org, &pb.AnchorPeers{AnchorPeers: []*pb.AnchorPeer{{Host: "peer0." + org, Port: 7051}}}, 0, "")
+		if err != nil {
+			t.Fatalf("Error creating the anchor peers of %s: %s", org, err)
+		}
+		config.Items = append(config.Items, item)
+	}
+	data, err := proto.Marshal(config)
+	if err != nil {
+		t.Fatalf("Error marshalling the configuration: %s", err)
+	}
+	b, err := proto.Marshal(&cb.Block{Header: &cb.BlockHeader{Number: 0}, Data: &cb.BlockData{Data: [][]byte{data}}})
+	if err != nil {
+		t.Fatalf("Error marshalling the block: %s", err)
+	}
+
+	dir := filepath.Join(viper.GetString("peer.fileSystemPath"), "chains", chainID)
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("Error creating the directory of the chain: %s", err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, "config.block"), b, 0644); err != nil {
+		t.Fatalf("Error writing the configuration block: %s", err)
+	}
+}
+
+func TestQueryChannelConfig(t *testing.T) {
+	ledgerPath, err := ioutil.TempDir("", "qscctest")
+	if err != nil {
+		t.Fatalf("Error creating temp dir: %s", err)
+	}
+	defer os.RemoveAll(ledgerPath)
+	kvledger.Initialize(ledgerPath)
+	viper.Set("peer.fileSystemPath", ledgerPath)
+	defer viper.Set("peer.fileSystemPath", nil)
+
+	//the organizations configured locally are not those of the chain
+	viper.Set("chaincode.lifecycle.organizations", []map[string]interface{}{{"name": "org3"}})
+	defer viper.Set("chaincode.lifecycle.organizations", nil)
+
+	writeConfigBlock(t, "myconfigchain", "org2", "org1")
+	kvledger.GetLedger("myconfigchain")
+	kvledger.GetLedger("myotherchain")
+
+	stub := shim.NewMockStub("LedgerQuerier", new(LedgerQuerier))
+	res, err := stub.MockInvoke("1", [][]byte{[]byte(GetChannelConfig), []byte("myconfigchain")})
+	if err != nil {
+		t.Fatalf("qscc GetChannelConfig failed: %s", err)
+	}
+	config := &pb.ChannelConfig{}
+	proto.Unmarshal(res, config)
+	testutil.AssertEquals(t, config.ChainID, "myconfigchain")
+	testutil.AssertEquals(t, config.Organizations, []string{"org1", "org2"})
+	testutil.AssertEquals(t, config.Policies["lccc/install"], "chaincode.lifecycle.installers")
+
+	//a chain without recorded configuration declares no organization
+	res, err = stub.MockInvoke("1", [][]byte{[]byte(GetChannelConfig), []byte("myotherchain")})
+	if err != nil {
+		t.Fatalf("qscc GetChannelConfig failed: %s", err)
+	}
+	config = &pb.ChannelConfig{}
+	proto.Unmarshal(res, config)
+	testutil.AssertEquals(t, len(config.Organizations), 0)
+}
+
+func TestQueryReadACL(t *testing.T) {
+	ledgerPath, err := ioutil.TempDir("", "qscctest")
+	if err != nil {
+		t.Fatalf("Error creating temp dir: %s", err)
+	}
+	defer os.RemoveAll(ledgerPath)
+	kvledger.Initialize(ledgerPath)
+
+	reader := filepath.Join(ledgerPath, "reader.pem")
+	member := filepath.Join(ledgerPath, "member.pem")
+	ioutil.WriteFile(reader, []byte("reader\n"), 0644)
+	ioutil.WriteFile(member, []byte("member"), 0644)
+	viper.Set("ledger.query.readers", []string{reader})
+	defer viper.Set("ledger.query.readers", nil)
+	viper.Set("chaincode.lifecycle.organizations", []map[string]interface{}{{"name": "org1", "members": []string{member}}})
+	defer viper.Set("chaincode.lifecycle.organizations", nil)
+
+	kvledger.GetLedger("myaclchain")
+
+	stub := shim.NewMockStub("LedgerQuerier", new(LedgerQuerier))
+	for creator, permitted := range map[string]bool{"reader": true, "member": true, "other": false, "": false} {
+		stub.Creator = []byte(creator)
+		_, err = stub.MockInvoke("1", [][]byte{[]byte(GetChainInfo), []byte("myaclchain")})
+		if permitted && err != nil {
+			t.Fatalf("qscc GetChainInfo failed for %q: %s", creator, err)
+		}
+		if !permitted && err == nil {
+			t.Fatalf("qscc GetChainInfo should have failed for %q", creator)
+		}
+	}
+}
@@ -0,0 +1,369 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tscc
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/hyperledger/fabric/core/chaincode/acl"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/core/crypto/primitives"
+	"github.com/op/go-logging"
+	"github.com/spf13/viper"
+)
+
+// TokenManager implements the fungible token functions:
+// - Issue creates tokens of a type for an owner
+// - Transfer moves tokens of a type from an owner to another
+// - Redeem destroys tokens of a type held by an owner
+// - GetBalance returns the tokens of a type held by an owner
+// - GetSupply returns the tokens of a type in circulation
+// - GetNonce returns the nonce an owner signs its next operation with
+// Owners are given by their PEM encoded certificates. Transfer and Redeem
+// carry the signature of the owner over SignedBytes, which includes the nonce
+// of the owner so that a signed operation is only executed once. Tokens are
+// issued by the identities listed in "chaincode.token.issuers", by no one
+// when the list is empty. Quantities and balances are returned in decimal.
+type TokenManager struct {
+}
+
+var tscclogger = logging.MustGetLogger("tscc")
+
+// These are function names from Invoke first parameter
+const (
+	Issue      string = "Issue"
+	Transfer   string = "Transfer"
+	Redeem     string = "Redeem"
+	GetBalance string = "GetBalance"
+	GetSupply  string = "GetSupply"
+	GetNonce   string = "GetNonce"
+)
+
+// These are the object types of the composite keys of the state
+const (
+	balanceKey string = "balance"
+	supplyKey  string = "supply"
+	nonceKey   string = "nonce"
+)
+
+// validTokenType matches the names of token types
+var validTokenType = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+// Init is called once per chain when the chain is created.
+// This allows the chaincode to initialize any variables on the ledger prior
+// to any transaction execution on the chain.
+func (t *TokenManager) Init(stub shim.ChaincodeStubInterface) ([]byte, error) {
+	tscclogger.Info("Init TSCC")
+
+	return nil, nil
+}
+
+// Invoke is called with args[0] contains the function name and args[1] the
+// token type, except for GetNonce which takes the owner in args[1]. The
+// other arguments are
+// - Issue: quantity, owner
+// - Transfer: quantity, owner, recipient, signature of the owner
+// - Redeem: quantity, owner, signature of the owner
+// - GetBalance: owner
+func (t *TokenManager) Invoke(stub shim.ChaincodeStubInterface) ([]byte, error) {
+	args := stub.GetArgs()
+
+	if len(args) < 2 {
+		return nil, fmt.Errorf("Incorrect number of arguments, %d", len(args))
+	}
+	fname := string(args[0])
+
+	tscclogger.Debugf("Invoke function: %s", fname)
+
+	if fname == GetNonce {
+		owner, err := getAccount(args[1])
+		if err != nil {
+			return nil, err
+		}
+		nonce, err := getNonce(stub, owner)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(strconv.FormatUint(nonce, 10)), nil
+	}
+
+	tokenType := string(args[1])
+	if !validTokenType.MatchString(tokenType) {
+		return nil, fmt.Errorf("invalid token type %q", tokenType)
+	}
+
+	switch fname {
+	case Issue:
+		if len(args) < 4 {
+			return nil, fmt.Errorf("Issue takes the token type, the quantity and the owner")
+		}
+		return nil, issue(stub, tokenType, string(args[2]), args[3])
+	case Transfer:
+		if len(args) < 6 {
+			return nil, fmt.Errorf("Transfer takes the token type, the quantity, the owner, the recipient and the signature of the owner")
+		}
+		return nil, transfer(stub, tokenType, string(args[2]), args[3], args[4], args[5])
+	case Redeem:
+		if len(args) < 5 {
+			return nil, fmt.Errorf("Redeem takes the token type, the quantity, the owner and the signature of the owner")
+		}
+		return nil, redeem(stub, tokenType, string(args[2]), args[3], args[4])
+	case GetBalance:
+		if len(args) < 3 {
+			return nil, fmt.Errorf("GetBalance takes the token type and the owner")
+		}
+		owner, err := getAccount(args[2])
+		if err != nil {
+			return nil, err
+		}
+		return getAmount(stub, balanceKey, tokenType, owner)
+	case GetSupply:
+		return getAmount(stub, supplyKey, tokenType)
+	}
+
+	return nil, fmt.Errorf("Requested function %s not found.", fname)
+}
+
+// Query is no longer implemented. Will be removed
+func (t *TokenManager) Query(stub shim.ChaincodeStubInterface) ([]byte, error) {
+	return nil, nil
+}
+
+// SignedBytes returns what an owner signs to transfer tokens to a recipient
+// or, with a nil recipient, to redeem them. The nonce is the one returned by
+// GetNonce for the owner
+func SignedBytes(fname string, tokenType string, quantity uint64, recipient []byte, nonce uint64) []byte {
+	var msg []byte
+	msg = append(msg, fname...)
+	msg = append(msg, 0)
+	msg = append(msg, tokenType...)
+	msg = append(msg, 0)
+	msg = strconv.AppendUint(msg, quantity, 10)
+	msg = append(msg, 0)
+	msg = append(msg, recipient...)
+	msg = append(msg, 0)
+	return strconv.AppendUint(msg, nonce, 10)
+}
+
+// issue credits the owner with new tokens
+func issue(stub shim.ChaincodeStubInterface, tokenType string, quantity string, owner []byte) error {
+	if err := checkIssuer(stub); err != nil {
+		return err
+	}
+	amount, err := parseQuantity(quantity)
+	if err != nil {
+		return err
+	}
+	account, err := getAccount(owner)
+	if err != nil {
+		return err
+	}
+
+	if err = add(stub, amount, supplyKey, tokenType); err != nil {
+		return err
+	}
+	return add(stub, amount, balanceKey, tokenType, account)
+}
+
+// transfer moves tokens from the owner to the recipient
+func transfer(stub shim.ChaincodeStubInterface, tokenType string, quantity string, owner []byte, recipient []byte, signature []byte) error {
+	amount, err := parseQuantity(quantity)
+	if err != nil {
+		return err
+	}
+	from, err := checkOwnerSignature(stub, owner, signature, func(nonce uint64) []byte {
+		return SignedBytes(Transfer, tokenType, amount, recipient, nonce)
+	})
+	if err != nil {
+		return err
+	}
+	to, err := getAccount(recipient)
+	if err != nil {
+		return fmt.Errorf("invalid recipient: %s", err)
+	}
+
+	if err = subtract(stub, amount, balanceKey, tokenType, from); err != nil {
+		return err
+	}
+	return add(stub, amount, balanceKey, tokenType, to)
+}
+
+// redeem destroys tokens of the owner
+func redeem(stub shim.ChaincodeStubInterface, tokenType string, quantity string, owner []byte, signature []byte) error {
+	amount, err := parseQuantity(quantity)
+	if err != nil {
+		return err
+	}
+	from, err := checkOwnerSignature(stub, owner, signature, func(nonce uint64) []byte {
+		return SignedBytes(Redeem, tokenType, amount, nil, nonce)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = subtract(stub, amount, balanceKey, tokenType, from); err != nil {
+		return err
+	}
+	return subtract(stub, amount, supplyKey, tokenType)
+}
+
+// checkIssuer checks that the creator of the proposal may issue tokens
+func checkIssuer(stub shim.ChaincodeStubInterface) error {
+	creator, err := stub.GetCreator()
+	if err != nil {
+		return err
+	}
+	if acl.IsListed(viper.GetStringSlice("chaincode.token.issuers"), "chaincode.token.issuers", creator) {
+		return nil
+	}
+	return fmt.Errorf("creator is not permitted to issue tokens")
+}
+
+// checkOwnerSignature verifies the signature of the owner over the bytes
+// signed with the current nonce of the owner, then consumes the nonce. It
+// returns the account of the owner
+func checkOwnerSignature(stub shim.ChaincodeStubInterface, owner []byte, signature []byte, signedBytes func(nonce uint64) []byte) (string, error) {
+	cert, der, err := primitives.PEMtoCertificateAndDER(owner)
+	if err != nil {
+		return "", fmt.Errorf("invalid owner certificate: %s", err)
+	}
+	if _, ok := cert.PublicKey.(*ecdsa.PublicKey); !ok {
+		return "", fmt.Errorf("certificate of owner %s has no ECDSA key", cert.Subject.CommonName)
+	}
+	account := accountOf(der)
+
+	nonce, err := getNonce(stub, account)
+	if err != nil {
+		return "", err
+	}
+	ok, err := primitives.ECDSAVerify(cert.PublicKey, signedBytes(nonce), signature)
+	if err != nil || !ok {
+		return "", fmt.Errorf("invalid signature of owner %s", cert.Subject.CommonName)
+	}
+
+	key, err := stub.CreateCompositeKey(nonceKey, []string{account})
+	if err != nil {
+		return "", err
+	}
+	if err = stub.PutState(key, []byte(strconv.FormatUint(nonce+1, 10))); err != nil {
+		return "", err
+	}
+	return account, nil
+}
+
+// getAccount returns the account of the owner of a PEM encoded certificate
+func getAccount(owner []byte) (string, error) {
+	_, der, err := primitives.PEMtoCertificateAndDER(owner)
+	if err != nil {
+		return "", fmt.Errorf("invalid owner certificate: %s", err)
+	}
+	return accountOf(der), nil
+}
+
+// accountOf returns the account of the owner of a DER encoded certificate,
+// the hex encoded SHA-256 hash of the certificate
+func accountOf(der []byte) string {
+	hash := sha256.Sum256(der)
+	return hex.EncodeToString(hash[:])
+}
+
+// getNonce returns the nonce the account signs its next operation with
+func getNonce(stub shim.ChaincodeStubInterface, account string) (uint64, error) {
+	key, err := stub.CreateCompositeKey(nonceKey, []string{account})
+	if err != nil {
+		return 0, err
+	}
+	return getUint(stub, key)
+}
+
+// parseQuantity parses a positive decimal quantity of tokens
+func parseQuantity(quantity string) (uint64, error) {
+	amount, err := strconv.ParseUint(quantity, 10, 64)
+	if err != nil || amount == 0 {
+		return 0, fmt.Errorf("invalid quantity %q", quantity)
+	}
+	return amount, nil
+}
+
+// getAmount returns the amount of the composite key in decimal
+func getAmount(stub shim.ChaincodeStubInterface, objectType string, attributes ...string) ([]byte, error) {
+	key, err := stub.CreateCompositeKey(objectType, attributes)
+	if err != nil {
+		return nil, err
+	}
+	amount, err := getUint(stub, key)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(strconv.FormatUint(amount, 10)), nil
+}
+
+// add adds to the amount of the composite key
+func add(stub shim.ChaincodeStubInterface, amount uint64, objectType string, attributes ...string) error {
+	key, err := stub.CreateCompositeKey(objectType, attributes)
+	if err != nil {
+		return err
+	}
+	current, err := getUint(stub, key)
+	if err != nil {
+		return err
+	}
+	if current+amount < current {
+		return fmt.Errorf("%s of %s overflows", objectType, attributes[0])
+	}
+	return stub.PutState(key, []byte(strconv.FormatUint(current+amount, 10)))
+}
+
+// subtract subtracts from the amount of the composite key
+func subtract(stub shim.ChaincodeStubInterface, amount uint64, objectType string, attributes ...string) error {
+	key, err := stub.CreateCompositeKey(objectType, attributes)
+	if err != nil {
+		return err
+	}
+	current, err := getUint(stub, key)
+	if err != nil {
+		return err
+	}
+	if current < amount {
+		return fmt.Errorf("insufficient %s of %s, %d held", objectType, attributes[0], current)
+	}
+	if current == amount {
+		return stub.DelState(key)
+	}
+	return stub.PutState(key, []byte(strconv.FormatUint(current-amount, 10)))
+}
+
+// getUint returns the decimal value of a key, 0 when the key is not set
+func getUint(stub shim.ChaincodeStubInterface, key string) (uint64, error) {
+	value, err := stub.GetState(key)
+	if err != nil {
+		return 0, err
+	}
+	if value == nil {
+		return 0, nil
+	}
+	n, err := strconv.ParseUint(string(value), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value of %s: %s", key, err)
+	}
+	return n, nil
+}
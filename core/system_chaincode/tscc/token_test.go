@@ -0,0 +1,129 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tscc
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/core/crypto/primitives"
+	"github.com/spf13/viper"
+)
+
+type owner struct {
+	cert []byte
+	key  interface{}
+}
+
+func newOwner(t *testing.T) *owner {
+	der, key, err := primitives.NewSelfSignedCert()
+	if err != nil {
+		t.Fatalf("Error creating certificate: %s", err)
+	}
+	return &owner{cert: primitives.DERCertToPEM(der), key: key}
+}
+
+func (o *owner) sign(t *testing.T, stub *shim.MockStub, fname string, quantity uint64, recipient *owner) []byte {
+	res, err := stub.MockInvoke("1", [][]byte{[]byte(GetNonce), o.cert})
+	if err != nil {
+		t.Fatalf("tscc GetNonce failed: %s", err)
+	}
+	nonce, _ := strconv.ParseUint(string(res), 10, 64)
+
+	var to []byte
+	if recipient != nil {
+		to = recipient.cert
+	}
+	signature, err := primitives.ECDSASign(o.key, SignedBytes(fname, "coin", quantity, to, nonce))
+	if err != nil {
+		t.Fatalf("Error signing: %s", err)
+	}
+	return signature
+}
+
+func checkAmount(t *testing.T, stub *shim.MockStub, args [][]byte, expected string) {
+	res, err := stub.MockInvoke("1", args)
+	if err != nil {
+		t.Fatalf("tscc %s failed: %s", args[0], err)
+	}
+	if string(res) != expected {
+		t.Fatalf("tscc %s returned %s, expected %s", args[0], res, expected)
+	}
+}
+
+func TestTokens(t *testing.T) {
+	primitives.InitSecurityLevel("SHA2", 256)
+
+	dir, err := ioutil.TempDir("", "tscctest")
+	if err != nil {
+		t.Fatalf("Error creating temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	issuer := filepath.Join(dir, "issuer.pem")
+	ioutil.WriteFile(issuer, []byte("issuer\n"), 0644)
+	viper.Set("chaincode.token.issuers", []string{issuer})
+	defer viper.Set("chaincode.token.issuers", nil)
+
+	stub := shim.NewMockStub("TokenManager", new(TokenManager))
+	alice, bob := newOwner(t), newOwner(t)
+
+	stub.Creator = []byte("other")
+	if _, err = stub.MockInvoke("1", [][]byte{[]byte(Issue), []byte("coin"), []byte("100"), alice.cert}); err == nil {
+		t.Fatalf("tscc Issue should have failed for a creator not an issuer")
+	}
+	stub.Creator = []byte("issuer")
+	for _, quantity := range []string{"0", "-1", "1.5"} {
+		if _, err = stub.MockInvoke("1", [][]byte{[]byte(Issue), []byte("coin"), []byte(quantity), alice.cert}); err == nil {
+			t.Fatalf("tscc Issue should have failed for quantity %s", quantity)
+		}
+	}
+	if _, err = stub.MockInvoke("1", [][]byte{[]byte(Issue), []byte("coin"), []byte("100"), alice.cert}); err != nil {
+		t.Fatalf("tscc Issue failed: %s", err)
+	}
+	checkAmount(t, stub, [][]byte{[]byte(GetBalance), []byte("coin"), alice.cert}, "100")
+	checkAmount(t, stub, [][]byte{[]byte(GetSupply), []byte("coin")}, "100")
+
+	signature := alice.sign(t, stub, Transfer, 30, bob)
+	if _, err = stub.MockInvoke("1", [][]byte{[]byte(Transfer), []byte("coin"), []byte("30"), alice.cert, bob.cert, signature}); err != nil {
+		t.Fatalf("tscc Transfer failed: %s", err)
+	}
+	if _, err = stub.MockInvoke("1", [][]byte{[]byte(Transfer), []byte("coin"), []byte("30"), alice.cert, bob.cert, signature}); err == nil {
+		t.Fatalf("tscc Transfer should have failed when replayed")
+	}
+	checkAmount(t, stub, [][]byte{[]byte(GetBalance), []byte("coin"), alice.cert}, "70")
+	checkAmount(t, stub, [][]byte{[]byte(GetBalance), []byte("coin"), bob.cert}, "30")
+
+	signature = bob.sign(t, stub, Transfer, 30, alice)
+	if _, err = stub.MockInvoke("1", [][]byte{[]byte(Transfer), []byte("coin"), []byte("30"), alice.cert, bob.cert, signature}); err == nil {
+		t.Fatalf("tscc Transfer should have failed when not signed by the owner")
+	}
+	signature = bob.sign(t, stub, Transfer, 31, alice)
+	if _, err = stub.MockInvoke("1", [][]byte{[]byte(Transfer), []byte("coin"), []byte("31"), bob.cert, alice.cert, signature}); err == nil {
+		t.Fatalf("tscc Transfer should have failed for an insufficient balance")
+	}
+
+	signature = bob.sign(t, stub, Redeem, 30, nil)
+	if _, err = stub.MockInvoke("1", [][]byte{[]byte(Redeem), []byte("coin"), []byte("30"), bob.cert, signature}); err != nil {
+		t.Fatalf("tscc Redeem failed: %s", err)
+	}
+	checkAmount(t, stub, [][]byte{[]byte(GetBalance), []byte("coin"), bob.cert}, "0")
+	checkAmount(t, stub, [][]byte{[]byte(GetSupply), []byte("coin")}, "70")
+}
@@ -46,9 +46,9 @@ func (vscc *ValidatorOneValidSignature) Init(stub shim.ChaincodeStubInterface) (
 // selecting which policy to use for validation using parameter function
 // @return serialized Block of valid and invalid transactions indentified
 // Note that Peer calls this function with 2 arguments, where args[0] is the
-// function name and args[1] is the block
+// chain of the block and args[1] is the block
 func (vscc *ValidatorOneValidSignature) Invoke(stub shim.ChaincodeStubInterface) ([]byte, error) {
-	// args[0] - function name (not used now)
+	// args[0] - chain name (not used now)
 	// args[1] - serialized Block object, which contains orderred transactions
 	args := stub.GetArgs()
 	if len(args) < 2 {
@@ -0,0 +1,88 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// traceparentKey is the gRPC metadata key of the trace context
+const traceparentKey = "traceparent"
+
+// OutgoingContext returns a context carrying, in the metadata of the gRPC
+// requests sent with it, the span of ctx, for the server to start its spans
+// as part of it. It returns ctx when ctx doesn't carry a span
+func OutgoingContext(ctx context.Context) context.Context {
+	span := SpanFromContext(ctx)
+	if span == nil {
+		return ctx
+	}
+	md, ok := metadata.FromContext(ctx)
+	if ok {
+		md = md.Copy()
+	} else {
+		md = metadata.MD{}
+	}
+	md[traceparentKey] = []string{SpanContext{TraceID: span.TraceID, SpanID: span.SpanID}.traceparent()}
+	return metadata.NewContext(ctx, md)
+}
+
+// incomingContext returns ctx carrying the trace context found in the
+// metadata of a gRPC request, if any
+func incomingContext(ctx context.Context) context.Context {
+	md, ok := metadata.FromContext(ctx)
+	if !ok || len(md[traceparentKey]) == 0 {
+		return ctx
+	}
+	sc, ok := parseTraceparent(md[traceparentKey][0])
+	if !ok {
+		logger.Debugf("Ignoring invalid trace context %s", md[traceparentKey][0])
+		return ctx
+	}
+	return ContextWithRemoteSpan(ctx, sc)
+}
+
+// UnaryServerInterceptor starts a span for every request, part of the trace
+// of the client when the request carries its trace context
+func UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	span, ctx := StartSpan(incomingContext(ctx), info.FullMethod)
+	resp, err := handler(ctx, req)
+	span.Finish(err)
+	return resp, err
+}
+
+// StreamServerInterceptor starts a span for every stream, part of the trace
+// of the client when the stream carries its trace context
+func StreamServerInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	span, ctx := StartSpan(incomingContext(ss.Context()), info.FullMethod)
+	err := handler(srv, &tracedStream{ServerStream: ss, ctx: ctx})
+	span.Finish(err)
+	return err
+}
+
+// tracedStream is a server stream whose context carries the span of the
+// stream
+type tracedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *tracedStream) Context() context.Context {
+	return s.ctx
+}
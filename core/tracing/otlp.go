@@ -0,0 +1,255 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	// otlpBatchSize is the maximum number of spans of an export request
+	otlpBatchSize = 512
+	// otlpQueueSize is the number of finished spans waiting for their
+	// export, the spans finished while the queue is full are dropped
+	otlpQueueSize = 2048
+	// otlpFlushInterval is the maximum time a finished span waits for its
+	// export
+	otlpFlushInterval = 5 * time.Second
+	// otlpTimeout is the timeout of an export request
+	otlpTimeout = 10 * time.Second
+)
+
+// The messages of the OTLP/HTTP protocol with the JSON encoding, of which
+// the trace and span IDs are hex encoded
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpAttribute struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+const (
+	otlpSpanKindInternal = 1
+	otlpStatusCodeError  = 2
+)
+
+// otlpReporter exports the spans to the traces endpoint of an OpenTelemetry
+// collector, or of any backend accepting OTLP/HTTP with the JSON encoding,
+// in batches of up to otlpBatchSize spans
+type otlpReporter struct {
+	endpoint string
+	service  string
+	client   *http.Client
+	queue    chan *Span
+	flush    chan chan struct{}
+}
+
+// NewOTLPReporter returns a Reporter exporting the spans of the service to
+// the OTLP/HTTP traces endpoint, e.g. http://localhost:4318/v1/traces
+func NewOTLPReporter(endpoint, service string) Reporter {
+	r := &otlpReporter{
+		endpoint: endpoint,
+		service:  service,
+		client:   &http.Client{Timeout: otlpTimeout},
+		queue:    make(chan *Span, otlpQueueSize),
+		flush:    make(chan chan struct{}),
+	}
+	go r.run()
+	return r
+}
+
+func (r *otlpReporter) Report(span *Span) {
+	select {
+	case r.queue <- span:
+	default:
+		logger.Warningf("Dropping span %s, %d spans are waiting for their export already", span.Name, otlpQueueSize)
+	}
+}
+
+// Flush exports the spans reported so far
+func (r *otlpReporter) Flush() {
+	done := make(chan struct{})
+	r.flush <- done
+	<-done
+}
+
+// run exports the spans once otlpBatchSize of them are reported, every
+// otlpFlushInterval, or when flushed
+func (r *otlpReporter) run() {
+	ticker := time.NewTicker(otlpFlushInterval)
+	defer ticker.Stop()
+	var batch []*Span
+	for {
+		select {
+		case span := <-r.queue:
+			if batch = append(batch, span); len(batch) >= otlpBatchSize {
+				r.export(batch)
+				batch = nil
+			}
+		case <-ticker.C:
+			r.export(batch)
+			batch = nil
+		case done := <-r.flush:
+			for len(r.queue) > 0 {
+				batch = append(batch, <-r.queue)
+			}
+			for len(batch) > 0 {
+				n := len(batch)
+				if n > otlpBatchSize {
+					n = otlpBatchSize
+				}
+				r.export(batch[:n])
+				batch = batch[n:]
+			}
+			batch = nil
+			close(done)
+		}
+	}
+}
+
+// export sends the spans in an export request, the failures are logged
+func (r *otlpReporter) export(batch []*Span) {
+	if len(batch) == 0 {
+		return
+	}
+	body, err := json.Marshal(r.newRequest(batch))
+	if err != nil {
+		logger.Warningf("Failed to encode %d spans: %s", len(batch), err)
+		return
+	}
+	resp, err := r.client.Post(r.endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		logger.Warningf("Failed to export %d spans to %s: %s", len(batch), r.endpoint, err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		logger.Warningf("Failed to export %d spans to %s: %s", len(batch), r.endpoint, resp.Status)
+	}
+}
+
+func (r *otlpReporter) newRequest(batch []*Span) *otlpRequest {
+	spans := make([]otlpSpan, 0, len(batch))
+	for _, span := range batch {
+		spans = append(spans, toOTLPSpan(span))
+	}
+	return &otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource: otlpResource{Attributes: []otlpAttribute{
+			{Key: "service.name", Value: otlpAnyValue{StringValue: r.service}},
+		}},
+		ScopeSpans: []otlpScopeSpans{{
+			Scope: otlpScope{Name: "github.com/hyperledger/fabric/core/tracing"},
+			Spans: spans,
+		}},
+	}}}
+}
+
+func toOTLPSpan(span *Span) otlpSpan {
+	span.lock.Lock()
+	defer span.lock.Unlock()
+	s := otlpSpan{
+		TraceID:           span.TraceID,
+		SpanID:            span.SpanID,
+		ParentSpanID:      span.ParentID,
+		Name:              span.Name,
+		Kind:              otlpSpanKindInternal,
+		StartTimeUnixNano: strconv.FormatInt(span.Start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(span.Start.Add(span.Duration).UnixNano(), 10),
+	}
+	keys := make([]string, 0, len(span.Tags))
+	for key := range span.Tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		s.Attributes = append(s.Attributes, otlpAttribute{Key: key, Value: otlpAnyValue{StringValue: span.Tags[key]}})
+	}
+	if span.Error != "" {
+		s.Status = otlpStatus{Code: otlpStatusCodeError, Message: span.Error}
+	}
+	return s
+}
+
+// flusher is a Reporter that buffers the spans
+type flusher interface {
+	Flush()
+}
+
+// Flush exports the spans buffered by the reporter, for the processes to
+// call before they exit
+func Flush() {
+	if f, ok := getReporter().(flusher); ok {
+		f.Flush()
+	}
+}
+
+// checkOTLPEndpoint checks that the traces endpoint is an http or https URL
+func checkOTLPEndpoint(endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("OTLP endpoint %s is not an http or https URL", endpoint)
+	}
+	return nil
+}
@@ -0,0 +1,257 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/op/go-logging"
+	"golang.org/x/net/context"
+)
+
+var logger = logging.MustGetLogger("tracing")
+
+// Span is a timed operation of a trace, such as the processing of a
+// proposal or the commit of a block. The spans of a trace share its TraceID
+// and point to the span they are part of with ParentID. The methods of a nil
+// Span do nothing, it is the span StartSpan returns when tracing is disabled
+type Span struct {
+	TraceID  string            `json:"traceID"`
+	SpanID   string            `json:"spanID"`
+	ParentID string            `json:"parentID,omitempty"`
+	Name     string            `json:"name"`
+	Start    time.Time         `json:"start"`
+	Duration time.Duration     `json:"duration"`
+	Tags     map[string]string `json:"tags,omitempty"`
+	Error    string            `json:"error,omitempty"`
+
+	lock sync.Mutex
+}
+
+// SetTag sets a tag of the span, like the ID of the transaction it handles
+func (s *Span) SetTag(key, value string) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.Tags == nil {
+		s.Tags = make(map[string]string)
+	}
+	s.Tags[key] = value
+}
+
+// Finish ends the span, failed with err when not nil, and reports it
+func (s *Span) Finish(err error) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	s.Duration = time.Since(s.Start)
+	if err != nil {
+		s.Error = err.Error()
+	}
+	s.lock.Unlock()
+	report(s)
+}
+
+// Reporter receives the finished spans
+type Reporter interface {
+	Report(span *Span)
+}
+
+var (
+	reporter     Reporter
+	reporterLock sync.RWMutex
+)
+
+// SetReporter enables tracing with the reporter, or disables it when nil
+func SetReporter(r Reporter) {
+	reporterLock.Lock()
+	defer reporterLock.Unlock()
+	reporter = r
+}
+
+func getReporter() Reporter {
+	reporterLock.RLock()
+	defer reporterLock.RUnlock()
+	return reporter
+}
+
+// Config configures the tracing of a process
+type Config struct {
+	Enabled bool
+	// ServiceName names the process in the spans exported over OTLP
+	ServiceName string
+	// OTLPEndpoint is the URL of the OTLP/HTTP traces endpoint the spans are
+	// exported to, e.g. http://localhost:4318/v1/traces of an OpenTelemetry
+	// collector
+	OTLPEndpoint string
+	// File the spans are written to as JSON lines when OTLPEndpoint is not
+	// set. They are logged when neither is set
+	File string
+}
+
+// Init enables tracing when conf.Enabled is set. The spans are exported over
+// OTLP, written to a file or logged
+func Init(conf Config) error {
+	if !conf.Enabled {
+		SetReporter(nil)
+		return nil
+	}
+	if conf.OTLPEndpoint != "" {
+		if err := checkOTLPEndpoint(conf.OTLPEndpoint); err != nil {
+			return err
+		}
+		SetReporter(NewOTLPReporter(conf.OTLPEndpoint, conf.ServiceName))
+		logger.Infof("Exporting the trace spans to %s", conf.OTLPEndpoint)
+		return nil
+	}
+	if conf.File == "" {
+		SetReporter(&writerReporter{log: true})
+		return nil
+	}
+	f, err := os.OpenFile(conf.File, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("Failed to open trace file %s: %s", conf.File, err)
+	}
+	SetReporter(NewWriterReporter(f))
+	logger.Infof("Writing the trace spans to %s", conf.File)
+	return nil
+}
+
+// writerReporter writes the spans as JSON lines
+type writerReporter struct {
+	lock sync.Mutex
+	w    io.Writer
+	log  bool
+}
+
+// NewWriterReporter returns a Reporter writing the spans to w as JSON lines
+func NewWriterReporter(w io.Writer) Reporter {
+	return &writerReporter{w: w}
+}
+
+func (r *writerReporter) Report(span *Span) {
+	span.lock.Lock()
+	line, err := json.Marshal(span)
+	span.lock.Unlock()
+	if err != nil {
+		logger.Warningf("Failed to encode span %s: %s", span.Name, err)
+		return
+	}
+	if r.log {
+		logger.Infof("%s", line)
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if _, err := r.w.Write(append(line, '\n')); err != nil {
+		logger.Warningf("Failed to write span %s: %s", span.Name, err)
+	}
+}
+
+func report(span *Span) {
+	if r := getReporter(); r != nil {
+		r.Report(span)
+	}
+}
+
+// SpanContext identifies a span, to start spans that are part of it in
+// another process
+type SpanContext struct {
+	TraceID string
+	SpanID  string
+}
+
+// traceparent formats the span context as the traceparent header of the W3C
+// Trace Context specification
+func (sc SpanContext) traceparent() string {
+	return fmt.Sprintf("00-%s-%s-01", sc.TraceID, sc.SpanID)
+}
+
+// parseTraceparent parses a traceparent header of the W3C Trace Context
+// specification
+func parseTraceparent(header string) (SpanContext, bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) != 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return SpanContext{}, false
+	}
+	for _, part := range parts[1:3] {
+		if _, err := hex.DecodeString(part); err != nil || strings.Trim(part, "0") == "" {
+			return SpanContext{}, false
+		}
+	}
+	return SpanContext{TraceID: strings.ToLower(parts[1]), SpanID: strings.ToLower(parts[2])}, true
+}
+
+type spanKey struct{}
+
+type remoteSpanKey struct{}
+
+// ContextWithSpan returns a context carrying the span, the parent of the
+// spans started with the context
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// SpanFromContext returns the span the context carries, or nil
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// ContextWithRemoteSpan returns a context carrying the span context of a
+// span of another process, the parent of the spans started with the context
+// when it doesn't carry a span of this process
+func ContextWithRemoteSpan(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteSpanKey{}, sc)
+}
+
+// StartSpan starts a span that is part of the span the context carries, or
+// the first span of a new trace, and returns it along with a context
+// carrying it. When tracing is disabled it returns a nil span and ctx
+func StartSpan(ctx context.Context, name string) (*Span, context.Context) {
+	if getReporter() == nil {
+		return nil, ctx
+	}
+	span := &Span{Name: name, SpanID: newID(8), Start: time.Now()}
+	if parent := SpanFromContext(ctx); parent != nil {
+		span.TraceID, span.ParentID = parent.TraceID, parent.SpanID
+	} else if remote, ok := ctx.Value(remoteSpanKey{}).(SpanContext); ok {
+		span.TraceID, span.ParentID = remote.TraceID, remote.SpanID
+	} else {
+		span.TraceID = newID(16)
+	}
+	return span, ContextWithSpan(ctx, span)
+}
+
+func newID(size int) string {
+	id := make([]byte, size)
+	if _, err := rand.Read(id); err != nil {
+		logger.Warningf("Failed to generate a span ID: %s", err)
+	}
+	return hex.EncodeToString(id)
+}
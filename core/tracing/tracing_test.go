@@ -0,0 +1,198 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+type recorder struct {
+	lock  sync.Mutex
+	spans []*Span
+}
+
+func (r *recorder) Report(span *Span) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.spans = append(r.spans, span)
+}
+
+func TestDisabled(t *testing.T) {
+	SetReporter(nil)
+	ctx := context.Background()
+	span, spanCtx := StartSpan(ctx, "op")
+	assert.Nil(t, span)
+	assert.Equal(t, ctx, spanCtx)
+	span.SetTag("k", "v")
+	span.Finish(nil)
+	assert.Equal(t, ctx, OutgoingContext(ctx))
+}
+
+func TestSpans(t *testing.T) {
+	r := &recorder{}
+	SetReporter(r)
+	defer SetReporter(nil)
+
+	root, ctx := StartSpan(context.Background(), "root")
+	child, _ := StartSpan(ctx, "child")
+	child.SetTag("txID", "tx1")
+	child.Finish(errors.New("failed"))
+	root.Finish(nil)
+
+	assert.Len(t, r.spans, 2)
+	assert.Equal(t, "child", r.spans[0].Name)
+	assert.Equal(t, root.TraceID, r.spans[0].TraceID)
+	assert.Equal(t, root.SpanID, r.spans[0].ParentID)
+	assert.Equal(t, "failed", r.spans[0].Error)
+	assert.Equal(t, map[string]string{"txID": "tx1"}, r.spans[0].Tags)
+	assert.Equal(t, "", r.spans[1].ParentID)
+	assert.Len(t, root.TraceID, 32)
+	assert.Len(t, root.SpanID, 16)
+}
+
+func TestTraceparent(t *testing.T) {
+	sc, ok := parseTraceparent("00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01")
+	assert.True(t, ok)
+	assert.Equal(t, SpanContext{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpanID: "00f067aa0ba902b7"}, sc)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", sc.traceparent())
+
+	for _, invalid := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902bz-01",
+	} {
+		_, ok := parseTraceparent(invalid)
+		assert.False(t, ok, invalid)
+	}
+}
+
+type testStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestServerInterceptors(t *testing.T) {
+	r := &recorder{}
+	SetReporter(r)
+	defer SetReporter(nil)
+
+	// the client propagates its span in the metadata of its requests
+	client, clientCtx := StartSpan(context.Background(), "client")
+	md, _ := metadata.FromContext(OutgoingContext(clientCtx))
+	serverCtx := metadata.NewContext(context.Background(), md)
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/protos.Endorser/ProcessProposal"}
+	_, err := UnaryServerInterceptor(serverCtx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		span, _ := StartSpan(ctx, "endorse")
+		span.Finish(nil)
+		return nil, nil
+	})
+	assert.NoError(t, err)
+
+	streamInfo := &grpc.StreamServerInfo{FullMethod: "/orderer.AtomicBroadcast/Broadcast"}
+	err = StreamServerInterceptor(nil, &testStream{ctx: serverCtx}, streamInfo, func(srv interface{}, ss grpc.ServerStream) error {
+		assert.NotNil(t, SpanFromContext(ss.Context()))
+		return nil
+	})
+	assert.NoError(t, err)
+
+	assert.Len(t, r.spans, 3)
+	assert.Equal(t, "endorse", r.spans[0].Name)
+	assert.Equal(t, r.spans[1].SpanID, r.spans[0].ParentID)
+	assert.Equal(t, info.FullMethod, r.spans[1].Name)
+	assert.Equal(t, client.SpanID, r.spans[1].ParentID)
+	assert.Equal(t, streamInfo.FullMethod, r.spans[2].Name)
+	assert.Equal(t, client.SpanID, r.spans[2].ParentID)
+	for _, span := range r.spans {
+		assert.Equal(t, client.TraceID, span.TraceID)
+	}
+}
+
+func TestWriterReporter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	SetReporter(NewWriterReporter(buf))
+	defer SetReporter(nil)
+
+	span, _ := StartSpan(context.Background(), "commit")
+	span.SetTag("block", "3")
+	span.Finish(nil)
+
+	reported := &Span{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), reported))
+	assert.Equal(t, "commit", reported.Name)
+	assert.Equal(t, span.TraceID, reported.TraceID)
+	assert.Equal(t, "3", reported.Tags["block"])
+}
+
+func TestOTLPReporter(t *testing.T) {
+	requests := make(chan *otlpRequest, 1)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		req := &otlpRequest{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(req))
+		requests <- req
+	}))
+	defer collector.Close()
+
+	assert.Error(t, Init(Config{Enabled: true, OTLPEndpoint: "localhost:4318"}))
+	assert.NoError(t, Init(Config{Enabled: true, ServiceName: "peer", OTLPEndpoint: collector.URL + "/v1/traces"}))
+	defer SetReporter(nil)
+
+	root, ctx := StartSpan(context.Background(), "root")
+	child, _ := StartSpan(ctx, "child")
+	child.SetTag("ledger", "default")
+	child.SetTag("block", "3")
+	child.Finish(errors.New("failed"))
+	root.Finish(nil)
+	Flush()
+
+	req := <-requests
+	assert.Len(t, req.ResourceSpans, 1)
+	assert.Equal(t, []otlpAttribute{{Key: "service.name", Value: otlpAnyValue{StringValue: "peer"}}},
+		req.ResourceSpans[0].Resource.Attributes)
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+	assert.Len(t, spans, 2)
+	assert.Equal(t, "child", spans[0].Name)
+	assert.Equal(t, root.TraceID, spans[0].TraceID)
+	assert.Equal(t, child.SpanID, spans[0].SpanID)
+	assert.Equal(t, root.SpanID, spans[0].ParentSpanID)
+	assert.Equal(t, []otlpAttribute{
+		{Key: "block", Value: otlpAnyValue{StringValue: "3"}},
+		{Key: "ledger", Value: otlpAnyValue{StringValue: "default"}},
+	}, spans[0].Attributes)
+	assert.Equal(t, otlpStatus{Code: otlpStatusCodeError, Message: "failed"}, spans[0].Status)
+	assert.Equal(t, "", spans[1].ParentSpanID)
+	assert.Equal(t, otlpStatus{}, spans[1].Status)
+}
@@ -80,7 +80,7 @@ func (t *SimpleChaincode) Invoke(stub shim.ChaincodeStubInterface) ([]byte, erro
 
 	f := "invoke"
 	invokeArgs := util.ToChaincodeArgs(f, "a", "b", "10")
-	response, err := stub.InvokeChaincode(chainCodeToCall, invokeArgs)
+	response, err := stub.InvokeChaincode(chainCodeToCall, invokeArgs, "")
 	if err != nil {
 		errStr := fmt.Sprintf("Failed to invoke chaincode. Got error: %s", err.Error())
 		fmt.Printf(errStr)
@@ -49,7 +49,7 @@ func (p *PassthruChaincode) iq(invoke bool, stub shim.ChaincodeStubInterface, fu
 	chaincodeID := function
 
 	if invoke {
-		return stub.InvokeChaincode(chaincodeID, util.ToChaincodeArgs(args...))
+		return stub.InvokeChaincode(chaincodeID, util.ToChaincodeArgs(args...), "")
 	}
 	return stub.QueryChaincode(chaincodeID, util.ToChaincodeArgs(args...))
 }
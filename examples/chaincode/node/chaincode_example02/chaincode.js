@@ -0,0 +1,100 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+
+'use strict';
+
+// fabric-shim is installed globally in the nodeenv image
+const shim = require('fabric-shim');
+
+function getAmount(stub, name) {
+	return stub.getState(name).then((value) => {
+		if (!value || value.length === 0) {
+			throw new Error('Entity not found');
+		}
+		return parseInt(value.toString(), 10);
+	});
+}
+
+const chaincode = {
+	Init(stub) {
+		const args = stub.getFunctionAndParameters().params;
+		if (args.length !== 4) {
+			throw new Error('Incorrect number of arguments. Expecting 4');
+		}
+		const aval = parseInt(args[1], 10);
+		const bval = parseInt(args[3], 10);
+		if (isNaN(aval) || isNaN(bval)) {
+			throw new Error('Expecting integer value for asset holding');
+		}
+		console.log('Aval = %d, Bval = %d', aval, bval);
+
+		return stub.putState(args[0], String(aval)).then(() => {
+			return stub.putState(args[2], String(bval));
+		});
+	},
+
+	// Transaction makes payment of X units from A to B
+	Invoke(stub) {
+		const fp = stub.getFunctionAndParameters();
+		const args = fp.params;
+		if (fp.fcn === 'delete') {
+			if (args.length !== 1) {
+				throw new Error('Incorrect number of arguments. Expecting 1');
+			}
+			return stub.delState(args[0]);
+		}
+
+		if (args.length !== 3) {
+			throw new Error('Incorrect number of arguments. Expecting 3');
+		}
+		const x = parseInt(args[2], 10);
+		if (isNaN(x)) {
+			throw new Error('Invalid transaction amount, expecting a integer value');
+		}
+
+		// the state requests of a transaction are served one at a time
+		let aval;
+		return getAmount(stub, args[0]).then((amount) => {
+			aval = amount - x;
+			return getAmount(stub, args[1]);
+		}).then((amount) => {
+			const bval = amount + x;
+			console.log('Aval = %d, Bval = %d', aval, bval);
+			return stub.putState(args[0], String(aval)).then(() => {
+				return stub.putState(args[1], String(bval));
+			});
+		});
+	},
+
+	Query(stub) {
+		const fp = stub.getFunctionAndParameters();
+		if (fp.fcn !== 'query') {
+			throw new Error('Invalid query function name. Expecting "query"');
+		}
+		if (fp.params.length !== 1) {
+			throw new Error('Incorrect number of arguments. Expecting name of the person to query');
+		}
+		return stub.getState(fp.params[0]).then((value) => {
+			if (!value || value.length === 0) {
+				throw new Error('{"Error":"Nil amount for ' + fp.params[0] + '"}');
+			}
+			return value;
+		});
+	}
+};
+
+shim.start(chaincode);
@@ -17,8 +17,10 @@ limitations under the License.
 package flogging
 
 import (
+	"fmt"
 	"os"
 	"strings"
+	"sync"
 
 	"github.com/op/go-logging"
 	"github.com/spf13/viper"
@@ -31,6 +33,14 @@ var loggingLogger = logging.MustGetLogger("logging")
 // case of configuration errors.
 var loggingDefaultLevel = logging.INFO
 
+// The logging specification last applied by LoggingInit() or
+// SetLoggingSpec()
+var loggingSpec string
+var loggingSpecLock sync.RWMutex
+
+// The file the logs are written to, when "logging.file.path" is set
+var logFile *RotatingFile
+
 // LoggingInit is a 'hook' called at the beginning of command processing to
 // parse logging-related options specified either on the command-line or in
 // config files.  Command-line options take precedence over config file
@@ -39,6 +49,10 @@ var loggingDefaultLevel = logging.INFO
 // module)`.  To debug this routine include logging=debug as the first
 // term of the logging specification.
 func LoggingInit(command string) {
+	if err := initLogFile(); err != nil {
+		loggingLogger.Errorf("Logging to stderr only: %s", err)
+	}
+
 	// Parse the logging specification in the form
 	//     [<module>[,<module>...]=]<level>[:[<module>[,<module>...]=]<level>...]
 	defaultLevel := loggingDefaultLevel
@@ -80,6 +94,61 @@ func LoggingInit(command string) {
 	// Set the default logging level for all modules
 	logging.SetLevel(defaultLevel, "")
 	loggingLogger.Debugf("Setting default logging level to %s for command '%s'", defaultLevel, command)
+
+	loggingSpecLock.Lock()
+	loggingSpec = spec
+	loggingSpecLock.Unlock()
+}
+
+// GetLoggingSpec returns the logging specification last applied to the
+// running process
+func GetLoggingSpec() string {
+	loggingSpecLock.RLock()
+	defer loggingSpecLock.RUnlock()
+	return loggingSpec
+}
+
+// SetLoggingSpec applies a logging specification, in the same form as the
+// logging_level option, to the running process. Unlike LoggingInit() the
+// specification is rejected as a whole if any of its terms is invalid, and
+// modules it does not name keep their current level.
+func SetLoggingSpec(spec string) error {
+	defaultLevel := logging.GetLevel("")
+	overrides := make(map[string]logging.Level)
+	for _, field := range strings.Split(spec, ":") {
+		split := strings.Split(field, "=")
+		switch len(split) {
+		case 1:
+			level, err := logging.LogLevel(field)
+			if err != nil {
+				return fmt.Errorf("Invalid logging level '%s'", field)
+			}
+			defaultLevel = level
+		case 2:
+			level, err := logging.LogLevel(split[1])
+			if err != nil {
+				return fmt.Errorf("Invalid logging level in '%s'", field)
+			}
+			if split[0] == "" {
+				return fmt.Errorf("Invalid logging override '%s' - no module specified", field)
+			}
+			for _, module := range strings.Split(split[0], ",") {
+				overrides[module] = level
+			}
+		default:
+			return fmt.Errorf("Invalid logging override '%s'; Missing ':' ?", field)
+		}
+	}
+
+	loggingSpecLock.Lock()
+	defer loggingSpecLock.Unlock()
+	for module, level := range overrides {
+		logging.SetLevel(level, module)
+	}
+	logging.SetLevel(defaultLevel, "")
+	loggingSpec = spec
+	loggingLogger.Infof("Logging specification set to '%s'", spec)
+	return nil
 }
 
 // DefaultLoggingLevel returns the fallback value for loggers to use if parsing fails
@@ -89,14 +158,59 @@ func DefaultLoggingLevel() logging.Level {
 
 // Initiate 'leveled' logging to stderr.
 func init() {
+	logging.SetBackend(stderrBackend()).SetLevel(loggingDefaultLevel, "")
+}
 
+func stderrBackend() logging.Backend {
 	format := logging.MustStringFormatter(
 		"%{color}%{time:15:04:05.000} [%{module}] %{shortfunc} -> %{level:.4s} %{id:03x}%{color:reset} %{message}",
 	)
 
 	backend := logging.NewLogBackend(os.Stderr, "", 0)
-	backendFormatter := logging.NewBackendFormatter(backend, format)
-	logging.SetBackend(backendFormatter).SetLevel(loggingDefaultLevel, "")
+	return logging.NewBackendFormatter(backend, format)
+}
+
+// initLogFile directs the logs to the file of "logging.file.path", rotated
+// and retained as configured in the logging.file section, in addition to
+// stderr unless "logging.file.stderr" is false. The logs keep going to
+// stderr only when no file is configured
+func initLogFile() error {
+	path := viper.GetString("logging.file.path")
+	if path == "" && logFile == nil {
+		return nil
+	}
+	if logFile != nil {
+		logFile.Close()
+		logFile = nil
+	}
+	if path == "" {
+		logging.SetBackend(stderrBackend())
+		return nil
+	}
+
+	f, err := NewRotatingFile(path, RotationOptions{
+		MaxSize:    int64(viper.GetInt("logging.file.maxSize")) * 1024 * 1024,
+		MaxAge:     viper.GetDuration("logging.file.maxAge"),
+		MaxBackups: viper.GetInt("logging.file.maxBackups"),
+		Retention:  viper.GetDuration("logging.file.retention"),
+	})
+	if err != nil {
+		logging.SetBackend(stderrBackend())
+		return fmt.Errorf("Failed to open log file %s: %s", path, err)
+	}
+	logFile = f
+
+	// no colors in the file
+	format := logging.MustStringFormatter(
+		"%{time:2006-01-02 15:04:05.000 MST} [%{module}] %{shortfunc} -> %{level:.4s} %{id:03x} %{message}",
+	)
+	backends := []logging.Backend{logging.NewBackendFormatter(logging.NewLogBackend(f, "", 0), format)}
+	viper.SetDefault("logging.file.stderr", true)
+	if viper.GetBool("logging.file.stderr") {
+		backends = append(backends, stderrBackend())
+	}
+	logging.SetBackend(backends...)
+	return nil
 }
 
 // GetModuleLogLevel gets the current logging level for the specified module
@@ -186,6 +186,35 @@ func TestSetModuleLoggingLevelInvalid(t *testing.T) {
 	assertModuleLoggingLevel(t, "peer", logging.WARNING)
 }
 
+func TestSetLoggingSpec(t *testing.T) {
+	viper.Reset()
+	viper.Set("logging_level", "info")
+	flogging.LoggingInit("")
+
+	if err := flogging.SetLoggingSpec("core,test=debug:warning"); err != nil {
+		t.Fatalf("Failed to set logging spec: %s", err)
+	}
+
+	assertDefaultLoggingLevel(t, logging.WARNING)
+	assertModuleLoggingLevel(t, "core", logging.DEBUG)
+	assertModuleLoggingLevel(t, "test", logging.DEBUG)
+	assertEquals(t, "core,test=debug:warning", flogging.GetLoggingSpec())
+}
+
+func TestSetLoggingSpecInvalid(t *testing.T) {
+	viper.Reset()
+	viper.Set("logging_level", "info")
+	flogging.LoggingInit("")
+
+	if err := flogging.SetLoggingSpec("core=debug:invalid"); err == nil {
+		t.Fatal("Invalid logging spec should have been rejected")
+	}
+
+	// ensure that no part of the rejected specification was applied
+	assertDefaultLoggingLevel(t, logging.INFO)
+	assertEquals(t, "info", flogging.GetLoggingSpec())
+}
+
 func assertDefaultLoggingLevel(t *testing.T, expectedLevel logging.Level) {
 	assertModuleLoggingLevel(t, "", expectedLevel)
 }
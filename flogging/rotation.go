@@ -0,0 +1,174 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flogging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat is the suffix of the rotated files, which sorts them by
+// the time of their rotation
+const backupTimeFormat = "20060102-150405.000"
+
+// RotationOptions configures when a RotatingFile is rotated and how long
+// the rotated files are kept. A zero value disables the option
+type RotationOptions struct {
+	// MaxSize is the size in bytes above which the file is rotated
+	MaxSize int64
+	// MaxAge is the time after which the file is rotated
+	MaxAge time.Duration
+	// MaxBackups is the number of rotated files kept
+	MaxBackups int
+	// Retention is the time the rotated files are kept
+	Retention time.Duration
+}
+
+// RotatingFile is a writer appending to a file, which is renamed with the
+// time of its rotation as suffix and replaced by a new file once it grew
+// too large or too old
+type RotatingFile struct {
+	path    string
+	options RotationOptions
+	now     func() time.Time
+
+	lock   sync.Mutex
+	file   *os.File
+	size   int64
+	opened time.Time
+}
+
+// NewRotatingFile opens the file at path, creating it and its directory if
+// needed
+func NewRotatingFile(path string, options RotationOptions) (*RotatingFile, error) {
+	f := &RotatingFile{path: path, options: options, now: time.Now}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file, f.size, f.opened = file, info.Size(), f.now()
+	return nil
+}
+
+// Write appends p to the file, rotating it first if p would make it grow
+// beyond MaxSize or the file is older than MaxAge
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.file == nil {
+		return 0, fmt.Errorf("log file %s is closed", f.path)
+	}
+	tooLarge := f.options.MaxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.options.MaxSize
+	tooOld := f.options.MaxAge > 0 && f.now().Sub(f.opened) >= f.options.MaxAge
+	if tooLarge || tooOld {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Rotate rotates the file regardless of its size and age
+func (f *RotatingFile) Rotate() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.rotate()
+}
+
+func (f *RotatingFile) rotate() error {
+	if f.file != nil {
+		if err := f.file.Close(); err != nil {
+			return err
+		}
+		f.file = nil
+	}
+	backup := f.path + "." + f.now().Format(backupTimeFormat)
+	if err := os.Rename(f.path, backup); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+	f.removeBackups()
+	return nil
+}
+
+// removeBackups removes the oldest rotated files beyond MaxBackups and the
+// files rotated longer than Retention ago
+func (f *RotatingFile) removeBackups() {
+	backups, err := filepath.Glob(f.path + ".*")
+	if err != nil {
+		return
+	}
+	now := f.now()
+	rotated := make(map[string]time.Time)
+	var names []string
+	for _, backup := range backups {
+		suffix := strings.TrimPrefix(backup, f.path+".")
+		if rotation, err := time.ParseInLocation(backupTimeFormat, suffix, now.Location()); err == nil {
+			rotated[backup] = rotation
+			names = append(names, backup)
+		}
+	}
+	// newest first
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+
+	for i, backup := range names {
+		expired := f.options.Retention > 0 && now.Sub(rotated[backup]) > f.options.Retention
+		if expired || (f.options.MaxBackups > 0 && i >= f.options.MaxBackups) {
+			// not logged, the logger would write to the file being rotated
+			if err := os.Remove(backup); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to remove rotated log file %s: %s\n", backup, err)
+			}
+		}
+	}
+}
+
+// Close closes the file, the writes that follow fail
+func (f *RotatingFile) Close() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
@@ -0,0 +1,129 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flogging
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/op/go-logging"
+	"github.com/spf13/viper"
+)
+
+func readFile(t *testing.T, path string) string {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %s", path, err)
+	}
+	return string(data)
+}
+
+func TestRotatingFileSize(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "flogging")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "peer.log")
+
+	f, err := NewRotatingFile(path, RotationOptions{MaxSize: 10, MaxBackups: 2})
+	if err != nil {
+		t.Fatalf("Failed to create rotating file: %s", err)
+	}
+	defer f.Close()
+	now := time.Date(2016, 11, 1, 10, 0, 0, 0, time.UTC)
+	f.now = func() time.Time { return now }
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatalf("Failed to write: %s", err)
+		}
+		now = now.Add(time.Second)
+	}
+
+	if content := readFile(t, path); content != "fourth\n" {
+		t.Fatalf("Expected the last line in the file, got %q", content)
+	}
+	backups, _ := filepath.Glob(path + ".*")
+	if len(backups) != 2 {
+		t.Fatalf("Expected 2 rotated files, got %v", backups)
+	}
+	if content := readFile(t, path+".20161101-100003.000"); content != "third\n" {
+		t.Fatalf("Expected the third line in the last rotated file, got %q", content)
+	}
+}
+
+func TestRotatingFileAge(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "flogging")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "logs", "peer.log")
+
+	f, err := NewRotatingFile(path, RotationOptions{MaxAge: time.Hour, Retention: 30 * time.Minute})
+	if err != nil {
+		t.Fatalf("Failed to create rotating file: %s", err)
+	}
+	defer f.Close()
+	now := time.Now()
+	f.now = func() time.Time { return now }
+
+	f.Write([]byte("first\n"))
+	f.Write([]byte("first again\n"))
+	now = now.Add(time.Hour)
+	f.Write([]byte("second\n"))
+
+	backups, _ := filepath.Glob(path + ".*")
+	if len(backups) != 1 || readFile(t, backups[0]) != "first\nfirst again\n" {
+		t.Fatalf("Expected the first hour in a rotated file, got %v", backups)
+	}
+
+	// the rotated file expires after the retention
+	now = now.Add(time.Hour)
+	f.Write([]byte("third\n"))
+	backups, _ = filepath.Glob(path + ".*")
+	if len(backups) != 1 || readFile(t, backups[0]) != "second\n" {
+		t.Fatalf("Expected the expired rotated file to be removed, got %v", backups)
+	}
+}
+
+func TestLogFile(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "flogging")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "peer.log")
+
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("logging.file.path", path)
+	viper.Set("logging.file.stderr", false)
+	LoggingInit("")
+
+	logging.MustGetLogger("flogging-test").Infof("written to the file")
+	if content := readFile(t, path); !strings.Contains(content, "[flogging-test]") || !strings.Contains(content, "written to the file") {
+		t.Fatalf("Expected the log in the file, got %q", content)
+	}
+
+	// without a file the logs go to stderr only
+	viper.Set("logging.file.path", "")
+	LoggingInit("")
+	if logFile != nil {
+		t.Fatal("Expected the log file to be closed")
+	}
+	logging.MustGetLogger("flogging-test").Infof("written to stderr")
+	if content := readFile(t, path); strings.Contains(content, "written to stderr") {
+		t.Fatalf("Expected no more logs in the file, got %q", content)
+	}
+}
@@ -95,6 +95,7 @@ func NewCommInstanceWithServer(port int, sec SecurityProvider, pkID PKIidType, d
 	commInst.connStore = newConnStore(commInst, pkID, commInst.logger)
 
 	if port > 0 {
+		proto.RegisterGossipServer(s, commInst)
 		go func() {
 			commInst.stopWG.Add(1)
 			defer commInst.stopWG.Done()
@@ -102,8 +103,6 @@ func NewCommInstanceWithServer(port int, sec SecurityProvider, pkID PKIidType, d
 		}()
 	}
 
-	proto.RegisterGossipServer(s, commInst)
-
 	commInst.logger.SetLevel(logging.WARNING)
 
 	time.Sleep(time.Duration(200) * time.Millisecond)
@@ -113,7 +112,7 @@ func NewCommInstanceWithServer(port int, sec SecurityProvider, pkID PKIidType, d
 
 // NewCommInstance creates a new comm instance that binds itself to the given gRPC server
 func NewCommInstance(s *grpc.Server, sec SecurityProvider, PKIID PKIidType, dialOpts ...grpc.DialOption) (Comm, error) {
-	commInst, err := NewCommInstanceWithServer(-1, sec, PKIID)
+	commInst, err := NewCommInstanceWithServer(-1, sec, PKIID, dialOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,307 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package election
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hyperledger/fabric/gossip/proto"
+	"github.com/hyperledger/fabric/gossip/util"
+)
+
+/* Leader election elects among the peers of an organization the peers that
+   connect to the orderer and disseminate the blocks to the others.
+
+   A peer that doesn't know of a leader proposes itself and waits for the
+   proposals of the others. If during the election no peer declared itself
+   leader and no peer with a lower ID proposed itself, the peer becomes the
+   leader and declares itself periodically.
+   A follower that didn't receive a declaration for the leader alive threshold
+   starts a new election. A leader that receives a declaration of a peer with
+   a lower ID steps down.
+
+        peer1                peer2 (leader)
+          |                     |
+          |---- Proposal ------>|
+          |<--- Declaration ----|
+          |                     |
+*/
+
+var startupGracePeriod = time.Duration(15) * time.Second
+var membershipSampleInterval = time.Duration(1) * time.Second
+var leaderAliveThreshold = time.Duration(10) * time.Second
+var leaderElectionDuration = time.Duration(5) * time.Second
+
+// SetStartupGracePeriod sets the maximum time to wait for the membership to
+// stabilize before the first election
+func SetStartupGracePeriod(t time.Duration) {
+	startupGracePeriod = t
+}
+
+// SetMembershipSampleInterval sets the interval between the samples of the
+// membership while waiting for it to stabilize
+func SetMembershipSampleInterval(t time.Duration) {
+	membershipSampleInterval = t
+}
+
+// SetLeaderAliveThreshold sets the time after which a follower that didn't
+// receive a declaration of the leader starts a new election
+func SetLeaderAliveThreshold(t time.Duration) {
+	leaderAliveThreshold = t
+}
+
+// SetLeaderElectionDuration sets the time a peer waits for the proposals of
+// the others after proposing itself
+func SetLeaderElectionDuration(t time.Duration) {
+	leaderElectionDuration = t
+}
+
+// LeaderElectionAdapter is used by the leader election service to send and
+// receive the leadership messages of the peers of the organization
+type LeaderElectionAdapter interface {
+	// Gossip sends a leadership message to the peers of the organization
+	Gossip(msg *proto.LeadershipMessage)
+
+	// Accept returns a channel of the leadership messages of the other peers
+	// of the organization
+	Accept() <-chan *proto.LeadershipMessage
+
+	// Peers returns the IDs of the alive peers of the organization
+	Peers() []string
+}
+
+// LeaderElectionService elects a leader among the peers of an organization
+type LeaderElectionService interface {
+	// IsLeader returns whether this peer is the leader
+	IsLeader() bool
+
+	// Stop stops the election service
+	Stop()
+}
+
+// NewLeaderElectionService starts the election of a leader among the peers of
+// the adapter. The callback is called with true when the peer becomes the
+// leader and with false when it steps down, and must not block
+func NewLeaderElectionService(adapter LeaderElectionAdapter, id string, callback func(bool)) LeaderElectionService {
+	le := &leaderElectionSvcImpl{
+		id:        id,
+		adapter:   adapter,
+		callback:  callback,
+		proposals: make(map[string]struct{}),
+		stopChan:  make(chan struct{}),
+		logger:    util.GetLogger("election", id),
+	}
+	le.stopWG.Add(2)
+	go le.handleMessages()
+	go le.run()
+	return le
+}
+
+type leaderElectionSvcImpl struct {
+	id       string
+	adapter  LeaderElectionAdapter
+	callback func(bool)
+	isLeader int32
+
+	lock            sync.Mutex
+	proposals       map[string]struct{}
+	lastDeclaration time.Time
+	lowerLeader     bool
+
+	stopChan chan struct{}
+	stopWG   sync.WaitGroup
+	logger   *util.Logger
+}
+
+// IsLeader returns whether this peer is the leader
+func (le *leaderElectionSvcImpl) IsLeader() bool {
+	return atomic.LoadInt32(&le.isLeader) == 1
+}
+
+// Stop stops the election service
+func (le *leaderElectionSvcImpl) Stop() {
+	close(le.stopChan)
+	le.stopWG.Wait()
+}
+
+func (le *leaderElectionSvcImpl) handleMessages() {
+	defer le.stopWG.Done()
+	msgs := le.adapter.Accept()
+	for {
+		select {
+		case <-le.stopChan:
+			return
+		case msg, ok := <-msgs:
+			if !ok {
+				return
+			}
+			le.handleMessage(msg)
+		}
+	}
+}
+
+func (le *leaderElectionSvcImpl) handleMessage(msg *proto.LeadershipMessage) {
+	sender := string(msg.PkiID)
+	if sender == le.id {
+		return
+	}
+
+	le.lock.Lock()
+	defer le.lock.Unlock()
+
+	if !msg.IsDeclaration {
+		le.proposals[sender] = struct{}{}
+		// let the proposing peer know of the leader before its election ends
+		if le.IsLeader() {
+			le.adapter.Gossip(le.newMessage(true))
+		} else if sender > le.id && time.Since(le.lastDeclaration) >= leaderAliveThreshold {
+			// make the proposing peer lose the election to this one
+			le.adapter.Gossip(le.newMessage(false))
+		}
+		return
+	}
+
+	le.lastDeclaration = time.Now()
+	if sender < le.id {
+		le.lowerLeader = true
+	}
+}
+
+func (le *leaderElectionSvcImpl) run() {
+	defer le.stopWG.Done()
+	if !le.waitForMembershipStabilization() {
+		return
+	}
+
+	for !le.stopping() {
+		if le.IsLeader() {
+			le.lead()
+			continue
+		}
+		if !le.hasLeader() {
+			le.elect()
+		}
+		if !le.IsLeader() {
+			le.sleep(leaderAliveThreshold / 2)
+		}
+	}
+}
+
+// waitForMembershipStabilization waits until the number of alive peers stops
+// changing or the startup grace period ends. Returns false if stopped meanwhile
+func (le *leaderElectionSvcImpl) waitForMembershipStabilization() bool {
+	deadline := time.Now().Add(startupGracePeriod)
+	count := len(le.adapter.Peers())
+	for time.Now().Before(deadline) {
+		if !le.sleep(membershipSampleInterval) {
+			return false
+		}
+		newCount := len(le.adapter.Peers())
+		if newCount == count {
+			return true
+		}
+		count = newCount
+	}
+	return !le.stopping()
+}
+
+func (le *leaderElectionSvcImpl) hasLeader() bool {
+	le.lock.Lock()
+	defer le.lock.Unlock()
+	return time.Since(le.lastDeclaration) < leaderAliveThreshold
+}
+
+// elect proposes the peer as leader, and makes it the leader if no peer
+// declared itself leader and no peer with a lower ID proposed itself meanwhile
+func (le *leaderElectionSvcImpl) elect() {
+	le.lock.Lock()
+	le.proposals = make(map[string]struct{})
+	start := time.Now()
+	le.lock.Unlock()
+
+	le.logger.Debug("Proposing", le.id, "as leader")
+	le.adapter.Gossip(le.newMessage(false))
+	if !le.sleep(leaderElectionDuration) {
+		return
+	}
+
+	le.lock.Lock()
+	defer le.lock.Unlock()
+	if le.lastDeclaration.After(start) {
+		return
+	}
+	for id := range le.proposals {
+		if id < le.id {
+			return
+		}
+	}
+	le.lowerLeader = false
+	le.setLeader(true)
+	le.adapter.Gossip(le.newMessage(true))
+}
+
+// lead declares the peer as leader and steps down if a peer with a lower ID
+// declared itself leader
+func (le *leaderElectionSvcImpl) lead() {
+	le.adapter.Gossip(le.newMessage(true))
+	stopped := !le.sleep(leaderAliveThreshold / 2)
+
+	le.lock.Lock()
+	defer le.lock.Unlock()
+	if stopped {
+		return
+	}
+	if le.lowerLeader {
+		le.logger.Info(le.id, "steps down as leader")
+		le.setLeader(false)
+	}
+}
+
+func (le *leaderElectionSvcImpl) setLeader(isLeader bool) {
+	if isLeader {
+		le.logger.Info(le.id, "is the leader")
+		atomic.StoreInt32(&le.isLeader, 1)
+	} else {
+		atomic.StoreInt32(&le.isLeader, 0)
+	}
+	le.callback(isLeader)
+}
+
+func (le *leaderElectionSvcImpl) newMessage(isDeclaration bool) *proto.LeadershipMessage {
+	return &proto.LeadershipMessage{PkiID: []byte(le.id), IsDeclaration: isDeclaration}
+}
+
+// sleep waits for the given duration, returns false if stopped meanwhile
+func (le *leaderElectionSvcImpl) sleep(d time.Duration) bool {
+	select {
+	case <-le.stopChan:
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+func (le *leaderElectionSvcImpl) stopping() bool {
+	select {
+	case <-le.stopChan:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,179 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package election
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/gossip/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	SetStartupGracePeriod(time.Duration(500) * time.Millisecond)
+	SetMembershipSampleInterval(time.Duration(100) * time.Millisecond)
+	SetLeaderAliveThreshold(time.Duration(1000) * time.Millisecond)
+	SetLeaderElectionDuration(time.Duration(500) * time.Millisecond)
+}
+
+// bus delivers the leadership messages of the peers connected to it
+type bus struct {
+	sync.Mutex
+	peers map[string]*peerMock
+}
+
+type peerMock struct {
+	id     string
+	bus    *bus
+	msgs   chan *proto.LeadershipMessage
+	leader chan bool
+	svc    LeaderElectionService
+}
+
+func (p *peerMock) Gossip(msg *proto.LeadershipMessage) {
+	p.bus.Lock()
+	defer p.bus.Unlock()
+	for id, peer := range p.bus.peers {
+		if id != p.id {
+			select {
+			case peer.msgs <- msg:
+			default:
+			}
+		}
+	}
+}
+
+func (p *peerMock) Accept() <-chan *proto.LeadershipMessage {
+	return p.msgs
+}
+
+func (p *peerMock) Peers() []string {
+	p.bus.Lock()
+	defer p.bus.Unlock()
+	var peers []string
+	for id := range p.bus.peers {
+		peers = append(peers, id)
+	}
+	return peers
+}
+
+func (b *bus) join(id string) *peerMock {
+	p := &peerMock{id: id, bus: b, msgs: make(chan *proto.LeadershipMessage, 100), leader: make(chan bool, 10)}
+	b.Lock()
+	b.peers[id] = p
+	b.Unlock()
+	p.svc = NewLeaderElectionService(p, id, func(isLeader bool) {
+		p.leader <- isLeader
+	})
+	return p
+}
+
+func (b *bus) leave(p *peerMock) {
+	b.Lock()
+	delete(b.peers, p.id)
+	b.Unlock()
+	p.svc.Stop()
+}
+
+func leaders(peers []*peerMock) []string {
+	var ids []string
+	for _, p := range peers {
+		if p.svc.IsLeader() {
+			ids = append(ids, p.id)
+		}
+	}
+	return ids
+}
+
+func waitForLeaders(t *testing.T, peers []*peerMock, expected []string) {
+	deadline := time.Now().Add(time.Duration(10) * time.Second)
+	for time.Now().Before(deadline) {
+		if fmt.Sprint(leaders(peers)) == fmt.Sprint(expected) {
+			return
+		}
+		time.Sleep(time.Duration(100) * time.Millisecond)
+	}
+	assert.Equal(t, expected, leaders(peers), "Unexpected leaders")
+}
+
+func TestSingleLeader(t *testing.T) {
+	b := &bus{peers: make(map[string]*peerMock)}
+	var peers []*peerMock
+	for i := 0; i < 4; i++ {
+		peers = append(peers, b.join(fmt.Sprintf("p%d", i)))
+	}
+
+	waitForLeaders(t, peers, []string{"p0"})
+	assert.True(t, <-peers[0].leader, "p0 should have been notified of its election")
+	// the leadership is stable
+	time.Sleep(leaderAliveThreshold * 2)
+	assert.Equal(t, []string{"p0"}, leaders(peers))
+	for _, p := range peers[1:] {
+		assert.Len(t, p.leader, 0, "%s should not have been notified", p.id)
+	}
+
+	for _, p := range peers {
+		b.leave(p)
+	}
+}
+
+func TestLeaderFailover(t *testing.T) {
+	b := &bus{peers: make(map[string]*peerMock)}
+	var peers []*peerMock
+	for i := 0; i < 3; i++ {
+		peers = append(peers, b.join(fmt.Sprintf("p%d", i)))
+	}
+	waitForLeaders(t, peers, []string{"p0"})
+
+	// a follower takes over once the leader is gone
+	b.leave(peers[0])
+	peers = peers[1:]
+	waitForLeaders(t, peers, []string{"p1"})
+	assert.True(t, <-peers[0].leader, "p1 should have been notified of its election")
+
+	// a new peer doesn't take over from the leader
+	peers = append(peers, b.join("p0"))
+	time.Sleep(startupGracePeriod + leaderAliveThreshold*2)
+	assert.Equal(t, []string{"p1"}, leaders(peers))
+
+	for _, p := range peers {
+		b.leave(p)
+	}
+}
+
+func TestLowerLeaderTakesOver(t *testing.T) {
+	// two partitions elect their own leader
+	b1 := &bus{peers: make(map[string]*peerMock)}
+	b2 := &bus{peers: make(map[string]*peerMock)}
+	p0, p1 := b1.join("p0"), b2.join("p1")
+	waitForLeaders(t, []*peerMock{p0, p1}, []string{"p0", "p1"})
+
+	// once the partitions merge the leader with the higher ID steps down
+	b1.Lock()
+	b1.peers["p1"] = p1
+	b1.Unlock()
+	p1.bus = b1
+	waitForLeaders(t, []*peerMock{p0, p1}, []string{"p0"})
+	assert.True(t, <-p1.leader)
+	assert.False(t, <-p1.leader, "p1 should have been notified it stepped down")
+
+	b1.leave(p0)
+	b1.leave(p1)
+}
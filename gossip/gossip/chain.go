@@ -0,0 +1,200 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gossip
+
+import (
+	"github.com/hyperledger/fabric/gossip/comm"
+	"github.com/hyperledger/fabric/gossip/gossip/algo"
+	"github.com/hyperledger/fabric/gossip/proto"
+)
+
+// chainState holds the data messages of a chain the peer joined,
+// and pulls the data messages of the chain from other peers that joined it
+type chainState struct {
+	chainID  []byte
+	g        *gossipServiceImpl
+	msgStore messageStore
+	pushPull *algo.PullEngine
+}
+
+// JoinChain makes the peer store, forward and pull the data messages of a chain
+func (g *gossipServiceImpl) JoinChain(chainID []byte) {
+	g.chainsLock.Lock()
+	defer g.chainsLock.Unlock()
+
+	if _, exists := g.chains[string(chainID)]; exists {
+		return
+	}
+
+	cs := &chainState{
+		chainID: chainID,
+		g:       g,
+	}
+	cs.msgStore = newMessageStore(g.invalidationPolicy, func(m interface{}) {
+		if dataMsg := m.(*proto.GossipMessage).GetDataMsg(); dataMsg != nil {
+			cs.pushPull.Remove(dataMsg.Payload.SeqNum)
+		}
+	})
+	cs.pushPull = algo.NewPullEngine(cs, g.conf.PullInterval)
+	g.chains[string(chainID)] = cs
+}
+
+// getChain returns the state of a joined chain, or nil if the chain wasn't joined
+func (g *gossipServiceImpl) getChain(chainID []byte) *chainState {
+	g.chainsLock.RLock()
+	defer g.chainsLock.RUnlock()
+	return g.chains[string(chainID)]
+}
+
+// pushPullChainID returns the chain of a push-pull message and whether the message is one
+func pushPullChainID(msg *proto.GossipMessage) ([]byte, bool) {
+	if hello := msg.GetHello(); hello != nil {
+		return hello.ChainID, true
+	}
+	if digest := msg.GetDataDig(); digest != nil {
+		return digest.ChainID, true
+	}
+	if req := msg.GetDataReq(); req != nil {
+		return req.ChainID, true
+	}
+	if res := msg.GetDataUpdate(); res != nil {
+		return res.ChainID, true
+	}
+	return nil, false
+}
+
+func (cs *chainState) SelectPeers() []string {
+	if cs.g.disc == nil {
+		return []string{}
+	}
+	peers := selectEndpoints(cs.g.conf.PullPeerNum, cs.g.disc.GetMembership())
+	cs.g.logger.Debug("Selected", len(peers), "peers")
+	return peers
+}
+
+func (cs *chainState) Hello(dest string, nonce uint64) {
+	helloMsg := &proto.GossipMessage{
+		Nonce: 0,
+		Content: &proto.GossipMessage_Hello{
+			Hello: &proto.GossipHello{
+				Nonce:   nonce,
+				ChainID: cs.chainID,
+			},
+		},
+	}
+
+	cs.g.logger.Debug("Sending hello to", dest)
+	cs.g.comm.Send(helloMsg, cs.g.peersWithEndpoints(dest)...)
+}
+
+func (cs *chainState) SendDigest(digest []uint64, nonce uint64, context interface{}) {
+	digMsg := &proto.GossipMessage{
+		Nonce: 0,
+		Content: &proto.GossipMessage_DataDig{
+			DataDig: &proto.DataDigest{
+				Nonce:   nonce,
+				SeqMap:  digest,
+				ChainID: cs.chainID,
+			},
+		},
+	}
+	cs.g.logger.Debug("Sending digest", digMsg.GetDataDig().SeqMap)
+	context.(comm.ReceivedMessage).Respond(digMsg)
+}
+
+func (cs *chainState) SendReq(dest string, items []uint64, nonce uint64) {
+	req := &proto.GossipMessage{
+		Nonce: 0,
+		Content: &proto.GossipMessage_DataReq{
+			DataReq: &proto.DataRequest{
+				Nonce:   nonce,
+				SeqMap:  items,
+				ChainID: cs.chainID,
+			},
+		},
+	}
+	cs.g.logger.Debug("Sending", req, "to", dest)
+	cs.g.comm.Send(req, cs.g.peersWithEndpoints(dest)...)
+}
+
+func (cs *chainState) SendRes(requestedItems []uint64, context interface{}, nonce uint64) {
+	itemMap := make(map[uint64]*proto.DataMessage)
+	for _, msg := range cs.msgStore.get() {
+		if dataMsg := msg.(*proto.GossipMessage).GetDataMsg(); dataMsg != nil {
+			itemMap[dataMsg.Payload.SeqNum] = dataMsg
+		}
+	}
+
+	dataMsgs := []*proto.DataMessage{}
+
+	for _, item := range requestedItems {
+		if dataMsg, exists := itemMap[item]; exists {
+			dataMsgs = append(dataMsgs, dataMsg)
+		}
+	}
+
+	returnedUpdate := &proto.GossipMessage{
+		Nonce: 0,
+		Content: &proto.GossipMessage_DataUpdate{
+			DataUpdate: &proto.DataUpdate{
+				Nonce:   nonce,
+				Data:    dataMsgs,
+				ChainID: cs.chainID,
+			},
+		},
+	}
+
+	cs.g.logger.Debug("Sending response", returnedUpdate.GetDataUpdate().Data)
+	context.(comm.ReceivedMessage).Respond(returnedUpdate)
+}
+
+func (cs *chainState) handlePushPullMsg(msg comm.ReceivedMessage) {
+	cs.g.logger.Debug(msg)
+	if helloMsg := msg.GetGossipMessage().GetHello(); helloMsg != nil {
+		cs.pushPull.OnHello(helloMsg.Nonce, msg)
+	}
+	if digest := msg.GetGossipMessage().GetDataDig(); digest != nil {
+		cs.pushPull.OnDigest(digest.SeqMap, digest.Nonce, msg)
+	}
+	if req := msg.GetGossipMessage().GetDataReq(); req != nil {
+		cs.pushPull.OnReq(req.SeqMap, req.Nonce, msg)
+	}
+	if res := msg.GetGossipMessage().GetDataUpdate(); res != nil {
+		items := make([]uint64, len(res.Data))
+		for i, data := range res.Data {
+			// Data of another chain doesn't belong in the response
+			if string(data.ChainID) != string(cs.chainID) {
+				continue
+			}
+			dataMsg := &proto.GossipMessage{
+				Content: &proto.GossipMessage_DataMsg{
+					DataMsg: data,
+				},
+				Nonce: msg.GetGossipMessage().Nonce,
+			}
+			added := cs.msgStore.add(dataMsg)
+			// if we can't add the message to the msgStore,
+			// no point in disseminating it to others...
+			if !added {
+				continue
+			}
+			cs.g.DeMultiplex(dataMsg)
+			items[i] = data.Payload.SeqNum
+		}
+		cs.pushPull.OnRes(items, res.Nonce)
+	}
+}
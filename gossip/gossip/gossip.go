@@ -39,6 +39,31 @@ type Gossip interface {
 	// Accept returns a channel that outputs messages from other peers
 	Accept(util.MessageAcceptor) <-chan *proto.GossipMessage
 
+	// JoinChain makes the peer store, forward and pull the data messages of a chain.
+	// Data messages of chains the peer didn't join are dropped, those of the default
+	// chain (without chain ID) are always handled
+	JoinChain(chainID []byte)
+
+	// SendPrivateData sends private data to the given peers only, without forwarding it,
+	// and returns the endpoints of the peers that acknowledged storing it before the timeout
+	SendPrivateData(msg *proto.PrivateDataMessage, timeout time.Duration, endpoints ...string) []string
+
+	// StorePrivateData stores private data of a chain the peer joined
+	StorePrivateData(msg *proto.PrivateDataMessage)
+
+	// PrivateData returns the private data of a transaction for a collection,
+	// or nil if the peer doesn't hold it
+	PrivateData(chainID []byte, txID string, collection string) []byte
+
+	// ServeState makes the peer answer the state requests of other peers for the
+	// blocks of a chain it joined with the blocks returned by getBlock, which
+	// returns nil for the blocks the peer doesn't have
+	ServeState(chainID []byte, getBlock func(seqNum uint64) *proto.Payload)
+
+	// RequestState requests from the peer at endpoint the blocks of a chain with sequence
+	// numbers from startSeqNum to endSeqNum, and returns those it sent before the timeout
+	RequestState(chainID []byte, startSeqNum, endSeqNum uint64, timeout time.Duration, endpoint string) []*proto.Payload
+
 	// Stop stops the gossip component
 	Stop()
 }
@@ -24,7 +24,6 @@ import (
 
 	"github.com/hyperledger/fabric/gossip/comm"
 	"github.com/hyperledger/fabric/gossip/discovery"
-	"github.com/hyperledger/fabric/gossip/gossip/algo"
 	"github.com/hyperledger/fabric/gossip/proto"
 	"github.com/hyperledger/fabric/gossip/util"
 	"github.com/op/go-logging"
@@ -42,7 +41,10 @@ type gossipServiceImpl struct {
 	stopFlag    int32
 	msgStore    messageStore
 	emitter     batchingEmitter
-	pushPull    *algo.PullEngine
+	chains      map[string]*chainState
+	chainsLock  sync.RWMutex
+	privData    *privateDataStore
+	state       *stateTransfer
 	goRoutines  []uint64
 	discAdapter *discoveryAdapter
 }
@@ -60,6 +62,9 @@ func NewGossipService(conf *Config, c comm.Comm, crypto discovery.CryptoService)
 		stopFlag:             int32(0),
 		stopSignal:           &sync.WaitGroup{},
 		goRoutines:           make([]uint64, 0),
+		chains:               make(map[string]*chainState),
+		privData:             newPrivateDataStore(conf.MaxMessageCountToStore),
+		state:                newStateTransfer(),
 	}
 
 	g.emitter = newBatchingEmitter(conf.PropagateIterations,
@@ -72,13 +77,10 @@ func NewGossipService(conf *Config, c comm.Comm, crypto discovery.CryptoService)
 		Endpoint: conf.SelfEndpoint, PKIid: discovery.PKIidType(g.comm.GetPKIid()), Metadata: []byte{},
 	}, g.discAdapter, crypto)
 
-	g.pushPull = algo.NewPullEngine(g, conf.PullInterval)
+	// Data messages without a chain belong to the default chain, which every peer joins
+	g.JoinChain(nil)
 
-	g.msgStore = newMessageStore(g.invalidationPolicy, func(m interface{}) {
-		if dataMsg, isDataMsg := m.(*proto.DataMessage); isDataMsg {
-			g.pushPull.Remove(dataMsg.Payload.SeqNum)
-		}
-	})
+	g.msgStore = newMessageStore(g.invalidationPolicy, func(m interface{}) {})
 
 	g.logger.SetLevel(logging.WARNING)
 
@@ -101,6 +103,10 @@ func (g *gossipServiceImpl) invalidationPolicy(this interface{}, that interface{
 		return aliveInvalidationPolicy(thisAliveMsg, thatAliveMsg)
 	}
 
+	if thisMsg.GetLeadershipMsg() != nil && thatMsg.GetLeadershipMsg() != nil {
+		return leadershipInvalidationPolicy(thisMsg.GetLeadershipMsg(), thatMsg.GetLeadershipMsg())
+	}
+
 	thisDataMsg, thisIsDataMessage := thisMsg.GetDataMsg(), thisMsg.GetDataMsg() != nil
 	thatDataMsg, thatIsDataMessage := thatMsg.GetDataMsg(), thatMsg.GetDataMsg() != nil
 
@@ -146,6 +152,24 @@ func aliveInvalidationPolicy(thisMsg *proto.AliveMessage, thatMsg *proto.AliveMe
 	return messageInvalidates
 }
 
+// leadershipInvalidationPolicy keeps the latest leadership message of each peer
+func leadershipInvalidationPolicy(thisMsg *proto.LeadershipMessage, thatMsg *proto.LeadershipMessage) invalidationResult {
+	if !equalPKIIds(thisMsg.PkiID, thatMsg.PkiID) {
+		return messageNoAction
+	}
+
+	if thisMsg.Timestamp.IncNumber == thatMsg.Timestamp.IncNumber {
+		if thisMsg.Timestamp.SeqNum > thatMsg.Timestamp.SeqNum {
+			return messageInvalidates
+		}
+		return messageInvalidated
+	}
+	if thisMsg.Timestamp.IncNumber < thatMsg.Timestamp.IncNumber {
+		return messageInvalidated
+	}
+	return messageInvalidates
+}
+
 func (g *gossipServiceImpl) handlePresumedDead() {
 	defer g.logger.Debug("Exiting")
 	g.stopSignal.Add(1)
@@ -212,88 +236,6 @@ func (g *gossipServiceImpl) acceptMessages(incMsgs <-chan comm.ReceivedMessage)
 	}
 }
 
-func (g *gossipServiceImpl) SelectPeers() []string {
-	if g.disc == nil {
-		return []string{}
-	}
-	peers := selectEndpoints(g.conf.PullPeerNum, g.disc.GetMembership())
-	g.logger.Debug("Selected", len(peers), "peers")
-	return peers
-}
-
-func (g *gossipServiceImpl) Hello(dest string, nonce uint64) {
-	helloMsg := &proto.GossipMessage{
-		Nonce: 0,
-		Content: &proto.GossipMessage_Hello{
-			Hello: &proto.GossipHello{
-				Nonce: nonce,
-			},
-		},
-	}
-
-	g.logger.Debug("Sending hello to", dest)
-	g.comm.Send(helloMsg, g.peersWithEndpoints(dest)...)
-
-}
-
-func (g *gossipServiceImpl) SendDigest(digest []uint64, nonce uint64, context interface{}) {
-	digMsg := &proto.GossipMessage{
-		Nonce: 0,
-		Content: &proto.GossipMessage_DataDig{
-			DataDig: &proto.DataDigest{
-				Nonce:  nonce,
-				SeqMap: digest,
-			},
-		},
-	}
-	g.logger.Debug("Sending digest", digMsg.GetDataDig().SeqMap)
-	context.(comm.ReceivedMessage).Respond(digMsg)
-}
-
-func (g *gossipServiceImpl) SendReq(dest string, items []uint64, nonce uint64) {
-	req := &proto.GossipMessage{
-		Nonce: 0,
-		Content: &proto.GossipMessage_DataReq{
-			DataReq: &proto.DataRequest{
-				Nonce:  nonce,
-				SeqMap: items,
-			},
-		},
-	}
-	g.logger.Debug("Sending", req, "to", dest)
-	g.comm.Send(req, g.peersWithEndpoints(dest)...)
-}
-
-func (g *gossipServiceImpl) SendRes(requestedItems []uint64, context interface{}, nonce uint64) {
-	itemMap := make(map[uint64]*proto.DataMessage)
-	for _, msg := range g.msgStore.get() {
-		if dataMsg := msg.(*proto.GossipMessage).GetDataMsg(); dataMsg != nil {
-			itemMap[dataMsg.Payload.SeqNum] = dataMsg
-		}
-	}
-
-	dataMsgs := []*proto.DataMessage{}
-
-	for _, item := range requestedItems {
-		if dataMsg, exists := itemMap[item]; exists {
-			dataMsgs = append(dataMsgs, dataMsg)
-		}
-	}
-
-	returnedUpdate := &proto.GossipMessage{
-		Nonce: 0,
-		Content: &proto.GossipMessage_DataUpdate{
-			DataUpdate: &proto.DataUpdate{
-				Nonce: nonce,
-				Data:  dataMsgs,
-			},
-		},
-	}
-
-	g.logger.Debug("Sending response", returnedUpdate.GetDataUpdate().Data)
-	context.(comm.ReceivedMessage).Respond(returnedUpdate)
-}
-
 func (g *gossipServiceImpl) handleMessage(msg comm.ReceivedMessage) {
 	if g.toDie() {
 		return
@@ -309,7 +251,7 @@ func (g *gossipServiceImpl) handleMessage(msg comm.ReceivedMessage) {
 	}
 
 	// TODO: add only validated alive messages!
-	if msg.GetGossipMessage().GetAliveMsg() != nil || msg.GetGossipMessage().GetDataMsg() != nil {
+	if msg.GetGossipMessage().GetAliveMsg() != nil {
 		added := g.msgStore.add(msg.GetGossipMessage())
 		if !added {
 			g.logger.Debug("Didn't add", msg, "to store")
@@ -317,18 +259,58 @@ func (g *gossipServiceImpl) handleMessage(msg comm.ReceivedMessage) {
 		}
 
 		g.emitter.Add(msg.GetGossipMessage())
+		return
+	}
+
+	if dataMsg := msg.GetGossipMessage().GetDataMsg(); dataMsg != nil {
+		// Data messages of chains the peer didn't join are neither stored nor forwarded
+		cs := g.getChain(dataMsg.ChainID)
+		if cs == nil {
+			g.logger.Debug("Dropping", msg, "of chain", string(dataMsg.ChainID), "which wasn't joined")
+			return
+		}
+
+		added := cs.msgStore.add(msg.GetGossipMessage())
+		if !added {
+			g.logger.Debug("Didn't add", msg, "to store")
+			return
+		}
+
+		g.emitter.Add(msg.GetGossipMessage())
+		g.DeMultiplex(msg.GetGossipMessage())
+		cs.pushPull.Add(dataMsg.Payload.SeqNum)
+		return
+	}
 
-		if dataMsg := msg.GetGossipMessage().GetDataMsg(); dataMsg != nil {
-			g.DeMultiplex(msg.GetGossipMessage())
-			g.pushPull.Add(dataMsg.Payload.SeqNum)
+	if msg.GetGossipMessage().GetLeadershipMsg() != nil {
+		if msg.GetGossipMessage().GetLeadershipMsg().Timestamp == nil {
+			g.logger.Warning("Leadership message without timestamp:", msg)
+			return
+		}
+		if !g.msgStore.add(msg.GetGossipMessage()) {
+			g.logger.Debug("Didn't add", msg, "to store")
+			return
 		}
 
+		g.emitter.Add(msg.GetGossipMessage())
+		g.DeMultiplex(msg.GetGossipMessage())
+		return
+	}
+
+	if msg.GetGossipMessage().GetPrivateData() != nil || msg.GetGossipMessage().GetPrivateAck() != nil {
+		g.handlePrivateData(msg)
 		return
 	}
 
-	if msg.GetGossipMessage().GetDataReq() != nil || msg.GetGossipMessage().GetDataUpdate() != nil ||
-		msg.GetGossipMessage().GetHello() != nil || msg.GetGossipMessage().GetDataDig() != nil {
-		g.handlePushPullMsg(msg)
+	if msg.GetGossipMessage().GetStateRequest() != nil || msg.GetGossipMessage().GetStateResponse() != nil {
+		g.handleStateMessage(msg)
+		return
+	}
+
+	if chainID, isPushPullMsg := pushPullChainID(msg.GetGossipMessage()); isPushPullMsg {
+		if cs := g.getChain(chainID); cs != nil {
+			cs.handlePushPullMsg(msg)
+		}
 	}
 }
 
@@ -339,39 +321,6 @@ func (g *gossipServiceImpl) forwardDiscoveryMsg(msg comm.ReceivedMessage) {
 	g.discAdapter.incChan <- msg.GetGossipMessage()
 }
 
-func (g *gossipServiceImpl) handlePushPullMsg(msg comm.ReceivedMessage) {
-	g.logger.Debug(msg)
-	if helloMsg := msg.GetGossipMessage().GetHello(); helloMsg != nil {
-		g.pushPull.OnHello(helloMsg.Nonce, msg)
-	}
-	if digest := msg.GetGossipMessage().GetDataDig(); digest != nil {
-		g.pushPull.OnDigest(digest.SeqMap, digest.Nonce, msg)
-	}
-	if req := msg.GetGossipMessage().GetDataReq(); req != nil {
-		g.pushPull.OnReq(req.SeqMap, req.Nonce, msg)
-	}
-	if res := msg.GetGossipMessage().GetDataUpdate(); res != nil {
-		items := make([]uint64, len(res.Data))
-		for i, data := range res.Data {
-			dataMsg := &proto.GossipMessage{
-				Content: &proto.GossipMessage_DataMsg{
-					DataMsg: data,
-				},
-				Nonce: msg.GetGossipMessage().Nonce,
-			}
-			added := g.msgStore.add(dataMsg)
-			// if we can't add the message to the msgStore,
-			// no point in disseminating it to others...
-			if !added {
-				continue
-			}
-			g.DeMultiplex(dataMsg)
-			items[i] = data.Payload.SeqNum
-		}
-		g.pushPull.OnRes(items, res.Nonce)
-	}
-}
-
 func (g *gossipServiceImpl) sendGossipBatch(a []interface{}) {
 	msgs2Gossip := make([]*proto.GossipMessage, len(a))
 	for i, e := range a {
@@ -407,8 +356,16 @@ func selectEndpoints(k int, peerPool []discovery.NetworkMember) []string {
 func (g *gossipServiceImpl) Gossip(msg *proto.GossipMessage) {
 	g.logger.Info(msg)
 	if dataMsg := msg.GetDataMsg(); dataMsg != nil {
+		cs := g.getChain(dataMsg.ChainID)
+		if cs == nil {
+			g.logger.Warning("Not gossiping", msg, "of chain", string(dataMsg.ChainID), "which wasn't joined")
+			return
+		}
+		cs.msgStore.add(msg)
+		cs.pushPull.Add(dataMsg.Payload.SeqNum)
+	}
+	if msg.GetLeadershipMsg() != nil {
 		g.msgStore.add(msg)
-		g.pushPull.Add(dataMsg.Payload.SeqNum)
 	}
 	g.emitter.Add(msg)
 }
@@ -430,7 +387,11 @@ func (g *gossipServiceImpl) Stop() {
 	go g.comm.Stop()
 	g.discAdapter.close()
 	go g.disc.Stop()
-	go g.pushPull.Stop()
+	g.chainsLock.RLock()
+	for _, cs := range g.chains {
+		go cs.pushPull.Stop()
+	}
+	g.chainsLock.RUnlock()
 	g.toDieChan <- struct{}{}
 	g.emitter.Stop()
 	g.ChannelDeMultiplexer.Close()
@@ -457,6 +457,183 @@ func TestMembershipConvergence(t *testing.T) {
 	ensureGoroutineExit(t)
 }
 
+func TestChainScoping(t *testing.T) {
+	t1 := time.Now()
+	// Scenario: 4 nodes and a bootstrap node, the bootstrap node and 2 of the nodes join a chain.
+	// The bootstrap node sends 5 messages of the chain and we check that
+	// the nodes that joined the chain got them, and the others didn't
+	testLock.Lock()
+	defer testLock.Unlock()
+
+	stopped := int32(0)
+	go waitForTestCompletion(&stopped, t)
+
+	n := 4
+	msgsCount2Send := 5
+	chainID := []byte("A")
+	boot := newGossipInstance(0, 100)
+	boot.JoinChain(chainID)
+	peers := make([]Gossip, n)
+	receivedMessages := make([]int32, n)
+	for i := 1; i <= n; i++ {
+		pI := newGossipInstance(i, 100, 0)
+		if i <= 2 {
+			pI.JoinChain(chainID)
+		}
+		peers[i-1] = pI
+		go func(index int, ch <-chan *proto.GossipMessage) {
+			for range ch {
+				atomic.AddInt32(&receivedMessages[index], 1)
+			}
+		}(i-1, pI.Accept(acceptData))
+	}
+
+	knowAll := func() bool {
+		for i := 1; i <= n; i++ {
+			if n != len(peers[i-1].GetPeers()) {
+				return false
+			}
+		}
+		return true
+	}
+	waitUntilOrFail(t, knowAll)
+
+	for i := 1; i <= msgsCount2Send; i++ {
+		msg := createDataMsg(uint64(i), []byte{}, "")
+		msg.GetDataMsg().ChainID = chainID
+		boot.Gossip(msg)
+	}
+
+	receivedAll := func() bool {
+		for i := 0; i < 2; i++ {
+			if int32(msgsCount2Send) != atomic.LoadInt32(&receivedMessages[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	waitUntilOrFail(t, receivedAll)
+
+	for i := 2; i < n; i++ {
+		assert.Equal(t, int32(0), atomic.LoadInt32(&receivedMessages[i]), "Peer that didn't join the chain got its messages")
+	}
+
+	stop := func() {
+		stopPeers(append(peers, boot))
+	}
+
+	waitUntilOrFailBlocking(t, stop)
+
+	fmt.Println("Took", time.Since(t1))
+	atomic.StoreInt32(&stopped, int32(1))
+	ensureGoroutineExit(t)
+}
+
+func TestPrivateData(t *testing.T) {
+	t1 := time.Now()
+	// Scenario: 3 nodes and a bootstrap node, the bootstrap node and 2 of the nodes join a chain.
+	// The bootstrap node sends private data of the chain to a node that joined the chain
+	// and to the node that didn't, only the first one stores and acknowledges it
+	testLock.Lock()
+	defer testLock.Unlock()
+
+	stopped := int32(0)
+	go waitForTestCompletion(&stopped, t)
+
+	n := 3
+	chainID := []byte("A")
+	boot := newGossipInstance(0, 100)
+	boot.JoinChain(chainID)
+	peers := make([]Gossip, n)
+	for i := 1; i <= n; i++ {
+		pI := newGossipInstance(i, 100, 0)
+		if i <= 2 {
+			pI.JoinChain(chainID)
+		}
+		peers[i-1] = pI
+	}
+
+	knowAll := func() bool {
+		return len(boot.GetPeers()) == n
+	}
+	waitUntilOrFail(t, knowAll)
+
+	msg := &proto.PrivateDataMessage{ChainID: chainID, TxID: "tx1", Collection: "c1", Data: []byte("secret")}
+	acked := boot.SendPrivateData(msg, time.Duration(2)*time.Second, bootPeers(1, 3)...)
+	assert.Equal(t, bootPeers(1), acked, "Only the node of the chain should have acknowledged the private data")
+
+	assert.Equal(t, []byte("secret"), peers[0].PrivateData(chainID, "tx1", "c1"))
+	assert.Nil(t, peers[1].PrivateData(chainID, "tx1", "c1"), "Private data reached a node it wasn't sent to")
+	assert.Nil(t, peers[2].PrivateData(chainID, "tx1", "c1"), "Private data stored by a node that didn't join the chain")
+	assert.Nil(t, boot.PrivateData(chainID, "tx1", "c1"), "Sender stored the private data it sent")
+
+	boot.StorePrivateData(msg)
+	assert.Equal(t, []byte("secret"), boot.PrivateData(chainID, "tx1", "c1"))
+
+	stop := func() {
+		stopPeers(append(peers, boot))
+	}
+
+	waitUntilOrFailBlocking(t, stop)
+
+	fmt.Println("Took", time.Since(t1))
+	atomic.StoreInt32(&stopped, int32(1))
+	ensureGoroutineExit(t)
+}
+
+func TestStateTransfer(t *testing.T) {
+	t1 := time.Now()
+	// Scenario: a bootstrap node serving the first 5 blocks of a chain, and 2 nodes of which
+	// one joined the chain. Both request blocks of the chain from the bootstrap node, only
+	// the blocks it has are sent, and only to the node that joined the chain
+	testLock.Lock()
+	defer testLock.Unlock()
+
+	stopped := int32(0)
+	go waitForTestCompletion(&stopped, t)
+
+	chainID := []byte("A")
+	boot := newGossipInstance(0, 100)
+	boot.JoinChain(chainID)
+	boot.ServeState(chainID, func(seqNum uint64) *proto.Payload {
+		if seqNum >= 5 {
+			return nil
+		}
+		return &proto.Payload{SeqNum: seqNum, Data: []byte{byte(seqNum)}}
+	})
+	p1 := newGossipInstance(1, 100, 0)
+	p1.JoinChain(chainID)
+	p2 := newGossipInstance(2, 100, 0)
+
+	knowAll := func() bool {
+		return len(boot.GetPeers()) == 2 && len(p1.GetPeers()) == 2
+	}
+	waitUntilOrFail(t, knowAll)
+
+	payloads := p1.RequestState(chainID, 2, 10, time.Duration(2)*time.Second, bootPeers(0)[0])
+	assert.Len(t, payloads, 3, "Only the blocks the bootstrap node has should have been sent")
+	for i, payload := range payloads {
+		assert.Equal(t, uint64(i+2), payload.SeqNum)
+	}
+	assert.Empty(t, p1.RequestState([]byte("B"), 0, 1, time.Duration(2)*time.Second, bootPeers(0)[0]), "State of an unserved chain was sent")
+	assert.Empty(t, boot.RequestState(chainID, 0, 1, time.Duration(2)*time.Second, bootPeers(1)[0]), "State was sent by a node not serving it")
+
+	p2.ServeState(chainID, func(seqNum uint64) *proto.Payload {
+		return &proto.Payload{SeqNum: seqNum}
+	})
+	assert.Empty(t, boot.RequestState(chainID, 0, 1, time.Duration(2)*time.Second, bootPeers(2)[0]), "State was sent by a node that didn't join the chain")
+
+	stop := func() {
+		stopPeers([]Gossip{boot, p1, p2})
+	}
+
+	waitUntilOrFailBlocking(t, stop)
+
+	fmt.Println("Took", time.Since(t1))
+	atomic.StoreInt32(&stopped, int32(1))
+	ensureGoroutineExit(t)
+}
+
 func createDataMsg(seqnum uint64, data []byte, hash string) *proto.GossipMessage {
 	return &proto.GossipMessage{
 		Nonce: 0,
@@ -0,0 +1,170 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gossip
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hyperledger/fabric/gossip/comm"
+	"github.com/hyperledger/fabric/gossip/proto"
+)
+
+// privateDataStore holds the private data the peer stored, the oldest being
+// evicted once the store holds more than its capacity
+type privateDataStore struct {
+	sync.RWMutex
+	capacity int
+	data     map[string][]byte
+	keys     []string
+
+	// acks routes the acknowledgements of private data to the senders waiting for them
+	acks     map[uint64]chan string
+	acksLock sync.Mutex
+	nonce    uint64
+}
+
+func newPrivateDataStore(capacity int) *privateDataStore {
+	return &privateDataStore{
+		capacity: capacity,
+		data:     make(map[string][]byte),
+		acks:     make(map[uint64]chan string),
+	}
+}
+
+func privateDataKey(chainID []byte, txID, collection string) string {
+	return string(chainID) + "\x00" + txID + "\x00" + collection
+}
+
+func (s *privateDataStore) put(msg *proto.PrivateDataMessage) {
+	s.Lock()
+	defer s.Unlock()
+
+	key := privateDataKey(msg.ChainID, msg.TxID, msg.Collection)
+	if _, exists := s.data[key]; !exists {
+		s.keys = append(s.keys, key)
+	}
+	s.data[key] = msg.Data
+	for len(s.keys) > s.capacity {
+		delete(s.data, s.keys[0])
+		s.keys = s.keys[1:]
+	}
+}
+
+func (s *privateDataStore) get(chainID []byte, txID, collection string) []byte {
+	s.RLock()
+	defer s.RUnlock()
+	return s.data[privateDataKey(chainID, txID, collection)]
+}
+
+// StorePrivateData stores private data of a chain the peer joined
+func (g *gossipServiceImpl) StorePrivateData(msg *proto.PrivateDataMessage) {
+	if g.getChain(msg.ChainID) == nil {
+		g.logger.Warning("Not storing private data of chain", string(msg.ChainID), "which wasn't joined")
+		return
+	}
+	g.privData.put(msg)
+}
+
+// PrivateData returns the private data of a transaction for a collection,
+// or nil if the peer doesn't hold it
+func (g *gossipServiceImpl) PrivateData(chainID []byte, txID, collection string) []byte {
+	return g.privData.get(chainID, txID, collection)
+}
+
+// SendPrivateData sends private data to the given peers only and returns the
+// endpoints of those that acknowledged storing it before the timeout
+func (g *gossipServiceImpl) SendPrivateData(msg *proto.PrivateDataMessage, timeout time.Duration, endpoints ...string) []string {
+	s := g.privData
+	nonce := atomic.AddUint64(&s.nonce, 1)
+	acks := make(chan string, len(endpoints))
+	s.acksLock.Lock()
+	s.acks[nonce] = acks
+	s.acksLock.Unlock()
+	defer func() {
+		s.acksLock.Lock()
+		delete(s.acks, nonce)
+		s.acksLock.Unlock()
+	}()
+
+	peers := g.peersWithEndpoints(endpoints...)
+	m := &proto.GossipMessage{
+		Content: &proto.GossipMessage_PrivateData{
+			PrivateData: &proto.PrivateDataMessage{
+				Nonce:      nonce,
+				ChainID:    msg.ChainID,
+				TxID:       msg.TxID,
+				Collection: msg.Collection,
+				Data:       msg.Data,
+			},
+		},
+	}
+	g.comm.Send(m, peers...)
+
+	acked := []string{}
+	pending := make(map[string]bool)
+	for _, p := range peers {
+		pending[p.Endpoint] = true
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for len(pending) > 0 {
+		select {
+		case endpoint := <-acks:
+			if pending[endpoint] {
+				delete(pending, endpoint)
+				acked = append(acked, endpoint)
+			}
+		case <-timer.C:
+			return acked
+		}
+	}
+	return acked
+}
+
+// handlePrivateData stores the private data sent to the peer for a chain it
+// joined and acknowledges it, or passes an acknowledgement to its sender
+func (g *gossipServiceImpl) handlePrivateData(msg comm.ReceivedMessage) {
+	if privData := msg.GetGossipMessage().GetPrivateData(); privData != nil {
+		if g.getChain(privData.ChainID) == nil {
+			g.logger.Warning("Dropping private data of chain", string(privData.ChainID), "which wasn't joined")
+			return
+		}
+		g.privData.put(privData)
+		msg.Respond(&proto.GossipMessage{
+			Content: &proto.GossipMessage_PrivateAck{
+				PrivateAck: &proto.PrivateDataAck{
+					Nonce:    privData.Nonce,
+					Endpoint: g.conf.SelfEndpoint,
+				},
+			},
+		})
+		return
+	}
+
+	ack := msg.GetGossipMessage().GetPrivateAck()
+	g.privData.acksLock.Lock()
+	acks, waiting := g.privData.acks[ack.Nonce]
+	g.privData.acksLock.Unlock()
+	if waiting {
+		select {
+		case acks <- ack.Endpoint:
+		default:
+		}
+	}
+}
@@ -0,0 +1,139 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gossip
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hyperledger/fabric/gossip/comm"
+	"github.com/hyperledger/fabric/gossip/proto"
+)
+
+// maxStateResponseSize is the maximum number of blocks sent in a state response
+const maxStateResponseSize = 100
+
+// stateTransfer serves the state requests of other peers for the chains the
+// peer provides the blocks of, and routes the state responses to the requests
+// waiting for them
+type stateTransfer struct {
+	providers     map[string]func(seqNum uint64) *proto.Payload
+	providersLock sync.RWMutex
+
+	responses     map[uint64]chan *proto.RemoteStateResponse
+	responsesLock sync.Mutex
+	nonce         uint64
+}
+
+func newStateTransfer() *stateTransfer {
+	return &stateTransfer{
+		providers: make(map[string]func(seqNum uint64) *proto.Payload),
+		responses: make(map[uint64]chan *proto.RemoteStateResponse),
+	}
+}
+
+// ServeState makes the peer answer the state requests of other peers for the
+// blocks of a chain it joined with the blocks returned by getBlock
+func (g *gossipServiceImpl) ServeState(chainID []byte, getBlock func(seqNum uint64) *proto.Payload) {
+	if g.getChain(chainID) == nil {
+		g.logger.Warning("Not serving the state of chain", string(chainID), "which wasn't joined")
+		return
+	}
+	g.state.providersLock.Lock()
+	defer g.state.providersLock.Unlock()
+	g.state.providers[string(chainID)] = getBlock
+}
+
+// RequestState requests from the peer at endpoint the blocks of a chain with sequence
+// numbers from startSeqNum to endSeqNum, and returns those it sent before the timeout
+func (g *gossipServiceImpl) RequestState(chainID []byte, startSeqNum, endSeqNum uint64, timeout time.Duration, endpoint string) []*proto.Payload {
+	peers := g.peersWithEndpoints(endpoint)
+	if len(peers) == 0 {
+		g.logger.Warning("Can't request the state of chain", string(chainID), "from unknown peer", endpoint)
+		return nil
+	}
+
+	s := g.state
+	nonce := atomic.AddUint64(&s.nonce, 1)
+	responses := make(chan *proto.RemoteStateResponse, 1)
+	s.responsesLock.Lock()
+	s.responses[nonce] = responses
+	s.responsesLock.Unlock()
+	defer func() {
+		s.responsesLock.Lock()
+		delete(s.responses, nonce)
+		s.responsesLock.Unlock()
+	}()
+
+	g.comm.Send(&proto.GossipMessage{
+		Content: &proto.GossipMessage_StateRequest{
+			StateRequest: &proto.RemoteStateRequest{
+				Nonce:       nonce,
+				ChainID:     chainID,
+				StartSeqNum: startSeqNum,
+				EndSeqNum:   endSeqNum,
+			},
+		},
+	}, peers...)
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case res := <-responses:
+		return res.Payloads
+	case <-timer.C:
+		g.logger.Warning("Peer", endpoint, "didn't send the state of chain", string(chainID), "in time")
+		return nil
+	}
+}
+
+// handleStateMessage answers a state request with the blocks the peer provides,
+// or passes a state response to the request waiting for it
+func (g *gossipServiceImpl) handleStateMessage(msg comm.ReceivedMessage) {
+	if req := msg.GetGossipMessage().GetStateRequest(); req != nil {
+		g.state.providersLock.RLock()
+		getBlock, exists := g.state.providers[string(req.ChainID)]
+		g.state.providersLock.RUnlock()
+
+		res := &proto.RemoteStateResponse{Nonce: req.Nonce}
+		if exists {
+			for seqNum := req.StartSeqNum; seqNum <= req.EndSeqNum && len(res.Payloads) < maxStateResponseSize; seqNum++ {
+				payload := getBlock(seqNum)
+				if payload == nil {
+					break
+				}
+				res.Payloads = append(res.Payloads, payload)
+			}
+		}
+		msg.Respond(&proto.GossipMessage{
+			Content: &proto.GossipMessage_StateResponse{StateResponse: res},
+		})
+		return
+	}
+
+	res := msg.GetGossipMessage().GetStateResponse()
+	g.state.responsesLock.Lock()
+	responses, waiting := g.state.responses[res.Nonce]
+	g.state.responsesLock.Unlock()
+	if waiting {
+		select {
+		case responses <- res:
+		default:
+		}
+	}
+}
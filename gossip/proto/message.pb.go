@@ -18,6 +18,11 @@ It has these top-level messages:
 	DataMessage
 	Payload
 	AckMessage
+	PrivateDataMessage
+	PrivateDataAck
+	LeadershipMessage
+	RemoteStateRequest
+	RemoteStateResponse
 	AliveMessage
 	PeerTime
 	MembershipRequest
@@ -91,6 +96,11 @@ type GossipMessage struct {
 	//	*GossipMessage_AckMsg
 	//	*GossipMessage_Empty
 	//	*GossipMessage_Conn
+	//	*GossipMessage_PrivateData
+	//	*GossipMessage_PrivateAck
+	//	*GossipMessage_LeadershipMsg
+	//	*GossipMessage_StateRequest
+	//	*GossipMessage_StateResponse
 	Content isGossipMessage_Content `protobuf_oneof:"content"`
 }
 
@@ -136,6 +146,21 @@ type GossipMessage_Empty struct {
 type GossipMessage_Conn struct {
 	Conn *ConnEstablish `protobuf:"bytes,12,opt,name=conn,oneof"`
 }
+type GossipMessage_PrivateData struct {
+	PrivateData *PrivateDataMessage `protobuf:"bytes,13,opt,name=privateData,oneof"`
+}
+type GossipMessage_PrivateAck struct {
+	PrivateAck *PrivateDataAck `protobuf:"bytes,14,opt,name=privateAck,oneof"`
+}
+type GossipMessage_LeadershipMsg struct {
+	LeadershipMsg *LeadershipMessage `protobuf:"bytes,15,opt,name=leadershipMsg,oneof"`
+}
+type GossipMessage_StateRequest struct {
+	StateRequest *RemoteStateRequest `protobuf:"bytes,16,opt,name=stateRequest,oneof"`
+}
+type GossipMessage_StateResponse struct {
+	StateResponse *RemoteStateResponse `protobuf:"bytes,17,opt,name=stateResponse,oneof"`
+}
 
 func (*GossipMessage_AliveMsg) isGossipMessage_Content()   {}
 func (*GossipMessage_MemReq) isGossipMessage_Content()     {}
@@ -148,6 +173,11 @@ func (*GossipMessage_DataUpdate) isGossipMessage_Content() {}
 func (*GossipMessage_AckMsg) isGossipMessage_Content()     {}
 func (*GossipMessage_Empty) isGossipMessage_Content()      {}
 func (*GossipMessage_Conn) isGossipMessage_Content()       {}
+func (*GossipMessage_PrivateData) isGossipMessage_Content() {}
+func (*GossipMessage_PrivateAck) isGossipMessage_Content()  {}
+func (*GossipMessage_LeadershipMsg) isGossipMessage_Content() {}
+func (*GossipMessage_StateRequest) isGossipMessage_Content()  {}
+func (*GossipMessage_StateResponse) isGossipMessage_Content() {}
 
 func (m *GossipMessage) GetContent() isGossipMessage_Content {
 	if m != nil {
@@ -233,6 +263,41 @@ func (m *GossipMessage) GetConn() *ConnEstablish {
 	return nil
 }
 
+func (m *GossipMessage) GetPrivateData() *PrivateDataMessage {
+	if x, ok := m.GetContent().(*GossipMessage_PrivateData); ok {
+		return x.PrivateData
+	}
+	return nil
+}
+
+func (m *GossipMessage) GetPrivateAck() *PrivateDataAck {
+	if x, ok := m.GetContent().(*GossipMessage_PrivateAck); ok {
+		return x.PrivateAck
+	}
+	return nil
+}
+
+func (m *GossipMessage) GetLeadershipMsg() *LeadershipMessage {
+	if x, ok := m.GetContent().(*GossipMessage_LeadershipMsg); ok {
+		return x.LeadershipMsg
+	}
+	return nil
+}
+
+func (m *GossipMessage) GetStateRequest() *RemoteStateRequest {
+	if x, ok := m.GetContent().(*GossipMessage_StateRequest); ok {
+		return x.StateRequest
+	}
+	return nil
+}
+
+func (m *GossipMessage) GetStateResponse() *RemoteStateResponse {
+	if x, ok := m.GetContent().(*GossipMessage_StateResponse); ok {
+		return x.StateResponse
+	}
+	return nil
+}
+
 // XXX_OneofFuncs is for the internal use of the proto package.
 func (*GossipMessage) XXX_OneofFuncs() (func(msg proto1.Message, b *proto1.Buffer) error, func(msg proto1.Message, tag, wire int, b *proto1.Buffer) (bool, error), func(msg proto1.Message) (n int), []interface{}) {
 	return _GossipMessage_OneofMarshaler, _GossipMessage_OneofUnmarshaler, _GossipMessage_OneofSizer, []interface{}{
@@ -247,6 +312,11 @@ func (*GossipMessage) XXX_OneofFuncs() (func(msg proto1.Message, b *proto1.Buffe
 		(*GossipMessage_AckMsg)(nil),
 		(*GossipMessage_Empty)(nil),
 		(*GossipMessage_Conn)(nil),
+		(*GossipMessage_PrivateData)(nil),
+		(*GossipMessage_PrivateAck)(nil),
+		(*GossipMessage_LeadershipMsg)(nil),
+		(*GossipMessage_StateRequest)(nil),
+		(*GossipMessage_StateResponse)(nil),
 	}
 }
 
@@ -309,6 +379,31 @@ func _GossipMessage_OneofMarshaler(msg proto1.Message, b *proto1.Buffer) error {
 		if err := b.EncodeMessage(x.Conn); err != nil {
 			return err
 		}
+	case *GossipMessage_PrivateData:
+		b.EncodeVarint(13<<3 | proto1.WireBytes)
+		if err := b.EncodeMessage(x.PrivateData); err != nil {
+			return err
+		}
+	case *GossipMessage_PrivateAck:
+		b.EncodeVarint(14<<3 | proto1.WireBytes)
+		if err := b.EncodeMessage(x.PrivateAck); err != nil {
+			return err
+		}
+	case *GossipMessage_LeadershipMsg:
+		b.EncodeVarint(15<<3 | proto1.WireBytes)
+		if err := b.EncodeMessage(x.LeadershipMsg); err != nil {
+			return err
+		}
+	case *GossipMessage_StateRequest:
+		b.EncodeVarint(16<<3 | proto1.WireBytes)
+		if err := b.EncodeMessage(x.StateRequest); err != nil {
+			return err
+		}
+	case *GossipMessage_StateResponse:
+		b.EncodeVarint(17<<3 | proto1.WireBytes)
+		if err := b.EncodeMessage(x.StateResponse); err != nil {
+			return err
+		}
 	case nil:
 	default:
 		return fmt.Errorf("GossipMessage.Content has unexpected type %T", x)
@@ -407,6 +502,46 @@ func _GossipMessage_OneofUnmarshaler(msg proto1.Message, tag, wire int, b *proto
 		err := b.DecodeMessage(msg)
 		m.Content = &GossipMessage_Conn{msg}
 		return true, err
+	case 13: // content.privateData
+		if wire != proto1.WireBytes {
+			return true, proto1.ErrInternalBadWireType
+		}
+		msg := new(PrivateDataMessage)
+		err := b.DecodeMessage(msg)
+		m.Content = &GossipMessage_PrivateData{msg}
+		return true, err
+	case 14: // content.privateAck
+		if wire != proto1.WireBytes {
+			return true, proto1.ErrInternalBadWireType
+		}
+		msg := new(PrivateDataAck)
+		err := b.DecodeMessage(msg)
+		m.Content = &GossipMessage_PrivateAck{msg}
+		return true, err
+	case 15: // content.leadershipMsg
+		if wire != proto1.WireBytes {
+			return true, proto1.ErrInternalBadWireType
+		}
+		msg := new(LeadershipMessage)
+		err := b.DecodeMessage(msg)
+		m.Content = &GossipMessage_LeadershipMsg{msg}
+		return true, err
+	case 16: // content.stateRequest
+		if wire != proto1.WireBytes {
+			return true, proto1.ErrInternalBadWireType
+		}
+		msg := new(RemoteStateRequest)
+		err := b.DecodeMessage(msg)
+		m.Content = &GossipMessage_StateRequest{msg}
+		return true, err
+	case 17: // content.stateResponse
+		if wire != proto1.WireBytes {
+			return true, proto1.ErrInternalBadWireType
+		}
+		msg := new(RemoteStateResponse)
+		err := b.DecodeMessage(msg)
+		m.Content = &GossipMessage_StateResponse{msg}
+		return true, err
 	default:
 		return false, nil
 	}
@@ -471,6 +606,31 @@ func _GossipMessage_OneofSizer(msg proto1.Message) (n int) {
 		n += proto1.SizeVarint(12<<3 | proto1.WireBytes)
 		n += proto1.SizeVarint(uint64(s))
 		n += s
+	case *GossipMessage_PrivateData:
+		s := proto1.Size(x.PrivateData)
+		n += proto1.SizeVarint(13<<3 | proto1.WireBytes)
+		n += proto1.SizeVarint(uint64(s))
+		n += s
+	case *GossipMessage_PrivateAck:
+		s := proto1.Size(x.PrivateAck)
+		n += proto1.SizeVarint(14<<3 | proto1.WireBytes)
+		n += proto1.SizeVarint(uint64(s))
+		n += s
+	case *GossipMessage_LeadershipMsg:
+		s := proto1.Size(x.LeadershipMsg)
+		n += proto1.SizeVarint(15<<3 | proto1.WireBytes)
+		n += proto1.SizeVarint(uint64(s))
+		n += s
+	case *GossipMessage_StateRequest:
+		s := proto1.Size(x.StateRequest)
+		n += proto1.SizeVarint(16<<3 | proto1.WireBytes)
+		n += proto1.SizeVarint(uint64(s))
+		n += s
+	case *GossipMessage_StateResponse:
+		s := proto1.Size(x.StateResponse)
+		n += proto1.SizeVarint(17<<3 | proto1.WireBytes)
+		n += proto1.SizeVarint(uint64(s))
+		n += s
 	case nil:
 	default:
 		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
@@ -491,6 +651,7 @@ func (*ConnEstablish) Descriptor() ([]byte, []int) { return fileDescriptor0, []i
 type DataRequest struct {
 	Nonce  uint64   `protobuf:"varint,1,opt,name=nonce" json:"nonce,omitempty"`
 	SeqMap []uint64 `protobuf:"varint,2,rep,packed,name=seqMap" json:"seqMap,omitempty"`
+	ChainID []byte   `protobuf:"bytes,3,opt,name=chainID,proto3" json:"chainID,omitempty"`
 }
 
 func (m *DataRequest) Reset()                    { *m = DataRequest{} }
@@ -500,6 +661,7 @@ func (*DataRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int
 
 type GossipHello struct {
 	Nonce uint64 `protobuf:"varint,1,opt,name=nonce" json:"nonce,omitempty"`
+	ChainID []byte `protobuf:"bytes,2,opt,name=chainID,proto3" json:"chainID,omitempty"`
 }
 
 func (m *GossipHello) Reset()                    { *m = GossipHello{} }
@@ -510,6 +672,7 @@ func (*GossipHello) Descriptor() ([]byte, []int) { return fileDescriptor0, []int
 type DataUpdate struct {
 	Nonce uint64         `protobuf:"varint,1,opt,name=nonce" json:"nonce,omitempty"`
 	Data  []*DataMessage `protobuf:"bytes,2,rep,name=data" json:"data,omitempty"`
+	ChainID []byte         `protobuf:"bytes,3,opt,name=chainID,proto3" json:"chainID,omitempty"`
 }
 
 func (m *DataUpdate) Reset()                    { *m = DataUpdate{} }
@@ -527,6 +690,7 @@ func (m *DataUpdate) GetData() []*DataMessage {
 type DataDigest struct {
 	Nonce  uint64   `protobuf:"varint,1,opt,name=nonce" json:"nonce,omitempty"`
 	SeqMap []uint64 `protobuf:"varint,2,rep,packed,name=seqMap" json:"seqMap,omitempty"`
+	ChainID []byte   `protobuf:"bytes,3,opt,name=chainID,proto3" json:"chainID,omitempty"`
 }
 
 func (m *DataDigest) Reset()                    { *m = DataDigest{} }
@@ -537,6 +701,7 @@ func (*DataDigest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{
 type DataMessage struct {
 	Type    DataMessage_Type `protobuf:"varint,1,opt,name=type,enum=proto.DataMessage_Type" json:"type,omitempty"`
 	Payload *Payload         `protobuf:"bytes,2,opt,name=payload" json:"payload,omitempty"`
+	ChainID []byte           `protobuf:"bytes,3,opt,name=chainID,proto3" json:"chainID,omitempty"`
 }
 
 func (m *DataMessage) Reset()                    { *m = DataMessage{} }
@@ -571,6 +736,84 @@ func (m *AckMessage) String() string            { return proto1.CompactTextStrin
 func (*AckMessage) ProtoMessage()               {}
 func (*AckMessage) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
 
+// PrivateDataMessage carries the private data of a transaction for a collection.
+// It is sent directly to the peers of the organizations of the collection and never forwarded
+type PrivateDataMessage struct {
+	Nonce      uint64 `protobuf:"varint,1,opt,name=nonce" json:"nonce,omitempty"`
+	ChainID    []byte `protobuf:"bytes,2,opt,name=chainID,proto3" json:"chainID,omitempty"`
+	TxID       string `protobuf:"bytes,3,opt,name=txID" json:"txID,omitempty"`
+	Collection string `protobuf:"bytes,4,opt,name=collection" json:"collection,omitempty"`
+	Data       []byte `protobuf:"bytes,5,opt,name=data,proto3" json:"data,omitempty"`
+}
+
+func (m *PrivateDataMessage) Reset()                    { *m = PrivateDataMessage{} }
+func (m *PrivateDataMessage) String() string            { return proto1.CompactTextString(m) }
+func (*PrivateDataMessage) ProtoMessage()               {}
+func (*PrivateDataMessage) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
+
+// PrivateDataAck acknowledges that a peer stored the private data of the PrivateDataMessage with the same nonce
+type PrivateDataAck struct {
+	Nonce    uint64 `protobuf:"varint,1,opt,name=nonce" json:"nonce,omitempty"`
+	Endpoint string `protobuf:"bytes,2,opt,name=endpoint" json:"endpoint,omitempty"`
+}
+
+func (m *PrivateDataAck) Reset()                    { *m = PrivateDataAck{} }
+func (m *PrivateDataAck) String() string            { return proto1.CompactTextString(m) }
+func (*PrivateDataAck) ProtoMessage()               {}
+func (*PrivateDataAck) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
+
+// LeadershipMessage is gossiped by the peers of an organization electing their leader,
+// either to propose themselves as leader or, once elected, to declare their leadership
+type LeadershipMessage struct {
+	PkiID         []byte    `protobuf:"bytes,1,opt,name=pkiID,proto3" json:"pkiID,omitempty"`
+	Timestamp     *PeerTime `protobuf:"bytes,2,opt,name=timestamp" json:"timestamp,omitempty"`
+	IsDeclaration bool      `protobuf:"varint,3,opt,name=isDeclaration" json:"isDeclaration,omitempty"`
+}
+
+func (m *LeadershipMessage) Reset()                    { *m = LeadershipMessage{} }
+func (m *LeadershipMessage) String() string            { return proto1.CompactTextString(m) }
+func (*LeadershipMessage) ProtoMessage()               {}
+func (*LeadershipMessage) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
+
+func (m *LeadershipMessage) GetTimestamp() *PeerTime {
+	if m != nil {
+		return m.Timestamp
+	}
+	return nil
+}
+
+// RemoteStateRequest is sent by a peer to fetch from another peer the blocks
+// of a chain with sequence numbers from startSeqNum to endSeqNum
+type RemoteStateRequest struct {
+	Nonce       uint64 `protobuf:"varint,1,opt,name=nonce" json:"nonce,omitempty"`
+	ChainID     []byte `protobuf:"bytes,2,opt,name=chainID,proto3" json:"chainID,omitempty"`
+	StartSeqNum uint64 `protobuf:"varint,3,opt,name=startSeqNum" json:"startSeqNum,omitempty"`
+	EndSeqNum   uint64 `protobuf:"varint,4,opt,name=endSeqNum" json:"endSeqNum,omitempty"`
+}
+
+func (m *RemoteStateRequest) Reset()                    { *m = RemoteStateRequest{} }
+func (m *RemoteStateRequest) String() string            { return proto1.CompactTextString(m) }
+func (*RemoteStateRequest) ProtoMessage()               {}
+func (*RemoteStateRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
+
+// RemoteStateResponse holds the requested blocks the peer has, in order
+type RemoteStateResponse struct {
+	Nonce    uint64     `protobuf:"varint,1,opt,name=nonce" json:"nonce,omitempty"`
+	Payloads []*Payload `protobuf:"bytes,2,rep,name=payloads" json:"payloads,omitempty"`
+}
+
+func (m *RemoteStateResponse) Reset()                    { *m = RemoteStateResponse{} }
+func (m *RemoteStateResponse) String() string            { return proto1.CompactTextString(m) }
+func (*RemoteStateResponse) ProtoMessage()               {}
+func (*RemoteStateResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
+
+func (m *RemoteStateResponse) GetPayloads() []*Payload {
+	if m != nil {
+		return m.Payloads
+	}
+	return nil
+}
+
 type AliveMessage struct {
 	Membership *Member   `protobuf:"bytes,1,opt,name=membership" json:"membership,omitempty"`
 	Timestamp  *PeerTime `protobuf:"bytes,2,opt,name=timestamp" json:"timestamp,omitempty"`
@@ -580,7 +823,7 @@ type AliveMessage struct {
 func (m *AliveMessage) Reset()                    { *m = AliveMessage{} }
 func (m *AliveMessage) String() string            { return proto1.CompactTextString(m) }
 func (*AliveMessage) ProtoMessage()               {}
-func (*AliveMessage) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
+func (*AliveMessage) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
 
 func (m *AliveMessage) GetMembership() *Member {
 	if m != nil {
@@ -604,7 +847,7 @@ type PeerTime struct {
 func (m *PeerTime) Reset()                    { *m = PeerTime{} }
 func (m *PeerTime) String() string            { return proto1.CompactTextString(m) }
 func (*PeerTime) ProtoMessage()               {}
-func (*PeerTime) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
+func (*PeerTime) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
 
 type MembershipRequest struct {
 	SelfInformation *AliveMessage `protobuf:"bytes,1,opt,name=selfInformation" json:"selfInformation,omitempty"`
@@ -614,7 +857,7 @@ type MembershipRequest struct {
 func (m *MembershipRequest) Reset()                    { *m = MembershipRequest{} }
 func (m *MembershipRequest) String() string            { return proto1.CompactTextString(m) }
 func (*MembershipRequest) ProtoMessage()               {}
-func (*MembershipRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
+func (*MembershipRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
 
 func (m *MembershipRequest) GetSelfInformation() *AliveMessage {
 	if m != nil {
@@ -631,7 +874,7 @@ type MembershipResponse struct {
 func (m *MembershipResponse) Reset()                    { *m = MembershipResponse{} }
 func (m *MembershipResponse) String() string            { return proto1.CompactTextString(m) }
 func (*MembershipResponse) ProtoMessage()               {}
-func (*MembershipResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
+func (*MembershipResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{17} }
 
 func (m *MembershipResponse) GetAlive() []*AliveMessage {
 	if m != nil {
@@ -651,12 +894,14 @@ type Member struct {
 	Endpoint string `protobuf:"bytes,1,opt,name=endpoint" json:"endpoint,omitempty"`
 	Metadata []byte `protobuf:"bytes,2,opt,name=metadata,proto3" json:"metadata,omitempty"`
 	PkiID    []byte `protobuf:"bytes,3,opt,name=pkiID,proto3" json:"pkiID,omitempty"`
+	// PEM encoded certificate the alive messages of the member are signed with
+	Identity []byte `protobuf:"bytes,4,opt,name=identity,proto3" json:"identity,omitempty"`
 }
 
 func (m *Member) Reset()                    { *m = Member{} }
 func (m *Member) String() string            { return proto1.CompactTextString(m) }
 func (*Member) ProtoMessage()               {}
-func (*Member) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
+func (*Member) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{18} }
 
 type Empty struct {
 }
@@ -664,7 +909,7 @@ type Empty struct {
 func (m *Empty) Reset()                    { *m = Empty{} }
 func (m *Empty) String() string            { return proto1.CompactTextString(m) }
 func (*Empty) ProtoMessage()               {}
-func (*Empty) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
+func (*Empty) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{19} }
 
 func init() {
 	proto1.RegisterType((*GossipMessage)(nil), "proto.GossipMessage")
@@ -676,6 +921,11 @@ func init() {
 	proto1.RegisterType((*DataMessage)(nil), "proto.DataMessage")
 	proto1.RegisterType((*Payload)(nil), "proto.Payload")
 	proto1.RegisterType((*AckMessage)(nil), "proto.AckMessage")
+	proto1.RegisterType((*PrivateDataMessage)(nil), "proto.PrivateDataMessage")
+	proto1.RegisterType((*PrivateDataAck)(nil), "proto.PrivateDataAck")
+	proto1.RegisterType((*LeadershipMessage)(nil), "proto.LeadershipMessage")
+	proto1.RegisterType((*RemoteStateRequest)(nil), "proto.RemoteStateRequest")
+	proto1.RegisterType((*RemoteStateResponse)(nil), "proto.RemoteStateResponse")
 	proto1.RegisterType((*AliveMessage)(nil), "proto.AliveMessage")
 	proto1.RegisterType((*PeerTime)(nil), "proto.PeerTime")
 	proto1.RegisterType((*MembershipRequest)(nil), "proto.MembershipRequest")
@@ -826,58 +1076,75 @@ var _Gossip_serviceDesc = grpc.ServiceDesc{
 func init() { proto1.RegisterFile("message.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 843 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x94, 0x54, 0x5f, 0x6f, 0xe3, 0x44,
-	0x10, 0x8f, 0x1b, 0xe7, 0x8f, 0x27, 0xc9, 0x5d, 0x6e, 0xa8, 0xc0, 0x54, 0x20, 0x55, 0xe6, 0x04,
-	0xa1, 0x47, 0x53, 0x48, 0x1f, 0x90, 0x40, 0x48, 0xb4, 0x4d, 0x20, 0x85, 0x6b, 0x39, 0xf6, 0x7a,
-	0xf7, 0xc0, 0xcb, 0xc9, 0x71, 0xb6, 0xf6, 0xaa, 0xf1, 0xda, 0xf5, 0x6e, 0x40, 0xf9, 0x0a, 0x7c,
-	0x14, 0xbe, 0x02, 0x5f, 0x0e, 0xed, 0x9f, 0x24, 0x0e, 0x49, 0x1e, 0xee, 0xc9, 0x9e, 0x99, 0xdf,
-	0x6f, 0x76, 0xe6, 0xb7, 0xb3, 0x03, 0x9d, 0x94, 0x0a, 0x11, 0xc6, 0xb4, 0x9f, 0x17, 0x99, 0xcc,
-	0xb0, 0xa6, 0x3f, 0xc1, 0xbf, 0x2e, 0x74, 0x7e, 0xce, 0x84, 0x60, 0xf9, 0x8d, 0x09, 0xe3, 0x21,
-	0xd4, 0x78, 0xc6, 0x23, 0xea, 0x3b, 0xc7, 0x4e, 0xcf, 0x25, 0xc6, 0xc0, 0x6f, 0xa0, 0x19, 0xce,
-	0xd8, 0x9f, 0xf4, 0x46, 0xc4, 0xfe, 0xc1, 0xb1, 0xd3, 0x6b, 0x0d, 0x3e, 0x30, 0x89, 0xfa, 0x17,
-	0xda, 0x6d, 0xc8, 0xe3, 0x0a, 0x59, 0xc1, 0x70, 0x00, 0xf5, 0x94, 0xa6, 0x84, 0x3e, 0xfa, 0x55,
-	0x4d, 0xf0, 0x2d, 0xe1, 0x86, 0xa6, 0x13, 0x5a, 0x88, 0x84, 0xe5, 0x84, 0x3e, 0xce, 0xa9, 0x90,
-	0xe3, 0x0a, 0xb1, 0x48, 0x3c, 0xb7, 0x1c, 0xe1, 0xbb, 0x9a, 0xf3, 0xf1, 0x0e, 0x8e, 0xc8, 0x33,
-	0x2e, 0xe8, 0x8a, 0x24, 0xb0, 0x0f, 0x8d, 0x69, 0x28, 0x43, 0x55, 0x5a, 0x4d, 0xb3, 0xd0, 0xb2,
-	0x86, 0xca, 0xbb, 0xaa, 0x6c, 0x09, 0xc2, 0x13, 0xa8, 0x25, 0x74, 0x36, 0xcb, 0xfc, 0xfa, 0x06,
-	0xda, 0xc8, 0x30, 0x56, 0x91, 0x71, 0x85, 0x18, 0x08, 0x9e, 0x9a, 0xdc, 0x43, 0x16, 0xfb, 0x0d,
-	0x8d, 0x7e, 0x56, 0xca, 0x3d, 0x64, 0xb1, 0x29, 0x7f, 0x89, 0x59, 0x96, 0xa2, 0x9a, 0x6e, 0x6e,
-	0x95, 0xb2, 0x6e, 0x77, 0x09, 0xc2, 0x73, 0x00, 0xf5, 0xfb, 0x26, 0x9f, 0x86, 0x92, 0xfa, 0xde,
-	0xd6, 0x09, 0x26, 0x30, 0xae, 0x90, 0x12, 0x0c, 0x5f, 0x40, 0x3d, 0x8c, 0x1e, 0x54, 0xbb, 0xb0,
-	0x41, 0xb8, 0x88, 0x1e, 0xd6, 0xdd, 0x5a, 0x08, 0x3e, 0x87, 0x1a, 0x4d, 0x73, 0xb9, 0xf0, 0x5b,
-	0x1a, 0xdb, 0xb6, 0xd8, 0x91, 0xf2, 0xa9, 0x36, 0x75, 0x10, 0x4f, 0xc0, 0x8d, 0x32, 0xce, 0xfd,
-	0xb6, 0x06, 0x1d, 0x5a, 0xd0, 0x55, 0xc6, 0xf9, 0x48, 0xc8, 0x70, 0x32, 0x63, 0x22, 0x19, 0x57,
-	0x88, 0xc6, 0x5c, 0x7a, 0xd0, 0x88, 0x32, 0x2e, 0x29, 0x97, 0xc1, 0xb7, 0xd0, 0xd9, 0xc0, 0x60,
-	0x17, 0xaa, 0x82, 0xc5, 0x7a, 0x74, 0xda, 0x44, 0xfd, 0xaa, 0x71, 0xca, 0x1f, 0xd8, 0xf5, 0x50,
-	0x4f, 0x4d, 0x9b, 0x18, 0x23, 0xf8, 0x1e, 0x5a, 0x25, 0x45, 0xf6, 0xcc, 0xdc, 0x87, 0x50, 0x17,
-	0xf4, 0xf1, 0x26, 0xcc, 0xfd, 0x83, 0xe3, 0x6a, 0xcf, 0x25, 0xd6, 0x0a, 0x3e, 0x83, 0x56, 0xe9,
-	0xae, 0x76, 0x93, 0x83, 0x5f, 0x00, 0xd6, 0x02, 0xee, 0x39, 0xe0, 0x73, 0x70, 0x95, 0xac, 0x3a,
-	0xfd, 0xce, 0xa9, 0x21, 0x3a, 0x1e, 0x7c, 0x67, 0x72, 0x99, 0xeb, 0x7e, 0xcf, 0x62, 0xff, 0x71,
-	0x4c, 0xab, 0xcb, 0xe7, 0xf5, 0x02, 0x5c, 0xb9, 0xc8, 0x0d, 0xf9, 0xc9, 0xe0, 0xa3, 0xed, 0x33,
-	0xfb, 0x77, 0x8b, 0x9c, 0x12, 0x0d, 0xc2, 0x1e, 0x34, 0xf2, 0x70, 0x31, 0xcb, 0xc2, 0xa9, 0x7d,
-	0x74, 0x4f, 0x2c, 0xfe, 0x95, 0xf1, 0x92, 0x65, 0x38, 0x18, 0x82, 0xab, 0x78, 0xd8, 0x01, 0xef,
-	0xcd, 0xed, 0x70, 0xf4, 0xd3, 0xf5, 0xed, 0x68, 0xd8, 0xad, 0xa0, 0x07, 0xb5, 0xcb, 0x97, 0xbf,
-	0x5d, 0xfd, 0xda, 0x75, 0xb0, 0x05, 0x8d, 0xb7, 0x17, 0x2f, 0xdf, 0x91, 0xd1, 0xef, 0xdd, 0x83,
-	0xb5, 0xf1, 0xba, 0x5b, 0xc5, 0x26, 0xb8, 0x57, 0x23, 0x72, 0xd7, 0x75, 0x83, 0x6b, 0x68, 0xd8,
-	0xcc, 0xb6, 0x9f, 0xdb, 0x79, 0x6a, 0xdb, 0xb4, 0x16, 0x22, 0xb8, 0x49, 0x28, 0x12, 0x5d, 0x8f,
-	0x47, 0xf4, 0xbf, 0xf2, 0x69, 0x1d, 0xab, 0xfa, 0x8a, 0x8d, 0x66, 0x01, 0xc0, 0x7a, 0x1e, 0xf7,
-	0xdc, 0xd1, 0xdf, 0x0e, 0xb4, 0xcb, 0xeb, 0x03, 0x4f, 0x01, 0xd2, 0xd5, 0x4b, 0xd7, 0xd8, 0xd6,
-	0xa0, 0xb3, 0xb1, 0x02, 0x48, 0x09, 0x80, 0xa7, 0xe0, 0x49, 0x96, 0x52, 0x21, 0xc3, 0x34, 0xb7,
-	0x02, 0x3d, 0x5d, 0x0a, 0x44, 0x69, 0x71, 0xc7, 0x52, 0x4a, 0xd6, 0x08, 0xfc, 0x04, 0x3c, 0xc1,
-	0x62, 0x1e, 0xca, 0x79, 0x41, 0x6d, 0xad, 0x6b, 0x47, 0x70, 0x01, 0xcd, 0x25, 0x09, 0x3f, 0x05,
-	0x60, 0x3c, 0x7a, 0xc7, 0xe7, 0xea, 0x28, 0x5b, 0xb3, 0xc7, 0x78, 0x74, 0xab, 0x1d, 0x25, 0x6d,
-	0x0e, 0xca, 0xda, 0x04, 0x09, 0x3c, 0xdb, 0x5a, 0x6e, 0xf8, 0x03, 0x3c, 0x15, 0x74, 0x76, 0x7f,
-	0xcd, 0xef, 0xb3, 0x22, 0x0d, 0x25, 0xcb, 0xb8, 0x6d, 0x6c, 0xd7, 0x02, 0x25, 0xff, 0xc7, 0x2a,
-	0xe5, 0x1e, 0x78, 0xf6, 0x17, 0xd7, 0x63, 0xd5, 0x26, 0xc6, 0x08, 0x12, 0xc0, 0xed, 0x95, 0x88,
-	0x5f, 0x42, 0x4d, 0x6f, 0x5f, 0xdf, 0xd1, 0x03, 0xbd, 0xf3, 0x00, 0x83, 0xc0, 0x2f, 0xc0, 0x9d,
-	0x52, 0x3d, 0x56, 0x7b, 0x91, 0x1a, 0x10, 0xbc, 0x85, 0xba, 0x39, 0x09, 0x8f, 0xa0, 0x49, 0xf9,
-	0x34, 0xcf, 0x18, 0x97, 0xba, 0x03, 0x8f, 0xac, 0x6c, 0x15, 0x4b, 0xa9, 0x0c, 0xed, 0x6b, 0x52,
-	0xca, 0xae, 0xec, 0xf5, 0x06, 0xa8, 0x96, 0x37, 0x40, 0x03, 0x6a, 0x7a, 0x07, 0x0d, 0x72, 0xa8,
-	0x9b, 0xd7, 0x8c, 0x3f, 0x42, 0xdb, 0xfc, 0xbd, 0x96, 0x05, 0x0d, 0x53, 0x3c, 0xdc, 0x58, 0xcc,
-	0xb6, 0xac, 0xa3, 0x9d, 0xde, 0xa0, 0xd2, 0x73, 0xbe, 0x76, 0xf0, 0x39, 0xb8, 0xaf, 0x18, 0x8f,
-	0x71, 0x63, 0xcb, 0x1d, 0x6d, 0x58, 0x41, 0xe5, 0xf2, 0xab, 0x3f, 0x4e, 0x62, 0x26, 0x93, 0xf9,
-	0xa4, 0x1f, 0x65, 0xe9, 0x59, 0xb2, 0xc8, 0x69, 0x31, 0xa3, 0xd3, 0x98, 0x16, 0x67, 0xf7, 0xe1,
-	0xa4, 0x60, 0xd1, 0x59, 0xac, 0x53, 0x9f, 0x69, 0xd6, 0xa4, 0xae, 0x3f, 0xe7, 0xff, 0x05, 0x00,
-	0x00, 0xff, 0xff, 0x80, 0x90, 0x27, 0x7b, 0x40, 0x07, 0x00, 0x00,
+	// 1117 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x56, 0x4d, 0x6f, 0x23, 0x45,
+	0x13, 0xf6, 0xc4, 0xe3, 0xaf, 0xb2, 0x9d, 0x75, 0x6a, 0xf3, 0xbe, 0x0c, 0xd1, 0x82, 0xa2, 0x51,
+	0x04, 0x21, 0x4b, 0x12, 0x48, 0x0e, 0x7b, 0x8a, 0x58, 0x27, 0x36, 0x38, 0x22, 0x09, 0x4b, 0x27,
+	0x01, 0x89, 0xcb, 0xaa, 0x33, 0xee, 0xd8, 0x2d, 0x7b, 0x7a, 0x26, 0xd3, 0x9d, 0x05, 0x5f, 0x38,
+	0x71, 0x42, 0xfc, 0x2c, 0x7e, 0x15, 0x27, 0x34, 0x3d, 0xed, 0xf9, 0x58, 0xdb, 0x2b, 0x45, 0xe2,
+	0x34, 0x5d, 0x55, 0xcf, 0x53, 0x5d, 0x55, 0x5d, 0xd5, 0xd3, 0xd0, 0xf6, 0x99, 0x94, 0x74, 0xc4,
+	0x0e, 0xc2, 0x28, 0x50, 0x01, 0x56, 0xf4, 0xc7, 0xfd, 0xa7, 0x0a, 0xed, 0xef, 0x02, 0x29, 0x79,
+	0x78, 0x99, 0x98, 0x71, 0x13, 0x2a, 0x22, 0x10, 0x1e, 0x73, 0xac, 0x6d, 0x6b, 0xd7, 0x26, 0x89,
+	0x80, 0x5f, 0x43, 0x9d, 0x4e, 0xf9, 0x3b, 0x76, 0x29, 0x47, 0xce, 0xda, 0xb6, 0xb5, 0xdb, 0x3c,
+	0x7a, 0x9e, 0x38, 0x3a, 0xe8, 0x6a, 0x75, 0x42, 0x1e, 0x94, 0x48, 0x0a, 0xc3, 0x23, 0xa8, 0xfa,
+	0xcc, 0x27, 0xec, 0xc1, 0x29, 0x6b, 0x82, 0x63, 0x08, 0x97, 0xcc, 0xbf, 0x63, 0x91, 0x1c, 0xf3,
+	0x90, 0xb0, 0x87, 0x47, 0x26, 0xd5, 0xa0, 0x44, 0x0c, 0x12, 0x8f, 0x0d, 0x47, 0x3a, 0xb6, 0xe6,
+	0x7c, 0xbc, 0x84, 0x23, 0xc3, 0x40, 0x48, 0x96, 0x92, 0x24, 0x1e, 0x40, 0x6d, 0x48, 0x15, 0x8d,
+	0x43, 0xab, 0x68, 0x16, 0x1a, 0x56, 0x2f, 0xd6, 0xa6, 0x91, 0xcd, 0x41, 0xb8, 0x07, 0x95, 0x31,
+	0x9b, 0x4e, 0x03, 0xa7, 0x5a, 0x40, 0x27, 0x65, 0x18, 0xc4, 0x96, 0x41, 0x89, 0x24, 0x10, 0xdc,
+	0x4f, 0x7c, 0xf7, 0xf8, 0xc8, 0xa9, 0x69, 0xf4, 0x46, 0xce, 0x77, 0x8f, 0x8f, 0x92, 0xf0, 0xe7,
+	0x98, 0x79, 0x28, 0x71, 0xd2, 0xf5, 0x85, 0x50, 0xb2, 0x74, 0xe7, 0x20, 0x3c, 0x06, 0x88, 0x97,
+	0xb7, 0xe1, 0x90, 0x2a, 0xe6, 0x34, 0x16, 0x76, 0x48, 0x0c, 0x83, 0x12, 0xc9, 0xc1, 0xf0, 0x25,
+	0x54, 0xa9, 0x37, 0x89, 0xd3, 0x85, 0x02, 0xa1, 0xeb, 0x4d, 0xb2, 0x6c, 0x0d, 0x04, 0x77, 0xa0,
+	0xc2, 0xfc, 0x50, 0xcd, 0x9c, 0xa6, 0xc6, 0xb6, 0x0c, 0xb6, 0x1f, 0xeb, 0xe2, 0x34, 0xb5, 0x11,
+	0xf7, 0xc0, 0xf6, 0x02, 0x21, 0x9c, 0x96, 0x06, 0x6d, 0x1a, 0xd0, 0x59, 0x20, 0x44, 0x5f, 0x2a,
+	0x7a, 0x37, 0xe5, 0x72, 0x3c, 0x28, 0x11, 0x8d, 0xc1, 0x13, 0x68, 0x86, 0x11, 0x7f, 0x47, 0x15,
+	0x8b, 0x23, 0x74, 0xda, 0x85, 0x83, 0x7a, 0x93, 0x59, 0xb2, 0x58, 0xf2, 0x78, 0x7c, 0x05, 0x60,
+	0xc4, 0xae, 0x37, 0x71, 0xd6, 0x35, 0xfb, 0x7f, 0x8b, 0xec, 0xae, 0x37, 0x89, 0xd3, 0xce, 0xa0,
+	0xf8, 0x1a, 0xda, 0x53, 0x46, 0x87, 0x49, 0x1b, 0xc4, 0xd9, 0x3f, 0x2b, 0xb4, 0xd5, 0x45, 0x66,
+	0x4b, 0x37, 0x2e, 0x12, 0xf0, 0x1b, 0x68, 0x49, 0x45, 0x15, 0x33, 0x07, 0xe1, 0x74, 0x0a, 0xa1,
+	0x13, 0xe6, 0x07, 0x8a, 0x5d, 0xe7, 0x00, 0x83, 0x12, 0x29, 0x10, 0xf0, 0x14, 0xda, 0x46, 0x4e,
+	0x9a, 0xd0, 0xd9, 0xd0, 0x1e, 0xb6, 0x96, 0x79, 0x48, 0xdb, 0xb4, 0x48, 0x39, 0x6d, 0x40, 0xcd,
+	0x0b, 0x84, 0x62, 0x42, 0xb9, 0xaf, 0xa0, 0x5d, 0x28, 0x31, 0x76, 0xa0, 0x2c, 0xf9, 0x48, 0x4f,
+	0x5e, 0x8b, 0xc4, 0xcb, 0x78, 0x1a, 0xc3, 0x09, 0x3f, 0xef, 0xe9, 0xa1, 0x6b, 0x91, 0x44, 0x70,
+	0x6f, 0xa1, 0x99, 0x6b, 0xa8, 0x15, 0x23, 0xfb, 0x7f, 0xa8, 0x4a, 0xf6, 0x70, 0x49, 0x43, 0x67,
+	0x6d, 0xbb, 0xbc, 0x6b, 0x13, 0x23, 0xa1, 0x03, 0x35, 0x6f, 0x4c, 0xb9, 0x38, 0xef, 0xe9, 0xc1,
+	0x6c, 0x91, 0xb9, 0xe8, 0x9e, 0x40, 0x33, 0x37, 0x04, 0x2b, 0xdc, 0xe6, 0xe8, 0x6b, 0x45, 0xfa,
+	0x10, 0x20, 0xeb, 0xd9, 0x15, 0xec, 0xcf, 0xc0, 0x8e, 0x3b, 0x59, 0x87, 0xb4, 0x74, 0x50, 0x89,
+	0xb6, 0x7f, 0x20, 0xc8, 0x1b, 0x80, 0x6c, 0xf6, 0xfe, 0xb3, 0xd4, 0xff, 0xb6, 0xa0, 0x99, 0x8b,
+	0x02, 0x5f, 0x82, 0xad, 0x66, 0x61, 0xe2, 0x76, 0xfd, 0xe8, 0xa3, 0xc5, 0x38, 0x0f, 0x6e, 0x66,
+	0x21, 0x23, 0x1a, 0x84, 0xbb, 0x50, 0x0b, 0xe9, 0x6c, 0x1a, 0xd0, 0xa1, 0xb9, 0x1b, 0xd7, 0xe7,
+	0xfd, 0x9c, 0x68, 0xc9, 0xdc, 0xfc, 0x81, 0x00, 0x7a, 0x60, 0xc7, 0x1e, 0xb1, 0x0d, 0x8d, 0xdb,
+	0xab, 0x5e, 0xff, 0xdb, 0xf3, 0xab, 0x7e, 0xaf, 0x53, 0xc2, 0x06, 0x54, 0x4e, 0x2f, 0x7e, 0x38,
+	0xfb, 0xbe, 0x63, 0x61, 0x13, 0x6a, 0x3f, 0x75, 0x2f, 0xde, 0x92, 0xfe, 0x8f, 0x9d, 0xb5, 0x4c,
+	0xb8, 0xee, 0x94, 0xb1, 0x0e, 0xf6, 0x59, 0x9f, 0xdc, 0x74, 0x6c, 0xf7, 0x1c, 0x6a, 0x66, 0x4f,
+	0x53, 0x83, 0xab, 0x47, 0xdf, 0x94, 0xc6, 0x48, 0x88, 0x60, 0x8f, 0xa9, 0x1c, 0xeb, 0x48, 0x1b,
+	0x44, 0xaf, 0x63, 0x9d, 0x3e, 0x95, 0x24, 0x26, 0xbd, 0x76, 0x5d, 0x80, 0xec, 0x42, 0x59, 0x5e,
+	0x67, 0xf7, 0x2f, 0x0b, 0x70, 0x71, 0xe2, 0x9f, 0xda, 0x38, 0xf1, 0xf6, 0xea, 0x37, 0x53, 0x92,
+	0x06, 0xd1, 0x6b, 0xfc, 0x14, 0xc0, 0x0b, 0xa6, 0x53, 0xe6, 0x29, 0x1e, 0x08, 0xfd, 0x37, 0x68,
+	0x90, 0x9c, 0x26, 0x0d, 0xb9, 0x92, 0x0b, 0xf9, 0x14, 0xd6, 0x8b, 0x37, 0xc8, 0x8a, 0x48, 0xb6,
+	0xa0, 0xce, 0xc4, 0x30, 0x0c, 0xb8, 0x50, 0xa6, 0x0c, 0xa9, 0xec, 0xfe, 0x0e, 0x1b, 0x0b, 0x37,
+	0x49, 0x36, 0x85, 0x56, 0x6e, 0x0a, 0x71, 0x1f, 0x1a, 0x8a, 0xfb, 0x4c, 0x2a, 0xea, 0x87, 0xe6,
+	0xe0, 0x9f, 0xcd, 0x0f, 0x9e, 0xb1, 0xe8, 0x86, 0xfb, 0x8c, 0x64, 0x08, 0xdc, 0x81, 0x36, 0x97,
+	0x3d, 0xe6, 0x4d, 0x69, 0x44, 0x75, 0x52, 0x71, 0xba, 0x75, 0x52, 0x54, 0xba, 0x7f, 0x58, 0x80,
+	0x8b, 0x37, 0xd1, 0x93, 0x4b, 0xba, 0x0d, 0x4d, 0xa9, 0x68, 0xa4, 0xae, 0x93, 0x16, 0x28, 0x6b,
+	0x56, 0x5e, 0x85, 0x2f, 0xa0, 0xc1, 0xc4, 0xd0, 0xd8, 0x6d, 0x6d, 0xcf, 0x14, 0xee, 0xcf, 0xf0,
+	0x7c, 0xc9, 0x6d, 0xb6, 0x22, 0x8c, 0x3d, 0xa8, 0x9b, 0x06, 0x97, 0x66, 0xb0, 0xdf, 0x1f, 0x80,
+	0xd4, 0xee, 0xfe, 0x69, 0x41, 0x2b, 0xff, 0x64, 0xc0, 0x7d, 0x00, 0x3f, 0xfd, 0xbb, 0x6b, 0xbf,
+	0xcd, 0xa3, 0x76, 0xe1, 0xb7, 0x4f, 0x72, 0x80, 0xa7, 0x16, 0xfd, 0x05, 0x34, 0x24, 0x1f, 0x09,
+	0xaa, 0x1e, 0x23, 0x66, 0xda, 0x3b, 0x53, 0xb8, 0x5d, 0xa8, 0xcf, 0x49, 0xf8, 0x09, 0x00, 0x17,
+	0xde, 0x5b, 0xf1, 0x18, 0x6f, 0x65, 0xf2, 0x6b, 0x70, 0xe1, 0x5d, 0x69, 0x45, 0x6e, 0x9c, 0xd6,
+	0xf2, 0xe3, 0xe4, 0x8e, 0x61, 0x63, 0xe1, 0x41, 0x83, 0x27, 0xf0, 0x4c, 0xb2, 0xe9, 0xfd, 0xb9,
+	0xb8, 0x0f, 0x22, 0x3f, 0x39, 0x6c, 0x6b, 0xe5, 0xa3, 0x89, 0xbc, 0x8f, 0x8d, 0xab, 0x3c, 0x11,
+	0xc1, 0xaf, 0x42, 0x17, 0xb3, 0x45, 0x12, 0xc1, 0x1d, 0x03, 0x2e, 0x3e, 0x83, 0xf0, 0x0b, 0xa8,
+	0xe8, 0x17, 0x97, 0x63, 0x6d, 0x97, 0x57, 0x6d, 0x90, 0x20, 0xf0, 0x73, 0xb0, 0x87, 0x4c, 0xdf,
+	0x51, 0x2b, 0x91, 0x1a, 0xe0, 0x46, 0x50, 0x4d, 0x76, 0x2a, 0x4c, 0x8a, 0x55, 0x9c, 0x94, 0xd8,
+	0xe6, 0x33, 0x45, 0xcd, 0x75, 0x1e, 0x57, 0x36, 0x95, 0xb3, 0x81, 0x29, 0xe7, 0x07, 0x66, 0x0b,
+	0xea, 0x7c, 0xc8, 0x84, 0xe2, 0x6a, 0xa6, 0x3b, 0xae, 0x45, 0x52, 0xd9, 0xad, 0x41, 0x45, 0xbf,
+	0x49, 0x8e, 0x42, 0xa8, 0x26, 0x3f, 0x21, 0x7c, 0x0d, 0xad, 0x64, 0x75, 0xad, 0x22, 0x46, 0x7d,
+	0xdc, 0x2c, 0x3c, 0xd4, 0x4c, 0xc8, 0x5b, 0x4b, 0xb5, 0x6e, 0x69, 0xd7, 0xfa, 0xca, 0xc2, 0x1d,
+	0xb0, 0xdf, 0x70, 0x31, 0xc2, 0xc2, 0xab, 0x67, 0xab, 0x20, 0xb9, 0xa5, 0xd3, 0x2f, 0x7f, 0xd9,
+	0x1b, 0x71, 0x35, 0x7e, 0xbc, 0x3b, 0xf0, 0x02, 0xff, 0x70, 0x3c, 0x0b, 0x59, 0x34, 0x65, 0xc3,
+	0x11, 0x8b, 0x0e, 0xef, 0xe9, 0x5d, 0xc4, 0xbd, 0xc3, 0x91, 0x76, 0x7d, 0xa8, 0x59, 0x77, 0x55,
+	0xfd, 0x39, 0xfe, 0x77, 0x00, 0x0a, 0xc0, 0xb1, 0xa7, 0x50, 0x0b, 0x00, 0x00,
 }
@@ -0,0 +1,197 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/core/crypto/primitives"
+	gproto "github.com/hyperledger/fabric/gossip/proto"
+	"github.com/spf13/viper"
+)
+
+// peerCryptoService signs the alive messages of the peer with the key of its
+// identity and validates those of the other peers against their identity: a
+// certificate issued by one of the root CAs of "peer.gossip.identity" and
+// valid for the host of the endpoint they announce, which is their PKI-ID.
+// The gossip messages are not signed otherwise.
+// TODO authenticate the peers with their membership service identity
+type peerCryptoService struct {
+	identity []byte
+	key      *ecdsa.PrivateKey
+	roots    *x509.CertPool
+}
+
+// newPeerCryptoService returns the crypto service of the identity of
+// "peer.gossip.identity", by default the TLS certificate and key of the peer
+// and the root CAs of its TLS clients
+func newPeerCryptoService() (*peerCryptoService, error) {
+	certFile := viper.GetString("peer.gossip.identity.cert.file")
+	if certFile == "" {
+		certFile = viper.GetString("peer.tls.cert.file")
+	}
+	keyFile := viper.GetString("peer.gossip.identity.key.file")
+	if keyFile == "" {
+		keyFile = viper.GetString("peer.tls.key.file")
+	}
+	rootCAFiles := viper.GetStringSlice("peer.gossip.identity.rootCAs.files")
+	if len(rootCAFiles) == 0 {
+		rootCAFiles = viper.GetStringSlice("peer.tls.clientRootCAs.files")
+	}
+	if len(rootCAFiles) == 0 {
+		return nil, errors.New("No root CA to verify the identities of the peers in peer.gossip.identity.rootCAs.files")
+	}
+
+	identity, err := ioutil.ReadFile(certFile)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading the certificate of the gossip identity: %s", err)
+	}
+	cert, err := primitives.PEMtoCertificate(identity)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid certificate of the gossip identity: %s", err)
+	}
+	rawKey, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading the key of the gossip identity: %s", err)
+	}
+	key, err := primitives.PEMtoPrivateKey(rawKey, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid key of the gossip identity: %s", err)
+	}
+	ecdsaKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("Invalid key of the gossip identity: not an ECDSA key")
+	}
+	if err = primitives.CheckCertPKAgainstSK(cert, key); err != nil {
+		return nil, fmt.Errorf("The key of the gossip identity does not match its certificate: %s", err)
+	}
+
+	roots := x509.NewCertPool()
+	for _, file := range rootCAFiles {
+		pem, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read root CA %s: %s", file, err)
+		}
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("No certificate found in root CA %s", file)
+		}
+	}
+	return &peerCryptoService{identity: identity, key: ecdsaKey, roots: roots}, nil
+}
+
+// ValidateAliveMsg returns whether the alive message is signed by the
+// identity it carries and the identity is the one of the endpoint it announces
+func (cs *peerCryptoService) ValidateAliveMsg(am *gproto.AliveMessage) bool {
+	if err := cs.validateAliveMsg(am); err != nil {
+		logger.Warningf("Dropping alive message: %s", err)
+		return false
+	}
+	return true
+}
+
+func (cs *peerCryptoService) validateAliveMsg(am *gproto.AliveMessage) error {
+	member := am.Membership
+	if member == nil {
+		return errors.New("no membership")
+	}
+	if string(member.PkiID) != member.Endpoint {
+		return fmt.Errorf("PKI-ID %v is not the endpoint %s", member.PkiID, member.Endpoint)
+	}
+	host, _, err := net.SplitHostPort(member.Endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid endpoint %s: %s", member.Endpoint, err)
+	}
+	cert, err := primitives.PEMtoCertificate(member.Identity)
+	if err != nil {
+		return fmt.Errorf("invalid identity of %s: %s", member.Endpoint, err)
+	}
+	opts := x509.VerifyOptions{DNSName: host, Roots: cs.roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}}
+	if _, err = cert.Verify(opts); err != nil {
+		return fmt.Errorf("identity not valid for %s: %s", member.Endpoint, err)
+	}
+	key, ok := cert.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("identity of %s has no ECDSA public key", member.Endpoint)
+	}
+	digest, err := aliveMsgDigest(am)
+	if err != nil {
+		return err
+	}
+	signature := &primitives.ECDSASignature{}
+	if _, err = asn1.Unmarshal(am.Signature, signature); err != nil {
+		return fmt.Errorf("invalid signature of %s: %s", member.Endpoint, err)
+	}
+	if !ecdsa.Verify(key, digest, signature.R, signature.S) {
+		return fmt.Errorf("alive message of %s is not signed by its identity", member.Endpoint)
+	}
+	return nil
+}
+
+// SignMessage adds the identity of the peer to its alive message and signs
+// it. The message is left unsigned, and dropped by the other peers, if the
+// signature fails
+func (cs *peerCryptoService) SignMessage(am *gproto.AliveMessage) *gproto.AliveMessage {
+	am.Membership.Identity = cs.identity
+	am.Signature = nil
+	digest, err := aliveMsgDigest(am)
+	if err != nil {
+		logger.Errorf("Error signing the alive message: %s", err)
+		return am
+	}
+	r, s, err := ecdsa.Sign(rand.Reader, cs.key, digest)
+	if err != nil {
+		logger.Errorf("Error signing the alive message: %s", err)
+		return am
+	}
+	if am.Signature, err = asn1.Marshal(primitives.ECDSASignature{R: r, S: s}); err != nil {
+		logger.Errorf("Error signing the alive message: %s", err)
+	}
+	return am
+}
+
+// aliveMsgDigest returns the digest of an alive message without its signature
+func aliveMsgDigest(am *gproto.AliveMessage) ([]byte, error) {
+	unsigned := *am
+	unsigned.Signature = nil
+	msgBytes, err := proto.Marshal(&unsigned)
+	if err != nil {
+		return nil, fmt.Errorf("Error marshalling the alive message: %s", err)
+	}
+	digest := sha256.Sum256(msgBytes)
+	return digest[:], nil
+}
+
+func (*peerCryptoService) IsEnabled() bool {
+	return false
+}
+
+func (*peerCryptoService) Sign(msg []byte) ([]byte, error) {
+	return msg, nil
+}
+
+func (*peerCryptoService) Verify(vkID, signature, message []byte) error {
+	return nil
+}
@@ -0,0 +1,157 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/core/crypto/primitives"
+	gproto "github.com/hyperledger/fabric/gossip/proto"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+type testIdentity struct {
+	cert    *x509.Certificate
+	certPEM []byte
+	key     *ecdsa.PrivateKey
+}
+
+// newTestIdentity returns an identity valid for host issued by issuer, or a
+// root CA if issuer is nil
+func newTestIdentity(host string, issuer *testIdentity) (*testIdentity, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: host},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	parent, parentKey := template, key
+	if issuer == nil {
+		template.Subject.CommonName = "gossip test CA"
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		template.DNSNames = []string{host}
+		parent, parentKey = issuer.cert, issuer.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	return &testIdentity{cert: cert, certPEM: primitives.DERCertToPEM(der), key: key}, nil
+}
+
+// TestMain gives the test peers, all on localhost, a gossip identity and the
+// settings of core.yaml the gossip service requires
+func TestMain(m *testing.M) {
+	dir, err := ioutil.TempDir("", "gossipservicetest")
+	if err != nil {
+		panic(err)
+	}
+	ca, err := newTestIdentity("", nil)
+	if err != nil {
+		panic(err)
+	}
+	peerIdentity, err := newTestIdentity("localhost", ca)
+	if err != nil {
+		panic(err)
+	}
+	keyPEM, err := primitives.PrivateKeyToPEM(peerIdentity.key, nil)
+	if err != nil {
+		panic(err)
+	}
+	files := map[string][]byte{"ca.pem": ca.certPEM, "peer.pem": peerIdentity.certPEM, "peer.key": keyPEM}
+	for name, content := range files {
+		if err = ioutil.WriteFile(filepath.Join(dir, name), content, 0600); err != nil {
+			panic(err)
+		}
+	}
+	viper.Set("peer.gossip.identity.cert.file", filepath.Join(dir, "peer.pem"))
+	viper.Set("peer.gossip.identity.key.file", filepath.Join(dir, "peer.key"))
+	viper.Set("peer.gossip.identity.rootCAs.files", []string{filepath.Join(dir, "ca.pem")})
+	viper.Set("peer.gossip.privateDataAckTimeout", 3*time.Second)
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func newTestAliveMsg(endpoint string) *gproto.AliveMessage {
+	return &gproto.AliveMessage{
+		Membership: &gproto.Member{Endpoint: endpoint, Metadata: []byte("metadata"), PkiID: []byte(endpoint)},
+		Timestamp:  &gproto.PeerTime{IncNumber: 1, SeqNum: 1},
+	}
+}
+
+func TestValidateAliveMsg(t *testing.T) {
+	cs, err := newPeerCryptoService()
+	assert.NoError(t, err)
+
+	am := cs.SignMessage(newTestAliveMsg("localhost:7051"))
+	assert.Equal(t, cs.identity, am.Membership.Identity)
+	assert.True(t, cs.ValidateAliveMsg(am))
+
+	am.Timestamp.SeqNum = 2
+	assert.False(t, cs.ValidateAliveMsg(am), "Alive message modified after its signature")
+
+	am = newTestAliveMsg("localhost:7051")
+	am.Membership.Identity = cs.identity
+	assert.False(t, cs.ValidateAliveMsg(am), "Alive message not signed")
+
+	assert.False(t, cs.ValidateAliveMsg(cs.SignMessage(newTestAliveMsg("otherhost:7051"))),
+		"Identity not valid for the host of the endpoint")
+
+	am = newTestAliveMsg("localhost:7051")
+	am.Membership.PkiID = []byte("localhost:7052")
+	assert.False(t, cs.ValidateAliveMsg(cs.SignMessage(am)), "PKI-ID other than the endpoint")
+
+	otherCA, err := newTestIdentity("", nil)
+	assert.NoError(t, err)
+	other, err := newTestIdentity("localhost", otherCA)
+	assert.NoError(t, err)
+	otherCS := &peerCryptoService{identity: other.certPEM, key: other.key, roots: cs.roots}
+	assert.False(t, cs.ValidateAliveMsg(otherCS.SignMessage(newTestAliveMsg("localhost:7051"))),
+		"Identity not issued by the root CAs")
+}
+
+func TestNewPeerCryptoServiceWithoutRootCAs(t *testing.T) {
+	defer viper.Set("peer.gossip.identity.rootCAs.files", viper.GetStringSlice("peer.gossip.identity.rootCAs.files"))
+	viper.Set("peer.gossip.identity.rootCAs.files", []string{})
+
+	_, err := newPeerCryptoService()
+	assert.Error(t, err)
+}
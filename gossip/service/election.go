@@ -0,0 +1,100 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/hyperledger/fabric/gossip/election"
+	"github.com/hyperledger/fabric/gossip/gossip"
+	gproto "github.com/hyperledger/fabric/gossip/proto"
+	"github.com/spf13/viper"
+)
+
+// NewLeaderElectionService elects over gossip a leader among the given peers
+// of the organization of the peer at endpoint, or among all the peers if no
+// organization peers are given. The election timing comes from the
+// "peer.gossip.election" settings
+func NewLeaderElectionService(g gossip.Gossip, endpoint string, orgPeers []string, callback func(bool)) election.LeaderElectionService {
+	viper.SetDefault("peer.gossip.election.startupGracePeriod", 15*time.Second)
+	viper.SetDefault("peer.gossip.election.membershipSampleInterval", time.Second)
+	viper.SetDefault("peer.gossip.election.leaderAliveThreshold", 10*time.Second)
+	viper.SetDefault("peer.gossip.election.leaderElectionDuration", 5*time.Second)
+	election.SetStartupGracePeriod(viper.GetDuration("peer.gossip.election.startupGracePeriod"))
+	election.SetMembershipSampleInterval(viper.GetDuration("peer.gossip.election.membershipSampleInterval"))
+	election.SetLeaderAliveThreshold(viper.GetDuration("peer.gossip.election.leaderAliveThreshold"))
+	election.SetLeaderElectionDuration(viper.GetDuration("peer.gossip.election.leaderElectionDuration"))
+
+	adapter := &electionAdapter{
+		gossip:   g,
+		orgPeers: make(map[string]bool),
+		incTime:  uint64(time.Now().UnixNano()),
+	}
+	for _, p := range orgPeers {
+		adapter.orgPeers[p] = true
+	}
+	return election.NewLeaderElectionService(adapter, endpoint, callback)
+}
+
+// electionAdapter sends the leadership messages of the election over gossip,
+// and restricts the election to the peers of the organization
+type electionAdapter struct {
+	gossip   gossip.Gossip
+	orgPeers map[string]bool
+	incTime  uint64
+	seqNum   uint64
+}
+
+func (a *electionAdapter) Gossip(msg *gproto.LeadershipMessage) {
+	// the gossip component keeps only the latest message of each peer
+	msg.Timestamp = &gproto.PeerTime{IncNumber: a.incTime, SeqNum: atomic.AddUint64(&a.seqNum, 1)}
+	a.gossip.Gossip(&gproto.GossipMessage{
+		Content: &gproto.GossipMessage_LeadershipMsg{LeadershipMsg: msg},
+	})
+}
+
+func (a *electionAdapter) Accept() <-chan *gproto.LeadershipMessage {
+	isOrgLeadershipMsg := func(m interface{}) bool {
+		leadershipMsg := m.(*gproto.GossipMessage).GetLeadershipMsg()
+		return leadershipMsg != nil && a.isOrgPeer(string(leadershipMsg.PkiID))
+	}
+
+	msgs := a.gossip.Accept(isOrgLeadershipMsg)
+	leadershipMsgs := make(chan *gproto.LeadershipMessage, 10)
+	go func() {
+		defer close(leadershipMsgs)
+		for msg := range msgs {
+			leadershipMsgs <- msg.GetLeadershipMsg()
+		}
+	}()
+	return leadershipMsgs
+}
+
+func (a *electionAdapter) Peers() []string {
+	var peers []string
+	for _, member := range a.gossip.GetPeers() {
+		if a.isOrgPeer(member.Endpoint) {
+			peers = append(peers, member.Endpoint)
+		}
+	}
+	return peers
+}
+
+func (a *electionAdapter) isOrgPeer(endpoint string) bool {
+	return len(a.orgPeers) == 0 || a.orgPeers[endpoint]
+}
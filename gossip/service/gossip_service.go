@@ -0,0 +1,197 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/core/metrics"
+	"github.com/hyperledger/fabric/gossip/comm"
+	"github.com/hyperledger/fabric/gossip/gossip"
+	gproto "github.com/hyperledger/fabric/gossip/proto"
+	cb "github.com/hyperledger/fabric/protos/common"
+	"github.com/op/go-logging"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+)
+
+var logger = logging.MustGetLogger("gossip/service")
+
+var (
+	gossipServiceInstance gossip.Gossip
+	once                  sync.Once
+)
+
+// privateDataAckTimeout is read from "peer.gossip.privateDataAckTimeout" when
+// the gossip service is created
+var privateDataAckTimeout time.Duration
+
+// InitGossipService starts the gossip component of the peer on the gRPC server
+// of the peer. The component learns about the other peers of the network from
+// the bootstrap peers, and dials them with the given options
+func InitGossipService(endpoint string, s *grpc.Server, dialOpts []grpc.DialOption, bootPeers ...string) error {
+	var err error
+	once.Do(func() {
+		gossipServiceInstance, err = newGossipService(endpoint, s, dialOpts, bootPeers...)
+	})
+	return err
+}
+
+// GetGossipService returns the gossip component of the peer, or nil if
+// InitGossipService wasn't called
+func GetGossipService() gossip.Gossip {
+	return gossipServiceInstance
+}
+
+func newGossipService(endpoint string, s *grpc.Server, dialOpts []grpc.DialOption, bootPeers ...string) (gossip.Gossip, error) {
+	conf := newConfig(endpoint, bootPeers)
+	privateDataAckTimeout = viper.GetDuration("peer.gossip.privateDataAckTimeout")
+	if privateDataAckTimeout <= 0 {
+		return nil, fmt.Errorf("Invalid peer.gossip.privateDataAckTimeout %s", privateDataAckTimeout)
+	}
+	cs, err := newPeerCryptoService()
+	if err != nil {
+		return nil, err
+	}
+	c, err := comm.NewCommInstance(s, cs, comm.PKIidType(endpoint), dialOpts...)
+	if err != nil {
+		return nil, err
+	}
+	logger.Infof("Starting gossip on %s with bootstrap peers %v", endpoint, bootPeers)
+	serviceMetrics = newGossipMetrics(metrics.GetProvider())
+	return gossip.NewGossipService(conf, c, cs), nil
+}
+
+// newConfig returns the gossip configuration of the peer.gossip section,
+// with the defaults of the gossip component for unset settings
+func newConfig(endpoint string, bootPeers []string) *gossip.Config {
+	viper.SetDefault("peer.gossip.propagateIterations", 1)
+	viper.SetDefault("peer.gossip.propagatePeerNum", 3)
+	viper.SetDefault("peer.gossip.maxMessageCountToStore", 200)
+	viper.SetDefault("peer.gossip.maxPropagationBurstSize", 10)
+	viper.SetDefault("peer.gossip.maxPropagationBurstLatency", 10*time.Millisecond)
+	viper.SetDefault("peer.gossip.pullInterval", 4*time.Second)
+	viper.SetDefault("peer.gossip.pullPeerNum", 3)
+
+	return &gossip.Config{
+		ID:                         endpoint,
+		SelfEndpoint:               endpoint,
+		BootstrapPeers:             bootPeers,
+		PropagateIterations:        viper.GetInt("peer.gossip.propagateIterations"),
+		PropagatePeerNum:           viper.GetInt("peer.gossip.propagatePeerNum"),
+		MaxMessageCountToStore:     viper.GetInt("peer.gossip.maxMessageCountToStore"),
+		MaxPropagationBurstSize:    viper.GetInt("peer.gossip.maxPropagationBurstSize"),
+		MaxPropagationBurstLatency: viper.GetDuration("peer.gossip.maxPropagationBurstLatency"),
+		PullInterval:               viper.GetDuration("peer.gossip.pullInterval"),
+		PullPeerNum:                viper.GetInt("peer.gossip.pullPeerNum"),
+	}
+}
+
+// GossipBlock disseminates a block of a chain to the peers that joined the chain.
+// The block number is the sequence number of the gossip message
+func GossipBlock(g gossip.Gossip, chainID string, block *cb.Block) error {
+	payload, err := blockToPayload(block)
+	if err != nil {
+		return err
+	}
+	g.Gossip(&gproto.GossipMessage{
+		Content: &gproto.GossipMessage_DataMsg{
+			DataMsg: &gproto.DataMessage{
+				ChainID: []byte(chainID),
+				Payload: payload,
+			},
+		},
+	})
+	serviceMetrics.blocksSent.With("chain", chainID).Add(1)
+	return nil
+}
+
+// AcceptBlocks returns a channel of the blocks of a chain that other peers
+// disseminated. The peer must have joined the chain to receive them
+func AcceptBlocks(g gossip.Gossip, chainID string) <-chan *cb.Block {
+	isChainBlock := func(m interface{}) bool {
+		dataMsg := m.(*gproto.GossipMessage).GetDataMsg()
+		return dataMsg != nil && dataMsg.Payload != nil && string(dataMsg.ChainID) == chainID
+	}
+
+	msgs := g.Accept(isChainBlock)
+	blocks := make(chan *cb.Block, 10)
+	go func() {
+		defer close(blocks)
+		for msg := range msgs {
+			block := &cb.Block{}
+			if err := proto.Unmarshal(msg.GetDataMsg().Payload.Data, block); err != nil {
+				logger.Warningf("Dropping invalid block of chain %s received over gossip: %s", chainID, err)
+				continue
+			}
+			serviceMetrics.blocksReceived.With("chain", chainID).Add(1)
+			blocks <- block
+		}
+	}()
+	return blocks
+}
+
+// DisseminatePrivateData sends the private data of a transaction for a collection
+// to up to maxPeerCount of the given peers, picked at random among the alive ones,
+// and fails unless at least requiredPeerCount of them acknowledged storing it
+// within "peer.gossip.privateDataAckTimeout"
+func DisseminatePrivateData(g gossip.Gossip, chainID, txID, collection string, data []byte, peers []string, requiredPeerCount, maxPeerCount int) error {
+	err := disseminatePrivateData(g, chainID, txID, collection, data, peers, requiredPeerCount, maxPeerCount)
+	serviceMetrics.privateData.With("chain", chainID, "success", strconv.FormatBool(err == nil)).Add(1)
+	return err
+}
+
+func disseminatePrivateData(g gossip.Gossip, chainID, txID, collection string, data []byte, peers []string, requiredPeerCount, maxPeerCount int) error {
+	alive := make(map[string]bool)
+	for _, member := range g.GetPeers() {
+		alive[member.Endpoint] = true
+	}
+	var candidates []string
+	for _, endpoint := range peers {
+		if alive[endpoint] {
+			candidates = append(candidates, endpoint)
+			delete(alive, endpoint)
+		}
+	}
+	if len(candidates) < requiredPeerCount {
+		return fmt.Errorf("private data of collection %s requires %d peers, %d of its peers are alive", collection, requiredPeerCount, len(candidates))
+	}
+
+	var selected []string
+	for _, i := range rand.Perm(len(candidates)) {
+		if len(selected) == maxPeerCount {
+			break
+		}
+		selected = append(selected, candidates[i])
+	}
+	if len(selected) == 0 {
+		return nil
+	}
+
+	msg := &gproto.PrivateDataMessage{ChainID: []byte(chainID), TxID: txID, Collection: collection, Data: data}
+	acked := g.SendPrivateData(msg, privateDataAckTimeout, selected...)
+	if len(acked) < requiredPeerCount {
+		return fmt.Errorf("private data of collection %s was acknowledged by %d peers, %d are required", collection, len(acked), requiredPeerCount)
+	}
+	logger.Debugf("Private data of transaction %s for collection %s stored by %v", txID, collection, acked)
+	return nil
+}
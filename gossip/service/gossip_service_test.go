@@ -0,0 +1,267 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/gossip/election"
+	"github.com/hyperledger/fabric/gossip/gossip"
+	cb "github.com/hyperledger/fabric/protos/common"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+func newTestGossipService(t *testing.T, port int, bootPeers ...string) (gossip.Gossip, *grpc.Server) {
+	endpoint := fmt.Sprintf("localhost:%d", port)
+	lis, err := net.Listen("tcp", endpoint)
+	if err != nil {
+		t.Fatalf("Could not listen on %s: %s", endpoint, err)
+	}
+	s := grpc.NewServer()
+	g, err := newGossipService(endpoint, s, []grpc.DialOption{grpc.WithInsecure(), grpc.WithTimeout(time.Second)}, bootPeers...)
+	if err != nil {
+		t.Fatalf("Could not create the gossip service: %s", err)
+	}
+	go s.Serve(lis)
+	return g, s
+}
+
+func TestGossipBlocks(t *testing.T) {
+	g1, s1 := newTestGossipService(t, 7611)
+	defer s1.Stop()
+	defer g1.Stop()
+	g2, s2 := newTestGossipService(t, 7612, "localhost:7611")
+	defer s2.Stop()
+	defer g2.Stop()
+	g3, s3 := newTestGossipService(t, 7613, "localhost:7611")
+	defer s3.Stop()
+	defer g3.Stop()
+
+	g1.JoinChain([]byte("testchain"))
+	g2.JoinChain([]byte("testchain"))
+	blocks := AcceptBlocks(g2, "testchain")
+	otherBlocks := AcceptBlocks(g3, "testchain")
+
+	deadline := time.Now().Add(10 * time.Second)
+	for len(g1.GetPeers()) != 2 || len(g2.GetPeers()) != 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Peers didn't learn about each other")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	for i := uint64(0); i < 3; i++ {
+		block := &cb.Block{Header: &cb.BlockHeader{Number: i}, Data: &cb.BlockData{Data: [][]byte{[]byte("tx")}}}
+		assert.NoError(t, GossipBlock(g1, "testchain", block))
+	}
+
+	for i := 0; i < 3; i++ {
+		select {
+		case block := <-blocks:
+			assert.Equal(t, [][]byte{[]byte("tx")}, block.Data.Data)
+		case <-time.After(10 * time.Second):
+			t.Fatalf("Peer of the chain received %d blocks out of 3", i)
+		}
+	}
+
+	select {
+	case <-otherBlocks:
+		t.Fatalf("Peer that didn't join the chain received a block")
+	case <-time.After(time.Second):
+	}
+
+	assert.Error(t, GossipBlock(g1, "testchain", &cb.Block{}), "Block without header")
+}
+
+func TestDisseminatePrivateData(t *testing.T) {
+	g1, s1 := newTestGossipService(t, 7621)
+	defer s1.Stop()
+	defer g1.Stop()
+	g2, s2 := newTestGossipService(t, 7622, "localhost:7621")
+	defer s2.Stop()
+	defer g2.Stop()
+	g3, s3 := newTestGossipService(t, 7623, "localhost:7621")
+	defer s3.Stop()
+	defer g3.Stop()
+
+	for _, g := range []gossip.Gossip{g1, g2, g3} {
+		g.JoinChain([]byte("testchain"))
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for len(g1.GetPeers()) != 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Peers didn't learn about each other")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	peers := []string{"localhost:7622", "localhost:7623", "localhost:7624"}
+	assert.Error(t, DisseminatePrivateData(g1, "testchain", "tx1", "c1", []byte("secret"), peers, 3, 3), "Only 2 of the peers are alive")
+
+	assert.NoError(t, DisseminatePrivateData(g1, "testchain", "tx1", "c1", []byte("secret"), peers, 1, 1))
+	held := 0
+	for _, g := range []gossip.Gossip{g2, g3} {
+		if data := g.PrivateData([]byte("testchain"), "tx1", "c1"); data != nil {
+			assert.Equal(t, []byte("secret"), data)
+			held++
+		}
+	}
+	assert.Equal(t, 1, held, "The private data should have been sent to maxPeerCount peers")
+
+	assert.NoError(t, DisseminatePrivateData(g1, "testchain", "tx2", "c1", []byte("secret"), peers[:1], 1, 2))
+	assert.Equal(t, []byte("secret"), g2.PrivateData([]byte("testchain"), "tx2", "c1"))
+	assert.Nil(t, g3.PrivateData([]byte("testchain"), "tx2", "c1"), "Private data sent to a peer of another organization")
+}
+
+func TestLeaderElection(t *testing.T) {
+	viper.Set("peer.gossip.election.startupGracePeriod", 2*time.Second)
+	viper.Set("peer.gossip.election.leaderAliveThreshold", 2*time.Second)
+	viper.Set("peer.gossip.election.leaderElectionDuration", time.Second)
+
+	org1 := []string{"localhost:7631", "localhost:7632"}
+	org2 := []string{"localhost:7633"}
+	var services []election.LeaderElectionService
+	leaders := make(chan string, 10)
+	for i, port := range []int{7631, 7632, 7633} {
+		g, s := newTestGossipService(t, port, "localhost:7631")
+		defer s.Stop()
+		defer g.Stop()
+		orgPeers := org1
+		if i == 2 {
+			orgPeers = org2
+		}
+		endpoint := fmt.Sprintf("localhost:%d", port)
+		le := NewLeaderElectionService(g, endpoint, orgPeers, func(isLeader bool) {
+			if isLeader {
+				leaders <- endpoint
+			}
+		})
+		defer le.Stop()
+		services = append(services, le)
+	}
+
+	elected := make(map[string]bool)
+	timeout := time.After(15 * time.Second)
+	for len(elected) < 2 {
+		select {
+		case endpoint := <-leaders:
+			elected[endpoint] = true
+		case <-timeout:
+			t.Fatalf("Leaders weren't elected, elected %v", elected)
+		}
+	}
+	// each organization elects its own leader
+	assert.True(t, elected["localhost:7633"])
+	assert.True(t, services[0].IsLeader() != services[1].IsLeader(), "Exactly one leader expected in org1")
+
+	time.Sleep(4 * time.Second)
+	assert.Len(t, leaders, 0, "The leadership should be stable")
+}
+
+// blockLedgerMock holds a chain of blocks
+type blockLedgerMock struct {
+	sync.Mutex
+	blocks []*cb.Block
+}
+
+func newBlockLedgerMock(height int) *blockLedgerMock {
+	l := &blockLedgerMock{}
+	for i := 0; i < height; i++ {
+		data := &cb.BlockData{Data: [][]byte{[]byte(fmt.Sprintf("tx%d", i))}}
+		header := &cb.BlockHeader{Number: uint64(i), DataHash: data.Hash()}
+		if i > 0 {
+			header.PreviousHash = l.blocks[i-1].Header.Hash()
+		}
+		l.blocks = append(l.blocks, &cb.Block{Header: header, Data: data})
+	}
+	return l
+}
+
+func (l *blockLedgerMock) Height() uint64 {
+	l.Lock()
+	defer l.Unlock()
+	return uint64(len(l.blocks))
+}
+
+func (l *blockLedgerMock) GetBlock(number uint64) *cb.Block {
+	l.Lock()
+	defer l.Unlock()
+	if number >= uint64(len(l.blocks)) {
+		return nil
+	}
+	return l.blocks[number]
+}
+
+func (l *blockLedgerMock) CommitBlock(block *cb.Block) error {
+	l.Lock()
+	defer l.Unlock()
+	if block.Header.Number != uint64(len(l.blocks)) {
+		return fmt.Errorf("expected block %d", len(l.blocks))
+	}
+	if len(l.blocks) > 0 && !bytes.Equal(block.Header.PreviousHash, l.blocks[len(l.blocks)-1].Header.Hash()) {
+		return fmt.Errorf("block %d doesn't extend the hash chain", block.Header.Number)
+	}
+	l.blocks = append(l.blocks, block)
+	return nil
+}
+
+func TestStateProvider(t *testing.T) {
+	viper.Set("peer.gossip.state.antiEntropyInterval", 500*time.Millisecond)
+	viper.Set("peer.gossip.state.batchSize", 4)
+
+	g1, s1 := newTestGossipService(t, 7641)
+	defer s1.Stop()
+	defer g1.Stop()
+	g2, s2 := newTestGossipService(t, 7642, "localhost:7641")
+	defer s2.Stop()
+	defer g2.Stop()
+	g1.JoinChain([]byte("testchain"))
+	g2.JoinChain([]byte("testchain"))
+
+	full := newBlockLedgerMock(10)
+	empty := newBlockLedgerMock(0)
+	defer NewStateProvider(g1, "testchain", full, nil).Stop()
+	defer NewStateProvider(g2, "testchain", empty, nil).Stop()
+
+	deadline := time.Now().Add(15 * time.Second)
+	for empty.Height() != 10 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Blocks weren't transferred, height is %d", empty.Height())
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	for i := uint64(0); i < 10; i++ {
+		assert.True(t, proto.Equal(full.GetBlock(i), empty.GetBlock(i)), "Block %d differs", i)
+	}
+}
+
+func TestNewGossipServiceWithoutPrivateDataAckTimeout(t *testing.T) {
+	defer viper.Set("peer.gossip.privateDataAckTimeout", viper.GetDuration("peer.gossip.privateDataAckTimeout"))
+	viper.Set("peer.gossip.privateDataAckTimeout", 0)
+
+	_, err := newGossipService("localhost:7641", grpc.NewServer(), []grpc.DialOption{grpc.WithInsecure()})
+	assert.Error(t, err)
+}
@@ -0,0 +1,56 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"github.com/hyperledger/fabric/core/metrics"
+)
+
+// gossipMetrics are the metrics of the blocks and private data the peer
+// disseminates and receives over gossip
+type gossipMetrics struct {
+	blocksSent     metrics.Counter
+	blocksReceived metrics.Counter
+	privateData    metrics.Counter
+}
+
+// serviceMetrics are replaced by those of the metrics provider of the peer
+// when the gossip service is created
+var serviceMetrics = newGossipMetrics(&metrics.DisabledProvider{})
+
+func newGossipMetrics(p metrics.Provider) *gossipMetrics {
+	return &gossipMetrics{
+		blocksSent: p.NewCounter(metrics.CounterOpts{
+			Namespace:  "gossip",
+			Name:       "blocks_sent",
+			Help:       "The number of blocks disseminated over gossip.",
+			LabelNames: []string{"chain"},
+		}),
+		blocksReceived: p.NewCounter(metrics.CounterOpts{
+			Namespace:  "gossip",
+			Name:       "blocks_received",
+			Help:       "The number of blocks received over gossip.",
+			LabelNames: []string{"chain"},
+		}),
+		privateData: p.NewCounter(metrics.CounterOpts{
+			Namespace:  "gossip",
+			Name:       "private_data_disseminated",
+			Help:       "The number of private data disseminations, by success.",
+			LabelNames: []string{"chain", "success"},
+		}),
+	}
+}
@@ -0,0 +1,112 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/gossip/gossip"
+	gproto "github.com/hyperledger/fabric/gossip/proto"
+	"github.com/hyperledger/fabric/gossip/state"
+	cb "github.com/hyperledger/fabric/protos/common"
+	"github.com/spf13/viper"
+)
+
+// BlockLedger holds the blocks of a chain as the orderer created them
+type BlockLedger interface {
+	// Height returns the number of blocks of the ledger
+	Height() uint64
+
+	// GetBlock returns the block with the given number, or nil if the ledger
+	// doesn't have it
+	GetBlock(number uint64) *cb.Block
+
+	// CommitBlock verifies that the block extends the hash chain of the ledger
+	// and commits it
+	CommitBlock(block *cb.Block) error
+}
+
+// NewStateProvider serves the blocks of the ledger of a chain to the other
+// peers, and fetches from them the blocks the ledger misses. The chaincodes
+// returned by chaincodes are published to the other peers along with the height
+// of the ledger. The state transfer settings come from "peer.gossip.state"
+func NewStateProvider(g gossip.Gossip, chainID string, ledger BlockLedger, chaincodes func() []string) state.GossipStateProvider {
+	viper.SetDefault("peer.gossip.state.antiEntropyInterval", 10*time.Second)
+	viper.SetDefault("peer.gossip.state.requestTimeout", 5*time.Second)
+	viper.SetDefault("peer.gossip.state.batchSize", 10)
+	state.SetAntiEntropyInterval(viper.GetDuration("peer.gossip.state.antiEntropyInterval"))
+	state.SetStateRequestTimeout(viper.GetDuration("peer.gossip.state.requestTimeout"))
+	state.SetStateBatchSize(uint64(viper.GetInt("peer.gossip.state.batchSize")))
+
+	return state.NewGossipStateProvider([]byte(chainID), g, &stateAdapter{ledger: ledger}, chaincodes)
+}
+
+// stateAdapter exposes the blocks of a ledger as gossip payloads
+type stateAdapter struct {
+	ledger BlockLedger
+}
+
+func (a *stateAdapter) Height() uint64 {
+	return a.ledger.Height()
+}
+
+func (a *stateAdapter) GetBlock(seqNum uint64) *gproto.Payload {
+	block := a.ledger.GetBlock(seqNum)
+	if block == nil {
+		return nil
+	}
+	payload, err := blockToPayload(block)
+	if err != nil {
+		logger.Errorf("Can't send block %d(%s)", seqNum, err)
+		return nil
+	}
+	return payload
+}
+
+func (a *stateAdapter) CommitBlock(payload *gproto.Payload) error {
+	block := &cb.Block{}
+	if err := proto.Unmarshal(payload.Data, block); err != nil {
+		return err
+	}
+	if block.Header == nil || block.Data == nil {
+		return fmt.Errorf("block without header or data")
+	}
+	if block.Header.Number != payload.SeqNum || hex.EncodeToString(block.Header.Hash()) != payload.Hash {
+		return fmt.Errorf("block %d doesn't match its payload", block.Header.Number)
+	}
+	return a.ledger.CommitBlock(block)
+}
+
+// blockToPayload returns the gossip payload of a block, of which the block
+// number is the sequence number
+func blockToPayload(block *cb.Block) (*gproto.Payload, error) {
+	if block.Header == nil {
+		return nil, fmt.Errorf("block without header")
+	}
+	data, err := proto.Marshal(block)
+	if err != nil {
+		return nil, err
+	}
+	return &gproto.Payload{
+		Data:   data,
+		Hash:   hex.EncodeToString(block.Header.Hash()),
+		SeqNum: block.Header.Number,
+	}, nil
+}
@@ -17,34 +17,35 @@ limitations under the License.
 package state
 
 import (
-	"bytes"
-	"encoding/binary"
+	"encoding/json"
 )
 
 // Metadata information to store the information about current
-// height of the ledger (last accepted block sequence number).
+// height of the ledger (last accepted block sequence number),
+// along with the chain of the ledger and the chaincodes installed
+// on the node
 type NodeMetastate struct {
 
 	// Actual ledger height
-	LedgerHeight uint64
+	LedgerHeight uint64 `json:"ledgerHeight"`
+
+	// Chain of the ledger
+	ChainID      string `json:"chainID,omitempty"`
+
+	// Chaincodes installed on the node, as name:version
+	Chaincodes   []string `json:"chaincodes,omitempty"`
 }
 
-// Create new meta data with given ledger height148.69
+// Create new meta data with given ledger height
 func NewNodeMetastate(height uint64) *NodeMetastate {
-	return &NodeMetastate{height}
+	return &NodeMetastate{LedgerHeight: height}
 }
 
 // Decodes meta state into byte array for serialization
 func (n *NodeMetastate) Bytes() ([]byte, error) {
-	buffer := new(bytes.Buffer)
-	// Explicitly specify byte order for write into the buffer
-	// to provide cross platform support, note the it consistent
-	// with FromBytes function
-	err := binary.Write(buffer, binary.BigEndian, *n)
-	if err != nil {
-		return nil, err
-	}
-	return buffer.Bytes(), nil
+	// JSON keeps the encoding independent of the platform, note
+	// it is consistent with FromBytes function
+	return json.Marshal(n)
 }
 
 // Get ledger height from the state
@@ -60,11 +61,7 @@ func (n *NodeMetastate) Update(height uint64) {
 // Encode from byte array into meta data structure
 func FromBytes(buf []byte) (*NodeMetastate, error) {
 	state  := NodeMetastate{}
-	reader := bytes.NewReader(buf)
-	// As bytes are written in the big endian to keep supporting
-	// cross platforming and for consistency reasons read also
-	// done using same order
-	err := binary.Read(reader, binary.BigEndian, &state)
+	err := json.Unmarshal(buf, &state)
 	if err != nil {
 		return nil, err
 	}
@@ -71,4 +71,18 @@ func TestNodeMetastate_FromBytes(t *testing.T) {
 	updatedState, err := FromBytes(bytes)
 	assert.NilError(t, err)
 	assert.Equal(t, updatedState.Height(), uint64(17))
-}
\ No newline at end of file
+}
+
+// Check the chain and the chaincodes survive the serialization
+func TestNodeMetastate_Chaincodes(t *testing.T) {
+	metastate := NewNodeMetastate(3)
+	metastate.ChainID = "A"
+	metastate.Chaincodes = []string{"mycc:1.0"}
+	bytes, err := metastate.Bytes()
+	assert.NilError(t, err)
+
+	state, err := FromBytes(bytes)
+	assert.NilError(t, err)
+	assert.Equal(t, state.ChainID, "A")
+	assert.EqualStringSlice(t, state.Chaincodes, []string{"mycc:1.0"})
+}
@@ -0,0 +1,192 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric/gossip/gossip"
+	"github.com/hyperledger/fabric/gossip/proto"
+	"github.com/hyperledger/fabric/gossip/util"
+)
+
+/* Anti-entropy lets the peers that lag behind, or just joined the chain,
+   fetch the blocks they miss directly from the other peers of the chain.
+
+   Each peer publishes the height of its ledger in its gossip metadata, and
+   periodically requests from a peer with a higher ledger the blocks that
+   follow its own, one batch at a time. Each received block must extend the
+   ledger, otherwise the rest of the response is dropped.
+*/
+
+var antiEntropyInterval = time.Duration(10) * time.Second
+var stateRequestTimeout = time.Duration(5) * time.Second
+var stateBatchSize = uint64(10)
+
+// SetAntiEntropyInterval sets the interval between the comparisons of the
+// ledger height with those of the other peers
+func SetAntiEntropyInterval(t time.Duration) {
+	antiEntropyInterval = t
+}
+
+// SetStateRequestTimeout sets the time to wait for the blocks requested from a peer
+func SetStateRequestTimeout(t time.Duration) {
+	stateRequestTimeout = t
+}
+
+// SetStateBatchSize sets the maximum number of blocks requested at once
+func SetStateBatchSize(size uint64) {
+	stateBatchSize = size
+}
+
+// Ledger gives the state transfer access to the blocks of the ledger of a chain
+type Ledger interface {
+	// Height returns the number of blocks of the ledger
+	Height() uint64
+
+	// GetBlock returns the block with the given sequence number, or nil if the
+	// ledger doesn't have it
+	GetBlock(seqNum uint64) *proto.Payload
+
+	// CommitBlock verifies that the block extends the ledger and commits it
+	CommitBlock(payload *proto.Payload) error
+}
+
+// GossipStateProvider serves the blocks of the ledger of a chain to the other
+// peers, and fetches from them the blocks the ledger misses
+type GossipStateProvider interface {
+	// Stop stops fetching the missing blocks
+	Stop()
+}
+
+// NewGossipStateProvider starts the state transfer of the ledger of a chain the
+// gossip component joined. The height of the ledger is published in the gossip
+// metadata of the peer, so the peer can transfer the state of a single chain,
+// along with the chaincodes returned by chaincodes if not nil
+func NewGossipStateProvider(chainID []byte, g gossip.Gossip, ledger Ledger, chaincodes func() []string) GossipStateProvider {
+	s := &gossipStateProviderImpl{
+		chainID:    chainID,
+		gossip:     g,
+		ledger:     ledger,
+		chaincodes: chaincodes,
+		stopChan:   make(chan struct{}),
+		logger:     util.GetLogger("state", string(chainID)),
+	}
+	g.ServeState(chainID, ledger.GetBlock)
+	s.publishHeight()
+
+	s.stopWG.Add(1)
+	go s.antiEntropy()
+	return s
+}
+
+type gossipStateProviderImpl struct {
+	chainID    []byte
+	gossip     gossip.Gossip
+	ledger     Ledger
+	chaincodes func() []string
+
+	stopChan chan struct{}
+	stopWG   sync.WaitGroup
+	logger   *util.Logger
+}
+
+// Stop stops fetching the missing blocks
+func (s *gossipStateProviderImpl) Stop() {
+	close(s.stopChan)
+	s.stopWG.Wait()
+}
+
+func (s *gossipStateProviderImpl) antiEntropy() {
+	defer s.stopWG.Done()
+	for {
+		select {
+		case <-s.stopChan:
+			return
+		case <-time.After(antiEntropyInterval):
+			s.fetchMissingBlocks()
+			s.publishHeight()
+		}
+	}
+}
+
+func (s *gossipStateProviderImpl) publishHeight() {
+	state := NewNodeMetastate(s.ledger.Height())
+	state.ChainID = string(s.chainID)
+	if s.chaincodes != nil {
+		state.Chaincodes = s.chaincodes()
+	}
+	md, err := state.Bytes()
+	if err != nil {
+		s.logger.Error("Can't publish the ledger height:", err)
+		return
+	}
+	s.gossip.UpdateMetadata(md)
+}
+
+// fetchMissingBlocks requests the blocks that follow the ledger from a peer
+// with a higher ledger, until the ledger reaches the height of that peer
+func (s *gossipStateProviderImpl) fetchMissingBlocks() {
+	height := s.ledger.Height()
+	var candidates []string
+	var heights []uint64
+	for _, member := range s.gossip.GetPeers() {
+		state, err := FromBytes(member.Metadata)
+		if err != nil || state.ChainID != string(s.chainID) || state.Height() <= height {
+			continue
+		}
+		candidates = append(candidates, member.Endpoint)
+		heights = append(heights, state.Height())
+	}
+	if len(candidates) == 0 {
+		return
+	}
+
+	i := rand.Intn(len(candidates))
+	endpoint, peerHeight := candidates[i], heights[i]
+	s.logger.Info("Ledger at height", height, "fetching the blocks up to", peerHeight, "from", endpoint)
+	for height < peerHeight {
+		end := height + stateBatchSize - 1
+		if end >= peerHeight {
+			end = peerHeight - 1
+		}
+		payloads := s.gossip.RequestState(s.chainID, height, end, stateRequestTimeout, endpoint)
+		if len(payloads) == 0 {
+			s.logger.Warning("Peer", endpoint, "sent none of the blocks from", height, "to", end)
+			return
+		}
+		for _, payload := range payloads {
+			if payload.SeqNum != height {
+				s.logger.Warning("Peer", endpoint, "sent block", payload.SeqNum, "instead of block", height)
+				return
+			}
+			if err := s.ledger.CommitBlock(payload); err != nil {
+				s.logger.Warning("Dropping block", height, "sent by", endpoint, ":", err)
+				return
+			}
+			height++
+		}
+
+		select {
+		case <-s.stopChan:
+			return
+		default:
+		}
+	}
+}
@@ -0,0 +1,212 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/gossip/discovery"
+	"github.com/hyperledger/fabric/gossip/gossip"
+	"github.com/hyperledger/fabric/gossip/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	SetAntiEntropyInterval(time.Duration(100) * time.Millisecond)
+	SetStateRequestTimeout(time.Duration(100) * time.Millisecond)
+	SetStateBatchSize(3)
+}
+
+// network connects the gossip mocks of the peers
+type network struct {
+	sync.Mutex
+	peers map[string]*gossipMock
+}
+
+type gossipComponent gossip.Gossip
+
+// gossipMock implements the part of the gossip component the state transfer uses
+type gossipMock struct {
+	gossipComponent
+	endpoint string
+	net      *network
+	metadata []byte
+	getBlock func(seqNum uint64) *proto.Payload
+}
+
+func (g *gossipMock) GetPeers() []discovery.NetworkMember {
+	g.net.Lock()
+	defer g.net.Unlock()
+	var members []discovery.NetworkMember
+	for endpoint, peer := range g.net.peers {
+		if endpoint != g.endpoint {
+			members = append(members, discovery.NetworkMember{Endpoint: endpoint, Metadata: peer.metadata})
+		}
+	}
+	return members
+}
+
+func (g *gossipMock) UpdateMetadata(md []byte) {
+	g.net.Lock()
+	defer g.net.Unlock()
+	g.metadata = md
+}
+
+func (g *gossipMock) ServeState(chainID []byte, getBlock func(seqNum uint64) *proto.Payload) {
+	g.net.Lock()
+	defer g.net.Unlock()
+	g.getBlock = getBlock
+}
+
+func (g *gossipMock) RequestState(chainID []byte, startSeqNum, endSeqNum uint64, timeout time.Duration, endpoint string) []*proto.Payload {
+	g.net.Lock()
+	peer := g.net.peers[endpoint]
+	g.net.Unlock()
+	var payloads []*proto.Payload
+	for seqNum := startSeqNum; seqNum <= endSeqNum; seqNum++ {
+		payload := peer.getBlock(seqNum)
+		if payload == nil {
+			break
+		}
+		payloads = append(payloads, payload)
+	}
+	return payloads
+}
+
+// ledgerMock holds blocks whose data is the hash of the previous block
+type ledgerMock struct {
+	sync.Mutex
+	blocks []*proto.Payload
+}
+
+func newLedgerMock(height int) *ledgerMock {
+	l := &ledgerMock{}
+	for i := 0; i < height; i++ {
+		l.CommitBlock(l.nextBlock())
+	}
+	return l
+}
+
+func (l *ledgerMock) nextBlock() *proto.Payload {
+	prevHash := ""
+	if len(l.blocks) > 0 {
+		prevHash = l.blocks[len(l.blocks)-1].Hash
+	}
+	seqNum := uint64(len(l.blocks))
+	return &proto.Payload{SeqNum: seqNum, Data: []byte(prevHash), Hash: fmt.Sprintf("block%d", seqNum)}
+}
+
+func (l *ledgerMock) Height() uint64 {
+	l.Lock()
+	defer l.Unlock()
+	return uint64(len(l.blocks))
+}
+
+func (l *ledgerMock) GetBlock(seqNum uint64) *proto.Payload {
+	l.Lock()
+	defer l.Unlock()
+	if seqNum >= uint64(len(l.blocks)) {
+		return nil
+	}
+	return l.blocks[seqNum]
+}
+
+func (l *ledgerMock) CommitBlock(payload *proto.Payload) error {
+	l.Lock()
+	defer l.Unlock()
+	if payload.SeqNum != uint64(len(l.blocks)) {
+		return fmt.Errorf("expected block %d", len(l.blocks))
+	}
+	if len(l.blocks) > 0 && !bytes.Equal(payload.Data, []byte(l.blocks[len(l.blocks)-1].Hash)) {
+		return fmt.Errorf("block %d doesn't extend the hash chain", payload.SeqNum)
+	}
+	l.blocks = append(l.blocks, payload)
+	return nil
+}
+
+func (n *network) join(endpoint string, ledger Ledger) GossipStateProvider {
+	g := &gossipMock{endpoint: endpoint, net: n}
+	n.Lock()
+	n.peers[endpoint] = g
+	n.Unlock()
+	return NewGossipStateProvider([]byte("A"), g, ledger, nil)
+}
+
+func waitForHeight(t *testing.T, l *ledgerMock, height uint64) {
+	deadline := time.Now().Add(time.Duration(5) * time.Second)
+	for time.Now().Before(deadline) {
+		if l.Height() == height {
+			return
+		}
+		time.Sleep(time.Duration(50) * time.Millisecond)
+	}
+	assert.Equal(t, height, l.Height(), "Ledger didn't reach the expected height")
+}
+
+func TestStateTransfer(t *testing.T) {
+	n := &network{peers: make(map[string]*gossipMock)}
+	full := newLedgerMock(10)
+	lagging := newLedgerMock(4)
+	empty := newLedgerMock(0)
+	for endpoint, ledger := range map[string]Ledger{"p0": full, "p1": lagging, "p2": empty} {
+		defer n.join(endpoint, ledger).Stop()
+	}
+
+	waitForHeight(t, lagging, 10)
+	waitForHeight(t, empty, 10)
+	for i := uint64(0); i < 10; i++ {
+		assert.Equal(t, full.GetBlock(i), empty.GetBlock(i))
+	}
+
+	// the blocks committed later are fetched as well
+	full.CommitBlock(full.nextBlock())
+	waitForHeight(t, lagging, 11)
+	waitForHeight(t, empty, 11)
+}
+
+func TestStateTransferPublishesMetadata(t *testing.T) {
+	n := &network{peers: make(map[string]*gossipMock)}
+	g := &gossipMock{endpoint: "p0", net: n}
+	n.peers["p0"] = g
+	other := n.join("p1", newLedgerMock(0))
+	defer other.Stop()
+	defer NewGossipStateProvider([]byte("A"), g, newLedgerMock(2), func() []string {
+		return []string{"mycc:1.0"}
+	}).Stop()
+
+	members := n.peers["p1"].GetPeers()
+	assert.Len(t, members, 1)
+	state, err := FromBytes(members[0].Metadata)
+	assert.NoError(t, err)
+	assert.Equal(t, &NodeMetastate{LedgerHeight: 2, ChainID: "A", Chaincodes: []string{"mycc:1.0"}}, state)
+}
+
+func TestStateTransferVerifiesHashChain(t *testing.T) {
+	n := &network{peers: make(map[string]*gossipMock)}
+	forged := newLedgerMock(6)
+	forged.blocks[3] = &proto.Payload{SeqNum: 3, Data: []byte("forged"), Hash: "block3"}
+	ledger := newLedgerMock(1)
+	defer n.join("p0", forged).Stop()
+	defer n.join("p1", ledger).Stop()
+
+	time.Sleep(time.Duration(500) * time.Millisecond)
+	assert.Equal(t, uint64(3), ledger.Height(), "Blocks that don't extend the hash chain should be dropped")
+}
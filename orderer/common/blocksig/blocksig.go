@@ -0,0 +1,148 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blocksig
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+
+	cb "github.com/hyperledger/fabric/protos/common"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// MetadataIndex is the index in the block metadata of the signature of the
+// orderer over the block header, the consensus proof being at index 0
+const MetadataIndex = 1
+
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+// Signer signs the blocks delivered by the orderer
+type Signer struct {
+	key *ecdsa.PrivateKey
+}
+
+// NewSigner returns a signer with the PEM encoded ECDSA private key of the
+// file. No file gives a nil signer, which leaves the blocks unsigned
+func NewSigner(keyFile string) (*Signer, error) {
+	if keyFile == "" {
+		return nil, nil
+	}
+	raw, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading signing key %s: %s", keyFile, err)
+	}
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, fmt.Errorf("signing key %s is not PEM encoded", keyFile)
+	}
+	key, err := x509.ParseECPrivateKey(block.Bytes)
+	if err != nil {
+		if k, err8 := x509.ParsePKCS8PrivateKey(block.Bytes); err8 == nil {
+			if ecKey, ok := k.(*ecdsa.PrivateKey); ok {
+				return &Signer{key: ecKey}, nil
+			}
+		}
+		return nil, fmt.Errorf("signing key %s is no ECDSA private key: %s", keyFile, err)
+	}
+	return &Signer{key: key}, nil
+}
+
+// Sign returns a copy of the block with the signature of its header in its
+// metadata. A nil signer returns the block itself
+func (s *Signer) Sign(block *cb.Block) (*cb.Block, error) {
+	if s == nil {
+		return block, nil
+	}
+	digest, err := headerDigest(block)
+	if err != nil {
+		return nil, err
+	}
+	r, ss, err := ecdsa.Sign(rand.Reader, s.key, digest)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := asn1.Marshal(ecdsaSignature{r, ss})
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := make([][]byte, MetadataIndex+1)
+	if block.Metadata != nil {
+		copy(metadata, block.Metadata.Metadata)
+	}
+	metadata[MetadataIndex] = sig
+	signed := *block
+	signed.Metadata = &cb.BlockMetadata{Metadata: metadata}
+	return &signed, nil
+}
+
+// Verify checks that the block holds its data and is signed by the owner of
+// one of the PEM encoded certificates
+func Verify(block *cb.Block, certs [][]byte) error {
+	digest, err := headerDigest(block)
+	if err != nil {
+		return err
+	}
+	if block.Data == nil || !bytes.Equal(block.Data.Hash(), block.Header.DataHash) {
+		return fmt.Errorf("block data does not match the data hash of its header")
+	}
+	if block.Metadata == nil || len(block.Metadata.Metadata) <= MetadataIndex || len(block.Metadata.Metadata[MetadataIndex]) == 0 {
+		return fmt.Errorf("block %d is not signed", block.Header.Number)
+	}
+	sig := &ecdsaSignature{}
+	if _, err := asn1.Unmarshal(block.Metadata.Metadata[MetadataIndex], sig); err != nil {
+		return fmt.Errorf("invalid signature of block %d: %s", block.Header.Number, err)
+	}
+
+	for _, raw := range certs {
+		pemBlock, _ := pem.Decode(raw)
+		if pemBlock == nil {
+			continue
+		}
+		cert, err := x509.ParseCertificate(pemBlock.Bytes)
+		if err != nil {
+			continue
+		}
+		if key, ok := cert.PublicKey.(*ecdsa.PublicKey); ok && ecdsa.Verify(key, digest, sig.R, sig.S) {
+			return nil
+		}
+	}
+	return fmt.Errorf("block %d is not signed by the orderer", block.Header.Number)
+}
+
+func headerDigest(block *cb.Block) ([]byte, error) {
+	if block.Header == nil {
+		return nil, fmt.Errorf("block without header")
+	}
+	b, err := proto.Marshal(block.Header)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(b)
+	return digest[:], nil
+}
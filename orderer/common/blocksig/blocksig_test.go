@@ -0,0 +1,128 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blocksig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cb "github.com/hyperledger/fabric/protos/common"
+)
+
+// newIdentity writes a new ECDSA key to the directory and returns the file
+// of the key and the PEM encoded self signed certificate of the key
+func newIdentity(t *testing.T, dir string, name string) (string, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Error generating key: %s", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Error creating certificate: %s", err)
+	}
+	raw, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Error marshaling key: %s", err)
+	}
+	keyFile := filepath.Join(dir, name+".key")
+	if err = ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: raw}), 0600); err != nil {
+		t.Fatalf("Error writing key: %s", err)
+	}
+	return keyFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func newBlock() *cb.Block {
+	data := &cb.BlockData{Data: [][]byte{[]byte("tx")}}
+	return &cb.Block{
+		Header:   &cb.BlockHeader{Number: 3, PreviousHash: []byte("previous"), DataHash: data.Hash()},
+		Data:     data,
+		Metadata: &cb.BlockMetadata{Metadata: [][]byte{[]byte("proof")}},
+	}
+}
+
+func TestSignAndVerify(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blocksigtest")
+	if err != nil {
+		t.Fatalf("Error creating temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	keyFile, cert := newIdentity(t, dir, "orderer")
+	_, otherCert := newIdentity(t, dir, "other")
+
+	signer, err := NewSigner(keyFile)
+	if err != nil {
+		t.Fatalf("NewSigner failed: %s", err)
+	}
+	block := newBlock()
+	signed, err := signer.Sign(block)
+	if err != nil {
+		t.Fatalf("Sign failed: %s", err)
+	}
+	if len(block.Metadata.Metadata) != 1 {
+		t.Fatalf("Sign should not modify the block")
+	}
+	if string(signed.Metadata.Metadata[0]) != "proof" {
+		t.Fatalf("Sign should keep the consensus proof")
+	}
+
+	if err = Verify(signed, [][]byte{otherCert, cert}); err != nil {
+		t.Fatalf("Verify failed: %s", err)
+	}
+	if err = Verify(signed, [][]byte{otherCert}); err == nil {
+		t.Fatalf("Verify should have failed with the certificate of another identity")
+	}
+	if err = Verify(block, [][]byte{cert}); err == nil {
+		t.Fatalf("Verify should have failed with an unsigned block")
+	}
+
+	signed.Header.Number++
+	if err = Verify(signed, [][]byte{cert}); err == nil {
+		t.Fatalf("Verify should have failed with a modified header")
+	}
+	signed.Header.Number--
+	signed.Data.Data = append(signed.Data.Data, []byte("injected"))
+	if err = Verify(signed, [][]byte{cert}); err == nil {
+		t.Fatalf("Verify should have failed with modified data")
+	}
+}
+
+func TestNilSigner(t *testing.T) {
+	signer, err := NewSigner("")
+	if err != nil || signer != nil {
+		t.Fatalf("Expected no signer without key file, got %v (%v)", signer, err)
+	}
+	block := newBlock()
+	if signed, _ := signer.Sign(block); signed != block {
+		t.Fatalf("Expected a nil signer to leave the block unsigned")
+	}
+}
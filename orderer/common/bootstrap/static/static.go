@@ -29,16 +29,31 @@ import (
 )
 
 type bootstrapper struct {
-	chainID []byte
+	chainID       []byte
+	chainCreators [][]byte
 }
 
 // New returns a new static bootstrap helper
 func New() bootstrap.Helper {
+	return NewWithChainCreators(nil)
+}
+
+// NewWithChainCreators returns a new static bootstrap helper for a system chain whose chain creation policy requires the
+// signature of one of the creators. No chain can be created when there is no creator
+func NewWithChainCreators(creators [][]byte) bootstrap.Helper {
 	b := make([]byte, 16)
 	rand.Read(b)
 
 	return &bootstrapper{
-		chainID: b,
+		chainID:       b,
+		chainCreators: creators,
+	}
+}
+
+// NewForChain returns a new static bootstrap helper for the chain with the given ID
+func NewForChain(chainID []byte) bootstrap.Helper {
+	return &bootstrapper{
+		chainID: chainID,
 	}
 }
 
@@ -82,12 +97,24 @@ func (b *bootstrapper) GenesisBlock() (*cb.Block, error) {
 	// Lock down the default modification policy to prevent any further policy modifications
 	lockdownDefaultModificationPolicy := b.makeSignedConfigurationItem(configtx.DefaultModificationPolicyID, ab.ConfigurationItem_Policy, sigPolicyToPolicy(cauthdsl.RejectAllPolicy), configtx.DefaultModificationPolicyID)
 
+	items := []*ab.SignedConfigurationItem{
+		lockdownDefaultModificationPolicy,
+	}
+
+	// Permit the creators, if any, to create chains
+	if len(b.chainCreators) > 0 {
+		signedBy := make([]*ab.SignaturePolicy, len(b.chainCreators))
+		for i := range b.chainCreators {
+			signedBy[i] = cauthdsl.SignedBy(int32(i))
+		}
+		creationPolicy := cauthdsl.Envelope(cauthdsl.NOutOf(1, signedBy), b.chainCreators)
+		items = append(items, b.makeSignedConfigurationItem(configtx.ChainCreationPolicyID, ab.ConfigurationItem_Policy, sigPolicyToPolicy(creationPolicy), configtx.DefaultModificationPolicyID))
+	}
+
 	initialConfigTX := errorlessMarshal(&ab.ConfigurationEnvelope{
 		Sequence: 0,
 		ChainID:  b.chainID,
-		Items: []*ab.SignedConfigurationItem{
-			lockdownDefaultModificationPolicy,
-		},
+		Items:    items,
 	})
 
 	data := &cb.BlockData{
@@ -54,6 +54,9 @@ type Manager interface {
 // DefaultModificationPolicyID is the ID of the policy used when no other policy can be resolved, for instance when attempting to create a new config item
 const DefaultModificationPolicyID = "DefaultModificationPolicy"
 
+// ChainCreationPolicyID is the ID of the policy of the system chain which the chain creation requests must satisfy
+const ChainCreationPolicyID = "ChainCreationPolicy"
+
 type acceptAllPolicy struct{}
 
 func (ap *acceptAllPolicy) Evaluate(headers [][]byte, payload []byte, identities [][]byte, signatures [][]byte) error {
@@ -152,42 +155,12 @@ func (cm *configurationManager) processConfig(configtx *ab.ConfigurationEnvelope
 			return nil, fmt.Errorf("Config item %v for type %v was not meant for a different chain %x", config.Key, config.Type, config.Header.ChainID)
 		}
 
-		// Get the modification policy for this config item if one was previously specified
-		// or the default if this is a new config item
-		var policy policies.Policy
-		oldItem, ok := cm.configuration[config.Type][config.Key]
-		if ok {
-			policy, _ = cm.pm.GetPolicy(oldItem.ModificationPolicy)
-		} else {
-			policy = defaultModificationPolicy
-		}
-
-		headers := make([][]byte, len(entry.Signatures))
-		signatures := make([][]byte, len(entry.Signatures))
-		identities := make([][]byte, len(entry.Signatures))
-
-		for i, configSig := range entry.Signatures {
-			headers[i] = configSig.Signature
-			signatures[i] = configSig.SignatureHeader
-			sigHeader := &cb.SignatureHeader{}
-			err := proto.Unmarshal(configSig.SignatureHeader, sigHeader)
-			if err != nil {
-				return nil, err
-			}
-			identities[i] = sigHeader.Creator
-		}
-
-		// Ensure the policy is satisfied
-		if err = policy.Evaluate(headers, entry.ConfigurationItem, identities, signatures); err != nil {
-			return nil, err
-		}
-
 		// Ensure the config sequence numbers are correct to prevent replay attacks
 		isModified := false
 
 		if val, ok := cm.configuration[config.Type][config.Key]; ok {
-			// Config was modified if the LastModified or the Data contents changed
-			isModified = (val.LastModified != config.LastModified) || !bytes.Equal(config.Value, val.Value)
+			// Config was modified if the LastModified, the Data contents or the ModificationPolicy changed
+			isModified = (val.LastModified != config.LastModified) || !bytes.Equal(config.Value, val.Value) || val.ModificationPolicy != config.ModificationPolicy
 		} else {
 			if config.LastModified != configtx.Sequence {
 				return nil, fmt.Errorf("Key %v for type %v was new, but had an older Sequence %d set", config.Key, config.Type, config.LastModified)
@@ -200,6 +173,37 @@ func (cm *configurationManager) processConfig(configtx *ab.ConfigurationEnvelope
 			if config.LastModified != configtx.Sequence {
 				return nil, fmt.Errorf("Key %v for type %v was modified, but its LastModified %d does not equal current configtx Sequence %d", config.Key, config.Type, config.LastModified, configtx.Sequence)
 			}
+
+			// Get the modification policy for this config item if one was previously specified
+			// or the default if this is a new config item, unmodified items are carried over without
+			// the policy being satisfied again
+			var policy policies.Policy
+			oldItem, ok := cm.configuration[config.Type][config.Key]
+			if ok {
+				policy, _ = cm.pm.GetPolicy(oldItem.ModificationPolicy)
+			} else {
+				policy = defaultModificationPolicy
+			}
+
+			headers := make([][]byte, len(entry.Signatures))
+			signatures := make([][]byte, len(entry.Signatures))
+			identities := make([][]byte, len(entry.Signatures))
+
+			for i, configSig := range entry.Signatures {
+				headers[i] = configSig.Signature
+				signatures[i] = configSig.SignatureHeader
+				sigHeader := &cb.SignatureHeader{}
+				err := proto.Unmarshal(configSig.SignatureHeader, sigHeader)
+				if err != nil {
+					return nil, err
+				}
+				identities[i] = sigHeader.Creator
+			}
+
+			// Ensure the policy is satisfied
+			if err = policy.Evaluate(headers, entry.ConfigurationItem, identities, signatures); err != nil {
+				return nil, err
+			}
 		}
 
 		// Ensure the type handler agrees the config is well formed
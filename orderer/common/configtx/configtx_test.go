@@ -425,6 +425,88 @@ func TestConfigChangeViolatesPolicy(t *testing.T) {
 	}
 }
 
+// policyMapManager returns the policy of each ID, the default policy being unset
+type policyMapManager map[string]*mockPolicy
+
+func (pmm policyMapManager) GetPolicy(id string) (policies.Policy, bool) {
+	policy, ok := pmm[id]
+	return policy, ok
+}
+
+// TestUnmodifiedConfigSkipsPolicy checks that the policy of a config item is only evaluated when the item is modified
+func TestUnmodifiedConfigSkipsPolicy(t *testing.T) {
+	pm := policyMapManager{"reject": &mockPolicy{fmt.Errorf("err")}, "accept": &mockPolicy{}}
+	cm, err := NewConfigurationManager(&ab.ConfigurationEnvelope{
+		Sequence: 0,
+		ChainID:  defaultChain,
+		Items: []*ab.SignedConfigurationItem{
+			makeSignedConfigurationItem("foo", "reject", 0, []byte("foo")),
+			makeSignedConfigurationItem("bar", "accept", 0, []byte("bar")),
+		},
+	}, pm, defaultHandlers())
+
+	if err != nil {
+		t.Fatalf("Error constructing configuration manager: %s", err)
+	}
+
+	err = cm.Validate(&ab.ConfigurationEnvelope{
+		Sequence: 1,
+		ChainID:  defaultChain,
+		Items: []*ab.SignedConfigurationItem{
+			makeSignedConfigurationItem("foo", "reject", 1, []byte("foo2")),
+			makeSignedConfigurationItem("bar", "accept", 0, []byte("bar")),
+		},
+	})
+	if err == nil {
+		t.Errorf("Should have errored validating config because policy rejected modification")
+	}
+
+	err = cm.Apply(&ab.ConfigurationEnvelope{
+		Sequence: 1,
+		ChainID:  defaultChain,
+		Items: []*ab.SignedConfigurationItem{
+			makeSignedConfigurationItem("foo", "reject", 0, []byte("foo")),
+			makeSignedConfigurationItem("bar", "accept", 1, []byte("bar2")),
+		},
+	})
+	if err != nil {
+		t.Errorf("Should not have errored applying config which only modifies an item its policy accepts: %s", err)
+	}
+}
+
+// TestModificationPolicyChangeRequiresPolicy checks that replacing only the modification policy of a config item
+// is a modification, evaluated against the policy being replaced
+func TestModificationPolicyChangeRequiresPolicy(t *testing.T) {
+	pm := policyMapManager{"reject": &mockPolicy{fmt.Errorf("err")}, "accept": &mockPolicy{}}
+	cm, err := NewConfigurationManager(&ab.ConfigurationEnvelope{
+		Sequence: 0,
+		ChainID:  defaultChain,
+		Items:    []*ab.SignedConfigurationItem{makeSignedConfigurationItem("foo", "reject", 0, []byte("foo"))},
+	}, pm, defaultHandlers())
+
+	if err != nil {
+		t.Fatalf("Error constructing configuration manager: %s", err)
+	}
+
+	err = cm.Validate(&ab.ConfigurationEnvelope{
+		Sequence: 1,
+		ChainID:  defaultChain,
+		Items:    []*ab.SignedConfigurationItem{makeSignedConfigurationItem("foo", "accept", 0, []byte("foo"))},
+	})
+	if err == nil {
+		t.Errorf("Should have errored validating config because the modification policy of foo was silently replaced")
+	}
+
+	err = cm.Validate(&ab.ConfigurationEnvelope{
+		Sequence: 1,
+		ChainID:  defaultChain,
+		Items:    []*ab.SignedConfigurationItem{makeSignedConfigurationItem("foo", "accept", 1, []byte("foo"))},
+	})
+	if err == nil {
+		t.Errorf("Should have errored validating config because the policy of foo rejected replacing it")
+	}
+}
+
 type failHandler struct{}
 
 func (fh failHandler) BeginConfig()    {}
@@ -48,7 +48,10 @@ type General struct {
 	ListenAddress string
 	ListenPort    uint16
 	GenesisMethod string
+	SignKey       string
+	ChainCreators []string
 	Profile       Profile
+	Tracing       Tracing
 }
 
 // Profile contains configuration for Go pprof profiling
@@ -57,6 +60,13 @@ type Profile struct {
 	Address string
 }
 
+// Tracing contains configuration for the tracing of the requests
+type Tracing struct {
+	Enabled      bool
+	OTLPEndpoint string
+	File         string
+}
+
 // RAMLedger contains config for the RAM ledger
 type RAMLedger struct {
 	HistorySize uint
@@ -110,6 +120,11 @@ var defaults = TopLevel{
 			Enabled: false,
 			Address: "0.0.0.0:6060",
 		},
+		Tracing: Tracing{
+			Enabled:      false,
+			OTLPEndpoint: "",
+			File:         "",
+		},
 	},
 	RAMLedger: RAMLedger{
 		HistorySize: 10000,
@@ -21,6 +21,7 @@ import (
 	"fmt"
 
 	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/orderer/common/blocksig"
 	"github.com/hyperledger/fabric/orderer/config"
 	cb "github.com/hyperledger/fabric/protos/common"
 	ab "github.com/hyperledger/fabric/protos/orderer"
@@ -32,6 +33,7 @@ type clientDelivererImpl struct {
 
 	consumer Consumer
 	config   *config.TopLevel
+	signer   *blocksig.Signer
 	deadChan chan struct{}
 
 	errChan   chan error
@@ -41,7 +43,7 @@ type clientDelivererImpl struct {
 	window    int64
 }
 
-func newClientDeliverer(conf *config.TopLevel, deadChan chan struct{}) Deliverer {
+func newClientDeliverer(conf *config.TopLevel, deadChan chan struct{}, signer *blocksig.Signer) Deliverer {
 	brokerFunc := func(conf *config.TopLevel) Broker {
 		return newBroker(conf)
 	}
@@ -54,6 +56,7 @@ func newClientDeliverer(conf *config.TopLevel, deadChan chan struct{}) Deliverer
 		consumerFunc: consumerFunc,
 
 		config:   conf,
+		signer:   signer,
 		deadChan: deadChan,
 		errChan:  make(chan error),
 		updChan:  make(chan *ab.DeliverUpdate), // TODO Size this properly
@@ -130,8 +133,12 @@ func (cd *clientDelivererImpl) sendBlocks(stream ab.AtomicBroadcast_DeliverServe
 				if err != nil {
 					logger.Info("Failed to unmarshal retrieved block from ordering service:", err)
 				}
+				signed, err := cd.signer.Sign(block)
+				if err != nil {
+					return fmt.Errorf("Failed to sign block: %s", err)
+				}
 				reply = new(ab.DeliverResponse)
-				reply.Type = &ab.DeliverResponse_Block{Block: block}
+				reply.Type = &ab.DeliverResponse_Block{Block: signed}
 				err = stream.Send(reply)
 				if err != nil {
 					return fmt.Errorf("Failed to send block to the client: %s", err)
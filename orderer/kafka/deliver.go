@@ -17,8 +17,10 @@ limitations under the License.
 package kafka
 
 import (
+	"fmt"
 	"sync"
 
+	"github.com/hyperledger/fabric/orderer/common/blocksig"
 	"github.com/hyperledger/fabric/orderer/config"
 	ab "github.com/hyperledger/fabric/protos/orderer"
 )
@@ -31,13 +33,19 @@ type Deliverer interface {
 
 type delivererImpl struct {
 	config   *config.TopLevel
+	signer   *blocksig.Signer
 	deadChan chan struct{}
 	wg       sync.WaitGroup
 }
 
 func newDeliverer(conf *config.TopLevel) Deliverer {
+	signer, err := blocksig.NewSigner(conf.General.SignKey)
+	if err != nil {
+		panic(fmt.Errorf("Failed to load the block signing key: %s", err))
+	}
 	return &delivererImpl{
 		config:   conf,
+		signer:   signer,
 		deadChan: make(chan struct{}),
 	}
 }
@@ -45,7 +53,7 @@ func newDeliverer(conf *config.TopLevel) Deliverer {
 // Deliver receives updates from connected clients and adjusts
 // the transmission of ordered messages to them accordingly
 func (d *delivererImpl) Deliver(stream ab.AtomicBroadcast_DeliverServer) error {
-	cd := newClientDeliverer(d.config, d.deadChan)
+	cd := newClientDeliverer(d.config, d.deadChan, d.signer)
 
 	d.wg.Add(1)
 	defer d.wg.Done()
@@ -17,6 +17,8 @@ limitations under the License.
 package main
 
 import (
+	"bytes"
+	"encoding/hex"
 	"flag"
 	"fmt"
 	"io/ioutil"
@@ -26,11 +28,14 @@ import (
 	_ "net/http/pprof"
 	"os"
 	"os/signal"
+	"path/filepath"
 
+	"github.com/hyperledger/fabric/core/tracing"
+	"github.com/hyperledger/fabric/orderer/common/blocksig"
 	"github.com/hyperledger/fabric/orderer/common/bootstrap"
 	"github.com/hyperledger/fabric/orderer/common/bootstrap/static"
 	"github.com/hyperledger/fabric/orderer/common/broadcastfilter"
-	// "github.com/hyperledger/fabric/orderer/common/broadcastfilter/configfilter"
+	"github.com/hyperledger/fabric/orderer/common/broadcastfilter/configfilter"
 	"github.com/hyperledger/fabric/orderer/common/configtx"
 	"github.com/hyperledger/fabric/orderer/common/policies"
 	"github.com/hyperledger/fabric/orderer/config"
@@ -39,6 +44,7 @@ import (
 	"github.com/hyperledger/fabric/orderer/rawledger/fileledger"
 	"github.com/hyperledger/fabric/orderer/rawledger/ramledger"
 	"github.com/hyperledger/fabric/orderer/solo"
+	cb "github.com/hyperledger/fabric/protos/common"
 	ab "github.com/hyperledger/fabric/protos/orderer"
 
 	"github.com/Shopify/sarama"
@@ -61,6 +67,15 @@ func main() {
 		}()
 	}
 
+	if err := tracing.Init(tracing.Config{
+		Enabled:      conf.General.Tracing.Enabled,
+		ServiceName:  "orderer",
+		OTLPEndpoint: conf.General.Tracing.OTLPEndpoint,
+		File:         conf.General.Tracing.File,
+	}); err != nil {
+		panic(err)
+	}
+
 	switch conf.General.OrdererType {
 	case "solo":
 		launchSolo(conf)
@@ -114,7 +129,15 @@ func retrieveConfiguration(rl rawledger.Reader) *ab.ConfigurationEnvelope {
 	}
 }
 
-func bootstrapConfigManager(lastConfigTx *ab.ConfigurationEnvelope) configtx.Manager {
+func bootstrapConfigManager(lastConfigTx *ab.ConfigurationEnvelope) (configtx.Manager, policies.Manager) {
+	configManager, policyManager, err := newConfigManager(lastConfigTx)
+	if err != nil {
+		panic(err)
+	}
+	return configManager, policyManager
+}
+
+func newConfigManager(lastConfigTx *ab.ConfigurationEnvelope) (configtx.Manager, policies.Manager, error) {
 	policyManager := policies.NewManagerImpl(xxxCryptoHelper{})
 	configHandlerMap := make(map[ab.ConfigurationItem_ConfigurationType]configtx.Handler)
 	for ctype := range ab.ConfigurationItem_ConfigurationType_name {
@@ -128,22 +151,75 @@ func bootstrapConfigManager(lastConfigTx *ab.ConfigurationEnvelope) configtx.Man
 	}
 
 	configManager, err := configtx.NewConfigurationManager(lastConfigTx, policyManager, configHandlerMap)
+	return configManager, policyManager, err
+}
+
+// readChainCreators returns the identities of the chain creators, given the files holding their PEM encoded certificates
+func readChainCreators(files []string) [][]byte {
+	var creators [][]byte
+	for _, file := range files {
+		cert, err := ioutil.ReadFile(file)
+		if err != nil {
+			panic(fmt.Errorf("Error reading the certificate of chain creator %s: %s", file, err))
+		}
+		creators = append(creators, bytes.TrimSpace(cert))
+	}
+	if len(creators) == 0 {
+		logger.Warning("No chain creator in General.ChainCreators, chains can't be created")
+	}
+	return creators
+}
+
+// restoreChains reopens the ledgers of the chains created earlier, which are kept in the chains directory of the file
+// ledger under their hex encoded chain ID
+func restoreChains(location string) map[string]*solo.Chain {
+	chains := make(map[string]*solo.Chain)
+	infos, err := ioutil.ReadDir(filepath.Join(location, "chains"))
+	if os.IsNotExist(err) {
+		return chains
+	}
 	if err != nil {
-		panic(err)
+		panic(fmt.Errorf("Error listing the created chains: %s", err))
+	}
+	for _, info := range infos {
+		chainID, err := hex.DecodeString(info.Name())
+		if !info.IsDir() || err != nil {
+			continue
+		}
+		rl := fileledger.New(filepath.Join(location, "chains", info.Name()), nil)
+		lastConfigTx := retrieveConfiguration(rl)
+		if lastConfigTx == nil {
+			panic(fmt.Errorf("No chain configuration found for chain %x", chainID))
+		}
+		configManager, _ := bootstrapConfigManager(lastConfigTx)
+		chains[string(chainID)] = &solo.Chain{
+			Ledger:        rl,
+			Filters:       createBroadcastRuleset(configManager),
+			ConfigManager: configManager,
+		}
+	}
+	return chains
+}
+
+// serverOptions returns the options of the gRPC server of the orderer, which
+// traces the requests as part of the trace of the client
+func serverOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.UnaryInterceptor(tracing.UnaryServerInterceptor),
+		grpc.StreamInterceptor(tracing.StreamServerInterceptor),
 	}
-	return configManager
 }
 
 func createBroadcastRuleset(configManager configtx.Manager) *broadcastfilter.RuleSet {
 	return broadcastfilter.NewRuleSet([]broadcastfilter.Rule{
 		broadcastfilter.EmptyRejectRule,
-		// configfilter.New(configManager),
+		configfilter.New(configManager),
 		broadcastfilter.AcceptRule,
 	})
 }
 
 func launchSolo(conf *config.TopLevel) {
-	grpcServer := grpc.NewServer()
+	grpcServer := grpc.NewServer(serverOptions()...)
 
 	lis, err := net.Listen("tcp", fmt.Sprintf("%s:%d", conf.General.ListenAddress, conf.General.ListenPort))
 	if err != nil {
@@ -156,7 +232,7 @@ func launchSolo(conf *config.TopLevel) {
 	// Select the bootstrapping mechanism
 	switch conf.General.GenesisMethod {
 	case "static":
-		bootstrapper = static.New()
+		bootstrapper = static.NewWithChainCreators(readChainCreators(conf.General.ChainCreators))
 	default:
 		panic(fmt.Errorf("Unknown genesis method %s", conf.General.GenesisMethod))
 	}
@@ -169,7 +245,9 @@ func launchSolo(conf *config.TopLevel) {
 
 	// Stand in until real config
 	ledgerType := os.Getenv("ORDERER_LEDGER_TYPE")
-	var rawledger rawledger.ReadWriter
+	var rl rawledger.ReadWriter
+	var newLedger func(chainID []byte, genesisBlock *cb.Block) rawledger.ReadWriter
+	var chains map[string]*solo.Chain
 	switch ledgerType {
 	case "file":
 		location := conf.FileLedger.Location
@@ -181,31 +259,59 @@ func launchSolo(conf *config.TopLevel) {
 			}
 		}
 
-		rawledger = fileledger.New(location, genesisBlock)
+		rl = fileledger.New(location, genesisBlock)
+		chains = restoreChains(location)
+		newLedger = func(chainID []byte, genesisBlock *cb.Block) rawledger.ReadWriter {
+			return fileledger.New(filepath.Join(location, "chains", fmt.Sprintf("%x", chainID)), genesisBlock)
+		}
 	case "ram":
 		fallthrough
 	default:
-		rawledger = ramledger.New(int(conf.RAMLedger.HistorySize), genesisBlock)
+		// The chains created on the RAM ledger are lost when the orderer stops
+		rl = ramledger.New(int(conf.RAMLedger.HistorySize), genesisBlock)
+		newLedger = func(chainID []byte, genesisBlock *cb.Block) rawledger.ReadWriter {
+			return ramledger.New(int(conf.RAMLedger.HistorySize), genesisBlock)
+		}
 	}
 
-	lastConfigTx := retrieveConfiguration(rawledger)
+	lastConfigTx := retrieveConfiguration(rl)
 	if lastConfigTx == nil {
 		panic("No chain configuration found")
 	}
 
-	configManager := bootstrapConfigManager(lastConfigTx)
+	configManager, policyManager := bootstrapConfigManager(lastConfigTx)
+
+	signer, err := blocksig.NewSigner(conf.General.SignKey)
+	if err != nil {
+		panic(fmt.Errorf("Error loading the block signing key: %s", err))
+	}
 
-	// XXX actually use the config manager in the future
-	_ = configManager
+	// Chains created by chain creation requests are bootstrapped from their genesis block like the system chain
+	newChain := func(chainID []byte, genesisBlock *cb.Block) (rawledger.ReadWriter, *broadcastfilter.RuleSet, configtx.Manager, error) {
+		genesisConfigTx := &ab.ConfigurationEnvelope{}
+		if err := proto.Unmarshal(genesisBlock.Data.Data[0], genesisConfigTx); err != nil {
+			return nil, nil, nil, err
+		}
+		configManager, _, err := newConfigManager(genesisConfigTx)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return newLedger(chainID, genesisBlock), createBroadcastRuleset(configManager), configManager, nil
+	}
 
 	solo.New(int(conf.General.QueueSize),
 		int(conf.General.BatchSize),
 		int(conf.General.MaxWindowSize),
 		conf.General.BatchTimeout,
-		rawledger,
+		rl,
 		grpcServer,
 		createBroadcastRuleset(configManager),
 		configManager,
+		policyManager,
+		lastConfigTx.ChainID,
+		chains,
+		newChain,
+		signer,
 	)
 	grpcServer.Serve(lis)
 }
@@ -235,7 +341,7 @@ func launchKafka(conf *config.TopLevel) {
 	if err != nil {
 		panic(err)
 	}
-	rpcSrv := grpc.NewServer() // TODO Add TLS support
+	rpcSrv := grpc.NewServer(serverOptions()...) // TODO Add TLS support
 	ab.RegisterAtomicBroadcastServer(rpcSrv, ordererSrv)
 	go rpcSrv.Serve(lis)
 
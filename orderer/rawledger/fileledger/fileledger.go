@@ -55,7 +55,7 @@ type fileLedger struct {
 	marshaler      *jsonpb.Marshaler
 }
 
-// New creates a new instance of the file ledger
+// New creates a new instance of the file ledger, a nil genesis block opening an existing ledger
 func New(directory string, genesisBlock *cb.Block) rawledger.ReadWriter {
 	logger.Debugf("Initializing fileLedger at '%s'", directory)
 	if err := os.MkdirAll(directory, 0700); err != nil {
@@ -67,8 +67,10 @@ func New(directory string, genesisBlock *cb.Block) rawledger.ReadWriter {
 		signal:         make(chan struct{}),
 		marshaler:      &jsonpb.Marshaler{Indent: "  "},
 	}
-	if _, err := os.Stat(fl.blockFilename(genesisBlock.Header.Number)); os.IsNotExist(err) {
-		fl.writeBlock(genesisBlock)
+	if genesisBlock != nil {
+		if _, err := os.Stat(fl.blockFilename(genesisBlock.Header.Number)); os.IsNotExist(err) {
+			fl.writeBlock(genesisBlock)
+		}
 	}
 	fl.initializeBlockHeight()
 	logger.Debugf("Initialized to block height %d with hash %x", fl.height-1, fl.lastHash)
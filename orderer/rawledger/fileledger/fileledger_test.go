@@ -90,6 +90,23 @@ func TestReinitialization(t *testing.T) {
 	}
 }
 
+func TestReopen(t *testing.T) {
+	tev, ofl := initialize(t)
+	defer tev.tearDown()
+	ofl.Append([]*cb.Envelope{&cb.Envelope{Payload: []byte("My Data")}}, nil)
+	fl := New(tev.location, nil).(*fileLedger)
+	if fl.height != 2 {
+		t.Fatalf("Block height should be 2")
+	}
+	block, found := fl.readBlock(1)
+	if block == nil || !found {
+		t.Fatalf("Error retrieving block 1")
+	}
+	if !bytes.Equal(block.Header.Hash(), fl.lastHash) {
+		t.Fatalf("Block hashes did no match")
+	}
+}
+
 func TestAddition(t *testing.T) {
 	tev, fl := initialize(t)
 	defer tev.tearDown()
@@ -170,29 +170,33 @@ func (b *broadcaster) queueEnvelopes(srv ab.AtomicBroadcast_BroadcastServer) err
 			return err
 		}
 
-		action, _ := b.bs.filter.Apply(msg)
-
-		switch action {
-		case broadcastfilter.Reconfigure:
-			fallthrough
-		case broadcastfilter.Accept:
-			select {
-			case b.queue <- msg:
-				err = srv.Send(&ab.BroadcastResponse{Status: ab.Status_SUCCESS})
-			default:
-				err = srv.Send(&ab.BroadcastResponse{Status: ab.Status_SERVICE_UNAVAILABLE})
-			}
-		case broadcastfilter.Forward:
-			fallthrough
-		case broadcastfilter.Reject:
-			err = srv.Send(&ab.BroadcastResponse{Status: ab.Status_BAD_REQUEST})
-		default:
-			logger.Fatalf("Unknown filter action :%v", action)
+		if err = srv.Send(b.queueEnvelope(msg)); err != nil {
+			return err
 		}
+	}
+}
 
-		if err != nil {
-			return err
+// queueEnvelope filters a message and queues it for ordering if it is accepted
+func (b *broadcaster) queueEnvelope(msg *cb.Envelope) *ab.BroadcastResponse {
+	action, _ := b.bs.filter.Apply(msg)
+
+	switch action {
+	case broadcastfilter.Reconfigure:
+		fallthrough
+	case broadcastfilter.Accept:
+		select {
+		case b.queue <- msg:
+			return &ab.BroadcastResponse{Status: ab.Status_SUCCESS}
+		default:
+			return &ab.BroadcastResponse{Status: ab.Status_SERVICE_UNAVAILABLE}
 		}
+	case broadcastfilter.Forward:
+		fallthrough
+	case broadcastfilter.Reject:
+		return &ab.BroadcastResponse{Status: ab.Status_BAD_REQUEST}
+	default:
+		logger.Fatalf("Unknown filter action :%v", action)
+		return nil
 	}
 }
 
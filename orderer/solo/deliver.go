@@ -17,20 +17,30 @@ limitations under the License.
 package solo
 
 import (
+	"github.com/hyperledger/fabric/orderer/common/blocksig"
 	"github.com/hyperledger/fabric/orderer/rawledger"
 	cb "github.com/hyperledger/fabric/protos/common"
 	ab "github.com/hyperledger/fabric/protos/orderer"
 )
 
 type DeliverServer struct {
-	rl        rawledger.Reader
+	ledger    func(chainID []byte) rawledger.Reader
 	maxWindow int
+	signer    *blocksig.Signer
 }
 
 func NewDeliverServer(rl rawledger.Reader, maxWindow int) *DeliverServer {
+	return newMultiChainDeliverServer(func(chainID []byte) rawledger.Reader { return rl }, maxWindow, nil)
+}
+
+// newMultiChainDeliverServer creates a DeliverServer which serves the blocks of
+// the ledger returned for the chain named in each seek, signed by signer. Seeks on chains
+// for which no ledger is returned are answered with NOT_FOUND
+func newMultiChainDeliverServer(ledger func(chainID []byte) rawledger.Reader, maxWindow int, signer *blocksig.Signer) *DeliverServer {
 	return &DeliverServer{
-		rl:        rl,
+		ledger:    ledger,
 		maxWindow: maxWindow,
+		signer:    signer,
 	}
 }
 
@@ -154,7 +164,14 @@ func (d *deliverer) sendErrorReply(status ab.Status) bool {
 }
 
 func (d *deliverer) sendBlockReply(block *cb.Block) bool {
-	err := d.srv.Send(&ab.DeliverResponse{
+	block, err := d.ds.signer.Sign(block)
+	if err != nil {
+		logger.Errorf("Error signing block: %s", err)
+		close(d.exitChan)
+		return false
+	}
+
+	err = d.srv.Send(&ab.DeliverResponse{
 		Type: &ab.DeliverResponse_Block{Block: block},
 	})
 
@@ -180,7 +197,13 @@ func (d *deliverer) processUpdate(update *ab.SeekInfo) bool {
 
 	d.windowSize = update.WindowSize
 
-	d.cursor, d.nextBlockNumber = d.ds.rl.Iterator(update.Start, update.SpecifiedNumber)
+	rl := d.ds.ledger(update.ChainID)
+	if rl == nil {
+		logger.Warningf("Rejecting seek on unknown chain %x", update.ChainID)
+		return d.sendErrorReply(ab.Status_NOT_FOUND)
+	}
+
+	d.cursor, d.nextBlockNumber = rl.Iterator(update.Start, update.SpecifiedNumber)
 	d.lastAck = d.nextBlockNumber - 1
 
 	return true
@@ -17,13 +17,19 @@ limitations under the License.
 package solo
 
 import (
+	"bytes"
+	"sync"
 	"time"
 
+	"github.com/hyperledger/fabric/orderer/common/blocksig"
 	"github.com/hyperledger/fabric/orderer/common/broadcastfilter"
 	"github.com/hyperledger/fabric/orderer/common/configtx"
+	"github.com/hyperledger/fabric/orderer/common/policies"
 	"github.com/hyperledger/fabric/orderer/rawledger"
+	cb "github.com/hyperledger/fabric/protos/common"
 	ab "github.com/hyperledger/fabric/protos/orderer"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/op/go-logging"
 	"google.golang.org/grpc"
 )
@@ -34,25 +40,209 @@ func init() {
 	logging.SetLevel(logging.DEBUG, "")
 }
 
-type server struct {
+// ChainFactory creates the ledger, broadcast filters and configuration manager of a new chain from its genesis block
+type ChainFactory func(chainID []byte, genesisBlock *cb.Block) (rawledger.ReadWriter, *broadcastfilter.RuleSet, configtx.Manager, error)
+
+// Chain is a chain created by an earlier chain creation request, which is restored when the orderer starts
+type Chain struct {
+	Ledger        rawledger.ReadWriter
+	Filters       *broadcastfilter.RuleSet
+	ConfigManager configtx.Manager
+}
+
+type chain struct {
+	rl rawledger.ReadWriter
 	bs *broadcastServer
-	ds *DeliverServer
+}
+
+type server struct {
+	queueSize     int
+	batchSize     int
+	batchTimeout  time.Duration
+	newChain      ChainFactory
+	policyManager policies.Manager
+	systemChain   *chain
+	chains        map[string]*chain
+	lock          sync.RWMutex
+	ds            *DeliverServer
 }
 
 // New creates a ab.AtomicBroadcastServer based on the solo orderer implementation
-func New(queueSize, batchSize, maxWindowSize int, batchTimeout time.Duration, rl rawledger.ReadWriter, grpcServer *grpc.Server, filters *broadcastfilter.RuleSet, configManager configtx.Manager) ab.AtomicBroadcastServer {
+// The system chain is ordered on the given ledger, the chains created earlier are restored from chains and additional
+// chains are created with newChain when the request satisfies the chain creation policy of the system chain. The
+// delivered blocks are signed by signer
+func New(queueSize, batchSize, maxWindowSize int, batchTimeout time.Duration, rl rawledger.ReadWriter, grpcServer *grpc.Server, filters *broadcastfilter.RuleSet, configManager configtx.Manager, policyManager policies.Manager, systemChainID []byte, chains map[string]*Chain, newChain ChainFactory, signer *blocksig.Signer) ab.AtomicBroadcastServer {
 	logger.Infof("Starting solo with queueSize=%d, batchSize=%d batchTimeout=%v and ledger=%T", queueSize, batchSize, batchTimeout, rl)
+	s := newServer(queueSize, batchSize, maxWindowSize, batchTimeout, rl, filters, configManager, policyManager, systemChainID, chains, newChain, signer)
+	ab.RegisterAtomicBroadcastServer(grpcServer, s)
+	return s
+}
+
+func newServer(queueSize, batchSize, maxWindowSize int, batchTimeout time.Duration, rl rawledger.ReadWriter, filters *broadcastfilter.RuleSet, configManager configtx.Manager, policyManager policies.Manager, systemChainID []byte, chains map[string]*Chain, newChain ChainFactory, signer *blocksig.Signer) *server {
 	s := &server{
-		bs: newBroadcastServer(queueSize, batchSize, batchTimeout, rl, filters, configManager),
-		ds: NewDeliverServer(rl, maxWindowSize),
+		queueSize:     queueSize,
+		batchSize:     batchSize,
+		batchTimeout:  batchTimeout,
+		newChain:      newChain,
+		policyManager: policyManager,
+		chains:        make(map[string]*chain),
 	}
-	ab.RegisterAtomicBroadcastServer(grpcServer, s)
+	for chainID, c := range chains {
+		s.chains[chainID] = s.startChain(c.Ledger, c.Filters, c.ConfigManager)
+		logger.Infof("Restored chain %x", chainID)
+	}
+	s.systemChain = s.startChain(rl, filters, configManager)
+	s.chains[string(systemChainID)] = s.systemChain
+	s.ds = newMultiChainDeliverServer(func(chainID []byte) rawledger.Reader {
+		c := s.chain(chainID)
+		if c == nil {
+			return nil
+		}
+		return c.rl
+	}, maxWindowSize, signer)
 	return s
 }
 
+func (s *server) startChain(rl rawledger.ReadWriter, filters *broadcastfilter.RuleSet, configManager configtx.Manager) *chain {
+	return &chain{
+		rl: rl,
+		bs: newBroadcastServer(s.queueSize, s.batchSize, s.batchTimeout, rl, filters, configManager),
+	}
+}
+
+// chain returns the chain with the given ID or nil if there is no such chain, messages which name no chain are ordered
+// on the system chain as the single chain clients do not name one
+func (s *server) chain(chainID []byte) *chain {
+	if len(chainID) == 0 {
+		return s.systemChain
+	}
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.chains[string(chainID)]
+}
+
+// createChain creates a new chain whose genesis block holds the configuration envelope of a chain creation request,
+// provided the request is signed as required by the chain creation policy of the system chain
+func (s *server) createChain(env *cb.Envelope, payload *cb.Payload) ab.Status {
+	chainID := payload.Header.ChainHeader.ChainID
+	if status := s.authorizeChainCreation(env, payload); status != ab.Status_SUCCESS {
+		return status
+	}
+
+	config := &ab.ConfigurationEnvelope{}
+	if err := proto.Unmarshal(payload.Data, config); err != nil {
+		logger.Warningf("Rejecting chain creation request with an invalid configuration envelope: %s", err)
+		return ab.Status_BAD_REQUEST
+	}
+	if len(chainID) == 0 || !bytes.Equal(config.ChainID, chainID) || config.Sequence != 0 {
+		logger.Warningf("Rejecting chain creation request for chain %x with the configuration of chain %x at sequence %d", chainID, config.ChainID, config.Sequence)
+		return ab.Status_BAD_REQUEST
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, ok := s.chains[string(chainID)]; ok {
+		logger.Warningf("Rejecting chain creation request for existing chain %x", chainID)
+		return ab.Status_BAD_REQUEST
+	}
+
+	data := &cb.BlockData{
+		Data: [][]byte{payload.Data},
+	}
+	genesisBlock := &cb.Block{
+		Header: &cb.BlockHeader{
+			Number:       0,
+			PreviousHash: []byte("GENESIS"),
+			DataHash:     data.Hash(),
+		},
+		Data: data,
+	}
+
+	rl, filters, configManager, err := s.newChain(chainID, genesisBlock)
+	if err != nil {
+		logger.Warningf("Could not create chain %x: %s", chainID, err)
+		return ab.Status_BAD_REQUEST
+	}
+	s.chains[string(chainID)] = s.startChain(rl, filters, configManager)
+	logger.Infof("Created chain %x", chainID)
+	return ab.Status_SUCCESS
+}
+
+// authorizeChainCreation evaluates the chain creation policy of the system chain against the signature of a chain
+// creation request, no chain can be created when the system chain has no such policy
+func (s *server) authorizeChainCreation(env *cb.Envelope, payload *cb.Payload) ab.Status {
+	chainID := payload.Header.ChainHeader.ChainID
+	policy, ok := s.policyManager.GetPolicy(configtx.ChainCreationPolicyID)
+	if !ok {
+		logger.Warningf("Rejecting chain creation request for chain %x as the system chain has no chain creation policy", chainID)
+		return ab.Status_FORBIDDEN
+	}
+
+	sigHeader := payload.Header.SignatureHeader
+	if sigHeader == nil {
+		logger.Warningf("Rejecting unsigned chain creation request for chain %x", chainID)
+		return ab.Status_FORBIDDEN
+	}
+	header, err := proto.Marshal(sigHeader)
+	if err != nil {
+		logger.Warningf("Rejecting chain creation request for chain %x with an invalid signature header: %s", chainID, err)
+		return ab.Status_BAD_REQUEST
+	}
+
+	if err := policy.Evaluate([][]byte{header}, env.Payload, [][]byte{sigHeader.Creator}, [][]byte{env.Signature}); err != nil {
+		logger.Warningf("Rejecting chain creation request for chain %x which does not satisfy the chain creation policy: %s", chainID, err)
+		return ab.Status_FORBIDDEN
+	}
+	return ab.Status_SUCCESS
+}
+
 // Broadcast receives a stream of messages from a client for ordering
 func (s *server) Broadcast(srv ab.AtomicBroadcast_BroadcastServer) error {
-	return s.bs.handleBroadcast(srv)
+	broadcasters := make(map[*chain]*broadcaster)
+	defer func() {
+		for _, b := range broadcasters {
+			close(b.queue)
+		}
+	}()
+
+	for {
+		msg, err := srv.Recv()
+		if err != nil {
+			return err
+		}
+
+		var chainID []byte
+		payload := &cb.Payload{}
+		if err = proto.Unmarshal(msg.Payload, payload); err == nil && payload.Header != nil && payload.Header.ChainHeader != nil {
+			if payload.Header.ChainHeader.Type == int32(cb.HeaderType_CHAIN_CREATION) {
+				if err = srv.Send(&ab.BroadcastResponse{Status: s.createChain(msg, payload)}); err != nil {
+					return err
+				}
+				continue
+			}
+			chainID = payload.Header.ChainHeader.ChainID
+		}
+
+		c := s.chain(chainID)
+		if c == nil {
+			logger.Warningf("Rejecting message for unknown chain %x", chainID)
+			if err = srv.Send(&ab.BroadcastResponse{Status: ab.Status_NOT_FOUND}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		b, ok := broadcasters[c]
+		if !ok {
+			b = newBroadcaster(c.bs)
+			go b.drainQueue()
+			broadcasters[c] = b
+		}
+
+		if err = srv.Send(b.queueEnvelope(msg)); err != nil {
+			return err
+		}
+	}
 }
 
 // Deliver sends a stream of blocks to a client after ordering
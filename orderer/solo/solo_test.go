@@ -0,0 +1,305 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package solo
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/orderer/common/broadcastfilter"
+	"github.com/hyperledger/fabric/orderer/common/configtx"
+	"github.com/hyperledger/fabric/orderer/common/policies"
+	"github.com/hyperledger/fabric/orderer/rawledger"
+	"github.com/hyperledger/fabric/orderer/rawledger/ramledger"
+	cb "github.com/hyperledger/fabric/protos/common"
+	ab "github.com/hyperledger/fabric/protos/orderer"
+
+	"github.com/golang/protobuf/proto"
+)
+
+var systemChainID = []byte("system")
+
+var chainCreator = []byte("creator")
+
+type mockPolicy struct {
+	creator []byte
+}
+
+func (mp *mockPolicy) Evaluate(header [][]byte, payload []byte, identities [][]byte, signatures [][]byte) error {
+	for _, identity := range identities {
+		if bytes.Equal(identity, mp.creator) {
+			return nil
+		}
+	}
+	return fmt.Errorf("Not signed by the creator")
+}
+
+type mockPolicyManager struct {
+	policies map[string]policies.Policy
+}
+
+func (mpm *mockPolicyManager) GetPolicy(id string) (policies.Policy, bool) {
+	policy, ok := mpm.policies[id]
+	return policy, ok
+}
+
+func newTestServerWithChains(chains map[string]*Chain) *server {
+	filters, cm := getFiltersAndConfig()
+	newChain := func(chainID []byte, genesisBlock *cb.Block) (rawledger.ReadWriter, *broadcastfilter.RuleSet, configtx.Manager, error) {
+		filters, cm := getFiltersAndConfig()
+		return ramledger.New(10, genesisBlock), filters, cm, nil
+	}
+	pm := &mockPolicyManager{policies: map[string]policies.Policy{configtx.ChainCreationPolicyID: &mockPolicy{creator: chainCreator}}}
+	return newServer(2, 1, MagicLargestWindow, time.Millisecond, ramledger.New(10, genesisBlock), filters, cm, pm, systemChainID, chains, newChain, nil)
+}
+
+func newTestServer() *server {
+	return newTestServerWithChains(nil)
+}
+
+func makeSignedEnvelope(headerType cb.HeaderType, chainID []byte, creator []byte, data []byte) *cb.Envelope {
+	header := &cb.Header{ChainHeader: &cb.ChainHeader{Type: int32(headerType), ChainID: chainID}}
+	if creator != nil {
+		header.SignatureHeader = &cb.SignatureHeader{Creator: creator}
+	}
+	payload, err := proto.Marshal(&cb.Payload{
+		Header: header,
+		Data:   data,
+	})
+	if err != nil {
+		panic(err)
+	}
+	return &cb.Envelope{Payload: payload}
+}
+
+func makeEnvelope(headerType cb.HeaderType, chainID []byte, data []byte) *cb.Envelope {
+	return makeSignedEnvelope(headerType, chainID, nil, data)
+}
+
+func makeChainCreationBy(creator, headerChainID, configChainID []byte) *cb.Envelope {
+	config, err := proto.Marshal(&ab.ConfigurationEnvelope{ChainID: configChainID})
+	if err != nil {
+		panic(err)
+	}
+	return makeSignedEnvelope(cb.HeaderType_CHAIN_CREATION, headerChainID, creator, config)
+}
+
+func makeChainCreation(headerChainID, configChainID []byte) *cb.Envelope {
+	return makeChainCreationBy(chainCreator, headerChainID, configChainID)
+}
+
+func broadcastStatus(t *testing.T, m *mockB, msg *cb.Envelope) ab.Status {
+	m.recvChan <- msg
+	select {
+	case reply := <-m.sendChan:
+		return reply.Status
+	case <-time.After(time.Second):
+		t.Fatalf("Timed out waiting for the broadcast reply")
+	}
+	return ab.Status_UNKNOWN
+}
+
+func waitForBlock(t *testing.T, it rawledger.Iterator) {
+	select {
+	case <-it.ReadyChan():
+	case <-time.After(time.Second):
+		t.Fatalf("Expected a block to be cut but it was not")
+	}
+}
+
+func TestChainCreation(t *testing.T) {
+	s := newTestServer()
+	m := newMockB()
+	defer close(m.recvChan)
+	go s.Broadcast(m)
+
+	chainID := []byte("mychain")
+	if status := broadcastStatus(t, m, makeChainCreation(chainID, chainID)); status != ab.Status_SUCCESS {
+		t.Fatalf("Expected the chain to be created, got %v", status)
+	}
+	if status := broadcastStatus(t, m, makeChainCreation(chainID, chainID)); status != ab.Status_BAD_REQUEST {
+		t.Fatalf("Expected the creation of an existing chain to be rejected, got %v", status)
+	}
+	if status := broadcastStatus(t, m, makeChainCreation(systemChainID, systemChainID)); status != ab.Status_BAD_REQUEST {
+		t.Fatalf("Expected the creation of the system chain to be rejected, got %v", status)
+	}
+	if status := broadcastStatus(t, m, makeChainCreation([]byte("other"), chainID)); status != ab.Status_BAD_REQUEST {
+		t.Fatalf("Expected a creation request with the configuration of another chain to be rejected, got %v", status)
+	}
+
+	c := s.chain(chainID)
+	if c == nil || c == s.systemChain {
+		t.Fatalf("Expected the chain to be distinct from the system chain")
+	}
+	it, _ := c.rl.Iterator(ab.SeekInfo_OLDEST, 0)
+	block, status := it.Next()
+	if status != ab.Status_SUCCESS {
+		t.Fatalf("Could not read the genesis block of the chain: %v", status)
+	}
+	config := &ab.ConfigurationEnvelope{}
+	if len(block.Data.Data) != 1 || proto.Unmarshal(block.Data.Data[0], config) != nil || !bytes.Equal(config.ChainID, chainID) {
+		t.Fatalf("Expected the genesis block to hold the configuration of the chain")
+	}
+}
+
+func TestBroadcastRoutesByChain(t *testing.T) {
+	s := newTestServer()
+	m := newMockB()
+	defer close(m.recvChan)
+	go s.Broadcast(m)
+
+	chainID := []byte("mychain")
+	if status := broadcastStatus(t, m, makeChainCreation(chainID, chainID)); status != ab.Status_SUCCESS {
+		t.Fatalf("Expected the chain to be created, got %v", status)
+	}
+
+	chainIt, _ := s.chain(chainID).rl.Iterator(ab.SeekInfo_SPECIFIED, 1)
+	systemIt, _ := s.systemChain.rl.Iterator(ab.SeekInfo_SPECIFIED, 1)
+
+	if status := broadcastStatus(t, m, makeEnvelope(cb.HeaderType_ENDORSER_TRANSACTION, chainID, []byte("Some bytes"))); status != ab.Status_SUCCESS {
+		t.Fatalf("Expected the message to be accepted, got %v", status)
+	}
+	waitForBlock(t, chainIt)
+	select {
+	case <-systemIt.ReadyChan():
+		t.Fatalf("Expected the message of the chain not to be ordered on the system chain")
+	default:
+	}
+
+	if status := broadcastStatus(t, m, makeEnvelope(cb.HeaderType_ENDORSER_TRANSACTION, nil, []byte("Some bytes"))); status != ab.Status_SUCCESS {
+		t.Fatalf("Expected the message to be accepted, got %v", status)
+	}
+	waitForBlock(t, systemIt)
+}
+
+func TestDeliverFromChain(t *testing.T) {
+	s := newTestServer()
+	mb := newMockB()
+	defer close(mb.recvChan)
+	go s.Broadcast(mb)
+
+	chainID := []byte("mychain")
+	if status := broadcastStatus(t, mb, makeChainCreation(chainID, chainID)); status != ab.Status_SUCCESS {
+		t.Fatalf("Expected the chain to be created, got %v", status)
+	}
+
+	m := newMockD()
+	defer close(m.recvChan)
+	go s.Deliver(m)
+
+	m.recvChan <- &ab.DeliverUpdate{Type: &ab.DeliverUpdate_Seek{Seek: &ab.SeekInfo{WindowSize: uint64(MagicLargestWindow), Start: ab.SeekInfo_OLDEST, ChainID: chainID}}}
+
+	select {
+	case deliverReply := <-m.sendChan:
+		block := deliverReply.GetBlock()
+		if block == nil {
+			t.Fatalf("Received an error on the reply channel")
+		}
+		config := &ab.ConfigurationEnvelope{}
+		if proto.Unmarshal(block.Data.Data[0], config) != nil || !bytes.Equal(config.ChainID, chainID) {
+			t.Fatalf("Expected the genesis block of the created chain")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Timed out waiting for the genesis block")
+	}
+}
+
+func TestUnauthorizedChainCreation(t *testing.T) {
+	s := newTestServer()
+	m := newMockB()
+	defer close(m.recvChan)
+	go s.Broadcast(m)
+
+	chainID := []byte("mychain")
+	if status := broadcastStatus(t, m, makeChainCreationBy(nil, chainID, chainID)); status != ab.Status_FORBIDDEN {
+		t.Fatalf("Expected an unsigned creation request to be rejected, got %v", status)
+	}
+	if status := broadcastStatus(t, m, makeChainCreationBy([]byte("intruder"), chainID, chainID)); status != ab.Status_FORBIDDEN {
+		t.Fatalf("Expected a creation request which does not satisfy the policy to be rejected, got %v", status)
+	}
+	if s.chain(chainID) != nil {
+		t.Fatalf("Expected the chain not to be created")
+	}
+
+	s.policyManager = &mockPolicyManager{}
+	if status := broadcastStatus(t, m, makeChainCreation(chainID, chainID)); status != ab.Status_FORBIDDEN {
+		t.Fatalf("Expected the creation request to be rejected without a chain creation policy, got %v", status)
+	}
+}
+
+func TestBroadcastUnknownChain(t *testing.T) {
+	s := newTestServer()
+	m := newMockB()
+	defer close(m.recvChan)
+	go s.Broadcast(m)
+
+	systemIt, _ := s.systemChain.rl.Iterator(ab.SeekInfo_SPECIFIED, 1)
+
+	if status := broadcastStatus(t, m, makeEnvelope(cb.HeaderType_ENDORSER_TRANSACTION, []byte("unknown"), []byte("Some bytes"))); status != ab.Status_NOT_FOUND {
+		t.Fatalf("Expected the message for an unknown chain to be rejected, got %v", status)
+	}
+	select {
+	case <-systemIt.ReadyChan():
+		t.Fatalf("Expected the message for an unknown chain not to be ordered on the system chain")
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestDeliverUnknownChain(t *testing.T) {
+	s := newTestServer()
+	m := newMockD()
+	defer close(m.recvChan)
+	go s.Deliver(m)
+
+	m.recvChan <- &ab.DeliverUpdate{Type: &ab.DeliverUpdate_Seek{Seek: &ab.SeekInfo{WindowSize: uint64(MagicLargestWindow), Start: ab.SeekInfo_OLDEST, ChainID: []byte("unknown")}}}
+
+	select {
+	case deliverReply := <-m.sendChan:
+		if deliverReply.GetError() != ab.Status_NOT_FOUND {
+			t.Fatalf("Expected the seek on an unknown chain to be answered with NOT_FOUND, got %v", deliverReply)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Timed out waiting for the reply")
+	}
+}
+
+func TestRestoredChain(t *testing.T) {
+	chainID := []byte("mychain")
+	filters, cm := getFiltersAndConfig()
+	s := newTestServerWithChains(map[string]*Chain{
+		string(chainID): {Ledger: ramledger.New(10, genesisBlock), Filters: filters, ConfigManager: cm},
+	})
+	m := newMockB()
+	defer close(m.recvChan)
+	go s.Broadcast(m)
+
+	c := s.chain(chainID)
+	if c == nil || c == s.systemChain {
+		t.Fatalf("Expected the chain to be restored")
+	}
+	if status := broadcastStatus(t, m, makeChainCreation(chainID, chainID)); status != ab.Status_BAD_REQUEST {
+		t.Fatalf("Expected the creation of a restored chain to be rejected, got %v", status)
+	}
+
+	it, _ := c.rl.Iterator(ab.SeekInfo_SPECIFIED, 1)
+	if status := broadcastStatus(t, m, makeEnvelope(cb.HeaderType_ENDORSER_TRANSACTION, chainID, []byte("Some bytes"))); status != ab.Status_SUCCESS {
+		t.Fatalf("Expected the message to be accepted, got %v", status)
+	}
+	waitForBlock(t, it)
+}
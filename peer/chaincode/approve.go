@@ -0,0 +1,170 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"golang.org/x/net/context"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/peer/common"
+	pb "github.com/hyperledger/fabric/protos"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func approveCmd() *cobra.Command {
+	flags := chaincodeApproveCmd.Flags()
+	flags.StringVar(&chaincodeOrganization, "org", "",
+		fmt.Sprintf("Organization approving the %s definition", chainFuncName))
+	addDefinitionFlags(flags)
+
+	return chaincodeApproveCmd
+}
+
+// Variables for the chaincode definitions approved by organizations.
+var (
+	chaincodeOrganization string
+	chaincodeCollections  string
+	chaincodeInterests    string
+)
+
+// addDefinitionFlags adds the flags of a chaincode definition that are not
+// persistent flags of the chaincode command.
+func addDefinitionFlags(flags *pflag.FlagSet) {
+	flags.StringVarP(&chaincodePolicy, "policy", "P", "",
+		fmt.Sprintf("Endorsement policy of the %s", chainFuncName))
+	flags.StringVar(&chaincodeCollections, "collections-config", "",
+		fmt.Sprintf("File with the configuration of the private data collections of the %s", chainFuncName))
+	flags.StringVar(&chaincodeInterests, "interests", "",
+		fmt.Sprintf("File with the JSON array of the collections and organizations each function of the %s touches", chainFuncName))
+	addSystemChaincodeFlags(flags)
+}
+
+var chaincodeApproveCmd = &cobra.Command{
+	Use:       "approve",
+	Short:     fmt.Sprintf("Approve the %s definition for an organization.", chainFuncName),
+	Long:      fmt.Sprintf(`Approve the %s definition (name, version, endorsement policy, collections, interests and constructor message) for an organization. The definition is committed once enough organizations approved it.`, chainFuncName),
+	ValidArgs: []string{"1"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return chaincodeDefine(cmd, "approve")
+	},
+}
+
+//getChaincodeDefinition gets the chaincode definition from the command line
+func getChaincodeDefinition(cmd *cobra.Command) (*pb.ChaincodeDefinition, error) {
+	spec, err := getChaincodeSpecification(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	def := &pb.ChaincodeDefinition{
+		Name:              spec.ChaincodeID.Name,
+		Version:           spec.ChaincodeID.Version,
+		EndorsementPolicy: []byte(chaincodePolicy),
+		CtorMsg:           spec.CtorMsg,
+		Escc:              chaincodeEscc,
+		Vscc:              chaincodeVscc,
+	}
+
+	if chaincodeCollections != "" {
+		if def.Collections, err = ioutil.ReadFile(chaincodeCollections); err != nil {
+			return nil, fmt.Errorf("Error reading collections configuration: %s", err)
+		}
+	}
+
+	if chaincodeInterests != "" {
+		b, err := ioutil.ReadFile(chaincodeInterests)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading interests: %s", err)
+		}
+		if err = json.Unmarshal(b, &def.Interests); err != nil {
+			return nil, fmt.Errorf("Error parsing interests: %s", err)
+		}
+	}
+
+	return def, nil
+}
+
+//define sends the approval or the commit of the chaincode definition
+//via Endorser
+func define(cmd *cobra.Command, function string) (*pb.Proposal, *pb.ProposalResponse, error) {
+	def, err := getChaincodeDefinition(cmd)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	b, err := proto.Marshal(def)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	args := [][]byte{[]byte(function), []byte(chainID)}
+	if function == "approve" {
+		if chaincodeOrganization == "" {
+			return nil, nil, fmt.Errorf("Must supply value for the organization parameter.\n")
+		}
+		args = append(args, []byte(chaincodeOrganization))
+	}
+	args = append(args, b)
+
+	endorserClient, err := common.GetEndorserClient(cmd)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error getting endorser client %s: %s", chainFuncName, err)
+	}
+
+	lcccSpec := &pb.ChaincodeInvocationSpec{ChaincodeSpec: &pb.ChaincodeSpec{Type: pb.ChaincodeSpec_GOLANG, ChaincodeID: &pb.ChaincodeID{Name: "lccc"}, CtorMsg: &pb.ChaincodeInput{Args: args}}}
+
+	identity, err := common.GetIdentity()
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error getting identity %s: %s\n", chainFuncName, err)
+	}
+
+	prop, err := getProposal(lcccSpec, identity.Cert, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error creating proposal  %s: %s\n", chainFuncName, err)
+	}
+
+	proposalResponse, err := processProposal(context.Background(), endorserClient, identity, prop)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error endorsing %s: %s\n", chainFuncName, err)
+	}
+	if err = checkProposalResponse(proposalResponse); err != nil {
+		return nil, nil, fmt.Errorf("Error endorsing %s: %s\n", chainFuncName, err)
+	}
+
+	logger.Infof("%s(endorser) result: %v", function, proposalResponse)
+	return prop, proposalResponse, nil
+}
+
+// chaincodeDefine approves or commits the chaincode definition and sends the
+// transaction to the orderer.
+func chaincodeDefine(cmd *cobra.Command, function string) error {
+	prop, presult, err := define(cmd, function)
+	if err != nil {
+		return err
+	}
+
+	if presult != nil {
+		err = sendTransaction(context.Background(), prop, presult)
+	}
+
+	return err
+}
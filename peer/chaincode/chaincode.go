@@ -34,22 +34,44 @@ var logger = logging.MustGetLogger("chaincodeCmd")
 func Cmd() *cobra.Command {
 	flags := chaincodeCmd.PersistentFlags()
 
+	flags.StringVarP(&chainID, "chainID", "C", defaultChainID,
+		fmt.Sprintf("Name of the chain the %s operations are proposed on", chainFuncName))
 	flags.StringVarP(&chaincodeLang, "lang", "l", "golang",
 		fmt.Sprintf("Language the %s is written in", chainFuncName))
 	flags.StringVarP(&chaincodeCtorJSON, "ctor", "c", "{}",
 		fmt.Sprintf("Constructor message for the %s in JSON format", chainFuncName))
 	flags.StringVarP(&chaincodeAttributesJSON, "attributes", "a", "[]",
 		fmt.Sprintf("User attributes for the %s in JSON format", chainFuncName))
+	flags.StringVar(&chaincodeTransientJSON, "transient", "{}",
+		fmt.Sprintf("Transient data for the %s in JSON format, a map of names to base64 encoded values that is never written to the ledger", chainFuncName))
 	flags.StringVarP(&chaincodePath, "path", "p", common.UndefinedParamValue,
 		fmt.Sprintf("Path to %s", chainFuncName))
 	flags.StringVarP(&chaincodeName, "name", "n", common.UndefinedParamValue,
 		fmt.Sprint("Name of the chaincode returned by the deploy transaction"))
+	flags.StringVarP(&chaincodeVersion, "ccversion", "V", "",
+		fmt.Sprintf("Version of the %s, required to install, instantiate and upgrade", chainFuncName))
 	flags.StringVarP(&chaincodeUsr, "username", "u", common.UndefinedParamValue,
 		fmt.Sprint("Username for chaincode operations when security is enabled"))
 	flags.StringVarP(&customIDGenAlg, "tid", "t", common.UndefinedParamValue,
 		fmt.Sprint("Name of a custom ID generation algorithm (hashing and decoding) e.g. sha256base64"))
+	flags.Int64Var(&chaincodeCPUShares, "cpu-shares", 0,
+		fmt.Sprintf("CPU shares of the %s container, 0 uses the limit of the peer", chainFuncName))
+	flags.Int64Var(&chaincodeMemory, "memory", 0,
+		fmt.Sprintf("Memory limit in bytes of the %s container, 0 uses the limit of the peer", chainFuncName))
+	flags.Int64Var(&chaincodePidsLimit, "pids-limit", 0,
+		fmt.Sprintf("Maximum number of processes in the %s container, 0 uses the limit of the peer", chainFuncName))
 
 	chaincodeCmd.AddCommand(deployCmd())
+	chaincodeCmd.AddCommand(packageCmd())
+	chaincodeCmd.AddCommand(installCmd())
+	chaincodeCmd.AddCommand(signPackageCmd())
+	chaincodeCmd.AddCommand(scaffoldCmd())
+	chaincodeCmd.AddCommand(instantiateCmd())
+	chaincodeCmd.AddCommand(upgradeCmd())
+	chaincodeCmd.AddCommand(approveCmd())
+	chaincodeCmd.AddCommand(commitCmd())
+	chaincodeCmd.AddCommand(listCmd())
+	chaincodeCmd.AddCommand(layoutsCmd())
 	chaincodeCmd.AddCommand(invokeCmd())
 	chaincodeCmd.AddCommand(queryCmd())
 
@@ -58,15 +80,22 @@ func Cmd() *cobra.Command {
 
 // Chaincode-related variables.
 var (
+	chainID                 string
 	chaincodeLang           string
 	chaincodeCtorJSON       string
 	chaincodePath           string
 	chaincodeName           string
+	chaincodeVersion        string
+	chaincodePolicy         string
 	chaincodeUsr            string
 	chaincodeQueryRaw       bool
 	chaincodeQueryHex       bool
 	chaincodeAttributesJSON string
+	chaincodeTransientJSON  string
 	customIDGenAlg          string
+	chaincodeCPUShares      int64
+	chaincodeMemory         int64
+	chaincodePidsLimit      int64
 )
 
 var chaincodeCmd = &cobra.Command{
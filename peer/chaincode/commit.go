@@ -0,0 +1,39 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chaincode
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func commitCmd() *cobra.Command {
+	addDefinitionFlags(chaincodeCommitCmd.Flags())
+
+	return chaincodeCommitCmd
+}
+
+var chaincodeCommitCmd = &cobra.Command{
+	Use:       "commit",
+	Short:     fmt.Sprintf("Commit the %s definition approved by the organizations.", chainFuncName),
+	Long:      fmt.Sprintf(`Commit the %s definition approved by the organizations, which instantiates or upgrades the installed %s. The definition must match the approved one.`, chainFuncName, chainFuncName),
+	ValidArgs: []string{"1"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return chaincodeDefine(cmd, "commit")
+	},
+}
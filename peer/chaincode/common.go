@@ -26,6 +26,8 @@ import (
 
 	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric/core"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/core/tracing"
 	"github.com/hyperledger/fabric/peer/common"
 	"github.com/hyperledger/fabric/peer/util"
 	pb "github.com/hyperledger/fabric/protos"
@@ -35,10 +37,11 @@ import (
 	"golang.org/x/net/context"
 )
 
-//getProposal gets the proposal for the chaincode invocation
+//getProposal gets the proposal for the chaincode invocation on the chain
+//given with --chainID
 //Currently supported only for Invokes (Queries still go through devops client)
-func getProposal(cis *pb.ChaincodeInvocationSpec, creator []byte) (*pb.Proposal, error) {
-	return putils.CreateChaincodeProposal(cis, creator)
+func getProposal(cis *pb.ChaincodeInvocationSpec, creator []byte, transientMap map[string][]byte) (*pb.Proposal, error) {
+	return putils.CreateChaincodeProposalForChain(chainID, cis, creator, transientMap)
 }
 
 //getDeployProposal gets the proposal for the chaincode deployment
@@ -50,10 +53,31 @@ func getDeployProposal(cds *pb.ChaincodeDeploymentSpec, creator []byte) (*pb.Pro
 	}
 
 	//wrap the deployment in an invocation spec to lccc...
-	lcccSpec := &pb.ChaincodeInvocationSpec{ChaincodeSpec: &pb.ChaincodeSpec{Type: pb.ChaincodeSpec_GOLANG, ChaincodeID: &pb.ChaincodeID{Name: "lccc"}, CtorMsg: &pb.ChaincodeInput{Args: [][]byte{[]byte("deploy"), []byte("default"), b}}}}
+	lcccSpec := &pb.ChaincodeInvocationSpec{ChaincodeSpec: &pb.ChaincodeSpec{Type: pb.ChaincodeSpec_GOLANG, ChaincodeID: &pb.ChaincodeID{Name: "lccc"}, CtorMsg: &pb.ChaincodeInput{Args: [][]byte{[]byte("deploy"), []byte(chainID), b}}}}
 
 	//...and get the proposal for it
-	return getProposal(lcccSpec, creator)
+	return getProposal(lcccSpec, creator, nil)
+}
+
+//getLifecycleProposal gets the proposal for a call to a chaincode lifecycle
+//function of lccc. The chain name is left out when empty and the given
+//trailing arguments follow the deployment spec or package
+func getLifecycleProposal(function string, chainname string, spec proto.Message, creator []byte, trailing ...[]byte) (*pb.Proposal, error) {
+	b, err := proto.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	args := [][]byte{[]byte(function)}
+	if chainname != "" {
+		args = append(args, []byte(chainname))
+	}
+	args = append(args, b)
+	args = append(args, trailing...)
+
+	lcccSpec := &pb.ChaincodeInvocationSpec{ChaincodeSpec: &pb.ChaincodeSpec{Type: pb.ChaincodeSpec_GOLANG, ChaincodeID: &pb.ChaincodeID{Name: "lccc"}, CtorMsg: &pb.ChaincodeInput{Args: args}}}
+
+	return getProposal(lcccSpec, creator, nil)
 }
 
 func getChaincodeSpecification(cmd *cobra.Command) (*pb.ChaincodeSpec, error) {
@@ -76,10 +100,17 @@ func getChaincodeSpecification(cmd *cobra.Command) (*pb.ChaincodeSpec, error) {
 	chaincodeLang = strings.ToUpper(chaincodeLang)
 	spec = &pb.ChaincodeSpec{
 		Type:        pb.ChaincodeSpec_Type(pb.ChaincodeSpec_Type_value[chaincodeLang]),
-		ChaincodeID: &pb.ChaincodeID{Path: chaincodePath, Name: chaincodeName},
+		ChaincodeID: &pb.ChaincodeID{Path: chaincodePath, Name: chaincodeName, Version: chaincodeVersion},
 		CtorMsg:     input,
 		Attributes:  attributes,
 	}
+	if chaincodeCPUShares != 0 || chaincodeMemory != 0 || chaincodePidsLimit != 0 {
+		spec.ResourceLimits = &pb.ChaincodeResourceLimits{
+			CpuShares: chaincodeCPUShares,
+			Memory:    chaincodeMemory,
+			PidsLimit: chaincodePidsLimit,
+		}
+	}
 	// If security is enabled, add client login token
 	if core.SecurityEnabled() {
 		if chaincodeUsr == common.UndefinedParamValue {
@@ -149,31 +180,61 @@ func chaincodeInvokeOrQuery(cmd *cobra.Command, args []string, invoke bool) (err
 	}
 
 	if invoke {
+		// the invocation is traced from the endorsement of the proposal to
+		// the broadcast of the transaction
+		if err = tracing.Init(tracing.Config{
+			Enabled:      viper.GetBool("tracing.enabled"),
+			ServiceName:  "peer-cli",
+			OTLPEndpoint: viper.GetString("tracing.otlp.endpoint"),
+			File:         viper.GetString("tracing.file"),
+		}); err != nil {
+			return err
+		}
+		// the spans are exported before the command exits, once the span
+		// of the invocation is finished
+		defer tracing.Flush()
+		span, ctx := tracing.StartSpan(context.Background(), "peer.chaincode.invoke")
+		span.SetTag("chainID", chainID)
+		span.SetTag("chaincode", chaincodeName)
+		defer func() { span.Finish(err) }()
+
 		endorserClient, err := common.GetEndorserClient(cmd)
 		if err != nil {
 			return fmt.Errorf("Error getting endorser client %s: %s", chainFuncName, err)
 		}
 
+		var transientMap map[string][]byte
+		if err = json.Unmarshal([]byte(chaincodeTransientJSON), &transientMap); err != nil {
+			return fmt.Errorf("Chaincode transient data error: %s", err)
+		}
+
+		var identity *common.Identity
+		identity, err = common.GetIdentity()
+		if err != nil {
+			return fmt.Errorf("Error getting identity %s: %s\n", chainFuncName, err)
+		}
+
 		var prop *pb.Proposal
-		// TODO: how should we get a cert from the command line?
-		prop, err = getProposal(invocation, []byte("cert"))
+		prop, err = getProposal(invocation, identity.Cert, transientMap)
 		if err != nil {
 			return fmt.Errorf("Error creating proposal  %s: %s\n", chainFuncName, err)
 		}
 
 		var proposalResp *pb.ProposalResponse
-		proposalResp, err = endorserClient.ProcessProposal(context.Background(), prop)
+		proposalResp, err = processProposal(tracing.OutgoingContext(ctx), endorserClient, identity, prop)
 		if err != nil {
 			return fmt.Errorf("Error endorsing %s: %s\n", chainFuncName, err)
 		}
+		if err = checkProposalResponse(proposalResp); err != nil {
+			return fmt.Errorf("Error invoking %s: %s\n", chainFuncName, err)
+		}
 
-		if proposalResp != nil {
-			if err = sendTransaction(proposalResp); err != nil {
-				return fmt.Errorf("Error sending transaction %s: %s\n", chainFuncName, err)
-			}
+		if err = sendTransaction(ctx, prop, proposalResp); err != nil {
+			return fmt.Errorf("Error sending transaction %s: %s\n", chainFuncName, err)
 		}
 
 		logger.Infof("Invoke result: %v", proposalResp)
+		fmt.Printf("Invoke Result: %s\n", string(proposalResp.Response.Payload))
 	} else {
 		//for now let's continue to use Query with devops
 		//eventually query will go away
@@ -217,6 +278,28 @@ func checkChaincodeCmdParams(cmd *cobra.Command) error {
 		return fmt.Errorf("Must supply value for %s name parameter.\n", chainFuncName)
 	}
 
+	var cmdName string
+	if cmd != nil {
+		cmdName = cmd.Name()
+	}
+
+	//installed packages are identified by name and version
+	switch cmdName {
+	case "install", "signpackage", "instantiate", "upgrade", "approve", "commit":
+		if chaincodeVersion == "" {
+			return fmt.Errorf("Must supply value for %s version parameter.\n", chainFuncName)
+		}
+	}
+
+	if chaincodeCPUShares < 0 || chaincodeMemory < 0 || chaincodePidsLimit < 0 {
+		return fmt.Errorf("Resource limits of the %s container must not be negative", chainFuncName)
+	}
+
+	//the package is only activated with its constructor message on instantiate
+	if cmdName == "install" || cmdName == "signpackage" {
+		return nil
+	}
+
 	// Check that non-empty chaincode parameters contain only Args as a key.
 	// Type checking is done later when the JSON is actually unmarshaled
 	// into a pb.ChaincodeInput. To better understand what's going
@@ -253,9 +336,55 @@ func checkChaincodeCmdParams(cmd *cobra.Command) error {
 	return nil
 }
 
-//sendTransactions converts a ProposalResponse and sends it as
+//processProposal signs the proposal with the identity and sends it to the
+//endorser, as a stream of chunks if it is larger than "peer.proposalChunkSize"
+func processProposal(ctxt context.Context, endorserClient pb.EndorserClient, identity *common.Identity, prop *pb.Proposal) (*pb.ProposalResponse, error) {
+	signedProp, err := identity.Sign(prop)
+	if err != nil {
+		return nil, fmt.Errorf("Error signing proposal: %s", err)
+	}
+
+	chunkSize := viper.GetInt("peer.proposalChunkSize")
+	if chunkSize <= 0 {
+		chunkSize = putils.DefaultChunkSize
+	}
+	if proto.Size(signedProp) <= chunkSize {
+		return endorserClient.ProcessProposal(ctxt, signedProp)
+	}
+
+	chunks, err := putils.GetProposalChunks(signedProp, chunkSize)
+	if err != nil {
+		return nil, err
+	}
+	logger.Infof("Sending the proposal in %d chunks", len(chunks))
+
+	stream, err := endorserClient.ProcessProposalStream(ctxt)
+	if err != nil {
+		return nil, err
+	}
+	for _, chunk := range chunks {
+		if err = stream.Send(chunk); err != nil {
+			return nil, err
+		}
+	}
+	return stream.CloseAndRecv()
+}
+
+//checkProposalResponse returns an error if the chaincode failed the
+//proposal, distinguishing it from a successful endorsement
+func checkProposalResponse(presp *pb.ProposalResponse) error {
+	if presp == nil || presp.Response == nil {
+		return errors.New("Proposal response has no response")
+	}
+	if presp.Response.Status >= shim.ERRORTHRESHOLD {
+		return fmt.Errorf("Chaincode failed with status %d: %s", presp.Response.Status, presp.Response.Message)
+	}
+	return nil
+}
+
+//sendTransactions converts the ProposalResponse of a proposal and sends it as
 //a Transaction to the orderer
-func sendTransaction(presp *pb.ProposalResponse) error {
+func sendTransaction(ctx context.Context, prop *pb.Proposal, presp *pb.ProposalResponse) error {
 	var orderer string
 	if viper.GetBool("peer.committer.enabled") {
 		orderer = viper.GetString("peer.committer.ledger.orderer")
@@ -271,14 +400,14 @@ func sendTransaction(presp *pb.ProposalResponse) error {
 			return fmt.Errorf("Proposal response erred with status %d", presp.Response.Status)
 		}
 		if presp.Payload != nil {
-			tx, err := putils.CreateTxFromProposalResponse(presp)
+			tx, err := putils.CreateTxFromProposalResponse(prop.Header, presp)
 			b, err := proto.Marshal(tx)
 			if err != nil {
 				return err
 			}
 
 			if b != nil {
-				err = Send(orderer, b)
+				err = Send(ctx, orderer, chainID, b)
 			}
 		}
 	}
@@ -76,3 +76,16 @@ func TestCheckChaincodeCmdParamsEmptyCtor(t *testing.T) {
 
 	require.Error(result)
 }
+
+func TestCheckChaincodeCmdParamsNegativeResourceLimit(t *testing.T) {
+	chaincodeAttributesJSON = "[]"
+	chaincodeCtorJSON = `{ "Args":["func", "param"] }`
+	chaincodePath = "some/path"
+	chaincodeName = "somename"
+	chaincodeMemory = -1
+	defer func() { chaincodeMemory = 0 }()
+	require := require.New(t)
+	result := checkChaincodeCmdParams(nil)
+
+	require.Error(result)
+}
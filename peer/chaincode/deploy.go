@@ -18,6 +18,7 @@ package chaincode
 
 import (
 	"fmt"
+	"io/ioutil"
 
 	"golang.org/x/net/context"
 
@@ -29,9 +30,27 @@ import (
 
 // Cmd returns the cobra command for Chaincode Deploy
 func deployCmd() *cobra.Command {
+	flags := chaincodeDeployCmd.Flags()
+	flags.StringVar(&chaincodeServerAddress, "server-address", "",
+		fmt.Sprintf("Address of the server of a %s run as an external service. The peer connects to it instead of building and launching a container", chainFuncName))
+	flags.BoolVar(&chaincodeServerTLS, "server-tls", false,
+		"If true, the peer connects to the chaincode server with TLS")
+	flags.StringVar(&chaincodeServerRootCert, "server-rootcert", "",
+		"File with the PEM encoded root certificate of the chaincode server, the system roots are used if not set")
+	flags.StringVar(&chaincodeServerHostOverride, "server-hostoverride", "",
+		"Server name the certificate of the chaincode server is verified against")
+
 	return chaincodeDeployCmd
 }
 
+// Variables for chaincodes run as an external service.
+var (
+	chaincodeServerAddress      string
+	chaincodeServerTLS          bool
+	chaincodeServerRootCert     string
+	chaincodeServerHostOverride string
+)
+
 var chaincodeDeployCmd = &cobra.Command{
 	Use:       "deploy",
 	Short:     fmt.Sprintf("Deploy the specified chaincode to the network."),
@@ -43,50 +62,82 @@ var chaincodeDeployCmd = &cobra.Command{
 }
 
 //deploy the command via Endorser
-func deploy(cmd *cobra.Command) (*pb.ProposalResponse, error) {
+func deploy(cmd *cobra.Command) (*pb.Proposal, *pb.ProposalResponse, error) {
 	spec, err := getChaincodeSpecification(cmd)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	ctxt := context.Background()
 
-	cds, err := core.GetChaincodeBytes(ctxt, spec)
+	var cds *pb.ChaincodeDeploymentSpec
+	if chaincodeServerAddress != "" {
+		cds, err = getExternalDeploymentSpec(spec)
+	} else {
+		cds, err = core.GetChaincodeBytes(ctxt, spec)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("Error getting chaincode code %s: %s", chainFuncName, err)
+		return nil, nil, fmt.Errorf("Error getting chaincode code %s: %s", chainFuncName, err)
 	}
 
 	endorserClient, err := common.GetEndorserClient(cmd)
 	if err != nil {
-		return nil, fmt.Errorf("Error getting endorser client %s: %s", chainFuncName, err)
+		return nil, nil, fmt.Errorf("Error getting endorser client %s: %s", chainFuncName, err)
+	}
+
+	identity, err := common.GetIdentity()
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error getting identity %s: %s\n", chainFuncName, err)
 	}
 
-	// TODO: how should we get a cert from the command line?
-	prop, err := getDeployProposal(cds, []byte("cert"))
+	prop, err := getDeployProposal(cds, identity.Cert)
 	if err != nil {
-		return nil, fmt.Errorf("Error creating proposal  %s: %s\n", chainFuncName, err)
+		return nil, nil, fmt.Errorf("Error creating proposal  %s: %s\n", chainFuncName, err)
 	}
 
-	proposalResponse, err := endorserClient.ProcessProposal(ctxt, prop)
+	proposalResponse, err := processProposal(ctxt, endorserClient, identity, prop)
 	if err != nil {
-		return nil, fmt.Errorf("Error endorsing %s: %s\n", chainFuncName, err)
+		return nil, nil, fmt.Errorf("Error endorsing %s: %s\n", chainFuncName, err)
+	}
+	if err = checkProposalResponse(proposalResponse); err != nil {
+		return nil, nil, fmt.Errorf("Error endorsing %s: %s\n", chainFuncName, err)
 	}
 
 	logger.Infof("Deploy(endorser) result: %v", proposalResponse)
-	return proposalResponse, nil
+	return prop, proposalResponse, nil
+}
+
+// getExternalDeploymentSpec returns the deployment spec of a chaincode run as
+// an external service. There is no code package, the peer connects to the
+// chaincode server instead.
+func getExternalDeploymentSpec(spec *pb.ChaincodeSpec) (*pb.ChaincodeDeploymentSpec, error) {
+	server := &pb.ChaincodeServerInfo{
+		Address:            chaincodeServerAddress,
+		TlsEnabled:         chaincodeServerTLS,
+		ServerHostOverride: chaincodeServerHostOverride,
+	}
+	if chaincodeServerRootCert != "" {
+		rootCert, err := ioutil.ReadFile(chaincodeServerRootCert)
+		if err != nil {
+			return nil, fmt.Errorf("error reading the root certificate of the chaincode server: %s", err)
+		}
+		server.TlsEnabled = true
+		server.RootCert = rootCert
+	}
+	return &pb.ChaincodeDeploymentSpec{ChaincodeSpec: spec, ExecEnv: pb.ChaincodeDeploymentSpec_EXTERNAL, ChaincodeServer: server}, nil
 }
 
 // chaincodeDeploy deploys the chaincode. On success, the chaincode name
 // (hash) is printed to STDOUT for use by subsequent chaincode-related CLI
 // commands.
 func chaincodeDeploy(cmd *cobra.Command, args []string) error {
-	presult, err := deploy(cmd)
+	prop, presult, err := deploy(cmd)
 	if err != nil {
 		return err
 	}
 
 	if presult != nil {
-		err = sendTransaction(presult)
+		err = sendTransaction(context.Background(), prop, presult)
 	}
 
 	return err
@@ -0,0 +1,103 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chaincode
+
+import (
+	"fmt"
+
+	"golang.org/x/net/context"
+
+	"github.com/hyperledger/fabric/core/chaincode/ccpackage"
+	"github.com/hyperledger/fabric/peer/common"
+	pb "github.com/hyperledger/fabric/protos"
+	"github.com/spf13/cobra"
+)
+
+func installCmd() *cobra.Command {
+	chaincodeInstallCmd.Flags().StringVar(&chaincodePackageFile, "package", "",
+		fmt.Sprintf("File with a %s package (see package and signpackage) to install instead of packaging the path", chainFuncName))
+
+	return chaincodeInstallCmd
+}
+
+var chaincodeInstallCmd = &cobra.Command{
+	Use:       "install",
+	Short:     fmt.Sprintf("Install the specified %s package on the peer.", chainFuncName),
+	Long:      fmt.Sprintf(`Install the specified %s package on the peer. The package is not active on any chain until it is instantiated.`, chainFuncName),
+	ValidArgs: []string{"1"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return chaincodeInstall(cmd, args)
+	},
+}
+
+//install the package via Endorser. Nothing is written to the chain so no
+//transaction is sent
+func install(cmd *cobra.Command) (*pb.ProposalResponse, error) {
+	ctxt := context.Background()
+
+	var spkg *pb.SignedChaincodeDeploymentSpec
+	var err error
+	if chaincodePackageFile != "" {
+		var md *ccpackage.Metadata
+		if spkg, md, err = readPackage(chaincodePackageFile); err == nil && md != nil {
+			logger.Infof("Installing package %s", md.Label)
+		}
+	} else {
+		spkg, err = getPackage(ctxt, cmd)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := packageHash(spkg)
+	if err != nil {
+		return nil, err
+	}
+	logger.Infof("Installing package with hash %s", hash)
+
+	endorserClient, err := common.GetEndorserClient(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting endorser client %s: %s", chainFuncName, err)
+	}
+
+	identity, err := common.GetIdentity()
+	if err != nil {
+		return nil, fmt.Errorf("Error getting identity %s: %s\n", chainFuncName, err)
+	}
+
+	prop, err := getLifecycleProposal("install", "", spkg, identity.Cert)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating proposal  %s: %s\n", chainFuncName, err)
+	}
+
+	proposalResponse, err := processProposal(ctxt, endorserClient, identity, prop)
+	if err != nil {
+		return nil, fmt.Errorf("Error endorsing %s: %s\n", chainFuncName, err)
+	}
+	if err = checkProposalResponse(proposalResponse); err != nil {
+		return nil, fmt.Errorf("Error endorsing %s: %s\n", chainFuncName, err)
+	}
+
+	logger.Infof("Install result: %v", proposalResponse)
+	return proposalResponse, nil
+}
+
+// chaincodeInstall installs the chaincode package on the peer.
+func chaincodeInstall(cmd *cobra.Command, args []string) error {
+	_, err := install(cmd)
+	return err
+}
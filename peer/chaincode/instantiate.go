@@ -0,0 +1,115 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chaincode
+
+import (
+	"fmt"
+
+	"golang.org/x/net/context"
+
+	"github.com/hyperledger/fabric/peer/common"
+	pb "github.com/hyperledger/fabric/protos"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func instantiateCmd() *cobra.Command {
+	chaincodeInstantiateCmd.Flags().StringVarP(&chaincodePolicy, "policy", "P", "",
+		fmt.Sprintf("Endorsement policy of the %s", chainFuncName))
+	addSystemChaincodeFlags(chaincodeInstantiateCmd.Flags())
+
+	return chaincodeInstantiateCmd
+}
+
+// Variables for the system chaincodes endorsing and validating the
+// transactions of a chaincode.
+var (
+	chaincodeEscc string
+	chaincodeVscc string
+)
+
+// addSystemChaincodeFlags adds the flags naming the system chaincodes
+// endorsing and validating the transactions of the chaincode.
+func addSystemChaincodeFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&chaincodeEscc, "escc", "",
+		fmt.Sprintf("System chaincode endorsing the transactions of the %s (escc by default)", chainFuncName))
+	flags.StringVar(&chaincodeVscc, "vscc", "",
+		fmt.Sprintf("System chaincode validating the transactions of the %s (vscc by default)", chainFuncName))
+}
+
+var chaincodeInstantiateCmd = &cobra.Command{
+	Use:       "instantiate",
+	Short:     fmt.Sprintf("Instantiate the specified installed %s on the chain.", chainFuncName),
+	Long:      fmt.Sprintf(`Instantiate the specified installed %s on the chain with its constructor message and policy.`, chainFuncName),
+	ValidArgs: []string{"1"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return chaincodeActivate(cmd, "instantiate")
+	},
+}
+
+//activate an installed package on the chain via Endorser. The function is
+//either instantiate or upgrade. The deployment spec carries no code, the
+//peer uses the package installed under the name and version
+func activate(cmd *cobra.Command, function string) (*pb.Proposal, *pb.ProposalResponse, error) {
+	spec, err := getChaincodeSpecification(cmd)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	endorserClient, err := common.GetEndorserClient(cmd)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error getting endorser client %s: %s", chainFuncName, err)
+	}
+
+	cds := &pb.ChaincodeDeploymentSpec{ChaincodeSpec: spec}
+
+	identity, err := common.GetIdentity()
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error getting identity %s: %s\n", chainFuncName, err)
+	}
+
+	prop, err := getLifecycleProposal(function, chainID, cds, identity.Cert, []byte(chaincodePolicy), []byte(chaincodeEscc), []byte(chaincodeVscc))
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error creating proposal  %s: %s\n", chainFuncName, err)
+	}
+
+	proposalResponse, err := processProposal(context.Background(), endorserClient, identity, prop)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error endorsing %s: %s\n", chainFuncName, err)
+	}
+	if err = checkProposalResponse(proposalResponse); err != nil {
+		return nil, nil, fmt.Errorf("Error endorsing %s: %s\n", chainFuncName, err)
+	}
+
+	logger.Infof("%s(endorser) result: %v", function, proposalResponse)
+	return prop, proposalResponse, nil
+}
+
+// chaincodeActivate instantiates or upgrades the chaincode and sends the
+// transaction to the orderer.
+func chaincodeActivate(cmd *cobra.Command, function string) error {
+	prop, presult, err := activate(cmd, function)
+	if err != nil {
+		return err
+	}
+
+	if presult != nil {
+		err = sendTransaction(context.Background(), prop, presult)
+	}
+
+	return err
+}
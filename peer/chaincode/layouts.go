@@ -0,0 +1,97 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chaincode
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/context"
+
+	"github.com/hyperledger/fabric/peer/common"
+	pb "github.com/hyperledger/fabric/protos"
+	"github.com/spf13/cobra"
+)
+
+func layoutsCmd() *cobra.Command {
+	return chaincodeLayoutsCmd
+}
+
+var chaincodeLayoutsCmd = &cobra.Command{
+	Use:   "layouts <name>[:<function>[:<collection>,...]] ...",
+	Short: fmt.Sprintf("Get the endorsers of a transaction calling %ss.", chainFuncName),
+	Long:  fmt.Sprintf(`Get the minimal sets of organizations, with their peers, whose endorsements satisfy the endorsement policies of all the %ss called by a transaction, one call per argument naming the %s and optionally its function and the private data collections it involves.`, chainFuncName, chainFuncName),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return chaincodeLayouts(cmd, args)
+	},
+}
+
+//ParseChaincodeCall parses a call of the form name[:function[:collection,...]]
+func ParseChaincodeCall(arg string) (*pb.ChaincodeCall, error) {
+	parts := strings.SplitN(arg, ":", 3)
+	if parts[0] == "" {
+		return nil, fmt.Errorf("Invalid call %s: no %s name\n", arg, chainFuncName)
+	}
+
+	call := &pb.ChaincodeCall{Name: parts[0]}
+	if len(parts) > 1 {
+		call.Function = parts[1]
+	}
+	if len(parts) > 2 && parts[2] != "" {
+		call.Collections = strings.Split(parts[2], ",")
+	}
+	return call, nil
+}
+
+// chaincodeLayouts prints the endorsement layouts of the calls, one per line.
+func chaincodeLayouts(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("Must supply at least one %s call\n", chainFuncName)
+	}
+
+	req := &pb.EndorsementLayoutsRequest{ChainID: chainID}
+	for _, arg := range args {
+		call, err := ParseChaincodeCall(arg)
+		if err != nil {
+			return err
+		}
+		req.Calls = append(req.Calls, call)
+	}
+
+	endorserClient, err := common.GetEndorserClient(cmd)
+	if err != nil {
+		return fmt.Errorf("Error getting endorser client %s: %s", chainFuncName, err)
+	}
+
+	resp, err := endorserClient.GetEndorsementLayouts(context.Background(), req)
+	if err != nil {
+		return fmt.Errorf("Error getting endorsement layouts: %s\n", err)
+	}
+	if len(resp.Layouts) == 0 {
+		return fmt.Errorf("No organizations can endorse the calls\n")
+	}
+
+	for _, layout := range resp.Layouts {
+		var orgs []string
+		for _, org := range layout.Organizations {
+			orgs = append(orgs, fmt.Sprintf("%s (%s)", org.Organization, strings.Join(org.Peers, ", ")))
+		}
+		fmt.Println(strings.Join(orgs, ", "))
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chaincode
+
+import (
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+
+	"github.com/hyperledger/fabric/peer/common"
+	pb "github.com/hyperledger/fabric/protos"
+	"github.com/spf13/cobra"
+)
+
+// Variables for listing the chaincodes.
+var (
+	chaincodeListInstalled    bool
+	chaincodeListInstantiated bool
+)
+
+func listCmd() *cobra.Command {
+	flags := chaincodeListCmd.Flags()
+
+	flags.BoolVar(&chaincodeListInstalled, "installed", false,
+		fmt.Sprintf("List the %ss installed on the peer", chainFuncName))
+	flags.BoolVar(&chaincodeListInstantiated, "instantiated", false,
+		fmt.Sprintf("List the %ss instantiated on the chain", chainFuncName))
+
+	return chaincodeListCmd
+}
+
+var chaincodeListCmd = &cobra.Command{
+	Use:   "list",
+	Short: fmt.Sprintf("List the %ss installed on the peer or instantiated on a chain.", chainFuncName),
+	Long:  fmt.Sprintf(`List the name, version, path, package hash and endorsement policy of the %ss installed on the peer (--installed) or instantiated on a chain (--instantiated).`, chainFuncName),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return chaincodeList(cmd)
+	},
+}
+
+//list gets the chaincodes from lccc via Endorser
+func list(cmd *cobra.Command) (*pb.ChaincodeQueryResponse, error) {
+	var args [][]byte
+	switch {
+	case chaincodeListInstalled && chaincodeListInstantiated:
+		return nil, fmt.Errorf("Options --installed and --instantiated are not compatible\n")
+	case chaincodeListInstalled:
+		args = [][]byte{[]byte("getinstalledchaincodes")}
+	case chaincodeListInstantiated:
+		args = [][]byte{[]byte("getchaincodes"), []byte(chainID)}
+	default:
+		return nil, fmt.Errorf("Must supply --installed or --instantiated\n")
+	}
+
+	endorserClient, err := common.GetEndorserClient(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting endorser client %s: %s", chainFuncName, err)
+	}
+
+	lcccSpec := &pb.ChaincodeInvocationSpec{ChaincodeSpec: &pb.ChaincodeSpec{Type: pb.ChaincodeSpec_GOLANG, ChaincodeID: &pb.ChaincodeID{Name: "lccc"}, CtorMsg: &pb.ChaincodeInput{Args: args}}}
+
+	identity, err := common.GetIdentity()
+	if err != nil {
+		return nil, fmt.Errorf("Error getting identity %s: %s\n", chainFuncName, err)
+	}
+
+	prop, err := getProposal(lcccSpec, identity.Cert, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating proposal  %s: %s\n", chainFuncName, err)
+	}
+
+	proposalResponse, err := processProposal(context.Background(), endorserClient, identity, prop)
+	if err != nil {
+		return nil, fmt.Errorf("Error endorsing %s: %s\n", chainFuncName, err)
+	}
+	if err = checkProposalResponse(proposalResponse); err != nil {
+		return nil, fmt.Errorf("Error listing %s: %s\n", chainFuncName, err)
+	}
+
+	resp := &pb.ChaincodeQueryResponse{}
+	if err = proto.Unmarshal(proposalResponse.Response.Payload, resp); err != nil {
+		return nil, fmt.Errorf("Error reading the %ss listed: %s\n", chainFuncName, err)
+	}
+
+	return resp, nil
+}
+
+// chaincodeList prints the chaincodes, one per line. Nothing is sent to the
+// orderer.
+func chaincodeList(cmd *cobra.Command) error {
+	resp, err := list(cmd)
+	if err != nil {
+		return err
+	}
+
+	for _, cc := range resp.Chaincodes {
+		fmt.Printf("Name: %s, Version: %s, Path: %s, Hash: %x", cc.Name, cc.Version, cc.Path, cc.Hash)
+		if chaincodeListInstantiated {
+			fmt.Printf(", Policy: %s, Escc: %s, Vscc: %s", string(cc.EndorsementPolicy), cc.Escc, cc.Vscc)
+		}
+		fmt.Println()
+	}
+
+	return nil
+}
@@ -26,12 +26,17 @@ import (
 	"fmt"
 
 	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/core/tracing"
 	cb "github.com/hyperledger/fabric/protos/common"
 	ab "github.com/hyperledger/fabric/protos/orderer"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
 )
 
+// defaultChainID is the chain used when none is given with --chainID, which
+// the orderer orders as its system chain
+const defaultChainID = "default"
+
 type broadcastClient struct {
 	client ab.AtomicBroadcast_BroadcastClient
 }
@@ -41,28 +46,37 @@ func newBroadcastClient(client ab.AtomicBroadcast_BroadcastClient) *broadcastCli
 	return &broadcastClient{client: client}
 }
 
-func (s *broadcastClient) broadcast(transaction []byte) error {
-	payload, err := proto.Marshal(&cb.Payload{Header: &cb.Header{ChainHeader: &cb.ChainHeader{Type: int32(cb.HeaderType_ENDORSER_TRANSACTION)}}, Data: transaction})
+func (s *broadcastClient) broadcast(chainID string, transaction []byte) error {
+	chainHeader := &cb.ChainHeader{Type: int32(cb.HeaderType_ENDORSER_TRANSACTION)}
+	if chainID != defaultChainID {
+		chainHeader.ChainID = []byte(chainID)
+	}
+	payload, err := proto.Marshal(&cb.Payload{Header: &cb.Header{ChainHeader: chainHeader}, Data: transaction})
 	if err != nil {
 		return fmt.Errorf("Unable to marshal: %s", err)
 	}
 	return s.client.Send(&cb.Envelope{Payload: payload})
 }
 
-//Send data to solo orderer
-func Send(serverAddr string, data []byte) error {
+//Send data to solo orderer for ordering on the given chain, the broadcast is
+//traced as part of the span of ctx if any
+func Send(ctx context.Context, serverAddr string, chainID string, data []byte) (err error) {
+	span, ctx := tracing.StartSpan(ctx, "peer.chaincode.broadcast")
+	span.SetTag("orderer", serverAddr)
+	defer func() { span.Finish(err) }()
+
 	conn, err := grpc.Dial(serverAddr, grpc.WithInsecure())
 	defer conn.Close()
 	if err != nil {
 		return fmt.Errorf("Error connecting: %s", err)
 	}
-	client, err := ab.NewAtomicBroadcastClient(conn).Broadcast(context.TODO())
+	client, err := ab.NewAtomicBroadcastClient(conn).Broadcast(tracing.OutgoingContext(ctx))
 	if err != nil {
 		return fmt.Errorf("Error connecting: %s", err)
 	}
 
 	s := newBroadcastClient(client)
-	s.broadcast(data)
+	s.broadcast(chainID, data)
 
 	return nil
 }
@@ -0,0 +1,78 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chaincode
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+
+	"golang.org/x/net/context"
+
+	"github.com/hyperledger/fabric/core/chaincode/ccpackage"
+	"github.com/spf13/cobra"
+)
+
+func packageCmd() *cobra.Command {
+	flags := chaincodePackageCmd.Flags()
+	flags.StringVar(&chaincodePackageLabel, "label", "",
+		"Label of the package, <name>_<version> if not set")
+	flags.StringSliceVarP(&chaincodeInstantiationPolicy, "instantiation-policy", "i", nil,
+		fmt.Sprintf("Files with the identities permitted to instantiate or upgrade the %s, everyone if not set", chainFuncName))
+
+	return chaincodePackageCmd
+}
+
+var chaincodePackageCmd = &cobra.Command{
+	Use:   "package <outputfile>",
+	Short: fmt.Sprintf("Package the specified %s into an archive.", chainFuncName),
+	Long: fmt.Sprintf(`Package the specified %s into an archive holding its code and the metadata describing it (type, label, name, version, path and instantiation policy).
+The archive can be distributed to other organizations, signed by the owners (see signpackage) and installed (see install).`, chainFuncName),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return chaincodePackage(cmd, args)
+	},
+}
+
+// chaincodePackage writes the package archive of the chaincode at the path.
+// Nothing is sent to the peer.
+func chaincodePackage(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("Must supply the output file.\n")
+	}
+
+	spkg, err := getPackage(context.Background(), cmd)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(args[0])
+	if err != nil {
+		return fmt.Errorf("Error creating package: %s", err)
+	}
+	defer f.Close()
+
+	if err = ccpackage.WriteArchive(f, spkg, chaincodePackageLabel); err != nil {
+		return fmt.Errorf("Error writing package: %s", err)
+	}
+
+	cds, err := ccpackage.GetDeploymentSpec(spkg)
+	if err != nil {
+		return err
+	}
+	logger.Infof("Package written to %s, package hash %s", args[0], hex.EncodeToString(ccpackage.GetPackageHash(cds)))
+	return nil
+}
@@ -0,0 +1,232 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chaincode
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/hyperledger/fabric/peer/common"
+	"github.com/spf13/cobra"
+)
+
+func scaffoldCmd() *cobra.Command {
+	chaincodeScaffoldCmd.Flags().StringVar(&chaincodeScaffoldDir, "dir", "",
+		fmt.Sprintf("Directory the %s is generated in, a directory named after it in the current directory if not set", chainFuncName))
+
+	return chaincodeScaffoldCmd
+}
+
+// Directory a new chaincode is generated in.
+var chaincodeScaffoldDir string
+
+var chaincodeScaffoldCmd = &cobra.Command{
+	Use:   "scaffold",
+	Short: fmt.Sprintf("Generate the skeleton of a new Go %s.", chainFuncName),
+	Long: fmt.Sprintf(`Generate the skeleton of a new Go %s: a contract routed to by function name, a unit test
+running it on a mock stub and the metadata to package it with. The directory must not exist yet.`, chainFuncName),
+	ValidArgs: []string{"1"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return chaincodeScaffold(cmd, args)
+	},
+}
+
+var scaffoldNameRegexp = regexp.MustCompile("^[a-zA-Z0-9]+([-_][a-zA-Z0-9]+)*$")
+
+// scaffoldParams are the values the files of a new chaincode are generated from
+type scaffoldParams struct {
+	Name    string
+	Version string
+	Path    string
+}
+
+var scaffoldTemplates = []struct {
+	file     string
+	template *template.Template
+}{
+	{"chaincode.go", template.Must(template.New("chaincode.go").Parse(`package main
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/core/chaincode/shim/contract"
+)
+
+var logger = shim.NewLogger("{{.Name}}")
+
+// Contract of the {{.Name}} chaincode. Its exported methods taking a stub are
+// the functions of the chaincode, see the documentation of package contract.
+type Contract struct{}
+
+// Init is called with the parameters of the deploy transaction
+func (c *Contract) Init(stub shim.ChaincodeStubInterface) error {
+	logger.Infof("Initializing {{.Name}} in transaction %s", stub.GetTxID())
+	return nil
+}
+
+// Put stores the value of the key
+func (c *Contract) Put(stub shim.ChaincodeStubInterface, key string, value string) error {
+	return stub.PutState(key, []byte(value))
+}
+
+// Get returns the value of the key
+func (c *Contract) Get(stub shim.ChaincodeStubInterface, key string) (string, error) {
+	value, err := stub.GetState(key)
+	if err != nil {
+		return "", err
+	}
+	if value == nil {
+		return "", fmt.Errorf("Key %s not found", key)
+	}
+	return string(value), nil
+}
+
+func main() {
+	router, err := contract.NewRouter(&Contract{})
+	if err != nil {
+		fmt.Printf("Error creating the contract router: %s", err)
+		return
+	}
+	if err = shim.Start(router); err != nil {
+		fmt.Printf("Error starting {{.Name}} chaincode: %s", err)
+	}
+}
+`))},
+	{"chaincode_test.go", template.Must(template.New("chaincode_test.go").Parse(`package main
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/core/chaincode/shim/contract"
+)
+
+func args(function string, params ...string) [][]byte {
+	bytes := [][]byte{[]byte(function)}
+	for _, param := range params {
+		bytes = append(bytes, []byte(param))
+	}
+	return bytes
+}
+
+func newStub(t *testing.T) *shim.MockStub {
+	router, err := contract.NewRouter(&Contract{})
+	if err != nil {
+		t.Fatalf("Error creating the contract router: %s", err)
+	}
+	stub := shim.NewMockStub("{{.Name}}", router)
+	if _, err = stub.MockInit("init", args("init")); err != nil {
+		t.Fatalf("Init failed: %s", err)
+	}
+	return stub
+}
+
+func TestPutGet(t *testing.T) {
+	stub := newStub(t)
+	if _, err := stub.MockInvoke("1", args("put", "key", "value")); err != nil {
+		t.Fatalf("Put failed: %s", err)
+	}
+	value, err := stub.MockQuery(args("get", "key"))
+	if err != nil || string(value) != "value" {
+		t.Fatalf("Unexpected value %s, error %v", value, err)
+	}
+	if _, err = stub.MockQuery(args("get", "missing")); err == nil {
+		t.Fatalf("Expected an error getting a missing key")
+	}
+}
+`))},
+	{"chaincode.yaml", template.Must(template.New("chaincode.yaml").Parse(`# Packaging metadata of the {{.Name}} chaincode. Test, install and
+# instantiate it with
+#   go test {{.Path}}
+#   peer chaincode install -n {{.Name}} -V {{.Version}} -p {{.Path}}
+#   peer chaincode instantiate -n {{.Name}} -V {{.Version}} -p {{.Path}} -c '{"Args":["init"]}'
+name: {{.Name}}
+version: {{.Version}}
+language: golang
+path: {{.Path}}
+`))},
+}
+
+// scaffoldImportPath returns the import path of the directory if it is in the
+// GOPATH, the path chaincodes are packaged by, and the directory otherwise
+func scaffoldImportPath(dir string) string {
+	for _, gopath := range filepath.SplitList(os.Getenv("GOPATH")) {
+		src := filepath.Join(gopath, "src") + string(filepath.Separator)
+		if strings.HasPrefix(dir, src) {
+			return filepath.ToSlash(strings.TrimPrefix(dir, src))
+		}
+	}
+	return dir
+}
+
+// scaffold generates the files of a new chaincode in dir, which must not exist
+func scaffold(dir string, params *scaffoldParams) error {
+	if _, err := os.Stat(dir); err == nil {
+		return fmt.Errorf("%s already exists", dir)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("Error creating %s: %s", dir, err)
+	}
+	for _, t := range scaffoldTemplates {
+		var buf bytes.Buffer
+		if err := t.template.Execute(&buf, params); err != nil {
+			return fmt.Errorf("Error generating %s: %s", t.file, err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, t.file), buf.Bytes(), 0644); err != nil {
+			return fmt.Errorf("Error writing %s: %s", t.file, err)
+		}
+	}
+	return nil
+}
+
+// chaincodeScaffold generates a new chaincode named by the name parameter.
+func chaincodeScaffold(cmd *cobra.Command, args []string) error {
+	if chaincodeName == common.UndefinedParamValue || !scaffoldNameRegexp.MatchString(chaincodeName) {
+		return fmt.Errorf("Must supply a name made of letters, digits, - and _ for the %s.\n", chainFuncName)
+	}
+	if chaincodeLang != "golang" {
+		return fmt.Errorf("Only Go %ss can be generated, not %s.\n", chainFuncName, chaincodeLang)
+	}
+
+	dir := chaincodeScaffoldDir
+	if dir == "" {
+		dir = chaincodeName
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+
+	version := chaincodeVersion
+	if version == "" {
+		version = "1.0"
+	}
+	params := &scaffoldParams{Name: chaincodeName, Version: version, Path: scaffoldImportPath(dir)}
+	if err = scaffold(dir, params); err != nil {
+		return err
+	}
+
+	logger.Infof("Generated %s %s in %s", chainFuncName, chaincodeName, dir)
+	return nil
+}
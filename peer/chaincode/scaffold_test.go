@@ -0,0 +1,69 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chaincode
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScaffold(t *testing.T) {
+	require := require.New(t)
+	tmp, err := ioutil.TempDir("", "scaffold")
+	require.NoError(err)
+	defer os.RemoveAll(tmp)
+
+	dir := filepath.Join(tmp, "mycc")
+	params := &scaffoldParams{Name: "mycc", Version: "1.0", Path: "example.com/mycc"}
+	require.NoError(scaffold(dir, params))
+
+	for _, file := range []string{"chaincode.go", "chaincode_test.go", "chaincode.yaml"} {
+		content, err := ioutil.ReadFile(filepath.Join(dir, file))
+		require.NoError(err, file)
+		require.Contains(string(content), "mycc", file)
+	}
+	metadata, err := ioutil.ReadFile(filepath.Join(dir, "chaincode.yaml"))
+	require.NoError(err)
+	require.Contains(string(metadata), "path: example.com/mycc")
+
+	require.Error(scaffold(dir, params), "Existing directories must not be overwritten")
+}
+
+func TestScaffoldImportPath(t *testing.T) {
+	gopath := os.Getenv("GOPATH")
+	defer os.Setenv("GOPATH", gopath)
+	os.Setenv("GOPATH", strings.Join([]string{"/a", "/b"}, string(filepath.ListSeparator)))
+
+	require.Equal(t, "example.com/mycc", scaffoldImportPath("/b/src/example.com/mycc"))
+	require.Equal(t, "/c/src/mycc", scaffoldImportPath("/c/src/mycc"))
+}
+
+func TestChaincodeScaffoldInvalidParams(t *testing.T) {
+	chaincodeLang = "golang"
+	chaincodeName = "bad name"
+	require.Error(t, chaincodeScaffold(nil, nil))
+
+	chaincodeName = "mycc"
+	chaincodeLang = "java"
+	require.Error(t, chaincodeScaffold(nil, nil))
+	chaincodeLang = "golang"
+}
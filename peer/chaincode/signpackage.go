@@ -0,0 +1,221 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chaincode
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+
+	"golang.org/x/net/context"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/core"
+	"github.com/hyperledger/fabric/core/chaincode/ccpackage"
+	"github.com/hyperledger/fabric/core/crypto/primitives"
+	pb "github.com/hyperledger/fabric/protos"
+	"github.com/spf13/cobra"
+)
+
+func signPackageCmd() *cobra.Command {
+	flags := chaincodeSignPackageCmd.Flags()
+	flags.StringVar(&chaincodePackageFile, "package", "",
+		fmt.Sprintf("File with a %s package to add the signature to, the path is packaged if not set", chainFuncName))
+	flags.StringVar(&chaincodePackageOut, "out", "",
+		"File the signed package is written to")
+	flags.StringVar(&chaincodeOwnerCert, "owner-cert", "",
+		"File with the PEM encoded certificate of the owner signing the package")
+	flags.StringVar(&chaincodeOwnerKey, "owner-key", "",
+		"File with the PEM encoded private key of the owner signing the package")
+	flags.StringSliceVarP(&chaincodeInstantiationPolicy, "instantiation-policy", "i", nil,
+		fmt.Sprintf("Files with the identities permitted to instantiate or upgrade the %s of a new package, everyone if not set", chainFuncName))
+	flags.StringVar(&chaincodePackageLabel, "label", "",
+		"Label of a new package, <name>_<version> if not set")
+
+	return chaincodeSignPackageCmd
+}
+
+// Variables for chaincode packages signed by their owners.
+var (
+	chaincodePackageFile string
+	chaincodePackageOut  string
+	chaincodeOwnerCert   string
+	chaincodeOwnerKey    string
+
+	chaincodeInstantiationPolicy []string
+	chaincodePackageLabel        string
+)
+
+var chaincodeSignPackageCmd = &cobra.Command{
+	Use:   "signpackage",
+	Short: fmt.Sprintf("Sign a %s package as one of its owners.", chainFuncName),
+	Long: fmt.Sprintf(`Sign a %s package as one of its owners. Peers trusting the owner install the signed package.
+The package is created from the path unless an already signed package is given, so the owners can sign in turn.
+New packages are written as archives (see package), a package in the former format is written in that format.`, chainFuncName),
+	ValidArgs: []string{"1"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return chaincodeSignPackage(cmd, args)
+	},
+}
+
+//readPackage reads a package archive written by package or signpackage, or
+//a marshalled package of the former format. The metadata is nil for the
+//latter
+func readPackage(file string) (*pb.SignedChaincodeDeploymentSpec, *ccpackage.Metadata, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error reading package: %s", err)
+	}
+
+	if ccpackage.IsArchive(b) {
+		spkg, md, err := ccpackage.ReadArchive(bytes.NewReader(b))
+		if err != nil {
+			return nil, nil, fmt.Errorf("Invalid package %s: %s", file, err)
+		}
+		return spkg, md, nil
+	}
+
+	spkg := &pb.SignedChaincodeDeploymentSpec{}
+	if err = proto.Unmarshal(b, spkg); err != nil {
+		return nil, nil, fmt.Errorf("Invalid package %s: %s", file, err)
+	}
+
+	return spkg, nil, nil
+}
+
+//writePackage writes the package as an archive with the given label, or
+//marshalled in the former format if there is no metadata
+func writePackage(file string, spkg *pb.SignedChaincodeDeploymentSpec, md *ccpackage.Metadata) error {
+	var b []byte
+	var err error
+	if md != nil {
+		buf := &bytes.Buffer{}
+		if err = ccpackage.WriteArchive(buf, spkg, md.Label); err != nil {
+			return err
+		}
+		b = buf.Bytes()
+	} else if b, err = proto.Marshal(spkg); err != nil {
+		return err
+	}
+
+	if err = ioutil.WriteFile(file, b, 0644); err != nil {
+		return fmt.Errorf("Error writing package: %s", err)
+	}
+	return nil
+}
+
+//packageHash returns the hex encoded hash of the code in the package. It is
+//the hash lccc records (gethash), organizations compare it to verify they
+//installed the same code
+func packageHash(spkg *pb.SignedChaincodeDeploymentSpec) (string, error) {
+	cds, err := ccpackage.GetDeploymentSpec(spkg)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(ccpackage.GetPackageHash(cds)), nil
+}
+
+//getPackage packages the chaincode at the path with the instantiation
+//policy, the package carries no signature. The constructor message and the
+//other settings of the spec that are given at instantiation are not packaged
+func getPackage(ctxt context.Context, cmd *cobra.Command) (*pb.SignedChaincodeDeploymentSpec, error) {
+	spec, err := getChaincodeSpecification(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	cds, err := core.GetChaincodeBytes(ctxt, spec)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting chaincode code %s: %s", chainFuncName, err)
+	}
+
+	var identities [][]byte
+	for _, file := range chaincodeInstantiationPolicy {
+		identity, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading identity of the instantiation policy: %s", err)
+		}
+		identities = append(identities, bytes.TrimSpace(identity))
+	}
+
+	policy, err := ccpackage.NewInstantiationPolicy(identities)
+	if err != nil {
+		return nil, err
+	}
+
+	return ccpackage.NewSignedPackage(ccpackage.NewPackageSpec(cds), policy)
+}
+
+// chaincodeSignPackage adds the signature of the owner to the package and
+// writes the signed package.
+func chaincodeSignPackage(cmd *cobra.Command, args []string) error {
+	if chaincodePackageOut == "" || chaincodeOwnerCert == "" || chaincodeOwnerKey == "" {
+		return fmt.Errorf("Must supply values for the out, owner-cert and owner-key parameters.\n")
+	}
+
+	if err := primitives.InitSecurityLevel("SHA2", 256); err != nil {
+		return err
+	}
+
+	var spkg *pb.SignedChaincodeDeploymentSpec
+	var md *ccpackage.Metadata
+	var err error
+	if chaincodePackageFile != "" {
+		//the signatures already on the package cover its instantiation policy
+		if len(chaincodeInstantiationPolicy) > 0 || chaincodePackageLabel != "" {
+			return fmt.Errorf("The instantiation policy and the label can only be set on a new package.\n")
+		}
+		spkg, md, err = readPackage(chaincodePackageFile)
+	} else if spkg, err = getPackage(context.Background(), cmd); err == nil {
+		md, err = ccpackage.GetMetadata(spkg, chaincodePackageLabel)
+	}
+	if err != nil {
+		return err
+	}
+
+	cert, err := ioutil.ReadFile(chaincodeOwnerCert)
+	if err != nil {
+		return fmt.Errorf("Error reading owner certificate: %s", err)
+	}
+
+	rawKey, err := ioutil.ReadFile(chaincodeOwnerKey)
+	if err != nil {
+		return fmt.Errorf("Error reading owner key: %s", err)
+	}
+
+	key, err := primitives.PEMtoPrivateKey(rawKey, nil)
+	if err != nil {
+		return fmt.Errorf("Invalid owner key: %s", err)
+	}
+
+	if err = ccpackage.Sign(spkg, cert, key); err != nil {
+		return fmt.Errorf("Error signing package: %s", err)
+	}
+
+	if err = writePackage(chaincodePackageOut, spkg, md); err != nil {
+		return err
+	}
+
+	hash, err := packageHash(spkg)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("Package signed by %d owners written to %s, package hash %s", len(spkg.OwnerEndorsements), chaincodePackageOut, hash)
+	return nil
+}
@@ -0,0 +1,41 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chaincode
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func upgradeCmd() *cobra.Command {
+	chaincodeUpgradeCmd.Flags().StringVarP(&chaincodePolicy, "policy", "P", "",
+		fmt.Sprintf("Endorsement policy of the %s", chainFuncName))
+	addSystemChaincodeFlags(chaincodeUpgradeCmd.Flags())
+
+	return chaincodeUpgradeCmd
+}
+
+var chaincodeUpgradeCmd = &cobra.Command{
+	Use:       "upgrade",
+	Short:     fmt.Sprintf("Upgrade the specified %s to another installed version.", chainFuncName),
+	Long:      fmt.Sprintf(`Upgrade the specified %s on the chain to another installed version, replacing its constructor message and policy.`, chainFuncName),
+	ValidArgs: []string{"1"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return chaincodeActivate(cmd, "upgrade")
+	},
+}
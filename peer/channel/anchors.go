@@ -0,0 +1,233 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package channel
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/core/crypto/primitives"
+	"github.com/hyperledger/fabric/orderer/common/cauthdsl"
+	"github.com/hyperledger/fabric/orderer/common/configtx"
+	pb "github.com/hyperledger/fabric/protos"
+	cb "github.com/hyperledger/fabric/protos/common"
+	ab "github.com/hyperledger/fabric/protos/orderer"
+	putils "github.com/hyperledger/fabric/protos/utils"
+	"github.com/spf13/viper"
+)
+
+// anchorPeersModificationPolicyID is the policy modifying the anchor peers of
+// a chain. It requires the signature of one of the admins of the
+// organizations in "chaincode.lifecycle.organizations" when the chain is
+// created, organizations have yet to be represented in the configuration of
+// the orderer to restrict it to the admins of the organization of the anchor
+// peers.
+const anchorPeersModificationPolicyID = "AnchorPeersModificationPolicy"
+
+var anchorPeers []string
+
+// adminCert and adminKey are the files holding the PEM encoded certificate
+// and private key of the admin signing a configuration update
+var adminCert, adminKey string
+
+// parseAnchorPeers parses anchor peers given as org=host:port, grouping them
+// by organization
+func parseAnchorPeers(specs []string) (map[string]*pb.AnchorPeers, error) {
+	anchors := make(map[string]*pb.AnchorPeers)
+	for _, spec := range specs {
+		kv := strings.SplitN(spec, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("Anchor peer %s is not of the form org=host:port", spec)
+		}
+		host, portStr, err := net.SplitHostPort(kv[1])
+		if err != nil {
+			return nil, fmt.Errorf("Invalid endpoint of anchor peer %s: %s", spec, err)
+		}
+		port, err := strconv.ParseInt(portStr, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid port of anchor peer %s: %s", spec, err)
+		}
+
+		if anchors[kv[0]] == nil {
+			anchors[kv[0]] = &pb.AnchorPeers{}
+		}
+		anchors[kv[0]].AnchorPeers = append(anchors[kv[0]].AnchorPeers, &pb.AnchorPeer{Host: host, Port: int32(port)})
+	}
+	return anchors, nil
+}
+
+// createAdminsPolicy returns the signature policy satisfied by the signature
+// of one of the admins of the organizations, each a file holding its
+// identity. It is satisfied by no one when no admin is configured
+func createAdminsPolicy() (*ab.SignaturePolicyEnvelope, error) {
+	var orgs []struct {
+		Admins []string
+	}
+	if err := viper.UnmarshalKey("chaincode.lifecycle.organizations", &orgs); err != nil {
+		return nil, fmt.Errorf("Error reading chaincode.lifecycle.organizations: %s", err)
+	}
+
+	var identities [][]byte
+	var signers []*ab.SignaturePolicy
+	for _, org := range orgs {
+		for _, file := range org.Admins {
+			identity, err := ioutil.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("Error reading admin identity %s: %s", file, err)
+			}
+			signers = append(signers, cauthdsl.SignedBy(int32(len(identities))))
+			identities = append(identities, bytes.TrimSpace(identity))
+		}
+	}
+	if len(identities) == 0 {
+		logger.Warning("No admin in chaincode.lifecycle.organizations, the configuration items created can't be modified")
+	}
+	return cauthdsl.Envelope(cauthdsl.NOutOf(1, signers), identities), nil
+}
+
+// readAdmin returns the PEM encoded certificate, which identifies the admin,
+// and the private key of the admin, given the files holding them
+func readAdmin(certFile, keyFile string) ([]byte, interface{}, error) {
+	if certFile == "" || keyFile == "" {
+		return nil, nil, fmt.Errorf("Must supply the certificate and the key of an admin with --admin-cert and --admin-key")
+	}
+	if err := primitives.InitSecurityLevel("SHA2", 256); err != nil {
+		return nil, nil, err
+	}
+
+	cert, err := ioutil.ReadFile(certFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error reading admin certificate: %s", err)
+	}
+	cert = bytes.TrimSpace(cert)
+	rawKey, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error reading admin key: %s", err)
+	}
+	key, err := primitives.PEMtoPrivateKey(rawKey, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Invalid admin key: %s", err)
+	}
+	x509Cert, err := primitives.PEMtoCertificate(cert)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Invalid admin certificate: %s", err)
+	}
+	if err = primitives.CheckCertPKAgainstSK(x509Cert, key); err != nil {
+		return nil, nil, fmt.Errorf("The key does not match the admin certificate: %s", err)
+	}
+	return cert, key, nil
+}
+
+// signModifiedItems adds the signature of the admin, given the files holding
+// its PEM encoded certificate and private key, to the items modified by the
+// configuration
+func signModifiedItems(config *ab.ConfigurationEnvelope, certFile, keyFile string) error {
+	cert, key, err := readAdmin(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+
+	header, err := proto.Marshal(&cb.SignatureHeader{Creator: cert})
+	if err != nil {
+		return err
+	}
+	for _, signedItem := range config.Items {
+		item := &ab.ConfigurationItem{}
+		if err = proto.Unmarshal(signedItem.ConfigurationItem, item); err != nil {
+			return fmt.Errorf("Invalid configuration item: %s", err)
+		}
+		if item.LastModified != config.Sequence {
+			continue
+		}
+		signature, err := primitives.ECDSASign(key, signedItem.ConfigurationItem)
+		if err != nil {
+			return err
+		}
+		signedItem.Signatures = append(signedItem.Signatures, &ab.ConfigurationSignature{SignatureHeader: header, Signature: signature})
+	}
+	return nil
+}
+
+// createAnchorPeersPolicyItem creates the configuration item of the policy
+// modifying the anchor peers of a chain
+func createAnchorPeersPolicyItem(chainID []byte) (*ab.SignedConfigurationItem, error) {
+	admins, err := createAdminsPolicy()
+	if err != nil {
+		return nil, err
+	}
+	policy, err := proto.Marshal(&ab.Policy{
+		Type: &ab.Policy_SignaturePolicy{
+			SignaturePolicy: admins,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := proto.Marshal(&ab.ConfigurationItem{
+		Header:             &cb.ChainHeader{ChainID: chainID},
+		Type:               ab.ConfigurationItem_Policy,
+		ModificationPolicy: configtx.DefaultModificationPolicyID,
+		Key:                anchorPeersModificationPolicyID,
+		Value:              policy,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ab.SignedConfigurationItem{ConfigurationItem: item}, nil
+}
+
+// setAnchorPeers returns the configuration of the given sequence which
+// declares the anchor peers of the given organizations, keeping the other
+// items of the configuration
+func setAnchorPeers(config *ab.ConfigurationEnvelope, anchors map[string]*pb.AnchorPeers, sequence uint64) (*ab.ConfigurationEnvelope, error) {
+	updated := &ab.ConfigurationEnvelope{
+		ChainID:  config.ChainID,
+		Sequence: sequence,
+	}
+	for _, signedItem := range config.Items {
+		item := &ab.ConfigurationItem{}
+		if err := proto.Unmarshal(signedItem.ConfigurationItem, item); err != nil {
+			return nil, fmt.Errorf("Invalid configuration item: %s", err)
+		}
+		if item.Type == ab.ConfigurationItem_Fabric && strings.HasPrefix(item.Key, putils.AnchorPeersKeyPrefix) {
+			if _, ok := anchors[strings.TrimPrefix(item.Key, putils.AnchorPeersKeyPrefix)]; ok {
+				continue
+			}
+		}
+		updated.Items = append(updated.Items, signedItem)
+	}
+
+	orgs := make([]string, 0, len(anchors))
+	for org := range anchors {
+		orgs = append(orgs, org)
+	}
+	sort.Strings(orgs)
+	for _, org := range orgs {
+		item, err := putils.CreateAnchorPeersItem(config.ChainID, org, anchors[org], sequence, anchorPeersModificationPolicyID)
+		if err != nil {
+			return nil, err
+		}
+		updated.Items = append(updated.Items, item)
+	}
+	return updated, nil
+}
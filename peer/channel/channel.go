@@ -0,0 +1,89 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package channel
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/net/context"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/peer/common"
+	pb "github.com/hyperledger/fabric/protos"
+	putils "github.com/hyperledger/fabric/protos/utils"
+	"github.com/op/go-logging"
+	"github.com/spf13/cobra"
+)
+
+const channelFuncName = "channel"
+
+var logger = logging.MustGetLogger("channelCmd")
+
+// Cmd returns the cobra command for Channel
+func Cmd() *cobra.Command {
+	channelCmd.AddCommand(createCmd())
+	channelCmd.AddCommand(joinCmd())
+	channelCmd.AddCommand(listCmd())
+	channelCmd.AddCommand(updateAnchorsCmd())
+	channelCmd.AddCommand(updateOrderersCmd())
+
+	return channelCmd
+}
+
+var channelCmd = &cobra.Command{
+	Use:   channelFuncName,
+	Short: fmt.Sprintf("%s specific commands.", channelFuncName),
+	Long:  fmt.Sprintf("%s specific commands.", channelFuncName),
+}
+
+// invokeCSCC calls the configuration system chaincode of the peer via
+// Endorser and returns the payload of its response
+func invokeCSCC(cmd *cobra.Command, args [][]byte) ([]byte, error) {
+	endorserClient, err := common.GetEndorserClient(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting endorser client %s: %s", channelFuncName, err)
+	}
+
+	csccSpec := &pb.ChaincodeInvocationSpec{ChaincodeSpec: &pb.ChaincodeSpec{Type: pb.ChaincodeSpec_GOLANG, ChaincodeID: &pb.ChaincodeID{Name: "cscc"}, CtorMsg: &pb.ChaincodeInput{Args: args}}}
+
+	identity, err := common.GetIdentity()
+	if err != nil {
+		return nil, fmt.Errorf("Error getting identity %s: %s", channelFuncName, err)
+	}
+
+	prop, err := putils.CreateChaincodeProposal(csccSpec, identity.Cert)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating proposal %s: %s", channelFuncName, err)
+	}
+	signedProp, err := identity.Sign(prop)
+	if err != nil {
+		return nil, fmt.Errorf("Error signing proposal %s: %s", channelFuncName, err)
+	}
+
+	presp, err := endorserClient.ProcessProposal(context.Background(), signedProp)
+	if err != nil {
+		return nil, fmt.Errorf("Error endorsing %s: %s", channelFuncName, err)
+	}
+	if presp == nil || presp.Response == nil {
+		return nil, errors.New("Proposal response has no response")
+	}
+	if presp.Response.Status >= shim.ERRORTHRESHOLD {
+		return nil, fmt.Errorf("%s failed with status %d: %s", args[0], presp.Response.Status, presp.Response.Message)
+	}
+
+	return presp.Response.Payload, nil
+}
@@ -0,0 +1,176 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package channel
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/core/crypto/primitives"
+	"github.com/hyperledger/fabric/orderer/common/bootstrap/static"
+	cb "github.com/hyperledger/fabric/protos/common"
+	ab "github.com/hyperledger/fabric/protos/orderer"
+	"github.com/spf13/cobra"
+)
+
+var (
+	chainID         string
+	outputBlockPath string
+)
+
+func createCmd() *cobra.Command {
+	flags := channelCreateCmd.Flags()
+	flags.StringVarP(&chainID, "chainID", "c", "", "Name of the chain to create")
+	flags.StringVarP(&ordererEndpoint, "orderer", "o", "",
+		"Address of the orderer, peer.committer.ledger.orderer when not set")
+	flags.StringSliceVarP(&anchorPeers, "anchorpeers", "a", nil,
+		"Anchor peers of the organizations of the chain, as org=host:port")
+	flags.StringSliceVar(&ordererAddresses, "ordereraddresses", nil,
+		"Addresses of the ordering service the peers pull the blocks of the chain from, as host:port")
+	flags.StringVarP(&outputBlockPath, "file", "f", "",
+		"Path of the file to write the genesis block of the chain to, <chainID>.block when not set")
+	flags.StringVar(&adminCert, "admin-cert", "", "PEM encoded certificate of the chain creator signing the creation")
+	flags.StringVar(&adminKey, "admin-key", "", "PEM encoded private key of the chain creator signing the creation")
+
+	return channelCreateCmd
+}
+
+var channelCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Creates a chain.",
+	Long:  `Sends the configuration transaction of a new chain to the orderer and writes the genesis block of the chain, which peers join the chain with.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return create()
+	},
+}
+
+// createChainConfiguration returns the initial configuration of a chain,
+// declaring the anchor peers of its organizations and the addresses of its
+// ordering service, if any
+func createChainConfiguration(chainID string, anchors, orderers []string) ([]byte, error) {
+	genesisBlock, err := static.NewForChain([]byte(chainID)).GenesisBlock()
+	if err != nil {
+		return nil, fmt.Errorf("Error creating the configuration of chain %s: %s", chainID, err)
+	}
+	config := &ab.ConfigurationEnvelope{}
+	if err = proto.Unmarshal(genesisBlock.Data.Data[0], config); err != nil {
+		return nil, fmt.Errorf("Error reading the configuration of chain %s: %s", chainID, err)
+	}
+
+	peers, err := parseAnchorPeers(anchors)
+	if err != nil {
+		return nil, err
+	}
+	policyItem, err := createAnchorPeersPolicyItem(config.ChainID)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating the anchor peers policy of chain %s: %s", chainID, err)
+	}
+	config.Items = append(config.Items, policyItem)
+	if config, err = setAnchorPeers(config, peers, config.Sequence); err != nil {
+		return nil, err
+	}
+
+	policyItem, err = createOrdererAddressesPolicyItem(config.ChainID)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating the orderer addresses policy of chain %s: %s", chainID, err)
+	}
+	config.Items = append(config.Items, policyItem)
+	if len(orderers) > 0 {
+		if config, err = setOrdererAddresses(config, orderers, config.Sequence); err != nil {
+			return nil, err
+		}
+	}
+
+	return proto.Marshal(config)
+}
+
+// createChainEnvelope returns the chain creation transaction of a chain
+// holding its initial configuration envelope, signed by the chain creator
+// given the files holding its PEM encoded certificate and private key as the
+// orderer only creates the chains its chain creators request
+func createChainEnvelope(chainID string, anchors, orderers []string, certFile, keyFile string) (*cb.Envelope, error) {
+	cert, key, err := readAdmin(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	config, err := createChainConfiguration(chainID, anchors, orderers)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := proto.Marshal(&cb.Payload{
+		Header: &cb.Header{
+			ChainHeader: &cb.ChainHeader{
+				Type:    int32(cb.HeaderType_CHAIN_CREATION),
+				ChainID: []byte(chainID),
+			},
+			SignatureHeader: &cb.SignatureHeader{Creator: cert},
+		},
+		Data: config,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Error marshaling the creation transaction of chain %s: %s", chainID, err)
+	}
+	signature, err := primitives.ECDSASign(key, payload)
+	if err != nil {
+		return nil, fmt.Errorf("Error signing the creation transaction of chain %s: %s", chainID, err)
+	}
+	return &cb.Envelope{Payload: payload, Signature: signature}, nil
+}
+
+func create() error {
+	if chainID == "" {
+		return errors.New("Must supply the name of the chain with --chainID")
+	}
+
+	env, err := createChainEnvelope(chainID, anchorPeers, ordererAddresses, adminCert, adminKey)
+	if err != nil {
+		return err
+	}
+
+	conn, err := connectOrderer()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	client := ab.NewAtomicBroadcastClient(conn)
+
+	if err = broadcastEnvelope(client, env); err != nil {
+		return err
+	}
+
+	block, err := getGenesisBlock(client, chainID)
+	if err != nil {
+		return err
+	}
+	b, err := proto.Marshal(block)
+	if err != nil {
+		return fmt.Errorf("Error marshaling the genesis block: %s", err)
+	}
+
+	path := outputBlockPath
+	if path == "" {
+		path = chainID + ".block"
+	}
+	if err = ioutil.WriteFile(path, b, 0644); err != nil {
+		return fmt.Errorf("Error writing the genesis block to %s: %s", path, err)
+	}
+
+	logger.Infof("Created chain %s, join peers to it with: peer channel join -b %s", chainID, path)
+	return nil
+}
@@ -0,0 +1,60 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package channel
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/spf13/cobra"
+)
+
+var genesisBlockPath string
+
+func joinCmd() *cobra.Command {
+	channelJoinCmd.Flags().StringVarP(&genesisBlockPath, "blockpath", "b", "",
+		"Path to the file holding the genesis block of the chain")
+
+	return channelJoinCmd
+}
+
+var channelJoinCmd = &cobra.Command{
+	Use:   "join",
+	Short: "Joins the peer to a chain.",
+	Long:  `Joins the peer to the chain of a genesis block, creating the ledger of the chain. Only the administrators of the peer may join it to chains.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return join(cmd)
+	},
+}
+
+func join(cmd *cobra.Command) error {
+	if genesisBlockPath == "" {
+		return errors.New("Must supply the genesis block with --blockpath")
+	}
+
+	block, err := ioutil.ReadFile(genesisBlockPath)
+	if err != nil {
+		return fmt.Errorf("Error reading the genesis block %s: %s", genesisBlockPath, err)
+	}
+
+	if _, err = invokeCSCC(cmd, [][]byte{[]byte("JoinChain"), block}); err != nil {
+		return err
+	}
+
+	logger.Infof("Peer joined the chain of %s", genesisBlockPath)
+	return nil
+}
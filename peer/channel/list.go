@@ -0,0 +1,54 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package channel
+
+import (
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	pb "github.com/hyperledger/fabric/protos"
+	"github.com/spf13/cobra"
+)
+
+func listCmd() *cobra.Command {
+	return channelListCmd
+}
+
+var channelListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists the chains the peer has joined.",
+	Long:  `Lists the chains the peer has joined.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return list(cmd)
+	},
+}
+
+func list(cmd *cobra.Command) error {
+	payload, err := invokeCSCC(cmd, [][]byte{[]byte("GetChannels")})
+	if err != nil {
+		return err
+	}
+
+	resp := &pb.ChannelQueryResponse{}
+	if err = proto.Unmarshal(payload, resp); err != nil {
+		return fmt.Errorf("Error reading the chains listed: %s", err)
+	}
+
+	for _, channel := range resp.Channels {
+		fmt.Println(channel.ChainID)
+	}
+	return nil
+}
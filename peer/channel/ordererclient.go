@@ -0,0 +1,148 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package channel
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	cb "github.com/hyperledger/fabric/protos/common"
+	ab "github.com/hyperledger/fabric/protos/orderer"
+	putils "github.com/hyperledger/fabric/protos/utils"
+	"github.com/spf13/viper"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+var ordererEndpoint string
+
+// connectOrderer connects to the orderer given with --orderer, or to the one
+// the peer commits from when not set
+func connectOrderer() (*grpc.ClientConn, error) {
+	orderer := ordererEndpoint
+	if orderer == "" {
+		orderer = viper.GetString("peer.committer.ledger.orderer")
+	}
+	if orderer == "" {
+		return nil, errors.New("Must supply the address of the orderer with --orderer")
+	}
+
+	conn, err := grpc.Dial(orderer, grpc.WithInsecure(), grpc.WithBlock(), grpc.WithTimeout(3*time.Second))
+	if err != nil {
+		return nil, fmt.Errorf("Error connecting to the orderer %s: %s", orderer, err)
+	}
+	return conn, nil
+}
+
+// broadcastEnvelope sends an envelope to the orderer and waits for it to be
+// accepted
+func broadcastEnvelope(client ab.AtomicBroadcastClient, env *cb.Envelope) error {
+	broadcast, err := client.Broadcast(context.Background())
+	if err != nil {
+		return fmt.Errorf("Error connecting to the orderer: %s", err)
+	}
+	defer broadcast.CloseSend()
+
+	if err = broadcast.Send(env); err != nil {
+		return fmt.Errorf("Error sending the transaction: %s", err)
+	}
+	resp, err := broadcast.Recv()
+	if err != nil {
+		return fmt.Errorf("Error receiving the reply of the orderer: %s", err)
+	}
+	if resp.Status != ab.Status_SUCCESS {
+		return fmt.Errorf("The orderer rejected the transaction with status %v", resp.Status)
+	}
+	return nil
+}
+
+// getBlock retrieves a block of a chain from the orderer
+func getBlock(deliver ab.AtomicBroadcast_DeliverClient, chainID string, start ab.SeekInfo_StartType, number uint64) (*cb.Block, error) {
+	err := deliver.Send(&ab.DeliverUpdate{
+		Type: &ab.DeliverUpdate_Seek{
+			Seek: &ab.SeekInfo{
+				Start:           start,
+				SpecifiedNumber: number,
+				WindowSize:      1,
+				ChainID:         []byte(chainID),
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Error requesting a block: %s", err)
+	}
+
+	resp, err := deliver.Recv()
+	if err != nil {
+		return nil, fmt.Errorf("Error receiving a block: %s", err)
+	}
+	block := resp.GetBlock()
+	if block == nil {
+		return nil, fmt.Errorf("The orderer could not deliver the block, status %v", resp.GetError())
+	}
+	return block, nil
+}
+
+// getGenesisBlock retrieves the genesis block of a chain from the orderer
+func getGenesisBlock(client ab.AtomicBroadcastClient, chainID string) (*cb.Block, error) {
+	deliver, err := client.Deliver(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("Error connecting to the orderer: %s", err)
+	}
+	defer deliver.CloseSend()
+
+	block, err := getBlock(deliver, chainID, ab.SeekInfo_OLDEST, 0)
+	if err != nil {
+		return nil, err
+	}
+	if block.Header == nil || block.Header.Number != 0 {
+		return nil, errors.New("The orderer delivered a block which is not a genesis block")
+	}
+	return block, nil
+}
+
+// getConfiguration retrieves the current configuration of a chain from the
+// orderer, the one of its most recent configuration block
+func getConfiguration(client ab.AtomicBroadcastClient, chainID string) (*ab.ConfigurationEnvelope, error) {
+	deliver, err := client.Deliver(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("Error connecting to the orderer: %s", err)
+	}
+	defer deliver.CloseSend()
+
+	newest, err := getBlock(deliver, chainID, ab.SeekInfo_NEWEST, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var config *ab.ConfigurationEnvelope
+	for number := uint64(0); number <= newest.Header.Number; number++ {
+		block := newest
+		if number < newest.Header.Number {
+			if block, err = getBlock(deliver, chainID, ab.SeekInfo_SPECIFIED, number); err != nil {
+				return nil, err
+			}
+		}
+		if c := putils.GetConfigurationEnvelope(block); c != nil && string(c.ChainID) == chainID {
+			config = c
+		}
+	}
+	if config == nil {
+		return nil, fmt.Errorf("No configuration found for chain %s", chainID)
+	}
+	return config, nil
+}
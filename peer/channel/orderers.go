@@ -0,0 +1,176 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package channel
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/orderer/common/configtx"
+	cb "github.com/hyperledger/fabric/protos/common"
+	ab "github.com/hyperledger/fabric/protos/orderer"
+	putils "github.com/hyperledger/fabric/protos/utils"
+	"github.com/spf13/cobra"
+)
+
+// ordererAddressesModificationPolicyID is the policy modifying the addresses
+// of the ordering service of a chain. Like the anchor peers policy, it
+// requires the signature of one of the admins of the organizations in
+// "chaincode.lifecycle.organizations" when the chain is created.
+const ordererAddressesModificationPolicyID = "OrdererAddressesModificationPolicy"
+
+var ordererAddresses []string
+
+func updateOrderersCmd() *cobra.Command {
+	flags := channelUpdateOrderersCmd.Flags()
+	flags.StringVarP(&chainID, "chainID", "c", "", "Name of the chain to update")
+	flags.StringVarP(&ordererEndpoint, "orderer", "o", "",
+		"Address of the orderer, peer.committer.ledger.orderer when not set")
+	flags.StringSliceVar(&ordererAddresses, "ordereraddresses", nil,
+		"Addresses of the ordering service replacing those of the chain, as host:port")
+	flags.StringVar(&adminCert, "admin-cert", "", "PEM encoded certificate of the admin signing the update")
+	flags.StringVar(&adminKey, "admin-key", "", "PEM encoded private key of the admin signing the update")
+
+	return channelUpdateOrderersCmd
+}
+
+var channelUpdateOrderersCmd = &cobra.Command{
+	Use:   "update-orderers",
+	Short: "Updates the addresses of the ordering service of a chain.",
+	Long:  `Sends a configuration transaction to the orderer replacing the addresses of the ordering service in the configuration of a chain. The peers of the chain pull the blocks from the new addresses once they commit the configuration block. Only chains which declared the addresses of their ordering service when created may update them, and the update must be signed by one of the admins of the organizations of the chain.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return updateOrderers()
+	},
+}
+
+// createOrdererAddressesPolicyItem creates the configuration item of the
+// policy modifying the addresses of the ordering service of a chain
+func createOrdererAddressesPolicyItem(chainID []byte) (*ab.SignedConfigurationItem, error) {
+	admins, err := createAdminsPolicy()
+	if err != nil {
+		return nil, err
+	}
+	policy, err := proto.Marshal(&ab.Policy{
+		Type: &ab.Policy_SignaturePolicy{
+			SignaturePolicy: admins,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := proto.Marshal(&ab.ConfigurationItem{
+		Header:             &cb.ChainHeader{ChainID: chainID},
+		Type:               ab.ConfigurationItem_Policy,
+		ModificationPolicy: configtx.DefaultModificationPolicyID,
+		Key:                ordererAddressesModificationPolicyID,
+		Value:              policy,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ab.SignedConfigurationItem{ConfigurationItem: item}, nil
+}
+
+// setOrdererAddresses returns the configuration of the given sequence which
+// declares the given addresses of the ordering service, keeping the other
+// items of the configuration
+func setOrdererAddresses(config *ab.ConfigurationEnvelope, addresses []string, sequence uint64) (*ab.ConfigurationEnvelope, error) {
+	updated := &ab.ConfigurationEnvelope{
+		ChainID:  config.ChainID,
+		Sequence: sequence,
+	}
+	for _, signedItem := range config.Items {
+		item := &ab.ConfigurationItem{}
+		if err := proto.Unmarshal(signedItem.ConfigurationItem, item); err != nil {
+			return nil, fmt.Errorf("Invalid configuration item: %s", err)
+		}
+		if item.Type == ab.ConfigurationItem_Orderer && item.Key == putils.OrdererAddressesKey {
+			continue
+		}
+		updated.Items = append(updated.Items, signedItem)
+	}
+
+	item, err := putils.CreateOrdererAddressesItem(config.ChainID, addresses, sequence, ordererAddressesModificationPolicyID)
+	if err != nil {
+		return nil, err
+	}
+	updated.Items = append(updated.Items, item)
+	return updated, nil
+}
+
+// createOrderersUpdateEnvelope returns the configuration transaction replacing
+// the addresses of the ordering service in the current configuration of a
+// chain, signed by the admin
+func createOrderersUpdateEnvelope(config *ab.ConfigurationEnvelope, addresses []string) (*cb.Envelope, error) {
+	updated, err := setOrdererAddresses(config, addresses, config.Sequence+1)
+	if err != nil {
+		return nil, err
+	}
+	if err = signModifiedItems(updated, adminCert, adminKey); err != nil {
+		return nil, err
+	}
+	data, err := proto.Marshal(updated)
+	if err != nil {
+		return nil, fmt.Errorf("Error marshaling the configuration: %s", err)
+	}
+
+	payload, err := proto.Marshal(&cb.Payload{
+		Header: &cb.Header{
+			ChainHeader: &cb.ChainHeader{
+				Type:    int32(cb.HeaderType_CONFIGURATION_TRANSACTION),
+				ChainID: config.ChainID,
+			},
+		},
+		Data: data,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Error marshaling the configuration transaction: %s", err)
+	}
+	return &cb.Envelope{Payload: payload}, nil
+}
+
+func updateOrderers() error {
+	if chainID == "" {
+		return errors.New("Must supply the name of the chain with --chainID")
+	}
+	if len(ordererAddresses) == 0 {
+		return errors.New("Must supply the addresses of the ordering service with --ordereraddresses")
+	}
+
+	conn, err := connectOrderer()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	client := ab.NewAtomicBroadcastClient(conn)
+
+	config, err := getConfiguration(client, chainID)
+	if err != nil {
+		return err
+	}
+	env, err := createOrderersUpdateEnvelope(config, ordererAddresses)
+	if err != nil {
+		return err
+	}
+	if err = broadcastEnvelope(client, env); err != nil {
+		return err
+	}
+
+	logger.Infof("Updated the addresses of the ordering service of chain %s", chainID)
+	return nil
+}
@@ -0,0 +1,114 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package channel
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	cb "github.com/hyperledger/fabric/protos/common"
+	ab "github.com/hyperledger/fabric/protos/orderer"
+	"github.com/spf13/cobra"
+)
+
+func updateAnchorsCmd() *cobra.Command {
+	flags := channelUpdateAnchorsCmd.Flags()
+	flags.StringVarP(&chainID, "chainID", "c", "", "Name of the chain to update")
+	flags.StringVarP(&ordererEndpoint, "orderer", "o", "",
+		"Address of the orderer, peer.committer.ledger.orderer when not set")
+	flags.StringSliceVarP(&anchorPeers, "anchorpeers", "a", nil,
+		"Anchor peers replacing those of their organization, as org=host:port")
+	flags.StringVar(&adminCert, "admin-cert", "", "PEM encoded certificate of the admin signing the update")
+	flags.StringVar(&adminKey, "admin-key", "", "PEM encoded private key of the admin signing the update")
+
+	return channelUpdateAnchorsCmd
+}
+
+var channelUpdateAnchorsCmd = &cobra.Command{
+	Use:   "update-anchors",
+	Short: "Updates the anchor peers of organizations on a chain.",
+	Long:  `Sends a configuration transaction to the orderer replacing the anchor peers of the given organizations in the configuration of a chain. The anchor peers of the other organizations are kept. Only organizations which declared anchor peers when the chain was created may update them, and the update must be signed by one of the admins of the organizations of the chain.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return updateAnchors()
+	},
+}
+
+// createAnchorsUpdateEnvelope returns the configuration transaction replacing
+// the anchor peers of organizations in the current configuration of a chain,
+// signed by the admin
+func createAnchorsUpdateEnvelope(config *ab.ConfigurationEnvelope, anchors []string) (*cb.Envelope, error) {
+	peers, err := parseAnchorPeers(anchors)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := setAnchorPeers(config, peers, config.Sequence+1)
+	if err != nil {
+		return nil, err
+	}
+	if err = signModifiedItems(updated, adminCert, adminKey); err != nil {
+		return nil, err
+	}
+	data, err := proto.Marshal(updated)
+	if err != nil {
+		return nil, fmt.Errorf("Error marshaling the configuration: %s", err)
+	}
+
+	payload, err := proto.Marshal(&cb.Payload{
+		Header: &cb.Header{
+			ChainHeader: &cb.ChainHeader{
+				Type:    int32(cb.HeaderType_CONFIGURATION_TRANSACTION),
+				ChainID: config.ChainID,
+			},
+		},
+		Data: data,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Error marshaling the configuration transaction: %s", err)
+	}
+	return &cb.Envelope{Payload: payload}, nil
+}
+
+func updateAnchors() error {
+	if chainID == "" {
+		return errors.New("Must supply the name of the chain with --chainID")
+	}
+	if len(anchorPeers) == 0 {
+		return errors.New("Must supply the anchor peers with --anchorpeers")
+	}
+
+	conn, err := connectOrderer()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	client := ab.NewAtomicBroadcastClient(conn)
+
+	config, err := getConfiguration(client, chainID)
+	if err != nil {
+		return err
+	}
+	env, err := createAnchorsUpdateEnvelope(config, anchorPeers)
+	if err != nil {
+		return err
+	}
+	if err = broadcastEnvelope(client, env); err != nil {
+		return err
+	}
+
+	logger.Infof("Updated the anchor peers of chain %s", chainID)
+	return nil
+}
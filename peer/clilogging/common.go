@@ -31,7 +31,7 @@ func checkLoggingCmdParams(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("no parameters provided")
 	}
 
-	if cmd.Name() == "setlevel" {
+	if cmd.Name() == "setlevel" || cmd.Name() == "setchaincodelevel" {
 		if len(args) == 1 {
 			err = fmt.Errorf("no log level provided")
 		} else {
@@ -31,6 +31,7 @@ var logger = logging.MustGetLogger("loggingCmd")
 func Cmd() *cobra.Command {
 	loggingCmd.AddCommand(getLevelCmd())
 	loggingCmd.AddCommand(setLevelCmd())
+	loggingCmd.AddCommand(setChaincodeLevelCmd())
 
 	return loggingCmd
 }
@@ -96,3 +96,30 @@ func TestSetLevel(t *testing.T) {
 		t.FailNow()
 	}
 }
+
+// TestSetChaincodeLevelOneParam tests the parameter checking for
+// setchaincodelevel, which should return an error when no log level is provided
+func TestSetChaincodeLevelOneParam(t *testing.T) {
+	args := []string{"mycc"}
+
+	err := checkLoggingCmdParams(setChaincodeLevelCmd(), args)
+
+	if err == nil {
+		t.FailNow()
+	}
+}
+
+// TestSetChaincodeLevel tests the parameter checking for setchaincodelevel,
+// which should return a nil error for a chaincode name, a valid log level and
+// an optional module
+func TestSetChaincodeLevel(t *testing.T) {
+	if err := checkLoggingCmdParams(setChaincodeLevelCmd(), []string{"mycc", "debug"}); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if err := checkLoggingCmdParams(setChaincodeLevelCmd(), []string{"mycc", "debug", "shim"}); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if err := checkLoggingCmdParams(setChaincodeLevelCmd(), []string{"mycc", "invalidlevel"}); err == nil {
+		t.Fatalf("Expected an error for an invalid log level")
+	}
+}
@@ -0,0 +1,71 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clilogging
+
+import (
+	"fmt"
+
+	"golang.org/x/net/context"
+
+	"github.com/hyperledger/fabric/core/peer"
+	pb "github.com/hyperledger/fabric/protos"
+	"github.com/spf13/cobra"
+)
+
+func setChaincodeLevelCmd() *cobra.Command {
+	return loggingSetChaincodeLevelCmd
+}
+
+var loggingSetChaincodeLevelCmd = &cobra.Command{
+	Use:   "setchaincodelevel <chaincode name> <log level> [module]",
+	Short: "Sets the logging level of a running chaincode.",
+	Long:  `Sets the logging level of the requested module of a running chaincode, of the shim and the chaincode loggers if no module is given`,
+	Run: func(cmd *cobra.Command, args []string) {
+		setChaincodeLevel(cmd, args)
+	},
+}
+
+func setChaincodeLevel(cmd *cobra.Command, args []string) (err error) {
+	err = checkLoggingCmdParams(cmd, args)
+
+	if err != nil {
+		logger.Warningf("Error: %s", err)
+	} else {
+		clientConn, err := peer.NewPeerClientConnection()
+		if err != nil {
+			logger.Infof("Error trying to connect to local peer: %s", err)
+			err = fmt.Errorf("Error trying to connect to local peer: %s", err)
+			fmt.Println(&pb.ServerStatus{Status: pb.ServerStatus_UNKNOWN})
+			return err
+		}
+
+		serverClient := pb.NewAdminClient(clientConn)
+
+		request := &pb.ChaincodeLogLevelRequest{ChaincodeName: args[0], LogLevel: args[1]}
+		if len(args) > 2 {
+			request.LogModule = args[2]
+		}
+		logResponse, err := serverClient.SetChaincodeLogLevel(context.Background(), request)
+
+		if err != nil {
+			logger.Warningf("Error setting the log level of chaincode %s: %s", args[0], err)
+			return err
+		}
+		logger.Infof("Log level set for module '%s' of chaincode %s: %s", logResponse.LogModule, args[0], logResponse.LogLevel)
+	}
+	return err
+}
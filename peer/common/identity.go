@@ -0,0 +1,93 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/hyperledger/fabric/core/crypto/primitives"
+	pb "github.com/hyperledger/fabric/protos"
+	putils "github.com/hyperledger/fabric/protos/utils"
+	"github.com/op/go-logging"
+	"github.com/spf13/viper"
+)
+
+var logger = logging.MustGetLogger("peer/common")
+
+// Identity is the identity the CLI creates and signs its proposals with
+type Identity struct {
+	// Cert is the PEM encoded certificate sent as the creator of the proposals
+	Cert []byte
+	key  *ecdsa.PrivateKey
+}
+
+// Sign signs the proposal with the key of the identity
+func (id *Identity) Sign(prop *pb.Proposal) (*pb.SignedProposal, error) {
+	return putils.SignProposal(prop, id.key)
+}
+
+// GetIdentity returns the identity given by peer.identity.cert and
+// peer.identity.key (--cert and --key). When they are not set, it returns an
+// ephemeral self-signed identity, which the ACLs of the peer do not list
+func GetIdentity() (*Identity, error) {
+	if err := primitives.InitSecurityLevel("SHA2", 256); err != nil {
+		return nil, err
+	}
+
+	certFile := viper.GetString("peer.identity.cert")
+	keyFile := viper.GetString("peer.identity.key")
+	if certFile == "" && keyFile == "" {
+		logger.Warning("No identity configured with --cert and --key, signing with an ephemeral self-signed identity")
+		der, key, err := primitives.NewSelfSignedCert()
+		if err != nil {
+			return nil, fmt.Errorf("Error creating an ephemeral identity: %s", err)
+		}
+		return &Identity{Cert: primitives.DERCertToPEM(der), key: key.(*ecdsa.PrivateKey)}, nil
+	}
+	if certFile == "" || keyFile == "" {
+		return nil, fmt.Errorf("Must supply both the certificate and the key of the identity with --cert and --key")
+	}
+
+	cert, err := ioutil.ReadFile(certFile)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading certificate: %s", err)
+	}
+	cert = bytes.TrimSpace(cert)
+	rawKey, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading key: %s", err)
+	}
+	key, err := primitives.PEMtoPrivateKey(rawKey, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid key: %s", err)
+	}
+	ecdsaKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("Invalid key: not an ECDSA key")
+	}
+	x509Cert, err := primitives.PEMtoCertificate(cert)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid certificate: %s", err)
+	}
+	if err = primitives.CheckCertPKAgainstSK(x509Cert, key); err != nil {
+		return nil, fmt.Errorf("The key does not match the certificate: %s", err)
+	}
+	return &Identity{Cert: cert, key: ecdsaKey}, nil
+}
@@ -0,0 +1,71 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ledger
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/peer"
+	pb "github.com/hyperledger/fabric/protos"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+)
+
+var compactChannelName string
+
+func compactCmd() *cobra.Command {
+	flags := ledgerCompactCmd.Flags()
+	flags.StringVarP(&compactChannelName, "channel", "c", "",
+		"Name of the channel whose ledger is compacted, by default the ledgers of all the channels")
+
+	return ledgerCompactCmd
+}
+
+var ledgerCompactCmd = &cobra.Command{
+	Use:   "compact",
+	Short: "Compacts the databases of the ledgers of the running peer.",
+	Long: `Compacts the state database and the block index database of the ledgers of the running peer, so that ` +
+		`the disk space held by deleted and overwritten entries is reclaimed, e.g. after large deletes. The size ` +
+		`of the databases before and after the compaction is reported.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return compact()
+	},
+}
+
+func compact() error {
+	clientConn, err := peer.NewPeerClientConnection()
+	if err != nil {
+		return fmt.Errorf("Error trying to connect to local peer: %s", err)
+	}
+	defer clientConn.Close()
+
+	response, err := pb.NewAdminClient(clientConn).CompactLedger(context.Background(),
+		&pb.CompactLedgerRequest{ChainID: compactChannelName})
+	if err != nil {
+		return fmt.Errorf("Error compacting the ledgers: %s", err)
+	}
+	for _, compaction := range response.Compactions {
+		fmt.Printf("Channel %s: state database %s, index database %s\n", compaction.ChainID,
+			describeCompaction(compaction.StateSizeBefore, compaction.StateSizeAfter),
+			describeCompaction(compaction.IndexSizeBefore, compaction.IndexSizeAfter))
+	}
+	return nil
+}
+
+func describeCompaction(sizeBefore int64, sizeAfter int64) string {
+	return fmt.Sprintf("%d -> %d bytes (%d bytes reclaimed)", sizeBefore, sizeAfter, sizeBefore-sizeAfter)
+}
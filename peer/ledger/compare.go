@@ -0,0 +1,141 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ledger
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/chaincode"
+	"github.com/hyperledger/fabric/core/peer"
+	pb "github.com/hyperledger/fabric/protos"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+)
+
+var compareChannelName string
+var comparePeerAddresses []string
+var compareBlockNumber uint64
+
+func compareCmd() *cobra.Command {
+	flags := ledgerCompareCmd.Flags()
+	flags.StringVarP(&compareChannelName, "channel", "c", string(chaincode.DefaultChain),
+		"Name of the channel whose ledgers are compared")
+	flags.StringSliceVarP(&comparePeerAddresses, "peers", "p", nil,
+		"Comma separated addresses of the peers whose ledgers are compared")
+	flags.Uint64VarP(&compareBlockNumber, "blockNumber", "b", 0,
+		"Number of the block up to which the ledgers are compared, by default the last block of the peer with the fewest blocks")
+
+	return ledgerCompareCmd
+}
+
+var ledgerCompareCmd = &cobra.Command{
+	Use:   "compare",
+	Short: "Compares the state of a ledger across peers.",
+	Long: `Compares the state hashes that running peers record for the blocks of a channel. The state hash of a ` +
+		`block is a hash of all the keys and values of the state after the commit of the block, so peers with the ` +
+		`same state hash have the same state. For every peer whose state diverges from the first peer, the first diverging block is reported.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(comparePeerAddresses) < 2 {
+			return errors.New("At least two peers must be given with --peers")
+		}
+		return compare()
+	},
+}
+
+// localMetadataSource returns the local metadata of a block of the compared ledger, of the last block if blockNum is 0
+type localMetadataSource func(blockNum uint64) (*pb.BlockLocalMetadata, error)
+
+func compare() error {
+	sources := make([]localMetadataSource, len(comparePeerAddresses))
+	for i, address := range comparePeerAddresses {
+		clientConn, err := peer.NewPeerClientConnectionWithAddress(address)
+		if err != nil {
+			return fmt.Errorf("Error connecting to peer %s: %s", address, err)
+		}
+		defer clientConn.Close()
+		adminClient := pb.NewAdminClient(clientConn)
+		address := address
+		sources[i] = func(blockNum uint64) (*pb.BlockLocalMetadata, error) {
+			metadata, err := adminClient.GetBlockLocalMetadata(context.Background(),
+				&pb.BlockLocalMetadataRequest{ChainID: compareChannelName, BlockNumber: blockNum})
+			if err != nil {
+				return nil, fmt.Errorf("Error getting the state hash of block [%d] from peer %s: %s", blockNum, address, err)
+			}
+			return metadata, nil
+		}
+	}
+
+	blockNum, divergingBlocks, err := compareStateHashes(sources, compareBlockNumber)
+	if err != nil {
+		return err
+	}
+	consistent := true
+	for i, divergingBlock := range divergingBlocks {
+		if divergingBlock != 0 {
+			consistent = false
+			fmt.Printf("The state of peer %s diverges from peer %s at block %d\n",
+				comparePeerAddresses[i], comparePeerAddresses[0], divergingBlock)
+		}
+	}
+	if !consistent {
+		return fmt.Errorf("The state of the ledger of channel %s differs across peers", compareChannelName)
+	}
+	fmt.Printf("The state of the ledger of channel %s is the same on all peers up to block %d\n", compareChannelName, blockNum)
+	return nil
+}
+
+// compareStateHashes compares the state hash of every block up to `blockNum` of every source with the first source,
+// by default up to the last block of the source with the fewest blocks. The state hash of a block only covers the
+// state after the block, not the blocks before it, so states that diverged may become the same again at a later block.
+// The blocks are hence compared one by one. It returns the number of the last compared block and, by source, the
+// first block whose state hash differs from the first source, 0 if none
+func compareStateHashes(sources []localMetadataSource, blockNum uint64) (uint64, []uint64, error) {
+	if blockNum == 0 {
+		for i, source := range sources {
+			metadata, err := source(0)
+			if err != nil {
+				return 0, nil, err
+			}
+			if i == 0 || metadata.BlockNumber < blockNum {
+				blockNum = metadata.BlockNumber
+			}
+		}
+	}
+
+	divergingBlocks := make([]uint64, len(sources))
+	for currentBlockNum := uint64(1); currentBlockNum <= blockNum; currentBlockNum++ {
+		expectedMetadata, err := sources[0](currentBlockNum)
+		if err != nil {
+			return 0, nil, err
+		}
+		for i := 1; i < len(sources); i++ {
+			if divergingBlocks[i] != 0 {
+				continue
+			}
+			metadata, err := sources[i](currentBlockNum)
+			if err != nil {
+				return 0, nil, err
+			}
+			if !bytes.Equal(metadata.StateHash, expectedMetadata.StateHash) {
+				divergingBlocks[i] = currentBlockNum
+			}
+		}
+	}
+	return blockNum, divergingBlocks, nil
+}
@@ -0,0 +1,77 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ledger
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric/core/ledger/testutil"
+	pb "github.com/hyperledger/fabric/protos"
+)
+
+// newTestSource returns a source whose state hashes differ from the other sources from block `divergingBlock` on
+func newTestSource(height uint64, divergingBlock uint64) localMetadataSource {
+	return newTestSourceWithDivergence(height, divergingBlock, height)
+}
+
+// newTestSourceWithDivergence returns a source whose state hashes differ from the other sources from block
+// `divergingBlock` to block `lastDivergingBlock`, the state being the same again after it
+func newTestSourceWithDivergence(height uint64, divergingBlock uint64, lastDivergingBlock uint64) localMetadataSource {
+	return func(blockNum uint64) (*pb.BlockLocalMetadata, error) {
+		if blockNum == 0 {
+			blockNum = height
+		}
+		if blockNum > height {
+			return nil, fmt.Errorf("Block [%d] is not in the ledger", blockNum)
+		}
+		stateHash := []byte(fmt.Sprintf("hash%d", blockNum))
+		if divergingBlock != 0 && blockNum >= divergingBlock && blockNum <= lastDivergingBlock {
+			stateHash = []byte(fmt.Sprintf("otherhash%d", blockNum))
+		}
+		return &pb.BlockLocalMetadata{BlockNumber: blockNum, StateHash: stateHash}, nil
+	}
+}
+
+func TestCompareStateHashes(t *testing.T) {
+	sources := []localMetadataSource{newTestSource(100, 0), newTestSource(90, 0), newTestSource(120, 37), newTestSource(95, 1)}
+	blockNum, divergingBlocks, err := compareStateHashes(sources, 0)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, blockNum, uint64(90))
+	testutil.AssertEquals(t, divergingBlocks, []uint64{0, 0, 37, 1})
+
+	blockNum, divergingBlocks, err = compareStateHashes(sources, 30)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, blockNum, uint64(30))
+	testutil.AssertEquals(t, divergingBlocks, []uint64{0, 0, 0, 1})
+
+	_, _, err = compareStateHashes(sources, 110)
+	testutil.AssertError(t, err, "Expected an error for a block that is not in all the ledgers")
+}
+
+func TestCompareStateHashesTemporaryDivergence(t *testing.T) {
+	// the state of the second source diverges at block 37 and is the same again from block 51 on
+	sources := []localMetadataSource{newTestSource(100, 0), newTestSourceWithDivergence(100, 37, 50)}
+	blockNum, divergingBlocks, err := compareStateHashes(sources, 0)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, blockNum, uint64(100))
+	testutil.AssertEquals(t, divergingBlocks, []uint64{0, 37})
+
+	_, divergingBlocks, err = compareStateHashes(sources, 36)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, divergingBlocks, []uint64{0, 0})
+}
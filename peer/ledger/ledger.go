@@ -0,0 +1,52 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ledger
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/ledger/kvledger"
+	"github.com/op/go-logging"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const ledgerFuncName = "ledger"
+
+var logger = logging.MustGetLogger("ledgerCmd")
+
+// Cmd returns the cobra command for Ledger
+func Cmd() *cobra.Command {
+	ledgerCmd.AddCommand(verifyCmd())
+	ledgerCmd.AddCommand(compareCmd())
+	ledgerCmd.AddCommand(compactCmd())
+	ledgerCmd.AddCommand(migrateCmd())
+
+	return ledgerCmd
+}
+
+// initializeLedgers initializes the ledger sub-system for the commands that work on the ledgers of the
+// stopped peer directly
+func initializeLedgers() {
+	kvledger.Initialize(viper.GetString("peer.fileSystemPath"))
+}
+
+var ledgerCmd = &cobra.Command{
+	Use:   ledgerFuncName,
+	Short: fmt.Sprintf("%s specific commands.", ledgerFuncName),
+	Long:  fmt.Sprintf("%s specific commands.", ledgerFuncName),
+}
@@ -0,0 +1,70 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ledger
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/ledger/kvledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/kvledgerconfig"
+	"github.com/spf13/cobra"
+)
+
+var (
+	migrateChannelName    string
+	migrateTargetDatabase string
+)
+
+func migrateCmd() *cobra.Command {
+	flags := ledgerMigrateCmd.Flags()
+	flags.StringVarP(&migrateChannelName, "channel", "c", "", "Name of the channel whose state is migrated")
+	flags.StringVarP(&migrateTargetDatabase, "to", "t", "", "Name of the state database the state is migrated to")
+
+	return ledgerMigrateCmd
+}
+
+var ledgerMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Migrates the state of a ledger to a different state database.",
+	Long: `Copies the state of the ledger of a channel from the state database configured by ` +
+		`ledger.state.stateDatabase into a store of the given state database, and puts the copy in place of ` +
+		`the existing store, which is kept next to it. The peer must be stopped, and ledger.state.stateDatabase ` +
+		`is set to the new state database before it is started again.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return migrate()
+	},
+}
+
+func migrate() error {
+	if migrateChannelName == "" {
+		return fmt.Errorf("Must supply the channel name")
+	}
+	if migrateTargetDatabase == "" {
+		return fmt.Errorf("Must supply the state database to migrate to")
+	}
+	if kvledgerconfig.IsCouchDBEnabled() {
+		return fmt.Errorf("The state is held in CouchDB, which is not migrated")
+	}
+	initializeLedgers()
+	numKeys, err := kvledger.MigrateState(migrateChannelName, migrateTargetDatabase)
+	if err != nil {
+		return fmt.Errorf("Error migrating the state of channel %s: %s", migrateChannelName, err)
+	}
+	fmt.Printf("Migrated %d keys of the state of channel %s to %s. Set ledger.state.stateDatabase to %s before "+
+		"starting the peer.\n", numKeys, migrateChannelName, migrateTargetDatabase, migrateTargetDatabase)
+	return nil
+}
@@ -0,0 +1,58 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ledger
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/chaincode"
+	"github.com/hyperledger/fabric/core/ledger/kvledger"
+	"github.com/spf13/cobra"
+)
+
+var verifyChannelName string
+
+func verifyCmd() *cobra.Command {
+	ledgerVerifyCmd.Flags().StringVarP(&verifyChannelName, "channel", "c", string(chaincode.DefaultChain),
+		"Name of the channel whose ledger is verified")
+
+	return ledgerVerifyCmd
+}
+
+var ledgerVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verifies the integrity of a ledger.",
+	Long: `Walks the block store of a channel, recomputes the block hashes and checks the hash chain and the ` +
+		`block indexes, and checks that the state database is at the height of the block store. ` +
+		`The first corrupt block is reported. The ledger is opened read-only and left as found. The node must be stopped.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return verify()
+	},
+}
+
+func verify() error {
+	initializeLedgers()
+	numBlocks, err := kvledger.VerifyLedger(verifyChannelName)
+	if _, ok := err.(kvledger.LedgerNotFoundErr); ok {
+		return fmt.Errorf("Channel %s has no ledger on this peer", verifyChannelName)
+	}
+	if err != nil {
+		return fmt.Errorf("Verification of the ledger of channel %s failed after %d valid blocks: %s", verifyChannelName, numBlocks, err)
+	}
+	logger.Infof("Verified %d blocks of the ledger of channel %s", numBlocks, verifyChannelName)
+	return nil
+}
@@ -32,7 +32,9 @@ import (
 	"github.com/hyperledger/fabric/core"
 	"github.com/hyperledger/fabric/flogging"
 	"github.com/hyperledger/fabric/peer/chaincode"
+	"github.com/hyperledger/fabric/peer/channel"
 	"github.com/hyperledger/fabric/peer/clilogging"
+	"github.com/hyperledger/fabric/peer/ledger"
 	"github.com/hyperledger/fabric/peer/network"
 	"github.com/hyperledger/fabric/peer/node"
 	"github.com/hyperledger/fabric/peer/version"
@@ -80,6 +82,10 @@ func main() {
 
 	mainFlags.String("logging-level", "", "Default logging level and overrides, see core.yaml for full syntax")
 	viper.BindPFlag("logging_level", mainFlags.Lookup("logging-level"))
+	mainFlags.String("cert", "", "File holding the PEM encoded certificate of the identity signing the proposals")
+	viper.BindPFlag("peer.identity.cert", mainFlags.Lookup("cert"))
+	mainFlags.String("key", "", "File holding the PEM encoded private key of the identity signing the proposals")
+	viper.BindPFlag("peer.identity.key", mainFlags.Lookup("key"))
 	testCoverProfile := ""
 	mainFlags.StringVarP(&testCoverProfile, "test.coverprofile", "", "coverage.cov", "Done")
 
@@ -109,7 +115,9 @@ func main() {
 	mainCmd.AddCommand(node.Cmd())
 	mainCmd.AddCommand(network.Cmd())
 	mainCmd.AddCommand(chaincode.Cmd())
+	mainCmd.AddCommand(channel.Cmd())
 	mainCmd.AddCommand(clilogging.Cmd())
+	mainCmd.AddCommand(ledger.Cmd())
 
 	runtime.GOMAXPROCS(viper.GetInt("peer.gomaxprocs"))
 
@@ -0,0 +1,76 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+
+package network
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/peer"
+	"github.com/hyperledger/fabric/peer/chaincode"
+	pb "github.com/hyperledger/fabric/protos"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+)
+
+var discoverChainID string
+
+func discoverCmd() *cobra.Command {
+	networkDiscoverCmd.Flags().StringVarP(&discoverChainID, "chainID", "C", "default",
+		"The chain to discover the peers and orderers of.")
+
+	return networkDiscoverCmd
+}
+
+var networkDiscoverCmd = &cobra.Command{
+	Use:   "discover [<name>[:<function>[:<collection>,...]] ...]",
+	Short: "Discovers the peers and orderers of a chain.",
+	Long: "Returns the peers of a chain known to the target peer node, with the " +
+		"height of their ledger and their installed chaincodes, and the orderers " +
+		"of the chain. The endorsement layouts of the chaincode calls given as " +
+		"arguments are returned as well.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return networkDiscover(args)
+	},
+}
+
+// Show the peers and orderers of a chain, and the endorsement layouts of
+// the chaincode calls
+func networkDiscover(args []string) error {
+	req := &pb.DiscoveryRequest{ChainID: discoverChainID}
+	for _, arg := range args {
+		call, err := chaincode.ParseChaincodeCall(arg)
+		if err != nil {
+			return err
+		}
+		req.Interest = append(req.Interest, call)
+	}
+
+	clientConn, err := peer.NewPeerClientConnection()
+	if err != nil {
+		return fmt.Errorf("Error trying to connect to local peer: %s", err)
+	}
+	resp, err := pb.NewDiscoveryClient(clientConn).Discover(context.Background(), req)
+	if err != nil {
+		return fmt.Errorf("Error trying to discover chain %s: %s", discoverChainID, err)
+	}
+
+	jsonOutput, _ := json.Marshal(resp)
+	fmt.Println(string(jsonOutput))
+	return nil
+}
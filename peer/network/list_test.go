@@ -30,3 +30,13 @@ func TestListCmd(t *testing.T) {
 	require.Equal("list", cmd.Name())
 	require.NotNil(cmd.RunE)
 }
+
+func TestDiscoverCmd(t *testing.T) {
+	require := require.New(t)
+	cmd := discoverCmd()
+
+	require.NotNil(cmd)
+	require.Equal("discover", cmd.Name())
+	require.NotNil(cmd.RunE)
+	require.NotNil(cmd.Flags().Lookup("chainID"))
+}
@@ -42,6 +42,11 @@ func Cmd() *cobra.Command {
 	nodeCmd.AddCommand(startCmd())
 	nodeCmd.AddCommand(statusCmd())
 	nodeCmd.AddCommand(stopCmd())
+	nodeCmd.AddCommand(rotateKeyCmd())
+	nodeCmd.AddCommand(rollbackCmd())
+	nodeCmd.AddCommand(resetCmd())
+	nodeCmd.AddCommand(reloadTLSCmd())
+	nodeCmd.AddCommand(verifyAuditCmd())
 
 	return nodeCmd
 }
@@ -51,3 +56,16 @@ var nodeCmd = &cobra.Command{
 	Short: fmt.Sprintf("%s specific commands.", nodeFuncName),
 	Long:  fmt.Sprintf("%s specific commands.", nodeFuncName),
 }
+
+// checkLedger checks that the peer has a ledger for the chain, so that a
+// command run on an unknown chain does not create an empty ledger for it
+func checkLedger(chainID string) error {
+	exists, err := kvledger.LedgerExists(chainID)
+	if err != nil {
+		return fmt.Errorf("Error looking up the ledger of chain %s: %s", chainID, err)
+	}
+	if !exists {
+		return fmt.Errorf("Chain %s not found", chainID)
+	}
+	return nil
+}
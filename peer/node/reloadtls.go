@@ -0,0 +1,55 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package node
+
+import (
+	"fmt"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/hyperledger/fabric/core/peer"
+	pb "github.com/hyperledger/fabric/protos"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+)
+
+func reloadTLSCmd() *cobra.Command {
+	return nodeReloadTLSCmd
+}
+
+var nodeReloadTLSCmd = &cobra.Command{
+	Use:   "reloadtls",
+	Short: "Reloads the TLS certificates of the node.",
+	Long: `Reloads the TLS certificates, keys and root CAs of the running node from their files. ` +
+		`The connections already established are kept.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return reloadTLS()
+	},
+}
+
+func reloadTLS() error {
+	clientConn, err := peer.NewPeerClientConnection()
+	if err != nil {
+		return fmt.Errorf("Error trying to connect to local peer: %s", err)
+	}
+	defer clientConn.Close()
+
+	if _, err = pb.NewAdminClient(clientConn).ReloadTLS(context.Background(), &empty.Empty{}); err != nil {
+		return fmt.Errorf("Error reloading the TLS certificates of the local peer: %s", err)
+	}
+	logger.Info("Reloaded the TLS certificates of the local peer")
+	return nil
+}
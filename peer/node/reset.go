@@ -0,0 +1,75 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package node
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/ledger/kvledger"
+	"github.com/spf13/cobra"
+)
+
+var resetChannelName string
+
+func resetCmd() *cobra.Command {
+	flags := nodeResetCmd.Flags()
+	flags.StringVarP(&resetChannelName, "channel", "c", "",
+		"Name of the channel whose ledger is reset, all the ledgers are reset if not set")
+
+	return nodeResetCmd
+}
+
+var nodeResetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "Rebuilds the state of the ledgers from their blocks.",
+	Long: `Drops the state database of the ledger of a channel, or of every channel ledger, and rebuilds ` +
+		`it by replaying the blocks of the local block store. The node must be stopped.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return reset()
+	},
+}
+
+func reset() error {
+	disableStateWarmUp()
+
+	names := []string{resetChannelName}
+	if resetChannelName == "" {
+		var err error
+		if names, err = kvledger.GetLedgerNames(); err != nil {
+			return fmt.Errorf("Error listing the ledgers: %s", err)
+		}
+	} else if err := checkLedger(resetChannelName); err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err := resetLedger(name); err != nil {
+			return err
+		}
+	}
+	logger.Infof("Rebuilt the state of %d ledgers", len(names))
+	return nil
+}
+
+func resetLedger(name string) error {
+	lgr := kvledger.GetLedger(name)
+	defer lgr.Close()
+	if err := lgr.RebuildState(); err != nil {
+		return fmt.Errorf("Error rebuilding the state of the ledger of channel %s: %s", name, err)
+	}
+	logger.Infof("Rebuilt the state of the ledger of channel %s", name)
+	return nil
+}
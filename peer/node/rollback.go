@@ -0,0 +1,81 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package node
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/chaincode"
+	"github.com/hyperledger/fabric/core/committer"
+	"github.com/hyperledger/fabric/core/ledger/kvledger"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var rollbackChannelName string
+var rollbackBlockNumber uint64
+
+func rollbackCmd() *cobra.Command {
+	flags := nodeRollbackCmd.Flags()
+	flags.StringVarP(&rollbackChannelName, "channel", "c", string(chaincode.DefaultChain),
+		"Name of the channel whose ledger is rolled back")
+	flags.Uint64VarP(&rollbackBlockNumber, "blockNumber", "b", 0,
+		"Number of the block that becomes the last block of the ledger")
+
+	return nodeRollbackCmd
+}
+
+var nodeRollbackCmd = &cobra.Command{
+	Use:   "rollback",
+	Short: "Rolls back the ledger of a channel to a given block.",
+	Long: `Removes the blocks after the given block from the block store of a channel and rebuilds the ` +
+		`state database from the remaining blocks. The node must be stopped.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !cmd.Flags().Changed("blockNumber") {
+			return errors.New("The block number must be specified with --blockNumber")
+		}
+		return rollback()
+	},
+}
+
+func rollback() error {
+	disableStateWarmUp()
+	if err := checkLedger(rollbackChannelName); err != nil {
+		return err
+	}
+	lgr := kvledger.GetLedger(rollbackChannelName)
+	defer lgr.Close()
+	if err := lgr.RollbackTo(rollbackBlockNumber); err != nil {
+		return fmt.Errorf("Error rolling back the ledger of channel %s to block %d: %s", rollbackChannelName, rollbackBlockNumber, err)
+	}
+	// the state listeners receive the blocks committed again after the rollback
+	notifier := committer.NewStateListenerNotifier(rollbackChannelName, lgr,
+		committer.GetStateListenerCursorDir(rollbackChannelName))
+	if err := notifier.ResetCursors(rollbackBlockNumber); err != nil {
+		return fmt.Errorf("Error resetting the cursors of the state listeners of channel %s: %s", rollbackChannelName, err)
+	}
+	logger.Infof("Rolled back the ledger of channel %s to block %d", rollbackChannelName, rollbackBlockNumber)
+	return nil
+}
+
+// disableStateWarmUp keeps the ledgers from warming up the state when they are opened,
+// which would compete with a rebuild of the state
+func disableStateWarmUp() {
+	viper.Set("ledger.state.warmup.blocks", 0)
+	viper.Set("ledger.state.warmup.indexes", false)
+}
@@ -0,0 +1,57 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package node
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/chaincode"
+	"github.com/hyperledger/fabric/core/ledger/kvledger"
+	"github.com/spf13/cobra"
+)
+
+var rotateKeyChainName string
+
+func rotateKeyCmd() *cobra.Command {
+	nodeRotateKeyCmd.Flags().StringVarP(&rotateKeyChainName, "chain", "c", string(chaincode.DefaultChain),
+		"Name of the chain whose block store key is rotated")
+
+	return nodeRotateKeyCmd
+}
+
+var nodeRotateKeyCmd = &cobra.Command{
+	Use:   "rotatekey",
+	Short: "Rotates the block store encryption key.",
+	Long: `Rotates the key used to encrypt the blocks of a chain. Blocks added afterwards are encrypted ` +
+		`with the new key and existing blocks remain readable. The node must be stopped.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return rotateKey()
+	},
+}
+
+func rotateKey() error {
+	if err := checkLedger(rotateKeyChainName); err != nil {
+		return err
+	}
+	lgr := kvledger.GetLedger(rotateKeyChainName)
+	defer lgr.Close()
+	if err := lgr.RotateBlockStoreKey(); err != nil {
+		return fmt.Errorf("Error rotating block store key for chain %s: %s", rotateKeyChainName, err)
+	}
+	logger.Infof("Rotated block store key for chain %s", rotateKeyChainName)
+	return nil
+}
@@ -24,37 +24,51 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"runtime"
 	"strconv"
 	"sync"
 	"syscall"
 	"time"
 
 	"github.com/hyperledger/fabric/core"
+	"github.com/hyperledger/fabric/core/audit"
 	"github.com/hyperledger/fabric/core/chaincode"
 	"github.com/hyperledger/fabric/core/comm"
 	"github.com/hyperledger/fabric/core/committer/noopssinglechain"
+	"github.com/hyperledger/fabric/core/config"
 	"github.com/hyperledger/fabric/core/crypto"
 	"github.com/hyperledger/fabric/core/crypto/primitives"
 	"github.com/hyperledger/fabric/core/db"
 	"github.com/hyperledger/fabric/core/endorser"
+	"github.com/hyperledger/fabric/core/ledger/kvledger"
+	"github.com/hyperledger/fabric/core/metrics"
+	"github.com/hyperledger/fabric/core/operations"
 	"github.com/hyperledger/fabric/core/peer"
 	"github.com/hyperledger/fabric/core/rest"
+	"github.com/hyperledger/fabric/core/system_chaincode/cscc"
+	"github.com/hyperledger/fabric/core/tracing"
 	"github.com/hyperledger/fabric/events/producer"
+	"github.com/hyperledger/fabric/flogging"
+	"github.com/hyperledger/fabric/gossip/service"
 	pb "github.com/hyperledger/fabric/protos"
+	"github.com/hyperledger/fabric/protos/utils"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"golang.org/x/net/context"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/grpclog"
 )
 
 var chaincodeDevMode bool
+var chaincodeDebug bool
 
 func startCmd() *cobra.Command {
 	// Set the flags on the node start command.
 	flags := nodeStartCmd.Flags()
 	flags.BoolVarP(&chaincodeDevMode, "peer-chaincodedev", "", false,
 		"Whether peer in chaincode development mode")
+	flags.BoolVarP(&chaincodeDebug, "peer-chaincodedebug", "", false,
+		"Whether chaincodes are stepped through in a debugger")
 
 	return nodeStartCmd
 }
@@ -75,12 +89,17 @@ func serve(args []string) error {
 		logger.Info("Running in chaincode development mode")
 		logger.Info("Set consensus to NOOPS and user starts chaincode")
 		logger.Info("Disable loading validity system chaincode")
+		logger.Info("Restarted chaincodes replace the running ones")
 
 		viper.Set("peer.validator.enabled", "true")
 		viper.Set("peer.validator.consensus", "noops")
 		viper.Set("chaincode.mode", chaincode.DevModeUserRunsChaincode)
 
 	}
+	if chaincodeDebug {
+		logger.Info("Running with chaincode debugging, unresponsive chaincodes are waited for")
+		viper.Set("chaincode.debug.enabled", true)
+	}
 
 	if err := peer.CacheConfiguration(); err != nil {
 		return err
@@ -103,8 +122,16 @@ func serve(args []string) error {
 	if err != nil {
 		grpclog.Fatalf("Failed to listen: %v", err)
 	}
-
-	ehubLis, ehubGrpcServer, err := createEventHubServer()
+	// The keepalive and the concurrency limits of the servers follow the
+	// changes of their settings in the config file
+	keepalive := comm.NewKeepalive(comm.GetServerKeepaliveOptions())
+	keepalive.WatchConfig("peer.keepalive.server")
+	comm.ClientKeepalive().WatchConfig("peer.keepalive.client")
+	limiter := comm.NewConcurrencyLimiter(comm.GetConcurrencyLimits())
+	limiter.WatchConfig()
+	lis = keepalive.Listener(lis)
+
+	ehubLis, ehubGrpcServer, err := createEventHubServer(keepalive, limiter)
 	if err != nil {
 		grpclog.Fatalf("Failed to create ehub server: %v", err)
 	}
@@ -122,15 +149,51 @@ func serve(args []string) error {
 
 	db.Start()
 
-	var opts []grpc.ServerOption
-	if comm.TLSEnabled() {
-		creds, err := credentials.NewServerTLSFromFile(viper.GetString("peer.tls.cert.file"),
-			viper.GetString("peer.tls.key.file"))
+	// The subsystems create their metrics with the provider when created
+	metricsProvider, err := metrics.InitProvider()
+	if err != nil {
+		return err
+	}
+	if statsd, ok := metricsProvider.(*metrics.StatsdProvider); ok {
+		defer statsd.Stop()
+	}
+
+	if err = tracing.Init(tracing.Config{
+		Enabled:      viper.GetBool("tracing.enabled"),
+		ServiceName:  "peer",
+		OTLPEndpoint: viper.GetString("tracing.otlp.endpoint"),
+		File:         viper.GetString("tracing.file"),
+	}); err != nil {
+		return err
+	}
+	defer tracing.Flush()
+
+	if err = audit.Init(viper.GetString("peer.audit.file"), viper.GetString("peer.audit.keyFile")); err != nil {
+		return err
+	}
 
+	metricsUnary, metricsStream := comm.MetricsServerInterceptors(metricsProvider)
+	unary := []grpc.UnaryServerInterceptor{metricsUnary, tracing.UnaryServerInterceptor}
+	stream := []grpc.StreamServerInterceptor{metricsStream, tracing.StreamServerInterceptor}
+	var serverTLS *comm.ServerTLS
+	if comm.TLSEnabled() {
+		serverTLS, err = newServerTLS("peer")
 		if err != nil {
 			grpclog.Fatalf("Failed to generate credentials %v", err)
 		}
-		opts = []grpc.ServerOption{grpc.Creds(creds)}
+		unary = append(unary, serverTLS.UnaryServerInterceptor)
+		stream = append(stream, serverTLS.StreamServerInterceptor)
+	}
+	// only the administrators of the peer may call the admin service
+	unary = append(unary, core.AdminServerInterceptor)
+	// the new proposals are rejected while the peer shuts down
+	drainer := comm.NewDrainer("protos.Endorser")
+	unary = append(unary, drainer.UnaryServerInterceptor, limiter.UnaryServerInterceptor)
+	stream = append(stream, drainer.StreamServerInterceptor, limiter.StreamServerInterceptor)
+	opts := comm.ServerInterceptorOptions(unary, stream)
+	opts = append(opts, comm.MessageSizeServerOption(comm.GetMessageSizeOptions()))
+	if serverTLS != nil {
+		opts = append(opts, grpc.Creds(serverTLS))
 	}
 
 	grpcServer := grpc.NewServer(opts...)
@@ -145,6 +208,9 @@ func serve(args []string) error {
 	}
 
 	registerChaincodeSupport(chaincode.DefaultChain, grpcServer, secHelper)
+	if err = addJoinedChains(); err != nil {
+		return err
+	}
 
 	var peerServer *peer.Impl
 
@@ -193,11 +259,22 @@ func serve(args []string) error {
 	serverEndorser := endorser.NewEndorserServer(peerServer)
 	pb.RegisterEndorserServer(grpcServer, serverEndorser)
 
+	// Register the Discovery server
+	pb.RegisterDiscoveryServer(grpcServer, endorser.NewDiscoveryServer(serverEndorser))
+
+	// Start gossip before the committer, which disseminates the blocks it
+	// commits, or commits the blocks received over gossip
+	if viper.GetBool("peer.gossip.enabled") {
+		if err = startGossip(peerEndpoint.Address, grpcServer); err != nil {
+			return err
+		}
+	}
+
 	// !!!IMPORTANT!!! - as mentioned in core.yaml, peer-orderer-committer
 	// interaction is closely tied to bootstrapping. This is to be viewed
 	// as temporary implementation to test the end-to-end flows in the
 	// system outside of multi-ledger, multi-channel work
-	if committer := noopssinglechain.NewCommitter(); committer != nil {
+	if committer := noopssinglechain.NewCommitter(peerEndpoint.Address); committer != nil {
 		go func() {
 			if err := committer.Start(); err != nil {
 				fmt.Printf("Could not start solo committer(%s), continuing without committer\n", err)
@@ -205,6 +282,30 @@ func serve(args []string) error {
 		}()
 	}
 
+	if interval := viper.GetDuration("peer.tls.reloadInterval"); comm.TLSEnabled() && interval > 0 {
+		stopWatch := make(chan struct{})
+		defer close(stopWatch)
+		go comm.WatchTLSFiles(interval, stopWatch)
+	}
+
+	if interval := viper.GetDuration("peer.config.reloadInterval"); interval > 0 {
+		watcher, err := newConfigWatcher()
+		if err != nil {
+			return err
+		}
+		stopWatch := make(chan struct{})
+		defer close(stopWatch)
+		go watcher.Watch(interval, stopWatch)
+	}
+
+	if viper.GetBool("peer.operations.enabled") {
+		system, err := startOperations(metricsProvider)
+		if err != nil {
+			return err
+		}
+		defer system.Stop()
+	}
+
 	logger.Infof("Starting peer with ID=%s, network ID=%s, address=%s, rootnodes=%v, validator=%v",
 		peerEndpoint.ID, viper.GetString("peer.networkId"), peerEndpoint.Address,
 		viper.GetString("peer.discovery.rootnode"), peer.ValidatorEnabled())
@@ -213,12 +314,15 @@ func serve(args []string) error {
 	// genesis block if needed.
 	serve := make(chan error)
 
+	// the admin service stops the peer by sending it SIGTERM
 	sigs := make(chan os.Signal, 1)
 	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
 		sig := <-sigs
 		fmt.Println()
 		fmt.Println(sig)
+		drain(drainer)
+		grpcServer.Stop()
 		serve <- nil
 	}()
 
@@ -281,7 +385,149 @@ func registerChaincodeSupport(chainname chaincode.ChainName, grpcServer *grpc.Se
 	pb.RegisterChaincodeSupportServer(grpcServer, ccSrv)
 }
 
-func createEventHubServer() (net.Listener, *grpc.Server, error) {
+// addJoinedChains creates the chaincode support of the chains the peer joined,
+// and of every chain it joins from then on
+func addJoinedChains() error {
+	cscc.OnJoin(func(chainID string) {
+		if _, err := chaincode.AddChain(chaincode.ChainName(chainID)); err != nil {
+			logger.Errorf("Failed to create the chaincode support of chain %s: %s", chainID, err)
+		}
+	})
+	chains, err := cscc.JoinedChains()
+	if err != nil {
+		return fmt.Errorf("Failed to read the joined chains: %s", err)
+	}
+	for chainID := range chains {
+		if _, err = chaincode.AddChain(chaincode.ChainName(chainID)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startGossip starts the gossip component on the gRPC server of the peer and
+// joins the default chain and the chains the peer joined. The peers listed in
+// "peer.gossip.bootstrap" and the anchor peers of the joined chains bootstrap
+// the membership
+func startGossip(endpoint string, grpcServer *grpc.Server) error {
+	chains, err := cscc.JoinedChains()
+	if err != nil {
+		return fmt.Errorf("Failed to read the joined chains: %s", err)
+	}
+
+	bootPeers := viper.GetStringSlice("peer.gossip.bootstrap")
+	for chainID, config := range chains {
+		anchorPeers, err := utils.GetAnchorPeers(config)
+		if err != nil {
+			return fmt.Errorf("Failed to get the anchor peers of chain %s: %s", chainID, err)
+		}
+		for _, peers := range anchorPeers {
+			for _, anchorPeer := range peers.AnchorPeers {
+				if anchor := fmt.Sprintf("%s:%d", anchorPeer.Host, anchorPeer.Port); anchor != endpoint {
+					bootPeers = append(bootPeers, anchor)
+				}
+			}
+		}
+	}
+
+	dialOpts := []grpc.DialOption{grpc.WithTimeout(3 * time.Second), comm.ClientKeepalive().DialOption(),
+		comm.MessageSizeDialOption(comm.GetMessageSizeOptions())}
+	if comm.TLSEnabled() {
+		creds, err := comm.NewReloadableCredentials(comm.PeerClientTLS, viper.GetString("peer.tls.cert.file"),
+			viper.GetString("peer.tls.clientCert.file"), viper.GetString("peer.tls.clientKey.file"))
+		if err != nil {
+			return fmt.Errorf("Failed to create TLS credentials: %s", err)
+		}
+		comm.RegisterTLSReloader("gossip", creds)
+		dialOpts = append(dialOpts, grpc.WithTransportCredentials(creds))
+	} else {
+		dialOpts = append(dialOpts, grpc.WithInsecure())
+	}
+	if err = service.InitGossipService(endpoint, grpcServer, dialOpts, bootPeers...); err != nil {
+		return fmt.Errorf("Failed to start gossip: %s", err)
+	}
+
+	g := service.GetGossipService()
+	g.JoinChain([]byte(chaincode.DefaultChain))
+	for chainID := range chains {
+		g.JoinChain([]byte(chainID))
+	}
+	return nil
+}
+
+// startOperations starts the operations server, which checks the ledger of
+// the default chain and gossip for /healthz, and serves the metrics of the
+// provider on /metrics when it is the prometheus one
+func startOperations(metricsProvider metrics.Provider) (*operations.System, error) {
+	options := operations.OptionsFromConfig()
+	protocol, err := comm.GetTLSProtocolConfig()
+	if err != nil {
+		return nil, err
+	}
+	options.TLS.MinVersion, options.TLS.CipherSuites = protocol.MinVersion, protocol.CipherSuites
+	if exporter, ok := metricsProvider.(operations.Exporter); ok {
+		options.Exporter = exporter
+	}
+	system := operations.NewSystem(options)
+
+	system.RegisterChecker("ledger", func() error {
+		_, err := kvledger.GetLedger(string(chaincode.DefaultChain)).GetBlockchainInfo()
+		return err
+	})
+	system.RegisterGauge("ledger_height", "Height of the ledger of the default chain", func() float64 {
+		info, err := kvledger.GetLedger(string(chaincode.DefaultChain)).GetBlockchainInfo()
+		if err != nil {
+			return 0
+		}
+		return float64(info.Height)
+	})
+
+	if viper.GetBool("peer.gossip.enabled") {
+		system.RegisterChecker("gossip", func() error {
+			if service.GetGossipService() == nil {
+				return errors.New("gossip service is not initialized")
+			}
+			return nil
+		})
+		system.RegisterGauge("gossip_peers", "Number of peers known to gossip", func() float64 {
+			if g := service.GetGossipService(); g != nil {
+				return float64(len(g.GetPeers()))
+			}
+			return 0
+		})
+	}
+
+	system.RegisterGauge("go_goroutines", "Number of goroutines that currently exist", func() float64 {
+		return float64(runtime.NumGoroutine())
+	})
+
+	if err := system.Start(); err != nil {
+		return nil, fmt.Errorf("Failed to start operations server: %s", err)
+	}
+	return system, nil
+}
+
+// newConfigWatcher returns the watcher of the config file of the peer,
+// registering the dynamic logging levels of the node command. These are not
+// dynamic when the --logging-level option or CORE_LOGGING_LEVEL is set
+func newConfigWatcher() (*config.ConfigWatcher, error) {
+	if viper.GetString("logging_level") == "" {
+		config.RegisterDynamicSetting("logging.node", config.DynamicSetting{
+			Keys: []string{"logging.node"},
+			Apply: func(c *viper.Viper) error {
+				spec := c.GetString("logging.node")
+				if spec == "" {
+					spec = flogging.DefaultLoggingLevel().String()
+				}
+				return flogging.SetLoggingSpec(spec)
+			},
+		})
+	}
+	// the environment variables of the settings are prefixed with CORE_ by main
+	return config.NewConfigWatcher(viper.ConfigFileUsed(), "core")
+}
+
+func createEventHubServer(keepalive *comm.Keepalive, limiter *comm.ConcurrencyLimiter) (net.Listener, *grpc.Server, error) {
 	var lis net.Listener
 	var grpcServer *grpc.Server
 	var err error
@@ -290,18 +536,26 @@ func createEventHubServer() (net.Listener, *grpc.Server, error) {
 		if err != nil {
 			return nil, nil, fmt.Errorf("failed to listen: %v", err)
 		}
+		lis = keepalive.Listener(lis)
 
 		//TODO - do we need different SSL material for events ?
-		var opts []grpc.ServerOption
+		var unary []grpc.UnaryServerInterceptor
+		var stream []grpc.StreamServerInterceptor
+		var serverTLS *comm.ServerTLS
 		if comm.TLSEnabled() {
-			creds, err := credentials.NewServerTLSFromFile(
-				viper.GetString("peer.tls.cert.file"),
-				viper.GetString("peer.tls.key.file"))
-
+			serverTLS, err = newServerTLS("events")
 			if err != nil {
 				return nil, nil, fmt.Errorf("Failed to generate credentials %v", err)
 			}
-			opts = []grpc.ServerOption{grpc.Creds(creds)}
+			unary = append(unary, serverTLS.UnaryServerInterceptor)
+			stream = append(stream, serverTLS.StreamServerInterceptor)
+		}
+		unary = append(unary, limiter.UnaryServerInterceptor)
+		stream = append(stream, limiter.StreamServerInterceptor)
+		opts := comm.ServerInterceptorOptions(unary, stream)
+		opts = append(opts, comm.MessageSizeServerOption(comm.GetMessageSizeOptions()))
+		if serverTLS != nil {
+			opts = append(opts, grpc.Creds(serverTLS))
 		}
 
 		grpcServer = grpc.NewServer(opts...)
@@ -314,6 +568,58 @@ func createEventHubServer() (net.Listener, *grpc.Server, error) {
 	return lis, grpcServer, err
 }
 
+// The steps of the drain after the proposals, replaced by the tests
+var stopCommitters = noopssinglechain.Stop
+var stopChaincodes = chaincode.StopAllChains
+
+// drain prepares the peer to exit within "peer.shutdown.gracePeriod". It
+// rejects the new proposals and waits for those in flight, waits for the
+// block being committed and stops committing the following ones, and stops
+// the chaincodes. The block being committed is waited for even past the
+// grace period, so that the peer never exits in the middle of a block. The
+// other steps still running when the grace period ends are abandoned
+func drain(drainer *comm.Drainer) {
+	gracePeriod := viper.GetDuration("peer.shutdown.gracePeriod")
+	logger.Infof("Draining the peer within %s", gracePeriod)
+	ctx, cancel := context.WithTimeout(context.Background(), gracePeriod)
+	defer cancel()
+
+	if err := drainer.Drain(ctx); err != nil {
+		logger.Warningf("Proposals still in flight at the end of the grace period: %s", err)
+	}
+	stopCommitters()
+	if ctx.Err() != nil {
+		logger.Warning("Not stopping the chaincodes, the grace period is over")
+		return
+	}
+	done := make(chan error, 1)
+	go func() { done <- stopChaincodes(ctx) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			logger.Warningf("Error stopping the chaincodes: %s", err)
+		}
+	case <-ctx.Done():
+		logger.Warning("Still stopping the chaincodes at the end of the grace period")
+		return
+	}
+	logger.Info("Drained the peer")
+}
+
+// newServerTLS returns the TLS credentials of a peer gRPC server and the
+// interceptors restricting the clients of the endorser, deliver and admin
+// services to those configured in "peer.tls.clientRootCAs". They are
+// registered under name to be reloaded on rotation of the certificates
+func newServerTLS(name string) (*comm.ServerTLS, error) {
+	serverTLS, err := comm.NewServerTLS(viper.GetString("peer.tls.cert.file"),
+		viper.GetString("peer.tls.key.file"), comm.GetClientAuthConfig())
+	if err != nil {
+		return nil, err
+	}
+	comm.RegisterTLSReloader(name, serverTLS)
+	return serverTLS, nil
+}
+
 func writePid(fileName string, pid int) error {
 	err := os.MkdirAll(filepath.Dir(fileName), 0755)
 	if err != nil {
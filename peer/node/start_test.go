@@ -0,0 +1,82 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/core/comm"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+func TestDrainProposalOutlivesGracePeriod(t *testing.T) {
+	defer viper.Set("peer.shutdown.gracePeriod", viper.Get("peer.shutdown.gracePeriod"))
+	viper.Set("peer.shutdown.gracePeriod", 50*time.Millisecond)
+	defer func(committers func(), chaincodes func(context.Context) error) {
+		stopCommitters, stopChaincodes = committers, chaincodes
+	}(stopCommitters, stopChaincodes)
+
+	// the block being committed completes after the grace period
+	blockCommitted := false
+	stopCommitters = func() {
+		time.Sleep(100 * time.Millisecond)
+		blockCommitted = true
+	}
+	chaincodesStopped := false
+	stopChaincodes = func(ctx context.Context) error {
+		chaincodesStopped = true
+		return nil
+	}
+
+	drainer := comm.NewDrainer("protos.Endorser")
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	go drainer.UnaryServerInterceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/protos.Endorser/ProcessProposal"},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			close(entered)
+			<-release
+			return nil, nil
+		})
+	<-entered
+
+	drain(drainer)
+	assert.True(t, blockCommitted, "Expected the drain to wait for the block being committed")
+	assert.False(t, chaincodesStopped, "Expected the chaincodes not to be stopped past the grace period")
+}
+
+func TestDrain(t *testing.T) {
+	defer viper.Set("peer.shutdown.gracePeriod", viper.Get("peer.shutdown.gracePeriod"))
+	viper.Set("peer.shutdown.gracePeriod", time.Second)
+	defer func(committers func(), chaincodes func(context.Context) error) {
+		stopCommitters, stopChaincodes = committers, chaincodes
+	}(stopCommitters, stopChaincodes)
+
+	var steps []string
+	stopCommitters = func() { steps = append(steps, "committers") }
+	stopChaincodes = func(ctx context.Context) error {
+		steps = append(steps, "chaincodes")
+		return nil
+	}
+
+	drain(comm.NewDrainer("protos.Endorser"))
+	assert.Equal(t, []string{"committers", "chaincodes"}, steps)
+}
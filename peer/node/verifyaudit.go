@@ -0,0 +1,59 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package node
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/audit"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func verifyAuditCmd() *cobra.Command {
+	return nodeVerifyAuditCmd
+}
+
+var nodeVerifyAuditCmd = &cobra.Command{
+	Use:   "verifyaudit [file]",
+	Short: "Verifies the audit log of the node.",
+	Long: `Verifies that no entry of the audit log of the node, the file of peer.audit.file unless given, ` +
+		`was modified, inserted or removed, with the key of peer.audit.keyFile, and prints the number of entries.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file := viper.GetString("peer.audit.file")
+		if len(args) > 0 {
+			file = args[0]
+		}
+		return verifyAudit(file)
+	},
+}
+
+func verifyAudit(file string) error {
+	if file == "" {
+		return fmt.Errorf("No audit log file, peer.audit.file is not set")
+	}
+	key, err := audit.ReadKey(viper.GetString("peer.audit.keyFile"))
+	if err != nil {
+		return err
+	}
+	entries, err := audit.Verify(file, key)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Audit log %s verified, %d entries\n", file, entries)
+	return nil
+}
@@ -22,6 +22,8 @@ It is generated from these files:
 	fabric_transaction_header.proto
 	fabric_transaction.proto
 	server_admin.proto
+	state_proof.proto
+	configuration.proto
 
 It has these top-level messages:
 	BlockNumber
@@ -30,10 +32,22 @@ It has these top-level messages:
 	ChaincodeHeaderExtension
 	ChaincodeProposalPayload
 	ChaincodeAction
+	PrivateDataHashes
+	PrivateDataHash
 	ChaincodeID
 	ChaincodeInput
 	ChaincodeSpec
+	ChaincodeResourceLimits
 	ChaincodeDeploymentSpec
+	SignedChaincodeDeploymentSpec
+	ChaincodeInstantiationPolicy
+	ChaincodeOwnerEndorsement
+	ChaincodeServerInfo
+	ChaincodeDefinition
+	ChaincodeInterest
+	ChaincodeInterests
+	ChaincodeInfo
+	ChaincodeQueryResponse
 	ChaincodeInvocationSpec
 	ChaincodeSecurityContext
 	ChaincodeMessage
@@ -43,6 +57,20 @@ It has these top-level messages:
 	RangeQueryStateClose
 	RangeQueryStateKeyValue
 	RangeQueryStateResponse
+	GetQueryResult
+	QueryResponseMetadata
+	GetStateMultiple
+	GetStateMultipleResponse
+	SetLogLevel
+	LedgerHeight
+	ChannelConfig
+	ChannelInfo
+	ChannelQueryResponse
+	RegisteredIdentity
+	IdentityAttribute
+	RegisteredIdentities
+	ApplicationPolicy
+	PolicyRole
 	ChaincodeActionPayload
 	ChaincodeEndorsedAction
 	Secret
@@ -58,6 +86,7 @@ It has these top-level messages:
 	Unregister
 	Event
 	Block2
+	BlockLocalMetadata
 	Message2
 	SignedProposal
 	Proposal
@@ -86,6 +115,15 @@ It has these top-level messages:
 	SyncStateSnapshot
 	SyncStateDeltasRequest
 	SyncStateDeltas
+	ProposalChunk
+	ChaincodeCall
+	EndorsementLayoutsRequest
+	OrganizationEndorsers
+	EndorsementLayout
+	EndorsementLayoutsResponse
+	DiscoveryRequest
+	PeerInfo
+	DiscoveryResponse
 	Header
 	SignedTransaction
 	InvalidTransaction
@@ -94,6 +132,17 @@ It has these top-level messages:
 	ServerStatus
 	LogLevelRequest
 	LogLevelResponse
+	ChaincodeLogLevelRequest
+	BlockLocalMetadataRequest
+	CompactLedgerRequest
+	LedgerCompaction
+	CompactLedgerResponse
+	StateProof
+	StateProofEntry
+	StateRoot
+	AnchorPeers
+	AnchorPeer
+	OrdererAddresses
 */
 package protos
 
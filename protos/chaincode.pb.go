@@ -74,24 +74,27 @@ func (ChaincodeSpec_Type) EnumDescriptor() ([]byte, []int) { return fileDescript
 type ChaincodeDeploymentSpec_ExecutionEnvironment int32
 
 const (
-	ChaincodeDeploymentSpec_DOCKER ChaincodeDeploymentSpec_ExecutionEnvironment = 0
-	ChaincodeDeploymentSpec_SYSTEM ChaincodeDeploymentSpec_ExecutionEnvironment = 1
+	ChaincodeDeploymentSpec_DOCKER   ChaincodeDeploymentSpec_ExecutionEnvironment = 0
+	ChaincodeDeploymentSpec_SYSTEM   ChaincodeDeploymentSpec_ExecutionEnvironment = 1
+	ChaincodeDeploymentSpec_EXTERNAL ChaincodeDeploymentSpec_ExecutionEnvironment = 2
 )
 
 var ChaincodeDeploymentSpec_ExecutionEnvironment_name = map[int32]string{
 	0: "DOCKER",
 	1: "SYSTEM",
+	2: "EXTERNAL",
 }
 var ChaincodeDeploymentSpec_ExecutionEnvironment_value = map[string]int32{
-	"DOCKER": 0,
-	"SYSTEM": 1,
+	"DOCKER":   0,
+	"SYSTEM":   1,
+	"EXTERNAL": 2,
 }
 
 func (x ChaincodeDeploymentSpec_ExecutionEnvironment) String() string {
 	return proto.EnumName(ChaincodeDeploymentSpec_ExecutionEnvironment_name, int32(x))
 }
 func (ChaincodeDeploymentSpec_ExecutionEnvironment) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor3, []int{3, 0}
+	return fileDescriptor3, []int{4, 0}
 }
 
 type ChaincodeMessage_Type int32
@@ -118,6 +121,10 @@ const (
 	ChaincodeMessage_RANGE_QUERY_STATE_NEXT  ChaincodeMessage_Type = 18
 	ChaincodeMessage_RANGE_QUERY_STATE_CLOSE ChaincodeMessage_Type = 19
 	ChaincodeMessage_KEEPALIVE               ChaincodeMessage_Type = 20
+	ChaincodeMessage_GET_QUERY_RESULT        ChaincodeMessage_Type = 21
+	ChaincodeMessage_GET_STATE_MULTIPLE      ChaincodeMessage_Type = 22
+	ChaincodeMessage_SET_LOG_LEVEL           ChaincodeMessage_Type = 23
+	ChaincodeMessage_GET_LEDGER_HEIGHT       ChaincodeMessage_Type = 24
 )
 
 var ChaincodeMessage_Type_name = map[int32]string{
@@ -142,6 +149,10 @@ var ChaincodeMessage_Type_name = map[int32]string{
 	18: "RANGE_QUERY_STATE_NEXT",
 	19: "RANGE_QUERY_STATE_CLOSE",
 	20: "KEEPALIVE",
+	21: "GET_QUERY_RESULT",
+	22: "GET_STATE_MULTIPLE",
+	23: "SET_LOG_LEVEL",
+	24: "GET_LEDGER_HEIGHT",
 }
 var ChaincodeMessage_Type_value = map[string]int32{
 	"UNDEFINED":               0,
@@ -165,12 +176,16 @@ var ChaincodeMessage_Type_value = map[string]int32{
 	"RANGE_QUERY_STATE_NEXT":  18,
 	"RANGE_QUERY_STATE_CLOSE": 19,
 	"KEEPALIVE":               20,
+	"GET_QUERY_RESULT":        21,
+	"GET_STATE_MULTIPLE":      22,
+	"SET_LOG_LEVEL":           23,
+	"GET_LEDGER_HEIGHT":       24,
 }
 
 func (x ChaincodeMessage_Type) String() string {
 	return proto.EnumName(ChaincodeMessage_Type_name, int32(x))
 }
-func (ChaincodeMessage_Type) EnumDescriptor() ([]byte, []int) { return fileDescriptor3, []int{6, 0} }
+func (ChaincodeMessage_Type) EnumDescriptor() ([]byte, []int) { return fileDescriptor3, []int{16, 0} }
 
 // ChaincodeID contains the path as specified by the deploy transaction
 // that created it as well as the hashCode that is generated by the
@@ -185,6 +200,9 @@ type ChaincodeID struct {
 	// all other requests will use the name (really a hashcode) generated by
 	// the deploy transaction
 	Name string `protobuf:"bytes,2,opt,name=name" json:"name,omitempty"`
+	// version of the chaincode package, set when the package is installed
+	// on a peer and when it is instantiated or upgraded on a chain
+	Version string `protobuf:"bytes,3,opt,name=version" json:"version,omitempty"`
 }
 
 func (m *ChaincodeID) Reset()                    { *m = ChaincodeID{} }
@@ -197,6 +215,9 @@ func (*ChaincodeID) Descriptor() ([]byte, []int) { return fileDescriptor3, []int
 // the []byte-based current ChaincodeInput structure.
 type ChaincodeInput struct {
 	Args [][]byte `protobuf:"bytes,1,rep,name=args,proto3" json:"args,omitempty"`
+	// decorations added by the decorators of the endorsing peer before the
+	// chaincode is executed, never supplied by the client
+	Decorations map[string][]byte `protobuf:"bytes,2,rep,name=decorations" json:"decorations,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value,proto3"`
 }
 
 func (m *ChaincodeInput) Reset()                    { *m = ChaincodeInput{} }
@@ -204,17 +225,25 @@ func (m *ChaincodeInput) String() string            { return proto.CompactTextSt
 func (*ChaincodeInput) ProtoMessage()               {}
 func (*ChaincodeInput) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{1} }
 
+func (m *ChaincodeInput) GetDecorations() map[string][]byte {
+	if m != nil {
+		return m.Decorations
+	}
+	return nil
+}
+
 // Carries the chaincode specification. This is the actual metadata required for
 // defining a chaincode.
 type ChaincodeSpec struct {
-	Type                 ChaincodeSpec_Type   `protobuf:"varint,1,opt,name=type,enum=protos.ChaincodeSpec_Type" json:"type,omitempty"`
-	ChaincodeID          *ChaincodeID         `protobuf:"bytes,2,opt,name=chaincodeID" json:"chaincodeID,omitempty"`
-	CtorMsg              *ChaincodeInput      `protobuf:"bytes,3,opt,name=ctorMsg" json:"ctorMsg,omitempty"`
-	Timeout              int32                `protobuf:"varint,4,opt,name=timeout" json:"timeout,omitempty"`
-	SecureContext        string               `protobuf:"bytes,5,opt,name=secureContext" json:"secureContext,omitempty"`
-	ConfidentialityLevel ConfidentialityLevel `protobuf:"varint,6,opt,name=confidentialityLevel,enum=protos.ConfidentialityLevel" json:"confidentialityLevel,omitempty"`
-	Metadata             []byte               `protobuf:"bytes,7,opt,name=metadata,proto3" json:"metadata,omitempty"`
-	Attributes           []string             `protobuf:"bytes,8,rep,name=attributes" json:"attributes,omitempty"`
+	Type                 ChaincodeSpec_Type       `protobuf:"varint,1,opt,name=type,enum=protos.ChaincodeSpec_Type" json:"type,omitempty"`
+	ChaincodeID          *ChaincodeID             `protobuf:"bytes,2,opt,name=chaincodeID" json:"chaincodeID,omitempty"`
+	CtorMsg              *ChaincodeInput          `protobuf:"bytes,3,opt,name=ctorMsg" json:"ctorMsg,omitempty"`
+	Timeout              int32                    `protobuf:"varint,4,opt,name=timeout" json:"timeout,omitempty"`
+	SecureContext        string                   `protobuf:"bytes,5,opt,name=secureContext" json:"secureContext,omitempty"`
+	ConfidentialityLevel ConfidentialityLevel     `protobuf:"varint,6,opt,name=confidentialityLevel,enum=protos.ConfidentialityLevel" json:"confidentialityLevel,omitempty"`
+	Metadata             []byte                   `protobuf:"bytes,7,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	Attributes           []string                 `protobuf:"bytes,8,rep,name=attributes" json:"attributes,omitempty"`
+	ResourceLimits       *ChaincodeResourceLimits `protobuf:"bytes,9,opt,name=resourceLimits" json:"resourceLimits,omitempty"`
 }
 
 func (m *ChaincodeSpec) Reset()                    { *m = ChaincodeSpec{} }
@@ -236,6 +265,26 @@ func (m *ChaincodeSpec) GetCtorMsg() *ChaincodeInput {
 	return nil
 }
 
+func (m *ChaincodeSpec) GetResourceLimits() *ChaincodeResourceLimits {
+	if m != nil {
+		return m.ResourceLimits
+	}
+	return nil
+}
+
+// Resource limits requested for the container running a chaincode. A zero
+// value leaves the limit configured for the peer in place.
+type ChaincodeResourceLimits struct {
+	CpuShares int64 `protobuf:"varint,1,opt,name=cpuShares" json:"cpuShares,omitempty"`
+	Memory    int64 `protobuf:"varint,2,opt,name=memory" json:"memory,omitempty"`
+	PidsLimit int64 `protobuf:"varint,3,opt,name=pidsLimit" json:"pidsLimit,omitempty"`
+}
+
+func (m *ChaincodeResourceLimits) Reset()                    { *m = ChaincodeResourceLimits{} }
+func (m *ChaincodeResourceLimits) String() string            { return proto.CompactTextString(m) }
+func (*ChaincodeResourceLimits) ProtoMessage()               {}
+func (*ChaincodeResourceLimits) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{3} }
+
 // Specify the deployment of a chaincode.
 // TODO: Define `codePackage`.
 type ChaincodeDeploymentSpec struct {
@@ -244,12 +293,15 @@ type ChaincodeDeploymentSpec struct {
 	EffectiveDate *google_protobuf.Timestamp                   `protobuf:"bytes,2,opt,name=effectiveDate" json:"effectiveDate,omitempty"`
 	CodePackage   []byte                                       `protobuf:"bytes,3,opt,name=codePackage,proto3" json:"codePackage,omitempty"`
 	ExecEnv       ChaincodeDeploymentSpec_ExecutionEnvironment `protobuf:"varint,4,opt,name=execEnv,enum=protos.ChaincodeDeploymentSpec_ExecutionEnvironment" json:"execEnv,omitempty"`
+	// The server the peer connects to when the chaincode is run as an
+	// external service (execEnv EXTERNAL). There is no code package then.
+	ChaincodeServer *ChaincodeServerInfo `protobuf:"bytes,5,opt,name=chaincodeServer" json:"chaincodeServer,omitempty"`
 }
 
 func (m *ChaincodeDeploymentSpec) Reset()                    { *m = ChaincodeDeploymentSpec{} }
 func (m *ChaincodeDeploymentSpec) String() string            { return proto.CompactTextString(m) }
 func (*ChaincodeDeploymentSpec) ProtoMessage()               {}
-func (*ChaincodeDeploymentSpec) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{3} }
+func (*ChaincodeDeploymentSpec) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{4} }
 
 func (m *ChaincodeDeploymentSpec) GetChaincodeSpec() *ChaincodeSpec {
 	if m != nil {
@@ -265,6 +317,185 @@ func (m *ChaincodeDeploymentSpec) GetEffectiveDate() *google_protobuf.Timestamp
 	return nil
 }
 
+func (m *ChaincodeDeploymentSpec) GetChaincodeServer() *ChaincodeServerInfo {
+	if m != nil {
+		return m.ChaincodeServer
+	}
+	return nil
+}
+
+// A chaincode package signed by its owners. The owners sign the deployment
+// spec together with the instantiation policy, a peer only installs packages
+// signed by the owners it trusts.
+type SignedChaincodeDeploymentSpec struct {
+	// marshalled ChaincodeDeploymentSpec
+	ChaincodeDeploymentSpec []byte `protobuf:"bytes,1,opt,name=chaincodeDeploymentSpec,proto3" json:"chaincodeDeploymentSpec,omitempty"`
+	// marshalled ChaincodeInstantiationPolicy, who may instantiate or upgrade
+	// the chaincode on a chain
+	InstantiationPolicy []byte                       `protobuf:"bytes,2,opt,name=instantiationPolicy,proto3" json:"instantiationPolicy,omitempty"`
+	OwnerEndorsements   []*ChaincodeOwnerEndorsement `protobuf:"bytes,3,rep,name=ownerEndorsements" json:"ownerEndorsements,omitempty"`
+}
+
+func (m *SignedChaincodeDeploymentSpec) Reset()                    { *m = SignedChaincodeDeploymentSpec{} }
+func (m *SignedChaincodeDeploymentSpec) String() string            { return proto.CompactTextString(m) }
+func (*SignedChaincodeDeploymentSpec) ProtoMessage()               {}
+func (*SignedChaincodeDeploymentSpec) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{5} }
+
+func (m *SignedChaincodeDeploymentSpec) GetOwnerEndorsements() []*ChaincodeOwnerEndorsement {
+	if m != nil {
+		return m.OwnerEndorsements
+	}
+	return nil
+}
+
+// Who may instantiate or upgrade the chaincode of a package on a chain.
+type ChaincodeInstantiationPolicy struct {
+	// identities, as sent in the proposal header, permitted. Everyone is
+	// permitted if there is none
+	Identities [][]byte `protobuf:"bytes,1,rep,name=identities,proto3" json:"identities,omitempty"`
+}
+
+func (m *ChaincodeInstantiationPolicy) Reset()                    { *m = ChaincodeInstantiationPolicy{} }
+func (m *ChaincodeInstantiationPolicy) String() string            { return proto.CompactTextString(m) }
+func (*ChaincodeInstantiationPolicy) ProtoMessage()               {}
+func (*ChaincodeInstantiationPolicy) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{6} }
+
+// Signature of an owner over a chaincode package.
+type ChaincodeOwnerEndorsement struct {
+	// PEM encoded certificate of the owner
+	Endorser  []byte `protobuf:"bytes,1,opt,name=endorser,proto3" json:"endorser,omitempty"`
+	Signature []byte `protobuf:"bytes,2,opt,name=signature,proto3" json:"signature,omitempty"`
+}
+
+func (m *ChaincodeOwnerEndorsement) Reset()                    { *m = ChaincodeOwnerEndorsement{} }
+func (m *ChaincodeOwnerEndorsement) String() string            { return proto.CompactTextString(m) }
+func (*ChaincodeOwnerEndorsement) ProtoMessage()               {}
+func (*ChaincodeOwnerEndorsement) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{7} }
+
+// Address and TLS settings of a chaincode that is run as an external service.
+// The peer connects to the chaincode server instead of building and launching
+// a container for the chaincode.
+type ChaincodeServerInfo struct {
+	Address    string `protobuf:"bytes,1,opt,name=address" json:"address,omitempty"`
+	TlsEnabled bool   `protobuf:"varint,2,opt,name=tlsEnabled" json:"tlsEnabled,omitempty"`
+	// PEM encoded root certificate the server certificate is verified with
+	RootCert           []byte `protobuf:"bytes,3,opt,name=rootCert,proto3" json:"rootCert,omitempty"`
+	ServerHostOverride string `protobuf:"bytes,4,opt,name=serverHostOverride" json:"serverHostOverride,omitempty"`
+}
+
+func (m *ChaincodeServerInfo) Reset()                    { *m = ChaincodeServerInfo{} }
+func (m *ChaincodeServerInfo) String() string            { return proto.CompactTextString(m) }
+func (*ChaincodeServerInfo) ProtoMessage()               {}
+func (*ChaincodeServerInfo) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{8} }
+
+// Definition of a chaincode on a chain that each organization approves. It
+// becomes active once the approvals satisfy the approval policy.
+type ChaincodeDefinition struct {
+	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	// version of an installed package
+	Version           string `protobuf:"bytes,2,opt,name=version" json:"version,omitempty"`
+	EndorsementPolicy []byte `protobuf:"bytes,3,opt,name=endorsementPolicy,proto3" json:"endorsementPolicy,omitempty"`
+	// configuration of the private data collections of the chaincode
+	Collections []byte `protobuf:"bytes,4,opt,name=collections,proto3" json:"collections,omitempty"`
+	// arguments the chaincode is initialized with when it is activated
+	CtorMsg *ChaincodeInput `protobuf:"bytes,5,opt,name=ctorMsg" json:"ctorMsg,omitempty"`
+	// the collections and organizations the functions of the chaincode touch
+	Interests []*ChaincodeInterest `protobuf:"bytes,6,rep,name=interests" json:"interests,omitempty"`
+	// system chaincodes endorsing and validating the transactions of the
+	// chaincode, "escc" and "vscc" when empty
+	Escc string `protobuf:"bytes,7,opt,name=escc" json:"escc,omitempty"`
+	Vscc string `protobuf:"bytes,8,opt,name=vscc" json:"vscc,omitempty"`
+}
+
+func (m *ChaincodeDefinition) Reset()                    { *m = ChaincodeDefinition{} }
+func (m *ChaincodeDefinition) String() string            { return proto.CompactTextString(m) }
+func (*ChaincodeDefinition) ProtoMessage()               {}
+func (*ChaincodeDefinition) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{9} }
+
+func (m *ChaincodeDefinition) GetCtorMsg() *ChaincodeInput {
+	if m != nil {
+		return m.CtorMsg
+	}
+	return nil
+}
+
+func (m *ChaincodeDefinition) GetInterests() []*ChaincodeInterest {
+	if m != nil {
+		return m.Interests
+	}
+	return nil
+}
+
+// The collections and organizations a function of a chaincode touches, as
+// declared in its definition. Endorsers of a call to the function are picked
+// among these organizations and the members of these collections, on top of
+// the endorsement policy of the chaincode.
+type ChaincodeInterest struct {
+	Function      string   `protobuf:"bytes,1,opt,name=function" json:"function,omitempty"`
+	Collections   []string `protobuf:"bytes,2,rep,name=collections" json:"collections,omitempty"`
+	Organizations []string `protobuf:"bytes,3,rep,name=organizations" json:"organizations,omitempty"`
+}
+
+func (m *ChaincodeInterest) Reset()                    { *m = ChaincodeInterest{} }
+func (m *ChaincodeInterest) String() string            { return proto.CompactTextString(m) }
+func (*ChaincodeInterest) ProtoMessage()               {}
+func (*ChaincodeInterest) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{10} }
+
+// The interests declared by a chaincode.
+type ChaincodeInterests struct {
+	Interests []*ChaincodeInterest `protobuf:"bytes,1,rep,name=interests" json:"interests,omitempty"`
+}
+
+func (m *ChaincodeInterests) Reset()                    { *m = ChaincodeInterests{} }
+func (m *ChaincodeInterests) String() string            { return proto.CompactTextString(m) }
+func (*ChaincodeInterests) ProtoMessage()               {}
+func (*ChaincodeInterests) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{11} }
+
+func (m *ChaincodeInterests) GetInterests() []*ChaincodeInterest {
+	if m != nil {
+		return m.Interests
+	}
+	return nil
+}
+
+// A chaincode installed on the peer or instantiated on a chain, as listed
+// by lccc.
+type ChaincodeInfo struct {
+	Name    string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Version string `protobuf:"bytes,2,opt,name=version" json:"version,omitempty"`
+	Path    string `protobuf:"bytes,3,opt,name=path" json:"path,omitempty"`
+	// hash of the code package
+	Hash []byte `protobuf:"bytes,4,opt,name=hash,proto3" json:"hash,omitempty"`
+	// endorsement policy of a chaincode instantiated on a chain
+	EndorsementPolicy []byte `protobuf:"bytes,5,opt,name=endorsementPolicy,proto3" json:"endorsementPolicy,omitempty"`
+	// system chaincodes endorsing and validating the transactions of a
+	// chaincode instantiated on a chain
+	Escc string `protobuf:"bytes,6,opt,name=escc" json:"escc,omitempty"`
+	Vscc string `protobuf:"bytes,7,opt,name=vscc" json:"vscc,omitempty"`
+}
+
+func (m *ChaincodeInfo) Reset()                    { *m = ChaincodeInfo{} }
+func (m *ChaincodeInfo) String() string            { return proto.CompactTextString(m) }
+func (*ChaincodeInfo) ProtoMessage()               {}
+func (*ChaincodeInfo) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{12} }
+
+// The chaincodes installed on the peer or instantiated on a chain.
+type ChaincodeQueryResponse struct {
+	Chaincodes []*ChaincodeInfo `protobuf:"bytes,1,rep,name=chaincodes" json:"chaincodes,omitempty"`
+}
+
+func (m *ChaincodeQueryResponse) Reset()                    { *m = ChaincodeQueryResponse{} }
+func (m *ChaincodeQueryResponse) String() string            { return proto.CompactTextString(m) }
+func (*ChaincodeQueryResponse) ProtoMessage()               {}
+func (*ChaincodeQueryResponse) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{13} }
+
+func (m *ChaincodeQueryResponse) GetChaincodes() []*ChaincodeInfo {
+	if m != nil {
+		return m.Chaincodes
+	}
+	return nil
+}
+
 // Carries the chaincode function and its arguments.
 type ChaincodeInvocationSpec struct {
 	ChaincodeSpec *ChaincodeSpec `protobuf:"bytes,1,opt,name=chaincodeSpec" json:"chaincodeSpec,omitempty"`
@@ -281,7 +512,7 @@ type ChaincodeInvocationSpec struct {
 func (m *ChaincodeInvocationSpec) Reset()                    { *m = ChaincodeInvocationSpec{} }
 func (m *ChaincodeInvocationSpec) String() string            { return proto.CompactTextString(m) }
 func (*ChaincodeInvocationSpec) ProtoMessage()               {}
-func (*ChaincodeInvocationSpec) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{4} }
+func (*ChaincodeInvocationSpec) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{14} }
 
 func (m *ChaincodeInvocationSpec) GetChaincodeSpec() *ChaincodeSpec {
 	if m != nil {
@@ -307,7 +538,7 @@ type ChaincodeSecurityContext struct {
 func (m *ChaincodeSecurityContext) Reset()                    { *m = ChaincodeSecurityContext{} }
 func (m *ChaincodeSecurityContext) String() string            { return proto.CompactTextString(m) }
 func (*ChaincodeSecurityContext) ProtoMessage()               {}
-func (*ChaincodeSecurityContext) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{5} }
+func (*ChaincodeSecurityContext) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{15} }
 
 func (m *ChaincodeSecurityContext) GetTxTimestamp() *google_protobuf.Timestamp {
 	if m != nil {
@@ -326,12 +557,23 @@ type ChaincodeMessage struct {
 	// This event is then stored (currently)
 	// with Block.NonHashData.TransactionResult
 	ChaincodeEvent *ChaincodeEvent `protobuf:"bytes,6,opt,name=chaincodeEvent" json:"chaincodeEvent,omitempty"`
+	// proposal being executed. Used only with Init or Invoke of
+	// a proposal, so that the chaincode can access its transient data
+	Proposal *Proposal `protobuf:"bytes,7,opt,name=proposal" json:"proposal,omitempty"`
+	// response of a failed Init, Invoke or Query, with ERROR or QUERY_ERROR.
+	// It carries the status set by the chaincode, the payload carrying the
+	// message for the shims not setting it
+	Response *Response2 `protobuf:"bytes,8,opt,name=response" json:"response,omitempty"`
+	// signed proposal being executed, as received by the endorser, with the
+	// header, payload and signature of proposal. Used only with Init or
+	// Invoke of a proposal, so that the chaincode can verify the signature
+	SignedProposal *SignedProposal `protobuf:"bytes,9,opt,name=signedProposal" json:"signedProposal,omitempty"`
 }
 
 func (m *ChaincodeMessage) Reset()                    { *m = ChaincodeMessage{} }
 func (m *ChaincodeMessage) String() string            { return proto.CompactTextString(m) }
 func (*ChaincodeMessage) ProtoMessage()               {}
-func (*ChaincodeMessage) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{6} }
+func (*ChaincodeMessage) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{16} }
 
 func (m *ChaincodeMessage) GetTimestamp() *google_protobuf.Timestamp {
 	if m != nil {
@@ -354,6 +596,27 @@ func (m *ChaincodeMessage) GetChaincodeEvent() *ChaincodeEvent {
 	return nil
 }
 
+func (m *ChaincodeMessage) GetProposal() *Proposal {
+	if m != nil {
+		return m.Proposal
+	}
+	return nil
+}
+
+func (m *ChaincodeMessage) GetResponse() *Response2 {
+	if m != nil {
+		return m.Response
+	}
+	return nil
+}
+
+func (m *ChaincodeMessage) GetSignedProposal() *SignedProposal {
+	if m != nil {
+		return m.SignedProposal
+	}
+	return nil
+}
+
 type PutStateInfo struct {
 	Key   string `protobuf:"bytes,1,opt,name=key" json:"key,omitempty"`
 	Value []byte `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
@@ -362,17 +625,21 @@ type PutStateInfo struct {
 func (m *PutStateInfo) Reset()                    { *m = PutStateInfo{} }
 func (m *PutStateInfo) String() string            { return proto.CompactTextString(m) }
 func (*PutStateInfo) ProtoMessage()               {}
-func (*PutStateInfo) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{7} }
+func (*PutStateInfo) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{17} }
 
+// A non-zero pageSize requests a single page of results starting from the
+// bookmark. Paginated queries are not recorded in the read set.
 type RangeQueryState struct {
 	StartKey string `protobuf:"bytes,1,opt,name=startKey" json:"startKey,omitempty"`
 	EndKey   string `protobuf:"bytes,2,opt,name=endKey" json:"endKey,omitempty"`
+	PageSize int32  `protobuf:"varint,3,opt,name=pageSize" json:"pageSize,omitempty"`
+	Bookmark string `protobuf:"bytes,4,opt,name=bookmark" json:"bookmark,omitempty"`
 }
 
 func (m *RangeQueryState) Reset()                    { *m = RangeQueryState{} }
 func (m *RangeQueryState) String() string            { return proto.CompactTextString(m) }
 func (*RangeQueryState) ProtoMessage()               {}
-func (*RangeQueryState) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{8} }
+func (*RangeQueryState) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{18} }
 
 type RangeQueryStateNext struct {
 	ID string `protobuf:"bytes,1,opt,name=ID" json:"ID,omitempty"`
@@ -381,7 +648,7 @@ type RangeQueryStateNext struct {
 func (m *RangeQueryStateNext) Reset()                    { *m = RangeQueryStateNext{} }
 func (m *RangeQueryStateNext) String() string            { return proto.CompactTextString(m) }
 func (*RangeQueryStateNext) ProtoMessage()               {}
-func (*RangeQueryStateNext) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{9} }
+func (*RangeQueryStateNext) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{19} }
 
 type RangeQueryStateClose struct {
 	ID string `protobuf:"bytes,1,opt,name=ID" json:"ID,omitempty"`
@@ -390,7 +657,7 @@ type RangeQueryStateClose struct {
 func (m *RangeQueryStateClose) Reset()                    { *m = RangeQueryStateClose{} }
 func (m *RangeQueryStateClose) String() string            { return proto.CompactTextString(m) }
 func (*RangeQueryStateClose) ProtoMessage()               {}
-func (*RangeQueryStateClose) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{10} }
+func (*RangeQueryStateClose) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{20} }
 
 type RangeQueryStateKeyValue struct {
 	Key   string `protobuf:"bytes,1,opt,name=key" json:"key,omitempty"`
@@ -400,18 +667,19 @@ type RangeQueryStateKeyValue struct {
 func (m *RangeQueryStateKeyValue) Reset()                    { *m = RangeQueryStateKeyValue{} }
 func (m *RangeQueryStateKeyValue) String() string            { return proto.CompactTextString(m) }
 func (*RangeQueryStateKeyValue) ProtoMessage()               {}
-func (*RangeQueryStateKeyValue) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{11} }
+func (*RangeQueryStateKeyValue) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{21} }
 
 type RangeQueryStateResponse struct {
 	KeysAndValues []*RangeQueryStateKeyValue `protobuf:"bytes,1,rep,name=keysAndValues" json:"keysAndValues,omitempty"`
 	HasMore       bool                       `protobuf:"varint,2,opt,name=hasMore" json:"hasMore,omitempty"`
 	ID            string                     `protobuf:"bytes,3,opt,name=ID" json:"ID,omitempty"`
+	Metadata      *QueryResponseMetadata     `protobuf:"bytes,4,opt,name=metadata" json:"metadata,omitempty"`
 }
 
 func (m *RangeQueryStateResponse) Reset()                    { *m = RangeQueryStateResponse{} }
 func (m *RangeQueryStateResponse) String() string            { return proto.CompactTextString(m) }
 func (*RangeQueryStateResponse) ProtoMessage()               {}
-func (*RangeQueryStateResponse) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{12} }
+func (*RangeQueryStateResponse) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{22} }
 
 func (m *RangeQueryStateResponse) GetKeysAndValues() []*RangeQueryStateKeyValue {
 	if m != nil {
@@ -420,11 +688,232 @@ func (m *RangeQueryStateResponse) GetKeysAndValues() []*RangeQueryStateKeyValue
 	return nil
 }
 
+func (m *RangeQueryStateResponse) GetMetadata() *QueryResponseMetadata {
+	if m != nil {
+		return m.Metadata
+	}
+	return nil
+}
+
+// Rich query against the state of the chaincode. A non-zero pageSize requests
+// a single page of results starting from the bookmark, otherwise the results
+// are returned in batches like a range query.
+type GetQueryResult struct {
+	Query    string `protobuf:"bytes,1,opt,name=query" json:"query,omitempty"`
+	PageSize int32  `protobuf:"varint,2,opt,name=pageSize" json:"pageSize,omitempty"`
+	Bookmark string `protobuf:"bytes,3,opt,name=bookmark" json:"bookmark,omitempty"`
+}
+
+func (m *GetQueryResult) Reset()                    { *m = GetQueryResult{} }
+func (m *GetQueryResult) String() string            { return proto.CompactTextString(m) }
+func (*GetQueryResult) ProtoMessage()               {}
+func (*GetQueryResult) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{23} }
+
+// Metadata returned with a page of results of a paginated query. The bookmark
+// is empty when there are no more results.
+type QueryResponseMetadata struct {
+	FetchedRecordsCount int32  `protobuf:"varint,1,opt,name=fetchedRecordsCount" json:"fetchedRecordsCount,omitempty"`
+	Bookmark            string `protobuf:"bytes,2,opt,name=bookmark" json:"bookmark,omitempty"`
+}
+
+func (m *QueryResponseMetadata) Reset()                    { *m = QueryResponseMetadata{} }
+func (m *QueryResponseMetadata) String() string            { return proto.CompactTextString(m) }
+func (*QueryResponseMetadata) ProtoMessage()               {}
+func (*QueryResponseMetadata) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{24} }
+
+// Request for the values of multiple keys, read in a single round trip.
+type GetStateMultiple struct {
+	Keys []string `protobuf:"bytes,1,rep,name=keys" json:"keys,omitempty"`
+}
+
+func (m *GetStateMultiple) Reset()                    { *m = GetStateMultiple{} }
+func (m *GetStateMultiple) String() string            { return proto.CompactTextString(m) }
+func (*GetStateMultiple) ProtoMessage()               {}
+func (*GetStateMultiple) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{25} }
+
+// The values are returned in the order of the requested keys. The value of a
+// key that does not exist is empty.
+type GetStateMultipleResponse struct {
+	Values [][]byte `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty"`
+}
+
+func (m *GetStateMultipleResponse) Reset()                    { *m = GetStateMultipleResponse{} }
+func (m *GetStateMultipleResponse) String() string            { return proto.CompactTextString(m) }
+func (*GetStateMultipleResponse) ProtoMessage()               {}
+func (*GetStateMultipleResponse) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{26} }
+
+// Changes the logging level of a running chaincode, sent by the peer outside
+// of any transaction. An empty module sets the level of the shim and the
+// default level of the loggers of the chaincode.
+type SetLogLevel struct {
+	Module string `protobuf:"bytes,1,opt,name=module" json:"module,omitempty"`
+	Level  string `protobuf:"bytes,2,opt,name=level" json:"level,omitempty"`
+}
+
+func (m *SetLogLevel) Reset()                    { *m = SetLogLevel{} }
+func (m *SetLogLevel) String() string            { return proto.CompactTextString(m) }
+func (*SetLogLevel) ProtoMessage()               {}
+func (*SetLogLevel) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{27} }
+
+// Height of the ledger whose state a transaction is simulated on, the payload
+// of the response to GET_LEDGER_HEIGHT. The height is recorded in the read set
+// and invalidates the transaction if a block is committed before it.
+type LedgerHeight struct {
+	Height uint64 `protobuf:"varint,1,opt,name=height" json:"height,omitempty"`
+}
+
+func (m *LedgerHeight) Reset()                    { *m = LedgerHeight{} }
+func (m *LedgerHeight) String() string            { return proto.CompactTextString(m) }
+func (*LedgerHeight) ProtoMessage()               {}
+func (*LedgerHeight) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{28} }
+
+// The configuration values of a chain that chaincodes may read, returned by
+// the GetChannelConfig function of QSCC. Policies names the access control
+// policies of the chain by the resource they control.
+type ChannelConfig struct {
+	ChainID       string            `protobuf:"bytes,1,opt,name=chainID" json:"chainID,omitempty"`
+	Organizations []string          `protobuf:"bytes,2,rep,name=organizations" json:"organizations,omitempty"`
+	Policies      map[string]string `protobuf:"bytes,3,rep,name=policies" json:"policies,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+}
+
+func (m *ChannelConfig) Reset()                    { *m = ChannelConfig{} }
+func (m *ChannelConfig) String() string            { return proto.CompactTextString(m) }
+func (*ChannelConfig) ProtoMessage()               {}
+func (*ChannelConfig) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{29} }
+
+func (m *ChannelConfig) GetPolicies() map[string]string {
+	if m != nil {
+		return m.Policies
+	}
+	return nil
+}
+
+// A chain the peer has joined.
+type ChannelInfo struct {
+	ChainID string `protobuf:"bytes,1,opt,name=chainID" json:"chainID,omitempty"`
+}
+
+func (m *ChannelInfo) Reset()                    { *m = ChannelInfo{} }
+func (m *ChannelInfo) String() string            { return proto.CompactTextString(m) }
+func (*ChannelInfo) ProtoMessage()               {}
+func (*ChannelInfo) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{30} }
+
+// The chains the peer has joined, returned by the GetChannels function of
+// CSCC.
+type ChannelQueryResponse struct {
+	Channels []*ChannelInfo `protobuf:"bytes,1,rep,name=channels" json:"channels,omitempty"`
+}
+
+func (m *ChannelQueryResponse) Reset()                    { *m = ChannelQueryResponse{} }
+func (m *ChannelQueryResponse) String() string            { return proto.CompactTextString(m) }
+func (*ChannelQueryResponse) ProtoMessage()               {}
+func (*ChannelQueryResponse) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{31} }
+
+func (m *ChannelQueryResponse) GetChannels() []*ChannelInfo {
+	if m != nil {
+		return m.Channels
+	}
+	return nil
+}
+
+// An application identity registered with the identity registry system
+// chaincode (ISCC) by a member of its organization. The affiliation is the
+// name of the organization, optionally followed by dot separated units, e.g.
+// org1.department1.
+type RegisteredIdentity struct {
+	Id          string               `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+	Affiliation string               `protobuf:"bytes,2,opt,name=affiliation" json:"affiliation,omitempty"`
+	Attributes  []*IdentityAttribute `protobuf:"bytes,3,rep,name=attributes" json:"attributes,omitempty"`
+}
+
+func (m *RegisteredIdentity) Reset()                    { *m = RegisteredIdentity{} }
+func (m *RegisteredIdentity) String() string            { return proto.CompactTextString(m) }
+func (*RegisteredIdentity) ProtoMessage()               {}
+func (*RegisteredIdentity) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{32} }
+
+func (m *RegisteredIdentity) GetAttributes() []*IdentityAttribute {
+	if m != nil {
+		return m.Attributes
+	}
+	return nil
+}
+
+// A named attribute of a registered identity.
+type IdentityAttribute struct {
+	Name  string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Value string `protobuf:"bytes,2,opt,name=value" json:"value,omitempty"`
+}
+
+func (m *IdentityAttribute) Reset()                    { *m = IdentityAttribute{} }
+func (m *IdentityAttribute) String() string            { return proto.CompactTextString(m) }
+func (*IdentityAttribute) ProtoMessage()               {}
+func (*IdentityAttribute) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{33} }
+
+// The identities registered in an organization, returned by the
+// GetIdentities function of ISCC.
+type RegisteredIdentities struct {
+	Identities []*RegisteredIdentity `protobuf:"bytes,1,rep,name=identities" json:"identities,omitempty"`
+}
+
+func (m *RegisteredIdentities) Reset()                    { *m = RegisteredIdentities{} }
+func (m *RegisteredIdentities) String() string            { return proto.CompactTextString(m) }
+func (*RegisteredIdentities) ProtoMessage()               {}
+func (*RegisteredIdentities) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{34} }
+
+func (m *RegisteredIdentities) GetIdentities() []*RegisteredIdentity {
+	if m != nil {
+		return m.Identities
+	}
+	return nil
+}
+
+// A named application policy defined with the policy system chaincode (PSCC)
+// by the channel admins. Chaincodes check that the creator of a proposal
+// holds a role of the policy.
+type ApplicationPolicy struct {
+	Name  string        `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Roles []*PolicyRole `protobuf:"bytes,2,rep,name=roles" json:"roles,omitempty"`
+}
+
+func (m *ApplicationPolicy) Reset()                    { *m = ApplicationPolicy{} }
+func (m *ApplicationPolicy) String() string            { return proto.CompactTextString(m) }
+func (*ApplicationPolicy) ProtoMessage()               {}
+func (*ApplicationPolicy) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{35} }
+
+func (m *ApplicationPolicy) GetRoles() []*PolicyRole {
+	if m != nil {
+		return m.Roles
+	}
+	return nil
+}
+
+// A role of an application policy and the identities, the principals,
+// holding it.
+type PolicyRole struct {
+	Role       string   `protobuf:"bytes,1,opt,name=role" json:"role,omitempty"`
+	Principals [][]byte `protobuf:"bytes,2,rep,name=principals,proto3" json:"principals,omitempty"`
+}
+
+func (m *PolicyRole) Reset()                    { *m = PolicyRole{} }
+func (m *PolicyRole) String() string            { return proto.CompactTextString(m) }
+func (*PolicyRole) ProtoMessage()               {}
+func (*PolicyRole) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{36} }
+
 func init() {
 	proto.RegisterType((*ChaincodeID)(nil), "protos.ChaincodeID")
 	proto.RegisterType((*ChaincodeInput)(nil), "protos.ChaincodeInput")
 	proto.RegisterType((*ChaincodeSpec)(nil), "protos.ChaincodeSpec")
+	proto.RegisterType((*ChaincodeResourceLimits)(nil), "protos.ChaincodeResourceLimits")
 	proto.RegisterType((*ChaincodeDeploymentSpec)(nil), "protos.ChaincodeDeploymentSpec")
+	proto.RegisterType((*SignedChaincodeDeploymentSpec)(nil), "protos.SignedChaincodeDeploymentSpec")
+	proto.RegisterType((*ChaincodeInstantiationPolicy)(nil), "protos.ChaincodeInstantiationPolicy")
+	proto.RegisterType((*ChaincodeOwnerEndorsement)(nil), "protos.ChaincodeOwnerEndorsement")
+	proto.RegisterType((*ChaincodeServerInfo)(nil), "protos.ChaincodeServerInfo")
+	proto.RegisterType((*ChaincodeDefinition)(nil), "protos.ChaincodeDefinition")
+	proto.RegisterType((*ChaincodeInterest)(nil), "protos.ChaincodeInterest")
+	proto.RegisterType((*ChaincodeInterests)(nil), "protos.ChaincodeInterests")
+	proto.RegisterType((*ChaincodeInfo)(nil), "protos.ChaincodeInfo")
+	proto.RegisterType((*ChaincodeQueryResponse)(nil), "protos.ChaincodeQueryResponse")
 	proto.RegisterType((*ChaincodeInvocationSpec)(nil), "protos.ChaincodeInvocationSpec")
 	proto.RegisterType((*ChaincodeSecurityContext)(nil), "protos.ChaincodeSecurityContext")
 	proto.RegisterType((*ChaincodeMessage)(nil), "protos.ChaincodeMessage")
@@ -434,6 +923,20 @@ func init() {
 	proto.RegisterType((*RangeQueryStateClose)(nil), "protos.RangeQueryStateClose")
 	proto.RegisterType((*RangeQueryStateKeyValue)(nil), "protos.RangeQueryStateKeyValue")
 	proto.RegisterType((*RangeQueryStateResponse)(nil), "protos.RangeQueryStateResponse")
+	proto.RegisterType((*GetQueryResult)(nil), "protos.GetQueryResult")
+	proto.RegisterType((*QueryResponseMetadata)(nil), "protos.QueryResponseMetadata")
+	proto.RegisterType((*GetStateMultiple)(nil), "protos.GetStateMultiple")
+	proto.RegisterType((*GetStateMultipleResponse)(nil), "protos.GetStateMultipleResponse")
+	proto.RegisterType((*SetLogLevel)(nil), "protos.SetLogLevel")
+	proto.RegisterType((*LedgerHeight)(nil), "protos.LedgerHeight")
+	proto.RegisterType((*ChannelConfig)(nil), "protos.ChannelConfig")
+	proto.RegisterType((*ChannelInfo)(nil), "protos.ChannelInfo")
+	proto.RegisterType((*ChannelQueryResponse)(nil), "protos.ChannelQueryResponse")
+	proto.RegisterType((*RegisteredIdentity)(nil), "protos.RegisteredIdentity")
+	proto.RegisterType((*IdentityAttribute)(nil), "protos.IdentityAttribute")
+	proto.RegisterType((*RegisteredIdentities)(nil), "protos.RegisteredIdentities")
+	proto.RegisterType((*ApplicationPolicy)(nil), "protos.ApplicationPolicy")
+	proto.RegisterType((*PolicyRole)(nil), "protos.PolicyRole")
 	proto.RegisterEnum("protos.ConfidentialityLevel", ConfidentialityLevel_name, ConfidentialityLevel_value)
 	proto.RegisterEnum("protos.ChaincodeSpec_Type", ChaincodeSpec_Type_name, ChaincodeSpec_Type_value)
 	proto.RegisterEnum("protos.ChaincodeDeploymentSpec_ExecutionEnvironment", ChaincodeDeploymentSpec_ExecutionEnvironment_name, ChaincodeDeploymentSpec_ExecutionEnvironment_value)
@@ -544,84 +1047,249 @@ var _ChaincodeSupport_serviceDesc = grpc.ServiceDesc{
 	Metadata: fileDescriptor3,
 }
 
+// Client API for Chaincode service
+
+type ChaincodeClient interface {
+	Connect(ctx context.Context, opts ...grpc.CallOption) (Chaincode_ConnectClient, error)
+}
+
+type chaincodeClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewChaincodeClient(cc *grpc.ClientConn) ChaincodeClient {
+	return &chaincodeClient{cc}
+}
+
+func (c *chaincodeClient) Connect(ctx context.Context, opts ...grpc.CallOption) (Chaincode_ConnectClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Chaincode_serviceDesc.Streams[0], c.cc, "/protos.Chaincode/Connect", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &chaincodeConnectClient{stream}
+	return x, nil
+}
+
+type Chaincode_ConnectClient interface {
+	Send(*ChaincodeMessage) error
+	Recv() (*ChaincodeMessage, error)
+	grpc.ClientStream
+}
+
+type chaincodeConnectClient struct {
+	grpc.ClientStream
+}
+
+func (x *chaincodeConnectClient) Send(m *ChaincodeMessage) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *chaincodeConnectClient) Recv() (*ChaincodeMessage, error) {
+	m := new(ChaincodeMessage)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Server API for Chaincode service
+
+type ChaincodeServer interface {
+	Connect(Chaincode_ConnectServer) error
+}
+
+func RegisterChaincodeServer(s *grpc.Server, srv ChaincodeServer) {
+	s.RegisterService(&_Chaincode_serviceDesc, srv)
+}
+
+func _Chaincode_Connect_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ChaincodeServer).Connect(&chaincodeConnectServer{stream})
+}
+
+type Chaincode_ConnectServer interface {
+	Send(*ChaincodeMessage) error
+	Recv() (*ChaincodeMessage, error)
+	grpc.ServerStream
+}
+
+type chaincodeConnectServer struct {
+	grpc.ServerStream
+}
+
+func (x *chaincodeConnectServer) Send(m *ChaincodeMessage) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *chaincodeConnectServer) Recv() (*ChaincodeMessage, error) {
+	m := new(ChaincodeMessage)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+var _Chaincode_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "protos.Chaincode",
+	HandlerType: (*ChaincodeServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Connect",
+			Handler:       _Chaincode_Connect_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: fileDescriptor3,
+}
+
 func init() { proto.RegisterFile("chaincode.proto", fileDescriptor3) }
 
 var fileDescriptor3 = []byte{
-	// 1201 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0xa4, 0x56, 0xcd, 0x6e, 0xdb, 0xc6,
-	0x13, 0x8f, 0xbe, 0x6c, 0x69, 0xf4, 0xb5, 0x59, 0x2b, 0x8e, 0xa0, 0xff, 0xbf, 0x89, 0x40, 0xa4,
-	0x81, 0xd0, 0x83, 0x9c, 0xaa, 0x49, 0x51, 0xa0, 0x45, 0x50, 0x46, 0xdc, 0xb8, 0x8c, 0x65, 0x4a,
-	0x59, 0xd1, 0x46, 0xd2, 0x8b, 0x41, 0x53, 0x6b, 0x9a, 0x88, 0x4c, 0x12, 0xe4, 0x4a, 0xb0, 0x6e,
-	0x3d, 0xf7, 0xd4, 0x67, 0xe8, 0x43, 0xf4, 0xd0, 0x07, 0xea, 0x73, 0x14, 0xbb, 0x24, 0x65, 0x7d,
-	0x39, 0x08, 0xd0, 0x13, 0x77, 0x66, 0x7e, 0x33, 0x3b, 0x3b, 0xf3, 0xdb, 0xe1, 0x42, 0xdd, 0xbe,
-	0xb6, 0x5c, 0xcf, 0xf6, 0x27, 0xac, 0x1b, 0x84, 0x3e, 0xf7, 0xf1, 0x9e, 0xfc, 0x44, 0xad, 0xc6,
-	0xd2, 0xc0, 0xe6, 0xcc, 0xe3, 0xb1, 0xb5, 0xf5, 0xd4, 0xf1, 0x7d, 0x67, 0xca, 0x8e, 0xa4, 0x74,
-	0x39, 0xbb, 0x3a, 0xe2, 0xee, 0x0d, 0x8b, 0xb8, 0x75, 0x13, 0xc4, 0x00, 0xe5, 0x15, 0x94, 0xfb,
-	0xa9, 0xa3, 0xae, 0x61, 0x0c, 0xf9, 0xc0, 0xe2, 0xd7, 0xcd, 0x4c, 0x3b, 0xd3, 0x29, 0x51, 0xb9,
-	0x16, 0x3a, 0xcf, 0xba, 0x61, 0xcd, 0x6c, 0xac, 0x13, 0x6b, 0xe5, 0x19, 0xd4, 0xee, 0xdc, 0xbc,
-	0x60, 0xc6, 0x05, 0xca, 0x0a, 0x9d, 0xa8, 0x99, 0x69, 0xe7, 0x3a, 0x15, 0x2a, 0xd7, 0xca, 0x5f,
-	0x39, 0xa8, 0x2e, 0x61, 0xe3, 0x80, 0xd9, 0xb8, 0x0b, 0x79, 0xbe, 0x08, 0x98, 0x8c, 0x5f, 0xeb,
-	0xb5, 0xe2, 0x24, 0xa2, 0xee, 0x1a, 0xa8, 0x6b, 0x2e, 0x02, 0x46, 0x25, 0x0e, 0xbf, 0x82, 0xb2,
-	0x7d, 0x97, 0x9e, 0x4c, 0xa1, 0xdc, 0x3b, 0xd8, 0x72, 0xd3, 0x35, 0xba, 0x8a, 0xc3, 0x2f, 0x60,
-	0xdf, 0xe6, 0x7e, 0x78, 0x1a, 0x39, 0xcd, 0x9c, 0x74, 0x39, 0xdc, 0x76, 0x11, 0x59, 0xd3, 0x14,
-	0x86, 0x9b, 0xb0, 0x2f, 0x4a, 0xe3, 0xcf, 0x78, 0x33, 0xdf, 0xce, 0x74, 0x0a, 0x34, 0x15, 0xf1,
-	0x33, 0xa8, 0x46, 0xcc, 0x9e, 0x85, 0xac, 0xef, 0x7b, 0x9c, 0xdd, 0xf2, 0x66, 0x41, 0xd6, 0x61,
-	0x5d, 0x89, 0x47, 0xd0, 0xb0, 0x7d, 0xef, 0xca, 0x9d, 0x30, 0x8f, 0xbb, 0xd6, 0xd4, 0xe5, 0x8b,
-	0x01, 0x9b, 0xb3, 0x69, 0x73, 0x4f, 0x1e, 0xf4, 0xff, 0xcb, 0xed, 0x77, 0x60, 0xe8, 0x4e, 0x4f,
-	0xdc, 0x82, 0xe2, 0x0d, 0xe3, 0xd6, 0xc4, 0xe2, 0x56, 0x73, 0xbf, 0x9d, 0xe9, 0x54, 0xe8, 0x52,
-	0xc6, 0x4f, 0x00, 0x2c, 0xce, 0x43, 0xf7, 0x72, 0xc6, 0x59, 0xd4, 0x2c, 0xb6, 0x73, 0x9d, 0x12,
-	0x5d, 0xd1, 0x28, 0xaf, 0x21, 0x2f, 0x8a, 0x88, 0xab, 0x50, 0x3a, 0x33, 0x34, 0xf2, 0x56, 0x37,
-	0x88, 0x86, 0x1e, 0x60, 0x80, 0xbd, 0xe3, 0xe1, 0x40, 0x35, 0x8e, 0x51, 0x06, 0x17, 0x21, 0x6f,
-	0x0c, 0x35, 0x82, 0xb2, 0x78, 0x1f, 0x72, 0x7d, 0x95, 0xa2, 0x9c, 0x50, 0xbd, 0x53, 0xcf, 0x55,
-	0x94, 0x57, 0xfe, 0xce, 0xc2, 0xe3, 0x65, 0xa5, 0x34, 0x16, 0x4c, 0xfd, 0xc5, 0x0d, 0xf3, 0xb8,
-	0x6c, 0xe1, 0x8f, 0x50, 0xb5, 0x57, 0xdb, 0x25, 0x7b, 0x59, 0xee, 0x3d, 0xda, 0xd9, 0x4b, 0xba,
-	0x8e, 0xc5, 0x3f, 0x43, 0x95, 0x5d, 0x5d, 0x31, 0x9b, 0xbb, 0x73, 0xa6, 0x59, 0x9c, 0x25, 0x1d,
-	0x6d, 0x75, 0x63, 0x9e, 0x76, 0x53, 0x9e, 0x76, 0xcd, 0x94, 0xa7, 0x74, 0xdd, 0x01, 0xb7, 0xa1,
-	0x2c, 0xa2, 0x8d, 0x2c, 0xfb, 0x93, 0xe5, 0x30, 0xd9, 0xde, 0x0a, 0x5d, 0x55, 0x61, 0x03, 0xf6,
-	0xd9, 0x2d, 0xb3, 0x89, 0x37, 0x97, 0xad, 0xac, 0xf5, 0x5e, 0x6e, 0xa5, 0xb6, 0x7e, 0xa4, 0x2e,
-	0xb9, 0x65, 0xf6, 0x8c, 0xbb, 0xbe, 0x47, 0xbc, 0xb9, 0x1b, 0xfa, 0x9e, 0x30, 0xd0, 0x34, 0x88,
-	0xd2, 0x85, 0xc6, 0x2e, 0x80, 0xa8, 0xa6, 0x36, 0xec, 0x9f, 0x10, 0x1a, 0x57, 0x76, 0xfc, 0x71,
-	0x6c, 0x92, 0x53, 0x94, 0x51, 0x7e, 0xcb, 0xac, 0x14, 0x4f, 0xf7, 0xe6, 0xbe, 0x6d, 0x09, 0xd7,
-	0xff, 0x5e, 0xbc, 0x0e, 0xd4, 0xdd, 0xc9, 0x31, 0xf3, 0x58, 0x28, 0x03, 0xaa, 0x53, 0x27, 0xb9,
-	0x93, 0x9b, 0x6a, 0xe5, 0x8f, 0x2c, 0x34, 0xef, 0x42, 0x09, 0xa2, 0xba, 0x7c, 0x91, 0x52, 0xf5,
-	0x09, 0x80, 0x6d, 0x4d, 0xa7, 0x2c, 0xec, 0xb3, 0x90, 0xcb, 0x04, 0x2a, 0x74, 0x45, 0x73, 0x67,
-	0x1f, 0xbb, 0x8e, 0x27, 0x77, 0x58, 0xda, 0x85, 0x46, 0x5c, 0x95, 0xc0, 0x5a, 0x4c, 0x7d, 0x6b,
-	0x92, 0x54, 0x3f, 0x15, 0x85, 0xe5, 0xd2, 0xf5, 0x26, 0xae, 0xe7, 0xc8, 0xca, 0x57, 0x68, 0x2a,
-	0xae, 0x91, 0xb9, 0xb0, 0x41, 0xe6, 0xe7, 0x50, 0x0b, 0xac, 0x90, 0x79, 0xfc, 0x34, 0x45, 0xec,
-	0x49, 0xc4, 0x86, 0x16, 0xff, 0x04, 0x65, 0x7e, 0xbb, 0xe4, 0x85, 0xbc, 0x13, 0x9f, 0x67, 0xce,
-	0x2a, 0x5c, 0xf9, 0xb3, 0x00, 0x68, 0x59, 0x92, 0x53, 0x16, 0x45, 0x82, 0x2a, 0xdf, 0xae, 0x8d,
-	0xa3, 0xaf, 0xb6, 0xba, 0x90, 0xe0, 0x56, 0x27, 0xd2, 0x0f, 0x50, 0x5a, 0xce, 0xd0, 0x2f, 0x60,
-	0xef, 0x1d, 0xf8, 0x33, 0x75, 0xc3, 0x90, 0xe7, 0xb7, 0xee, 0x44, 0x16, 0xad, 0x44, 0xe5, 0x1a,
-	0xbf, 0x83, 0x7a, 0xb4, 0xde, 0x38, 0x59, 0xb8, 0x72, 0xaf, 0xbd, 0xcd, 0x95, 0x75, 0x1c, 0xdd,
-	0x74, 0xc4, 0xaf, 0xa1, 0xb6, 0x64, 0x12, 0x11, 0x7f, 0x07, 0x59, 0xe1, 0x5d, 0x53, 0x51, 0x5a,
-	0xe9, 0x06, 0x5a, 0xf9, 0x27, 0xbb, 0x7b, 0x9e, 0x54, 0xa0, 0x48, 0xc9, 0xb1, 0x3e, 0x36, 0x09,
-	0x45, 0x19, 0x5c, 0x03, 0x48, 0x25, 0xa2, 0xa1, 0xac, 0x18, 0x27, 0xba, 0xa1, 0x9b, 0x28, 0x87,
-	0x4b, 0x50, 0xa0, 0x44, 0xd5, 0x3e, 0xa2, 0x3c, 0xae, 0x43, 0xd9, 0xa4, 0xaa, 0x31, 0x56, 0xfb,
-	0xa6, 0x3e, 0x34, 0x50, 0x41, 0x84, 0xec, 0x0f, 0x4f, 0x47, 0x03, 0x62, 0x12, 0x0d, 0xed, 0x09,
-	0x28, 0xa1, 0x74, 0x48, 0xd1, 0xbe, 0xb0, 0x1c, 0x13, 0xf3, 0x62, 0x6c, 0xaa, 0x26, 0x41, 0x45,
-	0x21, 0x8e, 0xce, 0x52, 0xb1, 0x24, 0x44, 0x8d, 0x0c, 0x12, 0x11, 0x70, 0x03, 0x90, 0x6e, 0x9c,
-	0x0f, 0x4f, 0xc8, 0x45, 0xff, 0x17, 0x55, 0x37, 0xfa, 0x62, 0xb4, 0x95, 0x31, 0x82, 0x4a, 0xa2,
-	0x7d, 0x7f, 0x46, 0xe8, 0x47, 0x54, 0x89, 0x53, 0x1e, 0x8f, 0x86, 0xc6, 0x98, 0xa0, 0xaa, 0xd8,
-	0x2d, 0x36, 0xd4, 0xf0, 0x01, 0xd4, 0xe5, 0xf2, 0xe2, 0x2e, 0x9b, 0xba, 0xc8, 0x36, 0x56, 0xc6,
-	0x39, 0x21, 0xfc, 0x08, 0x1e, 0x52, 0xd5, 0x38, 0x4e, 0xe2, 0x25, 0xbb, 0x3f, 0xc4, 0x2d, 0x38,
-	0xdc, 0x52, 0x5f, 0x18, 0xe4, 0x83, 0x89, 0x30, 0xfe, 0x1f, 0x3c, 0xde, 0xb6, 0xf5, 0x07, 0xc3,
-	0x31, 0x41, 0x07, 0xe2, 0x14, 0x27, 0x84, 0x8c, 0xd4, 0x81, 0x7e, 0x4e, 0x50, 0x43, 0xf9, 0x1e,
-	0x2a, 0xa3, 0x19, 0x1f, 0x73, 0x8b, 0x33, 0xdd, 0xbb, 0xf2, 0x31, 0x82, 0xdc, 0x27, 0xb6, 0x48,
-	0xfe, 0xc6, 0x62, 0x89, 0x1b, 0x50, 0x98, 0x5b, 0xd3, 0x19, 0x4b, 0xee, 0x65, 0x2c, 0x28, 0x04,
-	0xea, 0xd4, 0xf2, 0x1c, 0xf6, 0x7e, 0xc6, 0xc2, 0x85, 0x74, 0x17, 0x37, 0x2e, 0xe2, 0x56, 0xc8,
-	0x4f, 0x96, 0xfe, 0x4b, 0x19, 0x1f, 0xc2, 0x1e, 0xf3, 0x26, 0xc2, 0x12, 0xcf, 0x8f, 0x44, 0x52,
-	0xbe, 0x86, 0x83, 0x8d, 0x30, 0x86, 0xa0, 0x4f, 0x0d, 0xb2, 0xba, 0x96, 0x04, 0xc9, 0xea, 0x9a,
-	0xf2, 0x1c, 0x1a, 0x1b, 0xb0, 0xfe, 0xd4, 0x8f, 0xd8, 0x16, 0x4e, 0x85, 0xc7, 0x1b, 0xb8, 0x13,
-	0xb6, 0x38, 0x17, 0x09, 0x7f, 0xf1, 0xc1, 0x7e, 0xcf, 0x6c, 0xc5, 0xa0, 0x2c, 0x0a, 0x7c, 0x2f,
-	0x62, 0x98, 0x40, 0xf5, 0x13, 0x5b, 0x44, 0xaa, 0x37, 0x91, 0x31, 0xe3, 0xa7, 0x47, 0xb9, 0xf7,
-	0x34, 0x25, 0xf5, 0x3d, 0x7b, 0xd3, 0x75, 0x2f, 0x71, 0x2d, 0xaf, 0xad, 0xe8, 0xd4, 0x0f, 0xe3,
-	0xad, 0x8b, 0x34, 0x15, 0x93, 0xf3, 0xe4, 0xd2, 0xf3, 0x7c, 0xf3, 0x12, 0x1a, 0xbb, 0xfe, 0xdf,
-	0x62, 0xf8, 0x8f, 0xce, 0xde, 0x0c, 0xf4, 0x3e, 0x7a, 0x20, 0x18, 0xd7, 0x1f, 0x1a, 0x6f, 0x75,
-	0x8d, 0x18, 0xa6, 0xae, 0x0e, 0x50, 0xa6, 0xf7, 0x61, 0x65, 0xee, 0x8c, 0x67, 0x41, 0xe0, 0x87,
-	0x1c, 0x6b, 0x50, 0xa4, 0xcc, 0x71, 0x23, 0xce, 0x42, 0xdc, 0xbc, 0x6f, 0xea, 0xb4, 0xee, 0xb5,
-	0x28, 0x0f, 0x3a, 0x99, 0x17, 0x99, 0x37, 0xaf, 0xe1, 0xd0, 0x0f, 0x9d, 0xee, 0xf5, 0x22, 0x60,
-	0xe1, 0x94, 0x4d, 0x1c, 0x16, 0x26, 0x0e, 0xbf, 0x3e, 0x73, 0x5c, 0x7e, 0x3d, 0xbb, 0xec, 0xda,
-	0xfe, 0xcd, 0xd1, 0x8a, 0xf9, 0xe8, 0xca, 0xba, 0x0c, 0x5d, 0x3b, 0x7e, 0x0d, 0x46, 0x97, 0xf1,
-	0xd3, 0xf1, 0xbb, 0x7f, 0x03, 0x00, 0x00, 0xff, 0xff, 0xbe, 0x88, 0x9a, 0x2a, 0x54, 0x0a, 0x00,
-	0x00,
+	// 2319 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x18, 0x4d, 0x73, 0xdb, 0xc6,
+	0xd5, 0x24, 0x45, 0x89, 0x7c, 0xa4, 0x24, 0x68, 0xf5, 0x61, 0x46, 0x71, 0x1c, 0x15, 0x75, 0x1d,
+	0x4d, 0x27, 0xa5, 0x5d, 0x36, 0x69, 0x9d, 0x38, 0x75, 0xc3, 0x90, 0x1b, 0x9a, 0x31, 0x45, 0xd2,
+	0x4b, 0xca, 0x63, 0xf7, 0x50, 0x0d, 0x04, 0x2c, 0x49, 0x8c, 0x41, 0x2c, 0x0a, 0x2c, 0x19, 0x33,
+	0x33, 0x9d, 0x69, 0x7f, 0x41, 0x7b, 0xeb, 0xa1, 0xf7, 0x5e, 0xfb, 0x03, 0x3a, 0xd3, 0x5b, 0x8f,
+	0xfd, 0x07, 0xfd, 0x2f, 0xed, 0xec, 0xe2, 0x83, 0x00, 0x01, 0x25, 0x6e, 0x73, 0x02, 0xde, 0xc7,
+	0xbe, 0xcf, 0xdd, 0xf7, 0xde, 0x2e, 0xec, 0xeb, 0x33, 0xcd, 0xb4, 0x75, 0x66, 0xd0, 0xba, 0xe3,
+	0x32, 0xce, 0xd0, 0xb6, 0xfc, 0x78, 0xa7, 0x47, 0x11, 0x81, 0x2e, 0xa9, 0xcd, 0x7d, 0xea, 0xe9,
+	0xf1, 0x44, 0xbb, 0x76, 0x4d, 0xfd, 0xca, 0x71, 0x99, 0xc3, 0x3c, 0xcd, 0x0a, 0xd0, 0x77, 0x37,
+	0xd0, 0x57, 0x2e, 0xf5, 0x1c, 0x66, 0x7b, 0x81, 0xd0, 0xd3, 0xf7, 0xa7, 0x8c, 0x4d, 0x2d, 0xfa,
+	0x40, 0x42, 0xd7, 0x8b, 0xc9, 0x03, 0x6e, 0xce, 0xa9, 0xc7, 0xb5, 0xb9, 0xe3, 0x33, 0xa8, 0x03,
+	0xa8, 0xb4, 0x42, 0x7d, 0xdd, 0x36, 0x42, 0xb0, 0xe5, 0x68, 0x7c, 0x56, 0xcb, 0x9d, 0xe5, 0xce,
+	0xcb, 0x44, 0xfe, 0x0b, 0x9c, 0xad, 0xcd, 0x69, 0x2d, 0xef, 0xe3, 0xc4, 0x3f, 0xaa, 0xc1, 0xce,
+	0x92, 0xba, 0x9e, 0xc9, 0xec, 0x5a, 0x41, 0xa2, 0x43, 0x50, 0xfd, 0x5b, 0x0e, 0xf6, 0xd6, 0x12,
+	0x6d, 0x67, 0xc1, 0x85, 0x00, 0xcd, 0x9d, 0x7a, 0xb5, 0xdc, 0x59, 0xe1, 0xbc, 0x4a, 0xe4, 0x3f,
+	0xea, 0x42, 0xc5, 0xa0, 0x3a, 0x73, 0x35, 0x6e, 0x32, 0xdb, 0xab, 0xe5, 0xcf, 0x0a, 0xe7, 0x95,
+	0xc6, 0x07, 0xbe, 0x51, 0x5e, 0x3d, 0x29, 0xa0, 0xde, 0x5e, 0x73, 0x62, 0x9b, 0xbb, 0x2b, 0x12,
+	0x5f, 0x7b, 0xfa, 0x04, 0x94, 0x4d, 0x06, 0xa4, 0x40, 0xe1, 0x35, 0x5d, 0x05, 0x6e, 0x88, 0x5f,
+	0x74, 0x04, 0xc5, 0xa5, 0x66, 0x2d, 0x7c, 0x37, 0xaa, 0xc4, 0x07, 0x3e, 0xcd, 0x3f, 0xca, 0xa9,
+	0xff, 0x29, 0xc0, 0x6e, 0xa4, 0x70, 0xe4, 0x50, 0x1d, 0xd5, 0x61, 0x8b, 0xaf, 0x1c, 0x2a, 0x97,
+	0xef, 0x35, 0x4e, 0x53, 0x56, 0x09, 0xa6, 0xfa, 0x78, 0xe5, 0x50, 0x22, 0xf9, 0xd0, 0xc7, 0x50,
+	0xd1, 0xd7, 0x41, 0x94, 0x1a, 0x2a, 0x8d, 0xc3, 0xb4, 0x33, 0x6d, 0x12, 0xe7, 0x43, 0x0f, 0x61,
+	0x47, 0xe7, 0xcc, 0xbd, 0xf0, 0xa6, 0x32, 0x88, 0x95, 0xc6, 0x49, 0xb6, 0xff, 0x24, 0x64, 0x13,
+	0x61, 0x17, 0x09, 0x64, 0x0b, 0x5e, 0xdb, 0x3a, 0xcb, 0x9d, 0x17, 0x49, 0x08, 0xa2, 0x7b, 0xb0,
+	0xeb, 0x51, 0x7d, 0xe1, 0xd2, 0x16, 0xb3, 0x39, 0x7d, 0xc3, 0x6b, 0x45, 0xe9, 0x7a, 0x12, 0x89,
+	0x86, 0x70, 0xa4, 0x33, 0x7b, 0x62, 0x1a, 0xd4, 0xe6, 0xa6, 0x66, 0x99, 0x7c, 0xd5, 0xa3, 0x4b,
+	0x6a, 0xd5, 0xb6, 0xa5, 0xa3, 0x77, 0x22, 0xf5, 0x19, 0x3c, 0x24, 0x73, 0x25, 0x3a, 0x85, 0xd2,
+	0x9c, 0x72, 0xcd, 0xd0, 0xb8, 0x56, 0xdb, 0x91, 0x91, 0x8d, 0x60, 0x74, 0x17, 0x40, 0xe3, 0xdc,
+	0x35, 0xaf, 0x17, 0x9c, 0x7a, 0xb5, 0xd2, 0x59, 0xe1, 0xbc, 0x4c, 0x62, 0x18, 0xd4, 0x81, 0x3d,
+	0x97, 0x7a, 0x6c, 0xe1, 0xea, 0xb4, 0x67, 0xce, 0x4d, 0xee, 0xd5, 0xca, 0x32, 0x0c, 0xef, 0xa7,
+	0xc2, 0x40, 0x12, 0x6c, 0x64, 0x63, 0x99, 0xfa, 0x04, 0xb6, 0x44, 0x36, 0xd0, 0x2e, 0x94, 0x2f,
+	0xfb, 0x6d, 0xfc, 0x65, 0xb7, 0x8f, 0xdb, 0xca, 0x2d, 0x04, 0xb0, 0xdd, 0x19, 0xf4, 0x9a, 0xfd,
+	0x8e, 0x92, 0x43, 0x25, 0xd8, 0xea, 0x0f, 0xda, 0x58, 0xc9, 0xa3, 0x1d, 0x28, 0xb4, 0x9a, 0x44,
+	0x29, 0x08, 0xd4, 0x57, 0xcd, 0x17, 0x4d, 0x65, 0x4b, 0x9d, 0xc3, 0xed, 0x1b, 0x54, 0xa1, 0x3b,
+	0x50, 0xd6, 0x9d, 0xc5, 0x68, 0xa6, 0xb9, 0xd4, 0x93, 0xfb, 0xa1, 0x40, 0xd6, 0x08, 0x74, 0x02,
+	0xdb, 0x73, 0x3a, 0x67, 0xee, 0x4a, 0xe6, 0xbc, 0x40, 0x02, 0x48, 0xac, 0x72, 0x4c, 0xc3, 0x93,
+	0x32, 0x64, 0x6e, 0x0b, 0x64, 0x8d, 0x50, 0xff, 0x58, 0x88, 0xe9, 0x6b, 0x53, 0xc7, 0x62, 0xab,
+	0x39, 0xb5, 0xb9, 0xdc, 0x7a, 0x8f, 0x61, 0x57, 0x8f, 0x6f, 0x33, 0xa9, 0xb3, 0xd2, 0x38, 0xce,
+	0xdc, 0x83, 0x24, 0xc9, 0x8b, 0x3e, 0x87, 0x5d, 0x3a, 0x99, 0x50, 0x9d, 0x9b, 0x4b, 0xda, 0xd6,
+	0x38, 0x0d, 0x76, 0xe2, 0x69, 0xdd, 0xaf, 0x02, 0xf5, 0xb0, 0x0a, 0xd4, 0xc7, 0x61, 0x15, 0x20,
+	0xc9, 0x05, 0xe8, 0x0c, 0x2a, 0x42, 0xda, 0x50, 0xd3, 0x5f, 0x6b, 0x53, 0x2a, 0x4d, 0xaf, 0x92,
+	0x38, 0x0a, 0xf5, 0x61, 0x87, 0xbe, 0xa1, 0x3a, 0xb6, 0x97, 0x72, 0x0b, 0xee, 0x35, 0x3e, 0x4a,
+	0x99, 0x96, 0x74, 0xa9, 0x8e, 0xdf, 0x50, 0x7d, 0x21, 0xce, 0x26, 0xb6, 0x97, 0xa6, 0xcb, 0x6c,
+	0x41, 0x20, 0xa1, 0x10, 0x84, 0x63, 0x95, 0x70, 0x44, 0xdd, 0x25, 0x75, 0xe5, 0xd6, 0xad, 0x34,
+	0xde, 0x4d, 0xbb, 0x2c, 0xc9, 0x5d, 0x7b, 0xc2, 0xc8, 0xe6, 0x1a, 0xf5, 0x33, 0x38, 0xca, 0xd2,
+	0x23, 0xf6, 0x40, 0x7b, 0xd0, 0x7a, 0x86, 0x89, 0xbf, 0x1f, 0x46, 0xaf, 0x46, 0x63, 0x7c, 0xa1,
+	0xe4, 0x50, 0x15, 0x4a, 0xf8, 0xe5, 0x18, 0x93, 0x7e, 0xb3, 0xa7, 0xe4, 0xd5, 0x7f, 0xe7, 0xe0,
+	0xbd, 0x91, 0x39, 0xb5, 0xa9, 0x71, 0x53, 0x5e, 0x1e, 0xc1, 0x6d, 0x3d, 0x9b, 0x24, 0x33, 0x54,
+	0x25, 0x37, 0x91, 0xd1, 0x43, 0x38, 0x34, 0x6d, 0x8f, 0x6b, 0xe2, 0xdc, 0x08, 0xeb, 0x86, 0xcc,
+	0x32, 0xf5, 0x55, 0x50, 0x86, 0xb2, 0x48, 0x68, 0x00, 0x07, 0xec, 0x6b, 0x9b, 0xba, 0xd8, 0x36,
+	0x98, 0xeb, 0x51, 0x21, 0xc9, 0xab, 0x15, 0x64, 0x85, 0xfc, 0x41, 0x2a, 0x28, 0x83, 0x0d, 0x4e,
+	0x92, 0x5e, 0xab, 0x3e, 0x81, 0x3b, 0xb1, 0x8a, 0x92, 0x56, 0x78, 0x17, 0xc0, 0x3f, 0xd8, 0xdc,
+	0xa4, 0x61, 0x99, 0x8e, 0x61, 0xd4, 0x4b, 0x78, 0xe7, 0x46, 0x7d, 0xa2, 0x02, 0x50, 0x1f, 0x74,
+	0x83, 0x50, 0x44, 0xb0, 0x38, 0x07, 0x9e, 0x39, 0xb5, 0x35, 0xbe, 0x70, 0xc3, 0xc2, 0xbb, 0x46,
+	0xa8, 0x7f, 0xc9, 0xc1, 0x61, 0x46, 0x72, 0x45, 0x95, 0xd3, 0x0c, 0xc3, 0xa5, 0x9e, 0x17, 0x14,
+	0xf0, 0x10, 0x14, 0x86, 0x72, 0xcb, 0xc3, 0xb6, 0x76, 0x6d, 0x51, 0x43, 0x0a, 0x2c, 0x91, 0x18,
+	0x46, 0xd8, 0xe2, 0x32, 0xc6, 0x5b, 0xd4, 0xe5, 0xc1, 0xde, 0x8d, 0x60, 0x54, 0x07, 0xe4, 0x49,
+	0x1d, 0x4f, 0x99, 0xc7, 0x07, 0x4b, 0xea, 0xba, 0xa6, 0x41, 0xe5, 0x1e, 0x2e, 0x93, 0x0c, 0x8a,
+	0xfa, 0xd7, 0x7c, 0xcc, 0xba, 0x36, 0x9d, 0x98, 0xb6, 0x29, 0x42, 0x16, 0xb5, 0xc3, 0x5c, 0x76,
+	0x3b, 0xcc, 0x27, 0xda, 0x21, 0xfa, 0x10, 0x0e, 0xe8, 0x3a, 0x58, 0x41, 0xee, 0x7d, 0xd3, 0xd2,
+	0x04, 0xff, 0xf8, 0x59, 0x16, 0xd5, 0xfd, 0xae, 0xb8, 0x15, 0x1e, 0xbf, 0x08, 0x15, 0xef, 0x19,
+	0xc5, 0xb7, 0xeb, 0x19, 0xbf, 0x80, 0xb2, 0x69, 0x73, 0xea, 0x52, 0x8f, 0x7b, 0xb5, 0x6d, 0xb9,
+	0x8b, 0xde, 0xc9, 0x58, 0xe3, 0x73, 0x90, 0x35, 0xaf, 0x70, 0x94, 0x7a, 0xba, 0x2e, 0xcb, 0x7a,
+	0x99, 0xc8, 0x7f, 0x81, 0x5b, 0x0a, 0x5c, 0xc9, 0xc7, 0x89, 0x7f, 0xf5, 0x6b, 0x38, 0x48, 0xc9,
+	0x11, 0x99, 0x98, 0x2c, 0x6c, 0x69, 0x74, 0x10, 0xa9, 0x08, 0xde, 0xf4, 0x32, 0x2f, 0x1b, 0x43,
+	0xc2, 0xcb, 0x7b, 0xb0, 0xcb, 0xdc, 0xa9, 0x66, 0x9b, 0xdf, 0x04, 0xf3, 0x41, 0x41, 0xf2, 0x24,
+	0x91, 0xea, 0x05, 0xa0, 0x94, 0x62, 0x2f, 0xe9, 0x6f, 0xee, 0xed, 0xfd, 0x55, 0xff, 0x9e, 0x8b,
+	0xcd, 0x01, 0x72, 0x23, 0xfe, 0x6f, 0xa9, 0x0e, 0x67, 0xa7, 0x42, 0x72, 0x76, 0x9a, 0x69, 0xde,
+	0x2c, 0xc8, 0xa4, 0xfc, 0xcf, 0xde, 0x12, 0xc5, 0x9b, 0xb6, 0x44, 0x98, 0x85, 0xed, 0x8c, 0x2c,
+	0xec, 0xc4, 0xb2, 0x30, 0x80, 0x93, 0xc8, 0xf8, 0xe7, 0x0b, 0xea, 0xae, 0x48, 0x30, 0x09, 0xa2,
+	0x8f, 0x01, 0xa2, 0xda, 0x14, 0x46, 0xe4, 0x38, 0x23, 0x22, 0x13, 0x46, 0x62, 0x8c, 0xea, 0xef,
+	0x73, 0xb1, 0x2e, 0xd5, 0xb5, 0x97, 0x4c, 0x97, 0x61, 0xff, 0xfe, 0x5d, 0xea, 0x1c, 0xf6, 0x4d,
+	0xa3, 0x43, 0x6d, 0xea, 0x4f, 0x6c, 0x4d, 0x6b, 0x1a, 0x44, 0x72, 0x13, 0xad, 0xfe, 0x29, 0x0f,
+	0xb5, 0xb5, 0x28, 0x31, 0xc9, 0x98, 0x7c, 0x15, 0xce, 0x32, 0x77, 0x01, 0x74, 0xcd, 0xb2, 0xa8,
+	0x2b, 0x4f, 0xbb, 0x5f, 0x79, 0x62, 0x98, 0x35, 0x5d, 0x14, 0xf6, 0xa0, 0xf8, 0xc4, 0x30, 0x22,
+	0x91, 0x8e, 0xb6, 0xb2, 0x98, 0x66, 0x04, 0xe7, 0x31, 0x04, 0x05, 0xe5, 0xda, 0xb4, 0x0d, 0xd3,
+	0x9e, 0x06, 0x79, 0x0b, 0xc1, 0xc4, 0xb4, 0x53, 0xdc, 0x98, 0x76, 0xee, 0xc3, 0x9e, 0xa3, 0xb9,
+	0xd4, 0xe6, 0x17, 0x21, 0xc7, 0xb6, 0xe4, 0xd8, 0xc0, 0xa2, 0xcf, 0xa0, 0xc2, 0xdf, 0x44, 0x0d,
+	0xb8, 0xb6, 0xf3, 0x9d, 0x2d, 0x3a, 0xce, 0xae, 0xfe, 0x79, 0x07, 0x94, 0x28, 0x24, 0x17, 0xd4,
+	0xf3, 0x44, 0x4f, 0xfe, 0x69, 0x62, 0x5e, 0x7d, 0x2f, 0x95, 0x85, 0x80, 0x2f, 0x3e, 0xb2, 0x3e,
+	0x82, 0x72, 0x74, 0x15, 0x78, 0x8b, 0x31, 0x61, 0xcd, 0xfc, 0x2d, 0x71, 0x43, 0xb0, 0xc5, 0xdf,
+	0x98, 0x46, 0x50, 0x53, 0xe5, 0x3f, 0xfa, 0x0a, 0xf6, 0xbd, 0x64, 0xe2, 0x82, 0xba, 0x75, 0x96,
+	0xd1, 0xde, 0x13, 0x7c, 0x64, 0x73, 0x21, 0x7a, 0x02, 0x7b, 0xd1, 0x4e, 0xc2, 0xe2, 0x6e, 0x54,
+	0xdb, 0xbe, 0xa1, 0x04, 0x4a, 0x2a, 0xd9, 0xe0, 0x46, 0x1f, 0x42, 0x29, 0xbc, 0x27, 0x05, 0x61,
+	0x57, 0xc2, 0x95, 0xc3, 0x00, 0x4f, 0x22, 0x0e, 0xf4, 0x13, 0x28, 0x85, 0x97, 0x29, 0x59, 0xee,
+	0x2a, 0x8d, 0x83, 0x90, 0x3b, 0x3c, 0x5a, 0x0d, 0x12, 0xb1, 0x08, 0xe3, 0x3c, 0x39, 0x41, 0x84,
+	0xa2, 0x6a, 0xe5, 0xa4, 0x71, 0xa3, 0x04, 0x95, 0x6c, 0x70, 0xab, 0xff, 0x28, 0x64, 0x0f, 0xb1,
+	0x55, 0x28, 0x11, 0xdc, 0xe9, 0x8e, 0xc6, 0x98, 0x28, 0x39, 0xb4, 0x07, 0x10, 0x42, 0xb8, 0xad,
+	0xe4, 0xc5, 0x0c, 0xdb, 0xed, 0x77, 0xc7, 0x4a, 0x01, 0x95, 0xa1, 0x48, 0x70, 0xb3, 0xfd, 0x4a,
+	0xd9, 0x42, 0xfb, 0x50, 0x19, 0x93, 0x66, 0x7f, 0xd4, 0x6c, 0x8d, 0xbb, 0x83, 0xbe, 0x52, 0x14,
+	0x22, 0x5b, 0x83, 0x8b, 0x61, 0x0f, 0x8f, 0x71, 0x5b, 0xd9, 0x16, 0xac, 0x98, 0x90, 0x01, 0x51,
+	0x76, 0x04, 0xa5, 0x83, 0xc7, 0x57, 0xa3, 0x71, 0x73, 0x8c, 0x95, 0x92, 0x00, 0x87, 0x97, 0x21,
+	0x58, 0x16, 0x60, 0x1b, 0xf7, 0x02, 0x10, 0xd0, 0x11, 0x28, 0xdd, 0xfe, 0x8b, 0xc1, 0x33, 0x7c,
+	0xd5, 0x7a, 0xda, 0xec, 0xf6, 0x5b, 0x62, 0x9e, 0xae, 0x20, 0x05, 0xaa, 0x01, 0xf6, 0xf9, 0x25,
+	0x26, 0xaf, 0x94, 0xaa, 0x6f, 0xf2, 0x68, 0x38, 0xe8, 0x8f, 0xb0, 0xb2, 0x2b, 0xb4, 0xf9, 0x84,
+	0x3d, 0x74, 0x08, 0xfb, 0xf2, 0xf7, 0x6a, 0x6d, 0xcd, 0xbe, 0xb0, 0xd6, 0x47, 0xfa, 0x36, 0x29,
+	0xe8, 0x18, 0x0e, 0x48, 0xb3, 0xdf, 0x09, 0xe4, 0x05, 0xda, 0x0f, 0xd0, 0x29, 0x9c, 0xa4, 0xd0,
+	0x57, 0x7d, 0xfc, 0x72, 0xac, 0x20, 0xf4, 0x2e, 0xdc, 0x4e, 0xd3, 0x5a, 0xbd, 0xc1, 0x08, 0x2b,
+	0x87, 0xc2, 0x8b, 0x67, 0x18, 0x0f, 0x9b, 0xbd, 0xee, 0x0b, 0xac, 0x1c, 0x09, 0x2f, 0x84, 0xcb,
+	0x3e, 0x27, 0xc1, 0xa3, 0xcb, 0xde, 0x58, 0x39, 0x46, 0x27, 0x80, 0xa2, 0x40, 0x5c, 0x5d, 0x5c,
+	0xf6, 0xc6, 0xdd, 0x61, 0x0f, 0x2b, 0x27, 0xe8, 0x00, 0x76, 0x47, 0x78, 0x7c, 0xd5, 0x1b, 0x74,
+	0xae, 0x7a, 0xf8, 0x05, 0xee, 0x29, 0xb7, 0x85, 0x7d, 0x82, 0xb5, 0x87, 0xdb, 0x1d, 0x4c, 0xae,
+	0x9e, 0xe2, 0x6e, 0xe7, 0xe9, 0x58, 0xa9, 0xa9, 0x3f, 0x87, 0xea, 0x70, 0xc1, 0x47, 0x5c, 0xe3,
+	0x7e, 0xf3, 0x78, 0xcb, 0x2b, 0xa8, 0xfa, 0x3b, 0xd8, 0x27, 0x9a, 0x3d, 0xf5, 0x8b, 0xb6, 0x5c,
+	0x2e, 0xca, 0x8c, 0xc7, 0x35, 0x97, 0x3f, 0x8b, 0xd6, 0x47, 0xb0, 0xb8, 0x72, 0x50, 0xdb, 0x10,
+	0x14, 0xbf, 0x68, 0x06, 0x90, 0x58, 0xe3, 0x68, 0x53, 0x3a, 0x32, 0xbf, 0xf1, 0xc7, 0xf6, 0x22,
+	0x89, 0x60, 0x41, 0xbb, 0x66, 0xec, 0xf5, 0x5c, 0x73, 0x5f, 0x07, 0x87, 0x33, 0x82, 0xd5, 0x1f,
+	0xc1, 0xe1, 0x86, 0xfa, 0xbe, 0x38, 0x6b, 0x7b, 0x90, 0xef, 0xb6, 0x03, 0xe5, 0xf9, 0x6e, 0x5b,
+	0xbd, 0x0f, 0x47, 0x1b, 0x6c, 0x2d, 0x8b, 0x79, 0x34, 0xc5, 0xd7, 0x84, 0xdb, 0x1b, 0x7c, 0xcf,
+	0xe8, 0xea, 0x85, 0x70, 0xf4, 0xad, 0x03, 0xf2, 0xcf, 0x5c, 0x4a, 0x46, 0xd4, 0xcb, 0x30, 0xec,
+	0xbe, 0xa6, 0x2b, 0xaf, 0x69, 0x1b, 0x52, 0x66, 0xd8, 0xce, 0xa2, 0x1b, 0xe3, 0x0d, 0xba, 0x49,
+	0x72, 0x95, 0xa8, 0x61, 0x33, 0xcd, 0xbb, 0x60, 0xc1, 0x54, 0x5a, 0x22, 0x21, 0x18, 0xf8, 0x53,
+	0x08, 0xfd, 0x41, 0x9f, 0xc4, 0x2a, 0xfe, 0x96, 0x3c, 0xd0, 0x51, 0x79, 0x4d, 0x74, 0xd9, 0xb0,
+	0xbc, 0xaf, 0x1b, 0x82, 0xfa, 0x1b, 0xd8, 0xeb, 0x50, 0x1e, 0x72, 0x2d, 0x2c, 0x2e, 0xfc, 0xfd,
+	0xad, 0x00, 0x83, 0x18, 0xf8, 0x40, 0x22, 0x73, 0xf9, 0x6f, 0xc9, 0x5c, 0x61, 0x23, 0x73, 0x14,
+	0x8e, 0x33, 0x4d, 0x10, 0x37, 0x8e, 0x09, 0xe5, 0xfa, 0x8c, 0x1a, 0x84, 0xea, 0xcc, 0x35, 0xbc,
+	0x16, 0x5b, 0xd8, 0x7e, 0x8b, 0x2c, 0x92, 0x2c, 0x52, 0x42, 0x4d, 0x7e, 0x43, 0xcd, 0x7d, 0x50,
+	0x3a, 0xd4, 0xdf, 0xd7, 0x17, 0x0b, 0x8b, 0x9b, 0x8e, 0x45, 0x45, 0xa5, 0x17, 0x01, 0x95, 0xd1,
+	0x2f, 0x13, 0xf9, 0xaf, 0x36, 0xa0, 0xb6, 0xc9, 0x17, 0xa5, 0xed, 0x04, 0xb6, 0x97, 0xeb, 0x7c,
+	0x55, 0x49, 0x00, 0xa9, 0x8f, 0xa1, 0x32, 0xa2, 0xbc, 0xc7, 0xa6, 0xfe, 0x63, 0x82, 0xb8, 0x4e,
+	0x33, 0x63, 0x61, 0x85, 0x13, 0x57, 0x00, 0x89, 0xb8, 0x59, 0x82, 0x21, 0xb0, 0xcd, 0x07, 0xd4,
+	0xfb, 0x50, 0xed, 0x51, 0x63, 0x4a, 0xdd, 0xa7, 0xd4, 0x9c, 0xce, 0xb8, 0x58, 0x3d, 0x93, 0x7f,
+	0x72, 0xf5, 0x16, 0x09, 0x20, 0xf5, 0x5f, 0xfe, 0x5c, 0x67, 0xdb, 0xd4, 0x92, 0x0f, 0x1b, 0xf2,
+	0x19, 0x45, 0xb6, 0x86, 0x68, 0xe7, 0x86, 0x60, 0x7a, 0xf0, 0xcc, 0x67, 0x0c, 0x9e, 0xe8, 0x57,
+	0x50, 0x72, 0xc4, 0x74, 0x66, 0x52, 0x7f, 0x32, 0xad, 0x34, 0x7e, 0x18, 0x6b, 0x41, 0x6b, 0x45,
+	0xf5, 0x61, 0xc0, 0xe5, 0xbf, 0x5a, 0x45, 0x8b, 0x4e, 0x1f, 0xc3, 0x6e, 0x82, 0xf4, 0x5d, 0x67,
+	0xa3, 0x1c, 0x7f, 0xaf, 0xfa, 0x00, 0x2a, 0x81, 0x96, 0xf0, 0xb6, 0x94, 0xed, 0x8c, 0xda, 0x81,
+	0xa3, 0x80, 0x31, 0x39, 0x10, 0x3e, 0x80, 0x92, 0xee, 0xe3, 0xc3, 0xf3, 0x73, 0xb8, 0x61, 0xbe,
+	0x10, 0x4c, 0x22, 0x26, 0xf5, 0x0f, 0x39, 0x40, 0x84, 0x4e, 0x4d, 0x8f, 0x53, 0x97, 0x1a, 0x5d,
+	0xff, 0x62, 0xb8, 0x12, 0x67, 0xc5, 0x34, 0xc2, 0xb3, 0x6f, 0x1a, 0x62, 0xae, 0xd7, 0x26, 0x13,
+	0xd3, 0xf2, 0xef, 0x96, 0x81, 0xe1, 0x71, 0x14, 0xfa, 0x24, 0xf1, 0x22, 0x54, 0x48, 0x0e, 0xe7,
+	0xa1, 0xdc, 0x66, 0xc8, 0x11, 0x7f, 0x2c, 0x52, 0x7f, 0x09, 0x07, 0x29, 0x86, 0xcc, 0x01, 0x3d,
+	0x33, 0x70, 0x2a, 0x81, 0xa3, 0x94, 0x07, 0x26, 0xf5, 0xd0, 0xa7, 0xa9, 0xab, 0x6f, 0x65, 0xfd,
+	0xe0, 0x97, 0xf6, 0x39, 0x71, 0x2d, 0x7e, 0x0e, 0x07, 0x4d, 0xc7, 0xb1, 0x4c, 0x3d, 0x7e, 0x97,
+	0xce, 0x32, 0xe9, 0x1c, 0x8a, 0x2e, 0xb3, 0x68, 0xf8, 0xcc, 0x89, 0xa2, 0xa9, 0x43, 0x2e, 0x21,
+	0xcc, 0xa2, 0xc4, 0x67, 0x50, 0x3f, 0x07, 0x58, 0x23, 0x85, 0x2c, 0x81, 0x0e, 0x65, 0x89, 0x7f,
+	0x31, 0xd6, 0x3a, 0xae, 0x69, 0xeb, 0xa6, 0xa3, 0x59, 0xbe, 0xc0, 0x2a, 0x89, 0x61, 0x7e, 0xfc,
+	0x11, 0x1c, 0x65, 0x3d, 0xdf, 0x89, 0xc7, 0x8f, 0xe1, 0xe5, 0x17, 0xbd, 0x6e, 0x4b, 0xb9, 0x25,
+	0x5a, 0x76, 0x6b, 0xd0, 0xff, 0xb2, 0xdb, 0xc6, 0xfd, 0x71, 0xb7, 0xd9, 0x53, 0x72, 0x8d, 0x97,
+	0xb1, 0xa9, 0x72, 0xb4, 0x70, 0x1c, 0xe6, 0x72, 0xd4, 0x86, 0x52, 0x18, 0x00, 0x54, 0xbb, 0x69,
+	0xa6, 0x3c, 0xbd, 0x91, 0xa2, 0xde, 0x3a, 0xcf, 0x3d, 0xcc, 0x35, 0x86, 0x50, 0x8e, 0x28, 0xa8,
+	0x05, 0x3b, 0x2d, 0x66, 0xdb, 0x54, 0xe7, 0xff, 0xbf, 0xc4, 0x2f, 0x9e, 0xc0, 0x09, 0x73, 0xa7,
+	0xf5, 0xd9, 0xca, 0xa1, 0xae, 0x25, 0x0b, 0x40, 0xb0, 0xe0, 0xd7, 0xf7, 0xa6, 0x26, 0x9f, 0x2d,
+	0xae, 0xeb, 0x3a, 0x9b, 0x3f, 0x88, 0x91, 0x1f, 0xf8, 0x8f, 0xe4, 0xfe, 0x23, 0xb8, 0x77, 0xed,
+	0x3f, 0xb4, 0xff, 0xec, 0xbf, 0x03, 0x00, 0x3b, 0x57, 0xbd, 0x8f, 0x82, 0x17, 0x00, 0x00,
 }
@@ -52,11 +52,11 @@ type ChaincodeProposalPayload struct {
 	// Input contains the arguments for this invocation. If this invocation
 	// deploys a new chaincode, ESCC/VSCC are part of this field.
 	Input []byte `protobuf:"bytes,1,opt,name=Input,proto3" json:"Input,omitempty"`
-	// Transient contains data (e.g. cryptographic material) that might be used
+	// TransientMap contains data (e.g. cryptographic material) that might be used
 	// to implement some form of application-level confidentiality. The contents
-	// of this field are supposed to always be omitted from the transaction and
-	// excluded from the ledger.
-	Transient []byte `protobuf:"bytes,2,opt,name=Transient,proto3" json:"Transient,omitempty"`
+	// of this field are passed to the chaincode, but are supposed to always be
+	// omitted from the transaction and excluded from the ledger.
+	TransientMap map[string][]byte `protobuf:"bytes,2,rep,name=TransientMap" json:"TransientMap,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value,proto3"`
 }
 
 func (m *ChaincodeProposalPayload) Reset()                    { *m = ChaincodeProposalPayload{} }
@@ -64,6 +64,13 @@ func (m *ChaincodeProposalPayload) String() string            { return proto.Com
 func (*ChaincodeProposalPayload) ProtoMessage()               {}
 func (*ChaincodeProposalPayload) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{1} }
 
+func (m *ChaincodeProposalPayload) GetTransientMap() map[string][]byte {
+	if m != nil {
+		return m.TransientMap
+	}
+	return nil
+}
+
 // ChaincodeAction contains the actions the events generated by the execution
 // of the chaincode.
 type ChaincodeAction struct {
@@ -73,6 +80,13 @@ type ChaincodeAction struct {
 	// This field contains the events generated by the chaincode executing this
 	// invocation.
 	Events []byte `protobuf:"bytes,2,opt,name=events,proto3" json:"events,omitempty"`
+	// This field contains the response of the chaincode executing this
+	// invocation, so that its status and payload are endorsed.
+	Response *Response2 `protobuf:"bytes,3,opt,name=response" json:"response,omitempty"`
+	// This field contains the hashes of the private data of this invocation.
+	// The private data itself is disseminated by the endorsers to the peers
+	// of the organizations of its collections and never ordered.
+	PrivateData *PrivateDataHashes `protobuf:"bytes,4,opt,name=privateData" json:"privateData,omitempty"`
 }
 
 func (m *ChaincodeAction) Reset()                    { *m = ChaincodeAction{} }
@@ -80,31 +94,87 @@ func (m *ChaincodeAction) String() string            { return proto.CompactTextS
 func (*ChaincodeAction) ProtoMessage()               {}
 func (*ChaincodeAction) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{2} }
 
+func (m *ChaincodeAction) GetResponse() *Response2 {
+	if m != nil {
+		return m.Response
+	}
+	return nil
+}
+
+func (m *ChaincodeAction) GetPrivateData() *PrivateDataHashes {
+	if m != nil {
+		return m.PrivateData
+	}
+	return nil
+}
+
+// PrivateDataHashes contains the hashes of the private data of a transaction,
+// one per collection.
+type PrivateDataHashes struct {
+	Hashes []*PrivateDataHash `protobuf:"bytes,1,rep,name=hashes" json:"hashes,omitempty"`
+}
+
+func (m *PrivateDataHashes) Reset()                    { *m = PrivateDataHashes{} }
+func (m *PrivateDataHashes) String() string            { return proto.CompactTextString(m) }
+func (*PrivateDataHashes) ProtoMessage()               {}
+func (*PrivateDataHashes) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{3} }
+
+func (m *PrivateDataHashes) GetHashes() []*PrivateDataHash {
+	if m != nil {
+		return m.Hashes
+	}
+	return nil
+}
+
+// PrivateDataHash is the SHA-256 hash of the private data of a transaction for
+// a collection.
+type PrivateDataHash struct {
+	Collection string `protobuf:"bytes,1,opt,name=collection" json:"collection,omitempty"`
+	Hash       []byte `protobuf:"bytes,2,opt,name=hash,proto3" json:"hash,omitempty"`
+}
+
+func (m *PrivateDataHash) Reset()                    { *m = PrivateDataHash{} }
+func (m *PrivateDataHash) String() string            { return proto.CompactTextString(m) }
+func (*PrivateDataHash) ProtoMessage()               {}
+func (*PrivateDataHash) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{4} }
+
 func init() {
 	proto.RegisterType((*ChaincodeHeaderExtension)(nil), "protos.ChaincodeHeaderExtension")
 	proto.RegisterType((*ChaincodeProposalPayload)(nil), "protos.ChaincodeProposalPayload")
 	proto.RegisterType((*ChaincodeAction)(nil), "protos.ChaincodeAction")
+	proto.RegisterType((*PrivateDataHashes)(nil), "protos.PrivateDataHashes")
+	proto.RegisterType((*PrivateDataHash)(nil), "protos.PrivateDataHash")
 }
 
 func init() { proto.RegisterFile("chaincode_proposal.proto", fileDescriptor2) }
 
 var fileDescriptor2 = []byte{
-	// 257 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x64, 0x90, 0xc1, 0x4b, 0xc3, 0x30,
-	0x14, 0xc6, 0xa9, 0xe0, 0xc4, 0x6c, 0x30, 0x8c, 0x22, 0x45, 0x3c, 0x8c, 0x22, 0xb2, 0x83, 0xb4,
-	0xa0, 0xf8, 0x07, 0x68, 0x15, 0xdc, 0x45, 0x46, 0x11, 0x0f, 0x5e, 0x24, 0x4d, 0x9f, 0x6b, 0x20,
-	0x26, 0x21, 0xef, 0x55, 0xec, 0xc9, 0x7f, 0x5d, 0x5c, 0xb2, 0x6e, 0xe0, 0x29, 0x7c, 0xef, 0x7b,
-	0xfc, 0xbe, 0xf7, 0x85, 0xa5, 0xb2, 0x15, 0xca, 0x48, 0xdb, 0xc0, 0xbb, 0xf3, 0xd6, 0x59, 0x14,
-	0x3a, 0x77, 0xde, 0x92, 0xe5, 0xa3, 0xf5, 0x83, 0x67, 0xd3, 0x61, 0x23, 0x18, 0xd9, 0x0f, 0x4b,
-	0xcb, 0xcd, 0xe8, 0x09, 0x44, 0x03, 0xfe, 0xf1, 0x9b, 0xc0, 0xa0, 0xb2, 0x86, 0x5f, 0xb1, 0x23,
-	0x27, 0x7a, 0x6d, 0x45, 0xf3, 0xaa, 0x50, 0xd5, 0x4a, 0x2b, 0xea, 0xd3, 0x64, 0x96, 0xcc, 0x27,
-	0xd5, 0x7f, 0x83, 0xdf, 0xb2, 0xf1, 0x00, 0x5f, 0x3c, 0xa4, 0x7b, 0xb3, 0x64, 0x3e, 0xbe, 0x3e,
-	0x0e, 0x31, 0x98, 0x97, 0x5b, 0xab, 0xda, 0xdd, 0xcb, 0x9e, 0x77, 0x0e, 0x58, 0xc6, 0xa3, 0x97,
-	0x01, 0xce, 0x4f, 0xd8, 0xfe, 0xc2, 0xb8, 0x8e, 0x62, 0x68, 0x10, 0xfc, 0x9c, 0x1d, 0xbe, 0x78,
-	0x61, 0x50, 0x81, 0xa1, 0x75, 0xcc, 0xa4, 0xda, 0x0e, 0xb2, 0x92, 0x4d, 0x07, 0xde, 0x9d, 0xa4,
-	0xbf, 0x1e, 0x29, 0x3b, 0xf0, 0x80, 0x9d, 0x26, 0x8c, 0xa0, 0x8d, 0xe4, 0xa7, 0x6c, 0x04, 0x5f,
-	0x60, 0x08, 0x23, 0x27, 0xaa, 0xfb, 0xcb, 0xb7, 0x8b, 0x95, 0xa2, 0xb6, 0xab, 0x73, 0x69, 0x3f,
-	0x8b, 0xb6, 0x77, 0xe0, 0x35, 0x34, 0x2b, 0xf0, 0xc5, 0x87, 0xa8, 0xbd, 0x92, 0x45, 0x68, 0x55,
-	0x87, 0x6f, 0xbd, 0xf9, 0x0d, 0x00, 0x00, 0xff, 0xff, 0x2b, 0x05, 0x10, 0xfe, 0x79, 0x01, 0x00,
-	0x00,
+	// 418 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x74, 0x52, 0x41, 0x8b, 0x13, 0x31,
+	0x14, 0x66, 0xda, 0xb5, 0xea, 0x6b, 0xa1, 0x36, 0x8a, 0xc6, 0x1e, 0x96, 0x32, 0x88, 0xf4, 0xa0,
+	0x2d, 0x54, 0x04, 0xd1, 0x83, 0xe8, 0xb6, 0xb0, 0x7b, 0x10, 0x4a, 0x90, 0x3d, 0x78, 0x59, 0xd2,
+	0xe9, 0x73, 0x27, 0x18, 0x93, 0x90, 0xa4, 0xc5, 0x9e, 0xfc, 0x49, 0xfe, 0x0e, 0xff, 0x95, 0x34,
+	0xc9, 0xcc, 0x8e, 0x3b, 0xec, 0x69, 0xf2, 0xf2, 0x7d, 0xef, 0x7d, 0xef, 0xfb, 0x26, 0x40, 0x8b,
+	0x92, 0x0b, 0x55, 0xe8, 0x2d, 0x5e, 0x19, 0xab, 0x8d, 0x76, 0x5c, 0xce, 0x8c, 0xd5, 0x5e, 0x93,
+	0x5e, 0xf8, 0xb8, 0xf1, 0xb0, 0x66, 0x44, 0x60, 0x7c, 0xfa, 0x9d, 0x6f, 0xac, 0x28, 0x6a, 0xfe,
+	0x95, 0x45, 0x67, 0xb4, 0x72, 0x09, 0xcf, 0x7f, 0x03, 0x3d, 0xab, 0x5a, 0xce, 0x91, 0x6f, 0xd1,
+	0xae, 0x7e, 0x79, 0x54, 0x4e, 0x68, 0x45, 0x5e, 0xc1, 0xc8, 0xf0, 0x83, 0xd4, 0x7c, 0x7b, 0x29,
+	0x9c, 0xd8, 0x08, 0x29, 0xfc, 0x81, 0x66, 0x93, 0x6c, 0x3a, 0x60, 0x6d, 0x80, 0xbc, 0x85, 0x7e,
+	0x2d, 0x7e, 0xb1, 0xa4, 0x9d, 0x49, 0x36, 0xed, 0x2f, 0x1e, 0x47, 0x19, 0x37, 0x3b, 0xbb, 0x81,
+	0x58, 0x93, 0x97, 0xff, 0xcd, 0x1a, 0x1b, 0xac, 0xd3, 0x96, 0xeb, 0x38, 0x9d, 0x3c, 0x81, 0x7b,
+	0x17, 0xca, 0xec, 0x7c, 0x52, 0x8d, 0x05, 0xb9, 0x84, 0xc1, 0x57, 0xcb, 0x95, 0x13, 0xa8, 0xfc,
+	0x17, 0x6e, 0x68, 0x67, 0xd2, 0x9d, 0xf6, 0x17, 0x8b, 0x96, 0xd4, 0xad, 0x69, 0xb3, 0x66, 0xd3,
+	0x4a, 0x79, 0x7b, 0x60, 0xff, 0xcd, 0x19, 0x7f, 0x84, 0x51, 0x8b, 0x42, 0x1e, 0x41, 0xf7, 0x07,
+	0x46, 0xdb, 0x0f, 0xd9, 0xf1, 0x78, 0x5c, 0x6a, 0xcf, 0xe5, 0x0e, 0x83, 0xc5, 0x01, 0x8b, 0xc5,
+	0xfb, 0xce, 0xbb, 0x2c, 0xff, 0x93, 0xc1, 0xb0, 0x56, 0xff, 0x54, 0xf8, 0x63, 0x88, 0x14, 0xee,
+	0x5b, 0x74, 0x3b, 0xe9, 0x5d, 0x32, 0x51, 0x95, 0xe4, 0x29, 0xf4, 0x70, 0x8f, 0xca, 0xbb, 0x34,
+	0x28, 0x55, 0xe4, 0x35, 0x3c, 0xa8, 0x7e, 0x12, 0xed, 0x86, 0x14, 0x47, 0x95, 0x35, 0x96, 0xee,
+	0x17, 0xac, 0xa6, 0x90, 0x0f, 0xd0, 0x37, 0x56, 0xec, 0xb9, 0xc7, 0x25, 0xf7, 0x9c, 0x9e, 0x84,
+	0x8e, 0xe7, 0x55, 0xc7, 0xfa, 0x06, 0x3a, 0xe7, 0xae, 0x44, 0xc7, 0x9a, 0xec, 0x7c, 0x09, 0xa3,
+	0x16, 0x83, 0xcc, 0xa1, 0x57, 0x86, 0x13, 0xcd, 0x42, 0xb2, 0xcf, 0xee, 0x18, 0xc6, 0x12, 0x2d,
+	0x5f, 0xc1, 0xf0, 0x16, 0x44, 0x4e, 0x01, 0x0a, 0x2d, 0x25, 0x86, 0x10, 0x52, 0x7a, 0x8d, 0x1b,
+	0x42, 0xe0, 0xe4, 0xd8, 0x9c, 0xac, 0x87, 0xf3, 0xe7, 0x97, 0xdf, 0x5e, 0x5c, 0x0b, 0x5f, 0xee,
+	0x36, 0xb3, 0x42, 0xff, 0x9c, 0x97, 0x07, 0x83, 0x56, 0xe2, 0xf6, 0x1a, 0xed, 0x3c, 0x3e, 0xe2,
+	0x79, 0x5c, 0x63, 0x13, 0x1f, 0xfb, 0x9b, 0x7f, 0x03, 0x00, 0x69, 0x9b, 0x67, 0xcb, 0x0f, 0x03,
+	0x00, 0x00,
 }
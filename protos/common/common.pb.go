@@ -44,6 +44,7 @@ const (
 	HeaderType_CONFIGURATION_TRANSACTION HeaderType = 1
 	HeaderType_CONFIGURATION_ITEM        HeaderType = 2
 	HeaderType_ENDORSER_TRANSACTION      HeaderType = 3
+	HeaderType_CHAIN_CREATION            HeaderType = 4
 )
 
 var HeaderType_name = map[int32]string{
@@ -51,12 +52,14 @@ var HeaderType_name = map[int32]string{
 	1: "CONFIGURATION_TRANSACTION",
 	2: "CONFIGURATION_ITEM",
 	3: "ENDORSER_TRANSACTION",
+	4: "CHAIN_CREATION",
 }
 var HeaderType_value = map[string]int32{
 	"MESSAGE":                   0,
 	"CONFIGURATION_TRANSACTION": 1,
 	"CONFIGURATION_ITEM":        2,
 	"ENDORSER_TRANSACTION":      3,
+	"CHAIN_CREATION":            4,
 }
 
 func (x HeaderType) String() string {
@@ -240,39 +243,40 @@ func init() {
 func init() { proto.RegisterFile("common/common.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 542 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x5c, 0x54, 0x5d, 0x8f, 0xd2, 0x40,
-	0x14, 0xb5, 0xcb, 0xf7, 0x2d, 0xba, 0x38, 0xbb, 0xae, 0x48, 0x34, 0x4b, 0x26, 0xd1, 0x6c, 0x24,
-	0x42, 0x5c, 0x63, 0xe2, 0x2b, 0x1f, 0x75, 0x97, 0x07, 0x60, 0x33, 0x60, 0x4c, 0x7c, 0x31, 0x43,
-	0x99, 0xa5, 0x55, 0xe8, 0x34, 0xfd, 0x20, 0xe1, 0xd5, 0x1f, 0xa0, 0x7f, 0xd9, 0xcc, 0x57, 0xa1,
-	0x3c, 0x31, 0xe7, 0x9e, 0x73, 0x6f, 0xcf, 0x3d, 0x1d, 0x0a, 0x17, 0x2e, 0xdf, 0x6e, 0x79, 0xd0,
-	0x53, 0x3f, 0xdd, 0x30, 0xe2, 0x09, 0x47, 0x65, 0x85, 0x5a, 0xd7, 0x6b, 0xce, 0xd7, 0x1b, 0xd6,
-	0x93, 0xd5, 0x65, 0xfa, 0xd8, 0x4b, 0xfc, 0x2d, 0x8b, 0x13, 0xba, 0x0d, 0x95, 0x10, 0xff, 0xb1,
-	0xa0, 0x7c, 0xcf, 0xe8, 0x8a, 0x45, 0xe8, 0x33, 0xd8, 0xae, 0x47, 0xfd, 0x40, 0xc1, 0xa6, 0xd5,
-	0xb6, 0x6e, 0xec, 0xdb, 0x8b, 0xae, 0x9e, 0x3b, 0x3c, 0x50, 0xe4, 0x58, 0x87, 0xfa, 0x70, 0x1e,
-	0xfb, 0xeb, 0x80, 0x26, 0x69, 0xc4, 0x74, 0xeb, 0x99, 0x6c, 0x7d, 0x69, 0x5a, 0xe7, 0x79, 0x9a,
-	0x9c, 0xea, 0xf1, 0x3f, 0x0b, 0xec, 0xa3, 0xf9, 0x08, 0x41, 0x31, 0xd9, 0x87, 0x4c, 0x5a, 0x28,
-	0x11, 0x79, 0x46, 0x4d, 0xa8, 0xec, 0x58, 0x14, 0xfb, 0x3c, 0x90, 0xe3, 0x4b, 0xc4, 0x40, 0xf4,
-	0x05, 0x6a, 0xd9, 0x56, 0xcd, 0x82, 0x7c, 0x74, 0xab, 0xab, 0xf6, 0xee, 0x9a, 0xbd, 0xbb, 0x0b,
-	0xa3, 0x20, 0x07, 0xb1, 0x98, 0x29, 0x37, 0x19, 0x8f, 0x9a, 0xc5, 0xb6, 0x75, 0x53, 0x27, 0x06,
-	0xe2, 0xef, 0x70, 0x7e, 0xe2, 0x5a, 0x8a, 0x23, 0x46, 0x13, 0xae, 0xa2, 0x11, 0x62, 0x05, 0xd1,
-	0x25, 0x94, 0x02, 0x1e, 0xb8, 0x4c, 0x1a, 0xab, 0x13, 0x05, 0x44, 0x95, 0x85, 0xdc, 0xf5, 0xa4,
-	0xa5, 0x22, 0x51, 0x00, 0x3b, 0x50, 0x79, 0xa0, 0xfb, 0x0d, 0xa7, 0x2b, 0xf4, 0x0e, 0xca, 0xde,
-	0x71, 0xd4, 0xcf, 0x4c, 0x5e, 0x3a, 0x26, 0xcd, 0x8a, 0x34, 0x56, 0x34, 0xa1, 0x7a, 0xba, 0x3c,
-	0xe3, 0x01, 0x54, 0x9d, 0x60, 0xc7, 0x36, 0x5c, 0x25, 0x13, 0xaa, 0x91, 0xc6, 0x98, 0x86, 0xe8,
-	0x35, 0xd4, 0xb2, 0xa8, 0x75, 0xfb, 0xa1, 0x80, 0xff, 0x5a, 0x50, 0x1a, 0x6c, 0xb8, 0xfb, 0x1b,
-	0x75, 0xcc, 0x1d, 0x38, 0x7d, 0xe9, 0x92, 0x36, 0x76, 0x74, 0x0e, 0x6f, 0xa1, 0x38, 0x32, 0x76,
-	0xec, 0xdb, 0xe7, 0x39, 0xa9, 0x20, 0x88, 0xa4, 0xd1, 0x47, 0xa8, 0x4e, 0x58, 0x42, 0xa5, 0x73,
-	0xf5, 0x52, 0x5e, 0xe4, 0xa4, 0x86, 0x24, 0x99, 0x0c, 0x33, 0xb0, 0x8f, 0x1e, 0x88, 0xae, 0xa0,
-	0x3c, 0x4d, 0xb7, 0x4b, 0xed, 0xaa, 0x48, 0x34, 0x42, 0x18, 0xea, 0x0f, 0x11, 0xdb, 0xf9, 0x3c,
-	0x8d, 0xef, 0x69, 0xec, 0xe9, 0xc5, 0x72, 0x35, 0xd4, 0x82, 0xaa, 0x70, 0x21, 0xf9, 0x82, 0xe4,
-	0x33, 0x8c, 0xaf, 0xa1, 0x96, 0x99, 0x15, 0xe1, 0xca, 0x6d, 0xac, 0x76, 0x41, 0x84, 0x2b, 0xce,
-	0xb8, 0x03, 0x4f, 0x73, 0x16, 0xc5, 0xb4, 0x6c, 0x17, 0x25, 0xcc, 0xf0, 0xfb, 0x5f, 0x00, 0xca,
-	0xef, 0x42, 0xdc, 0x52, 0x1b, 0x2a, 0x13, 0x67, 0x3e, 0xef, 0xdf, 0x39, 0x8d, 0x27, 0xe8, 0x0d,
-	0xbc, 0x1a, 0xce, 0xa6, 0x5f, 0xc7, 0x77, 0xdf, 0x48, 0x7f, 0x31, 0x9e, 0x4d, 0x7f, 0x2e, 0x48,
-	0x7f, 0x3a, 0xef, 0x0f, 0xc5, 0xb9, 0x61, 0xa1, 0x2b, 0x40, 0x79, 0x7a, 0xbc, 0x70, 0x26, 0x8d,
-	0x33, 0xd4, 0x84, 0x4b, 0x67, 0x3a, 0x9a, 0x91, 0xb9, 0x43, 0x72, 0x1d, 0x85, 0xc1, 0x87, 0x1f,
-	0x9d, 0xb5, 0x9f, 0x78, 0xe9, 0x52, 0x24, 0xd9, 0xf3, 0xf6, 0x21, 0x8b, 0x36, 0x6c, 0xb5, 0x66,
-	0x51, 0xef, 0x91, 0x2e, 0x23, 0xdf, 0x55, 0x7f, 0xf3, 0x58, 0x7f, 0x0a, 0x96, 0x65, 0x09, 0x3f,
-	0xfd, 0x0f, 0x00, 0x00, 0xff, 0xff, 0xa4, 0x57, 0xb1, 0x3c, 0x22, 0x04, 0x00, 0x00,
+	// 556 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x5c, 0x54, 0xd1, 0x8e, 0xd2, 0x40,
+	0x14, 0xb5, 0x4b, 0x29, 0x70, 0x8b, 0xbb, 0x38, 0xbb, 0xae, 0x48, 0x34, 0x4b, 0x9a, 0x68, 0x36,
+	0x12, 0x21, 0xae, 0x31, 0xf1, 0xb5, 0x40, 0x5d, 0x78, 0xa0, 0x6c, 0x06, 0x8c, 0x89, 0x2f, 0x9b,
+	0xa1, 0xcc, 0xd2, 0x46, 0xe8, 0x34, 0x6d, 0x21, 0xe1, 0xc5, 0x07, 0x3f, 0x40, 0x7f, 0xd9, 0xcc,
+	0x4c, 0xa7, 0x50, 0x9e, 0x3a, 0xe7, 0x9e, 0x73, 0x6f, 0xcf, 0x3d, 0x1d, 0x80, 0x4b, 0x8f, 0x6d,
+	0x36, 0x2c, 0xec, 0xc9, 0x47, 0x37, 0x8a, 0x59, 0xca, 0x90, 0x21, 0x51, 0xeb, 0x66, 0xc5, 0xd8,
+	0x6a, 0x4d, 0x7b, 0xa2, 0xba, 0xd8, 0x3e, 0xf5, 0xd2, 0x60, 0x43, 0x93, 0x94, 0x6c, 0x22, 0x29,
+	0xb4, 0xfe, 0x68, 0x60, 0x8c, 0x28, 0x59, 0xd2, 0x18, 0x7d, 0x01, 0xd3, 0xf3, 0x49, 0x10, 0x4a,
+	0xd8, 0xd4, 0xda, 0xda, 0xad, 0x79, 0x77, 0xd9, 0xcd, 0xe6, 0x0e, 0x0e, 0x14, 0x3e, 0xd6, 0x21,
+	0x1b, 0x2e, 0x92, 0x60, 0x15, 0x92, 0x74, 0x1b, 0xd3, 0xac, 0xf5, 0x4c, 0xb4, 0xbe, 0x52, 0xad,
+	0xb3, 0x22, 0x8d, 0x4f, 0xf5, 0xd6, 0x3f, 0x0d, 0xcc, 0xa3, 0xf9, 0x08, 0x81, 0x9e, 0xee, 0x23,
+	0x2a, 0x2c, 0x94, 0xb1, 0x38, 0xa3, 0x26, 0x54, 0x76, 0x34, 0x4e, 0x02, 0x16, 0x8a, 0xf1, 0x65,
+	0xac, 0x20, 0xfa, 0x0a, 0xb5, 0x7c, 0xab, 0x66, 0x49, 0xbc, 0xba, 0xd5, 0x95, 0x7b, 0x77, 0xd5,
+	0xde, 0xdd, 0xb9, 0x52, 0xe0, 0x83, 0x98, 0xcf, 0x14, 0x9b, 0x8c, 0x87, 0x4d, 0xbd, 0xad, 0xdd,
+	0xd6, 0xb1, 0x82, 0xd6, 0x0f, 0xb8, 0x38, 0x71, 0x2d, 0xc4, 0x31, 0x25, 0x29, 0x93, 0xd1, 0xd4,
+	0xb1, 0x82, 0xe8, 0x0a, 0xca, 0x21, 0x0b, 0x3d, 0x2a, 0x8c, 0xd5, 0xb1, 0x04, 0xbc, 0x4a, 0x23,
+	0xe6, 0xf9, 0xc2, 0x92, 0x8e, 0x25, 0xb0, 0x1c, 0xa8, 0x3c, 0x90, 0xfd, 0x9a, 0x91, 0x25, 0x7a,
+	0x0f, 0x86, 0x7f, 0x1c, 0xf5, 0xb9, 0xca, 0x2b, 0x8b, 0xc9, 0xf0, 0xf3, 0x34, 0x96, 0x24, 0x25,
+	0xd9, 0x74, 0x71, 0xb6, 0xfa, 0x50, 0x75, 0xc2, 0x1d, 0x5d, 0x33, 0x99, 0x4c, 0x24, 0x47, 0x2a,
+	0x63, 0x19, 0x44, 0x6f, 0xa0, 0x96, 0x47, 0x9d, 0xb5, 0x1f, 0x0a, 0xd6, 0x5f, 0x0d, 0xca, 0xfd,
+	0x35, 0xf3, 0x7e, 0xa1, 0x8e, 0xba, 0x03, 0xa7, 0x1f, 0x5d, 0xd0, 0xca, 0x8e, 0x7c, 0xa2, 0x77,
+	0xa0, 0x0f, 0x95, 0x1d, 0xf3, 0xee, 0x45, 0x41, 0xca, 0x09, 0x2c, 0x68, 0xf4, 0x09, 0xaa, 0x13,
+	0x9a, 0x12, 0xe1, 0x5c, 0x7e, 0x94, 0x97, 0x05, 0xa9, 0x22, 0x71, 0x2e, 0xb3, 0x28, 0x98, 0x47,
+	0x2f, 0x44, 0xd7, 0x60, 0xb8, 0xdb, 0xcd, 0x22, 0x73, 0xa5, 0xe3, 0x0c, 0x21, 0x0b, 0xea, 0x0f,
+	0x31, 0xdd, 0x05, 0x6c, 0x9b, 0x8c, 0x48, 0xe2, 0x67, 0x8b, 0x15, 0x6a, 0xa8, 0x05, 0x55, 0xee,
+	0x42, 0xf0, 0x25, 0xc1, 0xe7, 0xd8, 0xba, 0x81, 0x5a, 0x6e, 0x96, 0x87, 0x2b, 0xb6, 0xd1, 0xda,
+	0x25, 0x1e, 0x2e, 0x3f, 0x5b, 0x1d, 0x78, 0x5e, 0xb0, 0xc8, 0xa7, 0xe5, 0xbb, 0x48, 0x61, 0x8e,
+	0x3f, 0xfc, 0x06, 0x90, 0x7e, 0xe7, 0xfc, 0x96, 0x9a, 0x50, 0x99, 0x38, 0xb3, 0x99, 0x7d, 0xef,
+	0x34, 0x9e, 0xa1, 0xb7, 0xf0, 0x7a, 0x30, 0x75, 0xbf, 0x8d, 0xef, 0xbf, 0x63, 0x7b, 0x3e, 0x9e,
+	0xba, 0x8f, 0x73, 0x6c, 0xbb, 0x33, 0x7b, 0xc0, 0xcf, 0x0d, 0x0d, 0x5d, 0x03, 0x2a, 0xd2, 0xe3,
+	0xb9, 0x33, 0x69, 0x9c, 0xa1, 0x26, 0x5c, 0x39, 0xee, 0x70, 0x8a, 0x67, 0x0e, 0x2e, 0x74, 0x94,
+	0x10, 0x82, 0xf3, 0xc1, 0xc8, 0x1e, 0xbb, 0x8f, 0x03, 0xec, 0x88, 0x96, 0x86, 0xde, 0xff, 0xf8,
+	0xb3, 0xb3, 0x0a, 0x52, 0x7f, 0xbb, 0xe0, 0xe9, 0xf6, 0xfc, 0x7d, 0x44, 0xe3, 0x35, 0x5d, 0xae,
+	0x68, 0xdc, 0x7b, 0x22, 0x8b, 0x38, 0xf0, 0xe4, 0x4f, 0x3f, 0xc9, 0xfe, 0x1e, 0x16, 0x86, 0x80,
+	0x9f, 0xff, 0x0f, 0x00, 0x5a, 0xf5, 0xcc, 0xea, 0x36, 0x04, 0x00, 0x00,
 }
@@ -0,0 +1,82 @@
+// Code generated by protoc-gen-go.
+// source: configuration.proto
+// DO NOT EDIT!
+
+package protos
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// AnchorPeers lists the anchor peers of an organization on a chain, the
+// well-known peers from which the peers of other organizations bootstrap
+// discovery and gossip. It is the value of a Fabric configuration item.
+type AnchorPeers struct {
+	AnchorPeers []*AnchorPeer `protobuf:"bytes,1,rep,name=anchorPeers" json:"anchorPeers,omitempty"`
+}
+
+func (m *AnchorPeers) Reset()                    { *m = AnchorPeers{} }
+func (m *AnchorPeers) String() string            { return proto.CompactTextString(m) }
+func (*AnchorPeers) ProtoMessage()               {}
+func (*AnchorPeers) Descriptor() ([]byte, []int) { return fileDescriptor17, []int{0} }
+
+func (m *AnchorPeers) GetAnchorPeers() []*AnchorPeer {
+	if m != nil {
+		return m.AnchorPeers
+	}
+	return nil
+}
+
+// AnchorPeer is the endpoint of an anchor peer
+type AnchorPeer struct {
+	Host string `protobuf:"bytes,1,opt,name=host" json:"host,omitempty"`
+	Port int32  `protobuf:"varint,2,opt,name=port" json:"port,omitempty"`
+	Cert []byte `protobuf:"bytes,3,opt,name=cert,proto3" json:"cert,omitempty"`
+}
+
+func (m *AnchorPeer) Reset()                    { *m = AnchorPeer{} }
+func (m *AnchorPeer) String() string            { return proto.CompactTextString(m) }
+func (*AnchorPeer) ProtoMessage()               {}
+func (*AnchorPeer) Descriptor() ([]byte, []int) { return fileDescriptor17, []int{1} }
+
+// OrdererAddresses lists the endpoints of the ordering service of a chain,
+// which the peers pull the blocks of the chain from. It is the value of an
+// Orderer configuration item.
+type OrdererAddresses struct {
+	Addresses []string `protobuf:"bytes,1,rep,name=addresses" json:"addresses,omitempty"`
+}
+
+func (m *OrdererAddresses) Reset()                    { *m = OrdererAddresses{} }
+func (m *OrdererAddresses) String() string            { return proto.CompactTextString(m) }
+func (*OrdererAddresses) ProtoMessage()               {}
+func (*OrdererAddresses) Descriptor() ([]byte, []int) { return fileDescriptor17, []int{2} }
+
+func init() {
+	proto.RegisterType((*AnchorPeers)(nil), "protos.AnchorPeers")
+	proto.RegisterType((*AnchorPeer)(nil), "protos.AnchorPeer")
+	proto.RegisterType((*OrdererAddresses)(nil), "protos.OrdererAddresses")
+}
+
+func init() { proto.RegisterFile("configuration.proto", fileDescriptor17) }
+
+var fileDescriptor17 = []byte{
+	// 205 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x4c, 0x8f, 0x31, 0x4f, 0xc4, 0x30,
+	0x0c, 0x85, 0x15, 0x0e, 0x90, 0xea, 0x32, 0xa0, 0xb0, 0x74, 0x60, 0x88, 0x2a, 0x84, 0x32, 0xb5,
+	0x08, 0xf8, 0x03, 0x07, 0x0b, 0x1b, 0x28, 0x23, 0x5b, 0x9a, 0xf8, 0x9a, 0x48, 0x50, 0x57, 0x4e,
+	0x6e, 0xe0, 0xdf, 0xa3, 0x4b, 0x85, 0xc2, 0xe4, 0xf7, 0x3e, 0x3f, 0x4b, 0xcf, 0x70, 0xe3, 0x68,
+	0x39, 0xc4, 0xf9, 0xc8, 0x36, 0x47, 0x5a, 0x86, 0x95, 0x29, 0x93, 0xbc, 0x2c, 0x23, 0xf5, 0xaf,
+	0xd0, 0xee, 0x17, 0x17, 0x88, 0x3f, 0x10, 0x39, 0xc9, 0x67, 0x68, 0x6d, 0xb5, 0x9d, 0x50, 0x3b,
+	0xdd, 0x3e, 0xca, 0xed, 0x26, 0x0d, 0x35, 0x69, 0xfe, 0xc7, 0xfa, 0x37, 0x80, 0xba, 0x92, 0x12,
+	0xce, 0x03, 0xa5, 0xdc, 0x09, 0x25, 0x74, 0x63, 0x8a, 0x3e, 0xb1, 0x95, 0x38, 0x77, 0x67, 0x4a,
+	0xe8, 0x0b, 0x53, 0xf4, 0x89, 0x39, 0xe4, 0xdc, 0xed, 0x94, 0xd0, 0x57, 0xa6, 0xe8, 0xfe, 0x01,
+	0xae, 0xdf, 0xd9, 0x23, 0x23, 0xef, 0xbd, 0x67, 0x4c, 0x09, 0x93, 0xbc, 0x85, 0xc6, 0xfe, 0x99,
+	0xd2, 0xa8, 0x31, 0x15, 0xbc, 0xdc, 0x7f, 0xde, 0xcd, 0x31, 0x87, 0xe3, 0x34, 0x38, 0xfa, 0x1e,
+	0xc3, 0xcf, 0x8a, 0xfc, 0x85, 0x7e, 0x46, 0x1e, 0x0f, 0x76, 0xe2, 0xe8, 0xc6, 0xad, 0xfb, 0xb4,
+	0x3d, 0xfc, 0xf4, 0x3b, 0x00, 0xec, 0x38, 0xa4, 0x14, 0x0e, 0x01, 0x00, 0x00,
+}
@@ -25,22 +25,42 @@ func (m *Block2) String() string            { return proto.CompactTextString(m)
 func (*Block2) ProtoMessage()               {}
 func (*Block2) Descriptor() ([]byte, []int) { return fileDescriptor7, []int{0} }
 
+// BlockLocalMetadata is computed by a peer for a block it commits and kept next to the block. It is not
+// part of the block and not covered by the block hash. updatesHash is the hash of the state updates of the
+// valid transactions of the block and stateHash is the hash of all the keys and values of the state after
+// the commit of the block, so that peers with the same stateHash for a block have the same state at that block
+type BlockLocalMetadata struct {
+	BlockNumber uint64 `protobuf:"varint,1,opt,name=blockNumber" json:"blockNumber,omitempty"`
+	UpdatesHash []byte `protobuf:"bytes,2,opt,name=updatesHash,proto3" json:"updatesHash,omitempty"`
+	StateHash   []byte `protobuf:"bytes,3,opt,name=stateHash,proto3" json:"stateHash,omitempty"`
+}
+
+func (m *BlockLocalMetadata) Reset()                    { *m = BlockLocalMetadata{} }
+func (m *BlockLocalMetadata) String() string            { return proto.CompactTextString(m) }
+func (*BlockLocalMetadata) ProtoMessage()               {}
+func (*BlockLocalMetadata) Descriptor() ([]byte, []int) { return fileDescriptor7, []int{1} }
+
 func init() {
 	proto.RegisterType((*Block2)(nil), "protos.Block2")
+	proto.RegisterType((*BlockLocalMetadata)(nil), "protos.BlockLocalMetadata")
 }
 
 func init() { proto.RegisterFile("fabric_block.proto", fileDescriptor7) }
 
 var fileDescriptor7 = []byte{
-	// 145 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0xe2, 0x12, 0x4a, 0x4b, 0x4c, 0x2a,
-	0xca, 0x4c, 0x8e, 0x4f, 0xca, 0xc9, 0x4f, 0xce, 0xd6, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0x62,
-	0x03, 0x53, 0xc5, 0x4a, 0x51, 0x5c, 0x6c, 0x4e, 0x20, 0x61, 0x23, 0x21, 0x1d, 0x2e, 0xc1, 0x80,
-	0xa2, 0xd4, 0xb2, 0xcc, 0xfc, 0xd2, 0x62, 0xb0, 0x88, 0x47, 0x62, 0x71, 0x86, 0x04, 0xa3, 0x02,
-	0xa3, 0x06, 0x4f, 0x10, 0xa6, 0x84, 0x90, 0x12, 0x17, 0x4f, 0x48, 0x51, 0x62, 0x5e, 0x71, 0x62,
-	0x72, 0x49, 0x66, 0x7e, 0x5e, 0xb1, 0x04, 0x93, 0x02, 0xb3, 0x06, 0x4f, 0x10, 0x8a, 0x98, 0x93,
-	0x5a, 0x94, 0x4a, 0x7a, 0x66, 0x49, 0x46, 0x69, 0x92, 0x5e, 0x72, 0x7e, 0xae, 0x7e, 0x46, 0x65,
-	0x41, 0x6a, 0x51, 0x4e, 0x6a, 0x4a, 0x7a, 0x6a, 0x91, 0x3e, 0xc4, 0x41, 0xfa, 0x10, 0x37, 0x24,
-	0x41, 0xdc, 0x62, 0x0c, 0x08, 0x00, 0x00, 0xff, 0xff, 0x72, 0xcb, 0x0c, 0x19, 0xa8, 0x00, 0x00,
+	// 209 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x64, 0x90, 0x41, 0x4b, 0x86, 0x40,
+	0x10, 0x86, 0xf9, 0x3e, 0x43, 0x68, 0xf3, 0xd2, 0x9e, 0x3c, 0x74, 0x10, 0x89, 0xf0, 0x10, 0x0a,
+	0xf5, 0x0f, 0x3c, 0x75, 0xa8, 0x08, 0xe9, 0xe4, 0x25, 0x66, 0xd7, 0x49, 0x97, 0xd4, 0x95, 0xdd,
+	0x59, 0xa1, 0x7f, 0x1f, 0x8e, 0x87, 0x8c, 0x4e, 0x0b, 0xcf, 0xbb, 0xc3, 0x3c, 0xf3, 0x0a, 0xf9,
+	0x09, 0xca, 0x19, 0xfd, 0xa1, 0x46, 0xab, 0xbf, 0xca, 0xc5, 0x59, 0xb2, 0x32, 0xe6, 0xc7, 0xe7,
+	0xad, 0x88, 0xeb, 0x0d, 0x3f, 0xc8, 0x7b, 0x71, 0xfd, 0xe6, 0x70, 0x35, 0x36, 0x78, 0x26, 0x4f,
+	0xe0, 0x87, 0xf4, 0x94, 0x9d, 0x8a, 0xa4, 0xf9, 0x1f, 0xc8, 0x5c, 0x24, 0xef, 0x0e, 0x66, 0x0f,
+	0x9a, 0x8c, 0x9d, 0x7d, 0x7a, 0xce, 0xa2, 0x22, 0x69, 0xfe, 0xb0, 0x7c, 0x15, 0x92, 0x07, 0x9e,
+	0xad, 0x86, 0xf1, 0x05, 0x09, 0x3a, 0x20, 0x90, 0x99, 0xb8, 0x62, 0x91, 0xd7, 0x30, 0x29, 0x74,
+	0xbc, 0xe1, 0xa2, 0x39, 0xa2, 0xed, 0x47, 0x58, 0x3a, 0x20, 0xf4, 0xec, 0x70, 0x66, 0x87, 0x23,
+	0x92, 0x37, 0xe2, 0xd2, 0x13, 0x10, 0x72, 0x1e, 0x71, 0xfe, 0x0b, 0xea, 0xbb, 0xf6, 0xb6, 0x37,
+	0x34, 0x04, 0x55, 0x6a, 0x3b, 0x55, 0xc3, 0xf7, 0x82, 0x6e, 0xc4, 0xae, 0x47, 0x57, 0xed, 0x45,
+	0x54, 0xfb, 0xed, 0x6a, 0xef, 0xe0, 0xf1, 0x67, 0x00, 0x3f, 0xd7, 0x62, 0xfe, 0x20, 0x01, 0x00,
 	0x00,
 }
@@ -18,6 +18,166 @@ var _ = proto.Marshal
 var _ = fmt.Errorf
 var _ = math.Inf
 
+// A chunk of a marshalled signed proposal. The size of the proposal is set on the
+// first chunk and its SHA-256 hash on the last one, the reassembled proposal
+// is checked against both.
+type ProposalChunk struct {
+	Content []byte `protobuf:"bytes,1,opt,name=content,proto3" json:"content,omitempty"`
+	Size    uint64 `protobuf:"varint,2,opt,name=size" json:"size,omitempty"`
+	Hash    []byte `protobuf:"bytes,3,opt,name=hash,proto3" json:"hash,omitempty"`
+}
+
+func (m *ProposalChunk) Reset()                    { *m = ProposalChunk{} }
+func (m *ProposalChunk) String() string            { return proto.CompactTextString(m) }
+func (*ProposalChunk) ProtoMessage()               {}
+func (*ProposalChunk) Descriptor() ([]byte, []int) { return fileDescriptor12, []int{0} }
+
+// A call to a chaincode function in a transaction, with the private data
+// collections it reads or writes besides those declared by the chaincode.
+type ChaincodeCall struct {
+	Name        string   `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Function    string   `protobuf:"bytes,2,opt,name=function" json:"function,omitempty"`
+	Collections []string `protobuf:"bytes,3,rep,name=collections" json:"collections,omitempty"`
+}
+
+func (m *ChaincodeCall) Reset()                    { *m = ChaincodeCall{} }
+func (m *ChaincodeCall) String() string            { return proto.CompactTextString(m) }
+func (*ChaincodeCall) ProtoMessage()               {}
+func (*ChaincodeCall) Descriptor() ([]byte, []int) { return fileDescriptor12, []int{1} }
+
+// The chaincode calls a client intends to make in one transaction.
+type EndorsementLayoutsRequest struct {
+	ChainID string           `protobuf:"bytes,1,opt,name=chainID" json:"chainID,omitempty"`
+	Calls   []*ChaincodeCall `protobuf:"bytes,2,rep,name=calls" json:"calls,omitempty"`
+}
+
+func (m *EndorsementLayoutsRequest) Reset()                    { *m = EndorsementLayoutsRequest{} }
+func (m *EndorsementLayoutsRequest) String() string            { return proto.CompactTextString(m) }
+func (*EndorsementLayoutsRequest) ProtoMessage()               {}
+func (*EndorsementLayoutsRequest) Descriptor() ([]byte, []int) { return fileDescriptor12, []int{2} }
+
+func (m *EndorsementLayoutsRequest) GetCalls() []*ChaincodeCall {
+	if m != nil {
+		return m.Calls
+	}
+	return nil
+}
+
+// The peers of an organization, any of which may endorse for it.
+type OrganizationEndorsers struct {
+	Organization string   `protobuf:"bytes,1,opt,name=organization" json:"organization,omitempty"`
+	Peers        []string `protobuf:"bytes,2,rep,name=peers" json:"peers,omitempty"`
+}
+
+func (m *OrganizationEndorsers) Reset()                    { *m = OrganizationEndorsers{} }
+func (m *OrganizationEndorsers) String() string            { return proto.CompactTextString(m) }
+func (*OrganizationEndorsers) ProtoMessage()               {}
+func (*OrganizationEndorsers) Descriptor() ([]byte, []int) { return fileDescriptor12, []int{3} }
+
+// A minimal set of organizations whose endorsements, one from a peer of each,
+// satisfy all the endorsement policies of a call pattern.
+type EndorsementLayout struct {
+	Organizations []*OrganizationEndorsers `protobuf:"bytes,1,rep,name=organizations" json:"organizations,omitempty"`
+}
+
+func (m *EndorsementLayout) Reset()                    { *m = EndorsementLayout{} }
+func (m *EndorsementLayout) String() string            { return proto.CompactTextString(m) }
+func (*EndorsementLayout) ProtoMessage()               {}
+func (*EndorsementLayout) Descriptor() ([]byte, []int) { return fileDescriptor12, []int{4} }
+
+func (m *EndorsementLayout) GetOrganizations() []*OrganizationEndorsers {
+	if m != nil {
+		return m.Organizations
+	}
+	return nil
+}
+
+type EndorsementLayoutsResponse struct {
+	Layouts []*EndorsementLayout `protobuf:"bytes,1,rep,name=layouts" json:"layouts,omitempty"`
+}
+
+func (m *EndorsementLayoutsResponse) Reset()                    { *m = EndorsementLayoutsResponse{} }
+func (m *EndorsementLayoutsResponse) String() string            { return proto.CompactTextString(m) }
+func (*EndorsementLayoutsResponse) ProtoMessage()               {}
+func (*EndorsementLayoutsResponse) Descriptor() ([]byte, []int) { return fileDescriptor12, []int{5} }
+
+func (m *EndorsementLayoutsResponse) GetLayouts() []*EndorsementLayout {
+	if m != nil {
+		return m.Layouts
+	}
+	return nil
+}
+
+// A discovery request for a chain, with the chaincode calls the client intends
+// to make in one transaction if it needs their endorsement layouts.
+type DiscoveryRequest struct {
+	ChainID  string           `protobuf:"bytes,1,opt,name=chainID" json:"chainID,omitempty"`
+	Interest []*ChaincodeCall `protobuf:"bytes,2,rep,name=interest" json:"interest,omitempty"`
+}
+
+func (m *DiscoveryRequest) Reset()                    { *m = DiscoveryRequest{} }
+func (m *DiscoveryRequest) String() string            { return proto.CompactTextString(m) }
+func (*DiscoveryRequest) ProtoMessage()               {}
+func (*DiscoveryRequest) Descriptor() ([]byte, []int) { return fileDescriptor12, []int{6} }
+
+func (m *DiscoveryRequest) GetInterest() []*ChaincodeCall {
+	if m != nil {
+		return m.Interest
+	}
+	return nil
+}
+
+// A peer of a chain, with the height of its ledger and the chaincodes
+// installed on it, as name:version.
+type PeerInfo struct {
+	Endpoint     string   `protobuf:"bytes,1,opt,name=endpoint" json:"endpoint,omitempty"`
+	Organization string   `protobuf:"bytes,2,opt,name=organization" json:"organization,omitempty"`
+	LedgerHeight uint64   `protobuf:"varint,3,opt,name=ledgerHeight" json:"ledgerHeight,omitempty"`
+	Chaincodes   []string `protobuf:"bytes,4,rep,name=chaincodes" json:"chaincodes,omitempty"`
+}
+
+func (m *PeerInfo) Reset()                    { *m = PeerInfo{} }
+func (m *PeerInfo) String() string            { return proto.CompactTextString(m) }
+func (*PeerInfo) ProtoMessage()               {}
+func (*PeerInfo) Descriptor() ([]byte, []int) { return fileDescriptor12, []int{7} }
+
+type DiscoveryResponse struct {
+	Peers    []*PeerInfo          `protobuf:"bytes,1,rep,name=peers" json:"peers,omitempty"`
+	Orderers []string             `protobuf:"bytes,2,rep,name=orderers" json:"orderers,omitempty"`
+	Layouts  []*EndorsementLayout `protobuf:"bytes,3,rep,name=layouts" json:"layouts,omitempty"`
+}
+
+func (m *DiscoveryResponse) Reset()                    { *m = DiscoveryResponse{} }
+func (m *DiscoveryResponse) String() string            { return proto.CompactTextString(m) }
+func (*DiscoveryResponse) ProtoMessage()               {}
+func (*DiscoveryResponse) Descriptor() ([]byte, []int) { return fileDescriptor12, []int{8} }
+
+func (m *DiscoveryResponse) GetPeers() []*PeerInfo {
+	if m != nil {
+		return m.Peers
+	}
+	return nil
+}
+
+func (m *DiscoveryResponse) GetLayouts() []*EndorsementLayout {
+	if m != nil {
+		return m.Layouts
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*ProposalChunk)(nil), "protos.ProposalChunk")
+	proto.RegisterType((*ChaincodeCall)(nil), "protos.ChaincodeCall")
+	proto.RegisterType((*EndorsementLayoutsRequest)(nil), "protos.EndorsementLayoutsRequest")
+	proto.RegisterType((*OrganizationEndorsers)(nil), "protos.OrganizationEndorsers")
+	proto.RegisterType((*EndorsementLayout)(nil), "protos.EndorsementLayout")
+	proto.RegisterType((*EndorsementLayoutsResponse)(nil), "protos.EndorsementLayoutsResponse")
+	proto.RegisterType((*DiscoveryRequest)(nil), "protos.DiscoveryRequest")
+	proto.RegisterType((*PeerInfo)(nil), "protos.PeerInfo")
+	proto.RegisterType((*DiscoveryResponse)(nil), "protos.DiscoveryResponse")
+}
+
 // Reference imports to suppress errors if they are not otherwise used.
 var _ context.Context
 var _ grpc.ClientConn
@@ -29,7 +189,15 @@ const _ = grpc.SupportPackageIsVersion3
 // Client API for Endorser service
 
 type EndorserClient interface {
-	ProcessProposal(ctx context.Context, in *Proposal, opts ...grpc.CallOption) (*ProposalResponse, error)
+	// ProcessProposal processes a proposal signed by its creator, whose
+	// signature is verified against the creator certificate of its header
+	ProcessProposal(ctx context.Context, in *SignedProposal, opts ...grpc.CallOption) (*ProposalResponse, error)
+	// ProcessProposalStream processes a signed proposal too large for a single
+	// message, sent as a stream of chunks of the marshalled signed proposal
+	ProcessProposalStream(ctx context.Context, opts ...grpc.CallOption) (Endorser_ProcessProposalStreamClient, error)
+	// GetEndorsementLayouts returns the sets of peers whose endorsements
+	// satisfy the endorsement policies of all the chaincodes of a call pattern
+	GetEndorsementLayouts(ctx context.Context, in *EndorsementLayoutsRequest, opts ...grpc.CallOption) (*EndorsementLayoutsResponse, error)
 }
 
 type endorserClient struct {
@@ -40,7 +208,7 @@ func NewEndorserClient(cc *grpc.ClientConn) EndorserClient {
 	return &endorserClient{cc}
 }
 
-func (c *endorserClient) ProcessProposal(ctx context.Context, in *Proposal, opts ...grpc.CallOption) (*ProposalResponse, error) {
+func (c *endorserClient) ProcessProposal(ctx context.Context, in *SignedProposal, opts ...grpc.CallOption) (*ProposalResponse, error) {
 	out := new(ProposalResponse)
 	err := grpc.Invoke(ctx, "/protos.Endorser/ProcessProposal", in, out, c.cc, opts...)
 	if err != nil {
@@ -49,10 +217,61 @@ func (c *endorserClient) ProcessProposal(ctx context.Context, in *Proposal, opts
 	return out, nil
 }
 
+func (c *endorserClient) ProcessProposalStream(ctx context.Context, opts ...grpc.CallOption) (Endorser_ProcessProposalStreamClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Endorser_serviceDesc.Streams[0], c.cc, "/protos.Endorser/ProcessProposalStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &endorserProcessProposalStreamClient{stream}
+	return x, nil
+}
+
+type Endorser_ProcessProposalStreamClient interface {
+	Send(*ProposalChunk) error
+	CloseAndRecv() (*ProposalResponse, error)
+	grpc.ClientStream
+}
+
+type endorserProcessProposalStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *endorserProcessProposalStreamClient) Send(m *ProposalChunk) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *endorserProcessProposalStreamClient) CloseAndRecv() (*ProposalResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(ProposalResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *endorserClient) GetEndorsementLayouts(ctx context.Context, in *EndorsementLayoutsRequest, opts ...grpc.CallOption) (*EndorsementLayoutsResponse, error) {
+	out := new(EndorsementLayoutsResponse)
+	err := grpc.Invoke(ctx, "/protos.Endorser/GetEndorsementLayouts", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for Endorser service
 
 type EndorserServer interface {
-	ProcessProposal(context.Context, *Proposal) (*ProposalResponse, error)
+	// ProcessProposal processes a proposal signed by its creator, whose
+	// signature is verified against the creator certificate of its header
+	ProcessProposal(context.Context, *SignedProposal) (*ProposalResponse, error)
+	// ProcessProposalStream processes a signed proposal too large for a single
+	// message, sent as a stream of chunks of the marshalled signed proposal
+	ProcessProposalStream(Endorser_ProcessProposalStreamServer) error
+	// GetEndorsementLayouts returns the sets of peers whose endorsements
+	// satisfy the endorsement policies of all the chaincodes of a call pattern
+	GetEndorsementLayouts(context.Context, *EndorsementLayoutsRequest) (*EndorsementLayoutsResponse, error)
 }
 
 func RegisterEndorserServer(s *grpc.Server, srv EndorserServer) {
@@ -60,7 +279,7 @@ func RegisterEndorserServer(s *grpc.Server, srv EndorserServer) {
 }
 
 func _Endorser_ProcessProposal_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(Proposal)
+	in := new(SignedProposal)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
@@ -72,7 +291,51 @@ func _Endorser_ProcessProposal_Handler(srv interface{}, ctx context.Context, dec
 		FullMethod: "/protos.Endorser/ProcessProposal",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(EndorserServer).ProcessProposal(ctx, req.(*Proposal))
+		return srv.(EndorserServer).ProcessProposal(ctx, req.(*SignedProposal))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Endorser_ProcessProposalStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(EndorserServer).ProcessProposalStream(&endorserProcessProposalStreamServer{stream})
+}
+
+type Endorser_ProcessProposalStreamServer interface {
+	SendAndClose(*ProposalResponse) error
+	Recv() (*ProposalChunk, error)
+	grpc.ServerStream
+}
+
+type endorserProcessProposalStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *endorserProcessProposalStreamServer) SendAndClose(m *ProposalResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *endorserProcessProposalStreamServer) Recv() (*ProposalChunk, error) {
+	m := new(ProposalChunk)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Endorser_GetEndorsementLayouts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EndorsementLayoutsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EndorserServer).GetEndorsementLayouts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protos.Endorser/GetEndorsementLayouts",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EndorserServer).GetEndorsementLayouts(ctx, req.(*EndorsementLayoutsRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -85,6 +348,80 @@ var _Endorser_serviceDesc = grpc.ServiceDesc{
 			MethodName: "ProcessProposal",
 			Handler:    _Endorser_ProcessProposal_Handler,
 		},
+		{
+			MethodName: "GetEndorsementLayouts",
+			Handler:    _Endorser_GetEndorsementLayouts_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ProcessProposalStream",
+			Handler:       _Endorser_ProcessProposalStream_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: fileDescriptor12,
+}
+
+// Client API for Discovery service
+
+type DiscoveryClient interface {
+	Discover(ctx context.Context, in *DiscoveryRequest, opts ...grpc.CallOption) (*DiscoveryResponse, error)
+}
+
+type discoveryClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewDiscoveryClient(cc *grpc.ClientConn) DiscoveryClient {
+	return &discoveryClient{cc}
+}
+
+func (c *discoveryClient) Discover(ctx context.Context, in *DiscoveryRequest, opts ...grpc.CallOption) (*DiscoveryResponse, error) {
+	out := new(DiscoveryResponse)
+	err := grpc.Invoke(ctx, "/protos.Discovery/Discover", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for Discovery service
+
+type DiscoveryServer interface {
+	Discover(context.Context, *DiscoveryRequest) (*DiscoveryResponse, error)
+}
+
+func RegisterDiscoveryServer(s *grpc.Server, srv DiscoveryServer) {
+	s.RegisterService(&_Discovery_serviceDesc, srv)
+}
+
+func _Discovery_Discover_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DiscoveryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DiscoveryServer).Discover(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protos.Discovery/Discover",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DiscoveryServer).Discover(ctx, req.(*DiscoveryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Discovery_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "protos.Discovery",
+	HandlerType: (*DiscoveryServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Discover",
+			Handler:    _Discovery_Discover_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: fileDescriptor12,
@@ -93,15 +430,42 @@ var _Endorser_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("fabric_service.proto", fileDescriptor12) }
 
 var fileDescriptor12 = []byte{
-	// 158 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0xe2, 0x12, 0x49, 0x4b, 0x4c, 0x2a,
-	0xca, 0x4c, 0x8e, 0x2f, 0x4e, 0x2d, 0x2a, 0xcb, 0x4c, 0x4e, 0xd5, 0x2b, 0x28, 0xca, 0x2f, 0xc9,
-	0x17, 0x62, 0x03, 0x53, 0xc5, 0x52, 0xa2, 0x50, 0xd9, 0x82, 0xa2, 0xfc, 0x82, 0xfc, 0xe2, 0xc4,
-	0x1c, 0x88, 0xb4, 0x94, 0x1c, 0x9a, 0x70, 0x7c, 0x51, 0x6a, 0x71, 0x41, 0x7e, 0x5e, 0x31, 0x54,
-	0xbb, 0x91, 0x37, 0x17, 0x87, 0x6b, 0x5e, 0x4a, 0x7e, 0x51, 0x71, 0x6a, 0x91, 0x90, 0x3d, 0x17,
-	0x7f, 0x40, 0x51, 0x7e, 0x72, 0x6a, 0x71, 0x71, 0x00, 0x54, 0xb5, 0x90, 0x00, 0x44, 0x59, 0xb1,
-	0x1e, 0x4c, 0x44, 0x4a, 0x02, 0x5d, 0x24, 0x08, 0x6a, 0xa0, 0x12, 0x83, 0x93, 0x5a, 0x94, 0x4a,
-	0x7a, 0x66, 0x49, 0x46, 0x69, 0x92, 0x5e, 0x72, 0x7e, 0xae, 0x7e, 0x46, 0x65, 0x41, 0x6a, 0x51,
-	0x4e, 0x6a, 0x4a, 0x7a, 0x6a, 0x91, 0x3e, 0xc4, 0x15, 0xfa, 0x10, 0xad, 0x49, 0x10, 0x37, 0x1b,
-	0x03, 0x02, 0x00, 0x00, 0xff, 0xff, 0x56, 0x55, 0x3b, 0xd9, 0xd2, 0x00, 0x00, 0x00,
+	// 588 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x54, 0x5b, 0x4f, 0x13, 0x41,
+	0x14, 0x76, 0xdb, 0x22, 0xed, 0x01, 0x22, 0x4c, 0xa8, 0x59, 0x36, 0x91, 0xd4, 0x89, 0x21, 0x4d,
+	0x4c, 0x20, 0xc2, 0x2f, 0xd0, 0x42, 0x94, 0x84, 0x28, 0x2c, 0x2f, 0xc6, 0x17, 0x9c, 0x6e, 0x0f,
+	0xdd, 0x89, 0xdb, 0x99, 0x75, 0x66, 0x4a, 0x02, 0x6f, 0xbe, 0x1b, 0x7f, 0xb3, 0xe9, 0x5c, 0xd6,
+	0xed, 0x45, 0xf4, 0x69, 0xe7, 0x7c, 0xe7, 0xf6, 0x9d, 0xcb, 0x1e, 0xd8, 0xbd, 0x65, 0x43, 0xc5,
+	0xb3, 0x1b, 0x8d, 0xea, 0x8e, 0x67, 0x78, 0x58, 0x2a, 0x69, 0x24, 0x79, 0x6a, 0x3f, 0x3a, 0xe9,
+	0x7a, 0x6d, 0xa9, 0x64, 0x29, 0x35, 0x2b, 0x9c, 0x3a, 0xd9, 0x5f, 0x80, 0x6f, 0x14, 0xea, 0x52,
+	0x0a, 0xed, 0xdd, 0xe9, 0x15, 0x6c, 0x5d, 0x7a, 0xd5, 0x20, 0x9f, 0x8a, 0x6f, 0x24, 0x86, 0xf5,
+	0x4c, 0x0a, 0x83, 0xc2, 0xc4, 0x51, 0x2f, 0xea, 0x6f, 0xa6, 0x41, 0x24, 0x04, 0x5a, 0x9a, 0x3f,
+	0x60, 0xdc, 0xe8, 0x45, 0xfd, 0x56, 0x6a, 0xdf, 0x33, 0x2c, 0x67, 0x3a, 0x8f, 0x9b, 0xd6, 0xd4,
+	0xbe, 0x29, 0x83, 0xad, 0x41, 0xce, 0xb8, 0xc8, 0xe4, 0x08, 0x07, 0xac, 0x28, 0x66, 0x46, 0x82,
+	0x4d, 0xd0, 0xc6, 0xeb, 0xa4, 0xf6, 0x4d, 0x12, 0x68, 0xdf, 0x4e, 0x45, 0x66, 0xb8, 0x14, 0x36,
+	0x60, 0x27, 0xad, 0x64, 0xd2, 0x83, 0x8d, 0x4c, 0x16, 0x05, 0x5a, 0x49, 0xc7, 0xcd, 0x5e, 0xb3,
+	0xdf, 0x49, 0xeb, 0x10, 0x1d, 0xc2, 0xde, 0x99, 0x18, 0x49, 0xa5, 0x71, 0x82, 0xc2, 0x5c, 0xb0,
+	0x7b, 0x39, 0x35, 0x3a, 0xc5, 0xef, 0x53, 0xd4, 0xc6, 0x56, 0x30, 0xcb, 0x7f, 0x7e, 0xea, 0x33,
+	0x06, 0x91, 0xbc, 0x86, 0xb5, 0x8c, 0x15, 0x85, 0x8e, 0x1b, 0xbd, 0x66, 0x7f, 0xe3, 0xb8, 0xeb,
+	0x7a, 0xa0, 0x0f, 0xe7, 0xe8, 0xa6, 0xce, 0x86, 0x5e, 0x41, 0xf7, 0x93, 0x1a, 0x33, 0xc1, 0x1f,
+	0xd8, 0x2c, 0xa9, 0xcf, 0xa7, 0x34, 0xa1, 0xb0, 0x29, 0x6b, 0x0a, 0x9f, 0x64, 0x0e, 0x23, 0xbb,
+	0xb0, 0x56, 0x22, 0x2a, 0x97, 0xa9, 0x93, 0x3a, 0x81, 0x7e, 0x86, 0x9d, 0x25, 0xda, 0x64, 0x00,
+	0x5b, 0x75, 0x57, 0x1d, 0x47, 0x96, 0xdc, 0x8b, 0x40, 0x6e, 0x25, 0x89, 0x74, 0xde, 0x87, 0x5e,
+	0x41, 0xb2, 0xaa, 0x21, 0x6e, 0xd4, 0xe4, 0x04, 0xd6, 0x0b, 0x07, 0xf9, 0xe0, 0x7b, 0x21, 0xf8,
+	0x92, 0x53, 0x1a, 0x2c, 0xe9, 0x0d, 0x6c, 0x9f, 0x72, 0x9d, 0xc9, 0x3b, 0x54, 0xf7, 0xff, 0x6e,
+	0xed, 0x1b, 0x68, 0x73, 0x61, 0x50, 0xa1, 0x36, 0x8f, 0x77, 0xb7, 0x32, 0xa3, 0xbf, 0x22, 0x68,
+	0x5f, 0x22, 0xaa, 0x73, 0x71, 0x2b, 0x67, 0xfb, 0x80, 0x62, 0x54, 0x4a, 0xee, 0xf7, 0xae, 0x93,
+	0x56, 0xf2, 0x52, 0xc3, 0x1b, 0x2b, 0x1a, 0x4e, 0x61, 0xb3, 0xc0, 0xd1, 0x18, 0xd5, 0x07, 0xe4,
+	0xe3, 0xdc, 0xd8, 0x85, 0x6c, 0xa5, 0x73, 0x18, 0xd9, 0x07, 0xc8, 0x02, 0x17, 0x1d, 0xb7, 0xec,
+	0x64, 0x6a, 0x08, 0xfd, 0x19, 0xc1, 0x4e, 0xad, 0x64, 0xdf, 0xbc, 0x83, 0x30, 0x4a, 0xd7, 0xba,
+	0xed, 0x50, 0x56, 0xa0, 0xee, 0x87, 0x3b, 0xab, 0x40, 0xaa, 0x11, 0xaa, 0x3f, 0x53, 0xaf, 0xe4,
+	0xfa, 0x00, 0x9a, 0xff, 0x3b, 0x80, 0xe3, 0x1f, 0x0d, 0x68, 0x7b, 0xb5, 0x22, 0x67, 0xf0, 0xec,
+	0x52, 0xc9, 0x0c, 0xb5, 0x0e, 0xbf, 0x2b, 0x79, 0x1e, 0x62, 0x5c, 0xf3, 0xb1, 0xc0, 0x51, 0xc0,
+	0x93, 0xb8, 0x62, 0xe8, 0x91, 0x50, 0x0a, 0x7d, 0x42, 0x2e, 0xa0, 0xbb, 0x10, 0xe6, 0xda, 0x28,
+	0x64, 0x13, 0xd2, 0x5d, 0x74, 0xb2, 0xd7, 0xe0, 0xb1, 0x58, 0xfd, 0x88, 0x7c, 0x85, 0xee, 0x7b,
+	0x34, 0xcb, 0x8b, 0x47, 0x5e, 0xfe, 0xb5, 0xbc, 0xf0, 0x97, 0x26, 0xf4, 0x31, 0x93, 0x90, 0xe3,
+	0xf8, 0x23, 0x74, 0xaa, 0x89, 0x90, 0xb7, 0xd0, 0x0e, 0x02, 0xa9, 0x88, 0x2d, 0xee, 0x68, 0xb2,
+	0xb7, 0x42, 0x13, 0xe2, 0xbd, 0x3b, 0xf8, 0xf2, 0x6a, 0xcc, 0x4d, 0x3e, 0x1d, 0x1e, 0x66, 0x72,
+	0x72, 0x94, 0xdf, 0x97, 0xa8, 0xdc, 0x8a, 0x1c, 0xb9, 0x3b, 0x79, 0xe4, 0x7c, 0x87, 0xee, 0xaa,
+	0x9e, 0xfc, 0x1e, 0x00, 0xc8, 0x67, 0x09, 0xd5, 0x74, 0x05, 0x00, 0x00,
 }
@@ -14,6 +14,34 @@ var _ = proto.Marshal
 var _ = fmt.Errorf
 var _ = math.Inf
 
+// The outcome of the validation of a transaction at commit, recorded by the
+// peer for the valid transactions of a block and for the invalid ones removed
+// from it. The codes of the invalid transactions correspond to the causes of
+// InvalidTransaction
+type TxValidationCode int32
+
+const (
+	TxValidationCode_Valid                  TxValidationCode = 0
+	TxValidationCode_TxIdAlreadyExists      TxValidationCode = 1
+	TxValidationCode_RWConflictDuringCommit TxValidationCode = 2
+)
+
+var TxValidationCode_name = map[int32]string{
+	0: "Valid",
+	1: "TxIdAlreadyExists",
+	2: "RWConflictDuringCommit",
+}
+var TxValidationCode_value = map[string]int32{
+	"Valid":                  0,
+	"TxIdAlreadyExists":      1,
+	"RWConflictDuringCommit": 2,
+}
+
+func (x TxValidationCode) String() string {
+	return proto.EnumName(TxValidationCode_name, int32(x))
+}
+func (TxValidationCode) EnumDescriptor() ([]byte, []int) { return fileDescriptor14, []int{0} }
+
 type InvalidTransaction_Cause int32
 
 const (
@@ -133,34 +161,37 @@ func init() {
 	proto.RegisterType((*InvalidTransaction)(nil), "protos.InvalidTransaction")
 	proto.RegisterType((*Transaction2)(nil), "protos.Transaction2")
 	proto.RegisterType((*TransactionAction)(nil), "protos.TransactionAction")
+	proto.RegisterEnum("protos.TxValidationCode", TxValidationCode_name, TxValidationCode_value)
 	proto.RegisterEnum("protos.InvalidTransaction_Cause", InvalidTransaction_Cause_name, InvalidTransaction_Cause_value)
 }
 
 func init() { proto.RegisterFile("fabric_transaction.proto", fileDescriptor14) }
 
 var fileDescriptor14 = []byte{
-	// 367 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x6c, 0x92, 0xd1, 0x6a, 0xfa, 0x30,
-	0x18, 0xc5, 0xff, 0xfd, 0x8b, 0x8a, 0x5f, 0x65, 0x68, 0xd8, 0xa4, 0x93, 0xc1, 0xa4, 0x8c, 0x21,
-	0xbb, 0x68, 0xa1, 0x82, 0x8c, 0xdd, 0xa9, 0xf3, 0xc2, 0xdb, 0x4e, 0x18, 0x0c, 0xc6, 0x48, 0xdb,
-	0x58, 0x03, 0x6d, 0x53, 0x92, 0x54, 0xec, 0x93, 0xec, 0x75, 0xf6, 0x68, 0xc3, 0xc6, 0xcc, 0x0e,
-	0x77, 0xd3, 0x72, 0xd2, 0x5f, 0xcf, 0x77, 0xc8, 0xf9, 0xc0, 0xda, 0xe0, 0x80, 0xd3, 0xf0, 0x43,
-	0x72, 0x9c, 0x09, 0x1c, 0x4a, 0xca, 0x32, 0x27, 0xe7, 0x4c, 0x32, 0xd4, 0xaa, 0x5e, 0x62, 0x78,
-	0x1b, 0x33, 0x16, 0x27, 0xc4, 0xad, 0x64, 0x50, 0x6c, 0x5c, 0x49, 0x53, 0x22, 0x24, 0x4e, 0x73,
-	0x05, 0xda, 0xef, 0xd0, 0x7f, 0xa1, 0x71, 0x46, 0xa2, 0xf5, 0xc9, 0x03, 0x3d, 0x40, 0xaf, 0x66,
-	0x39, 0x2f, 0x25, 0x11, 0x96, 0x31, 0x32, 0xc6, 0x5d, 0xff, 0xec, 0x1c, 0xdd, 0x40, 0x47, 0xd0,
-	0x38, 0xc3, 0xb2, 0xe0, 0xc4, 0xfa, 0x5f, 0x41, 0xa7, 0x03, 0xfb, 0xcb, 0x00, 0xb4, 0xca, 0x76,
-	0x38, 0xa1, 0xbf, 0x06, 0x4c, 0xc1, 0xac, 0x19, 0x55, 0xde, 0xa6, 0x77, 0xa9, 0x22, 0x09, 0xa7,
-	0x46, 0x7a, 0x7e, 0x1d, 0x44, 0x53, 0x68, 0x86, 0xb8, 0x10, 0x6a, 0xd0, 0x85, 0x37, 0xd2, 0x7f,
-	0x9c, 0x8f, 0x70, 0x16, 0x07, 0xce, 0x57, 0xb8, 0xfd, 0x04, 0xcd, 0x4a, 0xa3, 0x2b, 0xe8, 0xaf,
-	0xf7, 0xab, 0x68, 0x96, 0x70, 0x82, 0xa3, 0x72, 0xb9, 0xa7, 0x42, 0x8a, 0xde, 0x3f, 0x34, 0x84,
-	0x81, 0xff, 0xba, 0x60, 0xd9, 0x26, 0xa1, 0xa1, 0x7c, 0x2e, 0x38, 0xcd, 0xe2, 0x05, 0x4b, 0x53,
-	0x2a, 0x7b, 0x86, 0xfd, 0x69, 0x40, 0xb7, 0x9e, 0x08, 0x59, 0xd0, 0xde, 0x11, 0x2e, 0x74, 0xf0,
-	0xa6, 0xaf, 0x25, 0x7a, 0x84, 0xce, 0xcf, 0xfd, 0x56, 0x11, 0x4d, 0x6f, 0xe8, 0xa8, 0x06, 0x1c,
-	0xdd, 0x80, 0xb3, 0xd6, 0x84, 0x7f, 0x82, 0xd1, 0x04, 0xda, 0xca, 0x5e, 0x58, 0x8d, 0x51, 0x63,
-	0x6c, 0x7a, 0xd7, 0x7f, 0x5c, 0xc6, 0xac, 0x7a, 0xfa, 0x9a, 0xb4, 0x97, 0xd0, 0x3f, 0xfb, 0x8a,
-	0x06, 0xd0, 0xda, 0x12, 0x1c, 0x11, 0x7e, 0x6c, 0xec, 0xa8, 0x0e, 0xa9, 0x73, 0x5c, 0x26, 0x0c,
-	0x47, 0xc7, 0x96, 0xb4, 0x9c, 0xdf, 0xbf, 0xdd, 0xc5, 0x54, 0x6e, 0x8b, 0xc0, 0x09, 0x59, 0xea,
-	0x6e, 0xcb, 0x9c, 0xf0, 0x84, 0x44, 0x31, 0xe1, 0xae, 0x5a, 0x2f, 0xb5, 0x3c, 0x22, 0x50, 0x3b,
-	0x35, 0xf9, 0x0e, 0x00, 0x00, 0xff, 0xff, 0x63, 0x98, 0x39, 0x84, 0x76, 0x02, 0x00, 0x00,
+	// 394 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x92, 0xd1, 0x8a, 0x9b, 0x40,
+	0x14, 0x86, 0xd7, 0x5d, 0xdc, 0x25, 0xc7, 0xa5, 0x98, 0xa1, 0x0d, 0x36, 0x14, 0x1a, 0xa4, 0x94,
+	0x90, 0x0b, 0x05, 0x03, 0xa1, 0xf4, 0x2e, 0xb1, 0xb9, 0xc8, 0x5d, 0xb1, 0xd2, 0x42, 0xa1, 0x94,
+	0x51, 0x27, 0x66, 0x40, 0x1d, 0x99, 0x19, 0x43, 0x7c, 0x92, 0xbe, 0x4e, 0x1f, 0xad, 0x38, 0x13,
+	0x1b, 0x4b, 0x42, 0x6f, 0x94, 0x73, 0xfc, 0xfc, 0xcf, 0xcf, 0x7f, 0x0e, 0x38, 0x7b, 0x9c, 0x70,
+	0x9a, 0xfe, 0x94, 0x1c, 0x57, 0x02, 0xa7, 0x92, 0xb2, 0xca, 0xab, 0x39, 0x93, 0x0c, 0x3d, 0xaa,
+	0x97, 0x98, 0xbe, 0xcd, 0x19, 0xcb, 0x0b, 0xe2, 0xab, 0x32, 0x69, 0xf6, 0xbe, 0xa4, 0x25, 0x11,
+	0x12, 0x97, 0xb5, 0x06, 0xdd, 0x1f, 0x30, 0xfe, 0x42, 0xf3, 0x8a, 0x64, 0xf1, 0x45, 0x03, 0x2d,
+	0xc0, 0x1e, 0x48, 0x6e, 0x5a, 0x49, 0x84, 0x63, 0xcc, 0x8c, 0xf9, 0x73, 0x74, 0xd5, 0x47, 0x6f,
+	0x60, 0x24, 0x68, 0x5e, 0x61, 0xd9, 0x70, 0xe2, 0xdc, 0x2b, 0xe8, 0xd2, 0x70, 0x7f, 0x1b, 0x80,
+	0x76, 0xd5, 0x11, 0x17, 0xf4, 0x9f, 0x01, 0x2b, 0xb0, 0x06, 0x42, 0x4a, 0xdb, 0x0a, 0x5e, 0x6a,
+	0x4b, 0xc2, 0x1b, 0x90, 0x41, 0x34, 0x04, 0xd1, 0x0a, 0xcc, 0x14, 0x37, 0x42, 0x0f, 0x7a, 0x11,
+	0xcc, 0xfa, 0x3f, 0xae, 0x47, 0x78, 0x61, 0xc7, 0x45, 0x1a, 0x77, 0x3f, 0x82, 0xa9, 0x6a, 0xf4,
+	0x0a, 0xc6, 0xf1, 0x69, 0x97, 0xad, 0x0b, 0x4e, 0x70, 0xd6, 0x6e, 0x4f, 0x54, 0x48, 0x61, 0xdf,
+	0xa1, 0x29, 0x4c, 0xa2, 0x6f, 0x21, 0xab, 0xf6, 0x05, 0x4d, 0xe5, 0xa7, 0x86, 0xd3, 0x2a, 0x0f,
+	0x59, 0x59, 0x52, 0x69, 0x1b, 0xee, 0x2f, 0x03, 0x9e, 0x87, 0x8e, 0x90, 0x03, 0x4f, 0x47, 0xc2,
+	0x45, 0x6f, 0xdc, 0x8c, 0xfa, 0x12, 0x7d, 0x80, 0xd1, 0xdf, 0x7c, 0x95, 0x45, 0x2b, 0x98, 0x7a,
+	0x7a, 0x03, 0x5e, 0xbf, 0x01, 0x2f, 0xee, 0x89, 0xe8, 0x02, 0xa3, 0x25, 0x3c, 0x69, 0x79, 0xe1,
+	0x3c, 0xcc, 0x1e, 0xe6, 0x56, 0xf0, 0xfa, 0x46, 0x18, 0x6b, 0xf5, 0x8c, 0x7a, 0xd2, 0xdd, 0xc2,
+	0xf8, 0xea, 0x2b, 0x9a, 0xc0, 0xe3, 0x81, 0xe0, 0x8c, 0xf0, 0xf3, 0xc6, 0xce, 0x55, 0xe7, 0xba,
+	0xc6, 0x6d, 0xc1, 0x70, 0x76, 0xde, 0x52, 0x5f, 0x2e, 0x3e, 0x83, 0x1d, 0x9f, 0xbe, 0x76, 0xf9,
+	0xe1, 0x4e, 0x21, 0x64, 0x19, 0x41, 0x23, 0x30, 0x55, 0xc7, 0xbe, 0xbb, 0x1d, 0x99, 0xf1, 0x9f,
+	0xc8, 0xee, 0x37, 0xef, 0xbf, 0xbf, 0xcb, 0xa9, 0x3c, 0x34, 0x89, 0x97, 0xb2, 0xd2, 0x3f, 0xb4,
+	0x35, 0xe1, 0x05, 0xc9, 0x72, 0xc2, 0x7d, 0x7d, 0xb0, 0xfa, 0x1c, 0x45, 0xa2, 0xaf, 0x74, 0xf9,
+	0x67, 0x00, 0x04, 0x8f, 0x6c, 0xe9, 0xc8, 0x02, 0x00, 0x00,
 }
@@ -69,6 +69,9 @@ type Header struct {
 	ChainID []byte `protobuf:"bytes,6,opt,name=chainID,proto3" json:"chainID,omitempty"`
 	// Extensions is used to include type-dependant fields
 	Extensions []byte `protobuf:"bytes,7,opt,name=extensions,proto3" json:"extensions,omitempty"`
+	// Epoch in which the header is generated. Together with the nonce and the
+	// creator it binds application data to the transaction
+	Epoch uint64 `protobuf:"varint,8,opt,name=epoch" json:"epoch,omitempty"`
 }
 
 func (m *Header) Reset()                    { *m = Header{} }
@@ -91,24 +94,24 @@ func init() {
 func init() { proto.RegisterFile("fabric_transaction_header.proto", fileDescriptor13) }
 
 var fileDescriptor13 = []byte{
-	// 296 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x4c, 0x90, 0xbd, 0x4e, 0xf3, 0x30,
-	0x14, 0x86, 0x3f, 0xf7, 0xeb, 0x8f, 0x6a, 0x7e, 0x54, 0x19, 0x06, 0xab, 0x03, 0x8d, 0xaa, 0x0a,
-	0x32, 0x39, 0x52, 0x59, 0x58, 0xa1, 0x29, 0x6a, 0x97, 0x22, 0x45, 0x65, 0x61, 0xa9, 0x9c, 0xf4,
-	0x34, 0xb1, 0xd4, 0xd8, 0x91, 0xed, 0x22, 0x72, 0x21, 0xdc, 0x2f, 0x8a, 0x4d, 0x80, 0x29, 0x7a,
-	0x4e, 0x9e, 0x73, 0x5e, 0xeb, 0xc5, 0x93, 0x03, 0x4f, 0xb5, 0xc8, 0x76, 0x56, 0x73, 0x69, 0x78,
-	0x66, 0x85, 0x92, 0xbb, 0x02, 0xf8, 0x1e, 0x34, 0xab, 0xb4, 0xb2, 0x8a, 0xf4, 0xdd, 0xc7, 0x8c,
-	0x27, 0xb9, 0x52, 0xf9, 0x11, 0x22, 0x87, 0xe9, 0xe9, 0x10, 0x59, 0x51, 0x82, 0xb1, 0xbc, 0xac,
-	0xbc, 0x38, 0xfd, 0xec, 0xe0, 0xfe, 0xca, 0x6d, 0x12, 0x8a, 0x07, 0xef, 0xa0, 0x8d, 0x50, 0x92,
-	0xa2, 0x00, 0x85, 0xbd, 0xa4, 0x45, 0xf2, 0x80, 0x87, 0x3f, 0x7b, 0xb4, 0x13, 0xa0, 0xf0, 0x6c,
-	0x3e, 0x66, 0xfe, 0x32, 0x6b, 0x2f, 0xb3, 0x6d, 0x6b, 0x24, 0xbf, 0x32, 0xb9, 0xc3, 0x5d, 0x5b,
-	0x57, 0x40, 0xff, 0x07, 0x28, 0xbc, 0x9c, 0x5f, 0x79, 0xdb, 0x30, 0x9f, 0xc8, 0xb6, 0x75, 0x05,
-	0x89, 0x13, 0x9a, 0xf0, 0x4c, 0x03, 0xb7, 0x4a, 0xd3, 0x6e, 0x80, 0xc2, 0xf3, 0xa4, 0x45, 0x72,
-	0x8d, 0x7b, 0x52, 0xc9, 0x0c, 0x68, 0xcf, 0xcd, 0x3d, 0x38, 0xbf, 0xe0, 0x42, 0xae, 0x63, 0xda,
-	0xff, 0xf6, 0x3d, 0x92, 0x1b, 0x8c, 0xe1, 0xc3, 0x82, 0x6c, 0x5e, 0x6e, 0xe8, 0xc0, 0xfd, 0xfc,
-	0x33, 0x99, 0xce, 0x70, 0xb7, 0xc9, 0x25, 0x17, 0x78, 0xf8, 0xba, 0x89, 0x97, 0xcf, 0xeb, 0xcd,
-	0x32, 0x1e, 0xfd, 0x6b, 0x70, 0xb1, 0x7a, 0x5c, 0x6f, 0x16, 0x2f, 0xf1, 0x72, 0x84, 0x9e, 0x6e,
-	0xdf, 0x66, 0xb9, 0xb0, 0xc5, 0x29, 0x65, 0x99, 0x2a, 0xa3, 0xa2, 0xae, 0x40, 0x1f, 0x61, 0x9f,
-	0x83, 0x8e, 0x7c, 0xf5, 0xbe, 0x51, 0x93, 0xfa, 0xa2, 0xef, 0xbf, 0x02, 0x00, 0x00, 0xff, 0xff,
-	0x4e, 0x27, 0xaf, 0x4c, 0x92, 0x01, 0x00, 0x00,
+	// 304 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x4c, 0x91, 0xcf, 0x4f, 0xc2, 0x30,
+	0x14, 0xc7, 0x2d, 0x0e, 0x90, 0xfa, 0x23, 0xa4, 0x7a, 0x68, 0x38, 0xc8, 0x42, 0x88, 0xee, 0xd4,
+	0x25, 0x78, 0xf1, 0xaa, 0x0c, 0x03, 0x17, 0x4c, 0x16, 0xbc, 0x78, 0x21, 0xdd, 0x78, 0x6c, 0x4b,
+	0xa0, 0x6d, 0xda, 0x62, 0xe4, 0x6f, 0xf2, 0x9f, 0x34, 0x6b, 0x9d, 0x7a, 0x5a, 0x3e, 0x6f, 0x9f,
+	0xf7, 0x7d, 0x2f, 0xaf, 0x78, 0xb8, 0xe5, 0x99, 0xae, 0xf2, 0xb5, 0xd5, 0x5c, 0x18, 0x9e, 0xdb,
+	0x4a, 0x8a, 0x75, 0x09, 0x7c, 0x03, 0x9a, 0x29, 0x2d, 0xad, 0x24, 0x1d, 0xf7, 0x31, 0x83, 0x61,
+	0x21, 0x65, 0xb1, 0x83, 0xd8, 0x61, 0x76, 0xd8, 0xc6, 0xb6, 0xda, 0x83, 0xb1, 0x7c, 0xaf, 0xbc,
+	0x38, 0xfa, 0x6a, 0xe1, 0xce, 0xdc, 0x75, 0x12, 0x8a, 0xbb, 0x1f, 0xa0, 0x4d, 0x25, 0x05, 0x45,
+	0x21, 0x8a, 0xda, 0x69, 0x83, 0xe4, 0x11, 0xf7, 0x7e, 0xfb, 0x68, 0x2b, 0x44, 0xd1, 0xf9, 0x64,
+	0xc0, 0x7c, 0x32, 0x6b, 0x92, 0xd9, 0xaa, 0x31, 0xd2, 0x3f, 0x99, 0xdc, 0xe3, 0xc0, 0x1e, 0x15,
+	0xd0, 0xd3, 0x10, 0x45, 0x57, 0x93, 0x6b, 0x6f, 0x1b, 0xe6, 0x27, 0xb2, 0xd5, 0x51, 0x41, 0xea,
+	0x84, 0x7a, 0x78, 0xae, 0x81, 0x5b, 0xa9, 0x69, 0x10, 0xa2, 0xe8, 0x22, 0x6d, 0x90, 0xdc, 0xe0,
+	0xb6, 0x90, 0x22, 0x07, 0xda, 0x76, 0x75, 0x0f, 0xce, 0x2f, 0x79, 0x25, 0x16, 0x09, 0xed, 0xfc,
+	0xf8, 0x1e, 0xc9, 0x2d, 0xc6, 0xf0, 0x69, 0x41, 0xd4, 0x9b, 0x1b, 0xda, 0x75, 0x3f, 0xff, 0x55,
+	0xea, 0x3c, 0x50, 0x32, 0x2f, 0xe9, 0x59, 0x88, 0xa2, 0x20, 0xf5, 0x30, 0x1a, 0xe3, 0xa0, 0xde,
+	0x86, 0x5c, 0xe2, 0xde, 0xdb, 0x32, 0x99, 0xbd, 0x2c, 0x96, 0xb3, 0xa4, 0x7f, 0x52, 0xe3, 0x74,
+	0xfe, 0xb4, 0x58, 0x4e, 0x5f, 0x93, 0x59, 0x1f, 0x3d, 0xdf, 0xbd, 0x8f, 0x8b, 0xca, 0x96, 0x87,
+	0x8c, 0xe5, 0x72, 0x1f, 0x97, 0x47, 0x05, 0x7a, 0x07, 0x9b, 0x02, 0x74, 0xec, 0x1f, 0xc4, 0xdf,
+	0xd9, 0x64, 0xfe, 0xfc, 0x0f, 0xdf, 0x03, 0x00, 0x06, 0x93, 0x73, 0x72, 0xa8, 0x01, 0x00, 0x00,
 }
@@ -81,10 +81,80 @@ func (m *LogLevelResponse) String() string            { return proto.CompactText
 func (*LogLevelResponse) ProtoMessage()               {}
 func (*LogLevelResponse) Descriptor() ([]byte, []int) { return fileDescriptor15, []int{2} }
 
+// ChaincodeLogLevelRequest asks for the logging level of a module of a running chaincode to be changed,
+// the level of the shim and the default level of the chaincode loggers if logModule is empty
+type ChaincodeLogLevelRequest struct {
+	ChaincodeName string `protobuf:"bytes,1,opt,name=chaincodeName" json:"chaincodeName,omitempty"`
+	LogModule     string `protobuf:"bytes,2,opt,name=logModule" json:"logModule,omitempty"`
+	LogLevel      string `protobuf:"bytes,3,opt,name=logLevel" json:"logLevel,omitempty"`
+}
+
+func (m *ChaincodeLogLevelRequest) Reset()                    { *m = ChaincodeLogLevelRequest{} }
+func (m *ChaincodeLogLevelRequest) String() string            { return proto.CompactTextString(m) }
+func (*ChaincodeLogLevelRequest) ProtoMessage()               {}
+func (*ChaincodeLogLevelRequest) Descriptor() ([]byte, []int) { return fileDescriptor15, []int{3} }
+
+// BlockLocalMetadataRequest asks for the local metadata of a block of a chain, the last block if blockNumber is 0
+type BlockLocalMetadataRequest struct {
+	ChainID     string `protobuf:"bytes,1,opt,name=chainID" json:"chainID,omitempty"`
+	BlockNumber uint64 `protobuf:"varint,2,opt,name=blockNumber" json:"blockNumber,omitempty"`
+}
+
+func (m *BlockLocalMetadataRequest) Reset()                    { *m = BlockLocalMetadataRequest{} }
+func (m *BlockLocalMetadataRequest) String() string            { return proto.CompactTextString(m) }
+func (*BlockLocalMetadataRequest) ProtoMessage()               {}
+func (*BlockLocalMetadataRequest) Descriptor() ([]byte, []int) { return fileDescriptor15, []int{4} }
+
+// CompactLedgerRequest asks for the compaction of the state and index databases of the ledger of a chain,
+// of the ledgers of all the chains if chainID is empty
+type CompactLedgerRequest struct {
+	ChainID string `protobuf:"bytes,1,opt,name=chainID" json:"chainID,omitempty"`
+}
+
+func (m *CompactLedgerRequest) Reset()                    { *m = CompactLedgerRequest{} }
+func (m *CompactLedgerRequest) String() string            { return proto.CompactTextString(m) }
+func (*CompactLedgerRequest) ProtoMessage()               {}
+func (*CompactLedgerRequest) Descriptor() ([]byte, []int) { return fileDescriptor15, []int{5} }
+
+// LedgerCompaction reports the size in bytes of the databases of the ledger of a chain before and after a compaction
+type LedgerCompaction struct {
+	ChainID         string `protobuf:"bytes,1,opt,name=chainID" json:"chainID,omitempty"`
+	StateSizeBefore int64  `protobuf:"varint,2,opt,name=stateSizeBefore" json:"stateSizeBefore,omitempty"`
+	StateSizeAfter  int64  `protobuf:"varint,3,opt,name=stateSizeAfter" json:"stateSizeAfter,omitempty"`
+	IndexSizeBefore int64  `protobuf:"varint,4,opt,name=indexSizeBefore" json:"indexSizeBefore,omitempty"`
+	IndexSizeAfter  int64  `protobuf:"varint,5,opt,name=indexSizeAfter" json:"indexSizeAfter,omitempty"`
+}
+
+func (m *LedgerCompaction) Reset()                    { *m = LedgerCompaction{} }
+func (m *LedgerCompaction) String() string            { return proto.CompactTextString(m) }
+func (*LedgerCompaction) ProtoMessage()               {}
+func (*LedgerCompaction) Descriptor() ([]byte, []int) { return fileDescriptor15, []int{6} }
+
+type CompactLedgerResponse struct {
+	Compactions []*LedgerCompaction `protobuf:"bytes,1,rep,name=compactions" json:"compactions,omitempty"`
+}
+
+func (m *CompactLedgerResponse) Reset()                    { *m = CompactLedgerResponse{} }
+func (m *CompactLedgerResponse) String() string            { return proto.CompactTextString(m) }
+func (*CompactLedgerResponse) ProtoMessage()               {}
+func (*CompactLedgerResponse) Descriptor() ([]byte, []int) { return fileDescriptor15, []int{7} }
+
+func (m *CompactLedgerResponse) GetCompactions() []*LedgerCompaction {
+	if m != nil {
+		return m.Compactions
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*ServerStatus)(nil), "protos.ServerStatus")
 	proto.RegisterType((*LogLevelRequest)(nil), "protos.LogLevelRequest")
 	proto.RegisterType((*LogLevelResponse)(nil), "protos.LogLevelResponse")
+	proto.RegisterType((*ChaincodeLogLevelRequest)(nil), "protos.ChaincodeLogLevelRequest")
+	proto.RegisterType((*BlockLocalMetadataRequest)(nil), "protos.BlockLocalMetadataRequest")
+	proto.RegisterType((*CompactLedgerRequest)(nil), "protos.CompactLedgerRequest")
+	proto.RegisterType((*LedgerCompaction)(nil), "protos.LedgerCompaction")
+	proto.RegisterType((*CompactLedgerResponse)(nil), "protos.CompactLedgerResponse")
 	proto.RegisterEnum("protos.ServerStatus_StatusCode", ServerStatus_StatusCode_name, ServerStatus_StatusCode_value)
 }
 
@@ -105,6 +175,13 @@ type AdminClient interface {
 	StopServer(ctx context.Context, in *google_protobuf1.Empty, opts ...grpc.CallOption) (*ServerStatus, error)
 	GetModuleLogLevel(ctx context.Context, in *LogLevelRequest, opts ...grpc.CallOption) (*LogLevelResponse, error)
 	SetModuleLogLevel(ctx context.Context, in *LogLevelRequest, opts ...grpc.CallOption) (*LogLevelResponse, error)
+	GetBlockLocalMetadata(ctx context.Context, in *BlockLocalMetadataRequest, opts ...grpc.CallOption) (*BlockLocalMetadata, error)
+	CompactLedger(ctx context.Context, in *CompactLedgerRequest, opts ...grpc.CallOption) (*CompactLedgerResponse, error)
+	SetChaincodeLogLevel(ctx context.Context, in *ChaincodeLogLevelRequest, opts ...grpc.CallOption) (*LogLevelResponse, error)
+	// Reload the TLS certificates, keys and root CAs of the peer from their files.
+	ReloadTLS(ctx context.Context, in *google_protobuf1.Empty, opts ...grpc.CallOption) (*google_protobuf1.Empty, error)
+	// Return the chains the peer has joined.
+	ListChannels(ctx context.Context, in *google_protobuf1.Empty, opts ...grpc.CallOption) (*ChannelQueryResponse, error)
 }
 
 type adminClient struct {
@@ -160,6 +237,51 @@ func (c *adminClient) SetModuleLogLevel(ctx context.Context, in *LogLevelRequest
 	return out, nil
 }
 
+func (c *adminClient) GetBlockLocalMetadata(ctx context.Context, in *BlockLocalMetadataRequest, opts ...grpc.CallOption) (*BlockLocalMetadata, error) {
+	out := new(BlockLocalMetadata)
+	err := grpc.Invoke(ctx, "/protos.Admin/GetBlockLocalMetadata", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) CompactLedger(ctx context.Context, in *CompactLedgerRequest, opts ...grpc.CallOption) (*CompactLedgerResponse, error) {
+	out := new(CompactLedgerResponse)
+	err := grpc.Invoke(ctx, "/protos.Admin/CompactLedger", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) SetChaincodeLogLevel(ctx context.Context, in *ChaincodeLogLevelRequest, opts ...grpc.CallOption) (*LogLevelResponse, error) {
+	out := new(LogLevelResponse)
+	err := grpc.Invoke(ctx, "/protos.Admin/SetChaincodeLogLevel", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) ReloadTLS(ctx context.Context, in *google_protobuf1.Empty, opts ...grpc.CallOption) (*google_protobuf1.Empty, error) {
+	out := new(google_protobuf1.Empty)
+	err := grpc.Invoke(ctx, "/protos.Admin/ReloadTLS", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) ListChannels(ctx context.Context, in *google_protobuf1.Empty, opts ...grpc.CallOption) (*ChannelQueryResponse, error) {
+	out := new(ChannelQueryResponse)
+	err := grpc.Invoke(ctx, "/protos.Admin/ListChannels", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for Admin service
 
 type AdminServer interface {
@@ -169,6 +291,13 @@ type AdminServer interface {
 	StopServer(context.Context, *google_protobuf1.Empty) (*ServerStatus, error)
 	GetModuleLogLevel(context.Context, *LogLevelRequest) (*LogLevelResponse, error)
 	SetModuleLogLevel(context.Context, *LogLevelRequest) (*LogLevelResponse, error)
+	GetBlockLocalMetadata(context.Context, *BlockLocalMetadataRequest) (*BlockLocalMetadata, error)
+	CompactLedger(context.Context, *CompactLedgerRequest) (*CompactLedgerResponse, error)
+	SetChaincodeLogLevel(context.Context, *ChaincodeLogLevelRequest) (*LogLevelResponse, error)
+	// Reload the TLS certificates, keys and root CAs of the peer from their files.
+	ReloadTLS(context.Context, *google_protobuf1.Empty) (*google_protobuf1.Empty, error)
+	// Return the chains the peer has joined.
+	ListChannels(context.Context, *google_protobuf1.Empty) (*ChannelQueryResponse, error)
 }
 
 func RegisterAdminServer(s *grpc.Server, srv AdminServer) {
@@ -265,6 +394,96 @@ func _Admin_SetModuleLogLevel_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Admin_GetBlockLocalMetadata_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BlockLocalMetadataRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).GetBlockLocalMetadata(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protos.Admin/GetBlockLocalMetadata",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).GetBlockLocalMetadata(ctx, req.(*BlockLocalMetadataRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_CompactLedger_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CompactLedgerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).CompactLedger(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protos.Admin/CompactLedger",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).CompactLedger(ctx, req.(*CompactLedgerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_SetChaincodeLogLevel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChaincodeLogLevelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).SetChaincodeLogLevel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protos.Admin/SetChaincodeLogLevel",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).SetChaincodeLogLevel(ctx, req.(*ChaincodeLogLevelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_ReloadTLS_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(google_protobuf1.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).ReloadTLS(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protos.Admin/ReloadTLS",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).ReloadTLS(ctx, req.(*google_protobuf1.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_ListChannels_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(google_protobuf1.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).ListChannels(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protos.Admin/ListChannels",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).ListChannels(ctx, req.(*google_protobuf1.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Admin_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "protos.Admin",
 	HandlerType: (*AdminServer)(nil),
@@ -289,6 +508,26 @@ var _Admin_serviceDesc = grpc.ServiceDesc{
 			MethodName: "SetModuleLogLevel",
 			Handler:    _Admin_SetModuleLogLevel_Handler,
 		},
+		{
+			MethodName: "GetBlockLocalMetadata",
+			Handler:    _Admin_GetBlockLocalMetadata_Handler,
+		},
+		{
+			MethodName: "CompactLedger",
+			Handler:    _Admin_CompactLedger_Handler,
+		},
+		{
+			MethodName: "SetChaincodeLogLevel",
+			Handler:    _Admin_SetChaincodeLogLevel_Handler,
+		},
+		{
+			MethodName: "ReloadTLS",
+			Handler:    _Admin_ReloadTLS_Handler,
+		},
+		{
+			MethodName: "ListChannels",
+			Handler:    _Admin_ListChannels_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: fileDescriptor15,
@@ -297,29 +536,47 @@ var _Admin_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("server_admin.proto", fileDescriptor15) }
 
 var fileDescriptor15 = []byte{
-	// 375 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0xac, 0x92, 0xc1, 0x4e, 0xea, 0x50,
-	0x10, 0x86, 0x29, 0xf7, 0xc2, 0xbd, 0x1d, 0xee, 0xd5, 0x7a, 0x62, 0x94, 0x54, 0x13, 0x4d, 0x63,
-	0x8c, 0xab, 0x36, 0xc1, 0x85, 0x0b, 0x75, 0x81, 0xb6, 0xa2, 0x01, 0x0b, 0x69, 0x21, 0x46, 0x37,
-	0xa6, 0xa5, 0x43, 0x21, 0x29, 0x9c, 0xda, 0x73, 0x4a, 0xc2, 0xeb, 0xf8, 0x4e, 0xbe, 0x8f, 0x69,
-	0x0f, 0x88, 0x51, 0x37, 0x12, 0x57, 0xd3, 0x99, 0xf9, 0xff, 0x3f, 0x3d, 0x5f, 0x06, 0x08, 0xc3,
-	0x64, 0x8a, 0xc9, 0xa3, 0x17, 0x8c, 0x47, 0x13, 0x3d, 0x4e, 0x28, 0xa7, 0xa4, 0x9c, 0x17, 0xa6,
-	0xee, 0x84, 0x94, 0x86, 0x11, 0x1a, 0x79, 0xeb, 0xa7, 0x03, 0x03, 0xc7, 0x31, 0x9f, 0x09, 0x91,
-	0xf6, 0x2c, 0xc1, 0x3f, 0x37, 0xf7, 0xba, 0xdc, 0xe3, 0x29, 0x23, 0x27, 0x50, 0x66, 0xf9, 0x57,
-	0x55, 0xda, 0x97, 0x8e, 0xd6, 0x6a, 0x7b, 0x42, 0xc8, 0xf4, 0xf7, 0x2a, 0x5d, 0x94, 0x4b, 0x1a,
-	0xa0, 0x33, 0x97, 0x6b, 0xf7, 0x00, 0xcb, 0x29, 0xf9, 0x0f, 0x72, 0xcf, 0x36, 0xad, 0xab, 0x1b,
-	0xdb, 0x32, 0x95, 0x02, 0xa9, 0xc0, 0x1f, 0xb7, 0x5b, 0x77, 0xba, 0x96, 0xa9, 0x48, 0xa2, 0x69,
-	0x77, 0x3a, 0x96, 0xa9, 0x14, 0x09, 0x40, 0xb9, 0x53, 0xef, 0xb9, 0x96, 0xa9, 0xfc, 0x22, 0x32,
-	0x94, 0x2c, 0xc7, 0x69, 0x3b, 0xca, 0xef, 0x4c, 0xd3, 0xb3, 0x9b, 0x76, 0xfb, 0xce, 0x56, 0x4a,
-	0x5a, 0x13, 0xd6, 0x5b, 0x34, 0x6c, 0xe1, 0x14, 0x23, 0x07, 0x9f, 0x52, 0x64, 0x9c, 0xec, 0x82,
-	0x1c, 0xd1, 0xf0, 0x96, 0x06, 0x69, 0x84, 0xf9, 0x9f, 0xca, 0xce, 0x72, 0x40, 0x54, 0xf8, 0x1b,
-	0xcd, 0x0d, 0xd5, 0x62, 0xbe, 0x7c, 0xeb, 0xb5, 0x16, 0x28, 0xcb, 0x30, 0x16, 0xd3, 0x09, 0xc3,
-	0xd5, 0xd3, 0x6a, 0x2f, 0x45, 0x28, 0xd5, 0x33, 0xe8, 0xe4, 0x14, 0xe4, 0x06, 0xf2, 0x39, 0xc5,
-	0x2d, 0x5d, 0x40, 0xd7, 0x17, 0xd0, 0x75, 0x2b, 0x83, 0xae, 0x6e, 0x7e, 0x45, 0x53, 0x2b, 0x90,
-	0x73, 0xa8, 0xb8, 0xdc, 0x4b, 0xb8, 0x18, 0x7f, 0xdb, 0x7e, 0x96, 0xb1, 0xa7, 0xf1, 0x8a, 0xee,
-	0x6b, 0xd8, 0x68, 0x20, 0x17, 0x8f, 0x5d, 0xa0, 0x21, 0xdb, 0x0b, 0xf1, 0x07, 0xf2, 0x6a, 0xf5,
-	0xf3, 0x42, 0x50, 0x14, 0x49, 0xee, 0x8f, 0x24, 0x5d, 0x1c, 0x3e, 0x1c, 0x84, 0x23, 0x3e, 0x4c,
-	0x7d, 0xbd, 0x4f, 0xc7, 0xc6, 0x70, 0x16, 0x63, 0x12, 0x61, 0x10, 0x62, 0x62, 0x0c, 0x3c, 0x3f,
-	0x19, 0xf5, 0xc5, 0x35, 0x33, 0x5f, 0x1c, 0xf9, 0xf1, 0x6b, 0x00, 0x00, 0x00, 0xff, 0xff, 0x32,
-	0xe6, 0x21, 0xb7, 0x01, 0x03, 0x00, 0x00,
+	// 672 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x55, 0x5f, 0x53, 0xd3, 0x40,
+	0x10, 0x6f, 0x29, 0x05, 0xbb, 0xe5, 0x4f, 0xbc, 0x01, 0xad, 0x11, 0x47, 0xcc, 0x30, 0x0c, 0x4f,
+	0xa9, 0x83, 0x0f, 0xce, 0xa8, 0x3c, 0x14, 0x1a, 0x90, 0x21, 0x04, 0x4c, 0xca, 0xa0, 0xbe, 0x30,
+	0x69, 0xb2, 0x2d, 0x19, 0xd3, 0x5c, 0x4c, 0x2e, 0x8c, 0xf0, 0x71, 0xfc, 0x44, 0x7e, 0x00, 0x3f,
+	0x8c, 0x93, 0x5c, 0x92, 0xb6, 0x81, 0xe0, 0xf8, 0xe7, 0x29, 0xb9, 0xdf, 0xee, 0xfe, 0x76, 0x6f,
+	0x6f, 0x7f, 0x77, 0x40, 0x42, 0x0c, 0xae, 0x30, 0xb8, 0x30, 0xed, 0x91, 0xe3, 0xc9, 0x7e, 0x40,
+	0x19, 0x25, 0x73, 0xc9, 0x27, 0x14, 0x97, 0xad, 0x4b, 0xd3, 0xf1, 0x2c, 0x6a, 0x23, 0x37, 0x88,
+	0x64, 0x60, 0xf6, 0x03, 0xc7, 0xba, 0xe8, 0xbb, 0xd4, 0xfa, 0x92, 0x62, 0x4f, 0x87, 0x94, 0x0e,
+	0x5d, 0x6c, 0x27, 0xab, 0x7e, 0x34, 0x68, 0xe3, 0xc8, 0x67, 0xd7, 0xdc, 0x28, 0x7d, 0xaf, 0xc2,
+	0x82, 0x91, 0x24, 0x30, 0x98, 0xc9, 0xa2, 0x90, 0xbc, 0x86, 0xb9, 0x30, 0xf9, 0x6b, 0x55, 0xd7,
+	0xab, 0x5b, 0x4b, 0xdb, 0xcf, 0xb9, 0x63, 0x28, 0x4f, 0x7a, 0xc9, 0xfc, 0xb3, 0x47, 0x6d, 0xd4,
+	0x53, 0x77, 0xe9, 0x13, 0xc0, 0x18, 0x25, 0x8b, 0xd0, 0x38, 0xd3, 0xba, 0xca, 0xfe, 0xa1, 0xa6,
+	0x74, 0x85, 0x0a, 0x69, 0xc2, 0xbc, 0xd1, 0xeb, 0xe8, 0x3d, 0xa5, 0x2b, 0x54, 0xf9, 0xe2, 0xe4,
+	0xf4, 0x54, 0xe9, 0x0a, 0x33, 0x04, 0x60, 0xee, 0xb4, 0x73, 0x66, 0x28, 0x5d, 0xa1, 0x46, 0x1a,
+	0x50, 0x57, 0x74, 0xfd, 0x44, 0x17, 0x66, 0x63, 0x9f, 0x33, 0xed, 0x48, 0x3b, 0x39, 0xd7, 0x84,
+	0xba, 0x74, 0x04, 0xcb, 0x2a, 0x1d, 0xaa, 0x78, 0x85, 0xae, 0x8e, 0x5f, 0x23, 0x0c, 0x19, 0x59,
+	0x83, 0x86, 0x4b, 0x87, 0xc7, 0xd4, 0x8e, 0x5c, 0x4c, 0x2a, 0x6d, 0xe8, 0x63, 0x80, 0x88, 0xf0,
+	0xc0, 0x4d, 0x03, 0x5a, 0x33, 0x89, 0x31, 0x5f, 0x4b, 0x2a, 0x08, 0x63, 0xb2, 0xd0, 0xa7, 0x5e,
+	0x88, 0xff, 0xc0, 0x76, 0x03, 0xad, 0xbd, 0xec, 0x0c, 0x8a, 0x35, 0x6e, 0xc0, 0x62, 0x7e, 0x3e,
+	0x9a, 0x39, 0xca, 0x98, 0xa7, 0xc1, 0xe9, 0xdc, 0x33, 0xf7, 0xe5, 0xae, 0x15, 0x72, 0x9f, 0xc3,
+	0x93, 0xdd, 0xf8, 0x9c, 0x55, 0x6a, 0x99, 0xee, 0x31, 0x32, 0xd3, 0x36, 0x99, 0x99, 0x25, 0x6f,
+	0xc1, 0x7c, 0x92, 0xe7, 0xb0, 0x9b, 0xa6, 0xcd, 0x96, 0x64, 0x1d, 0x9a, 0xc9, 0x78, 0x68, 0xd1,
+	0xa8, 0x8f, 0x41, 0x92, 0x72, 0x56, 0x9f, 0x84, 0xa4, 0x97, 0xb0, 0xb2, 0x47, 0x47, 0xbe, 0x69,
+	0x31, 0x15, 0xed, 0x21, 0x06, 0xbf, 0xe5, 0x94, 0x7e, 0x54, 0x41, 0xe0, 0xbe, 0x69, 0xa0, 0x43,
+	0xbd, 0x7b, 0x4a, 0xd8, 0x82, 0xe5, 0x78, 0x6a, 0xd0, 0x70, 0x6e, 0x70, 0x17, 0x07, 0x34, 0xe0,
+	0x3b, 0xaf, 0xe9, 0x45, 0x98, 0x6c, 0xc2, 0x52, 0x0e, 0x75, 0x06, 0x0c, 0x83, 0xa4, 0x0b, 0x35,
+	0xbd, 0x80, 0xc6, 0x8c, 0x8e, 0x67, 0xe3, 0xb7, 0x09, 0xc6, 0x59, 0xce, 0x58, 0x80, 0x63, 0xc6,
+	0x1c, 0xe2, 0x8c, 0x75, 0xce, 0x38, 0x8d, 0x4a, 0x06, 0xac, 0x16, 0x9a, 0x90, 0x0e, 0xcb, 0x1b,
+	0x68, 0x5a, 0xf9, 0x26, 0x63, 0x99, 0xd4, 0xb6, 0x9a, 0xdb, 0xad, 0x4c, 0x26, 0xc5, 0x2e, 0xe8,
+	0x93, 0xce, 0xdb, 0x3f, 0xeb, 0x50, 0xef, 0xc4, 0x42, 0x26, 0x6f, 0xa1, 0x71, 0x80, 0x2c, 0x15,
+	0xdd, 0x23, 0x99, 0x6b, 0x54, 0xce, 0x34, 0x2a, 0x2b, 0xb1, 0x46, 0xc5, 0x95, 0xbb, 0xc4, 0x27,
+	0x55, 0xc8, 0x0e, 0x34, 0x0d, 0x66, 0x06, 0x8c, 0xc3, 0x7f, 0x1c, 0xfe, 0x2e, 0x96, 0x2a, 0xf5,
+	0xff, 0x32, 0xfa, 0x3d, 0x3c, 0x3c, 0x40, 0xc6, 0xe7, 0x33, 0x1b, 0x79, 0xf2, 0x38, 0xdf, 0xff,
+	0xb4, 0x08, 0xc4, 0xd6, 0x6d, 0x03, 0xef, 0x23, 0x67, 0x32, 0xfe, 0x0f, 0xd3, 0x47, 0x58, 0x3d,
+	0x40, 0x76, 0x5b, 0x0d, 0xe4, 0x45, 0x16, 0x54, 0xaa, 0x14, 0x51, 0x2c, 0x77, 0x91, 0x2a, 0x44,
+	0x83, 0xc5, 0xa9, 0x31, 0x20, 0x6b, 0x99, 0xfb, 0x5d, 0x12, 0x11, 0x9f, 0x95, 0x58, 0xf3, 0x4a,
+	0x7b, 0xb0, 0x62, 0x20, 0xbb, 0x75, 0x67, 0x90, 0xf5, 0x3c, 0xb0, 0xe4, 0x3a, 0xb9, 0x77, 0xff,
+	0x3b, 0xd0, 0xd0, 0xd1, 0xa5, 0xa6, 0xdd, 0x53, 0x8d, 0xd2, 0x03, 0x2d, 0xc1, 0xa5, 0x0a, 0xd9,
+	0x87, 0x05, 0xd5, 0x09, 0xe3, 0xaa, 0x3c, 0x0f, 0xdd, 0xf2, 0x79, 0x5c, 0x9b, 0x28, 0x32, 0xf6,
+	0xfc, 0x10, 0x61, 0x70, 0x3d, 0x2e, 0x63, 0x77, 0xf3, 0xf3, 0xc6, 0xd0, 0x61, 0x97, 0x51, 0x5f,
+	0xb6, 0xe8, 0xa8, 0x7d, 0x79, 0xed, 0x63, 0xe0, 0x26, 0x0d, 0x68, 0xf3, 0x77, 0x89, 0xbf, 0x41,
+	0x61, 0x9f, 0xbf, 0x5f, 0xaf, 0x7e, 0x0d, 0x00, 0xce, 0xcc, 0x15, 0x78, 0xdc, 0x06, 0x00, 0x00,
 }
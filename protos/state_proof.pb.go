@@ -0,0 +1,101 @@
+// Code generated by protoc-gen-go.
+// source: state_proof.proto
+// DO NOT EDIT!
+
+package protos
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// StateProof carries the committed value of a key together with a proof against the root of the
+// hash tree over the state, as recorded for a block. The proof holds the entries of the bucket of
+// the key and the hashes of the sibling nodes on the path from the bucket to the root, so that a
+// client can recompute the root without trusting the peer that returned the value
+type StateProof struct {
+	Namespace string `protobuf:"bytes,1,opt,name=namespace" json:"namespace,omitempty"`
+	Key       string `protobuf:"bytes,2,opt,name=key" json:"key,omitempty"`
+	// exists is false if the key does not exist in the state, the proof then proves its absence
+	Exists bool   `protobuf:"varint,3,opt,name=exists" json:"exists,omitempty"`
+	Value  []byte `protobuf:"bytes,4,opt,name=value,proto3" json:"value,omitempty"`
+	// the state root is the one recorded for block blockNumber
+	BlockNumber  uint64 `protobuf:"varint,5,opt,name=blockNumber" json:"blockNumber,omitempty"`
+	StateRoot    []byte `protobuf:"bytes,6,opt,name=stateRoot,proto3" json:"stateRoot,omitempty"`
+	BucketNumber uint64 `protobuf:"varint,7,opt,name=bucketNumber" json:"bucketNumber,omitempty"`
+	// bucketEntries are all the entries of the bucket, sorted by key hash
+	BucketEntries []*StateProofEntry `protobuf:"bytes,8,rep,name=bucketEntries" json:"bucketEntries,omitempty"`
+	// siblingHashes are the hashes of the siblings of the nodes on the path from the bucket to the root
+	SiblingHashes [][]byte `protobuf:"bytes,9,rep,name=siblingHashes,proto3" json:"siblingHashes,omitempty"`
+}
+
+func (m *StateProof) Reset()                    { *m = StateProof{} }
+func (m *StateProof) String() string            { return proto.CompactTextString(m) }
+func (*StateProof) ProtoMessage()               {}
+func (*StateProof) Descriptor() ([]byte, []int) { return fileDescriptor16, []int{0} }
+
+func (m *StateProof) GetBucketEntries() []*StateProofEntry {
+	if m != nil {
+		return m.BucketEntries
+	}
+	return nil
+}
+
+// StateProofEntry is an entry of a bucket of the state hash tree. The key and the value are
+// hashed so that a proof does not disclose the other keys of the bucket
+type StateProofEntry struct {
+	KeyHash   []byte `protobuf:"bytes,1,opt,name=keyHash,proto3" json:"keyHash,omitempty"`
+	ValueHash []byte `protobuf:"bytes,2,opt,name=valueHash,proto3" json:"valueHash,omitempty"`
+}
+
+func (m *StateProofEntry) Reset()                    { *m = StateProofEntry{} }
+func (m *StateProofEntry) String() string            { return proto.CompactTextString(m) }
+func (*StateProofEntry) ProtoMessage()               {}
+func (*StateProofEntry) Descriptor() ([]byte, []int) { return fileDescriptor16, []int{1} }
+
+// StateRoot is the root of the state hash tree after the commit of block blockNumber
+type StateRoot struct {
+	BlockNumber uint64 `protobuf:"varint,1,opt,name=blockNumber" json:"blockNumber,omitempty"`
+	Root        []byte `protobuf:"bytes,2,opt,name=root,proto3" json:"root,omitempty"`
+}
+
+func (m *StateRoot) Reset()                    { *m = StateRoot{} }
+func (m *StateRoot) String() string            { return proto.CompactTextString(m) }
+func (*StateRoot) ProtoMessage()               {}
+func (*StateRoot) Descriptor() ([]byte, []int) { return fileDescriptor16, []int{2} }
+
+func init() {
+	proto.RegisterType((*StateProof)(nil), "protos.StateProof")
+	proto.RegisterType((*StateProofEntry)(nil), "protos.StateProofEntry")
+	proto.RegisterType((*StateRoot)(nil), "protos.StateRoot")
+}
+
+func init() { proto.RegisterFile("state_proof.proto", fileDescriptor16) }
+
+var fileDescriptor16 = []byte{
+	// 317 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x64, 0x51, 0x3d, 0x4f, 0xc3, 0x30,
+	0x14, 0x54, 0x9a, 0x7e, 0xe5, 0x35, 0x15, 0x60, 0x21, 0xf0, 0xc0, 0x60, 0x45, 0x15, 0xca, 0xd4,
+	0x4a, 0x30, 0x33, 0x80, 0x84, 0x04, 0x0b, 0x42, 0xee, 0xc6, 0x82, 0xe2, 0xf0, 0xda, 0x46, 0x49,
+	0xeb, 0xc8, 0x76, 0x10, 0xf9, 0x5d, 0xfc, 0x41, 0x64, 0xbb, 0xb4, 0xb4, 0x4c, 0x79, 0x77, 0xf7,
+	0x72, 0xf6, 0x9d, 0xe1, 0x4c, 0x9b, 0xcc, 0xe0, 0x7b, 0xad, 0xa4, 0x5c, 0x4c, 0x6b, 0x25, 0x8d,
+	0x24, 0x7d, 0xf7, 0xd1, 0xc9, 0x77, 0x07, 0x60, 0x6e, 0xd5, 0x57, 0x2b, 0x92, 0x2b, 0x88, 0x36,
+	0xd9, 0x1a, 0x75, 0x9d, 0xe5, 0x48, 0x03, 0x16, 0xa4, 0x11, 0xdf, 0x13, 0xe4, 0x14, 0xc2, 0x12,
+	0x5b, 0xda, 0x71, 0xbc, 0x1d, 0xc9, 0x05, 0xf4, 0xf1, 0xab, 0xd0, 0x46, 0xd3, 0x90, 0x05, 0xe9,
+	0x90, 0x6f, 0x11, 0x39, 0x87, 0xde, 0x67, 0x56, 0x35, 0x48, 0xbb, 0x2c, 0x48, 0x63, 0xee, 0x01,
+	0x61, 0x30, 0x12, 0x95, 0xcc, 0xcb, 0x97, 0x66, 0x2d, 0x50, 0xd1, 0x1e, 0x0b, 0xd2, 0x2e, 0xff,
+	0x4b, 0xd9, 0xf3, 0xdd, 0x5d, 0xb9, 0x94, 0x86, 0xf6, 0xdd, 0xbf, 0x7b, 0x82, 0x24, 0x10, 0x8b,
+	0x26, 0x2f, 0xd1, 0x6c, 0x0d, 0x06, 0xce, 0xe0, 0x80, 0x23, 0x77, 0x30, 0xf6, 0xf8, 0x71, 0x63,
+	0x54, 0x81, 0x9a, 0x0e, 0x59, 0x98, 0x8e, 0x6e, 0x2e, 0x7d, 0x6e, 0x3d, 0xdd, 0x87, 0xb5, 0x0b,
+	0x2d, 0x3f, 0xdc, 0x26, 0x13, 0x18, 0xeb, 0x42, 0x54, 0xc5, 0x66, 0xf9, 0x94, 0xe9, 0x15, 0x6a,
+	0x1a, 0xb1, 0x30, 0x8d, 0xf9, 0x21, 0x99, 0x3c, 0xc3, 0xc9, 0x91, 0x0f, 0xa1, 0x30, 0x28, 0xb1,
+	0xb5, 0xba, 0xeb, 0x2d, 0xe6, 0xbf, 0xd0, 0x66, 0x72, 0xf1, 0x9d, 0xd6, 0xf1, 0x99, 0x76, 0x44,
+	0x72, 0x0f, 0xd1, 0x7c, 0x17, 0xf0, 0xa8, 0xa0, 0xe0, 0x7f, 0x41, 0x04, 0xba, 0xca, 0x76, 0xe3,
+	0x7d, 0xdc, 0xfc, 0x70, 0xfd, 0x36, 0x59, 0x16, 0x66, 0xd5, 0x88, 0x69, 0x2e, 0xd7, 0xb3, 0x55,
+	0x5b, 0xa3, 0xaa, 0xf0, 0x63, 0x89, 0x6a, 0xb6, 0xc8, 0x84, 0x2a, 0xf2, 0x99, 0x8f, 0x2e, 0xfc,
+	0x9b, 0xdf, 0xfe, 0x0c, 0x00, 0xee, 0x1b, 0x3d, 0xf2, 0x0f, 0x02, 0x00, 0x00,
+}
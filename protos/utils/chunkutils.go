@@ -0,0 +1,124 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/protos"
+)
+
+// DefaultChunkSize is the size of the chunks a proposal is split into when
+// none is configured
+const DefaultChunkSize = 1024 * 1024
+
+// GetProposalChunks splits the marshalled signed proposal into chunks of at
+// most chunkSize bytes. The first chunk carries the size of the proposal and
+// the last one its hash
+func GetProposalChunks(signedProp *protos.SignedProposal, chunkSize int) ([]*protos.ProposalChunk, error) {
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+
+	propBytes, err := proto.Marshal(signedProp)
+	if err != nil {
+		return nil, err
+	}
+
+	var chunks []*protos.ProposalChunk
+	for offset := 0; offset < len(propBytes); offset += chunkSize {
+		end := offset + chunkSize
+		if end > len(propBytes) {
+			end = len(propBytes)
+		}
+		chunks = append(chunks, &protos.ProposalChunk{Content: propBytes[offset:end]})
+	}
+	if len(chunks) == 0 {
+		chunks = append(chunks, &protos.ProposalChunk{})
+	}
+
+	hash := sha256.Sum256(propBytes)
+	chunks[0].Size = uint64(len(propBytes))
+	chunks[len(chunks)-1].Hash = hash[:]
+
+	return chunks, nil
+}
+
+// ProposalAssembler reassembles a signed proposal from its chunks, in the order
+// they were sent
+type ProposalAssembler struct {
+	maxSize uint64
+	size    uint64
+	hash    []byte
+	buf     bytes.Buffer
+	chunks  int
+}
+
+// NewProposalAssembler returns an assembler refusing proposals larger than
+// maxSize bytes
+func NewProposalAssembler(maxSize uint64) *ProposalAssembler {
+	return &ProposalAssembler{maxSize: maxSize}
+}
+
+// Add appends the chunk to the proposal being reassembled
+func (a *ProposalAssembler) Add(chunk *protos.ProposalChunk) error {
+	if a.hash != nil {
+		return fmt.Errorf("chunk received after the last chunk of the proposal")
+	}
+	if a.chunks == 0 {
+		if chunk.Size > a.maxSize {
+			return fmt.Errorf("proposal of %d bytes exceeds the maximum of %d bytes", chunk.Size, a.maxSize)
+		}
+		a.size = chunk.Size
+	} else if chunk.Size != 0 {
+		return fmt.Errorf("size of the proposal set on chunk %d", a.chunks)
+	}
+	if uint64(a.buf.Len()+len(chunk.Content)) > a.size {
+		return fmt.Errorf("chunks exceed the size of the proposal, %d bytes", a.size)
+	}
+
+	a.buf.Write(chunk.Content)
+	if len(chunk.Hash) > 0 {
+		a.hash = chunk.Hash
+	}
+	a.chunks++
+	return nil
+}
+
+// GetSignedProposal checks the reassembled signed proposal against its size
+// and hash and returns it
+func (a *ProposalAssembler) GetSignedProposal() (*protos.SignedProposal, error) {
+	if a.hash == nil {
+		return nil, fmt.Errorf("the last chunk of the proposal was not received")
+	}
+	if uint64(a.buf.Len()) != a.size {
+		return nil, fmt.Errorf("received %d bytes of a proposal of %d bytes", a.buf.Len(), a.size)
+	}
+	hash := sha256.Sum256(a.buf.Bytes())
+	if !bytes.Equal(hash[:], a.hash) {
+		return nil, fmt.Errorf("hash mismatch of the reassembled proposal")
+	}
+
+	prop := &protos.SignedProposal{}
+	if err := proto.Unmarshal(a.buf.Bytes(), prop); err != nil {
+		return nil, fmt.Errorf("invalid reassembled proposal: %s", err)
+	}
+	return prop, nil
+}
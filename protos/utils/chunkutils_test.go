@@ -0,0 +1,74 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/protos"
+)
+
+func assemble(chunks []*protos.ProposalChunk, maxSize uint64) (*protos.SignedProposal, error) {
+	a := NewProposalAssembler(maxSize)
+	for _, chunk := range chunks {
+		if err := a.Add(chunk); err != nil {
+			return nil, err
+		}
+	}
+	return a.GetSignedProposal()
+}
+
+func TestProposalChunks(t *testing.T) {
+	prop := &protos.SignedProposal{ProposalBytes: bytes.Repeat([]byte("proposal"), 100), Signature: []byte("signature")}
+	size := proto.Size(prop)
+
+	chunks, err := GetProposalChunks(prop, 64)
+	if err != nil {
+		t.Fatalf("Could not split the proposal, err %s\n", err)
+	}
+	if len(chunks) != (size+63)/64 || chunks[0].Size != uint64(size) || chunks[len(chunks)-1].Hash == nil {
+		t.Fatalf("Unexpected chunks of a proposal of %d bytes", size)
+	}
+
+	reassembled, err := assemble(chunks, uint64(size))
+	if err != nil {
+		t.Fatalf("Could not reassemble the proposal, err %s\n", err)
+	}
+	if !proto.Equal(prop, reassembled) {
+		t.Fatalf("Reassembled proposal differs from the original")
+	}
+
+	// larger than the maximum size
+	if _, err = assemble(chunks, uint64(size-1)); err == nil {
+		t.Fatalf("Expected a proposal exceeding the maximum size to be refused")
+	}
+
+	// a missing chunk
+	missing := append([]*protos.ProposalChunk{}, chunks[:1]...)
+	if _, err = assemble(append(missing, chunks[2:]...), uint64(size)); err == nil {
+		t.Fatalf("Expected a proposal with a missing chunk to be refused")
+	}
+
+	// a tampered chunk
+	tampered := append([]*protos.ProposalChunk{}, chunks...)
+	tampered[1] = &protos.ProposalChunk{Content: bytes.Repeat([]byte("x"), len(chunks[1].Content))}
+	if _, err = assemble(tampered, uint64(size)); err == nil {
+		t.Fatalf("Expected a tampered proposal to be refused")
+	}
+}
@@ -0,0 +1,167 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/protos"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/orderer"
+)
+
+// AnchorPeersKeyPrefix prefixes the organization in the key of the Fabric
+// configuration item holding the anchor peers of the organization
+const AnchorPeersKeyPrefix = "AnchorPeers."
+
+// AnchorPeersKey returns the key of the configuration item holding the anchor
+// peers of an organization
+func AnchorPeersKey(org string) string {
+	return AnchorPeersKeyPrefix + org
+}
+
+// CreateAnchorPeersItem creates the configuration item declaring the anchor
+// peers of an organization on a chain, modified at the given sequence
+func CreateAnchorPeersItem(chainID []byte, org string, anchorPeers *protos.AnchorPeers, lastModified uint64, modificationPolicy string) (*orderer.SignedConfigurationItem, error) {
+	if org == "" {
+		return nil, fmt.Errorf("Anchor peers must belong to an organization")
+	}
+	value, err := proto.Marshal(anchorPeers)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := proto.Marshal(&orderer.ConfigurationItem{
+		Header:             &common.ChainHeader{ChainID: chainID},
+		Type:               orderer.ConfigurationItem_Fabric,
+		LastModified:       lastModified,
+		ModificationPolicy: modificationPolicy,
+		Key:                AnchorPeersKey(org),
+		Value:              value,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &orderer.SignedConfigurationItem{ConfigurationItem: item}, nil
+}
+
+// GetAnchorPeers returns the anchor peers declared in a chain configuration,
+// keyed by organization
+func GetAnchorPeers(config *orderer.ConfigurationEnvelope) (map[string]*protos.AnchorPeers, error) {
+	anchorPeers := make(map[string]*protos.AnchorPeers)
+	for _, signedItem := range config.Items {
+		item := &orderer.ConfigurationItem{}
+		if err := proto.Unmarshal(signedItem.ConfigurationItem, item); err != nil {
+			return nil, err
+		}
+		if item.Type != orderer.ConfigurationItem_Fabric || !strings.HasPrefix(item.Key, AnchorPeersKeyPrefix) {
+			continue
+		}
+
+		peers := &protos.AnchorPeers{}
+		if err := proto.Unmarshal(item.Value, peers); err != nil {
+			return nil, fmt.Errorf("Invalid anchor peers in configuration item %s: %s", item.Key, err)
+		}
+		anchorPeers[strings.TrimPrefix(item.Key, AnchorPeersKeyPrefix)] = peers
+	}
+	return anchorPeers, nil
+}
+
+// OrdererAddressesKey is the key of the Orderer configuration item holding
+// the endpoints of the ordering service of a chain
+const OrdererAddressesKey = "OrdererAddresses"
+
+// CreateOrdererAddressesItem creates the configuration item declaring the
+// endpoints of the ordering service of a chain, modified at the given sequence
+func CreateOrdererAddressesItem(chainID []byte, addresses []string, lastModified uint64, modificationPolicy string) (*orderer.SignedConfigurationItem, error) {
+	if len(addresses) == 0 {
+		return nil, fmt.Errorf("The ordering service must have at least one address")
+	}
+	value, err := proto.Marshal(&protos.OrdererAddresses{Addresses: addresses})
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := proto.Marshal(&orderer.ConfigurationItem{
+		Header:             &common.ChainHeader{ChainID: chainID},
+		Type:               orderer.ConfigurationItem_Orderer,
+		LastModified:       lastModified,
+		ModificationPolicy: modificationPolicy,
+		Key:                OrdererAddressesKey,
+		Value:              value,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &orderer.SignedConfigurationItem{ConfigurationItem: item}, nil
+}
+
+// GetOrdererAddresses returns the endpoints of the ordering service declared
+// in a chain configuration, or nil if it declares none
+func GetOrdererAddresses(config *orderer.ConfigurationEnvelope) ([]string, error) {
+	for _, signedItem := range config.Items {
+		item := &orderer.ConfigurationItem{}
+		if err := proto.Unmarshal(signedItem.ConfigurationItem, item); err != nil {
+			return nil, err
+		}
+		if item.Type != orderer.ConfigurationItem_Orderer || item.Key != OrdererAddressesKey {
+			continue
+		}
+
+		addresses := &protos.OrdererAddresses{}
+		if err := proto.Unmarshal(item.Value, addresses); err != nil {
+			return nil, fmt.Errorf("Invalid orderer addresses in configuration item %s: %s", item.Key, err)
+		}
+		return addresses.Addresses, nil
+	}
+	return nil, nil
+}
+
+// GetConfigurationEnvelope returns the configuration envelope a block holds,
+// or nil if it holds none. The genesis block holds the envelope as is, later
+// configuration blocks hold it in a configuration transaction.
+func GetConfigurationEnvelope(block *common.Block) *orderer.ConfigurationEnvelope {
+	if block.Header == nil || block.Data == nil || len(block.Data.Data) != 1 {
+		return nil
+	}
+
+	config := &orderer.ConfigurationEnvelope{}
+	if block.Header.Number == 0 {
+		if err := proto.Unmarshal(block.Data.Data[0], config); err != nil {
+			return nil
+		}
+		return config
+	}
+
+	env := &common.Envelope{}
+	if err := proto.Unmarshal(block.Data.Data[0], env); err != nil {
+		return nil
+	}
+	payload := &common.Payload{}
+	if err := proto.Unmarshal(env.Payload, payload); err != nil {
+		return nil
+	}
+	if payload.Header == nil || payload.Header.ChainHeader == nil || payload.Header.ChainHeader.Type != int32(common.HeaderType_CONFIGURATION_TRANSACTION) {
+		return nil
+	}
+	if err := proto.Unmarshal(payload.Data, config); err != nil {
+		return nil
+	}
+	return config
+}
@@ -0,0 +1,106 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/protos"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/orderer"
+)
+
+func TestAnchorPeers(t *testing.T) {
+	chainID := []byte("mychain")
+	anchors := &protos.AnchorPeers{AnchorPeers: []*protos.AnchorPeer{{Host: "peer0.org1", Port: 7051}}}
+
+	item, err := CreateAnchorPeersItem(chainID, "org1", anchors, 0, "policy")
+	if err != nil {
+		t.Fatalf("Could not create anchor peers item, err %s\n", err)
+	}
+	if _, err = CreateAnchorPeersItem(chainID, "", anchors, 0, "policy"); err == nil {
+		t.Fatalf("Expected anchor peers without organization to be rejected\n")
+	}
+
+	config := &orderer.ConfigurationEnvelope{ChainID: chainID, Items: []*orderer.SignedConfigurationItem{item}}
+	anchorPeers, err := GetAnchorPeers(config)
+	if err != nil {
+		t.Fatalf("Could not get anchor peers, err %s\n", err)
+	}
+	if len(anchorPeers) != 1 || len(anchorPeers["org1"].AnchorPeers) != 1 {
+		t.Fatalf("Expected the anchor peer of org1, got %v\n", anchorPeers)
+	}
+	if peer := anchorPeers["org1"].AnchorPeers[0]; peer.Host != "peer0.org1" || peer.Port != 7051 {
+		t.Fatalf("Unexpected anchor peer %v\n", peer)
+	}
+}
+
+func TestOrdererAddresses(t *testing.T) {
+	chainID := []byte("mychain")
+	config := &orderer.ConfigurationEnvelope{ChainID: chainID}
+	if addresses, err := GetOrdererAddresses(config); err != nil || addresses != nil {
+		t.Fatalf("Expected no orderer addresses, got %v, err %v\n", addresses, err)
+	}
+
+	item, err := CreateOrdererAddressesItem(chainID, []string{"orderer0:7050", "orderer1:7050"}, 0, "policy")
+	if err != nil {
+		t.Fatalf("Could not create orderer addresses item, err %s\n", err)
+	}
+	if _, err = CreateOrdererAddressesItem(chainID, nil, 0, "policy"); err == nil {
+		t.Fatalf("Expected an ordering service without address to be rejected\n")
+	}
+
+	config.Items = append(config.Items, item)
+	addresses, err := GetOrdererAddresses(config)
+	if err != nil {
+		t.Fatalf("Could not get orderer addresses, err %s\n", err)
+	}
+	if !reflect.DeepEqual(addresses, []string{"orderer0:7050", "orderer1:7050"}) {
+		t.Fatalf("Unexpected orderer addresses %v\n", addresses)
+	}
+}
+
+func TestGetConfigurationEnvelope(t *testing.T) {
+	config := &orderer.ConfigurationEnvelope{ChainID: []byte("mychain"), Sequence: 1}
+	data, _ := proto.Marshal(config)
+	genesis := &common.Block{Header: &common.BlockHeader{Number: 0}, Data: &common.BlockData{Data: [][]byte{data}}}
+	if c := GetConfigurationEnvelope(genesis); c == nil || string(c.ChainID) != "mychain" {
+		t.Fatalf("Expected the configuration of the genesis block, got %v\n", c)
+	}
+
+	payload, _ := proto.Marshal(&common.Payload{
+		Header: &common.Header{ChainHeader: &common.ChainHeader{Type: int32(common.HeaderType_CONFIGURATION_TRANSACTION)}},
+		Data:   data,
+	})
+	env, _ := proto.Marshal(&common.Envelope{Payload: payload})
+	block := &common.Block{Header: &common.BlockHeader{Number: 5}, Data: &common.BlockData{Data: [][]byte{env}}}
+	if c := GetConfigurationEnvelope(block); c == nil || c.Sequence != 1 {
+		t.Fatalf("Expected the configuration of the configuration block, got %v\n", c)
+	}
+
+	payload, _ = proto.Marshal(&common.Payload{
+		Header: &common.Header{ChainHeader: &common.ChainHeader{Type: int32(common.HeaderType_ENDORSER_TRANSACTION)}},
+		Data:   []byte("tx"),
+	})
+	env, _ = proto.Marshal(&common.Envelope{Payload: payload})
+	block.Data.Data = [][]byte{env}
+	if c := GetConfigurationEnvelope(block); c != nil {
+		t.Fatalf("Expected no configuration in an endorser transaction block, got %v\n", c)
+	}
+}
@@ -17,11 +17,20 @@ limitations under the License.
 package utils
 
 import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/binary"
+	"encoding/pem"
 	"errors"
 	"fmt"
+	"math/big"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric/core/crypto/primitives"
+	"github.com/hyperledger/fabric/core/util"
 	"github.com/hyperledger/fabric/protos"
 )
 
@@ -111,6 +120,18 @@ func GetProposalResponsePayload(prpBytes []byte) (*protos.ProposalResponsePayloa
 
 // CreateChaincodeProposal creates a proposal from given input
 func CreateChaincodeProposal(cis *protos.ChaincodeInvocationSpec, creator []byte) (*protos.Proposal, error) {
+	return CreateChaincodeProposalWithTransient(cis, creator, nil)
+}
+
+// CreateChaincodeProposalWithTransient creates a proposal from given input, carrying
+// transient data that is passed to the chaincode but never written to the ledger
+func CreateChaincodeProposalWithTransient(cis *protos.ChaincodeInvocationSpec, creator []byte, transientMap map[string][]byte) (*protos.Proposal, error) {
+	return CreateChaincodeProposalForChain("", cis, creator, transientMap)
+}
+
+// CreateChaincodeProposalForChain creates a proposal from given input targeting
+// the given chain, the default chain of the endorsers when empty
+func CreateChaincodeProposalForChain(chainID string, cis *protos.ChaincodeInvocationSpec, creator []byte, transientMap map[string][]byte) (*protos.Proposal, error) {
 	ccHdrExt := &protos.ChaincodeHeaderExtension{ChaincodeID: cis.ChaincodeSpec.ChaincodeID}
 	ccHdrExtBytes, err := proto.Marshal(ccHdrExt)
 	if err != nil {
@@ -122,7 +143,7 @@ func CreateChaincodeProposal(cis *protos.ChaincodeInvocationSpec, creator []byte
 		return nil, err
 	}
 
-	ccPropPayload := &protos.ChaincodeProposalPayload{Input: cisBytes}
+	ccPropPayload := &protos.ChaincodeProposalPayload{Input: cisBytes, TransientMap: transientMap}
 	ccPropPayloadBytes, err := proto.Marshal(ccPropPayload)
 	if err != nil {
 		return nil, err
@@ -134,10 +155,14 @@ func CreateChaincodeProposal(cis *protos.ChaincodeInvocationSpec, creator []byte
 		return nil, err
 	}
 
+	// the timestamp asserted by the client is the one seen by all the
+	// endorsers of the proposal
 	hdr := &protos.Header{Type: protos.Header_CHAINCODE,
+		Timestamp:  util.CreateUtcTimestamp(),
 		Extensions: ccHdrExtBytes,
 		Nonce:      nonce,
-		Creator:    creator}
+		Creator:    creator,
+		ChainID:    []byte(chainID)}
 	hdrBytes, err := proto.Marshal(hdr)
 	if err != nil {
 		return nil, err
@@ -146,8 +171,80 @@ func CreateChaincodeProposal(cis *protos.ChaincodeInvocationSpec, creator []byte
 	return &protos.Proposal{Header: hdrBytes, Payload: ccPropPayloadBytes}, nil
 }
 
-func GetBytesProposalResponsePayload(hash []byte, epoch []byte, result []byte, event []byte) ([]byte, error) {
-	cAct := &protos.ChaincodeAction{Events: event, Results: result}
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+// SignProposal returns the proposal signed with the ECDSA private key of its
+// creator, over the SHA-256 hash of the marshalled proposal
+func SignProposal(prop *protos.Proposal, key *ecdsa.PrivateKey) (*protos.SignedProposal, error) {
+	propBytes, err := proto.Marshal(prop)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(propBytes)
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		return nil, err
+	}
+	signature, err := asn1.Marshal(ecdsaSignature{r, s})
+	if err != nil {
+		return nil, err
+	}
+	return &protos.SignedProposal{ProposalBytes: propBytes, Signature: signature}, nil
+}
+
+// GetProposal returns the proposal of a signed proposal, whose signature is
+// left to check with VerifyProposalSignature
+func GetProposal(signedProp *protos.SignedProposal) (*protos.Proposal, error) {
+	if signedProp == nil {
+		return nil, errors.New("nil signed proposal")
+	}
+	prop := &protos.Proposal{}
+	if err := proto.Unmarshal(signedProp.ProposalBytes, prop); err != nil {
+		return nil, fmt.Errorf("invalid proposal bytes: %s", err)
+	}
+	return prop, nil
+}
+
+// VerifyProposalSignature checks that the proposal is signed by the owner of
+// the PEM encoded certificate creator
+func VerifyProposalSignature(signedProp *protos.SignedProposal, creator []byte) error {
+	pemBlock, _ := pem.Decode(creator)
+	if pemBlock == nil {
+		return errors.New("creator is not a PEM encoded certificate")
+	}
+	cert, err := x509.ParseCertificate(pemBlock.Bytes)
+	if err != nil {
+		return fmt.Errorf("invalid creator certificate: %s", err)
+	}
+	key, ok := cert.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return errors.New("creator certificate has no ECDSA public key")
+	}
+	signature := &ecdsaSignature{}
+	if _, err = asn1.Unmarshal(signedProp.Signature, signature); err != nil {
+		return fmt.Errorf("invalid proposal signature: %s", err)
+	}
+	digest := sha256.Sum256(signedProp.ProposalBytes)
+	if !ecdsa.Verify(key, digest[:], signature.R, signature.S) {
+		return errors.New("proposal is not signed by its creator")
+	}
+	return nil
+}
+
+// PrivateDataTransientKeyPrefix prefixes the keys of the transient map of a
+// proposal holding its private data, followed by the name of the collection
+const PrivateDataTransientKeyPrefix = "private/"
+
+// PrivateDataTransientKey returns the key of the transient map of a proposal
+// holding its private data for a collection
+func PrivateDataTransientKey(collection string) string {
+	return PrivateDataTransientKeyPrefix + collection
+}
+
+func GetBytesProposalResponsePayload(hash []byte, epoch []byte, response *protos.Response2, result []byte, event []byte, privateData *protos.PrivateDataHashes) ([]byte, error) {
+	cAct := &protos.ChaincodeAction{Events: event, Results: result, Response: response, PrivateData: privateData}
 	cActBytes, err := proto.Marshal(cAct)
 	if err != nil {
 		return nil, err
@@ -180,13 +277,13 @@ func GetBytesChaincodeEvent(event *protos.ChaincodeEvent) ([]byte, error) {
 	return eventBytes, nil
 }
 
-func GetBytesProposalResponse(prpBytes []byte, endorsement *protos.Endorsement) ([]byte, error) {
+func GetBytesProposalResponse(prpBytes []byte, response *protos.Response2, endorsement *protos.Endorsement) ([]byte, error) {
 	resp := &protos.ProposalResponse{
 		// Timestamp: TODO!
 		Version:     1, // TODO: pick right version number
 		Endorsement: endorsement,
 		Payload:     prpBytes,
-		Response:    &protos.Response2{Status: 200, Message: "OK"}}
+		Response:    response}
 	respBytes, err := proto.Marshal(resp)
 	if err != nil {
 		return nil, err
@@ -203,8 +300,8 @@ func GetProposalHash(header []byte, ccPropPayl []byte, visibility []byte) ([]byt
 		return nil, errors.New("Failure while unmarshalling the ChaincodeProposalPayload!")
 	}
 
-	// strip the transient bytes off the payload - this needs to be done no matter the visibility mode
-	cppNoTransient := &protos.ChaincodeProposalPayload{Input: cpp.Input, Transient: nil}
+	// strip the transient data off the payload - this needs to be done no matter the visibility mode
+	cppNoTransient := &protos.ChaincodeProposalPayload{Input: cpp.Input, TransientMap: nil}
 	cppBytes, err := GetBytesChaincodeProposalPayload(cppNoTransient)
 	if err != nil {
 		return nil, errors.New("Failure while marshalling the ChaincodeProposalPayload!")
@@ -216,10 +313,31 @@ func GetProposalHash(header []byte, ccPropPayl []byte, visibility []byte) ([]byt
 
 	// TODO: use bccsp interfaces and providers as soon as they are ready!
 	hash1 := primitives.GetDefaultHash()()
-	hash1.Write(cppBytes) // hash the serialized ChaincodeProposalPayload object (stripped of the transient bytes)
+	hash1.Write(cppBytes) // hash the serialized ChaincodeProposalPayload object (stripped of the transient data)
 	hash2 := primitives.GetDefaultHash()()
 	hash2.Write(header)         // hash the serialized Header object
 	hash2.Write(hash1.Sum(nil)) // hash the hash of the serialized ChaincodeProposalPayload object
 
 	return hash2.Sum(nil), nil
 }
+
+// ComputeProposalBinding computes the binding of the proposal, the SHA-256 hash
+// over the nonce, the creator and the epoch of its header. Application data
+// signed over the binding cannot be replayed in another transaction. A fixed
+// hash is used so that clients, endorsers and chaincodes compute the same value
+func ComputeProposalBinding(prop *protos.Proposal) ([]byte, error) {
+	hdr, err := GetHeader(prop)
+	if err != nil {
+		return nil, err
+	}
+
+	epoch := make([]byte, 8)
+	binary.LittleEndian.PutUint64(epoch, hdr.Epoch)
+
+	hash := sha256.New()
+	hash.Write(hdr.Nonce)
+	hash.Write(hdr.Creator)
+	hash.Write(epoch)
+
+	return hash.Sum(nil), nil
+}
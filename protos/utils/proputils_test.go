@@ -18,8 +18,11 @@ package utils
 
 import (
 	"bytes"
+	"crypto/ecdsa"
 	"testing"
 
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/core/crypto/primitives"
 	"github.com/hyperledger/fabric/protos"
 )
 
@@ -84,6 +87,136 @@ func TestProposal(t *testing.T) {
 	}
 }
 
+func TestSignedProposal(t *testing.T) {
+	primitives.InitSecurityLevel("SHA2", 256)
+	cert, key, err := primitives.NewSelfSignedCert()
+	if err != nil {
+		t.Fatalf("Could not create the creator certificate, err %s\n", err)
+	}
+	creator := primitives.DERCertToPEM(cert)
+	prop, err := CreateChaincodeProposal(createCIS(), creator)
+	if err != nil {
+		t.Fatalf("Could not create chaincode proposal, err %s\n", err)
+	}
+
+	signedProp, err := SignProposal(prop, key.(*ecdsa.PrivateKey))
+	if err != nil {
+		t.Fatalf("Could not sign the proposal, err %s\n", err)
+	}
+	if err = VerifyProposalSignature(signedProp, creator); err != nil {
+		t.Fatalf("Expected the signature of the creator to verify, err %s\n", err)
+	}
+	unsigned, err := GetProposal(signedProp)
+	if err != nil || !proto.Equal(prop, unsigned) {
+		t.Fatalf("Expected the proposal back from the signed proposal, err %v\n", err)
+	}
+
+	// another identity pasting the certificate of the creator
+	otherCert, otherKey, _ := primitives.NewSelfSignedCert()
+	forged, _ := SignProposal(prop, otherKey.(*ecdsa.PrivateKey))
+	if err = VerifyProposalSignature(forged, creator); err == nil {
+		t.Fatalf("Expected a proposal signed by another identity to be refused")
+	}
+	if err = VerifyProposalSignature(signedProp, primitives.DERCertToPEM(otherCert)); err == nil {
+		t.Fatalf("Expected the signature to be refused for another certificate")
+	}
+
+	// a tampered proposal
+	tampered := &protos.SignedProposal{ProposalBytes: append([]byte{}, signedProp.ProposalBytes...), Signature: signedProp.Signature}
+	tampered.ProposalBytes[len(tampered.ProposalBytes)-1] ^= 1
+	if err = VerifyProposalSignature(tampered, creator); err == nil {
+		t.Fatalf("Expected a tampered proposal to be refused")
+	}
+
+	// a creator which is no certificate
+	if err = VerifyProposalSignature(signedProp, []byte("cert")); err == nil {
+		t.Fatalf("Expected a creator which is no certificate to be refused")
+	}
+}
+
+func TestProposalWithTransient(t *testing.T) {
+	primitives.InitSecurityLevel("SHA2", 256)
+	transientMap := map[string][]byte{"key": []byte("secret")}
+	prop, err := CreateChaincodeProposalWithTransient(createCIS(), []byte("creator"), transientMap)
+	if err != nil {
+		t.Fatalf("Could not create chaincode proposal, err %s\n", err)
+	}
+
+	// the transient data is carried by the payload...
+	cpp := &protos.ChaincodeProposalPayload{}
+	if err = proto.Unmarshal(prop.Payload, cpp); err != nil {
+		t.Fatalf("Could not unmarshal the chaincode proposal payload, err %s\n", err)
+	}
+	if !bytes.Equal(cpp.TransientMap["key"], []byte("secret")) {
+		t.Fatalf("Invalid transient data, got %v\n", cpp.TransientMap)
+	}
+
+	// ...but is excluded from the proposal hash
+	hash, err := GetProposalHash(prop.Header, prop.Payload, nil)
+	if err != nil {
+		t.Fatalf("Could not compute the proposal hash, err %s\n", err)
+	}
+	cpp.TransientMap = nil
+	payloadNoTransient, err := GetBytesChaincodeProposalPayload(cpp)
+	if err != nil {
+		t.Fatalf("Could not marshal the chaincode proposal payload, err %s\n", err)
+	}
+	hashNoTransient, err := GetProposalHash(prop.Header, payloadNoTransient, nil)
+	if err != nil {
+		t.Fatalf("Could not compute the proposal hash, err %s\n", err)
+	}
+	if !bytes.Equal(hash, hashNoTransient) {
+		t.Fatalf("The proposal hash depends on the transient data\n")
+	}
+}
+
+func TestProposalForChain(t *testing.T) {
+	primitives.InitSecurityLevel("SHA2", 256)
+	prop, err := CreateChaincodeProposalForChain("mychain", createCIS(), []byte("creator"), nil)
+	if err != nil {
+		t.Fatalf("Could not create chaincode proposal, err %s\n", err)
+	}
+
+	hdr, err := GetHeader(prop)
+	if err != nil {
+		t.Fatalf("Could not extract the header from the proposal, err %s\n", err)
+	}
+	if string(hdr.ChainID) != "mychain" {
+		t.Fatalf("Invalid chain in the header, got %s\n", hdr.ChainID)
+	}
+}
+
+func TestProposalBinding(t *testing.T) {
+	prop, err := CreateChaincodeProposal(createCIS(), []byte("creator"))
+	if err != nil {
+		t.Fatalf("Could not create chaincode proposal, err %s\n", err)
+	}
+	binding, err := ComputeProposalBinding(prop)
+	if err != nil {
+		t.Fatalf("Could not compute the proposal binding, err %s\n", err)
+	}
+	if len(binding) != 32 {
+		t.Fatalf("Invalid binding length %d\n", len(binding))
+	}
+
+	// the binding of another proposal of the same creator differs by the nonce
+	other, err := CreateChaincodeProposal(createCIS(), []byte("creator"))
+	if err != nil {
+		t.Fatalf("Could not create chaincode proposal, err %s\n", err)
+	}
+	otherBinding, err := ComputeProposalBinding(other)
+	if err != nil {
+		t.Fatalf("Could not compute the proposal binding, err %s\n", err)
+	}
+	if bytes.Equal(binding, otherBinding) {
+		t.Fatalf("Two proposals have the same binding\n")
+	}
+
+	if _, err = ComputeProposalBinding(&protos.Proposal{Header: []byte("garbage")}); err == nil {
+		t.Fatalf("Expected an error for a malformed header\n")
+	}
+}
+
 func TestProposalResponse(t *testing.T) {
 	events := &protos.ChaincodeEvent{
 		ChaincodeID: "ccid",
@@ -101,7 +234,8 @@ func TestProposalResponse(t *testing.T) {
 	}
 
 	// get the bytes of the ProposalResponsePayload
-	prpBytes, err := GetBytesProposalResponsePayload(pHashBytes, epoch, results, eventBytes)
+	response := &protos.Response2{Status: 200, Message: "OK", Payload: []byte("payload")}
+	prpBytes, err := GetBytesProposalResponsePayload(pHashBytes, epoch, response, results, eventBytes, nil)
 	if err != nil {
 		t.Fatalf("Failure while marshalling the ProposalResponsePayload")
 		return
@@ -129,13 +263,13 @@ func TestProposalResponse(t *testing.T) {
 	}
 
 	// sanity check on the action
-	if string(act.Results) != "results" {
+	if string(act.Results) != "results" || string(act.Response.Payload) != "payload" {
 		t.Fatalf("Invalid actions after unmarshalling")
 		return
 	}
 
 	// create a proposal response
-	prBytes, err := GetBytesProposalResponse(prpBytes, &protos.Endorsement{Endorser: []byte("endorser"), Signature: []byte("signature")})
+	prBytes, err := GetBytesProposalResponse(prpBytes, response, &protos.Endorsement{Endorser: []byte("endorser"), Signature: []byte("signature")})
 	if err != nil {
 		t.Fatalf("Failure while marshalling the ProposalResponse")
 		return
@@ -17,9 +17,13 @@ limitations under the License.
 package utils
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 
 	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/core/crypto/primitives"
 	"github.com/hyperledger/fabric/protos"
 	"github.com/hyperledger/fabric/protos/common"
 )
@@ -65,12 +69,25 @@ func GetPayloads(txActions *protos.TransactionAction) (*protos.ChaincodeActionPa
 	}
 }
 
-// CreateTx creates a Transaction2 from given inputs
+// CreateTx creates a Transaction2 from given inputs. The header of its action carries a random nonce, so that
+// the transaction has an identifier of its own
 func CreateTx(typ protos.Header_Type, ccPropPayload []byte, ccEvents []byte, simulationResults []byte, endorsements []*protos.Endorsement) (*protos.Transaction2, error) {
 	if typ != protos.Header_CHAINCODE {
 		panic("-----Only CHAINCODE Type is supported-----")
 	}
 
+	nonce, err := primitives.GetRandomNonce()
+	if err != nil {
+		return nil, err
+	}
+	hdrBytes, err := proto.Marshal(&protos.Header{Type: typ, Nonce: nonce})
+	if err != nil {
+		return nil, err
+	}
+	return createTx(hdrBytes, ccPropPayload, ccEvents, simulationResults, endorsements)
+}
+
+func createTx(hdrBytes []byte, ccPropPayload []byte, ccEvents []byte, simulationResults []byte, endorsements []*protos.Endorsement) (*protos.Transaction2, error) {
 	ext := &protos.ChaincodeAction{Results: simulationResults, Events: ccEvents}
 	extBytes, err := proto.Marshal(ext)
 	if err != nil {
@@ -93,20 +110,16 @@ func CreateTx(typ protos.Header_Type, ccPropPayload []byte, ccEvents []byte, sim
 		return nil, err
 	}
 
-	hdr := &protos.Header{Type: typ}
-	hdrBytes, err := proto.Marshal(hdr)
-	if err != nil {
-		return nil, err
-	}
-
 	tx := &protos.Transaction2{}
 	tx.Actions = []*protos.TransactionAction{&protos.TransactionAction{Header: hdrBytes, Payload: actionBytes}}
 
 	return tx, nil
 }
 
-// CreateTxFromProposalResponse create's the Transaction from just one proposal response
-func CreateTxFromProposalResponse(pResp *protos.ProposalResponse) (*protos.Transaction2, error) {
+// CreateTxFromProposalResponse create's the Transaction from just one proposal response. The header of the
+// proposal, `hdr`, is the header of the action of the transaction, so that the transaction and the proposal
+// have the same identifier
+func CreateTxFromProposalResponse(hdr []byte, pResp *protos.ProposalResponse) (*protos.Transaction2, error) {
 	pRespPayload := &protos.ProposalResponsePayload{}
 	err := proto.Unmarshal(pResp.Payload, pRespPayload)
 	if err != nil {
@@ -117,7 +130,43 @@ func CreateTxFromProposalResponse(pResp *protos.ProposalResponse) (*protos.Trans
 	if err != nil {
 		return nil, err
 	}
-	return CreateTx(protos.Header_CHAINCODE, pRespPayload.ProposalHash, ccAction.Events, ccAction.Results, []*protos.Endorsement{pResp.Endorsement})
+	return createTx(hdr, pRespPayload.ProposalHash, ccAction.Events, ccAction.Results, []*protos.Endorsement{pResp.Endorsement})
+}
+
+// ComputeTxID returns the identifier of the transaction of a proposal, the hex encoded hash of the header of the
+// proposal. The nonce of the header makes it unique
+func ComputeTxID(hdr []byte) string {
+	hash := sha256.Sum256(hdr)
+	return hex.EncodeToString(hash[:])
+}
+
+// GetTxID returns the identifier of a Transaction2, the one of the proposal whose header is the header of its
+// first action
+func GetTxID(tx *protos.Transaction2) (string, error) {
+	if len(tx.Actions) == 0 {
+		return "", errors.New("Transaction has no action")
+	}
+	return ComputeTxID(tx.Actions[0].Header), nil
+}
+
+// GetChaincodeEvents gets the chaincode events set by the chaincodes invoked in the actions of a Transaction2
+func GetChaincodeEvents(tx *protos.Transaction2) ([]*protos.ChaincodeEvent, error) {
+	var ccEvents []*protos.ChaincodeEvent
+	for _, action := range tx.Actions {
+		_, respPayload, err := GetPayloads(action)
+		if err != nil {
+			return nil, err
+		}
+		if respPayload == nil || len(respPayload.Events) == 0 {
+			continue
+		}
+		ccEvent := &protos.ChaincodeEvent{}
+		if err = proto.Unmarshal(respPayload.Events, ccEvent); err != nil {
+			return nil, fmt.Errorf("Error getting chaincode event(%s)", err)
+		}
+		ccEvents = append(ccEvents, ccEvent)
+	}
+	return ccEvents, nil
 }
 
 // GetEndorserTxFromBlock gets Transaction2 from Block.Data.Data
@@ -0,0 +1,97 @@
+/*
+Copyright IBM Corp. 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/hyperledger/fabric/protos"
+)
+
+func TestGetChaincodeEvents(t *testing.T) {
+	// a transaction without events carries no chaincode events
+	tx, err := CreateTx(protos.Header_CHAINCODE, []byte("hash"), nil, []byte("results"), nil)
+	if err != nil {
+		t.Fatalf("Could not create transaction, err %s\n", err)
+	}
+	ccEvents, err := GetChaincodeEvents(tx)
+	if err != nil {
+		t.Fatalf("Could not get chaincode events, err %s\n", err)
+	}
+	if len(ccEvents) != 0 {
+		t.Fatalf("Expected no chaincode events, got %d\n", len(ccEvents))
+	}
+
+	// a transaction with an event carries it back
+	event := &protos.ChaincodeEvent{ChaincodeID: "chaincode_name", TxID: "txid", EventName: "event_name", Payload: []byte("payload")}
+	eventBytes, err := GetBytesChaincodeEvent(event)
+	if err != nil {
+		t.Fatalf("Could not marshal chaincode event, err %s\n", err)
+	}
+	tx, err = CreateTx(protos.Header_CHAINCODE, []byte("hash"), eventBytes, []byte("results"), nil)
+	if err != nil {
+		t.Fatalf("Could not create transaction, err %s\n", err)
+	}
+	ccEvents, err = GetChaincodeEvents(tx)
+	if err != nil {
+		t.Fatalf("Could not get chaincode events, err %s\n", err)
+	}
+	if len(ccEvents) != 1 {
+		t.Fatalf("Expected one chaincode event, got %d\n", len(ccEvents))
+	}
+	if ccEvents[0].ChaincodeID != event.ChaincodeID || ccEvents[0].TxID != event.TxID ||
+		ccEvents[0].EventName != event.EventName || !bytes.Equal(ccEvents[0].Payload, event.Payload) {
+		t.Fatalf("Unexpected chaincode event %v, expected %v\n", ccEvents[0], event)
+	}
+}
+
+func TestGetTxID(t *testing.T) {
+	prop, err := CreateChaincodeProposal(&protos.ChaincodeInvocationSpec{ChaincodeSpec: &protos.ChaincodeSpec{ChaincodeID: &protos.ChaincodeID{Name: "chaincode_name"}}}, []byte("creator"))
+	if err != nil {
+		t.Fatalf("Could not create proposal, err %s\n", err)
+	}
+	prpBytes, err := GetBytesProposalResponsePayload([]byte("hash"), nil, nil, []byte("results"), nil, nil)
+	if err != nil {
+		t.Fatalf("Could not create proposal response payload, err %s\n", err)
+	}
+	resp := &protos.ProposalResponse{Payload: prpBytes, Endorsement: &protos.Endorsement{}}
+	tx, err := CreateTxFromProposalResponse(prop.Header, resp)
+	if err != nil {
+		t.Fatalf("Could not create transaction, err %s\n", err)
+	}
+	txID, err := GetTxID(tx)
+	if err != nil {
+		t.Fatalf("Could not get the transaction id, err %s\n", err)
+	}
+	if txID != ComputeTxID(prop.Header) {
+		t.Fatalf("The transaction id %s is not the one of its proposal %s\n", txID, ComputeTxID(prop.Header))
+	}
+
+	// the transactions created without a proposal have ids of their own
+	tx1, _ := CreateTx(protos.Header_CHAINCODE, []byte("hash"), nil, []byte("results"), nil)
+	tx2, _ := CreateTx(protos.Header_CHAINCODE, []byte("hash"), nil, []byte("results"), nil)
+	txID1, _ := GetTxID(tx1)
+	txID2, _ := GetTxID(tx2)
+	if txID1 == txID2 {
+		t.Fatalf("Expected distinct transaction ids, got %s twice\n", txID1)
+	}
+
+	if _, err = GetTxID(&protos.Transaction2{}); err == nil {
+		t.Fatalf("Expected an error for a transaction without action\n")
+	}
+}